@@ -246,6 +246,10 @@ variable "provider" {
   description = "Internal: Provider name"
   type        = string
   default     = ""
+  validation {
+    condition     = contains(["", "aws", "azure", "gcp", "oracle", "zero"], var.provider)
+    error_message = "Provider must be empty or one of: aws, azure, gcp, oracle, zero"
+  }
 }
 
 variable "created_at" {
@@ -10,7 +10,7 @@ variable "instance_name" {
   type        = string
   validation {
     condition     = can(regex("^[a-z0-9]([a-z0-9-]*[a-z0-9])?$", var.instance_name))
-    error_message = "Instance name must start and end with alphanumeric, contain only lowercase letters, numbers, and hyphens"
+    error_message = "Instance name must start and end with alphanumeric, contain only lowercase letters, numbers, and hyphens, e.g. web-01"
   }
   validation {
     condition     = length(var.instance_name) >= 3 && length(var.instance_name) <= 63
@@ -47,7 +47,7 @@ variable "ssh_public_key" {
   sensitive   = true
   validation {
     condition     = var.ssh_public_key == null || can(regex("^ssh-(rsa|ed25519|ecdsa) ", var.ssh_public_key))
-    error_message = "SSH key must be in valid OpenSSH format (ssh-rsa, ssh-ed25519, or ssh-ecdsa)"
+    error_message = "ssh_public_key must be in valid OpenSSH format (ssh-rsa, ssh-ed25519, or ssh-ecdsa)"
   }
 }
 
@@ -57,7 +57,7 @@ variable "admin_username" {
   default     = "cloudadmin"
   validation {
     condition     = can(regex("^[a-z][a-z0-9_-]*$", var.admin_username))
-    error_message = "Admin username must start with a letter and contain only lowercase letters, numbers, underscores, and hyphens"
+    error_message = "Admin username must start with a letter and contain only lowercase letters, numbers, underscores, and hyphens, e.g. app-admin"
   }
 }
 
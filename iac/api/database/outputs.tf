@@ -93,23 +93,102 @@ output "metadata" {
 }
 
 # Variable definitions for outputs (these would be populated by the core layer)
-variable "database_id" { type = string }
-variable "database_arn" { type = string }
-variable "endpoint" { type = string }
-variable "address" { type = string }
-variable "port" { type = number }
-variable "database_name" { type = string }
-variable "engine" { type = string }
-variable "engine_version" { type = string }
-variable "status" { type = string }
-variable "availability_zone" { type = string }
-variable "multi_az" { type = bool }
-variable "storage_encrypted" { type = bool }
-variable "allocated_storage_gb" { type = number }
-variable "backup_retention_days" { type = number }
-variable "publicly_accessible" { type = bool }
-variable "connection_string" { type = string }
-variable "created_at" { type = string }
-variable "last_modified" { type = string }
-variable "instance_class" { type = string }
-variable "deletion_protection" { type = bool }
+variable "database_id" {
+  description = "Provider-specific database identifier"
+  type        = string
+}
+
+variable "database_arn" {
+  description = "Database ARN or fully qualified resource ID"
+  type        = string
+}
+
+variable "endpoint" {
+  description = "Connection endpoint (host:port)"
+  type        = string
+}
+
+variable "address" {
+  description = "Database host address"
+  type        = string
+}
+
+variable "port" {
+  description = "Database port"
+  type        = number
+}
+
+variable "database_name" {
+  description = "Name of the initial database"
+  type        = string
+}
+
+variable "engine" {
+  description = "Database engine"
+  type        = string
+}
+
+variable "engine_version" {
+  description = "Running engine version"
+  type        = string
+}
+
+variable "status" {
+  description = "Provisioning status reported by the provider"
+  type        = string
+}
+
+variable "availability_zone" {
+  description = "Availability zone of the primary instance"
+  type        = string
+}
+
+variable "multi_az" {
+  description = "Whether the instance is replicated across zones"
+  type        = bool
+}
+
+variable "storage_encrypted" {
+  description = "Whether storage is encrypted at rest"
+  type        = bool
+}
+
+variable "allocated_storage_gb" {
+  description = "Allocated storage in GB"
+  type        = number
+}
+
+variable "backup_retention_days" {
+  description = "Days automated backups are kept"
+  type        = number
+}
+
+variable "publicly_accessible" {
+  description = "Whether the instance is reachable from the internet"
+  type        = bool
+}
+
+variable "connection_string" {
+  description = "Connection string for clients"
+  type        = string
+}
+
+variable "created_at" {
+  description = "Creation timestamp"
+  type        = string
+}
+
+variable "last_modified" {
+  description = "Last modification timestamp"
+  type        = string
+}
+
+variable "instance_class" {
+  description = "Provider instance class"
+  type        = string
+}
+
+variable "deletion_protection" {
+  description = "Whether deletion protection is enabled"
+  type        = bool
+}
@@ -7,7 +7,7 @@ variable "database_identifier" {
   
   validation {
     condition     = can(regex("^[a-z0-9-]+$", var.database_identifier))
-    error_message = "Database identifier must contain only lowercase letters, numbers, and hyphens."
+    error_message = "Database identifier must contain only lowercase letters, numbers, and hyphens, e.g. orders-db."
   }
 }
 
@@ -44,7 +44,7 @@ variable "allocated_storage_gb" {
   
   validation {
     condition     = var.allocated_storage_gb >= 20 && var.allocated_storage_gb <= 65536
-    error_message = "Storage must be between 20 and 65536 GB."
+    error_message = "allocated_storage_gb must be between 20 and 65536 GB."
   }
 }
 
@@ -91,7 +91,7 @@ variable "backup_retention_days" {
   
   validation {
     condition     = var.backup_retention_days >= 0 && var.backup_retention_days <= 35
-    error_message = "Backup retention must be between 0 and 35 days."
+    error_message = "backup_retention_days must be between 0 and 35 days."
   }
 }
 
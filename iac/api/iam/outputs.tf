@@ -34,9 +34,32 @@ output "client_secret" {
 }
 
 # Variable definitions for contract enforcement
-variable "identity_id" { type = string }
-variable "identity_arn" { type = string }
-variable "identity_name" { type = string }
-variable "principal_id" { type = string }
-variable "client_id" { type = string }
-variable "client_secret" { type = string }
+variable "identity_id" {
+  description = "Provider-specific identity identifier"
+  type        = string
+}
+
+variable "identity_arn" {
+  description = "Identity ARN or fully qualified resource ID"
+  type        = string
+}
+
+variable "identity_name" {
+  description = "Identity name"
+  type        = string
+}
+
+variable "principal_id" {
+  description = "Principal ID used in role assignments"
+  type        = string
+}
+
+variable "client_id" {
+  description = "Client ID of the identity, where the provider issues one"
+  type        = string
+}
+
+variable "client_secret" {
+  description = "Client secret of the identity, where the provider issues one"
+  type        = string
+}
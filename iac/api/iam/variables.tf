@@ -7,7 +7,7 @@ variable "identity_name" {
   
   validation {
     condition     = can(regex("^[a-z0-9-]+$", var.identity_name))
-    error_message = "Identity name must contain only lowercase letters, numbers, and hyphens."
+    error_message = "Identity name must contain only lowercase letters, numbers, and hyphens, e.g. app-deployer."
   }
 }
 
@@ -38,6 +38,10 @@ variable "tags" {
 variable "environment" {
   description = "Deployment environment"
   type        = string
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
@@ -83,24 +83,107 @@ output "network_configuration" {
 }
 
 # Variable definitions for outputs (these would be populated by the core layer)
-variable "network_id" { type = string }
-variable "network_arn" { type = string }
-variable "network_name" { type = string }
-variable "network_cidr" { type = string }
-variable "internet_gateway_id" { type = string }
-variable "nat_gateway_ids" { type = list(string) }
-variable "public_subnet_ids" { type = list(string) }
-variable "private_subnet_ids" { type = list(string) }
-variable "public_route_table_ids" { type = list(string) }
-variable "private_route_table_ids" { type = list(string) }
-variable "default_security_group_id" { type = string }
-variable "availability_zones_used" { type = list(string) }
-variable "public_subnet_details" { type = list(map(string)) }
-variable "private_subnet_details" { type = list(map(string)) }
-variable "dns_enabled" { type = bool }
-variable "has_internet_gateway" { type = bool }
-variable "nat_gateway_count_actual" { type = number }
-variable "flow_logs_enabled" { type = bool }
-variable "total_subnets" { type = number }
-variable "public_subnet_count" { type = number }
-variable "private_subnet_count" { type = number }
+variable "network_id" {
+  description = "Provider-specific network identifier"
+  type        = string
+}
+
+variable "network_arn" {
+  description = "Network ARN or fully qualified resource ID"
+  type        = string
+}
+
+variable "network_name" {
+  description = "Network name"
+  type        = string
+}
+
+variable "network_cidr" {
+  description = "Network CIDR block"
+  type        = string
+}
+
+variable "internet_gateway_id" {
+  description = "Internet gateway ID, if one was created"
+  type        = string
+}
+
+variable "nat_gateway_ids" {
+  description = "NAT gateway IDs"
+  type        = list(string)
+}
+
+variable "public_subnet_ids" {
+  description = "Public subnet IDs"
+  type        = list(string)
+}
+
+variable "private_subnet_ids" {
+  description = "Private subnet IDs"
+  type        = list(string)
+}
+
+variable "public_route_table_ids" {
+  description = "Public route table IDs"
+  type        = list(string)
+}
+
+variable "private_route_table_ids" {
+  description = "Private route table IDs"
+  type        = list(string)
+}
+
+variable "default_security_group_id" {
+  description = "Default security group ID"
+  type        = string
+}
+
+variable "availability_zones_used" {
+  description = "Availability zones the subnets span"
+  type        = list(string)
+}
+
+variable "public_subnet_details" {
+  description = "Public subnet attributes (id, cidr, zone)"
+  type        = list(map(string))
+}
+
+variable "private_subnet_details" {
+  description = "Private subnet attributes (id, cidr, zone)"
+  type        = list(map(string))
+}
+
+variable "dns_enabled" {
+  description = "Whether DNS support is enabled"
+  type        = bool
+}
+
+variable "has_internet_gateway" {
+  description = "Whether an internet gateway was created"
+  type        = bool
+}
+
+variable "nat_gateway_count_actual" {
+  description = "Number of NAT gateways created"
+  type        = number
+}
+
+variable "flow_logs_enabled" {
+  description = "Whether flow logs are enabled"
+  type        = bool
+}
+
+variable "total_subnets" {
+  description = "Total number of subnets"
+  type        = number
+}
+
+variable "public_subnet_count" {
+  description = "Number of public subnets"
+  type        = number
+}
+
+variable "private_subnet_count" {
+  description = "Number of private subnets"
+  type        = number
+}
@@ -7,7 +7,7 @@ variable "network_name" {
   
   validation {
     condition     = can(regex("^[a-z0-9-]+$", var.network_name))
-    error_message = "Network name must contain only lowercase letters, numbers, and hyphens."
+    error_message = "Network name must contain only lowercase letters, numbers, and hyphens, e.g. main-vpc."
   }
 }
 
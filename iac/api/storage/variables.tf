@@ -10,7 +10,7 @@ variable "bucket_name" {
   type        = string
   validation {
     condition     = can(regex("^[a-z0-9][a-z0-9-]*[a-z0-9]$", var.bucket_name))
-    error_message = "Bucket name must start and end with alphanumeric, contain only lowercase letters, numbers, and hyphens"
+    error_message = "Bucket name must start and end with alphanumeric, contain only lowercase letters, numbers, and hyphens, e.g. my-app-assets"
   }
   validation {
     condition     = length(var.bucket_name) >= 3 && length(var.bucket_name) <= 63
@@ -0,0 +1,95 @@
+//go:build audit
+
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/driftaudit"
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditDrift plans each root module in SWE_AUDIT_MODULES against the
+// state backend in SWE_AUDIT_STATE_BACKEND, read-only (see tfopts.Audit),
+// and fails for every module that drifted, could not be planned, or
+// declares no backend to read state from. The drift report goes to the
+// test log, and to drift.md in the test's artifact directory when
+// SWE_TEST_ARTIFACT_DIR is set. Run it with the audit tag and the
+// credentials of an account allowed to read the state and the resources:
+//
+//	SWE_AUDIT_STATE_BACKEND=$PWD/backend.hcl SWE_AUDIT_MODULES=live/network \
+//	  go test -tags audit -run TestAuditDrift .
+func TestAuditDrift(t *testing.T) {
+	backendConfig, err := tfopts.AuditStateBackendFromEnv()
+	require.NoError(t, err)
+	if backendConfig == "" {
+		t.Skipf("TestAuditDrift plans against real state; set %s to its backend configuration file to run it", tfopts.EnvAuditStateBackend)
+	}
+	modules, err := driftaudit.ModulesFromEnv()
+	require.NoError(t, err)
+	if len(modules) == 0 {
+		t.Skipf("No root modules to audit; list them in %s", driftaudit.EnvModules)
+	}
+
+	var results driftaudit.Results
+
+	t.Run("plan", func(t *testing.T) {
+		for _, module := range modules {
+			module := module
+
+			t.Run(module, func(t *testing.T) {
+				t.Parallel()
+
+				backend, err := driftaudit.DeclaredBackend(module)
+				if err != nil {
+					results.Add(driftaudit.Result{Module: module, Status: driftaudit.Failed, Err: err})
+					t.Fatal(err)
+				}
+				if backend == "" {
+					results.Add(driftaudit.Result{Module: module, Status: driftaudit.NoBackend})
+					t.Fatalf("%s declares no backend block, so terraform would plan it against an empty local state", module)
+				}
+
+				// The copy keeps the module's .terraform directory out of
+				// the tree
+				options := tflog.WithSensitive(t, tfopts.New(t, &terraform.Options{
+					TerraformDir: test_structure.CopyTerraformFolderToTemp(t, ".", module),
+					PlanFilePath: filepath.Join(t.TempDir(), "audit.plan"),
+					NoColor:      true,
+				}))
+
+				planJSON, err := terraform.InitAndPlanAndShowE(t, options)
+				if err != nil {
+					results.Add(driftaudit.Result{Module: module, Status: driftaudit.Failed, Err: err})
+					t.Fatalf("Planning %s against its %s state: %v", module, backend, err)
+				}
+
+				result := driftaudit.NewResult(module, []byte(planJSON))
+				results.Add(result)
+				switch result.Status {
+				case driftaudit.Failed:
+					t.Fatal(result.Err)
+				case driftaudit.Drifted:
+					t.Errorf("%s drifted from its state on %d resources", module, len(result.Drift))
+				}
+			})
+		}
+	})
+
+	var report strings.Builder
+	require.NoError(t, driftaudit.WriteReport(&report, results.All()))
+	t.Logf("Drift audit:\n%s", report.String())
+	if dir := tflog.LoadOptions().ArtifactDir; dir != "" {
+		path := filepath.Join(dir, tflog.ArtifactName(t.Name()), driftaudit.ReportFile)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(report.String()), 0o644))
+	}
+}
@@ -0,0 +1,83 @@
+# AWS Backup Core Module
+
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+resource "aws_backup_vault" "this" {
+  name        = var.vault_name
+  kms_key_arn = var.kms_key_arn
+
+  tags = var.tags
+}
+
+resource "aws_backup_plan" "this" {
+  name = var.plan_name
+
+  rule {
+    rule_name         = "${var.plan_name}-rule"
+    target_vault_name = aws_backup_vault.this.name
+    schedule          = var.schedule_expression
+
+    lifecycle {
+      delete_after = var.retention_days
+    }
+  }
+
+  tags = var.tags
+}
+
+# Role AWS Backup assumes to snapshot and restore the selected resources;
+# S3 needs its own pair of managed policies on top of the service ones
+resource "aws_iam_role" "backup" {
+  name = "${var.plan_name}-backup"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [{
+      Effect    = "Allow"
+      Principal = { Service = "backup.amazonaws.com" }
+      Action    = "sts:AssumeRole"
+    }]
+  })
+
+  tags = var.tags
+}
+
+resource "aws_iam_role_policy_attachment" "backup" {
+  for_each = toset([
+    "AWSBackupServiceRolePolicyForBackup",
+    "AWSBackupServiceRolePolicyForRestores",
+    "AWSBackupServiceRolePolicyForS3Backup",
+    "AWSBackupServiceRolePolicyForS3Restore",
+  ])
+
+  role       = aws_iam_role.backup.name
+  policy_arn = "arn:aws:iam::aws:policy/service-role/${each.key}"
+}
+
+resource "aws_backup_selection" "this" {
+  count = length(var.resource_arns) > 0 ? 1 : 0
+
+  name         = "${var.plan_name}-selection"
+  plan_id      = aws_backup_plan.this.id
+  iam_role_arn = aws_iam_role.backup.arn
+  resources    = var.resource_arns
+}
+
+output "vault_arn" {
+  value = aws_backup_vault.this.arn
+}
+
+output "plan_id" {
+  value = aws_backup_plan.this.id
+}
+
+output "role_arn" {
+  value = aws_iam_role.backup.arn
+}
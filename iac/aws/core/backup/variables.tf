@@ -0,0 +1,37 @@
+variable "vault_name" {
+  description = "Name of the backup vault"
+  type        = string
+}
+
+variable "plan_name" {
+  description = "Name of the backup plan; also prefixes the rule, selection and IAM role"
+  type        = string
+}
+
+variable "schedule_expression" {
+  description = "AWS cron expression for the backup rule, e.g. cron(0 3 * * ? *)"
+  type        = string
+}
+
+variable "retention_days" {
+  description = "Days a recovery point is kept before it is deleted"
+  type        = number
+}
+
+variable "resource_arns" {
+  description = "ARNs of the resources to back up (RDS instances, DynamoDB tables, S3 buckets)"
+  type        = list(string)
+  default     = []
+}
+
+variable "kms_key_arn" {
+  description = "KMS key encrypting the vault; null uses the AWS Backup managed key"
+  type        = string
+  default     = null
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+  default     = {}
+}
@@ -0,0 +1,50 @@
+# AWS Budgets Core Module
+
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+resource "aws_budgets_budget" "this" {
+  name         = var.name
+  budget_type  = "COST"
+  limit_amount = var.limit_amount
+  limit_unit   = "USD"
+  time_unit    = "MONTHLY"
+
+  # Only costs carrying the Project tag count against the budget; the tag
+  # must be activated as a cost allocation tag
+  dynamic "cost_filter" {
+    for_each = var.project_tag != null ? [var.project_tag] : []
+    content {
+      name   = "TagKeyValue"
+      values = ["user:Project$${cost_filter.value}"]
+    }
+  }
+
+  dynamic "notification" {
+    for_each = var.threshold_percentages
+    content {
+      comparison_operator        = "GREATER_THAN"
+      threshold                  = notification.value
+      threshold_type             = "PERCENTAGE"
+      notification_type          = "ACTUAL"
+      subscriber_email_addresses = var.email_addresses
+      subscriber_sns_topic_arns  = var.sns_topic_arn != null ? [var.sns_topic_arn] : []
+    }
+  }
+
+  tags = var.tags
+}
+
+output "budget_id" {
+  value = aws_budgets_budget.this.id
+}
+
+output "budget_arn" {
+  value = aws_budgets_budget.this.arn
+}
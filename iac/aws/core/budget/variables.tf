@@ -0,0 +1,38 @@
+variable "name" {
+  description = "Budget name"
+  type        = string
+}
+
+variable "limit_amount" {
+  description = "Monthly limit in USD, as the decimal string the Budgets API takes"
+  type        = string
+}
+
+variable "project_tag" {
+  description = "Project tag value the budget is filtered to (null for the whole account)"
+  type        = string
+  default     = null
+}
+
+variable "threshold_percentages" {
+  description = "Percentages of the limit that send a notification"
+  type        = list(number)
+}
+
+variable "email_addresses" {
+  description = "Email subscribers"
+  type        = list(string)
+  default     = []
+}
+
+variable "sns_topic_arn" {
+  description = "SNS topic subscribed to the notifications"
+  type        = string
+  default     = null
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+  default     = {}
+}
@@ -0,0 +1,108 @@
+# AWS CloudFront Core Module
+
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+# CloudFront signs its requests to the bucket, which stays private
+resource "aws_cloudfront_origin_access_control" "this" {
+  name                              = var.name
+  description                       = "Origin access for ${var.bucket_name}"
+  origin_access_control_origin_type = "s3"
+  signing_behavior                  = "always"
+  signing_protocol                  = "sigv4"
+}
+
+resource "aws_cloudfront_distribution" "this" {
+  enabled             = true
+  comment             = var.name
+  aliases             = var.aliases
+  default_root_object = var.index_document
+  price_class         = var.price_class
+
+  origin {
+    origin_id                = var.bucket_name
+    domain_name              = var.bucket_regional_domain_name
+    origin_access_control_id = aws_cloudfront_origin_access_control.this.id
+  }
+
+  default_cache_behavior {
+    target_origin_id       = var.bucket_name
+    allowed_methods        = ["GET", "HEAD"]
+    cached_methods         = ["GET", "HEAD"]
+    viewer_protocol_policy = "redirect-to-https"
+    compress               = true
+
+    min_ttl     = 0
+    default_ttl = var.default_ttl
+    max_ttl     = max(var.default_ttl, 31536000)
+
+    forwarded_values {
+      query_string = false
+      cookies {
+        forward = "none"
+      }
+    }
+  }
+
+  # A private bucket answers 403 for missing keys
+  dynamic "custom_error_response" {
+    for_each = var.error_document != null ? [403, 404] : []
+    content {
+      error_code         = custom_error_response.value
+      response_code      = 404
+      response_page_path = "/${var.error_document}"
+    }
+  }
+
+  restrictions {
+    geo_restriction {
+      restriction_type = "none"
+    }
+  }
+
+  viewer_certificate {
+    cloudfront_default_certificate = var.acm_certificate_arn == null
+    acm_certificate_arn            = var.acm_certificate_arn
+    ssl_support_method             = var.acm_certificate_arn != null ? "sni-only" : null
+    minimum_protocol_version       = var.acm_certificate_arn != null ? "TLSv1.2_2021" : "TLSv1"
+  }
+
+  tags = var.tags
+}
+
+# Replaces the bucket policy: the bucket must not carry one of its own
+resource "aws_s3_bucket_policy" "cdn" {
+  bucket = var.bucket_name
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [{
+      Sid       = "AllowCloudFrontRead"
+      Effect    = "Allow"
+      Principal = { Service = "cloudfront.amazonaws.com" }
+      Action    = "s3:GetObject"
+      Resource  = "${var.bucket_arn}/*"
+      Condition = {
+        StringEquals = { "AWS:SourceArn" = aws_cloudfront_distribution.this.arn }
+      }
+    }]
+  })
+}
+
+output "distribution_id" {
+  value = aws_cloudfront_distribution.this.id
+}
+
+output "distribution_arn" {
+  value = aws_cloudfront_distribution.this.arn
+}
+
+output "domain_name" {
+  value = aws_cloudfront_distribution.this.domain_name
+}
@@ -0,0 +1,61 @@
+variable "name" {
+  description = "Name of the distribution (comment) and its origin access control"
+  type        = string
+}
+
+variable "bucket_name" {
+  description = "Origin S3 bucket name"
+  type        = string
+}
+
+variable "bucket_arn" {
+  description = "Origin S3 bucket ARN, for the bucket policy"
+  type        = string
+}
+
+variable "bucket_regional_domain_name" {
+  description = "Regional domain name of the origin bucket"
+  type        = string
+}
+
+variable "index_document" {
+  description = "Object returned for requests to the root"
+  type        = string
+  default     = "index.html"
+}
+
+variable "error_document" {
+  description = "Object returned for missing keys (optional)"
+  type        = string
+  default     = null
+}
+
+variable "aliases" {
+  description = "Alternate domain names"
+  type        = list(string)
+  default     = []
+}
+
+variable "acm_certificate_arn" {
+  description = "ACM certificate in us-east-1 covering the aliases; null uses the cloudfront.net certificate"
+  type        = string
+  default     = null
+}
+
+variable "default_ttl" {
+  description = "Seconds objects stay cached when the origin sends no Cache-Control"
+  type        = number
+  default     = 3600
+}
+
+variable "price_class" {
+  description = "CloudFront price class"
+  type        = string
+  default     = "PriceClass_100"
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+  default     = {}
+}
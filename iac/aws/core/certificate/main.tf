@@ -0,0 +1,63 @@
+# AWS Certificate Manager Core Module (DNS validation in Route 53)
+
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+resource "aws_acm_certificate" "this" {
+  domain_name               = var.domain_name
+  subject_alternative_names = var.subject_alternative_names
+  validation_method         = "DNS"
+
+  tags = var.tags
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+
+locals {
+  # A wildcard and its apex share one validation record, so records are
+  # keyed by the name without its wildcard label; the keys are known at plan
+  validation_domains = distinct([for d in concat([var.domain_name], var.subject_alternative_names) : trimprefix(d, "*.")])
+  validation_options = {
+    for d in local.validation_domains : d => [
+      for dvo in aws_acm_certificate.this.domain_validation_options : dvo if trimprefix(dvo.domain_name, "*.") == d
+    ][0]
+  }
+}
+
+resource "aws_route53_record" "validation" {
+  for_each = toset(local.validation_domains)
+
+  zone_id         = var.zone_id
+  name            = local.validation_options[each.key].resource_record_name
+  type            = local.validation_options[each.key].resource_record_type
+  records         = [local.validation_options[each.key].resource_record_value]
+  ttl             = 300
+  allow_overwrite = true
+}
+
+# Waits until ACM has seen the records and issued the certificate
+resource "aws_acm_certificate_validation" "this" {
+  certificate_arn         = aws_acm_certificate.this.arn
+  validation_record_fqdns = [for record in aws_route53_record.validation : record.fqdn]
+}
+
+output "certificate_arn" {
+  value = aws_acm_certificate_validation.this.certificate_arn
+}
+
+output "status" {
+  value = aws_acm_certificate.this.status
+}
+
+output "validation_record_names" {
+  description = "Validation record name by domain (without its wildcard label)"
+  value       = { for d, record in aws_route53_record.validation : d => record.name }
+}
@@ -0,0 +1,21 @@
+variable "domain_name" {
+  description = "Primary domain name of the certificate"
+  type        = string
+}
+
+variable "subject_alternative_names" {
+  description = "Additional domain names covered by the certificate"
+  type        = list(string)
+  default     = []
+}
+
+variable "zone_id" {
+  description = "Route 53 hosted zone that receives the validation records"
+  type        = string
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+  default     = {}
+}
@@ -23,10 +23,109 @@ resource "aws_instance" "this" {
   
   monitoring    = var.enable_monitoring
   ebs_optimized = var.ebs_optimized
+
+  # IMDSv2 sessions, whose responses go no further than the instance
+  metadata_options {
+    http_endpoint               = "enabled"
+    http_tokens                 = var.metadata_http_tokens
+    http_put_response_hop_limit = var.metadata_hop_limit
+  }
   
   tags = var.tags
 }
 
+# ============================================================================
+# DATA VOLUMES
+# ============================================================================
+
+locals {
+  data_volumes = { for v in var.data_volumes : v.mount_name => v }
+
+  # Volumes are attached in list order from /dev/sdf, the first device AWS
+  # recommends for EBS volumes
+  device_names = { for i, v in var.data_volumes : v.mount_name => "/dev/sd${substr("fghijklmnop", i, 1)}" }
+
+  snapshotted = { for k, v in local.data_volumes : k => v if v.snapshot_cron != null }
+}
+
+resource "aws_ebs_volume" "data" {
+  for_each = local.data_volumes
+
+  availability_zone = aws_instance.this.availability_zone
+  size              = each.value.size_gb
+  type              = each.value.type
+  iops              = each.value.iops
+  encrypted         = each.value.encrypted
+
+  # SnapshotPolicy is the tag the volume's DLM policy targets
+  tags = merge(
+    var.tags,
+    { Name = each.value.name },
+    each.value.snapshot_cron != null ? { SnapshotPolicy = each.value.name } : {}
+  )
+}
+
+resource "aws_volume_attachment" "data" {
+  for_each = local.data_volumes
+
+  device_name = local.device_names[each.key]
+  volume_id   = aws_ebs_volume.data[each.key].id
+  instance_id = aws_instance.this.id
+}
+
+# Data Lifecycle Manager creates and expires the snapshots under this role
+resource "aws_iam_role" "dlm" {
+  count = length(local.snapshotted) > 0 ? 1 : 0
+
+  name_prefix = "dlm-snapshots-"
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [{
+      Effect    = "Allow"
+      Principal = { Service = "dlm.amazonaws.com" }
+      Action    = "sts:AssumeRole"
+    }]
+  })
+
+  tags = var.tags
+}
+
+resource "aws_iam_role_policy_attachment" "dlm" {
+  count = length(local.snapshotted) > 0 ? 1 : 0
+
+  role       = aws_iam_role.dlm[0].name
+  policy_arn = "arn:aws:iam::aws:policy/service-role/AWSDataLifecycleManagerServiceRole"
+}
+
+resource "aws_dlm_lifecycle_policy" "data" {
+  for_each = local.snapshotted
+
+  description        = "Snapshots of ${each.value.name}"
+  execution_role_arn = aws_iam_role.dlm[0].arn
+  state              = "ENABLED"
+
+  policy_details {
+    resource_types = ["VOLUME"]
+    target_tags    = { SnapshotPolicy = each.value.name }
+
+    schedule {
+      name      = "${each.key} snapshots"
+      copy_tags = true
+
+      create_rule {
+        cron_expression = each.value.snapshot_cron
+      }
+
+      retain_rule {
+        interval      = each.value.retention_days
+        interval_unit = "DAYS"
+      }
+    }
+  }
+
+  tags = var.tags
+}
+
 output "instance_id" {
   value = aws_instance.this.id
 }
@@ -50,3 +149,8 @@ output "instance_state" {
 output "availability_zone" {
   value = aws_instance.this.availability_zone
 }
+
+output "data_volume_ids" {
+  description = "EBS volume ID of each data volume, by mount name"
+  value       = { for name, volume in aws_ebs_volume.data : name => volume.id }
+}
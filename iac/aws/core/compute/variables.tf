@@ -50,8 +50,39 @@ variable "ebs_optimized" {
   default     = false
 }
 
+variable "metadata_http_tokens" {
+  description = "Instance metadata session tokens: required allows IMDSv2 only, optional IMDSv1 as well"
+  type        = string
+  default     = "required"
+  validation {
+    condition     = contains(["required", "optional"], var.metadata_http_tokens)
+    error_message = "metadata_http_tokens must be one of: required, optional"
+  }
+}
+
+variable "metadata_hop_limit" {
+  description = "Network hops an instance metadata response may take; 1 keeps it from containers on a bridge network"
+  type        = number
+  default     = 1
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
   default     = {}
 }
+
+variable "data_volumes" {
+  description = "EBS volumes to attach, in order from /dev/sdf, keyed by mount_name. A volume with a snapshot_cron, a DLM cron() expression, is snapshotted on it and each snapshot kept retention_days."
+  type = list(object({
+    mount_name     = string
+    name           = string
+    size_gb        = number
+    type           = string
+    iops           = optional(number)
+    encrypted      = bool
+    snapshot_cron  = optional(string)
+    retention_days = optional(number)
+  }))
+  default = []
+}
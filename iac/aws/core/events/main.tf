@@ -1,10 +1,88 @@
 # AWS EventBridge Core Module
 
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
 resource "aws_cloudwatch_event_bus" "this" {
   name = var.name
   tags = var.tags
 }
 
+locals {
+  rules        = { for r in var.rules : r.name => r }
+  lambda_rules = { for name, r in local.rules : name => r if r.target_type == "lambda" }
+  queue_rules  = { for name, r in local.rules : name => r if r.target_type == "queue" }
+}
+
+# Rules and Targets
+# One target per rule; the target ID is the rule name
+resource "aws_cloudwatch_event_rule" "this" {
+  for_each = local.rules
+
+  name           = each.key
+  event_bus_name = aws_cloudwatch_event_bus.this.name
+  event_pattern  = each.value.pattern
+
+  tags = var.tags
+}
+
+resource "aws_cloudwatch_event_target" "this" {
+  for_each = local.rules
+
+  rule           = aws_cloudwatch_event_rule.this[each.key].name
+  event_bus_name = aws_cloudwatch_event_bus.this.name
+  target_id      = each.key
+  arn            = each.value.target_arn
+}
+
+# Lambda targets: let EventBridge invoke the function for this rule only
+resource "aws_lambda_permission" "events" {
+  for_each = local.lambda_rules
+
+  statement_id  = "AllowEventBridge-${each.key}"
+  action        = "lambda:InvokeFunction"
+  function_name = each.value.target_arn
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.this[each.key].arn
+}
+
+# SQS targets: the queue policy is replaced, so a queue can only be the
+# target of one rule and must not carry a policy of its own, such as the
+# access policy the messaging facade writes for grants
+resource "aws_sqs_queue_policy" "events" {
+  for_each = local.queue_rules
+
+  # arn:aws:sqs:<region>:<account>:<name>
+  queue_url = format("https://sqs.%s.amazonaws.com/%s/%s", split(":", each.value.target_arn)[3], split(":", each.value.target_arn)[4], split(":", each.value.target_arn)[5])
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [{
+      Sid       = "AllowEventBridge-${each.key}"
+      Effect    = "Allow"
+      Principal = { Service = "events.amazonaws.com" }
+      Action    = "sqs:SendMessage"
+      Resource  = each.value.target_arn
+      Condition = {
+        ArnEquals = { "aws:SourceArn" = aws_cloudwatch_event_rule.this[each.key].arn }
+      }
+    }]
+  })
+
+  lifecycle {
+    precondition {
+      condition     = length([for r in local.queue_rules : r if r.target_arn == each.value.target_arn]) == 1
+      error_message = "Queue ${each.value.target_arn} is the target of more than one rule, and each rule would overwrite the other's queue policy"
+    }
+  }
+}
+
 output "event_resource_id" {
   value = aws_cloudwatch_event_bus.this.name
 }
@@ -12,3 +90,8 @@ output "event_resource_id" {
 output "event_resource_arn" {
   value = aws_cloudwatch_event_bus.this.arn
 }
+
+output "rule_arns" {
+  description = "ARN of each rule, by rule name"
+  value       = { for name, rule in aws_cloudwatch_event_rule.this : name => rule.arn }
+}
@@ -9,6 +9,17 @@ variable "description" {
   default     = null
 }
 
+variable "rules" {
+  description = "Rules routing events on the bus to one target each; target_type lambda or queue adds the permission the target needs, other targets get none"
+  type = list(object({
+    name        = string
+    pattern     = string # EventBridge event pattern JSON
+    target_arn  = string
+    target_type = string # lambda, queue or other
+  }))
+  default = []
+}
+
 variable "tags" {
   description = "Tags"
   type        = map(string)
@@ -10,6 +10,40 @@ terraform {
   }
 }
 
+# Trust Policy Statements
+# Services and cross-account principals get separate statements so the
+# external ID condition only applies to the AWS principal block.
+locals {
+  service_trust_statements = length(var.trusted_services) > 0 ? [
+    {
+      Action = "sts:AssumeRole"
+      Effect = "Allow"
+      Principal = {
+        Service = var.trusted_services
+      }
+    }
+  ] : []
+
+  account_trust_statements = length(var.trusted_principal_arns) > 0 ? [
+    merge(
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          AWS = var.trusted_principal_arns
+        }
+      },
+      var.external_id != null ? {
+        Condition = {
+          StringEquals = {
+            "sts:ExternalId" = var.external_id
+          }
+        }
+      } : {}
+    )
+  ] : []
+}
+
 # IAM Role
 resource "aws_iam_role" "this" {
   count = var.create_role ? 1 : 0
@@ -18,16 +52,8 @@ resource "aws_iam_role" "this" {
   description = var.role_description
   
   assume_role_policy = var.assume_role_policy != null ? var.assume_role_policy : jsonencode({
-    Version = "2012-10-17"
-    Statement = [
-      {
-        Action = "sts:AssumeRole"
-        Effect = "Allow"
-        Principal = {
-          Service = var.trusted_services
-        }
-      }
-    ]
+    Version   = "2012-10-17"
+    Statement = concat(local.service_trust_statements, local.account_trust_statements)
   })
   
   max_session_duration = var.max_session_duration
@@ -29,6 +29,18 @@ variable "trusted_services" {
   default     = ["ec2.amazonaws.com"]
 }
 
+variable "trusted_principal_arns" {
+  description = "AWS principal ARNs (accounts, roles, users) that can assume this role"
+  type        = list(string)
+  default     = []
+}
+
+variable "external_id" {
+  description = "External ID that cross-account principals must present (sts:ExternalId)"
+  type        = string
+  default     = null
+}
+
 variable "max_session_duration" {
   description = "Maximum session duration in seconds"
   type        = number
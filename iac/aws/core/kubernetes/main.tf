@@ -7,12 +7,39 @@ terraform {
   }
 }
 
-variable "cluster_name" { type = string }
-variable "node_count" { type = number }
-variable "instance_size" { type = string }
-variable "vpc_id" { type = string }
-variable "subnet_ids" { type = list(string) }
-variable "tags" { type = map(string) }
+variable "cluster_name" {
+  description = "EKS cluster name"
+  type        = string
+}
+
+variable "node_count" {
+  description = "Desired number of worker nodes"
+  type        = number
+}
+
+variable "instance_size" {
+  description = "Node size (small, medium, large)"
+  type        = string
+  validation {
+    condition     = contains(["small", "medium", "large"], var.instance_size)
+    error_message = "Instance size must be one of: small, medium, large"
+  }
+}
+
+variable "vpc_id" {
+  description = "VPC the cluster runs in"
+  type        = string
+}
+
+variable "subnet_ids" {
+  description = "Subnets for the control plane and node group"
+  type        = list(string)
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+}
 
 locals {
   instance_types = {
@@ -14,8 +14,19 @@ terraform {
   }
 }
 
+locals {
+  # The function runs as role_arn when given, else as a role of its own.
+  # Either way it gets the logging and VPC policies; only its own role gets
+  # the account-wide SQS trigger policy, a given role is expected to carry
+  # the queue access itself.
+  role_arn  = var.create_role ? aws_iam_role.this[0].arn : var.role_arn
+  role_name = var.create_role ? aws_iam_role.this[0].name : element(split("/", var.role_arn), length(split("/", var.role_arn)) - 1)
+}
+
 # IAM Role for Lambda
 resource "aws_iam_role" "this" {
+  count = var.create_role ? 1 : 0
+
   name = "${var.function_name}-role"
 
   assume_role_policy = jsonencode({
@@ -34,9 +45,14 @@ resource "aws_iam_role" "this" {
   tags = var.tags
 }
 
+moved {
+  from = aws_iam_role.this
+  to   = aws_iam_role.this[0]
+}
+
 # Basic Logging Policy
 resource "aws_iam_role_policy_attachment" "basic_execution" {
-  role       = aws_iam_role.this.name
+  role       = local.role_name
   policy_arn = "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"
 }
 
@@ -44,19 +60,31 @@ resource "aws_iam_role_policy_attachment" "basic_execution" {
 resource "aws_iam_role_policy_attachment" "vpc_access" {
   count = var.vpc_subnet_ids != null ? 1 : 0
   
-  role       = aws_iam_role.this.name
+  role       = local.role_name
   policy_arn = "arn:aws:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
 }
 
+# Optional SQS trigger access
+resource "aws_iam_role_policy_attachment" "sqs_trigger" {
+  count = var.create_role && var.create_event_source_mapping ? 1 : 0
+  
+  role       = local.role_name
+  policy_arn = "arn:aws:iam::aws:policy/service-role/AWSLambdaSQSQueueExecutionRole"
+}
+
 # Lambda Function
 resource "aws_lambda_function" "this" {
   function_name = var.function_name
   description   = var.description
-  role          = aws_iam_role.this.arn
+  role          = local.role_arn
   handler       = var.handler
   runtime       = var.runtime
   memory_size   = var.memory_size
   timeout       = var.timeout
+  publish       = var.publish
+  
+  # -1 leaves the function on the account's unreserved pool
+  reserved_concurrent_executions = var.reserved_concurrent_executions
   
   # Source code
   filename         = var.filename
@@ -81,6 +109,9 @@ resource "aws_lambda_function" "this" {
     }
   }
   
+  # Environment variable encryption
+  kms_key_arn = var.kms_key_arn
+  
   # Tracing
   tracing_config {
     mode = var.tracing_mode
@@ -93,6 +124,7 @@ resource "aws_lambda_function" "this" {
 resource "aws_cloudwatch_log_group" "this" {
   name              = "/aws/lambda/${var.function_name}"
   retention_in_days = var.log_retention_days
+  kms_key_id        = var.kms_key_arn
   
   tags = var.tags
 }
@@ -108,6 +140,75 @@ resource "aws_lambda_permission" "apigw" {
   source_arn    = "${var.apigw_execution_arn}/*/*"
 }
 
+# SQS Trigger (Optional)
+resource "aws_lambda_event_source_mapping" "this" {
+  count = var.create_event_source_mapping ? 1 : 0
+  
+  event_source_arn = var.event_source_arn
+  function_name    = aws_lambda_function.this.arn
+  batch_size       = var.event_source_batch_size
+  
+  # The role needs the queue access before the mapping first polls
+  depends_on = [aws_iam_role_policy_attachment.sqs_trigger]
+}
+
+# Alias (Optional), with weighted routing for canary rollouts: the stable
+# version keeps the alias while the new version receives canary_weight
+resource "aws_lambda_alias" "this" {
+  count = var.alias_name != null ? 1 : 0
+  
+  name             = var.alias_name
+  function_name    = aws_lambda_function.this.function_name
+  function_version = var.canary_weight != null ? var.stable_version : aws_lambda_function.this.version
+  
+  dynamic "routing_config" {
+    for_each = var.canary_weight != null ? [1] : []
+    content {
+      additional_version_weights = {
+        (aws_lambda_function.this.version) = var.canary_weight
+      }
+    }
+  }
+}
+
+# Provisioned concurrency (Optional), kept warm on the alias's version
+resource "aws_lambda_provisioned_concurrency_config" "this" {
+  count = var.provisioned_concurrent_executions != null && var.alias_name != null ? 1 : 0
+  
+  function_name                     = aws_lambda_function.this.function_name
+  qualifier                         = aws_lambda_alias.this[0].name
+  provisioned_concurrent_executions = var.provisioned_concurrent_executions
+}
+
+# Function URL (Optional HTTPS endpoint)
+resource "aws_lambda_function_url" "this" {
+  count = var.create_function_url ? 1 : 0
+  
+  function_name      = aws_lambda_function.this.function_name
+  authorization_type = var.function_url_auth_type
+}
+
+# Public URLs still need a resource policy allowing anonymous callers
+resource "aws_lambda_permission" "function_url_public" {
+  count = var.create_function_url && var.function_url_auth_type == "NONE" ? 1 : 0
+  
+  statement_id           = "AllowPublicFunctionUrlInvoke"
+  action                 = "lambda:InvokeFunctionUrl"
+  function_name          = aws_lambda_function.this.function_name
+  principal              = "*"
+  function_url_auth_type = "NONE"
+}
+
+resource "aws_lambda_permission" "function_url_invokers" {
+  for_each = var.create_function_url && var.function_url_auth_type == "AWS_IAM" ? toset(var.function_url_invokers) : toset([])
+  
+  statement_id           = "AllowFunctionUrlInvoke${substr(sha1(each.value), 0, 8)}"
+  action                 = "lambda:InvokeFunctionUrl"
+  function_name          = aws_lambda_function.this.function_name
+  principal              = each.value
+  function_url_auth_type = "AWS_IAM"
+}
+
 # Outputs
 output "function_arn" {
   description = "ARN of the Lambda function"
@@ -124,12 +225,37 @@ output "invoke_arn" {
   value       = aws_lambda_function.this.invoke_arn
 }
 
+output "version" {
+  description = "Latest published version ($LATEST when not publishing)"
+  value       = aws_lambda_function.this.version
+}
+
+output "alias_arn" {
+  description = "ARN of the alias"
+  value       = try(aws_lambda_alias.this[0].arn, null)
+}
+
+output "alias_invoke_arn" {
+  description = "Invoke ARN of the alias for API Gateway integration"
+  value       = try(aws_lambda_alias.this[0].invoke_arn, null)
+}
+
+output "function_url" {
+  description = "HTTPS endpoint of the function URL (null when not created)"
+  value       = try(aws_lambda_function_url.this[0].function_url, null)
+}
+
 output "role_name" {
   description = "IAM Role name"
-  value       = aws_iam_role.this.name
+  value       = local.role_name
 }
 
 output "role_arn" {
   description = "IAM Role ARN"
-  value       = aws_iam_role.this.arn
+  value       = local.role_arn
+}
+
+output "event_source_mapping_id" {
+  description = "UUID of the SQS trigger (null when not created)"
+  value       = try(aws_lambda_event_source_mapping.this[0].uuid, null)
 }
@@ -77,6 +77,12 @@ variable "vpc_security_group_ids" {
   default     = null
 }
 
+variable "kms_key_arn" {
+  description = "KMS key for environment variables and logs (optional)"
+  type        = string
+  default     = null
+}
+
 variable "tracing_mode" {
   description = "X-Ray tracing mode (PassThrough or Active)"
   type        = string
@@ -89,7 +95,38 @@ variable "log_retention_days" {
   default     = 14
 }
 
+# Execution Role
+variable "create_role" {
+  description = "Create the function's execution role; when false the function runs as role_arn"
+  type        = bool
+  default     = true
+}
+
+variable "role_arn" {
+  description = "Existing execution role the function runs as when create_role is false"
+  type        = string
+  default     = null
+}
+
 # Triggers
+variable "create_event_source_mapping" {
+  description = "Invoke the function with the messages of the SQS queue event_source_arn"
+  type        = bool
+  default     = false
+}
+
+variable "event_source_arn" {
+  description = "ARN of the SQS queue that triggers the function"
+  type        = string
+  default     = null
+}
+
+variable "event_source_batch_size" {
+  description = "Largest number of queue messages passed to one invocation"
+  type        = number
+  default     = 10
+}
+
 variable "create_apigw_permission" {
   description = "Create permission for API Gateway invoke"
   type        = bool
@@ -102,6 +139,62 @@ variable "apigw_execution_arn" {
   default     = null
 }
 
+# Versions and aliases
+variable "publish" {
+  description = "Publish a new version on changes"
+  type        = bool
+  default     = false
+}
+
+variable "alias_name" {
+  description = "Name of the alias to create"
+  type        = string
+  default     = null
+}
+
+variable "canary_weight" {
+  description = "Traffic weight routed to the new version through the alias"
+  type        = number
+  default     = null
+}
+
+variable "stable_version" {
+  description = "Version receiving the remaining alias traffic during a canary"
+  type        = string
+  default     = null
+}
+
+# Concurrency
+variable "reserved_concurrent_executions" {
+  description = "Concurrent executions reserved for the function, and its limit (-1 for none, 0 to stop invocations)"
+  type        = number
+  default     = -1
+}
+
+variable "provisioned_concurrent_executions" {
+  description = "Execution environments kept initialized for the alias; requires alias_name"
+  type        = number
+  default     = null
+}
+
+variable "create_function_url" {
+  description = "Create a Lambda function URL"
+  type        = bool
+  default     = false
+}
+
+variable "function_url_auth_type" {
+  description = "Function URL authorization type (AWS_IAM or NONE)"
+  type        = string
+  default     = "AWS_IAM"
+}
+
+variable "function_url_invokers" {
+  description = "Principal ARNs granted lambda:InvokeFunctionUrl when the URL uses AWS_IAM"
+  type        = list(string)
+  default     = []
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
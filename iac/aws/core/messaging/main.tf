@@ -27,10 +27,13 @@ resource "aws_sqs_queue" "this" {
   delay_seconds               = var.delay_seconds
   receive_wait_time_seconds   = var.receive_wait_time_seconds
   
-  sqs_managed_sse_enabled     = var.sqs_managed_sse_enabled
+  # A customer-managed key replaces SQS-managed SSE
+  kms_master_key_id           = var.queue_kms_master_key_id
+  sqs_managed_sse_enabled     = var.queue_kms_master_key_id == null ? var.sqs_managed_sse_enabled : null
   
-  redrive_policy = var.dead_letter_queue_arn != null ? jsonencode({
-    deadLetterTargetArn = var.dead_letter_queue_arn
+  # Redrive to the managed DLQ, or to an existing one
+  redrive_policy = var.create_dlq || var.dead_letter_queue_arn != null ? jsonencode({
+    deadLetterTargetArn = var.create_dlq ? aws_sqs_queue.dlq[0].arn : var.dead_letter_queue_arn
     maxReceiveCount     = var.max_receive_count
   }) : null
 
@@ -44,7 +47,8 @@ resource "aws_sqs_queue" "dlq" {
   name = "${var.queue_name}-dlq"
   
   message_retention_seconds = var.dlq_message_retention_seconds
-  sqs_managed_sse_enabled   = true
+  kms_master_key_id         = var.queue_kms_master_key_id
+  sqs_managed_sse_enabled   = var.queue_kms_master_key_id == null ? true : null
   
   tags = var.tags
 }
@@ -77,6 +81,99 @@ resource "aws_sns_topic_subscription" "this" {
   raw_message_delivery = lookup(var.subscriptions[count.index], "raw_message_delivery", false)
 }
 
+# ============================================================================
+# ACCESS POLICIES
+# ============================================================================
+
+data "aws_partition" "current" {
+  count = local.access_policy_enabled ? 1 : 0
+}
+
+data "aws_region" "current" {
+  count = local.access_policy_enabled ? 1 : 0
+}
+
+data "aws_caller_identity" "current" {
+  count = local.access_policy_enabled ? 1 : 0
+}
+
+locals {
+  access_policy_enabled = length(var.access_grants) + length(var.service_grants) + length(var.source_policy_documents) > 0
+
+  # arn:<partition>:<service>:<region>:<account>:<name>, built from the name
+  # rather than read from the queue or topic so the policy is known at plan
+  # time and can be reviewed there
+  access_resource = !local.access_policy_enabled ? null : format(
+    "arn:%s:%s:%s:%s:%s",
+    data.aws_partition.current[0].partition,
+    var.create_queue ? "sqs" : "sns",
+    data.aws_region.current[0].name,
+    data.aws_caller_identity.current[0].account_id,
+    var.create_queue ? var.queue_name : var.topic_name,
+  )
+}
+
+# The statements of source_policy_documents come first, so the service
+# statements other modules need (S3 notifications, EventBridge targets) can
+# be kept alongside the grants
+data "aws_iam_policy_document" "access" {
+  count = local.access_policy_enabled ? 1 : 0
+
+  source_policy_documents = var.source_policy_documents
+
+  dynamic "statement" {
+    for_each = var.access_grants
+    content {
+      effect    = "Allow"
+      actions   = statement.value.actions
+      resources = [local.access_resource]
+
+      principals {
+        type        = "AWS"
+        identifiers = [statement.value.principal_arn]
+      }
+    }
+  }
+
+  # Services act for every account, so each is confined to one source
+  dynamic "statement" {
+    for_each = var.service_grants
+    content {
+      effect    = "Allow"
+      actions   = statement.value.actions
+      resources = [local.access_resource]
+
+      principals {
+        type        = "Service"
+        identifiers = [statement.value.service]
+      }
+
+      condition {
+        test     = "ArnEquals"
+        variable = "aws:SourceArn"
+        values   = [statement.value.source_arn]
+      }
+    }
+  }
+}
+
+# Either policy replaces the resource's whole policy, so every principal
+# that needs access must be among the grants or source_policy_documents, and
+# no other module may write a policy for the same queue or topic
+resource "aws_sqs_queue_policy" "access" {
+  count = var.create_queue && local.access_policy_enabled ? 1 : 0
+
+  queue_url = aws_sqs_queue.this[0].id
+  policy    = data.aws_iam_policy_document.access[0].json
+}
+
+resource "aws_sns_topic_policy" "access" {
+  count = !var.create_queue && var.create_topic && local.access_policy_enabled ? 1 : 0
+
+  arn    = aws_sns_topic.this[0].arn
+  policy = data.aws_iam_policy_document.access[0].json
+}
+
 # ============================================================================
 # OUTPUTS
 # ============================================================================
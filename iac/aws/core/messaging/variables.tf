@@ -59,6 +59,12 @@ variable "sqs_managed_sse_enabled" {
   default     = true
 }
 
+variable "queue_kms_master_key_id" {
+  description = "KMS key for SQS encryption (replaces SQS-managed SSE when set)"
+  type        = string
+  default     = null
+}
+
 # Dead Letter Queue
 variable "create_dlq" {
   description = "Create a managed Dead Letter Queue"
@@ -119,6 +125,32 @@ variable "subscriptions" {
   default = []
 }
 
+# Access Policy
+variable "access_grants" {
+  description = "Principals (IAM role or user ARNs) granted actions on the queue, or on the topic when no queue is created; one statement each in the resource's access policy"
+  type = list(object({
+    principal_arn = string
+    actions       = list(string)
+  }))
+  default = []
+}
+
+variable "service_grants" {
+  description = "AWS services (e.g. s3.amazonaws.com) granted actions on the queue or topic for requests from one source ARN, such as a bucket sending notifications"
+  type = list(object({
+    service    = string
+    source_arn = string
+    actions    = list(string)
+  }))
+  default = []
+}
+
+variable "source_policy_documents" {
+  description = "Policy documents (JSON) whose statements are merged into the queue or topic policy ahead of the grants"
+  type        = list(string)
+  default     = []
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
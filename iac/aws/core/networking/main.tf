@@ -16,6 +16,9 @@ resource "aws_vpc" "this" {
   enable_dns_hostnames = var.enable_dns_hostnames
   enable_dns_support   = var.enable_dns_support
   
+  # An Amazon-provided /56
+  assign_generated_ipv6_cidr_block = var.enable_ipv6
+  
   tags = merge(
     var.tags,
     {
@@ -38,6 +41,13 @@ resource "aws_internet_gateway" "this" {
   )
 }
 
+# Which /64 of the VPC's /56 each subnet gets: public subnets first, then
+# private, unless the caller picks them
+locals {
+  public_ipv6_netnums  = length(var.public_ipv6_netnums) > 0 ? var.public_ipv6_netnums : range(length(var.public_subnet_cidrs))
+  private_ipv6_netnums = length(var.private_ipv6_netnums) > 0 ? var.private_ipv6_netnums : [for i in range(length(var.private_subnet_cidrs)) : length(var.public_subnet_cidrs) + i]
+}
+
 # Public Subnets
 resource "aws_subnet" "public" {
   count = length(var.public_subnet_cidrs)
@@ -47,6 +57,9 @@ resource "aws_subnet" "public" {
   availability_zone       = var.availability_zones[count.index]
   map_public_ip_on_launch = true
   
+  ipv6_cidr_block                 = var.enable_ipv6 ? cidrsubnet(aws_vpc.this.ipv6_cidr_block, 8, local.public_ipv6_netnums[count.index]) : null
+  assign_ipv6_address_on_creation = var.enable_ipv6
+  
   tags = merge(
     var.tags,
     {
@@ -64,6 +77,9 @@ resource "aws_subnet" "private" {
   cidr_block        = var.private_subnet_cidrs[count.index]
   availability_zone = var.availability_zones[count.index]
   
+  ipv6_cidr_block                 = var.enable_ipv6 ? cidrsubnet(aws_vpc.this.ipv6_cidr_block, 8, local.private_ipv6_netnums[count.index]) : null
+  assign_ipv6_address_on_creation = var.enable_ipv6
+  
   tags = merge(
     var.tags,
     {
@@ -96,6 +112,14 @@ resource "aws_route" "public_internet" {
   gateway_id             = aws_internet_gateway.this[0].id
 }
 
+resource "aws_route" "public_internet_ipv6" {
+  count = var.enable_ipv6 && var.create_internet_gateway && length(var.public_subnet_cidrs) > 0 ? 1 : 0
+  
+  route_table_id              = aws_route_table.public[0].id
+  destination_ipv6_cidr_block = "::/0"
+  gateway_id                  = aws_internet_gateway.this[0].id
+}
+
 # Public Route Table Associations
 resource "aws_route_table_association" "public" {
   count = length(var.public_subnet_cidrs)
@@ -126,6 +150,29 @@ resource "aws_route_table_association" "private" {
   route_table_id = aws_route_table.private[count.index].id
 }
 
+# Egress-only Internet Gateway: outbound IPv6 for private subnets, which
+# have public addresses but must not be reachable from the internet
+resource "aws_egress_only_internet_gateway" "this" {
+  count = var.enable_ipv6 && length(var.private_subnet_cidrs) > 0 ? 1 : 0
+  
+  vpc_id = aws_vpc.this.id
+  
+  tags = merge(
+    var.tags,
+    {
+      Name = "${var.vpc_name}-eigw"
+    }
+  )
+}
+
+resource "aws_route" "private_ipv6_egress" {
+  count = var.enable_ipv6 ? length(var.private_subnet_cidrs) : 0
+  
+  route_table_id              = aws_route_table.private[count.index].id
+  destination_ipv6_cidr_block = "::/0"
+  egress_only_gateway_id      = aws_egress_only_internet_gateway.this[0].id
+}
+
 # Default Security Group
 resource "aws_security_group" "default" {
   count = var.create_default_security_group ? 1 : 0
@@ -158,6 +205,174 @@ resource "aws_security_group" "default" {
   )
 }
 
+# Firewall Rules
+resource "aws_security_group" "rules" {
+  count = length(var.firewall_rules) > 0 ? 1 : 0
+  
+  name_prefix = "${var.vpc_name}-rules-"
+  description = "Firewall rules for ${var.vpc_name}"
+  vpc_id      = aws_vpc.this.id
+  
+  tags = merge(
+    var.tags,
+    {
+      Name = "${var.vpc_name}-rules-sg"
+    }
+  )
+}
+
+locals {
+  # One security group rule per rule, port range and CIDR block. "*" stands
+  # for every port.
+  firewall_entries = {
+    for e in flatten([
+      for r in var.firewall_rules : [
+        for port in (length(r.ports) > 0 ? r.ports : ["*"]) : [
+          for cidr in r.cidr_blocks : {
+            key       = "${r.name}/${port}/${cidr}"
+            direction = r.direction
+            protocol  = r.protocol == "all" ? "-1" : r.protocol
+            # icmp takes type and code in the port fields; -1 is any
+            from_port   = r.protocol == "all" ? null : r.protocol == "icmp" ? -1 : port == "*" ? 0 : tonumber(split("-", port)[0])
+            to_port     = r.protocol == "all" ? null : r.protocol == "icmp" ? -1 : port == "*" ? 65535 : tonumber(reverse(split("-", port))[0])
+            cidr_ipv4   = can(cidrnetmask(cidr)) ? cidr : null
+            cidr_ipv6   = can(cidrnetmask(cidr)) ? null : cidr
+            description = r.description != "" ? r.description : r.name
+          }
+        ]
+      ]
+    ]) : e.key => e
+  }
+}
+
+resource "aws_vpc_security_group_ingress_rule" "rules" {
+  for_each = { for k, e in local.firewall_entries : k => e if e.direction == "ingress" }
+  
+  security_group_id = aws_security_group.rules[0].id
+  description       = each.value.description
+  ip_protocol       = each.value.protocol
+  from_port         = each.value.from_port
+  to_port           = each.value.to_port
+  cidr_ipv4         = each.value.cidr_ipv4
+  cidr_ipv6         = each.value.cidr_ipv6
+  
+  tags = var.tags
+}
+
+resource "aws_vpc_security_group_egress_rule" "rules" {
+  for_each = { for k, e in local.firewall_entries : k => e if e.direction == "egress" }
+  
+  security_group_id = aws_security_group.rules[0].id
+  description       = each.value.description
+  ip_protocol       = each.value.protocol
+  from_port         = each.value.from_port
+  to_port           = each.value.to_port
+  cidr_ipv4         = each.value.cidr_ipv4
+  cidr_ipv6         = each.value.cidr_ipv6
+  
+  tags = var.tags
+}
+
+# VPC Endpoints
+data "aws_region" "current" {}
+
+locals {
+  # Interface endpoints go in the private subnets, or the public ones in a
+  # network without any
+  endpoint_subnet_ids = length(aws_subnet.private) > 0 ? aws_subnet.private[*].id : aws_subnet.public[*].id
+}
+
+resource "aws_vpc_endpoint" "this" {
+  for_each = var.vpc_endpoints
+  
+  vpc_id            = aws_vpc.this.id
+  service_name      = "com.amazonaws.${data.aws_region.current.name}.${each.value.service}"
+  vpc_endpoint_type = each.value.type
+  
+  # Gateway endpoints are routes in every route table
+  route_table_ids = each.value.type == "Gateway" ? concat(aws_route_table.public[*].id, aws_route_table.private[*].id) : null
+  
+  # Interface endpoints are network interfaces with private DNS names
+  subnet_ids          = each.value.type == "Interface" ? local.endpoint_subnet_ids : null
+  security_group_ids  = each.value.type == "Interface" && length(aws_security_group.default) > 0 ? [aws_security_group.default[0].id] : null
+  private_dns_enabled = each.value.type == "Interface" ? true : null
+  
+  tags = merge(
+    var.tags,
+    {
+      Name = "${var.vpc_name}-${each.key}-endpoint"
+    }
+  )
+}
+
+# Flow Logs
+locals {
+  flow_log_to_s3         = var.enable_flow_logs && can(regex("^arn:[a-z-]+:s3:::", var.flow_log_destination))
+  flow_log_to_cloudwatch = var.enable_flow_logs && !local.flow_log_to_s3
+}
+
+# Only CloudWatch Logs delivery needs a role; S3 delivery uses the bucket policy
+resource "aws_iam_role" "flow_logs" {
+  count = local.flow_log_to_cloudwatch ? 1 : 0
+  
+  name = "${var.vpc_name}-flow-logs"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "vpc-flow-logs.amazonaws.com"
+        }
+      }
+    ]
+  })
+  
+  tags = var.tags
+}
+
+resource "aws_iam_role_policy" "flow_logs" {
+  count = local.flow_log_to_cloudwatch ? 1 : 0
+  
+  name = "${var.vpc_name}-flow-logs"
+  role = aws_iam_role.flow_logs[0].id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = [
+          "logs:CreateLogStream",
+          "logs:PutLogEvents",
+          "logs:DescribeLogGroups",
+          "logs:DescribeLogStreams"
+        ]
+        Effect   = "Allow"
+        Resource = [var.flow_log_destination, "${var.flow_log_destination}:*"]
+      }
+    ]
+  })
+}
+
+resource "aws_flow_log" "this" {
+  count = var.enable_flow_logs ? 1 : 0
+  
+  vpc_id               = aws_vpc.this.id
+  traffic_type         = "ALL"
+  log_destination_type = local.flow_log_to_s3 ? "s3" : "cloud-watch-logs"
+  log_destination      = var.flow_log_destination
+  iam_role_arn         = local.flow_log_to_cloudwatch ? aws_iam_role.flow_logs[0].arn : null
+  
+  tags = merge(
+    var.tags,
+    {
+      Name = "${var.vpc_name}-flow-log"
+    }
+  )
+}
+
 # Outputs
 output "vpc_id" {
   description = "VPC ID"
@@ -174,6 +389,26 @@ output "vpc_cidr" {
   value       = aws_vpc.this.cidr_block
 }
 
+output "ipv6_cidr" {
+  description = "The VPC's Amazon-provided IPv6 /56, when enable_ipv6 is set"
+  value       = var.enable_ipv6 ? aws_vpc.this.ipv6_cidr_block : null
+}
+
+output "public_ipv6_cidrs" {
+  description = "IPv6 /64s of the public subnets"
+  value       = var.enable_ipv6 ? aws_subnet.public[*].ipv6_cidr_block : []
+}
+
+output "private_ipv6_cidrs" {
+  description = "IPv6 /64s of the private subnets"
+  value       = var.enable_ipv6 ? aws_subnet.private[*].ipv6_cidr_block : []
+}
+
+output "egress_only_internet_gateway_id" {
+  description = "ID of the egress-only Internet Gateway"
+  value       = length(aws_egress_only_internet_gateway.this) > 0 ? aws_egress_only_internet_gateway.this[0].id : null
+}
+
 output "internet_gateway_id" {
   description = "Internet Gateway ID"
   value       = length(aws_internet_gateway.this) > 0 ? aws_internet_gateway.this[0].id : null
@@ -203,3 +438,18 @@ output "default_security_group_id" {
   description = "Default security group ID"
   value       = length(aws_security_group.default) > 0 ? aws_security_group.default[0].id : null
 }
+
+output "rules_security_group_id" {
+  description = "ID of the security group holding firewall_rules"
+  value       = length(aws_security_group.rules) > 0 ? aws_security_group.rules[0].id : null
+}
+
+output "vpc_endpoint_ids" {
+  description = "VPC endpoint IDs by name"
+  value       = { for name, ep in aws_vpc_endpoint.this : name => ep.id }
+}
+
+output "flow_log_id" {
+  description = "ID of the VPC flow log, when enabled"
+  value       = length(aws_flow_log.this) > 0 ? aws_flow_log.this[0].id : null
+}
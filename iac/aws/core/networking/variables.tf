@@ -21,6 +21,24 @@ variable "enable_dns_support" {
   default     = true
 }
 
+variable "enable_ipv6" {
+  description = "Dual stack: an Amazon-provided IPv6 /56 with a /64 per subnet"
+  type        = bool
+  default     = false
+}
+
+variable "public_ipv6_netnums" {
+  description = "Which /64 of the /56 (0-255) each public subnet gets; empty numbers them from 0"
+  type        = list(number)
+  default     = []
+}
+
+variable "private_ipv6_netnums" {
+  description = "Which /64 of the /56 (0-255) each private subnet gets; empty numbers them after the public subnets"
+  type        = list(number)
+  default     = []
+}
+
 variable "availability_zones" {
   description = "List of availability zones"
   type        = list(string)
@@ -50,6 +68,40 @@ variable "create_default_security_group" {
   default     = true
 }
 
+variable "firewall_rules" {
+  description = "Firewall rules from the networking facade: direction ingress or egress, protocol tcp, udp, icmp or all, ports as \"443\" or \"8000-8080\" (empty for every port)"
+  type = list(object({
+    name        = string
+    direction   = string
+    protocol    = string
+    ports       = list(string)
+    cidr_blocks = list(string)
+    description = string
+  }))
+  default = []
+}
+
+variable "vpc_endpoints" {
+  description = "VPC endpoints by name: the AWS service (e.g. s3) and Gateway or Interface"
+  type = map(object({
+    service = string
+    type    = string
+  }))
+  default = {}
+}
+
+variable "enable_flow_logs" {
+  description = "Log all VPC traffic to flow_log_destination"
+  type        = bool
+  default     = false
+}
+
+variable "flow_log_destination" {
+  description = "ARN of the S3 bucket or CloudWatch Logs log group flow logs are delivered to"
+  type        = string
+  default     = null
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
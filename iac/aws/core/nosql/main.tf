@@ -1,33 +1,68 @@
 # AWS NoSQL (DynamoDB) Core Module
 
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+locals {
+  provisioned = var.billing_mode == "PROVISIONED"
+
+  # Every key attribute needs one attribute block, however many indexes
+  # share it; the table's own keys win over an index that repeats them
+  key_attributes = merge(
+    { for a in var.attributes : a.name => a.type },
+    { for gsi in var.global_secondary_indexes : gsi.range_key => gsi.range_key_type if gsi.range_key != null },
+    { for gsi in var.global_secondary_indexes : gsi.hash_key => gsi.hash_key_type },
+    var.range_key != null ? { (var.range_key) = var.range_key_type } : {},
+    { (var.hash_key) = var.hash_key_type },
+  )
+}
+
 resource "aws_dynamodb_table" "this" {
   name         = var.table_name
   billing_mode = var.billing_mode
 
-  read_capacity  = var.billing_mode == "PROVISIONED" ? var.read_capacity : null
-  write_capacity = var.billing_mode == "PROVISIONED" ? var.write_capacity : null
+  read_capacity  = local.provisioned ? var.read_capacity : null
+  write_capacity = local.provisioned ? var.write_capacity : null
 
   hash_key  = var.hash_key
   range_key = var.range_key
 
-  attribute {
-    name = var.hash_key
-    type = var.hash_key_type
+  dynamic "attribute" {
+    for_each = local.key_attributes
+    content {
+      name = attribute.key
+      type = attribute.value
+    }
   }
 
-  dynamic "attribute" {
-    for_each = var.range_key != null ? [1] : []
+  # Provisioned indexes take the table's capacity
+  dynamic "global_secondary_index" {
+    for_each = var.global_secondary_indexes
     content {
-      name = var.range_key
-      type = var.range_key_type
+      name               = global_secondary_index.value.name
+      hash_key           = global_secondary_index.value.hash_key
+      range_key          = global_secondary_index.value.range_key
+      projection_type    = global_secondary_index.value.projection_type
+      non_key_attributes = global_secondary_index.value.projection_type == "INCLUDE" ? global_secondary_index.value.non_key_attributes : null
+      read_capacity      = local.provisioned ? var.read_capacity : null
+      write_capacity     = local.provisioned ? var.write_capacity : null
     }
   }
 
-  dynamic "attribute" {
-    for_each = var.attributes
+  stream_enabled   = var.stream_view_type != null
+  stream_view_type = var.stream_view_type
+
+  dynamic "ttl" {
+    for_each = var.ttl_attribute != null ? [1] : []
     content {
-      name = attribute.value.name
-      type = attribute.value.type
+      attribute_name = var.ttl_attribute
+      enabled        = true
     }
   }
 
@@ -41,3 +76,7 @@ output "table_id" {
 output "table_arn" {
   value = aws_dynamodb_table.this.arn
 }
+
+output "stream_arn" {
+  value = aws_dynamodb_table.this.stream_arn
+}
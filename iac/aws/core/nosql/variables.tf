@@ -50,6 +50,32 @@ variable "attributes" {
   default = []
 }
 
+variable "global_secondary_indexes" {
+  description = "Global secondary indexes; projection_type is ALL, KEYS_ONLY or INCLUDE, the last copying non_key_attributes into the index"
+  type = list(object({
+    name               = string
+    hash_key           = string
+    hash_key_type      = optional(string, "S")
+    range_key          = optional(string)
+    range_key_type     = optional(string, "S")
+    projection_type    = optional(string, "ALL")
+    non_key_attributes = optional(list(string), [])
+  }))
+  default = []
+}
+
+variable "stream_view_type" {
+  description = "What the table's stream records for each change (KEYS_ONLY, NEW_IMAGE, OLD_IMAGE, NEW_AND_OLD_IMAGES); null leaves the stream off"
+  type        = string
+  default     = null
+}
+
+variable "ttl_attribute" {
+  description = "Attribute holding each item's expiry as epoch seconds; null leaves TTL off"
+  type        = string
+  default     = null
+}
+
 variable "tags" {
   description = "Tags"
   type        = map(string)
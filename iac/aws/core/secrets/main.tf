@@ -1,5 +1,14 @@
 # AWS Secrets Manager Core Module
 
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
 resource "aws_secretsmanager_secret" "this" {
   name                    = var.name
   description             = var.description
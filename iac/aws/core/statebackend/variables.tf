@@ -0,0 +1,33 @@
+variable "bucket_name" {
+  description = "Name of the state bucket"
+  type        = string
+}
+
+variable "lock_table_name" {
+  description = "Name of the DynamoDB lock table"
+  type        = string
+}
+
+variable "kms_key_arn" {
+  description = "KMS key encrypting the bucket and the lock table; S3-managed keys and the DynamoDB-owned key when null"
+  type        = string
+  default     = null
+}
+
+variable "noncurrent_version_days" {
+  description = "Days a replaced state version is kept"
+  type        = number
+  default     = 90
+}
+
+variable "force_destroy" {
+  description = "Delete the bucket on destroy even when it holds state"
+  type        = bool
+  default     = false
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
@@ -47,6 +47,63 @@ resource "aws_s3_bucket_public_access_block" "this" {
   restrict_public_buckets = true
 }
 
+# S3 must be allowed to invoke each function it notifies. One permission per
+# notification, as the function ARNs may not be known until apply.
+locals {
+  function_notifications = [for n in var.notifications : n if n.target_type == "function"]
+}
+
+resource "aws_lambda_permission" "notifications" {
+  count = length(local.function_notifications)
+
+  statement_id  = "AllowS3Invoke-${var.bucket_name}-${count.index}"
+  action        = "lambda:InvokeFunction"
+  function_name = local.function_notifications[count.index].target_arn
+  principal     = "s3.amazonaws.com"
+  source_arn    = aws_s3_bucket.this.arn
+}
+
+# S3 keeps one notification configuration per bucket, and each
+# aws_s3_bucket_notification replaces it whole, so every target goes into
+# this one resource
+resource "aws_s3_bucket_notification" "this" {
+  count  = length(var.notifications) > 0 ? 1 : 0
+  bucket = aws_s3_bucket.this.id
+
+  dynamic "lambda_function" {
+    for_each = local.function_notifications
+    content {
+      lambda_function_arn = lambda_function.value.target_arn
+      events              = lambda_function.value.events
+      filter_prefix       = lambda_function.value.filter_prefix
+      filter_suffix       = lambda_function.value.filter_suffix
+    }
+  }
+
+  dynamic "queue" {
+    for_each = [for n in var.notifications : n if n.target_type == "queue"]
+    content {
+      queue_arn     = queue.value.target_arn
+      events        = queue.value.events
+      filter_prefix = queue.value.filter_prefix
+      filter_suffix = queue.value.filter_suffix
+    }
+  }
+
+  dynamic "topic" {
+    for_each = [for n in var.notifications : n if n.target_type == "topic"]
+    content {
+      topic_arn     = topic.value.target_arn
+      events        = topic.value.events
+      filter_prefix = topic.value.filter_prefix
+      filter_suffix = topic.value.filter_suffix
+    }
+  }
+
+  # S3 tests each destination when the configuration is written
+  depends_on = [aws_lambda_permission.notifications]
+}
+
 output "bucket_id" {
   value = aws_s3_bucket.this.id
 }
@@ -59,6 +116,10 @@ output "bucket_domain_name" {
   value = aws_s3_bucket.this.bucket_domain_name
 }
 
+output "bucket_regional_domain_name" {
+  value = aws_s3_bucket.this.bucket_regional_domain_name
+}
+
 output "region" {
   value = aws_s3_bucket.this.region
 }
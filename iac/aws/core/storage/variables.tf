@@ -28,6 +28,18 @@ variable "public_access_block" {
   default     = true
 }
 
+variable "notifications" {
+  description = "Object events to send to Lambda functions, SQS queues or SNS topics (target_type function, queue or topic); every one goes into the bucket's single notification configuration"
+  type = list(object({
+    events        = list(string)
+    filter_prefix = optional(string)
+    filter_suffix = optional(string)
+    target_type   = string
+    target_arn    = string
+  }))
+  default = []
+}
+
 variable "force_destroy" {
   description = "Allow bucket deletion with objects"
   type        = bool
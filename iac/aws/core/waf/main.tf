@@ -0,0 +1,100 @@
+# AWS WAFv2 Core Module (regional web ACL)
+
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+locals {
+  # Metric names allow letters, digits, hyphens and underscores
+  metric_name = replace(var.name, "-", "_")
+}
+
+resource "aws_wafv2_web_acl" "this" {
+  name  = var.name
+  scope = "REGIONAL"
+
+  default_action {
+    allow {}
+  }
+
+  # One rule per AWS managed rule group, in the order given
+  dynamic "rule" {
+    for_each = { for i, group in var.managed_rule_groups : group => i }
+    content {
+      name     = rule.key
+      priority = rule.value
+
+      override_action {
+        none {}
+      }
+
+      statement {
+        managed_rule_group_statement {
+          name        = rule.key
+          vendor_name = "AWS"
+        }
+      }
+
+      visibility_config {
+        cloudwatch_metrics_enabled = true
+        metric_name                = "${local.metric_name}_${rule.key}"
+        sampled_requests_enabled   = true
+      }
+    }
+  }
+
+  # Blocks a client IP over rate_limit requests in a 5 minute window
+  dynamic "rule" {
+    for_each = var.rate_limit != null ? [var.rate_limit] : []
+    content {
+      name     = "rate-limit"
+      priority = length(var.managed_rule_groups)
+
+      action {
+        block {}
+      }
+
+      statement {
+        rate_based_statement {
+          limit              = rule.value
+          aggregate_key_type = "IP"
+        }
+      }
+
+      visibility_config {
+        cloudwatch_metrics_enabled = true
+        metric_name                = "${local.metric_name}_rate_limit"
+        sampled_requests_enabled   = true
+      }
+    }
+  }
+
+  visibility_config {
+    cloudwatch_metrics_enabled = true
+    metric_name                = local.metric_name
+    sampled_requests_enabled   = true
+  }
+
+  tags = var.tags
+}
+
+# Counted rather than keyed: the ARNs are often unknown until apply
+resource "aws_wafv2_web_acl_association" "this" {
+  count = length(var.resource_arns)
+
+  resource_arn = var.resource_arns[count.index]
+  web_acl_arn  = aws_wafv2_web_acl.this.arn
+}
+
+output "web_acl_arn" {
+  value = aws_wafv2_web_acl.this.arn
+}
+
+output "web_acl_id" {
+  value = aws_wafv2_web_acl.this.id
+}
@@ -0,0 +1,28 @@
+variable "name" {
+  description = "Name of the web ACL"
+  type        = string
+}
+
+variable "managed_rule_groups" {
+  description = "AWS managed rule group names, e.g. AWSManagedRulesCommonRuleSet"
+  type        = list(string)
+  default     = []
+}
+
+variable "rate_limit" {
+  description = "Requests per client IP in 5 minutes before it is blocked (null to skip)"
+  type        = number
+  default     = null
+}
+
+variable "resource_arns" {
+  description = "API Gateway stage and Application Load Balancer ARNs to associate"
+  type        = list(string)
+  default     = []
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+  default     = {}
+}
@@ -1,5 +1,14 @@
 # AWS Step Functions Core Module
 
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
 resource "aws_sfn_state_machine" "this" {
   name     = var.name
   role_arn = var.role_arn
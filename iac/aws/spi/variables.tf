@@ -37,6 +37,10 @@ variable "aws_assume_role" {
 variable "environment" {
   description = "Environment name"
   type        = string
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
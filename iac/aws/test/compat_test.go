@@ -0,0 +1,116 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/compat"
+	"iac/testutil/tflog"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuCompatibilityMatrix probes a subset of the operations in
+// testutil/compat/matrix.json against CloudEmu and fails when the matrix
+// is out of date, most usefully when an operation listed as unsupported
+// starts working, so the tests that skip on it can be turned back on. The
+// report goes to the test log, and to compat.md in the test's artifact
+// directory when SWE_TEST_ARTIFACT_DIR is set.
+func TestCloudEmuCompatibilityMatrix(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	matrix, err := compat.Default()
+	require.NoError(t, err)
+
+	sess := newCloudEmuSession(t)
+	s3Client, snsClient, sqsClient := s3.New(sess), sns.New(sess), sqs.New(sess)
+	stamp := time.Now().Unix()
+
+	probes := map[string]compat.Probe{
+		"s3.PutBucketVersioning": func(context.Context) error {
+			bucket := fmt.Sprintf("compat-versioning-%d", stamp)
+			defer deleteBucket(s3Client, bucket)
+			return createVersionedBucket(s3Client, bucket)
+		},
+		"s3.PutBucketReplication": func(context.Context) error {
+			source := fmt.Sprintf("compat-replication-src-%d", stamp)
+			destination := fmt.Sprintf("compat-replication-dst-%d", stamp)
+			defer deleteBucket(s3Client, source)
+			defer deleteBucket(s3Client, destination)
+			for _, bucket := range []string{source, destination} {
+				if err := createVersionedBucket(s3Client, bucket); err != nil {
+					return err
+				}
+			}
+			return putReplication(s3Client, source, destination)
+		},
+		"sns.FilterPolicy": func(ctx context.Context) error {
+			return probeFilterPolicy(ctx, snsClient, sqsClient, fmt.Sprintf("compat-filter-%d", stamp))
+		},
+	}
+
+	results := compat.Verify(context.Background(), matrix, compat.CloudEmu, probes, compat.DefaultProbeTimeout)
+
+	var report strings.Builder
+	require.NoError(t, compat.WriteReport(&report, results))
+	t.Logf("CloudEmu compatibility:\n%s", report.String())
+	if dir := tflog.LoadOptions().ArtifactDir; dir != "" {
+		path := filepath.Join(dir, tflog.ArtifactName(t.Name()), "compat.md")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(report.String()), 0o644))
+	}
+
+	compat.Check(t, results)
+}
+
+// probeFilterPolicy publishes a non-matching and then a matching message
+// through a filtered subscription and reads the queue until the matching
+// one arrives. Seeing the non-matching one first means the policy is
+// ignored.
+func probeFilterPolicy(ctx context.Context, snsClient *sns.SNS, sqsClient *sqs.SQS, name string) error {
+	topicARN, queueURL, err := filteredSubscription(snsClient, sqsClient, name, orderCreatedPolicy)
+	defer deleteSubscription(snsClient, sqsClient, topicARN, queueURL)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range []string{"order_cancelled", "order_created"} {
+		if err := publishEvent(snsClient, topicARN, event); err != nil {
+			return err
+		}
+	}
+
+	for ctx.Err() == nil {
+		msg, err := receiveOne(sqsClient, queueURL)
+		if errors.Is(err, errNoMessage) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		event, err := eventType(msg)
+		if err != nil {
+			return err
+		}
+		if event != "order_created" {
+			return fmt.Errorf("%w: the subscription received a %s message its filter policy excludes", compat.ErrUnsupported, event)
+		}
+		return nil
+	}
+	return fmt.Errorf("no message was delivered: %w", ctx.Err())
+}
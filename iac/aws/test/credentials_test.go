@@ -0,0 +1,53 @@
+//go:build integration
+
+package test
+
+import (
+	"testing"
+
+	"iac/testutil/config"
+
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envRealCloud opts in to the tests that reach a real AWS account
+const envRealCloud = "SWE_REAL_CLOUD"
+
+// TestRealCloudAssumeRole assumes the configured role in the account the
+// environment's credentials or profile belong to and lists its buckets, a
+// read-only call, to prove the SDK helpers authenticate against AWS itself.
+// Opt in with SWE_REAL_CLOUD=1 and set credentials.aws.role_arn (or
+// SWE_AWS_ROLE_ARN).
+func TestRealCloudAssumeRole(t *testing.T) {
+	run, err := config.BoolFromEnv(envRealCloud)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run {
+		t.Skipf("Real cloud test; set %s=1 to run it", envRealCloud)
+	}
+
+	cfg := config.Load(t)
+	if cfg.Credentials.AWS.RoleARN == "" {
+		t.Skipf("No role to assume; set %s", config.EnvAWSRoleARN)
+	}
+
+	provider, err := cfg.AWSCredentialsProvider("")
+	require.NoError(t, err)
+	_, ok := provider.(*stscreds.AssumeRoleProvider)
+	require.True(t, ok, "The configured role should be assumed, got %T", provider)
+
+	sess, err := cfg.AWSSession("", nil)
+	require.NoError(t, err)
+
+	identity, err := sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	require.NoError(t, err)
+	assert.Contains(t, *identity.Arn, config.AssumeRoleSessionName, "Calls should be made in the assumed role's session")
+
+	_, err = s3.New(sess).ListBuckets(&s3.ListBucketsInput{})
+	require.NoError(t, err, "The assumed role should be able to list buckets in %s", cfg.Region)
+}
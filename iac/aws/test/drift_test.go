@@ -0,0 +1,83 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/driftcheck"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuStorageDrift removes the bucket's tags and suspends its
+// versioning behind Terraform's back, then verifies the storage facade
+// detects both and restores them on the next apply
+func TestCloudEmuStorageDrift(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	bucketName := fmt.Sprintf("drift-bucket-%d", time.Now().Unix())
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: storageFixture(t),
+		VarFiles:     []string{"drift.tfvars"},
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"bucket_name": bucketName,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+
+	client := s3.New(newCloudEmuSession(t))
+
+	mutate := func() {
+		_, err := client.DeleteBucketTagging(&s3.DeleteBucketTaggingInput{Bucket: aws.String(bucketName)})
+		require.NoError(t, err, "Deleting the tags of %s should succeed", bucketName)
+
+		_, err = client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+			Bucket:                  aws.String(bucketName),
+			VersioningConfiguration: &s3.VersioningConfiguration{Status: aws.String(s3.BucketVersioningStatusSuspended)},
+		})
+		require.NoError(t, err, "Suspending versioning on %s should succeed", bucketName)
+	}
+
+	assertRepair := func(plan *driftcheck.Plan) {
+		bucket := plan.Change("module.storage.module.aws_storage[0].aws_s3_bucket.this")
+		if assert.NotNil(t, bucket, "The plan should list the bucket") {
+			assert.Equal(t, []string{"update"}, bucket.Change.Actions, "Tags should be restored in place")
+		}
+
+		versioning := plan.Change("module.storage.module.aws_storage[0].aws_s3_bucket_versioning.this[0]")
+		if assert.NotNil(t, versioning, "The plan should list the versioning configuration") {
+			assert.Equal(t, []string{"update"}, versioning.Change.Actions, "Versioning should be re-enabled in place")
+		}
+	}
+
+	concurrency.RunThrottled(t, func() {
+		driftcheck.DetectAndRepairDrift(t, terraformOptions, mutate, assertRepair)
+	})
+
+	tagging, err := client.GetBucketTagging(&s3.GetBucketTaggingInput{Bucket: aws.String(bucketName)})
+	require.NoError(t, err)
+	tags := map[string]string{}
+	for _, tag := range tagging.TagSet {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	assert.Equal(t, "drift", tags["Project"], "The Project tag should be restored")
+	assert.Equal(t, "swe-cloud", tags["ManagedBy"], "The ManagedBy tag should be restored")
+
+	versioning, err := client.GetBucketVersioning(&s3.GetBucketVersioningInput{Bucket: aws.String(bucketName)})
+	require.NoError(t, err)
+	assert.Equal(t, s3.BucketVersioningStatusEnabled, aws.StringValue(versioning.Status), "Versioning should be enabled again")
+}
@@ -0,0 +1,86 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"iac/testutil/artifacts"
+	"iac/testutil/concurrency"
+	"iac/testutil/driftscan"
+	"iac/testutil/planrisk"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuDriftScan deploys the storage fixture, removes the bucket's
+// tags through the SDK, and verifies a drift scan of the deployment
+// reports the bucket as changed outside Terraform and the plan as
+// restoring it in place
+func TestCloudEmuDriftScan(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	bucketName := fmt.Sprintf("driftscan-bucket-%d", time.Now().Unix())
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: storageFixture(t),
+		VarFiles:     []string{"drift.tfvars"},
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"bucket_name": bucketName,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	client := s3.New(newCloudEmuSession(t))
+	_, err := client.DeleteBucketTagging(&s3.DeleteBucketTaggingInput{Bucket: aws.String(bucketName)})
+	require.NoError(t, err, "Deleting the tags of %s should succeed", bucketName)
+
+	tf := driftscan.NewTerraform(terraformOptions.TerraformBinary, filepath.Join(artifacts.TempDir(t), "logs"))
+	tf.Retries = tfopts.Retries(terraformOptions)
+
+	modules := []driftscan.Module{{
+		Name:     "storage",
+		Path:     terraformOptions.TerraformDir,
+		VarFiles: []string{filepath.Join(terraformOptions.TerraformDir, "drift.tfvars")},
+		Vars:     terraformOptions.Vars,
+	}}
+	var results []driftscan.Result
+	concurrency.RunThrottled(t, func() {
+		results = driftscan.Scan(context.Background(), modules, 1, tf)
+	})
+
+	require.Len(t, results, 1)
+	result := results[0]
+	require.NoError(t, result.Err, "The scan should plan the module; see %s", tf.LogPath("storage"))
+	assert.Equal(t, driftscan.InPlaceDrift, result.Outcome, "Removed tags are restored in place")
+
+	const bucket = "module.storage.module.aws_storage[0].aws_s3_bucket.this"
+	assert.Contains(t, actions(result.Drift), "update "+bucket, "The refresh should find the bucket changed outside Terraform")
+	assert.Contains(t, actions(result.Changes), "update "+bucket, "The plan should restore the bucket's tags")
+
+	report := driftscan.NewReport(results, tf.LogPath)
+	assert.Equal(t, driftscan.ExitInPlaceDrift, report.ExitCode)
+}
+
+// actions returns each of changes as its action and address
+func actions(changes []planrisk.Change) []string {
+	var s []string
+	for _, c := range changes {
+		s = append(s, fmt.Sprintf("%s %s", c.Action, c.Address))
+	}
+	return s
+}
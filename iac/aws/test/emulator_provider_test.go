@@ -0,0 +1,89 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+	"iac/testutil/egress"
+	"iac/testutil/faultproxy"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emulatorProviderOptions returns options for a copy of
+// fixtures/emulator-provider, which configures the AWS provider with
+// nothing but the shared emulator_provider.tf, pointed straight at CloudEmu
+func emulatorProviderOptions(t *testing.T, name string) *terraform.Options {
+	return tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: test_structure.CopyTerraformFolderToTemp(t, "../..", "aws/test/fixtures/emulator-provider"),
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"name": name,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+}
+
+// TestCloudEmuEmulatorProvider applies a bucket, a queue and a caller
+// identity lookup through the shared emulator provider configuration, and
+// checks each came from CloudEmu
+func TestCloudEmuEmulatorProvider(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	name := fmt.Sprintf("test-emulator-provider-%d", time.Now().Unix())
+	terraformOptions := emulatorProviderOptions(t, name)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	assert.NotEmpty(t, terraform.Output(t, terraformOptions, "account_id"), "sts should have answered from CloudEmu")
+	assert.Equal(t, name, terraform.Output(t, terraformOptions, "bucket_name"))
+	verifyS3BucketExists(t, name)
+
+	queueURL, err := url.Parse(terraform.Output(t, terraformOptions, "queue_url"))
+	require.NoError(t, err)
+	assert.NotContains(t, queueURL.Hostname(), "amazonaws.com", "The queue should live on the emulator")
+}
+
+// TestCloudEmuEmulatorProviderStaysLocal applies the same fixture with
+// every request that is not for the emulator sent to an egress canary, and
+// checks none was: an endpoint missing from the shared configuration, or a
+// metadata or checkpoint lookup, would reach the canary instead of the
+// internet. The emulator is reached through a pass-through fault proxy, to
+// show the apply did talk to it.
+func TestCloudEmuEmulatorProviderStaysLocal(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	cfg := config.Load(t)
+	proxy := faultproxy.Start(t, cfg.CloudEmuEndpoint, faultproxy.Scenario{Name: "pass-through"})
+	canary := egress.Start(t)
+
+	direct := emulatorProviderOptions(t, fmt.Sprintf("test-emulator-local-%d", time.Now().Unix()))
+	defer concurrency.Destroy(t, direct)
+
+	// init downloads the provider from the registry, so it runs without
+	// the canary
+	concurrency.Init(t, direct)
+
+	local := throughProxy(t, direct, proxy.URL)
+	local.EnvVars = canary.EnvVars(cfg.CloudEmuEndpoint)
+	concurrency.RunThrottled(t, func() {
+		tfopts.Apply(t, local)
+	})
+
+	assert.Empty(t, canary.Hosts(), "No request should leave for anywhere but the emulator")
+	assert.Positive(t, proxy.Stats().Requests, "The apply should have reached the emulator")
+}
@@ -0,0 +1,71 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/eventually"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuEventBusLambdaTarget puts an event on a bus whose rule targets
+// a Lambda function, then waits for the function to log it
+func TestCloudEmuEventBusLambdaTarget(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	suffix := time.Now().Unix()
+	busName := fmt.Sprintf("eventbus-%d", suffix)
+	functionName := fmt.Sprintf("eventbus-fn-%d", suffix)
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/eventbus",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"bus_name":      busName,
+			"function_name": functionName,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+
+	concurrency.InitAndApply(t, terraformOptions)
+	verifyLambdaFunctionExists(t, functionName)
+	require.Contains(t, terraform.OutputMap(t, terraformOptions, "rule_ids"), "orders")
+
+	marker := fmt.Sprintf("order-%d", suffix)
+	detail := fmt.Sprintf(`{"order_id": %q}`, marker)
+	entries := fmt.Sprintf(`[{"EventBusName": %q, "Source": "orders", "DetailType": "OrderPlaced", "Detail": %q}]`, busName, detail)
+
+	output, err := runAWS(t, "events", "put-events", "--entries", entries)
+	require.NoError(t, err)
+	var put struct {
+		FailedEntryCount int
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &put))
+	require.Zero(t, put.FailedEntryCount, "Bus should accept the event: %s", output)
+
+	// The rule delivers asynchronously; the handler prints each event it gets
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		output, err := runAWS(t, "logs", "filter-log-events",
+			"--log-group-name", "/aws/lambda/"+functionName,
+			"--filter-pattern", fmt.Sprintf("%q", marker))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, marker) {
+			return fmt.Errorf("function %s has not logged event %s yet", functionName, marker)
+		}
+		return nil
+	})
+}
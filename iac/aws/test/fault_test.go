@@ -0,0 +1,146 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+	"iac/testutil/faultproxy"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// faultRetryBudget bounds an apply against an endpoint that always fails:
+// the provider's retries (max_retries in faults.tfvars) and terratest's,
+// with room to spare. An apply still running after it is hanging.
+const faultRetryBudget = 3 * time.Minute
+
+// TestCloudEmuStorageFacadeTransientFaults applies the storage facade
+// through a proxy that answers 20% of requests with 503 SlowDown, and
+// checks the retries get the apply through
+func TestCloudEmuStorageFacadeTransientFaults(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	cfg := config.Load(t)
+	proxy := faultproxy.Start(t, cfg.CloudEmuEndpoint, faultproxy.Scenario{
+		Name:            "transient 503s",
+		SlowDownPercent: 20,
+	})
+
+	bucketName := fmt.Sprintf("test-bucket-faults-%d", time.Now().Unix())
+	direct := faultOptions(t, bucketName)
+	// Clean up without the faults, so a failed destroy means a real problem
+	defer concurrency.Destroy(t, direct)
+
+	concurrency.InitAndApply(t, throughProxy(t, direct, proxy.URL))
+
+	verifyS3BucketExists(t, bucketName)
+	assert.Positive(t, proxy.Stats().Faults[faultproxy.SlowDown], "The apply should have been sent a 503")
+}
+
+// TestCloudEmuStorageFacadePersistentFaults applies the storage facade
+// through a proxy that answers every request with 500 InternalError, and
+// checks the apply fails once its retries are spent rather than hanging
+func TestCloudEmuStorageFacadePersistentFaults(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	cfg := config.Load(t)
+	proxy := faultproxy.Start(t, cfg.CloudEmuEndpoint, faultproxy.Scenario{
+		Name:                 "persistent 500s",
+		InternalErrorPercent: 100,
+	})
+
+	direct := faultOptions(t, fmt.Sprintf("test-bucket-faults-%d", time.Now().Unix()))
+	defer concurrency.Destroy(t, direct)
+
+	options := throughProxy(t, direct, proxy.URL)
+	concurrency.Init(t, options)
+
+	var err error
+	start := time.Now()
+	concurrency.RunThrottled(t, func() {
+		_, err = tfopts.ApplyE(t, options)
+	})
+	elapsed := time.Since(start)
+
+	require.Error(t, err, "Apply should fail when every request gets a 500")
+	assert.Contains(t, err.Error(), "InternalError")
+	assert.Less(t, elapsed, faultRetryBudget, "Apply should give up within the retry budget")
+	assert.Greater(t, proxy.Stats().Requests, 1, "The provider should have retried")
+	t.Logf("Apply failed after %s and %d requests", elapsed.Round(time.Second), proxy.Stats().Requests)
+}
+
+// faultWarmup is how long TestCloudEmuStorageFacadeWarmup's proxy resets
+// every connection: longer than the provider's retries of one request last
+// with faults.tfvars, so only retrying the apply gets through
+const faultWarmup = 20 * time.Second
+
+// TestCloudEmuStorageFacadeWarmup applies the storage facade through a
+// proxy that resets every connection for faultWarmup, as CloudEmu's
+// published port does while the server behind it starts, and checks that
+// the retries of WithEmulatorRetries get the apply through once it is up
+func TestCloudEmuStorageFacadeWarmup(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	bucketName := fmt.Sprintf("test-bucket-warmup-%d", time.Now().Unix())
+	direct := faultOptions(t, bucketName)
+	defer concurrency.Destroy(t, direct)
+
+	// Init talks to no endpoint; the warmup starts with the apply, once
+	// it holds its slot
+	concurrency.Init(t, direct)
+
+	cfg := config.Load(t)
+	var proxy *faultproxy.Server
+	var elapsed time.Duration
+	concurrency.RunThrottled(t, func() {
+		proxy = faultproxy.Start(t, cfg.CloudEmuEndpoint, faultproxy.Scenario{
+			Name:   "warmup",
+			Warmup: faultWarmup,
+		})
+		start := time.Now()
+		tfopts.Apply(t, throughProxy(t, direct, proxy.URL))
+		elapsed = time.Since(start)
+	})
+
+	verifyS3BucketExists(t, bucketName)
+	assert.Positive(t, proxy.Stats().Faults[faultproxy.Reset], "The apply should have started during the warmup")
+	assert.Greater(t, elapsed, faultWarmup, "The apply should have been retried until the warmup was over")
+}
+
+// faultOptions returns options for a copy of fixtures/storage with
+// faults.tfvars, pointed straight at CloudEmu
+func faultOptions(t *testing.T, bucketName string) *terraform.Options {
+	return tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: storageFixture(t),
+		VarFiles:     []string{"faults.tfvars"},
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"bucket_name": bucketName,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+}
+
+// throughProxy returns a copy of options, sharing its directory and state,
+// that sends the provider's requests to proxyURL instead
+func throughProxy(t *testing.T, options *terraform.Options, proxyURL string) *terraform.Options {
+	t.Helper()
+
+	proxied, err := options.Clone()
+	require.NoError(t, err)
+	proxied.Vars["emulator_endpoint"] = proxyURL
+	return proxied
+}
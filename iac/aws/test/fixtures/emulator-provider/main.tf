@@ -0,0 +1,59 @@
+# Emulator provider fixture
+#
+# The least that exercises the shared emulator_provider.tf: the caller
+# identity, which goes to sts, a bucket and a queue. Every request the
+# apply makes has to reach the emulator for it to succeed.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "name" {
+  description = "Name of the bucket and queue under test"
+  type        = string
+}
+
+data "aws_caller_identity" "current" {}
+
+resource "aws_s3_bucket" "this" {
+  bucket        = var.name
+  force_destroy = true
+}
+
+resource "aws_sqs_queue" "this" {
+  name = var.name
+}
+
+output "account_id" {
+  description = "Account the emulator reports for the test credentials"
+  value       = data.aws_caller_identity.current.account_id
+}
+
+output "bucket_name" {
+  description = "Name of the bucket"
+  value       = aws_s3_bucket.this.bucket
+}
+
+output "queue_url" {
+  description = "URL of the queue, on the emulator's host"
+  value       = aws_sqs_queue.this.url
+}
@@ -0,0 +1,7 @@
+import json
+
+
+def handler(event, context):
+    # The test finds the event's detail in the function's log stream
+    print(json.dumps(event))
+    return {"statusCode": 200}
@@ -0,0 +1,76 @@
+# Event bus fixture
+#
+# Deploys the Event Bus facade against CloudEmu with one rule routing order
+# events to a Lambda function that logs every event it receives.
+
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "bus_name" {
+  description = "Name of the bus under test"
+  type        = string
+}
+
+variable "function_name" {
+  description = "Name of the target function"
+  type        = string
+}
+
+module "lambda" {
+  source = "../../../../facade/lambda"
+
+  provider_name = "aws"
+  project_name  = "eventbus-test"
+  function_name = var.function_name
+  runtime       = "python3.11"
+  handler       = "index.handler"
+  source_dir    = "${path.module}/handler"
+}
+
+module "eventbus" {
+  source = "../../../../facade/eventbus"
+
+  provider_name = "aws"
+  project_name  = "eventbus-test"
+  environment   = "local"
+  bus_name      = var.bus_name
+
+  rules = [
+    {
+      name    = "orders"
+      pattern = jsonencode({ source = ["orders"], detail-type = ["OrderPlaced"] })
+      target_ref = {
+        type = "lambda"
+        id   = module.lambda.function_arn
+      }
+    },
+  ]
+}
+
+output "bus_arn" {
+  value = module.eventbus.bus_arn
+}
+
+output "rule_ids" {
+  value = module.eventbus.rule_ids
+}
@@ -0,0 +1,45 @@
+# NoSQL import fixture
+#
+# The NoSQL facade against CloudEmu, describing a PAY_PER_REQUEST table keyed
+# on a string "id" so a table created outside Terraform can be imported and
+# planned clean.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "table_name" {
+  description = "Name of the existing table to adopt"
+  type        = string
+}
+
+module "nosql_table" {
+  source = "../../../../facade/nosql"
+
+  provider_name = "aws"
+  project_name  = "adopt"
+  environment   = "local"
+  table_name    = var.table_name
+
+  hash_key      = "id"
+  hash_key_type = "S"
+}
@@ -0,0 +1,74 @@
+# Lambda canary fixture
+#
+# Publishes versions behind a "live" alias against CloudEmu and optionally
+# shifts part of the alias traffic to the newest version.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "function_name" {
+  description = "Name of the function under test"
+  type        = string
+}
+
+variable "source_dir" {
+  description = "Directory containing the handler source"
+  type        = string
+}
+
+variable "canary_weight" {
+  description = "Share of alias traffic for the newest version"
+  type        = number
+  default     = null
+}
+
+variable "stable_version" {
+  description = "Version keeping the remaining alias traffic"
+  type        = string
+  default     = null
+}
+
+module "lambda" {
+  source = "../../../../facade/lambda"
+
+  provider_name   = "aws"
+  project_name    = "canary-test"
+  function_name   = var.function_name
+  runtime         = "python3.11"
+  handler         = "index.handler"
+  source_dir      = var.source_dir
+  source_excludes = ["__pycache__", "README.md"]
+
+  publish        = true
+  alias_name     = "live"
+  canary_weight  = var.canary_weight
+  stable_version = var.stable_version
+}
+
+output "function_name" {
+  value = module.lambda.function_name
+}
+
+output "alias_arn" {
+  value = module.lambda.alias_arn
+}
@@ -0,0 +1,64 @@
+# Lambda concurrency fixture
+#
+# Deploys a function that holds its execution for a few seconds, with
+# reserved concurrency, against CloudEmu.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "function_name" {
+  description = "Name of the function under test"
+  type        = string
+}
+
+variable "reserved_concurrency" {
+  description = "Concurrent executions reserved for the function"
+  type        = number
+}
+
+module "lambda" {
+  source = "../../../../facade/lambda"
+
+  provider_name   = "aws"
+  project_name    = "concurrency-test"
+  function_name   = var.function_name
+  runtime         = "python3.11"
+  handler         = "index.handler"
+  timeout_seconds = 30
+
+  # Long enough for every concurrent invocation to arrive while the first
+  # still holds the execution
+  source_code = <<-EOT
+    import time
+
+    def handler(event, context):
+        time.sleep(5)
+        return {"statusCode": 200, "body": "done"}
+  EOT
+
+  reserved_concurrency = var.reserved_concurrency
+}
+
+output "function_name" {
+  value = module.lambda.function_name
+}
@@ -0,0 +1,52 @@
+# Lambda function URL fixture
+#
+# Deploys a public function URL through the Lambda facade against CloudEmu.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "function_name" {
+  description = "Name of the function under test"
+  type        = string
+}
+
+module "lambda" {
+  source = "../../../../facade/lambda"
+
+  provider_name        = "aws"
+  project_name         = "function-url-test"
+  function_name        = var.function_name
+  runtime              = "python3.11"
+  handler              = "index.handler"
+  enable_http_endpoint = true
+  http_auth_type       = "NONE"
+
+  source_code = <<-EOT
+    def handler(event, context):
+        return {"statusCode": 200, "body": "hello from function url"}
+  EOT
+}
+
+output "invoke_url" {
+  value = module.lambda.invoke_url
+}
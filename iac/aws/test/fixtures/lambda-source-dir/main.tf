@@ -0,0 +1,57 @@
+# Lambda source_dir fixture
+#
+# Deploys the Lambda facade against CloudEmu, packaging the handler from a
+# local directory supplied by the integration test.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "function_name" {
+  description = "Name of the function under test"
+  type        = string
+}
+
+variable "source_dir" {
+  description = "Directory containing the handler source"
+  type        = string
+}
+
+module "lambda" {
+  source = "../../../../facade/lambda"
+
+  provider_name   = "aws"
+  project_name    = "source-dir-test"
+  function_name   = var.function_name
+  runtime         = "python3.11"
+  handler         = "index.handler"
+  source_dir      = var.source_dir
+  source_excludes = ["__pycache__", "README.md"]
+}
+
+output "function_name" {
+  value = module.lambda.function_name
+}
+
+output "function_arn" {
+  value = module.lambda.function_arn
+}
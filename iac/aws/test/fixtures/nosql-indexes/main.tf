@@ -0,0 +1,68 @@
+# NoSQL indexes fixture
+#
+# A DynamoDB table from the database facade against CloudEmu with a global
+# secondary index by customer, ordered by creation time, and a stream, for
+# seeding items and querying the index through the SDK.
+
+terraform {
+  required_version = ">= 1.9"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "table_name" {
+  description = "Name of the table under test"
+  type        = string
+}
+
+module "table" {
+  source = "../../../../facade/database"
+
+  provider_name = "aws"
+  identifier    = var.table_name
+  engine_type   = "nosql"
+  hash_key      = "order_id"
+  project_name  = "indexes"
+  environment   = "local"
+
+  global_secondary_indexes = [
+    {
+      name           = "by-customer"
+      hash_key       = "customer_id"
+      range_key      = "created_at"
+      range_key_type = "N"
+    },
+    {
+      name               = "by-status"
+      hash_key           = "status"
+      projection         = "INCLUDE"
+      non_key_attributes = ["total"]
+    },
+  ]
+  enable_streams = true
+}
+
+output "table_name" {
+  value = module.table.nosql_table.name
+}
+
+output "stream_arn" {
+  value = module.table.stream_arn
+}
@@ -0,0 +1,54 @@
+# NoSQL TTL fixture
+#
+# A DynamoDB table from the NoSQL facade against CloudEmu with TTL on
+# expires_at, for checking the TTL configuration and conditional writes
+# through the SDK.
+
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "table_name" {
+  description = "Name of the table under test"
+  type        = string
+}
+
+variable "ttl_attribute" {
+  description = "Attribute holding each item's expiry as epoch seconds"
+  type        = string
+  default     = "expires_at"
+}
+
+module "table" {
+  source = "../../../../facade/nosql"
+
+  provider_name = "aws"
+  table_name    = var.table_name
+  hash_key      = "id"
+  ttl_attribute = var.ttl_attribute
+  project_name  = "ttl"
+  environment   = "local"
+}
+
+output "table_name" {
+  value = module.table.table_id
+}
@@ -0,0 +1,24 @@
+import json
+import os
+
+import boto3
+
+# boto3 sends every call to AWS_ENDPOINT_URL, which the fixture points at
+# CloudEmu
+dynamodb = boto3.client("dynamodb")
+s3 = boto3.client("s3")
+
+
+def handler(event, context):
+    # The queue trigger delivers a batch; each body is an order with an id
+    for record in event["Records"]:
+        order = json.loads(record["body"])
+        dynamodb.put_item(
+            TableName=os.environ["TABLE_NAME"],
+            Item={"id": {"S": order["id"]}, "body": {"S": record["body"]}},
+        )
+        s3.put_object(
+            Bucket=os.environ["BUCKET_NAME"],
+            Key=f"orders/{order['id']}.json",
+            Body=record["body"].encode(),
+        )
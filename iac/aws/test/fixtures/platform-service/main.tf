@@ -0,0 +1,82 @@
+# Platform service fixture
+#
+# Deploys the platform service module against CloudEmu with every feature
+# on. The handler stores each queued order in the table and the bucket.
+
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "service_name" {
+  description = "Name of the service under test"
+  type        = string
+}
+
+variable "function_endpoint" {
+  description = "CloudEmu endpoint as the function reaches it from inside the emulator; emulator_endpoint when null"
+  type        = string
+  default     = null
+}
+
+module "service" {
+  source = "../../../../platform/service"
+
+  service_name = var.service_name
+  environment  = "local"
+  provider     = "aws"
+  size         = "small"
+
+  features = {
+    network  = true
+    database = true
+    queue    = true
+    bucket   = true
+    alarms   = true
+  }
+
+  function = {
+    source_dir = "${path.module}/handler"
+    environment_variables = {
+      AWS_ENDPOINT_URL = coalesce(var.function_endpoint, var.emulator_endpoint)
+    }
+  }
+
+  provider_config = {
+    availability_zones = ["${var.aws_region}a", "${var.aws_region}b"]
+  }
+}
+
+output "function_name" {
+  value = module.service.function_name
+}
+
+output "queue_url" {
+  value = module.service.queue_url
+}
+
+output "table_name" {
+  value = module.service.table_name
+}
+
+output "bucket_name" {
+  value = module.service.bucket_name
+}
@@ -0,0 +1,54 @@
+# Queue visibility fixture
+#
+# A queue from the messaging facade against CloudEmu with a short visibility
+# timeout, so a test can watch a received message become invisible, reappear
+# and be deleted within seconds.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "queue_name" {
+  description = "Name of the queue under test"
+  type        = string
+}
+
+variable "visibility_timeout_seconds" {
+  description = "How long a received message stays hidden from other consumers"
+  type        = number
+  default     = 5
+}
+
+module "queue" {
+  source = "../../../../facade/messaging"
+
+  provider_name              = "aws"
+  name                       = var.queue_name
+  type                       = "queue"
+  project_name               = "visibility"
+  environment                = "local"
+  visibility_timeout_seconds = var.visibility_timeout_seconds
+}
+
+output "queue_url" {
+  value = module.queue.queue_url
+}
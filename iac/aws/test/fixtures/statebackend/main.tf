@@ -0,0 +1,66 @@
+# State backend fixture
+#
+# Bootstraps the State Backend facade against CloudEmu and writes the
+# backend.hcl the consumer fixture initializes with.
+
+terraform {
+  required_version = ">= 1.9"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "bucket_name" {
+  description = "Name of the state bucket under test"
+  type        = string
+}
+
+variable "backend_config_path" {
+  description = "Where the backend settings are written"
+  type        = string
+}
+
+module "statebackend" {
+  source = "../../../../facade/statebackend"
+
+  provider_name = "aws"
+  project_name  = "statebackend-test"
+  environment   = "local"
+  bucket_name   = var.bucket_name
+  force_destroy = true
+
+  write_backend_config = true
+  backend_config_path  = var.backend_config_path
+
+  provider_config = {
+    endpoint = var.emulator_endpoint
+  }
+}
+
+output "bucket_name" {
+  value = module.statebackend.bucket_name
+}
+
+output "lock_table_name" {
+  value = module.statebackend.lock_table_name
+}
+
+output "backend_config_file" {
+  value = module.statebackend.backend_config_file
+}
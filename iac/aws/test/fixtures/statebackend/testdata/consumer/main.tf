@@ -0,0 +1,31 @@
+# State backend consumer fixture
+#
+# Kept under testdata, as init without the written backend.hcl fails. A
+# trivial configuration keeping its state in the backend the statebackend
+# fixture bootstraps; the test passes the written backend.hcl to init. Each
+# apply replaces one resource whose provisioner holds the state lock for
+# hold_seconds.
+
+terraform {
+  required_version = ">= 1.5"
+
+  backend "s3" {}
+}
+
+variable "hold_seconds" {
+  description = "How long the apply holds the state lock"
+  type        = number
+  default     = 0
+}
+
+resource "terraform_data" "this" {
+  triggers_replace = timestamp()
+
+  provisioner "local-exec" {
+    command = "sleep ${var.hold_seconds}"
+  }
+}
+
+output "applied_at" {
+  value = terraform_data.this.id
+}
@@ -0,0 +1,110 @@
+# Storage notifications fixture
+#
+# A bucket from the storage facade that notifies a function, a queue and a
+# topic from the lambda and messaging facades, against CloudEmu, so a test
+# can read the bucket's notification configuration back.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "name" {
+  description = "Name of the bucket, and prefix of the function, queue and topic"
+  type        = string
+}
+
+module "function" {
+  source = "../../../../facade/lambda"
+
+  provider_name = "aws"
+  project_name  = "notifications-test"
+  function_name = "${var.name}-ingest"
+  runtime       = "python3.11"
+  handler       = "index.handler"
+  source_code   = <<-EOT
+    def handler(event, context):
+        return {"statusCode": 200}
+  EOT
+}
+
+module "queue" {
+  source = "../../../../facade/messaging"
+
+  provider_name = "aws"
+  name          = "${var.name}-deletions"
+  type          = "queue"
+  project_name  = "notifications-test"
+  environment   = "local"
+}
+
+module "topic" {
+  source = "../../../../facade/messaging"
+
+  provider_name = "aws"
+  name          = "${var.name}-reports"
+  type          = "topic"
+  project_name  = "notifications-test"
+  environment   = "local"
+}
+
+module "bucket" {
+  source = "../../../../facade/storage"
+
+  provider_name = "aws"
+  bucket_name   = var.name
+  project_name  = "notifications-test"
+  environment   = "local"
+
+  notifications = [
+    {
+      events        = ["created"]
+      filter_prefix = "uploads/"
+      filter_suffix = ".csv"
+      target_ref    = module.function.event_target_ref
+    },
+    {
+      events     = ["deleted"]
+      target_ref = module.queue.event_target_ref
+    },
+    {
+      events        = ["created"]
+      filter_prefix = "reports/"
+      target_ref    = module.topic.event_target_ref
+    },
+  ]
+}
+
+output "bucket_name" {
+  value = var.name
+}
+
+output "function_arn" {
+  value = module.function.function_arn
+}
+
+output "queue_arn" {
+  value = module.queue.queue_id
+}
+
+output "topic_arn" {
+  value = module.topic.topic_arn
+}
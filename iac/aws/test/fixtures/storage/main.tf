@@ -0,0 +1,99 @@
+# Storage fixture
+#
+# The storage facade against CloudEmu. Each test picks its scenario with a
+# tfvars file next to this one:
+#
+#   drift.tfvars      versioning on, so drift on it can be detected
+#   import.tfvars     only the bucket itself, so an existing bucket imports
+#   stress.tfvars     the facade's default bucket settings
+#   multipart.tfvars  the facade's default bucket settings
+#   faults.tfvars     the facade's default bucket settings, with few retries
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+# Not the shared emulator_provider.tf: faults.tfvars lowers max_retries,
+# which the shared block leaves at the provider's default
+provider "aws" {
+  region = var.aws_region
+
+  endpoints {
+    s3  = var.emulator_endpoint
+    sts = var.emulator_endpoint
+  }
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+  s3_use_path_style           = true
+  max_retries                 = var.max_retries
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "max_retries" {
+  description = "Retries the AWS provider makes per API call, or null for its default"
+  type        = number
+  default     = null
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "bucket_name" {
+  description = "Name of the bucket under test"
+  type        = string
+}
+
+variable "project_name" {
+  description = "Project name passed to the facade"
+  type        = string
+}
+
+variable "versioning_enabled" {
+  description = "Enable object versioning on the bucket"
+  type        = bool
+  default     = false
+}
+
+variable "encryption_enabled" {
+  description = "Enable encryption at rest on the bucket"
+  type        = bool
+  default     = true
+}
+
+variable "allow_public_access" {
+  description = "Let the bucket be made public, skipping its public access block"
+  type        = bool
+  default     = false
+}
+
+module "storage" {
+  source = "../../../../facade/storage"
+
+  provider_name       = "aws"
+  project_name        = var.project_name
+  environment         = "local"
+  bucket_name         = var.bucket_name
+  versioning_enabled  = var.versioning_enabled
+  encryption_enabled  = var.encryption_enabled
+  allow_public_access = var.allow_public_access
+}
@@ -0,0 +1,97 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/importcheck"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuImportStorage creates a bucket outside Terraform and verifies
+// the storage facade adopts it with terraform import and no change beyond
+// the facade's tags
+func TestCloudEmuImportStorage(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	bucketName := fmt.Sprintf("adopted-bucket-%d", time.Now().Unix())
+
+	client := s3.New(newCloudEmuSession(t))
+	_, err := client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(bucketName)})
+	require.NoError(t, err, "Creating bucket %s should succeed", bucketName)
+
+	// Removes the bucket if the import fails; after a successful import the
+	// destroy below already has
+	t.Cleanup(func() {
+		client.DeleteBucket(&s3.DeleteBucketInput{Bucket: aws.String(bucketName)})
+	})
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: storageFixture(t),
+		VarFiles:     []string{"import.tfvars"},
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"bucket_name": bucketName,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+
+	concurrency.RunThrottled(t, func() {
+		importcheck.ImportAndAssertClean(t, terraformOptions, "module.storage.module.aws_storage[0].aws_s3_bucket.this", bucketName)
+	})
+}
+
+// TestCloudEmuImportNoSQL creates a DynamoDB table outside Terraform and
+// verifies the NoSQL facade adopts it with terraform import and no change
+// beyond the facade's tags
+func TestCloudEmuImportNoSQL(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	tableName := fmt.Sprintf("adopted-table-%d", time.Now().Unix())
+
+	client := dynamodb.New(newCloudEmuSession(t))
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		TableName:   aws.String(tableName),
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+		},
+	})
+	require.NoError(t, err, "Creating table %s should succeed", tableName)
+
+	t.Cleanup(func() {
+		client.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(tableName)})
+	})
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/import-nosql",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"table_name": tableName,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+
+	concurrency.RunThrottled(t, func() {
+		importcheck.ImportAndAssertClean(t, terraformOptions, "module.nosql_table.module.aws_nosql[0].aws_dynamodb_table.this", tableName)
+	})
+}
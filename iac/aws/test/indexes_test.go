@@ -0,0 +1,100 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"iac/testutil/compat"
+	"iac/testutil/concurrency"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuDynamoDBIndexes deploys a table with two global secondary
+// indexes through the database facade, checks DescribeTable reports them
+// and the stream, then seeds orders for two customers and queries the
+// by-customer index for one of them, newest first.
+func TestCloudEmuDynamoDBIndexes(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+	compat.RequireEmulatorSupport(t, "dynamodb.GlobalSecondaryIndex")
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/nosql-indexes",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"table_name": fmt.Sprintf("test-indexes-%d", time.Now().Unix()),
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	tableName := terraform.Output(t, terraformOptions, "table_name")
+	client := dynamodb.New(newCloudEmuSession(t))
+
+	t.Run("describe", func(t *testing.T) {
+		out, err := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+		require.NoError(t, err)
+
+		var names []string
+		for _, gsi := range out.Table.GlobalSecondaryIndexes {
+			names = append(names, aws.StringValue(gsi.IndexName))
+		}
+		assert.ElementsMatch(t, []string{"by-customer", "by-status"}, names)
+
+		require.NotNil(t, out.Table.StreamSpecification, "enable_streams should turn the table's stream on")
+		assert.True(t, aws.BoolValue(out.Table.StreamSpecification.StreamEnabled))
+		assert.Equal(t, dynamodb.StreamViewTypeNewAndOldImages, aws.StringValue(out.Table.StreamSpecification.StreamViewType))
+		assert.Equal(t, aws.StringValue(out.Table.LatestStreamArn), terraform.Output(t, terraformOptions, "stream_arn"))
+	})
+
+	t.Run("query", func(t *testing.T) {
+		orders := []struct {
+			id, customer string
+			createdAt    int
+		}{
+			{"order-1", "alice", 100},
+			{"order-2", "bob", 200},
+			{"order-3", "alice", 300},
+			{"order-4", "alice", 200},
+		}
+		for _, order := range orders {
+			_, err := client.PutItem(&dynamodb.PutItemInput{
+				TableName: aws.String(tableName),
+				Item: map[string]*dynamodb.AttributeValue{
+					"order_id":    {S: aws.String(order.id)},
+					"customer_id": {S: aws.String(order.customer)},
+					"created_at":  {N: aws.String(strconv.Itoa(order.createdAt))},
+					"status":      {S: aws.String("open")},
+				},
+			})
+			require.NoError(t, err, "Seeding %s", order.id)
+		}
+
+		out, err := client.Query(&dynamodb.QueryInput{
+			TableName:                 aws.String(tableName),
+			IndexName:                 aws.String("by-customer"),
+			KeyConditionExpression:    aws.String("customer_id = :customer AND created_at >= :since"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":customer": {S: aws.String("alice")}, ":since": {N: aws.String("200")}},
+			ScanIndexForward:          aws.Bool(false),
+		})
+		require.NoError(t, err)
+
+		var ids []string
+		for _, item := range out.Items {
+			ids = append(ids, aws.StringValue(item["order_id"].S))
+		}
+		assert.Equal(t, []string{"order-3", "order-4"}, ids, "The index should return alice's orders since 200, newest first")
+	})
+}
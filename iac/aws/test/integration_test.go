@@ -1,23 +1,33 @@
+//go:build integration
+
 package test
 
 import (
+	"encoding/json"
 	"fmt"
-	"net/http"
-	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"iac/testutil/artifacts"
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+	"iac/testutil/eventually"
+	"iac/testutil/httprecord"
+	"iac/testutil/integration"
+	"iac/testutil/smoke"
+	"iac/testutil/stateinspect"
+	"iac/testutil/tfopts"
+	"iac/testutil/tfout"
+
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-const (
-	cloudEmuEndpoint = "http://localhost:4566"
-	healthCheckPath  = "/health"
-)
-
 // TestCloudEmuStorageFacade tests the storage facade with CloudEmu
 func TestCloudEmuStorageFacade(t *testing.T) {
 	t.Parallel()
@@ -25,20 +35,20 @@ func TestCloudEmuStorageFacade(t *testing.T) {
 	// Ensure CloudEmu is running
 	ensureCloudEmuRunning(t)
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
 		TerraformDir: "../../examples/local-cloudemu",
-		Vars: map[string]interface{}{
-			"bucket_name":   fmt.Sprintf("test-bucket-%d", time.Now().Unix()),
-			"environment":   "test",
-		},
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"bucket_name": fmt.Sprintf("test-bucket-%d", time.Now().Unix()),
+			"environment": "local",
+		}),
 		NoColor: true,
-	})
+	}, tfopts.CloudEmu)
 
 	// Clean up resources
-	defer terraform.Destroy(t, terraformOptions)
+	defer concurrency.Destroy(t, terraformOptions)
 
 	// Deploy infrastructure
-	terraform.InitAndApply(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
 
 	// Verify outputs
 	bucketName := terraform.Output(t, terraformOptions, "bucket_name")
@@ -61,17 +71,17 @@ func TestCloudEmuDatabaseFacade(t *testing.T) {
 
 	ensureCloudEmuRunning(t)
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
 		TerraformDir: "../../examples/local-cloudemu",
-		Vars: map[string]interface{}{
+		Vars: cloudEmuVars(t, map[string]interface{}{
 			"database_name": fmt.Sprintf("test-table-%d", time.Now().Unix()),
-			"environment":   "test",
-		},
+			"environment":   "local",
+		}),
 		NoColor: true,
-	})
+	}, tfopts.CloudEmu)
 
-	defer terraform.Destroy(t, terraformOptions)
-	terraform.InitAndApply(t, terraformOptions)
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
 
 	tableName := terraform.Output(t, terraformOptions, "table_name")
 	assert.NotEmpty(t, tableName)
@@ -90,18 +100,18 @@ func TestCloudEmuMessagingFacade(t *testing.T) {
 
 	ensureCloudEmuRunning(t)
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
 		TerraformDir: "../../examples/local-cloudemu",
-		Vars: map[string]interface{}{
+		Vars: cloudEmuVars(t, map[string]interface{}{
 			"queue_name":  fmt.Sprintf("test-queue-%d", time.Now().Unix()),
 			"topic_name":  fmt.Sprintf("test-topic-%d", time.Now().Unix()),
-			"environment": "test",
-		},
+			"environment": "local",
+		}),
 		NoColor: true,
-	})
+	}, tfopts.CloudEmu)
 
-	defer terraform.Destroy(t, terraformOptions)
-	terraform.InitAndApply(t, terraformOptions)
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
 
 	queueURL := terraform.Output(t, terraformOptions, "queue_url")
 	assert.NotEmpty(t, queueURL)
@@ -117,6 +127,19 @@ func TestCloudEmuMessagingFacade(t *testing.T) {
 	testSNSPublish(t, topicARN)
 }
 
+// FullStackOutputs are the outputs of examples/local-cloudemu
+type FullStackOutputs struct {
+	BucketName           string            `tfout:"bucket_name"`
+	BucketARN            string            `tfout:"bucket_arn"`
+	TableName            string            `tfout:"table_name"`
+	TableARN             string            `tfout:"table_arn"`
+	QueueURL             string            `tfout:"queue_url"`
+	TopicARN             string            `tfout:"topic_arn"`
+	FunctionName         string            `tfout:"function_name"`
+	FunctionARN          string            `tfout:"function_arn"`
+	VerificationCommands map[string]string `tfout:"verification_commands"`
+}
+
 // TestCloudEmuFullStack tests deploying all services together
 func TestCloudEmuFullStack(t *testing.T) {
 	t.Parallel()
@@ -124,129 +147,205 @@ func TestCloudEmuFullStack(t *testing.T) {
 	ensureCloudEmuRunning(t)
 
 	timestamp := time.Now().Unix()
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
 		TerraformDir: "../../examples/local-cloudemu",
-		Vars: map[string]interface{}{
+		Vars: cloudEmuVars(t, map[string]interface{}{
 			"bucket_name":   fmt.Sprintf("fullstack-bucket-%d", timestamp),
 			"database_name": fmt.Sprintf("fullstack-table-%d", timestamp),
 			"queue_name":    fmt.Sprintf("fullstack-queue-%d", timestamp),
 			"topic_name":    fmt.Sprintf("fullstack-topic-%d", timestamp),
 			"function_name": fmt.Sprintf("fullstack-fn-%d", timestamp),
-			"environment":   "test",
-		},
+			"environment":   "local",
+		}),
 		NoColor: true,
-	})
+	}, tfopts.CloudEmu)
 
-	defer terraform.Destroy(t, terraformOptions)
-	terraform.InitAndApply(t, terraformOptions)
+	defer concurrency.Destroy(t, terraformOptions)
+	// Deploys every facade at once, so it counts double against
+	// SWE_TEST_MAX_PARALLEL
+	concurrency.RunWeighted(t, 2, func() {
+		concurrency.InitAndApply(t, terraformOptions)
+	})
 
 	// Verify all resources created
-	bucketName := terraform.Output(t, terraformOptions, "bucket_name")
-	tableName := terraform.Output(t, terraformOptions, "table_name")
-	queueURL := terraform.Output(t, terraformOptions, "queue_url")
-	topicARN := terraform.Output(t, terraformOptions, "topic_arn")
-	functionName := terraform.Output(t, terraformOptions, "function_name")
-
-	assert.NotEmpty(t, bucketName)
-	assert.NotEmpty(t, tableName)
-	assert.NotEmpty(t, queueURL)
-	assert.NotEmpty(t, topicARN)
-	assert.NotEmpty(t, functionName)
+	outputs := tfout.OutputsAs[FullStackOutputs](t, terraformOptions)
+
+	assert.Equal(t, fmt.Sprintf("fullstack-bucket-%d", timestamp), outputs.BucketName)
+	assert.Equal(t, fmt.Sprintf("fullstack-table-%d", timestamp), outputs.TableName)
+	assert.Contains(t, outputs.QueueURL, fmt.Sprintf("fullstack-queue-%d", timestamp))
+	assert.Contains(t, outputs.TopicARN, fmt.Sprintf("fullstack-topic-%d", timestamp))
+	assert.Equal(t, fmt.Sprintf("fullstack-fn-%d", timestamp), outputs.FunctionName)
+	assert.NotEmpty(t, outputs.BucketARN)
+	assert.NotEmpty(t, outputs.TableARN)
+	assert.NotEmpty(t, outputs.FunctionARN)
+	assert.Len(t, outputs.VerificationCommands, 5)
 
 	// Verify resources exist in CloudEmu
-	verifyS3BucketExists(t, bucketName)
-	verifyDynamoDBTableExists(t, tableName)
-	verifySQSQueueExists(t, queueURL)
-	verifySNSTopicExists(t, topicARN)
-	verifyLambdaFunctionExists(t, functionName)
+	verifyS3BucketExists(t, outputs.BucketName)
+	verifyDynamoDBTableExists(t, outputs.TableName)
+	verifySQSQueueExists(t, outputs.QueueURL)
+	verifySNSTopicExists(t, outputs.TopicARN)
+	verifyLambdaFunctionExists(t, outputs.FunctionName)
+
+	// Attributes the example does not export, read from state
+	state := stateinspect.Read(t, terraformOptions)
+	state.AssertNoTainted(t)
+
+	bucket, err := state.ResourceByAddress("module.storage.module.aws_storage[0].aws_s3_bucket.this")
+	require.NoError(t, err)
+	bucketTags, err := bucket.AttributeMap("tags")
+	require.NoError(t, err)
+	assert.Equal(t, "local-test", bucketTags["Project"])
+	assert.Equal(t, "local", bucketTags["Environment"])
+	assert.Equal(t, "swe-cloud", bucketTags["ManagedBy"])
+	assert.Equal(t, "aws", bucketTags["Provider"])
+
+	versioning, err := state.ResourceByAddress("module.storage.module.aws_storage[0].aws_s3_bucket_versioning.this[0]")
+	require.NoError(t, err)
+	versioningStatus, err := versioning.AttributeString("versioning_configuration", "0", "status")
+	require.NoError(t, err)
+	assert.Equal(t, "Enabled", versioningStatus, "The example enables versioning on the bucket")
+
+	table, err := state.ResourceByAddress("module.nosql_table.module.aws_nosql[0].aws_dynamodb_table.this")
+	require.NoError(t, err)
+	billingMode, err := table.AttributeString("billing_mode")
+	require.NoError(t, err)
+	assert.Equal(t, "PAY_PER_REQUEST", billingMode, "The nosql facade provisions on-demand tables")
 
 	t.Log("✓ Full stack deployment successful")
 }
 
 // Helper Functions
 
+// ensureCloudEmuRunning skips the test, or fails it under
+// SWE_REQUIRE_INTEGRATION, unless every AWS service CloudEmu serves answers
 func ensureCloudEmuRunning(t *testing.T) {
-	client := &http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get(cloudEmuEndpoint + healthCheckPath)
-	
-	if err != nil || resp.StatusCode != 200 {
-		t.Skip("CloudEmu not running. Start with: cd cloudemu && cargo run --release -p cloudemu-server")
+	t.Helper()
+
+	cfg := config.Load(t)
+	integration.Require(t, "CloudEmu", integration.StartCloudEmu, smoke.Probe(cfg, smoke.ForProvider("aws")...))
+}
+
+// awsCommand runs the AWS CLI against the configured CloudEmu endpoint
+func awsCommand(t *testing.T, args ...string) *exec.Cmd {
+	cfg := config.Load(t)
+	cmdArgs := []string{"--endpoint-url", cfg.CloudEmuEndpoint, "--region", cfg.Region}
+	if cfg.CredentialsProfile != "" {
+		cmdArgs = append(cmdArgs, "--profile", cfg.CredentialsProfile)
 	}
-	
-	t.Log("✓ CloudEmu is running")
+	return exec.Command("aws", append(cmdArgs, args...)...)
 }
 
-func awsCommand(args ...string) *exec.Cmd {
-	cmdArgs := append([]string{"--endpoint-url", cloudEmuEndpoint}, args...)
-	return exec.Command("aws", cmdArgs...)
+// cloudEmuVars returns the Terraform variables that point a CloudEmu
+// fixture at the configured endpoint and region. With SWE_RECORD_HTTP=1 the
+// endpoint is a proxy recording the providers' requests.
+func cloudEmuVars(t *testing.T, vars map[string]interface{}) map[string]interface{} {
+	cfg := config.Load(t)
+	vars["emulator_endpoint"] = httprecord.Endpoint(t, cfg.CloudEmuEndpoint)
+	vars["aws_region"] = cfg.Region
+	return vars
+}
+
+// storageFixture copies fixtures/storage into a temporary directory, so
+// tests sharing it keep their own state, and returns the copy
+func storageFixture(t *testing.T) string {
+	return test_structure.CopyTerraformFolderToTemp(t, "../..", "aws/test/fixtures/storage")
+}
+
+// runAWS runs an AWS CLI command, folding its output into the error so
+// retried attempts report what the emulator said
+func runAWS(t *testing.T, args ...string) (string, error) {
+	output, err := awsCommand(t, args...).CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("aws %s: %w: %s", strings.Join(args[:2], " "), err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
 }
 
 func verifyS3BucketExists(t *testing.T, bucketName string) {
-	cmd := awsCommand("s3", "ls", "s3://"+bucketName)
-	output, err := cmd.CombinedOutput()
-	require.NoError(t, err, "Bucket %s should exist. Output: %s", bucketName, string(output))
+	// A bucket listed right after apply can briefly 404
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		_, err := runAWS(t, "s3", "ls", "s3://"+bucketName)
+		return err
+	})
 	t.Logf("✓ S3 bucket %s exists", bucketName)
 }
 
 func verifyDynamoDBTableExists(t *testing.T, tableName string) {
-	cmd := awsCommand("dynamodb", "describe-table", "--table-name", tableName)
+	cmd := awsCommand(t, "dynamodb", "describe-table", "--table-name", tableName)
 	output, err := cmd.CombinedOutput()
 	require.NoError(t, err, "Table %s should exist. Output: %s", tableName, string(output))
 	t.Logf("✓ DynamoDB table %s exists", tableName)
 }
 
 func verifySQSQueueExists(t *testing.T, queueURL string) {
-	cmd := awsCommand("sqs", "get-queue-attributes", "--queue-url", queueURL, "--attribute-names", "All")
+	cmd := awsCommand(t, "sqs", "get-queue-attributes", "--queue-url", queueURL, "--attribute-names", "All")
 	output, err := cmd.CombinedOutput()
 	require.NoError(t, err, "Queue %s should exist. Output: %s", queueURL, string(output))
 	t.Logf("✓ SQS queue exists at %s", queueURL)
 }
 
 func verifySNSTopicExists(t *testing.T, topicARN string) {
-	cmd := awsCommand("sns", "get-topic-attributes", "--topic-arn", topicARN)
+	cmd := awsCommand(t, "sns", "get-topic-attributes", "--topic-arn", topicARN)
 	output, err := cmd.CombinedOutput()
 	require.NoError(t, err, "Topic %s should exist. Output: %s", topicARN, string(output))
 	t.Logf("✓ SNS topic exists: %s", topicARN)
 }
 
+// verifyLambdaFunctionExists waits for the function to exist and, when the
+// emulator reports a state, to be Active
 func verifyLambdaFunctionExists(t *testing.T, functionName string) {
-	cmd := awsCommand("lambda", "get-function", "--function-name", functionName)
-	output, err := cmd.CombinedOutput()
-	require.NoError(t, err, "Function %s should exist. Output: %s", functionName, string(output))
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		output, err := runAWS(t, "lambda", "get-function", "--function-name", functionName)
+		if err != nil {
+			return err
+		}
+
+		var function struct {
+			Configuration struct {
+				State string
+			}
+		}
+		if err := json.Unmarshal([]byte(output), &function); err != nil {
+			return fmt.Errorf("decoding get-function output for %s: %w", functionName, err)
+		}
+		if state := function.Configuration.State; state != "" && state != "Active" {
+			return fmt.Errorf("function %s is %s, not Active", functionName, state)
+		}
+		return nil
+	})
 	t.Logf("✓ Lambda function %s exists", functionName)
 }
 
+// s3TestContent is what testS3Upload uploads and testS3Download expects
+const s3TestContent = "Hello from Terratest!"
+
 func testS3Upload(t *testing.T, bucketName string) {
-	// Create test file
-	testFile := "/tmp/cloudemu-test.txt"
-	err := os.WriteFile(testFile, []byte("Hello from Terratest!"), 0644)
-	require.NoError(t, err)
-	defer os.Remove(testFile)
+	testFile := artifacts.WriteTempFile(t, "upload.txt", []byte(s3TestContent))
 
 	// Upload to S3
-	cmd := awsCommand("s3", "cp", testFile, fmt.Sprintf("s3://%s/test.txt", bucketName))
+	cmd := awsCommand(t, "s3", "cp", testFile, fmt.Sprintf("s3://%s/test.txt", bucketName))
 	output, err := cmd.CombinedOutput()
 	require.NoError(t, err, "Failed to upload to S3: %s", string(output))
 	t.Logf("✓ Uploaded file to S3 bucket %s", bucketName)
 }
 
 func testS3Download(t *testing.T, bucketName string) {
-	downloadFile := "/tmp/cloudemu-download.txt"
-	defer os.Remove(downloadFile)
+	downloadFile := filepath.Join(artifacts.TempDir(t), "download.txt")
 
-	cmd := awsCommand("s3", "cp", fmt.Sprintf("s3://%s/test.txt", bucketName), downloadFile)
+	cmd := awsCommand(t, "s3", "cp", fmt.Sprintf("s3://%s/test.txt", bucketName), downloadFile)
 	output, err := cmd.CombinedOutput()
 	require.NoError(t, err, "Failed to download from S3: %s", string(output))
 
-	content, err := os.ReadFile(downloadFile)
-	require.NoError(t, err)
-	assert.Equal(t, "Hello from Terratest!", string(content))
+	if !artifacts.ReadAndCompare(t, downloadFile, []byte(s3TestContent)) {
+		return
+	}
 	t.Logf("✓ Downloaded and verified file from S3")
 }
 
 func testDynamoDBPutItem(t *testing.T, tableName string) {
 	item := `{"id": {"S": "test-id-1"}, "name": {"S": "Test Item"}}`
-	cmd := awsCommand("dynamodb", "put-item", "--table-name", tableName, "--item", item)
+	cmd := awsCommand(t, "dynamodb", "put-item", "--table-name", tableName, "--item", item)
 	output, err := cmd.CombinedOutput()
 	require.NoError(t, err, "Failed to put item: %s", string(output))
 	t.Logf("✓ Put item to DynamoDB table %s", tableName)
@@ -254,7 +353,7 @@ func testDynamoDBPutItem(t *testing.T, tableName string) {
 
 func testDynamoDBGetItem(t *testing.T, tableName string) {
 	key := `{"id": {"S": "test-id-1"}}`
-	cmd := awsCommand("dynamodb", "get-item", "--table-name", tableName, "--key", key)
+	cmd := awsCommand(t, "dynamodb", "get-item", "--table-name", tableName, "--key", key)
 	output, err := cmd.CombinedOutput()
 	require.NoError(t, err, "Failed to get item: %s", string(output))
 	assert.Contains(t, string(output), "Test Item")
@@ -262,22 +361,29 @@ func testDynamoDBGetItem(t *testing.T, tableName string) {
 }
 
 func testSQSSendMessage(t *testing.T, queueURL string) {
-	cmd := awsCommand("sqs", "send-message", "--queue-url", queueURL, "--message-body", "Test message from Terratest")
+	cmd := awsCommand(t, "sqs", "send-message", "--queue-url", queueURL, "--message-body", "Test message from Terratest")
 	output, err := cmd.CombinedOutput()
 	require.NoError(t, err, "Failed to send message: %s", string(output))
 	t.Logf("✓ Sent message to SQS queue")
 }
 
 func testSQSReceiveMessage(t *testing.T, queueURL string) {
-	cmd := awsCommand("sqs", "receive-message", "--queue-url", queueURL)
-	output, err := cmd.CombinedOutput()
-	require.NoError(t, err, "Failed to receive message: %s", string(output))
-	assert.Contains(t, string(output), "Test message")
+	// A sent message is not always visible on the first receive
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		output, err := runAWS(t, "sqs", "receive-message", "--queue-url", queueURL)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, "Test message") {
+			return fmt.Errorf("message not yet visible on %s: %q", queueURL, strings.TrimSpace(output))
+		}
+		return nil
+	})
 	t.Logf("✓ Received message from SQS queue")
 }
 
 func testSNSPublish(t *testing.T, topicARN string) {
-	cmd := awsCommand("sns", "publish", "--topic-arn", topicARN, "--message", "Test message from Terratest")
+	cmd := awsCommand(t, "sns", "publish", "--topic-arn", topicARN, "--message", "Test message from Terratest")
 	output, err := cmd.CombinedOutput()
 	require.NoError(t, err, "Failed to publish to SNS: %s", string(output))
 	t.Logf("✓ Published message to SNS topic")
@@ -0,0 +1,198 @@
+//go:build integration
+
+package test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/compat"
+	"iac/testutil/concurrency"
+	"iac/testutil/fanout"
+	"iac/testutil/httpassert"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuLambdaSourceDir packages a handler from a local directory,
+// then verifies that editing the source redeploys the function
+func TestCloudEmuLambdaSourceDir(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	sourceDir := t.TempDir()
+	writePythonHandler(t, sourceDir, "v1")
+
+	functionName := fmt.Sprintf("source-dir-fn-%d", time.Now().Unix())
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/lambda-source-dir",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"function_name": functionName,
+			"source_dir":    sourceDir,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+
+	concurrency.InitAndApply(t, terraformOptions)
+	verifyLambdaFunctionExists(t, functionName)
+
+	response := invokeLambdaFunction(t, functionName, map[string]string{"name": "terratest"})
+	assert.Contains(t, response, "hello terratest from v1")
+
+	// Changing the handler must change the package hash and redeploy
+	writePythonHandler(t, sourceDir, "v2")
+	concurrency.RunThrottled(t, func() { tfopts.Apply(t, terraformOptions) })
+
+	response = invokeLambdaFunction(t, functionName, map[string]string{"name": "terratest"})
+	assert.Contains(t, response, "hello terratest from v2")
+}
+
+// TestCloudEmuLambdaFunctionURL calls a public function URL through
+// httpassert, so an https URL served with CloudEmu's self-signed
+// certificate is verified against SWE_TLS_CA_BUNDLE, or not at all with
+// SWE_TLS_INSECURE_SKIP_VERIFY, instead of failing or falling back to http
+func TestCloudEmuLambdaFunctionURL(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/lambda-function-url",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"function_name": fmt.Sprintf("function-url-fn-%d", time.Now().Unix()),
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+
+	concurrency.InitAndApply(t, terraformOptions)
+
+	invokeURL := terraform.Output(t, terraformOptions, "invoke_url")
+	require.NotEmpty(t, invokeURL, "Function URL should be exported as invoke_url")
+
+	opts, err := httpassert.LoadOptions()
+	require.NoError(t, err)
+	resp := httpassert.GetWithRetries(t, invokeURL, opts)
+	resp.AssertStatus(t, http.StatusOK)
+	resp.AssertBodyContains(t, "hello from function url")
+}
+
+// TestCloudEmuLambdaCanary shifts half of the alias traffic to a new version
+// and checks that both versions answer through the alias
+func TestCloudEmuLambdaCanary(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	sourceDir := t.TempDir()
+	writePythonHandler(t, sourceDir, "v1")
+
+	functionName := fmt.Sprintf("canary-fn-%d", time.Now().Unix())
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/lambda-canary",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"function_name": functionName,
+			"source_dir":    sourceDir,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+
+	concurrency.InitAndApply(t, terraformOptions)
+	assert.NotEmpty(t, terraform.Output(t, terraformOptions, "alias_arn"))
+
+	stableVersion := getLambdaAliasVersion(t, functionName, "live")
+
+	// Publish v2 and route 50% of the alias to it
+	writePythonHandler(t, sourceDir, "v2")
+	terraformOptions.Vars["canary_weight"] = 0.5
+	terraformOptions.Vars["stable_version"] = stableVersion
+	concurrency.RunThrottled(t, func() { tfopts.Apply(t, terraformOptions) })
+
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		response := invokeLambdaQualified(t, functionName, "live", map[string]string{"name": "canary"})
+		for _, version := range []string{"v1", "v2"} {
+			if strings.Contains(response, "from "+version) {
+				seen[version] = true
+			}
+		}
+	}
+
+	assert.True(t, seen["v1"], "Stable version should still receive traffic")
+	assert.True(t, seen["v2"], "Canary version should receive traffic")
+}
+
+// TestCloudEmuLambdaReservedConcurrency reserves one concurrent execution
+// and invokes the function five times at once: the invocations that find
+// the execution busy are throttled rather than queued
+func TestCloudEmuLambdaReservedConcurrency(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+	compat.RequireEmulatorSupport(t, "lambda.ReservedConcurrency")
+
+	functionName := fmt.Sprintf("concurrency-fn-%d", time.Now().Unix())
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/lambda-concurrency",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"function_name":        functionName,
+			"reserved_concurrency": 1,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+
+	concurrency.InitAndApply(t, terraformOptions)
+
+	// The SDK retries throttled calls, which would hide the throttling
+	client := lambda.New(newCloudEmuSession(t), aws.NewConfig().WithMaxRetries(0))
+	invokes := fanout.Run(5, func(int) error {
+		_, err := client.Invoke(&lambda.InvokeInput{
+			FunctionName: aws.String(functionName),
+			Payload:      []byte(`{}`),
+		})
+		return err
+	})
+
+	throttled := 0
+	for _, r := range invokes {
+		var aerr awserr.Error
+		if errors.As(r.Err, &aerr) && aerr.Code() == lambda.ErrCodeTooManyRequestsException {
+			throttled++
+			continue
+		}
+		assert.NoError(t, r.Err, "An invocation should either run or be throttled")
+	}
+	assert.GreaterOrEqual(t, throttled, 1, "Invocations beyond the reserved concurrency should be throttled")
+	assert.Less(t, throttled, len(invokes), "The reserved execution should run one invocation")
+}
+
+func writePythonHandler(t *testing.T, dir, version string) {
+	source := fmt.Sprintf(`def handler(event, context):
+    return {"statusCode": 200, "body": "hello %%s from %s" %% event.get("name", "world")}
+`, version)
+	err := os.WriteFile(filepath.Join(dir, "index.py"), []byte(source), 0644)
+	require.NoError(t, err)
+}
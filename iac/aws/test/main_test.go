@@ -0,0 +1,16 @@
+//go:build integration
+
+package test
+
+import (
+	"os"
+	"testing"
+
+	"iac/testutil/emureset"
+)
+
+// TestMain clears CloudEmu of resources earlier runs left behind when
+// SWE_TEST_RESET_EMULATOR is set
+func TestMain(m *testing.M) {
+	os.Exit(emureset.Main(m, emureset.AWS))
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/config"
+	"iac/testutil/plandiff"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	multiRegionExample = "../../examples/multi-region-cloudemu"
+
+	primaryBucketAddress   = "module.primary_storage.module.aws_storage[0].aws_s3_bucket.this"
+	secondaryBucketAddress = "module.secondary_storage.module.aws_storage[0].aws_s3_bucket.this"
+)
+
+// multiRegionOptions returns options for the multi-region example
+func multiRegionOptions(t *testing.T, prefix string) *terraform.Options {
+	return tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: multiRegionExample,
+		Vars: map[string]interface{}{
+			"cloudemu_endpoint": config.Load(t).CloudEmuEndpoint,
+			"bucket_prefix":     prefix,
+			"primary_region":    "us-east-1",
+			"secondary_region":  "eu-west-1",
+		},
+		NoColor: true,
+	})
+}
+
+// TestMultiRegionStoragePlan plans the example without CloudEmu and checks
+// one bucket is planned per aliased provider, each named for its region.
+// Not parallel: under the integration tag it shares the example's
+// .terraform directory with TestCloudEmuMultiRegionStorage, which only
+// starts once it has finished.
+func TestMultiRegionStoragePlan(t *testing.T) {
+	terraformOptions := multiRegionOptions(t, "plan-only")
+	terraformOptions.PlanFilePath = filepath.Join(t.TempDir(), "multi-region.plan")
+
+	plan, err := plandiff.Parse([]byte(terraform.InitAndPlanAndShow(t, terraformOptions)))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, plan.TypeCounts()["aws_s3_bucket"], "One bucket should be planned per region")
+
+	for address, name := range map[string]string{
+		primaryBucketAddress:   "plan-only-us-east-1",
+		secondaryBucketAddress: "plan-only-eu-west-1",
+	} {
+		bucket, ok := plan.Resources[address]
+		if assert.True(t, ok, "%s should be planned", address) {
+			assert.Equal(t, name, bucket.Values["bucket"])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuMultiRegionStorage applies the example and looks each bucket
+// up with a client for its own region
+func TestCloudEmuMultiRegionStorage(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	prefix := fmt.Sprintf("multi-region-%d", time.Now().Unix())
+	terraformOptions := multiRegionOptions(t, prefix)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	for _, side := range []string{"primary", "secondary"} {
+		name := terraform.Output(t, terraformOptions, side+"_bucket_name")
+		region := terraform.Output(t, terraformOptions, side+"_bucket_region")
+		assert.Equal(t, prefix+"-"+region, name, "The %s bucket should be named for its region", side)
+
+		client := s3.New(newCloudEmuSessionInRegion(t, region))
+		_, err := client.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(name)})
+		require.NoError(t, err, "%s should exist", name)
+
+		location, err := client.GetBucketLocation(&s3.GetBucketLocationInput{Bucket: aws.String(name)})
+		require.NoError(t, err)
+		// S3 reports us-east-1 as an empty location constraint
+		assert.Equal(t, region, s3.NormalizeBucketLocation(aws.StringValue(location.LocationConstraint)),
+			"%s should be created in %s", name, region)
+	}
+}
@@ -0,0 +1,191 @@
+//go:build integration
+
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeObjectSeed seeds the pseudorandom object content, so a given size
+// always has the same checksum
+const largeObjectSeed = 1345
+
+// envLargeObjectMB sizes the object TestCloudEmuMultipartUpload round-trips
+const envLargeObjectMB = "SWE_TEST_LARGE_OBJECT_MB"
+
+// defaultLargeObjectMB is the size when SWE_TEST_LARGE_OBJECT_MB is unset
+const defaultLargeObjectMB = 64
+
+// TestCloudEmuMultipartUpload deploys a bucket through the storage facade,
+// round-trips a SWE_TEST_LARGE_OBJECT_MB object (64 MiB by default) through
+// the SDK's multipart uploader and ranged downloader, and checks an aborted
+// multipart upload leaves nothing behind. The object is generated and
+// hashed as it streams, never held in memory.
+func TestCloudEmuMultipartUpload(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	objectMB, err := config.IntFromEnv(envLargeObjectMB, defaultLargeObjectMB, 1)
+	require.NoError(t, err)
+	bucketName := fmt.Sprintf("test-multipart-%d", time.Now().Unix())
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: storageFixture(t),
+		VarFiles:     []string{"multipart.tfvars"},
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"bucket_name": bucketName,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	sess := newCloudEmuSession(t)
+	client := s3.New(sess)
+
+	t.Run("round trip", func(t *testing.T) {
+		size := int64(objectMB) << 20
+		key := fmt.Sprintf("large/%dmib.bin", objectMB)
+		want := sha256Hex(t, largeObject(size))
+
+		// The bucket must be empty again for the destroy
+		defer client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+
+		uploader := s3manager.NewUploader(sess, func(u *s3manager.Uploader) {
+			u.PartSize = s3manager.MinUploadPartSize
+		})
+		upload, err := uploader.Upload(&s3manager.UploadInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(key),
+			Body:   largeObject(size),
+		})
+		require.NoError(t, err, "Uploading %d bytes should succeed", size)
+		if size > s3manager.MinUploadPartSize {
+			assert.NotEmpty(t, upload.UploadID, "An object larger than one part should go up in parts")
+		}
+
+		head, err := client.HeadObject(&s3.HeadObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+		require.NoError(t, err)
+		assert.Equal(t, size, aws.Int64Value(head.ContentLength))
+
+		// One part at a time, so the parts arrive in order and can be hashed
+		// as they come
+		downloader := s3manager.NewDownloader(sess, func(d *s3manager.Downloader) {
+			d.PartSize = s3manager.DefaultDownloadPartSize
+			d.Concurrency = 1
+		})
+		got := &hashWriterAt{hash: sha256.New()}
+		n, err := downloader.Download(got, &s3.GetObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+		require.NoError(t, err)
+		assert.Equal(t, size, n)
+		assert.Equal(t, want, hex.EncodeToString(got.hash.Sum(nil)), "The downloaded object should match what was uploaded")
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		key := "aborted/part.bin"
+
+		created, err := client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(key),
+		})
+		require.NoError(t, err)
+		uploadID := aws.StringValue(created.UploadId)
+
+		// Only the last part of a completed upload may be under 5 MiB, so a
+		// small part is enough for one that is never completed
+		_, err = client.UploadPart(&s3.UploadPartInput{
+			Bucket:     aws.String(bucketName),
+			Key:        aws.String(key),
+			UploadId:   aws.String(uploadID),
+			PartNumber: aws.Int64(1),
+			Body:       strings.NewReader(strings.Repeat("x", 1024)),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{uploadID}, multipartUploads(t, client, bucketName), "The upload should be in progress")
+
+		_, err = client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucketName),
+			Key:      aws.String(key),
+			UploadId: aws.String(uploadID),
+		})
+		require.NoError(t, err)
+
+		assert.Empty(t, multipartUploads(t, client, bucketName), "An aborted upload should leave no upload behind")
+
+		_, err = client.ListParts(&s3.ListPartsInput{
+			Bucket:   aws.String(bucketName),
+			Key:      aws.String(key),
+			UploadId: aws.String(uploadID),
+		})
+		assert.Error(t, err, "An aborted upload should have no parts left to list")
+
+		_, err = client.HeadObject(&s3.HeadObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+		assert.Error(t, err, "An aborted upload should not create the object")
+	})
+}
+
+// largeObject returns size bytes of pseudorandom content, the same for
+// every call
+func largeObject(size int64) io.Reader {
+	return io.LimitReader(rand.New(rand.NewSource(largeObjectSeed)), size)
+}
+
+// sha256Hex hashes everything r yields
+func sha256Hex(t *testing.T, r io.Reader) string {
+	h := sha256.New()
+	_, err := io.Copy(h, r)
+	require.NoError(t, err)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashWriterAt is an io.WriterAt for s3manager.Downloader that hashes what
+// it is given instead of storing it. It needs the writes in order, which a
+// downloader with Concurrency 1 makes.
+type hashWriterAt struct {
+	hash   hash.Hash
+	offset int64
+}
+
+func (w *hashWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	if off != w.offset {
+		return 0, fmt.Errorf("write at offset %d, want %d: parts arrived out of order", off, w.offset)
+	}
+	n, err := w.hash.Write(p)
+	w.offset += int64(n)
+	return n, err
+}
+
+// multipartUploads returns the IDs of the multipart uploads in progress in
+// a bucket
+func multipartUploads(t *testing.T, client *s3.S3, bucketName string) []string {
+	out, err := client.ListMultipartUploads(&s3.ListMultipartUploadsInput{Bucket: aws.String(bucketName)})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, u := range out.Uploads {
+		ids = append(ids, aws.StringValue(u.UploadId))
+	}
+	return ids
+}
@@ -0,0 +1,81 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/compat"
+	"iac/testutil/concurrency"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuStorageNotifications applies a bucket from the storage facade
+// that notifies a function, a queue and a topic, and reads its notification
+// configuration back: each target should get the events and key filters
+// its notifications entry asked for, translated to their S3 names.
+func TestCloudEmuStorageNotifications(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+	compat.RequireEmulatorSupport(t, "s3.PutBucketNotificationConfiguration")
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/storage-notifications",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"name": fmt.Sprintf("test-notify-%d", time.Now().Unix()),
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	bucket := terraform.Output(t, terraformOptions, "bucket_name")
+	client := s3.New(newCloudEmuSession(t))
+
+	config, err := client.GetBucketNotificationConfiguration(&s3.GetBucketNotificationConfigurationRequest{
+		Bucket: aws.String(bucket),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, config.LambdaFunctionConfigurations, 1, "The bucket should notify one function")
+	function := config.LambdaFunctionConfigurations[0]
+	assert.Equal(t, terraform.Output(t, terraformOptions, "function_arn"), aws.StringValue(function.LambdaFunctionArn))
+	assert.Equal(t, []string{"s3:ObjectCreated:*"}, aws.StringValueSlice(function.Events))
+	assert.Equal(t, map[string]string{"prefix": "uploads/", "suffix": ".csv"}, filterRules(function.Filter))
+
+	require.Len(t, config.QueueConfigurations, 1, "The bucket should notify one queue")
+	queue := config.QueueConfigurations[0]
+	assert.Equal(t, terraform.Output(t, terraformOptions, "queue_arn"), aws.StringValue(queue.QueueArn))
+	assert.Equal(t, []string{"s3:ObjectRemoved:*"}, aws.StringValueSlice(queue.Events))
+	assert.Empty(t, filterRules(queue.Filter), "A notification without filters should match every key")
+
+	require.Len(t, config.TopicConfigurations, 1, "The bucket should notify one topic")
+	topic := config.TopicConfigurations[0]
+	assert.Equal(t, terraform.Output(t, terraformOptions, "topic_arn"), aws.StringValue(topic.TopicArn))
+	assert.Equal(t, []string{"s3:ObjectCreated:*"}, aws.StringValueSlice(topic.Events))
+	assert.Equal(t, map[string]string{"prefix": "reports/"}, filterRules(topic.Filter))
+}
+
+// filterRules returns a notification's key filter rules by lower-cased
+// name, since S3 answers "Prefix" where it was sent "prefix"
+func filterRules(filter *s3.NotificationConfigurationFilter) map[string]string {
+	rules := map[string]string{}
+	if filter == nil || filter.Key == nil {
+		return rules
+	}
+	for _, rule := range filter.Key.FilterRules {
+		rules[strings.ToLower(aws.StringValue(rule.Name))] = aws.StringValue(rule.Value)
+	}
+	return rules
+}
@@ -0,0 +1,69 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/eventually"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuPlatformService deploys the platform service module with every
+// feature on, then follows one message from the queue through the function
+// into the table and the bucket
+func TestCloudEmuPlatformService(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	serviceName := fmt.Sprintf("svc-%d", time.Now().Unix())
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/platform-service",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"service_name": serviceName,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+
+	concurrency.InitAndApply(t, terraformOptions)
+
+	queueURL := terraform.Output(t, terraformOptions, "queue_url")
+	tableName := terraform.Output(t, terraformOptions, "table_name")
+	bucketName := terraform.Output(t, terraformOptions, "bucket_name")
+	verifyLambdaFunctionExists(t, terraform.Output(t, terraformOptions, "function_name"))
+	verifyDynamoDBTableExists(t, tableName)
+	verifyS3BucketExists(t, bucketName)
+
+	orderID := fmt.Sprintf("order-%d", time.Now().UnixNano())
+	_, err := runAWS(t, "sqs", "send-message", "--queue-url", queueURL, "--message-body", fmt.Sprintf(`{"id": %q}`, orderID))
+	require.NoError(t, err)
+
+	// The queue trigger polls asynchronously; the handler writes the item
+	// before the object
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		output, err := runAWS(t, "dynamodb", "get-item", "--table-name", tableName, "--key", fmt.Sprintf(`{"id": {"S": %q}}`, orderID))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, orderID) {
+			return fmt.Errorf("table %s has no item %s yet", tableName, orderID)
+		}
+		return nil
+	})
+
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		_, err := runAWS(t, "s3api", "head-object", "--bucket", bucketName, "--key", "orders/"+orderID+".json")
+		return err
+	})
+}
@@ -0,0 +1,89 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/objectstore"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuStoragePresignedURLs deploys a bucket through the storage
+// facade, which blocks public access, and hands out temporary access to an
+// object the way a consumer would: an upload through a presigned PUT and a
+// download through a presigned GET, both over plain net/http without
+// credentials. A presigned URL past its expiry is refused.
+func TestCloudEmuStoragePresignedURLs(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	bucketName := fmt.Sprintf("test-presign-%d", time.Now().Unix())
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: storageFixture(t),
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"bucket_name": bucketName,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	client := s3.New(newCloudEmuSession(t))
+	store := objectstore.NewS3WithClient(client, bucketName)
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
+	key := "shared/report.txt"
+	body := "shared by " + t.Name()
+
+	// The bucket must be empty again for the destroy
+	defer client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+
+	putURL, err := store.PresignPutURL(key, time.Minute)
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPut, putURL, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := httpClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "The presigned PUT should upload the object")
+
+	getURL, err := store.PresignGetURL(key, time.Minute)
+	require.NoError(t, err)
+	status, got := httpGet(t, httpClient, getURL)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, body, got, "The presigned GET should download what the presigned PUT uploaded")
+
+	expiredURL, err := store.PresignGetURL(key, time.Second)
+	require.NoError(t, err)
+	time.Sleep(2 * time.Second)
+	status, got = httpGet(t, httpClient, expiredURL)
+	assert.Equal(t, http.StatusForbidden, status, "An expired presigned URL should be refused")
+	assert.NotContains(t, got, body, "An expired presigned URL should not return the object")
+}
+
+// httpGet fetches url without credentials and returns the status and body
+func httpGet(t *testing.T, client *http.Client, url string) (int, string) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
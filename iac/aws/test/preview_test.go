@@ -0,0 +1,82 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+	"iac/testutil/preview"
+
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuPreviewUpDown brings a preview environment up on CloudEmu
+// the way tools/preview does, checks its resources exist and that status
+// lists it, then brings it down and checks they are gone
+func TestCloudEmuPreviewUpDown(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	cfg := config.Load(t)
+	opts := preview.Options{
+		Dir:              test_structure.CopyTerraformFolderToTemp(t, "../..", preview.DefaultDir),
+		EmulatorEndpoint: cfg.CloudEmuEndpoint,
+		Region:           cfg.Region,
+		Features:         map[string]bool{"database": true, "queue": true, "bucket": true},
+	}
+	branch := fmt.Sprintf("test/preview-%d", time.Now().Unix()%100000)
+	ctx := context.Background()
+
+	var entry preview.Entry
+	var err error
+	concurrency.RunThrottled(t, func() {
+		entry, err = preview.Up(ctx, opts, branch)
+	})
+	// Whatever Up managed to create goes when the test does
+	defer func() {
+		if entries, _ := preview.Status(opts); len(entries) > 0 {
+			concurrency.RunThrottled(t, func() {
+				assert.NoError(t, preview.Down(ctx, opts, branch))
+			})
+		}
+	}()
+	require.NoError(t, err)
+
+	functionName := entry.Outputs["function_name"]
+	tableName := entry.Outputs["table_name"]
+	bucketName := entry.Outputs["bucket_name"]
+	assert.Contains(t, functionName, entry.Slug, "Resources should be named after the preview")
+	verifyLambdaFunctionExists(t, functionName)
+	verifyDynamoDBTableExists(t, tableName)
+	verifySQSQueueExists(t, entry.Outputs["queue_url"])
+	verifyS3BucketExists(t, bucketName)
+
+	entries, err := preview.Status(opts)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, branch, entries[0].Name)
+	assert.Equal(t, cfg.CloudEmuEndpoint, entries[0].Target)
+
+	concurrency.RunThrottled(t, func() {
+		require.NoError(t, preview.Down(ctx, opts, branch))
+	})
+
+	_, err = runAWS(t, "lambda", "get-function", "--function-name", functionName)
+	assert.Error(t, err, "The function should be gone")
+	_, err = runAWS(t, "dynamodb", "describe-table", "--table-name", tableName)
+	assert.Error(t, err, "The table should be gone")
+	_, err = runAWS(t, "s3api", "head-bucket", "--bucket", bucketName)
+	assert.Error(t, err, "The bucket should be gone")
+
+	entries, err = preview.Status(opts)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "A preview brought down should leave the registry")
+}
@@ -0,0 +1,63 @@
+//go:build integration
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+	"iac/testutil/queueload"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuQueueLoad runs queueload briefly against a messaging facade
+// queue, as tools/queueloader does, and checks every message sent arrives
+// with nothing failing. It is a smoke test of the load generator, not a
+// benchmark: the report is logged for comparison between emulator releases.
+func TestCloudEmuQueueLoad(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/queue-visibility",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"queue_name": fmt.Sprintf("test-queueload-%d", time.Now().Unix()),
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	backend, err := queueload.NewSQS(config.Load(t), terraform.Output(t, terraformOptions, "queue_url"))
+	require.NoError(t, err)
+	defer backend.Close()
+
+	report := queueload.Run(context.Background(), backend, queueload.Options{
+		Producers:   2,
+		Consumers:   2,
+		MessageSize: 512,
+		Duration:    3 * time.Second,
+		Drain:       15 * time.Second,
+	})
+
+	var table bytes.Buffer
+	require.NoError(t, queueload.WriteTable(&table, report))
+	t.Logf("queue load on CloudEmu:\n%s", table.String())
+
+	assert.Positive(t, report.Sent, "The producers should send messages")
+	assert.Zero(t, report.SendErrors)
+	assert.Zero(t, report.ReceiveErrors)
+	assert.Zero(t, report.Lost, "Every message sent should arrive before the drain runs out")
+	assert.Zero(t, report.Foreign, "The queue is new, so every message should be the run's own")
+}
@@ -0,0 +1,114 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/compat"
+	"iac/testutil/eventually"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replicationRole is the role S3 replicates as; CloudEmu does not check it
+const replicationRole = "arn:aws:iam::000000000000:role/replication"
+
+// TestCloudEmuStorageReplication replicates an object between two
+// versioned buckets. The storage facade declares replication_enabled but
+// does not configure replication yet, so the rule is put through the SDK;
+// the test covers the emulator behaviour the facade will rely on.
+func TestCloudEmuStorageReplication(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+	compat.RequireEmulatorSupport(t, "s3.PutBucketReplication")
+
+	stamp := time.Now().Unix()
+	source := fmt.Sprintf("test-replication-src-%d", stamp)
+	destination := fmt.Sprintf("test-replication-dst-%d", stamp)
+
+	client := s3.New(newCloudEmuSession(t))
+	for _, bucket := range []string{source, destination} {
+		require.NoError(t, createVersionedBucket(client, bucket))
+		t.Cleanup(func() { deleteBucket(client, bucket) })
+	}
+	require.NoError(t, putReplication(client, source, destination))
+
+	body := "replicated by " + t.Name()
+	_, err := client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(source),
+		Key:    aws.String("replicated.txt"),
+		Body:   strings.NewReader(body),
+	})
+	require.NoError(t, err)
+
+	replica := eventually.EventuallyValue(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() (string, error) {
+		out, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String(destination), Key: aws.String("replicated.txt")})
+		if err != nil {
+			return "", err
+		}
+		defer out.Body.Close()
+		data, err := io.ReadAll(out.Body)
+		return string(data), err
+	})
+	assert.Equal(t, body, replica, "The object should reach the destination bucket unchanged")
+}
+
+// createVersionedBucket creates a bucket with versioning on, which
+// replication requires on both sides
+func createVersionedBucket(client *s3.S3, bucket string) error {
+	if _, err := client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return fmt.Errorf("creating %s: %w", bucket, err)
+	}
+	_, err := client.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket:                  aws.String(bucket),
+		VersioningConfiguration: &s3.VersioningConfiguration{Status: aws.String(s3.BucketVersioningStatusEnabled)},
+	})
+	if err != nil {
+		return fmt.Errorf("enabling versioning on %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// putReplication replicates every object in source to destination
+func putReplication(client *s3.S3, source, destination string) error {
+	_, err := client.PutBucketReplication(&s3.PutBucketReplicationInput{
+		Bucket: aws.String(source),
+		ReplicationConfiguration: &s3.ReplicationConfiguration{
+			Role: aws.String(replicationRole),
+			Rules: []*s3.ReplicationRule{{
+				ID:                      aws.String("all"),
+				Status:                  aws.String(s3.ReplicationRuleStatusEnabled),
+				Priority:                aws.Int64(1),
+				Filter:                  &s3.ReplicationRuleFilter{Prefix: aws.String("")},
+				DeleteMarkerReplication: &s3.DeleteMarkerReplication{Status: aws.String(s3.DeleteMarkerReplicationStatusDisabled)},
+				Destination:             &s3.Destination{Bucket: aws.String("arn:aws:s3:::" + destination)},
+			}},
+		},
+	})
+	return err
+}
+
+// deleteBucket removes every object version in bucket and then the bucket,
+// ignoring errors; emureset clears anything left on the next reset
+func deleteBucket(client *s3.S3, bucket string) {
+	client.ListObjectVersionsPages(&s3.ListObjectVersionsInput{Bucket: aws.String(bucket)},
+		func(page *s3.ListObjectVersionsOutput, _ bool) bool {
+			for _, v := range page.Versions {
+				client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: v.Key, VersionId: v.VersionId})
+			}
+			for _, m := range page.DeleteMarkers {
+				client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: m.Key, VersionId: m.VersionId})
+			}
+			return true
+		})
+	client.DeleteBucket(&s3.DeleteBucketInput{Bucket: aws.String(bucket)})
+}
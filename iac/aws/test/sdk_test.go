@@ -0,0 +1,74 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"iac/testutil/config"
+	"iac/testutil/httprecord"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/stretchr/testify/require"
+)
+
+// newCloudEmuSession returns an AWS SDK session pointed at the configured
+// CloudEmu endpoint, with the configured credentials, recording its
+// requests with SWE_RECORD_HTTP=1
+func newCloudEmuSession(t *testing.T) *session.Session {
+	return newCloudEmuSessionInRegion(t, config.Load(t).Region)
+}
+
+// newCloudEmuSessionInRegion is newCloudEmuSession for a region other than
+// the configured one
+func newCloudEmuSessionInRegion(t *testing.T, region string) *session.Session {
+	cfg := config.Load(t)
+
+	awsConfig, err := cfg.AWSConfig(cfg.CloudEmuEndpoint, httprecord.Transport(t, nil))
+	require.NoError(t, err)
+
+	sess, err := session.NewSession(awsConfig.WithRegion(region))
+	require.NoError(t, err)
+	return sess
+}
+
+// invokeLambdaFunction synchronously invokes a function and returns its raw response payload
+func invokeLambdaFunction(t *testing.T, functionName string, payload interface{}) string {
+	return invokeLambdaQualified(t, functionName, "", payload)
+}
+
+// invokeLambdaQualified invokes a specific version or alias of a function
+func invokeLambdaQualified(t *testing.T, functionName, qualifier string, payload interface{}) string {
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	input := &lambda.InvokeInput{
+		FunctionName: aws.String(functionName),
+		Payload:      body,
+	}
+	if qualifier != "" {
+		input.Qualifier = aws.String(qualifier)
+	}
+
+	client := lambda.New(newCloudEmuSession(t))
+	out, err := client.Invoke(input)
+	require.NoError(t, err, "Invoking %s should succeed", functionName)
+	require.Nil(t, out.FunctionError, "Function %s returned an error: %s", functionName, string(out.Payload))
+
+	return string(out.Payload)
+}
+
+// getLambdaAliasVersion returns the version an alias currently points at
+func getLambdaAliasVersion(t *testing.T, functionName, aliasName string) string {
+	client := lambda.New(newCloudEmuSession(t))
+	out, err := client.GetAlias(&lambda.GetAliasInput{
+		FunctionName: aws.String(functionName),
+		Name:         aws.String(aliasName),
+	})
+	require.NoError(t, err, "Alias %s of %s should exist", aliasName, functionName)
+
+	return aws.StringValue(out.FunctionVersion)
+}
@@ -0,0 +1,290 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"iac/testutil/compat"
+	"iac/testutil/eventually"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orderCreatedPolicy lets through only messages whose event_type attribute
+// is order_created
+const orderCreatedPolicy = `{"event_type": ["order_created"]}`
+
+// TestCloudEmuSNSFilterPolicy publishes a matching and a non-matching
+// message to a topic whose only subscriber filters on event_type, and
+// expects the queue to receive the matching one alone
+func TestCloudEmuSNSFilterPolicy(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+	compat.RequireEmulatorSupport(t, "sns.FilterPolicy")
+
+	sess := newCloudEmuSession(t)
+	snsClient, sqsClient := sns.New(sess), sqs.New(sess)
+
+	name := fmt.Sprintf("test-filter-%d", time.Now().Unix())
+	topicARN, queueURL, err := filteredSubscription(snsClient, sqsClient, name, orderCreatedPolicy)
+	t.Cleanup(func() { deleteSubscription(snsClient, sqsClient, topicARN, queueURL) })
+	require.NoError(t, err)
+
+	require.NoError(t, publishEvent(snsClient, topicARN, "order_cancelled"))
+	require.NoError(t, publishEvent(snsClient, topicARN, "order_created"))
+
+	msg := eventually.EventuallyValue(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() (*sqs.Message, error) {
+		return receiveOne(sqsClient, queueURL)
+	})
+	got, err := eventType(msg)
+	require.NoError(t, err)
+	assert.Equal(t, "order_created", got, "Only the matching message should be delivered")
+
+	_, err = sqsClient.DeleteMessage(&sqs.DeleteMessageInput{QueueUrl: aws.String(queueURL), ReceiptHandle: msg.ReceiptHandle})
+	require.NoError(t, err)
+
+	extra, err := receiveOne(sqsClient, queueURL)
+	assert.ErrorIs(t, err, errNoMessage, "The filtered message should not be delivered")
+	if extra != nil {
+		got, _ := eventType(extra)
+		t.Logf("unexpected message with event_type %q", got)
+	}
+}
+
+// TestCloudEmuSNSMessageAttributes publishes three events to a topic with
+// a filtered and an unfiltered queue subscribed. The filtered queue must
+// receive the order_created event alone and the unfiltered queue all
+// three, so a policy that delivers everything fails, and each String and
+// Number attribute must reach the SNS envelope with its type and value.
+func TestCloudEmuSNSMessageAttributes(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+	compat.RequireEmulatorSupport(t, "sns.FilterPolicy")
+
+	sess := newCloudEmuSession(t)
+	snsClient, sqsClient := sns.New(sess), sqs.New(sess)
+
+	name := fmt.Sprintf("test-attributes-%d", time.Now().Unix())
+	topicARN, filteredURL, err := filteredSubscription(snsClient, sqsClient, name, orderCreatedPolicy)
+	t.Cleanup(func() { deleteSubscription(snsClient, sqsClient, topicARN, filteredURL) })
+	require.NoError(t, err)
+
+	allURL, err := subscribeQueue(snsClient, sqsClient, topicARN, name+"-all", "")
+	t.Cleanup(func() { deleteSubscription(snsClient, sqsClient, "", allURL) })
+	require.NoError(t, err)
+
+	events := []map[string]interface{}{
+		{"event_type": "order_created", "order_id": 1001, "amount": 42.5},
+		{"event_type": "order_cancelled", "order_id": 1002, "amount": 0},
+		{"event_type": "order_shipped", "order_id": 1003, "amount": -7.25},
+	}
+	for _, attrs := range events {
+		require.NoError(t, publishWithAttributes(snsClient, topicARN, fmt.Sprint("event ", attrs["event_type"]), attrs))
+	}
+
+	// Keyed by event_type, so a redelivered message is counted once
+	received := map[string]map[string]envelopeAttribute{}
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		msgs, err := receiveBatch(sqsClient, allURL)
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			attrs, err := envelopeAttributes(msg)
+			if err != nil {
+				return err
+			}
+			received[attrs["event_type"].Value] = attrs
+		}
+		if len(received) < len(events) {
+			return fmt.Errorf("the unfiltered queue has received %d of %d events", len(received), len(events))
+		}
+		return nil
+	})
+
+	for _, attrs := range events {
+		event := attrs["event_type"].(string)
+		got, ok := received[event]
+		if !assert.True(t, ok, "The unfiltered queue should receive %s", event) {
+			continue
+		}
+		assert.Equal(t, envelopeAttribute{Type: "String", Value: event}, got["event_type"])
+		assert.Equal(t, envelopeAttribute{Type: "Number", Value: fmt.Sprint(attrs["order_id"])}, got["order_id"])
+		assert.Equal(t, envelopeAttribute{Type: "Number", Value: fmt.Sprint(attrs["amount"])}, got["amount"])
+	}
+
+	msg := eventually.EventuallyValue(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() (*sqs.Message, error) {
+		return receiveOne(sqsClient, filteredURL)
+	})
+	attrs, err := envelopeAttributes(msg)
+	require.NoError(t, err)
+	assert.Equal(t, received["order_created"], attrs, "The filtered queue should receive order_created with the same attributes")
+
+	_, err = sqsClient.DeleteMessage(&sqs.DeleteMessageInput{QueueUrl: aws.String(filteredURL), ReceiptHandle: msg.ReceiptHandle})
+	require.NoError(t, err)
+
+	// Every event has reached the unfiltered queue, so one the filter let
+	// through would be waiting by now
+	extra, err := receiveOne(sqsClient, filteredURL)
+	assert.ErrorIs(t, err, errNoMessage, "The filtered queue should receive exactly one event")
+	if extra != nil {
+		got, _ := eventType(extra)
+		t.Logf("unexpected message with event_type %q", got)
+	}
+}
+
+// filteredSubscription creates a topic and a queue called name and
+// subscribes the queue with policy. The ARN and URL are returned as far as
+// they were created, for cleanup.
+func filteredSubscription(snsClient *sns.SNS, sqsClient *sqs.SQS, name, policy string) (topicARN, queueURL string, err error) {
+	topic, err := snsClient.CreateTopic(&sns.CreateTopicInput{Name: aws.String(name)})
+	if err != nil {
+		return "", "", fmt.Errorf("creating topic %s: %w", name, err)
+	}
+	topicARN = aws.StringValue(topic.TopicArn)
+
+	queueURL, err = subscribeQueue(snsClient, sqsClient, topicARN, name, policy)
+	return topicARN, queueURL, err
+}
+
+// subscribeQueue creates a queue called name and subscribes it to the
+// topic with policy, or with no filter policy when it is empty. The URL is
+// returned once the queue exists, for cleanup.
+func subscribeQueue(snsClient *sns.SNS, sqsClient *sqs.SQS, topicARN, name, policy string) (string, error) {
+	queue, err := sqsClient.CreateQueue(&sqs.CreateQueueInput{QueueName: aws.String(name)})
+	if err != nil {
+		return "", fmt.Errorf("creating queue %s: %w", name, err)
+	}
+	queueURL := aws.StringValue(queue.QueueUrl)
+
+	attrs, err := sqsClient.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl:       queue.QueueUrl,
+		AttributeNames: []*string{aws.String(sqs.QueueAttributeNameQueueArn)},
+	})
+	if err != nil {
+		return queueURL, fmt.Errorf("reading the ARN of %s: %w", name, err)
+	}
+
+	subscription := map[string]*string{"RawMessageDelivery": aws.String("false")}
+	if policy != "" {
+		subscription["FilterPolicy"] = aws.String(policy)
+	}
+	_, err = snsClient.Subscribe(&sns.SubscribeInput{
+		TopicArn:   aws.String(topicARN),
+		Protocol:   aws.String("sqs"),
+		Endpoint:   attrs.Attributes[sqs.QueueAttributeNameQueueArn],
+		Attributes: subscription,
+	})
+	if err != nil {
+		return queueURL, fmt.Errorf("subscribing %s: %w", name, err)
+	}
+	return queueURL, nil
+}
+
+// publishEvent publishes a message carrying eventType as its event_type
+// attribute
+func publishEvent(client *sns.SNS, topicARN, eventType string) error {
+	return publishWithAttributes(client, topicARN, "event "+eventType, map[string]interface{}{"event_type": eventType})
+}
+
+// publishWithAttributes publishes message with attrs as its message
+// attributes (see snsAttributes)
+func publishWithAttributes(client *sns.SNS, topicARN, message string, attrs map[string]interface{}) error {
+	values, err := snsAttributes(attrs)
+	if err != nil {
+		return err
+	}
+	_, err = client.Publish(&sns.PublishInput{
+		TopicArn:          aws.String(topicARN),
+		Message:           aws.String(message),
+		MessageAttributes: values,
+	})
+	return err
+}
+
+// snsAttributes converts attrs to SNS message attributes: a string is sent
+// as a String attribute, an integer or a float as a Number
+func snsAttributes(attrs map[string]interface{}) (map[string]*sns.MessageAttributeValue, error) {
+	values := make(map[string]*sns.MessageAttributeValue, len(attrs))
+	for name, v := range attrs {
+		var dataType, value string
+		switch v := v.(type) {
+		case string:
+			dataType, value = "String", v
+		case int:
+			dataType, value = "Number", strconv.Itoa(v)
+		case int64:
+			dataType, value = "Number", strconv.FormatInt(v, 10)
+		case float64:
+			dataType, value = "Number", strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("message attribute %s: %T is neither a string nor a number", name, v)
+		}
+		values[name] = &sns.MessageAttributeValue{DataType: aws.String(dataType), StringValue: aws.String(value)}
+	}
+	return values, nil
+}
+
+// envelopeAttribute is a message attribute as the SNS envelope of an SQS
+// message carries it
+type envelopeAttribute struct {
+	Type  string
+	Value string
+}
+
+// envelopeAttributes reads the message attributes from the SNS envelope
+// of msg
+func envelopeAttributes(msg *sqs.Message) (map[string]envelopeAttribute, error) {
+	var envelope struct {
+		MessageAttributes map[string]envelopeAttribute
+	}
+	if err := json.Unmarshal([]byte(aws.StringValue(msg.Body)), &envelope); err != nil {
+		return nil, fmt.Errorf("the body is not an SNS envelope: %w", err)
+	}
+	return envelope.MessageAttributes, nil
+}
+
+// eventType reads the event_type attribute from the SNS envelope of msg
+func eventType(msg *sqs.Message) (string, error) {
+	attrs, err := envelopeAttributes(msg)
+	if err != nil {
+		return "", err
+	}
+	return attrs["event_type"].Value, nil
+}
+
+// receiveBatch receives up to ten messages, waiting up to a second for
+// one to become visible
+func receiveBatch(client *sqs.SQS, queueURL string) ([]*sqs.Message, error) {
+	out, err := client.ReceiveMessage(&sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURL),
+		MaxNumberOfMessages: aws.Int64(10),
+		WaitTimeSeconds:     aws.Int64(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Messages, nil
+}
+
+// deleteSubscription removes the topic and queue of filteredSubscription,
+// ignoring errors
+func deleteSubscription(snsClient *sns.SNS, sqsClient *sqs.SQS, topicARN, queueURL string) {
+	if topicARN != "" {
+		snsClient.DeleteTopic(&sns.DeleteTopicInput{TopicArn: aws.String(topicARN)})
+	}
+	if queueURL != "" {
+		sqsClient.DeleteQueue(&sqs.DeleteQueueInput{QueueUrl: aws.String(queueURL)})
+	}
+}
@@ -0,0 +1,104 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/eventually"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloudEmuStateBackend bootstraps a state backend with the State Backend
+// facade, initializes a second configuration against it with the written
+// backend.hcl, and checks that its state lands in the bucket and that a
+// concurrent apply waits on the lock table
+func TestCloudEmuStateBackend(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	bucketName := fmt.Sprintf("tfstate-%d", time.Now().UnixNano())
+	backendConfig := filepath.Join(t.TempDir(), "backend.hcl")
+
+	backendOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/statebackend",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"bucket_name":         bucketName,
+			"backend_config_path": backendConfig,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, backendOptions)
+
+	concurrency.InitAndApply(t, backendOptions)
+
+	lockTable := terraform.Output(t, backendOptions, "lock_table_name")
+	assert.Equal(t, backendConfig, terraform.Output(t, backendOptions, "backend_config_file"))
+	verifyS3BucketExists(t, bucketName)
+	verifyDynamoDBTableExists(t, lockTable)
+
+	// BackendConfig only passes key=value pairs, so the file itself goes to
+	// init through TF_CLI_ARGS_init; the backend reads the emulator's
+	// placeholder credentials from the environment
+	consumerOptions := tfopts.New(t, &terraform.Options{
+		TerraformDir: test_structure.CopyTerraformFolderToTemp(t, "../..", "aws/test/fixtures/statebackend/testdata/consumer"),
+		EnvVars: map[string]string{
+			"TF_CLI_ARGS_init":      "-backend-config=" + backendConfig,
+			"AWS_ACCESS_KEY_ID":     "test",
+			"AWS_SECRET_ACCESS_KEY": "test",
+		},
+		Lock:        true,
+		LockTimeout: "60s",
+		NoColor:     true,
+	})
+
+	defer tfopts.Destroy(t, consumerOptions)
+
+	concurrency.Init(t, consumerOptions)
+	tfopts.Apply(t, consumerOptions)
+
+	objects, err := runAWS(t, "s3api", "list-objects-v2", "--bucket", bucketName, "--query", "Contents[].Key", "--output", "text")
+	require.NoError(t, err)
+	assert.Contains(t, strings.Fields(objects), "terraform.tfstate", "The consumer's state should be stored in the bucket")
+
+	// Hold the lock with a slow apply, and wait for its item in the table
+	holdOptions := *consumerOptions
+	holdOptions.Vars = map[string]interface{}{"hold_seconds": 20}
+	held := make(chan error, 1)
+	go func() {
+		_, err := tfopts.ApplyE(t, &holdOptions)
+		held <- err
+	}()
+
+	lockID := fmt.Sprintf(`{"LockID": {"S": %q}}`, bucketName+"/terraform.tfstate")
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		output, err := runAWS(t, "dynamodb", "get-item", "--table-name", lockTable, "--key", lockID)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, "Info") {
+			return fmt.Errorf("lock table %s holds no lock on %s yet", lockTable, bucketName)
+		}
+		return nil
+	})
+
+	contendOptions := *consumerOptions
+	contendOptions.LockTimeout = "0s"
+	output, err := tfopts.ApplyE(t, &contendOptions)
+	require.Error(t, err, "A second apply should not get the lock while the first holds it")
+	assert.Contains(t, output, "Error acquiring the state lock")
+
+	require.NoError(t, <-held, "The apply holding the lock should finish")
+}
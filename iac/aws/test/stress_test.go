@@ -0,0 +1,115 @@
+//go:build integration
+
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+	"iac/testutil/fanout"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// stressInstances is how many copies of the storage facade
+// TestCloudEmuConcurrentStorageCreates applies at once
+const stressInstances = 20
+
+// envRunStress opts in to TestCloudEmuConcurrentStorageCreates
+const envRunStress = "SWE_RUN_STRESS"
+
+// TestCloudEmuConcurrentStorageCreates applies stressInstances copies of the
+// storage facade at the same time, each in its own workspace with its own
+// bucket, checks every bucket exists, destroys them all and checks none is
+// left. Any failed apply or destroy fails the test; nothing is retried, so
+// a throttled or racing create is reported rather than hidden. Opt in with
+// SWE_RUN_STRESS=1.
+func TestCloudEmuConcurrentStorageCreates(t *testing.T) {
+	run, err := config.BoolFromEnv(envRunStress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run {
+		t.Skipf("Stress test; set %s=1 to run it", envRunStress)
+	}
+	ensureCloudEmuRunning(t)
+
+	throttle, err := concurrency.LoadOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dirs := fanout.Workspaces(t, "../..", "aws/test/fixtures/storage", stressInstances)
+
+	stamp := time.Now().Unix()
+	buckets := make([]string, stressInstances)
+	options := make([]*terraform.Options, stressInstances)
+	for i, dir := range dirs {
+		buckets[i] = fmt.Sprintf("stress-bucket-%d-%02d", stamp, i)
+		options[i] = tfopts.New(t, &terraform.Options{
+			TerraformDir: dir,
+			VarFiles:     []string{"stress.tfvars"},
+			Vars: cloudEmuVars(t, map[string]interface{}{
+				"bucket_name": buckets[i],
+			}),
+			NoColor: true,
+		})
+	}
+
+	// Init one workspace at a time, through the plugin cache lock, so only
+	// the applies race
+	for _, o := range options {
+		concurrency.Init(t, o)
+	}
+
+	// Hold every slot, so no other test deploys while the emulator is
+	// under load
+	concurrency.RunWeighted(t, int64(throttle.MaxParallel), func() {
+		applies := fanout.Run(stressInstances, func(i int) error {
+			_, err := tfopts.ApplyE(t, options[i])
+			return err
+		})
+		t.Logf("apply latency: %s", fanout.Latencies(applies))
+		assert.NoError(t, fanout.Errors(applies), "Every concurrent apply should succeed")
+
+		client := s3.New(newCloudEmuSession(t))
+		for i, r := range applies {
+			if r.Err == nil {
+				_, err := client.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(buckets[i])})
+				assert.NoError(t, err, "%s should exist after its apply", buckets[i])
+			}
+		}
+
+		// Destroy every workspace, failed applies included, since they may
+		// have created their bucket before failing
+		destroys := fanout.Run(stressInstances, func(i int) error {
+			_, err := tfopts.DestroyE(t, options[i])
+			return err
+		})
+		t.Logf("destroy latency: %s", fanout.Latencies(destroys))
+		assert.NoError(t, fanout.Errors(destroys), "Every concurrent destroy should succeed")
+
+		for _, name := range buckets {
+			_, err := client.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(name)})
+			assert.True(t, isMissingBucket(err), "%s should be gone after destroy, got %v", name, err)
+		}
+	})
+}
+
+// isMissingBucket reports whether err is S3's answer for a missing bucket
+func isMissingBucket(err error) bool {
+	var awsErr awserr.RequestFailure
+	if errors.As(err, &awsErr) {
+		return awsErr.StatusCode() == 404
+	}
+	return false
+}
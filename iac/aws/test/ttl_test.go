@@ -0,0 +1,113 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/eventually"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ttlAttribute is the fixture's ttl_attribute
+const ttlAttribute = "expires_at"
+
+// TestCloudEmuDynamoDBTTL deploys a table through the NoSQL facade with TTL
+// on, checks DescribeTimeToLive reports it enabled on the right attribute
+// and that an item whose expiry has already passed can still be written,
+// then checks a conditional PutItem refuses to overwrite an existing item.
+func TestCloudEmuDynamoDBTTL(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/nosql-ttl",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"table_name":    fmt.Sprintf("test-ttl-%d", time.Now().Unix()),
+			"ttl_attribute": ttlAttribute,
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	tableName := terraform.Output(t, terraformOptions, "table_name")
+	client := dynamodb.New(newCloudEmuSession(t))
+
+	t.Run("time to live", func(t *testing.T) {
+		// DynamoDB reports ENABLING for a while after the update
+		ttl := eventually.EventuallyValue(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() (*dynamodb.TimeToLiveDescription, error) {
+			out, err := client.DescribeTimeToLive(&dynamodb.DescribeTimeToLiveInput{TableName: aws.String(tableName)})
+			if err != nil {
+				return nil, err
+			}
+			if status := aws.StringValue(out.TimeToLiveDescription.TimeToLiveStatus); status != dynamodb.TimeToLiveStatusEnabled {
+				return nil, fmt.Errorf("TTL status is %s, want %s", status, dynamodb.TimeToLiveStatusEnabled)
+			}
+			return out.TimeToLiveDescription, nil
+		})
+		assert.Equal(t, ttlAttribute, aws.StringValue(ttl.AttributeName))
+
+		// Expired items are deleted in the background, not refused
+		expired := time.Now().Add(-time.Hour).Unix()
+		_, err := client.PutItem(&dynamodb.PutItemInput{
+			TableName: aws.String(tableName),
+			Item: map[string]*dynamodb.AttributeValue{
+				"id":         {S: aws.String("expired")},
+				ttlAttribute: {N: aws.String(strconv.FormatInt(expired, 10))},
+			},
+		})
+		require.NoError(t, err, "An item whose expiry has passed should still be written")
+
+		got, err := client.GetItem(&dynamodb.GetItemInput{
+			TableName: aws.String(tableName),
+			Key:       map[string]*dynamodb.AttributeValue{"id": {S: aws.String("expired")}},
+		})
+		require.NoError(t, err)
+		t.Logf("expired item still readable after write: %t", got.Item != nil)
+	})
+
+	t.Run("conditional write", func(t *testing.T) {
+		put := func(value string) error {
+			_, err := client.PutItem(&dynamodb.PutItemInput{
+				TableName: aws.String(tableName),
+				Item: map[string]*dynamodb.AttributeValue{
+					"id":    {S: aws.String("conditional")},
+					"value": {S: aws.String(value)},
+				},
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			})
+			return err
+		}
+
+		require.NoError(t, put("first"), "The first conditional write should succeed")
+
+		err := put("second")
+		require.Error(t, err, "A second write with attribute_not_exists(id) should be refused")
+		var aerr awserr.Error
+		require.ErrorAs(t, err, &aerr)
+		assert.Equal(t, dynamodb.ErrCodeConditionalCheckFailedException, aerr.Code())
+
+		got, err := client.GetItem(&dynamodb.GetItemInput{
+			TableName:      aws.String(tableName),
+			Key:            map[string]*dynamodb.AttributeValue{"id": {S: aws.String("conditional")}},
+			ConsistentRead: aws.Bool(true),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, got.Item)
+		assert.Equal(t, "first", aws.StringValue(got.Item["value"].S), "The refused write should leave the item unchanged")
+	})
+}
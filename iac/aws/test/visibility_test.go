@@ -0,0 +1,167 @@
+//go:build integration
+
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/eventually"
+	"iac/testutil/tfopts"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// visibilityTimeout is the queue's visibility_timeout_seconds
+	visibilityTimeout = 5 * time.Second
+
+	// extendedVisibility is what ChangeMessageVisibility extends the
+	// window to; far enough past visibilityTimeout to tell them apart
+	extendedVisibility = 10 * time.Second
+
+	// visibilityTolerance is how far either side of the window a message
+	// may reappear, for clock granularity in the emulator and the gaps
+	// between polls
+	visibilityTolerance = 2 * time.Second
+)
+
+// TestCloudEmuQueueVisibility walks one message through the SQS lifecycle
+// downstream services rely on: a received message is hidden for the
+// queue's visibility timeout, is redelivered once it expires, stays hidden
+// longer after ChangeMessageVisibility, and is gone once deleted. Observed
+// timings are logged, so drift between CloudEmu and SQS shows up even
+// while it is within tolerance.
+func TestCloudEmuQueueVisibility(t *testing.T) {
+	t.Parallel()
+
+	ensureCloudEmuRunning(t)
+
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: "fixtures/queue-visibility",
+		Vars: cloudEmuVars(t, map[string]interface{}{
+			"queue_name":                 fmt.Sprintf("test-visibility-%d", time.Now().Unix()),
+			"visibility_timeout_seconds": int(visibilityTimeout.Seconds()),
+		}),
+		NoColor: true,
+	}, tfopts.CloudEmu)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	concurrency.InitAndApply(t, terraformOptions)
+
+	queueURL := terraform.Output(t, terraformOptions, "queue_url")
+	client := sqs.New(newCloudEmuSession(t))
+
+	_, err := client.SendMessage(&sqs.SendMessageInput{
+		QueueUrl:    aws.String(queueURL),
+		MessageBody: aws.String("visibility test"),
+	})
+	require.NoError(t, err)
+
+	// A message sent a moment ago may not be receivable yet
+	first := eventually.EventuallyValue(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() (*sqs.Message, error) {
+		return receiveOne(client, queueURL)
+	})
+	received := time.Now()
+	assert.Equal(t, "1", aws.StringValue(first.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]))
+
+	again, err := receiveOne(client, queueURL)
+	assert.ErrorIs(t, err, errNoMessage, "A received message should be invisible to the next receive")
+	assert.Nil(t, again)
+
+	second, after := awaitRedelivery(t, client, queueURL, received, visibilityTimeout)
+	received = time.Now()
+	t.Logf("visibility timeout %s: redelivered after %s", visibilityTimeout, after.Round(time.Millisecond))
+	assert.Equal(t, "2", aws.StringValue(second.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]),
+		"The redelivered message should count the second receive")
+
+	_, err = client.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(queueURL),
+		ReceiptHandle:     second.ReceiptHandle,
+		VisibilityTimeout: aws.Int64(int64(extendedVisibility.Seconds())),
+	})
+	require.NoError(t, err)
+
+	third, after := awaitRedelivery(t, client, queueURL, received, extendedVisibility)
+	t.Logf("visibility extended to %s: redelivered after %s", extendedVisibility, after.Round(time.Millisecond))
+
+	_, err = client.DeleteMessage(&sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(queueURL),
+		ReceiptHandle: third.ReceiptHandle,
+	})
+	require.NoError(t, err, "Deleting with the latest receipt handle should succeed")
+
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		return queueEmpty(client, queueURL)
+	})
+}
+
+// errNoMessage is returned by receiveOne when no message is visible
+var errNoMessage = errors.New("no message visible")
+
+// receiveOne receives at most one message, with its receive count,
+// waiting up to a second for one to become visible
+func receiveOne(client *sqs.SQS, queueURL string) (*sqs.Message, error) {
+	out, err := client.ReceiveMessage(&sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURL),
+		MaxNumberOfMessages: aws.Int64(1),
+		WaitTimeSeconds:     aws.Int64(1),
+		AttributeNames:      []*string{aws.String(sqs.MessageSystemAttributeNameApproximateReceiveCount)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Messages) == 0 {
+		return nil, errNoMessage
+	}
+	return out.Messages[0], nil
+}
+
+// awaitRedelivery polls until the message received at since is visible
+// again and returns it with how long it was hidden, which must be within
+// visibilityTolerance of window
+func awaitRedelivery(t *testing.T, client *sqs.SQS, queueURL string, since time.Time, window time.Duration) (*sqs.Message, time.Duration) {
+	t.Helper()
+
+	// A short interval keeps the gaps between polls, and so the error in
+	// the measured time, well under the tolerance
+	msg := eventually.EventuallyValue(t, window+visibilityTolerance-time.Since(since), 50*time.Millisecond, func() (*sqs.Message, error) {
+		return receiveOne(client, queueURL)
+	})
+	hidden := time.Since(since)
+	if msg == nil {
+		return nil, hidden
+	}
+
+	assert.True(t, hidden >= window-visibilityTolerance,
+		"The message should stay hidden for about %s, but reappeared after %s", window, hidden)
+	return msg, hidden
+}
+
+// queueEmpty returns an error unless the queue holds no messages, visible
+// or in flight
+func queueEmpty(client *sqs.SQS, queueURL string) error {
+	out, err := client.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl: aws.String(queueURL),
+		AttributeNames: []*string{
+			aws.String(sqs.QueueAttributeNameApproximateNumberOfMessages),
+			aws.String(sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for name, count := range out.Attributes {
+		if aws.StringValue(count) != "0" {
+			return fmt.Errorf("%s is %s, want 0", name, aws.StringValue(count))
+		}
+	}
+	return nil
+}
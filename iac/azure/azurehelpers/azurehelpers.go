@@ -0,0 +1,137 @@
+// Package azurehelpers verifies Azure resources in the CloudEmu emulator
+// through the Azure data-plane APIs, so integration tests prove a resource
+// exists instead of trusting the Terraform output that names it.
+//
+// Blob and Cosmos DB calls go through the azblob and azcosmos SDKs using the
+// emulator's well-known credentials. Service Bus is driven over its HTTP
+// protocol: the azservicebus SDK only speaks AMQP, which CloudEmu does not
+// implement. Function invocation logs are read from the Function App's Kudu
+// (SCM) API.
+package azurehelpers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"iac/testutil/config"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+// Well-known emulator credentials (the same values Azurite and the Cosmos DB
+// emulator publish); they only work against local emulators
+const (
+	DevStoreAccountName = "devstoreaccount1"
+	DevStoreAccountKey  = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	CosmosEmulatorKey   = "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+)
+
+// Config points the helpers at an emulator
+type Config struct {
+	BlobEndpoint       string // e.g. http://localhost:10000/devstoreaccount1
+	CosmosEndpoint     string // e.g. http://localhost:10000
+	ServiceBusEndpoint string // e.g. http://localhost:10000
+	FunctionsEndpoint  string // e.g. http://localhost:10000; each app's Kudu API is under /<app name>
+	AccountName        string
+	AccountKey         string
+	CosmosKey          string
+
+	// Credential, when set, authenticates the blob and Cosmos DB clients
+	// in place of the account and Cosmos DB keys
+	Credential azcore.TokenCredential
+}
+
+// EmulatorConfig returns a Config for a CloudEmu instance serving every Azure
+// surface from a single endpoint, using the well-known credentials
+func EmulatorConfig(endpoint string) Config {
+	endpoint = strings.TrimRight(endpoint, "/")
+	return Config{
+		BlobEndpoint:       endpoint + "/" + DevStoreAccountName,
+		CosmosEndpoint:     endpoint,
+		ServiceBusEndpoint: endpoint,
+		FunctionsEndpoint:  endpoint,
+		AccountName:        DevStoreAccountName,
+		AccountKey:         DevStoreAccountKey,
+		CosmosKey:          CosmosEmulatorKey,
+	}
+}
+
+// NewFromTestConfig builds helpers for the Azure endpoint of the shared
+// integration test config, authenticating with its Azure credentials when
+// they are set
+func NewFromTestConfig(cfg *config.TestConfig) (*Helpers, error) {
+	cred, err := cfg.AzureCredential()
+	if err != nil {
+		return nil, fmt.Errorf("azurehelpers: %w", err)
+	}
+	c := EmulatorConfig(cfg.AzureEndpoint)
+	c.Credential = cred
+	return New(c)
+}
+
+// Helpers holds the data-plane clients for one emulator
+type Helpers struct {
+	blob       *azblob.Client
+	cosmos     *azcosmos.Client
+	serviceBus string
+	functions  string
+	httpClient *http.Client
+}
+
+// New builds the blob, Cosmos DB, Service Bus and Functions clients for cfg
+func New(cfg Config) (*Helpers, error) {
+	blobClient, err := NewBlobClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var cosmosClient *azcosmos.Client
+	if cfg.Credential != nil {
+		cosmosClient, err = azcosmos.NewClient(cfg.CosmosEndpoint, cfg.Credential, nil)
+	} else {
+		var cosmosCred azcosmos.KeyCredential
+		cosmosCred, err = azcosmos.NewKeyCredential(cfg.CosmosKey)
+		if err != nil {
+			return nil, fmt.Errorf("azurehelpers: cosmos credential: %w", err)
+		}
+		cosmosClient, err = azcosmos.NewClientWithKey(cfg.CosmosEndpoint, cosmosCred, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("azurehelpers: cosmos client for %s: %w", cfg.CosmosEndpoint, err)
+	}
+
+	return &Helpers{
+		blob:       blobClient,
+		cosmos:     cosmosClient,
+		serviceBus: strings.TrimRight(cfg.ServiceBusEndpoint, "/"),
+		functions:  strings.TrimRight(cfg.FunctionsEndpoint, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// NewBlobClient builds a blob client for cfg's storage account, with cfg's
+// Credential or, without one, the account key
+func NewBlobClient(cfg Config) (*azblob.Client, error) {
+	serviceURL := strings.TrimRight(cfg.BlobEndpoint, "/") + "/"
+	if cfg.Credential != nil {
+		client, err := azblob.NewClient(serviceURL, cfg.Credential, nil)
+		if err != nil {
+			return nil, fmt.Errorf("azurehelpers: blob client for %s: %w", cfg.BlobEndpoint, err)
+		}
+		return client, nil
+	}
+
+	blobCred, err := azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+	if err != nil {
+		return nil, fmt.Errorf("azurehelpers: blob credential: %w", err)
+	}
+	client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, blobCred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("azurehelpers: blob client for %s: %w", cfg.BlobEndpoint, err)
+	}
+	return client, nil
+}
@@ -0,0 +1,60 @@
+package azurehelpers
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+// VerifyContainerExists returns an error unless the blob container exists
+func (h *Helpers) VerifyContainerExists(ctx context.Context, container string) error {
+	_, err := h.blob.ServiceClient().NewContainerClient(container).GetProperties(ctx, nil)
+	if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+		return fmt.Errorf("azurehelpers: container %q does not exist", container)
+	}
+	if err != nil {
+		return fmt.Errorf("azurehelpers: reading container %q: %w", container, err)
+	}
+	return nil
+}
+
+// ListContainers returns the names of the account's blob containers
+func (h *Helpers) ListContainers(ctx context.Context) ([]string, error) {
+	var names []string
+	pager := h.blob.NewListContainersPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("azurehelpers: listing containers: %w", err)
+		}
+		for _, c := range page.ContainerItems {
+			names = append(names, *c.Name)
+		}
+	}
+	return names, nil
+}
+
+// UploadBlob writes data to container/name, overwriting any existing blob
+func (h *Helpers) UploadBlob(ctx context.Context, container, name string, data []byte) error {
+	if _, err := h.blob.UploadBuffer(ctx, container, name, data, nil); err != nil {
+		return fmt.Errorf("azurehelpers: uploading %s/%s: %w", container, name, err)
+	}
+	return nil
+}
+
+// DownloadBlob returns the contents of container/name
+func (h *Helpers) DownloadBlob(ctx context.Context, container, name string) ([]byte, error) {
+	resp, err := h.blob.DownloadStream(ctx, container, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("azurehelpers: downloading %s/%s: %w", container, name, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("azurehelpers: reading %s/%s: %w", container, name, err)
+	}
+	return data, nil
+}
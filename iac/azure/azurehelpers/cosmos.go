@@ -0,0 +1,57 @@
+package azurehelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+// VerifyTableExists returns an error unless the Cosmos DB (SQL API) container
+// exists in the database. The nosql facade calls its container a table.
+func (h *Helpers) VerifyTableExists(ctx context.Context, database, table string) error {
+	container, err := h.cosmos.NewContainer(database, table)
+	if err != nil {
+		return fmt.Errorf("azurehelpers: cosmos container %s/%s: %w", database, table, err)
+	}
+
+	_, err = container.Read(ctx, nil)
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("azurehelpers: cosmos container %s/%s does not exist", database, table)
+	}
+	if err != nil {
+		return fmt.Errorf("azurehelpers: reading cosmos container %s/%s: %w", database, table, err)
+	}
+	return nil
+}
+
+// Throughput is a Cosmos DB container's RU/s offer. Exactly one field is set
+// for a container with its own throughput; both are zero on serverless
+// accounts, which have no offers.
+type Throughput struct {
+	Manual       int32 // fixed RU/s (throughput_mode provisioned)
+	AutoscaleMax int32 // RU/s ceiling (throughput_mode autoscale)
+}
+
+// ReadThroughput returns the throughput provisioned on the container
+func (h *Helpers) ReadThroughput(ctx context.Context, database, table string) (Throughput, error) {
+	container, err := h.cosmos.NewContainer(database, table)
+	if err != nil {
+		return Throughput{}, fmt.Errorf("azurehelpers: cosmos container %s/%s: %w", database, table, err)
+	}
+
+	resp, err := container.ReadThroughput(ctx, nil)
+	if err != nil {
+		return Throughput{}, fmt.Errorf("azurehelpers: reading throughput of cosmos container %s/%s: %w", database, table, err)
+	}
+
+	var throughput Throughput
+	if resp.ThroughputProperties != nil {
+		throughput.Manual, _ = resp.ThroughputProperties.ManualThroughput()
+		throughput.AutoscaleMax, _ = resp.ThroughputProperties.AutoscaleMaxThroughput()
+	}
+	return throughput, nil
+}
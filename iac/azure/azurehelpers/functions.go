@@ -0,0 +1,80 @@
+package azurehelpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FunctionLogs returns the invocation log lines the Functions host wrote for
+// one function of a Function App, oldest first. They are read from the log
+// files Kudu serves under LogFiles/Application/Functions/Function/<function>.
+// Kudu answers 404 both when the function never ran and when the emulator
+// has no Kudu API; the error says so.
+func (h *Helpers) FunctionLogs(ctx context.Context, app, function string) ([]string, error) {
+	dir := fmt.Sprintf("%s/%s/api/vfs/LogFiles/Application/Functions/Function/%s/", h.functions, url.PathEscape(app), url.PathEscape(function))
+
+	var files []struct {
+		Name  string    `json:"name"`
+		MTime time.Time `json:"mtime"`
+	}
+	data, status, err := h.get(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("azurehelpers: listing logs of function %s/%s: %w", app, function, err)
+	}
+	switch status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("azurehelpers: no logs of function %s/%s: it never ran, or the emulator has no Kudu API", app, function)
+	default:
+		return nil, fmt.Errorf("azurehelpers: listing logs of function %s/%s: status %d: %s", app, function, status, data)
+	}
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("azurehelpers: decoding the log listing of function %s/%s: %w", app, function, err)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].MTime.Before(files[j].MTime) })
+
+	var lines []string
+	for _, f := range files {
+		data, status, err := h.get(ctx, dir+url.PathEscape(f.Name))
+		if err == nil && status != http.StatusOK {
+			err = fmt.Errorf("status %d: %s", status, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("azurehelpers: reading log %s of function %s/%s: %w", f.Name, app, function, err)
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, nil
+}
+
+// get reads endpoint and returns the body and status code of the response
+func (h *Helpers) get(ctx context.Context, endpoint string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return data, resp.StatusCode, nil
+}
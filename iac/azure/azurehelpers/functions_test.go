@@ -0,0 +1,61 @@
+package azurehelpers_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"iac/azure/azurehelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newKuduFake serves the log files of function copy_message of app
+// orders-func the way Kudu's vfs API does, listed newest first
+func newKuduFake(t *testing.T) *azurehelpers.Helpers {
+	const dir = "/orders-func/api/vfs/LogFiles/Application/Functions/Function/copy_message/"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case dir:
+			io.WriteString(w, `[
+				{"name": "2024-05-02T10-00-00Z-b.log", "mtime": "2024-05-02T10:00:00Z"},
+				{"name": "2024-05-01T10-00-00Z-a.log", "mtime": "2024-05-01T10:00:00Z"}
+			]`)
+		case dir + "2024-05-01T10-00-00Z-a.log":
+			io.WriteString(w, "Executing 'Functions.copy_message'\n\ncopying message msg-1 to msg-1.txt\n")
+		case dir + "2024-05-02T10-00-00Z-b.log":
+			io.WriteString(w, "Executed 'Functions.copy_message' (Failed)\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	helpers, err := azurehelpers.New(azurehelpers.EmulatorConfig(server.URL))
+	require.NoError(t, err)
+	return helpers
+}
+
+func TestFunctionLogs(t *testing.T) {
+	t.Parallel()
+
+	lines, err := newKuduFake(t).FunctionLogs(context.Background(), "orders-func", "copy_message")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Executing 'Functions.copy_message'",
+		"copying message msg-1 to msg-1.txt",
+		"Executed 'Functions.copy_message' (Failed)",
+	}, lines, "Files should be read oldest first, without blank lines")
+}
+
+func TestFunctionLogsNotFound(t *testing.T) {
+	t.Parallel()
+
+	lines, err := newKuduFake(t).FunctionLogs(context.Background(), "orders-func", "other")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "never ran, or the emulator has no Kudu API")
+	assert.Nil(t, lines)
+}
@@ -0,0 +1,102 @@
+package azurehelpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// QueueMessage is a message received from a Service Bus queue
+type QueueMessage struct {
+	ID   string
+	Body string
+}
+
+// SendQueueMessage posts body to a Service Bus queue
+func (h *Helpers) SendQueueMessage(ctx context.Context, queue, body string) error {
+	return h.SendQueueMessageWithID(ctx, queue, "", body)
+}
+
+// SendQueueMessageWithID posts body to a Service Bus queue as message id,
+// so a trigger that names its output after the message ID writes somewhere
+// the test knows. An empty id leaves the ID to the broker.
+func (h *Helpers) SendQueueMessageWithID(ctx context.Context, queue, id, body string) error {
+	endpoint := h.serviceBus + "/" + url.PathEscape(queue) + "/messages"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	if id != "" {
+		props, err := json.Marshal(map[string]string{"MessageId": id})
+		if err != nil {
+			return err
+		}
+		req.Header.Set("BrokerProperties", string(props))
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("azurehelpers: sending to queue %q: %w", queue, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("azurehelpers: sending to queue %q: status %d: %s", queue, resp.StatusCode, data)
+	}
+	return nil
+}
+
+// ReceiveQueueMessage removes and returns the head of a Service Bus queue
+// (receive-and-delete), or nil when the queue is empty
+func (h *Helpers) ReceiveQueueMessage(ctx context.Context, queue string) (*QueueMessage, error) {
+	endpoint := h.serviceBus + "/" + url.PathEscape(queue) + "/messages/head"
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("azurehelpers: receiving from queue %q: %w", queue, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("azurehelpers: reading message from queue %q: %w", queue, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusOK, http.StatusCreated:
+	default:
+		return nil, fmt.Errorf("azurehelpers: receiving from queue %q: status %d: %s", queue, resp.StatusCode, data)
+	}
+
+	// CloudEmu wraps the message in a JSON envelope; Service Bus returns the raw
+	// body and carries the message ID in the BrokerProperties header
+	var envelope struct {
+		MessageID string  `json:"messageId"`
+		Body      *string `json:"body"`
+	}
+	if json.Unmarshal(data, &envelope) == nil && envelope.Body != nil {
+		return &QueueMessage{ID: envelope.MessageID, Body: *envelope.Body}, nil
+	}
+
+	var broker struct {
+		MessageID string `json:"MessageId"`
+	}
+	if props := resp.Header.Get("BrokerProperties"); props != "" {
+		if err := json.Unmarshal([]byte(props), &broker); err != nil {
+			return nil, fmt.Errorf("azurehelpers: decoding BrokerProperties from queue %q: %w", queue, err)
+		}
+	}
+	return &QueueMessage{ID: broker.MessageID, Body: string(data)}, nil
+}
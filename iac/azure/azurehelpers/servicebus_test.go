@@ -0,0 +1,137 @@
+package azurehelpers_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"iac/azure/azurehelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServiceBusFake serves a single in-memory queue over the Service Bus HTTP
+// protocol, answering receives with CloudEmu's JSON envelope or, when raw is
+// set, with the real service's body + BrokerProperties header
+func newServiceBusFake(t *testing.T, raw bool) *azurehelpers.Helpers {
+	var queued []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/orders/messages":
+			body, _ := io.ReadAll(r.Body)
+			queued = append(queued, string(body))
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && r.URL.Path == "/orders/messages/head":
+			if len(queued) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			body := queued[0]
+			queued = queued[1:]
+			if raw {
+				w.Header().Set("BrokerProperties", `{"MessageId":"msg-1","DeliveryCount":1}`)
+				io.WriteString(w, body)
+				return
+			}
+			io.WriteString(w, `{"messageId":"msg-1","body":"`+body+`"}`)
+		default:
+			http.Error(w, "unsupported", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := azurehelpers.EmulatorConfig(server.URL)
+	helpers, err := azurehelpers.New(cfg)
+	require.NoError(t, err)
+	return helpers
+}
+
+func TestQueueRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for name, raw := range map[string]bool{"emulator envelope": false, "service bus body": true} {
+		raw := raw
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			helpers := newServiceBusFake(t, raw)
+			ctx := context.Background()
+
+			require.NoError(t, helpers.SendQueueMessage(ctx, "orders", "order-42"))
+
+			msg, err := helpers.ReceiveQueueMessage(ctx, "orders")
+			require.NoError(t, err)
+			require.NotNil(t, msg)
+			assert.Equal(t, "msg-1", msg.ID)
+			assert.Equal(t, "order-42", msg.Body)
+
+			msg, err = helpers.ReceiveQueueMessage(ctx, "orders")
+			require.NoError(t, err)
+			assert.Nil(t, msg, "Receive-and-delete should leave the queue empty")
+		})
+	}
+}
+
+func TestSendQueueMessageUnknownQueue(t *testing.T) {
+	t.Parallel()
+
+	helpers := newServiceBusFake(t, false)
+
+	err := helpers.SendQueueMessage(context.Background(), "missing", "hello")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `queue "missing"`)
+	assert.Contains(t, err.Error(), "status 400")
+}
+
+func TestReceiveQueueMessageMalformedBrokerProperties(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("BrokerProperties", `{"MessageId":`)
+		io.WriteString(w, "order-42")
+	}))
+	t.Cleanup(server.Close)
+
+	helpers, err := azurehelpers.New(azurehelpers.EmulatorConfig(server.URL))
+	require.NoError(t, err)
+
+	msg, err := helpers.ReceiveQueueMessage(context.Background(), "orders")
+	require.Error(t, err, "A malformed BrokerProperties header should not yield an empty message ID")
+	assert.Contains(t, err.Error(), "BrokerProperties")
+	assert.Nil(t, msg)
+}
+
+func TestSendQueueMessageWithID(t *testing.T) {
+	t.Parallel()
+
+	var props string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		props = r.Header.Get("BrokerProperties")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+
+	helpers, err := azurehelpers.New(azurehelpers.EmulatorConfig(server.URL))
+	require.NoError(t, err)
+
+	require.NoError(t, helpers.SendQueueMessageWithID(context.Background(), "orders", "msg-7", "hello"))
+	assert.JSONEq(t, `{"MessageId":"msg-7"}`, props)
+
+	require.NoError(t, helpers.SendQueueMessage(context.Background(), "orders", "hello"))
+	assert.Empty(t, props, "Without an ID the broker should pick one")
+}
+
+func TestEmulatorConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := azurehelpers.EmulatorConfig("http://localhost:10000/")
+
+	assert.Equal(t, "http://localhost:10000/devstoreaccount1", cfg.BlobEndpoint)
+	assert.Equal(t, "http://localhost:10000", cfg.CosmosEndpoint)
+	assert.Equal(t, "http://localhost:10000", cfg.ServiceBusEndpoint)
+	assert.Equal(t, "http://localhost:10000", cfg.FunctionsEndpoint)
+	assert.Equal(t, azurehelpers.DevStoreAccountName, cfg.AccountName)
+}
@@ -0,0 +1,66 @@
+# Azure Backup Core Module (Data Protection backup vault)
+
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
+resource "azurerm_data_protection_backup_vault" "this" {
+  name                = var.vault_name
+  resource_group_name = var.resource_group_name
+  location            = var.location
+  datastore_type      = "VaultStore"
+  redundancy          = var.redundancy
+
+  identity {
+    type = "SystemAssigned"
+  }
+
+  tags = var.tags
+}
+
+# Operational blob backup is continuous (point-in-time restore within the
+# retention window), so the policy has a retention but no schedule
+resource "azurerm_data_protection_backup_policy_blob_storage" "this" {
+  name               = "${var.policy_name}-blob"
+  vault_id           = azurerm_data_protection_backup_vault.this.id
+  retention_duration = "P${var.retention_days}D"
+}
+
+# The vault identity needs this role on each protected storage account
+resource "azurerm_role_assignment" "blob" {
+  count = length(var.storage_account_ids)
+
+  scope                = var.storage_account_ids[count.index]
+  role_definition_name = "Storage Account Backup Contributor"
+  principal_id         = azurerm_data_protection_backup_vault.this.identity[0].principal_id
+}
+
+resource "azurerm_data_protection_backup_instance_blob_storage" "this" {
+  count = length(var.storage_account_ids)
+
+  name               = "${var.policy_name}-blob-${count.index}"
+  vault_id           = azurerm_data_protection_backup_vault.this.id
+  location           = var.location
+  storage_account_id = var.storage_account_ids[count.index]
+  backup_policy_id   = azurerm_data_protection_backup_policy_blob_storage.this.id
+
+  depends_on = [azurerm_role_assignment.blob]
+}
+
+output "vault_id" {
+  value = azurerm_data_protection_backup_vault.this.id
+}
+
+output "policy_id" {
+  value = azurerm_data_protection_backup_policy_blob_storage.this.id
+}
+
+output "backup_instance_ids" {
+  description = "Backup instance ID of each protected storage account, in input order"
+  value       = azurerm_data_protection_backup_instance_blob_storage.this[*].id
+}
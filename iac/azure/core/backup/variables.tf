@@ -0,0 +1,42 @@
+variable "vault_name" {
+  description = "Name of the backup vault"
+  type        = string
+}
+
+variable "policy_name" {
+  description = "Prefix of the backup policy and backup instance names"
+  type        = string
+}
+
+variable "resource_group_name" {
+  description = "Resource group name"
+  type        = string
+}
+
+variable "location" {
+  description = "Azure region"
+  type        = string
+}
+
+variable "redundancy" {
+  description = "Vault storage redundancy (LocallyRedundant, GeoRedundant, ZoneRedundant)"
+  type        = string
+  default     = "LocallyRedundant"
+}
+
+variable "retention_days" {
+  description = "Days of operational blob backup kept (1-360)"
+  type        = number
+}
+
+variable "storage_account_ids" {
+  description = "IDs of the storage accounts to protect"
+  type        = list(string)
+  default     = []
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+  default     = {}
+}
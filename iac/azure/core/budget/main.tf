@@ -0,0 +1,45 @@
+# Azure Consumption Budget Core Module (resource group scope)
+
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
+data "azurerm_client_config" "current" {}
+
+resource "azurerm_consumption_budget_resource_group" "this" {
+  name              = var.name
+  resource_group_id = "/subscriptions/${data.azurerm_client_config.current.subscription_id}/resourceGroups/${var.resource_group_name}"
+  amount            = var.amount
+  time_grain        = "Monthly"
+
+  # The start must be the first of the current month, so it is taken once
+  # and kept
+  time_period {
+    start_date = coalesce(var.start_date, formatdate("YYYY-MM-01'T'00:00:00'Z'", timestamp()))
+  }
+
+  dynamic "notification" {
+    for_each = var.threshold_percentages
+    content {
+      enabled        = true
+      threshold      = notification.value
+      operator       = "GreaterThan"
+      threshold_type = "Actual"
+      contact_emails = var.contact_emails
+      contact_groups = var.action_group_id != null ? [var.action_group_id] : []
+    }
+  }
+
+  lifecycle {
+    ignore_changes = [time_period]
+  }
+}
+
+output "budget_id" {
+  value = azurerm_consumption_budget_resource_group.this.id
+}
@@ -0,0 +1,37 @@
+variable "name" {
+  description = "Budget name"
+  type        = string
+}
+
+variable "resource_group_name" {
+  description = "Resource group the budget tracks"
+  type        = string
+}
+
+variable "amount" {
+  description = "Monthly limit in the billing account's currency"
+  type        = number
+}
+
+variable "start_date" {
+  description = "First of the month the budget starts (RFC 3339); null for the current month"
+  type        = string
+  default     = null
+}
+
+variable "threshold_percentages" {
+  description = "Percentages of the limit that send a notification"
+  type        = list(number)
+}
+
+variable "contact_emails" {
+  description = "Email addresses notified"
+  type        = list(string)
+  default     = []
+}
+
+variable "action_group_id" {
+  description = "Monitor action group notified"
+  type        = string
+  default     = null
+}
@@ -0,0 +1,158 @@
+# Azure Front Door (Standard) Core Module
+
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
+locals {
+  # Front Door cache durations are d.hh:mm:ss
+  cache_duration = format("%d.%02d:%02d:%02d",
+    floor(var.default_ttl / 86400),
+    floor(var.default_ttl % 86400 / 3600),
+    floor(var.default_ttl % 3600 / 60),
+    var.default_ttl % 60,
+  )
+
+  # Rule set and rule names are letters and digits only
+  rule_name = replace(title(replace(var.name, "-", " ")), " ", "")
+}
+
+resource "azurerm_cdn_frontdoor_profile" "this" {
+  name                = var.name
+  resource_group_name = var.resource_group_name
+  sku_name            = var.sku_name
+
+  tags = var.tags
+}
+
+resource "azurerm_cdn_frontdoor_endpoint" "this" {
+  name                     = var.name
+  cdn_frontdoor_profile_id = azurerm_cdn_frontdoor_profile.this.id
+
+  tags = var.tags
+}
+
+resource "azurerm_cdn_frontdoor_origin_group" "this" {
+  name                     = "${var.name}-origins"
+  cdn_frontdoor_profile_id = azurerm_cdn_frontdoor_profile.this.id
+
+  load_balancing {
+    sample_size                 = 4
+    successful_samples_required = 3
+  }
+}
+
+resource "azurerm_cdn_frontdoor_origin" "this" {
+  name                          = "${var.name}-origin"
+  cdn_frontdoor_origin_group_id = azurerm_cdn_frontdoor_origin_group.this.id
+  enabled                       = true
+
+  host_name                      = var.origin_host_name
+  origin_host_header             = var.origin_host_name
+  http_port                      = 80
+  https_port                     = 443
+  priority                       = 1
+  weight                         = 1000
+  certificate_name_check_enabled = true
+}
+
+resource "azurerm_cdn_frontdoor_rule_set" "this" {
+  name                     = "${local.rule_name}Cache"
+  cdn_frontdoor_profile_id = azurerm_cdn_frontdoor_profile.this.id
+}
+
+# Applies default_ttl where the origin sends no Cache-Control
+resource "azurerm_cdn_frontdoor_rule" "cache" {
+  name                      = "DefaultTtl"
+  cdn_frontdoor_rule_set_id = azurerm_cdn_frontdoor_rule_set.this.id
+  order                     = 1
+  behavior_on_match         = "Continue"
+
+  actions {
+    route_configuration_override_action {
+      cache_behavior                = "OverrideIfOriginMissing"
+      cache_duration                = local.cache_duration
+      query_string_caching_behavior = "IgnoreQueryString"
+      compression_enabled           = true
+    }
+  }
+
+  depends_on = [azurerm_cdn_frontdoor_origin_group.this, azurerm_cdn_frontdoor_origin.this]
+}
+
+# Custom domains use the caller's Key Vault certificate; Front Door must be
+# allowed to read it
+resource "azurerm_cdn_frontdoor_secret" "this" {
+  count = var.key_vault_certificate_id != null ? 1 : 0
+
+  name                     = "${var.name}-certificate"
+  cdn_frontdoor_profile_id = azurerm_cdn_frontdoor_profile.this.id
+
+  secret {
+    customer_certificate {
+      key_vault_certificate_id = var.key_vault_certificate_id
+    }
+  }
+}
+
+resource "azurerm_cdn_frontdoor_custom_domain" "this" {
+  for_each = toset(var.custom_domains)
+
+  name                     = replace(each.key, ".", "-")
+  cdn_frontdoor_profile_id = azurerm_cdn_frontdoor_profile.this.id
+  host_name                = each.key
+
+  tls {
+    certificate_type        = "CustomerCertificate"
+    minimum_tls_version     = "TLS12"
+    cdn_frontdoor_secret_id = azurerm_cdn_frontdoor_secret.this[0].id
+  }
+}
+
+resource "azurerm_cdn_frontdoor_route" "this" {
+  name                          = "${var.name}-route"
+  cdn_frontdoor_endpoint_id     = azurerm_cdn_frontdoor_endpoint.this.id
+  cdn_frontdoor_origin_group_id = azurerm_cdn_frontdoor_origin_group.this.id
+  cdn_frontdoor_origin_ids      = [azurerm_cdn_frontdoor_origin.this.id]
+  cdn_frontdoor_rule_set_ids    = [azurerm_cdn_frontdoor_rule_set.this.id]
+  enabled                       = true
+
+  supported_protocols    = ["Http", "Https"]
+  https_redirect_enabled = true
+  forwarding_protocol    = "HttpsOnly"
+  patterns_to_match      = ["/*"]
+
+  cdn_frontdoor_custom_domain_ids = [for domain in azurerm_cdn_frontdoor_custom_domain.this : domain.id]
+  link_to_default_domain          = true
+
+  cache {
+    query_string_caching_behavior = "IgnoreQueryString"
+    compression_enabled           = true
+    content_types_to_compress     = ["text/html", "text/css", "application/javascript", "application/json", "image/svg+xml"]
+  }
+}
+
+resource "azurerm_cdn_frontdoor_custom_domain_association" "this" {
+  for_each = azurerm_cdn_frontdoor_custom_domain.this
+
+  cdn_frontdoor_custom_domain_id = each.value.id
+  cdn_frontdoor_route_ids        = [azurerm_cdn_frontdoor_route.this.id]
+}
+
+output "profile_id" {
+  value = azurerm_cdn_frontdoor_profile.this.id
+}
+
+output "endpoint_host_name" {
+  value = azurerm_cdn_frontdoor_endpoint.this.host_name
+}
+
+output "custom_domain_validation_tokens" {
+  description = "TXT record value proving ownership of each custom domain (_dnsauth.<domain>)"
+  value       = { for name, domain in azurerm_cdn_frontdoor_custom_domain.this : name => domain.validation_token }
+}
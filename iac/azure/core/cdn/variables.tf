@@ -0,0 +1,44 @@
+variable "name" {
+  description = "Name of the Front Door profile and endpoint"
+  type        = string
+}
+
+variable "resource_group_name" {
+  description = "Resource group name"
+  type        = string
+}
+
+variable "sku_name" {
+  description = "Front Door SKU (Standard_AzureFrontDoor, Premium_AzureFrontDoor)"
+  type        = string
+  default     = "Standard_AzureFrontDoor"
+}
+
+variable "origin_host_name" {
+  description = "Origin host name, e.g. the storage account's static website host"
+  type        = string
+}
+
+variable "custom_domains" {
+  description = "Custom domain names routed to the endpoint"
+  type        = list(string)
+  default     = []
+}
+
+variable "key_vault_certificate_id" {
+  description = "Key Vault certificate covering the custom domains"
+  type        = string
+  default     = null
+}
+
+variable "default_ttl" {
+  description = "Seconds objects stay cached when the origin sends no Cache-Control"
+  type        = number
+  default     = 3600
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+  default     = {}
+}
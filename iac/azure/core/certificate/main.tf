@@ -0,0 +1,65 @@
+# Azure Key Vault Certificate Core Module
+# Issued by a certificate authority integrated with the vault (an issuer
+# configured on it, e.g. DigiCert or GlobalSign), which validates the domains
+
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
+resource "azurerm_key_vault_certificate" "this" {
+  name         = var.name
+  key_vault_id = var.key_vault_id
+
+  certificate_policy {
+    issuer_parameters {
+      name = var.issuer_name
+    }
+
+    key_properties {
+      exportable = true
+      key_size   = 2048
+      key_type   = "RSA"
+      reuse_key  = false
+    }
+
+    lifetime_action {
+      action {
+        action_type = "AutoRenew"
+      }
+      trigger {
+        days_before_expiry = 30
+      }
+    }
+
+    secret_properties {
+      content_type = "application/x-pkcs12"
+    }
+
+    x509_certificate_properties {
+      subject            = "CN=${var.domain_name}"
+      validity_in_months = var.validity_in_months
+      key_usage          = ["digitalSignature", "keyEncipherment"]
+      extended_key_usage = ["1.3.6.1.5.5.7.3.1"] # server authentication
+
+      subject_alternative_names {
+        dns_names = distinct(concat([var.domain_name], var.subject_alternative_names))
+      }
+    }
+  }
+
+  tags = var.tags
+}
+
+output "certificate_id" {
+  description = "Versionless certificate ID, so renewals are picked up"
+  value       = azurerm_key_vault_certificate.this.versionless_id
+}
+
+output "status" {
+  value = azurerm_key_vault_certificate.this.certificate_attribute[0].enabled ? "ISSUED" : "DISABLED"
+}
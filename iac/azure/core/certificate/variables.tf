@@ -0,0 +1,38 @@
+variable "name" {
+  description = "Certificate name in the vault"
+  type        = string
+}
+
+variable "key_vault_id" {
+  description = "Key Vault holding the certificate"
+  type        = string
+}
+
+variable "issuer_name" {
+  description = "Certificate issuer configured on the vault"
+  type        = string
+  default     = "DigiCert"
+}
+
+variable "domain_name" {
+  description = "Primary domain name (certificate subject)"
+  type        = string
+}
+
+variable "subject_alternative_names" {
+  description = "Additional domain names covered by the certificate"
+  type        = list(string)
+  default     = []
+}
+
+variable "validity_in_months" {
+  description = "Certificate validity; Key Vault renews it 30 days before expiry"
+  type        = number
+  default     = 12
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+  default     = {}
+}
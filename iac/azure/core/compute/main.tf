@@ -10,6 +10,12 @@ terraform {
   }
 }
 
+# Trusted Launch needs a generation 2 image, which Marketplace SKUs mark
+# with a gen2 suffix
+locals {
+  trusted_launch = var.trusted_launch && can(regex("gen2$", var.image_sku))
+}
+
 resource "azurerm_linux_virtual_machine" "this" {
   name                = var.vm_name
   resource_group_name = var.resource_group_name
@@ -39,6 +45,16 @@ resource "azurerm_linux_virtual_machine" "this" {
     sku       = var.image_sku
     version   = var.image_version
   }
+
+  secure_boot_enabled = local.trusted_launch
+  vtpm_enabled        = local.trusted_launch
+
+  dynamic "identity" {
+    for_each = var.system_assigned_identity ? [1] : []
+    content {
+      type = "SystemAssigned"
+    }
+  }
   
   tags = var.tags
 }
@@ -70,6 +86,108 @@ resource "azurerm_public_ip" "this" {
   tags = var.tags
 }
 
+# ============================================================================
+# DATA DISKS
+# ============================================================================
+
+locals {
+  data_volumes = { for v in var.data_volumes : v.mount_name => v }
+
+  # Disks are attached in list order from LUN 0
+  luns = { for i, v in var.data_volumes : v.mount_name => i }
+
+  backed_up = { for k, v in local.data_volumes : k => v if v.backup_interval != null }
+
+  # The resource group ID, without a data source: the VM's ID up to the
+  # resource group name
+  resource_group_id = join("/", slice(split("/", azurerm_linux_virtual_machine.this.id), 0, 5))
+}
+
+resource "azurerm_managed_disk" "data" {
+  for_each = local.data_volumes
+
+  name                 = each.value.name
+  location             = var.location
+  resource_group_name  = var.resource_group_name
+  storage_account_type = each.value.type
+  create_option        = "Empty"
+  disk_size_gb         = each.value.size_gb
+
+  tags = var.tags
+}
+
+resource "azurerm_virtual_machine_data_disk_attachment" "data" {
+  for_each = local.data_volumes
+
+  managed_disk_id    = azurerm_managed_disk.data[each.key].id
+  virtual_machine_id = azurerm_linux_virtual_machine.this.id
+  lun                = local.luns[each.key]
+
+  # Host caching is not supported on disks of 4 TiB and more
+  caching = each.value.size_gb < 4096 ? "ReadWrite" : "None"
+}
+
+# Azure Disk Backup takes incremental snapshots into the VM's resource
+# group on each disk's policy
+resource "azurerm_data_protection_backup_vault" "disks" {
+  count = length(local.backed_up) > 0 ? 1 : 0
+
+  name                = var.backup_vault_name
+  resource_group_name = var.resource_group_name
+  location            = var.location
+  datastore_type      = "VaultStore"
+  redundancy          = "LocallyRedundant"
+
+  identity {
+    type = "SystemAssigned"
+  }
+
+  tags = var.tags
+}
+
+resource "azurerm_data_protection_backup_policy_disk" "data" {
+  for_each = local.backed_up
+
+  name                            = each.value.name
+  vault_id                        = azurerm_data_protection_backup_vault.disks[0].id
+  backup_repeating_time_intervals = [each.value.backup_interval]
+  default_retention_duration      = "P${each.value.retention_days}D"
+}
+
+# The vault identity reads each disk and writes snapshots to the resource
+# group
+resource "azurerm_role_assignment" "disk_backup_reader" {
+  for_each = local.backed_up
+
+  scope                = azurerm_managed_disk.data[each.key].id
+  role_definition_name = "Disk Backup Reader"
+  principal_id         = azurerm_data_protection_backup_vault.disks[0].identity[0].principal_id
+}
+
+resource "azurerm_role_assignment" "disk_snapshot_contributor" {
+  count = length(local.backed_up) > 0 ? 1 : 0
+
+  scope                = local.resource_group_id
+  role_definition_name = "Disk Snapshot Contributor"
+  principal_id         = azurerm_data_protection_backup_vault.disks[0].identity[0].principal_id
+}
+
+resource "azurerm_data_protection_backup_instance_disk" "data" {
+  for_each = local.backed_up
+
+  name                         = each.value.name
+  location                     = var.location
+  vault_id                     = azurerm_data_protection_backup_vault.disks[0].id
+  disk_id                      = azurerm_managed_disk.data[each.key].id
+  snapshot_resource_group_name = var.resource_group_name
+  backup_policy_id             = azurerm_data_protection_backup_policy_disk.data[each.key].id
+
+  depends_on = [
+    azurerm_role_assignment.disk_backup_reader,
+    azurerm_role_assignment.disk_snapshot_contributor,
+  ]
+}
+
 # Outputs
 output "vm_id" {
   description = "Virtual machine ID"
@@ -95,3 +213,8 @@ output "network_interface_id" {
   description = "Network interface ID"
   value       = azurerm_network_interface.this.id
 }
+
+output "data_volume_ids" {
+  description = "Managed disk ID of each data disk, by mount name"
+  value       = { for name, disk in azurerm_managed_disk.data : name => disk.id }
+}
@@ -70,8 +70,39 @@ variable "image_version" {
   default     = "latest"
 }
 
+variable "trusted_launch" {
+  description = "Turn on secure boot and the vTPM, when image_sku is a generation 2 image"
+  type        = bool
+  default     = true
+}
+
+variable "system_assigned_identity" {
+  description = "Give the VM a system-assigned managed identity, and no user-assigned one"
+  type        = bool
+  default     = true
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
   default     = {}
 }
+
+variable "data_volumes" {
+  description = "Managed data disks to attach, in order from LUN 0, keyed by mount_name. A disk with a backup_interval, an ISO 8601 repeating interval such as R/2024-01-01T03:00:00+00:00/P1D, is backed up on it by Azure Disk Backup and each snapshot kept retention_days."
+  type = list(object({
+    mount_name      = string
+    name            = string
+    size_gb         = number
+    type            = string
+    backup_interval = optional(string)
+    retention_days  = optional(number)
+  }))
+  default = []
+}
+
+variable "backup_vault_name" {
+  description = "Name of the Data Protection backup vault created when a data disk has a backup_interval"
+  type        = string
+  default     = null
+}
@@ -10,6 +10,17 @@ terraform {
   }
 }
 
+# Identity used by the server to reach the TDE protector key
+resource "azurerm_user_assigned_identity" "cmk" {
+  count = var.tde_key_vault_key_id != null ? 1 : 0
+  
+  name                = "${var.server_name}-cmk"
+  resource_group_name = var.resource_group_name
+  location            = var.location
+  
+  tags = var.tags
+}
+
 resource "azurerm_mssql_server" "this" {
   name                         = var.server_name
   resource_group_name          = var.resource_group_name
@@ -20,6 +31,18 @@ resource "azurerm_mssql_server" "this" {
   
   minimum_tls_version = "1.2"
   
+  # Transparent Data Encryption with a customer-managed key
+  dynamic "identity" {
+    for_each = var.tde_key_vault_key_id != null ? [1] : []
+    content {
+      type         = "UserAssigned"
+      identity_ids = [azurerm_user_assigned_identity.cmk[0].id]
+    }
+  }
+  
+  primary_user_assigned_identity_id            = var.tde_key_vault_key_id != null ? azurerm_user_assigned_identity.cmk[0].id : null
+  transparent_data_encryption_key_vault_key_id = var.tde_key_vault_key_id
+  
   tags = var.tags
 }
 
@@ -64,6 +87,11 @@ output "server_fqdn" {
   value       = azurerm_mssql_server.this.fully_qualified_domain_name
 }
 
+output "cmk_identity_principal_id" {
+  description = "Principal ID that must be granted access to the TDE key"
+  value       = var.tde_key_vault_key_id != null ? azurerm_user_assigned_identity.cmk[0].principal_id : null
+}
+
 output "database_id" {
   description = "Database ID"
   value       = azurerm_mssql_database.this.id
@@ -68,6 +68,12 @@ variable "allowed_ip_ranges" {
   default = []
 }
 
+variable "tde_key_vault_key_id" {
+  description = "Key Vault key ID used as the TDE protector (optional)"
+  type        = string
+  default     = null
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
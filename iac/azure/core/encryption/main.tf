@@ -1,5 +1,14 @@
 # Azure Key Vault Key
 
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
 resource "azurerm_key_vault_key" "this" {
   name         = var.name
   key_vault_id = var.key_vault_id
@@ -22,6 +31,10 @@ output "key_id" {
   value = azurerm_key_vault_key.this.id
 }
 
+output "versionless_id" {
+  value = azurerm_key_vault_key.this.versionless_id
+}
+
 output "key_name" {
   value = azurerm_key_vault_key.this.name
 }
@@ -1,12 +1,53 @@
 # Azure Event Grid Topic
 
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
 resource "azurerm_eventgrid_topic" "this" {
   name                = var.name
   location            = var.location
   resource_group_name = var.resource_group_name
+  input_schema        = var.input_schema
   tags                = var.tags
 }
 
+# Event Subscriptions
+# Each delivers matching events to one Function or Service Bus queue
+resource "azurerm_eventgrid_event_subscription" "this" {
+  for_each = { for s in var.subscriptions : s.name => s }
+
+  name                  = each.key
+  scope                 = azurerm_eventgrid_topic.this.id
+  event_delivery_schema = var.input_schema
+
+  included_event_types = length(each.value.included_event_types) > 0 ? each.value.included_event_types : null
+
+  dynamic "advanced_filter" {
+    for_each = length(each.value.sources) > 0 ? [1] : []
+    content {
+      string_in {
+        key    = "source"
+        values = each.value.sources
+      }
+    }
+  }
+
+  dynamic "azure_function_endpoint" {
+    for_each = each.value.target_type == "lambda" ? [1] : []
+    content {
+      function_id = each.value.target_id
+    }
+  }
+
+  service_bus_queue_endpoint_id = each.value.target_type == "queue" ? each.value.target_id : null
+}
+
 output "topic_id" {
   value = azurerm_eventgrid_topic.this.id
 }
@@ -18,3 +59,8 @@ output "topic_name" {
 output "endpoint" {
   value = azurerm_eventgrid_topic.this.endpoint
 }
+
+output "subscription_ids" {
+  description = "ID of each event subscription, by name"
+  value       = { for name, s in azurerm_eventgrid_event_subscription.this : name => s.id }
+}
@@ -13,6 +13,24 @@ variable "location" {
   type        = string
 }
 
+variable "input_schema" {
+  description = "Schema events are published in, and delivered in (EventGridSchema, CloudEventSchemaV1_0)"
+  type        = string
+  default     = "EventGridSchema"
+}
+
+variable "subscriptions" {
+  description = "Event subscriptions on the topic; sources filters on the CloudEvents source attribute, so it needs input_schema CloudEventSchemaV1_0"
+  type = list(object({
+    name                 = string
+    included_event_types = list(string)
+    sources              = list(string)
+    target_type          = string # lambda (a Function ID) or queue (a Service Bus queue ID)
+    target_id            = string
+  }))
+  default = []
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
@@ -7,6 +7,10 @@ terraform {
       source  = "hashicorp/azurerm"
       version = "~> 3.0"
     }
+    azuread = {
+      source  = "hashicorp/azuread"
+      version = "~> 2.47"
+    }
   }
 }
 
@@ -39,6 +43,34 @@ resource "azurerm_role_assignment" "subscription" {
   principal_id         = var.create_identity ? azurerm_user_assigned_identity.this[0].principal_id : var.principal_id
 }
 
+# Cross-Tenant Role Assignments
+# One assignment per (principal, role) pair. The AAD check is skipped because
+# principals from other tenants cannot be resolved in the local directory.
+resource "azurerm_role_assignment" "trusted" {
+  for_each = var.scope_id != null ? {
+    for pair in setproduct(var.trusted_principal_ids, var.trusted_role_definition_names) :
+    "${pair[0]}/${pair[1]}" => {
+      principal_id         = pair[0]
+      role_definition_name = pair[1]
+    }
+  } : {}
+
+  scope                            = var.scope_id
+  role_definition_name             = each.value.role_definition_name
+  principal_id                     = each.value.principal_id
+  skip_service_principal_aad_check = true
+}
+
+# Role Assignments (at single resources)
+# Counted rather than keyed by scope, which is often not known until apply
+resource "azurerm_role_assignment" "resource" {
+  count = var.create_identity ? length(var.resource_role_assignments) : 0
+
+  scope                = var.resource_role_assignments[count.index].scope
+  role_definition_name = var.resource_role_assignments[count.index].role_definition_name
+  principal_id         = azurerm_user_assigned_identity.this[0].principal_id
+}
+
 # Custom Role Definition
 resource "azurerm_role_definition" "this" {
   count = var.create_role_definition ? 1 : 0
@@ -55,6 +87,29 @@ resource "azurerm_role_definition" "this" {
   assignable_scopes = [var.scope_id]
 }
 
+# Application Password
+# Managed identities cannot hold secrets, so credentials for callers outside
+# Azure (CI bootstrap) come from an app registration and its service principal
+resource "azuread_application" "this" {
+  count = var.create_application_password ? 1 : 0
+
+  display_name = var.identity_name
+}
+
+resource "azuread_service_principal" "this" {
+  count = var.create_application_password ? 1 : 0
+
+  client_id = azuread_application.this[0].client_id
+}
+
+resource "azuread_application_password" "this" {
+  count = var.create_application_password ? 1 : 0
+
+  application_id    = azuread_application.this[0].id
+  display_name      = "${var.identity_name}-credentials"
+  end_date_relative = var.password_expiry_days != null ? "${var.password_expiry_days * 24}h" : null
+}
+
 # Outputs
 output "identity_id" {
   description = "The ID of the User Assigned Identity"
@@ -71,6 +126,17 @@ output "identity_client_id" {
   value       = var.create_identity ? azurerm_user_assigned_identity.this[0].client_id : null
 }
 
+output "application_client_id" {
+  description = "Client ID of the app registration"
+  value       = var.create_application_password ? azuread_application.this[0].client_id : null
+}
+
+output "application_password" {
+  description = "Client secret of the app registration"
+  value       = var.create_application_password ? azuread_application_password.this[0].value : null
+  sensitive   = true
+}
+
 output "role_definition_id" {
   description = "The Role Definition ID"
   value       = var.create_role_definition ? azurerm_role_definition.this[0].role_definition_id : null
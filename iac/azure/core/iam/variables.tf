@@ -53,6 +53,28 @@ variable "principal_id" {
   default     = null
 }
 
+# Cross-Tenant Principals
+variable "trusted_principal_ids" {
+  description = "Object IDs (from any tenant) granted the trusted roles at scope_id"
+  type        = list(string)
+  default     = []
+}
+
+variable "trusted_role_definition_names" {
+  description = "Built-in role names assigned to each trusted principal"
+  type        = list(string)
+  default     = []
+}
+
+variable "resource_role_assignments" {
+  description = "Built-in roles assigned to the created identity, each at its own scope (e.g. a storage container or Service Bus queue ID)"
+  type = list(object({
+    role_definition_name = string
+    scope                = string
+  }))
+  default = []
+}
+
 # Custom Role Definitions
 variable "create_role_definition" {
   description = "Create custom role definition"
@@ -78,6 +100,19 @@ variable "role_actions" {
   default     = []
 }
 
+# Application Password Variables
+variable "create_application_password" {
+  description = "Create an app registration with a client secret, for callers outside Azure"
+  type        = bool
+  default     = false
+}
+
+variable "password_expiry_days" {
+  description = "Days until the client secret expires; null lets it default to two years"
+  type        = number
+  default     = null
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
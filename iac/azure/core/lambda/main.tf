@@ -1,21 +1,67 @@
+# Azure Functions (Linux Function App)
+# Counterpart of the AWS Lambda core module
+
 terraform {
   required_providers {
     azurerm = {
-      source = "hashicorp/azurerm"
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+    null = {
+      source  = "hashicorp/null"
+      version = "~> 3.0"
     }
   }
 }
 
-variable "function_name" { type = string }
-variable "handler" { type = string }
-variable "runtime" { type = string }
-variable "filename" { type = string }
-variable "environment_variables" { type = map(string) }
-variable "tags" { type = map(string) }
+locals {
+  # Consumption (Y1) caps out at 1.5 GB and cannot join a VNet, so larger or
+  # private functions move to the smallest Elastic Premium tier that fits.
+  sku_name = (
+    var.vpc_subnet_id == null && var.memory_size <= 1536 ? "Y1" :
+    var.memory_size <= 3584 ? "EP1" :
+    var.memory_size <= 7168 ? "EP2" :
+    "EP3"
+  )
+
+  # host.json functionTimeout expressed as hh:mm:ss
+  function_timeout = format("%02d:%02d:%02d", floor(var.timeout / 3600), floor(var.timeout % 3600 / 60), var.timeout % 60)
+
+  # Runtime arrives as "STACK|version" (e.g. PYTHON|3.9, NODE|18)
+  runtime_parts   = split("|", coalesce(var.runtime, "|"))
+  runtime_stack   = lower(local.runtime_parts[0])
+  runtime_version = try(local.runtime_parts[1], null)
+
+  base_app_settings = merge(var.environment_variables, {
+    FUNCTIONS_WORKER_RUNTIME               = local.runtime_stack
+    AzureFunctionsJobHost__functionTimeout = local.function_timeout
+  })
+
+  # The package hash is carried as an app setting so a code change always
+  # produces a diff on the Function App and re-runs the zip deploy.
+  app_settings = merge(local.base_app_settings, var.source_code_hash != null ? {
+    SOURCE_CODE_HASH = var.source_code_hash
+  } : {})
+
+  canary = var.alias_name != null && var.canary_weight != null
+}
 
 resource "azurerm_resource_group" "this" {
   name     = "${var.function_name}-rg"
-  location = "East US"
+  location = var.location
+
+  tags = var.tags
+}
+
+# Identity used by the backing storage account to reach the customer-managed key
+resource "azurerm_user_assigned_identity" "cmk" {
+  count = var.customer_managed_key_id != null ? 1 : 0
+
+  name                = "${var.function_name}-cmk"
+  resource_group_name = azurerm_resource_group.this.name
+  location            = azurerm_resource_group.this.location
+
+  tags = var.tags
 }
 
 resource "azurerm_storage_account" "this" {
@@ -24,6 +70,24 @@ resource "azurerm_storage_account" "this" {
   location                 = azurerm_resource_group.this.location
   account_tier             = "Standard"
   account_replication_type = "LRS"
+
+  dynamic "identity" {
+    for_each = var.customer_managed_key_id != null ? [1] : []
+    content {
+      type         = "UserAssigned"
+      identity_ids = [azurerm_user_assigned_identity.cmk[0].id]
+    }
+  }
+
+  dynamic "customer_managed_key" {
+    for_each = var.customer_managed_key_id != null ? [1] : []
+    content {
+      key_vault_key_id          = var.customer_managed_key_id
+      user_assigned_identity_id = azurerm_user_assigned_identity.cmk[0].id
+    }
+  }
+
+  tags = var.tags
 }
 
 resource "azurerm_service_plan" "this" {
@@ -31,7 +95,9 @@ resource "azurerm_service_plan" "this" {
   resource_group_name = azurerm_resource_group.this.name
   location            = azurerm_resource_group.this.location
   os_type             = "Linux"
-  sku_name            = "Y1"
+  sku_name            = local.sku_name
+
+  tags = var.tags
 }
 
 resource "azurerm_linux_function_app" "this" {
@@ -43,9 +109,118 @@ resource "azurerm_linux_function_app" "this" {
   storage_account_access_key = azurerm_storage_account.this.primary_access_key
   service_plan_id            = azurerm_service_plan.this.id
 
-  site_config {}
+  # VNet integration (Elastic Premium only)
+  virtual_network_subnet_id = var.vpc_subnet_id
+
+  # Zip deploy (WEBSITE_RUN_FROM_PACKAGE) of the packaged source; during a
+  # canary the new package only goes to the slot and production keeps the old one
+  zip_deploy_file = local.canary ? null : var.filename
+
+  # Managed identities the function's code authenticates as
+  dynamic "identity" {
+    for_each = length(var.identity_ids) > 0 ? [1] : []
+    content {
+      type         = "UserAssigned"
+      identity_ids = var.identity_ids
+    }
+  }
+
+  site_config {
+    application_stack {
+      python_version = local.runtime_stack == "python" ? local.runtime_version : null
+      node_version   = local.runtime_stack == "node" ? local.runtime_version : null
+    }
+
+    # Scale-out limit, and the always-ready instances of an Elastic Premium plan
+    app_scale_limit          = var.maximum_instance_count
+    elastic_instance_minimum = var.always_ready_instances
+  }
   
-  app_settings = var.environment_variables
+  app_settings = local.canary ? local.base_app_settings : local.app_settings
 
   tags = var.tags
 }
+
+# Deployment slot acting as the alias
+resource "azurerm_linux_function_app_slot" "alias" {
+  count = var.alias_name != null ? 1 : 0
+
+  name            = var.alias_name
+  function_app_id = azurerm_linux_function_app.this.id
+
+  storage_account_name       = azurerm_storage_account.this.name
+  storage_account_access_key = azurerm_storage_account.this.primary_access_key
+
+  site_config {
+    application_stack {
+      python_version = local.runtime_stack == "python" ? local.runtime_version : null
+      node_version   = local.runtime_stack == "node" ? local.runtime_version : null
+    }
+  }
+
+  app_settings = local.app_settings
+
+  tags = var.tags
+}
+
+# Slots have no zip deploy attribute and traffic routing is not exposed by
+# azurerm, so the package push and the percentage go through the Azure CLI.
+resource "null_resource" "slot_rollout" {
+  count = var.alias_name != null ? 1 : 0
+
+  triggers = {
+    slot             = azurerm_linux_function_app_slot.alias[0].id
+    source_code_hash = var.source_code_hash
+    canary_weight    = var.canary_weight != null ? var.canary_weight : 0
+  }
+
+  provisioner "local-exec" {
+    command = join(" && ", [
+      "az functionapp deployment source config-zip --resource-group ${azurerm_resource_group.this.name} --name ${azurerm_linux_function_app.this.name} --slot ${var.alias_name} --src ${var.filename}",
+      var.canary_weight != null ?
+      "az webapp traffic-routing set --resource-group ${azurerm_resource_group.this.name} --name ${azurerm_linux_function_app.this.name} --distribution ${var.alias_name}=${floor(var.canary_weight * 100)}" :
+      "az webapp traffic-routing clear --resource-group ${azurerm_resource_group.this.name} --name ${azurerm_linux_function_app.this.name}",
+    ])
+  }
+}
+
+# Outputs
+output "function_app_id" {
+  description = "ID of the Function App"
+  value       = azurerm_linux_function_app.this.id
+}
+
+output "resource_group_name" {
+  description = "Resource group holding the Function App"
+  value       = azurerm_resource_group.this.name
+}
+
+output "function_app_name" {
+  description = "Name of the Function App"
+  value       = azurerm_linux_function_app.this.name
+}
+
+output "default_hostname" {
+  description = "Default hostname of the Function App"
+  value       = azurerm_linux_function_app.this.default_hostname
+}
+
+output "invoke_url" {
+  description = "HTTP trigger URL of the function"
+  value       = "https://${azurerm_linux_function_app.this.default_hostname}/api/${var.function_name}"
+}
+
+output "slot_id" {
+  description = "ID of the alias deployment slot"
+  value       = try(azurerm_linux_function_app_slot.alias[0].id, null)
+}
+
+output "slot_invoke_url" {
+  description = "HTTP trigger URL of the function in the alias slot"
+  value       = try("https://${azurerm_linux_function_app_slot.alias[0].default_hostname}/api/${var.function_name}", null)
+}
+
+output "service_plan_sku" {
+  description = "SKU of the service plan hosting the Function App"
+  value       = azurerm_service_plan.this.sku_name
+}
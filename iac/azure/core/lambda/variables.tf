@@ -1,38 +1,24 @@
 variable "function_name" {
-  description = "Name of the Lambda function"
+  description = "Name of the Function App"
   type        = string
 }
 
-variable "description" {
-  description = "Description of what the function does"
-  type        = string
-  default     = null
-}
-
 variable "handler" {
   description = "Function entrypoint (e.g. index.handler)"
   type        = string
 }
 
 variable "runtime" {
-  description = "Lambda runtime (e.g. nodejs18.x, python3.9)"
+  description = "Function runtime in LinuxFxVersion form (e.g. PYTHON|3.9, NODE|18)"
   type        = string
-  default     = "python3.9"
 }
 
-variable "memory_size" {
-  description = "Memory size in MB"
-  type        = number
-  default     = 128
-}
-
-variable "timeout" {
-  description = "Function timeout in seconds"
-  type        = number
-  default     = 3
+variable "location" {
+  description = "Azure region for the Function App and its dependencies"
+  type        = string
+  default     = "East US"
 }
 
-# Source Code (Option A: Local file)
 variable "filename" {
   description = "Path to the deployment package (zip)"
   type        = string
@@ -45,61 +31,52 @@ variable "source_code_hash" {
   default     = null
 }
 
-# Source Code (Option B: S3)
-variable "s3_bucket" {
-  description = "S3 bucket location containing the package"
-  type        = string
-  default     = null
-}
-
-variable "s3_key" {
-  description = "S3 key for the package"
+variable "customer_managed_key_id" {
+  description = "Key Vault key ID encrypting the backing storage account (optional)"
   type        = string
   default     = null
 }
 
 variable "environment_variables" {
-  description = "Map of environment variables"
+  description = "Map of app settings"
   type        = map(string)
   default     = {}
 }
 
-# VPC Configuration
-variable "vpc_subnet_ids" {
-  description = "List of subnet IDs for VPC access"
-  type        = list(string)
-  default     = null
+variable "memory_size" {
+  description = "Memory size in MB (selects the service plan tier)"
+  type        = number
+  default     = 128
 }
 
-variable "vpc_security_group_ids" {
-  description = "List of security group IDs for VPC access"
-  type        = list(string)
-  default     = null
+variable "timeout" {
+  description = "Function timeout in seconds"
+  type        = number
+  default     = 3
 }
 
-variable "tracing_mode" {
-  description = "X-Ray tracing mode (PassThrough or Active)"
+variable "vpc_subnet_id" {
+  description = "Subnet ID for VNet integration (requires Elastic Premium plan)"
   type        = string
-  default     = "PassThrough"
+  default     = null
 }
 
-variable "log_retention_days" {
-  description = "CloudWatch log retention in days"
+variable "maximum_instance_count" {
+  description = "Most instances the Function App scales out to (null for the plan's limit)"
   type        = number
-  default     = 14
+  default     = null
 }
 
-# Triggers
-variable "create_apigw_permission" {
-  description = "Create permission for API Gateway invoke"
-  type        = bool
-  default     = false
+variable "always_ready_instances" {
+  description = "Instances kept warm on an Elastic Premium plan; not available on Consumption (Y1)"
+  type        = number
+  default     = null
 }
 
-variable "apigw_execution_arn" {
-  description = "Execution ARN of the API Gateway"
-  type        = string
-  default     = null
+variable "identity_ids" {
+  description = "User-assigned managed identities attached to the Function App"
+  type        = list(string)
+  default     = []
 }
 
 variable "tags" {
@@ -107,3 +84,15 @@ variable "tags" {
   type        = map(string)
   default     = {}
 }
+
+variable "alias_name" {
+  description = "Name of the deployment slot used as the alias"
+  type        = string
+  default     = null
+}
+
+variable "canary_weight" {
+  description = "Share of production traffic routed to the slot (0-1)"
+  type        = number
+  default     = null
+}
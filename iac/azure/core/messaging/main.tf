@@ -1,37 +1,148 @@
+# Azure Messaging (Service Bus)
+# Counterpart of the AWS SQS/SNS core module
+
 terraform {
   required_providers {
     azurerm = {
-      source = "hashicorp/azurerm"
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
     }
   }
 }
 
-variable "queue_name" { type = string }
-variable "topic_name" { type = string }
-variable "create_queue" { type = bool }
-variable "create_topic" { type = bool }
-variable "tags" { type = map(string) }
+locals {
+  entity_name    = var.create_queue ? var.queue_name : var.topic_name
+  namespace_name = var.namespace_name != null ? var.namespace_name : "${local.entity_name}-ns"
+}
+
+# Service Bus takes durations in ISO 8601
+module "lock_duration" {
+  source  = "../../../common/duration"
+  seconds = var.lock_duration_seconds
+}
+
+module "message_ttl" {
+  source  = "../../../common/duration"
+  seconds = var.message_ttl_seconds
+}
+
+# ============================================================================
+# SERVICE BUS NAMESPACE
+# ============================================================================
+
+# Identity used by the namespace to reach the customer-managed key
+resource "azurerm_user_assigned_identity" "cmk" {
+  count = var.customer_managed_key_id != null ? 1 : 0
+
+  name                = "${local.namespace_name}-cmk"
+  location            = var.location
+  resource_group_name = var.resource_group_name
+
+  tags = var.tags
+}
 
-# Service Bus Namespace
 resource "azurerm_servicebus_namespace" "this" {
-  count               = var.create_queue || var.create_topic ? 1 : 0
-  name                = "${var.queue_name != null ? var.queue_name : var.topic_name}-ns"
-  location            = "East US"
-  resource_group_name = "azure-test-rg" # In real implementation, this comes from a data source or input
-  sku                 = "Standard"
-  tags                = var.tags
+  count = var.create_queue || var.create_topic ? 1 : 0
+
+  name                = local.namespace_name
+  location            = var.location
+  resource_group_name = var.resource_group_name
+  sku                 = var.sku
+
+  # Customer-managed keys require the Premium SKU
+  dynamic "identity" {
+    for_each = var.customer_managed_key_id != null ? [1] : []
+    content {
+      type         = "UserAssigned"
+      identity_ids = [azurerm_user_assigned_identity.cmk[0].id]
+    }
+  }
+
+  dynamic "customer_managed_key" {
+    for_each = var.customer_managed_key_id != null ? [1] : []
+    content {
+      key_vault_key_id = var.customer_managed_key_id
+      identity_id      = azurerm_user_assigned_identity.cmk[0].id
+    }
+  }
+
+  tags = var.tags
 }
 
-# Service Bus Queue
+# ============================================================================
+# QUEUES & TOPICS
+# ============================================================================
+
 resource "azurerm_servicebus_queue" "this" {
-  count        = var.create_queue ? 1 : 0
+  count = var.create_queue ? 1 : 0
+
   name         = var.queue_name
   namespace_id = azurerm_servicebus_namespace.this[0].id
+
+  lock_duration       = module.lock_duration.iso8601
+  default_message_ttl = module.message_ttl.iso8601
+
+  # Messages delivered this often move to the queue's dead-letter subqueue
+  max_delivery_count = var.max_delivery_count
+
+  # Message size is only configurable on Premium namespaces (1 MB minimum);
+  # Standard is fixed at 256 KB
+  max_message_size_in_kilobytes = var.sku == "Premium" ? max(var.max_message_size_kb, 1024) : null
 }
 
-# Service Bus Topic
 resource "azurerm_servicebus_topic" "this" {
-  count        = var.create_topic ? 1 : 0
+  count = var.create_topic ? 1 : 0
+
   name         = var.topic_name
   namespace_id = azurerm_servicebus_namespace.this[0].id
 }
+
+# ============================================================================
+# ACCESS CONTROL
+# ============================================================================
+
+# Keyed by principal and role, so removing one assignment leaves the others
+# in place. Principal IDs must therefore be known at plan time
+resource "azurerm_role_assignment" "this" {
+  for_each = var.create_queue || var.create_topic ? {
+    for a in var.role_assignments : "${a.principal_id}-${a.role_definition_name}" => a
+  } : {}
+
+  scope                = var.create_queue ? azurerm_servicebus_queue.this[0].id : azurerm_servicebus_topic.this[0].id
+  role_definition_name = each.value.role_definition_name
+  principal_id         = each.value.principal_id
+}
+
+# ============================================================================
+# OUTPUTS
+# ============================================================================
+
+output "namespace_id" {
+  description = "ID of the Service Bus namespace"
+  value       = length(azurerm_servicebus_namespace.this) > 0 ? azurerm_servicebus_namespace.this[0].id : null
+}
+
+output "namespace_endpoint" {
+  description = "HTTPS endpoint of the Service Bus namespace"
+  value       = length(azurerm_servicebus_namespace.this) > 0 ? "https://${azurerm_servicebus_namespace.this[0].name}.servicebus.windows.net" : null
+}
+
+output "cmk_identity_principal_id" {
+  description = "Principal ID that must be granted access to the customer-managed key"
+  value       = var.customer_managed_key_id != null ? azurerm_user_assigned_identity.cmk[0].principal_id : null
+}
+
+output "queue_id" {
+  description = "Resource ID of the Service Bus queue"
+  value       = var.create_queue ? azurerm_servicebus_queue.this[0].id : null
+}
+
+output "queue_url" {
+  description = "HTTPS endpoint of the Service Bus queue"
+  value       = var.create_queue ? "https://${azurerm_servicebus_namespace.this[0].name}.servicebus.windows.net/${azurerm_servicebus_queue.this[0].name}" : null
+}
+
+output "topic_id" {
+  description = "Resource ID of the Service Bus topic"
+  value       = var.create_topic ? azurerm_servicebus_topic.this[0].id : null
+}
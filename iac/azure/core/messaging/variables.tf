@@ -1,6 +1,6 @@
-# SQS Configuration
+# Queue Configuration
 variable "create_queue" {
-  description = "Create SQS queue"
+  description = "Create Service Bus queue"
   type        = bool
   default     = false
 }
@@ -11,110 +11,78 @@ variable "queue_name" {
   default     = null
 }
 
-variable "fifo_queue" {
-  description = "Boolean designating a FIFO queue"
-  type        = bool
-  default     = false
-}
-
-variable "content_based_deduplication" {
-  description = "Enable content-based deduplication for FIFO queues"
-  type        = bool
-  default     = false
-}
-
-variable "visibility_timeout_seconds" {
-  description = "Visibility timeout for the queue"
-  type        = number
-  default     = 30
-}
-
-variable "message_retention_seconds" {
-  description = "Message retention in seconds"
+variable "lock_duration_seconds" {
+  description = "Peek-lock duration in seconds (5-300)"
   type        = number
-  default     = 345600 # 4 days
+  default     = 60
 }
 
-variable "max_message_size" {
-  description = "Max message size in bytes"
+variable "message_ttl_seconds" {
+  description = "Default message time-to-live in seconds"
   type        = number
-  default     = 262144 # 256 KB
+  default     = 1209600 # 14 days
 }
 
-variable "delay_seconds" {
-  description = "Delay seconds"
+variable "max_message_size_kb" {
+  description = "Maximum message size in KB (Premium namespaces only)"
   type        = number
-  default     = 0
+  default     = 1024
 }
 
-variable "receive_wait_time_seconds" {
-  description = "Receive wait time seconds"
+variable "max_delivery_count" {
+  description = "Deliveries before a message is dead-lettered (null for the Service Bus default of 10)"
   type        = number
-  default     = 0
-}
-
-variable "sqs_managed_sse_enabled" {
-  description = "Enable server-side encryption with SQS-owned key"
-  type        = bool
-  default     = true
+  default     = null
 }
 
-# Dead Letter Queue
-variable "create_dlq" {
-  description = "Create a managed Dead Letter Queue"
+# Topic Configuration
+variable "create_topic" {
+  description = "Create Service Bus topic"
   type        = bool
   default     = false
 }
 
-variable "dead_letter_queue_arn" {
-  description = "ARN of an existing DLQ (if create_dlq is false)"
+variable "topic_name" {
+  description = "Name of the topic"
   type        = string
   default     = null
 }
 
-variable "max_receive_count" {
-  description = "Max receive count before moving to DLQ"
-  type        = number
-  default     = 3
-}
-
-variable "dlq_message_retention_seconds" {
-  description = "Message retention for DLQ"
-  type        = number
-  default     = 1209600 # 14 days
+# Namespace Configuration
+variable "namespace_name" {
+  description = "Service Bus namespace name (defaults to <queue/topic name>-ns)"
+  type        = string
+  default     = null
 }
 
-# SNS Configuration
-variable "create_topic" {
-  description = "Create SNS topic"
-  type        = bool
-  default     = false
+variable "resource_group_name" {
+  description = "Resource group name"
+  type        = string
 }
 
-variable "topic_name" {
-  description = "Name of the SNS topic"
+variable "location" {
+  description = "Azure region"
   type        = string
-  default     = null
 }
 
-variable "fifo_topic" {
-  description = "Boolean designating a FIFO topic"
-  type        = bool
-  default     = false
+variable "sku" {
+  description = "Namespace SKU (Basic, Standard, Premium); topics need Standard or above"
+  type        = string
+  default     = "Standard"
 }
 
-variable "kms_master_key_id" {
-  description = "ID of an AWS-managed customer master key (CMK) for SNS"
+variable "customer_managed_key_id" {
+  description = "Key Vault key ID for customer-managed encryption (Premium only)"
   type        = string
-  default     = "alias/aws/sns"
+  default     = null
 }
 
-variable "subscriptions" {
-  description = "List of subscriptions for the topic"
+# Access Control
+variable "role_assignments" {
+  description = "Built-in data roles assigned on the queue, or on the topic when no queue is created (e.g. Azure Service Bus Data Sender to a managed identity's principal ID)"
   type = list(object({
-    protocol             = string
-    endpoint             = string
-    raw_message_delivery = optional(bool)
+    role_definition_name = string
+    principal_id         = string
   }))
   default = []
 }
@@ -24,6 +24,8 @@ resource "azurerm_monitor_action_group" "this" {
       email_address = email_receiver.value.email
     }
   }
+  
+  tags = var.tags
 }
 
 resource "azurerm_monitor_metric_alert" "this" {
@@ -40,10 +42,22 @@ resource "azurerm_monitor_metric_alert" "this" {
     aggregation      = var.aggregation
     operator         = var.operator
     threshold        = var.threshold
+
+    dynamic "dimension" {
+      for_each = var.dimensions
+      content {
+        name     = dimension.key
+        operator = "Include"
+        values   = [dimension.value]
+      }
+    }
   }
   
-  action {
-    action_group_id = var.action_group_id
+  dynamic "action" {
+    for_each = var.action_group_id != null ? [var.action_group_id] : []
+    content {
+      action_group_id = action.value
+    }
   }
   
   tags = var.tags
@@ -62,6 +76,10 @@ resource "azurerm_log_analytics_workspace" "this" {
   tags = var.tags
 }
 
+output "metric_alert_id" {
+  value = var.create_alert ? azurerm_monitor_metric_alert.this[0].id : null
+}
+
 output "workspace_id" {
   value = var.create_workspace ? azurerm_log_analytics_workspace.this[0].id : null
 }
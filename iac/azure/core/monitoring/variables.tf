@@ -1,25 +1,130 @@
-variable "resource_group_name" { description = "Resource group"; type = string }
-variable "location" { description = "Location"; type = string; default = null } # Required for workspace
-
-variable "create_action_group" { type = bool; default = false }
-variable "action_group_name" { type = string; default = null }
-variable "short_name" { type = string; default = null }
-variable "email_receivers" { type = list(object({ name = string, email = string })); default = [] }
-
-variable "create_alert" { type = bool; default = false }
-variable "alert_name" { type = string; default = null }
-variable "scopes" { type = list(string); default = [] }
-variable "description" { type = string; default = null }
-variable "metric_namespace" { type = string; default = null }
-variable "metric_name" { type = string; default = null }
-variable "aggregation" { type = string; default = "Average" }
-variable "operator" { type = string; default = "GreaterThan" }
-variable "threshold" { type = number; default = 0 }
-variable "action_group_id" { type = string; default = null }
-
-variable "create_workspace" { type = bool; default = false }
-variable "workspace_name" { type = string; default = null }
-variable "sku" { type = string; default = "PerGB2018" }
-variable "retention_in_days" { type = number; default = 30 }
-
-variable "tags" { type = map(string); default = {} }
+variable "resource_group_name" {
+  description = "Resource group for the action group, alert and workspace"
+  type        = string
+}
+
+variable "location" {
+  description = "Location of the Log Analytics workspace (required when create_workspace is true)"
+  type        = string
+  default     = null
+}
+
+variable "create_action_group" {
+  description = "Create an action group"
+  type        = bool
+  default     = false
+}
+
+variable "action_group_name" {
+  description = "Action group name"
+  type        = string
+  default     = null
+}
+
+variable "short_name" {
+  description = "Action group short name (at most 12 characters)"
+  type        = string
+  default     = null
+}
+
+variable "email_receivers" {
+  description = "Email receivers notified by the action group"
+  type        = list(object({ name = string, email = string }))
+  default     = []
+}
+
+variable "create_alert" {
+  description = "Create a metric alert"
+  type        = bool
+  default     = false
+}
+
+variable "alert_name" {
+  description = "Metric alert name"
+  type        = string
+  default     = null
+}
+
+variable "scopes" {
+  description = "Resource IDs the metric alert watches"
+  type        = list(string)
+  default     = []
+}
+
+variable "description" {
+  description = "Metric alert description"
+  type        = string
+  default     = null
+}
+
+variable "metric_namespace" {
+  description = "Namespace of the alerted metric, e.g. Microsoft.Compute/virtualMachines"
+  type        = string
+  default     = null
+}
+
+variable "metric_name" {
+  description = "Name of the alerted metric, e.g. Percentage CPU"
+  type        = string
+  default     = null
+}
+
+variable "aggregation" {
+  description = "Metric aggregation (Average, Count, Minimum, Maximum, Total)"
+  type        = string
+  default     = "Average"
+}
+
+variable "operator" {
+  description = "Comparison operator (Equals, GreaterThan, GreaterThanOrEqual, LessThan, LessThanOrEqual)"
+  type        = string
+  default     = "GreaterThan"
+}
+
+variable "threshold" {
+  description = "Alert threshold"
+  type        = number
+  default     = 0
+}
+
+variable "dimensions" {
+  description = "Metric dimensions the alert is limited to, e.g. { EntityName = \"orders\" }"
+  type        = map(string)
+  default     = {}
+}
+
+variable "action_group_id" {
+  description = "Existing action group notified by the alert, when create_action_group is false"
+  type        = string
+  default     = null
+}
+
+variable "create_workspace" {
+  description = "Create a Log Analytics workspace"
+  type        = bool
+  default     = false
+}
+
+variable "workspace_name" {
+  description = "Log Analytics workspace name"
+  type        = string
+  default     = null
+}
+
+variable "sku" {
+  description = "Log Analytics workspace SKU"
+  type        = string
+  default     = "PerGB2018"
+}
+
+variable "retention_in_days" {
+  description = "Days logs are retained in the workspace"
+  type        = number
+  default     = 30
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
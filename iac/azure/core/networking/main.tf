@@ -14,7 +14,7 @@ resource "azurerm_virtual_network" "this" {
   name                = var.vnet_name
   resource_group_name = var.resource_group_name
   location            = var.location
-  address_space       = [var.address_space]
+  address_space       = var.enable_ipv6 ? [var.address_space, var.ipv6_address_space] : [var.address_space]
   
   tags = var.tags
 }
@@ -25,7 +25,7 @@ resource "azurerm_subnet" "public" {
   name                 = var.public_subnets[count.index].name
   resource_group_name  = var.resource_group_name
   virtual_network_name = azurerm_virtual_network.this.name
-  address_prefixes     = [var.public_subnets[count.index].address_prefix]
+  address_prefixes     = compact([var.public_subnets[count.index].address_prefix, var.enable_ipv6 ? var.public_subnets[count.index].ipv6_address_prefix : null])
 }
 
 resource "azurerm_subnet" "private" {
@@ -34,7 +34,7 @@ resource "azurerm_subnet" "private" {
   name                 = var.private_subnets[count.index].name
   resource_group_name  = var.resource_group_name
   virtual_network_name = azurerm_virtual_network.this.name
-  address_prefixes     = [var.private_subnets[count.index].address_prefix]
+  address_prefixes     = compact([var.private_subnets[count.index].address_prefix, var.enable_ipv6 ? var.private_subnets[count.index].ipv6_address_prefix : null])
 }
 
 resource "azurerm_network_security_group" "default" {
@@ -59,6 +59,123 @@ resource "azurerm_network_security_group" "default" {
   tags = var.tags
 }
 
+# Firewall rules, in their own NSG associated with every subnet
+resource "azurerm_network_security_group" "rules" {
+  count = length(var.firewall_rules) > 0 ? 1 : 0
+  
+  name                = "${var.vnet_name}-rules-nsg"
+  location            = var.location
+  resource_group_name = var.resource_group_name
+  
+  tags = var.tags
+}
+
+resource "azurerm_network_security_rule" "rules" {
+  for_each = { for i, r in var.firewall_rules : r.name => merge(r, { priority = 200 + i }) }
+  
+  name                         = each.key
+  description                  = each.value.description != "" ? each.value.description : null
+  priority                     = each.value.priority
+  direction                    = each.value.direction == "ingress" ? "Inbound" : "Outbound"
+  access                       = "Allow"
+  protocol                     = each.value.protocol == "all" ? "*" : title(each.value.protocol)
+  source_port_range            = "*"
+  destination_port_range       = length(each.value.ports) > 0 ? null : "*"
+  destination_port_ranges      = length(each.value.ports) > 0 ? each.value.ports : null
+  source_address_prefixes      = each.value.direction == "ingress" ? each.value.cidr_blocks : null
+  source_address_prefix        = each.value.direction == "ingress" ? null : "*"
+  destination_address_prefixes = each.value.direction == "egress" ? each.value.cidr_blocks : null
+  destination_address_prefix   = each.value.direction == "egress" ? null : "*"
+  resource_group_name          = var.resource_group_name
+  network_security_group_name  = azurerm_network_security_group.rules[0].name
+}
+
+resource "azurerm_subnet_network_security_group_association" "rules" {
+  count = length(var.firewall_rules) > 0 ? length(var.public_subnets) + length(var.private_subnets) : 0
+  
+  subnet_id                 = concat(azurerm_subnet.public[*].id, azurerm_subnet.private[*].id)[count.index]
+  network_security_group_id = azurerm_network_security_group.rules[0].id
+}
+
+# Private endpoints. The DNS zones and VNet links are created for every
+# service, so endpoints added later resolve; an endpoint needs the ID of the
+# resource it connects to.
+locals {
+  private_dns_zones          = toset([for ep in values(var.private_endpoints) : ep.dns_zone])
+  private_endpoint_subnet_id = length(azurerm_subnet.private) > 0 ? azurerm_subnet.private[0].id : try(azurerm_subnet.public[0].id, null)
+}
+
+resource "azurerm_private_dns_zone" "this" {
+  for_each = local.private_dns_zones
+  
+  name                = each.value
+  resource_group_name = var.resource_group_name
+  
+  tags = var.tags
+}
+
+resource "azurerm_private_dns_zone_virtual_network_link" "this" {
+  for_each = local.private_dns_zones
+  
+  name                  = "${var.vnet_name}-${replace(each.value, ".", "-")}"
+  resource_group_name   = var.resource_group_name
+  private_dns_zone_name = azurerm_private_dns_zone.this[each.value].name
+  virtual_network_id    = azurerm_virtual_network.this.id
+  
+  tags = var.tags
+}
+
+resource "azurerm_private_endpoint" "this" {
+  for_each = { for name, ep in var.private_endpoints : name => ep if ep.resource_id != null }
+  
+  name                = "${var.vnet_name}-${each.key}-pe"
+  location            = var.location
+  resource_group_name = var.resource_group_name
+  subnet_id           = local.private_endpoint_subnet_id
+  
+  private_service_connection {
+    name                           = "${var.vnet_name}-${each.key}"
+    private_connection_resource_id = each.value.resource_id
+    subresource_names              = [each.value.subresource]
+    is_manual_connection           = false
+  }
+  
+  private_dns_zone_group {
+    name                 = each.key
+    private_dns_zone_ids = [azurerm_private_dns_zone.this[each.value.dns_zone].id]
+  }
+  
+  tags = var.tags
+}
+
+# Flow logs, on the NSG associated with the subnets when there is one.
+# Azure creates a network watcher per region the first time a VNet is made.
+locals {
+  flow_log_nsg_id = (
+    length(azurerm_network_security_group.rules) > 0 ? azurerm_network_security_group.rules[0].id :
+    length(azurerm_network_security_group.default) > 0 ? azurerm_network_security_group.default[0].id :
+    null
+  )
+}
+
+resource "azurerm_network_watcher_flow_log" "this" {
+  count = var.enable_flow_logs && (var.create_default_nsg || length(var.firewall_rules) > 0) ? 1 : 0
+  
+  name                      = "${var.vnet_name}-flow-log"
+  network_watcher_name      = coalesce(var.network_watcher_name, "NetworkWatcher_${var.location}")
+  resource_group_name       = var.network_watcher_resource_group
+  network_security_group_id = local.flow_log_nsg_id
+  storage_account_id        = var.flow_log_destination
+  enabled                   = true
+  
+  retention_policy {
+    enabled = true
+    days    = var.flow_log_retention_days
+  }
+  
+  tags = var.tags
+}
+
 # Outputs
 output "vnet_id" {
   description = "Virtual network ID"
@@ -75,6 +192,21 @@ output "address_space" {
   value       = azurerm_virtual_network.this.address_space
 }
 
+output "ipv6_cidr" {
+  description = "IPv6 address space of the VNet, when enable_ipv6 is set"
+  value       = var.enable_ipv6 ? var.ipv6_address_space : null
+}
+
+output "public_ipv6_cidrs" {
+  description = "IPv6 prefixes of the public subnets"
+  value       = var.enable_ipv6 ? [for s in var.public_subnets : s.ipv6_address_prefix] : []
+}
+
+output "private_ipv6_cidrs" {
+  description = "IPv6 prefixes of the private subnets"
+  value       = var.enable_ipv6 ? [for s in var.private_subnets : s.ipv6_address_prefix] : []
+}
+
 output "public_subnet_ids" {
   description = "Public subnet IDs"
   value       = azurerm_subnet.public[*].id
@@ -89,3 +221,18 @@ output "default_nsg_id" {
   description = "Default network security group ID"
   value       = var.create_default_nsg ? azurerm_network_security_group.default[0].id : null
 }
+
+output "rules_nsg_id" {
+  description = "ID of the network security group holding firewall_rules"
+  value       = length(azurerm_network_security_group.rules) > 0 ? azurerm_network_security_group.rules[0].id : null
+}
+
+output "private_endpoint_ids" {
+  description = "Private endpoint IDs by name"
+  value       = { for name, ep in azurerm_private_endpoint.this : name => ep.id }
+}
+
+output "flow_log_id" {
+  description = "ID of the NSG flow log, when enabled"
+  value       = length(azurerm_network_watcher_flow_log.this) > 0 ? azurerm_network_watcher_flow_log.this[0].id : null
+}
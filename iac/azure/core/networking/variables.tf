@@ -19,20 +19,34 @@ variable "address_space" {
   default     = "10.0.0.0/16"
 }
 
+variable "enable_ipv6" {
+  description = "Dual stack: add ipv6_address_space to the VNet and each subnet's ipv6_address_prefix"
+  type        = bool
+  default     = false
+}
+
+variable "ipv6_address_space" {
+  description = "IPv6 address space for the VNet (CIDR), e.g. a unique local /56"
+  type        = string
+  default     = null
+}
+
 variable "public_subnets" {
-  description = "Public subnet configurations"
+  description = "Public subnet configurations; ipv6_address_prefix is used when enable_ipv6 is set"
   type = list(object({
-    name           = string
-    address_prefix = string
+    name                = string
+    address_prefix      = string
+    ipv6_address_prefix = optional(string)
   }))
   default = []
 }
 
 variable "private_subnets" {
-  description = "Private subnet configurations"
+  description = "Private subnet configurations; ipv6_address_prefix is used when enable_ipv6 is set"
   type = list(object({
-    name           = string
-    address_prefix = string
+    name                = string
+    address_prefix      = string
+    ipv6_address_prefix = optional(string)
   }))
   default = []
 }
@@ -43,6 +57,59 @@ variable "create_default_nsg" {
   default     = true
 }
 
+variable "firewall_rules" {
+  description = "Firewall rules from the networking facade: direction ingress or egress, protocol tcp, udp, icmp or all, ports as \"443\" or \"8000-8080\" (empty for every port)"
+  type = list(object({
+    name        = string
+    direction   = string
+    protocol    = string
+    ports       = list(string)
+    cidr_blocks = list(string)
+    description = string
+  }))
+  default = []
+}
+
+variable "private_endpoints" {
+  description = "Private endpoints by name: the privatelink DNS zone, the target's subresource and, when known, the target resource ID"
+  type = map(object({
+    dns_zone    = string
+    subresource = string
+    resource_id = optional(string)
+  }))
+  default = {}
+}
+
+variable "enable_flow_logs" {
+  description = "Log traffic through the subnets' NSG to the flow_log_destination storage account"
+  type        = bool
+  default     = false
+}
+
+variable "flow_log_destination" {
+  description = "Resource ID of the storage account flow logs are written to"
+  type        = string
+  default     = null
+}
+
+variable "flow_log_retention_days" {
+  description = "Days flow logs are kept in the storage account"
+  type        = number
+  default     = 30
+}
+
+variable "network_watcher_name" {
+  description = "Network watcher for flow logs; defaults to the one Azure creates, NetworkWatcher_<location>"
+  type        = string
+  default     = null
+}
+
+variable "network_watcher_resource_group" {
+  description = "Resource group of the network watcher"
+  type        = string
+  default     = "NetworkWatcherRG"
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
@@ -1,5 +1,14 @@
 # Azure CosmosDB Core Module
 
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
 resource "azurerm_cosmosdb_account" "this" {
   name                = var.account_name
   resource_group_name = var.resource_group_name
@@ -8,7 +17,16 @@ resource "azurerm_cosmosdb_account" "this" {
   kind                = "GlobalDocumentDB"
 
   consistency_policy {
-    consistency_level = "Session"
+    consistency_level = var.consistency_level
+  }
+
+  # Serverless is an account-wide capability: its containers take no
+  # throughput at all
+  dynamic "capabilities" {
+    for_each = var.throughput_mode == "serverless" ? [1] : []
+    content {
+      name = "EnableServerless"
+    }
   }
 
   geo_location {
@@ -31,7 +49,15 @@ resource "azurerm_cosmosdb_sql_container" "this" {
   account_name        = azurerm_cosmosdb_account.this.name
   database_name       = azurerm_cosmosdb_sql_database.this.name
   partition_key_path  = var.partition_key_path
-  throughput          = var.throughput
+  throughput          = var.throughput_mode == "provisioned" ? var.throughput : null
+  default_ttl         = var.default_ttl
+
+  dynamic "autoscale_settings" {
+    for_each = var.throughput_mode == "autoscale" ? [1] : []
+    content {
+      max_throughput = var.throughput
+    }
+  }
 }
 
 output "account_id" {
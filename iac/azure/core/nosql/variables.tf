@@ -30,12 +30,34 @@ variable "partition_key_path" {
   default     = "/id"
 }
 
+variable "throughput_mode" {
+  description = "provisioned for fixed RU/s, autoscale to scale between 10% and 100% of throughput, serverless to bill per request"
+  type        = string
+  default     = "provisioned"
+  validation {
+    condition     = contains(["provisioned", "autoscale", "serverless"], var.throughput_mode)
+    error_message = "throughput_mode must be one of: provisioned, autoscale, serverless"
+  }
+}
+
 variable "throughput" {
-  description = "Throughput (RU/s)"
+  description = "Container RU/s: the fixed throughput when provisioned, the maximum when autoscale; ignored when serverless"
   type        = number
   default     = 400
 }
 
+variable "consistency_level" {
+  description = "Account default consistency (Strong, BoundedStaleness, Session, ConsistentPrefix, Eventual)"
+  type        = string
+  default     = "Session"
+}
+
+variable "default_ttl" {
+  description = "Container TTL in seconds; -1 lets each item set its own in a ttl property, null leaves TTL off"
+  type        = number
+  default     = null
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
@@ -1,5 +1,14 @@
 # Azure Key Vault Secret
 
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
 resource "azurerm_key_vault_secret" "this" {
   name         = var.name
   value        = var.secret_value
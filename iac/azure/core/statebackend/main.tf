@@ -0,0 +1,102 @@
+# Azure State Backend Core Module
+# Storage account and container holding Terraform state. The azurerm
+# backend locks state with a lease on its blob, so no lock store is needed.
+
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
+# The backend is bootstrapped before anything else, so it brings its own
+# resource group
+resource "azurerm_resource_group" "this" {
+  name     = var.resource_group_name
+  location = var.location
+
+  tags = var.tags
+}
+
+# Identity used by the storage account to reach the customer-managed key;
+# it needs wrapKey/unwrapKey/get on the Key Vault
+resource "azurerm_user_assigned_identity" "cmk" {
+  count = var.customer_managed_key_id != null ? 1 : 0
+
+  name                = "${var.storage_account_name}-cmk"
+  resource_group_name = azurerm_resource_group.this.name
+  location            = azurerm_resource_group.this.location
+
+  tags = var.tags
+}
+
+resource "azurerm_storage_account" "this" {
+  name                     = var.storage_account_name
+  resource_group_name      = azurerm_resource_group.this.name
+  location                 = azurerm_resource_group.this.location
+  account_tier             = "Standard"
+  account_replication_type = var.replication_type
+  account_kind             = "StorageV2"
+
+  # Security; state holds secrets in plain text
+  enable_https_traffic_only         = true
+  min_tls_version                   = "TLS1_2"
+  allow_nested_items_to_be_public   = false
+  infrastructure_encryption_enabled = true
+
+  dynamic "identity" {
+    for_each = var.customer_managed_key_id != null ? [1] : []
+    content {
+      type         = "UserAssigned"
+      identity_ids = [azurerm_user_assigned_identity.cmk[0].id]
+    }
+  }
+
+  dynamic "customer_managed_key" {
+    for_each = var.customer_managed_key_id != null ? [1] : []
+    content {
+      key_vault_key_id          = var.customer_managed_key_id
+      user_assigned_identity_id = azurerm_user_assigned_identity.cmk[0].id
+    }
+  }
+
+  # Every write of the state keeps the previous one as a version, and a
+  # deleted state blob can be restored
+  blob_properties {
+    versioning_enabled = true
+
+    delete_retention_policy {
+      days = var.delete_retention_days
+    }
+
+    container_delete_retention_policy {
+      days = var.delete_retention_days
+    }
+  }
+
+  tags = var.tags
+}
+
+resource "azurerm_storage_container" "this" {
+  name                  = var.container_name
+  storage_account_name  = azurerm_storage_account.this.name
+  container_access_type = "private"
+}
+
+output "resource_group_name" {
+  value = azurerm_resource_group.this.name
+}
+
+output "storage_account_name" {
+  value = azurerm_storage_account.this.name
+}
+
+output "storage_account_id" {
+  value = azurerm_storage_account.this.id
+}
+
+output "container_name" {
+  value = azurerm_storage_container.this.name
+}
@@ -0,0 +1,44 @@
+variable "resource_group_name" {
+  description = "Resource group created for the state storage account"
+  type        = string
+}
+
+variable "location" {
+  description = "Azure region"
+  type        = string
+}
+
+variable "storage_account_name" {
+  description = "Name of the storage account (3-24 lower case letters and digits)"
+  type        = string
+}
+
+variable "container_name" {
+  description = "Name of the container holding the state blobs"
+  type        = string
+  default     = "tfstate"
+}
+
+variable "replication_type" {
+  description = "Storage account replication (LRS, ZRS, GRS, RAGRS)"
+  type        = string
+  default     = "GRS"
+}
+
+variable "delete_retention_days" {
+  description = "Days a deleted state blob or container can be restored"
+  type        = number
+  default     = 30
+}
+
+variable "customer_managed_key_id" {
+  description = "Key Vault key ID encrypting the storage account; Microsoft-managed keys when null"
+  type        = string
+  default     = null
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
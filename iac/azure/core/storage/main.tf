@@ -10,6 +10,18 @@ terraform {
   }
 }
 
+# Identity used by the storage account to reach the customer-managed key;
+# it needs wrapKey/unwrapKey/get on the Key Vault
+resource "azurerm_user_assigned_identity" "cmk" {
+  count = var.customer_managed_key_id != null ? 1 : 0
+  
+  name                = "${var.storage_account_name}-cmk"
+  resource_group_name = var.resource_group_name
+  location            = var.location
+  
+  tags = var.tags
+}
+
 resource "azurerm_storage_account" "this" {
   name                     = var.storage_account_name
   resource_group_name      = var.resource_group_name
@@ -23,6 +35,23 @@ resource "azurerm_storage_account" "this" {
   min_tls_version                 = "TLS1_2"
   allow_nested_items_to_be_public = !var.block_public_access
   
+  # Customer-managed key
+  dynamic "identity" {
+    for_each = var.customer_managed_key_id != null ? [1] : []
+    content {
+      type         = "UserAssigned"
+      identity_ids = [azurerm_user_assigned_identity.cmk[0].id]
+    }
+  }
+  
+  dynamic "customer_managed_key" {
+    for_each = var.customer_managed_key_id != null ? [1] : []
+    content {
+      key_vault_key_id          = var.customer_managed_key_id
+      user_assigned_identity_id = azurerm_user_assigned_identity.cmk[0].id
+    }
+  }
+  
   # Blob properties
   blob_properties {
     versioning_enabled = var.versioning_enabled
@@ -36,6 +65,14 @@ resource "azurerm_storage_account" "this" {
     }
   }
   
+  dynamic "static_website" {
+    for_each = var.static_website != null ? [var.static_website] : []
+    content {
+      index_document     = static_website.value.index_document
+      error_404_document = static_website.value.error_404_document
+    }
+  }
+  
   tags = var.tags
 }
 
@@ -47,6 +84,45 @@ resource "azurerm_storage_container" "this" {
   container_access_type = var.container_access_type
 }
 
+# Blob events reach Event Grid through the account's system topic, which an
+# account may only have one of
+resource "azurerm_eventgrid_system_topic" "this" {
+  count = length(var.notifications) > 0 ? 1 : 0
+
+  name                   = "${var.storage_account_name}-events"
+  resource_group_name    = var.resource_group_name
+  location               = var.location
+  source_arm_resource_id = azurerm_storage_account.this.id
+  topic_type             = "Microsoft.Storage.StorageAccounts"
+
+  tags = var.tags
+}
+
+resource "azurerm_eventgrid_system_topic_event_subscription" "this" {
+  count = length(var.notifications)
+
+  name                 = "${var.storage_account_name}-${count.index}"
+  system_topic         = azurerm_eventgrid_system_topic.this[0].name
+  resource_group_name  = var.resource_group_name
+  included_event_types = var.notifications[count.index].events
+
+  # Subjects name the blob: /blobServices/default/containers/<container>/blobs/<path>
+  subject_filter {
+    subject_begins_with = "/blobServices/default/containers/${var.container_name}/blobs/${var.notifications[count.index].filter_prefix == null ? "" : var.notifications[count.index].filter_prefix}"
+    subject_ends_with   = var.notifications[count.index].filter_suffix
+  }
+
+  service_bus_queue_endpoint_id = var.notifications[count.index].target_type == "queue" ? var.notifications[count.index].target_id : null
+  service_bus_topic_endpoint_id = var.notifications[count.index].target_type == "topic" ? var.notifications[count.index].target_id : null
+
+  dynamic "azure_function_endpoint" {
+    for_each = var.notifications[count.index].target_type == "function" ? [var.notifications[count.index].target_id] : []
+    content {
+      function_id = azure_function_endpoint.value
+    }
+  }
+}
+
 # Outputs
 output "storage_account_id" {
   description = "Storage account ID"
@@ -63,12 +139,22 @@ output "primary_blob_endpoint" {
   value       = azurerm_storage_account.this.primary_blob_endpoint
 }
 
+output "primary_web_host" {
+  description = "Static website host name, when static_website is set"
+  value       = var.static_website != null ? azurerm_storage_account.this.primary_web_host : null
+}
+
 output "primary_access_key" {
   description = "Primary access key"
   value       = azurerm_storage_account.this.primary_access_key
   sensitive   = true
 }
 
+output "cmk_identity_principal_id" {
+  description = "Principal ID that must be granted access to the customer-managed key"
+  value       = var.customer_managed_key_id != null ? azurerm_user_assigned_identity.cmk[0].principal_id : null
+}
+
 output "container_name" {
   description = "Container name"
   value       = var.create_container ? azurerm_storage_container.this[0].name : null
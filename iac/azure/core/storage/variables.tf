@@ -78,6 +78,33 @@ variable "container_access_type" {
   default     = "private"
 }
 
+variable "static_website" {
+  description = "Serve the $web container as a static website (optional)"
+  type = object({
+    index_document     = string
+    error_404_document = string
+  })
+  default = null
+}
+
+variable "notifications" {
+  description = "Blob events in the container to send to Functions, Service Bus queues or Service Bus topics (target_type function, queue or topic), each through its own Event Grid subscription"
+  type = list(object({
+    events        = list(string)
+    filter_prefix = optional(string)
+    filter_suffix = optional(string)
+    target_type   = string
+    target_id     = string
+  }))
+  default = []
+}
+
+variable "customer_managed_key_id" {
+  description = "Key Vault key ID for customer-managed encryption (optional)"
+  type        = string
+  default     = null
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
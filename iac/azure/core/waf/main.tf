@@ -0,0 +1,131 @@
+# Azure WAF Core Module (Front Door or Application Gateway policy)
+
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
+locals {
+  frontdoor = length(var.frontdoor_domain_ids) > 0
+
+  # Front Door policy names are letters and digits only
+  frontdoor_policy_name = replace(var.name, "-", "")
+}
+
+# Front Door: policy linked to endpoints or custom domains by a security policy
+resource "azurerm_cdn_frontdoor_firewall_policy" "this" {
+  count = local.frontdoor ? 1 : 0
+
+  name                = local.frontdoor_policy_name
+  resource_group_name = var.resource_group_name
+  sku_name            = var.frontdoor_sku
+  mode                = "Prevention"
+
+  dynamic "managed_rule" {
+    for_each = var.managed_rule_sets
+    content {
+      type    = managed_rule.value.type
+      version = managed_rule.value.version
+      action  = "Block"
+    }
+  }
+
+  dynamic "custom_rule" {
+    for_each = var.rate_limit != null ? [var.rate_limit] : []
+    content {
+      name                           = "RateLimit"
+      type                           = "RateLimitRule"
+      action                         = "Block"
+      priority                       = 100
+      rate_limit_duration_in_minutes = 5
+      rate_limit_threshold           = custom_rule.value
+
+      match_condition {
+        match_variable = "SocketAddr"
+        operator       = "IPMatch"
+        match_values   = ["0.0.0.0/0", "::/0"]
+      }
+    }
+  }
+
+  tags = var.tags
+}
+
+resource "azurerm_cdn_frontdoor_security_policy" "this" {
+  count = local.frontdoor ? 1 : 0
+
+  name                     = var.name
+  cdn_frontdoor_profile_id = var.frontdoor_profile_id
+
+  security_policies {
+    firewall {
+      cdn_frontdoor_firewall_policy_id = azurerm_cdn_frontdoor_firewall_policy.this[0].id
+
+      association {
+        patterns_to_match = ["/*"]
+
+        dynamic "domain" {
+          for_each = var.frontdoor_domain_ids
+          content {
+            cdn_frontdoor_domain_id = domain.value
+          }
+        }
+      }
+    }
+  }
+}
+
+# Application Gateway: the gateway links the policy through firewall_policy_id
+resource "azurerm_web_application_firewall_policy" "this" {
+  count = local.frontdoor ? 0 : 1
+
+  name                = var.name
+  resource_group_name = var.resource_group_name
+  location            = var.location
+
+  policy_settings {
+    enabled = true
+    mode    = "Prevention"
+  }
+
+  managed_rules {
+    dynamic "managed_rule_set" {
+      for_each = var.managed_rule_sets
+      content {
+        type    = managed_rule_set.value.type
+        version = managed_rule_set.value.version
+      }
+    }
+  }
+
+  dynamic "custom_rules" {
+    for_each = var.rate_limit != null ? [var.rate_limit] : []
+    content {
+      name                 = "RateLimit"
+      rule_type            = "RateLimitRule"
+      action               = "Block"
+      priority             = 100
+      rate_limit_duration  = "FiveMins"
+      rate_limit_threshold = custom_rules.value
+      group_rate_limit_by  = "ClientAddr"
+
+      match_conditions {
+        match_variables {
+          variable_name = "RemoteAddr"
+        }
+        operator     = "IPMatch"
+        match_values = ["0.0.0.0/0"]
+      }
+    }
+  }
+
+  tags = var.tags
+}
+
+output "policy_id" {
+  value = local.frontdoor ? azurerm_cdn_frontdoor_firewall_policy.this[0].id : azurerm_web_application_firewall_policy.this[0].id
+}
@@ -0,0 +1,54 @@
+variable "name" {
+  description = "Name of the policy (letters and digits only on Front Door)"
+  type        = string
+}
+
+variable "resource_group_name" {
+  description = "Resource group name"
+  type        = string
+}
+
+variable "location" {
+  description = "Azure region of the Application Gateway policy"
+  type        = string
+  default     = "eastus"
+}
+
+variable "managed_rule_sets" {
+  description = "Managed rule sets ({type, version}) for the policy kind in use"
+  type = list(object({
+    type    = string
+    version = string
+  }))
+  default = []
+}
+
+variable "rate_limit" {
+  description = "Requests per client IP in 5 minutes before it is blocked (null to skip)"
+  type        = number
+  default     = null
+}
+
+variable "frontdoor_profile_id" {
+  description = "Front Door profile owning frontdoor_domain_ids"
+  type        = string
+  default     = null
+}
+
+variable "frontdoor_domain_ids" {
+  description = "Front Door endpoint or custom domain IDs; empty for an Application Gateway policy"
+  type        = list(string)
+  default     = []
+}
+
+variable "frontdoor_sku" {
+  description = "SKU of the Front Door profile; managed rules need Premium_AzureFrontDoor"
+  type        = string
+  default     = "Premium_AzureFrontDoor"
+}
+
+variable "tags" {
+  description = "Tags"
+  type        = map(string)
+  default     = {}
+}
@@ -1,5 +1,14 @@
 # Azure Logic App Workflow
 
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
 resource "azurerm_logic_app_workflow" "this" {
   name                = var.name
   location            = var.location
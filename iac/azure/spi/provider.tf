@@ -1,6 +1,17 @@
 # Azure Provider Configuration
 # SPI Layer for Azure
 
+terraform {
+  required_version = ">= 1.0"
+
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
 provider "azurerm" {
   features {}
   
@@ -1,7 +1,24 @@
-variable "subscription_id" { type = string }
-variable "tenant_id" { type = string }
-variable "client_id" { type = string; default = null }
-variable "client_secret" { type = string; default = null }
+variable "subscription_id" {
+  description = "Azure subscription ID"
+  type        = string
+}
+
+variable "tenant_id" {
+  description = "Azure AD tenant ID"
+  type        = string
+}
+
+variable "client_id" {
+  description = "Service principal client ID; null uses the Azure CLI or managed identity login"
+  type        = string
+  default     = null
+}
+
+variable "client_secret" {
+  description = "Service principal client secret"
+  type        = string
+  default     = null
+}
 
 variable "stack_name" {
   description = "Name of the stack (e.g. dev, prod)"
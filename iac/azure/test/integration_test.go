@@ -1,77 +1,154 @@
+//go:build integration
+
 package test
 
 import (
+	"context"
 	"fmt"
-	"net/http"
+	"strings"
 	"testing"
 	"time"
 
+	"iac/azure/azurehelpers"
+	"iac/testutil/artifacts"
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+	"iac/testutil/eventually"
+	"iac/testutil/integration"
+	"iac/testutil/smoke"
+	"iac/testutil/tfopts"
+
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
-	azureEndpoint = "http://localhost:10000"
+	// Provisioned RU/s of the Cosmos DB container; not the module default
+	// of 400, so a dropped variable shows up
+	cosmosThroughput = 800
+
+	// Function in examples/azure-integration/functions/queue-to-blob that
+	// copies queue messages into the storage container
+	copyFunction = "copy_message"
+
+	// How long the queue trigger may take to copy a message: the emulator
+	// starts the Functions host on the first message
+	triggerTimeout = 2 * time.Minute
 )
 
-// TestAzureIntegration tests the Azure provider integration with CloudEmu
+// TestAzureIntegration tests the Azure provider integration with CloudEmu.
+// Every output that names a data-plane resource is checked against the
+// emulator, so a module returning a made-up name fails the test.
 func TestAzureIntegration(t *testing.T) {
 	t.Parallel()
 
-	ensureAzureRunning(t)
+	cfg := ensureAzureRunning(t)
+
+	helpers, err := azurehelpers.NewFromTestConfig(cfg)
+	require.NoError(t, err)
+	ctx := context.Background()
 
 	timestamp := time.Now().Unix()
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
 		TerraformDir: "../../examples/azure-integration",
 		Vars: map[string]interface{}{
-			"bucket_name": fmt.Sprintf("test-azure-container-%d", timestamp),
-			"table_name":  fmt.Sprintf("test-azure-cosmos-%d", timestamp),
-			"environment": "test",
+			"bucket_name":       fmt.Sprintf("test-azure-container-%d", timestamp),
+			"table_name":        fmt.Sprintf("test-azure-cosmos-%d", timestamp),
+			"queue_name":        fmt.Sprintf("test-azure-queue-%d", timestamp),
+			"cosmos_throughput": cosmosThroughput,
+			"environment":       "local",
+			"azure_endpoint":    cfg.AzureEndpoint,
 		},
 		NoColor: true,
-	})
+	}, tfopts.CloudEmuAzure)
 
-	defer terraform.Destroy(t, terraformOptions)
-	terraform.InitAndApply(t, terraformOptions)
+	defer concurrency.Destroy(t, terraformOptions)
+	// Deploys every facade at once, so it counts double against
+	// SWE_TEST_MAX_PARALLEL
+	concurrency.RunWeighted(t, 2, func() {
+		concurrency.InitAndApply(t, terraformOptions)
+	})
 
-	// 1. Verify Storage (Azure Blob)
+	// 1. Verify Storage (Azure Blob): container exists and a blob round-trips
 	bucketName := terraform.Output(t, terraformOptions, "bucket_name")
-	assert.NotEmpty(t, bucketName)
+	assert.Contains(t, terraform.Output(t, terraformOptions, "bucket_url"), bucketName)
+
+	// bucket_name should name an existing container
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		return helpers.VerifyContainerExists(ctx, bucketName)
+	})
 
-	bucketURL := terraform.Output(t, terraformOptions, "bucket_url")
-	assert.Contains(t, bucketURL, bucketName)
+	blobContent := fmt.Sprintf("hello from blob %d", timestamp)
+	require.NoError(t, helpers.UploadBlob(ctx, bucketName, "roundtrip.txt", []byte(blobContent)))
+	downloaded, err := helpers.DownloadBlob(ctx, bucketName, "roundtrip.txt")
+	require.NoError(t, err)
+	artifacts.Compare(t, "roundtrip.txt", downloaded, []byte(blobContent))
 
 	// 2. Verify NoSQL (Cosmos DB)
 	tableName := terraform.Output(t, terraformOptions, "table_name")
-	assert.NotEmpty(t, tableName)
+	cosmosDatabase := terraform.Output(t, terraformOptions, "cosmos_database")
+	// table_name should name an existing Cosmos container
+	eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+		return helpers.VerifyTableExists(ctx, cosmosDatabase, tableName)
+	})
+
+	// The container's offer should carry the provisioned throughput
+	throughput, err := helpers.ReadThroughput(ctx, cosmosDatabase, tableName)
+	require.NoError(t, err)
+	assert.Equal(t, azurehelpers.Throughput{Manual: cosmosThroughput}, throughput)
 
 	// 3. Verify Networking (VNet)
 	vnetID := terraform.Output(t, terraformOptions, "vnet_id")
-	assert.NotEmpty(t, vnetID)
+	assert.Contains(t, vnetID, "/virtualNetworks/")
 
 	// 4. Verify Identity (Managed Identity)
 	identityID := terraform.Output(t, terraformOptions, "identity_id")
 	assert.NotEmpty(t, identityID)
 
-	// 5. Verify Compute (Function)
+	// 5-6. Verify Messaging and Compute (Service Bus Queue -> Function ->
+	// Blob): the queue trigger copies a message into the storage container.
+	// The function consumes the queue, so a message is not received back.
 	functionName := terraform.Output(t, terraformOptions, "function_name")
-	assert.NotEmpty(t, functionName)
+	queueName := terraform.Output(t, terraformOptions, "queue_name")
+	assert.Contains(t, terraform.Output(t, terraformOptions, "queue_url"), queueName)
 
-	// 6. Verify Messaging (Service Bus Queue)
-	queueURL := terraform.Output(t, terraformOptions, "queue_url")
-	assert.NotEmpty(t, queueURL)
+	messageID := fmt.Sprintf("msg-%d", timestamp)
+	messageBody := fmt.Sprintf("hello from service bus %d", timestamp)
+	require.NoError(t, helpers.SendQueueMessageWithID(ctx, queueName, messageID, messageBody), "queue_name output should name an existing queue")
+
+	// The blob is named after the message ID (copy_message/function.json)
+	copied, err := eventually.Poll(triggerTimeout, eventually.DefaultInterval, func() ([]byte, error) {
+		return helpers.DownloadBlob(ctx, bucketName, messageID+".txt")
+	})
+	if err != nil {
+		t.Fatalf("function %s did not copy message %s into container %s: %v\n%s", functionName, messageID, bucketName, err, functionLogs(ctx, helpers, functionName))
+	}
+	assert.Equal(t, messageBody, string(copied))
 
 	t.Log("✓ Azure integration test successful")
 }
 
-func ensureAzureRunning(t *testing.T) {
-	client := &http.Client{Timeout: 2 * time.Second}
-	// Check Azure Blob endpoint
-	resp, err := client.Get(azureEndpoint + "/devstoreaccount1")
-	
-	if err != nil || (resp.StatusCode != 200 && resp.StatusCode != 400 && resp.StatusCode != 404) {
-		t.Skip("CloudEmu (Azure) not running. Start with: cd cloudemu && cargo run --release -p cloudemu-server")
+// functionLogs describes the invocation logs of the copy function for a
+// failure message, or why they could not be read
+func functionLogs(ctx context.Context, helpers *azurehelpers.Helpers, app string) string {
+	lines, err := helpers.FunctionLogs(ctx, app, copyFunction)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("invocation logs unavailable: %v", err)
+	case len(lines) == 0:
+		return "no invocation logs"
 	}
-	
-	t.Log("✓ CloudEmu (Azure) is running")
+	return "invocation logs:\n  " + strings.Join(lines, "\n  ")
+}
+
+// ensureAzureRunning skips the test, or fails it under
+// SWE_REQUIRE_INTEGRATION, unless the Azure services CloudEmu serves answer, and
+// returns the config it checked
+func ensureAzureRunning(t *testing.T) *config.TestConfig {
+	t.Helper()
+
+	cfg := config.Load(t)
+	integration.Require(t, "CloudEmu (Azure)", integration.StartCloudEmu, smoke.Probe(cfg, smoke.ForProvider("azure")...))
+	return cfg
 }
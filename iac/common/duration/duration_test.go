@@ -0,0 +1,146 @@
+package duration_test
+
+import (
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// TestIso8601Duration evaluates the module's locals for each case with the
+// HCL evaluator Terraform uses, so the rendering edge cases are covered
+// without a Terraform binary or provider
+func TestIso8601Duration(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		seconds  int
+		expected string
+	}{
+		{"zero", 0, "PT0S"},
+		{"one second", 1, "PT1S"},
+		{"just under a minute", 59, "PT59S"},
+		{"one minute", 60, "PT1M"},
+		{"minutes and seconds", 90, "PT1M30S"},
+		{"five minutes", 300, "PT5M"},
+		{"one hour", 3600, "PT1H"},
+		{"hours, minutes and seconds", 3661, "PT1H1M1S"},
+		{"just under a day", 86399, "PT23H59M59S"},
+		{"one day", 86400, "P1D"},
+		{"day and seconds without minutes", 86401, "P1DT1S"},
+		{"day and hours", 90000, "P1DT1H"},
+		{"day and minutes without hours", 86460, "P1DT1M"},
+		{"four days", 345600, "P4D"},
+		{"fourteen days", 1209600, "P14D"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, evalIso8601(t, tc.seconds), "%d seconds", tc.seconds)
+		})
+	}
+}
+
+// moduleFunctions are the Terraform functions main.tf calls
+var moduleFunctions = map[string]function.Function{
+	"floor": stdlib.FloorFunc,
+	"join":  stdlib.JoinFunc,
+}
+
+// evalIso8601 evaluates the locals in main.tf with var.seconds set and
+// returns local.iso8601. Locals are evaluated once every local they refer
+// to has a value, as Terraform orders them.
+func evalIso8601(t *testing.T, seconds int) string {
+	t.Helper()
+
+	file, diags := hclparse.NewParser().ParseHCLFile("main.tf")
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	content, diags := file.Body.Content(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "terraform"},
+			{Type: "variable", LabelNames: []string{"name"}},
+			{Type: "locals"},
+			{Type: "output", LabelNames: []string{"name"}},
+		},
+	})
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	pending := map[string]*hcl.Attribute{}
+	for _, block := range content.Blocks.OfType("locals") {
+		attrs, diags := block.Body.JustAttributes()
+		require.False(t, diags.HasErrors(), diags.Error())
+		for name, attr := range attrs {
+			pending[name] = attr
+		}
+	}
+
+	locals := map[string]cty.Value{}
+	vars := cty.ObjectVal(map[string]cty.Value{"seconds": cty.NumberIntVal(int64(seconds))})
+
+	for len(pending) > 0 {
+		progressed := false
+		for name, attr := range pending {
+			if !localsReady(attr.Expr, locals) {
+				continue
+			}
+			ctx := &hcl.EvalContext{
+				Variables: map[string]cty.Value{"var": vars, "local": cty.ObjectVal(locals)},
+				Functions: moduleFunctions,
+			}
+			value, diags := attr.Expr.Value(ctx)
+			require.False(t, diags.HasErrors(), "local.%s: %s", name, diags.Error())
+
+			locals[name] = value
+			delete(pending, name)
+			progressed = true
+		}
+		require.True(t, progressed, "locals in main.tf refer to each other in a cycle or to undefined locals")
+	}
+
+	iso8601, ok := locals["iso8601"]
+	require.True(t, ok, "main.tf should define local.iso8601")
+	return iso8601.AsString()
+}
+
+// localsReady reports whether every local expr refers to is evaluated
+func localsReady(expr hcl.Expression, locals map[string]cty.Value) bool {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "local" || len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		if _, done := locals[attr.Name]; !done {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIso8601DurationRejectsNegative(t *testing.T) {
+	terraformOptions := tfopts.New(t, &terraform.Options{
+		TerraformDir: test_structure.CopyTerraformFolderToTemp(t, ".", "."),
+		Vars: map[string]interface{}{
+			"seconds": -1,
+		},
+	})
+
+	_, err := terraform.InitAndPlanE(t, terraformOptions)
+	assert.Error(t, err, "Negative durations should be rejected")
+}
@@ -0,0 +1,38 @@
+# ISO 8601 Duration
+# Renders a number of seconds as an ISO 8601 duration (e.g. 86400 -> P1D,
+# 90 -> PT1M30S) for providers that take durations in that format.
+
+terraform {
+  required_version = ">= 1.0"
+}
+
+variable "seconds" {
+  description = "Duration in seconds"
+  type        = number
+
+  validation {
+    condition     = var.seconds >= 0 && floor(var.seconds) == var.seconds
+    error_message = "seconds must be a non-negative integer, e.g. 86400 for a day"
+  }
+}
+
+locals {
+  days    = floor(var.seconds / 86400)
+  hours   = floor(var.seconds % 86400 / 3600)
+  minutes = floor(var.seconds % 3600 / 60)
+  secs    = var.seconds % 60
+
+  date_part = local.days > 0 ? "${local.days}D" : ""
+  time_part = join("", [
+    local.hours > 0 ? "${local.hours}H" : "",
+    local.minutes > 0 ? "${local.minutes}M" : "",
+    local.secs > 0 ? "${local.secs}S" : "",
+  ])
+
+  iso8601 = var.seconds == 0 ? "PT0S" : "P${local.date_part}${local.time_part != "" ? "T${local.time_part}" : ""}"
+}
+
+output "iso8601" {
+  description = "Duration in ISO 8601 format"
+  value       = local.iso8601
+}
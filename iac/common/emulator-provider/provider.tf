@@ -0,0 +1,49 @@
+# AWS provider pointed at a local emulator (CloudEmu, ZeroCloud)
+#
+# Every service the AWS modules use is sent to var.emulator_endpoint, sts
+# and iam included: an endpoint left out goes to the real AWS API, which
+# fails without credentials at best. The credentials are the emulator's
+# placeholders, and the checks that would reach AWS or the instance
+# metadata service are skipped.
+
+provider "aws" {
+  region = var.aws_region
+
+  endpoints {
+    acm            = var.emulator_endpoint
+    apigateway     = var.emulator_endpoint
+    backup         = var.emulator_endpoint
+    budgets        = var.emulator_endpoint
+    cloudfront     = var.emulator_endpoint
+    cloudwatch     = var.emulator_endpoint
+    dynamodb       = var.emulator_endpoint
+    ec2            = var.emulator_endpoint
+    eks            = var.emulator_endpoint
+    events         = var.emulator_endpoint
+    iam            = var.emulator_endpoint
+    kms            = var.emulator_endpoint
+    lambda         = var.emulator_endpoint
+    logs           = var.emulator_endpoint
+    pricing        = var.emulator_endpoint
+    rds            = var.emulator_endpoint
+    route53        = var.emulator_endpoint
+    s3             = var.emulator_endpoint
+    secretsmanager = var.emulator_endpoint
+    sfn            = var.emulator_endpoint
+    sns            = var.emulator_endpoint
+    sqs            = var.emulator_endpoint
+    sts            = var.emulator_endpoint
+    wafv2          = var.emulator_endpoint
+  }
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_region_validation      = true
+  skip_requesting_account_id  = true
+
+  # Emulators serve buckets under the endpoint's path, not as subdomains
+  s3_use_path_style = true
+
+  access_key = "test"
+  secret_key = "test"
+}
@@ -0,0 +1,14 @@
+# The variables provider.tf reads. Each consumer declares them itself, with
+# the default that suits its emulator.
+
+variable "emulator_endpoint" {
+  description = "Emulator endpoint URL every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
@@ -0,0 +1,13 @@
+# Lets terraform validate check provider.tf on its own; consumers get a
+# copy of provider.tf only and keep their own terraform block
+
+terraform {
+  required_version = ">= 1.0"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
@@ -0,0 +1,98 @@
+# Resource Naming
+# Builds a resource name by the convention project_name-environment-name and
+# makes it legal for the resource type: illegal characters are replaced or
+# dropped, and a name outside the type's length limits is cut and given a
+# hash suffix, so it fails neither at apply time nor by colliding with
+# another cut name. naming.json holds the rules of each resource type;
+# testutil/naming reads the same file to test every rule.
+#
+# Facades with an Azure implementation name their resource group here, as
+# module "resource_group_name" with resource_type azure_resource_group and
+# name "rg", e.g. acme-dev-rg.
+
+terraform {
+  required_version = ">= 1.2"
+}
+
+variable "resource_type" {
+  description = "Resource type the name is for, a top-level key of naming.json, e.g. azure_storage_account"
+  type        = string
+}
+
+variable "project_name" {
+  description = "First part of the name; null to leave it out, e.g. for a name the caller chose"
+  type        = string
+  default     = null
+}
+
+variable "environment" {
+  description = "Second part of the name; null to leave it out"
+  type        = string
+  default     = null
+  validation {
+    condition     = can(regex("^[A-Za-z0-9]+$", coalesce(var.environment, "dev")))
+    error_message = "environment must be letters and digits, e.g. dev or prod, or null to leave it out"
+  }
+}
+
+variable "name" {
+  description = "Last part of the name, e.g. rg, or a whole name the caller chose; null to leave it out"
+  type        = string
+  default     = null
+}
+
+locals {
+  rules = jsondecode(file("${path.module}/naming.json"))
+  known = contains(keys(local.rules), var.resource_type)
+
+  # An unknown type gets permissive rules, so the precondition on name can
+  # report it
+  rule = merge({
+    min_length   = 1
+    max_length   = 255
+    lowercase    = false
+    chars        = "A-Za-z0-9-"
+    separator    = "-"
+    letter_first = false
+    pattern      = ".*"
+  }, try(local.rules[var.resource_type], {}))
+
+  # Long enough that two names cut to the same prefix do not collide
+  hash_length = 8
+
+  full = join("-", compact([var.project_name, var.environment, var.name]))
+
+  # Each run of characters the type does not allow becomes the separator,
+  # then repeated hyphens become one and none is left at either end
+  cased   = local.rule.lowercase ? lower(local.full) : local.full
+  cleaned = trim(replace(replace(local.cased, "/[^${local.rule.chars}]+/", local.rule.separator), "/-+/", "-"), "-")
+
+  lettered = local.rule.letter_first && !can(regex("^[A-Za-z]", local.cleaned)) ? "n${local.cleaned}" : local.cleaned
+
+  # A name that is too long is cut to make room for the hash of the full
+  # name, and one that is too short is padded with it
+  fits   = length(local.lettered) >= local.rule.min_length && length(local.lettered) <= local.rule.max_length
+  hash   = substr(sha256(local.full), 0, local.hash_length)
+  prefix = trim(substr(local.lettered, 0, local.rule.max_length - length(local.rule.separator) - local.hash_length), "-")
+  result = local.fits ? local.lettered : join(local.rule.separator, compact([local.prefix, local.hash]))
+}
+
+output "name" {
+  description = "The legal name"
+  value       = local.result
+
+  precondition {
+    condition     = local.known
+    error_message = "naming.json has no rules for resource type \"${var.resource_type}\""
+  }
+
+  precondition {
+    condition     = length(local.result) >= local.rule.min_length && length(local.result) <= local.rule.max_length && can(regex(local.rule.pattern, local.result))
+    error_message = "\"${local.result}\" breaks the ${var.resource_type} rules in naming.json"
+  }
+}
+
+output "truncated" {
+  description = "Whether the name was cut or padded to fit, and so ends in a hash"
+  value       = !local.fits
+}
@@ -0,0 +1,76 @@
+package naming_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/naming"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestModuleMatchesGenerate applies the module to awkward names of each
+// kind testutil/naming's property tests cover, and checks it gives the
+// same name as naming.Generate, which those tests hold to the rules
+func TestModuleMatchesGenerate(t *testing.T) {
+	rules, err := naming.Load(filepath.Join("..", "..", naming.RulesFile))
+	require.NoError(t, err)
+
+	cases := []struct {
+		resourceType string
+		parts        [3]string
+	}{
+		{"azure_resource_group", [3]string{"acme", "dev", "rg"}},
+		{"azure_resource_group", [3]string{"Acme_Platform", "PROD", "web.01"}},
+		{"azure_storage_account", [3]string{"acme-platform-payments-reconciliation", "dev", "assets"}},
+		{"azure_storage_account", [3]string{"", "", "ab"}},
+		{"aws_s3_bucket", [3]string{"-acme-", "dev", "-bucket-"}},
+		{"aws_s3_bucket", [3]string{"projet-été", "dev", "données"}},
+		{"aws_lb", [3]string{"acme-platform-payments-reconciliation-svc", "staging", "alb"}},
+		{"gcp_compute_instance", [3]string{"", "", "9lives"}},
+		{"gcp_service_account", [3]string{"日本語", "dev", ""}},
+		{"gcp_service_account", [3]string{"acme-platform-payments", "staging", "build-agent"}},
+		{"gcp_kms_key_ring", [3]string{"acme", "dev", "keyring"}},
+		{"azure_linux_virtual_machine", [3]string{strings.Repeat("x", 200), "dev", "vm"}},
+	}
+
+	terraformOptions := tfopts.New(t, &terraform.Options{TerraformDir: "."})
+	terraform.Init(t, terraformOptions)
+
+	// Applies share the directory's state, so run one after another
+	for _, tc := range cases {
+		vars := map[string]interface{}{"resource_type": tc.resourceType}
+		for i, name := range []string{"project_name", "environment", "name"} {
+			if tc.parts[i] != "" {
+				vars[name] = tc.parts[i]
+			}
+		}
+		terraformOptions.Vars = vars
+
+		t.Run(fmt.Sprintf("%s/%s", tc.resourceType, strings.Join(tc.parts[:], "-")), func(t *testing.T) {
+			tfopts.Apply(t, terraformOptions)
+
+			want := naming.Generate(rules[tc.resourceType], tc.parts[:]...)
+			assert.Equal(t, want, terraform.Output(t, terraformOptions, "name"))
+		})
+	}
+}
+
+func TestUnknownResourceType(t *testing.T) {
+	terraformOptions := tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"resource_type": "aws_spaceship",
+			"name":          "enterprise",
+		},
+	})
+
+	_, err := tfopts.InitAndApplyE(t, terraformOptions)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `naming.json has no rules for resource type "aws_spaceship"`)
+}
@@ -0,0 +1,49 @@
+# Size Mappings
+# Resolves a provider-neutral size (small, medium, large, xlarge) to the
+# provider's instance type. sizes.json is the only copy of the mappings; the
+# facades read it through this module and the root size tests read it to
+# generate their plan assertions.
+
+terraform {
+  required_version = ">= 1.2"
+}
+
+variable "resource_kind" {
+  description = "Kind of resource being sized, a top-level key of sizes.json"
+  type        = string
+  validation {
+    condition     = contains(["compute", "database"], var.resource_kind)
+    error_message = "Resource kind must be one of: compute, database"
+  }
+}
+
+variable "provider_name" {
+  description = "Cloud provider the size is resolved for"
+  type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp", "oracle", "zero"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp, oracle, zero"
+  }
+}
+
+variable "size" {
+  description = "Provider-neutral size (small, medium, large, or xlarge)"
+  type        = string
+}
+
+locals {
+  sizes = jsondecode(file("${path.module}/sizes.json"))
+
+  # null when the provider has no mappings for this kind, e.g. zero databases
+  provider_sizes = lookup(local.sizes[var.resource_kind], var.provider_name, null)
+}
+
+output "instance_type" {
+  description = "Provider instance type for the size, null when the provider has no mappings for this kind"
+  value       = local.provider_sizes == null ? null : lookup(local.provider_sizes, var.size, null)
+
+  precondition {
+    condition     = local.provider_sizes == null || contains(keys(local.provider_sizes), var.size)
+    error_message = "sizes.json has no ${var.resource_kind} size \"${var.size}\" for ${var.provider_name}"
+  }
+}
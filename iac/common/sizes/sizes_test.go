@@ -0,0 +1,40 @@
+package sizes_test
+
+import (
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeLookup(t *testing.T) {
+	terraformOptions := tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"resource_kind": "database",
+			"provider_name": "gcp",
+			"size":          "large",
+		},
+	})
+	tfopts.InitAndApply(t, terraformOptions)
+
+	assert.Equal(t, "db-n1-standard-1", terraform.Output(t, terraformOptions, "instance_type"))
+}
+
+func TestUnmappedSize(t *testing.T) {
+	terraformOptions := tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"resource_kind": "compute",
+			"provider_name": "aws",
+			"size":          "huge",
+		},
+	})
+
+	_, err := tfopts.InitAndApplyE(t, terraformOptions)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `sizes.json has no compute size "huge" for aws`)
+}
@@ -0,0 +1,86 @@
+# Mandatory Tags
+# Builds the tags every facade applies. Project, Environment and ManagedBy
+# are always set and cannot be overridden by caller tags; GCP receives the
+# same set as project, environment and managed_by labels, with caller tags
+# normalized to the GCP label rules.
+
+terraform {
+  required_version = ">= 1.0"
+}
+
+variable "project_name" {
+  description = "Project the resources belong to"
+  type        = string
+}
+
+variable "environment" {
+  description = "Deployment environment"
+  type        = string
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+variable "tags" {
+  description = "Caller tags merged under the mandatory ones"
+  type        = map(string)
+  default     = {}
+}
+
+locals {
+  # AWS and Azure keep the tag keys the facades have always written, so
+  # existing cost allocation tags and tag-based policies keep matching
+  mandatory_tags = {
+    Project     = var.project_name
+    Environment = var.environment
+    ManagedBy   = "swe-cloud"
+  }
+
+  mandatory_labels = {
+    project     = substr(replace(lower(var.project_name), "/[^a-z0-9_-]/", "_"), 0, 63)
+    environment = var.environment
+    managed_by  = "swe-cloud"
+  }
+
+  # Caller keys naming a mandatory key in any case, with or without the
+  # underscore, are dropped; Azure treats tag names case-insensitively and
+  # would reject both
+  reserved_keys = ["project", "environment", "managedby", "managed_by"]
+
+  caller_tags = {
+    for k, v in var.tags : k => v
+    if !contains(local.reserved_keys, lower(k))
+  }
+
+  tags = merge(local.caller_tags, local.mandatory_tags)
+
+  # GCP labels allow only lowercase letters, digits, "_" and "-", at most 63
+  # characters for keys and values, and keys must start with a letter; keys
+  # that do not are prefixed with "k_". Keys that collide after
+  # normalization keep the value of the last one in lexical order.
+  normalized_keys = {
+    for k in keys(local.caller_tags) :
+    k => replace(lower(k), "/[^a-z0-9_-]/", "_")
+  }
+
+  grouped_labels = {
+    for k, v in local.caller_tags :
+    substr(can(regex("^[a-z]", local.normalized_keys[k])) ? local.normalized_keys[k] : "k_${local.normalized_keys[k]}", 0, 63) => substr(replace(lower(v), "/[^a-z0-9_-]/", "_"), 0, 63)...
+  }
+
+  labels = merge(
+    { for k, values in local.grouped_labels : k => values[length(values) - 1] },
+    local.mandatory_labels,
+  )
+}
+
+output "tags" {
+  description = "Tags for AWS and Azure resources"
+  value       = local.tags
+}
+
+output "labels" {
+  description = "Labels for GCP resources"
+  value       = local.labels
+}
@@ -0,0 +1,119 @@
+package tags_test
+
+import (
+	"strings"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMandatoryTags(t *testing.T) {
+	terraformOptions := tfopts.New(t, &terraform.Options{
+		// Applied from a copy so no state is left in the module directory
+		TerraformDir: test_structure.CopyTerraformFolderToTemp(t, ".", "."),
+		Vars: map[string]interface{}{
+			"project_name": "billing",
+			"environment":  "prod",
+			"tags": map[string]string{
+				"Team":        "payments",
+				"project":     "spoofed",
+				"managed_by":  "someone-else",
+				"ENVIRONMENT": "dev",
+			},
+		},
+	})
+	tfopts.InitAndApply(t, terraformOptions)
+
+	tags := terraform.OutputMap(t, terraformOptions, "tags")
+	assert.Equal(t, map[string]string{
+		"Project":     "billing",
+		"Environment": "prod",
+		"ManagedBy":   "swe-cloud",
+		"Team":        "payments",
+	}, tags, "Mandatory tags should win over caller tags, whatever their case")
+
+	labels := terraform.OutputMap(t, terraformOptions, "labels")
+	assert.Equal(t, map[string]string{
+		"project":     "billing",
+		"environment": "prod",
+		"managed_by":  "swe-cloud",
+		"team":        "payments",
+	}, labels, "GCP should get the mandatory keys as lowercase labels")
+}
+
+func TestGcpLabelNormalization(t *testing.T) {
+	longKey := strings.Repeat("k", 70)
+	longValue := strings.Repeat("V", 70)
+
+	cases := []struct {
+		name     string
+		tags     map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "uppercase",
+			tags:     map[string]string{"CostCenter": "Engineering"},
+			expected: map[string]string{"costcenter": "engineering"},
+		},
+		{
+			name:     "spaces",
+			tags:     map[string]string{"Cost Center": "Platform Team"},
+			expected: map[string]string{"cost_center": "platform_team"},
+		},
+		{
+			name:     "invalid characters",
+			tags:     map[string]string{"owner": "ops@example.com"},
+			expected: map[string]string{"owner": "ops_example_com"},
+		},
+		{
+			name:     "longer than 63 characters",
+			tags:     map[string]string{longKey: longValue},
+			expected: map[string]string{strings.Repeat("k", 63): strings.Repeat("v", 63)},
+		},
+		{
+			name:     "key starting with a digit",
+			tags:     map[string]string{"1st Owner": "ops"},
+			expected: map[string]string{"k_1st_owner": "ops"},
+		},
+		{
+			name:     "key starting with an underscore",
+			tags:     map[string]string{"_internal": "yes"},
+			expected: map[string]string{"k__internal": "yes"},
+		},
+		{
+			name:     "collision after normalization",
+			tags:     map[string]string{"Cost Center": "a", "cost_center": "b"},
+			expected: map[string]string{"cost_center": "b"},
+		},
+	}
+
+	terraformOptions := tfopts.New(t, &terraform.Options{
+		TerraformDir: test_structure.CopyTerraformFolderToTemp(t, ".", "."),
+	})
+	terraform.Init(t, terraformOptions)
+
+	for _, tc := range cases {
+		terraformOptions.Vars = map[string]interface{}{
+			"project_name": "billing",
+			"environment":  "prod",
+			"tags":         tc.tags,
+		}
+		tfopts.Apply(t, terraformOptions)
+
+		expected := map[string]string{
+			"project":     "billing",
+			"environment": "prod",
+			"managed_by":  "swe-cloud",
+		}
+		for k, v := range tc.expected {
+			expected[k] = v
+		}
+
+		labels := terraform.OutputMap(t, terraformOptions, "labels")
+		assert.Equal(t, expected, labels, tc.name)
+	}
+}
@@ -33,7 +33,7 @@ variable "resource_size" {
   default     = "medium"
   validation {
     condition     = contains(["small", "medium", "large", "xlarge"], var.resource_size)
-    error_message = "Size must be one of: small, medium, large, xlarge"
+    error_message = "Resource size must be one of: small, medium, large, xlarge"
   }
 }
 
@@ -46,7 +46,7 @@ variable "project_name" {
   type        = string
   validation {
     condition     = can(regex("^[a-z0-9-]+$", var.project_name))
-    error_message = "Project name must contain only lowercase letters, numbers, and hyphens"
+    error_message = "Project name must contain only lowercase letters, numbers, and hyphens, e.g. my-project"
   }
 }
 
@@ -61,7 +61,7 @@ variable "owner" {
   type        = string
   validation {
     condition     = can(regex("^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\\.[a-zA-Z]{2,}$|^[a-z-]+$", var.owner))
-    error_message = "Owner must be a valid email or team name"
+    error_message = "Owner must be a valid email or team name, e.g. ops@example.com or platform-team"
   }
 }
 
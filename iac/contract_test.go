@@ -0,0 +1,215 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// facadeOutputContracts lists the outputs each facade must expose regardless
+// of the selected provider. Consumers compose facades through these names, so
+// every provider branch has to resolve them to a real value.
+var facadeOutputContracts = map[string][]string{
+	"facade/database":   {"db_instance_id", "db_endpoint"},
+	"facade/iam":        {"identity_id", "principal_id"},
+	"facade/lambda":     {"function_arn", "function_name", "invoke_url", "alarm_arns"},
+	"facade/messaging":  {"queue_url", "queue_id", "topic_arn", "topic_id", "alarm_arns"},
+	"facade/networking": {"network_id"},
+	"facade/storage":    {"bucket_id", "bucket_url", "bucket_arn"},
+}
+
+// platformOutputContracts lists the outputs each platform module must
+// expose. A platform module reads facade outputs, so each of those must be
+// declared by the facade it calls.
+var platformOutputContracts = map[string][]string{
+	"platform/service": {"function_name", "function_arn", "identity_ref", "network_id", "table_name", "queue_url", "queue_id", "bucket_name", "alarm_ids"},
+}
+
+// facadesWithoutZero are the contract facades with no ZeroCloud branch, and
+// why; they must reject provider_name = "zero" instead
+var facadesWithoutZero = map[string]string{
+	"facade/database": "ZeroCloud has no relational database service",
+}
+
+var outputBlockPattern = regexp.MustCompile(`(?m)^output\s+"([^"]+)"\s*\{`)
+
+var (
+	zeroModulePattern    = regexp.MustCompile(`(?m)^module\s+"(zero_\w+)"\s*\{[^}]*?source\s*=\s*"([^"]+)"`)
+	zeroModuleRefPattern = regexp.MustCompile(`module\.(zero_\w+)\[0\]\.(\w+)`)
+)
+
+var (
+	modulePattern    = regexp.MustCompile(`(?m)^module\s+"(\w+)"\s*\{[^}]*?source\s*=\s*"([^"]+)"`)
+	moduleRefPattern = regexp.MustCompile(`module\.(\w+)(?:\[0\])?\.(\w+)`)
+)
+
+// TestFacadeOutputContracts statically checks that facades declare their
+// contract outputs and that none of them fall back to placeholder values.
+func TestFacadeOutputContracts(t *testing.T) {
+	t.Parallel()
+
+	for dir, required := range facadeOutputContracts {
+		dir, required := dir, required
+
+		t.Run(dir, func(t *testing.T) {
+			t.Parallel()
+
+			outputs, err := findDeclaredOutputs(dir)
+			require.NoError(t, err)
+
+			for _, name := range required {
+				body, ok := outputs[name]
+				if !assert.True(t, ok, "Facade %s should declare output %q", dir, name) {
+					continue
+				}
+				assert.NotContains(t, body, "placeholder", "Output %q in %s should not return a placeholder", name, dir)
+			}
+		})
+	}
+}
+
+// TestFacadeZeroBranches checks that every contract facade routes
+// provider_name = "zero" to a ZeroCloud core module, and that each output the
+// facade reads from that module is actually declared by it. A missing output
+// only surfaces at plan time with provider "zero", which no cloud test covers.
+// Facades in facadesWithoutZero must instead have no zero_* module and say
+// why in their validation.
+func TestFacadeZeroBranches(t *testing.T) {
+	t.Parallel()
+
+	for dir := range facadeOutputContracts {
+		dir := dir
+
+		t.Run(dir, func(t *testing.T) {
+			t.Parallel()
+
+			text, err := readModuleSource(dir)
+			require.NoError(t, err)
+
+			modules := make(map[string]string)
+			for _, match := range zeroModulePattern.FindAllStringSubmatch(text, -1) {
+				modules[match[1]] = filepath.Join(dir, match[2])
+			}
+			if reason, ok := facadesWithoutZero[dir]; ok {
+				assert.Empty(t, modules, "Facade %s should not route provider_name = \"zero\": %s", dir, reason)
+				assert.Contains(t, text, reason, "Facade %s should reject provider_name = \"zero\" with the reason", dir)
+				return
+			}
+			require.NotEmpty(t, modules, "Facade %s should have a zero_* module for provider_name = \"zero\"", dir)
+
+			for _, ref := range zeroModuleRefPattern.FindAllStringSubmatch(text, -1) {
+				source, ok := modules[ref[1]]
+				if !assert.True(t, ok, "Facade %s references undeclared module %s", dir, ref[1]) {
+					continue
+				}
+
+				outputs, err := findDeclaredOutputs(source)
+				require.NoError(t, err)
+				assert.Contains(t, outputs, ref[2], "Module %s (%s) should declare output %q used by %s", ref[1], source, ref[2], dir)
+			}
+		})
+	}
+}
+
+// TestPlatformOutputContracts checks that platform modules declare their
+// contract outputs, and that every facade output they read is declared by
+// the facade, which otherwise only fails at plan time with the feature on.
+func TestPlatformOutputContracts(t *testing.T) {
+	t.Parallel()
+
+	for dir, required := range platformOutputContracts {
+		dir, required := dir, required
+
+		t.Run(dir, func(t *testing.T) {
+			t.Parallel()
+
+			outputs, err := findDeclaredOutputs(dir)
+			require.NoError(t, err)
+			for _, name := range required {
+				body, ok := outputs[name]
+				if !assert.True(t, ok, "Platform module %s should declare output %q", dir, name) {
+					continue
+				}
+				assert.NotContains(t, body, "placeholder", "Output %q in %s should not return a placeholder", name, dir)
+			}
+
+			text, err := readModuleSource(dir)
+			require.NoError(t, err)
+
+			modules := make(map[string]string)
+			for _, match := range modulePattern.FindAllStringSubmatch(text, -1) {
+				modules[match[1]] = filepath.Join(dir, match[2])
+			}
+			require.NotEmpty(t, modules, "Platform module %s should compose facades", dir)
+
+			for _, ref := range moduleRefPattern.FindAllStringSubmatch(text, -1) {
+				source, ok := modules[ref[1]]
+				if !assert.True(t, ok, "Platform module %s references undeclared module %s", dir, ref[1]) {
+					continue
+				}
+
+				outputs, err := findDeclaredOutputs(source)
+				require.NoError(t, err)
+				assert.Contains(t, outputs, ref[2], "Facade %s should declare output %q used by %s", source, ref[2], dir)
+			}
+		})
+	}
+}
+
+// readModuleSource concatenates the .tf files of a module directory with
+// normalized line endings.
+func readModuleSource(dir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(strings.ReplaceAll(string(content), "\r\n", "\n"))
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
+
+// findDeclaredOutputs returns the body of every output block declared in the
+// .tf files of a module directory, keyed by output name.
+func findDeclaredOutputs(dir string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[string]string)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		text := strings.ReplaceAll(string(content), "\r\n", "\n")
+
+		for _, match := range outputBlockPattern.FindAllStringSubmatchIndex(text, -1) {
+			name := text[match[2]:match[3]]
+			body := text[match[1]:]
+			if end := strings.Index(body, "\n}"); end >= 0 {
+				body = body[:end]
+			}
+			// Keep the first declaration; later matches come from commented usage examples
+			if _, seen := outputs[name]; !seen {
+				outputs[name] = body
+			}
+		}
+	}
+
+	return outputs, nil
+}
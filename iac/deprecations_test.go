@@ -0,0 +1,87 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/deprecation"
+	"iac/testutil/planerr"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeprecatedVariables holds each facade to its entries in
+// deprecations.json until they are removed: both names are declared, a
+// caller setting only the old name still plans, with a warning naming it,
+// and a caller setting both fails validation on the old one. The plans use
+// the facade's facadePlanVars with the replacement renamed.
+func TestDeprecatedVariables(t *testing.T) {
+	t.Parallel()
+
+	deprecations, err := deprecation.Load(deprecation.ManifestFile)
+	require.NoError(t, err)
+
+	for _, d := range deprecations {
+		d := d
+
+		t.Run(d.Module+"/"+d.Variable, func(t *testing.T) {
+			t.Parallel()
+
+			problems, err := deprecation.Check(".", d)
+			require.NoError(t, err)
+			require.Empty(t, problems)
+
+			vars, ok := facadePlanVars[filepath.Base(d.Module)]
+			require.True(t, ok, "%s needs plan variables in facadePlanVars", d.Module)
+			value, ok := vars[d.Replacement]
+			require.True(t, ok, "facadePlanVars should set %s, so the test can set %s instead", d.Replacement, d.Variable)
+
+			base := map[string]interface{}{
+				"provider_name": "aws",
+				"project_name":  "deprecation",
+			}
+			for k, v := range vars {
+				base[k] = v
+			}
+
+			t.Run("OldNameOnly", func(t *testing.T) {
+				t.Parallel()
+
+				planVars := make(map[string]interface{}, len(base))
+				for k, v := range base {
+					planVars[k] = v
+				}
+				delete(planVars, d.Replacement)
+				planVars[d.Variable] = value
+
+				output, err := terraform.InitAndPlanE(t, tfopts.New(t, &terraform.Options{
+					TerraformDir: d.Module,
+					Vars:         planVars,
+					NoColor:      true,
+				}))
+				require.NoError(t, err, "Setting only %s should plan until %s", d.Variable, d.RemovedIn)
+				assert.Contains(t, planerr.Normalize(output), d.Variable+" is deprecated", "The plan should warn that %s is deprecated", d.Variable)
+			})
+
+			t.Run("BothNames", func(t *testing.T) {
+				t.Parallel()
+
+				planVars := make(map[string]interface{}, len(base)+1)
+				for k, v := range base {
+					planVars[k] = v
+				}
+				planVars[d.Variable] = value
+
+				err := planerr.PlanE(t, tfopts.New(t, &terraform.Options{
+					TerraformDir: d.Module,
+					Vars:         planVars,
+					NoColor:      true,
+				}))
+				planerr.AssertValidationError(t, err, d.Variable, d.Variable+" is deprecated")
+			})
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/emulatorprovider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmulatorProvider checks that every emulator_provider.tf is a current
+// copy of common/emulator-provider/provider.tf and that its directory
+// declares the variables the block reads. `go run ./tools/emulatorprovider`
+// rewrites the copies.
+func TestEmulatorProvider(t *testing.T) {
+	t.Parallel()
+
+	generated, err := emulatorprovider.Render(emulatorprovider.Source)
+	require.NoError(t, err)
+
+	dirs, err := emulatorprovider.Consumers(".")
+	require.NoError(t, err)
+	for _, dir := range []string{"examples/local-cloudemu", "examples/zero-integration"} {
+		assert.Contains(t, dirs, filepath.FromSlash(dir), "%s should use the shared emulator provider", dir)
+	}
+
+	for _, dir := range dirs {
+		diff, err := emulatorprovider.Stale(dir, generated)
+		require.NoError(t, err)
+		if diff != "" {
+			t.Errorf("%s/%s is stale; run go run ./tools/emulatorprovider:\n%s", dir, emulatorprovider.FileName, diff)
+		}
+
+		missing, err := emulatorprovider.Missing(emulatorprovider.Source, dir)
+		require.NoError(t, err)
+		assert.Empty(t, missing, "%s should declare the variables %s reads", dir, emulatorprovider.FileName)
+	}
+}
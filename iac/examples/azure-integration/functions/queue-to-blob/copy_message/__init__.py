@@ -0,0 +1,11 @@
+import logging
+
+import azure.functions as func
+
+
+def main(msg: func.ServiceBusMessage, output: func.Out[str]) -> None:
+    # Copies the message into a blob named after its message ID (see
+    # function.json), so the test finds the copy of the message it sent
+    body = msg.get_body().decode("utf-8")
+    logging.info("copying message %s to %s.txt", msg.message_id, msg.message_id)
+    output.set(body)
@@ -16,7 +16,14 @@ provider "azurerm" {
   storage_use_azuread        = false
   
   # CloudEmu Azure endpoint
-  metadata_host = "http://localhost:10000"
+  metadata_host = var.azure_endpoint
+}
+
+# Connection strings the function's bindings use to reach the emulator, with
+# the well-known development credentials
+locals {
+  service_bus_connection = "Endpoint=sb://${trimprefix(trimprefix(var.azure_endpoint, "http://"), "https://")};SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=SAS_KEY_VALUE;UseDevelopmentEmulator=true;"
+  storage_connection     = "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;BlobEndpoint=${var.azure_endpoint}/devstoreaccount1;"
 }
 
 # 1. Storage Resource (Blob)
@@ -25,7 +32,7 @@ module "storage" {
   
   provider_name = "azure"
   bucket_name   = var.bucket_name
-  project_name  = "azure-test"
+  project_name  = var.project_name
   environment   = var.environment
   
   # CloudEmu-specific
@@ -34,12 +41,23 @@ module "storage" {
 
 # 2. NoSQL Resource (Cosmos DB)
 module "nosql" {
-  source = "../../facade/nosql"
+  source = "../../facade/database"
   
   provider_name = "azure"
-  table_name    = var.table_name
+  engine_type   = "nosql"
+  identifier    = var.table_name
   hash_key      = "id"
-  project_name  = "azure-test"
+  
+  # Provisioned, so the test can read the offer back
+  throughput_mode = "provisioned"
+  max_throughput  = var.cosmos_throughput
+  
+  provider_config = {
+    resource_group_name = "${var.project_name}-${var.environment}-rg"
+    location            = "East US"
+  }
+  
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -50,14 +68,14 @@ module "networking" {
   provider_name = "azure"
   network_name  = "azure-vnet"
   
-  metrics = {
+  network_config = {
     cidr            = "10.0.0.0/16"
     azs             = ["1", "2"]
     public_subnets  = ["10.0.1.0/24", "10.0.2.0/24"]
     private_subnets = ["10.0.3.0/24", "10.0.4.0/24"]
   }
   
-  project_name  = "azure-test"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -70,25 +88,33 @@ module "iam" {
   identity_name = "azure-test-identity"
   principals    = []
   
-  project_name  = "azure-test"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
 # 5. Compute Resource (Function App)
+# copy_message is triggered by the Service Bus queue below and copies each
+# message into the storage container above, as <message ID>.txt
 module "lambda" {
   source = "../../facade/lambda"
   
   provider_name = "azure"
   function_name = "azure-test-func"
-  handler       = "index.handler"
-  runtime       = "node"
+  handler       = "copy_message.main"
+  runtime       = "python3.11"
   
-  # Basic inline code not supported easily in Azure provider via Terraform directly
-  # filename is required, but we can mock it or assume file existence for integration test
-  # This part relies on core module implementation details
-  filename      = "${path.module}/files/test_function.zip" 
+  source_dir = "${path.module}/functions/queue-to-blob"
   
-  project_name  = "azure-test"
+  # Read by the bindings in copy_message/function.json. The queue name is
+  # taken from the queue's ID so the trigger is not created before its queue.
+  environment_variables = {
+    QUEUE_NAME           = reverse(split("/", module.queue.queue_id))[0]
+    OUTPUT_CONTAINER     = module.storage.bucket.name
+    ServiceBusConnection = local.service_bus_connection
+    OutputStorage        = local.storage_connection
+  }
+  
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -98,26 +124,57 @@ module "queue" {
   
   provider_name = "azure"
   type          = "queue"
-  name          = "azure-test-queue"
+  name          = var.queue_name
   
-  project_name  = "azure-test"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
 # Variables
+variable "azure_endpoint" {
+  description = "Azure emulator endpoint"
+  type        = string
+  default     = "http://localhost:10000"
+}
+
 variable "bucket_name" {
-  type    = string
-  default = "test-azure-container"
+  description = "Storage container name"
+  type        = string
+  default     = "test-azure-container"
 }
 
 variable "table_name" {
-  type    = string
-  default = "test-azure-cosmos"
+  description = "Cosmos DB container name"
+  type        = string
+  default     = "test-azure-cosmos"
+}
+
+variable "cosmos_throughput" {
+  description = "Provisioned RU/s of the Cosmos DB container"
+  type        = number
+  default     = 400
+}
+
+variable "queue_name" {
+  description = "Service Bus queue name"
+  type        = string
+  default     = "azure-test-queue"
+}
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "azure-test"
 }
 
 variable "environment" {
-  type    = string
-  default = "test"
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "local"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 # Outputs
@@ -130,7 +187,11 @@ output "bucket_url" {
 }
 
 output "table_name" {
-  value = var.table_name
+  value = module.nosql.nosql_table.name
+}
+
+output "cosmos_database" {
+  value = module.nosql.nosql_table.database
 }
 
 output "vnet_id" {
@@ -145,6 +206,10 @@ output "function_name" {
   value = module.lambda.function_name
 }
 
+output "queue_name" {
+  value = var.queue_name
+}
+
 output "queue_url" {
   value = module.queue.resource_url
 }
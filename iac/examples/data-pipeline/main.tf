@@ -6,13 +6,19 @@ terraform {
 }
 
 variable "environment" {
-  type    = string
-  default = "dev"
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
-  type    = string
-  default = "data-pipe-demo"
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "data-pipe-demo"
 }
 
 # ============================================================================
@@ -28,7 +34,7 @@ module "network_aws" {
   environment  = var.environment
   network_name = "${var.project_name}-net"
   
-  metrics = {
+  network_config = {
     cidr            = "10.0.0.0/16"
     azs             = ["us-east-1a", "us-east-1b"]
     public_subnets  = ["10.0.1.0/24", "10.0.2.0/24"]
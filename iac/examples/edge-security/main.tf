@@ -0,0 +1,59 @@
+# Edge Security Example
+# A certificate facade certificate for a site and its apex, validated
+# through records written into an existing Route 53 zone, and a waf facade
+# web ACL with the common and SQL injection rule sets and a per-IP rate
+# limit, attached to the load balancers or API stages passed in.
+#
+#   terraform plan -var="zone_id=Z0123456789ABCDEFGHIJ" -var="zone_name=example.com"
+#   terraform plan -var="zone_id=Z0123456789ABCDEFGHIJ" -var="zone_name=example.com" \
+#     -var='protected_arns=["arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web/0123456789abcdef"]'
+
+terraform {
+  required_version = ">= 1.9"
+}
+
+locals {
+  provider_name = "aws"
+  name_prefix   = "${var.project_name}-${var.environment}"
+}
+
+# ============================================================================
+# CERTIFICATE
+# ============================================================================
+
+module "certificate" {
+  source = "../../facade/certificate"
+
+  provider_name             = local.provider_name
+  project_name              = var.project_name
+  environment               = var.environment
+  domain_name               = "www.${var.zone_name}"
+  subject_alternative_names = [var.zone_name]
+
+  zone_ref = {
+    provider = local.provider_name
+    id       = var.zone_id
+    name     = var.zone_name
+  }
+}
+
+# ============================================================================
+# FIREWALL
+# ============================================================================
+
+module "waf" {
+  source = "../../facade/waf"
+
+  provider_name     = local.provider_name
+  project_name      = var.project_name
+  environment       = var.environment
+  waf_name          = "${local.name_prefix}-edge"
+  managed_rule_sets = ["common", "sqli"]
+  rate_limit        = var.rate_limit
+
+  attach_to = [for arn in var.protected_arns : {
+    provider = local.provider_name
+    type     = strcontains(arn, ":elasticloadbalancing:") ? "alb" : "api_gateway_stage"
+    id       = arn
+  }]
+}
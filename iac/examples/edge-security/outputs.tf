@@ -0,0 +1,14 @@
+output "certificate_arn" {
+  description = "Certificate for www.<zone_name> and <zone_name>, for load balancer listeners"
+  value       = module.certificate.certificate_arn
+}
+
+output "validation_status" {
+  description = "Whether the certificate has been issued"
+  value       = module.certificate.validation_status
+}
+
+output "web_acl_id" {
+  description = "Web ACL protecting protected_arns"
+  value       = module.waf.web_acl_id
+}
@@ -0,0 +1,39 @@
+# Edge Security Example Variables
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "edge"
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+variable "zone_id" {
+  description = "Route 53 hosted zone ID the certificate's validation records are written to"
+  type        = string
+}
+
+variable "zone_name" {
+  description = "Domain of the hosted zone; the certificate covers it and its www name"
+  type        = string
+}
+
+variable "rate_limit" {
+  description = "Requests a client IP may send in 5 minutes before it is blocked"
+  type        = number
+  default     = 2000
+}
+
+variable "protected_arns" {
+  description = "Application Load Balancer or API Gateway stage ARNs the web ACL protects"
+  type        = list(string)
+  default     = []
+}
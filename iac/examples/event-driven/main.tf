@@ -0,0 +1,127 @@
+# Event-Driven Example
+# Order events on an eventbus facade bus are routed to a messaging facade
+# queue, an Amazon States Language workflow from the workflows facade runs
+# as an iam facade role, and a monitoring facade alarm watches the queue
+# backlog. The events facade adds a separate bus for audit events.
+#
+#   terraform plan
+#   terraform plan -var='alert_channel_arn=arn:aws:sns:us-east-1:123456789012:ops'
+
+terraform {
+  required_version = ">= 1.9"
+}
+
+locals {
+  # The workflow definition below is Amazon States Language, so the example
+  # deploys to AWS
+  provider_name = "aws"
+  name_prefix   = "${var.project_name}-${var.environment}"
+}
+
+# ============================================================================
+# ORDER QUEUE
+# ============================================================================
+
+module "orders_queue" {
+  source = "../../facade/messaging"
+
+  provider_name     = local.provider_name
+  project_name      = var.project_name
+  environment       = var.environment
+  name              = "${local.name_prefix}-orders"
+  type              = "queue"
+  dead_letter_queue = true
+}
+
+# ============================================================================
+# EVENT BUSES
+# ============================================================================
+
+module "bus" {
+  source = "../../facade/eventbus"
+
+  provider_name = local.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+  bus_name      = "${local.name_prefix}-orders"
+
+  rules = [{
+    name    = "orders-to-queue"
+    pattern = jsonencode({ source = ["orders"] })
+    target_ref = {
+      type = "queue"
+      id   = module.orders_queue.queue_id
+    }
+  }]
+}
+
+module "audit_events" {
+  source = "../../facade/events"
+
+  provider_name = local.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "${local.name_prefix}-audit"
+}
+
+# ============================================================================
+# WORKFLOW
+# ============================================================================
+
+module "workflow_role" {
+  source = "../../facade/iam"
+
+  provider_name = local.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+  identity_name = "${local.name_prefix}-workflow"
+  identity_type = "role"
+  principals    = ["states.amazonaws.com"]
+}
+
+module "order_workflow" {
+  source = "../../facade/workflows"
+
+  provider_name = local.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "${local.name_prefix}-fulfil-order"
+  role_arn      = module.workflow_role.principal_id
+
+  definition = jsonencode({
+    Comment = "Accepts an order and hands it on for fulfilment"
+    StartAt = "Accept"
+    States = {
+      Accept = {
+        Type   = "Pass"
+        Result = { status = "accepted" }
+        End    = true
+      }
+    }
+  })
+}
+
+# ============================================================================
+# ALARM
+# ============================================================================
+
+module "backlog_alarm" {
+  source = "../../facade/monitoring"
+
+  provider_name = local.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+  alarm_name    = "${local.name_prefix}-orders-backlog"
+  preset        = "queue_depth"
+  threshold     = var.backlog_threshold
+
+  monitored_resource = {
+    facade_type = "messaging"
+    resource_id = "${local.name_prefix}-orders"
+  }
+
+  notification_ref = var.alert_channel_arn == null ? null : {
+    provider   = local.provider_name
+    channel_id = var.alert_channel_arn
+  }
+}
@@ -0,0 +1,24 @@
+output "bus_arn" {
+  description = "Bus to put order events on, with source \"orders\""
+  value       = module.bus.bus_arn
+}
+
+output "orders_queue_url" {
+  description = "Queue receiving the order events"
+  value       = module.orders_queue.queue_url
+}
+
+output "audit_bus_arn" {
+  description = "Bus for audit events"
+  value       = module.audit_events.event_resource_arn
+}
+
+output "workflow_arn" {
+  description = "Order fulfilment state machine"
+  value       = module.order_workflow.workflow_arn
+}
+
+output "backlog_alarm_id" {
+  description = "Alarm on the order queue backlog"
+  value       = module.backlog_alarm.alarm_id
+}
@@ -0,0 +1,29 @@
+# Event-Driven Example Variables
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "orders"
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+variable "backlog_threshold" {
+  description = "Visible messages in the order queue that raise the backlog alarm"
+  type        = number
+  default     = 100
+}
+
+variable "alert_channel_arn" {
+  description = "SNS topic ARN the backlog alarm notifies (null for no notification)"
+  type        = string
+  default     = null
+}
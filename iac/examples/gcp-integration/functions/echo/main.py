@@ -0,0 +1,13 @@
+import json
+
+
+def handler(request):
+    # Echoes the JSON body it is sent; the test finds the same line in the
+    # function's logs
+    payload = request.get_json(silent=True)
+    if payload is None:
+        return ("expected a JSON body", 400)
+
+    body = json.dumps({"echo": payload})
+    print(body, flush=True)
+    return (body, 200, {"Content-Type": "application/json"})
@@ -15,9 +15,13 @@ provider "google" {
   region  = "us-east1"
   
   # CloudEmu GCP endpoints
-  storage_custom_endpoint   = "http://localhost:4567"
-  firestore_custom_endpoint = "http://localhost:4567/firestore/"
-  pubsub_custom_endpoint    = "http://localhost:4567/"
+  storage_custom_endpoint   = var.gcp_endpoint
+  firestore_custom_endpoint = "${var.gcp_endpoint}/firestore/"
+  pubsub_custom_endpoint    = "${var.gcp_endpoint}/"
+
+  # Cloud Functions (2nd gen) and the Cloud Run service behind each one
+  cloudfunctions2_custom_endpoint = "${var.gcp_endpoint}/v2/"
+  cloud_run_custom_endpoint       = "${var.gcp_endpoint}/"
 }
 
 # 1. Storage Resource (GCS)
@@ -26,20 +30,27 @@ module "storage" {
   
   provider_name = "gcp"
   bucket_name   = var.bucket_name
-  project_name  = "gcp-test"
+  project_name  = var.project_name
   environment   = var.environment
   
   versioning_enabled = true
 }
 
-# 2. NoSQL Resource (Firestore)
+# 2. NoSQL Resource (Firestore, through the database facade's nosql engine)
 module "nosql" {
-  source = "../../facade/nosql"
+  source = "../../facade/database"
   
   provider_name = "gcp"
-  table_name    = var.table_name
+  engine_type   = "nosql"
+  identifier    = var.table_name
   hash_key      = "id"
-  project_name  = "gcp-test"
+  
+  provider_config = {
+    project_id = "local-test"
+    region     = "us-east1"
+  }
+  
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -50,14 +61,14 @@ module "networking" {
   provider_name = "gcp"
   network_name  = "gcp-vpc"
   
-  metrics = {
+  network_config = {
     cidr            = "10.0.0.0/16"
     azs             = ["us-east1-b", "us-east1-c"]
     public_subnets  = ["10.0.1.0/24", "10.0.2.0/24"]
     private_subnets = ["10.0.3.0/24", "10.0.4.0/24"]
   }
   
-  project_name  = "gcp-test"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -70,22 +81,31 @@ module "iam" {
   identity_name = "gcp-test-sa"
   principals    = []
   
-  project_name  = "gcp-test"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
-# 5. Compute Resource (Cloud Function)
+# 5. Compute Resource (Cloud Function): an HTTP function that echoes the
+# JSON it is sent, packaged from functions/echo
 module "lambda" {
   source = "../../facade/lambda"
   
   provider_name = "gcp"
-  function_name = "gcp-test-func"
+  function_name = var.function_name
   handler       = "main.handler"
   runtime       = "python3.11"
   
-  filename      = "${path.module}/files/test_function.zip"
+  source_dir = "${path.module}/functions/echo"
+  
+  enable_http_endpoint = true
+  http_auth_type       = "NONE"
   
-  project_name  = "gcp-test"
+  provider_config = {
+    project_id = "local-test"
+    region     = "us-east1"
+  }
+  
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -97,24 +117,49 @@ module "queue" {
   type          = "topic" # Start with Topic for Pub/Sub
   name          = "gcp-test-topic"
   
-  project_name  = "gcp-test"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
 # Variables
+variable "gcp_endpoint" {
+  description = "GCP emulator endpoint"
+  type        = string
+  default     = "http://localhost:4567"
+}
+
 variable "bucket_name" {
-  type    = string
-  default = "test-gcp-bucket"
+  description = "Storage bucket name"
+  type        = string
+  default     = "test-gcp-bucket"
 }
 
 variable "table_name" {
-  type    = string
-  default = "test-gcp-collection"
+  description = "Firestore collection name"
+  type        = string
+  default     = "test-gcp-collection"
+}
+
+variable "function_name" {
+  description = "Cloud Function name"
+  type        = string
+  default     = "gcp-test-func"
+}
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "gcp-test"
 }
 
 variable "environment" {
-  type    = string
-  default = "test"
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "local"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 # Outputs
@@ -127,7 +172,11 @@ output "bucket_url" {
 }
 
 output "table_name" {
-  value = var.table_name
+  value = module.nosql.nosql_table.name
+}
+
+output "firestore_database" {
+  value = module.nosql.nosql_table.database
 }
 
 output "vpc_id" {
@@ -142,6 +191,10 @@ output "function_name" {
   value = module.lambda.function_name
 }
 
+output "function_url" {
+  value = module.lambda.invoke_url
+}
+
 output "topic_arn" {
   value = module.queue.resource_arn
 }
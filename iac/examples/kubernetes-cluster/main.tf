@@ -0,0 +1,58 @@
+# Kubernetes Cluster Example
+# A networking facade network with private subnets across two zones, and a
+# kubernetes facade cluster whose nodes run in them.
+#
+#   terraform plan -var="provider_name=aws"
+#   terraform plan -var="provider_name=zero" -var="environment=local"
+
+terraform {
+  required_version = ">= 1.9"
+}
+
+locals {
+  name_prefix = "${var.project_name}-${var.environment}"
+
+  # Zone names differ by provider; ZeroCloud takes the AWS names
+  zones = lookup({
+    aws   = ["us-east-1a", "us-east-1b"]
+    azure = ["1", "2"]
+    gcp   = ["us-central1-a", "us-central1-b"]
+  }, var.provider_name, ["us-east-1a", "us-east-1b"])
+}
+
+# ============================================================================
+# NETWORK
+# ============================================================================
+
+module "network" {
+  source = "../../facade/networking"
+
+  provider_name = var.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+  network_name  = "${local.name_prefix}-k8s"
+
+  network_config = {
+    cidr            = "10.20.0.0/16"
+    azs             = local.zones
+    public_subnets  = ["10.20.1.0/24", "10.20.2.0/24"]
+    private_subnets = ["10.20.10.0/24", "10.20.11.0/24"]
+  }
+}
+
+# ============================================================================
+# CLUSTER
+# ============================================================================
+
+module "cluster" {
+  source = "../../facade/kubernetes"
+
+  provider_name = var.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+  cluster_name  = "${local.name_prefix}-cluster"
+  node_count    = var.node_count
+  instance_size = var.instance_size
+  vpc_id        = module.network.network_id
+  subnet_ids    = module.network.private_subnet_ids
+}
@@ -0,0 +1,19 @@
+output "cluster_name" {
+  description = "Name of the cluster"
+  value       = module.cluster.cluster_name
+}
+
+output "cluster_endpoint" {
+  description = "Kubernetes API server endpoint"
+  value       = module.cluster.cluster_endpoint
+}
+
+output "kubeconfig_command" {
+  description = "Command that adds the cluster to the local kubeconfig"
+  value       = module.cluster.kubeconfig_command
+}
+
+output "network_id" {
+  description = "Network the cluster runs in"
+  value       = module.network.network_id
+}
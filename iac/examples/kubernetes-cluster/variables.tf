@@ -0,0 +1,43 @@
+# Kubernetes Cluster Example Variables
+
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp, zero)"
+  type        = string
+  default     = "aws"
+  validation {
+    condition     = contains(["aws", "azure", "gcp", "zero"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp, zero"
+  }
+}
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "platform"
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+variable "node_count" {
+  description = "Worker nodes in the cluster"
+  type        = number
+  default     = 2
+}
+
+variable "instance_size" {
+  description = "Size of the worker nodes (small, medium, large)"
+  type        = string
+  default     = "medium"
+  validation {
+    condition     = contains(["small", "medium", "large"], var.instance_size)
+    error_message = "Instance size must be one of: small, medium, large"
+  }
+}
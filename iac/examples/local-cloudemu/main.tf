@@ -11,40 +11,19 @@ terraform {
       source  = "hashicorp/aws"
       version = "~> 5.0"
     }
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
   }
 }
 
-# Configure AWS provider to use CloudEmu endpoints
-provider "aws" {
-  region = var.aws_region
-  
-  # CloudEmu endpoints for all services
-  endpoints {
-    s3             = "http://localhost:4566"
-    dynamodb       = "http://localhost:4566"
-    sqs            = "http://localhost:4566"
-    sns            = "http://localhost:4566"
-    lambda         = "http://localhost:4566"
-    kms            = "http://localhost:4566"
-    secretsmanager = "http://localhost:4566"
-    cloudwatch     = "http://localhost:4566"
-    events         = "http://localhost:4566"
-    sts            = "http://localhost:4566"
-    iam            = "http://localhost:4566"
-    pricing        = "http://localhost:4566"
-  }
-  
-  # Skip AWS API validation (not needed for CloudEmu)
-  skip_credentials_validation = true
-  skip_metadata_api_check     = true
-  skip_requesting_account_id  = true
-  
-  # Use path-style S3 URLs (required for CloudEmu)
-  s3_use_path_style = true
-  
-  access_key = "test"
-  secret_key = "test"
-}
+# The AWS provider is configured in emulator_provider.tf, generated from
+# common/emulator-provider by go run ./tools/emulatorprovider
 
 # Configure Azure provider for CloudEmu
 provider "azurerm" {
@@ -53,11 +32,11 @@ provider "azurerm" {
   storage_use_azuread        = false
   
   # CloudEmu Azure endpoint (standard Azurite port)
-  metadata_host = "http://localhost:10000" 
+  metadata_host = var.azure_endpoint
   # Note: Terraform Azure provider requires specific endpoint overrides usually,
   # or setting ARM_ENDPOINT env var. But we try explicit config here.
   # Actually, for storage specifically:
-  # blob_endpoint = "http://localhost:10000/devstoreaccount1"
+  # blob_endpoint = "${var.azure_endpoint}/devstoreaccount1"
 }
 
 # Configure Google provider for CloudEmu
@@ -66,9 +45,9 @@ provider "google" {
   region      = var.gcp_region
   
   # CloudEmu GCP endpoint
-  storage_custom_endpoint = "http://localhost:4567"
-  firestore_custom_endpoint = "http://localhost:4567/firestore/"
-  pubsub_custom_endpoint    = "http://localhost:4567/"
+  storage_custom_endpoint = var.gcp_endpoint
+  firestore_custom_endpoint = "${var.gcp_endpoint}/firestore/"
+  pubsub_custom_endpoint    = "${var.gcp_endpoint}/"
   # General endpoint override if supported, else service specific
 }
 
@@ -77,7 +56,7 @@ module "storage" {
   source = "../../facade/storage"
   
   provider_name = "aws"
-  project_name  = "local-test"
+  project_name  = var.project_name
   bucket_name   = var.bucket_name
   environment   = var.environment
   
@@ -91,7 +70,7 @@ module "nosql_table" {
   source = "../../facade/nosql"
   
   provider_name = "aws"
-  project_name  = "local-test"
+  project_name  = var.project_name
   table_name    = var.database_name # Reusing the variable name for simplicity
   environment   = var.environment
   
@@ -106,7 +85,7 @@ module "queue" {
   provider_name = "aws"
   name          = var.queue_name
   type          = "queue"
-  project_name  = "local-test"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -116,7 +95,7 @@ module "topic" {
   provider_name = "aws"
   name          = var.topic_name
   type          = "topic"
-  project_name  = "local-test"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -125,7 +104,7 @@ module "lambda" {
   source = "../../facade/lambda"
   
   provider_name    = "aws"
-  project_name     = "local-test"
+  project_name     = var.project_name
   function_name    = var.function_name
   runtime          = "python3.11"
   handler          = "index.handler"
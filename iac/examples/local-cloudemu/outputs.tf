@@ -16,18 +16,16 @@ output "bucket_endpoint" {
   value       = module.storage.bucket_url
 }
 
-# Database outputs (disabled)
-/*
+# Database outputs
 output "table_name" {
   description = "Name of the created DynamoDB table"
-  value       = module.database.table_name
+  value       = module.nosql_table.table_id
 }
 
 output "table_arn" {
   description = "ARN of the created DynamoDB table"
-  value       = module.database.table_arn
+  value       = module.nosql_table.table_arn
 }
-*/
 
 # Messaging outputs
 output "queue_url" {
@@ -52,18 +50,18 @@ output "function_arn" {
 }
 
 # CloudEmu connection info
-output "cloudemu_endpoint" {
+output "emulator_endpoint" {
   description = "CloudEmu AWS endpoint URL"
-  value       = "http://localhost:4566"
+  value       = var.emulator_endpoint
 }
 
 output "verification_commands" {
   description = "Commands to verify resources in CloudEmu"
   value = {
-    list_buckets   = "aws --endpoint-url=http://localhost:4566 s3 ls"
-    list_tables    = "aws --endpoint-url=http://localhost:4566 dynamodb list-tables"
-    list_queues    = "aws --endpoint-url=http://localhost:4566 sqs list-queues"
-    list_topics    = "aws --endpoint-url=http://localhost:4566 sns list-topics"
-    list_functions = "aws --endpoint-url=http://localhost:4566 lambda list-functions"
+    list_buckets   = "aws --endpoint-url=${var.emulator_endpoint} s3 ls"
+    list_tables    = "aws --endpoint-url=${var.emulator_endpoint} dynamodb list-tables"
+    list_queues    = "aws --endpoint-url=${var.emulator_endpoint} sqs list-queues"
+    list_topics    = "aws --endpoint-url=${var.emulator_endpoint} sns list-topics"
+    list_functions = "aws --endpoint-url=${var.emulator_endpoint} lambda list-functions"
   }
 }
@@ -1,5 +1,23 @@
 # Variables for CloudEmu testing
 
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "azure_endpoint" {
+  description = "CloudEmu Azure endpoint URL"
+  type        = string
+  default     = "http://localhost:10000"
+}
+
+variable "gcp_endpoint" {
+  description = "CloudEmu GCP endpoint URL"
+  type        = string
+  default     = "http://localhost:4567"
+}
+
 variable "aws_region" {
   description = "AWS region (used by CloudEmu for naming)"
   type        = string
@@ -12,10 +30,20 @@ variable "gcp_region" {
   default     = "us-central1"
 }
 
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "local-test"
+}
+
 variable "environment" {
   description = "Environment name (dev, test, local)"
   type        = string
   default     = "local"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "bucket_name" {
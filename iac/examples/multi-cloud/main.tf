@@ -5,8 +5,21 @@ terraform {
   required_version = ">= 1.0"
 }
 
-variable "project_name" { default = "global-app" }
-variable "environment" { default = "prod" }
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "global-app"
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "prod"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
 
 # ============================================================================
 # AWS: Frontend & API Layer
@@ -20,7 +33,7 @@ module "aws_network" {
   environment  = var.environment
   network_name = "aws-vpc"
   
-  metrics = {
+  network_config = {
     cidr            = "10.1.0.0/16"
     azs             = ["us-east-1a"]
     public_subnets  = ["10.1.1.0/24"]
@@ -51,7 +64,7 @@ module "azure_network" {
   environment  = var.environment
   network_name = "azure-vnet"
   
-  metrics = {
+  network_config = {
     cidr            = "10.2.0.0/16"
     azs             = []
     public_subnets  = ["10.2.1.0/24"]
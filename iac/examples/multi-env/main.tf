@@ -0,0 +1,60 @@
+# Multi-Environment Example
+# One composition of the storage, database and messaging facades, layered
+# per environment by a tfvars file:
+#
+#   terraform plan -var-file=dev.tfvars  -var="db_password=..."
+#   terraform plan -var-file=prod.tfvars -var="db_password=..."
+
+terraform {
+  required_version = ">= 1.0"
+}
+
+locals {
+  # Every resource name is derived from the project and environment, so
+  # environments can share an account without colliding
+  name_prefix = "${var.project_name}-${var.environment}"
+}
+
+# ============================================================================
+# STORAGE
+# ============================================================================
+
+module "storage" {
+  source = "../../facade/storage"
+
+  provider_name      = "aws"
+  project_name       = var.project_name
+  environment        = var.environment
+  bucket_name        = "${local.name_prefix}-data"
+  versioning_enabled = true
+}
+
+# ============================================================================
+# DATABASE
+# ============================================================================
+
+module "database" {
+  source = "../../facade/database"
+
+  provider_name   = "aws"
+  project_name    = var.project_name
+  environment     = var.environment
+  identifier      = "${local.name_prefix}-db"
+  instance_class  = var.instance_class
+  multi_az        = var.high_availability
+  master_password = var.db_password
+}
+
+# ============================================================================
+# MESSAGING
+# ============================================================================
+
+module "events" {
+  source = "../../facade/messaging"
+
+  provider_name = "aws"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "${local.name_prefix}-events"
+  type          = "queue"
+}
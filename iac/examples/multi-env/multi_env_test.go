@@ -0,0 +1,91 @@
+package multienv_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/password"
+	"iac/testutil/plandiff"
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const databaseAddress = "module.database.module.aws_database[0].aws_db_instance.this"
+
+// environmentAttributes are the attributes dev and prod may differ in:
+// names derived from the environment, the environment tag, and the sizing
+// and HA settings the tfvars files choose
+var environmentAttributes = []string{
+	"aws_s3_bucket.bucket",
+	"aws_db_instance.identifier",
+	"aws_db_instance.final_snapshot_identifier",
+	"aws_db_instance.instance_class",
+	"aws_db_instance.multi_az",
+	"aws_sqs_queue.name",
+	"*.tags.environment",
+	"*.tags_all.environment",
+}
+
+// planEnvironment plans the example with <env>.tfvars
+func planEnvironment(t *testing.T, env string) *plandiff.Plan {
+	t.Helper()
+
+	dbPassword := password.New(t, "aws")
+	terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		VarFiles:     []string{env + ".tfvars"},
+		Vars: map[string]interface{}{
+			"db_password": dbPassword,
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), env+".plan"),
+		NoColor:      true,
+	}), dbPassword)
+
+	plan, err := plandiff.Parse([]byte(terraform.InitAndPlanAndShow(t, terraformOptions)))
+	require.NoError(t, err)
+	return plan
+}
+
+// databaseSize reads the RDS instance class an abstract size maps to
+func databaseSize(t *testing.T, size string) string {
+	data, err := os.ReadFile("../../common/sizes/sizes.json")
+	require.NoError(t, err)
+
+	var sizes map[string]map[string]map[string]string
+	require.NoError(t, json.Unmarshal(data, &sizes))
+	return sizes["database"]["aws"][size]
+}
+
+// TestEnvironmentsShareTopology plans dev and prod and checks they create
+// the same resources, differing only in names, the environment tag, the
+// database size (small vs medium) and HA (off vs on)
+func TestEnvironmentsShareTopology(t *testing.T) {
+	t.Parallel()
+
+	// Sequential: both plans share the example's .terraform directory
+	dev := planEnvironment(t, "dev")
+	prod := planEnvironment(t, "prod")
+
+	assert.Equal(t, dev.TypeCounts(), prod.TypeCounts(), "dev and prod should plan the same resource types and counts")
+
+	result := plandiff.Compare(dev, prod, environmentAttributes)
+	assert.True(t, result.Clean(), "dev and prod plans differ beyond the environment attributes:\n%s", result.Report())
+
+	class := result.Find(databaseAddress, "instance_class")
+	if assert.NotNil(t, class, "dev and prod should use different instance classes") {
+		assert.Equal(t, databaseSize(t, "small"), class.A, "dev should use the small instance class")
+		assert.Equal(t, databaseSize(t, "medium"), class.B, "prod should use the medium instance class")
+	}
+
+	multiAZ := result.Find(databaseAddress, "multi_az")
+	if assert.NotNil(t, multiAZ, "dev and prod should differ in high availability") {
+		assert.Equal(t, false, multiAZ.A, "dev should run in a single availability zone")
+		assert.Equal(t, true, multiAZ.B, "prod should run across availability zones")
+	}
+}
@@ -0,0 +1,14 @@
+output "bucket_id" {
+  description = "Data bucket ID"
+  value       = module.storage.bucket_id
+}
+
+output "db_endpoint" {
+  description = "Database connection endpoint"
+  value       = module.database.db_endpoint
+}
+
+output "queue_url" {
+  description = "Event queue URL"
+  value       = module.events.queue_url
+}
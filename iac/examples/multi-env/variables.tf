@@ -0,0 +1,37 @@
+# Multi-Environment Example Variables
+# Values that change between environments are set in <environment>.tfvars
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "multienv"
+}
+
+variable "environment" {
+  description = "Environment (dev, staging, or prod)"
+  type        = string
+  validation {
+    condition     = contains(["dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: dev, staging, prod"
+  }
+}
+
+variable "instance_class" {
+  description = "Abstract database size (small, medium, large, xlarge)"
+  type        = string
+  validation {
+    condition     = contains(["small", "medium", "large", "xlarge"], var.instance_class)
+    error_message = "Instance class must be one of: small, medium, large, xlarge."
+  }
+}
+
+variable "high_availability" {
+  description = "Run the database across availability zones"
+  type        = bool
+}
+
+variable "db_password" {
+  description = "Database master password; pass with -var or TF_VAR_db_password, never in a tfvars file"
+  type        = string
+  sensitive   = true
+}
@@ -0,0 +1,80 @@
+# Multi-Region Storage on CloudEmu
+#
+# Instantiates the storage facade once per region by passing each instance
+# an aliased aws provider. The facade declares no provider of its own, so
+# the region comes entirely from the configuration passed in.
+
+terraform {
+  required_version = ">= 1.5.0"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "primary"
+  region = var.primary_region
+
+  endpoints {
+    s3  = var.cloudemu_endpoint
+    sts = var.cloudemu_endpoint
+  }
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+  s3_use_path_style           = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+provider "aws" {
+  alias  = "secondary"
+  region = var.secondary_region
+
+  endpoints {
+    s3  = var.cloudemu_endpoint
+    sts = var.cloudemu_endpoint
+  }
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+  s3_use_path_style           = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+module "primary_storage" {
+  source = "../../facade/storage"
+
+  providers = {
+    aws = aws.primary
+  }
+
+  provider_name      = "aws"
+  project_name       = var.project_name
+  environment        = var.environment
+  bucket_name        = "${var.bucket_prefix}-${var.primary_region}"
+  versioning_enabled = true
+}
+
+module "secondary_storage" {
+  source = "../../facade/storage"
+
+  providers = {
+    aws = aws.secondary
+  }
+
+  provider_name      = "aws"
+  project_name       = var.project_name
+  environment        = var.environment
+  bucket_name        = "${var.bucket_prefix}-${var.secondary_region}"
+  versioning_enabled = true
+}
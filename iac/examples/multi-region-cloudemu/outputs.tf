@@ -0,0 +1,19 @@
+output "primary_bucket_name" {
+  description = "Name of the bucket in the primary region"
+  value       = module.primary_storage.bucket.name
+}
+
+output "primary_bucket_region" {
+  description = "Region the primary bucket was created in"
+  value       = module.primary_storage.bucket.region
+}
+
+output "secondary_bucket_name" {
+  description = "Name of the bucket in the secondary region"
+  value       = module.secondary_storage.bucket.name
+}
+
+output "secondary_bucket_region" {
+  description = "Region the secondary bucket was created in"
+  value       = module.secondary_storage.bucket.region
+}
@@ -0,0 +1,38 @@
+variable "cloudemu_endpoint" {
+  description = "CloudEmu AWS endpoint URL"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "primary_region" {
+  description = "Region of the primary bucket"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "secondary_region" {
+  description = "Region of the secondary bucket"
+  type        = string
+  default     = "eu-west-1"
+}
+
+variable "bucket_prefix" {
+  description = "Bucket name prefix; each bucket appends its region"
+  type        = string
+}
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "multi-region"
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "local"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
@@ -5,8 +5,21 @@ terraform {
   required_version = ">= 1.0"
 }
 
-variable "project_name" { default = "dr-capable-app" }
-variable "environment" { default = "prod" }
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "dr-capable-app"
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "prod"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
 
 # ============================================================================
 # PRIMARY REGION: US-EAST-1
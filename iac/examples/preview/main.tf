@@ -0,0 +1,73 @@
+# Preview Environment Example
+# The full platform service stack, named after a branch so several
+# previews share one account or emulator. tools/preview applies it in a
+# Terraform workspace per preview with a generated tfvars file:
+#
+#   go run ./tools/preview up -name my-branch
+#   go run ./tools/preview down -name my-branch
+
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+locals {
+  emulated = var.emulator_endpoint != null
+}
+
+# AWS provider for a real account, or pointed at an emulator when
+# emulator_endpoint is set. With an emulator every service the platform
+# service module reaches goes to it, sts included, as in
+# common/emulator-provider.
+provider "aws" {
+  region = var.aws_region
+
+  dynamic "endpoints" {
+    for_each = local.emulated ? [var.emulator_endpoint] : []
+    content {
+      cloudwatch = endpoints.value
+      dynamodb   = endpoints.value
+      ec2        = endpoints.value
+      iam        = endpoints.value
+      lambda     = endpoints.value
+      logs       = endpoints.value
+      s3         = endpoints.value
+      sns        = endpoints.value
+      sqs        = endpoints.value
+      sts        = endpoints.value
+    }
+  }
+
+  skip_credentials_validation = local.emulated
+  skip_metadata_api_check     = local.emulated
+  skip_region_validation      = local.emulated
+  skip_requesting_account_id  = local.emulated
+  s3_use_path_style           = local.emulated
+
+  access_key = local.emulated ? "test" : null
+  secret_key = local.emulated ? "test" : null
+}
+
+module "service" {
+  source = "../../platform/service"
+
+  service_name = "${var.project_name}-${var.preview}"
+  environment  = var.environment
+  provider     = "aws"
+  size         = "small"
+  features     = var.features
+
+  provider_config = {
+    availability_zones = ["${var.aws_region}a", "${var.aws_region}b"]
+  }
+
+  tags = {
+    Preview = var.preview
+  }
+}
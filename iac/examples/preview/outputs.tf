@@ -0,0 +1,19 @@
+output "function_name" {
+  description = "The preview's function"
+  value       = module.service.function_name
+}
+
+output "queue_url" {
+  description = "Queue that triggers the function"
+  value       = module.service.queue_url
+}
+
+output "table_name" {
+  description = "The preview's table"
+  value       = module.service.table_name
+}
+
+output "bucket_name" {
+  description = "The preview's bucket"
+  value       = module.service.bucket_name
+}
@@ -0,0 +1,50 @@
+# Preview Environment Example Variables
+
+variable "project_name" {
+  description = "Service name the preview slug is appended to"
+  type        = string
+  default     = "preview"
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, or prod); local against an emulator"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+variable "preview" {
+  description = "Slug naming the preview, derived from the branch by tools/preview"
+  type        = string
+  validation {
+    condition     = can(regex("^[a-z0-9]([a-z0-9-]{0,14}[a-z0-9])?$", var.preview))
+    error_message = "preview must be 1-16 lower case letters, digits and hyphens, not starting or ending with a hyphen, e.g. feature-login"
+  }
+}
+
+variable "features" {
+  description = "Parts of the platform service stack to create; all of them by default"
+  type        = map(bool)
+  default = {
+    network  = true
+    database = true
+    queue    = true
+    bucket   = true
+    alarms   = true
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "Emulator AWS endpoint URL (e.g. CloudEmu's http://localhost:4566); the real account the credentials select when null"
+  type        = string
+  default     = null
+}
+
+variable "aws_region" {
+  description = "AWS region"
+  type        = string
+  default     = "us-east-1"
+}
@@ -0,0 +1,28 @@
+# Remote State Example
+# Bootstraps the bucket (and, on AWS, the lock table) that other
+# configurations keep their state in, and writes backend.hcl beside this
+# module for them to initialize with:
+#
+#   terraform apply -var="provider_name=aws"
+#   cd ../web-app && terraform init -backend-config=../remote-state/backend.hcl
+#
+# This configuration keeps its own state locally; it is the one that
+# creates the backend.
+
+terraform {
+  required_version = ">= 1.9"
+}
+
+module "state" {
+  source = "../../facade/statebackend"
+
+  provider_name           = var.provider_name
+  project_name            = var.project_name
+  environment             = var.environment
+  bucket_name             = coalesce(var.bucket_name, "${var.project_name}-${var.environment}-tfstate")
+  state_key               = var.state_key
+  noncurrent_version_days = var.noncurrent_version_days
+  provider_config         = var.provider_config
+
+  write_backend_config = true
+}
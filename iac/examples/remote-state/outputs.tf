@@ -0,0 +1,14 @@
+output "backend_config" {
+  description = "terraform block to paste into a configuration that keeps its state in the backend"
+  value       = module.state.backend_config
+}
+
+output "backend_config_file" {
+  description = "backend.hcl to pass to terraform init -backend-config"
+  value       = module.state.backend_config_file
+}
+
+output "bucket_name" {
+  description = "State bucket (the storage account on Azure)"
+  value       = module.state.bucket_name
+}
@@ -0,0 +1,51 @@
+# Remote State Example Variables
+
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp)"
+  type        = string
+  default     = "aws"
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
+}
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "acme"
+}
+
+variable "environment" {
+  description = "Environment (dev, staging, or prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: dev, staging, prod"
+  }
+}
+
+variable "bucket_name" {
+  description = "State bucket name; <project_name>-<environment>-tfstate when null. Bucket names are global, so pick one nobody else has."
+  type        = string
+  default     = null
+}
+
+variable "state_key" {
+  description = "Key the consuming configuration stores its state under"
+  type        = string
+  default     = "terraform.tfstate"
+}
+
+variable "noncurrent_version_days" {
+  description = "Days a replaced state version is kept, to roll back a bad apply"
+  type        = number
+  default     = 90
+}
+
+variable "provider_config" {
+  description = "Provider-specific settings passed to the facade (resource_group_name, location for azure; project_id for gcp)"
+  type        = any
+  default     = {}
+}
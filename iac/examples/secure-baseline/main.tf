@@ -0,0 +1,86 @@
+# Secure Baseline Example
+# The account-level pieces most projects start with: a customer-managed key
+# from the encryption facade, an application secret from the secrets facade,
+# a data bucket encrypted with the key and protected by a backup facade
+# plan, and a budget facade budget that emails as spend approaches the
+# limit.
+#
+#   terraform plan
+#   terraform plan -var='alert_emails=["finops@example.com"]' -var="monthly_limit_usd=2000"
+
+terraform {
+  required_version = ">= 1.9"
+}
+
+locals {
+  provider_name = "aws"
+  name_prefix   = "${var.project_name}-${var.environment}"
+}
+
+# ============================================================================
+# KEY AND SECRET
+# ============================================================================
+
+module "key" {
+  source = "../../facade/encryption"
+
+  provider_name = local.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "${local.name_prefix}-data"
+  description   = "Encrypts ${local.name_prefix} data at rest"
+}
+
+module "app_secret" {
+  source = "../../facade/secrets"
+
+  provider_name = local.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "${local.name_prefix}-app"
+  description   = "Application credentials, set outside Terraform"
+}
+
+# ============================================================================
+# DATA BUCKET AND BACKUP
+# ============================================================================
+
+module "data" {
+  source = "../../facade/storage"
+
+  provider_name      = local.provider_name
+  project_name       = var.project_name
+  environment        = var.environment
+  bucket_name        = "${local.name_prefix}-data"
+  versioning_enabled = true
+  kms_key_ref        = module.key.kms_key_ref
+}
+
+module "backup" {
+  source = "../../facade/backup"
+
+  provider_name  = local.provider_name
+  project_name   = var.project_name
+  environment    = var.environment
+  plan_name      = "${local.name_prefix}-daily"
+  retention_days = var.backup_retention_days
+  resources      = [module.data.backup_ref]
+}
+
+# ============================================================================
+# BUDGET
+# ============================================================================
+
+module "budget" {
+  source = "../../facade/budget"
+
+  provider_name     = local.provider_name
+  project_name      = var.project_name
+  environment       = var.environment
+  budget_name       = "${local.name_prefix}-monthly"
+  monthly_limit_usd = var.monthly_limit_usd
+
+  notification_ref = {
+    emails = var.alert_emails
+  }
+}
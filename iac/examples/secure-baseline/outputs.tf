@@ -0,0 +1,24 @@
+output "kms_key_ref" {
+  description = "Key reference for other facades' kms_key_ref inputs"
+  value       = module.key.kms_key_ref
+}
+
+output "app_secret_arn" {
+  description = "Secret to store the application credentials in"
+  value       = module.app_secret.secret_arn
+}
+
+output "data_bucket_id" {
+  description = "Encrypted, backed-up data bucket"
+  value       = module.data.bucket_id
+}
+
+output "backup_plan_id" {
+  description = "Backup plan protecting the data bucket"
+  value       = module.backup.plan_id
+}
+
+output "budget_id" {
+  description = "Monthly budget"
+  value       = module.budget.budget_id
+}
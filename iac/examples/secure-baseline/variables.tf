@@ -0,0 +1,35 @@
+# Secure Baseline Example Variables
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "baseline"
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+variable "backup_retention_days" {
+  description = "Days each daily backup of the data bucket is kept"
+  type        = number
+  default     = 35
+}
+
+variable "monthly_limit_usd" {
+  description = "Monthly spend limit the budget notifies against"
+  type        = number
+  default     = 500
+}
+
+variable "alert_emails" {
+  description = "Addresses notified at 50%, 80% and 100% of the monthly limit"
+  type        = list(string)
+  default     = ["ops@example.com"]
+}
@@ -0,0 +1,54 @@
+# Static Site Example
+# A storage facade bucket in website mode served through the cdn facade,
+# optionally on a custom domain:
+#
+#   terraform plan -var="provider_name=aws"
+#   terraform plan -var="provider_name=aws" \
+#     -var='domain_aliases=["www.example.com"]' \
+#     -var='certificate_ref={provider="aws",id="arn:aws:acm:us-east-1:...:certificate/..."}'
+
+terraform {
+  required_version = ">= 1.9"
+}
+
+locals {
+  name_prefix = "${var.project_name}-${var.environment}"
+}
+
+# ============================================================================
+# SITE BUCKET
+# ============================================================================
+
+module "site" {
+  source = "../../facade/storage"
+
+  provider_name      = var.provider_name
+  project_name       = var.project_name
+  environment        = var.environment
+  bucket_name        = "${local.name_prefix}-site"
+  versioning_enabled = true
+  provider_config    = var.provider_config
+
+  website = {
+    index_document = "index.html"
+    error_document = "404.html"
+  }
+}
+
+# ============================================================================
+# CDN
+# ============================================================================
+
+module "cdn" {
+  source = "../../facade/cdn"
+
+  provider_name   = var.provider_name
+  project_name    = var.project_name
+  environment     = var.environment
+  name            = "${local.name_prefix}-site"
+  origin_ref      = module.site.origin_ref
+  domain_aliases  = var.domain_aliases
+  certificate_ref = var.certificate_ref
+  default_ttl     = var.default_ttl
+  provider_config = var.provider_config
+}
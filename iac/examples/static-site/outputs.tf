@@ -0,0 +1,14 @@
+output "site_bucket" {
+  description = "Bucket to upload the site to (the $web container of this storage account on Azure)"
+  value       = module.site.origin_ref.bucket_name
+}
+
+output "cdn_domain_name" {
+  description = "Where to point the DNS records of domain_aliases"
+  value       = module.cdn.cdn_domain_name
+}
+
+output "cdn_id" {
+  description = "CDN identifier, e.g. for cache invalidation"
+  value       = module.cdn.cdn_id
+}
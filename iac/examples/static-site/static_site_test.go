@@ -0,0 +1,42 @@
+package staticsite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const siteBucket = "staticsite-dev-site"
+
+func TestStaticSiteAws(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars:         map[string]interface{}{"provider_name": "aws"},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	require.Contains(t, plan.ResourcePlannedValuesMap, "module.site.module.aws_storage[0].aws_s3_bucket.this")
+
+	distribution, ok := plan.ResourcePlannedValuesMap["module.cdn.module.aws_cdn[0].aws_cloudfront_distribution.this"]
+	require.True(t, ok, "Plan should create a CloudFront distribution")
+	origin := distribution.AttributeValues["origin"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, siteBucket, origin["origin_id"], "The distribution's origin should be the site bucket")
+
+	bucketPolicy, ok := plan.ResourcePlannedValuesMap["module.cdn.module.aws_cdn[0].aws_s3_bucket_policy.cdn"]
+	require.True(t, ok, "Plan should grant CloudFront read access to the bucket")
+	assert.Equal(t, siteBucket, bucketPolicy.AttributeValues["bucket"])
+
+	// The regional domain name is unknown until the bucket exists, so check
+	// that it is wired from the storage facade
+	call := plan.RawPlan.Config.RootModule.ModuleCalls["cdn"]
+	require.NotNil(t, call)
+	assert.Contains(t, call.Expressions["origin_ref"].References, "module.site.origin_ref")
+}
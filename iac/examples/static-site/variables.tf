@@ -0,0 +1,54 @@
+# Static Site Example Variables
+
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp)"
+  type        = string
+  default     = "aws"
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
+}
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "staticsite"
+}
+
+variable "environment" {
+  description = "Environment (dev, staging, or prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: dev, staging, prod"
+  }
+}
+
+variable "domain_aliases" {
+  description = "Custom domain names for the site; needs certificate_ref"
+  type        = list(string)
+  default     = []
+}
+
+variable "certificate_ref" {
+  description = "Certificate covering domain_aliases ({provider, id})"
+  type = object({
+    provider = string
+    id       = string
+  })
+  default = null
+}
+
+variable "default_ttl" {
+  description = "Seconds pages stay cached when uploads set no Cache-Control"
+  type        = number
+  default     = 300
+}
+
+variable "provider_config" {
+  description = "Provider-specific settings passed to both facades (resource_group_name for azure, project_id for gcp)"
+  type        = any
+  default     = {}
+}
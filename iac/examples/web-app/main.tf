@@ -14,6 +14,10 @@ variable "environment" {
   description = "Environment name"
   type        = string
   default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
@@ -81,7 +85,7 @@ module "aws_storage" {
   storage_class       = "standard"
   versioning_enabled  = var.environment == "prod"
   encryption_enabled  = true
-  public_access_block = true
+  allow_public_access = false
 
   # Lifecycle for cost optimization
   lifecycle_rules = [{
@@ -8,32 +8,16 @@ terraform {
   }
 }
 
-# ZeroCloud Provider Configuration (SPI)
-# Redirects AWS protocol requests to local ZeroCloud endpoints
-provider "aws" {
-  region                      = "us-east-1"
-  skip_credentials_validation = true
-  skip_requesting_account_id  = true
-  skip_metadata_api_check     = true
-  s3_use_path_style           = true
-  
-  endpoints {
-    ec2        = "http://localhost:8080"
-    s3         = "http://localhost:8080"
-    dynamodb   = "http://localhost:8080"
-    lambda     = "http://localhost:8080"
-    sqs        = "http://localhost:8080"
-    iam        = "http://localhost:8080"
-    sts        = "http://localhost:8080"
-  }
-}
+# The AWS provider is configured in emulator_provider.tf, generated from
+# common/emulator-provider by go run ./tools/emulatorprovider; it sends
+# every AWS protocol request to var.emulator_endpoint, ZeroCloud here
 
 # 1. Storage Resource (ZeroStore)
 module "storage" {
   source        = "../../facade/storage"
   provider_name = "zero"
   bucket_name   = var.bucket_name
-  project_name  = "zero-test-project"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -43,7 +27,7 @@ module "nosql" {
   provider_name = "zero"
   table_name    = var.table_name
   hash_key      = "id"
-  project_name  = "zero-test-project"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -53,14 +37,14 @@ module "networking" {
   provider_name = "zero"
   network_name  = "zero-vpc"
   
-  metrics = {
+  network_config = {
     cidr            = "10.0.0.0/16"
     azs             = ["us-east-1a", "us-east-1b"]
     public_subnets  = ["10.0.1.0/24", "10.0.2.0/24"]
     private_subnets = ["10.0.3.0/24", "10.0.4.0/24"]
   }
   
-  project_name  = "zero-test-project"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -73,7 +57,7 @@ module "iam" {
   principals    = ["lambda.amazonaws.com"] # ZeroFunc uses AWS style principals
   roles         = ["storage_read", "nosql_write"]
   
-  project_name  = "zero-test-project"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -88,7 +72,7 @@ module "lambda" {
   # Basic inline code for testing
   # source_code   = "exports.handler = async (event) => { return 'Hello from ZeroFunc'; };"
   
-  project_name  = "zero-test-project"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
@@ -99,24 +83,49 @@ module "queue" {
   type          = "queue"
   name          = "zero-test-queue"
   
-  project_name  = "zero-test-project"
+  project_name  = var.project_name
   environment   = var.environment
 }
 
 # Variables
+variable "emulator_endpoint" {
+  description = "ZeroCloud endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:8080"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
 variable "bucket_name" {
-  type    = string
-  default = "test-zero-bucket"
+  description = "Storage bucket name"
+  type        = string
+  default     = "test-zero-bucket"
 }
 
 variable "table_name" {
-  type    = string
-  default = "test-zero-table"
+  description = "NoSQL table name"
+  type        = string
+  default     = "test-zero-table"
+}
+
+variable "project_name" {
+  description = "Project name used in resource names and tags"
+  type        = string
+  default     = "zero-test-project"
 }
 
 variable "environment" {
-  type    = string
-  default = "test"
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "local"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 # Outputs
@@ -1,18 +1,38 @@
 terraform {
   required_providers {
     null = {
-      source = "hashicorp/null"
-      version = "3.2.1"
+      source  = "hashicorp/null"
+      version = "~> 3.2"
     }
   }
 }
 
+variable "project_name" {
+  description = "Project name, used to name the function"
+  type        = string
+  default     = "zero-native"
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, or prod)"
+  type        = string
+  default     = "local"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
 variable "bucket_name" {
-  default = "my-zero-bucket"
+  description = "ZeroStore bucket name"
+  type        = string
+  default     = "my-zero-bucket"
 }
 
 variable "table_name" {
-  default = "my-zero-table"
+  description = "ZeroDB table name"
+  type        = string
+  default     = "my-zero-table"
 }
 
 # 1. Provision a ZeroStore Bucket
@@ -45,8 +65,12 @@ resource "null_resource" "zero_table" {
 
 # 3. Deploy a ZeroFunction
 resource "null_resource" "zero_func" {
+  triggers = {
+    function = "${var.project_name}-${var.environment}-hello"
+  }
+
   provisioner "local-exec" {
-    command = "zero func deploy --name hello-tf --code 'console.log(\"Hello from Terraform!\")' --handler index.handler"
+    command = "zero func deploy --name ${self.triggers.function} --code 'console.log(\"Hello from Terraform!\")' --handler index.handler"
   }
 }
 
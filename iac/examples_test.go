@@ -0,0 +1,42 @@
+package test
+
+import (
+	"testing"
+
+	"iac/testutil/examplecheck"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExamples checks that every facade has an example under examples/
+// calling it, that examples only call facades that exist, declare the
+// standard variables and have an output, and that each example validates
+func TestExamples(t *testing.T) {
+	t.Parallel()
+
+	inv, err := examplecheck.Inspect(".")
+	require.NoError(t, err)
+	require.NotEmpty(t, inv.Examples)
+
+	if problems := examplecheck.Check(inv, examplecheck.StandardVariables); len(problems) > 0 {
+		t.Errorf("%d example problems (facades without an example, calls to missing facades, missing standard variables or outputs):\n%s",
+			len(problems), examplecheck.Report(problems))
+	}
+
+	for _, ex := range inv.Examples {
+		ex := ex
+
+		t.Run(ex.Dir, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := terraform.InitAndValidateE(t, tfopts.New(t, &terraform.Options{
+				TerraformDir:  ex.Dir,
+				BackendConfig: map[string]interface{}{},
+			}))
+			assert.NoError(t, err, "Example %s failed validation", ex.Dir)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package backup_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/password"
+	"iac/testutil/planerr"
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupFacadeAws(t *testing.T) {
+	t.Parallel()
+
+	resources := []string{
+		"arn:aws:rds:us-east-1:123456789012:db:orders-db",
+		"arn:aws:dynamodb:us-east-1:123456789012:table/orders",
+		"arn:aws:s3:::orders-exports",
+	}
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":  "aws",
+			"project_name":   "testproject",
+			"environment":    "dev",
+			"plan_name":      "orders-weekly",
+			"schedule":       "30 2 * * 0",
+			"retention_days": 90,
+			"resources": []map[string]interface{}{
+				{"provider": "aws", "type": "rds", "id": resources[0]},
+				{"provider": "aws", "type": "dynamodb", "id": resources[1]},
+				{"provider": "aws", "type": "s3", "id": resources[2]},
+			},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	selection, ok := plan.ResourcePlannedValuesMap["module.aws_backup[0].aws_backup_selection.this[0]"]
+	require.True(t, ok, "Plan should create a backup selection")
+	assert.ElementsMatch(t, resources, selection.AttributeValues["resources"], "The selection should cover exactly the passed resources")
+
+	backupPlan, ok := plan.ResourcePlannedValuesMap["module.aws_backup[0].aws_backup_plan.this"]
+	require.True(t, ok, "Plan should create a backup plan")
+	rule := backupPlan.AttributeValues["rule"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "cron(30 2 ? * SUN *)", rule["schedule"])
+	assert.Equal(t, "orders-weekly-vault", rule["target_vault_name"])
+	lifecycle := rule["lifecycle"].([]interface{})[0].(map[string]interface{})
+	assert.EqualValues(t, 90, lifecycle["delete_after"])
+
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "module.aws_backup[0].aws_iam_role.backup")
+	assert.Contains(t, plan.ResourcePlannedValuesMap, `module.aws_backup[0].aws_iam_role_policy_attachment.backup["AWSBackupServiceRolePolicyForS3Backup"]`)
+}
+
+func TestBackupFacadeAzure(t *testing.T) {
+	t.Parallel()
+
+	const storageAccount = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/test-rg/providers/Microsoft.Storage/storageAccounts/ordersexports"
+
+	planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":  "azure",
+			"project_name":   "testproject",
+			"environment":    "dev",
+			"plan_name":      "orders-daily",
+			"retention_days": 30,
+			"resources": []map[string]interface{}{
+				{"provider": "azure", "type": "blob", "id": storageAccount},
+			},
+			"provider_config": map[string]interface{}{"resource_group_name": "test-rg", "location": "eastus"},
+		},
+		NoColor: true,
+	}))
+
+	assert.Contains(t, planString, "module.azure_backup[0].azurerm_data_protection_backup_vault.this")
+	assert.Contains(t, planString, "module.azure_backup[0].azurerm_data_protection_backup_policy_blob_storage.this")
+	assert.Regexp(t, `retention_duration\s+= "P30D"`, planString)
+
+	// One backup instance and role assignment per storage account, and no more
+	assert.Contains(t, planString, "module.azure_backup[0].azurerm_data_protection_backup_instance_blob_storage.this[0]")
+	assert.NotContains(t, planString, "azurerm_data_protection_backup_instance_blob_storage.this[1]")
+	assert.Regexp(t, `storage_account_id\s+= "`+storageAccount+`"`, planString)
+	assert.Regexp(t, `role_definition_name\s+= "Storage Account Backup Contributor"`, planString)
+}
+
+func TestBackupFacadeGcp(t *testing.T) {
+	t.Parallel()
+
+	planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":  "gcp",
+			"project_name":   "testproject",
+			"environment":    "dev",
+			"plan_name":      "orders-weekly",
+			"schedule":       "15 4 * * 3",
+			"retention_days": 60,
+			"resources": []map[string]interface{}{
+				{"provider": "gcp", "type": "gcs", "id": "orders-exports"},
+			},
+			"provider_config": map[string]interface{}{"project_id": "test-project"},
+		},
+		NoColor: true,
+	}))
+
+	assert.Contains(t, planString, "module.gcp_backup[0].google_storage_bucket.backup[0]")
+	assert.Contains(t, planString, "module.gcp_backup[0].google_storage_transfer_job.this[0]")
+	assert.NotContains(t, planString, "google_storage_transfer_job.this[1]")
+	assert.Regexp(t, `bucket_name\s+= "orders-exports"`, planString)
+	assert.Regexp(t, `name\s+= "orders-exports-backup"`, planString)
+	assert.Regexp(t, `days_since_noncurrent_time\s+= 60`, planString)
+
+	// Weekly on Wednesday: the first run is 2024-01-10 and repeats every 7 days
+	assert.Regexp(t, `day\s+= 10`, planString)
+	assert.Regexp(t, `hours\s+= 4`, planString)
+	assert.Regexp(t, `minutes\s+= 15`, planString)
+	assert.Regexp(t, `repeat_interval\s+= "604800s"`, planString)
+}
+
+func TestBackupFacadeComposition(t *testing.T) {
+	t.Parallel()
+
+	masterPassword := password.New(t, "aws")
+	terraformOptions := tflog.WithSensitive(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: "testdata/composition",
+		Vars:         map[string]interface{}{"master_password": masterPassword},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}), masterPassword)
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, terraformOptions)
+
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "module.db.module.aws_database[0].aws_db_instance.this", "Plan should create the database")
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "module.orders.module.aws_nosql[0].aws_dynamodb_table.this", "Plan should create the table")
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "module.backup.module.aws_backup[0].aws_backup_selection.this[0]",
+		"The backup_ref types should pass the facade's preconditions and produce a selection")
+
+	// The ARNs are unknown until apply, so check the wiring in the configuration
+	call := plan.RawPlan.Config.RootModule.ModuleCalls["backup"]
+	require.NotNil(t, call)
+	assert.Subset(t, call.Expressions["resources"].References, []string{"module.db.backup_ref", "module.orders.backup_ref"})
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"plan_name":     "orders-daily",
+	}
+
+	rds := map[string]interface{}{"provider": "aws", "type": "rds", "id": "arn:aws:rds:us-east-1:123456789012:db:orders-db"}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "RetentionBelowSevenDays",
+			Vars: map[string]interface{}{"retention_days": 6},
+			Want: "retention_days must be a whole number of at least 7 days",
+		},
+		{
+			Name: "FractionalRetention",
+			Vars: map[string]interface{}{"retention_days": 7.5},
+			Want: "retention_days must be a whole number of at least 7 days",
+		},
+		{
+			Name: "AzureRetentionAboveLimit",
+			Vars: map[string]interface{}{"provider_name": "azure", "retention_days": 365},
+			Want: "Azure operational blob backup keeps at most 360 days",
+		},
+		{
+			Name: "HourlySchedule",
+			Vars: map[string]interface{}{"schedule": "0 * * * *"},
+			Want: "Schedule must be a daily",
+		},
+		{
+			Name: "MonthlySchedule",
+			Vars: map[string]interface{}{"schedule": "0 3 1 * *"},
+			Want: "Schedule must be a daily",
+		},
+		{
+			Name: "BadPlanName",
+			Vars: map[string]interface{}{"plan_name": "Orders_Daily"},
+			Want: "Plan name must be 3-44 lower case letters",
+		},
+		{
+			Name: "ResourceFromOtherProvider",
+			Vars: map[string]interface{}{"resources": []map[string]interface{}{
+				rds,
+				{"provider": "gcp", "type": "gcs", "id": "orders-exports"},
+			}},
+			Want: "Every resource must belong to aws",
+		},
+		{
+			Name: "CloudSqlOnGcp",
+			Vars: map[string]interface{}{
+				"provider_name": "gcp",
+				"resources": []map[string]interface{}{
+					{"provider": "gcp", "type": "cloud_sql", "id": "orders-db"},
+				},
+			},
+			Want: "Resource types cloud_sql cannot be backed up on gcp",
+		},
+		{
+			Name: "CosmosOnAzure",
+			Vars: map[string]interface{}{
+				"provider_name": "azure",
+				"resources": []map[string]interface{}{
+					{"provider": "azure", "type": "cosmos", "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/test-rg/providers/Microsoft.DocumentDB/databaseAccounts/orders"},
+				},
+			},
+			Want: "Resource types cosmos cannot be backed up on azure",
+		},
+		{
+			Name: "UnknownProvider",
+			Vars: map[string]interface{}{"provider_name": "zero"},
+			Want: "provider_name must be one of: aws, azure, gcp",
+		},
+	})
+}
@@ -0,0 +1,145 @@
+# Backup Facade
+# Unified interface for scheduled backups and retention of databases and storage
+
+terraform {
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
+}
+
+# ============================================================================
+# IMPORT COMMON LAYER
+# ============================================================================
+
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
+    {
+      Provider = var.provider_name
+      Module   = "Backup-Facade"
+    },
+    var.tags
+  )
+}
+
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  # Resource types each provider's backup service can protect
+  supported_types = {
+    aws   = ["rds", "dynamodb", "s3"]
+    azure = ["blob"]
+    gcp   = ["gcs"]
+  }
+  unsupported = [for r in var.resources : r.type if !contains(local.supported_types[var.provider_name], r.type)]
+
+  # "M H * * D" in UTC; AWS wants its own six-field cron with a ? in the
+  # unused day field, GCP a start date on the right weekday (2024-01-07 was
+  # a Sunday) and a repeat interval
+  cron     = split(" ", var.schedule)
+  minute   = tonumber(local.cron[0])
+  hour     = tonumber(local.cron[1])
+  weekly   = local.cron[4] != "*"
+  weekdays = ["SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"]
+
+  aws_schedule = (
+    local.weekly
+    ? "cron(${local.minute} ${local.hour} ? * ${local.weekdays[tonumber(local.cron[4])]} *)"
+    : "cron(${local.minute} ${local.hour} * * ? *)"
+  )
+  gcp_start_date = {
+    year  = 2024
+    month = 1
+    day   = local.weekly ? 7 + tonumber(local.cron[4]) : 1
+  }
+}
+
+# ============================================================================
+# PROVIDER-SPECIFIC MODULE ROUTING
+# ============================================================================
+
+# AWS: Backup vault, plan, IAM role and a selection of the resource ARNs
+module "aws_backup" {
+  count  = var.provider_name == "aws" ? 1 : 0
+  source = "../../aws/core/backup"
+
+  vault_name          = "${var.plan_name}-vault"
+  plan_name           = var.plan_name
+  schedule_expression = local.aws_schedule
+  retention_days      = var.retention_days
+  resource_arns       = [for r in var.resources : r.id]
+
+  tags = local.default_tags
+}
+
+# Azure: Data Protection backup vault, blob policy and one backup instance
+# per storage account
+module "azure_backup" {
+  count  = var.provider_name == "azure" ? 1 : 0
+  source = "../../azure/core/backup"
+
+  vault_name          = "${var.plan_name}-vault"
+  policy_name         = var.plan_name
+  resource_group_name = lookup(var.provider_config, "resource_group_name", module.resource_group_name.name)
+  location            = lookup(var.provider_config, "location", "East US")
+  redundancy          = lookup(var.provider_config, "redundancy", "LocallyRedundant")
+  retention_days      = var.retention_days
+  storage_account_ids = [for r in var.resources : r.id if r.type == "blob"]
+
+  tags = local.default_tags
+}
+
+# GCP: Storage Transfer Service jobs copying each bucket into a versioned
+# backup bucket
+module "gcp_backup" {
+  count  = var.provider_name == "gcp" ? 1 : 0
+  source = "../../gcp/core/backup"
+
+  project_id      = lookup(var.provider_config, "project_id", var.project_name)
+  job_name        = var.plan_name
+  location        = lookup(var.provider_config, "location", "US")
+  bucket_names    = [for r in var.resources : r.id if r.type == "gcs"]
+  retention_days  = var.retention_days
+  start_date      = local.gcp_start_date
+  start_time      = { hours = local.hour, minutes = local.minute }
+  repeat_interval = local.weekly ? "604800s" : "86400s"
+
+  labels = local.default_labels
+}
+
+# ============================================================================
+# AGGREGATED OUTPUTS
+# ============================================================================
+
+locals {
+  vault_id = (
+    var.provider_name == "aws"   ? (length(module.aws_backup) > 0 ? module.aws_backup[0].vault_arn : null) :
+    var.provider_name == "azure" ? (length(module.azure_backup) > 0 ? module.azure_backup[0].vault_id : null) :
+    null
+  )
+
+  plan_id = (
+    var.provider_name == "aws"   ? (length(module.aws_backup) > 0 ? module.aws_backup[0].plan_id : null) :
+    var.provider_name == "azure" ? (length(module.azure_backup) > 0 ? module.azure_backup[0].policy_id : null) :
+    null
+  )
+
+  protection_ids = (
+    var.provider_name == "azure" ? (length(module.azure_backup) > 0 ? module.azure_backup[0].backup_instance_ids : []) :
+    var.provider_name == "gcp"   ? (length(module.gcp_backup) > 0 ? module.gcp_backup[0].transfer_job_names : []) :
+    []
+  )
+}
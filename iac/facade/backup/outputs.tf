@@ -0,0 +1,39 @@
+output "plan_id" {
+  description = "Backup plan identifier (AWS Backup plan ID / Data Protection backup policy ID / null on GCP, where each bucket has its own transfer job)"
+  value       = local.plan_id
+
+  precondition {
+    condition     = alltrue([for r in var.resources : r.provider == var.provider_name])
+    error_message = "Every resource must belong to ${var.provider_name}; backup_refs from other providers cannot be protected by this plan"
+  }
+
+  precondition {
+    condition     = length(local.unsupported) == 0
+    error_message = "Resource types ${join(", ", distinct(local.unsupported))} cannot be backed up on ${var.provider_name}, which supports ${join(", ", local.supported_types[var.provider_name])}. Azure SQL and Cosmos DB keep their own point-in-time backups, Cloud SQL uses the database facade's backup_retention_days, and Firestore is not supported."
+  }
+}
+
+output "vault_id" {
+  description = "Where recovery points are stored (AWS Backup vault ARN / Data Protection backup vault ID / null on GCP)"
+  value       = local.vault_id
+}
+
+output "protected_ids" {
+  description = "Identifiers of the resources in the plan, in input order"
+  value       = [for r in var.resources : r.id]
+}
+
+output "protection_ids" {
+  description = "Per-resource protection on Azure and GCP, in input order (backup instance IDs / transfer job names); empty on AWS, where one selection covers every resource"
+  value       = local.protection_ids
+}
+
+output "backup_bucket_names" {
+  description = "GCP backup bucket of each protected bucket, in input order; empty elsewhere"
+  value       = length(module.gcp_backup) > 0 ? module.gcp_backup[0].backup_bucket_names : []
+}
+
+output "provider" {
+  description = "Cloud provider"
+  value       = var.provider_name
+}
@@ -0,0 +1,65 @@
+# Backup composition fixture
+#
+# A SQL database and a NoSQL table from the database facade, both protected
+# by one plan from the backup facade through their backup_ref outputs.
+# Planned only, with placeholder credentials.
+
+terraform {
+  required_version = ">= 1.9"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+variable "master_password" {
+  description = "Master password for the database"
+  type        = string
+  sensitive   = true
+}
+
+module "db" {
+  source = "../../../database"
+
+  provider_name   = "aws"
+  project_name    = "composition"
+  environment     = "dev"
+  identifier      = "composition-db"
+  master_password = var.master_password
+}
+
+module "orders" {
+  source = "../../../database"
+
+  provider_name = "aws"
+  project_name  = "composition"
+  environment   = "dev"
+  engine_type   = "nosql"
+  identifier    = "composition-orders"
+  hash_key      = "order_id"
+}
+
+module "backup" {
+  source = "../../"
+
+  provider_name  = "aws"
+  project_name   = "composition"
+  environment    = "dev"
+  plan_name      = "composition-daily"
+  retention_days = 14
+  resources      = [module.db.backup_ref, module.orders.backup_ref]
+}
@@ -0,0 +1,93 @@
+# General Configuration
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp)"
+  type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
+}
+
+variable "project_name" {
+  description = "Project name"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+# Backup Configuration
+variable "plan_name" {
+  description = "Backup plan name; also names the vault (<plan_name>-vault)"
+  type        = string
+  validation {
+    condition     = can(regex("^[a-z][a-z0-9-]{2,43}$", var.plan_name))
+    error_message = "Plan name must be 3-44 lower case letters, digits and hyphens, starting with a letter"
+  }
+}
+
+variable "schedule" {
+  description = <<-EOT
+    When backups run, as a UTC cron expression: daily "M H * * *" or weekly
+    "M H * * D" (D = 0-6, Sunday = 0). Azure blob backup is continuous, so
+    the schedule does not apply there.
+  EOT
+  type        = string
+  default     = "0 3 * * *"
+  validation {
+    condition     = can(regex("^([0-5]?[0-9]) ([01]?[0-9]|2[0-3]) \\* \\* (\\*|[0-6])$", var.schedule))
+    error_message = "Schedule must be a daily \"M H * * *\" or weekly \"M H * * D\" cron expression"
+  }
+}
+
+variable "retention_days" {
+  description = "Days each backup is kept (at most 360 on azure)"
+  type        = number
+  default     = 35
+  validation {
+    condition     = var.retention_days >= 7 && floor(var.retention_days) == var.retention_days
+    error_message = "retention_days must be a whole number of at least 7 days"
+  }
+  validation {
+    condition     = var.provider_name != "azure" || var.retention_days <= 360
+    error_message = "Azure operational blob backup keeps at most 360 days"
+  }
+}
+
+variable "resources" {
+  description = <<-EOT
+    Resources to back up, as the backup_ref output of the database and
+    storage facades ({provider, type, id}). Supported types: rds, dynamodb
+    and s3 on aws, blob on azure, gcs on gcp.
+  EOT
+  type = list(object({
+    provider = string
+    type     = string
+    id       = string
+  }))
+  default = []
+  validation {
+    condition     = alltrue([for r in var.resources : contains(["aws", "azure", "gcp"], r.provider) && length(r.type) > 0])
+    error_message = "Each entry in resources must have provider aws, azure or gcp and a type, e.g. { provider = \"aws\", type = \"s3\", id = \"my-bucket\" }"
+  }
+}
+
+# Provider-specific Configuration
+variable "provider_config" {
+  description = "Provider-specific settings (resource_group_name, location, redundancy for azure; project_id, location for gcp)"
+  type        = any
+  default     = {}
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
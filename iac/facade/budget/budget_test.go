@@ -0,0 +1,209 @@
+package budget_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/planerr"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var thresholds = []interface{}{float64(50), float64(80), float64(100), float64(120)}
+
+// blocks returns a nested block list from planned attribute values
+func blocks(values map[string]interface{}, name string) []map[string]interface{} {
+	var result []map[string]interface{}
+	list, _ := values[name].([]interface{})
+	for _, item := range list {
+		result = append(result, item.(map[string]interface{}))
+	}
+	return result
+}
+
+func TestBudgetFacadeAws(t *testing.T) {
+	t.Parallel()
+
+	const topic = "arn:aws:sns:us-east-1:123456789012:finops"
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":         "aws",
+			"project_name":          "shop",
+			"environment":           "dev",
+			"budget_name":           "shop-monthly",
+			"monthly_limit_usd":     1250.5,
+			"threshold_percentages": thresholds,
+			"notification_ref": map[string]interface{}{
+				"provider":   "aws",
+				"channel_id": topic,
+				"emails":     []string{"finops@example.com"},
+			},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	budget, ok := plan.ResourcePlannedValuesMap["module.aws_budget[0].aws_budgets_budget.this"]
+	require.True(t, ok, "Plan should create a budget")
+	// The Budgets API takes the limit as a decimal string
+	assert.Equal(t, "1250.5", budget.AttributeValues["limit_amount"])
+	assert.Equal(t, "USD", budget.AttributeValues["limit_unit"])
+	assert.Equal(t, "MONTHLY", budget.AttributeValues["time_unit"])
+
+	filters := blocks(budget.AttributeValues, "cost_filter")
+	require.Len(t, filters, 1)
+	assert.Equal(t, []interface{}{"user:Project$shop"}, filters[0]["values"])
+
+	var planned []interface{}
+	for _, notification := range blocks(budget.AttributeValues, "notification") {
+		planned = append(planned, notification["threshold"])
+		assert.Equal(t, "PERCENTAGE", notification["threshold_type"])
+		assert.ElementsMatch(t, []interface{}{topic}, notification["subscriber_sns_topic_arns"])
+		assert.ElementsMatch(t, []interface{}{"finops@example.com"}, notification["subscriber_email_addresses"])
+	}
+	assert.ElementsMatch(t, thresholds, planned, "There should be one notification per threshold")
+}
+
+func TestBudgetFacadeAzure(t *testing.T) {
+	t.Parallel()
+
+	const actionGroup = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/shop-rg/providers/microsoft.insights/actionGroups/finops"
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":         "azure",
+			"project_name":          "shop",
+			"environment":           "dev",
+			"budget_name":           "shop-monthly",
+			"monthly_limit_usd":     1250.5,
+			"threshold_percentages": thresholds,
+			"notification_ref":      map[string]interface{}{"provider": "azure", "channel_id": actionGroup},
+			"provider_config":       map[string]interface{}{"resource_group_name": "shop-rg", "start_date": "2026-10-01T00:00:00Z"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	budget, ok := plan.ResourcePlannedValuesMap["module.azure_budget[0].azurerm_consumption_budget_resource_group.this"]
+	require.True(t, ok, "Plan should create a consumption budget")
+	// Azure takes the amount as a number
+	assert.Equal(t, 1250.5, budget.AttributeValues["amount"])
+	assert.Equal(t, "Monthly", budget.AttributeValues["time_grain"])
+	assert.Equal(t, "2026-10-01T00:00:00Z", blocks(budget.AttributeValues, "time_period")[0]["start_date"])
+
+	var planned []interface{}
+	for _, notification := range blocks(budget.AttributeValues, "notification") {
+		planned = append(planned, notification["threshold"])
+		assert.Equal(t, []interface{}{actionGroup}, notification["contact_groups"])
+	}
+	assert.ElementsMatch(t, thresholds, planned, "There should be one notification per threshold")
+}
+
+func TestBudgetFacadeGcp(t *testing.T) {
+	t.Parallel()
+
+	const topic = "projects/test-project/topics/budget-alerts"
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":         "gcp",
+			"project_name":          "shop",
+			"environment":           "dev",
+			"budget_name":           "shop-monthly",
+			"monthly_limit_usd":     1250.5,
+			"threshold_percentages": thresholds,
+			"notification_ref": map[string]interface{}{
+				"provider":   "gcp",
+				"channel_id": topic,
+				"emails":     []string{"finops@example.com", "cto@example.com"},
+			},
+			"provider_config": map[string]interface{}{"project_id": "test-project", "billing_account_id": "012345-6789AB-CDEF01"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	budget, ok := plan.ResourcePlannedValuesMap["module.gcp_budget[0].google_billing_budget.this"]
+	require.True(t, ok, "Plan should create a billing budget")
+	assert.Equal(t, "012345-6789AB-CDEF01", budget.AttributeValues["billing_account"])
+
+	// Whole units are a string, the fraction is nanos
+	amount := blocks(blocks(budget.AttributeValues, "amount")[0], "specified_amount")[0]
+	assert.Equal(t, "1250", amount["units"])
+	assert.EqualValues(t, 500000000, amount["nanos"])
+	assert.Equal(t, "USD", amount["currency_code"])
+
+	var planned []interface{}
+	for _, rule := range blocks(budget.AttributeValues, "threshold_rules") {
+		planned = append(planned, rule["threshold_percent"])
+	}
+	assert.ElementsMatch(t, []interface{}{0.5, 0.8, float64(1), 1.2}, planned, "There should be one threshold rule per percentage, as a fraction")
+
+	updates := blocks(budget.AttributeValues, "all_updates_rule")[0]
+	assert.Equal(t, topic, updates["pubsub_topic"])
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "module.gcp_budget[0].google_monitoring_notification_channel.email[0]")
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "module.gcp_budget[0].google_monitoring_notification_channel.email[1]")
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name":     "aws",
+		"project_name":      "testproject",
+		"environment":       "dev",
+		"budget_name":       "shop-monthly",
+		"monthly_limit_usd": 100,
+		"notification_ref":  map[string]interface{}{"emails": []string{"finops@example.com"}},
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "ThresholdsNotAscending",
+			Vars: map[string]interface{}{"threshold_percentages": []int{80, 50, 100}},
+			Want: "threshold_percentages must be in ascending order without repeats",
+		},
+		{
+			Name: "RepeatedThreshold",
+			Vars: map[string]interface{}{"threshold_percentages": []int{50, 50}},
+			Want: "threshold_percentages must be in ascending order without repeats",
+		},
+		{
+			Name: "ThresholdAbove200",
+			Vars: map[string]interface{}{"threshold_percentages": []int{100, 250}},
+			Want: "Threshold percentages must be greater than 0 and at most 200",
+		},
+		{
+			Name: "TooManyThresholds",
+			Vars: map[string]interface{}{"threshold_percentages": []int{10, 20, 30, 40, 50, 60}},
+			Want: "threshold_percentages must have 1 to 5 entries",
+		},
+		{
+			Name: "ZeroLimit",
+			Vars: map[string]interface{}{"monthly_limit_usd": 0},
+			Want: "monthly_limit_usd must be greater than 0",
+		},
+		{
+			Name: "NobodyNotified",
+			Vars: map[string]interface{}{"notification_ref": map[string]interface{}{}},
+			Want: "notification_ref needs emails or a channel_id",
+		},
+		{
+			Name: "ChannelFromOtherProvider",
+			Vars: map[string]interface{}{"notification_ref": map[string]interface{}{"provider": "gcp", "channel_id": "projects/p/topics/t"}},
+			Want: "notification_ref.channel_id belongs to gcp but this module deploys to aws",
+		},
+		{
+			Name: "GcpWithoutBillingAccount",
+			Vars: map[string]interface{}{"provider_name": "gcp"},
+			Want: "set provider_config.billing_account_id",
+		},
+	})
+}
@@ -0,0 +1,106 @@
+# Budget Facade
+# Unified interface for monthly cost budgets with threshold notifications
+
+terraform {
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
+}
+
+# ============================================================================
+# IMPORT COMMON LAYER
+# ============================================================================
+
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
+    {
+      Provider = var.provider_name
+      Module   = "Budget-Facade"
+    },
+    var.tags
+  )
+}
+
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  # A channel from another provider is rejected by the budget_id precondition
+  channel_id = var.notification_ref.provider == var.provider_name ? var.notification_ref.channel_id : null
+}
+
+# ============================================================================
+# PROVIDER-SPECIFIC MODULE ROUTING
+# ============================================================================
+
+# AWS: Budgets cost budget filtered to the Project tag
+module "aws_budget" {
+  count  = var.provider_name == "aws" ? 1 : 0
+  source = "../../aws/core/budget"
+
+  name                  = var.budget_name
+  limit_amount          = tostring(var.monthly_limit_usd)
+  project_tag           = var.project_name
+  threshold_percentages = var.threshold_percentages
+  email_addresses       = var.notification_ref.emails
+  sns_topic_arn         = local.channel_id
+
+  tags = local.default_tags
+}
+
+# Azure: Consumption budget on the project resource group
+module "azure_budget" {
+  count  = var.provider_name == "azure" ? 1 : 0
+  source = "../../azure/core/budget"
+
+  name                  = var.budget_name
+  resource_group_name   = lookup(var.provider_config, "resource_group_name", module.resource_group_name.name)
+  amount                = var.monthly_limit_usd
+  start_date            = lookup(var.provider_config, "start_date", null)
+  threshold_percentages = var.threshold_percentages
+  contact_emails        = var.notification_ref.emails
+  action_group_id       = local.channel_id
+}
+
+# GCP: Billing budget filtered to the project
+module "gcp_budget" {
+  count  = var.provider_name == "gcp" ? 1 : 0
+  source = "../../gcp/core/budget"
+
+  project_id            = lookup(var.provider_config, "project_id", var.project_name)
+  billing_account_id    = var.provider_config.billing_account_id
+  name                  = var.budget_name
+  amount                = var.monthly_limit_usd
+  threshold_percentages = var.threshold_percentages
+  email_addresses       = var.notification_ref.emails
+  pubsub_topic          = local.channel_id != null && can(regex("/topics/", local.channel_id)) ? local.channel_id : null
+  notification_channels = local.channel_id != null && !can(regex("/topics/", local.channel_id)) ? [local.channel_id] : []
+
+  labels = local.default_labels
+}
+
+# ============================================================================
+# AGGREGATED OUTPUTS
+# ============================================================================
+
+locals {
+  budget_id = (
+    var.provider_name == "aws"   ? (length(module.aws_budget) > 0 ? module.aws_budget[0].budget_id : null) :
+    var.provider_name == "azure" ? (length(module.azure_budget) > 0 ? module.azure_budget[0].budget_id : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_budget) > 0 ? module.gcp_budget[0].budget_id : null) :
+    null
+  )
+}
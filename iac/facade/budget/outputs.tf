@@ -0,0 +1,19 @@
+output "budget_id" {
+  description = "Budget identifier (account:name / consumption budget ID / billing budget name)"
+  value       = local.budget_id
+
+  precondition {
+    condition     = var.notification_ref.channel_id == null || var.notification_ref.provider == var.provider_name
+    error_message = "notification_ref.channel_id belongs to ${coalesce(var.notification_ref.provider, "no provider")} but this module deploys to ${var.provider_name}"
+  }
+}
+
+output "threshold_percentages" {
+  description = "Percentages of the limit that send a notification"
+  value       = var.threshold_percentages
+}
+
+output "provider" {
+  description = "Cloud provider"
+  value       = var.provider_name
+}
@@ -0,0 +1,104 @@
+# General Configuration
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp)"
+  type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
+}
+
+variable "project_name" {
+  description = "Project name"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+# Budget Configuration
+variable "budget_name" {
+  description = "Budget name"
+  type        = string
+  validation {
+    condition     = can(regex("^[a-z][a-z0-9-]{2,59}$", var.budget_name))
+    error_message = "Budget name must be 3-60 lower case letters, digits and hyphens, starting with a letter"
+  }
+}
+
+variable "monthly_limit_usd" {
+  description = "Monthly spend limit in USD (in the billing currency on azure)"
+  type        = number
+  validation {
+    condition     = var.monthly_limit_usd > 0
+    error_message = "monthly_limit_usd must be greater than 0"
+  }
+}
+
+variable "threshold_percentages" {
+  description = "Percentages of monthly_limit_usd that send a notification, ascending"
+  type        = list(number)
+  default     = [50, 80, 100]
+  validation {
+    condition     = length(var.threshold_percentages) > 0 && length(var.threshold_percentages) <= 5
+    error_message = "threshold_percentages must have 1 to 5 entries, the most a budget notifies on"
+  }
+  validation {
+    condition     = alltrue([for p in var.threshold_percentages : p > 0 && p <= 200])
+    error_message = "Threshold percentages must be greater than 0 and at most 200"
+  }
+  validation {
+    condition     = alltrue([for i in range(1, length(var.threshold_percentages)) : var.threshold_percentages[i] > var.threshold_percentages[i - 1]])
+    error_message = "threshold_percentages must be in ascending order without repeats, e.g. [50, 80, 100]"
+  }
+}
+
+variable "notification_ref" {
+  description = <<-EOT
+    Who is notified ({provider, channel_id, emails}): email addresses, and/or
+    a channel on provider - an SNS topic ARN on aws, a Monitor action group ID
+    on azure, a Pub/Sub topic or Cloud Monitoring notification channel ID on
+    gcp.
+  EOT
+  type = object({
+    provider   = optional(string)
+    channel_id = optional(string)
+    emails     = optional(list(string), [])
+  })
+  validation {
+    condition     = length(var.notification_ref.emails) > 0 || var.notification_ref.channel_id != null
+    error_message = "notification_ref needs emails or a channel_id, e.g. { emails = [\"ops@example.com\"] }"
+  }
+  validation {
+    condition     = alltrue([for e in var.notification_ref.emails : can(regex("^[^@\\s]+@[^@\\s]+\\.[^@\\s]+$", e))])
+    error_message = "notification_ref.emails must be email addresses, e.g. ops@example.com"
+  }
+  validation {
+    condition     = var.notification_ref.channel_id == null || var.notification_ref.provider != null
+    error_message = "notification_ref.channel_id needs the provider it belongs to, e.g. provider = \"aws\" for an SNS topic ARN"
+  }
+}
+
+# Provider-specific Configuration
+variable "provider_config" {
+  description = "Provider-specific settings (resource_group_name, start_date for azure; project_id, billing_account_id for gcp)"
+  type        = any
+  default     = {}
+  validation {
+    condition     = var.provider_name != "gcp" || try(length(var.provider_config.billing_account_id) > 0, false)
+    error_message = "On gcp the budget belongs to a billing account; set provider_config.billing_account_id"
+  }
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
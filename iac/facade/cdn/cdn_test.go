@@ -0,0 +1,227 @@
+package cdn_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/planerr"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const acmCertificate = "arn:aws:acm:us-east-1:123456789012:certificate/0f3c1c2e-8f7a-4b7e-9d3c-2a1b5c6d7e8f"
+
+// awsOrigin is the storage facade's origin_ref for a website bucket on AWS
+var awsOrigin = map[string]interface{}{
+	"provider":       "aws",
+	"bucket_name":    "shop-site",
+	"id":             "arn:aws:s3:::shop-site",
+	"domain_name":    "shop-site.s3.us-east-1.amazonaws.com",
+	"index_document": "index.html",
+	"error_document": "404.html",
+}
+
+func TestCdnFacadeAws(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":   "aws",
+			"project_name":    "testproject",
+			"environment":     "dev",
+			"name":            "shop-site",
+			"origin_ref":      awsOrigin,
+			"domain_aliases":  []string{"www.example.com"},
+			"certificate_ref": map[string]interface{}{"provider": "aws", "id": acmCertificate},
+			"default_ttl":     600,
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	distribution, ok := plan.ResourcePlannedValuesMap["module.aws_cdn[0].aws_cloudfront_distribution.this"]
+	require.True(t, ok, "Plan should create a CloudFront distribution")
+	origin := distribution.AttributeValues["origin"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "shop-site.s3.us-east-1.amazonaws.com", origin["domain_name"], "The origin should be the facade bucket")
+	assert.Equal(t, []interface{}{"www.example.com"}, distribution.AttributeValues["aliases"])
+	assert.Equal(t, "index.html", distribution.AttributeValues["default_root_object"])
+	certificate := distribution.AttributeValues["viewer_certificate"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, acmCertificate, certificate["acm_certificate_arn"])
+	behavior := distribution.AttributeValues["default_cache_behavior"].([]interface{})[0].(map[string]interface{})
+	assert.EqualValues(t, 600, behavior["default_ttl"])
+
+	oac, ok := plan.ResourcePlannedValuesMap["module.aws_cdn[0].aws_cloudfront_origin_access_control.this"]
+	require.True(t, ok, "Plan should create an origin access control")
+	assert.Equal(t, "s3", oac.AttributeValues["origin_access_control_origin_type"])
+
+	// The bucket policy names the distribution, whose ARN is unknown until
+	// apply, so only the static parts render in the plan
+	bucketPolicy, ok := plan.ResourcePlannedValuesMap["module.aws_cdn[0].aws_s3_bucket_policy.cdn"]
+	require.True(t, ok, "Plan should update the bucket policy")
+	assert.Equal(t, "shop-site", bucketPolicy.AttributeValues["bucket"])
+	change := plan.ResourceChangesMap["module.aws_cdn[0].aws_s3_bucket_policy.cdn"].Change
+	assert.Equal(t, true, change.AfterUnknown.(map[string]interface{})["policy"], "The policy should wait for the distribution ARN")
+}
+
+func TestCdnFacadeAwsDefaultCertificate(t *testing.T) {
+	t.Parallel()
+
+	planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"name":          "shop-site",
+			"origin_ref":    awsOrigin,
+		},
+		NoColor: true,
+	}))
+
+	assert.Regexp(t, `cloudfront_default_certificate\s+= true`, planString)
+	assert.Contains(t, planString, `"cloudfront.amazonaws.com"`)
+	assert.Contains(t, planString, `"arn:aws:s3:::shop-site/*"`)
+}
+
+func TestCdnFacadeAzure(t *testing.T) {
+	t.Parallel()
+
+	const certificate = "https://shop-kv.vault.azure.net/certificates/www-example-com"
+
+	planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "azure",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"name":          "shop-site",
+			"origin_ref": map[string]interface{}{
+				"provider":       "azure",
+				"bucket_name":    "shopsite",
+				"id":             "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/test-rg/providers/Microsoft.Storage/storageAccounts/shopsite",
+				"domain_name":    "shopsite.z13.web.core.windows.net",
+				"index_document": "index.html",
+				"error_document": "404.html",
+			},
+			"domain_aliases":  []string{"www.example.com"},
+			"certificate_ref": map[string]interface{}{"provider": "azure", "id": certificate},
+			"default_ttl":     90061,
+			"provider_config": map[string]interface{}{"resource_group_name": "test-rg"},
+		},
+		NoColor: true,
+	}))
+
+	assert.Contains(t, planString, "module.azure_cdn[0].azurerm_cdn_frontdoor_profile.this")
+	assert.Contains(t, planString, "module.azure_cdn[0].azurerm_cdn_frontdoor_endpoint.this")
+	assert.Contains(t, planString, "module.azure_cdn[0].azurerm_cdn_frontdoor_route.this")
+	assert.Regexp(t, `host_name\s+= "shopsite.z13.web.core.windows.net"`, planString, "The origin should be the facade's static website host")
+	assert.Regexp(t, `cache_duration\s+= "1.01:01:01"`, planString)
+	assert.Contains(t, planString, `module.azure_cdn[0].azurerm_cdn_frontdoor_custom_domain.this["www.example.com"]`)
+	assert.Regexp(t, `key_vault_certificate_id\s+= "`+certificate+`"`, planString)
+}
+
+func TestCdnFacadeGcp(t *testing.T) {
+	t.Parallel()
+
+	const certificate = "projects/test-project/global/sslCertificates/www-example-com"
+
+	planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "gcp",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"name":          "shop-site",
+			"origin_ref": map[string]interface{}{
+				"provider":       "gcp",
+				"bucket_name":    "shop-site",
+				"id":             "shop-site",
+				"index_document": "index.html",
+				"error_document": "404.html",
+			},
+			"domain_aliases":  []string{"www.example.com"},
+			"certificate_ref": map[string]interface{}{"provider": "gcp", "id": certificate},
+			"provider_config": map[string]interface{}{"project_id": "test-project"},
+		},
+		NoColor: true,
+	}))
+
+	assert.Contains(t, planString, "module.gcp_cdn[0].google_compute_backend_bucket.this")
+	assert.Regexp(t, `bucket_name\s+= "shop-site"`, planString, "The backend bucket should be the facade bucket")
+	assert.Regexp(t, `enable_cdn\s+= true`, planString)
+	assert.Contains(t, planString, "module.gcp_cdn[0].google_compute_url_map.this")
+	assert.Contains(t, planString, "module.gcp_cdn[0].google_compute_target_https_proxy.this[0]")
+	assert.NotContains(t, planString, "google_compute_target_http_proxy.this")
+	assert.Contains(t, planString, certificate)
+	assert.Regexp(t, `port_range\s+= "443"`, planString)
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"name":          "shop-site",
+		"origin_ref":    awsOrigin,
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "AliasesWithoutCertificate",
+			Vars: map[string]interface{}{"domain_aliases": []string{"www.example.com"}},
+			Want: "domain_aliases need a certificate_ref covering them",
+		},
+		{
+			Name: "BadAlias",
+			Vars: map[string]interface{}{
+				"domain_aliases":  []string{"https://www.example.com"},
+				"certificate_ref": map[string]interface{}{"provider": "aws", "id": acmCertificate},
+			},
+			Want: "Domain aliases must be lower case host names",
+		},
+		{
+			Name: "CertificateFromOtherRegion",
+			Vars: map[string]interface{}{
+				"domain_aliases":  []string{"www.example.com"},
+				"certificate_ref": map[string]interface{}{"provider": "aws", "id": "arn:aws:acm:eu-west-1:123456789012:certificate/0f3c1c2e"},
+			},
+			Want: "CloudFront only uses ACM certificates from us-east-1",
+		},
+		{
+			Name: "CertificateFromOtherProvider",
+			Vars: map[string]interface{}{
+				"domain_aliases":  []string{"www.example.com"},
+				"certificate_ref": map[string]interface{}{"provider": "gcp", "id": "projects/p/global/sslCertificates/c"},
+			},
+			Want: "certificate_ref belongs to gcp but this module deploys to aws",
+		},
+		{
+			Name: "OriginFromOtherProvider",
+			Vars: map[string]interface{}{"provider_name": "gcp"},
+			Want: "origin_ref belongs to aws but this module deploys to gcp",
+		},
+		{
+			Name: "OriginWithoutWebsiteMode",
+			Vars: map[string]interface{}{
+				"provider_name": "gcp",
+				"origin_ref":    map[string]interface{}{"provider": "gcp", "bucket_name": "shop-site", "id": "shop-site"},
+			},
+			Want: "origin_ref comes from a storage facade without website mode",
+		},
+		{
+			Name: "NegativeTtl",
+			Vars: map[string]interface{}{"default_ttl": -1},
+			Want: "default_ttl must be a whole number of seconds",
+		},
+		{
+			Name: "UnknownProvider",
+			Vars: map[string]interface{}{"provider_name": "zero"},
+			Want: "provider_name must be one of: aws, azure, gcp",
+		},
+	})
+}
@@ -0,0 +1,117 @@
+# CDN Facade
+# Unified interface for a CDN with TLS in front of a storage facade website
+
+terraform {
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
+}
+
+# ============================================================================
+# IMPORT COMMON LAYER
+# ============================================================================
+
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
+    {
+      Provider = var.provider_name
+      Module   = "CDN-Facade"
+    },
+    var.tags
+  )
+}
+
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  certificate_id = var.certificate_ref != null ? var.certificate_ref.id : null
+}
+
+# ============================================================================
+# PROVIDER-SPECIFIC MODULE ROUTING
+# ============================================================================
+
+# AWS: CloudFront distribution with origin access control on the private bucket
+module "aws_cdn" {
+  count  = var.provider_name == "aws" ? 1 : 0
+  source = "../../aws/core/cdn"
+
+  name                        = var.name
+  bucket_name                 = var.origin_ref.bucket_name
+  bucket_arn                  = var.origin_ref.id
+  bucket_regional_domain_name = var.origin_ref.domain_name
+  index_document              = coalesce(var.origin_ref.index_document, "index.html")
+  error_document              = var.origin_ref.error_document
+  aliases                     = var.domain_aliases
+  acm_certificate_arn         = local.certificate_id
+  default_ttl                 = var.default_ttl
+  price_class                 = lookup(var.provider_config, "price_class", "PriceClass_100")
+
+  tags = local.default_tags
+}
+
+# Azure: Front Door profile, endpoint and route to the static website host
+module "azure_cdn" {
+  count  = var.provider_name == "azure" ? 1 : 0
+  source = "../../azure/core/cdn"
+
+  name                     = var.name
+  resource_group_name      = lookup(var.provider_config, "resource_group_name", module.resource_group_name.name)
+  sku_name                 = lookup(var.provider_config, "sku_name", "Standard_AzureFrontDoor")
+  origin_host_name         = var.origin_ref.domain_name
+  custom_domains           = var.domain_aliases
+  key_vault_certificate_id = local.certificate_id
+  default_ttl              = var.default_ttl
+
+  tags = local.default_tags
+}
+
+# GCP: backend bucket with Cloud CDN behind a global external load balancer
+module "gcp_cdn" {
+  count  = var.provider_name == "gcp" ? 1 : 0
+  source = "../../gcp/core/cdn"
+
+  project_id         = lookup(var.provider_config, "project_id", var.project_name)
+  name               = var.name
+  bucket_name        = var.origin_ref.bucket_name
+  ssl_certificate_id = local.certificate_id
+  default_ttl        = var.default_ttl
+
+  labels = local.default_labels
+}
+
+# ============================================================================
+# AGGREGATED OUTPUTS
+# ============================================================================
+
+locals {
+  # GCP has no CDN host name, so the first alias (or the load balancer IP)
+  # stands in for it
+  cdn_domain_name = (
+    var.provider_name == "aws"   ? (length(module.aws_cdn) > 0 ? module.aws_cdn[0].domain_name : null) :
+    var.provider_name == "azure" ? (length(module.azure_cdn) > 0 ? module.azure_cdn[0].endpoint_host_name : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_cdn) > 0 ? (length(var.domain_aliases) > 0 ? var.domain_aliases[0] : module.gcp_cdn[0].ip_address) : null) :
+    null
+  )
+
+  cdn_id = (
+    var.provider_name == "aws"   ? (length(module.aws_cdn) > 0 ? module.aws_cdn[0].distribution_id : null) :
+    var.provider_name == "azure" ? (length(module.azure_cdn) > 0 ? module.azure_cdn[0].profile_id : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_cdn) > 0 ? module.gcp_cdn[0].backend_bucket_id : null) :
+    null
+  )
+}
@@ -0,0 +1,39 @@
+output "cdn_domain_name" {
+  description = "Where to point DNS (CloudFront domain / Front Door endpoint host name / first alias or load balancer IP on GCP)"
+  value       = local.cdn_domain_name
+
+  precondition {
+    condition     = var.origin_ref.provider == var.provider_name
+    error_message = "origin_ref belongs to ${var.origin_ref.provider} but this module deploys to ${var.provider_name}"
+  }
+
+  precondition {
+    condition     = var.provider_name == "aws" || var.origin_ref.index_document != null
+    error_message = "origin_ref comes from a storage facade without website mode; set its website variable to serve the bucket through a CDN on ${var.provider_name}"
+  }
+
+  precondition {
+    condition     = var.certificate_ref == null || try(var.certificate_ref.provider == var.provider_name, false)
+    error_message = "certificate_ref belongs to ${try(var.certificate_ref.provider, "another provider")} but this module deploys to ${var.provider_name}"
+  }
+
+  precondition {
+    condition     = var.provider_name != "aws" || var.certificate_ref == null || can(regex("^arn:aws:acm:us-east-1:", var.certificate_ref.id))
+    error_message = "CloudFront only uses ACM certificates from us-east-1"
+  }
+}
+
+output "cdn_id" {
+  description = "CDN resource identifier (CloudFront distribution ID / Front Door profile ID / backend bucket ID)"
+  value       = local.cdn_id
+}
+
+output "custom_domain_validation_tokens" {
+  description = "Azure only: TXT record value for _dnsauth.<domain>, by domain alias"
+  value       = length(module.azure_cdn) > 0 ? module.azure_cdn[0].custom_domain_validation_tokens : {}
+}
+
+output "provider" {
+  description = "Cloud provider"
+  value       = var.provider_name
+}
@@ -0,0 +1,96 @@
+# General Configuration
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp)"
+  type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
+}
+
+variable "project_name" {
+  description = "Project name"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+# CDN Configuration
+variable "name" {
+  description = "CDN name (CloudFront comment / Front Door profile and endpoint / URL map)"
+  type        = string
+  validation {
+    condition     = can(regex("^[a-z][a-z0-9-]{2,45}$", var.name))
+    error_message = "CDN name must be 3-46 lower case letters, digits and hyphens, starting with a letter"
+  }
+}
+
+variable "origin_ref" {
+  description = "The storage facade's origin_ref output; the bucket must be in website mode on azure and gcp"
+  type = object({
+    provider       = string
+    bucket_name    = string
+    id             = string
+    domain_name    = optional(string)
+    index_document = optional(string)
+    error_document = optional(string)
+  })
+}
+
+variable "domain_aliases" {
+  description = "Custom domain names served by the CDN; each needs a DNS record pointing at cdn_domain_name"
+  type        = list(string)
+  default     = []
+  validation {
+    condition     = alltrue([for d in var.domain_aliases : can(regex("^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\\.)+[a-z]{2,}$", d))])
+    error_message = "Domain aliases must be lower case host names, e.g. www.example.com"
+  }
+  validation {
+    condition     = length(var.domain_aliases) == 0 || var.certificate_ref != null
+    error_message = "domain_aliases need a certificate_ref covering them"
+  }
+}
+
+variable "certificate_ref" {
+  description = "TLS certificate for domain_aliases ({provider, id}): an ACM certificate ARN in us-east-1, a Key Vault certificate ID, or a Compute SSL or Certificate Manager certificate ID; the certificate facade's certificate_ref output fits"
+  type = object({
+    provider = string
+    id       = string
+  })
+  default = null
+  validation {
+    condition     = var.certificate_ref == null || try(contains(["aws", "azure", "gcp"], var.certificate_ref.provider) && length(var.certificate_ref.id) > 0, false)
+    error_message = "certificate_ref must have provider aws, azure or gcp and a non-empty id, e.g. the certificate facade's certificate_ref output"
+  }
+}
+
+variable "default_ttl" {
+  description = "Seconds objects stay cached when the origin sends no Cache-Control"
+  type        = number
+  default     = 3600
+  validation {
+    condition     = var.default_ttl >= 0 && var.default_ttl <= 31536000 && floor(var.default_ttl) == var.default_ttl
+    error_message = "default_ttl must be a whole number of seconds between 0 and 31536000 (one year)"
+  }
+}
+
+# Provider-specific Configuration
+variable "provider_config" {
+  description = "Provider-specific settings (price_class for aws; resource_group_name, sku_name for azure; project_id for gcp)"
+  type        = any
+  default     = {}
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
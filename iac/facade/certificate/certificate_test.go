@@ -0,0 +1,190 @@
+package certificate_test
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"iac/testutil/planerr"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sans covers the apex and its wildcard, which share a validation record,
+// and a name two levels down, which gets its own
+var sans = []string{"*.example.com", "api.internal.example.com"}
+
+// validationDomains are the record keys sans needs next to example.com
+var validationDomains = []string{"api.internal.example.com", "example.com"}
+
+// recordKeys returns the for_each keys planned for a resource address prefix
+func recordKeys(plan *terraform.PlanStruct, prefix string) []string {
+	var keys []string
+	for address := range plan.ResourcePlannedValuesMap {
+		if strings.HasPrefix(address, prefix+`["`) {
+			keys = append(keys, strings.TrimSuffix(strings.TrimPrefix(address, prefix+`["`), `"]`))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestCertificateFacadeAws(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":             "aws",
+			"project_name":              "testproject",
+			"environment":               "dev",
+			"domain_name":               "example.com",
+			"subject_alternative_names": sans,
+			"zone_ref":                  map[string]interface{}{"provider": "aws", "id": "Z0123456789ABCDEFGHIJ", "name": "example.com"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	certificate, ok := plan.ResourcePlannedValuesMap["module.aws_certificate[0].aws_acm_certificate.this"]
+	require.True(t, ok, "Plan should request an ACM certificate")
+	assert.Equal(t, "DNS", certificate.AttributeValues["validation_method"])
+	assert.ElementsMatch(t, sans, certificate.AttributeValues["subject_alternative_names"])
+
+	assert.Equal(t, validationDomains, recordKeys(plan, "module.aws_certificate[0].aws_route53_record.validation"),
+		"There should be one validation record per name, with the wildcard sharing the apex's")
+	for _, domain := range validationDomains {
+		record := plan.ResourcePlannedValuesMap[`module.aws_certificate[0].aws_route53_record.validation["`+domain+`"]`]
+		assert.Equal(t, "Z0123456789ABCDEFGHIJ", record.AttributeValues["zone_id"], "Records should go to the zone_ref zone")
+	}
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "module.aws_certificate[0].aws_acm_certificate_validation.this")
+}
+
+func TestCertificateFacadeAzure(t *testing.T) {
+	t.Parallel()
+
+	const keyVault = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/test-rg/providers/Microsoft.KeyVault/vaults/test-kv"
+
+	planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":             "azure",
+			"project_name":              "testproject",
+			"environment":               "dev",
+			"domain_name":               "example.com",
+			"subject_alternative_names": sans,
+			"provider_config":           map[string]interface{}{"key_vault_id": keyVault, "issuer_name": "GlobalSign"},
+		},
+		NoColor: true,
+	}))
+
+	assert.Contains(t, planString, "module.azure_certificate[0].azurerm_key_vault_certificate.this")
+	assert.Regexp(t, `name\s+= "example-com"`, planString)
+	assert.Regexp(t, `key_vault_id\s+= "`+keyVault+`"`, planString)
+	assert.Regexp(t, `name\s+= "GlobalSign"`, planString)
+	assert.Regexp(t, `subject\s+= "CN=example.com"`, planString)
+	for _, name := range append([]string{"example.com"}, sans...) {
+		assert.Contains(t, planString, `"`+name+`"`, "The certificate should cover %s", name)
+	}
+}
+
+func TestCertificateFacadeGcp(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":             "gcp",
+			"project_name":              "testproject",
+			"environment":               "dev",
+			"domain_name":               "example.com",
+			"subject_alternative_names": sans,
+			"zone_ref":                  map[string]interface{}{"provider": "gcp", "id": "example-zone", "name": "example.com."},
+			"provider_config":           map[string]interface{}{"project_id": "test-project"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	certificate, ok := plan.ResourcePlannedValuesMap["module.gcp_certificate[0].google_certificate_manager_certificate.this"]
+	require.True(t, ok, "Plan should create a Certificate Manager certificate")
+	managed := certificate.AttributeValues["managed"].([]interface{})[0].(map[string]interface{})
+	assert.ElementsMatch(t, append([]interface{}{"example.com"}, "*.example.com", "api.internal.example.com"), managed["domains"])
+
+	assert.Equal(t, validationDomains, recordKeys(plan, "module.gcp_certificate[0].google_certificate_manager_dns_authorization.this"))
+	assert.Equal(t, validationDomains, recordKeys(plan, "module.gcp_certificate[0].google_dns_record_set.validation"),
+		"Every DNS authorization should get its record in the zone")
+	for _, domain := range validationDomains {
+		record := plan.ResourcePlannedValuesMap[`module.gcp_certificate[0].google_dns_record_set.validation["`+domain+`"]`]
+		assert.Equal(t, "example-zone", record.AttributeValues["managed_zone"])
+	}
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"domain_name":   "example.com",
+		"zone_ref":      map[string]interface{}{"provider": "aws", "id": "Z0123456789ABCDEFGHIJ", "name": "example.com"},
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "NestedWildcard",
+			Vars: map[string]interface{}{"domain_name": "*.*.example.com"},
+			Want: "Domain names must be lower case host names with at most one wildcard",
+		},
+		{
+			Name: "WildcardNotLeftmost",
+			Vars: map[string]interface{}{"subject_alternative_names": []string{"www.*.example.com"}},
+			Want: "subject_alternative_names must be lower case host names with at most one wildcard",
+		},
+		{
+			Name: "WildcardOnTopLevelDomain",
+			Vars: map[string]interface{}{"subject_alternative_names": []string{"*.com"}},
+			Want: "subject_alternative_names must be lower case host names with at most one wildcard",
+		},
+		{
+			Name: "NameCoveredByWildcard",
+			Vars: map[string]interface{}{"domain_name": "*.example.com", "subject_alternative_names": []string{"www.example.com"}},
+			Want: "A name covered by a wildcard in the same certificate",
+		},
+		{
+			Name: "RepeatedName",
+			Vars: map[string]interface{}{"subject_alternative_names": []string{"www.example.com", "example.com"}},
+			Want: "subject_alternative_names must not repeat a name or the domain_name",
+		},
+		{
+			Name: "NameOutsideZone",
+			Vars: map[string]interface{}{"subject_alternative_names": []string{"www.example.org"}},
+			Want: "Every domain name must be in the zone_ref zone",
+		},
+		{
+			Name: "SuffixIsNotZone",
+			Vars: map[string]interface{}{"domain_name": "badexample.com"},
+			Want: "Every domain name must be in the zone_ref zone",
+		},
+		{
+			Name: "MissingZone",
+			Vars: map[string]interface{}{"zone_ref": nil},
+			Want: "zone_ref is required on aws and gcp",
+		},
+		{
+			Name: "ZoneFromOtherProvider",
+			Vars: map[string]interface{}{"provider_name": "gcp", "provider_config": map[string]interface{}{"project_id": "test-project"}},
+			Want: "zone_ref belongs to aws but this module deploys to gcp",
+		},
+		{
+			Name: "AzureWithoutKeyVault",
+			Vars: map[string]interface{}{"provider_name": "azure", "zone_ref": nil},
+			Want: "set provider_config.key_vault_id",
+		},
+	})
+}
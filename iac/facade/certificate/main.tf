@@ -0,0 +1,104 @@
+# Certificate Facade
+# Unified interface for TLS certificate issuance with DNS validation
+
+terraform {
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
+}
+
+# ============================================================================
+# IMPORT COMMON LAYER
+# ============================================================================
+
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
+    {
+      Provider = var.provider_name
+      Module   = "Certificate-Facade"
+    },
+    var.tags
+  )
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  # Key Vault and Certificate Manager names: *.example.com -> wildcard-example-com
+  certificate_name = replace(replace(var.domain_name, "*.", "wildcard-"), ".", "-")
+}
+
+# ============================================================================
+# PROVIDER-SPECIFIC MODULE ROUTING
+# ============================================================================
+
+# AWS: ACM certificate validated through Route 53 records
+module "aws_certificate" {
+  count  = var.provider_name == "aws" ? 1 : 0
+  source = "../../aws/core/certificate"
+
+  domain_name               = var.domain_name
+  subject_alternative_names = var.subject_alternative_names
+  zone_id                   = var.zone_ref.id
+
+  tags = local.default_tags
+}
+
+# Azure: Key Vault certificate from the vault's certificate issuer
+module "azure_certificate" {
+  count  = var.provider_name == "azure" ? 1 : 0
+  source = "../../azure/core/certificate"
+
+  name                      = local.certificate_name
+  key_vault_id              = var.provider_config.key_vault_id
+  issuer_name               = lookup(var.provider_config, "issuer_name", "DigiCert")
+  domain_name               = var.domain_name
+  subject_alternative_names = var.subject_alternative_names
+
+  tags = local.default_tags
+}
+
+# GCP: Certificate Manager certificate with DNS authorizations in Cloud DNS
+module "gcp_certificate" {
+  count  = var.provider_name == "gcp" ? 1 : 0
+  source = "../../gcp/core/certificate"
+
+  project_id                = lookup(var.provider_config, "project_id", var.project_name)
+  name                      = local.certificate_name
+  domain_name               = var.domain_name
+  subject_alternative_names = var.subject_alternative_names
+  managed_zone              = var.zone_ref.id
+
+  labels = local.default_labels
+}
+
+# ============================================================================
+# AGGREGATED OUTPUTS
+# ============================================================================
+
+locals {
+  certificate_id = (
+    var.provider_name == "aws"   ? (length(module.aws_certificate) > 0 ? module.aws_certificate[0].certificate_arn : null) :
+    var.provider_name == "azure" ? (length(module.azure_certificate) > 0 ? module.azure_certificate[0].certificate_id : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_certificate) > 0 ? module.gcp_certificate[0].certificate_id : null) :
+    null
+  )
+
+  validation_status = (
+    var.provider_name == "aws"   ? (length(module.aws_certificate) > 0 ? module.aws_certificate[0].status : null) :
+    var.provider_name == "azure" ? (length(module.azure_certificate) > 0 ? module.azure_certificate[0].status : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_certificate) > 0 ? module.gcp_certificate[0].status : null) :
+    null
+  )
+
+  validation_record_names = (
+    var.provider_name == "aws" ? (length(module.aws_certificate) > 0 ? module.aws_certificate[0].validation_record_names : {}) :
+    var.provider_name == "gcp" ? (length(module.gcp_certificate) > 0 ? module.gcp_certificate[0].validation_record_names : {}) :
+    {}
+  )
+}
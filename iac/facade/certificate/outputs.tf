@@ -0,0 +1,43 @@
+output "certificate_arn" {
+  description = "Certificate identifier (ACM certificate ARN / versionless Key Vault certificate ID / Certificate Manager certificate ID)"
+  value       = local.certificate_id
+
+  precondition {
+    condition     = var.zone_ref == null || try(var.zone_ref.provider == var.provider_name, false)
+    error_message = "zone_ref belongs to ${try(var.zone_ref.provider, "another provider")} but this module deploys to ${var.provider_name}"
+  }
+}
+
+output "certificate_id" {
+  description = "Certificate identifier (same value as certificate_arn)"
+  value       = local.certificate_id
+}
+
+# Shared certificate reference accepted by the cdn facade (certificate_ref)
+output "certificate_ref" {
+  description = "Certificate reference ({provider, id}) for other facades"
+  value = {
+    provider = var.provider_name
+    id       = local.certificate_id
+  }
+}
+
+output "validation_status" {
+  description = "Issuance status (ACM status, e.g. ISSUED / ISSUED once Key Vault holds the certificate / Certificate Manager state, e.g. ACTIVE)"
+  value       = local.validation_status
+}
+
+output "validation_record_names" {
+  description = "Validation record written to zone_ref, by domain without its wildcard label; empty on azure"
+  value       = local.validation_record_names
+}
+
+output "domains" {
+  description = "Every domain name the certificate covers"
+  value       = concat([var.domain_name], var.subject_alternative_names)
+}
+
+output "provider" {
+  description = "Cloud provider"
+  value       = var.provider_name
+}
@@ -0,0 +1,101 @@
+# General Configuration
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp)"
+  type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
+}
+
+variable "project_name" {
+  description = "Project name"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+# Certificate Configuration
+variable "domain_name" {
+  description = "Primary domain name; a wildcard is allowed as the whole leftmost label, e.g. *.example.com"
+  type        = string
+  validation {
+    condition     = can(regex("^(\\*\\.)?([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\\.)+[a-z]{2,}$", var.domain_name))
+    error_message = "Domain names must be lower case host names with at most one wildcard, as the whole leftmost label above a registrable domain (*.example.com)"
+  }
+}
+
+variable "subject_alternative_names" {
+  description = "Additional domain names covered by the certificate"
+  type        = list(string)
+  default     = []
+  validation {
+    condition     = alltrue([for d in var.subject_alternative_names : can(regex("^(\\*\\.)?([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\\.)+[a-z]{2,}$", d))])
+    error_message = "subject_alternative_names must be lower case host names with at most one wildcard, as the whole leftmost label above a registrable domain (*.example.com)"
+  }
+  validation {
+    condition     = length(distinct(concat([var.domain_name], var.subject_alternative_names))) == length(var.subject_alternative_names) + 1
+    error_message = "subject_alternative_names must not repeat a name or the domain_name"
+  }
+  # A wildcard and its apex are fine (one validation record covers both),
+  # but a name a wildcard already covers is rejected by Certificate Manager
+  # and only duplicates validation work elsewhere
+  validation {
+    condition = alltrue([
+      for d in concat([var.domain_name], var.subject_alternative_names) :
+      startswith(d, "*.") || !contains(concat([var.domain_name], var.subject_alternative_names), "*.${join(".", slice(split(".", d), 1, length(split(".", d))))}")
+    ])
+    error_message = "A name covered by a wildcard in the same certificate (www.example.com with *.example.com) is not supported; drop the name or the wildcard"
+  }
+}
+
+variable "zone_ref" {
+  description = <<-EOT
+    DNS zone receiving the validation records ({provider, id, name}): the
+    Route 53 hosted zone ID or Cloud DNS managed zone name as id, and the
+    zone's domain as name. Required on aws and gcp; Azure Key Vault issuers
+    validate domains themselves.
+  EOT
+  type = object({
+    provider = string
+    id       = string
+    name     = string
+  })
+  default = null
+  validation {
+    condition     = var.provider_name == "azure" || var.zone_ref != null
+    error_message = "zone_ref is required on aws and gcp, where the validation records are written to the zone"
+  }
+  validation {
+    condition = var.zone_ref == null || alltrue([
+      for d in concat([var.domain_name], var.subject_alternative_names) :
+      trimprefix(d, "*.") == trimsuffix(var.zone_ref.name, ".") || endswith(d, ".${trimsuffix(var.zone_ref.name, ".")}")
+    ])
+    error_message = "Every domain name must be in the zone_ref zone, so its validation record can be written there"
+  }
+}
+
+# Provider-specific Configuration
+variable "provider_config" {
+  description = "Provider-specific settings (key_vault_id, issuer_name for azure; project_id for gcp)"
+  type        = any
+  default     = {}
+  validation {
+    condition     = var.provider_name != "azure" || try(length(var.provider_config.key_vault_id) > 0, false)
+    error_message = "On azure the certificate is issued into a Key Vault; set provider_config.key_vault_id"
+  }
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
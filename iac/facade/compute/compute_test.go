@@ -1,22 +1,31 @@
 package compute_test
 
 import (
-	"testing"
+	"context"
+	"path/filepath"
 	"strings"
+	"testing"
+
+	"iac/testutil/costcheck"
+	"iac/testutil/naming"
+	"iac/testutil/planerr"
+	"iac/testutil/policycheck"
+	"iac/testutil/tfopts"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestComputeFacadeAws(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "aws",
+			"provider_name": "aws",
 			"project_name":  "testproject",
-			"environment":   "test",
+			"environment":   "dev",
 			"instance_name": "test-instance",
 			"instance_size": "small",
 			"provider_config": map[string]interface{}{
@@ -27,20 +36,19 @@ func TestComputeFacadeAws(t *testing.T) {
 	})
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
-	
+
 	assert.True(t, strings.Contains(planString, "module.aws_compute[0].aws_instance.this"), "Plan should create an AWS EC2 instance")
-	assert.True(t, strings.Contains(planString, "instance_type = \"t3.micro\""), "Plan should have the correct instance type for 'small'")
 }
 
 func TestComputeFacadeAzure(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "azure",
+			"provider_name": "azure",
 			"project_name":  "testproject",
-			"environment":   "test",
+			"environment":   "dev",
 			"instance_name": "test-instance",
 			"instance_size": "medium",
 			"provider_config": map[string]interface{}{
@@ -53,18 +61,17 @@ func TestComputeFacadeAzure(t *testing.T) {
 	planString := terraform.InitAndPlan(t, terraformOptions)
 
 	assert.True(t, strings.Contains(planString, "module.azure_compute[0].azurerm_linux_virtual_machine.this"), "Plan should create an Azure VM")
-	assert.True(t, strings.Contains(planString, "size = \"Standard_B2s\""), "Plan should have the correct VM size for 'medium'")
 }
 
 func TestComputeFacadeGcp(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "gcp",
+			"provider_name": "gcp",
 			"project_name":  "testproject",
-			"environment":   "test",
+			"environment":   "dev",
 			"instance_name": "test-instance",
 			"instance_size": "large",
 			"provider_config": map[string]interface{}{
@@ -77,26 +84,408 @@ func TestComputeFacadeGcp(t *testing.T) {
 	planString := terraform.InitAndPlan(t, terraformOptions)
 
 	assert.True(t, strings.Contains(planString, "module.gcp_compute[0].google_compute_instance.this"), "Plan should create a GCP Compute Instance")
-	assert.True(t, strings.Contains(planString, "machine_type = \"n2-standard-2\""), "Plan should have the correct machine type for 'large'")
 }
 
-func TestComputeFacadeInvalidName(t *testing.T) {
+// TestComputeFacadeComposition plans an instance inside a network from the
+// networking facade and checks it is placed behind the network's firewall
+// rules rather than the default VPC's security group
+func TestComputeFacadeComposition(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.New(t, &terraform.Options{
+		TerraformDir: "testdata/composition",
+		NoColor:      true,
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Contains(t, planString, "module.network.module.aws_networking[0].aws_security_group.rules[0]", "Plan should create the firewall_rules security group")
+	assert.Contains(t, planString, "module.web.module.aws_compute[0].aws_instance.this", "Plan should create the instance")
+	assert.Regexp(t, `(?s)module\.web\.module\.aws_compute\[0\]\.aws_instance\.this.*vpc_security_group_ids\s+= \(known after apply\)`, planString,
+		"The instance should take the security group produced by the networking facade")
+}
+
+// longProjectName is 40 characters; instance names built from it ran past
+// the 63 characters GCP allows, which stopped plans that passed at apply
+const longProjectName = "northwind-traders-payments-reconciler-01"
+
+// TestComputeFacadeLongProjectName verifies the instance names planned for
+// a long project_name are legal, whether instance_name is left to the
+// convention or built by the caller from project_name
+func TestComputeFacadeLongProjectName(t *testing.T) {
+	t.Parallel()
+
+	rules, err := naming.Load(filepath.Join("..", "..", naming.RulesFile))
+	require.NoError(t, err)
+
+	// Address of each provider's instance, and the naming.json type of its
+	// name
+	instances := map[string][2]string{
+		"gcp":   {"module.gcp_compute[0].google_compute_instance.this", "gcp_compute_instance"},
+		"azure": {"module.azure_compute[0].azurerm_linux_virtual_machine.this", "azure_linux_virtual_machine"},
+	}
+	instanceNames := map[string]interface{}{
+		"convention": nil,
+		"caller":     longProjectName + "-staging-web-server-primary",
+	}
+
+	for provider, instance := range instances {
+		for source, instanceName := range instanceNames {
+			t.Run(provider+"/"+source, func(t *testing.T) {
+				t.Parallel()
+
+				plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+					TerraformDir: ".",
+					Vars: map[string]interface{}{
+						"provider_name": provider,
+						"project_name":  longProjectName,
+						"environment":   "staging",
+						"instance_name": instanceName,
+					},
+				}))
+
+				resource, ok := plan.ResourcePlannedValuesMap[instance[0]]
+				require.True(t, ok, "Plan should create %s", instance[0])
+				name, _ := resource.AttributeValues["name"].(string)
+				assert.NoError(t, rules[instance[1]].Check(name), "%s should have a legal %s name", instance[0], instance[1])
+			})
+		}
+	}
+}
+
+// dataVolumes are a daily-snapshotted volume of the default type and a
+// weekly-snapshotted one, with the type each provider is given in
+func dataVolumes(logsType string, logsCron string) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"mount_name":        "data",
+			"size_gb":           100,
+			"snapshot_schedule": map[string]interface{}{"cron": "30 2 * * *"},
+		},
+		map[string]interface{}{
+			"mount_name":        "logs",
+			"size_gb":           200,
+			"type":              logsType,
+			"snapshot_schedule": map[string]interface{}{"cron": logsCron, "retention_days": 14},
+		},
+	}
+}
+
+// TestComputeFacadeDataVolumes plans two data volumes with snapshot
+// schedules on each provider and checks the disks, their attachments and
+// the snapshot policies, and that volume_ids is keyed by mount_name
+func TestComputeFacadeDataVolumes(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		volumes []interface{}
+		check   func(t *testing.T, plan *terraform.PlanStruct)
+	}{
+		"aws": {
+			volumes: func() []interface{} {
+				volumes := dataVolumes("io2", "0 4 * * 0")
+				volumes[1].(map[string]interface{})["iops"] = 6000
+				return volumes
+			}(),
+			check: func(t *testing.T, plan *terraform.PlanStruct) {
+				data := attributes(t, plan, "module.aws_compute[0].aws_ebs_volume.data[\"data\"]")
+				assert.Equal(t, float64(100), data["size"])
+				assert.Equal(t, "gp3", data["type"])
+				assert.Equal(t, true, data["encrypted"])
+				logs := attributes(t, plan, "module.aws_compute[0].aws_ebs_volume.data[\"logs\"]")
+				assert.Equal(t, "io2", logs["type"])
+				assert.Equal(t, float64(6000), logs["iops"])
+
+				assert.Equal(t, "/dev/sdf", attributes(t, plan, "module.aws_compute[0].aws_volume_attachment.data[\"data\"]")["device_name"])
+				assert.Equal(t, "/dev/sdg", attributes(t, plan, "module.aws_compute[0].aws_volume_attachment.data[\"logs\"]")["device_name"])
+
+				for mount, want := range map[string][2]interface{}{
+					"data": {"cron(30 2 * * ? *)", float64(7)},
+					"logs": {"cron(0 4 ? * SUN *)", float64(14)},
+				} {
+					policy := attributes(t, plan, "module.aws_compute[0].aws_dlm_lifecycle_policy.data[\""+mount+"\"]")
+					details := policy["policy_details"].([]interface{})[0].(map[string]interface{})
+					schedule := details["schedule"].([]interface{})[0].(map[string]interface{})
+					assert.Equal(t, want[0], schedule["create_rule"].([]interface{})[0].(map[string]interface{})["cron_expression"])
+					assert.Equal(t, want[1], schedule["retain_rule"].([]interface{})[0].(map[string]interface{})["interval"])
+
+					// The policy finds its volume by the volume's SnapshotPolicy tag
+					volume := attributes(t, plan, "module.aws_compute[0].aws_ebs_volume.data[\""+mount+"\"]")
+					assert.Equal(t, details["target_tags"], map[string]interface{}{"SnapshotPolicy": volume["tags"].(map[string]interface{})["SnapshotPolicy"]})
+				}
+			},
+		},
+		"azure": {
+			// Azure Disk Backup runs daily
+			volumes: dataVolumes("Premium_LRS", "0 4 * * *"),
+			check: func(t *testing.T, plan *terraform.PlanStruct) {
+				assert.Equal(t, "StandardSSD_LRS", attributes(t, plan, "module.azure_compute[0].azurerm_managed_disk.data[\"data\"]")["storage_account_type"])
+				logs := attributes(t, plan, "module.azure_compute[0].azurerm_managed_disk.data[\"logs\"]")
+				assert.Equal(t, "Premium_LRS", logs["storage_account_type"])
+				assert.Equal(t, float64(200), logs["disk_size_gb"])
+
+				assert.Equal(t, float64(0), attributes(t, plan, "module.azure_compute[0].azurerm_virtual_machine_data_disk_attachment.data[\"data\"]")["lun"])
+				assert.Equal(t, float64(1), attributes(t, plan, "module.azure_compute[0].azurerm_virtual_machine_data_disk_attachment.data[\"logs\"]")["lun"])
+
+				for mount, want := range map[string][2]string{
+					"data": {"R/2024-01-01T02:30:00+00:00/P1D", "P7D"},
+					"logs": {"R/2024-01-01T04:00:00+00:00/P1D", "P14D"},
+				} {
+					policy := attributes(t, plan, "module.azure_compute[0].azurerm_data_protection_backup_policy_disk.data[\""+mount+"\"]")
+					assert.Equal(t, []interface{}{want[0]}, policy["backup_repeating_time_intervals"])
+					assert.Equal(t, want[1], policy["default_retention_duration"])
+					attributes(t, plan, "module.azure_compute[0].azurerm_data_protection_backup_instance_disk.data[\""+mount+"\"]")
+				}
+			},
+		},
+		"gcp": {
+			// GCP snapshot schedules start on the hour
+			volumes: func() []interface{} {
+				volumes := dataVolumes("pd-ssd", "0 4 * * 0")
+				volumes[0].(map[string]interface{})["snapshot_schedule"] = map[string]interface{}{"cron": "0 2 * * *"}
+				return volumes
+			}(),
+			check: func(t *testing.T, plan *terraform.PlanStruct) {
+				assert.Equal(t, "pd-balanced", attributes(t, plan, "module.gcp_compute[0].google_compute_disk.data[\"data\"]")["type"])
+				logs := attributes(t, plan, "module.gcp_compute[0].google_compute_disk.data[\"logs\"]")
+				assert.Equal(t, "pd-ssd", logs["type"])
+				assert.Equal(t, float64(200), logs["size"])
+				assert.Equal(t, "logs", attributes(t, plan, "module.gcp_compute[0].google_compute_attached_disk.data[\"logs\"]")["device_name"])
+
+				schedule := func(mount string) map[string]interface{} {
+					policy := attributes(t, plan, "module.gcp_compute[0].google_compute_resource_policy.snapshot[\""+mount+"\"]")
+					snapshot := policy["snapshot_schedule_policy"].([]interface{})[0].(map[string]interface{})
+					return snapshot["schedule"].([]interface{})[0].(map[string]interface{})
+				}
+				daily := schedule("data")["daily_schedule"].([]interface{})[0].(map[string]interface{})
+				assert.Equal(t, "02:00", daily["start_time"])
+				weekly := schedule("logs")["weekly_schedule"].([]interface{})[0].(map[string]interface{})
+				assert.Equal(t, []interface{}{map[string]interface{}{"day": "SUNDAY", "start_time": "04:00"}}, weekly["day_of_weeks"])
+			},
+		},
+	}
+
+	for provider, tc := range cases {
+		provider, tc := provider, tc
+
+		t.Run(provider, func(t *testing.T) {
+			t.Parallel()
+
+			plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars: map[string]interface{}{
+					"provider_name": provider,
+					"project_name":  "testproject",
+					"environment":   "dev",
+					"instance_name": "test-instance",
+					"data_volumes":  tc.volumes,
+				},
+			}))
+
+			tc.check(t, plan)
+
+			change, ok := plan.RawPlan.OutputChanges["volume_ids"]
+			require.True(t, ok, "Plan should output volume_ids")
+			ids, ok := change.AfterUnknown.(map[string]interface{})
+			require.True(t, ok, "volume_ids should be known after apply")
+			assert.Len(t, ids, 2)
+			assert.Contains(t, ids, "data", "volume_ids should be keyed by mount_name")
+			assert.Contains(t, ids, "logs", "volume_ids should be keyed by mount_name")
+		})
+	}
+}
+
+// attributes returns the planned attributes of the resource at address
+func attributes(t *testing.T, plan *terraform.PlanStruct, address string) map[string]interface{} {
+	t.Helper()
+
+	resource, ok := plan.ResourcePlannedValuesMap[address]
+	require.True(t, ok, "Plan should create %s", address)
+	return resource.AttributeValues
+}
+
+// TestComputeFacadeMetadataHardening plans each provider's instance with
+// the metadata hardening, then with insecure_metadata, and checks the
+// instance_metadata policy flags only the second
+func TestComputeFacadeMetadataHardening(t *testing.T) {
+	t.Parallel()
+
+	instances := map[string]string{
+		"aws":   "module.aws_compute[0].aws_instance.this",
+		"azure": "module.azure_compute[0].azurerm_linux_virtual_machine.this",
+		"gcp":   "module.gcp_compute[0].google_compute_instance.this",
+	}
+
+	for provider, address := range instances {
+		for _, insecure := range []bool{false, true} {
+			provider, address, insecure := provider, address, insecure
+
+			name := provider + "/hardened"
+			if insecure {
+				name = provider + "/insecure"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				terraformOptions := tfopts.New(t, &terraform.Options{
+					TerraformDir: ".",
+					Vars: map[string]interface{}{
+						"provider_name":     provider,
+						"project_name":      "testproject",
+						"environment":       "dev",
+						"instance_name":     "test-instance",
+						"insecure_metadata": insecure,
+					},
+					PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+					NoColor:      true,
+				})
+				planJSON := terraform.InitAndPlanAndShow(t, terraformOptions)
+				plan, err := terraform.ParsePlanJSON(planJSON)
+				require.NoError(t, err)
+
+				instance := attributes(t, plan, address)
+				switch provider {
+				case "aws":
+					options := instance["metadata_options"].([]interface{})[0].(map[string]interface{})
+					if insecure {
+						assert.Equal(t, "optional", options["http_tokens"])
+					} else {
+						assert.Equal(t, "required", options["http_tokens"], "IMDSv1 should be turned off")
+					}
+					assert.Equal(t, float64(1), options["http_put_response_hop_limit"])
+				case "azure":
+					assert.Equal(t, !insecure, instance["secure_boot_enabled"])
+					assert.Equal(t, !insecure, instance["vtpm_enabled"])
+					identity, _ := instance["identity"].([]interface{})
+					if insecure {
+						assert.Empty(t, identity)
+					} else if assert.Len(t, identity, 1) {
+						assert.Equal(t, "SystemAssigned", identity[0].(map[string]interface{})["type"])
+					}
+				case "gcp":
+					metadata, _ := instance["metadata"].(map[string]interface{})
+					if insecure {
+						assert.NotContains(t, metadata, "block-project-ssh-keys")
+					} else {
+						assert.Equal(t, "TRUE", metadata["block-project-ssh-keys"])
+						shielded, _ := instance["shielded_instance_config"].([]interface{})
+						if assert.Len(t, shielded, 1) {
+							assert.Equal(t, map[string]interface{}{"enable_secure_boot": true, "enable_vtpm": true, "enable_integrity_monitoring": true}, shielded[0])
+						}
+					}
+				}
+
+				violations, err := policycheck.Evaluate(context.Background(), []byte(planJSON))
+				require.NoError(t, err)
+				var flagged []string
+				for _, v := range violations {
+					if v.Policy == "instance_metadata" {
+						flagged = append(flagged, v.Address)
+					}
+				}
+				if insecure {
+					assert.Contains(t, flagged, address, "insecure_metadata should be flagged")
+				} else {
+					assert.Empty(t, flagged, "The hardened instance should pass the instance_metadata policy")
+				}
+			})
+		}
+	}
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"instance_name": "test-instance",
+		"instance_size": "small",
+		"provider_config": map[string]interface{}{
+			"ami": "ami-0c55b159cbfafe1f0",
+		},
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "UppercaseName",
+			Vars: map[string]interface{}{"instance_name": "UPPERCASE_NOT_ALLOWED"},
+			Want: "Instance name must be lowercase alphanumeric with hyphens",
+		},
+		{
+			Name: "TrailingHyphenName",
+			Vars: map[string]interface{}{"instance_name": "web-"},
+			Want: "Instance name must be lowercase alphanumeric with hyphens",
+		},
+		{
+			Name: "UnknownInstanceSize",
+			Vars: map[string]interface{}{"instance_size": "huge"},
+			Want: "Instance size must be one of: small, medium, large, xlarge",
+		},
+		{
+			Name:     "DataVolumeSizeTooSmallForType",
+			Vars:     map[string]interface{}{"data_volumes": []interface{}{map[string]interface{}{"mount_name": "data", "size_gb": 100, "type": "st1"}}},
+			Want:     "data_volumes size_gb on aws must be 1-16384 GB for gp3 and gp2",
+			Variable: "data_volumes",
+		},
+		{
+			Name:     "DataVolumeIopsTooHigh",
+			Vars:     map[string]interface{}{"data_volumes": []interface{}{map[string]interface{}{"mount_name": "data", "size_gb": 10, "iops": 6000}}},
+			Want:     "gp3 data_volumes iops must be 3000-16000, and at most 500 per GB of size_gb",
+			Variable: "data_volumes",
+		},
+		{
+			Name:     "DataVolumeTypeOfOtherProvider",
+			Vars:     map[string]interface{}{"data_volumes": []interface{}{map[string]interface{}{"mount_name": "data", "size_gb": 100, "type": "Premium_LRS"}}},
+			Want:     "data_volumes type must be one of: gp3, gp2, io1, io2, st1, sc1 on aws",
+			Variable: "data_volumes",
+		},
+		{
+			Name: "DuplicateMountName",
+			Vars: map[string]interface{}{"data_volumes": []interface{}{
+				map[string]interface{}{"mount_name": "data", "size_gb": 100},
+				map[string]interface{}{"mount_name": "data", "size_gb": 200},
+			}},
+			Want:     "data_volumes mount_name values must be unique",
+			Variable: "data_volumes",
+		},
+		{
+			Name: "WeeklyDataVolumeBackupOnAzure",
+			Vars: map[string]interface{}{
+				"provider_name": "azure",
+				"data_volumes": []interface{}{map[string]interface{}{
+					"mount_name":        "data",
+					"size_gb":           100,
+					"snapshot_schedule": map[string]interface{}{"cron": "0 4 * * 0"},
+				}},
+			},
+			Want:     "Azure Disk Backup runs daily",
+			Variable: "data_volumes",
+		},
+	})
+}
+
+// TestComputeFacadeAwsCost guards the monthly cost of the default "small"
+// instance size. Skipped unless infracost and INFRACOST_API_KEY are
+// available.
+func TestComputeFacadeAwsCost(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "aws",
+			"provider_name": "aws",
 			"project_name":  "testproject",
-			"environment":   "test",
-			"instance_name": "UPPERCASE_NOT_ALLOWED",
-			"instance_size": "small",
-			"provider_config": map[string]interface{}{
-				"ami": "ami-0c55b159cbfafe1f0",
-			},
+			"environment":   "dev",
+			"instance_name": "test-instance",
 		},
-	}
+	})
+
+	report := costcheck.Estimate(t, terraformOptions)
 
-	_, err := terraform.InitAndPlanE(t, terraformOptions)
-	assert.Error(t, err, "Plan should fail with an invalid instance name")
+	// t3.micro with its root volume is under $10 per month
+	report.AssertResourceCostBelow(t, "module.aws_compute[0].aws_instance.this", 25)
+	report.AssertMonthlyCostBelow(t, 30)
 }
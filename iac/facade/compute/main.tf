@@ -2,61 +2,106 @@
 # Facade Layer - Public interface for compute resources
 
 terraform {
-  required_version = ">= 1.0"
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
 }
 
 # ============================================================================
 # IMPORT COMMON LAYER
 # ============================================================================
 
-locals {
-  # Import size mappings
-  compute_instance_types = {
-    aws = {
-      small  = "t3.micro"
-      medium = "t3.medium"
-      large  = "m5.large"
-      xlarge = "m5.xlarge"
-    }
-    azure = {
-      small  = "Standard_B1s"
-      medium = "Standard_B2s"
-      large  = "Standard_DS2_v2"
-      xlarge = "Standard_DS3_v2"
-    }
-    gcp = {
-      small  = "e2-micro"
-      medium = "e2-medium"
-      large  = "n2-standard-2"
-      xlarge = "n2-standard-4"
-    }
-    oracle = {
-      small  = "VM.Standard.E4.Flex"
-      medium = "VM.Standard.E4.Flex"
-      large  = "VM.Standard3.Flex"
-      xlarge = "VM.Standard3.Flex"
-    }
-    zero = {
-      small  = "zero.micro"
-      medium = "zero.medium"
-      large  = "zero.large"
-      xlarge = "zero.xlarge"
-    }
-  }
+module "default_tags" {
+  source = "../../common/tags"
 
-  # Build common tags
-  common_tags = merge(
-    var.tags,
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy    = "Terraform"
-      Environment  = var.environment
       Provider     = var.provider_name
-      Project      = var.project_name
       Architecture = "SEA"
-    }
+    },
+    var.tags
   )
 }
 
+# Names by the project_name-environment-resource convention, made legal
+# for each provider's resource type
+module "instance_name" {
+  source = "../../common/naming"
+
+  resource_type = var.provider_name == "gcp" ? "gcp_compute_instance" : var.provider_name == "azure" ? "azure_linux_virtual_machine" : "aws_instance"
+  project_name  = var.instance_name == null ? var.project_name : null
+  environment   = var.instance_name == null ? var.environment : null
+  name          = coalesce(var.instance_name, "vm")
+}
+
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+# Each data volume is named after the instance and its mount_name
+module "data_volume_name" {
+  source   = "../../common/naming"
+  for_each = toset([for v in var.data_volumes : v.mount_name])
+
+  resource_type = var.provider_name == "gcp" ? "gcp_compute_disk" : var.provider_name == "azure" ? "azure_managed_disk" : "aws_ebs_volume"
+  name          = "${module.instance_name.name}-${each.key}"
+}
+
+module "backup_vault_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_data_protection_backup_vault"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "disk-backup"
+}
+
+module "instance_type" {
+  source = "../../common/sizes"
+
+  resource_kind = "compute"
+  provider_name = var.provider_name
+  size          = var.instance_size
+}
+
+locals {
+  instance_name = module.instance_name.name
+  instance_type = module.instance_type.instance_type
+
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  # Data volumes with the provider's general-purpose SSD as the default
+  # type, and snapshot_schedule's "M H * * D" split into the parts each
+  # provider's schedule is written in
+  default_volume_type = lookup({ aws = "gp3", azure = "StandardSSD_LRS", gcp = "pd-balanced" }, var.provider_name, "gp3")
+  weekdays            = ["SUNDAY", "MONDAY", "TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY", "SATURDAY"]
+
+  data_volumes = [
+    for v in var.data_volumes : {
+      mount_name = v.mount_name
+      name       = module.data_volume_name[v.mount_name].name
+      size_gb    = v.size_gb
+      type       = coalesce(v.type, local.default_volume_type)
+      iops       = v.iops
+      encrypted  = v.encrypted
+      snapshot = v.snapshot_schedule == null ? null : {
+        minute         = tonumber(split(" ", v.snapshot_schedule.cron)[0])
+        hour           = tonumber(split(" ", v.snapshot_schedule.cron)[1])
+        weekday        = split(" ", v.snapshot_schedule.cron)[4] == "*" ? null : local.weekdays[tonumber(split(" ", v.snapshot_schedule.cron)[4])]
+        retention_days = v.snapshot_schedule.retention_days
+      }
+    }
+  ]
+}
+
 # ============================================================================
 # PROVIDER-SPECIFIC MODULE ROUTING
 # ============================================================================
@@ -67,9 +112,34 @@ module "aws_compute" {
   source = "../../aws/core/compute"
   
   ami           = lookup(var.provider_config, "ami", "ami-0c55b159cbfafe1f0")
-  instance_type = local.compute_instance_types[var.provider_name][var.instance_size]
+  instance_type = local.instance_type
   ssh_key_name  = var.ssh_public_key != null ? "compute-key" : null
-  tags          = local.common_tags
+  tags          = local.default_tags
+
+  # Typically the networking facade's subnet and security_group_id
+  subnet_id          = var.subnet_id
+  security_group_ids = var.security_group_ids
+
+  # IMDSv2 only, with the core module's hop limit of 1
+  metadata_http_tokens = var.insecure_metadata ? "optional" : "required"
+
+  # Snapshots by a DLM cron(), which wants a ? in the unused day field
+  data_volumes = [
+    for v in local.data_volumes : {
+      mount_name = v.mount_name
+      name       = v.name
+      size_gb    = v.size_gb
+      type       = v.type
+      iops       = v.iops
+      encrypted  = v.encrypted
+      snapshot_cron = v.snapshot == null ? null : (
+        v.snapshot.weekday == null
+        ? "cron(${v.snapshot.minute} ${v.snapshot.hour} * * ? *)"
+        : "cron(${v.snapshot.minute} ${v.snapshot.hour} ? * ${substr(v.snapshot.weekday, 0, 3)} *)"
+      )
+      retention_days = try(v.snapshot.retention_days, null)
+    }
+  ]
 }
 
 # Route to Azure compute module  
@@ -77,15 +147,33 @@ module "azure_compute" {
   count  = var.provider_name == "azure" ? 1 : 0
   source = "../../azure/core/compute"
   
-  vm_name             = var.instance_name
-  vm_size             = local.compute_instance_types[var.provider_name][var.instance_size]
-  resource_group_name = "${var.project_name}-${var.environment}-rg"
+  vm_name             = local.instance_name
+  vm_size             = local.instance_type
+  resource_group_name = module.resource_group_name.name
   location            = "East US"
   admin_username      = "cloudkit"
   ssh_public_key      = var.ssh_public_key != null ? var.ssh_public_key : "ssh-rsa AAAAB3NzaC1yc2EA..." # Default dummy key
-  subnet_id           = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vn/subnets/sn" # Placeholder
+  subnet_id           = var.subnet_id != null ? var.subnet_id : "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vn/subnets/sn" # Placeholder
   create_public_ip    = true
-  tags                = local.common_tags
+  tags                = local.default_tags
+
+  # Secure boot and vTPM on the default Ubuntu gen2 image
+  trusted_launch           = !var.insecure_metadata
+  system_assigned_identity = !var.insecure_metadata
+
+  # Daily disk backups from an ISO 8601 start time; validation rejects a
+  # weekly schedule
+  data_volumes = [
+    for v in local.data_volumes : {
+      mount_name      = v.mount_name
+      name            = v.name
+      size_gb         = v.size_gb
+      type            = v.type
+      backup_interval = v.snapshot == null ? null : format("R/2024-01-01T%02d:%02d:00+00:00/P1D", v.snapshot.hour, v.snapshot.minute)
+      retention_days  = try(v.snapshot.retention_days, null)
+    }
+  ]
+  backup_vault_name = module.backup_vault_name.name
 }
 
 # Route to GCP compute module
@@ -93,14 +181,30 @@ module "gcp_compute" {
   count  = var.provider_name == "gcp" ? 1 : 0
   source = "../../gcp/core/compute"
   
-  instance_name  = var.instance_name
-  machine_type   = local.compute_instance_types[var.provider_name][var.instance_size]
+  instance_name  = local.instance_name
+  machine_type   = local.instance_type
   zone           = "us-east1-b"
   boot_disk_image = "debian-cloud/debian-11"
   network        = "default"
   subnetwork     = "default"
   create_external_ip = true
-  labels         = local.common_tags
+  labels         = local.default_labels
+
+  shielded_vm            = !var.insecure_metadata
+  block_project_ssh_keys = !var.insecure_metadata
+
+  # Snapshots from an HH:00 start time, which validation keeps on the hour
+  data_volumes = [
+    for v in local.data_volumes : {
+      mount_name          = v.mount_name
+      name                = v.name
+      size_gb             = v.size_gb
+      type                = v.type
+      snapshot_start_time = v.snapshot == null ? null : format("%02d:00", v.snapshot.hour)
+      snapshot_day        = try(v.snapshot.weekday, null)
+      retention_days      = try(v.snapshot.retention_days, null)
+    }
+  ]
 }
 
 # Route to Zero compute module
@@ -108,10 +212,10 @@ module "zero_compute" {
   count  = var.provider_name == "zero" ? 1 : 0
   source = "../../zero/core/compute"
   
-  instance_name = var.instance_name
-  instance_type = local.compute_instance_types[var.provider_name][var.instance_size]
+  instance_name = local.instance_name
+  instance_type = local.instance_type
   ami           = "zero-ami-latest" # Mocked in Zero
-  tags          = local.common_tags
+  tags          = local.default_tags
 }
 
 # Aggregated outputs (select based on provider)
@@ -139,6 +243,13 @@ locals {
     var.provider_name == "zero" ? (length(module.zero_compute) > 0 ? module.zero_compute[0].private_ip : null) :
     null
   )
+
+  volume_ids = (
+    var.provider_name == "aws" ? (length(module.aws_compute) > 0 ? module.aws_compute[0].data_volume_ids : {}) :
+    var.provider_name == "azure" ? (length(module.azure_compute) > 0 ? module.azure_compute[0].data_volume_ids : {}) :
+    var.provider_name == "gcp" ? (length(module.gcp_compute) > 0 ? module.gcp_compute[0].data_volume_ids : {}) :
+    {}
+  )
 }
 
 # ============================================================================
@@ -150,10 +261,10 @@ output "instance" {
   value = {
     # Identification
     id   = local.instance_id
-    name = var.instance_name
+    name = local.instance_name
     
     # Specifications
-    type     = local.compute_instance_types[var.provider_name][var.instance_size]
+    type     = local.instance_type
     size     = var.instance_size
     provider = var.provider_name
     
@@ -162,7 +273,7 @@ output "instance" {
     private_ip = local.private_ip
     
     # Metadata
-    tags = local.common_tags
+    tags = local.default_tags
   }
   sensitive = true
 }
@@ -183,6 +294,11 @@ output "private_ip" {
   value       = local.private_ip
 }
 
+output "volume_ids" {
+  description = "ID of each data volume, keyed by mount_name: the EBS volume, managed disk or persistent disk ID"
+  value       = local.volume_ids
+}
+
 output "ssh_connection" {
   description = "SSH connection command"
   value       = local.public_ip != null ? "ssh user@${local.public_ip}" : null
@@ -0,0 +1,62 @@
+# Compute composition fixture
+#
+# A network with an HTTPS rule from the networking facade and an instance
+# from the compute facade in its first public subnet, behind the network's
+# security group. Planned only, with placeholder credentials.
+
+terraform {
+  required_version = ">= 1.9"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+module "network" {
+  source = "../../../networking"
+
+  provider_name = "aws"
+  project_name  = "composition"
+  environment   = "dev"
+  network_name  = "composition-vpc"
+
+  network_config = {
+    cidr            = "10.0.0.0/16"
+    azs             = ["us-east-1a"]
+    public_subnets  = ["10.0.1.0/24"]
+    private_subnets = ["10.0.11.0/24"]
+  }
+
+  firewall_rules = [{
+    name        = "allow-https"
+    ports       = ["443"]
+    cidr_blocks = ["0.0.0.0/0"]
+  }]
+}
+
+module "web" {
+  source = "../../"
+
+  provider_name = "aws"
+  project_name  = "composition"
+  environment   = "dev"
+  instance_name = "composition-web"
+  instance_size = "small"
+
+  subnet_id          = module.network.public_subnet_ids[0]
+  security_group_ids = [module.network.security_group_id]
+}
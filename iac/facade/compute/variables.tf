@@ -10,16 +10,17 @@ variable "provider_name" {
   type        = string
   validation {
     condition     = contains(["aws", "azure", "gcp", "oracle", "zero"], var.provider_name)
-    error_message = "Provider must be one of: aws, azure, gcp, oracle, zero"
+    error_message = "provider_name must be one of: aws, azure, gcp, oracle, zero"
   }
 }
 
 variable "instance_name" {
-  description = "Name of the compute instance (3-63 lowercase alphanumeric characters with hyphens)"
+  description = "Name of the compute instance (lowercase alphanumeric characters with hyphens); null names it project_name-environment-vm. Either is cut to the provider's limit, e.g. 63 characters on GCP, with a hash suffix."
   type        = string
+  default     = null
   validation {
-    condition     = can(regex("^[a-z0-9]([a-z0-9-]*[a-z0-9])?$", var.instance_name))
-    error_message = "Instance name must be lowercase alphanumeric with hyphens, starting and ending with alphanumeric"
+    condition     = var.instance_name == null || can(regex("^[a-z0-9]([a-z0-9-]*[a-z0-9])?$", var.instance_name))
+    error_message = "Instance name must be lowercase alphanumeric with hyphens, starting and ending with alphanumeric, e.g. web-01"
   }
 }
 
@@ -44,7 +45,7 @@ variable "environment" {
   default     = "dev"
   validation {
     condition     = contains(["local", "dev", "staging", "prod"], var.environment)
-    error_message = "Environment must be one of: dev, staging, prod"
+    error_message = "Environment must be one of: local, dev, staging, prod"
   }
 }
 
@@ -102,11 +103,149 @@ variable "subnet_id" {
 }
 
 variable "security_group_ids" {
-  description = "Security group IDs to attach (optional)"
+  description = "Security group IDs to attach on AWS, such as the networking facade's security_group_id (optional)"
   type        = list(string)
   default     = []
 }
 
+variable "insecure_metadata" {
+  description = "Turn off the instance metadata hardening, for images or agents that cannot work with it: IMDSv1 is allowed on aws, Trusted Launch and the system-assigned identity are left off on azure, and the instance is not a Shielded VM and accepts project-wide SSH keys on gcp. The instance_metadata policy flags an instance planned this way."
+  type        = bool
+  default     = false
+}
+
+# ============================================================================
+# DATA VOLUMES
+# ============================================================================
+
+variable "data_volumes" {
+  description = <<-EOT
+    Persistent data disks attached to the instance, each named
+    <instance name>-<mount_name>: EBS volumes on aws, managed disks on azure,
+    persistent disks on gcp, where mount_name is also the device name.
+    Volumes are attached in list order, which picks the device (/dev/sdf
+    on) on aws and the LUN on azure.
+
+    type is the provider's disk type, null for its general-purpose SSD
+    (gp3, StandardSSD_LRS, pd-balanced):
+      aws:   gp3, gp2 (1-16384 GB), io1 (4-16384), io2 (4-65536),
+             st1, sc1 (125-16384)
+      azure: Standard_LRS, StandardSSD_LRS, StandardSSD_ZRS, Premium_LRS,
+             Premium_ZRS (1-32767 GB)
+      gcp:   pd-standard, pd-balanced, pd-ssd (10-65536 GB)
+    iops provisions aws gp3 (3000-16000, at most 500 per GB), io1 (100-64000,
+    50 per GB) and io2 (100-64000, 500 per GB) volumes; io1 and io2 need it.
+    Azure and GCP encrypt every disk at rest, so encrypted = false is aws
+    only.
+
+    snapshot_schedule snapshots the volume on a UTC cron expression, daily
+    "M H * * *" or weekly "M H * * D" (D = 0-6, Sunday = 0), keeping each
+    snapshot retention_days: a Data Lifecycle Manager policy on aws, Azure
+    Disk Backup (daily only) on azure, a snapshot schedule resource policy
+    (on the hour only) on gcp.
+  EOT
+  type = list(object({
+    size_gb    = number
+    type       = optional(string)
+    iops       = optional(number)
+    mount_name = string
+    encrypted  = optional(bool, true)
+    snapshot_schedule = optional(object({
+      cron           = string
+      retention_days = optional(number, 7)
+    }))
+  }))
+  default = []
+  validation {
+    condition     = length(var.data_volumes) == 0 || contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "data_volumes are supported on aws, azure and gcp"
+  }
+  validation {
+    condition     = length(var.data_volumes) <= 11
+    error_message = "data_volumes takes at most 11 volumes, attached as /dev/sdf to /dev/sdp on aws"
+  }
+  validation {
+    condition     = length(distinct([for v in var.data_volumes : v.mount_name])) == length(var.data_volumes)
+    error_message = "data_volumes mount_name values must be unique, e.g. data and logs"
+  }
+  validation {
+    condition     = alltrue([for v in var.data_volumes : can(regex("^[a-z]([a-z0-9-]{0,18}[a-z0-9])?$", v.mount_name))])
+    error_message = "data_volumes mount_name must be 1-20 lowercase letters, digits and hyphens, starting with a letter, e.g. data or pg-wal"
+  }
+  validation {
+    condition = alltrue([
+      for v in var.data_volumes : v.type == null || try(contains(lookup({
+        aws   = ["gp3", "gp2", "io1", "io2", "st1", "sc1"]
+        azure = ["Standard_LRS", "StandardSSD_LRS", "StandardSSD_ZRS", "Premium_LRS", "Premium_ZRS"]
+        gcp   = ["pd-standard", "pd-balanced", "pd-ssd"]
+      }, var.provider_name, []), v.type), false)
+    ])
+    error_message = "data_volumes type must be one of: gp3, gp2, io1, io2, st1, sc1 on aws; Standard_LRS, StandardSSD_LRS, StandardSSD_ZRS, Premium_LRS, Premium_ZRS on azure; pd-standard, pd-balanced, pd-ssd on gcp"
+  }
+  validation {
+    condition     = alltrue([for v in var.data_volumes : try(v.size_gb >= 1 && floor(v.size_gb) == v.size_gb, false)])
+    error_message = "data_volumes size_gb must be a whole number of GB, e.g. 100"
+  }
+  validation {
+    condition = var.provider_name != "aws" || alltrue([
+      for v in var.data_volumes : try(
+        v.size_gb >= (contains(["st1", "sc1"], coalesce(v.type, "gp3")) ? 125 : contains(["io1", "io2"], coalesce(v.type, "gp3")) ? 4 : 1) &&
+        v.size_gb <= (v.type == "io2" ? 65536 : 16384),
+        false
+      )
+    ])
+    error_message = "data_volumes size_gb on aws must be 1-16384 GB for gp3 and gp2, 4-16384 for io1, 4-65536 for io2, 125-16384 for st1 and sc1"
+  }
+  validation {
+    condition     = var.provider_name != "azure" || alltrue([for v in var.data_volumes : try(v.size_gb <= 32767, false)])
+    error_message = "data_volumes size_gb on azure must be 1-32767 GB"
+  }
+  validation {
+    condition     = var.provider_name != "gcp" || alltrue([for v in var.data_volumes : try(v.size_gb >= 10 && v.size_gb <= 65536, false)])
+    error_message = "data_volumes size_gb on gcp must be 10-65536 GB"
+  }
+  validation {
+    condition     = alltrue([for v in var.data_volumes : v.iops == null || contains(["gp3", "io1", "io2"], coalesce(v.type, var.provider_name == "aws" ? "gp3" : "-"))])
+    error_message = "data_volumes iops only applies to gp3, io1 and io2 volumes on aws"
+  }
+  validation {
+    condition     = alltrue([for v in var.data_volumes : v.iops != null || !contains(["io1", "io2"], coalesce(v.type, "-"))])
+    error_message = "io1 and io2 data_volumes need iops"
+  }
+  validation {
+    condition = alltrue([
+      for v in var.data_volumes : v.iops == null || coalesce(v.type, "gp3") != "gp3" || try(v.iops >= 3000 && v.iops <= 16000 && v.iops <= 500 * v.size_gb, false)
+    ])
+    error_message = "gp3 data_volumes iops must be 3000-16000, and at most 500 per GB of size_gb"
+  }
+  validation {
+    condition = alltrue([
+      for v in var.data_volumes : v.iops == null || !contains(["io1", "io2"], coalesce(v.type, "-")) || try(v.iops >= 100 && v.iops <= 64000 && v.iops <= (v.type == "io1" ? 50 : 500) * v.size_gb, false)
+    ])
+    error_message = "io1 and io2 data_volumes iops must be 100-64000, and at most 50 (io1) or 500 (io2) per GB of size_gb"
+  }
+  validation {
+    condition     = var.provider_name == "aws" || alltrue([for v in var.data_volumes : v.encrypted])
+    error_message = "data_volumes encrypted = false only applies to aws: Azure and GCP encrypt every disk at rest"
+  }
+  validation {
+    condition     = alltrue([for v in var.data_volumes : v.snapshot_schedule == null || can(regex("^([0-5]?[0-9]) ([01]?[0-9]|2[0-3]) \\* \\* (\\*|[0-6])$", v.snapshot_schedule.cron))])
+    error_message = "data_volumes snapshot_schedule cron must be a daily \"M H * * *\" or weekly \"M H * * D\" cron expression"
+  }
+  validation {
+    condition     = alltrue([for v in var.data_volumes : v.snapshot_schedule == null || try(v.snapshot_schedule.retention_days >= 1 && floor(v.snapshot_schedule.retention_days) == v.snapshot_schedule.retention_days, false)])
+    error_message = "data_volumes snapshot_schedule retention_days must be a whole number of at least 1"
+  }
+  validation {
+    condition     = var.provider_name != "azure" || alltrue([for v in var.data_volumes : v.snapshot_schedule == null || try(endswith(v.snapshot_schedule.cron, " * * *"), false)])
+    error_message = "Azure Disk Backup runs daily: data_volumes snapshot_schedule cron must be \"M H * * *\" on azure"
+  }
+  validation {
+    condition     = var.provider_name != "gcp" || alltrue([for v in var.data_volumes : v.snapshot_schedule == null || can(regex("^0?0 ", v.snapshot_schedule.cron))])
+    error_message = "GCP snapshot schedules start on the hour: data_volumes snapshot_schedule cron must be \"0 H * * *\" or \"0 H * * D\" on gcp"
+  }
+}
+
 variable "tags" {
   description = "Additional tags to apply to the instance"
   type        = map(string)
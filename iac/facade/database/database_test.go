@@ -1,102 +1,738 @@
 package database_test
 
 import (
-	"testing"
+	"fmt"
 	"strings"
+	"testing"
+
+	"iac/testutil/costcheck"
+	"iac/testutil/password"
+	"iac/testutil/planerr"
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDatabaseFacadeAws(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	masterPassword := password.New(t, "aws")
+	terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":             "aws",
+			"provider_name":        "aws",
 			"project_name":         "testproject",
-			"environment":          "test",
+			"environment":          "dev",
 			"identifier":           "test-db",
-			"master_password":      "password123",
+			"master_password":      masterPassword,
 			"allocated_storage_gb": 20,
 		},
 		BackendConfig: map[string]interface{}{},
-	})
+	}), masterPassword)
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
-	
+
 	assert.True(t, strings.Contains(planString, "module.aws_database[0].aws_db_instance.this"), "Plan should create an AWS RDS instance")
-	assert.True(t, strings.Contains(planString, "instance_class = \"db.t3.micro\""), "Plan should have the correct instance class for 'small'")
 }
 
 func TestDatabaseFacadeAzure(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	masterPassword := password.New(t, "azure")
+	terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":             "azure",
+			"provider_name":        "azure",
 			"project_name":         "testproject",
-			"environment":          "test",
+			"environment":          "dev",
 			"identifier":           "test-db",
 			"instance_class":       "medium",
-			"master_password":      "password123",
+			"master_password":      masterPassword,
 			"allocated_storage_gb": 20,
 			"provider_config": map[string]interface{}{
 				"resource_group_name": "test-rg",
 				"location":            "eastus",
 			},
 		},
-	})
+	}), masterPassword)
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
 
 	assert.True(t, strings.Contains(planString, "module.azure_database[0].azurerm_mssql_server.this"), "Plan should create an Azure SQL Server")
-	assert.True(t, strings.Contains(planString, "sku_name = \"S1\""), "Plan should have the correct SKU name for 'medium'")
 }
 
 func TestDatabaseFacadeGcp(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	masterPassword := password.New(t, "gcp")
+	terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":             "gcp",
+			"provider_name":        "gcp",
 			"project_name":         "testproject",
-			"environment":          "test",
+			"environment":          "dev",
 			"identifier":           "test-db",
 			"instance_class":       "large",
-			"master_password":      "password123",
+			"master_password":      masterPassword,
 			"allocated_storage_gb": 20,
 			"provider_config": map[string]interface{}{
 				"region": "us-central1",
 			},
 		},
-	})
+	}), masterPassword)
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
 
 	assert.True(t, strings.Contains(planString, "module.gcp_database[0].google_sql_database_instance.this"), "Plan should create a GCP SQL Instance")
-	assert.True(t, strings.Contains(planString, "tier = \"db-n1-standard-1\""), "Plan should have the correct tier for 'large'")
+}
+
+// TestDatabaseFacadeNoSQL plans engine_type nosql on each provider and
+// checks it creates the provider's key-value table instead of a SQL server
+func TestDatabaseFacadeNoSQL(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		vars   map[string]interface{}
+		want   []string // regular expressions
+		notSQL string
+	}{
+		"aws": {
+			vars: map[string]interface{}{"range_key": "created_at", "range_key_type": "N", "ttl_attribute": "expires_at"},
+			want: []string{
+				`module\.aws_nosql\[0\]\.aws_dynamodb_table\.this`,
+				`hash_key\s+= "order_id"`,
+				`range_key\s+= "created_at"`,
+				`billing_mode\s+= "PAY_PER_REQUEST"`,
+			},
+			notSQL: "aws_db_instance",
+		},
+		"azure": {
+			vars: map[string]interface{}{
+				"provider_config": map[string]interface{}{"resource_group_name": "test-rg", "location": "eastus"},
+			},
+			want: []string{
+				`module\.azure_nosql\[0\]\.azurerm_cosmosdb_sql_container\.this`,
+				`partition_key_path\s+= "/order_id"`,
+				`name\s+= "orders"`,
+			},
+			notSQL: "azurerm_mssql_server",
+		},
+		"gcp": {
+			vars: map[string]interface{}{
+				"provider_config": map[string]interface{}{"region": "us-central1", "project_id": "test-project"},
+			},
+			want: []string{
+				`module\.gcp_nosql\[0\]\.google_firestore_database\.this`,
+				`type\s+= "FIRESTORE_NATIVE"`,
+				`location_id\s+= "us-central1"`,
+			},
+			notSQL: "google_sql_database_instance",
+		},
+	}
+
+	for provider, tc := range cases {
+		provider, tc := provider, tc
+
+		t.Run(provider, func(t *testing.T) {
+			t.Parallel()
+
+			vars := map[string]interface{}{
+				"provider_name": provider,
+				"project_name":  "testproject",
+				"environment":   "dev",
+				"identifier":    "orders",
+				"engine_type":   "nosql",
+				"hash_key":      "order_id",
+			}
+			for k, v := range tc.vars {
+				vars[k] = v
+			}
+
+			planString := terraform.InitAndPlan(t, tflog.WithSensitive(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars:         vars,
+				NoColor:      true,
+			})))
+
+			for _, want := range tc.want {
+				assert.Regexp(t, want, planString)
+			}
+			assert.NotContains(t, planString, tc.notSQL, "engine_type nosql should not create a SQL server")
+			assert.NotContains(t, planString, "Check block assertion failed", "ttl_attribute applies to nosql tables")
+		})
+	}
+}
+
+// TestDatabaseFacadeCosmosThroughput plans each Cosmos DB throughput mode and
+// checks it lands on the account (serverless, consistency) or the container
+// (provisioned and autoscale RU/s)
+func TestDatabaseFacadeCosmosThroughput(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		vars    map[string]interface{}
+		want    []string // regular expressions
+		notWant []string
+	}{
+		"serverless": {
+			want: []string{
+				`name\s+= "EnableServerless"`,
+				`consistency_level\s+= "Session"`,
+			},
+			notWant: []string{`autoscale_settings`, `\bthroughput\s+= \d+`},
+		},
+		"provisioned": {
+			vars: map[string]interface{}{"throughput_mode": "provisioned", "max_throughput": 800, "consistency_level": "Eventual"},
+			want: []string{
+				`throughput\s+= 800`,
+				`consistency_level\s+= "Eventual"`,
+			},
+			notWant: []string{`EnableServerless`, `autoscale_settings`},
+		},
+		"provisioned-default": {
+			vars: map[string]interface{}{"throughput_mode": "provisioned"},
+			want: []string{`throughput\s+= 400`},
+		},
+		"autoscale": {
+			vars: map[string]interface{}{"throughput_mode": "autoscale", "max_throughput": 4000, "consistency_level": "Strong"},
+			want: []string{
+				`autoscale_settings\s+{\s+\+ max_throughput\s+= 4000`,
+				`consistency_level\s+= "Strong"`,
+			},
+			notWant: []string{`EnableServerless`, `\bthroughput\s+= 4000`},
+		},
+		"autoscale-default": {
+			vars: map[string]interface{}{"throughput_mode": "autoscale"},
+			want: []string{`max_throughput\s+= 1000`},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			vars := map[string]interface{}{
+				"provider_name":   "azure",
+				"project_name":    "testproject",
+				"environment":     "dev",
+				"identifier":      "orders",
+				"engine_type":     "nosql",
+				"hash_key":        "order_id",
+				"provider_config": map[string]interface{}{"resource_group_name": "test-rg", "location": "eastus"},
+			}
+			for k, v := range tc.vars {
+				vars[k] = v
+			}
+
+			planString := terraform.InitAndPlan(t, tflog.WithSensitive(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars:         vars,
+				NoColor:      true,
+			})))
+
+			for _, want := range tc.want {
+				assert.Regexp(t, want, planString)
+			}
+			for _, notWant := range tc.notWant {
+				assert.NotRegexp(t, notWant, planString)
+			}
+		})
+	}
+}
+
+// ordersIndexes are two global secondary indexes on an orders table: one by
+// customer ordered by date, one by status projecting only the total
+var ordersIndexes = []map[string]interface{}{
+	{"name": "by-customer", "hash_key": "customer_id", "range_key": "created_at", "range_key_type": "N"},
+	{"name": "by-status", "hash_key": "status", "projection": "INCLUDE", "non_key_attributes": []string{"total"}},
+}
+
+// TestDatabaseFacadeGlobalSecondaryIndexes plans a DynamoDB table with two
+// indexes, on demand and then provisioned, and checks each index, its key
+// attributes and the table's stream
+func TestDatabaseFacadeGlobalSecondaryIndexes(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		vars        map[string]interface{}
+		billingMode string
+		capacity    interface{} // of the table and each index; nil on demand
+	}{
+		"on-demand": {
+			billingMode: "PAY_PER_REQUEST",
+		},
+		"provisioned": {
+			vars:        map[string]interface{}{"billing_mode": "provisioned", "read_capacity": 5, "write_capacity": 5},
+			billingMode: "PROVISIONED",
+			capacity:    float64(5),
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			vars := map[string]interface{}{
+				"provider_name":            "aws",
+				"project_name":             "testproject",
+				"environment":              "dev",
+				"identifier":               "orders",
+				"engine_type":              "nosql",
+				"hash_key":                 "order_id",
+				"global_secondary_indexes": ordersIndexes,
+				"enable_streams":           true,
+			}
+			for k, v := range tc.vars {
+				vars[k] = v
+			}
+
+			plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars:         vars,
+			}))
+
+			resource, ok := plan.ResourcePlannedValuesMap["module.aws_nosql[0].aws_dynamodb_table.this"]
+			require.True(t, ok, "Plan should create a DynamoDB table")
+			table := resource.AttributeValues
+
+			assert.Equal(t, tc.billingMode, table["billing_mode"])
+			assert.Equal(t, true, table["stream_enabled"])
+			assert.Equal(t, "NEW_AND_OLD_IMAGES", table["stream_view_type"])
+
+			attributes := map[string]interface{}{}
+			for _, a := range table["attribute"].([]interface{}) {
+				a := a.(map[string]interface{})
+				attributes[a["name"].(string)] = a["type"]
+			}
+			assert.Equal(t, map[string]interface{}{"order_id": "S", "customer_id": "S", "created_at": "N", "status": "S"}, attributes,
+				"Every key attribute should be defined once")
+
+			indexes := map[string]map[string]interface{}{}
+			for _, gsi := range table["global_secondary_index"].([]interface{}) {
+				gsi := gsi.(map[string]interface{})
+				indexes[gsi["name"].(string)] = gsi
+			}
+			require.Len(t, indexes, 2)
+
+			byCustomer := indexes["by-customer"]
+			assert.Equal(t, "customer_id", byCustomer["hash_key"])
+			assert.Equal(t, "created_at", byCustomer["range_key"])
+			assert.Equal(t, "ALL", byCustomer["projection_type"])
+
+			byStatus := indexes["by-status"]
+			assert.Equal(t, "status", byStatus["hash_key"])
+			assert.Equal(t, "INCLUDE", byStatus["projection_type"])
+			assert.Equal(t, []interface{}{"total"}, byStatus["non_key_attributes"])
+
+			for _, capacity := range []interface{}{table["read_capacity"], table["write_capacity"], byCustomer["read_capacity"], byStatus["write_capacity"]} {
+				if tc.capacity == nil {
+					assert.Contains(t, []interface{}{nil, float64(0)}, capacity, "On-demand tables and indexes take no capacity")
+				} else {
+					assert.Equal(t, tc.capacity, capacity)
+				}
+			}
+		})
+	}
+}
+
+// TestDatabaseFacadeFirestoreIndexes checks an index with a range_key
+// becomes a Firestore composite index on the table's collection, and one
+// without is left to Firestore's single-field indexes
+func TestDatabaseFacadeFirestoreIndexes(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":            "gcp",
+			"project_name":             "testproject",
+			"environment":              "dev",
+			"identifier":               "orders",
+			"engine_type":              "nosql",
+			"hash_key":                 "order_id",
+			"global_secondary_indexes": []map[string]interface{}{ordersIndexes[0], {"name": "by-status", "hash_key": "status"}},
+			"provider_config":          map[string]interface{}{"region": "us-central1", "project_id": "test-project"},
+		},
+	}))
+
+	var indexes []string
+	for address, resource := range plan.ResourcePlannedValuesMap {
+		if resource.Type == "google_firestore_index" {
+			indexes = append(indexes, address)
+		}
+	}
+	require.Equal(t, []string{`module.gcp_nosql[0].google_firestore_index.this["by-customer"]`}, indexes)
+
+	index := plan.ResourcePlannedValuesMap[indexes[0]].AttributeValues
+	assert.Equal(t, "orders", index["collection"])
+	// Firestore orders ties by document name, which the provider may add
+	var fields []interface{}
+	for _, field := range index["fields"].([]interface{}) {
+		if path := field.(map[string]interface{})["field_path"]; path != "__name__" {
+			fields = append(fields, path)
+		}
+	}
+	assert.Equal(t, []interface{}{"customer_id", "created_at"}, fields)
 }
 
 func TestDatabaseFacadeInvalidPassword(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := &terraform.Options{
+	terraformOptions := tflog.WithSensitive(t, tfopts.New(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":             "aws",
+			"provider_name":        "aws",
 			"project_name":         "testproject",
-			"environment":          "test",
+			"environment":          "dev",
 			"identifier":           "test-db",
-			"master_password":      "short", // Many providers require min length
+			"master_password":      "short",
 			"allocated_storage_gb": 20,
 		},
+		NoColor: true,
+	}))
+
+	err := planerr.PlanE(t, terraformOptions)
+	planerr.AssertValidationError(t, err, "master_password", "master_password does not meet the aws password rules")
+}
+
+// passwordVars are the variables each provider's plan needs besides the
+// password
+var passwordVars = map[string]map[string]interface{}{
+	"aws": {},
+	"azure": {
+		"provider_config": map[string]interface{}{
+			"resource_group_name": "test-rg",
+			"location":            "eastus",
+		},
+	},
+	"gcp": {
+		"provider_config": map[string]interface{}{
+			"region": "us-central1",
+		},
+	},
+}
+
+// TestDatabaseFacadePasswordRules plans the facade with passwords on either
+// side of each provider's rules and fails wherever the master_password
+// validation and password.ValidatePassword disagree, so the Terraform rule and
+// the one generated passwords are checked against cannot drift apart
+func TestDatabaseFacadePasswordRules(t *testing.T) {
+	t.Parallel()
+
+	samples := map[string][]string{
+		"aws":   {"password123", strings.Repeat("a", 41), "short", strings.Repeat("a", 42), "pass word123", "pass/word123", "pass@word123", `pass"word123`},
+		"azure": {"Password123", "pass word-123", "password123", "PASSWORD!!", "Pa1!xyz", "MyAdmin123", strings.Repeat("Ab1", 43)},
+		"gcp":   {"password", "hunter2", strings.Repeat("p", 128), strings.Repeat("p", 129)},
+	}
+
+	for provider, passwords := range samples {
+		for i, pw := range passwords {
+			provider, pw := provider, pw
+
+			t.Run(fmt.Sprintf("%s/%d", provider, i), func(t *testing.T) {
+				t.Parallel()
+
+				vars := map[string]interface{}{
+					"provider_name":   provider,
+					"project_name":    "testproject",
+					"environment":     "dev",
+					"identifier":      "test-db",
+					"master_password": pw,
+				}
+				for k, v := range passwordVars[provider] {
+					vars[k] = v
+				}
+
+				output, err := terraform.InitAndPlanE(t, tflog.WithSensitive(t, tfopts.New(t, &terraform.Options{
+					TerraformDir: ".",
+					Vars:         vars,
+					NoColor:      true,
+				})))
+
+				want := "master_password does not meet the " + provider + " password rules"
+				if goErr := password.ValidatePassword(provider, password.DefaultUsername, pw); goErr != nil {
+					assert.NoError(t, planerr.Match(output, err, want), "password.ValidatePassword rejects it: %v", goErr)
+				} else if err != nil {
+					assert.NotContains(t, planerr.Normalize(output+"\n"+err.Error()), want, "password.ValidatePassword accepts it")
+				}
+			})
+		}
+	}
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name":        "aws",
+		"project_name":         "testproject",
+		"environment":          "dev",
+		"identifier":           "test-db",
+		"master_password":      password.New(t, "aws"),
+		"allocated_storage_gb": 20,
+	}
+
+	// Overrides base for engine_type nosql on azure
+	cosmos := func(vars map[string]interface{}) map[string]interface{} {
+		merged := map[string]interface{}{
+			"provider_name":        "azure",
+			"engine_type":          "nosql",
+			"hash_key":             "id",
+			"allocated_storage_gb": nil,
+			"master_password":      nil,
+			"provider_config":      map[string]interface{}{"resource_group_name": "test-rg", "location": "eastus"},
+		}
+		for k, v := range vars {
+			merged[k] = v
+		}
+		return merged
+	}
+
+	// Overrides base for engine_type nosql on aws
+	dynamo := func(vars map[string]interface{}) map[string]interface{} {
+		merged := map[string]interface{}{
+			"engine_type":          "nosql",
+			"hash_key":             "id",
+			"allocated_storage_gb": nil,
+			"master_password":      nil,
+		}
+		for k, v := range vars {
+			merged[k] = v
+		}
+		return merged
+	}
+
+	tooManyIndexes := make([]map[string]interface{}, 21)
+	for i := range tooManyIndexes {
+		tooManyIndexes[i] = map[string]interface{}{"name": fmt.Sprintf("index-%02d", i), "hash_key": fmt.Sprintf("key%d", i)}
 	}
 
-	// This might fail either at Terraform validation OR provider validation during plan
-	_, err := terraform.InitAndPlanE(t, terraformOptions)
-	assert.Error(t, err, "Plan should fail with a weak password")
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "ZeroCloud",
+			Vars: map[string]interface{}{"provider_name": "zero"},
+			Want: "ZeroCloud has no relational database service",
+		},
+		{
+			Name: "UnknownInstanceClass",
+			Vars: map[string]interface{}{"instance_class": "huge"},
+			Want: "Instance class must be one of: small, medium, large, xlarge",
+		},
+		{
+			Name: "KmsKeyRefUnknownProvider",
+			Vars: map[string]interface{}{"kms_key_ref": map[string]interface{}{"provider": "oracle", "id": "ocid1.key.oc1..example"}},
+			Want: "kms_key_ref must have provider aws, azure or gcp and a non-empty id",
+		},
+		{
+			Name: "KmsKeyRefEmptyID",
+			Vars: map[string]interface{}{"kms_key_ref": map[string]interface{}{"provider": "aws", "id": ""}},
+			Want: "kms_key_ref must have provider aws, azure or gcp and a non-empty id",
+		},
+		{
+			Name: "UnknownEngineType",
+			Vars: map[string]interface{}{"engine_type": "graph"},
+			Want: "engine_type must be one of: sql, nosql",
+		},
+		{
+			Name: "AllocatedStorageForNoSQL",
+			Vars: map[string]interface{}{"engine_type": "nosql", "hash_key": "id"},
+			Want: "allocated_storage_gb does not apply to engine_type nosql",
+		},
+		{
+			Name: "NoSQLWithoutHashKey",
+			Vars: map[string]interface{}{"engine_type": "nosql", "allocated_storage_gb": nil},
+			Want: "engine_type nosql needs a hash_key",
+		},
+		{
+			Name: "HashKeyForSQL",
+			Vars: map[string]interface{}{"hash_key": "id"},
+			Want: "engine_type sql takes none",
+		},
+		{
+			Name: "RangeKeyForSQL",
+			Vars: map[string]interface{}{"range_key": "created_at"},
+			Want: "range_key only applies to engine_type nosql",
+		},
+		{
+			Name: "UnknownHashKeyType",
+			Vars: map[string]interface{}{"engine_type": "nosql", "hash_key": "id", "hash_key_type": "BOOL", "allocated_storage_gb": nil},
+			Want: "hash_key_type must be one of: S, N, B",
+		},
+		{
+			Name: "RangeKeyOnCosmos",
+			Vars: cosmos(map[string]interface{}{"range_key": "created_at"}),
+			Want: "range_key is not supported on azure",
+		},
+		{
+			Name: "UnknownThroughputMode",
+			Vars: cosmos(map[string]interface{}{"throughput_mode": "burst"}),
+			Want: "throughput_mode must be one of: provisioned, autoscale, serverless",
+		},
+		{
+			Name: "ServerlessWithThroughput",
+			Vars: cosmos(map[string]interface{}{"max_throughput": 1000}),
+			Want: "Serverless Cosmos DB accounts bill per request and take no throughput",
+		},
+		{
+			Name: "AutoscaleOffStep",
+			Vars: cosmos(map[string]interface{}{"throughput_mode": "autoscale", "max_throughput": 1500}),
+			Want: "Autoscale max_throughput must be 1000-1,000,000 RU/s in steps of 1000",
+		},
+		{
+			Name: "AutoscaleTooHigh",
+			Vars: cosmos(map[string]interface{}{"throughput_mode": "autoscale", "max_throughput": 2000000}),
+			Want: "Autoscale max_throughput must be 1000-1,000,000 RU/s in steps of 1000",
+		},
+		{
+			Name: "ProvisionedTooLow",
+			Vars: cosmos(map[string]interface{}{"throughput_mode": "provisioned", "max_throughput": 100}),
+			Want: "Provisioned max_throughput must be 400-1,000,000 RU/s in steps of 100",
+		},
+		{
+			Name: "UnknownConsistencyLevel",
+			Vars: cosmos(map[string]interface{}{"consistency_level": "Linearizable"}),
+			Want: "consistency_level must be one of: Strong, BoundedStaleness, Session, ConsistentPrefix, Eventual",
+		},
+		{
+			Name: "ThroughputModeOnDynamoDB",
+			Vars: map[string]interface{}{"engine_type": "nosql", "hash_key": "id", "allocated_storage_gb": nil, "throughput_mode": "provisioned"},
+			Want: "throughput_mode only applies to engine_type nosql on azure",
+		},
+		{
+			Name: "MaxThroughputForSQL",
+			Vars: map[string]interface{}{"provider_name": "azure", "throughput_mode": "provisioned", "max_throughput": 400},
+			Want: "max_throughput only applies to engine_type nosql on azure",
+		},
+		{
+			Name: "UnknownBillingMode",
+			Vars: dynamo(map[string]interface{}{"billing_mode": "reserved"}),
+			Want: "billing_mode must be one of: on_demand, provisioned",
+		},
+		{
+			Name: "ProvisionedWithoutCapacity",
+			Vars: dynamo(map[string]interface{}{"billing_mode": "provisioned", "read_capacity": 5}),
+			Want: "billing_mode provisioned needs read_capacity and write_capacity",
+		},
+		{
+			Name: "CapacityOnDemand",
+			Vars: dynamo(map[string]interface{}{"read_capacity": 5}),
+			Want: "read_capacity only applies to billing_mode provisioned",
+		},
+		{
+			Name: "ZeroCapacity",
+			Vars: dynamo(map[string]interface{}{"billing_mode": "provisioned", "read_capacity": 5, "write_capacity": 0}),
+			Want: "write_capacity must be a whole number of at least 1",
+		},
+		{
+			Name: "ProvisionedOnCosmos",
+			Vars: cosmos(map[string]interface{}{"billing_mode": "provisioned", "read_capacity": 5, "write_capacity": 5}),
+			Want: "billing_mode provisioned only applies to engine_type nosql on aws",
+		},
+		{
+			Name: "TooManyIndexes",
+			Vars: dynamo(map[string]interface{}{"global_secondary_indexes": tooManyIndexes}),
+			Want: "DynamoDB tables take at most 20 global_secondary_indexes",
+		},
+		{
+			Name: "DuplicateIndexNames",
+			Vars: dynamo(map[string]interface{}{"global_secondary_indexes": []map[string]interface{}{
+				{"name": "by-customer", "hash_key": "customer_id"},
+				{"name": "by-customer", "hash_key": "status"},
+			}}),
+			Want: "global_secondary_indexes names must be unique",
+		},
+		{
+			Name: "UnknownProjection",
+			Vars: dynamo(map[string]interface{}{"global_secondary_indexes": []map[string]interface{}{
+				{"name": "by-customer", "hash_key": "customer_id", "projection": "SOME"},
+			}}),
+			Want: "global_secondary_indexes projection must be one of: ALL, KEYS_ONLY, INCLUDE",
+		},
+		{
+			Name: "IncludeWithoutAttributes",
+			Vars: dynamo(map[string]interface{}{"global_secondary_indexes": []map[string]interface{}{
+				{"name": "by-customer", "hash_key": "customer_id", "projection": "INCLUDE"},
+			}}),
+			Want: "global_secondary_indexes projection INCLUDE needs non_key_attributes",
+		},
+		{
+			Name: "IndexesForSQL",
+			Vars: map[string]interface{}{"global_secondary_indexes": []map[string]interface{}{{"name": "by-customer", "hash_key": "customer_id"}}},
+			Want: "global_secondary_indexes only apply to engine_type nosql",
+		},
+		{
+			Name: "StreamsForSQL",
+			Vars: map[string]interface{}{"enable_streams": true},
+			Want: "enable_streams only applies to engine_type nosql",
+		},
+		{
+			// Valid on its own, rejected by the stream_arn precondition
+			Name: "StreamsOnFirestore",
+			Vars: dynamo(map[string]interface{}{
+				"provider_name":   "gcp",
+				"enable_streams":  true,
+				"provider_config": map[string]interface{}{"region": "us-central1"},
+			}),
+			Want: "enable_streams is not supported on gcp",
+		},
+		{
+			Name: "NumericDocumentID",
+			Vars: map[string]interface{}{
+				"provider_name":        "gcp",
+				"engine_type":          "nosql",
+				"hash_key":             "id",
+				"hash_key_type":        "N",
+				"allocated_storage_gb": nil,
+				"provider_config":      map[string]interface{}{"region": "us-central1"},
+			},
+			Want: "hash_key_type must be S on gcp",
+		},
+		{
+			Name: "SQLWithoutPassword",
+			Vars: map[string]interface{}{"master_password": nil},
+			Want: "engine_type sql needs a master_password",
+		},
+		{
+			// Valid on its own, rejected by the facade's cross-provider precondition
+			Name: "KmsKeyRefOtherProvider",
+			Vars: map[string]interface{}{"kms_key_ref": map[string]interface{}{
+				"provider": "gcp",
+				"id":       "projects/p/locations/us/keyRings/r/cryptoKeys/k",
+			}},
+			Want: "kms_key_ref belongs to gcp but this module deploys to aws",
+		},
+	})
+}
+
+// TestDatabaseFacadeAwsCost guards the monthly cost of the default "small"
+// instance class; a size mapping change that moves it to a larger class
+// fails here. Skipped unless infracost and INFRACOST_API_KEY are available.
+func TestDatabaseFacadeAwsCost(t *testing.T) {
+	t.Parallel()
+
+	masterPassword := password.New(t, "aws")
+	terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":        "aws",
+			"project_name":         "testproject",
+			"environment":          "dev",
+			"identifier":           "test-db",
+			"master_password":      masterPassword,
+			"allocated_storage_gb": 20,
+		},
+	}), masterPassword)
+
+	report := costcheck.Estimate(t, terraformOptions)
+
+	// db.t3.micro with 20 GB of gp2 is about $15 per month
+	report.AssertResourceCostBelow(t, "module.aws_database[0].aws_db_instance.this", 40)
+	report.AssertMonthlyCostBelow(t, 50)
 }
@@ -2,63 +2,81 @@
 # Unified interface for Database resources across providers
 
 terraform {
-  required_version = ">= 1.0"
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
 }
 
 # ============================================================================
 # IMPORT COMMON LAYER
 # ============================================================================
 
-locals {
-  # Import size mappings
-  db_instance_types = {
-    aws = {
-      small   = "db.t3.micro"
-      medium  = "db.t3.medium"
-      large   = "db.m5.large"
-      xlarge  = "db.m5.xlarge"
-    }
-    azure = {
-      small   = "S0"
-      medium  = "S1"
-      large   = "P1"
-      xlarge  = "P2"
-    }
-    gcp = {
-      small   = "db-f1-micro"
-      medium  = "db-g1-small"
-      large   = "db-n1-standard-1"
-      xlarge  = "db-n1-standard-2"
-    }
-  }
+module "default_tags" {
+  source = "../../common/tags"
 
-  # Common tags merged with user tags
-  common_tags = merge(
-    var.tags,
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy    = "Terraform"
-      Environment  = var.environment
-      Provider     = var.provider_name
-      Project      = var.project_name
-      Module       = "Database-Facade"
-    }
+      Provider = var.provider_name
+      Module   = "Database-Facade"
+    },
+    var.tags
   )
 }
 
+module "instance_class" {
+  source = "../../common/sizes"
+
+  resource_kind = "database"
+  provider_name = var.provider_name
+  size          = var.instance_class
+}
+
+locals {
+  instance_class = module.instance_class.instance_type
+
+  nosql                = var.engine_type == "nosql"
+  allocated_storage_gb = coalesce(var.allocated_storage_gb, 20)
+
+  # The smallest legal container throughput for each Cosmos DB mode
+  cosmos_throughput = var.max_throughput != null ? var.max_throughput : lookup({ provisioned = 400, autoscale = 1000 }, var.throughput_mode, null)
+
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  kms_key_id = var.kms_key_ref != null ? var.kms_key_ref.id : null
+
+  # master_password rules per provider, checked by its validation in
+  # variables.tf. testutil/password mirrors them for generated passwords.
+  master_password  = var.master_password != null ? var.master_password : ""
+  password_classes = length([for re in ["[A-Z]", "[a-z]", "[0-9]", "[^A-Za-z0-9]"] : re if can(regex(re, local.master_password))])
+  password_valid = {
+    aws   = can(regex("^[!-~]{8,41}$", local.master_password)) && !can(regex("[/@\"]", local.master_password))
+    azure = length(local.master_password) >= 8 && length(local.master_password) <= 128 && local.password_classes >= 3 && replace(lower(local.master_password), lower(var.master_username), "") == lower(local.master_password)
+    gcp   = length(local.master_password) >= 8 && length(local.master_password) <= 128
+  }
+  password_rules = {
+    aws   = "8-41 printable ASCII characters without /, @, \" or spaces"
+    azure = "8-128 characters mixing 3 of upper case, lower case, digits and symbols, without the master_username"
+    gcp   = "8-128 characters"
+  }
+}
+
 # ============================================================================
 # PROVIDER-SPECIFIC MODULE ROUTING
 # ============================================================================
 
 # AWS: RDS
 module "aws_database" {
-  count  = var.provider_name == "aws" ? 1 : 0
+  count  = var.provider_name == "aws" && !local.nosql ? 1 : 0
   source = "../../aws/core/database"
   
   identifier             = var.identifier
   engine                 = var.engine
   engine_version         = var.engine_version
-  instance_class         = local.db_instance_types["aws"][var.instance_class]
-  allocated_storage      = var.allocated_storage_gb
+  instance_class         = local.instance_class
+  allocated_storage      = local.allocated_storage_gb
   
   database_name          = var.database_name
   master_username        = var.master_username
@@ -71,15 +89,16 @@ module "aws_database" {
   
   # HA & Backup
   multi_az              = var.multi_az
-  storage_encrypted     = var.storage_encrypted
+  storage_encrypted     = var.storage_encrypted || var.kms_key_ref != null
+  kms_key_id            = local.kms_key_id
   backup_retention_period = var.backup_retention_days
   
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # Azure: SQL Database
 module "azure_database" {
-  count  = var.provider_name == "azure" ? 1 : 0
+  count  = var.provider_name == "azure" && !local.nosql ? 1 : 0
   source = "../../azure/core/database"
   
   server_name         = var.identifier
@@ -91,33 +110,151 @@ module "azure_database" {
   admin_username      = var.master_username
   admin_password      = var.master_password
   
-  sku_name            = local.db_instance_types["azure"][var.instance_class]
-  max_size_gb         = var.allocated_storage_gb
+  sku_name            = local.instance_class
+  max_size_gb         = local.allocated_storage_gb
   zone_redundant      = var.multi_az
   
-  tags = local.common_tags
+  tde_key_vault_key_id = local.kms_key_id
+  
+  tags = local.default_tags
 }
 
 # GCP: Cloud SQL
 module "gcp_database" {
-  count  = var.provider_name == "gcp" ? 1 : 0
+  count  = var.provider_name == "gcp" && !local.nosql ? 1 : 0
   source = "../../gcp/core/database"
   
   instance_name    = var.identifier
   database_name    = var.database_name != null ? var.database_name : "main-db"
   
   region           = var.provider_config["region"]
-  tier             = local.db_instance_types["gcp"][var.instance_class]
+  tier             = local.instance_class
   
   user_name        = var.master_username
   user_password    = var.master_password
   
-  disk_size_gb     = var.allocated_storage_gb
+  disk_size_gb     = local.allocated_storage_gb
   high_availability = var.multi_az
   
+  encryption_key_name = local.kms_key_id
+  
   # Network
   private_network  = lookup(var.provider_config, "network_link", null)
   public_ip_enabled = var.publicly_accessible
+  
+  labels = local.default_labels
+}
+
+# ============================================================================
+# NOSQL ENGINE
+# ============================================================================
+
+# AWS: DynamoDB
+module "aws_nosql" {
+  count  = var.provider_name == "aws" && local.nosql ? 1 : 0
+  source = "../../aws/core/nosql"
+
+  table_name     = var.identifier
+  hash_key       = var.hash_key
+  hash_key_type  = var.hash_key_type
+  range_key      = var.range_key
+  range_key_type = var.range_key_type
+  ttl_attribute  = var.ttl_attribute
+
+  billing_mode   = var.billing_mode == "provisioned" ? "PROVISIONED" : "PAY_PER_REQUEST"
+  read_capacity  = var.read_capacity
+  write_capacity = var.write_capacity
+
+  global_secondary_indexes = [
+    for gsi in var.global_secondary_indexes : {
+      name               = gsi.name
+      hash_key           = gsi.hash_key
+      hash_key_type      = gsi.hash_key_type
+      range_key          = gsi.range_key
+      range_key_type     = gsi.range_key_type
+      projection_type    = gsi.projection
+      non_key_attributes = gsi.non_key_attributes
+    }
+  ]
+  stream_view_type = var.enable_streams ? "NEW_AND_OLD_IMAGES" : null
+
+  tags = local.default_tags
+}
+
+# Azure: Cosmos DB SQL API account, database and container
+module "azure_nosql" {
+  count  = var.provider_name == "azure" && local.nosql ? 1 : 0
+  source = "../../azure/core/nosql"
+
+  account_name        = replace(lower(var.identifier), "-", "")
+  resource_group_name = var.provider_config["resource_group_name"]
+  location            = var.provider_config["location"]
+  database_name       = var.database_name != null ? var.database_name : "main-db"
+  container_name      = var.identifier
+  partition_key_path  = "/${var.hash_key}"
+
+  throughput_mode   = var.throughput_mode
+  throughput        = local.cosmos_throughput
+  consistency_level = var.consistency_level
+
+  # Cosmos DB reads each item's TTL from its ttl property
+  default_ttl = var.ttl_attribute != null ? -1 : null
+
+  tags = local.default_tags
+}
+
+# GCP: Firestore database in native mode. Firestore has no tables, so by
+# convention items go in a collection named after the identifier, one
+# document per item with the hash_key value as its ID (see nosql_table).
+module "gcp_nosql" {
+  count  = var.provider_name == "gcp" && local.nosql ? 1 : 0
+  source = "../../gcp/core/nosql"
+
+  project_id  = lookup(var.provider_config, "project_id", var.project_name)
+  database_id = var.identifier
+  location_id = var.provider_config["region"]
+  type        = "FIRESTORE_NATIVE"
+
+  # Querying one field while ordering by another needs a composite index;
+  # an index without a range_key is served by Firestore's automatic
+  # single-field indexes
+  composite_indexes = [
+    for gsi in var.global_secondary_indexes : {
+      name       = gsi.name
+      collection = var.identifier
+      fields     = [gsi.hash_key, gsi.range_key]
+    } if gsi.range_key != null
+  ]
+}
+
+# SQL engines have no item TTL. Warn rather than fail, so one variable set
+# can be shared with facade/nosql.
+check "ttl_attribute" {
+  assert {
+    condition     = local.nosql || var.ttl_attribute == null
+    error_message = "ttl_attribute is ignored: ${var.engine} has no item TTL. Set engine_type = \"nosql\" for tables whose items expire."
+  }
+}
+
+check "ttl_attribute_firestore" {
+  assert {
+    condition     = !(local.nosql && var.provider_name == "gcp") || var.ttl_attribute == null
+    error_message = "ttl_attribute is ignored on gcp: Firestore expires documents through a TTL policy on each collection, and this facade creates no collections."
+  }
+}
+
+check "global_secondary_indexes_cosmos" {
+  assert {
+    condition     = var.provider_name != "azure" || length(var.global_secondary_indexes) == 0
+    error_message = "global_secondary_indexes create nothing on azure: Cosmos DB indexes every property of every item already, so queries on an index's keys need no index."
+  }
+}
+
+check "global_secondary_indexes_projection" {
+  assert {
+    condition     = var.provider_name == "aws" || alltrue([for gsi in var.global_secondary_indexes : gsi.projection == "ALL"])
+    error_message = "global_secondary_indexes projection is ignored on ${var.provider_name}: queries return whole items."
+  }
 }
 
 # ============================================================================
@@ -127,16 +264,16 @@ module "gcp_database" {
 locals {
   # ID
   db_id = (
-    var.provider_name == "aws"   ? (length(module.aws_database) > 0 ? module.aws_database[0].db_instance_id : null) :
-    var.provider_name == "azure" ? (length(module.azure_database) > 0 ? module.azure_database[0].database_id : null) :
-    var.provider_name == "gcp"   ? (length(module.gcp_database) > 0 ? module.gcp_database[0].instance_name : null) :
+    var.provider_name == "aws"   ? (length(module.aws_database) > 0 ? module.aws_database[0].db_instance_id : length(module.aws_nosql) > 0 ? module.aws_nosql[0].table_id : null) :
+    var.provider_name == "azure" ? (length(module.azure_database) > 0 ? module.azure_database[0].database_id : length(module.azure_nosql) > 0 ? module.azure_nosql[0].container_id : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_database) > 0 ? module.gcp_database[0].instance_name : length(module.gcp_nosql) > 0 ? module.gcp_nosql[0].database_id : null) :
     null
   )
   
   # Endpoint
   db_endpoint = (
     var.provider_name == "aws"   ? (length(module.aws_database) > 0 ? module.aws_database[0].db_instance_endpoint : null) :
-    var.provider_name == "azure" ? (length(module.azure_database) > 0 ? module.azure_database[0].server_fqdn : null) :
+    var.provider_name == "azure" ? (length(module.azure_database) > 0 ? module.azure_database[0].server_fqdn : length(module.azure_nosql) > 0 ? module.azure_nosql[0].endpoint : null) :
     var.provider_name == "gcp"   ? (length(module.gcp_database) > 0 ? module.gcp_database[0].public_ip : null) :
     null
   )
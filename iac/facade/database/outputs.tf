@@ -1,24 +1,89 @@
 output "db_instance_id" {
   description = "Database instance ID"
   value       = local.db_id
+
+  precondition {
+    condition     = var.kms_key_ref == null || try(var.kms_key_ref.provider == var.provider_name, false)
+    error_message = "kms_key_ref belongs to ${try(var.kms_key_ref.provider, "another provider")} but this module deploys to ${var.provider_name}"
+  }
+}
+
+output "monitored_resource" {
+  description = "Reference for the monitoring facade's monitored_resource: the DB instance identifier or table name on AWS, the database or Cosmos DB account resource ID on Azure, the instance name or Firestore database ID on GCP"
+  value = {
+    # Tables report their own metrics, so they are alarmed on as nosql
+    facade_type = local.nosql ? "nosql" : "database"
+    resource_id = (
+      var.provider_name == "aws" ? var.identifier :
+      var.provider_name == "azure" && local.nosql ? (length(module.azure_nosql) > 0 ? module.azure_nosql[0].account_id : null) :
+      local.db_id
+    )
+  }
+}
+
+# Backup reference accepted by the backup facade's resources input: the RDS
+# instance or DynamoDB table ARN on AWS, the SQL database or Cosmos DB account
+# ID on Azure, the Cloud SQL instance name or Firestore database ID on GCP.
+output "backup_ref" {
+  description = "Backup reference ({provider, type, id}) for the backup facade"
+  value = {
+    provider = var.provider_name
+    type = lookup({
+      aws   = local.nosql ? "dynamodb" : "rds"
+      azure = local.nosql ? "cosmos" : "azure_sql"
+      gcp   = local.nosql ? "firestore" : "cloud_sql"
+    }, var.provider_name, null)
+    id = (
+      var.provider_name == "aws"   ? (length(module.aws_database) > 0 ? module.aws_database[0].db_instance_arn : length(module.aws_nosql) > 0 ? module.aws_nosql[0].table_arn : null) :
+      var.provider_name == "azure" ? (length(module.azure_database) > 0 ? module.azure_database[0].database_id : length(module.azure_nosql) > 0 ? module.azure_nosql[0].account_id : null) :
+      var.provider_name == "gcp"   ? (length(module.gcp_database) > 0 ? module.gcp_database[0].instance_name : length(module.gcp_nosql) > 0 ? module.gcp_nosql[0].database_id : null) :
+      null
+    )
+  }
 }
 
 output "db_endpoint" {
-  description = "Database connection endpoint"
+  description = "Database connection endpoint; for engine_type nosql the Cosmos DB account endpoint on Azure, null elsewhere"
   value       = local.db_endpoint
 }
 
+output "nosql_table" {
+  description = "Where engine_type nosql items live, null for sql: the DynamoDB table, the Cosmos DB database and container, or the Firestore database and collection. Firestore items are documents whose ID is the hash_key value. indexes lists the global_secondary_indexes names to query by."
+  value = local.nosql ? {
+    database  = var.provider_name == "azure" ? (var.database_name != null ? var.database_name : "main-db") : var.provider_name == "gcp" ? var.identifier : null
+    name      = var.identifier
+    hash_key  = var.hash_key
+    range_key = var.range_key
+    indexes   = [for gsi in var.global_secondary_indexes : gsi.name]
+  } : null
+}
+
+output "stream_arn" {
+  description = "ARN of the DynamoDB stream of an engine_type nosql table with enable_streams, e.g. for a Lambda event source; null elsewhere, including Cosmos DB, whose change feed is read through the account"
+  value       = var.enable_streams && length(module.aws_nosql) > 0 ? module.aws_nosql[0].stream_arn : null
+
+  precondition {
+    condition     = !(var.enable_streams && var.provider_name == "gcp")
+    error_message = "enable_streams is not supported on gcp: Firestore has no change stream to enable; trigger on document writes through Eventarc instead"
+  }
+}
+
 output "db_name" {
   description = "Database name"
   value       = var.database_name
 }
 
+output "engine_type" {
+  description = "sql or nosql"
+  value       = var.engine_type
+}
+
 output "engine" {
-  description = "Database engine"
-  value       = var.engine
+  description = "SQL database engine, null for engine_type nosql"
+  value       = local.nosql ? null : var.engine
 }
 
 output "provider" {
   description = "Cloud provider"
-  value       = var.provider
+  value       = var.provider_name
 }
@@ -1,10 +1,14 @@
 # General Configuration
 variable "provider_name" {
-  description = "Cloud provider (aws, azure, gcp)"
+  description = "Cloud provider (aws, azure, gcp, zero)"
   type        = string
   validation {
-    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
-    error_message = "Provider must be one of: aws, azure, gcp."
+    condition     = contains(["aws", "azure", "gcp", "zero"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp, zero."
+  }
+  validation {
+    condition     = var.provider_name != "zero"
+    error_message = "ZeroCloud has no relational database service; ZeroDB is a key-value store, so use facade/nosql with provider_name = \"zero\" instead."
   }
 }
 
@@ -17,6 +21,10 @@ variable "environment" {
   description = "Environment (dev, staging, prod)"
   type        = string
   default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 # Database Configuration
@@ -31,8 +39,18 @@ variable "database_name" {
   default     = null
 }
 
+variable "engine_type" {
+  description = "sql for a relational database (RDS, Azure SQL, Cloud SQL), nosql for a key-value table (DynamoDB, Cosmos DB SQL API container, Firestore native mode)"
+  type        = string
+  default     = "sql"
+  validation {
+    condition     = contains(["sql", "nosql"], var.engine_type)
+    error_message = "engine_type must be one of: sql, nosql"
+  }
+}
+
 variable "engine" {
-  description = "Database engine (postgres, mysql, etc.)"
+  description = "SQL database engine (postgres, mysql, etc.); ignored for engine_type nosql"
   type        = string
   default     = "postgres"
 }
@@ -54,9 +72,212 @@ variable "instance_class" {
 }
 
 variable "allocated_storage_gb" {
-  description = "Storage size in GB"
+  description = "Storage size in GB for engine_type sql, 20 when null; NoSQL tables grow on demand, so it must be null for nosql"
+  type        = number
+  default     = null
+  validation {
+    condition     = var.engine_type == "sql" || var.allocated_storage_gb == null
+    error_message = "allocated_storage_gb does not apply to engine_type nosql, whose tables grow on demand; leave it unset"
+  }
+}
+
+# NoSQL Schema
+variable "hash_key" {
+  description = "Partition key attribute for engine_type nosql: the DynamoDB hash key, the Cosmos DB partition key path, the field holding each Firestore document's ID"
+  type        = string
+  default     = null
+  validation {
+    condition     = (var.engine_type == "nosql") == (var.hash_key != null)
+    error_message = "engine_type nosql needs a hash_key, and engine_type sql takes none"
+  }
+}
+
+variable "hash_key_type" {
+  description = "Partition key type (S, N, B); Firestore document IDs are strings, so only S on gcp"
+  type        = string
+  default     = "S"
+  validation {
+    condition     = contains(["S", "N", "B"], var.hash_key_type)
+    error_message = "hash_key_type must be one of: S, N, B"
+  }
+  validation {
+    condition     = var.provider_name != "gcp" || var.hash_key_type == "S"
+    error_message = "Firestore document IDs are strings, so hash_key_type must be S on gcp"
+  }
+}
+
+variable "range_key" {
+  description = "Sort key attribute for engine_type nosql: the DynamoDB range key, the field Firestore queries order by; Cosmos DB containers have none"
+  type        = string
+  default     = null
+  validation {
+    condition     = var.range_key == null || var.engine_type == "nosql"
+    error_message = "range_key only applies to engine_type nosql"
+  }
+  validation {
+    condition     = var.range_key == null || var.provider_name != "azure"
+    error_message = "Cosmos DB containers are keyed by partition key alone, so range_key is not supported on azure"
+  }
+}
+
+variable "range_key_type" {
+  description = "Sort key type (S, N, B)"
+  type        = string
+  default     = "S"
+  validation {
+    condition     = contains(["S", "N", "B"], var.range_key_type)
+    error_message = "range_key_type must be one of: S, N, B"
+  }
+}
+
+# DynamoDB Capacity, Indexes and Streams (engine_type nosql)
+variable "billing_mode" {
+  description = "DynamoDB capacity: on_demand bills per request, provisioned reserves read_capacity and write_capacity units for the table and each index. Cosmos DB capacity is set by throughput_mode; Firestore bills per operation."
+  type        = string
+  default     = "on_demand"
+  validation {
+    condition     = contains(["on_demand", "provisioned"], var.billing_mode)
+    error_message = "billing_mode must be one of: on_demand, provisioned"
+  }
+  validation {
+    condition     = var.billing_mode == "on_demand" || (var.engine_type == "nosql" && var.provider_name == "aws")
+    error_message = "billing_mode provisioned only applies to engine_type nosql on aws; set throughput_mode for Cosmos DB, and Firestore bills per operation"
+  }
+}
+
+variable "read_capacity" {
+  description = "Read capacity units for the table and each global secondary index when billing_mode is provisioned"
+  type        = number
+  default     = null
+  validation {
+    condition     = var.billing_mode != "provisioned" || var.read_capacity != null
+    error_message = "billing_mode provisioned needs read_capacity and write_capacity"
+  }
+  validation {
+    condition     = var.read_capacity == null || var.billing_mode == "provisioned"
+    error_message = "read_capacity only applies to billing_mode provisioned"
+  }
+  validation {
+    condition     = var.read_capacity == null || try(var.read_capacity >= 1 && floor(var.read_capacity) == var.read_capacity, false)
+    error_message = "read_capacity must be a whole number of at least 1"
+  }
+}
+
+variable "write_capacity" {
+  description = "Write capacity units for the table and each global secondary index when billing_mode is provisioned"
+  type        = number
+  default     = null
+  validation {
+    condition     = var.billing_mode != "provisioned" || var.write_capacity != null
+    error_message = "billing_mode provisioned needs read_capacity and write_capacity"
+  }
+  validation {
+    condition     = var.write_capacity == null || var.billing_mode == "provisioned"
+    error_message = "write_capacity only applies to billing_mode provisioned"
+  }
+  validation {
+    condition     = var.write_capacity == null || try(var.write_capacity >= 1 && floor(var.write_capacity) == var.write_capacity, false)
+    error_message = "write_capacity must be a whole number of at least 1"
+  }
+}
+
+variable "global_secondary_indexes" {
+  description = "Alternate keys to query engine_type nosql items by: DynamoDB global secondary indexes, whose projection (ALL, KEYS_ONLY, INCLUDE) picks the attributes copied into them, INCLUDE adding non_key_attributes. On gcp an index with a range_key becomes a Firestore composite index on the collection; Cosmos DB indexes every property already, so azure creates nothing."
+  type = list(object({
+    name               = string
+    hash_key           = string
+    hash_key_type      = optional(string, "S")
+    range_key          = optional(string)
+    range_key_type     = optional(string, "S")
+    projection         = optional(string, "ALL")
+    non_key_attributes = optional(list(string), [])
+  }))
+  default = []
+  validation {
+    condition     = length(var.global_secondary_indexes) == 0 || var.engine_type == "nosql"
+    error_message = "global_secondary_indexes only apply to engine_type nosql"
+  }
+  validation {
+    condition     = length(var.global_secondary_indexes) <= 20
+    error_message = "DynamoDB tables take at most 20 global_secondary_indexes"
+  }
+  validation {
+    condition     = length(distinct([for gsi in var.global_secondary_indexes : gsi.name])) == length(var.global_secondary_indexes)
+    error_message = "global_secondary_indexes names must be unique, e.g. by-customer and by-status"
+  }
+  validation {
+    condition     = alltrue([for gsi in var.global_secondary_indexes : can(regex("^[A-Za-z0-9_.-]{3,255}$", gsi.name))])
+    error_message = "global_secondary_indexes names must be 3-255 letters, digits, underscores, hyphens or dots"
+  }
+  validation {
+    condition     = alltrue([for gsi in var.global_secondary_indexes : contains(["S", "N", "B"], gsi.hash_key_type) && contains(["S", "N", "B"], gsi.range_key_type)])
+    error_message = "global_secondary_indexes key types must be one of: S, N, B"
+  }
+  validation {
+    condition     = alltrue([for gsi in var.global_secondary_indexes : contains(["ALL", "KEYS_ONLY", "INCLUDE"], gsi.projection)])
+    error_message = "global_secondary_indexes projection must be one of: ALL, KEYS_ONLY, INCLUDE"
+  }
+  validation {
+    condition     = alltrue([for gsi in var.global_secondary_indexes : (gsi.projection == "INCLUDE") == (length(gsi.non_key_attributes) > 0)])
+    error_message = "global_secondary_indexes projection INCLUDE needs non_key_attributes, e.g. [\"total\"], and the other projections take none"
+  }
+}
+
+variable "enable_streams" {
+  description = "Record every item change of an engine_type nosql table, old and new images, in a DynamoDB stream (see stream_arn); Cosmos DB's change feed is always on, and Firestore has no stream"
+  type        = bool
+  default     = false
+  validation {
+    condition     = !var.enable_streams || var.engine_type == "nosql"
+    error_message = "enable_streams only applies to engine_type nosql"
+  }
+}
+
+# Cosmos DB Capacity (engine_type nosql on azure)
+variable "throughput_mode" {
+  description = "Cosmos DB capacity: serverless bills per request, provisioned reserves max_throughput RU/s, autoscale scales between 10% and 100% of max_throughput"
+  type        = string
+  default     = "serverless"
+  validation {
+    condition     = contains(["provisioned", "autoscale", "serverless"], var.throughput_mode)
+    error_message = "throughput_mode must be one of: provisioned, autoscale, serverless"
+  }
+  validation {
+    condition     = var.throughput_mode == "serverless" || (var.engine_type == "nosql" && var.provider_name == "azure")
+    error_message = "throughput_mode only applies to engine_type nosql on azure; set billing_mode for DynamoDB, and Firestore bills per operation"
+  }
+}
+
+variable "max_throughput" {
+  description = "Container RU/s: the fixed throughput when provisioned (400 when null), the ceiling when autoscale (1000 when null); must be null when serverless"
   type        = number
-  default     = 20
+  default     = null
+  validation {
+    condition     = var.max_throughput == null || (var.engine_type == "nosql" && var.provider_name == "azure")
+    error_message = "max_throughput only applies to engine_type nosql on azure"
+  }
+  validation {
+    condition     = var.max_throughput == null || var.throughput_mode != "serverless"
+    error_message = "Serverless Cosmos DB accounts bill per request and take no throughput; leave max_throughput unset or pick throughput_mode provisioned or autoscale"
+  }
+  validation {
+    condition     = var.max_throughput == null || var.throughput_mode != "autoscale" || (var.max_throughput >= 1000 && var.max_throughput <= 1000000 && var.max_throughput % 1000 == 0)
+    error_message = "Autoscale max_throughput must be 1000-1,000,000 RU/s in steps of 1000"
+  }
+  validation {
+    condition     = var.max_throughput == null || var.throughput_mode != "provisioned" || (var.max_throughput >= 400 && var.max_throughput <= 1000000 && var.max_throughput % 100 == 0)
+    error_message = "Provisioned max_throughput must be 400-1,000,000 RU/s in steps of 100"
+  }
+}
+
+variable "consistency_level" {
+  description = "Cosmos DB default consistency (Strong, BoundedStaleness, Session, ConsistentPrefix, Eventual)"
+  type        = string
+  default     = "Session"
+  validation {
+    condition     = contains(["Strong", "BoundedStaleness", "Session", "ConsistentPrefix", "Eventual"], var.consistency_level)
+    error_message = "consistency_level must be one of: Strong, BoundedStaleness, Session, ConsistentPrefix, Eventual"
+  }
 }
 
 # Credentials
@@ -67,9 +288,18 @@ variable "master_username" {
 }
 
 variable "master_password" {
-  description = "Master password"
+  description = "Master password for engine_type sql; must meet the provider's rules (see password_rules in main.tf)"
   type        = string
+  default     = null
   sensitive   = true
+  validation {
+    condition     = var.engine_type == "nosql" || var.master_password != null
+    error_message = "engine_type sql needs a master_password"
+  }
+  validation {
+    condition     = local.nosql || try(local.password_valid[var.provider_name], true)
+    error_message = "master_password does not meet the ${var.provider_name} password rules: ${try(local.password_rules[var.provider_name], "")}"
+  }
 }
 
 # Features
@@ -91,6 +321,25 @@ variable "storage_encrypted" {
   default     = true
 }
 
+variable "kms_key_ref" {
+  description = "Customer-managed key from the encryption facade (its kms_key_ref output); must belong to the same provider"
+  type = object({
+    provider = string
+    id       = string
+  })
+  default = null
+  validation {
+    condition     = var.kms_key_ref == null || try(contains(["aws", "azure", "gcp"], var.kms_key_ref.provider) && length(var.kms_key_ref.id) > 0, false)
+    error_message = "kms_key_ref must have provider aws, azure or gcp and a non-empty id, e.g. the encryption facade's kms_key_ref output"
+  }
+}
+
+variable "ttl_attribute" {
+  description = "Item expiry attribute for engine_type nosql, as on facade/nosql; SQL engines have no TTL, so there it only raises a warning"
+  type        = string
+  default     = null
+}
+
 variable "backup_retention_days" {
   description = "Backup retention days"
   type        = number
@@ -1,17 +1,35 @@
 # Encryption Facade
 
-locals {
-  common_tags = merge(
-    var.tags,
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy   = "Terraform"
-      Environment = var.environment
-      Project     = var.project_name
-      Module      = "Encryption-Facade"
-    }
+      Module = "Encryption-Facade"
+    },
+    var.tags
   )
 }
 
+# Key ring name by the project_name-environment-resource convention, made
+# legal for GCP
+module "key_ring_name" {
+  source = "../../common/naming"
+
+  resource_type = "gcp_kms_key_ring"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "keyring"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+}
+
 # AWS: KMS
 module "aws_kms" {
   count  = var.provider_name == "aws" ? 1 : 0
@@ -20,7 +38,7 @@ module "aws_kms" {
   name        = var.name
   description = var.description
   
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # Azure: Key Vault Key
@@ -30,7 +48,7 @@ module "azure_kms" {
 
   name         = var.name
   key_vault_id = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/vault" # Placeholder
-  tags         = local.common_tags
+  tags         = local.default_tags
 }
 
 # GCP: KMS
@@ -39,10 +57,10 @@ module "gcp_kms" {
   source = "../../gcp/core/encryption"
 
   project_id    = var.project_name
-  key_ring_name = "${var.project_name}-${var.environment}-keyring"
+  key_ring_name = module.key_ring_name.name
   key_name      = var.name
   location      = "global"
-  labels        = local.common_tags
+  labels        = local.default_labels
 }
 
 output "key_id" {
@@ -62,3 +80,20 @@ output "key_arn" {
     null
   )
 }
+
+# Shared key reference accepted by the storage, messaging, database and lambda
+# facades (kms_key_ref input). The id is what each provider's CMK settings
+# expect: the key ARN on AWS, the versionless Key Vault key ID on Azure (so
+# rotation is picked up) and the crypto key ID on GCP.
+output "kms_key_ref" {
+  description = "Customer-managed key reference ({provider, id}) for other facades"
+  value = {
+    provider = var.provider_name
+    id = (
+      var.provider_name == "aws" ? (length(module.aws_kms) > 0 ? module.aws_kms[0].key_arn : null) :
+      var.provider_name == "azure" ? (length(module.azure_kms) > 0 ? module.azure_kms[0].versionless_id : null) :
+      var.provider_name == "gcp" ? (length(module.gcp_kms) > 0 ? module.gcp_kms[0].key_id : null) :
+      null
+    )
+  }
+}
@@ -0,0 +1,196 @@
+package eventbus_test
+
+import (
+	"testing"
+
+	"iac/testutil/planerr"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	ordersPattern = `{"source": ["orders"], "detail-type": ["OrderPlaced"]}`
+	auditPattern  = `{"source": ["orders", "billing"]}`
+)
+
+func TestEventBusFacadeAws(t *testing.T) {
+	t.Parallel()
+
+	planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"bus_name":      "orders-bus",
+			"rules": []map[string]interface{}{
+				{"name": "orders", "pattern": ordersPattern, "target_ref": map[string]interface{}{
+					"type": "lambda",
+					"id":   "arn:aws:lambda:us-east-1:123456789012:function:orders-fn",
+				}},
+				{"name": "audit", "pattern": auditPattern, "target_arn": "arn:aws:sqs:us-east-1:123456789012:audit"},
+			},
+		},
+		NoColor: true,
+	}))
+
+	assert.Contains(t, planString, "module.aws_eventbus[0].aws_cloudwatch_event_bus.this")
+	assert.Contains(t, planString, `module.aws_eventbus[0].aws_cloudwatch_event_rule.this["orders"]`)
+	assert.Contains(t, planString, `module.aws_eventbus[0].aws_cloudwatch_event_rule.this["audit"]`)
+	assert.Contains(t, planString, "OrderPlaced", "Rule should carry the event pattern")
+
+	// Each target gets the permission it needs, and only that one
+	assert.Contains(t, planString, `module.aws_eventbus[0].aws_lambda_permission.events["orders"]`)
+	assert.NotContains(t, planString, `aws_lambda_permission.events["audit"]`)
+	assert.Contains(t, planString, `module.aws_eventbus[0].aws_sqs_queue_policy.events["audit"]`)
+	assert.Contains(t, planString, "https://sqs.us-east-1.amazonaws.com/123456789012/audit")
+	assert.Regexp(t, `principal\s+= "events.amazonaws.com"`, planString)
+}
+
+func TestEventBusFacadeAzure(t *testing.T) {
+	t.Parallel()
+
+	const (
+		functionApp = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/test-rg/providers/Microsoft.Web/sites/orders-fn"
+		queue       = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/test-rg/providers/Microsoft.ServiceBus/namespaces/test-sb/queues/audit"
+	)
+
+	planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "azure",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"bus_name":      "orders-bus",
+			"rules": []map[string]interface{}{
+				{"name": "orders", "pattern": ordersPattern, "target_ref": map[string]interface{}{"type": "lambda", "id": functionApp}},
+				{"name": "audit", "pattern": auditPattern, "target_arn": queue},
+			},
+			"provider_config": map[string]interface{}{"resource_group_name": "test-rg", "location": "eastus"},
+		},
+		NoColor: true,
+	}))
+
+	assert.Contains(t, planString, "module.azure_eventbus[0].azurerm_eventgrid_topic.this")
+	assert.Contains(t, planString, `module.azure_eventbus[0].azurerm_eventgrid_event_subscription.this["orders"]`)
+	assert.Contains(t, planString, `module.azure_eventbus[0].azurerm_eventgrid_event_subscription.this["audit"]`)
+	assert.Regexp(t, `input_schema\s+= "CloudEventSchemaV1_0"`, planString)
+
+	// The Function App ID is expanded to the function it hosts
+	assert.Contains(t, planString, functionApp+"/functions/orders-fn")
+	assert.Regexp(t, `service_bus_queue_endpoint_id\s+= "`+queue+`"`, planString)
+	assert.Contains(t, planString, `"OrderPlaced"`, "detail-type should become an included event type")
+	assert.Contains(t, planString, `"billing"`, "source should become a string_in filter")
+	assert.NotContains(t, planString, "Check block assertion failed", "source and detail-type patterns are portable to Event Grid")
+}
+
+func TestEventBusFacadeGcp(t *testing.T) {
+	t.Parallel()
+
+	planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "gcp",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"bus_name":      "orders-bus",
+			"rules": []map[string]interface{}{
+				{"name": "orders", "pattern": ordersPattern, "target_ref": map[string]interface{}{
+					"type": "lambda",
+					"id":   "projects/test-project/locations/us-east1/functions/orders-fn",
+				}},
+				{"name": "audit", "pattern": auditPattern, "target_arn": "projects/test-project/locations/europe-west1/services/audit-svc"},
+			},
+			"provider_config": map[string]interface{}{"project_id": "test-project"},
+		},
+		NoColor: true,
+	}))
+
+	assert.Contains(t, planString, "module.gcp_eventbus[0].google_pubsub_topic.this")
+	assert.Contains(t, planString, `module.gcp_eventbus[0].google_eventarc_trigger.this["orders"]`)
+	assert.Contains(t, planString, `module.gcp_eventbus[0].google_eventarc_trigger.this["audit"]`)
+	assert.Regexp(t, `service\s+= "orders-fn"`, planString)
+	assert.Regexp(t, `region\s+= "us-east1"`, planString)
+	assert.Regexp(t, `service\s+= "audit-svc"`, planString)
+	assert.Regexp(t, `region\s+= "europe-west1"`, planString)
+	assert.Contains(t, planString, "every rule receives every event", "Plan should warn that Eventarc ignores patterns")
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"bus_name":      "orders-bus",
+	}
+
+	lambda := "arn:aws:lambda:us-east-1:123456789012:function:orders-fn"
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "MalformedPattern",
+			Vars: map[string]interface{}{"rules": []map[string]interface{}{
+				{"name": "orders", "pattern": `{"source": ["orders"]`, "target_arn": lambda},
+			}},
+			Want: "rules[*].pattern must be a JSON object",
+		},
+		{
+			Name: "PatternNotAnObject",
+			Vars: map[string]interface{}{"rules": []map[string]interface{}{
+				{"name": "orders", "pattern": `["orders"]`, "target_arn": lambda},
+			}},
+			Want: "rules[*].pattern must be a JSON object",
+		},
+		{
+			Name: "NoTarget",
+			Vars: map[string]interface{}{"rules": []map[string]interface{}{
+				{"name": "orders", "pattern": ordersPattern},
+			}},
+			Want: "Each entry in rules needs exactly one of target_arn and target_ref",
+		},
+		{
+			Name: "TwoTargets",
+			Vars: map[string]interface{}{"rules": []map[string]interface{}{
+				{"name": "orders", "pattern": ordersPattern, "target_arn": lambda, "target_ref": map[string]interface{}{"type": "lambda", "id": lambda}},
+			}},
+			Want: "Each entry in rules needs exactly one of target_arn and target_ref",
+		},
+		{
+			Name: "UnknownTargetRefType",
+			Vars: map[string]interface{}{"rules": []map[string]interface{}{
+				{"name": "orders", "pattern": ordersPattern, "target_ref": map[string]interface{}{"type": "topic", "id": lambda}},
+			}},
+			Want: "rules[*].target_ref.type must be one of: lambda, queue",
+		},
+		{
+			Name: "DuplicateRuleNames",
+			Vars: map[string]interface{}{"rules": []map[string]interface{}{
+				{"name": "orders", "pattern": ordersPattern, "target_arn": lambda},
+				{"name": "orders", "pattern": auditPattern, "target_arn": lambda},
+			}},
+			Want: "rules[*].name must be unique",
+		},
+		{
+			Name: "RuleNameWithUnderscore",
+			Vars: map[string]interface{}{"rules": []map[string]interface{}{
+				{"name": "new_orders", "pattern": ordersPattern, "target_arn": lambda},
+			}},
+			Want: "rules[*].name must be 3-63 lower case letters, digits and hyphens",
+		},
+		{
+			// Valid on its own, rejected by the bus_arn precondition
+			Name: "QueueTargetOnGcp",
+			Vars: map[string]interface{}{
+				"provider_name": "gcp",
+				"rules": []map[string]interface{}{
+					{"name": "audit", "pattern": auditPattern, "target_ref": map[string]interface{}{"type": "queue", "id": "projects/p/subscriptions/audit"}},
+				},
+			},
+			Want: "queue targets are not supported on gcp",
+		},
+	})
+}
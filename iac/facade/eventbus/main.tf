@@ -0,0 +1,166 @@
+# Event Bus Facade
+# Unified interface for event buses with rules routing to targets
+
+terraform {
+  # check blocks
+  required_version = ">= 1.5"
+}
+
+# ============================================================================
+# IMPORT COMMON LAYER
+# ============================================================================
+
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
+    {
+      Provider = var.provider_name
+      Module   = "EventBus-Facade"
+    },
+    var.tags
+  )
+}
+
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  # Rules with their target resolved. A target_arn is classified by shape,
+  # so it must be known at plan time; target_ref carries its type.
+  rules = [
+    for r in var.rules : {
+      name    = r.name
+      pattern = r.pattern
+      target  = r.target_ref != null ? r.target_ref.id : r.target_arn
+      target_type = (
+        r.target_ref != null ? r.target_ref.type :
+        can(regex(":lambda:|/Microsoft\\.Web/sites/|/functions/|/services/", r.target_arn)) ? "lambda" :
+        can(regex(":sqs:|/Microsoft\\.ServiceBus/namespaces/[^/]+/queues/", r.target_arn)) ? "queue" :
+        "other"
+      )
+    }
+  ]
+
+  # Event Grid filters on the CloudEvents type and source attributes, which
+  # is where the detail-type and source of an EventBridge pattern end up
+  azure_subscriptions = [
+    for r in local.rules : {
+      name                 = r.name
+      included_event_types = try(tolist(jsondecode(r.pattern)["detail-type"]), [])
+      sources              = try(tolist(jsondecode(r.pattern)["source"]), [])
+      target_type          = r.target_type
+
+      # A Function App from the lambda facade hosts one function of the same name
+      target_id = r.target_type == "lambda" && !can(regex("/functions/", r.target)) ? "${r.target}/functions/${basename(r.target)}" : r.target
+    }
+  ]
+
+  # projects/<project>/locations/<region>/functions|services/<name>
+  gcp_triggers = [
+    for r in local.rules : {
+      name    = r.name
+      service = try(regex("/(?:functions|services)/([^/]+)$", r.target)[0], r.target)
+      region  = try(regex("/locations/([^/]+)/", r.target)[0], lookup(var.provider_config, "region", "us-central1"))
+    }
+  ]
+}
+
+# ============================================================================
+# PROVIDER-SPECIFIC MODULE ROUTING
+# ============================================================================
+
+# AWS: EventBridge bus, rules and targets
+module "aws_eventbus" {
+  count  = var.provider_name == "aws" ? 1 : 0
+  source = "../../aws/core/events"
+
+  name = var.bus_name
+  rules = [
+    for r in local.rules : {
+      name        = r.name
+      pattern     = r.pattern
+      target_arn  = r.target
+      target_type = r.target_type
+    }
+  ]
+
+  tags = local.default_tags
+}
+
+# Azure: Event Grid topic and event subscriptions, in CloudEvents schema
+module "azure_eventbus" {
+  count  = var.provider_name == "azure" ? 1 : 0
+  source = "../../azure/core/events"
+
+  name                = var.bus_name
+  resource_group_name = lookup(var.provider_config, "resource_group_name", module.resource_group_name.name)
+  location            = lookup(var.provider_config, "location", "East US")
+  input_schema        = "CloudEventSchemaV1_0"
+  subscriptions       = local.azure_subscriptions
+
+  tags = local.default_tags
+}
+
+# GCP: Pub/Sub topic with Eventarc triggers
+module "gcp_eventbus" {
+  count  = var.provider_name == "gcp" ? 1 : 0
+  source = "../../gcp/core/events"
+
+  project_id              = lookup(var.provider_config, "project_id", var.project_name)
+  topic_name              = var.bus_name
+  triggers                = local.gcp_triggers
+  trigger_service_account = lookup(var.provider_config, "service_account", null)
+
+  labels = local.default_labels
+}
+
+# Patterns are only partly portable; warn rather than fail, so one rule set
+# can be planned on every provider
+check "azure_patterns" {
+  assert {
+    condition = var.provider_name != "azure" || alltrue([
+      for r in var.rules : length(setsubtract(keys(jsondecode(r.pattern)), ["source", "detail-type"])) == 0
+    ])
+    error_message = "Event Grid subscriptions only filter on a pattern's source and detail-type; other fields are ignored on azure, so filter in the target."
+  }
+}
+
+check "gcp_patterns" {
+  assert {
+    condition     = var.provider_name != "gcp" || length(var.rules) == 0
+    error_message = "Eventarc Pub/Sub triggers cannot filter on message content, so on gcp every rule receives every event regardless of its pattern; filter in the target."
+  }
+}
+
+# ============================================================================
+# AGGREGATED OUTPUTS
+# ============================================================================
+
+locals {
+  bus_arn = (
+    var.provider_name == "aws"   ? (length(module.aws_eventbus) > 0 ? module.aws_eventbus[0].event_resource_arn : null) :
+    var.provider_name == "azure" ? (length(module.azure_eventbus) > 0 ? module.azure_eventbus[0].topic_id : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_eventbus) > 0 ? module.gcp_eventbus[0].topic_id : null) :
+    null
+  )
+
+  rule_ids = (
+    var.provider_name == "aws"   ? (length(module.aws_eventbus) > 0 ? module.aws_eventbus[0].rule_arns : {}) :
+    var.provider_name == "azure" ? (length(module.azure_eventbus) > 0 ? module.azure_eventbus[0].subscription_ids : {}) :
+    var.provider_name == "gcp"   ? (length(module.gcp_eventbus) > 0 ? module.gcp_eventbus[0].trigger_ids : {}) :
+    {}
+  )
+}
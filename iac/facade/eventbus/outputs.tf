@@ -0,0 +1,34 @@
+output "bus_arn" {
+  description = "Bus identifier for publishers (EventBridge bus ARN / Event Grid topic ID / Pub/Sub topic ID)"
+  value       = local.bus_arn
+
+  precondition {
+    condition     = var.provider_name == "aws" || alltrue([for r in local.rules : contains(["lambda", "queue"], r.target_type)])
+    error_message = "On ${var.provider_name} every rule must target a function or a queue; other targets are only supported on aws"
+  }
+
+  precondition {
+    condition     = var.provider_name != "gcp" || alltrue([for r in local.rules : r.target_type == "lambda"])
+    error_message = "Eventarc triggers deliver to Cloud Run services and 2nd gen Cloud Functions, so queue targets are not supported on gcp"
+  }
+}
+
+output "topic_id" {
+  description = "Bus resource identifier (same value as bus_arn)"
+  value       = local.bus_arn
+}
+
+output "bus_name" {
+  description = "Name of the bus"
+  value       = var.bus_name
+}
+
+output "rule_ids" {
+  description = "Rule identifier by rule name (EventBridge rule ARN / Event Grid subscription ID / Eventarc trigger ID)"
+  value       = local.rule_ids
+}
+
+output "provider" {
+  description = "Cloud provider"
+  value       = var.provider_name
+}
@@ -0,0 +1,89 @@
+# General Configuration
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp)"
+  type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
+}
+
+variable "project_name" {
+  description = "Project name"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+# Bus Configuration
+variable "bus_name" {
+  description = "Event bus name (EventBridge bus / Event Grid topic / Pub/Sub topic)"
+  type        = string
+}
+
+variable "rules" {
+  description = <<-EOT
+    Rules routing events on the bus to one target each:
+      - name:       rule name, 3-63 lower case letters, digits and hyphens
+      - pattern:    EventBridge-style event pattern JSON. Azure applies its
+                    source and detail-type lists; GCP cannot filter, so every
+                    rule there receives every event.
+      - target_arn: provider identifier of the target (Lambda or SQS ARN,
+                    Function or Service Bus queue ID, Cloud Function or Cloud
+                    Run service name), or
+      - target_ref: { type = "lambda" | "queue", id = the lambda facade's
+                    function_arn or the messaging facade's queue_id }
+    Lambda and queue targets get the permission to receive events.
+  EOT
+  type = list(object({
+    name       = string
+    pattern    = string
+    target_arn = optional(string)
+    target_ref = optional(object({
+      type = string
+      id   = string
+    }))
+  }))
+  default = []
+  validation {
+    condition     = alltrue([for r in var.rules : can(regex("^[a-z][a-z0-9-]{2,62}$", r.name))])
+    error_message = "rules[*].name must be 3-63 lower case letters, digits and hyphens, starting with a letter, e.g. order-created"
+  }
+  validation {
+    condition     = length(distinct([for r in var.rules : r.name])) == length(var.rules)
+    error_message = "rules[*].name must be unique, e.g. order-created and order-shipped rather than order-created twice"
+  }
+  validation {
+    condition     = alltrue([for r in var.rules : can(keys(jsondecode(r.pattern)))])
+    error_message = "rules[*].pattern must be a JSON object, like {\"source\": [\"orders\"]}"
+  }
+  validation {
+    condition     = alltrue([for r in var.rules : (r.target_arn == null) != (r.target_ref == null)])
+    error_message = "Each entry in rules needs exactly one of target_arn and target_ref"
+  }
+  validation {
+    condition     = alltrue([for r in var.rules : r.target_ref == null || contains(["lambda", "queue"], try(r.target_ref.type, ""))])
+    error_message = "rules[*].target_ref.type must be one of: lambda, queue"
+  }
+}
+
+# Provider Specifics
+variable "provider_config" {
+  description = "Provider-specific configuration: resource_group_name and location on Azure (default <project>-<environment>-rg in East US); project_id, region and service_account on GCP"
+  type        = any
+  default     = {}
+}
+
+variable "tags" {
+  description = "Additional tags"
+  type        = map(string)
+  default     = {}
+}
@@ -1,24 +1,40 @@
 # Events Facade
 
-locals {
-  common_tags = merge(
-    var.tags,
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy   = "Terraform"
-      Environment = var.environment
-      Project     = var.project_name
-      Module      = "Events-Facade"
-    }
+      Module = "Events-Facade"
+    },
+    var.tags
   )
 }
 
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+}
+
 # AWS: EventBridge
 module "aws_events" {
   count  = var.provider_name == "aws" ? 1 : 0
   source = "../../aws/core/events"
 
   name = var.name
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # Azure: Event Grid
@@ -27,9 +43,9 @@ module "azure_events" {
   source = "../../azure/core/events"
 
   name                = var.name
-  resource_group_name = "${var.project_name}-${var.environment}-rg"
+  resource_group_name = module.resource_group_name.name
   location            = "East US"
-  tags                = local.common_tags
+  tags                = local.default_tags
 }
 
 # GCP: PubSub
@@ -39,7 +55,7 @@ module "gcp_events" {
 
   project_id = var.project_name
   topic_name = var.name
-  labels     = local.common_tags
+  labels     = local.default_labels
 }
 
 output "event_resource_id" {
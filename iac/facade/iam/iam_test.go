@@ -1,22 +1,29 @@
 package iam_test
 
 import (
-	"testing"
+	"context"
+	"path/filepath"
 	"strings"
+	"testing"
+
+	"iac/testutil/planerr"
+	"iac/testutil/policycheck"
+	"iac/testutil/tfopts"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIamFacadeAws(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "aws",
+			"provider_name": "aws",
 			"project_name":  "testproject",
-			"environment":   "test",
+			"environment":   "dev",
 			"identity_name": "test-role",
 			"identity_type": "role",
 			"principals":    []string{"ec2.amazonaws.com"},
@@ -25,20 +32,74 @@ func TestIamFacadeAws(t *testing.T) {
 	})
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
-	
+
 	assert.True(t, strings.Contains(planString, "module.aws_iam[0].aws_iam_role.this"), "Plan should create an AWS IAM role")
 	assert.True(t, strings.Contains(planString, "name = \"test-role\""), "Plan should have the correct role name")
+	assert.True(t, strings.Contains(planString, "Service"), "Trust policy should contain a Service principal block")
+	assert.True(t, strings.Contains(planString, "ec2.amazonaws.com"), "Trust policy should trust the EC2 service")
+	assert.False(t, strings.Contains(planString, "sts:ExternalId"), "Service-only trust policy should not require an external ID")
+}
+
+func TestIamFacadeAwsAccountPrincipal(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"identity_name": "test-account-role",
+			"identity_type": "role",
+			"principals":    []string{"123456789012"},
+		},
+		BackendConfig: map[string]interface{}{},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.aws_iam[0].aws_iam_role.this"), "Plan should create an AWS IAM role")
+	assert.True(t, strings.Contains(planString, "arn:aws:iam::123456789012:root"), "Raw account IDs should be trusted via the account root ARN")
+	assert.False(t, strings.Contains(planString, "Service"), "Account-only trust policy should not contain a Service principal block")
+}
+
+func TestIamFacadeAwsCrossAccountArnWithExternalId(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"identity_name": "test-xacct-role",
+			"identity_type": "role",
+			"principals": []string{
+				"lambda.amazonaws.com",
+				"arn:aws:iam::210987654321:role/deployer",
+			},
+			"external_id": "partner-4f2a",
+		},
+		BackendConfig: map[string]interface{}{},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "lambda.amazonaws.com"), "Service principals should still be trusted alongside ARNs")
+	assert.True(t, strings.Contains(planString, "arn:aws:iam::210987654321:role/deployer"), "Cross-account role ARN should be trusted as-is")
+	assert.True(t, strings.Contains(planString, "sts:ExternalId"), "Cross-account statement should carry the external ID condition")
+	assert.True(t, strings.Contains(planString, "partner-4f2a"), "Condition should use the configured external ID")
 }
 
 func TestIamFacadeAzure(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "azure",
+			"provider_name": "azure",
 			"project_name":  "testproject",
-			"environment":   "test",
+			"environment":   "dev",
 			"identity_name": "test-id",
 			"identity_type": "user",
 			"provider_config": map[string]interface{}{
@@ -54,15 +115,77 @@ func TestIamFacadeAzure(t *testing.T) {
 	assert.True(t, strings.Contains(planString, "name = \"test-id\""), "Plan should have the correct identity name")
 }
 
+func TestIamFacadeAwsResourceGrants(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"identity_name": "orders-fn",
+			"identity_type": "role",
+			"principals":    []string{"lambda.amazonaws.com"},
+			"resource_grants": []map[string]interface{}{
+				{"capability": "storage_write", "resource": "arn:aws:s3:::orders-data"},
+				{"capability": "queue_consume", "resource": "arn:aws:sqs:us-east-1:123456789012:orders"},
+			},
+		},
+		NoColor: true,
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Contains(t, planString, "module.aws_iam[0].aws_iam_policy.this[0]", "Grants should become one policy")
+	assert.Contains(t, planString, "module.aws_iam[0].aws_iam_role_policy_attachment.custom[0]", "The policy should be attached to the role")
+	assert.Regexp(t, `name\s+= "orders-fn-grants"`, planString)
+	assert.Contains(t, planString, `"arn:aws:s3:::orders-data/*"`, "Bucket grants should cover the bucket's objects")
+	assert.Contains(t, planString, `"s3:PutObject"`)
+	assert.Contains(t, planString, `"sqs:ReceiveMessage"`)
+	assert.NotContains(t, planString, `"sqs:SendMessage"`, "queue_consume should not allow sending")
+	assert.NotContains(t, planString, "AmazonS3FullAccess", "Grants should not fall back to account-wide policies")
+}
+
+func TestIamFacadeAzureResourceGrants(t *testing.T) {
+	t.Parallel()
+
+	container := "/subscriptions/sub/resourceGroups/test-rg/providers/Microsoft.Storage/storageAccounts/orders/blobServices/default/containers/data"
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "azure",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"identity_name": "orders-fn",
+			"identity_type": "service_agent",
+			"provider_config": map[string]interface{}{
+				"resource_group_name": "test-rg",
+				"location":            "eastus",
+			},
+			"resource_grants": []map[string]interface{}{
+				{"capability": "storage_write", "resource": container},
+			},
+		},
+		NoColor: true,
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Contains(t, planString, "module.azure_iam[0].azurerm_role_assignment.resource[0]", "Grants should become role assignments")
+	assert.Regexp(t, `role_definition_name\s+= "Storage Blob Data Contributor"`, planString)
+	assert.Contains(t, planString, `"`+container+`"`, "The role should be assigned at the container, not the subscription")
+}
+
 func TestIamFacadeGcp(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "gcp",
+			"provider_name": "gcp",
 			"project_name":  "testproject",
-			"environment":   "test",
+			"environment":   "dev",
 			"identity_name": "test-sa-unique",
 			"identity_type": "service_agent",
 			"provider_config": map[string]interface{}{
@@ -77,19 +200,187 @@ func TestIamFacadeGcp(t *testing.T) {
 	assert.True(t, strings.Contains(planString, "account_id = \"test-sa-unique\""), "Plan should have the correct account ID")
 }
 
-func TestIamFacadeInvalidProvider(t *testing.T) {
+// TestIamFacadeAccessCredentials plans create_access_credentials on each
+// provider, with and without credentials_expiry_days, and checks the
+// credential resource, that its outputs are sensitive in the JSON plan, and
+// that the credentials policy only passes when an expiry is set
+func TestIamFacadeAccessCredentials(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := &terraform.Options{
-		TerraformDir: ".",
-		Vars: map[string]interface{}{
-			"provider":      "invalid-cloud", // Should fail validation
-			"project_name":  "testproject",
-			"environment":   "test",
-			"identity_name": "test-role",
+	cases := map[string]struct {
+		vars     map[string]interface{}
+		resource string
+		outputs  []string
+	}{
+		"aws": {
+			resource: "module.aws_iam[0].aws_iam_access_key.this[0]",
+			outputs:  []string{"access_key_id", "access_key_secret"},
+		},
+		"azure": {
+			vars: map[string]interface{}{
+				"provider_config": map[string]interface{}{"resource_group_name": "test-rg", "location": "eastus"},
+			},
+			resource: "module.azure_iam[0].azuread_application_password.this[0]",
+			outputs:  []string{"access_key_id", "access_key_secret"},
+		},
+		"gcp": {
+			vars: map[string]interface{}{
+				"provider_config": map[string]interface{}{"project_id": "test-project"},
+			},
+			resource: "module.gcp_iam[0].google_service_account_key.this[0]",
+			outputs:  []string{"credentials_json"},
 		},
 	}
 
-	_, err := terraform.InitAndPlanE(t, terraformOptions)
-	assert.Error(t, err, "Plan should fail with an invalid provider")
+	for provider, tc := range cases {
+		for _, expiry := range []interface{}{90, nil} {
+			provider, tc, expiry := provider, tc, expiry
+
+			name := provider + "/no-expiry"
+			if expiry != nil {
+				name = provider + "/expiry"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				vars := map[string]interface{}{
+					"provider_name":             provider,
+					"project_name":              "testproject",
+					"environment":               "dev",
+					"identity_name":             "ci-bootstrap",
+					"identity_type":             "service_agent",
+					"create_access_credentials": true,
+					"credentials_expiry_days":   expiry,
+				}
+				for k, v := range tc.vars {
+					vars[k] = v
+				}
+
+				terraformOptions := tfopts.New(t, &terraform.Options{
+					TerraformDir: ".",
+					Vars:         vars,
+					PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+					NoColor:      true,
+				})
+				planJSON := terraform.InitAndPlanAndShow(t, terraformOptions)
+				plan, err := terraform.ParsePlanJSON(planJSON)
+				require.NoError(t, err)
+
+				assert.Contains(t, plan.ResourcePlannedValuesMap, tc.resource)
+				for _, name := range tc.outputs {
+					output, ok := plan.RawPlan.PlannedValues.Outputs[name]
+					if assert.True(t, ok, "Plan should have output %s", name) {
+						assert.True(t, output.Sensitive, "Output %s should be sensitive", name)
+					}
+				}
+
+				violations, err := policycheck.Evaluate(context.Background(), []byte(planJSON))
+				require.NoError(t, err)
+				var flagged []string
+				for _, v := range violations {
+					if v.Policy == "credentials" {
+						flagged = append(flagged, v.Address)
+					}
+				}
+				if expiry != nil {
+					assert.Empty(t, flagged, "Credentials with an expiry should pass the credentials policy")
+				} else {
+					assert.Equal(t, []string{tc.resource}, flagged, "Credentials without an expiry should be flagged")
+				}
+			})
+		}
+	}
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"identity_name": "test-role",
+		"identity_type": "role",
+		"principals":    []string{"ec2.amazonaws.com"},
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name:     "IdentityTypeTypo",
+			Vars:     map[string]interface{}{"identity_type": "rol"},
+			Want:     "Identity type must be one of: role, user, service_agent",
+			Variable: "identity_type",
+		},
+		{
+			Name:     "MalformedPrincipal",
+			Vars:     map[string]interface{}{"principals": []string{"12345"}}, // Neither a service, account ID nor ARN
+			Want:     "Each of principals must be an AWS service principal",
+			Variable: "principals",
+		},
+		{
+			Name:     "ArnWithoutAccount",
+			Vars:     map[string]interface{}{"principals": []string{"arn:aws:iam::role/deployer"}},
+			Want:     "Each of principals must be an AWS service principal",
+			Variable: "principals",
+		},
+		{
+			Name: "ExternalIDTooShort",
+			Vars: map[string]interface{}{
+				"principals":  []string{"123456789012"},
+				"external_id": "x",
+			},
+			Want:     "External ID must be 2-1224 characters",
+			Variable: "external_id",
+		},
+		{
+			Name:     "CredentialsForRole",
+			Vars:     map[string]interface{}{"create_access_credentials": true, "credentials_expiry_days": 90},
+			Want:     "create_access_credentials needs identity_type user or service_agent",
+			Variable: "create_access_credentials",
+		},
+		{
+			Name:     "CredentialsOnZeroCloud",
+			Vars:     map[string]interface{}{"provider_name": "zero", "identity_type": "user", "create_access_credentials": true},
+			Want:     "create_access_credentials is not supported on zero",
+			Variable: "create_access_credentials",
+		},
+		{
+			Name:     "ExpiryWithoutCredentials",
+			Vars:     map[string]interface{}{"credentials_expiry_days": 90},
+			Want:     "credentials_expiry_days only applies with create_access_credentials",
+			Variable: "credentials_expiry_days",
+		},
+		{
+			Name:     "UnknownGrant",
+			Vars:     map[string]interface{}{"resource_grants": []map[string]interface{}{{"capability": "storage_admin", "resource": "arn:aws:s3:::orders"}}},
+			Want:     "Each resource_grants capability must be one of: storage_read, storage_write",
+			Variable: "resource_grants",
+		},
+		{
+			Name: "GrantsOnGcp",
+			Vars: map[string]interface{}{
+				"provider_name":   "gcp",
+				"identity_type":   "service_agent",
+				"resource_grants": []map[string]interface{}{{"capability": "storage_read", "resource": "orders"}},
+			},
+			Want:     "resource_grants is only available on aws and azure",
+			Variable: "resource_grants",
+		},
+		{
+			Name: "GrantsOnAwsUser",
+			Vars: map[string]interface{}{
+				"identity_type":   "user",
+				"resource_grants": []map[string]interface{}{{"capability": "storage_read", "resource": "arn:aws:s3:::orders"}},
+			},
+			Want:     "resource_grants on aws are attached to a role",
+			Variable: "resource_grants",
+		},
+		{
+			Name:     "ExpiryTooLong",
+			Vars:     map[string]interface{}{"identity_type": "user", "create_access_credentials": true, "credentials_expiry_days": 1000},
+			Want:     "credentials_expiry_days must be a whole number of days from 1 to 730",
+			Variable: "credentials_expiry_days",
+		},
+	})
 }
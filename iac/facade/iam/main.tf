@@ -2,23 +2,41 @@
 # Unified interface for Identity resources across providers
 
 terraform {
-  required_version = ">= 1.0"
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
 }
 
 # ============================================================================
 # IMPORT COMMON LAYER
 # ============================================================================
 
-  common_tags = merge(
-    var.tags,
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy    = "Terraform"
-      Environment  = var.environment
-      Provider     = var.provider_name
-      Project      = var.project_name
-      Module       = "IAM-Facade"
-    }
+      Provider = var.provider_name
+      Module   = "IAM-Facade"
+    },
+    var.tags
   )
+}
+
+# GCP service account ids are 6-30 characters starting with a letter; a
+# longer or shorter identity_name is cut or padded to fit
+module "service_account_id" {
+  source = "../../common/naming"
+
+  resource_type = "gcp_service_account"
+  name          = var.identity_name
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
 
   # Unified Capability Mapping
   # Maps abstract roles (e.g. "storage_read") to provider-specific policies/ARNs
@@ -67,6 +85,76 @@ terraform {
   
   # Remove nulls (unsupported roles for a provider)
   final_roles = [for r in local.selected_roles : r if r != null]
+
+  # Resource Grants
+  # The IAM actions each capability allows on AWS, and the built-in data
+  # role it assigns on Azure
+  grant_actions = {
+    storage_read  = ["s3:GetObject", "s3:ListBucket"]
+    storage_write = ["s3:GetObject", "s3:ListBucket", "s3:PutObject", "s3:DeleteObject"]
+    nosql_read    = ["dynamodb:DescribeTable", "dynamodb:GetItem", "dynamodb:BatchGetItem", "dynamodb:Query", "dynamodb:Scan"]
+    nosql_write   = ["dynamodb:DescribeTable", "dynamodb:GetItem", "dynamodb:BatchGetItem", "dynamodb:Query", "dynamodb:Scan", "dynamodb:PutItem", "dynamodb:UpdateItem", "dynamodb:DeleteItem", "dynamodb:BatchWriteItem"]
+    queue_send    = ["sqs:GetQueueUrl", "sqs:GetQueueAttributes", "sqs:SendMessage"]
+    queue_consume = ["sqs:GetQueueUrl", "sqs:GetQueueAttributes", "sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility"]
+  }
+
+  grant_roles = {
+    storage_read  = "Storage Blob Data Reader"
+    storage_write = "Storage Blob Data Contributor"
+    nosql_read    = "Cosmos DB Account Reader Role"
+    nosql_write   = "DocumentDB Account Contributor"
+    queue_send    = "Azure Service Bus Data Sender"
+    queue_consume = "Azure Service Bus Data Receiver"
+  }
+
+  # One statement per grant; "<resource>/*" covers a bucket's objects and a
+  # table's indexes
+  grant_policy = length(var.resource_grants) > 0 ? jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      for g in var.resource_grants : {
+        Effect   = "Allow"
+        Action   = local.grant_actions[g.capability]
+        Resource = [g.resource, "${g.resource}/*"]
+      }
+    ]
+  }) : null
+
+  # Principal Classification
+  # Sorts var.principals into the trust shapes each provider understands:
+  # AWS service principals, AWS account IDs (expanded to the account root),
+  # AWS IAM ARNs, Azure object IDs and GCP IAM members.
+  aws_service_principals = [
+    for p in var.principals : p
+    if can(regex("^[a-z0-9.-]+\\.amazonaws\\.com(\\.cn)?$", p))
+  ]
+
+  aws_account_principals = [
+    for p in var.principals : "arn:aws:iam::${p}:root"
+    if can(regex("^[0-9]{12}$", p))
+  ]
+
+  aws_arn_principals = [
+    for p in var.principals : p
+    if can(regex("^arn:aws[a-z-]*:iam::[0-9]{12}:(root|role/.+|user/.+)$", p))
+  ]
+
+  azure_object_principals = [
+    for p in var.principals : lower(p)
+    if can(regex("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$", p))
+  ]
+
+  gcp_member_principals = [
+    for p in var.principals : p
+    if can(regex("^(user|group|serviceAccount|domain|principal|principalSet):.+$", p))
+  ]
+
+  # Account IDs and ARNs both land in the AWS principal block of the trust policy
+  aws_trusted_principal_arns = concat(local.aws_account_principals, local.aws_arn_principals)
+
+  # Rotation metadata for providers that cannot expire the credential itself;
+  # policies/credentials.rego flags access keys minted without it
+  credentials_expiry = var.credentials_expiry_days != null ? { CredentialsExpiryDays = tostring(var.credentials_expiry_days) } : {}
 }
 
 # ============================================================================
@@ -86,12 +174,22 @@ module "aws_iam" {
   user_name   = var.identity_name
   
   # Trust Policy (Principals)
-  trusted_services = var.principals
+  trusted_services       = local.aws_service_principals
+  trusted_principal_arns = local.aws_trusted_principal_arns
+  external_id            = var.external_id
   
   # Policy Attachment
   managed_policy_arns = local.final_roles
   
-  tags = local.common_tags
+  # Resource grants, as one customer-managed policy
+  create_policy      = length(var.resource_grants) > 0
+  policy_name        = "${var.identity_name}-grants"
+  policy_description = "Access of ${var.identity_name} to the resources it was granted"
+  policy_document    = local.grant_policy
+  
+  create_access_key = var.create_access_credentials
+  
+  tags = merge(local.default_tags, local.credentials_expiry)
 }
 
 # Azure: User Assigned Managed Identity
@@ -105,7 +203,23 @@ module "azure_iam" {
   resource_group_name = try(var.provider_config.resource_group_name, "default-rg")
   location            = try(var.provider_config.location, "eastus")
   
-  tags = local.common_tags
+  # Cross-tenant principals receive the mapped roles at the configured scope
+  scope_id                      = try(var.provider_config.scope_id, null)
+  trusted_principal_ids         = local.azure_object_principals
+  trusted_role_definition_names = local.final_roles
+  
+  # Resource grants, as data role assignments at each resource
+  resource_role_assignments = [
+    for g in var.resource_grants : {
+      role_definition_name = local.grant_roles[g.capability]
+      scope                = g.resource
+    }
+  ]
+  
+  create_application_password = var.create_access_credentials
+  password_expiry_days        = var.credentials_expiry_days
+  
+  tags = local.default_tags
 }
 
 # GCP: Service Account
@@ -115,9 +229,15 @@ module "gcp_iam" {
   
   # For GCP, we map 'service_agent'/'user' to Service Account
   create_service_account = contains(["user", "service_agent"], var.identity_type)
-  account_id             = var.identity_name
+  account_id             = module.service_account_id.name
   display_name           = var.identity_name
   project_id             = try(var.provider_config.project_id, null)
+  
+  # Members from other projects may impersonate the service account
+  trusted_members = local.gcp_member_principals
+  
+  create_key  = var.create_access_credentials
+  key_keepers = var.credentials_expiry_days != null ? { credentials_expiry_days = tostring(var.credentials_expiry_days) } : null
 }
 
 # ZeroCloud: ZeroID
@@ -131,11 +251,21 @@ module "zero_iam" {
   create_user = contains(["user", "service_agent"], var.identity_type)
   user_name   = var.identity_name
   
-  trusted_services = var.principals
+  trusted_services       = local.aws_service_principals
+  trusted_principal_arns = local.aws_trusted_principal_arns
+  external_id            = var.external_id
   
   managed_policy_arns = local.final_roles
   
-  tags = local.common_tags
+  tags = local.default_tags
+}
+
+# Credentials without an expiry stay valid until someone deletes them
+check "credentials_expiry" {
+  assert {
+    condition     = !var.create_access_credentials || var.credentials_expiry_days != null
+    error_message = "create_access_credentials without credentials_expiry_days mints ${var.provider_name} credentials that are never rotated; set credentials_expiry_days and rotate before it passes."
+  }
 }
 
 # ============================================================================
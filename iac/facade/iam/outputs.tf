@@ -8,7 +8,56 @@ output "principal_id" {
   value       = local.principal_id
 }
 
+# Identity reference accepted by the lambda facade's identity_ref input: the
+# role ARN on AWS and the managed identity's resource ID on Azure, the
+# identities a function can run as.
+output "identity_ref" {
+  description = "Execution identity reference ({provider, id}) for the lambda facade; null unless a role on aws or a managed identity on azure"
+  value = (
+    var.provider_name == "aws" && var.identity_type == "role" ? { provider = "aws", id = local.principal_id } :
+    var.provider_name == "azure" && var.identity_type != "role" ? { provider = "azure", id = local.identity_id } :
+    null
+  )
+}
+
+# Principal reference accepted by the messaging facade's grants: the role or
+# user ARN on AWS, the managed identity's principal ID on Azure and the
+# service account email on GCP, the principals a resource's own policy names.
+output "principal_ref" {
+  description = "Principal reference ({provider, id}) for the messaging facade's grants; null on zero and for roles on azure and gcp"
+  value = (
+    var.provider_name == "aws" || (contains(["azure", "gcp"], var.provider_name) && var.identity_type != "role") ? { provider = var.provider_name, id = local.principal_id } :
+    null
+  )
+}
+
+output "access_key_id" {
+  description = "AWS access key ID or Azure client ID from create_access_credentials, null on GCP"
+  value = (
+    var.provider_name == "aws" ? (length(module.aws_iam) > 0 ? module.aws_iam[0].access_key_id : null) :
+    var.provider_name == "azure" ? (length(module.azure_iam) > 0 ? module.azure_iam[0].application_client_id : null) :
+    null
+  )
+  sensitive = true
+}
+
+output "access_key_secret" {
+  description = "AWS secret access key or Azure client secret from create_access_credentials, null on GCP"
+  value = (
+    var.provider_name == "aws" ? (length(module.aws_iam) > 0 ? module.aws_iam[0].secret_access_key : null) :
+    var.provider_name == "azure" ? (length(module.azure_iam) > 0 ? module.azure_iam[0].application_password : null) :
+    null
+  )
+  sensitive = true
+}
+
+output "credentials_json" {
+  description = "GCP service account key file from create_access_credentials, null elsewhere"
+  value       = length(module.gcp_iam) > 0 && var.create_access_credentials ? base64decode(module.gcp_iam[0].private_key) : null
+  sensitive   = true
+}
+
 output "provider" {
   description = "Cloud provider"
-  value       = var.provider
+  value       = var.provider_name
 }
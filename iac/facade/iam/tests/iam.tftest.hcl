@@ -0,0 +1,136 @@
+# Native tests of the iam facade: its validation rules, and what it plans
+# on AWS. TestNativeModuleTests in the iac module runs them with
+# `terraform test -json`, setting emulator_endpoint and aws_region to
+# CloudEmu; to run them by hand, from facade/iam:
+#
+#   terraform init
+#   TF_VAR_emulator_endpoint=http://localhost:4566 TF_VAR_aws_region=us-east-1 terraform test
+
+provider "aws" {
+  region = var.aws_region
+
+  endpoints {
+    iam = var.emulator_endpoint
+    sts = var.emulator_endpoint
+  }
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_region_validation      = true
+  skip_requesting_account_id  = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+variables {
+  provider_name = "aws"
+  project_name  = "nativetest"
+  environment   = "dev"
+  identity_name = "native-test-role"
+  identity_type = "role"
+  principals    = ["lambda.amazonaws.com"]
+}
+
+run "plans_role" {
+  command = plan
+
+  assert {
+    condition     = length(module.aws_iam) == 1 && length(module.azure_iam) == 0 && length(module.gcp_iam) == 0
+    error_message = "provider_name = \"aws\" should route to the AWS IAM module alone"
+  }
+
+  assert {
+    condition     = output.identity_ref.provider == "aws"
+    error_message = "An AWS role should have an identity_ref the lambda facade accepts"
+  }
+
+  assert {
+    condition     = output.access_key_id == null
+    error_message = "No access key should be created without create_access_credentials"
+  }
+}
+
+run "plans_user_without_identity_ref" {
+  command = plan
+
+  variables {
+    identity_type = "user"
+    principals    = []
+  }
+
+  assert {
+    condition     = output.identity_ref == null
+    error_message = "An AWS user cannot run a function, so it should have no identity_ref"
+  }
+
+  assert {
+    condition     = output.principal_ref.provider == "aws"
+    error_message = "An AWS user can still be granted access to a queue, so it should have a principal_ref"
+  }
+}
+
+run "rejects_unknown_identity_type" {
+  command = plan
+
+  variables {
+    identity_type = "robot"
+  }
+
+  expect_failures = [
+    var.identity_type,
+  ]
+}
+
+run "rejects_unknown_principal" {
+  command = plan
+
+  variables {
+    principals = ["not-a-principal"]
+  }
+
+  expect_failures = [
+    var.principals,
+  ]
+}
+
+run "rejects_short_external_id" {
+  command = plan
+
+  variables {
+    external_id = "x"
+  }
+
+  expect_failures = [
+    var.external_id,
+  ]
+}
+
+run "rejects_access_credentials_for_roles" {
+  command = plan
+
+  variables {
+    create_access_credentials = true
+  }
+
+  expect_failures = [
+    var.create_access_credentials,
+  ]
+}
+
+run "rejects_resource_grants_on_gcp" {
+  command = plan
+
+  variables {
+    provider_name = "gcp"
+    identity_type = "service_agent"
+    resource_grants = [{
+      capability = "storage_read"
+      resource   = "native-test-bucket"
+    }]
+  }
+
+  expect_failures = [
+    var.resource_grants,
+  ]
+}
@@ -1,6 +1,10 @@
 variable "provider_name" {
-  description = "Cloud provider (aws, azure, gcp)"
+  description = "Cloud provider (aws, azure, gcp, zero)"
   type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp", "zero"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp, zero"
+  }
 }
 
 variable "project_name" {
@@ -11,6 +15,10 @@ variable "project_name" {
 variable "environment" {
   description = "Environment name"
   type        = string
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "identity_name" {
@@ -22,12 +30,73 @@ variable "identity_type" {
   description = "Type of identity (role, user, service_agent)"
   type        = string
   default     = "service_agent"
+  validation {
+    condition     = contains(["role", "user", "service_agent"], var.identity_type)
+    error_message = "Identity type must be one of: role, user, service_agent"
+  }
 }
 
 variable "principals" {
-  description = "List of trusted principals (for roles)"
+  description = <<-EOT
+    List of trusted principals (for roles). Each entry is classified by shape:
+      - AWS service principal (e.g. ec2.amazonaws.com)
+      - AWS account ID (e.g. 123456789012), trusted via the account root
+      - AWS IAM ARN (e.g. arn:aws:iam::123456789012:role/deployer)
+      - Azure object ID from any tenant (GUID)
+      - GCP IAM member from any project (e.g. serviceAccount:ci@other-project.iam.gserviceaccount.com)
+  EOT
   type        = list(string)
   default     = []
+  validation {
+    condition = alltrue([
+      for p in var.principals : anytrue([
+        can(regex("^[a-z0-9.-]+\\.amazonaws\\.com(\\.cn)?$", p)),
+        can(regex("^[0-9]{12}$", p)),
+        can(regex("^arn:aws[a-z-]*:iam::[0-9]{12}:(root|role/.+|user/.+)$", p)),
+        can(regex("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$", p)),
+        can(regex("^(user|group|serviceAccount|domain|principal|principalSet):.+$", p)),
+      ])
+    ])
+    error_message = "Each of principals must be an AWS service principal, 12-digit AWS account ID, AWS IAM ARN, Azure object ID (GUID), or GCP member (type:identifier)."
+  }
+}
+
+variable "external_id" {
+  description = "External ID required from cross-account AWS principals (sts:ExternalId condition)"
+  type        = string
+  default     = null
+  validation {
+    condition     = var.external_id == null || can(regex("^[\\w+=,.@:/-]{2,1224}$", var.external_id))
+    error_message = "External ID must be 2-1224 characters of letters, digits, and +=,.@:/-"
+  }
+}
+
+variable "create_access_credentials" {
+  description = "Mint long-lived credentials for callers outside the cloud (CI bootstrap): an IAM access key on AWS, an app registration client secret on Azure, a service account key on GCP"
+  type        = bool
+  default     = false
+  validation {
+    condition     = !var.create_access_credentials || var.identity_type != "role"
+    error_message = "create_access_credentials needs identity_type user or service_agent; roles are assumed, not logged into"
+  }
+  validation {
+    condition     = !var.create_access_credentials || var.provider_name != "zero"
+    error_message = "ZeroCloud does not issue access keys, so create_access_credentials is not supported on zero"
+  }
+}
+
+variable "credentials_expiry_days" {
+  description = "Days the credentials from create_access_credentials stay valid: the client secret end date on Azure, a CredentialsExpiryDays tag on the AWS user and a key keeper on GCP, which cannot expire keys themselves"
+  type        = number
+  default     = null
+  validation {
+    condition     = var.credentials_expiry_days == null || var.create_access_credentials
+    error_message = "credentials_expiry_days only applies with create_access_credentials"
+  }
+  validation {
+    condition     = var.credentials_expiry_days == null || try(var.credentials_expiry_days >= 1 && var.credentials_expiry_days <= 730 && floor(var.credentials_expiry_days) == var.credentials_expiry_days, false)
+    error_message = "credentials_expiry_days must be a whole number of days from 1 to 730"
+  }
 }
 
 variable "provider_config" {
@@ -42,6 +111,31 @@ variable "roles" {
   default     = []
 }
 
+variable "resource_grants" {
+  description = "Capabilities granted on single resources rather than account-wide through roles: storage_read or storage_write on a bucket ARN or storage container ID, nosql_read or nosql_write on a table ARN or Cosmos DB account ID, queue_send or queue_consume on a queue ARN or Service Bus queue ID. AWS attaches them to the role as one policy; Azure assigns the matching data role to the managed identity at each resource."
+  type = list(object({
+    capability = string
+    resource   = string
+  }))
+  default = []
+  validation {
+    condition     = alltrue([for g in var.resource_grants : contains(["storage_read", "storage_write", "nosql_read", "nosql_write", "queue_send", "queue_consume"], g.capability)])
+    error_message = "Each resource_grants capability must be one of: storage_read, storage_write, nosql_read, nosql_write, queue_send, queue_consume"
+  }
+  validation {
+    condition     = length(var.resource_grants) == 0 || contains(["aws", "azure"], var.provider_name)
+    error_message = "resource_grants is only available on aws and azure; GCP grants access in each resource's own IAM policy and ZeroCloud only attaches managed policies"
+  }
+  validation {
+    condition     = length(var.resource_grants) == 0 || var.provider_name != "aws" || var.identity_type == "role"
+    error_message = "resource_grants on aws are attached to a role; set identity_type = \"role\""
+  }
+  validation {
+    condition     = length(var.resource_grants) == 0 || var.provider_name != "azure" || var.identity_type != "role"
+    error_message = "resource_grants on azure are assigned to a managed identity; set identity_type = \"user\" or \"service_agent\""
+  }
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
@@ -2,19 +2,26 @@ terraform {
   required_version = ">= 1.0"
 }
 
-locals {
-  common_tags = merge(
-    var.tags,
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy    = "Terraform"
-      Environment  = var.environment
-      Provider     = var.provider_name
-      Project      = var.project_name
-      Module       = "Kubernetes-Facade"
-    }
+      Provider = var.provider_name
+      Module   = "Kubernetes-Facade"
+    },
+    var.tags
   )
 }
 
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+}
+
 # --------------------------------------------------------------------------------
 # AWS EKS
 # --------------------------------------------------------------------------------
@@ -27,7 +34,7 @@ module "aws_eks" {
   instance_size = var.instance_size
   vpc_id        = var.vpc_id
   subnet_ids    = var.subnet_ids
-  tags          = local.common_tags
+  tags          = local.default_tags
 }
 
 # --------------------------------------------------------------------------------
@@ -47,7 +54,7 @@ module "zero_eks" {
   instance_size = var.instance_size
   vpc_id        = var.vpc_id
   subnet_ids    = var.subnet_ids
-  tags          = local.common_tags
+  tags          = local.default_tags
 }
 
 # --------------------------------------------------------------------------------
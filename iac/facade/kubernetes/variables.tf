@@ -3,7 +3,7 @@ variable "provider_name" {
   type        = string
   validation {
     condition     = contains(["aws", "azure", "gcp", "zero"], var.provider_name)
-    error_message = "Provider must be one of: aws, azure, gcp, zero."
+    error_message = "provider_name must be one of: aws, azure, gcp, zero."
   }
 }
 
@@ -47,6 +47,10 @@ variable "tags" {
 variable "environment" {
   description = "Deployment environment"
   type        = string
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
@@ -1,31 +1,645 @@
 package lambda_test
 
 import (
-	"testing"
+	"os"
+	"path/filepath"
 	"strings"
+	"testing"
+
+	"iac/testutil/planerr"
+	"iac/testutil/tfopts"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+const testHandlerSource = `def handler(event, context):
+    return {"statusCode": 200, "body": "ok"}
+`
+
 func TestLambdaFacadeAws(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "aws",
-			"project_name":  "testproject",
-			"environment":   "test",
-			"function_name": "test-function",
-			"handler":       "index.handler",
-			"runtime":       "python3.9",
+			"provider_name":   "aws",
+			"project_name":    "testproject",
+			"environment":     "dev",
+			"function_name":   "test-function",
+			"handler":         "index.handler",
+			"runtime":         "python3.9",
+			"memory_mb":       256,
+			"timeout_seconds": 30,
+			"environment_variables": map[string]interface{}{
+				"LOG_LEVEL": "debug",
+			},
+			"vpc_config": map[string]interface{}{
+				"subnet_ids":         []string{"subnet-0123456789abcdef0"},
+				"security_group_ids": []string{"sg-0123456789abcdef0"},
+			},
 		},
 		BackendConfig: map[string]interface{}{},
 	})
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
-	
+
 	assert.True(t, strings.Contains(planString, "module.aws_lambda[0].aws_lambda_function.this"), "Plan should create an AWS Lambda function")
 	assert.True(t, strings.Contains(planString, "function_name = \"test-function\""), "Plan should have the correct function name")
+	assert.True(t, strings.Contains(planString, "environment {"), "Plan should render an environment block")
+	assert.True(t, strings.Contains(planString, "\"LOG_LEVEL\" = \"debug\""), "Environment block should contain the configured variables")
+	assert.Regexp(t, `memory_size\s+= 256`, planString, "Plan should set the requested memory")
+	assert.Regexp(t, `timeout\s+= 30`, planString, "Plan should set the requested timeout")
+	assert.True(t, strings.Contains(planString, "subnet-0123456789abcdef0"), "Plan should attach the function to the VPC subnets")
+	assert.True(t, strings.Contains(planString, "module.aws_lambda[0].aws_iam_role_policy_attachment.vpc_access[0]"), "VPC functions need the VPC access execution policy")
+}
+
+func TestLambdaFacadeAzure(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":   "azure",
+			"project_name":    "testproject",
+			"environment":     "dev",
+			"function_name":   "test-function",
+			"handler":         "index.handler",
+			"runtime":         "python3.9",
+			"source_code":     testHandlerSource,
+			"memory_mb":       2048,
+			"timeout_seconds": 300,
+			"environment_variables": map[string]interface{}{
+				"LOG_LEVEL": "debug",
+			},
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.azure_lambda[0].azurerm_linux_function_app.this"), "Plan should create an Azure Function App")
+	assert.True(t, strings.Contains(planString, "module.azure_lambda[0].azurerm_service_plan.this"), "Plan should create a service plan")
+	assert.True(t, strings.Contains(planString, "module.azure_lambda[0].azurerm_storage_account.this"), "Plan should create the backing storage account")
+	assert.Regexp(t, `python_version\s+= "3.9"`, planString, "python3.9 should translate to the PYTHON|3.9 application stack")
+	assert.True(t, strings.Contains(planString, "\"LOG_LEVEL\""), "Environment variables should become app settings")
+	assert.True(t, strings.Contains(planString, "00:05:00"), "Timeout should be rendered as the host functionTimeout")
+	assert.Regexp(t, `sku_name\s+= "EP1"`, planString, "2 GB of memory should select the EP1 plan")
+}
+
+func TestLambdaFacadeGcp(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":   "gcp",
+			"project_name":    "testproject",
+			"environment":     "dev",
+			"function_name":   "test-function",
+			"handler":         "main.handler",
+			"runtime":         "python3.11",
+			"source_code":     testHandlerSource,
+			"memory_mb":       300,
+			"timeout_seconds": 120,
+			"environment_variables": map[string]interface{}{
+				"LOG_LEVEL": "debug",
+			},
+			"vpc_config": map[string]interface{}{
+				"subnet_ids": []string{"projects/test-project/locations/us-central1/connectors/test-connector"},
+			},
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.gcp_lambda[0].google_cloudfunctions2_function.this"), "Plan should create a GCP Cloud Function (2nd gen)")
+	assert.True(t, strings.Contains(planString, "module.gcp_lambda[0].google_storage_bucket_object.archive"), "Plan should upload the source archive")
+	assert.Regexp(t, `runtime\s+= "python311"`, planString, "python3.11 should translate to the python311 runtime")
+	assert.Regexp(t, `entry_point\s+= "handler"`, planString, "Entry point should be the function name from the handler")
+	assert.Regexp(t, `available_memory\s+= "512M"`, planString, "Memory should be rounded up to the next Cloud Functions tier")
+	assert.True(t, strings.Contains(planString, "test-connector"), "Plan should attach the VPC connector")
+	assert.True(t, strings.Contains(planString, "\"LOG_LEVEL\" = \"debug\""), "Plan should pass environment variables")
+}
+
+func TestLambdaFacadeAwsHttpEndpoint(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":        "aws",
+			"project_name":         "testproject",
+			"function_name":        "test-function",
+			"enable_http_endpoint": true,
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.aws_lambda[0].aws_lambda_function_url.this[0]"), "Plan should create a Lambda function URL")
+	assert.Regexp(t, `authorization_type\s+= "AWS_IAM"`, planString, "Function URL should default to IAM auth")
+	assert.False(t, strings.Contains(planString, "aws_lambda_permission.function_url_public"), "Private URLs must not grant anonymous access")
+}
+
+func TestLambdaFacadeAwsQueueTrigger(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"function_name": "test-function",
+			"queue_trigger": map[string]interface{}{
+				"queue_id":   "arn:aws:sqs:us-east-1:123456789012:orders",
+				"batch_size": 5,
+			},
+		},
+		NoColor: true,
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Contains(t, planString, "module.aws_lambda[0].aws_lambda_event_source_mapping.this[0]", "Plan should map the queue to the function")
+	assert.Regexp(t, `event_source_arn\s+= "arn:aws:sqs:us-east-1:123456789012:orders"`, planString)
+	assert.Regexp(t, `batch_size\s+= 5`, planString)
+	assert.Contains(t, planString, "module.aws_lambda[0].aws_iam_role_policy_attachment.sqs_trigger[0]", "The function's own role needs to read the queue")
+}
+
+func TestLambdaFacadeAwsIdentityRef(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"function_name": "test-function",
+			"identity_ref": map[string]interface{}{
+				"provider": "aws",
+				"id":       "arn:aws:iam::123456789012:role/orders-worker",
+			},
+			"queue_trigger": map[string]interface{}{
+				"queue_id": "arn:aws:sqs:us-east-1:123456789012:orders",
+			},
+		},
+		NoColor: true,
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.NotContains(t, planString, "module.aws_lambda[0].aws_iam_role.this", "The function should not get a role of its own")
+	assert.Regexp(t, `role\s+= "arn:aws:iam::123456789012:role/orders-worker"`, planString)
+	assert.Regexp(t, `role\s+= "orders-worker"`, planString, "Logging should be attached to the given role")
+	assert.NotContains(t, planString, "sqs_trigger", "A given role carries its own queue access")
+}
+
+func TestLambdaFacadeAwsPublicHttpEndpoint(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":        "aws",
+			"project_name":         "testproject",
+			"function_name":        "test-function",
+			"enable_http_endpoint": true,
+			"http_auth_type":       "NONE",
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `authorization_type\s+= "NONE"`, planString, "Public URLs should use NONE auth")
+	assert.True(t, strings.Contains(planString, "module.aws_lambda[0].aws_lambda_permission.function_url_public[0]"), "Public URLs need an anonymous invoke permission")
+}
+
+func TestLambdaFacadeGcpHttpEndpoint(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":        "gcp",
+			"project_name":         "testproject",
+			"function_name":        "test-function",
+			"handler":              "main.handler",
+			"runtime":              "python3.11",
+			"source_code":          testHandlerSource,
+			"enable_http_endpoint": true,
+			"http_invokers":        []string{"serviceAccount:caller@test-project.iam.gserviceaccount.com"},
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `ingress_settings\s+= "ALLOW_ALL"`, planString, "HTTP endpoint should accept external traffic")
+	assert.True(t, strings.Contains(planString, "google_cloud_run_service_iam_member.invoker"), "Plan should bind the invoker role")
+	assert.Regexp(t, `role\s+= "roles/run.invoker"`, planString, "Invoker binding should use roles/run.invoker")
+	assert.True(t, strings.Contains(planString, "caller@test-project.iam.gserviceaccount.com"), "Invoker binding should target the configured member")
+	assert.False(t, strings.Contains(planString, "allUsers"), "Endpoint should stay private by default")
+}
+
+func TestLambdaFacadeAwsAlias(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"function_name": "test-function",
+			"publish":       true,
+			"alias_name":    "live",
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `publish\s+= true`, planString, "Function should publish versions")
+	assert.True(t, strings.Contains(planString, "module.aws_lambda[0].aws_lambda_alias.this[0]"), "Plan should create the alias")
+	assert.Regexp(t, `name\s+= "live"`, planString, "Alias should use the requested name")
+	assert.False(t, strings.Contains(planString, "routing_config {"), "Alias should not split traffic without a canary")
+}
+
+func TestLambdaFacadeAwsCanary(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":  "aws",
+			"project_name":   "testproject",
+			"function_name":  "test-function",
+			"publish":        true,
+			"alias_name":     "live",
+			"canary_weight":  0.1,
+			"stable_version": "3",
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.aws_lambda[0].aws_lambda_alias.this[0]"), "Plan should create the alias")
+	assert.Regexp(t, `function_version\s+= "3"`, planString, "Alias should keep pointing at the stable version")
+	assert.True(t, strings.Contains(planString, "routing_config {"), "Alias should carry weighted routing")
+}
+
+func TestLambdaFacadeAzureCanary(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "azure",
+			"project_name":  "testproject",
+			"function_name": "test-function",
+			"source_code":   testHandlerSource,
+			"publish":       true,
+			"alias_name":    "canary",
+			"canary_weight": 0.25,
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.azure_lambda[0].azurerm_linux_function_app_slot.alias[0]"), "Plan should create the deployment slot")
+	assert.True(t, strings.Contains(planString, "module.azure_lambda[0].null_resource.slot_rollout[0]"), "Plan should push the package and routing to the slot")
+	assert.Regexp(t, `"canary_weight"\s+= "0.25"`, planString, "Slot rollout should carry the canary weight")
+}
+
+func TestLambdaFacadeAwsConcurrency(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":           "aws",
+			"project_name":            "testproject",
+			"function_name":           "test-function",
+			"publish":                 true,
+			"alias_name":              "live",
+			"reserved_concurrency":    20,
+			"provisioned_concurrency": 5,
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `reserved_concurrent_executions\s+= 20`, planString, "Function should reserve the requested concurrency")
+	assert.True(t, strings.Contains(planString, "module.aws_lambda[0].aws_lambda_provisioned_concurrency_config.this[0]"), "Plan should configure provisioned concurrency")
+	assert.Regexp(t, `provisioned_concurrent_executions\s+= 5`, planString, "Provisioned concurrency should use the requested count")
+	assert.Regexp(t, `qualifier\s+= "live"`, planString, "Provisioned concurrency should be configured on the alias")
+}
+
+func TestLambdaFacadeAwsUnreservedConcurrency(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"function_name": "test-function",
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `reserved_concurrent_executions\s+= -1`, planString, "Function should stay on the unreserved pool by default")
+	assert.False(t, strings.Contains(planString, "aws_lambda_provisioned_concurrency_config"), "Plan should not configure provisioned concurrency by default")
+}
+
+func TestLambdaFacadeAzureConcurrency(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":           "azure",
+			"project_name":            "testproject",
+			"function_name":           "test-function",
+			"source_code":             testHandlerSource,
+			"memory_mb":               2048,
+			"reserved_concurrency":    10,
+			"provisioned_concurrency": 2,
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `sku_name\s+= "EP1"`, planString, "2 GB of memory should select the EP1 plan")
+	assert.Regexp(t, `app_scale_limit\s+= 10`, planString, "reserved_concurrency should cap the instance count")
+	assert.Regexp(t, `elastic_instance_minimum\s+= 2`, planString, "provisioned_concurrency should set the always-ready instances")
+}
+
+func TestLambdaFacadeGcpConcurrency(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":           "gcp",
+			"project_name":            "testproject",
+			"function_name":           "test-function",
+			"source_code":             testHandlerSource,
+			"reserved_concurrency":    8,
+			"provisioned_concurrency": 1,
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `max_instance_count\s+= 8`, planString, "reserved_concurrency should cap the instance count")
+	assert.Regexp(t, `min_instance_count\s+= 1`, planString, "provisioned_concurrency should keep instances running")
+}
+
+func TestLambdaFacadeBuildCommandRequiresOptIn(t *testing.T) {
+	t.Parallel()
+
+	sourceDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(sourceDir, "index.py"), []byte(testHandlerSource), 0644)
+	assert.NoError(t, err)
+
+	terraformOptions := tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"function_name": "test-function",
+			"source_dir":    sourceDir,
+			"build_command": "pip install -r requirements.txt -t .",
+		},
+	})
+
+	out, err := terraform.InitAndPlanE(t, terraformOptions)
+	assert.NoError(t, planerr.Match(out, err, "set allow_local_build = true"), "Plan should fail when build_command is set without allow_local_build")
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"function_name": "test-function",
+	}
+	canary := func(vars map[string]interface{}) map[string]interface{} {
+		vars["alias_name"] = "live"
+		vars["stable_version"] = "1"
+		return vars
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "UnsupportedRuntime",
+			Vars: map[string]interface{}{"runtime": "python2.7"},
+			Want: "Runtime must be one of: python3.9, python3.10, python3.11, python3.12, nodejs18.x, nodejs20.x",
+		},
+		{
+			Name: "RuntimeNotOfferedByProvider",
+			Vars: map[string]interface{}{
+				"provider_name": "azure",
+				"runtime":       "python3.12", // Not offered by the Azure Functions Linux stack
+				"source_code":   testHandlerSource,
+			},
+			Want: "Runtime python3.12 is not supported on azure",
+		},
+		{
+			Name: "MemoryAboveLimit",
+			Vars: map[string]interface{}{"memory_mb": 20480}, // Above the 10240 MB Lambda limit
+			Want: "memory_mb must be a whole number of MB between 128 and 10240",
+		},
+		{
+			Name: "MemoryBelowLimit",
+			Vars: map[string]interface{}{"memory_mb": 64},
+			Want: "memory_mb must be a whole number of MB between 128 and 10240",
+		},
+		{
+			Name: "TimeoutAboveLimit",
+			Vars: map[string]interface{}{"timeout_seconds": 901},
+			Want: "timeout_seconds must be a whole number of seconds between 1 and 900",
+		},
+		{
+			Name: "EnvironmentVariableNameStartsWithDigit",
+			Vars: map[string]interface{}{"environment_variables": map[string]interface{}{"1_TABLE": "orders"}},
+			Want: "environment_variables names must start with a letter",
+		},
+		{
+			Name: "ReservedEnvironmentVariable",
+			Vars: map[string]interface{}{"environment_variables": map[string]interface{}{"AWS_REGION": "us-east-1"}},
+			Want: "must not use names reserved by the Lambda runtime",
+		},
+		{
+			Name: "VpcConfigWithoutSubnets",
+			Vars: map[string]interface{}{"vpc_config": map[string]interface{}{"subnet_ids": []string{}}},
+			Want: "vpc_config.subnet_ids must contain at least one subnet",
+		},
+		{
+			Name: "UnknownHttpAuthType",
+			Vars: map[string]interface{}{"http_auth_type": "OPEN"},
+			Want: "http_auth_type must be one of: IAM, NONE",
+		},
+		{
+			Name: "CanaryWeightOne",
+			Vars: canary(map[string]interface{}{"publish": true, "canary_weight": 1}),
+			Want: "canary_weight must be greater than 0 and less than 1",
+		},
+		{
+			Name: "CanaryWithoutPublish",
+			Vars: canary(map[string]interface{}{"canary_weight": 0.5}),
+			Want: "canary_weight requires publish = true and an alias_name",
+		},
+		{
+			Name: "QueueTriggerOnAzure",
+			Vars: map[string]interface{}{
+				"provider_name": "azure",
+				"queue_trigger": map[string]interface{}{"queue_id": "/subscriptions/sub/queues/orders"},
+			},
+			Want: "queue_trigger is only available on aws",
+		},
+		{
+			Name: "QueueTriggerBatchTooLarge",
+			Vars: map[string]interface{}{"queue_trigger": map[string]interface{}{"queue_id": "arn:aws:sqs:us-east-1:123456789012:orders", "batch_size": 100}},
+			Want: "queue_trigger.batch_size must be 1-10 messages",
+		},
+		{
+			Name: "IdentityFromOtherProvider",
+			Vars: map[string]interface{}{"identity_ref": map[string]interface{}{"provider": "azure", "id": "/subscriptions/sub/userAssignedIdentities/worker"}},
+			Want: "identity_ref belongs to azure but this module deploys to aws",
+		},
+		{
+			Name: "ReservedConcurrencyNegative",
+			Vars: map[string]interface{}{"reserved_concurrency": -1},
+			Want: "reserved_concurrency must be a whole number, at least 0 on aws",
+		},
+		{
+			Name: "ReservedConcurrencyZeroOnGcp",
+			Vars: map[string]interface{}{"provider_name": "gcp", "reserved_concurrency": 0},
+			Want: "at least 1 on azure and gcp",
+		},
+		{
+			Name: "ProvisionedConcurrencyWithoutAlias",
+			Vars: map[string]interface{}{"publish": true, "provisioned_concurrency": 2},
+			Want: "provisioned_concurrency on aws requires publish = true and an alias_name",
+		},
+		{
+			Name: "ProvisionedConcurrencyAboveReserved",
+			Vars: map[string]interface{}{"publish": true, "alias_name": "live", "reserved_concurrency": 2, "provisioned_concurrency": 5},
+			Want: "provisioned_concurrency must not exceed reserved_concurrency",
+		},
+		{
+			Name: "ProvisionedConcurrencyOnAzureConsumption",
+			Vars: map[string]interface{}{
+				"provider_name":           "azure",
+				"source_code":             testHandlerSource,
+				"provisioned_concurrency": 1, // 128 MB without a VNet runs on Y1
+			},
+			Want: "provisioned_concurrency needs an Elastic Premium plan on azure",
+		},
+		{
+			Name: "ConcurrencyOnZero",
+			Vars: map[string]interface{}{"provider_name": "zero", "reserved_concurrency": 1},
+			Want: "reserved_concurrency is not available on zero",
+		},
+		{
+			Name: "AlarmsOnZero",
+			Vars: map[string]interface{}{"provider_name": "zero", "enable_default_alarms": true},
+			Want: "enable_default_alarms is not available on zero",
+		},
+	})
+}
+
+func TestLambdaFacadeAwsDefaultAlarms(t *testing.T) {
+	t.Parallel()
+
+	const topic = "arn:aws:sns:us-east-1:123456789012:oncall"
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":         "aws",
+			"project_name":          "testproject",
+			"environment":           "dev",
+			"function_name":         "alarmed-function",
+			"handler":               "index.handler",
+			"runtime":               "python3.12",
+			"source_code":           testHandlerSource,
+			"enable_default_alarms": true,
+			"notification_ref":      map[string]interface{}{"provider": "aws", "channel_id": topic},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	for key, metric := range map[string]string{"errors": "Errors", "throttles": "Throttles"} {
+		alarm, ok := plan.ResourcePlannedValuesMap[`module.default_alarms["`+key+`"].module.aws_monitoring[0].aws_cloudwatch_metric_alarm.this[0]`]
+		require.True(t, ok, "Plan should create the %s alarm", key)
+		assert.Equal(t, "alarmed-function-"+key, alarm.AttributeValues["alarm_name"])
+		assert.Equal(t, metric, alarm.AttributeValues["metric_name"])
+		assert.Equal(t, "Sum", alarm.AttributeValues["statistic"])
+		assert.EqualValues(t, 300, alarm.AttributeValues["period"])
+		assert.EqualValues(t, 0, alarm.AttributeValues["threshold"])
+		assert.Equal(t, map[string]interface{}{"FunctionName": "alarmed-function"}, alarm.AttributeValues["dimensions"])
+		assert.Equal(t, []interface{}{topic}, alarm.AttributeValues["alarm_actions"])
+	}
+}
+
+func TestLambdaFacadeGcpDefaultAlarms(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":         "gcp",
+			"project_name":          "testproject",
+			"environment":           "dev",
+			"function_name":         "alarmed-gcp-function",
+			"handler":               "main.handler",
+			"runtime":               "python3.11",
+			"source_code":           testHandlerSource,
+			"enable_default_alarms": true,
+			"provider_config":       map[string]interface{}{"project_id": "test-project"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	var alarms []string
+	for address := range plan.ResourcePlannedValuesMap {
+		if strings.HasPrefix(address, "module.default_alarms") {
+			alarms = append(alarms, address)
+		}
+	}
+	require.Equal(t, []string{`module.default_alarms["errors"].module.gcp_monitoring[0].google_monitoring_alert_policy.this[0]`}, alarms,
+		"Cloud Functions have no throttling metric, so only the error alarm is created")
+
+	alert := plan.ResourcePlannedValuesMap[alarms[0]]
+	condition := alert.AttributeValues["conditions"].([]interface{})[0].(map[string]interface{})
+	threshold := condition["condition_threshold"].([]interface{})[0].(map[string]interface{})
+	assert.Contains(t, threshold["filter"], `resource.labels.function_name = "alarmed-gcp-function"`)
+}
+
+func TestLambdaFacadeWithoutDefaultAlarms(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"function_name": "quiet-function",
+			"handler":       "index.handler",
+			"runtime":       "python3.12",
+			"source_code":   testHandlerSource,
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	for address := range plan.ResourcePlannedValuesMap {
+		assert.False(t, strings.HasPrefix(address, "module.default_alarms"), "No alarms without enable_default_alarms, found %s", address)
+	}
 }
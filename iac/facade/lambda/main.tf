@@ -1,53 +1,222 @@
+# Lambda Facade
+# Unified interface for Function-as-a-Service resources across providers
+
+terraform {
+  # The runtime validation looks the provider up in local.runtime_map
+  required_version = ">= 1.9"
+
+  # aws is declared so callers can pass an aliased configuration with the
+  # providers argument; the default alarms inherit it
+  required_providers {
+    archive = {
+      source  = "hashicorp/archive"
+      version = "~> 2.0"
+    }
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    null = {
+      source  = "hashicorp/null"
+      version = "~> 3.0"
+    }
+  }
+}
+
+# ============================================================================
+# RUNTIME TRANSLATION
+# ============================================================================
+
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags         = var.tags
+}
+
+locals {
+  # Maps the facade's runtime names (AWS-style) to each provider's identifier.
+  # Azure values use the LinuxFxVersion "STACK|version" form.
+  runtime_map = {
+    aws = {
+      "python3.9"  = "python3.9"
+      "python3.10" = "python3.10"
+      "python3.11" = "python3.11"
+      "python3.12" = "python3.12"
+      "nodejs18.x" = "nodejs18.x"
+      "nodejs20.x" = "nodejs20.x"
+    }
+    zero = {
+      "python3.9"  = "python3.9"
+      "python3.10" = "python3.10"
+      "python3.11" = "python3.11"
+      "python3.12" = "python3.12"
+      "nodejs18.x" = "nodejs18.x"
+      "nodejs20.x" = "nodejs20.x"
+    }
+    azure = {
+      "python3.9"  = "PYTHON|3.9"
+      "python3.10" = "PYTHON|3.10"
+      "python3.11" = "PYTHON|3.11"
+      "nodejs18.x" = "NODE|18"
+      "nodejs20.x" = "NODE|20"
+    }
+    gcp = {
+      "python3.9"  = "python39"
+      "python3.10" = "python310"
+      "python3.11" = "python311"
+      "python3.12" = "python312"
+      "nodejs18.x" = "nodejs18"
+      "nodejs20.x" = "nodejs20"
+    }
+  }
+
+  runtime = lookup(local.runtime_map[var.provider_name], var.runtime, null)
+
+  # Deployment package, in order of precedence:
+  #   source_code -> zipped inline, source_dir -> zipped from disk, source_path -> used as-is
+  package_path = (
+    var.source_code != null ? data.archive_file.lambda_zip[0].output_path :
+    var.source_dir != null ? data.archive_file.source_dir[0].output_path :
+    var.source_path
+  )
+  package_hash = (
+    var.source_code != null ? data.archive_file.lambda_zip[0].output_base64sha256 :
+    var.source_dir != null ? data.archive_file.source_dir[0].output_base64sha256 :
+    var.source_path != null ? filebase64sha256(var.source_path) :
+    null
+  )
+  package_md5 = (
+    var.source_code != null ? data.archive_file.lambda_zip[0].output_md5 :
+    var.source_dir != null ? data.archive_file.source_dir[0].output_md5 :
+    var.source_path != null ? filemd5(var.source_path) :
+    null
+  )
+
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  kms_key_id = var.kms_key_ref != null ? var.kms_key_ref.id : null
+}
+
+# ============================================================================
+# PROVIDER-SPECIFIC MODULE ROUTING
+# ============================================================================
+
 module "aws_lambda" {
   count  = var.provider_name == "aws" ? 1 : 0
   source = "../../aws/core/lambda"
 
   function_name = var.function_name
   handler       = var.handler
-  runtime       = var.runtime
+  runtime       = local.runtime
 
   # Source Code handling
-  filename = var.source_code != null ? data.archive_file.lambda_zip[0].output_path : null
+  filename         = local.package_path
+  source_code_hash = local.package_hash
   
   environment_variables = var.environment_variables
+  memory_size           = var.memory_mb
+  timeout               = var.timeout_seconds
   
-  # Map other variables
-  tags = merge(var.tags, {
-    Environment = var.environment
-    Project     = var.project_name
-  })
+  # VPC Configuration
+  vpc_subnet_ids         = var.vpc_config != null ? var.vpc_config.subnet_ids : null
+  vpc_security_group_ids = var.vpc_config != null ? var.vpc_config.security_group_ids : null
+  
+  # Function URL
+  create_function_url    = var.enable_http_endpoint
+  function_url_auth_type = var.http_auth_type == "NONE" ? "NONE" : "AWS_IAM"
+  function_url_invokers  = var.http_invokers
+  
+  # Versions and weighted alias
+  publish        = var.publish
+  alias_name     = var.alias_name
+  canary_weight  = var.canary_weight
+  stable_version = var.stable_version
+  
+  # Concurrency; provisioned concurrency is configured on the alias
+  reserved_concurrent_executions    = coalesce(var.reserved_concurrency, -1)
+  provisioned_concurrent_executions = var.provisioned_concurrency
+  
+  # Execution role from the iam facade, or one of its own
+  create_role = var.identity_ref == null
+  role_arn    = try(var.identity_ref.id, null)
+  
+  # SQS trigger
+  create_event_source_mapping = var.queue_trigger != null
+  event_source_arn            = try(var.queue_trigger.queue_id, null)
+  event_source_batch_size     = try(var.queue_trigger.batch_size, 10)
+  
+  kms_key_arn = local.kms_key_id
+  
+  tags = local.default_tags
 }
 
+# Azure: Linux Function App
 module "azure_lambda" {
   count  = var.provider_name == "azure" ? 1 : 0
   source = "../../azure/core/lambda"
 
   function_name = var.function_name
   handler       = var.handler
-  runtime       = var.runtime
-  filename      = var.source_code != null ? data.archive_file.lambda_zip[0].output_path : null
+  runtime       = local.runtime
+  filename         = local.package_path
+  source_code_hash = local.package_hash
+  location         = try(var.provider_config.location, "East US")
   
+  # Environment variables become app settings on the Function App
   environment_variables = var.environment_variables
-  tags = merge(var.tags, {
-    Environment = var.environment
-    Project     = var.project_name
-  })
+  memory_size           = var.memory_mb
+  timeout               = var.timeout_seconds
+  vpc_subnet_id         = var.vpc_config != null ? var.vpc_config.subnet_ids[0] : null
+  
+  # Deployment slot acting as the alias
+  alias_name    = var.alias_name
+  canary_weight = var.canary_weight
+  
+  # Scale-out limit and always-ready instances
+  maximum_instance_count = var.reserved_concurrency
+  always_ready_instances = var.provisioned_concurrency
+  
+  # Managed identity from the iam facade
+  identity_ids = var.identity_ref != null ? [var.identity_ref.id] : []
+  
+  customer_managed_key_id = local.kms_key_id
+  
+  tags = local.default_tags
 }
 
+# GCP: Cloud Functions (2nd gen)
 module "gcp_lambda" {
   count  = var.provider_name == "gcp" ? 1 : 0
   source = "../../gcp/core/lambda"
 
   function_name = var.function_name
   handler       = var.handler
-  runtime       = var.runtime
-  filename      = var.source_code != null ? data.archive_file.lambda_zip[0].output_path : null
+  runtime       = local.runtime
+  filename      = local.package_path
+  source_md5    = local.package_md5
+  project_id    = try(var.provider_config.project_id, null)
+  region        = try(var.provider_config.region, "us-central1")
   
   environment_variables = var.environment_variables
-  tags = merge(var.tags, {
-    Environment = var.environment
-    Project     = var.project_name
-  })
+  memory_size           = var.memory_mb
+  timeout               = var.timeout_seconds
+  vpc_connector         = var.vpc_config != null ? var.vpc_config.subnet_ids[0] : null
+  
+  # Instance bounds
+  min_instance_count = coalesce(var.provisioned_concurrency, 0)
+  max_instance_count = coalesce(var.reserved_concurrency, 100)
+  
+  # HTTPS trigger; "allUsers" makes the endpoint public
+  http_endpoint   = var.enable_http_endpoint
+  invoker_members = var.enable_http_endpoint ? (var.http_auth_type == "NONE" ? ["allUsers"] : var.http_invokers) : []
+  
+  kms_key_name = local.kms_key_id
+  
+  tags = local.default_labels
 }
 
 module "zero_lambda" {
@@ -56,14 +225,16 @@ module "zero_lambda" {
 
   function_name = var.function_name
   handler       = var.handler
-  runtime       = var.runtime
-  filename      = var.source_code != null ? data.archive_file.lambda_zip[0].output_path : null
+  runtime       = local.runtime
+
+  filename         = local.package_path
+  source_code_hash = local.package_hash
   
   environment_variables = var.environment_variables
-  tags = merge(var.tags, {
-    Environment = var.environment
-    Project     = var.project_name
-  })
+  memory_size           = var.memory_mb
+  timeout               = var.timeout_seconds
+  
+  tags = local.default_tags
 }
 
 locals {
@@ -82,16 +253,194 @@ data "archive_file" "lambda_zip" {
   }
 }
 
-output "function_arn" {
-  value = (
-    var.provider_name == "aws" ? module.aws_lambda[0].function_arn : 
-    var.provider_name == "azure" ? "azure-id-placeholder" :
-    var.provider_name == "gcp" ? "gcp-id-placeholder" :
-    var.provider_name == "zero" ? module.zero_lambda[0].function_arn :
-    "placeholder-arn"
+# ============================================================================
+# SOURCE DIRECTORY PACKAGING
+# ============================================================================
+
+# Optional build step for compiled runtimes. Runs arbitrary local commands,
+# so it must be explicitly allowed by the caller.
+resource "null_resource" "build" {
+  count = var.source_code == null && var.source_dir != null && var.build_command != null ? 1 : 0
+
+  triggers = {
+    command     = var.build_command
+    source_hash = sha1(join("", [for f in sort(fileset(var.source_dir, "**")) : filesha1("${var.source_dir}/${f}")]))
+  }
+
+  provisioner "local-exec" {
+    command     = var.build_command
+    working_dir = var.source_dir
+  }
+
+  lifecycle {
+    precondition {
+      condition     = var.allow_local_build
+      error_message = "build_command runs on the machine executing Terraform; set allow_local_build = true to enable it."
+    }
+  }
+}
+
+data "archive_file" "source_dir" {
+  count       = var.source_code == null && var.source_dir != null ? 1 : 0
+  type        = "zip"
+  source_dir  = var.source_dir
+  excludes    = var.source_excludes
+  output_path = "${path.module}/lambda_${var.function_name}.zip"
+
+  depends_on = [null_resource.build]
+}
+
+# ============================================================================
+# DEFAULT ALARMS
+# ============================================================================
+
+locals {
+  # Alarm key => monitoring preset; only Lambda reports throttles
+  default_alarms = var.enable_default_alarms ? merge(
+    { errors = "lambda_errors" },
+    var.provider_name == "aws" ? { throttles = "lambda_throttles" } : {}
+  ) : {}
+}
+
+# Alarms fire on the first error or throttle in a 5 minute period. Azure
+# alarms on the Function App and are created in its resource group.
+module "default_alarms" {
+  for_each = local.default_alarms
+  source   = "../monitoring"
+
+  provider_name = var.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+
+  alarm_name = "${var.function_name}-${each.key}"
+  preset     = each.value
+  threshold  = 0
+  period     = 300
+  monitored_resource = {
+    facade_type = "lambda"
+    resource_id = var.provider_name == "azure" ? module.azure_lambda[0].function_app_id : var.function_name
+  }
+
+  notification_ref = var.notification_ref
+  # Only the Azure module reads resource_group_name, so null elsewhere is fine
+  provider_config = merge(var.provider_config, {
+    resource_group_name = try(module.azure_lambda[0].resource_group_name, null)
+  })
+  tags = var.tags
+}
+
+# ============================================================================
+# AGGREGATED OUTPUTS
+# ============================================================================
+
+locals {
+  function_arn = (
+    var.provider_name == "aws"   ? (length(module.aws_lambda) > 0 ? module.aws_lambda[0].function_arn : null) :
+    var.provider_name == "azure" ? (length(module.azure_lambda) > 0 ? module.azure_lambda[0].function_app_id : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_lambda) > 0 ? module.gcp_lambda[0].function_id : null) :
+    var.provider_name == "zero"  ? (length(module.zero_lambda) > 0 ? module.zero_lambda[0].function_arn : null) :
+    null
+  )
+
+  # HTTP endpoint (only when enable_http_endpoint is set); ZeroCloud functions
+  # are only reachable through the Invoke API
+  invoke_url = !var.enable_http_endpoint ? null : (
+    var.provider_name == "aws"   ? (length(module.aws_lambda) > 0 ? module.aws_lambda[0].function_url : null) :
+    var.provider_name == "azure" ? (length(module.azure_lambda) > 0 ? module.azure_lambda[0].invoke_url : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_lambda) > 0 ? module.gcp_lambda[0].function_uri : null) :
+    null
   )
+
+  # Alias / slot; Cloud Functions (2nd gen) always serve the latest revision
+  alias_arn = (
+    var.provider_name == "aws"   ? (length(module.aws_lambda) > 0 ? module.aws_lambda[0].alias_arn : null) :
+    var.provider_name == "azure" ? (length(module.azure_lambda) > 0 ? module.azure_lambda[0].slot_id : null) :
+    null
+  )
+
+  qualified_invoke_arn = (
+    var.provider_name == "aws"   ? (length(module.aws_lambda) > 0 ? module.aws_lambda[0].alias_invoke_arn : null) :
+    var.provider_name == "azure" ? (length(module.azure_lambda) > 0 ? module.azure_lambda[0].slot_invoke_url : null) :
+    null
+  )
+}
+
+output "function_arn" {
+  description = "Function identifier (Lambda ARN / Function App ID / Cloud Function ID)"
+  value       = local.function_arn
+
+  precondition {
+    condition     = var.kms_key_ref == null || try(var.kms_key_ref.provider == var.provider_name, false)
+    error_message = "kms_key_ref belongs to ${try(var.kms_key_ref.provider, "another provider")} but this module deploys to ${var.provider_name}"
+  }
+
+  precondition {
+    condition     = var.identity_ref == null || try(var.identity_ref.provider == var.provider_name, false)
+    error_message = "identity_ref belongs to ${try(var.identity_ref.provider, "another provider")} but this module deploys to ${var.provider_name}"
+  }
 }
 
 output "function_name" {
-  value = var.function_name
+  description = "Name of the function"
+  value       = var.function_name
+}
+
+# Target reference accepted in the storage facade's notifications: the
+# Lambda ARN on AWS, and the function inside the Function App on Azure, as
+# Event Grid addresses it. GCP buckets only notify Pub/Sub topics.
+output "event_target_ref" {
+  description = "Event target reference ({provider, type, id}) for the storage facade's notifications"
+  value = {
+    provider = var.provider_name
+    type     = "function"
+    id = (
+      var.provider_name == "azure" ? (length(module.azure_lambda) > 0 ? "${module.azure_lambda[0].function_app_id}/functions/${var.function_name}" : null) :
+      local.function_arn
+    )
+  }
+}
+
+output "invoke_url" {
+  description = "HTTPS endpoint that invokes the function (null when the function has no HTTP trigger)"
+  value       = local.invoke_url
+}
+
+output "alias_arn" {
+  description = "Alias ARN (AWS) / deployment slot ID (Azure); null when alias_name is not set"
+  value       = local.alias_arn
+
+  precondition {
+    condition     = var.canary_weight == null || (var.publish && var.alias_name != null)
+    error_message = "canary_weight requires publish = true and an alias_name"
+  }
+
+  precondition {
+    condition     = var.canary_weight == null || var.provider_name != "aws" || var.stable_version != null
+    error_message = "canary_weight on AWS requires stable_version, the version that keeps the remaining traffic"
+  }
+
+  precondition {
+    condition     = var.canary_weight == null || contains(["aws", "azure"], var.provider_name)
+    error_message = "Weighted traffic shifting is not supported on ${var.provider_name}"
+  }
+}
+
+output "service_plan_sku" {
+  description = "SKU of the Azure Functions plan (Y1 Consumption or EP1-EP3 Elastic Premium); null on other providers"
+  value       = try(module.azure_lambda[0].service_plan_sku, null)
+
+  precondition {
+    condition     = var.provisioned_concurrency == null || try(module.azure_lambda[0].service_plan_sku != "Y1", true)
+    error_message = "provisioned_concurrency needs an Elastic Premium plan on azure, which has always-ready instances; the function is on the Consumption (Y1) plan. Set memory_mb above 1536 or vpc_config to move it to Elastic Premium."
+  }
+}
+
+output "qualified_invoke_arn" {
+  description = "Invoke ARN of the alias (AWS) / invoke URL of the slot (Azure)"
+  value       = local.qualified_invoke_arn
+}
+
+output "alarm_arns" {
+  description = "Identifiers of the default alarms (CloudWatch alarm ARNs / metric alert IDs / alert policy IDs); empty unless enable_default_alarms is set"
+  value       = [for key in keys(local.default_alarms) : module.default_alarms[key].alarm_id]
 }
@@ -3,7 +3,7 @@ variable "provider_name" {
   type        = string
   validation {
     condition     = contains(["aws", "azure", "gcp", "zero"], var.provider_name)
-    error_message = "Provider must be one of: aws, azure, gcp, zero"
+    error_message = "provider_name must be one of: aws, azure, gcp, zero"
   }
 }
 
@@ -19,15 +19,28 @@ variable "handler" {
 }
 
 variable "runtime" {
-  description = "Function runtime"
+  description = "Function runtime in AWS notation (python3.9-3.12, nodejs18.x, nodejs20.x); translated per provider"
   type        = string
   default     = "python3.9"
+  validation {
+    condition     = can(regex("^(python3\\.(9|10|11|12)|nodejs(18|20)\\.x)$", var.runtime))
+    error_message = "Runtime must be one of: python3.9, python3.10, python3.11, python3.12, nodejs18.x, nodejs20.x"
+  }
+  validation {
+    # An unknown provider_name is reported by its own validation
+    condition     = !contains(keys(local.runtime_map), var.provider_name) || contains(keys(try(local.runtime_map[var.provider_name], {})), var.runtime)
+    error_message = "Runtime ${var.runtime} is not supported on ${var.provider_name}. Supported runtimes: ${join(", ", keys(try(local.runtime_map[var.provider_name], {})))}"
+  }
 }
 
 variable "environment" {
   description = "Environment name"
   type        = string
   default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
@@ -53,9 +66,237 @@ variable "source_code" {
   default     = null
 }
 
+variable "source_path" {
+  description = "Path to a pre-built deployment package (zip); ignored when source_code or source_dir is set"
+  type        = string
+  default     = null
+}
+
+variable "source_dir" {
+  description = "Local directory packaged into the deployment zip; content changes trigger a redeploy"
+  type        = string
+  default     = null
+}
+
+variable "source_excludes" {
+  description = "Files in source_dir to leave out of the package (relative paths)"
+  type        = list(string)
+  default     = []
+}
+
+variable "build_command" {
+  description = "Command run inside source_dir before packaging (e.g. for compiled runtimes); requires allow_local_build"
+  type        = string
+  default     = null
+}
+
+variable "allow_local_build" {
+  description = "Allow build_command to execute on the machine running Terraform"
+  type        = bool
+  default     = false
+}
+
 variable "environment_variables" {
-  description = "Environment variables"
+  description = "Environment variables (AWS / ZeroCloud env, Azure app settings, GCP runtime env)"
   type        = map(string)
   default     = {}
+  validation {
+    condition     = alltrue([for k in keys(var.environment_variables) : can(regex("^[a-zA-Z][a-zA-Z0-9_]*$", k))])
+    error_message = "environment_variables names must start with a letter and contain only letters, digits, and underscores, e.g. LOG_LEVEL"
+  }
+  validation {
+    condition = length(setintersection(keys(var.environment_variables), [
+      "_HANDLER", "_X_AMZN_TRACE_ID", "AWS_DEFAULT_REGION", "AWS_REGION", "AWS_EXECUTION_ENV",
+      "AWS_LAMBDA_FUNCTION_NAME", "AWS_LAMBDA_FUNCTION_MEMORY_SIZE", "AWS_LAMBDA_FUNCTION_VERSION",
+      "AWS_LAMBDA_INITIALIZATION_TYPE", "AWS_LAMBDA_LOG_GROUP_NAME", "AWS_LAMBDA_LOG_STREAM_NAME",
+      "AWS_ACCESS_KEY", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+      "AWS_LAMBDA_RUNTIME_API", "LAMBDA_TASK_ROOT", "LAMBDA_RUNTIME_DIR",
+    ])) == 0
+    error_message = "Environment variables must not use names reserved by the Lambda runtime (e.g. AWS_REGION, AWS_LAMBDA_FUNCTION_NAME, _HANDLER)"
+  }
+}
+
+variable "memory_mb" {
+  description = "Memory available to the function in MB (AWS limits: 128-10240)"
+  type        = number
+  default     = 128
+  validation {
+    condition     = var.memory_mb >= 128 && var.memory_mb <= 10240 && floor(var.memory_mb) == var.memory_mb
+    error_message = "memory_mb must be a whole number of MB between 128 and 10240"
+  }
+}
+
+variable "timeout_seconds" {
+  description = "Maximum execution time in seconds (AWS limit: 900)"
+  type        = number
+  default     = 3
+  validation {
+    condition     = var.timeout_seconds >= 1 && var.timeout_seconds <= 900 && floor(var.timeout_seconds) == var.timeout_seconds
+    error_message = "timeout_seconds must be a whole number of seconds between 1 and 900"
+  }
+}
+
+variable "vpc_config" {
+  description = <<-EOT
+    Private network attachment (optional):
+      - subnet_ids: AWS subnets / Azure integration subnet (first entry) / GCP Serverless VPC Access connector (first entry)
+      - security_group_ids: AWS security groups (ignored on Azure and GCP)
+  EOT
+  type = object({
+    subnet_ids         = list(string)
+    security_group_ids = optional(list(string), [])
+  })
+  default = null
+  validation {
+    condition     = var.vpc_config == null || try(length(var.vpc_config.subnet_ids) > 0, false)
+    error_message = "vpc_config.subnet_ids must contain at least one subnet, e.g. [\"subnet-0123456789abcdef0\"]"
+  }
 }
 
+variable "enable_http_endpoint" {
+  description = "Expose the function over HTTPS (Lambda function URL / Function App hostname / Cloud Functions HTTPS trigger)"
+  type        = bool
+  default     = false
+}
+
+variable "http_auth_type" {
+  description = "Authentication for the HTTP endpoint: IAM (private, default) or NONE (public)"
+  type        = string
+  default     = "IAM"
+  validation {
+    condition     = contains(["IAM", "NONE"], var.http_auth_type)
+    error_message = "http_auth_type must be one of: IAM, NONE"
+  }
+}
+
+variable "http_invokers" {
+  description = "Principals allowed to call the HTTP endpoint when http_auth_type is IAM (AWS principal ARNs / GCP members)"
+  type        = list(string)
+  default     = []
+}
+
+variable "publish" {
+  description = "Publish an immutable version on every code or configuration change"
+  type        = bool
+  default     = false
+}
+
+variable "alias_name" {
+  description = "Alias (AWS) / deployment slot (Azure) that callers should target"
+  type        = string
+  default     = null
+}
+
+variable "canary_weight" {
+  description = "Share of alias traffic sent to the newly published version, between 0 and 1 exclusive; requires publish and alias_name"
+  type        = number
+  default     = null
+  validation {
+    condition     = var.canary_weight == null || try(var.canary_weight > 0 && var.canary_weight < 1, false)
+    error_message = "canary_weight must be greater than 0 and less than 1"
+  }
+}
+
+variable "stable_version" {
+  description = "Version that keeps the remaining alias traffic during a canary (AWS); usually the version the alias pointed at before this rollout"
+  type        = string
+  default     = null
+}
+
+variable "reserved_concurrency" {
+  description = "Concurrency limit: reserved concurrent executions on AWS (0 stops invocations), the maximum instance count on Azure and GCP. Null leaves the provider's default."
+  type        = number
+  default     = null
+  validation {
+    condition     = var.reserved_concurrency == null || try(var.reserved_concurrency >= (var.provider_name == "aws" ? 0 : 1) && floor(var.reserved_concurrency) == var.reserved_concurrency, false)
+    error_message = "reserved_concurrency must be a whole number, at least 0 on aws and at least 1 on azure and gcp"
+  }
+  validation {
+    condition     = var.reserved_concurrency == null || var.provider_name != "zero"
+    error_message = "reserved_concurrency is not available on zero"
+  }
+}
+
+variable "provisioned_concurrency" {
+  description = "Instances kept initialized: provisioned concurrency on the AWS alias (requires publish and alias_name), always-ready instances on an Azure Elastic Premium plan, minimum instances on GCP"
+  type        = number
+  default     = null
+  validation {
+    condition     = var.provisioned_concurrency == null || try(var.provisioned_concurrency >= 1 && floor(var.provisioned_concurrency) == var.provisioned_concurrency, false)
+    error_message = "provisioned_concurrency must be a whole number of at least 1"
+  }
+  validation {
+    condition     = var.provisioned_concurrency == null || var.provider_name != "aws" || (var.publish && var.alias_name != null)
+    error_message = "provisioned_concurrency on aws requires publish = true and an alias_name, the alias it is configured on"
+  }
+  validation {
+    condition     = var.provisioned_concurrency == null || var.reserved_concurrency == null || try(var.provisioned_concurrency <= var.reserved_concurrency, false)
+    error_message = "provisioned_concurrency must not exceed reserved_concurrency"
+  }
+  validation {
+    condition     = var.provisioned_concurrency == null || var.provider_name != "zero"
+    error_message = "provisioned_concurrency is not available on zero"
+  }
+}
+
+variable "kms_key_ref" {
+  description = "Customer-managed key from the encryption facade (its kms_key_ref output); must belong to the same provider"
+  type = object({
+    provider = string
+    id       = string
+  })
+  default = null
+  validation {
+    condition     = var.kms_key_ref == null || try(contains(["aws", "azure", "gcp"], var.kms_key_ref.provider) && length(var.kms_key_ref.id) > 0, false)
+    error_message = "kms_key_ref must have provider aws, azure or gcp and a non-empty id, e.g. the encryption facade's kms_key_ref output"
+  }
+}
+
+variable "identity_ref" {
+  description = "Identity the function runs as, the iam facade's identity_ref output: an IAM role on aws, a managed identity on azure. Without it AWS creates an execution role and Azure attaches no identity."
+  type = object({
+    provider = string
+    id       = string
+  })
+  default = null
+  validation {
+    condition     = var.identity_ref == null || contains(["aws", "azure"], var.provider_name)
+    error_message = "identity_ref is only available on aws and azure"
+  }
+}
+
+variable "queue_trigger" {
+  description = "Queue whose messages invoke the function, as the messaging facade's queue_id (an SQS queue ARN), with up to batch_size messages per invocation. Only on aws: Azure Functions declare a serviceBusTrigger in the package's function.json instead."
+  type = object({
+    queue_id   = string
+    batch_size = optional(number, 10)
+  })
+  default = null
+  validation {
+    condition     = var.queue_trigger == null || var.provider_name == "aws"
+    error_message = "queue_trigger is only available on aws; on azure declare a serviceBusTrigger binding in function.json"
+  }
+  validation {
+    condition     = var.queue_trigger == null || try(var.queue_trigger.batch_size >= 1 && var.queue_trigger.batch_size <= 10, false)
+    error_message = "queue_trigger.batch_size must be 1-10 messages, e.g. 10"
+  }
+}
+
+variable "enable_default_alarms" {
+  description = "Create monitoring facade alarms on function errors (Errors / Http5xx / failed executions) over 5 minutes, and on throttles on AWS"
+  type        = bool
+  default     = false
+  validation {
+    condition     = !var.enable_default_alarms || var.provider_name != "zero"
+    error_message = "enable_default_alarms is not available on zero, which has no monitoring service"
+  }
+}
+
+variable "notification_ref" {
+  description = "Channel the default alarms notify ({provider, channel_id}): an SNS topic ARN on aws, a Monitor action group ID on azure, a Cloud Monitoring notification channel ID on gcp"
+  type = object({
+    provider   = string
+    channel_id = string
+  })
+  default = null
+}
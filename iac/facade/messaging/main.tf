@@ -2,22 +2,156 @@
 # Unified interface for Queue and Topic resources across providers
 
 terraform {
-  required_version = ">= 1.0"
+  # Variable validations compare the queue tuning against local.queue_limits
+  required_version = ">= 1.9"
+
+  # Declared so callers can pass an aliased configuration with the
+  # providers argument; the default alarms inherit it
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
 }
 
-locals {
-  common_tags = merge(
-    var.tags,
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy    = "Terraform"
-      Environment  = var.environment
-      Provider     = var.provider_name
-      Project      = var.project_name
-      Module       = "Messaging-Facade"
-    }
+      Provider = var.provider_name
+      Module   = "Messaging-Facade"
+    },
+    var.tags
   )
 }
 
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  azure_sku = try(var.provider_config.sku, "Standard")
+
+  # Provider bounds for the normalized queue parameters, enforced by the
+  # validations on the queue tuning variables
+  queue_limits = {
+    aws = {
+      visibility_field = "SQS visibility timeout"
+      visibility_min   = 0
+      visibility_max   = 43200
+      retention_min    = 60
+      retention_max    = 1209600 # 14 days
+      size_max_kb      = 256
+      delay_max        = 900
+    }
+    zero = {
+      visibility_field = "ZeroQueue visibility timeout"
+      visibility_min   = 0
+      visibility_max   = 43200
+      retention_min    = 60
+      retention_max    = 1209600
+      size_max_kb      = 256
+      delay_max        = 900
+    }
+    azure = {
+      visibility_field = "Service Bus lock duration"
+      visibility_min   = 5
+      visibility_max   = 300
+      retention_min    = 1
+      retention_max    = 922337203685 # TimeSpan.MaxValue
+      size_max_kb      = local.azure_sku == "Premium" ? 102400 : 256
+      delay_max        = 0 # no entity-level delay; schedule messages instead
+    }
+    gcp = {
+      visibility_field = "Pub/Sub ack deadline"
+      visibility_min   = 10
+      visibility_max   = 600
+      retention_min    = 600
+      retention_max    = 604800 # 7 days
+      size_max_kb      = 10240
+      delay_max        = 0
+    }
+  }
+
+  limits = local.queue_limits[var.provider_name]
+
+  kms_key_id = var.kms_key_ref != null ? var.kms_key_ref.id : null
+
+  # Access Grants
+  # The actions each access level allows on AWS, by resource type. Never a
+  # wildcard, which the messaging_access policy rejects
+  grant_actions = {
+    queue = {
+      produce = ["sqs:GetQueueUrl", "sqs:GetQueueAttributes", "sqs:SendMessage"]
+      consume = ["sqs:GetQueueUrl", "sqs:GetQueueAttributes", "sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility"]
+      manage  = ["sqs:GetQueueUrl", "sqs:GetQueueAttributes", "sqs:SendMessage", "sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility", "sqs:PurgeQueue", "sqs:SetQueueAttributes"]
+    }
+    topic = {
+      produce = ["sns:GetTopicAttributes", "sns:Publish"]
+      consume = ["sns:GetTopicAttributes", "sns:Subscribe", "sns:ListSubscriptionsByTopic"]
+      manage  = ["sns:GetTopicAttributes", "sns:Publish", "sns:Subscribe", "sns:ListSubscriptionsByTopic", "sns:SetTopicAttributes"]
+    }
+  }
+
+  # The built-in data role each access level assigns on Azure
+  grant_roles = {
+    produce = "Azure Service Bus Data Sender"
+    consume = "Azure Service Bus Data Receiver"
+    manage  = "Azure Service Bus Data Owner"
+  }
+
+  # The Pub/Sub roles each access level grants on GCP. A queue's consumers
+  # pull from its subscription, so consume is granted there rather than on
+  # the topic, and manage on both. Manage is publisher, subscriber and viewer
+  # rather than editor, which could also delete the topic or subscription
+  grant_gcp_roles = {
+    produce = ["roles/pubsub.publisher"]
+    consume = ["roles/pubsub.subscriber"]
+    manage  = ["roles/pubsub.publisher", "roles/pubsub.subscriber", "roles/pubsub.viewer"]
+  }
+
+  # Distinct, since the core modules key members by member and role and a
+  # principal granted both produce and manage would repeat publisher
+  gcp_topic_members = distinct(flatten([
+    for g in var.grants : [
+      for role in local.grant_gcp_roles[g.access] : {
+        role   = role
+        member = "serviceAccount:${g.principal_ref.id}"
+      }
+    ]
+    if var.type == "topic" || g.access != "consume"
+  ]))
+
+  gcp_subscription_members = distinct(flatten([
+    for g in var.grants : [
+      for role in local.grant_gcp_roles[g.access] : {
+        role   = role
+        member = "serviceAccount:${g.principal_ref.id}"
+      }
+    ]
+    if var.type == "queue" && g.access != "produce"
+  ]))
+
+  # The action an AWS service is granted to deliver to the queue or topic
+  service_grant_actions = {
+    queue = ["sqs:SendMessage"]
+    topic = ["sns:Publish"]
+  }
+}
+
 # AWS: SQS or SNS
 module "aws_messaging" {
   count  = var.provider_name == "aws" ? 1 : 0
@@ -29,7 +163,35 @@ module "aws_messaging" {
   create_topic = var.type == "topic"
   topic_name   = var.name
   
-  tags = local.common_tags
+  visibility_timeout_seconds = var.visibility_timeout_seconds
+  message_retention_seconds  = var.message_retention_seconds
+  max_message_size           = var.max_message_size_kb * 1024
+  delay_seconds              = var.delivery_delay_seconds
+  
+  create_dlq        = var.dead_letter_queue
+  max_receive_count = var.max_receive_count
+  
+  # Encryption: SNS keeps the AWS-managed key unless a CMK is given
+  queue_kms_master_key_id = local.kms_key_id
+  kms_master_key_id       = local.kms_key_id != null ? local.kms_key_id : "alias/aws/sns"
+  
+  # Grants and service grants, as one statement each in the queue or topic
+  # policy
+  access_grants = [
+    for g in var.grants : {
+      principal_arn = g.principal_ref.id
+      actions       = local.grant_actions[var.type][g.access]
+    }
+  ]
+  service_grants = [
+    for s in var.service_grants : {
+      service    = s.service
+      source_arn = s.source_arn
+      actions    = local.service_grant_actions[var.type]
+    }
+  ]
+  
+  tags = local.default_tags
 }
 
 # Azure: Service Bus
@@ -43,10 +205,32 @@ module "azure_messaging" {
   create_topic = var.type == "topic"
   topic_name   = var.name
   
-  tags = local.common_tags
+  namespace_name      = try(var.provider_config.namespace_name, null)
+  resource_group_name = try(var.provider_config.resource_group_name, module.resource_group_name.name)
+  location            = try(var.provider_config.location, "East US")
+  sku                 = local.azure_sku
+  
+  lock_duration_seconds = var.visibility_timeout_seconds
+  message_ttl_seconds   = var.message_retention_seconds
+  max_message_size_kb   = var.max_message_size_kb
+  
+  # Service Bus always dead-letters; without a DLQ keep its default count
+  max_delivery_count = var.dead_letter_queue ? var.max_receive_count : null
+  
+  customer_managed_key_id = local.kms_key_id
+  
+  # Grants, as data role assignments on the queue or topic
+  role_assignments = distinct([
+    for g in var.grants : {
+      role_definition_name = local.grant_roles[g.access]
+      principal_id         = g.principal_ref.id
+    }
+  ])
+  
+  tags = local.default_tags
 }
 
-# GCP: Pub/Sub
+# GCP: Pub/Sub (a queue is a topic plus a pull subscription)
 module "gcp_messaging" {
   count  = var.provider_name == "gcp" ? 1 : 0
   source = "../../gcp/core/messaging"
@@ -57,7 +241,21 @@ module "gcp_messaging" {
   create_topic = var.type == "topic"
   topic_name   = var.name
   
-  tags = local.common_tags
+  project_id = try(var.provider_config.project_id, null)
+  
+  ack_deadline_seconds      = var.visibility_timeout_seconds
+  message_retention_seconds = var.message_retention_seconds
+  
+  dead_letter           = var.dead_letter_queue
+  max_delivery_attempts = var.max_receive_count
+  
+  kms_key_name = local.kms_key_id
+  
+  # Grants, as IAM members of the topic and queue subscription
+  topic_iam_members        = local.gcp_topic_members
+  subscription_iam_members = local.gcp_subscription_members
+  
+  tags = local.default_labels
 }
 
 # ZeroCloud: ZeroQueue
@@ -71,24 +269,133 @@ module "zero_messaging" {
   create_topic = var.type == "topic"
   topic_name   = var.name
   
-  tags = local.common_tags
+  visibility_timeout_seconds = var.visibility_timeout_seconds
+  message_retention_seconds  = var.message_retention_seconds
+  max_message_size           = var.max_message_size_kb * 1024
+  delay_seconds              = var.delivery_delay_seconds
+  
+  create_dlq        = var.dead_letter_queue
+  max_receive_count = var.max_receive_count
+  
+  tags = local.default_tags
 }
 
-output "resource_arn" {
-  value = (
-    var.provider_name == "aws" ? (var.type == "queue" ? module.aws_messaging[0].queue_arn : module.aws_messaging[0].topic_arn) : 
-    var.provider_name == "azure" ? "azure-arn-placeholder" :
-    var.provider_name == "gcp" ? "gcp-id-placeholder" :
-    var.provider_name == "zero" ? (var.type == "queue" ? module.zero_messaging[0].queue_arn : module.zero_messaging[0].topic_arn) :
+# ============================================================================
+# DEFAULT ALARMS
+# ============================================================================
+
+# Any message in the dead-letter queue means a consumer gave up on it. The
+# DLQ is a queue of its own on AWS and a subscription of its own on GCP;
+# Service Bus counts dead letters on the queue entity of the namespace.
+module "default_alarms" {
+  count  = var.enable_default_alarms ? 1 : 0
+  source = "../monitoring"
+
+  provider_name = var.provider_name
+  project_name  = var.project_name
+  environment   = var.environment
+
+  alarm_name = "${var.name}-dead-letters"
+  preset     = "dead_letter_depth"
+  threshold  = 0
+  monitored_resource = {
+    facade_type = "messaging"
+    resource_id = var.provider_name == "azure" ? module.azure_messaging[0].namespace_id : "${var.name}-dlq"
+    entity_name = var.provider_name == "azure" ? var.name : null
+  }
+
+  notification_ref = var.notification_ref
+  provider_config  = var.provider_config
+  tags             = var.tags
+}
+
+# ============================================================================
+# AGGREGATED OUTPUTS
+# ============================================================================
+
+# Normalized identifiers:
+#   queue_url - endpoint producers/consumers talk to (SQS URL / Service Bus queue URL / Pub/Sub pull URL)
+#   queue_id  - provider resource identifier (SQS ARN / Service Bus queue ID / subscription ID)
+#   topic_arn - identifier publishers target (SNS ARN / Service Bus topic ID / Pub/Sub topic ID)
+#   topic_id  - provider resource identifier of the topic
+locals {
+  queue_url = var.type != "queue" ? null : (
+    var.provider_name == "aws"   ? (length(module.aws_messaging) > 0 ? module.aws_messaging[0].queue_id : null) :
+    var.provider_name == "azure" ? (length(module.azure_messaging) > 0 ? module.azure_messaging[0].queue_url : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_messaging) > 0 ? module.gcp_messaging[0].subscription_pull_url : null) :
+    var.provider_name == "zero"  ? (length(module.zero_messaging) > 0 ? module.zero_messaging[0].queue_id : null) :
     null
   )
-}
 
-output "resource_url" {
-  value = (
-    var.provider_name == "aws" && var.type == "queue" ? module.aws_messaging[0].queue_id :
-    var.provider_name == "zero" && var.type == "queue" ? module.zero_messaging[0].queue_id :
+  queue_id = var.type != "queue" ? null : (
+    var.provider_name == "aws"   ? (length(module.aws_messaging) > 0 ? module.aws_messaging[0].queue_arn : null) :
+    var.provider_name == "azure" ? (length(module.azure_messaging) > 0 ? module.azure_messaging[0].queue_id : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_messaging) > 0 ? module.gcp_messaging[0].subscription_id : null) :
+    var.provider_name == "zero"  ? (length(module.zero_messaging) > 0 ? module.zero_messaging[0].queue_arn : null) :
+    null
+  )
+
+  topic_arn = var.type != "topic" ? null : (
+    var.provider_name == "aws"   ? (length(module.aws_messaging) > 0 ? module.aws_messaging[0].topic_arn : null) :
+    var.provider_name == "azure" ? (length(module.azure_messaging) > 0 ? module.azure_messaging[0].topic_id : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_messaging) > 0 ? module.gcp_messaging[0].topic_id : null) :
+    var.provider_name == "zero"  ? (length(module.zero_messaging) > 0 ? module.zero_messaging[0].topic_arn : null) :
     null
   )
 }
 
+output "queue_url" {
+  description = "Queue endpoint (SQS URL / Service Bus queue URL / Pub/Sub subscription pull URL)"
+  value       = local.queue_url
+}
+
+output "queue_id" {
+  description = "Queue identifier (SQS ARN / Service Bus queue ID / Pub/Sub subscription ID)"
+  value       = local.queue_id
+}
+
+output "topic_arn" {
+  description = "Topic identifier for publishers (SNS ARN / Service Bus topic ID / Pub/Sub topic ID)"
+  value       = local.topic_arn
+}
+
+output "topic_id" {
+  description = "Topic resource identifier (same value as topic_arn)"
+  value       = local.topic_arn
+}
+
+output "resource_arn" {
+  description = "Identifier of the queue or topic (queue_id or topic_arn)"
+  value       = var.type == "queue" ? local.queue_id : local.topic_arn
+
+  precondition {
+    condition     = var.kms_key_ref == null || try(var.kms_key_ref.provider == var.provider_name, false)
+    error_message = "kms_key_ref belongs to ${try(var.kms_key_ref.provider, "another provider")} but this module deploys to ${var.provider_name}"
+  }
+
+  precondition {
+    condition     = var.kms_key_ref == null || var.provider_name != "azure" || local.azure_sku == "Premium"
+    error_message = "Customer-managed keys on Service Bus require provider_config.sku = \"Premium\""
+  }
+}
+
+output "resource_url" {
+  description = "Queue endpoint (queue_url); null for topics"
+  value       = local.queue_url
+}
+
+# Target reference accepted in the storage facade's notifications: the
+# queue (queue_id) or topic (topic_arn) the facade created
+output "event_target_ref" {
+  description = "Event target reference ({provider, type, id}) for the storage facade's notifications"
+  value = {
+    provider = var.provider_name
+    type     = var.type
+    id       = var.type == "queue" ? local.queue_id : local.topic_arn
+  }
+}
+
+output "alarm_arns" {
+  description = "Identifiers of the default alarms (CloudWatch alarm ARNs / metric alert IDs / alert policy IDs); empty unless enable_default_alarms is set"
+  value       = module.default_alarms[*].alarm_id
+}
@@ -1,30 +1,39 @@
 package messaging_test
 
 import (
-	"testing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
 	"strings"
+	"testing"
+
+	"iac/testutil/planerr"
+	"iac/testutil/policycheck"
+	"iac/testutil/tfopts"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMessagingFacadeAwsQueue(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":     "aws",
-			"project_name": "testproject",
-			"environment":  "test",
-			"name":         "test-queue",
-			"type":         "queue",
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"name":          "test-queue",
+			"type":          "queue",
 		},
 		BackendConfig: map[string]interface{}{},
 	})
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
-	
+
 	assert.True(t, strings.Contains(planString, "module.aws_messaging[0].aws_sqs_queue.this"), "Plan should create an AWS SQS queue")
 	assert.True(t, strings.Contains(planString, "name = \"test-queue\""), "Plan should have the correct queue name")
 }
@@ -32,20 +41,670 @@ func TestMessagingFacadeAwsQueue(t *testing.T) {
 func TestMessagingFacadeAwsTopic(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":     "aws",
-			"project_name": "testproject",
-			"environment":  "test",
-			"name":         "test-topic-sns",
-			"type":         "topic",
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"name":          "test-topic-sns",
+			"type":          "topic",
 		},
 		BackendConfig: map[string]interface{}{},
 	})
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
-	
+
 	assert.True(t, strings.Contains(planString, "module.aws_messaging[0].aws_sns_topic.this"), "Plan should create an AWS SNS topic")
 	assert.True(t, strings.Contains(planString, "name = \"test-topic-sns\""), "Plan should have the correct topic name")
 }
+
+func TestMessagingFacadeAzureQueue(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "azure",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"name":          "test-queue",
+			"type":          "queue",
+			"provider_config": map[string]interface{}{
+				"resource_group_name": "messaging-rg",
+				"location":            "westeurope",
+			},
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.azure_messaging[0].azurerm_servicebus_namespace.this[0]"), "Plan should create a Service Bus namespace")
+	assert.True(t, strings.Contains(planString, "module.azure_messaging[0].azurerm_servicebus_queue.this[0]"), "Plan should create a Service Bus queue")
+	assert.False(t, strings.Contains(planString, "azurerm_servicebus_topic"), "Queues should not create a topic")
+	assert.Regexp(t, `name\s+= "test-queue"`, planString, "Plan should have the correct queue name")
+	assert.Regexp(t, `resource_group_name\s+= "messaging-rg"`, planString, "Namespace should use the configured resource group")
+	assert.True(t, strings.Contains(planString, "https://test-queue-ns.servicebus.windows.net/test-queue"), "queue_url should be the Service Bus queue endpoint")
+}
+
+func TestMessagingFacadeAzureTopic(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "azure",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"name":          "test-topic",
+			"type":          "topic",
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.azure_messaging[0].azurerm_servicebus_topic.this[0]"), "Plan should create a Service Bus topic")
+	assert.False(t, strings.Contains(planString, "azurerm_servicebus_queue"), "Topics should not create a queue")
+	assert.Regexp(t, `sku\s+= "Standard"`, planString, "Topics need at least the Standard namespace SKU")
+}
+
+func TestMessagingFacadeGcpQueue(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "gcp",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"name":          "test-queue",
+			"type":          "queue",
+			"provider_config": map[string]interface{}{
+				"project_id": "test-project",
+			},
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.gcp_messaging[0].google_pubsub_topic.this[0]"), "Queues need a backing Pub/Sub topic")
+	assert.True(t, strings.Contains(planString, "module.gcp_messaging[0].google_pubsub_subscription.queue[0]"), "Queues should be a pull subscription")
+	assert.Regexp(t, `name\s+= "test-queue"`, planString, "Plan should have the correct queue name")
+	assert.Regexp(t, `project\s+= "test-project"`, planString, "Resources should be created in the configured project")
+	assert.True(t, strings.Contains(planString, "\"managedby\" = \"terraform\""), "Labels should be lowercased for GCP")
+}
+
+func TestMessagingFacadeGcpTopic(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "gcp",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"name":          "test-topic",
+			"type":          "topic",
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.gcp_messaging[0].google_pubsub_topic.this[0]"), "Plan should create a Pub/Sub topic")
+	assert.False(t, strings.Contains(planString, "google_pubsub_subscription"), "Topics should not create a subscription")
+}
+
+func TestMessagingFacadeZero(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":              "zero",
+			"project_name":               "testproject",
+			"environment":                "dev",
+			"name":                       "test-queue",
+			"type":                       "queue",
+			"visibility_timeout_seconds": 60,
+		},
+		BackendConfig: map[string]interface{}{},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.zero_messaging[0].aws_sqs_queue.this[0]"), "Plan should create a ZeroQueue queue")
+	assert.False(t, strings.Contains(planString, "module.zero_messaging[0].aws_sns_topic.this"), "Plan should not create a topic for a queue")
+	assert.Regexp(t, `visibility_timeout_seconds\s+= 60`, planString)
+}
+
+func TestMessagingFacadeAwsQueueTuning(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":              "aws",
+			"project_name":               "testproject",
+			"name":                       "tuned-queue",
+			"visibility_timeout_seconds": 60,
+			"message_retention_seconds":  86400,
+			"max_message_size_kb":        128,
+			"delivery_delay_seconds":     15,
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `visibility_timeout_seconds\s+= 60`, planString, "Visibility timeout should pass through in seconds")
+	assert.Regexp(t, `message_retention_seconds\s+= 86400`, planString, "Retention should pass through in seconds")
+	assert.Regexp(t, `max_message_size\s+= 131072`, planString, "Message size should be converted to bytes")
+	assert.Regexp(t, `delay_seconds\s+= 15`, planString, "Delivery delay should map to delay_seconds")
+}
+
+func TestMessagingFacadeAzureQueueTuning(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":              "azure",
+			"project_name":               "testproject",
+			"name":                       "tuned-queue",
+			"visibility_timeout_seconds": 60,
+			"message_retention_seconds":  86400,
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `lock_duration\s+= "PT1M"`, planString, "Visibility timeout should become an ISO 8601 lock duration")
+	assert.Regexp(t, `default_message_ttl\s+= "P1D"`, planString, "Retention should become an ISO 8601 TTL")
+}
+
+func TestMessagingFacadeGcpQueueTuning(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":              "gcp",
+			"project_name":               "testproject",
+			"name":                       "tuned-queue",
+			"visibility_timeout_seconds": 60,
+			"message_retention_seconds":  86400,
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `ack_deadline_seconds\s+= 60`, planString, "Visibility timeout should become the ack deadline")
+	assert.Regexp(t, `message_retention_duration\s+= "86400s"`, planString, "Retention should be rendered as a duration string")
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"name":          "test-queue",
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "UnknownType",
+			Vars: map[string]interface{}{"type": "stream"},
+			Want: "type must be one of: queue, topic",
+		},
+		{
+			Name: "NegativeVisibilityTimeout",
+			Vars: map[string]interface{}{"visibility_timeout_seconds": -1},
+			Want: "visibility_timeout_seconds must be a non-negative integer",
+		},
+		{
+			Name: "ZeroRetention",
+			Vars: map[string]interface{}{"message_retention_seconds": 0},
+			Want: "message_retention_seconds must be a positive integer",
+		},
+		{
+			Name: "ZeroMessageSize",
+			Vars: map[string]interface{}{"max_message_size_kb": 0},
+			Want: "max_message_size_kb must be a positive integer",
+		},
+		{
+			Name: "FractionalDeliveryDelay",
+			Vars: map[string]interface{}{"delivery_delay_seconds": 1.5},
+			Want: "delivery_delay_seconds must be a non-negative integer",
+		},
+		{
+			Name: "DeadLetterQueueOnTopic",
+			Vars: map[string]interface{}{"type": "topic", "dead_letter_queue": true},
+			Want: "dead_letter_queue is only available for type = \"queue\"",
+		},
+		{
+			Name: "ReceiveCountBelowPubSubMinimum",
+			Vars: map[string]interface{}{"dead_letter_queue": true, "max_receive_count": 3},
+			Want: "max_receive_count must be a whole number between 5 and 100",
+		},
+		{
+			Name: "AlarmsWithoutDeadLetterQueue",
+			Vars: map[string]interface{}{"enable_default_alarms": true},
+			Want: "enable_default_alarms alarms on the dead-letter queue; set dead_letter_queue = true",
+		},
+		{
+			Name: "AlarmsOnZero",
+			Vars: map[string]interface{}{"provider_name": "zero", "dead_letter_queue": true, "enable_default_alarms": true},
+			Want: "enable_default_alarms is not available on zero",
+		},
+		{
+			Name: "UnknownGrantAccess",
+			Vars: map[string]interface{}{"grants": []map[string]interface{}{grant("aws", "arn:aws:iam::123456789012:role/worker", "admin")}},
+			Want: "Each grants access must be one of: produce, consume, manage",
+		},
+		{
+			Name: "GrantToAnotherProvider",
+			Vars: map[string]interface{}{"grants": []map[string]interface{}{grant("gcp", "worker@test-project.iam.gserviceaccount.com", "produce")}},
+			Want: "Each grants principal_ref must belong to aws",
+		},
+		{
+			Name: "GrantsOnZero",
+			Vars: map[string]interface{}{"provider_name": "zero", "grants": []map[string]interface{}{grant("zero", "arn:aws:iam::000000000000:role/worker", "produce")}},
+			Want: "grants is not available on zero",
+		},
+		{
+			Name: "ServiceGrantWithoutSource",
+			Vars: map[string]interface{}{"service_grants": []map[string]interface{}{{"service": "s3.amazonaws.com", "source_arn": ""}}},
+			Want: "Each service_grants entry needs a service principal such as s3.amazonaws.com and a source_arn",
+		},
+		{
+			Name: "ServiceGrantsOnAzure",
+			Vars: map[string]interface{}{"provider_name": "azure", "service_grants": []map[string]interface{}{{"service": "s3.amazonaws.com", "source_arn": "arn:aws:s3:::uploads"}}},
+			Want: "service_grants is only available on aws",
+		},
+	})
+}
+
+// TestMessagingFacadeQueueTuningBounds covers values that pass the variable
+// validations but exceed what the chosen provider accepts
+func TestMessagingFacadeQueueTuningBounds(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"project_name": "testproject",
+		"name":         "bounded-queue",
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "SqsRetentionOver14Days",
+			Vars: map[string]interface{}{"provider_name": "aws", "message_retention_seconds": 1209601},
+			Want: "message_retention_seconds must be between 60 and 1209600 seconds on aws",
+		},
+		{
+			Name: "ServiceBusLockOver5Minutes",
+			Vars: map[string]interface{}{"provider_name": "azure", "visibility_timeout_seconds": 301},
+			Want: "Service Bus lock duration on azure, which must be between 5 and 300 seconds",
+		},
+		{
+			Name: "PubSubAckDeadlineOver600",
+			Vars: map[string]interface{}{"provider_name": "gcp", "visibility_timeout_seconds": 601},
+			Want: "Pub/Sub ack deadline on gcp, which must be between 10 and 600 seconds",
+		},
+		{
+			Name: "ServiceBusDeliveryDelay",
+			Vars: map[string]interface{}{"provider_name": "azure", "delivery_delay_seconds": 10},
+			Want: "delivery_delay_seconds must be at most 0 on azure",
+		},
+		{
+			Name: "SqsMessageOver256KB",
+			Vars: map[string]interface{}{"provider_name": "aws", "max_message_size_kb": 512},
+			Want: "max_message_size_kb exceeds the 256 KB limit on aws",
+		},
+	})
+}
+
+func TestMessagingFacadeAwsDefaultAlarms(t *testing.T) {
+	t.Parallel()
+
+	const topic = "arn:aws:sns:us-east-1:123456789012:oncall"
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":         "aws",
+			"project_name":          "testproject",
+			"environment":           "dev",
+			"name":                  "test-queue",
+			"dead_letter_queue":     true,
+			"max_receive_count":     8,
+			"enable_default_alarms": true,
+			"notification_ref":      map[string]interface{}{"provider": "aws", "channel_id": topic},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	dlq, ok := plan.ResourcePlannedValuesMap["module.aws_messaging[0].aws_sqs_queue.dlq[0]"]
+	require.True(t, ok, "Plan should create the dead-letter queue")
+	assert.Equal(t, "test-queue-dlq", dlq.AttributeValues["name"])
+
+	alarm, ok := plan.ResourcePlannedValuesMap["module.default_alarms[0].module.aws_monitoring[0].aws_cloudwatch_metric_alarm.this[0]"]
+	require.True(t, ok, "Plan should create the dead-letter alarm")
+	assert.Equal(t, "AWS/SQS", alarm.AttributeValues["namespace"])
+	assert.Equal(t, "ApproximateNumberOfMessagesVisible", alarm.AttributeValues["metric_name"])
+	assert.EqualValues(t, 0, alarm.AttributeValues["threshold"])
+	assert.Equal(t, map[string]interface{}{"QueueName": "test-queue-dlq"}, alarm.AttributeValues["dimensions"],
+		"The alarm should watch the dead-letter queue, not the queue itself")
+	assert.Equal(t, []interface{}{topic}, alarm.AttributeValues["alarm_actions"])
+}
+
+func TestMessagingFacadeWithoutDefaultAlarms(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":     "aws",
+			"project_name":      "testproject",
+			"environment":       "dev",
+			"name":              "test-queue",
+			"dead_letter_queue": true,
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "module.aws_messaging[0].aws_sqs_queue.dlq[0]")
+	for address := range plan.ResourcePlannedValuesMap {
+		assert.False(t, strings.HasPrefix(address, "module.default_alarms"), "No alarms without enable_default_alarms, found %s", address)
+	}
+}
+
+func TestMessagingFacadeAzureDefaultAlarms(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":         "azure",
+			"project_name":          "testproject",
+			"environment":           "dev",
+			"name":                  "test-queue",
+			"dead_letter_queue":     true,
+			"enable_default_alarms": true,
+			"provider_config":       map[string]interface{}{"resource_group_name": "messaging-rg"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	queue := plan.ResourcePlannedValuesMap["module.azure_messaging[0].azurerm_servicebus_queue.this[0]"]
+	require.NotNil(t, queue)
+	assert.EqualValues(t, 5, queue.AttributeValues["max_delivery_count"])
+
+	alert, ok := plan.ResourcePlannedValuesMap["module.default_alarms[0].module.azure_monitoring[0].azurerm_monitor_metric_alert.this[0]"]
+	require.True(t, ok, "Plan should create the dead-letter alert")
+	assert.Equal(t, "messaging-rg", alert.AttributeValues["resource_group_name"])
+	criteria := alert.AttributeValues["criteria"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "DeadletteredMessages", criteria["metric_name"])
+	dimension := criteria["dimension"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "EntityName", dimension["name"])
+	assert.Equal(t, []interface{}{"test-queue"}, dimension["values"], "The alert should be narrowed to the queue")
+}
+
+func TestMessagingFacadeGcpDefaultAlarms(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":         "gcp",
+			"project_name":          "testproject",
+			"environment":           "dev",
+			"name":                  "test-queue",
+			"dead_letter_queue":     true,
+			"enable_default_alarms": true,
+			"provider_config":       map[string]interface{}{"project_id": "test-project"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	subscription, ok := plan.ResourcePlannedValuesMap["module.gcp_messaging[0].google_pubsub_subscription.dead_letter[0]"]
+	require.True(t, ok, "Plan should create the dead-letter subscription")
+	assert.Equal(t, "test-queue-dlq", subscription.AttributeValues["name"])
+	queue := plan.ResourcePlannedValuesMap["module.gcp_messaging[0].google_pubsub_subscription.queue[0]"]
+	policy := queue.AttributeValues["dead_letter_policy"].([]interface{})[0].(map[string]interface{})
+	assert.EqualValues(t, 5, policy["max_delivery_attempts"])
+
+	alert, ok := plan.ResourcePlannedValuesMap["module.default_alarms[0].module.gcp_monitoring[0].google_monitoring_alert_policy.this[0]"]
+	require.True(t, ok, "Plan should create the dead-letter alert policy")
+	condition := alert.AttributeValues["conditions"].([]interface{})[0].(map[string]interface{})
+	threshold := condition["condition_threshold"].([]interface{})[0].(map[string]interface{})
+	assert.Contains(t, threshold["filter"], `resource.labels.subscription_id = "test-queue-dlq"`,
+		"The alert should watch the dead-letter subscription")
+}
+
+// grant is one entry of the grants variable
+func grant(provider, id, access string) map[string]interface{} {
+	return map[string]interface{}{
+		"principal_ref": map[string]interface{}{"provider": provider, "id": id},
+		"access":        access,
+	}
+}
+
+// accessLevels are the grants access levels, in the order the grant tests
+// pass them
+var accessLevels = []string{"produce", "consume", "manage"}
+
+// TestMessagingFacadeAwsGrants plans a queue and a topic with one grant of
+// each access level and a service grant for a bucket, and checks that each
+// statement of the access policy allows exactly that grant's actions, none
+// of them a wildcard
+func TestMessagingFacadeAwsGrants(t *testing.T) {
+	t.Parallel()
+
+	const (
+		principal = "arn:aws:iam::123456789012:role/"
+		bucket    = "arn:aws:s3:::uploads"
+	)
+
+	tests := map[string]struct {
+		address string
+		actions map[string][]string
+		send    string
+	}{
+		"queue": {
+			address: "module.aws_messaging[0].aws_sqs_queue_policy.access[0]",
+			actions: map[string][]string{
+				"produce": {"sqs:GetQueueUrl", "sqs:GetQueueAttributes", "sqs:SendMessage"},
+				"consume": {"sqs:GetQueueUrl", "sqs:GetQueueAttributes", "sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility"},
+				"manage": {"sqs:GetQueueUrl", "sqs:GetQueueAttributes", "sqs:SendMessage", "sqs:ReceiveMessage", "sqs:DeleteMessage",
+					"sqs:ChangeMessageVisibility", "sqs:PurgeQueue", "sqs:SetQueueAttributes"},
+			},
+			send: "sqs:SendMessage",
+		},
+		"topic": {
+			address: "module.aws_messaging[0].aws_sns_topic_policy.access[0]",
+			actions: map[string][]string{
+				"produce": {"sns:GetTopicAttributes", "sns:Publish"},
+				"consume": {"sns:GetTopicAttributes", "sns:Subscribe", "sns:ListSubscriptionsByTopic"},
+				"manage":  {"sns:GetTopicAttributes", "sns:Publish", "sns:Subscribe", "sns:ListSubscriptionsByTopic", "sns:SetTopicAttributes"},
+			},
+			send: "sns:Publish",
+		},
+	}
+
+	for messagingType, tc := range tests {
+		messagingType, tc := messagingType, tc
+
+		t.Run(messagingType, func(t *testing.T) {
+			t.Parallel()
+
+			name := "granted-" + messagingType
+			grants := make([]map[string]interface{}, 0, len(accessLevels))
+			for _, access := range accessLevels {
+				grants = append(grants, grant("aws", principal+access, access))
+			}
+
+			planJSON := terraform.InitAndPlanAndShow(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars: map[string]interface{}{
+					"provider_name": "aws",
+					"project_name":  "testproject",
+					"environment":   "dev",
+					"name":          name,
+					"type":          messagingType,
+					"grants":        grants,
+					"service_grants": []map[string]interface{}{
+						{"service": "s3.amazonaws.com", "source_arn": bucket},
+					},
+				},
+				PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+				NoColor:      true,
+			}))
+			plan, err := terraform.ParsePlanJSON(planJSON)
+			require.NoError(t, err)
+
+			resource, ok := plan.ResourcePlannedValuesMap[tc.address]
+			require.True(t, ok, "Plan should create %s", tc.address)
+			document, ok := resource.AttributeValues["policy"].(string)
+			require.True(t, ok, "The access policy should be known at plan time")
+
+			// aws_iam_policy_document renders a lone action as a string
+			// rather than a list, and sorts them
+			var policy struct {
+				Statement []struct {
+					Effect    string
+					Principal struct{ AWS, Service string }
+					Action    interface{}
+					Resource  string
+					Condition map[string]map[string]string
+				}
+			}
+			require.NoError(t, json.Unmarshal([]byte(document), &policy))
+			require.Len(t, policy.Statement, len(accessLevels)+1, "Each grant and service grant should be one statement")
+			for i, access := range accessLevels {
+				statement := policy.Statement[i]
+				assert.Equal(t, "Allow", statement.Effect)
+				assert.Equal(t, principal+access, statement.Principal.AWS)
+				assert.ElementsMatch(t, tc.actions[access], statement.Action, "%s should allow only its own actions", access)
+				assert.Regexp(t, `^arn:aws:s[qn]s:[a-z0-9-]+:[0-9]{12}:`+name+`$`, statement.Resource, "Statements should be scoped to the %s", messagingType)
+			}
+
+			service := policy.Statement[len(accessLevels)]
+			assert.Equal(t, "s3.amazonaws.com", service.Principal.Service)
+			assert.Equal(t, tc.send, service.Action, "The bucket should only be able to deliver")
+			assert.Equal(t, map[string]string{"aws:SourceArn": bucket}, service.Condition["ArnEquals"], "The service should be confined to the bucket")
+			assert.Equal(t, policy.Statement[0].Resource, service.Resource)
+
+			violations, err := policycheck.Evaluate(context.Background(), []byte(planJSON))
+			require.NoError(t, err)
+			for _, v := range violations {
+				assert.NotEqual(t, "messaging_access", v.Policy, "Grants should pass the messaging_access policy: %s", v)
+			}
+		})
+	}
+}
+
+func TestMessagingFacadeAzureGrants(t *testing.T) {
+	t.Parallel()
+
+	principals := []string{
+		"11111111-1111-1111-1111-111111111111",
+		"22222222-2222-2222-2222-222222222222",
+		"33333333-3333-3333-3333-333333333333",
+	}
+	grants := make([]map[string]interface{}, 0, len(accessLevels))
+	for i, access := range accessLevels {
+		grants = append(grants, grant("azure", principals[i], access))
+	}
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":   "azure",
+			"project_name":    "testproject",
+			"environment":     "dev",
+			"name":            "test-queue",
+			"grants":          grants,
+			"provider_config": map[string]interface{}{"resource_group_name": "messaging-rg"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	roles := []string{"Azure Service Bus Data Sender", "Azure Service Bus Data Receiver", "Azure Service Bus Data Owner"}
+	for i, role := range roles {
+		address := fmt.Sprintf("module.azure_messaging[0].azurerm_role_assignment.this[%q]", principals[i]+"-"+role)
+		assignment, ok := plan.ResourcePlannedValuesMap[address]
+		require.True(t, ok, "Plan should create %s", address)
+		assert.Equal(t, role, assignment.AttributeValues["role_definition_name"], "%s should assign %s", accessLevels[i], role)
+		assert.Equal(t, principals[i], assignment.AttributeValues["principal_id"])
+	}
+	assignments := 0
+	for address := range plan.ResourcePlannedValuesMap {
+		if strings.HasPrefix(address, "module.azure_messaging[0].azurerm_role_assignment.this[") {
+			assignments++
+		}
+	}
+	assert.Equal(t, len(roles), assignments, "Each grant should be one role assignment")
+}
+
+// TestMessagingFacadeGcpGrants checks that a queue's producers are granted
+// on its topic and its consumers on its subscription, where they pull, and
+// that manage is granted without roles/pubsub.editor
+func TestMessagingFacadeGcpGrants(t *testing.T) {
+	t.Parallel()
+
+	grants := make([]map[string]interface{}, 0, len(accessLevels))
+	for _, access := range accessLevels {
+		grants = append(grants, grant("gcp", access+"@test-project.iam.gserviceaccount.com", access))
+	}
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":   "gcp",
+			"project_name":    "testproject",
+			"environment":     "dev",
+			"name":            "test-queue",
+			"grants":          grants,
+			"provider_config": map[string]interface{}{"project_id": "test-project"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	// members returns the planned members of resourceType, by the
+	// "<member>-<role>" key each is planned under
+	members := func(resourceType string) []string {
+		var keys []string
+		for address, resource := range plan.ResourcePlannedValuesMap {
+			if strings.HasPrefix(address, "module.gcp_messaging[0]."+resourceType+".this[") {
+				key := fmt.Sprintf("%s-%s", resource.AttributeValues["member"], resource.AttributeValues["role"])
+				assert.Equal(t, fmt.Sprintf("module.gcp_messaging[0].%s.this[%q]", resourceType, key), address)
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+
+	const (
+		produce = "serviceAccount:produce@test-project.iam.gserviceaccount.com"
+		consume = "serviceAccount:consume@test-project.iam.gserviceaccount.com"
+		manage  = "serviceAccount:manage@test-project.iam.gserviceaccount.com"
+	)
+	assert.ElementsMatch(t, []string{
+		produce + "-roles/pubsub.publisher",
+		manage + "-roles/pubsub.publisher",
+		manage + "-roles/pubsub.subscriber",
+		manage + "-roles/pubsub.viewer",
+	}, members("google_pubsub_topic_iam_member"))
+	assert.ElementsMatch(t, []string{
+		consume + "-roles/pubsub.subscriber",
+		manage + "-roles/pubsub.publisher",
+		manage + "-roles/pubsub.subscriber",
+		manage + "-roles/pubsub.viewer",
+	}, members("google_pubsub_subscription_iam_member"))
+}
@@ -1,6 +1,10 @@
 variable "provider_name" {
-  description = "Cloud provider (aws, azure, gcp)"
+  description = "Cloud provider (aws, azure, gcp, zero)"
   type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp", "zero"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp, zero"
+  }
 }
 
 variable "name" {
@@ -12,12 +16,173 @@ variable "type" {
   description = "Type of messaging resource (topic, queue)"
   type        = string
   default     = "queue"
+  validation {
+    condition     = contains(["queue", "topic"], var.type)
+    error_message = "type must be one of: queue, topic"
+  }
+}
+
+# ============================================================================
+# QUEUE TUNING (normalized across providers)
+# ============================================================================
+
+variable "visibility_timeout_seconds" {
+  description = "Time a received message stays hidden from other consumers (SQS visibility timeout / Service Bus lock duration / Pub/Sub ack deadline)"
+  type        = number
+  default     = 30
+  validation {
+    condition     = var.visibility_timeout_seconds >= 0 && floor(var.visibility_timeout_seconds) == var.visibility_timeout_seconds
+    error_message = "visibility_timeout_seconds must be a non-negative integer, e.g. 30"
+  }
+  validation {
+    condition     = var.type != "queue" || (var.visibility_timeout_seconds >= local.limits.visibility_min && var.visibility_timeout_seconds <= local.limits.visibility_max)
+    error_message = "visibility_timeout_seconds maps to the ${local.limits.visibility_field} on ${var.provider_name}, which must be between ${local.limits.visibility_min} and ${local.limits.visibility_max} seconds"
+  }
+}
+
+variable "message_retention_seconds" {
+  description = "How long undelivered messages are kept (SQS retention / Service Bus default TTL / Pub/Sub retention)"
+  type        = number
+  default     = 345600 # 4 days
+  validation {
+    condition     = var.message_retention_seconds > 0 && floor(var.message_retention_seconds) == var.message_retention_seconds
+    error_message = "message_retention_seconds must be a positive integer, e.g. 345600 for four days"
+  }
+  validation {
+    condition     = var.type != "queue" || (var.message_retention_seconds >= local.limits.retention_min && var.message_retention_seconds <= local.limits.retention_max)
+    error_message = "message_retention_seconds must be between ${local.limits.retention_min} and ${local.limits.retention_max} seconds on ${var.provider_name}"
+  }
+}
+
+variable "max_message_size_kb" {
+  description = "Maximum message size in KB"
+  type        = number
+  default     = 256
+  validation {
+    condition     = var.max_message_size_kb >= 1 && floor(var.max_message_size_kb) == var.max_message_size_kb
+    error_message = "max_message_size_kb must be a positive integer, e.g. 256"
+  }
+  validation {
+    condition     = var.type != "queue" || var.max_message_size_kb <= local.limits.size_max_kb
+    error_message = "max_message_size_kb exceeds the ${local.limits.size_max_kb} KB limit on ${var.provider_name}"
+  }
+}
+
+variable "delivery_delay_seconds" {
+  description = "Delay before a new message becomes visible (SQS only; must be 0 elsewhere)"
+  type        = number
+  default     = 0
+  validation {
+    condition     = var.delivery_delay_seconds >= 0 && floor(var.delivery_delay_seconds) == var.delivery_delay_seconds
+    error_message = "delivery_delay_seconds must be a non-negative integer, e.g. 0 or 30"
+  }
+  validation {
+    condition     = var.type != "queue" || var.delivery_delay_seconds <= local.limits.delay_max
+    error_message = "delivery_delay_seconds must be at most ${local.limits.delay_max} on ${var.provider_name}"
+  }
+}
+
+# ============================================================================
+# DEAD LETTERS AND ALARMS
+# ============================================================================
+
+variable "dead_letter_queue" {
+  description = "Move messages that fail max_receive_count deliveries to a <name>-dlq queue (SQS queue / Service Bus dead-letter subqueue / Pub/Sub dead-letter topic and subscription); queues only"
+  type        = bool
+  default     = false
+  validation {
+    condition     = !var.dead_letter_queue || var.type == "queue"
+    error_message = "dead_letter_queue is only available for type = \"queue\""
+  }
+}
+
+variable "max_receive_count" {
+  description = "Deliveries before a message is dead-lettered when dead_letter_queue is set"
+  type        = number
+  default     = 5
+  validation {
+    # Pub/Sub accepts 5-100 delivery attempts; the others accept this range too
+    condition     = var.max_receive_count >= 5 && var.max_receive_count <= 100 && floor(var.max_receive_count) == var.max_receive_count
+    error_message = "max_receive_count must be a whole number between 5 and 100"
+  }
+}
+
+variable "enable_default_alarms" {
+  description = "Create a monitoring facade alarm that fires as soon as the dead-letter queue holds a message"
+  type        = bool
+  default     = false
+  validation {
+    condition     = !var.enable_default_alarms || var.dead_letter_queue
+    error_message = "enable_default_alarms alarms on the dead-letter queue; set dead_letter_queue = true"
+  }
+  validation {
+    condition     = !var.enable_default_alarms || var.provider_name != "zero"
+    error_message = "enable_default_alarms is not available on zero, which has no monitoring service"
+  }
+}
+
+variable "notification_ref" {
+  description = "Channel the default alarms notify ({provider, channel_id}): an SNS topic ARN on aws, a Monitor action group ID on azure, a Cloud Monitoring notification channel ID on gcp"
+  type = object({
+    provider   = string
+    channel_id = string
+  })
+  default = null
+}
+
+# ============================================================================
+# ACCESS
+# ============================================================================
+
+variable "grants" {
+  description = "Principals granted access to the queue or topic, each a principal_ref (the iam facade's principal_ref output) with access produce (send or publish), consume (receive from a queue or subscribe to a topic) or manage (both, plus purging and changing attributes). AWS renders one queue or topic policy statement per grant; Azure assigns the Service Bus Data Sender, Receiver or Owner role; GCP grants the Pub/Sub publisher or subscriber role, or for manage both plus viewer, on the topic or subscription."
+  type = list(object({
+    principal_ref = object({
+      provider = string
+      id       = string
+    })
+    access = string
+  }))
+  default = []
+  validation {
+    condition     = alltrue([for g in var.grants : contains(["produce", "consume", "manage"], g.access)])
+    error_message = "Each grants access must be one of: produce, consume, manage"
+  }
+  validation {
+    condition     = alltrue([for g in var.grants : g.principal_ref.provider == var.provider_name])
+    error_message = "Each grants principal_ref must belong to ${var.provider_name}, e.g. the principal_ref output of an iam facade with the same provider_name"
+  }
+  validation {
+    condition     = length(var.grants) == 0 || var.provider_name != "zero"
+    error_message = "grants is not available on zero; ZeroQueue has no resource policies"
+  }
+}
+
+variable "service_grants" {
+  description = "AWS services allowed to send to the queue or publish to the topic, each for requests from one source_arn only (e.g. { service = \"s3.amazonaws.com\", source_arn = <bucket ARN> } for the storage facade's notifications). Rendered in the same queue or topic policy as grants; AWS only."
+  type = list(object({
+    service    = string
+    source_arn = string
+  }))
+  default = []
+  validation {
+    condition     = alltrue([for s in var.service_grants : endswith(s.service, ".amazonaws.com") && startswith(s.source_arn, "arn:")])
+    error_message = "Each service_grants entry needs a service principal such as s3.amazonaws.com and a source_arn, e.g. the bucket's ARN"
+  }
+  validation {
+    condition     = length(var.service_grants) == 0 || var.provider_name == "aws"
+    error_message = "service_grants is only available on aws; Azure and GCP deliver through their own subscriptions"
+  }
 }
 
 variable "environment" {
   description = "Environment name"
   type        = string
   default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
@@ -25,8 +190,31 @@ variable "project_name" {
   type        = string
 }
 
+variable "provider_config" {
+  description = <<-EOT
+    Provider-specific configuration:
+      - Azure: resource_group_name, location, namespace_name, sku (Standard or Premium)
+      - GCP: project_id
+  EOT
+  type        = map(string)
+  default     = {}
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
   default     = {}
 }
+
+variable "kms_key_ref" {
+  description = "Customer-managed key from the encryption facade (its kms_key_ref output); must belong to the same provider"
+  type = object({
+    provider = string
+    id       = string
+  })
+  default = null
+  validation {
+    condition     = var.kms_key_ref == null || try(contains(["aws", "azure", "gcp"], var.kms_key_ref.provider) && length(var.kms_key_ref.id) > 0, false)
+    error_message = "kms_key_ref must have provider aws, azure or gcp and a non-empty id, e.g. the encryption facade's kms_key_ref output"
+  }
+}
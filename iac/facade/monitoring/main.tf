@@ -2,22 +2,145 @@
 # Unified interface for Monitoring resources across providers
 
 terraform {
-  required_version = ">= 1.0"
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
+
+  # Declared so callers can pass an aliased configuration with the
+  # providers argument, including the messaging and lambda facades that
+  # instantiate this module for their default alarms
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
 }
 
-locals {
-  common_tags = merge(
-    var.tags,
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy    = "Terraform"
-      Environment  = var.environment
-      Provider     = var.provider_name
-      Project      = var.project_name
-      Module       = "Monitoring-Facade"
-    }
+      Provider = var.provider_name
+      Module   = "Monitoring-Facade"
+    },
+    var.tags
   )
 }
 
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  # Where each facade's metrics live: the CloudWatch namespace and
+  # dimension, the Azure Monitor namespace, and the GCP resource type and
+  # the label resource_id is matched against
+  resource_types = {
+    database = {
+      aws_namespace   = "AWS/RDS"
+      aws_dimension   = "DBInstanceIdentifier"
+      azure_namespace = "Microsoft.Sql/servers/databases"
+      gcp_resource    = "cloudsql_database"
+      # database_id is <project>:<instance>
+      gcp_filter = "resource.labels.database_id = ends_with(\":%s\")"
+    }
+    messaging = {
+      aws_namespace   = "AWS/SQS"
+      aws_dimension   = "QueueName"
+      azure_namespace = "Microsoft.ServiceBus/namespaces"
+      gcp_resource    = "pubsub_subscription"
+      gcp_filter      = "resource.labels.subscription_id = \"%s\""
+    }
+    lambda = {
+      aws_namespace   = "AWS/Lambda"
+      aws_dimension   = "FunctionName"
+      azure_namespace = "Microsoft.Web/sites"
+      gcp_resource    = "cloud_function"
+      gcp_filter      = "resource.labels.function_name = \"%s\""
+    }
+    # engine_type nosql of the database facade: a DynamoDB table, a Cosmos
+    # DB account, a Firestore database
+    nosql = {
+      aws_namespace   = "AWS/DynamoDB"
+      aws_dimension   = "TableName"
+      azure_namespace = "Microsoft.DocumentDB/databaseAccounts"
+      gcp_resource    = "firestore_instance"
+      gcp_filter      = "resource.labels.database_id = \"%s\""
+    }
+  }
+
+  # Metric, statistic (in CloudWatch terms) and comparison per preset and
+  # provider. Storage is free bytes on AWS but percent used elsewhere, so
+  # free_storage alarms below the threshold on AWS only.
+  presets = {
+    cpu = {
+      aws   = { metric = "CPUUtilization", statistic = "Average", comparison = "GreaterThanThreshold" }
+      azure = { metric = "cpu_percent", statistic = "Average", comparison = "GreaterThanThreshold" }
+      gcp   = { metric = "cloudsql.googleapis.com/database/cpu/utilization", statistic = "Average", comparison = "GreaterThanThreshold" }
+    }
+    connections = {
+      aws   = { metric = "DatabaseConnections", statistic = "Average", comparison = "GreaterThanThreshold" }
+      azure = { metric = "sessions_count", statistic = "Average", comparison = "GreaterThanThreshold" }
+      gcp   = { metric = "cloudsql.googleapis.com/database/network/connections", statistic = "Average", comparison = "GreaterThanThreshold" }
+    }
+    free_storage = {
+      aws   = { metric = "FreeStorageSpace", statistic = "Average", comparison = "LessThanThreshold" }
+      azure = { metric = "storage_percent", statistic = "Maximum", comparison = "GreaterThanThreshold" }
+      gcp   = { metric = "cloudsql.googleapis.com/database/disk/utilization", statistic = "Maximum", comparison = "GreaterThanThreshold" }
+    }
+    queue_depth = {
+      aws   = { metric = "ApproximateNumberOfMessagesVisible", statistic = "Maximum", comparison = "GreaterThanThreshold" }
+      azure = { metric = "ActiveMessages", statistic = "Maximum", comparison = "GreaterThanThreshold" }
+      gcp   = { metric = "pubsub.googleapis.com/subscription/num_undelivered_messages", statistic = "Maximum", comparison = "GreaterThanThreshold" }
+    }
+    # The dead-letter queue on AWS and the dead-letter subscription on GCP
+    # are queues of their own; Service Bus keeps dead letters in a subqueue
+    # with a metric of its own
+    dead_letter_depth = {
+      aws   = { metric = "ApproximateNumberOfMessagesVisible", statistic = "Maximum", comparison = "GreaterThanThreshold" }
+      azure = { metric = "DeadletteredMessages", statistic = "Maximum", comparison = "GreaterThanThreshold" }
+      gcp   = { metric = "pubsub.googleapis.com/subscription/num_undelivered_messages", statistic = "Maximum", comparison = "GreaterThanThreshold" }
+    }
+    lambda_errors = {
+      aws   = { metric = "Errors", statistic = "Sum", comparison = "GreaterThanThreshold" }
+      azure = { metric = "Http5xx", statistic = "Sum", comparison = "GreaterThanThreshold" }
+      gcp   = { metric = "cloudfunctions.googleapis.com/function/execution_count", statistic = "Sum", comparison = "GreaterThanThreshold" }
+    }
+    lambda_throttles = {
+      aws = { metric = "Throttles", statistic = "Sum", comparison = "GreaterThanThreshold" }
+    }
+    # Requests rejected for exceeding the provisioned capacity: throttled
+    # writes on DynamoDB, requests answered 429 on Cosmos DB (see
+    # preset_dimensions). Firestore does not throttle by capacity.
+    nosql_throttles = {
+      aws   = { metric = "WriteThrottleEvents", statistic = "Sum", comparison = "GreaterThanThreshold" }
+      azure = { metric = "TotalRequests", statistic = "Sum", comparison = "GreaterThanThreshold" }
+    }
+  }
+
+  # Dimensions a preset's Azure metric is narrowed to
+  preset_dimensions = {
+    nosql_throttles = { StatusCode = "429" }
+  }
+
+  resource_type = var.monitored_resource != null ? local.resource_types[var.monitored_resource.facade_type] : null
+  preset        = var.preset != null ? local.presets[var.preset][var.provider_name] : null
+
+  metric_name         = local.preset != null ? local.preset.metric : var.metric_name
+  statistic           = local.preset != null ? local.preset.statistic : null
+  comparison_operator = coalesce(var.comparison_operator, try(local.preset.comparison, null), "GreaterThanThreshold")
+
+  # A failed function execution is one whose status label is not ok
+  gcp_status_filter = var.preset == "lambda_errors" ? " AND metric.labels.status != \"ok\"" : ""
+
+  # Checked by the alarm_id precondition, so a channel from another
+  # provider is never attached
+  channel_id = try(var.notification_ref.provider == var.provider_name, false) ? var.notification_ref.channel_id : null
+}
+
 # AWS: CloudWatch
 module "aws_monitoring" {
   count  = var.provider_name == "aws" ? 1 : 0
@@ -25,15 +148,17 @@ module "aws_monitoring" {
   
   create_alarm        = true
   alarm_name          = var.alarm_name
-  metric_name         = var.metric_name
+  metric_name         = local.metric_name
   threshold           = var.threshold
-  comparison_operator = var.comparison_operator
+  comparison_operator = local.comparison_operator
   evaluation_periods  = var.evaluation_periods
   period              = var.period
-  namespace           = lookup(var.provider_config, "namespace", "AWS/EC2")
-  statistic           = lookup(var.provider_config, "statistic", "Average")
+  namespace           = local.resource_type != null ? local.resource_type.aws_namespace : lookup(var.provider_config, "namespace", "AWS/EC2")
+  statistic           = coalesce(local.statistic, lookup(var.provider_config, "statistic", "Average"))
+  dimensions          = local.resource_type != null ? { (local.resource_type.aws_dimension) = var.monitored_resource.resource_id } : {}
+  alarm_actions       = local.channel_id != null ? [local.channel_id] : []
   
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # Azure: Azure Monitor
@@ -41,16 +166,22 @@ module "azure_monitoring" {
   count  = var.provider_name == "azure" ? 1 : 0
   source = "../../azure/core/monitoring"
   
-  alarm_name          = var.alarm_name
+  create_alert        = true
+  alert_name          = var.alarm_name
   resource_group_name = lookup(var.provider_config, "resource_group_name", "monitoring-rg")
-  scopes              = lookup(var.provider_config, "scopes", [])
-  metric_name         = var.metric_name
-  metric_namespace    = lookup(var.provider_config, "metric_namespace", "Microsoft.Compute/virtualMachines")
-  aggregation         = lookup(var.provider_config, "aggregation", "Average")
-  operator            = var.comparison_operator == "GreaterThanThreshold" ? "GreaterThan" : "LessThan"
+  scopes              = var.monitored_resource != null ? [var.monitored_resource.resource_id] : lookup(var.provider_config, "scopes", [])
+  metric_name         = local.metric_name
+  metric_namespace    = local.resource_type != null ? local.resource_type.azure_namespace : lookup(var.provider_config, "metric_namespace", "Microsoft.Compute/virtualMachines")
+  aggregation         = local.statistic != null ? replace(local.statistic, "Sum", "Total") : lookup(var.provider_config, "aggregation", "Average")
+  operator            = local.comparison_operator == "GreaterThanThreshold" ? "GreaterThan" : "LessThan"
   threshold           = var.threshold
+  dimensions = merge(
+    try(var.monitored_resource.entity_name, null) != null ? { EntityName = var.monitored_resource.entity_name } : {},
+    lookup(local.preset_dimensions, coalesce(var.preset, "none"), {}),
+  )
+  action_group_id     = local.channel_id
   
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # GCP: Cloud Monitoring
@@ -63,9 +194,17 @@ module "gcp_monitoring" {
   # project_id        = lookup(var.provider_config, "project_id", null) # Not in core variables
   
   # GCP uses MQL or filter strings, this is simplified for the facade
-  filter          = "metric.type=\"compute.googleapis.com/instance/cpu/utilization\" AND resource.type=\"gce_instance\""
-  threshold_value = var.threshold
-  comparison      = var.comparison_operator == "GreaterThanThreshold" ? "COMPARISON_GT" : "COMPARISON_LT"
+  filter = (
+    local.resource_type != null ?
+    "metric.type=\"${local.metric_name}\" AND resource.type=\"${local.resource_type.gcp_resource}\" AND ${format(local.resource_type.gcp_filter, var.monitored_resource.resource_id)}${local.gcp_status_filter}" :
+    "metric.type=\"compute.googleapis.com/instance/cpu/utilization\" AND resource.type=\"gce_instance\""
+  )
+  per_series_aligner    = local.statistic != null ? lookup({ Average = "ALIGN_MEAN", Maximum = "ALIGN_MAX", Sum = "ALIGN_SUM" }, local.statistic) : "ALIGN_MEAN"
+  threshold_value       = var.threshold
+  comparison            = local.comparison_operator == "GreaterThanThreshold" ? "COMPARISON_GT" : "COMPARISON_LT"
+  notification_channels = local.channel_id != null ? [local.channel_id] : []
+  
+  labels = local.default_labels
 }
 
 output "alarm_id" {
@@ -75,4 +214,9 @@ output "alarm_id" {
     var.provider_name == "gcp" ? (length(module.gcp_monitoring) > 0 ? module.gcp_monitoring[0].alert_policy_id : null) :
     null
   )
+
+  precondition {
+    condition     = var.notification_ref == null || try(var.notification_ref.provider == var.provider_name, false)
+    error_message = "notification_ref belongs to ${try(var.notification_ref.provider, "another provider")} but this module deploys to ${var.provider_name}"
+  }
 }
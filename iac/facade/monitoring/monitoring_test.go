@@ -1,8 +1,14 @@
 package monitoring_test
 
 import (
-	"testing"
+	"path/filepath"
 	"strings"
+	"testing"
+
+	"iac/testutil/password"
+	"iac/testutil/planerr"
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
@@ -11,21 +17,21 @@ import (
 func TestMonitoringFacadeAws(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":     "aws",
-			"project_name": "testproject",
-			"environment":  "test",
-			"alarm_name":   "cpu-high",
-			"metric_name":  "CPUUtilization",
-			"threshold":    80,
+			"provider_name": "aws",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"alarm_name":    "cpu-high",
+			"metric_name":   "CPUUtilization",
+			"threshold":     80,
 		},
 		BackendConfig: map[string]interface{}{},
 	})
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
-	
+
 	assert.True(t, strings.Contains(planString, "module.aws_monitoring[0].aws_cloudwatch_metric_alarm.this"), "Plan should create an AWS CloudWatch alarm")
 	assert.True(t, strings.Contains(planString, "threshold = 80"), "Plan should have the correct threshold")
 }
@@ -33,15 +39,15 @@ func TestMonitoringFacadeAws(t *testing.T) {
 func TestMonitoringFacadeAzure(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":     "azure",
-			"project_name": "testproject",
-			"environment":  "test",
-			"alarm_name":   "cpu-high",
-			"metric_name":  "Percentage CPU",
-			"threshold":    75,
+			"provider_name": "azure",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"alarm_name":    "cpu-high",
+			"metric_name":   "Percentage CPU",
+			"threshold":     75,
 			"provider_config": map[string]interface{}{
 				"resource_group_name": "test-rg",
 				"scopes":              []string{"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/test-rg"},
@@ -58,15 +64,15 @@ func TestMonitoringFacadeAzure(t *testing.T) {
 func TestMonitoringFacadeGcp(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":     "gcp",
-			"project_name": "testproject",
-			"environment":  "test",
-			"alarm_name":   "cpu-critical",
-			"metric_name":  "cpu/utilization",
-			"threshold":    0.9,
+			"provider_name": "gcp",
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"alarm_name":    "cpu-critical",
+			"metric_name":   "cpu/utilization",
+			"threshold":     0.9,
 			"provider_config": map[string]interface{}{
 				"project_id": "test-project",
 			},
@@ -79,25 +85,249 @@ func TestMonitoringFacadeGcp(t *testing.T) {
 	assert.True(t, strings.Contains(planString, "threshold_value = 0.9"), "Plan should have the correct threshold value")
 }
 
-func TestMonitoringFacadeInvalidThreshold(t *testing.T) {
+func TestFacadeValidationMatrix(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := &terraform.Options{
-		TerraformDir: ".",
-		Vars: map[string]interface{}{
-			"provider":     "aws",
-			"project_name": "testproject",
-			"environment":  "test",
-			"alarm_name":   "cpu-high",
-			"metric_name":  "CPUUtilization",
-			"threshold":    -1, // Invalid threshold
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"alarm_name":    "cpu-high",
+		"metric_name":   "CPUUtilization",
+		"threshold":     80,
+	}
+	database := map[string]interface{}{"facade_type": "database", "resource_id": "orders-db"}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "NegativeThreshold",
+			Vars: map[string]interface{}{"threshold": -1},
+			Want: "Threshold must not be negative",
+		},
+		{
+			Name: "UnknownPreset",
+			Vars: map[string]interface{}{"preset": "memory", "monitored_resource": database},
+			Want: "preset must be one of: cpu, connections, free_storage, nosql_throttles, queue_depth, dead_letter_depth, lambda_errors, lambda_throttles",
+		},
+		{
+			Name: "PresetForOtherFacade",
+			Vars: map[string]interface{}{"preset": "queue_depth", "monitored_resource": database},
+			Want: "preset queue_depth does not apply to database resources",
+		},
+		{
+			Name: "PresetWithoutResource",
+			Vars: map[string]interface{}{"preset": "cpu"},
+			Want: "preset needs monitored_resource to alarm on",
+		},
+		{
+			Name: "UnknownFacadeType",
+			Vars: map[string]interface{}{"monitored_resource": map[string]interface{}{"facade_type": "storage", "resource_id": "bucket"}},
+			Want: "monitored_resource.facade_type must be one of: database, nosql, messaging, lambda",
+		},
+		{
+			Name: "ThrottlesOffAws",
+			Vars: map[string]interface{}{
+				"provider_name":      "gcp",
+				"preset":             "lambda_throttles",
+				"monitored_resource": map[string]interface{}{"facade_type": "lambda", "resource_id": "resize-images"},
+			},
+			Want: "preset lambda_throttles is only available on aws",
+		},
+		{
+			Name: "NosqlThrottlesOnGcp",
+			Vars: map[string]interface{}{
+				"provider_name":      "gcp",
+				"preset":             "nosql_throttles",
+				"monitored_resource": map[string]interface{}{"facade_type": "nosql", "resource_id": "orders"},
+			},
+			Want: "preset nosql_throttles is only available on aws and azure",
+		},
+		{
+			Name: "NotificationFromOtherProvider",
+			Vars: map[string]interface{}{"notification_ref": map[string]interface{}{"provider": "gcp", "channel_id": "projects/p/notificationChannels/1"}},
+			Want: "notification_ref belongs to gcp but this module deploys to aws",
+		},
+	})
+}
+
+func TestMonitoringFacadePresets(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		vars  map[string]interface{}
+		match []string
+	}{
+		"aws free storage": {
+			vars: map[string]interface{}{
+				"provider_name": "aws",
+				"preset":        "free_storage",
+				"monitored_resource": map[string]interface{}{
+					"facade_type": "database",
+					"resource_id": "orders-db",
+				},
+			},
+			match: []string{
+				`namespace\s+= "AWS/RDS"`,
+				`metric_name\s+= "FreeStorageSpace"`,
+				`comparison_operator\s+= "LessThanThreshold"`,
+				`"DBInstanceIdentifier" = "orders-db"`,
+			},
+		},
+		"azure queue depth": {
+			vars: map[string]interface{}{
+				"provider_name":   "azure",
+				"preset":          "queue_depth",
+				"provider_config": map[string]interface{}{"resource_group_name": "test-rg"},
+				"monitored_resource": map[string]interface{}{
+					"facade_type": "messaging",
+					"resource_id": "/subscriptions/sub/resourceGroups/test-rg/providers/Microsoft.ServiceBus/namespaces/orders",
+				},
+			},
+			match: []string{
+				`metric_namespace\s+= "Microsoft.ServiceBus/namespaces"`,
+				`metric_name\s+= "ActiveMessages"`,
+				`"/subscriptions/sub/resourceGroups/test-rg/providers/Microsoft.ServiceBus/namespaces/orders"`,
+			},
+		},
+		"azure dead letters": {
+			vars: map[string]interface{}{
+				"provider_name":   "azure",
+				"preset":          "dead_letter_depth",
+				"provider_config": map[string]interface{}{"resource_group_name": "test-rg"},
+				"monitored_resource": map[string]interface{}{
+					"facade_type": "messaging",
+					"resource_id": "/subscriptions/sub/resourceGroups/test-rg/providers/Microsoft.ServiceBus/namespaces/orders",
+					"entity_name": "orders",
+				},
+			},
+			match: []string{
+				`metric_name\s+= "DeadletteredMessages"`,
+				`name\s+= "EntityName"`,
+				`"orders"`,
+			},
+		},
+		"aws nosql throttles": {
+			vars: map[string]interface{}{
+				"provider_name": "aws",
+				"preset":        "nosql_throttles",
+				"monitored_resource": map[string]interface{}{
+					"facade_type": "nosql",
+					"resource_id": "orders",
+				},
+			},
+			match: []string{
+				`namespace\s+= "AWS/DynamoDB"`,
+				`metric_name\s+= "WriteThrottleEvents"`,
+				`"TableName" = "orders"`,
+			},
+		},
+		"azure nosql throttles": {
+			vars: map[string]interface{}{
+				"provider_name":   "azure",
+				"preset":          "nosql_throttles",
+				"provider_config": map[string]interface{}{"resource_group_name": "test-rg"},
+				"monitored_resource": map[string]interface{}{
+					"facade_type": "nosql",
+					"resource_id": "/subscriptions/sub/resourceGroups/test-rg/providers/Microsoft.DocumentDB/databaseAccounts/orders",
+				},
+			},
+			match: []string{
+				`metric_namespace\s+= "Microsoft.DocumentDB/databaseAccounts"`,
+				`metric_name\s+= "TotalRequests"`,
+				`name\s+= "StatusCode"`,
+				`"429"`,
+			},
+		},
+		"gcp lambda errors": {
+			vars: map[string]interface{}{
+				"provider_name":   "gcp",
+				"preset":          "lambda_errors",
+				"provider_config": map[string]interface{}{"project_id": "test-project"},
+				"monitored_resource": map[string]interface{}{
+					"facade_type": "lambda",
+					"resource_id": "resize-images",
+				},
+			},
+			match: []string{
+				`resource\.type=\\"cloud_function\\"`,
+				`resource\.labels\.function_name = \\"resize-images\\"`,
+				`metric\.labels\.status != \\"ok\\"`,
+				`per_series_aligner\s+= "ALIGN_SUM"`,
+			},
 		},
 	}
 
-	// This is just a placeholder example, actual behavior depends on variables.tf validations
-	_, err := terraform.InitAndPlanE(t, terraformOptions)
-	// If there's a validation rule in variables.tf, this will be Error
-	if err != nil {
-		assert.Error(t, err)
+	for name, tc := range tests {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			vars := map[string]interface{}{
+				"project_name": "testproject",
+				"environment":  "dev",
+				"alarm_name":   "preset-alarm",
+				"threshold":    10,
+			}
+			for k, v := range tc.vars {
+				vars[k] = v
+			}
+
+			planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars:         vars,
+				NoColor:      true,
+			}))
+
+			for _, pattern := range tc.match {
+				assert.Regexp(t, pattern, planString)
+			}
+		})
+	}
+}
+
+func TestMonitoringFacadeComposition(t *testing.T) {
+	t.Parallel()
+
+	masterPassword := password.New(t, "aws")
+	terraformOptions := tflog.WithSensitive(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: "testdata/composition",
+		Vars:         map[string]interface{}{"master_password": masterPassword},
+		NoColor:      true,
+	}), masterPassword)
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Contains(t, planString, "module.db.module.aws_database[0].aws_db_instance.this", "Plan should create the database")
+	assert.Contains(t, planString, "module.db_cpu.module.aws_monitoring[0].aws_cloudwatch_metric_alarm.this[0]", "Plan should create the alarm")
+	assert.Regexp(t, `namespace\s+= "AWS/RDS"`, planString)
+	assert.Regexp(t, `metric_name\s+= "CPUUtilization"`, planString)
+	assert.Regexp(t, `"DBInstanceIdentifier" = "composition-db"`, planString,
+		"The alarm should take its dimension from the database facade's monitored_resource")
+}
+
+// TestMonitoringFacadeProviderAlias plans the messaging and lambda facades'
+// default alarms with only an aliased aws provider. The environment has no
+// region, so a nested module falling back to a default provider would fail.
+func TestMonitoringFacadeProviderAlias(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: "testdata/provider_alias",
+		EnvVars: map[string]string{
+			"AWS_REGION":         "",
+			"AWS_DEFAULT_REGION": "",
+			"AWS_PROFILE":        "",
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	for _, address := range []string{
+		"module.orders.module.default_alarms[0].module.aws_monitoring[0].aws_cloudwatch_metric_alarm.this[0]",
+		`module.worker.module.default_alarms["errors"].module.aws_monitoring[0].aws_cloudwatch_metric_alarm.this[0]`,
+		`module.worker.module.default_alarms["throttles"].module.aws_monitoring[0].aws_cloudwatch_metric_alarm.this[0]`,
+	} {
+		assert.Contains(t, plan.ResourcePlannedValuesMap, address, "Plan should create the default alarms through the alias")
 	}
 }
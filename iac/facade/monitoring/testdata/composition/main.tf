@@ -0,0 +1,55 @@
+# Monitoring composition fixture
+#
+# A database from the database facade and a CPU alarm from the monitoring
+# facade on it, wired through the database's monitored_resource output.
+# Planned only, with placeholder credentials.
+
+terraform {
+  required_version = ">= 1.9"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+variable "master_password" {
+  description = "Master password for the database"
+  type        = string
+  sensitive   = true
+}
+
+module "db" {
+  source = "../../../database"
+
+  provider_name   = "aws"
+  project_name    = "composition"
+  environment     = "dev"
+  identifier      = "composition-db"
+  master_password = var.master_password
+}
+
+module "db_cpu" {
+  source = "../../"
+
+  provider_name      = "aws"
+  project_name       = "composition"
+  environment        = "dev"
+  alarm_name         = "composition-db-cpu"
+  monitored_resource = module.db.monitored_resource
+  preset             = "cpu"
+  threshold          = 80
+}
@@ -0,0 +1,60 @@
+# Provider alias fixture
+#
+# The messaging and lambda facades with default alarms, given only an
+# aliased aws provider. The monitoring facade they instantiate internally
+# must inherit the alias. Planned only, with placeholder credentials.
+
+terraform {
+  required_version = ">= 1.9"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "secondary"
+  region = "eu-west-1"
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+module "orders" {
+  source = "../../../messaging"
+
+  providers = {
+    aws = aws.secondary
+  }
+
+  provider_name         = "aws"
+  project_name          = "alias"
+  environment           = "dev"
+  name                  = "orders"
+  dead_letter_queue     = true
+  enable_default_alarms = true
+}
+
+module "worker" {
+  source = "../../../lambda"
+
+  providers = {
+    aws = aws.secondary
+  }
+
+  provider_name         = "aws"
+  project_name          = "alias"
+  environment           = "dev"
+  function_name         = "alias-worker"
+  handler               = "index.handler"
+  runtime               = "python3.12"
+  source_code           = "def handler(event, context):\n    return None\n"
+  enable_default_alarms = true
+}
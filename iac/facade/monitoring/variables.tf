@@ -1,6 +1,10 @@
 variable "provider_name" {
   description = "Cloud provider (aws, azure, gcp)"
   type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
 }
 
 variable "project_name" {
@@ -12,6 +16,10 @@ variable "environment" {
   description = "Environment name"
   type        = string
   default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "alarm_name" {
@@ -20,19 +28,76 @@ variable "alarm_name" {
 }
 
 variable "metric_name" {
-  description = "Name of the metric to monitor"
+  description = "Name of the metric to monitor; required unless preset is set"
   type        = string
+  default     = null
+  validation {
+    condition     = var.metric_name != null || var.preset != null
+    error_message = "Set metric_name or preset"
+  }
+}
+
+variable "monitored_resource" {
+  description = "Resource to alarm on, e.g. the database facade's monitored_resource output. facade_type (database, nosql, messaging, lambda) picks the namespace and dimensions; resource_id is the DB instance identifier, table, queue or function name on AWS, the resource ID on Azure, and the instance, Firestore database, subscription or function name on GCP. entity_name narrows a Service Bus namespace to one queue on Azure."
+  type = object({
+    facade_type = string
+    resource_id = string
+    entity_name = optional(string)
+  })
+  default = null
+  validation {
+    condition     = var.monitored_resource == null || try(contains(["database", "nosql", "messaging", "lambda"], var.monitored_resource.facade_type), false)
+    error_message = "monitored_resource.facade_type must be one of: database, nosql, messaging, lambda"
+  }
+}
+
+variable "preset" {
+  description = "Canned metric for monitored_resource, in place of metric_name: cpu, connections, free_storage (database), nosql_throttles (nosql), queue_depth, dead_letter_depth (messaging), lambda_errors, lambda_throttles (lambda)"
+  type        = string
+  default     = null
+  validation {
+    condition     = var.preset == null || contains(["cpu", "connections", "free_storage", "nosql_throttles", "queue_depth", "dead_letter_depth", "lambda_errors", "lambda_throttles"], var.preset)
+    error_message = "preset must be one of: cpu, connections, free_storage, nosql_throttles, queue_depth, dead_letter_depth, lambda_errors, lambda_throttles"
+  }
+  validation {
+    condition     = var.preset == null || var.monitored_resource != null
+    error_message = "preset needs monitored_resource to alarm on"
+  }
+  validation {
+    condition = var.preset == null || var.monitored_resource == null || try(
+      lookup({
+        cpu = "database", connections = "database", free_storage = "database",
+        nosql_throttles = "nosql",
+        queue_depth = "messaging", dead_letter_depth = "messaging",
+        lambda_errors = "lambda", lambda_throttles = "lambda",
+      }, var.preset, "") == var.monitored_resource.facade_type,
+      false
+    )
+    error_message = "preset ${coalesce(var.preset, "null")} does not apply to ${try(var.monitored_resource.facade_type, "this")} resources"
+  }
+  validation {
+    condition     = var.preset != "lambda_throttles" || var.provider_name == "aws"
+    error_message = "preset lambda_throttles is only available on aws; Azure Functions and Cloud Functions have no throttling metric"
+  }
+  validation {
+    condition     = var.preset != "nosql_throttles" || contains(["aws", "azure"], var.provider_name)
+    error_message = "preset nosql_throttles is only available on aws and azure; Firestore does not throttle by capacity"
+  }
 }
 
 variable "threshold" {
   description = "Threshold for the alarm"
   type        = number
+  validation {
+    condition     = var.threshold >= 0
+    error_message = "Threshold must not be negative, e.g. 0 or 80"
+  }
 }
 
 variable "comparison_operator" {
-  description = "Comparison operator for the alarm"
+  description = "Comparison operator for the alarm; defaults to the preset's, else GreaterThanThreshold"
   type        = string
-  default     = "GreaterThanThreshold"
+  default     = null
 }
 
 variable "evaluation_periods" {
@@ -47,6 +112,15 @@ variable "period" {
   default     = 300
 }
 
+variable "notification_ref" {
+  description = "Channel notified when the alarm fires ({provider, channel_id}): an SNS topic ARN on aws, a Monitor action group ID on azure, a Cloud Monitoring notification channel ID on gcp"
+  type = object({
+    provider   = string
+    channel_id = string
+  })
+  default = null
+}
+
 variable "tags" {
   description = "Resource tags"
   type        = map(string)
@@ -2,24 +2,73 @@
 # Unified interface for Network resources across providers
 
 terraform {
-  required_version = ">= 1.0"
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
 }
 
 # ============================================================================
 # IMPORT COMMON LAYER
 # ============================================================================
 
-locals {
-  common_tags = merge(
-    var.tags,
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy    = "Terraform"
-      Environment  = var.environment
-      Provider     = var.provider_name
-      Project      = var.project_name
-      Module       = "Networking-Facade"
-    }
+      Provider = var.provider_name
+      Module   = "Networking-Facade"
+    },
+    var.tags
+  )
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  # network_config, or the deprecated metrics it replaces
+  network_config = var.network_config != null ? var.network_config : var.metrics
+
+  # enable_private_endpoints with the AWS aliases resolved
+  private_services = distinct([for s in var.enable_private_endpoints : lookup({ s3 = "storage", dynamodb = "nosql" }, s, s)])
+
+  # S3 and DynamoDB have free gateway endpoints; the rest are interfaces
+  aws_endpoints = {
+    storage = { service = "s3", type = "Gateway" }
+    nosql   = { service = "dynamodb", type = "Gateway" }
+    queue   = { service = "sqs", type = "Interface" }
+    secrets = { service = "secretsmanager", type = "Interface" }
+    kms     = { service = "kms", type = "Interface" }
+  }
+
+  # Which /64 of the network's /56 each subnet gets: public subnets first,
+  # then private, unless network_config picks them
+  public_ipv6_netnums = (
+    length(local.network_config.public_ipv6_netnums) > 0 ? local.network_config.public_ipv6_netnums : range(length(local.network_config.public_subnets))
+  )
+  private_ipv6_netnums = (
+    length(local.network_config.private_ipv6_netnums) > 0 ? local.network_config.private_ipv6_netnums :
+    [for i in range(length(local.network_config.private_subnets)) : length(local.network_config.public_subnets) + i]
   )
+
+  # Azure has no provided IPv6 range, so default to a unique local /56
+  # (RFC 4193) whose global ID is hashed from the project and network names
+  azure_ipv6_hash = md5("${var.project_name}/${var.network_name}")
+  azure_ipv6_cidr = coalesce(
+    local.network_config.ipv6_cidr,
+    "fd${substr(local.azure_ipv6_hash, 0, 2)}:${substr(local.azure_ipv6_hash, 2, 4)}:${substr(local.azure_ipv6_hash, 6, 4)}::/56"
+  )
+
+  azure_private_links = {
+    storage = { dns_zone = "privatelink.blob.core.windows.net", subresource = "blob" }
+    nosql   = { dns_zone = "privatelink.documents.azure.com", subresource = "Sql" }
+    queue   = { dns_zone = "privatelink.servicebus.windows.net", subresource = "namespace" }
+    secrets = { dns_zone = "privatelink.vaultcore.azure.net", subresource = "vault" }
+    kms     = { dns_zone = "privatelink.vaultcore.azure.net", subresource = "vault" }
+  }
 }
 
 # ============================================================================
@@ -32,16 +81,27 @@ module "aws_networking" {
   source = "../../aws/core/networking"
   
   vpc_name            = var.network_name
-  vpc_cidr            = var.metrics.cidr
-  availability_zones  = var.metrics.azs
+  vpc_cidr            = local.network_config.cidr
+  availability_zones  = local.network_config.azs
   
-  public_subnet_cidrs  = var.metrics.public_subnets
-  private_subnet_cidrs = var.metrics.private_subnets
+  public_subnet_cidrs  = local.network_config.public_subnets
+  private_subnet_cidrs = local.network_config.private_subnets
+  
+  enable_ipv6          = var.enable_ipv6
+  public_ipv6_netnums  = local.public_ipv6_netnums
+  private_ipv6_netnums = local.private_ipv6_netnums
+  
+  enable_dns_support   = local.network_config.enable_dns
+  enable_dns_hostnames = local.network_config.enable_dns
   
   create_internet_gateway = var.internet_access
   create_default_security_group = true
+  firewall_rules          = var.firewall_rules
+  vpc_endpoints           = { for s in local.private_services : s => local.aws_endpoints[s] }
+  enable_flow_logs        = var.enable_flow_logs
+  flow_log_destination    = var.flow_log_destination
   
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # Azure: VNet
@@ -53,25 +113,37 @@ module "azure_networking" {
   resource_group_name = try(var.provider_config.resource_group_name, "default-rg")
   location            = try(var.provider_config.location, "eastus")
   
-  address_space       = var.metrics.cidr
+  address_space       = local.network_config.cidr
+  enable_ipv6         = var.enable_ipv6
+  ipv6_address_space  = local.azure_ipv6_cidr
   
   # Map generic subnets to Azure format
   public_subnets = [
-    for i, cidr in var.metrics.public_subnets : {
-      name           = "${var.network_name}-public-${i}"
-      address_prefix = cidr
+    for i, cidr in local.network_config.public_subnets : {
+      name                = "${var.network_name}-public-${i}"
+      address_prefix      = cidr
+      ipv6_address_prefix = var.enable_ipv6 ? cidrsubnet(local.azure_ipv6_cidr, 8, local.public_ipv6_netnums[i]) : null
     }
   ]
   
   private_subnets = [
-    for i, cidr in var.metrics.private_subnets : {
-      name           = "${var.network_name}-private-${i}"
-      address_prefix = cidr
+    for i, cidr in local.network_config.private_subnets : {
+      name                = "${var.network_name}-private-${i}"
+      address_prefix      = cidr
+      ipv6_address_prefix = var.enable_ipv6 ? cidrsubnet(local.azure_ipv6_cidr, 8, local.private_ipv6_netnums[i]) : null
     }
   ]
   
   create_default_nsg = true
-  tags               = local.common_tags
+  firewall_rules     = var.firewall_rules
+  private_endpoints = {
+    for s in local.private_services : s => merge(local.azure_private_links[s], {
+      resource_id = lookup(var.private_endpoint_targets, s, null)
+    })
+  }
+  enable_flow_logs     = var.enable_flow_logs
+  flow_log_destination = var.flow_log_destination
+  tags                 = local.default_tags
 }
 
 # GCP: VPC
@@ -85,24 +157,32 @@ module "gcp_networking" {
   # Map generic subnets to GCP format
   subnets = concat(
     [
-      for i, cidr in var.metrics.public_subnets : {
+      for i, cidr in local.network_config.public_subnets : {
         name   = "${var.network_name}-public-${i}"
         cidr   = cidr
         region = try(var.provider_config.region, "us-central1")
+        # Private Google Access reaches Cloud Storage and other Google APIs
+        private_ip_google_access = length(local.private_services) > 0
+        ipv6_access_type         = "EXTERNAL"
       }
     ],
     [
-      for i, cidr in var.metrics.private_subnets : {
+      for i, cidr in local.network_config.private_subnets : {
         name                     = "${var.network_name}-private-${i}"
         cidr                     = cidr
         region                   = try(var.provider_config.region, "us-central1")
         private_ip_google_access = true
+        ipv6_access_type         = "INTERNAL"
       }
     ]
   )
   
   create_internal_firewall = true
   create_ssh_firewall      = true
+  firewall_rules           = var.firewall_rules
+  private_service_access   = length(local.private_services) > 0
+  enable_flow_logs         = var.enable_flow_logs
+  enable_ipv6              = var.enable_ipv6
 }
 
 # ZeroCloud: ZeroNet
@@ -111,16 +191,35 @@ module "zero_networking" {
   source = "../../zero/core/networking"
   
   vpc_name            = var.network_name
-  vpc_cidr            = var.metrics.cidr
-  availability_zones  = var.metrics.azs
+  vpc_cidr            = local.network_config.cidr
+  availability_zones  = local.network_config.azs
+  
+  public_subnet_cidrs  = local.network_config.public_subnets
+  private_subnet_cidrs = local.network_config.private_subnets
   
-  public_subnet_cidrs  = var.metrics.public_subnets
-  private_subnet_cidrs = var.metrics.private_subnets
+  enable_dns_support   = local.network_config.enable_dns
+  enable_dns_hostnames = local.network_config.enable_dns
   
   create_internet_gateway = var.internet_access
   create_default_security_group = true
   
-  tags = local.common_tags
+  tags = local.default_tags
+}
+
+# metrics still plans until it is removed, with a warning to move off it
+check "metrics_deprecated" {
+  assert {
+    condition     = var.metrics == null
+    error_message = "metrics is deprecated: set network_config instead, which takes the same object. metrics is removed in 2.0.0."
+  }
+}
+
+# An Azure private endpoint needs the resource it connects to
+check "private_endpoint_targets" {
+  assert {
+    condition     = var.provider_name != "azure" || alltrue([for s in local.private_services : contains(keys(var.private_endpoint_targets), s)])
+    error_message = "No private endpoint is created for ${join(", ", [for s in local.private_services : s if !contains(keys(var.private_endpoint_targets), s)])}: set their resource IDs in private_endpoint_targets. Their private DNS zones are linked to the VNet anyway."
+  }
 }
 
 # ============================================================================
@@ -135,4 +234,66 @@ locals {
     var.provider_name == "zero"  ? (length(module.zero_networking) > 0 ? module.zero_networking[0].vpc_id : null) :
     null
   )
+
+  # GCP lists public subnets before private ones
+  public_subnet_ids = (
+    var.provider_name == "aws"   ? (length(module.aws_networking) > 0 ? module.aws_networking[0].public_subnet_ids : []) :
+    var.provider_name == "azure" ? (length(module.azure_networking) > 0 ? module.azure_networking[0].public_subnet_ids : []) :
+    var.provider_name == "gcp"   ? (length(module.gcp_networking) > 0 ? slice(module.gcp_networking[0].subnet_self_links, 0, length(local.network_config.public_subnets)) : []) :
+    var.provider_name == "zero"  ? (length(module.zero_networking) > 0 ? module.zero_networking[0].public_subnet_ids : []) :
+    []
+  )
+
+  private_subnet_ids = (
+    var.provider_name == "aws"   ? (length(module.aws_networking) > 0 ? module.aws_networking[0].private_subnet_ids : []) :
+    var.provider_name == "azure" ? (length(module.azure_networking) > 0 ? module.azure_networking[0].private_subnet_ids : []) :
+    var.provider_name == "gcp"   ? (length(module.gcp_networking) > 0 ? slice(module.gcp_networking[0].subnet_self_links, length(local.network_config.public_subnets), length(module.gcp_networking[0].subnet_self_links)) : []) :
+    var.provider_name == "zero"  ? (length(module.zero_networking) > 0 ? module.zero_networking[0].private_subnet_ids : []) :
+    []
+  )
+
+  private_endpoint_ids = (
+    var.provider_name == "aws"   ? (length(module.aws_networking) > 0 ? module.aws_networking[0].vpc_endpoint_ids : {}) :
+    var.provider_name == "azure" ? (length(module.azure_networking) > 0 ? module.azure_networking[0].private_endpoint_ids : {}) :
+    var.provider_name == "gcp"   ? (length(module.gcp_networking) > 0 && length(local.private_services) > 0 ? tomap({ private_service_access = module.gcp_networking[0].private_service_connection_id }) : {}) :
+    {}
+  )
+
+  ipv6_cidr = (
+    var.provider_name == "aws"   ? (length(module.aws_networking) > 0 ? module.aws_networking[0].ipv6_cidr : null) :
+    var.provider_name == "azure" ? (length(module.azure_networking) > 0 ? module.azure_networking[0].ipv6_cidr : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_networking) > 0 ? module.gcp_networking[0].ipv6_cidr : null) :
+    null
+  )
+
+  # GCP lists its subnets public first, as they are passed in
+  ipv6_cidrs = (
+    var.provider_name == "aws" && length(module.aws_networking) > 0 ? {
+      public  = module.aws_networking[0].public_ipv6_cidrs
+      private = module.aws_networking[0].private_ipv6_cidrs
+    } :
+    var.provider_name == "azure" && length(module.azure_networking) > 0 ? {
+      public  = module.azure_networking[0].public_ipv6_cidrs
+      private = module.azure_networking[0].private_ipv6_cidrs
+    } :
+    var.provider_name == "gcp" && length(module.gcp_networking) > 0 && var.enable_ipv6 ? {
+      public  = slice(module.gcp_networking[0].subnet_ipv6_cidrs, 0, length(local.network_config.public_subnets))
+      private = slice(module.gcp_networking[0].subnet_ipv6_cidrs, length(local.network_config.public_subnets), length(module.gcp_networking[0].subnet_ipv6_cidrs))
+    } :
+    { public = [], private = [] }
+  )
+
+  # GCP subnet flow logs have no resource of their own
+  flow_log_id = (
+    var.provider_name == "aws"   ? (length(module.aws_networking) > 0 ? module.aws_networking[0].flow_log_id : null) :
+    var.provider_name == "azure" ? (length(module.azure_networking) > 0 ? module.azure_networking[0].flow_log_id : null) :
+    null
+  )
+
+  # GCP firewall rules attach to the network, so there is no group to hand on
+  security_group_id = (
+    var.provider_name == "aws"   ? (length(module.aws_networking) > 0 ? module.aws_networking[0].rules_security_group_id : null) :
+    var.provider_name == "azure" ? (length(module.azure_networking) > 0 ? module.azure_networking[0].rules_nsg_id : null) :
+    null
+  )
 }
@@ -1,55 +1,72 @@
 package networking_test
 
 import (
-	"testing"
+	"fmt"
+	"path/filepath"
 	"strings"
+	"testing"
+
+	"iac/testutil/cidralloc"
+	"iac/testutil/planerr"
+	"iac/testutil/snapshot"
+	"iac/testutil/tfopts"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// networkConfig allocates the test its own /16 and splits perTier public
+// and then perTier private /24s out of it
+func networkConfig(t *testing.T, azs []string, perTier int) (string, map[string]interface{}) {
+	t.Helper()
+
+	cidr := cidralloc.Block(t)
+	subnets, err := cidralloc.SubnetsFrom(cidr, 2*perTier, 8)
+	require.NoError(t, err)
+
+	return cidr, map[string]interface{}{
+		"cidr":            cidr,
+		"azs":             azs,
+		"public_subnets":  subnets[:perTier],
+		"private_subnets": subnets[perTier:],
+	}
+}
+
 func TestNetworkingFacadeAws(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	cidr, network := networkConfig(t, []string{"us-east-1a", "us-east-1b"}, 2)
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":     "aws",
-			"project_name": "testproject",
-			"environment":  "test",
-			"network_name": "test-vpc",
-			"metrics": map[string]interface{}{
-				"cidr":            "10.0.0.0/16",
-				"azs":             []string{"us-east-1a", "us-east-1b"},
-				"public_subnets":  []string{"10.0.1.0/24", "10.0.2.0/24"},
-				"private_subnets": []string{"10.0.11.0/24", "10.0.12.0/24"},
-			},
+			"provider_name":  "aws",
+			"project_name":   "testproject",
+			"environment":    "dev",
+			"network_name":   "test-vpc",
+			"network_config": network,
 		},
 		BackendConfig: map[string]interface{}{},
 	})
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
-	
+
 	assert.True(t, strings.Contains(planString, "module.aws_networking[0].aws_vpc.this"), "Plan should create an AWS VPC")
-	assert.True(t, strings.Contains(planString, "cidr_block = \"10.0.0.0/16\""), "Plan should have the correct CIDR block")
+	assert.True(t, strings.Contains(planString, "cidr_block = \""+cidr+"\""), "Plan should have the correct CIDR block")
 }
 
 func TestNetworkingFacadeAzure(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	cidr, network := networkConfig(t, []string{"1", "2"}, 1)
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":     "azure",
-			"project_name": "testproject",
-			"environment":  "test",
-			"network_name": "test-vnet",
-			"metrics": map[string]interface{}{
-				"cidr":            "10.1.0.0/16",
-				"azs":             []string{"1", "2"},
-				"public_subnets":  []string{"10.1.1.0/24"},
-				"private_subnets": []string{"10.1.11.0/24"},
-			},
+			"provider_name":  "azure",
+			"project_name":   "testproject",
+			"environment":    "dev",
+			"network_name":   "test-vnet",
+			"network_config": network,
 			"provider_config": map[string]interface{}{
 				"resource_group_name": "test-rg",
 				"location":            "eastus",
@@ -60,20 +77,64 @@ func TestNetworkingFacadeAzure(t *testing.T) {
 	planString := terraform.InitAndPlan(t, terraformOptions)
 
 	assert.True(t, strings.Contains(planString, "module.azure_networking[0].azurerm_virtual_network.this"), "Plan should create an Azure VNet")
-	assert.True(t, strings.Contains(planString, "address_space = [\"10.1.0.0/16\"]"), "Plan should have the correct address space")
+	assert.True(t, strings.Contains(planString, "address_space = [\""+cidr+"\"]"), "Plan should have the correct address space")
 }
 
 func TestNetworkingFacadeGcp(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	_, network := networkConfig(t, []string{"us-central1-a"}, 1)
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":     "gcp",
-			"project_name": "testproject",
-			"environment":  "test",
-			"network_name": "test-network",
-			"metrics": map[string]interface{}{
+			"provider_name":  "gcp",
+			"project_name":   "testproject",
+			"environment":    "dev",
+			"network_name":   "test-network",
+			"network_config": network,
+			"provider_config": map[string]interface{}{
+				"region": "us-central1",
+			},
+		},
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.gcp_networking[0].google_compute_network.this"), "Plan should create a GCP Network")
+	assert.True(t, strings.Contains(planString, "name = \"test-network\""), "Plan should have the correct network name")
+}
+
+// TestNetworkingFacadePlanSnapshot compares the whole normalized plan for
+// each provider against testdata/plan-<provider>.golden.json, with Terraform
+// and OpenTofu in turn when both are installed. The CIDRs are
+// fixed rather than allocated because the golden files record them; the
+// test only plans, so they cannot collide with a deployed network.
+func TestNetworkingFacadePlanSnapshot(t *testing.T) {
+	t.Parallel()
+
+	providers := map[string]map[string]interface{}{
+		"aws": {
+			"network_config": map[string]interface{}{
+				"cidr":            "10.0.0.0/16",
+				"azs":             []string{"us-east-1a", "us-east-1b"},
+				"public_subnets":  []string{"10.0.1.0/24", "10.0.2.0/24"},
+				"private_subnets": []string{"10.0.11.0/24", "10.0.12.0/24"},
+			},
+		},
+		"azure": {
+			"network_config": map[string]interface{}{
+				"cidr":            "10.1.0.0/16",
+				"azs":             []string{"1", "2"},
+				"public_subnets":  []string{"10.1.1.0/24"},
+				"private_subnets": []string{"10.1.11.0/24"},
+			},
+			"provider_config": map[string]interface{}{
+				"resource_group_name": "test-rg",
+				"location":            "eastus",
+			},
+		},
+		"gcp": {
+			"network_config": map[string]interface{}{
 				"cidr":            "10.2.0.0/16",
 				"azs":             []string{"us-central1-a"},
 				"public_subnets":  []string{"10.2.1.0/24"},
@@ -83,33 +144,596 @@ func TestNetworkingFacadeGcp(t *testing.T) {
 				"region": "us-central1",
 			},
 		},
+	}
+
+	for provider, extra := range providers {
+		vars := map[string]interface{}{
+			"provider_name": provider,
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"network_name":  "test-network",
+		}
+		for k, v := range extra {
+			vars[k] = v
+		}
+
+		t.Run(provider, func(t *testing.T) {
+			tfopts.Matrix(t, func(t *testing.T, binary string) {
+				terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+					TerraformDir:    ".",
+					TerraformBinary: binary,
+					Vars:            vars,
+					BackendConfig:   map[string]interface{}{},
+				})
+
+				snapshot.AssertPlan(t, terraformOptions, "plan-"+provider)
+			})
+		})
+	}
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	config := func(cidr string, public, private []string) map[string]interface{} {
+		return map[string]interface{}{
+			"cidr":            cidr,
+			"azs":             []string{"us-east-1a", "us-east-1b"},
+			"public_subnets":  public,
+			"private_subnets": private,
+		}
+	}
+	public := []string{"10.0.1.0/24", "10.0.2.0/24"}
+	private := []string{"10.0.11.0/24", "10.0.12.0/24"}
+	ipv6 := func(ipv6Fields map[string]interface{}) map[string]interface{} {
+		m := config("10.0.0.0/16", public, private)
+		for k, v := range ipv6Fields {
+			m[k] = v
+		}
+		return map[string]interface{}{"enable_ipv6": true, "network_config": m}
+	}
+
+	base := map[string]interface{}{
+		"provider_name":  "aws",
+		"project_name":   "testproject",
+		"environment":    "dev",
+		"network_name":   "test-vpc",
+		"network_config": config("10.0.0.0/16", public, private),
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name:     "CidrPrefixOver32",
+			Vars:     map[string]interface{}{"network_config": config("10.0.0.0/33", public, private)},
+			Want:     "network_config.cidr must be a valid IPv4 CIDR block",
+			Variable: "network_config",
+		},
+		{
+			Name:     "CidrOctetOutOfRange",
+			Vars:     map[string]interface{}{"network_config": config("999.0.0.0/16", public, private)},
+			Want:     "network_config.cidr must be a valid IPv4 CIDR block",
+			Variable: "network_config",
+		},
+		{
+			Name:     "MalformedSubnet",
+			Vars:     map[string]interface{}{"network_config": config("10.0.0.0/16", []string{"10.0.1.0"}, private)},
+			Want:     "network_config.public_subnets and network_config.private_subnets must be valid IPv4 CIDR blocks",
+			Variable: "network_config",
+		},
+		{
+			Name:     "OverlappingSubnets",
+			Vars:     map[string]interface{}{"network_config": config("10.0.0.0/16", public, []string{"10.0.1.128/25"})},
+			Want:     "network_config.public_subnets and network_config.private_subnets must not overlap",
+			Variable: "network_config",
+		},
+		{
+			Name:     "DuplicateSubnet",
+			Vars:     map[string]interface{}{"network_config": config("10.0.0.0/16", []string{"10.0.1.0/24", "10.0.1.0/24"}, private)},
+			Want:     "network_config.public_subnets and network_config.private_subnets must not overlap",
+			Variable: "network_config",
+		},
+		{
+			Name:     "SubnetOutsideCidr",
+			Vars:     map[string]interface{}{"network_config": config("10.0.0.0/16", public, []string{"10.1.11.0/24", "10.0.12.0/24"})},
+			Want:     "network_config.public_subnets and network_config.private_subnets must be within network_config.cidr",
+			Variable: "network_config",
+		},
+		{
+			Name:     "SubnetLargerThanCidr",
+			Vars:     map[string]interface{}{"network_config": config("10.0.0.0/16", []string{"10.0.0.0/15"}, private)},
+			Want:     "network_config.public_subnets and network_config.private_subnets must be within network_config.cidr",
+			Variable: "network_config",
+		},
+		{
+			Name:     "MoreSubnetsThanAzs",
+			Vars:     map[string]interface{}{"network_config": config("10.0.0.0/16", []string{"10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"}, private)},
+			Want:     "may each have at most one subnet per AZ in network_config.azs on aws",
+			Variable: "network_config",
+		},
+		{
+			// Only optional attributes have defaults, so a misspelt
+			// required one fails the type rather than planning an empty
+			// tier
+			Name: "TypoedKey",
+			Vars: map[string]interface{}{"network_config": map[string]interface{}{
+				"cidr":            "10.0.0.0/16",
+				"azs":             []string{"us-east-1a", "us-east-1b"},
+				"public_subnet":   public,
+				"private_subnets": private,
+			}},
+			Want: `attribute "public_subnets" is required`,
+		},
+		{
+			Name:     "FirewallPortRangeReversed",
+			Vars:     firewallRules(map[string]interface{}{"name": "app", "ports": []string{"8080-8000"}, "cidr_blocks": []string{"10.0.0.0/16"}}),
+			Want:     "firewall_rules ports must be a port or range between 1 and 65535",
+			Variable: "firewall_rules",
+		},
+		{
+			Name:     "FirewallPortOutOfRange",
+			Vars:     firewallRules(map[string]interface{}{"name": "app", "ports": []string{"70000"}, "cidr_blocks": []string{"10.0.0.0/16"}}),
+			Want:     "firewall_rules ports must be a port or range between 1 and 65535",
+			Variable: "firewall_rules",
+		},
+		{
+			Name:     "FirewallPortZero",
+			Vars:     firewallRules(map[string]interface{}{"name": "app", "ports": []string{"0"}, "cidr_blocks": []string{"10.0.0.0/16"}}),
+			Want:     "firewall_rules ports must be a port or range between 1 and 65535",
+			Variable: "firewall_rules",
+		},
+		{
+			Name:     "FirewallPortsOnIcmp",
+			Vars:     firewallRules(map[string]interface{}{"name": "ping", "protocol": "icmp", "ports": []string{"8"}, "cidr_blocks": []string{"10.0.0.0/16"}}),
+			Want:     "firewall_rules ports only apply to tcp and udp",
+			Variable: "firewall_rules",
+		},
+		{
+			Name:     "FirewallUnknownProtocol",
+			Vars:     firewallRules(map[string]interface{}{"name": "gre", "protocol": "gre", "cidr_blocks": []string{"10.0.0.0/16"}}),
+			Want:     "firewall_rules protocol must be one of: tcp, udp, icmp, all",
+			Variable: "firewall_rules",
+		},
+		{
+			Name:     "FirewallUnknownDirection",
+			Vars:     firewallRules(map[string]interface{}{"name": "in", "direction": "inbound", "cidr_blocks": []string{"10.0.0.0/16"}}),
+			Want:     "firewall_rules direction must be one of: ingress, egress",
+			Variable: "firewall_rules",
+		},
+		{
+			Name: "FirewallDuplicateNames",
+			Vars: firewallRules(
+				map[string]interface{}{"name": "web", "ports": []string{"443"}, "cidr_blocks": []string{"0.0.0.0/0"}},
+				map[string]interface{}{"name": "web", "ports": []string{"80"}, "cidr_blocks": []string{"0.0.0.0/0"}},
+			),
+			Want:     "firewall_rules names must be unique",
+			Variable: "firewall_rules",
+		},
+		{
+			Name:     "FirewallBadCidr",
+			Vars:     firewallRules(map[string]interface{}{"name": "app", "ports": []string{"443"}, "cidr_blocks": []string{"10.0.0.0"}}),
+			Want:     "firewall_rules cidr_blocks must be a non-empty list of valid CIDR blocks",
+			Variable: "firewall_rules",
+		},
+		{
+			Name:     "FirewallOpenAllPorts",
+			Vars:     firewallRules(map[string]interface{}{"name": "any", "protocol": "all", "cidr_blocks": []string{"0.0.0.0/0"}}),
+			Want:     "firewall_rules may only open ports 80 and 443",
+			Variable: "firewall_rules",
+		},
+		{
+			Name:     "FirewallOpenIPv6",
+			Vars:     firewallRules(map[string]interface{}{"name": "db", "ports": []string{"5432"}, "cidr_blocks": []string{"::/0"}}),
+			Want:     "firewall_rules may only open ports 80 and 443",
+			Variable: "firewall_rules",
+		},
+		{
+			Name:     "UnknownPrivateEndpoint",
+			Vars:     map[string]interface{}{"enable_private_endpoints": []string{"s3", "bigtable"}},
+			Want:     "enable_private_endpoints supports: storage, s3, nosql, dynamodb, queue, secrets, kms",
+			Variable: "enable_private_endpoints",
+		},
+		{
+			Name:     "FlowLogsWithoutDestination",
+			Vars:     map[string]interface{}{"enable_flow_logs": true},
+			Want:     "enable_flow_logs on aws needs flow_log_destination",
+			Variable: "flow_log_destination",
+		},
+		{
+			Name: "FlowLogsAzureWithoutDestination",
+			Vars: map[string]interface{}{
+				"provider_name":    "azure",
+				"provider_config":  map[string]interface{}{"resource_group_name": "test-rg", "location": "eastus"},
+				"enable_flow_logs": true,
+			},
+			Want:     "enable_flow_logs on azure needs flow_log_destination",
+			Variable: "flow_log_destination",
+		},
+		{
+			Name:     "FlowLogsBadAwsDestination",
+			Vars:     map[string]interface{}{"enable_flow_logs": true, "flow_log_destination": "my-bucket"},
+			Want:     "flow_log_destination on aws must be an S3 bucket or CloudWatch Logs log group ARN",
+			Variable: "flow_log_destination",
+		},
+		{
+			Name:     "IPv6NetnumOutsideSlash56",
+			Vars:     ipv6(map[string]interface{}{"public_ipv6_netnums": []int{0, 256}}),
+			Want:     "network_config IPv6 netnums must fit the /56",
+			Variable: "enable_ipv6",
+		},
+		{
+			Name:     "IPv6NetnumsShort",
+			Vars:     ipv6(map[string]interface{}{"private_ipv6_netnums": []int{10}}),
+			Want:     "need one entry per subnet",
+			Variable: "enable_ipv6",
+		},
+		{
+			Name:     "IPv6NetnumsRepeat",
+			Vars:     ipv6(map[string]interface{}{"public_ipv6_netnums": []int{0, 1}, "private_ipv6_netnums": []int{1, 2}}),
+			Want:     "network_config IPv6 netnums must not repeat",
+			Variable: "enable_ipv6",
+		},
+		{
+			Name:     "IPv6CidrOnAws",
+			Vars:     ipv6(map[string]interface{}{"ipv6_cidr": "fd00::/56"}),
+			Want:     "network_config.ipv6_cidr must be an IPv6 /56 and is only used on azure",
+			Variable: "enable_ipv6",
+		},
+		{
+			Name:     "IPv6OnZero",
+			Vars:     map[string]interface{}{"provider_name": "zero", "enable_ipv6": true},
+			Want:     "ZeroNet has no IPv6",
+			Variable: "enable_ipv6",
+		},
+		{
+			Name:     "FlowLogsOnZero",
+			Vars:     map[string]interface{}{"provider_name": "zero", "enable_flow_logs": true},
+			Want:     "ZeroNet has no flow logs",
+			Variable: "enable_flow_logs",
+		},
 	})
+}
 
-	planString := terraform.InitAndPlan(t, terraformOptions)
+// firewallRules is the firewall_rules variable for rules
+func firewallRules(rules ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"firewall_rules": rules}
+}
 
-	assert.True(t, strings.Contains(planString, "module.gcp_networking[0].google_compute_network.this"), "Plan should create a GCP Network")
-	assert.True(t, strings.Contains(planString, "name = \"test-network\""), "Plan should have the correct network name")
+// allowHTTPS opens HTTPS to the internet, which needs no allow_open_ingress
+var allowHTTPS = map[string]interface{}{
+	"name":        "allow-https",
+	"ports":       []string{"443"},
+	"cidr_blocks": []string{"0.0.0.0/0"},
+	"description": "HTTPS from anywhere",
+}
+
+// openSSH opens SSH to the internet, which the facade rejects
+var openSSH = map[string]interface{}{
+	"name":        "open-ssh",
+	"ports":       []string{"22"},
+	"cidr_blocks": []string{"0.0.0.0/0"},
+}
+
+// providerVars returns the facade variables for a one-AZ network of
+// provider with its own CIDR block, plus extra
+func providerVars(t *testing.T, provider string, extra map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	azs := map[string][]string{"aws": {"us-east-1a"}, "azure": {"1"}, "gcp": {"us-central1-a"}}
+	configs := map[string]map[string]interface{}{
+		"azure": {"resource_group_name": "test-rg", "location": "eastus"},
+		"gcp":   {"region": "us-central1"},
+	}
+
+	_, network := networkConfig(t, azs[provider], 1)
+	vars := map[string]interface{}{
+		"provider_name":  provider,
+		"project_name":   "testproject",
+		"environment":    "dev",
+		"network_name":   "test-network",
+		"network_config": network,
+	}
+	if config, ok := configs[provider]; ok {
+		vars["provider_config"] = config
+	}
+	for k, v := range extra {
+		vars[k] = v
+	}
+	return vars
 }
 
-func TestNetworkingFacadeInvalidCidr(t *testing.T) {
+func TestNetworkingFacadeFirewallRules(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := &terraform.Options{
-		TerraformDir: ".",
-		Vars: map[string]interface{}{
-			"provider":     "aws",
-			"project_name": "testproject",
-			"environment":  "test",
-			"network_name": "test-vpc",
-			"metrics": map[string]interface{}{
-				"cidr":            "999.0.0.0/16", // Invalid CIDR
-				"azs":             []string{"us-east-1a"},
-				"public_subnets":  []string{"10.0.1.0/24"},
-				"private_subnets": []string{"10.0.11.0/24"},
+	want := map[string][]string{
+		"aws": {
+			"module.aws_networking[0].aws_security_group.rules[0]",
+			`module.aws_networking[0].aws_vpc_security_group_ingress_rule.rules["allow-https/443/0.0.0.0/0"]`,
+		},
+		"azure": {
+			"module.azure_networking[0].azurerm_network_security_group.rules[0]",
+			`module.azure_networking[0].azurerm_network_security_rule.rules["allow-https"]`,
+			"module.azure_networking[0].azurerm_subnet_network_security_group_association.rules[1]",
+		},
+		"gcp": {
+			`module.gcp_networking[0].google_compute_firewall.rules["allow-https"]`,
+		},
+	}
+
+	for provider, addresses := range want {
+		provider, addresses := provider, addresses
+
+		t.Run(provider, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("allow https", func(t *testing.T) {
+				t.Parallel()
+
+				planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+					TerraformDir: ".",
+					Vars:         providerVars(t, provider, firewallRules(allowHTTPS)),
+					NoColor:      true,
+				}))
+
+				for _, address := range addresses {
+					assert.Contains(t, planString, address)
+				}
+				assert.Contains(t, planString, "443", "The rule should open port 443")
+			})
+
+			vars := providerVars(t, provider, nil)
+
+			planerr.RunMatrix(t, ".", vars, []planerr.Case{{
+				Name:     "open ssh rejected",
+				Vars:     firewallRules(openSSH),
+				Want:     "firewall_rules may only open ports 80 and 443",
+				Variable: "firewall_rules",
+			}})
+		})
+	}
+}
+
+func TestNetworkingFacadePrivateEndpoints(t *testing.T) {
+	t.Parallel()
+
+	storageAccount := "/subscriptions/sub/resourceGroups/test-rg/providers/Microsoft.Storage/storageAccounts/teststorage"
+
+	tests := map[string]struct {
+		extra map[string]interface{}
+		want  []string
+		match []string
+	}{
+		"aws": {
+			extra: map[string]interface{}{"enable_private_endpoints": []string{"s3", "dynamodb", "secrets"}},
+			want: []string{
+				`module.aws_networking[0].aws_vpc_endpoint.this["storage"]`,
+				`module.aws_networking[0].aws_vpc_endpoint.this["nosql"]`,
+				`module.aws_networking[0].aws_vpc_endpoint.this["secrets"]`,
+			},
+			match: []string{
+				`service_name\s+= "com\.amazonaws\.[a-z0-9-]+\.s3"`,
+				`vpc_endpoint_type\s+= "Gateway"`,
+				`vpc_endpoint_type\s+= "Interface"`,
+				`private_dns_enabled\s+= true`,
+				`route_table_ids\s+= \(known after apply\)`,
+			},
+		},
+		"azure": {
+			extra: map[string]interface{}{
+				"enable_private_endpoints": []string{"storage"},
+				"private_endpoint_targets": map[string]interface{}{"storage": storageAccount},
+			},
+			want: []string{
+				`module.azure_networking[0].azurerm_private_dns_zone.this["privatelink.blob.core.windows.net"]`,
+				`module.azure_networking[0].azurerm_private_dns_zone_virtual_network_link.this["privatelink.blob.core.windows.net"]`,
+				`module.azure_networking[0].azurerm_private_endpoint.this["storage"]`,
+			},
+			match: []string{
+				`subresource_names\s+= \[\s+"blob"`,
+				`private_connection_resource_id\s+= "` + storageAccount + `"`,
+			},
+		},
+		"gcp": {
+			extra: map[string]interface{}{"enable_private_endpoints": []string{"storage"}},
+			want: []string{
+				"module.gcp_networking[0].google_compute_global_address.private_service_access[0]",
+				"module.gcp_networking[0].google_service_networking_connection.private_service_access[0]",
+			},
+			match: []string{
+				`purpose\s+= "VPC_PEERING"`,
+				`service\s+= "servicenetworking.googleapis.com"`,
+				`private_ip_google_access\s+= true`,
 			},
 		},
 	}
 
-	_, err := terraform.InitAndPlanE(t, terraformOptions)
-	assert.Error(t, err, "Plan should fail with an invalid CIDR block")
+	for provider, tc := range tests {
+		provider, tc := provider, tc
+
+		t.Run(provider, func(t *testing.T) {
+			t.Parallel()
+
+			planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars:         providerVars(t, provider, tc.extra),
+				NoColor:      true,
+			}))
+
+			for _, address := range tc.want {
+				assert.Contains(t, planString, address)
+			}
+			for _, pattern := range tc.match {
+				assert.Regexp(t, pattern, planString)
+			}
+		})
+	}
+}
+
+func TestNetworkingFacadeFlowLogs(t *testing.T) {
+	t.Parallel()
+
+	logGroup := "arn:aws:logs:us-east-1:123456789012:log-group:vpc-flow-logs"
+	storageAccount := "/subscriptions/sub/resourceGroups/test-rg/providers/Microsoft.Storage/storageAccounts/flowlogs"
+
+	tests := map[string]struct {
+		provider string
+		extra    map[string]interface{}
+		want     []string
+		match    []string
+		absent   []string
+	}{
+		"aws cloudwatch": {
+			provider: "aws",
+			extra:    map[string]interface{}{"enable_flow_logs": true, "flow_log_destination": logGroup},
+			want: []string{
+				"module.aws_networking[0].aws_flow_log.this[0]",
+				"module.aws_networking[0].aws_iam_role.flow_logs[0]",
+				"module.aws_networking[0].aws_iam_role_policy.flow_logs[0]",
+			},
+			match: []string{
+				`log_destination_type\s+= "cloud-watch-logs"`,
+				`traffic_type\s+= "ALL"`,
+				`"vpc-flow-logs\.amazonaws\.com"`,
+				`"logs:PutLogEvents"`,
+				`"` + logGroup + `:\*"`,
+			},
+		},
+		"aws s3": {
+			provider: "aws",
+			extra:    map[string]interface{}{"enable_flow_logs": true, "flow_log_destination": "arn:aws:s3:::flow-logs"},
+			want:     []string{"module.aws_networking[0].aws_flow_log.this[0]"},
+			match:    []string{`log_destination_type\s+= "s3"`},
+			absent:   []string{"aws_iam_role.flow_logs"},
+		},
+		"azure": {
+			provider: "azure",
+			extra:    map[string]interface{}{"enable_flow_logs": true, "flow_log_destination": storageAccount},
+			want:     []string{"module.azure_networking[0].azurerm_network_watcher_flow_log.this[0]"},
+			match: []string{
+				`network_watcher_name\s+= "NetworkWatcher_eastus"`,
+				`storage_account_id\s+= "` + storageAccount + `"`,
+			},
+		},
+		"gcp without destination": {
+			provider: "gcp",
+			extra:    map[string]interface{}{"enable_flow_logs": true},
+			want:     []string{"module.gcp_networking[0].google_compute_subnetwork.subnets[0]"},
+			match: []string{
+				`log_config\s+\{`,
+				`aggregation_interval\s+= "INTERVAL_5_SEC"`,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars:         providerVars(t, tc.provider, tc.extra),
+				NoColor:      true,
+			}))
+
+			for _, address := range tc.want {
+				assert.Contains(t, planString, address)
+			}
+			for _, pattern := range tc.match {
+				assert.Regexp(t, pattern, planString)
+			}
+			for _, address := range tc.absent {
+				assert.NotContains(t, planString, address)
+			}
+		})
+	}
+}
+
+// TestNetworkingFacadeDNS checks that network_config.enable_dns turns
+// both VPC DNS settings on by default and off when false
+func TestNetworkingFacadeDNS(t *testing.T) {
+	t.Parallel()
+
+	for _, enabled := range []bool{true, false} {
+		enabled := enabled
+
+		t.Run(fmt.Sprintf("enable_dns=%t", enabled), func(t *testing.T) {
+			t.Parallel()
+
+			vars := providerVars(t, "aws", nil)
+			if !enabled {
+				vars["network_config"].(map[string]interface{})["enable_dns"] = false
+			}
+
+			plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars:         vars,
+				PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+				NoColor:      true,
+			}))
+
+			vpc, ok := plan.ResourcePlannedValuesMap["module.aws_networking[0].aws_vpc.this"]
+			require.True(t, ok, "Plan should create an AWS VPC")
+			assert.Equal(t, enabled, vpc.AttributeValues["enable_dns_support"])
+			assert.Equal(t, enabled, vpc.AttributeValues["enable_dns_hostnames"])
+		})
+	}
+}
+
+func TestNetworkingFacadeIPv6(t *testing.T) {
+	t.Parallel()
+
+	t.Run("aws", func(t *testing.T) {
+		t.Parallel()
+
+		planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+			TerraformDir: ".",
+			Vars:         providerVars(t, "aws", map[string]interface{}{"enable_ipv6": true}),
+			NoColor:      true,
+		}))
+
+		assert.Contains(t, planString, "module.aws_networking[0].aws_egress_only_internet_gateway.this[0]")
+		assert.Contains(t, planString, "module.aws_networking[0].aws_route.private_ipv6_egress[0]")
+		assert.Contains(t, planString, "module.aws_networking[0].aws_route.public_internet_ipv6[0]")
+		assert.Regexp(t, `assign_generated_ipv6_cidr_block\s+= true`, planString)
+		assert.Regexp(t, `destination_ipv6_cidr_block\s+= "::/0"`, planString)
+		assert.Regexp(t, `assign_ipv6_address_on_creation\s+= true`, planString)
+	})
+
+	t.Run("azure", func(t *testing.T) {
+		t.Parallel()
+
+		block := cidralloc.Block6(t)
+		subnets, err := cidralloc.SubnetsFrom(block, 2, 8)
+		require.NoError(t, err)
+
+		vars := providerVars(t, "azure", map[string]interface{}{"enable_ipv6": true})
+		vars["network_config"].(map[string]interface{})["ipv6_cidr"] = block
+
+		planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+			TerraformDir: ".",
+			Vars:         vars,
+			NoColor:      true,
+		}))
+
+		// Public subnets take the first /64s, then private
+		assert.Contains(t, planString, `"`+block+`"`, "The VNet should have the IPv6 address space")
+		assert.Contains(t, planString, `"`+subnets[0]+`"`, "The public subnet should get the first /64")
+		assert.Contains(t, planString, `"`+subnets[1]+`"`, "The private subnet should get the second /64")
+	})
+
+	t.Run("gcp", func(t *testing.T) {
+		t.Parallel()
+
+		planString := terraform.InitAndPlan(t, tfopts.New(t, &terraform.Options{
+			TerraformDir: ".",
+			Vars:         providerVars(t, "gcp", map[string]interface{}{"enable_ipv6": true}),
+			NoColor:      true,
+		}))
+
+		assert.Regexp(t, `enable_ula_internal_ipv6\s+= true`, planString)
+		assert.Regexp(t, `stack_type\s+= "IPV4_IPV6"`, planString)
+		assert.Regexp(t, `ipv6_access_type\s+= "EXTERNAL"`, planString)
+		assert.Regexp(t, `ipv6_access_type\s+= "INTERNAL"`, planString)
+	})
 }
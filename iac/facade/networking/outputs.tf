@@ -5,10 +5,55 @@ output "network_id" {
 
 output "provider" {
   description = "Cloud provider"
-  value       = var.provider
+  value       = var.provider_name
+}
+
+output "public_subnet_ids" {
+  description = "Public subnet IDs (self links on GCP)"
+  value       = local.public_subnet_ids
+}
+
+output "private_subnet_ids" {
+  description = "Private subnet IDs (self links on GCP)"
+  value       = local.private_subnet_ids
+}
+
+output "security_group_id" {
+  description = "Security group (AWS) or NSG (Azure) holding firewall_rules; null on GCP, where the rules apply to the whole network, and without rules"
+  value       = local.security_group_id
+}
+
+output "nsg_id" {
+  description = "Azure NSG holding firewall_rules, associated with every subnet"
+  value       = var.provider_name == "azure" ? local.security_group_id : null
+}
+
+output "firewall_rule_ids" {
+  description = "GCP firewall IDs by rule name"
+  value       = length(module.gcp_networking) > 0 ? module.gcp_networking[0].firewall_rule_ids : {}
+}
+
+output "private_endpoint_ids" {
+  description = "VPC endpoint (AWS) or private endpoint (Azure) IDs by service; on GCP the service networking connection"
+  value       = local.private_endpoint_ids
+}
+
+output "flow_log_id" {
+  description = "VPC flow log (AWS) or NSG flow log (Azure) ID; null on GCP, where flow logs are a subnet setting"
+  value       = local.flow_log_id
+}
+
+output "ipv6_cidr" {
+  description = "The network's IPv6 range when enable_ipv6 is set: the /56 on AWS and Azure, the internal /48 on GCP"
+  value       = local.ipv6_cidr
+}
+
+output "ipv6_cidrs" {
+  description = "IPv6 /64 of each subnet, as public and private lists in subnet order"
+  value       = local.ipv6_cidrs
 }
 
 output "cidr" {
   description = "Network CIDR"
-  value       = var.metrics.cidr
+  value       = local.network_config.cidr
 }
@@ -3,7 +3,7 @@ variable "provider_name" {
   type        = string
   validation {
     condition     = contains(["aws", "azure", "gcp", "zero"], var.provider_name)
-    error_message = "Provider must be one of: aws, azure, gcp, zero"
+    error_message = "provider_name must be one of: aws, azure, gcp, zero"
   }
 }
 
@@ -15,6 +15,10 @@ variable "project_name" {
 variable "environment" {
   description = "Environment name"
   type        = string
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "network_name" {
@@ -22,14 +26,142 @@ variable "network_name" {
   type        = string
 }
 
+variable "network_config" {
+  description = "Network CIDR, AZs, and subnet ranges. Subnet i of each tier is placed in azs[i] on AWS and ZeroNet; Azure and GCP ignore azs. With enable_ipv6, the ipv6 fields pick which /64 of the network's /56 each subnet gets, and the /56 itself on Azure. enable_dns = false turns off DNS resolution and hostnames in the VPC on AWS and ZeroNet"
+  type = object({
+    cidr                 = string
+    azs                  = list(string)
+    public_subnets       = list(string)
+    private_subnets      = list(string)
+    ipv6_cidr            = optional(string)
+    public_ipv6_netnums  = optional(list(number), [])
+    private_ipv6_netnums = optional(list(number), [])
+    enable_dns           = optional(bool, true)
+  })
+  # Null only while the deprecated metrics is set instead; the rules check
+  # whichever of the two is given
+  default = null
+  validation {
+    condition     = var.network_config != null || var.metrics != null
+    error_message = "network_config is required, e.g. { cidr = \"10.0.0.0/16\", azs = [\"us-east-1a\"], public_subnets = [\"10.0.1.0/24\"], private_subnets = [\"10.0.10.0/24\"] }"
+  }
+  validation {
+    condition     = alltrue([for c in [var.network_config, var.metrics] : can(cidrnetmask(c.cidr)) if c != null])
+    error_message = "network_config.cidr must be a valid IPv4 CIDR block, e.g. 10.0.0.0/16"
+  }
+  validation {
+    condition = alltrue(flatten([
+      for c in [var.network_config, var.metrics] : [
+        for s in concat(c.public_subnets, c.private_subnets) : can(cidrnetmask(s))
+      ] if c != null
+    ]))
+    error_message = "network_config.public_subnets and network_config.private_subnets must be valid IPv4 CIDR blocks"
+  }
+  validation {
+    # Two blocks overlap when they share a network address at the shorter
+    # of their two prefix lengths. Malformed blocks are left to the rule
+    # above.
+    condition = try(alltrue(flatten([
+      for c in [var.network_config, var.metrics] : [
+        for i, a in concat(c.public_subnets, c.private_subnets) : [
+          for j, b in concat(c.public_subnets, c.private_subnets) : [
+            for prefix in [min(tonumber(split("/", a)[1]), tonumber(split("/", b)[1]))] :
+            cidrhost("${split("/", a)[0]}/${prefix}", 0) != cidrhost("${split("/", b)[0]}/${prefix}", 0)
+          ] if i < j
+        ]
+      ] if c != null
+    ])), true)
+    error_message = "network_config.public_subnets and network_config.private_subnets must not overlap, e.g. 10.0.1.0/24 and 10.0.2.0/24"
+  }
+  validation {
+    # A subnet lies in the network when its prefix is no shorter and its
+    # address masked to the network's prefix is the network's
+    condition = try(alltrue(flatten([
+      for c in [var.network_config, var.metrics] : [
+        for s in concat(c.public_subnets, c.private_subnets) :
+        tonumber(split("/", s)[1]) >= tonumber(split("/", c.cidr)[1]) &&
+        cidrhost("${split("/", s)[0]}/${split("/", c.cidr)[1]}", 0) == cidrhost(c.cidr, 0)
+      ] if c != null
+    ])), true)
+    error_message = "network_config.public_subnets and network_config.private_subnets must be within network_config.cidr, e.g. 10.0.1.0/24 in 10.0.0.0/16"
+  }
+  validation {
+    # AWS and ZeroNet index azs by subnet, so a tier cannot have more
+    # subnets than AZs
+    condition = !contains(["aws", "zero"], var.provider_name) || alltrue([
+      for c in [var.network_config, var.metrics] :
+      length(c.public_subnets) <= length(c.azs) && length(c.private_subnets) <= length(c.azs)
+      if c != null
+    ])
+    error_message = "network_config.public_subnets and network_config.private_subnets may each have at most one subnet per AZ in network_config.azs on ${var.provider_name}"
+  }
+}
+
+# Deprecated in favor of network_config, as listed in deprecations.json
 variable "metrics" {
-  description = "Network metrics including CIDR, AZs, and subnet ranges"
+  description = "Deprecated: renamed network_config, which takes the same object; metrics is removed in 2.0.0"
   type = object({
-    cidr            = string
-    azs             = list(string)
-    public_subnets  = list(string)
-    private_subnets = list(string)
+    cidr                 = string
+    azs                  = list(string)
+    public_subnets       = list(string)
+    private_subnets      = list(string)
+    ipv6_cidr            = optional(string)
+    public_ipv6_netnums  = optional(list(number), [])
+    private_ipv6_netnums = optional(list(number), [])
+    enable_dns           = optional(bool, true)
   })
+  default = null
+  validation {
+    condition     = var.metrics == null || var.network_config == null
+    error_message = "metrics is deprecated and replaced by network_config: set network_config alone"
+  }
+}
+
+variable "enable_ipv6" {
+  description = "Dual stack: an IPv6 /56 for the network and a /64 per subnet. Private subnets reach the internet over IPv6 through an egress-only gateway on AWS."
+  type        = bool
+  default     = false
+  validation {
+    condition     = var.provider_name != "zero" || !var.enable_ipv6
+    error_message = "ZeroNet has no IPv6, so enable_ipv6 must be false on zero"
+  }
+  validation {
+    condition = !var.enable_ipv6 || alltrue(flatten([
+      for c in [var.network_config, var.metrics] : [
+        for n in concat(c.public_ipv6_netnums, c.private_ipv6_netnums) : n >= 0 && n <= 255 && floor(n) == n
+      ] if c != null
+    ]))
+    error_message = "network_config IPv6 netnums must fit the /56: each subnet gets one of its 256 /64s, numbered 0 to 255"
+  }
+  validation {
+    condition = !var.enable_ipv6 || alltrue([
+      for c in [var.network_config, var.metrics] :
+      contains([0, length(c.public_subnets)], length(c.public_ipv6_netnums)) &&
+      contains([0, length(c.private_subnets)], length(c.private_ipv6_netnums))
+      if c != null
+    ])
+    error_message = "network_config.public_ipv6_netnums and network_config.private_ipv6_netnums need one entry per subnet"
+  }
+  validation {
+    condition = !var.enable_ipv6 || alltrue([
+      for c in [var.network_config, var.metrics] :
+      length(distinct(concat(c.public_ipv6_netnums, c.private_ipv6_netnums))) == length(concat(c.public_ipv6_netnums, c.private_ipv6_netnums))
+      if c != null
+    ])
+    error_message = "network_config IPv6 netnums must not repeat"
+  }
+  validation {
+    condition     = var.provider_name != "gcp" || alltrue([for c in [var.network_config, var.metrics] : length(concat(c.public_ipv6_netnums, c.private_ipv6_netnums)) == 0 if c != null])
+    error_message = "GCP assigns subnet IPv6 ranges itself, so network_config IPv6 netnums are not supported on gcp"
+  }
+  validation {
+    condition = alltrue([
+      for c in [var.network_config, var.metrics] :
+      c.ipv6_cidr == null || (var.provider_name == "azure" && can(regex(":", c.ipv6_cidr)) && endswith(try(cidrsubnet(c.ipv6_cidr, 0, 0), ""), "/56"))
+      if c != null
+    ])
+    error_message = "network_config.ipv6_cidr must be an IPv6 /56 and is only used on azure; AWS and GCP assign the network's IPv6 range"
+  }
 }
 
 variable "internet_access" {
@@ -38,6 +170,121 @@ variable "internet_access" {
   default     = true
 }
 
+variable "firewall_rules" {
+  description = "Firewall rules: an AWS security group, an Azure NSG associated with every subnet, or GCP VPC firewall rules. ports are \"443\" or \"8000-8080\"; empty means every port"
+  type = list(object({
+    name        = string
+    direction   = optional(string, "ingress")
+    protocol    = optional(string, "tcp")
+    ports       = optional(list(string), [])
+    cidr_blocks = list(string)
+    description = optional(string, "")
+  }))
+  default = []
+  validation {
+    condition     = length(distinct([for r in var.firewall_rules : r.name])) == length(var.firewall_rules)
+    error_message = "firewall_rules names must be unique, e.g. web and ssh rather than web twice"
+  }
+  validation {
+    condition     = alltrue([for r in var.firewall_rules : contains(["ingress", "egress"], r.direction)])
+    error_message = "firewall_rules direction must be one of: ingress, egress"
+  }
+  validation {
+    condition     = alltrue([for r in var.firewall_rules : contains(["tcp", "udp", "icmp", "all"], r.protocol)])
+    error_message = "firewall_rules protocol must be one of: tcp, udp, icmp, all"
+  }
+  validation {
+    condition     = alltrue([for r in var.firewall_rules : length(r.ports) == 0 || contains(["tcp", "udp"], r.protocol)])
+    error_message = "firewall_rules ports only apply to tcp and udp; leave them empty (ports = []) for icmp and all"
+  }
+  validation {
+    # A port is N or N-M with 1 <= N <= M <= 65535
+    condition = alltrue(flatten([
+      for r in var.firewall_rules : [
+        for p in r.ports : try(
+          can(regex("^[0-9]+(-[0-9]+)?$", p)) &&
+          tonumber(split("-", p)[0]) >= 1 &&
+          tonumber(split("-", p)[0]) <= tonumber(reverse(split("-", p))[0]) &&
+          tonumber(reverse(split("-", p))[0]) <= 65535,
+          false
+        )
+      ]
+    ]))
+    error_message = "firewall_rules ports must be a port or range between 1 and 65535, e.g. \"443\" or \"8000-8080\", with the lower port first"
+  }
+  validation {
+    condition     = alltrue([for r in var.firewall_rules : length(r.cidr_blocks) > 0 && alltrue([for c in r.cidr_blocks : can(cidrhost(c, 0))])])
+    error_message = "firewall_rules cidr_blocks must be a non-empty list of valid CIDR blocks, e.g. [\"10.0.0.0/16\"]"
+  }
+  validation {
+    # Open ingress is only allowed for HTTP and HTTPS
+    condition = var.allow_open_ingress || alltrue([
+      for r in var.firewall_rules :
+      r.direction != "ingress" || length(setintersection(r.cidr_blocks, ["0.0.0.0/0", "::/0"])) == 0 ||
+      (length(r.ports) > 0 && length(setsubtract(r.ports, ["80", "443"])) == 0)
+    ])
+    error_message = "firewall_rules may only open ports 80 and 443 to 0.0.0.0/0 or ::/0; set allow_open_ingress = true to open other ports to the internet"
+  }
+  validation {
+    condition     = var.provider_name != "zero" || length(var.firewall_rules) == 0
+    error_message = "ZeroNet has no security groups, so firewall_rules must be empty on zero"
+  }
+}
+
+variable "allow_open_ingress" {
+  description = "Allow firewall_rules to open ports other than 80 and 443 to 0.0.0.0/0 or ::/0"
+  type        = bool
+  default     = false
+}
+
+variable "enable_private_endpoints" {
+  description = "Services private subnets reach without NAT: storage (or s3), nosql (or dynamodb), queue, secrets, kms"
+  type        = list(string)
+  default     = []
+  validation {
+    condition     = alltrue([for s in var.enable_private_endpoints : contains(["storage", "s3", "nosql", "dynamodb", "queue", "secrets", "kms"], s)])
+    error_message = "enable_private_endpoints supports: storage, s3, nosql, dynamodb, queue, secrets, kms"
+  }
+  validation {
+    condition     = var.provider_name != "zero" || length(var.enable_private_endpoints) == 0
+    error_message = "ZeroNet has no private endpoints, so enable_private_endpoints must be empty on zero"
+  }
+}
+
+variable "private_endpoint_targets" {
+  description = "Azure only: IDs of the resources private endpoints connect to, by service, e.g. { storage = <storage account ID> }"
+  type        = map(string)
+  default     = {}
+}
+
+variable "enable_flow_logs" {
+  description = "Log the network's traffic: to flow_log_destination on AWS and Azure, to Cloud Logging on GCP"
+  type        = bool
+  default     = false
+  validation {
+    condition     = var.provider_name != "zero" || !var.enable_flow_logs
+    error_message = "ZeroNet has no flow logs, so enable_flow_logs must be false on zero"
+  }
+}
+
+variable "flow_log_destination" {
+  description = "Where flow logs go: an S3 bucket or CloudWatch Logs log group ARN on AWS (e.g. the storage facade's bucket_arn), a storage account ID on Azure (the storage facade's bucket_id). Ignored on GCP."
+  type        = string
+  default     = null
+  validation {
+    condition     = !var.enable_flow_logs || !contains(["aws", "azure"], var.provider_name) || var.flow_log_destination != null
+    error_message = "enable_flow_logs on ${var.provider_name} needs flow_log_destination"
+  }
+  validation {
+    condition     = var.flow_log_destination == null || var.provider_name != "aws" || can(regex("^arn:[a-z-]+:(s3:::|logs:)", var.flow_log_destination))
+    error_message = "flow_log_destination on aws must be an S3 bucket or CloudWatch Logs log group ARN"
+  }
+  validation {
+    condition     = var.flow_log_destination == null || var.provider_name != "azure" || can(regex("(?i)/providers/Microsoft\\.Storage/storageAccounts/[^/]+$", var.flow_log_destination))
+    error_message = "flow_log_destination on azure must be a storage account resource ID"
+  }
+}
+
 variable "provider_config" {
   description = "Provider specific configuration (region, resource_group, etc)"
   type        = map(string)
@@ -1,17 +1,38 @@
 # NoSQL Facade (Unified Interface)
 
-locals {
-  common_tags = merge(
-    var.tags,
+terraform {
+  # check blocks
+  required_version = ">= 1.5"
+}
+
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy   = "Terraform"
-      Environment = var.environment
-      Project     = var.project_name
-      Module      = "NoSQL-Facade"
-    }
+      Module = "NoSQL-Facade"
+    },
+    var.tags
   )
 }
 
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+}
+
 # AWS: DynamoDB
 module "aws_nosql" {
   count  = var.provider_name == "aws" ? 1 : 0
@@ -22,12 +43,13 @@ module "aws_nosql" {
   hash_key_type = var.hash_key_type
   range_key     = var.range_key
   range_key_type = var.range_key_type
+  ttl_attribute  = var.ttl_attribute
   
   billing_mode  = "PAY_PER_REQUEST"
   read_capacity = 0
   write_capacity = 0
 
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # Azure: CosmosDB
@@ -36,12 +58,15 @@ module "azure_nosql" {
   source = "../../azure/core/nosql"
 
   account_name        = replace(lower(var.table_name), "-", "")
-  resource_group_name = "${var.project_name}-${var.environment}-rg"
+  resource_group_name = module.resource_group_name.name
   location            = "East US"
   container_name      = var.table_name
   partition_key_path  = "/${var.hash_key}"
 
-  tags = local.common_tags
+  # Cosmos DB reads each item's TTL from its ttl property
+  default_ttl = var.ttl_attribute != null ? -1 : null
+
+  tags = local.default_tags
 }
 
 # GCP: Firestore
@@ -64,8 +89,25 @@ module "zero_nosql" {
   hash_key_type = var.hash_key_type
   range_key     = var.range_key
   range_key_type = var.range_key_type
+  ttl_attribute  = var.ttl_attribute
+
+  tags = local.default_tags
+}
+
+# TTL is best effort outside DynamoDB and ZeroDB, so say what was dropped
+# instead of failing the plan
+check "ttl_attribute_cosmos" {
+  assert {
+    condition     = var.provider_name != "azure" || var.ttl_attribute == null || var.ttl_attribute == "ttl"
+    error_message = "Cosmos DB only reads item TTLs from the ttl property, as seconds to live, so ttl_attribute \"${coalesce(var.ttl_attribute, "ttl")}\" is ignored; TTL is enabled on the container anyway."
+  }
+}
 
-  tags = local.common_tags
+check "ttl_attribute_firestore" {
+  assert {
+    condition     = var.provider_name != "gcp" || var.ttl_attribute == null
+    error_message = "Firestore TTL policies are set per collection, which this facade does not create, so ttl_attribute is ignored on gcp."
+  }
 }
 
 output "table_id" {
@@ -0,0 +1,67 @@
+package nosql_test
+
+import (
+	"strings"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func nosqlVars(provider string, extra map[string]interface{}) map[string]interface{} {
+	vars := map[string]interface{}{
+		"provider_name": provider,
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"table_name":    "test-table",
+		"hash_key":      "id",
+	}
+	for k, v := range extra {
+		vars[k] = v
+	}
+	return vars
+}
+
+func TestNoSQLFacadeAwsTTL(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars:         nosqlVars("aws", map[string]interface{}{"ttl_attribute": "expires_at"}),
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.aws_nosql[0].aws_dynamodb_table.this"), "Plan should create a DynamoDB table")
+	assert.Regexp(t, `(?s)ttl \{\s+attribute_name\s+= "expires_at"\s+enabled\s+= true`, planString, "Plan should enable TTL on expires_at")
+	assert.NotContains(t, planString, "Check block assertion failed", "DynamoDB honors ttl_attribute, so nothing should warn")
+}
+
+func TestNoSQLFacadeAwsNoTTL(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars:         nosqlVars("aws", nil),
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.NotRegexp(t, `attribute_name\s+= `, planString, "TTL should stay off without ttl_attribute")
+}
+
+func TestNoSQLFacadeAzureTTL(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars:         nosqlVars("azure", map[string]interface{}{"ttl_attribute": "expires_at"}),
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Regexp(t, `default_ttl\s+= -1`, planString, "Cosmos DB should let each item set its own TTL")
+	assert.Contains(t, planString, `ttl_attribute "expires_at" is ignored`, "The plan should warn that Cosmos DB reads ttl instead")
+}
@@ -3,7 +3,7 @@ variable "provider_name" {
   type        = string
   validation {
     condition     = contains(["aws", "azure", "gcp", "zero"], var.provider_name)
-    error_message = "Provider must be one of: aws, azure, gcp, zero"
+    error_message = "provider_name must be one of: aws, azure, gcp, zero"
   }
 }
 
@@ -35,10 +35,24 @@ variable "range_key_type" {
   default     = "S"
 }
 
+variable "ttl_attribute" {
+  description = "Attribute holding each item's expiry as epoch seconds (DynamoDB, ZeroDB); on Cosmos DB it enables per-item TTL, read from the ttl property; ignored on Firestore"
+  type        = string
+  default     = null
+  validation {
+    condition     = var.ttl_attribute == null || try(length(var.ttl_attribute) > 0, false)
+    error_message = "ttl_attribute must be null or a non-empty attribute name, e.g. \"expires_at\""
+  }
+}
+
 variable "environment" {
   description = "Deployment environment"
   type        = string
   default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
@@ -1,17 +1,24 @@
 # Secrets Facade
 
-locals {
-  common_tags = merge(
-    var.tags,
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy   = "Terraform"
-      Environment = var.environment
-      Project     = var.project_name
-      Module      = "Secrets-Facade"
-    }
+      Module = "Secrets-Facade"
+    },
+    var.tags
   )
 }
 
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+}
+
 # AWS: Secrets Manager
 module "aws_secrets" {
   count  = var.provider_name == "aws" ? 1 : 0
@@ -21,7 +28,7 @@ module "aws_secrets" {
   description   = var.description
   secret_string = var.secret_string
   
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # Azure: Key Vault Secret
@@ -32,7 +39,7 @@ module "azure_secrets" {
   name         = var.name
   secret_value = var.secret_string
   key_vault_id = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/vault" # Placeholder
-  tags         = local.common_tags
+  tags         = local.default_tags
 }
 
 # GCP: Secret Manager
@@ -43,7 +50,7 @@ module "gcp_secrets" {
   project_id  = var.project_name
   secret_id   = var.name
   secret_data = var.secret_string
-  labels      = local.common_tags
+  labels      = local.default_labels
 }
 
 output "secret_id" {
@@ -1,6 +1,10 @@
 variable "provider_name" {
   description = "Cloud provider (aws, azure, gcp)"
   type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
 }
 
 variable "name" {
@@ -24,6 +28,10 @@ variable "secret_string" {
 variable "environment" {
   description = "Deployment environment"
   type        = string
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
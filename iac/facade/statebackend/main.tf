@@ -0,0 +1,168 @@
+# State Backend Facade
+# Bootstraps the remote state backend other configurations use, and renders
+# the backend configuration that points them at it
+
+terraform {
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
+
+  required_providers {
+    local = {
+      source  = "hashicorp/local"
+      version = "~> 2.0"
+    }
+  }
+}
+
+# ============================================================================
+# IMPORT COMMON LAYER
+# ============================================================================
+
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
+    {
+      Provider = var.provider_name
+      Module   = "StateBackend-Facade"
+    },
+    var.tags
+  )
+}
+
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "tfstate-rg"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  kms_key_id = var.kms_key_ref != null ? var.kms_key_ref.id : null
+
+  lock_table_name      = coalesce(var.lock_table_name, "${var.bucket_name}-locks")
+  storage_account_name = replace(var.bucket_name, "-", "")
+}
+
+# ============================================================================
+# PROVIDER-SPECIFIC MODULE ROUTING
+# ============================================================================
+
+# AWS: S3 bucket and DynamoDB lock table
+module "aws_statebackend" {
+  count  = var.provider_name == "aws" ? 1 : 0
+  source = "../../aws/core/statebackend"
+
+  bucket_name             = var.bucket_name
+  lock_table_name         = local.lock_table_name
+  kms_key_arn             = local.kms_key_id
+  noncurrent_version_days = var.noncurrent_version_days
+  force_destroy           = var.force_destroy
+
+  tags = local.default_tags
+}
+
+# Azure: storage account and container; the backend leases the state blob
+module "azure_statebackend" {
+  count  = var.provider_name == "azure" ? 1 : 0
+  source = "../../azure/core/statebackend"
+
+  resource_group_name     = lookup(var.provider_config, "resource_group_name", module.resource_group_name.name)
+  location                = lookup(var.provider_config, "location", "eastus")
+  storage_account_name    = local.storage_account_name
+  replication_type        = lookup(var.provider_config, "replication_type", "GRS")
+  delete_retention_days   = var.noncurrent_version_days
+  customer_managed_key_id = local.kms_key_id
+
+  tags = local.default_tags
+}
+
+# GCP: Cloud Storage bucket; the backend writes a lock object beside the state
+module "gcp_statebackend" {
+  count  = var.provider_name == "gcp" ? 1 : 0
+  source = "../../gcp/core/statebackend"
+
+  bucket_name             = var.bucket_name
+  project_id              = lookup(var.provider_config, "project_id", null)
+  location                = lookup(var.provider_config, "location", "US")
+  encryption_key_name     = local.kms_key_id
+  noncurrent_version_days = var.noncurrent_version_days
+  force_destroy           = var.force_destroy
+
+  labels = local.default_labels
+}
+
+# ============================================================================
+# BACKEND CONFIGURATION
+# ============================================================================
+
+locals {
+  # Endpoint of an S3-compatible emulator, written with the settings that
+  # keep the backend from reaching AWS itself
+  aws_endpoint = lookup(var.provider_config, "endpoint", null)
+
+  # Backend settings, in the order they are rendered, with HCL values
+  backend_settings = {
+    aws = var.provider_name != "aws" ? [] : concat(
+      [
+        { name = "bucket", value = jsonencode(module.aws_statebackend[0].bucket_name) },
+        { name = "key", value = jsonencode(var.state_key) },
+        { name = "region", value = jsonencode(module.aws_statebackend[0].region) },
+        { name = "dynamodb_table", value = jsonencode(module.aws_statebackend[0].lock_table_name) },
+        { name = "encrypt", value = "true" },
+      ],
+      local.aws_endpoint == null ? [] : [
+        { name = "endpoints", value = format("{ s3 = %s, dynamodb = %s }", jsonencode(local.aws_endpoint), jsonencode(local.aws_endpoint)) },
+        { name = "use_path_style", value = "true" },
+        { name = "skip_credentials_validation", value = "true" },
+        { name = "skip_requesting_account_id", value = "true" },
+        { name = "skip_metadata_api_check", value = "true" },
+        { name = "skip_region_validation", value = "true" },
+      ],
+    )
+    azure = var.provider_name != "azure" ? [] : [
+      { name = "resource_group_name", value = jsonencode(module.azure_statebackend[0].resource_group_name) },
+      { name = "storage_account_name", value = jsonencode(module.azure_statebackend[0].storage_account_name) },
+      { name = "container_name", value = jsonencode(module.azure_statebackend[0].container_name) },
+      { name = "key", value = jsonencode(var.state_key) },
+    ]
+    # The gcs backend stores <prefix>/<workspace>.tfstate
+    gcp = var.provider_name != "gcp" ? [] : [
+      { name = "bucket", value = jsonencode(module.gcp_statebackend[0].bucket_name) },
+      { name = "prefix", value = jsonencode(trimsuffix(var.state_key, ".tfstate")) },
+    ]
+  }[var.provider_name]
+
+  backend_type = { aws = "s3", azure = "azurerm", gcp = "gcs" }[var.provider_name]
+
+  # Names are padded to the longest, as terraform fmt aligns them
+  name_width = max([for s in local.backend_settings : length(s.name)]...)
+  backend_lines = [
+    for s in local.backend_settings :
+    "${substr("${s.name}${join("", [for i in range(local.name_width) : " "])}", 0, local.name_width)} = ${s.value}"
+  ]
+
+  backend_hcl = join("", [for line in local.backend_lines : "${line}\n"])
+  backend_block = join("", concat(
+    ["terraform {\n", "  backend \"${local.backend_type}\" {\n"],
+    [for line in local.backend_lines : "    ${line}\n"],
+    ["  }\n", "}\n"],
+  ))
+}
+
+# Settings file for terraform init -backend-config=backend.hcl
+resource "local_file" "backend_config" {
+  count = var.write_backend_config ? 1 : 0
+
+  filename        = coalesce(var.backend_config_path, "${path.root}/backend.hcl")
+  content         = local.backend_hcl
+  file_permission = "0644"
+}
@@ -0,0 +1,44 @@
+output "backend_config" {
+  description = "Ready-to-paste terraform block configuring the backend, e.g. terraform { backend \"s3\" { ... } }"
+  value       = local.backend_block
+
+  precondition {
+    condition     = var.kms_key_ref == null || try(var.kms_key_ref.provider == var.provider_name, false)
+    error_message = "kms_key_ref belongs to ${try(var.kms_key_ref.provider, "another provider")} but this module deploys to ${var.provider_name}"
+  }
+}
+
+output "backend_hcl" {
+  description = "The backend settings alone, the contents of backend.hcl, for terraform init -backend-config"
+  value       = local.backend_hcl
+}
+
+output "backend_config_file" {
+  description = "Path of the written backend.hcl; null unless write_backend_config is set"
+  value       = var.write_backend_config ? local_file.backend_config[0].filename : null
+}
+
+output "backend_type" {
+  description = "Backend type the configuration uses (s3, azurerm, gcs)"
+  value       = local.backend_type
+}
+
+output "bucket_name" {
+  description = "State bucket (S3 bucket / storage account / Cloud Storage bucket)"
+  value = (
+    var.provider_name == "aws"   ? (length(module.aws_statebackend) > 0 ? module.aws_statebackend[0].bucket_name : null) :
+    var.provider_name == "azure" ? (length(module.azure_statebackend) > 0 ? module.azure_statebackend[0].storage_account_name : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_statebackend) > 0 ? module.gcp_statebackend[0].bucket_name : null) :
+    null
+  )
+}
+
+output "lock_table_name" {
+  description = "DynamoDB lock table on aws; null on azure and gcp, which lock state in the bucket"
+  value       = length(module.aws_statebackend) > 0 ? module.aws_statebackend[0].lock_table_name : null
+}
+
+output "provider" {
+  description = "Cloud provider"
+  value       = var.provider_name
+}
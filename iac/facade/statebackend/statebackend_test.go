@@ -0,0 +1,209 @@
+package statebackend_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/planerr"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blocks returns a nested block list from planned attribute values
+func blocks(values map[string]interface{}, name string) []map[string]interface{} {
+	var result []map[string]interface{}
+	list, _ := values[name].([]interface{})
+	for _, item := range list {
+		result = append(result, item.(map[string]interface{}))
+	}
+	return result
+}
+
+// planBackend plans the facade with vars and returns the planned resources
+// and the rendered backend configuration
+func planBackend(t *testing.T, vars map[string]interface{}) (*terraform.PlanStruct, string) {
+	t.Helper()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars:         vars,
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	output, ok := plan.RawPlan.PlannedValues.Outputs["backend_config"]
+	require.True(t, ok, "Plan should render backend_config")
+	config, _ := output.Value.(string)
+	return plan, config
+}
+
+func resource(t *testing.T, plan *terraform.PlanStruct, address string) map[string]interface{} {
+	t.Helper()
+
+	r, ok := plan.ResourcePlannedValuesMap[address]
+	require.True(t, ok, "Plan should create %s", address)
+	return r.AttributeValues
+}
+
+func TestStateBackendFacadeAws(t *testing.T) {
+	t.Parallel()
+
+	plan, config := planBackend(t, map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "acme",
+		"bucket_name":   "acme-tfstate",
+		"state_key":     "network/terraform.tfstate",
+	})
+
+	versioning := blocks(resource(t, plan, "module.aws_statebackend[0].aws_s3_bucket_versioning.this"), "versioning_configuration")
+	require.Len(t, versioning, 1)
+	assert.Equal(t, "Enabled", versioning[0]["status"])
+
+	rules := blocks(resource(t, plan, "module.aws_statebackend[0].aws_s3_bucket_server_side_encryption_configuration.this"), "rule")
+	require.Len(t, rules, 1)
+	assert.Equal(t, "AES256", blocks(rules[0], "apply_server_side_encryption_by_default")[0]["sse_algorithm"])
+
+	public := resource(t, plan, "module.aws_statebackend[0].aws_s3_bucket_public_access_block.this")
+	assert.Equal(t, true, public["block_public_acls"])
+	assert.Equal(t, true, public["restrict_public_buckets"])
+
+	lock := resource(t, plan, "module.aws_statebackend[0].aws_dynamodb_table.lock")
+	assert.Equal(t, "acme-tfstate-locks", lock["name"])
+	assert.Equal(t, "LockID", lock["hash_key"], "The s3 backend locks by LockID")
+	assert.Equal(t, "PAY_PER_REQUEST", lock["billing_mode"])
+	assert.Equal(t, true, blocks(lock, "server_side_encryption")[0]["enabled"])
+
+	assert.Contains(t, config, "  backend \"s3\" {\n")
+	assert.Contains(t, config, "    bucket         = \"acme-tfstate\"\n")
+	assert.Contains(t, config, "    key            = \"network/terraform.tfstate\"\n")
+	assert.Regexp(t, `\n    region         = "[a-z]+-[a-z]+-\d"\n`, config)
+	assert.Contains(t, config, "    dynamodb_table = \"acme-tfstate-locks\"\n")
+	assert.Contains(t, config, "    encrypt        = true\n")
+	assert.NotContains(t, config, "endpoints", "Only an emulator endpoint is written out")
+	assert.NotContains(t, plan.ResourcePlannedValuesMap, "local_file.backend_config[0]")
+}
+
+func TestStateBackendFacadeAzure(t *testing.T) {
+	t.Parallel()
+
+	plan, config := planBackend(t, map[string]interface{}{
+		"provider_name": "azure",
+		"project_name":  "acme",
+		"bucket_name":   "acme-tfstate",
+		"provider_config": map[string]interface{}{
+			"resource_group_name": "acme-tfstate-rg",
+			"location":            "westeurope",
+		},
+	})
+
+	account := resource(t, plan, "module.azure_statebackend[0].azurerm_storage_account.this")
+	assert.Equal(t, "acmetfstate", account["name"], "Storage account names have no hyphens")
+	assert.Equal(t, false, account["allow_nested_items_to_be_public"])
+	assert.Equal(t, true, account["infrastructure_encryption_enabled"])
+	assert.Equal(t, true, blocks(account, "blob_properties")[0]["versioning_enabled"])
+
+	container := resource(t, plan, "module.azure_statebackend[0].azurerm_storage_container.this")
+	assert.Equal(t, "tfstate", container["name"])
+	assert.Equal(t, "private", container["container_access_type"])
+
+	assert.Equal(t, ""+
+		"terraform {\n"+
+		"  backend \"azurerm\" {\n"+
+		"    resource_group_name  = \"acme-tfstate-rg\"\n"+
+		"    storage_account_name = \"acmetfstate\"\n"+
+		"    container_name       = \"tfstate\"\n"+
+		"    key                  = \"terraform.tfstate\"\n"+
+		"  }\n"+
+		"}\n", config, "The azurerm backend locks with a blob lease, so there is no lock table")
+}
+
+func TestStateBackendFacadeGcp(t *testing.T) {
+	t.Parallel()
+
+	plan, config := planBackend(t, map[string]interface{}{
+		"provider_name":   "gcp",
+		"project_name":    "acme",
+		"bucket_name":     "acme-tfstate",
+		"state_key":       "network/terraform.tfstate",
+		"provider_config": map[string]interface{}{"project_id": "acme-prod"},
+	})
+
+	bucket := resource(t, plan, "module.gcp_statebackend[0].google_storage_bucket.this")
+	assert.Equal(t, true, blocks(bucket, "versioning")[0]["enabled"])
+	assert.Equal(t, true, bucket["uniform_bucket_level_access"])
+	assert.Equal(t, "enforced", bucket["public_access_prevention"])
+
+	assert.Equal(t, ""+
+		"terraform {\n"+
+		"  backend \"gcs\" {\n"+
+		"    bucket = \"acme-tfstate\"\n"+
+		"    prefix = \"network/terraform\"\n"+
+		"  }\n"+
+		"}\n", config, "The gcs backend stores <prefix>/<workspace>.tfstate")
+}
+
+func TestStateBackendFacadeWritesBackendHcl(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "backend.hcl")
+
+	plan, _ := planBackend(t, map[string]interface{}{
+		"provider_name":        "aws",
+		"project_name":         "acme",
+		"environment":          "local",
+		"bucket_name":          "acme-tfstate",
+		"write_backend_config": true,
+		"backend_config_path":  path,
+		"provider_config":      map[string]interface{}{"endpoint": "http://localhost:4566"},
+	})
+
+	file := resource(t, plan, "local_file.backend_config[0]")
+	assert.Equal(t, path, file["filename"])
+
+	content, _ := file["content"].(string)
+	assert.Contains(t, content, "bucket                      = \"acme-tfstate\"\n", "backend.hcl holds the settings without the terraform block")
+	assert.NotContains(t, content, "terraform {")
+	assert.Contains(t, content, "endpoints                   = { s3 = \"http://localhost:4566\", dynamodb = \"http://localhost:4566\" }\n")
+	assert.Contains(t, content, "use_path_style              = true\n")
+	assert.Contains(t, content, "skip_credentials_validation = true\n")
+}
+
+func TestStateBackendValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "acme",
+		"bucket_name":   "acme-tfstate",
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name:     "LockTableOnAzure",
+			Vars:     map[string]interface{}{"provider_name": "azure", "lock_table_name": "locks"},
+			Want:     "lock_table_name only applies to aws",
+			Variable: "lock_table_name",
+		},
+		{
+			Name:     "StorageAccountTooLong",
+			Vars:     map[string]interface{}{"provider_name": "azure", "bucket_name": "acme-platform-terraform-state"},
+			Want:     "without hyphens it must be at most 24 characters",
+			Variable: "bucket_name",
+		},
+		{
+			Name:     "AbsoluteStateKey",
+			Vars:     map[string]interface{}{"state_key": "/terraform.tfstate"},
+			Want:     "state_key must be a non-empty key without a leading slash",
+			Variable: "state_key",
+		},
+		{
+			Name:     "UpperCaseBucket",
+			Vars:     map[string]interface{}{"bucket_name": "Acme-TFState"},
+			Want:     "bucket_name must be 3-63 lower case letters",
+			Variable: "bucket_name",
+		},
+	})
+}
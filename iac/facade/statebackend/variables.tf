@@ -0,0 +1,114 @@
+# General Configuration
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp)"
+  type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
+}
+
+variable "project_name" {
+  description = "Project name"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+# Backend Configuration
+variable "bucket_name" {
+  description = "Name of the state bucket (S3 bucket / storage account, without hyphens / Cloud Storage bucket)"
+  type        = string
+  validation {
+    condition     = can(regex("^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$", var.bucket_name))
+    error_message = "bucket_name must be 3-63 lower case letters, digits and hyphens, starting and ending with a letter or digit, e.g. acme-tfstate"
+  }
+  validation {
+    # Storage account names are 3-24 letters and digits
+    condition     = var.provider_name != "azure" || length(replace(var.bucket_name, "-", "")) <= 24
+    error_message = "bucket_name names a storage account on azure, so without hyphens it must be at most 24 characters"
+  }
+}
+
+variable "lock_table_name" {
+  description = "Name of the DynamoDB lock table on aws; <bucket_name>-locks when null. Azure and GCP lock state in the bucket itself."
+  type        = string
+  default     = null
+  validation {
+    condition     = var.lock_table_name == null || var.provider_name == "aws"
+    error_message = "lock_table_name only applies to aws; the azurerm and gcs backends lock state in the bucket itself"
+  }
+}
+
+variable "state_key" {
+  description = "Key of the state object in the rendered backend configuration (the gcs prefix on gcp)"
+  type        = string
+  default     = "terraform.tfstate"
+  validation {
+    condition     = length(var.state_key) > 0 && !startswith(var.state_key, "/")
+    error_message = "state_key must be a non-empty key without a leading slash, e.g. network/terraform.tfstate"
+  }
+}
+
+variable "noncurrent_version_days" {
+  description = "Days a replaced state version is kept before it expires (aws, gcp); azure keeps deleted blobs this long, at most 365"
+  type        = number
+  default     = 90
+  validation {
+    condition     = var.noncurrent_version_days >= 1 && var.noncurrent_version_days <= 365
+    error_message = "noncurrent_version_days must be 1-365, e.g. 90"
+  }
+}
+
+variable "force_destroy" {
+  description = "Delete the bucket on destroy even when it holds state; for test backends only"
+  type        = bool
+  default     = false
+}
+
+variable "kms_key_ref" {
+  description = "Customer-managed key from the encryption facade (its kms_key_ref output); must belong to the same provider"
+  type = object({
+    provider = string
+    id       = string
+  })
+  default = null
+  validation {
+    condition     = var.kms_key_ref == null || try(contains(["aws", "azure", "gcp"], var.kms_key_ref.provider) && length(var.kms_key_ref.id) > 0, false)
+    error_message = "kms_key_ref must have provider aws, azure or gcp and a non-empty id, e.g. the encryption facade's kms_key_ref output"
+  }
+}
+
+# Rendered Configuration
+variable "write_backend_config" {
+  description = "Write the backend settings to backend_config_path for terraform init -backend-config"
+  type        = bool
+  default     = false
+}
+
+variable "backend_config_path" {
+  description = "File the backend settings are written to with write_backend_config; backend.hcl in the root module when null"
+  type        = string
+  default     = null
+}
+
+# Provider-specific Configuration
+variable "provider_config" {
+  description = "Provider-specific settings (endpoint for aws, the S3 and DynamoDB endpoint written into the backend settings, e.g. an emulator's; resource_group_name, location, replication_type for azure; project_id, location for gcp)"
+  type        = any
+  default     = {}
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
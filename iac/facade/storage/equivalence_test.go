@@ -0,0 +1,107 @@
+//go:build integration
+
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"iac/testutil/config"
+	"iac/testutil/eventually"
+	"iac/testutil/objectstore"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+// emulators are the emulators the providers' buckets are deployed to
+var emulators = map[string]tfopts.Emulator{
+	"aws":   tfopts.CloudEmu,
+	"azure": tfopts.CloudEmuAzure,
+	"gcp":   tfopts.CloudEmuGCP,
+}
+
+// equivalenceVars returns the variables of testdata/equivalence/<provider>
+func equivalenceVars(cfg *config.TestConfig, provider, bucket string) map[string]interface{} {
+	vars := map[string]interface{}{"bucket_name": bucket}
+	switch provider {
+	case "aws":
+		vars["cloudemu_endpoint"] = cfg.CloudEmuEndpoint
+		vars["aws_region"] = cfg.Region
+	case "azure":
+		vars["azure_endpoint"] = cfg.AzureEndpoint
+	case "gcp":
+		vars["gcp_endpoint"] = cfg.GCPEndpoint
+	}
+	return vars
+}
+
+// TestStorageDataPlaneEquivalence deploys the facade to CloudEmu S3, Blob
+// Storage and Cloud Storage and runs the same scenario against each through
+// objectstore, failing any provider that diverges on put, get, list by
+// prefix, delete or the metadata round-trip. Providers whose emulator is not
+// running are skipped and shown as "-" in the logged capability report.
+func TestStorageDataPlaneEquivalence(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Load(t)
+
+	var mu sync.Mutex
+	results := make(map[string][]objectstore.Result)
+
+	// Returns once every provider subtest has finished
+	t.Run("providers", func(t *testing.T) {
+		for _, provider := range objectstore.Providers {
+			t.Run(provider, func(t *testing.T) {
+				t.Parallel()
+
+				mu.Lock()
+				results[provider] = nil
+				mu.Unlock()
+
+				objectstore.SkipUnlessRunning(t, cfg, provider)
+
+				// Short enough for an Azure storage account name (24
+				// characters once the facade strips the hyphens)
+				bucket := fmt.Sprintf("equiv-%s-%d", provider, time.Now().Unix())
+
+				terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+					TerraformDir: filepath.Join("testdata", "equivalence", provider),
+					Vars:         equivalenceVars(cfg, provider, bucket),
+					NoColor:      true,
+				}, emulators[provider])
+
+				defer tfopts.Destroy(t, terraformOptions)
+				tfopts.InitAndApply(t, terraformOptions)
+
+				ctx := context.Background()
+				store, err := objectstore.Open(ctx, cfg, provider, terraform.Output(t, terraformOptions, "bucket_name"))
+				require.NoError(t, err)
+				defer store.Close()
+
+				// The emulator may not serve the bucket the moment Terraform
+				// reports it
+				eventually.Eventually(t, eventually.DefaultTimeout, eventually.DefaultInterval, func() error {
+					_, err := store.List(ctx, "")
+					return err
+				})
+
+				checked := objectstore.Check(ctx, store, "equivalence/")
+				mu.Lock()
+				results[provider] = checked
+				mu.Unlock()
+
+				for _, r := range objectstore.Failed(checked) {
+					t.Errorf("%s diverges on %s: %v", provider, r.Capability, r.Err)
+				}
+			})
+		}
+	})
+
+	t.Logf("Storage data-plane capabilities:\n%s", objectstore.Report(results))
+}
@@ -2,14 +2,67 @@
 # Facade Layer - Public interface for storage resources
 
 terraform {
-  required_version = ">= 1.0"
+  # Validations that refer to other variables
+  required_version = ">= 1.9"
+
+  # Declared so callers can pass an aliased configuration, e.g. one aws
+  # provider per region, with the providers argument
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
 }
 
 # ============================================================================
 # IMPORT COMMON LAYER
 # ============================================================================
 
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
+    {
+      Provider     = var.provider_name
+      Architecture = "SEA"
+    },
+    var.tags
+  )
+}
+
+# Names by the project_name-environment-resource convention, made legal
+# for each provider's resource type
+module "bucket_name" {
+  source = "../../common/naming"
+
+  resource_type = var.provider_name == "gcp" ? "gcp_storage_bucket" : var.provider_name == "azure" ? "azure_storage_container" : "aws_s3_bucket"
+  project_name  = var.bucket_name == null ? var.project_name : null
+  environment   = var.bucket_name == null ? var.environment : null
+  name          = coalesce(var.bucket_name, "bucket")
+}
+
+module "storage_account_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_storage_account"
+  name          = module.bucket_name.name
+}
+
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
 locals {
+  bucket_name = module.bucket_name.name
+
   # Import storage class mappings
   storage_class_mapping = {
     aws = {
@@ -38,17 +91,34 @@ locals {
     }
   }
 
-  # Build common tags
-  common_tags = merge(
-    var.tags,
-    {
-      ManagedBy    = "Terraform"
-      Environment  = var.environment
-      Provider     = var.provider_name
-      Project      = var.project_name
-      Architecture = "SEA"
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+
+  # kms_key_ref (shared contract) takes precedence over the legacy encryption_key_id
+  kms_key_id = var.kms_key_ref != null ? var.kms_key_ref.id : var.encryption_key_id
+
+  # allow_public_access, or the negation of the deprecated public_access_block
+  allow_public_access = var.public_access_block != null ? !var.public_access_block : var.allow_public_access == true
+
+  # Notification event names on each provider
+  notification_events = {
+    aws = {
+      created = "s3:ObjectCreated:*"
+      deleted = "s3:ObjectRemoved:*"
     }
-  )
+    azure = {
+      created = "Microsoft.Storage.BlobCreated"
+      deleted = "Microsoft.Storage.BlobDeleted"
+    }
+    gcp = {
+      created = "OBJECT_FINALIZE"
+      deleted = "OBJECT_DELETE"
+    }
+  }
+  notifications = [for n in var.notifications : merge(n, {
+    events = [for e in n.events : local.notification_events[var.provider_name][e]]
+  })]
 }
 
 # ============================================================================
@@ -60,12 +130,20 @@ module "aws_storage" {
   count  = var.provider_name == "aws" ? 1 : 0
   source = "../../aws/core/storage"
   
-  bucket_name         = var.bucket_name
+  bucket_name         = local.bucket_name
   versioning_enabled  = var.versioning_enabled
   encryption_enabled  = var.encryption_enabled
-  encryption_key_id   = var.encryption_key_id
-  public_access_block = var.public_access_block
-  tags                = local.common_tags
+  encryption_key_id   = local.kms_key_id
+  public_access_block = !local.allow_public_access
+  tags                = local.default_tags
+
+  notifications = [for n in local.notifications : {
+    events        = n.events
+    filter_prefix = n.filter_prefix
+    filter_suffix = n.filter_suffix
+    target_type   = n.target_ref.type
+    target_arn    = n.target_ref.id
+  }]
 }
 
 # Route to Azure storage module  
@@ -73,14 +151,28 @@ module "azure_storage" {
   count  = var.provider_name == "azure" ? 1 : 0
   source = "../../azure/core/storage"
   
-  storage_account_name = replace(lower(var.bucket_name), "-", "") # Azure requires alphanumeric
-  resource_group_name  = "${var.project_name}-${var.environment}-rg"
-  location             = "East US"
-  versioning_enabled   = var.versioning_enabled
-  block_public_access  = var.public_access_block
-  create_container     = true
-  container_name       = var.bucket_name
-  tags                 = local.common_tags
+  storage_account_name    = module.storage_account_name.name # Azure requires 3-24 lowercase alphanumerics
+  resource_group_name     = module.resource_group_name.name
+  location                = "East US"
+  versioning_enabled      = var.versioning_enabled
+  block_public_access     = !local.allow_public_access
+  create_container        = true
+  container_name          = local.bucket_name
+  customer_managed_key_id = local.kms_key_id
+  tags                    = local.default_tags
+
+  static_website = var.website != null ? {
+    index_document     = var.website.index_document
+    error_404_document = var.website.error_document
+  } : null
+
+  notifications = [for n in local.notifications : {
+    events        = n.events
+    filter_prefix = n.filter_prefix
+    filter_suffix = n.filter_suffix
+    target_type   = n.target_ref.type
+    target_id     = n.target_ref.id
+  }]
 }
 
 # Route to GCP storage module
@@ -88,11 +180,26 @@ module "gcp_storage" {
   count  = var.provider_name == "gcp" ? 1 : 0
   source = "../../gcp/core/storage"
   
-  bucket_name         = var.bucket_name
+  bucket_name         = local.bucket_name
   versioning_enabled  = var.versioning_enabled
-  project_id          = var.project_name
+  encryption_key_name = local.kms_key_id
+  project_id          = try(var.provider_config.project_id, var.project_name)
   location            = "US"
-  tags                = local.common_tags
+  labels              = local.default_labels
+
+  # Cloud CDN backend buckets only serve publicly readable objects
+  block_public_access = !local.allow_public_access && var.website == null
+  public_read         = var.website != null
+  website = var.website != null ? {
+    main_page_suffix = var.website.index_document
+    not_found_page   = var.website.error_document
+  } : null
+
+  notifications = [for n in local.notifications : {
+    events        = n.events
+    filter_prefix = n.filter_prefix
+    topic         = n.target_ref.id
+  }]
 }
 
 # Route to ZeroCloud storage module  
@@ -100,9 +207,17 @@ module "zero_storage" {
   count  = var.provider_name == "zero" ? 1 : 0
   source = "../../zero/core/storage"
   
-  bucket_name         = var.bucket_name
+  bucket_name         = local.bucket_name
   versioning_enabled  = var.versioning_enabled
-  tags                = local.common_tags
+  tags                = local.default_tags
+}
+
+# public_access_block still plans until it is removed, with a warning to move off it
+check "public_access_block_deprecated" {
+  assert {
+    condition     = var.public_access_block == null
+    error_message = "public_access_block is deprecated: set allow_public_access instead, which is its negation. public_access_block is removed in 2.0.0."
+  }
 }
 
 # Aggregated outputs (select based on provider)
@@ -150,7 +265,7 @@ output "bucket" {
     # Identification
     id   = local.bucket_id
     arn  = local.bucket_arn
-    name = var.bucket_name
+    name = local.bucket_name
     
     # Access
     url    = local.bucket_url
@@ -165,7 +280,7 @@ output "bucket" {
     provider = var.provider_name
     
     # Metadata
-    tags = local.common_tags
+    tags = local.default_tags
   }
 }
 
@@ -173,6 +288,16 @@ output "bucket" {
 output "bucket_id" {
   description = "Bucket ID for reference in other resources"
   value       = local.bucket_id
+
+  precondition {
+    condition     = var.kms_key_ref == null || try(var.kms_key_ref.provider == var.provider_name, false)
+    error_message = "kms_key_ref belongs to ${try(var.kms_key_ref.provider, "another provider")} but this module deploys to ${var.provider_name}"
+  }
+
+  precondition {
+    condition     = alltrue([for n in var.notifications : n.target_ref.provider == var.provider_name])
+    error_message = "Every notifications target_ref must belong to ${var.provider_name}, where this module deploys"
+  }
 }
 
 output "bucket_url" {
@@ -185,6 +310,48 @@ output "bucket_arn" {
   value       = local.bucket_arn
 }
 
+# Origin reference accepted by the cdn facade's origin_ref input: the bucket
+# behind CloudFront origin access control on AWS, the static website host on
+# Azure and the bucket of a Cloud CDN backend bucket on GCP.
+output "origin_ref" {
+  description = "CDN origin reference ({provider, bucket_name, id, domain_name, index_document, error_document}) for the cdn facade; set website first"
+  value = {
+    provider    = var.provider_name
+    bucket_name = var.provider_name == "azure" ? (length(module.azure_storage) > 0 ? module.azure_storage[0].storage_account_name : null) : local.bucket_name
+    id = (
+      var.provider_name == "aws" ? (length(module.aws_storage) > 0 ? module.aws_storage[0].bucket_arn : null) :
+      var.provider_name == "azure" ? (length(module.azure_storage) > 0 ? module.azure_storage[0].storage_account_id : null) :
+      var.provider_name == "gcp" ? (length(module.gcp_storage) > 0 ? module.gcp_storage[0].bucket_name : null) :
+      null
+    )
+    domain_name = (
+      var.provider_name == "aws" ? (length(module.aws_storage) > 0 ? module.aws_storage[0].bucket_regional_domain_name : null) :
+      var.provider_name == "azure" ? (length(module.azure_storage) > 0 ? module.azure_storage[0].primary_web_host : null) :
+      null
+    )
+    index_document = try(var.website.index_document, null)
+    error_document = try(var.website.error_document, null)
+  }
+}
+
+# Backup reference accepted by the backup facade's resources input. The id is
+# what each provider's backup service protects: the bucket ARN on AWS, the
+# storage account ID on Azure and the bucket name on GCP.
+output "backup_ref" {
+  description = "Backup reference ({provider, type, id}) for the backup facade"
+  value = {
+    provider = var.provider_name
+    type     = lookup({ aws = "s3", azure = "blob", gcp = "gcs", zero = "zero_bucket" }, var.provider_name, null)
+    id = (
+      var.provider_name == "aws" ? (length(module.aws_storage) > 0 ? module.aws_storage[0].bucket_arn : null) :
+      var.provider_name == "azure" ? (length(module.azure_storage) > 0 ? module.azure_storage[0].storage_account_id : null) :
+      var.provider_name == "gcp" ? (length(module.gcp_storage) > 0 ? module.gcp_storage[0].bucket_name : null) :
+      var.provider_name == "zero" ? (length(module.zero_storage) > 0 ? module.zero_storage[0].bucket_arn : null) :
+      null
+    )
+  }
+}
+
 # ============================================================================
 # USAGE EXAMPLE (in comments for reference)
 # ============================================================================
@@ -207,7 +374,7 @@ module "data_bucket" {
   storage_class       = "standard"
   versioning_enabled  = true
   encryption_enabled  = true
-  public_access_block = true
+  allow_public_access = false
   
   # Lifecycle
   lifecycle_rules = [{
@@ -1,11 +1,19 @@
 package storage_test
 
 import (
-	"testing"
+	"path/filepath"
 	"strings"
+	"testing"
+
+	"iac/testutil/naming"
+	"iac/testutil/planerr"
+	"iac/testutil/snapshot"
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestStorageFacadeAws verifies the Storage Facade creates an S3 bucket
@@ -13,36 +21,36 @@ func TestStorageFacadeAws(t *testing.T) {
 	t.Parallel()
 
 	// 1. Configure Terraform options
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		// Path to the Terraform module we want to test.
-        // Since the test is now colocated, we use the current directory.
+		// Since the test is now colocated, we use the current directory.
 		TerraformDir: ".",
 
 		// Variables to pass to our module using -var options
 		Vars: map[string]interface{}{
-			"provider":      "aws",
+			"provider_name": "aws",
 			"project_name":  "testproject",
-			"environment":   "test",
+			"environment":   "dev",
 			"bucket_name":   "unit-test-bucket",
-			"storage_class": "STANDARD",
+			"storage_class": "standard",
 		},
-		
+
 		// Disable backend to avoid remote state locking during tests
 		BackendConfig: map[string]interface{}{},
-	})
+	}))
 
 	// 2. Defer destroy (cleanup) - though for Unit Tests we might skip 'apply'
 	// cleanup is only needed if we actually provision resources.
-	// defer terraform.Destroy(t, terraformOptions)
+	// defer tfopts.Destroy(t, terraformOptions)
 
 	// 3. Run 'terraform init' and 'terraform plan'
 	// We use Plan (not Apply) for Unit Testing to avoid costs/cloud deps.
 	planString := terraform.InitAndPlan(t, terraformOptions)
-	
+
 	// 4. Validate the Plan Outcome
 	// We expect 1 resource to be added (the S3 bucket)
 	// Output looks like: "Plan: 1 to add, 0 to change, 0 to destroy."
-	
+
 	// Check that we are creating the correct resource
 	assert.True(t, strings.Contains(planString, "module.aws_storage[0].aws_s3_bucket.this"), "Plan should create an AWS S3 bucket")
 	assert.True(t, strings.Contains(planString, "bucket = \"unit-test-bucket\""), "Plan should have the correct bucket name")
@@ -53,19 +61,19 @@ func TestStorageFacadeAws(t *testing.T) {
 func TestStorageFacadeAzure(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "azure",
+			"provider_name": "azure",
 			"project_name":  "testproject",
-			"environment":   "test",
-			"bucket_name":   "unittestbucket", 
+			"environment":   "dev",
+			"bucket_name":   "unittestbucket",
 			"provider_config": map[string]interface{}{
 				"resource_group_name": "test-rg",
-				"location": "eastus",
+				"location":            "eastus",
 			},
 		},
-	})
+	}))
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
 
@@ -78,19 +86,19 @@ func TestStorageFacadeAzure(t *testing.T) {
 func TestStorageFacadeGcp(t *testing.T) {
 	t.Parallel()
 
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "gcp",
+			"provider_name": "gcp",
 			"project_name":  "testproject",
-			"environment":   "test",
+			"environment":   "dev",
 			"bucket_name":   "unit-test-bucket",
 			"provider_config": map[string]interface{}{
 				"project_id": "test-project",
 				"location":   "US",
 			},
 		},
-	})
+	}))
 
 	planString := terraform.InitAndPlan(t, terraformOptions)
 
@@ -98,22 +106,509 @@ func TestStorageFacadeGcp(t *testing.T) {
 	assert.True(t, strings.Contains(planString, "name = \"unit-test-bucket\""), "Plan should have the correct bucket name")
 }
 
-// TestStorageFacadeInvalidName verifies that invalid bucket names are caught
-func TestStorageFacadeInvalidName(t *testing.T) {
+// TestStorageFacadeZero verifies the ZeroCloud branch creates a ZeroStore bucket
+func TestStorageFacadeZero(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":      "zero",
+			"project_name":       "testproject",
+			"environment":        "dev",
+			"bucket_name":        "unit-test-bucket",
+			"versioning_enabled": true,
+		},
+	}))
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.True(t, strings.Contains(planString, "module.zero_storage[0].aws_s3_bucket.this"), "Plan should create a ZeroStore bucket")
+	assert.True(t, strings.Contains(planString, "module.zero_storage[0].aws_s3_bucket_versioning.this[0]"), "Plan should enable versioning on the bucket")
+	assert.True(t, strings.Contains(planString, "bucket_url = \"http://localhost:8080/v1/store/buckets/unit-test-bucket\""), "Plan should expose the ZeroStore bucket URL")
+}
+
+// TestStorageFacadeBlocksPublicAccessByDefault verifies each provider's
+// bucket is kept private unless allow_public_access is set
+func TestStorageFacadeBlocksPublicAccessByDefault(t *testing.T) {
+	t.Parallel()
+
+	providers := map[string]struct {
+		vars  map[string]interface{}
+		wants []string
+	}{
+		"aws": {
+			wants: []string{
+				`module.aws_storage\[0\].aws_s3_bucket_public_access_block.this\[0\]`,
+				`block_public_acls\s+= true`,
+				`block_public_policy\s+= true`,
+				`ignore_public_acls\s+= true`,
+				`restrict_public_buckets\s+= true`,
+			},
+		},
+		"azure": {
+			vars:  map[string]interface{}{"bucket_name": "unittestbucket"},
+			wants: []string{`allow_nested_items_to_be_public\s+= false`},
+		},
+		"gcp": {
+			vars: map[string]interface{}{
+				"provider_config": map[string]interface{}{"project_id": "test-project"},
+			},
+			wants: []string{`public_access_prevention\s+= "enforced"`},
+		},
+	}
+
+	for provider, tc := range providers {
+		t.Run(provider, func(t *testing.T) {
+			t.Parallel()
+
+			vars := map[string]interface{}{
+				"provider_name": provider,
+				"project_name":  "testproject",
+				"bucket_name":   "unit-test-bucket",
+			}
+			for k, v := range tc.vars {
+				vars[k] = v
+			}
+
+			planString := terraform.InitAndPlan(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+				TerraformDir: ".",
+				Vars:         vars,
+			}))
+
+			for _, want := range tc.wants {
+				assert.Regexp(t, want, planString, "Public access should be blocked by default on %s", provider)
+			}
+		})
+	}
+}
+
+// TestStorageFacadeAllowPublicAccess verifies allow_public_access drops the
+// S3 public access block and GCS public access prevention
+func TestStorageFacadeAllowPublicAccess(t *testing.T) {
+	t.Parallel()
+
+	planString := terraform.InitAndPlan(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":       "aws",
+			"project_name":        "testproject",
+			"bucket_name":         "unit-test-bucket",
+			"allow_public_access": true,
+		},
+	}))
+	assert.NotContains(t, planString, "aws_s3_bucket_public_access_block", "allow_public_access should drop the S3 public access block")
+
+	planString = terraform.InitAndPlan(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":       "gcp",
+			"project_name":        "testproject",
+			"bucket_name":         "unit-test-bucket",
+			"allow_public_access": true,
+			"provider_config":     map[string]interface{}{"project_id": "test-project"},
+		},
+	}))
+	assert.Regexp(t, `public_access_prevention\s+= "inherited"`, planString, "allow_public_access should leave GCS public access prevention to the organization policy")
+}
+
+// awsNotifications sends created objects under uploads/ ending .csv to a
+// function, deleted objects to a queue and created reports to a topic
+var awsNotifications = []map[string]interface{}{
+	{
+		"events":        []string{"created"},
+		"filter_prefix": "uploads/",
+		"filter_suffix": ".csv",
+		"target_ref": map[string]interface{}{
+			"provider": "aws",
+			"type":     "function",
+			"id":       "arn:aws:lambda:us-east-1:123456789012:function:ingest",
+		},
+	},
+	{
+		"events": []string{"deleted"},
+		"target_ref": map[string]interface{}{
+			"provider": "aws",
+			"type":     "queue",
+			"id":       "arn:aws:sqs:us-east-1:123456789012:deletions",
+		},
+	},
+	{
+		"events":        []string{"created", "deleted"},
+		"filter_prefix": "reports/",
+		"target_ref": map[string]interface{}{
+			"provider": "aws",
+			"type":     "topic",
+			"id":       "arn:aws:sns:us-east-1:123456789012:reports",
+		},
+	},
+}
+
+// TestStorageFacadeAwsNotifications verifies function, queue and topic
+// targets share the bucket's one aws_s3_bucket_notification: S3 keeps a
+// single configuration per bucket, so a second resource would replace the
+// first
+func TestStorageFacadeAwsNotifications(t *testing.T) {
 	t.Parallel()
 
-	// Use an invalid name (contains spaces and uppercase)
-	terraformOptions := &terraform.Options{
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
 		TerraformDir: ".",
 		Vars: map[string]interface{}{
-			"provider":      "aws",
+			"provider_name": "aws",
 			"project_name":  "testproject",
-			"environment":   "test",
-			"bucket_name":   "INVALID BUCKET NAME",
+			"bucket_name":   "unit-test-bucket",
+			"notifications": awsNotifications,
 		},
+	}))
+
+	var configurations []string
+	for address, resource := range plan.ResourcePlannedValuesMap {
+		if resource.Type == "aws_s3_bucket_notification" {
+			configurations = append(configurations, address)
+		}
 	}
+	require.Equal(t, []string{"module.aws_storage[0].aws_s3_bucket_notification.this[0]"}, configurations, "Every target should be in one notification configuration")
+
+	notification := plan.ResourcePlannedValuesMap[configurations[0]].AttributeValues
+	block := func(name string) map[string]interface{} {
+		blocks, _ := notification[name].([]interface{})
+		require.Len(t, blocks, 1, "The configuration should have one %s target", name)
+		return blocks[0].(map[string]interface{})
+	}
+
+	function := block("lambda_function")
+	assert.Equal(t, "arn:aws:lambda:us-east-1:123456789012:function:ingest", function["lambda_function_arn"])
+	assert.Equal(t, []interface{}{"s3:ObjectCreated:*"}, function["events"])
+	assert.Equal(t, "uploads/", function["filter_prefix"])
+	assert.Equal(t, ".csv", function["filter_suffix"])
+
+	queue := block("queue")
+	assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:deletions", queue["queue_arn"])
+	assert.Equal(t, []interface{}{"s3:ObjectRemoved:*"}, queue["events"])
+
+	topic := block("topic")
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:reports", topic["topic_arn"])
+	assert.ElementsMatch(t, []interface{}{"s3:ObjectCreated:*", "s3:ObjectRemoved:*"}, topic["events"])
+	assert.Equal(t, "reports/", topic["filter_prefix"])
+
+	permission, ok := plan.ResourcePlannedValuesMap["module.aws_storage[0].aws_lambda_permission.notifications[0]"]
+	require.True(t, ok, "S3 should be allowed to invoke the function")
+	assert.Equal(t, "s3.amazonaws.com", permission.AttributeValues["principal"])
+	assert.Equal(t, "arn:aws:lambda:us-east-1:123456789012:function:ingest", permission.AttributeValues["function_name"])
+}
+
+// TestStorageFacadeAzureNotifications verifies blob events go through one
+// Event Grid system topic on the account, with a subscription per target
+// filtered to the bucket's container
+func TestStorageFacadeAzureNotifications(t *testing.T) {
+	t.Parallel()
+
+	queueID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.ServiceBus/namespaces/ns/queues/uploads"
+	functionID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Web/sites/ingest/functions/ingest"
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name": "azure",
+			"project_name":  "testproject",
+			"bucket_name":   "unittestbucket",
+			"notifications": []map[string]interface{}{
+				{
+					"events":        []string{"created"},
+					"filter_prefix": "uploads/",
+					"filter_suffix": ".csv",
+					"target_ref":    map[string]interface{}{"provider": "azure", "type": "queue", "id": queueID},
+				},
+				{
+					"events":     []string{"deleted"},
+					"target_ref": map[string]interface{}{"provider": "azure", "type": "function", "id": functionID},
+				},
+			},
+		},
+	}))
+
+	topic, ok := plan.ResourcePlannedValuesMap["module.azure_storage[0].azurerm_eventgrid_system_topic.this[0]"]
+	require.True(t, ok, "Plan should create the account's Event Grid system topic")
+	assert.Equal(t, "Microsoft.Storage.StorageAccounts", topic.AttributeValues["topic_type"])
+
+	queue := plan.ResourcePlannedValuesMap["module.azure_storage[0].azurerm_eventgrid_system_topic_event_subscription.this[0]"]
+	require.NotNil(t, queue, "Plan should subscribe the queue")
+	assert.Equal(t, queueID, queue.AttributeValues["service_bus_queue_endpoint_id"])
+	assert.Equal(t, []interface{}{"Microsoft.Storage.BlobCreated"}, queue.AttributeValues["included_event_types"])
+	filter := queue.AttributeValues["subject_filter"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "/blobServices/default/containers/unittestbucket/blobs/uploads/", filter["subject_begins_with"])
+	assert.Equal(t, ".csv", filter["subject_ends_with"])
 
-	// We expect the plan to fail due to variable validation
-	_, err := terraform.InitAndPlanE(t, terraformOptions)
-	assert.Error(t, err, "Plan should fail with an invalid bucket name")
+	function := plan.ResourcePlannedValuesMap["module.azure_storage[0].azurerm_eventgrid_system_topic_event_subscription.this[1]"]
+	require.NotNil(t, function, "Plan should subscribe the function")
+	assert.Equal(t, []interface{}{"Microsoft.Storage.BlobDeleted"}, function.AttributeValues["included_event_types"])
+	endpoint := function.AttributeValues["azure_function_endpoint"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, functionID, endpoint["function_id"])
+	filter = function.AttributeValues["subject_filter"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "/blobServices/default/containers/unittestbucket/blobs/", filter["subject_begins_with"], "A notification without a prefix should still only see the bucket's container")
+}
+
+// TestStorageFacadeGcpNotifications verifies a notification publishes to
+// its topic and grants the storage service agent publisher on it, without
+// which Cloud Storage cannot deliver
+func TestStorageFacadeGcpNotifications(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":   "gcp",
+			"project_name":    "testproject",
+			"bucket_name":     "unit-test-bucket",
+			"provider_config": map[string]interface{}{"project_id": "test-project"},
+			"notifications": []map[string]interface{}{
+				{
+					"events":        []string{"created", "deleted"},
+					"filter_prefix": "uploads/",
+					"target_ref":    map[string]interface{}{"provider": "gcp", "type": "topic", "id": "projects/test-project/topics/uploads"},
+				},
+			},
+		},
+	}))
+
+	notification, ok := plan.ResourcePlannedValuesMap["module.gcp_storage[0].google_storage_notification.this[0]"]
+	require.True(t, ok, "Plan should create the bucket notification")
+	assert.Equal(t, "projects/test-project/topics/uploads", notification.AttributeValues["topic"])
+	assert.Equal(t, "JSON_API_V1", notification.AttributeValues["payload_format"])
+	assert.Equal(t, "uploads/", notification.AttributeValues["object_name_prefix"])
+	assert.ElementsMatch(t, []interface{}{"OBJECT_FINALIZE", "OBJECT_DELETE"}, notification.AttributeValues["event_types"])
+
+	binding, ok := plan.ResourcePlannedValuesMap["module.gcp_storage[0].google_pubsub_topic_iam_member.notifications[0]"]
+	require.True(t, ok, "Plan should let the storage service agent publish to the topic")
+	assert.Equal(t, "projects/test-project/topics/uploads", binding.AttributeValues["topic"])
+	assert.Equal(t, "roles/pubsub.publisher", binding.AttributeValues["role"])
+}
+
+// TestStorageFacadePlanSnapshot compares the whole normalized plan for each
+// provider against testdata/plan-<provider>.golden.json, with Terraform and
+// OpenTofu in turn when both are installed
+// longProjectName is 40 characters, too long for a storage account name
+// built from it, which stopped plans that passed at apply
+const longProjectName = "northwind-traders-payments-reconciler-01"
+
+// TestStorageFacadeLongProjectName verifies the names planned for a long
+// project_name are legal on each provider, whether bucket_name is left to
+// the convention or built by the caller from project_name
+func TestStorageFacadeLongProjectName(t *testing.T) {
+	t.Parallel()
+
+	rules, err := naming.Load(filepath.Join("..", "..", naming.RulesFile))
+	require.NoError(t, err)
+
+	// Address of each provider's resources, and the naming.json type of
+	// their names
+	names := map[string]map[string]string{
+		"aws": {"module.aws_storage[0].aws_s3_bucket.this": "aws_s3_bucket"},
+		"gcp": {"module.gcp_storage[0].google_storage_bucket.this": "gcp_storage_bucket"},
+		"azure": {
+			"module.azure_storage[0].azurerm_storage_account.this":   "azure_storage_account",
+			"module.azure_storage[0].azurerm_storage_container.this": "azure_storage_container",
+		},
+	}
+	bucketNames := map[string]interface{}{
+		"convention": nil,
+		"caller":     longProjectName + "-dev-assets",
+	}
+
+	for provider, resources := range names {
+		for source, bucketName := range bucketNames {
+			t.Run(provider+"/"+source, func(t *testing.T) {
+				t.Parallel()
+
+				plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+					TerraformDir: ".",
+					Vars: map[string]interface{}{
+						"provider_name":   provider,
+						"project_name":    longProjectName,
+						"environment":     "dev",
+						"bucket_name":     bucketName,
+						"provider_config": map[string]interface{}{"project_id": "test-project"},
+					},
+				}))
+
+				for address, resourceType := range resources {
+					resource, ok := plan.ResourcePlannedValuesMap[address]
+					require.True(t, ok, "Plan should create %s", address)
+					name, _ := resource.AttributeValues["name"].(string)
+					if resourceType == "aws_s3_bucket" {
+						name, _ = resource.AttributeValues["bucket"].(string)
+					}
+					assert.NoError(t, rules[resourceType].Check(name), "%s should have a legal %s name", address, resourceType)
+				}
+			})
+		}
+	}
+}
+
+func TestStorageFacadePlanSnapshot(t *testing.T) {
+	t.Parallel()
+
+	providers := map[string]map[string]interface{}{
+		"aws": {
+			"storage_class": "standard",
+		},
+		"azure": {
+			"provider_config": map[string]interface{}{
+				"resource_group_name": "test-rg",
+				"location":            "eastus",
+			},
+		},
+		"gcp": {
+			"provider_config": map[string]interface{}{
+				"project_id": "test-project",
+				"location":   "US",
+			},
+		},
+	}
+
+	for provider, extra := range providers {
+		vars := map[string]interface{}{
+			"provider_name": provider,
+			"project_name":  "testproject",
+			"environment":   "dev",
+			"bucket_name":   "unittestbucket",
+		}
+		for k, v := range extra {
+			vars[k] = v
+		}
+
+		t.Run(provider, func(t *testing.T) {
+			tfopts.Matrix(t, func(t *testing.T, binary string) {
+				terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+					TerraformDir:    ".",
+					TerraformBinary: binary,
+					Vars:            vars,
+				}))
+
+				snapshot.AssertPlan(t, terraformOptions, "plan-"+provider)
+			})
+		})
+	}
+}
+
+// notificationsWith returns one notification of created objects to an SQS
+// queue, with override's fields replacing its own
+func notificationsWith(override map[string]interface{}) []map[string]interface{} {
+	notification := map[string]interface{}{
+		"events":     []string{"created"},
+		"target_ref": map[string]interface{}{"provider": "aws", "type": "queue", "id": "arn:aws:sqs:us-east-1:123456789012:uploads"},
+	}
+	for k, v := range override {
+		notification[k] = v
+	}
+	return []map[string]interface{}{notification}
+}
+
+// TestFacadeValidationMatrix verifies each invalid input is caught by the
+// variable validation meant for it
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"bucket_name":   "test-bucket",
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name:     "SpacesAndUppercase",
+			Vars:     map[string]interface{}{"bucket_name": "INVALID BUCKET NAME"},
+			Want:     "Bucket name must be lowercase alphanumeric with hyphens",
+			Variable: "bucket_name",
+		},
+		{
+			// GCS and S3 both reject uppercase bucket names
+			Name:     "UppercaseGcsName",
+			Vars:     map[string]interface{}{"provider_name": "gcp", "bucket_name": "MyBucket"},
+			Want:     "Bucket name must be lowercase alphanumeric with hyphens",
+			Variable: "bucket_name",
+		},
+		{
+			Name:     "NameLongerThan63",
+			Vars:     map[string]interface{}{"bucket_name": strings.Repeat("a", 64)},
+			Want:     "Bucket name must be 3-63 characters long",
+			Variable: "bucket_name",
+		},
+		{
+			Name:     "NameShorterThan3",
+			Vars:     map[string]interface{}{"bucket_name": "ab"},
+			Want:     "Bucket name must be 3-63 characters long",
+			Variable: "bucket_name",
+		},
+		{
+			Name:     "UnknownStorageClass",
+			Vars:     map[string]interface{}{"storage_class": "glacier"},
+			Want:     "Storage class must be one of: standard, infrequent, archive, cold",
+			Variable: "storage_class",
+		},
+		{
+			Name:     "KmsKeyRefEmptyID",
+			Vars:     map[string]interface{}{"kms_key_ref": map[string]interface{}{"provider": "aws", "id": ""}},
+			Want:     "kms_key_ref must have provider aws, azure or gcp and a non-empty id",
+			Variable: "kms_key_ref",
+		},
+		{
+			Name:     "NotificationUnknownEvent",
+			Vars:     map[string]interface{}{"notifications": notificationsWith(map[string]interface{}{"events": []string{"s3:ObjectCreated:*"}})},
+			Want:     "notifications events must be a non-empty list of created and deleted",
+			Variable: "notifications",
+		},
+		{
+			Name:     "NotificationWithoutEvents",
+			Vars:     map[string]interface{}{"notifications": notificationsWith(map[string]interface{}{"events": []string{}})},
+			Want:     "notifications events must be a non-empty list of created and deleted",
+			Variable: "notifications",
+		},
+		{
+			Name: "NotificationUnknownTargetType",
+			Vars: map[string]interface{}{"notifications": notificationsWith(map[string]interface{}{
+				"target_ref": map[string]interface{}{"provider": "aws", "type": "bucket", "id": "arn:aws:s3:::other"},
+			})},
+			Want:     "notifications target_ref must have type function, queue or topic and a non-empty id",
+			Variable: "notifications",
+		},
+		{
+			Name: "NotificationSuffixOnGcp",
+			Vars: map[string]interface{}{
+				"provider_name": "gcp",
+				"notifications": notificationsWith(map[string]interface{}{
+					"filter_suffix": ".csv",
+					"target_ref":    map[string]interface{}{"provider": "gcp", "type": "topic", "id": "projects/p/topics/uploads"},
+				}),
+			},
+			Want:     "Cloud Storage notifications only publish to topics and only filter on filter_prefix",
+			Variable: "notifications",
+		},
+		{
+			Name: "NotificationQueueOnGcp",
+			Vars: map[string]interface{}{
+				"provider_name": "gcp",
+				"notifications": notificationsWith(map[string]interface{}{
+					"target_ref": map[string]interface{}{"provider": "gcp", "type": "queue", "id": "projects/p/subscriptions/uploads"},
+				}),
+			},
+			Want:     "Cloud Storage notifications only publish to topics and only filter on filter_prefix",
+			Variable: "notifications",
+		},
+		{
+			Name:     "NotificationsOnZero",
+			Vars:     map[string]interface{}{"provider_name": "zero", "notifications": notificationsWith(nil)},
+			Want:     "notifications are not available on zero",
+			Variable: "notifications",
+		},
+		{
+			Name: "NotificationTargetOnAnotherProvider",
+			Vars: map[string]interface{}{"notifications": notificationsWith(map[string]interface{}{
+				"target_ref": map[string]interface{}{"provider": "azure", "type": "queue", "id": "/subscriptions/s/queues/q"},
+			})},
+			Want: "Every notifications target_ref must belong to aws",
+		},
+	})
 }
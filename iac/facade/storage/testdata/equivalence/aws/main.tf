@@ -0,0 +1,63 @@
+# Storage equivalence fixture: AWS
+#
+# The storage facade against CloudEmu S3. The azure and gcp fixtures next to
+# this one deploy the same module call, differing only in provider_name and
+# the provider block.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = var.aws_region
+
+  endpoints {
+    s3  = var.cloudemu_endpoint
+    sts = var.cloudemu_endpoint
+  }
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+  s3_use_path_style           = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+variable "cloudemu_endpoint" {
+  description = "CloudEmu AWS endpoint URL"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "bucket_name" {
+  description = "Name of the bucket under test"
+  type        = string
+}
+
+module "storage" {
+  source = "../../.."
+
+  provider_name = "aws"
+  project_name  = "equivalence"
+  environment   = "local"
+  bucket_name   = var.bucket_name
+}
+
+output "bucket_name" {
+  value = module.storage.bucket.name
+}
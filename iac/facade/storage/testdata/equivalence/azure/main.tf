@@ -0,0 +1,48 @@
+# Storage equivalence fixture: Azure
+#
+# The storage facade against CloudEmu Blob Storage. The facade names the
+# blob container after bucket_name, so that is what the test reads and
+# writes.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+
+provider "azurerm" {
+  features {}
+  skip_provider_registration = true
+  storage_use_azuread        = false
+
+  metadata_host = var.azure_endpoint
+}
+
+variable "azure_endpoint" {
+  description = "CloudEmu Azure endpoint URL"
+  type        = string
+  default     = "http://localhost:10000"
+}
+
+variable "bucket_name" {
+  description = "Name of the container under test; also the storage account name, without hyphens, so at most 24 characters once they are removed"
+  type        = string
+}
+
+module "storage" {
+  source = "../../.."
+
+  provider_name = "azure"
+  project_name  = "equivalence"
+  environment   = "local"
+  bucket_name   = var.bucket_name
+}
+
+output "bucket_name" {
+  value = module.storage.bucket.name
+}
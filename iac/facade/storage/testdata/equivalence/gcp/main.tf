@@ -0,0 +1,49 @@
+# Storage equivalence fixture: GCP
+#
+# The storage facade against CloudEmu Cloud Storage.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "google" {
+  project = "local-test"
+  region  = "us-east1"
+
+  storage_custom_endpoint = var.gcp_endpoint
+}
+
+variable "gcp_endpoint" {
+  description = "CloudEmu GCP endpoint URL"
+  type        = string
+  default     = "http://localhost:4567"
+}
+
+variable "bucket_name" {
+  description = "Name of the bucket under test"
+  type        = string
+}
+
+module "storage" {
+  source = "../../.."
+
+  provider_name = "gcp"
+  project_name  = "equivalence"
+  environment   = "local"
+  bucket_name   = var.bucket_name
+
+  provider_config = {
+    project_id = "local-test"
+  }
+}
+
+output "bucket_name" {
+  value = module.storage.bucket.name
+}
@@ -0,0 +1,136 @@
+# Native tests of the storage facade: its validation rules, and what it
+# plans on AWS. TestNativeModuleTests in the iac module runs them with
+# `terraform test -json`, setting emulator_endpoint and aws_region to
+# CloudEmu; to run them by hand, from facade/storage:
+#
+#   terraform init
+#   TF_VAR_emulator_endpoint=http://localhost:4566 TF_VAR_aws_region=us-east-1 terraform test
+#
+# Plans need no emulator running; a run with command = apply creates its
+# bucket on CloudEmu.
+
+provider "aws" {
+  region = var.aws_region
+
+  endpoints {
+    iam    = var.emulator_endpoint
+    lambda = var.emulator_endpoint
+    s3     = var.emulator_endpoint
+    sns    = var.emulator_endpoint
+    sqs    = var.emulator_endpoint
+    sts    = var.emulator_endpoint
+  }
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_region_validation      = true
+  skip_requesting_account_id  = true
+  s3_use_path_style           = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+variables {
+  provider_name = "aws"
+  project_name  = "nativetest"
+  environment   = "dev"
+  bucket_name   = "native-test-bucket"
+}
+
+run "plans_private_bucket" {
+  command = plan
+
+  assert {
+    condition     = length(module.aws_storage) == 1 && length(module.azure_storage) == 0 && length(module.gcp_storage) == 0
+    error_message = "provider_name = \"aws\" should route to the AWS storage module alone"
+  }
+
+  assert {
+    condition     = output.backup_ref.type == "s3"
+    error_message = "The backup_ref of an S3 bucket should have type s3"
+  }
+
+  assert {
+    condition     = output.origin_ref.bucket_name == "native-test-bucket"
+    error_message = "The origin_ref should name the bucket"
+  }
+}
+
+run "names_bucket_by_convention" {
+  command = plan
+
+  variables {
+    bucket_name = null
+  }
+
+  assert {
+    condition     = output.origin_ref.bucket_name == "nativetest-dev-bucket"
+    error_message = "A bucket without bucket_name should be named project_name-environment-bucket"
+  }
+}
+
+run "rejects_uppercase_bucket_name" {
+  command = plan
+
+  variables {
+    bucket_name = "Native-Test-Bucket"
+  }
+
+  expect_failures = [
+    var.bucket_name,
+  ]
+}
+
+run "rejects_short_bucket_name" {
+  command = plan
+
+  variables {
+    bucket_name = "ab"
+  }
+
+  expect_failures = [
+    var.bucket_name,
+  ]
+}
+
+run "rejects_unknown_storage_class" {
+  command = plan
+
+  variables {
+    storage_class = "frozen"
+  }
+
+  expect_failures = [
+    var.storage_class,
+  ]
+}
+
+run "rejects_deprecated_public_access_block_with_allow_public_access" {
+  command = plan
+
+  variables {
+    public_access_block = true
+    allow_public_access = false
+  }
+
+  expect_failures = [
+    var.public_access_block,
+  ]
+}
+
+run "rejects_notifications_on_zero" {
+  command = plan
+
+  variables {
+    provider_name = "zero"
+    notifications = [{
+      events     = ["created"]
+      target_ref = { provider = "zero", type = "queue", id = "uploads" }
+    }]
+  }
+
+  expect_failures = [
+    var.notifications,
+  ]
+}
@@ -0,0 +1,26 @@
+//go:build integration
+
+package storage_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"iac/testutil/upgrade"
+)
+
+// TestStorageFacadeUpgrade applies the facade from the base ref (see
+// upgrade.BaseRef) and fails if the working tree would delete or replace
+// the bucket or its versioning. Skipped unless CloudEmu is running.
+func TestStorageFacadeUpgrade(t *testing.T) {
+	t.Parallel()
+
+	upgrade.RunUpgradeTest(t, ".", map[string]interface{}{
+		"provider_name":      "aws",
+		"project_name":       "upgrade",
+		"environment":        "local",
+		"bucket_name":        fmt.Sprintf("upgrade-bucket-%d", time.Now().Unix()),
+		"versioning_enabled": true,
+	})
+}
@@ -10,16 +10,21 @@ variable "provider_name" {
   type        = string
   validation {
     condition     = contains(["aws", "azure", "gcp", "oracle", "zero"], var.provider_name)
-    error_message = "Provider must be one of: aws, azure, gcp, oracle, zero"
+    error_message = "provider_name must be one of: aws, azure, gcp, oracle, zero"
   }
 }
 
 variable "bucket_name" {
-  description = "Name of the storage bucket (3-63 lowercase alphanumeric characters with hyphens)"
+  description = "Name of the storage bucket (3-63 lowercase alphanumeric characters with hyphens); null names it project_name-environment-bucket, cut to fit. On Azure it also names the storage account, without hyphens and cut to 24 characters."
   type        = string
+  default     = null
+  validation {
+    condition     = var.bucket_name == null || can(regex("^[a-z0-9][a-z0-9-]*[a-z0-9]$", var.bucket_name))
+    error_message = "Bucket name must be lowercase alphanumeric with hyphens, starting and ending with alphanumeric, e.g. my-app-assets"
+  }
   validation {
-    condition     = can(regex("^[a-z0-9][a-z0-9-]*[a-z0-9]$", var.bucket_name))
-    error_message = "Bucket name must be lowercase alphanumeric with hyphens, starting and ending with alphanumeric"
+    condition     = var.bucket_name == null || (length(var.bucket_name) >= 3 && length(var.bucket_name) <= 63)
+    error_message = "Bucket name must be 3-63 characters long"
   }
 }
 
@@ -34,7 +39,7 @@ variable "environment" {
   default     = "dev"
   validation {
     condition     = contains(["local", "dev", "staging", "prod"], var.environment)
-    error_message = "Environment must be one of: dev, staging, prod"
+    error_message = "Environment must be one of: local, dev, staging, prod"
   }
 }
 
@@ -71,10 +76,97 @@ variable "encryption_key_id" {
   sensitive   = true
 }
 
+variable "kms_key_ref" {
+  description = "Customer-managed key from the encryption facade (its kms_key_ref output); must belong to the same provider"
+  type = object({
+    provider = string
+    id       = string
+  })
+  default = null
+  validation {
+    condition     = var.kms_key_ref == null || try(contains(["aws", "azure", "gcp"], var.kms_key_ref.provider) && length(var.kms_key_ref.id) > 0, false)
+    error_message = "kms_key_ref must have provider aws, azure or gcp and a non-empty id, e.g. the encryption facade's kms_key_ref output"
+  }
+}
+
+variable "allow_public_access" {
+  description = "Let the bucket be made public. Unset or false blocks it: an S3 public access block with all four flags, no public blobs on Azure, enforced public access prevention on GCS."
+  type        = bool
+  default     = null
+}
+
+# Deprecated in favor of allow_public_access, as listed in deprecations.json
 variable "public_access_block" {
-  description = "Block all public access (recommended for security)"
+  description = "Deprecated: replaced by allow_public_access, its negation; public_access_block is removed in 2.0.0"
   type        = bool
-  default     = true
+  default     = null
+  validation {
+    condition     = var.public_access_block == null || var.allow_public_access == null
+    error_message = "public_access_block is deprecated and replaced by allow_public_access: set allow_public_access alone"
+  }
+}
+
+variable "website" {
+  description = <<-EOT
+    Website mode, for a static site served through the cdn facade (pass it
+    the origin_ref output). AWS keeps the bucket private and serves it through
+    CloudFront origin access control; Azure enables the static website ($web
+    container); GCP sets the website pages and makes objects publicly
+    readable, which Cloud CDN backend buckets need.
+  EOT
+  type = object({
+    index_document = optional(string, "index.html")
+    error_document = optional(string, "404.html")
+  })
+  default = null
+}
+
+# ============================================================================
+# EVENT NOTIFICATIONS
+# ============================================================================
+
+variable "notifications" {
+  description = <<-EOT
+    Object events to send to a function, queue or topic. target_ref is the
+    event_target_ref output of the lambda or messaging facade. Example:
+    [{
+      events        = ["created"]
+      filter_prefix = "uploads/"
+      filter_suffix = ".csv"
+      target_ref    = module.ingest.event_target_ref
+    }]
+    Events are created and deleted. AWS sends them with S3 event
+    notifications, Azure through an Event Grid system topic on the account,
+    and GCP as Cloud Storage notifications to a Pub/Sub topic, which only
+    filter on a prefix and only reach topics.
+  EOT
+  type = list(object({
+    events        = list(string)
+    filter_prefix = optional(string)
+    filter_suffix = optional(string)
+    target_ref = object({
+      provider = string
+      type     = string
+      id       = string
+    })
+  }))
+  default = []
+  validation {
+    condition     = alltrue([for n in var.notifications : length(n.events) > 0 && length(setsubtract(n.events, ["created", "deleted"])) == 0])
+    error_message = "notifications events must be a non-empty list of created and deleted, e.g. [\"created\"]"
+  }
+  validation {
+    condition     = alltrue([for n in var.notifications : contains(["function", "queue", "topic"], n.target_ref.type) && length(n.target_ref.id) > 0])
+    error_message = "notifications target_ref must have type function, queue or topic and a non-empty id, e.g. the event_target_ref output of the lambda or messaging facade"
+  }
+  validation {
+    condition     = length(var.notifications) == 0 || contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "notifications are not available on ${var.provider_name}"
+  }
+  validation {
+    condition     = var.provider_name != "gcp" || alltrue([for n in var.notifications : n.target_ref.type == "topic" && n.filter_suffix == null])
+    error_message = "Cloud Storage notifications only publish to topics and only filter on filter_prefix: on gcp, use a topic target_ref and no filter_suffix"
+  }
 }
 
 # ============================================================================
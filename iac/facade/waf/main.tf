@@ -0,0 +1,136 @@
+# WAF Facade
+# Unified interface for a managed-rules web application firewall
+
+terraform {
+  # Output preconditions
+  required_version = ">= 1.2"
+}
+
+# ============================================================================
+# IMPORT COMMON LAYER
+# ============================================================================
+
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
+    {
+      Provider = var.provider_name
+      Module   = "WAF-Facade"
+    },
+    var.tags
+  )
+}
+
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+# ============================================================================
+# RULE SET TRANSLATION
+# ============================================================================
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags = module.default_tags.tags
+
+  aws_rule_groups = {
+    common = "AWSManagedRulesCommonRuleSet"
+    sqli   = "AWSManagedRulesSQLiRuleSet"
+    bot    = "AWSManagedRulesBotControlRuleSet"
+  }
+
+  # The Azure default rule sets already include the SQL injection rules, so
+  # common and sqli resolve to the same set
+  azure_frontdoor_rule_sets = {
+    common = { type = "Microsoft_DefaultRuleSet", version = "2.1" }
+    sqli   = { type = "Microsoft_DefaultRuleSet", version = "2.1" }
+    bot    = { type = "Microsoft_BotManagerRuleSet", version = "1.0" }
+  }
+
+  azure_app_gateway_rule_sets = {
+    common = { type = "OWASP", version = "3.2" }
+    sqli   = { type = "OWASP", version = "3.2" }
+    bot    = { type = "Microsoft_BotManagerRuleSet", version = "1.0" }
+  }
+
+  # Cloud Armor has no bot rule set without reCAPTCHA; scanner detection is
+  # the closest preconfigured rule
+  gcp_waf_expressions = {
+    common = "evaluatePreconfiguredWaf('xss-v33-stable') || evaluatePreconfiguredWaf('lfi-v33-stable') || evaluatePreconfiguredWaf('rfi-v33-stable') || evaluatePreconfiguredWaf('rce-v33-stable')"
+    sqli   = "evaluatePreconfiguredWaf('sqli-v33-stable')"
+    bot    = "evaluatePreconfiguredWaf('scannerdetection-v33-stable')"
+  }
+
+  attach_ids     = [for r in var.attach_to : r.id if r.provider == var.provider_name]
+  frontdoor_ids  = [for r in var.attach_to : r.id if r.provider == "azure" && r.type == "frontdoor"]
+  frontdoor_sets = distinct([for s in var.managed_rule_sets : local.azure_frontdoor_rule_sets[s]])
+  gateway_sets   = distinct([for s in var.managed_rule_sets : local.azure_app_gateway_rule_sets[s]])
+}
+
+# ============================================================================
+# PROVIDER-SPECIFIC MODULE ROUTING
+# ============================================================================
+
+# AWS: regional WAFv2 web ACL associated with API Gateway stages and ALBs
+module "aws_waf" {
+  count  = var.provider_name == "aws" ? 1 : 0
+  source = "../../aws/core/waf"
+
+  name                = var.waf_name
+  managed_rule_groups = [for s in var.managed_rule_sets : local.aws_rule_groups[s]]
+  rate_limit          = var.rate_limit
+  resource_arns       = local.attach_ids
+
+  tags = local.default_tags
+}
+
+# Azure: Front Door firewall and security policy when attached to Front Door,
+# otherwise an Application Gateway policy
+module "azure_waf" {
+  count  = var.provider_name == "azure" ? 1 : 0
+  source = "../../azure/core/waf"
+
+  name                 = var.waf_name
+  resource_group_name  = lookup(var.provider_config, "resource_group_name", module.resource_group_name.name)
+  location             = lookup(var.provider_config, "location", "eastus")
+  managed_rule_sets    = length(local.frontdoor_ids) > 0 ? local.frontdoor_sets : local.gateway_sets
+  rate_limit           = var.rate_limit
+  frontdoor_profile_id = length(local.frontdoor_ids) > 0 ? regex("^(.*/profiles/[^/]+)/", local.frontdoor_ids[0])[0] : null
+  frontdoor_domain_ids = local.frontdoor_ids
+  frontdoor_sku        = lookup(var.provider_config, "frontdoor_sku", "Premium_AzureFrontDoor")
+
+  tags = local.default_tags
+}
+
+# GCP: Cloud Armor security policy attached to global backend services
+module "gcp_waf" {
+  count  = var.provider_name == "gcp" ? 1 : 0
+  source = "../../gcp/core/waf"
+
+  project_id       = lookup(var.provider_config, "project_id", var.project_name)
+  name             = var.waf_name
+  waf_expressions  = [for s in var.managed_rule_sets : local.gcp_waf_expressions[s]]
+  rate_limit       = var.rate_limit
+  backend_services = local.attach_ids
+}
+
+# ============================================================================
+# AGGREGATED OUTPUTS
+# ============================================================================
+
+locals {
+  web_acl_id = (
+    var.provider_name == "aws"   ? (length(module.aws_waf) > 0 ? module.aws_waf[0].web_acl_arn : null) :
+    var.provider_name == "azure" ? (length(module.azure_waf) > 0 ? module.azure_waf[0].policy_id : null) :
+    var.provider_name == "gcp"   ? (length(module.gcp_waf) > 0 ? module.gcp_waf[0].security_policy_id : null) :
+    null
+  )
+}
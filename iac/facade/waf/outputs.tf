@@ -0,0 +1,25 @@
+output "web_acl_id" {
+  description = "Firewall identifier (web ACL ARN / WAF policy ID / security policy ID)"
+  value       = local.web_acl_id
+
+  precondition {
+    condition     = alltrue([for r in var.attach_to : r.provider == var.provider_name])
+    error_message = "attach_to includes a resource from ${join(", ", distinct([for r in var.attach_to : r.provider if r.provider != var.provider_name]))} but this module deploys to ${var.provider_name}"
+  }
+
+  # Standard Front Door firewall policies only take custom rules
+  precondition {
+    condition     = var.provider_name != "azure" || length(local.frontdoor_ids) == 0 || length(var.managed_rule_sets) == 0 || lookup(var.provider_config, "frontdoor_sku", "Premium_AzureFrontDoor") == "Premium_AzureFrontDoor"
+    error_message = "Managed rule sets on Front Door need a Premium_AzureFrontDoor profile; set provider_config.frontdoor_sku to match the profile"
+  }
+}
+
+output "attached_ids" {
+  description = "Resources the firewall is attached to; on azure without Front Door targets, link web_acl_id as the Application Gateway's firewall_policy_id"
+  value       = local.attach_ids
+}
+
+output "provider" {
+  description = "Cloud provider"
+  value       = var.provider_name
+}
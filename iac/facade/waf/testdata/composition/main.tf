@@ -0,0 +1,68 @@
+# WAF composition fixture
+#
+# An API Gateway REST API stage protected by the waf facade through an
+# attach_to reference. There is no API gateway facade yet, so the stage is
+# declared directly. Planned only, with placeholder credentials.
+
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+resource "aws_api_gateway_rest_api" "this" {
+  name = "composition-api"
+}
+
+resource "aws_api_gateway_method" "health" {
+  rest_api_id   = aws_api_gateway_rest_api.this.id
+  resource_id   = aws_api_gateway_rest_api.this.root_resource_id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "health" {
+  rest_api_id       = aws_api_gateway_rest_api.this.id
+  resource_id       = aws_api_gateway_rest_api.this.root_resource_id
+  http_method       = aws_api_gateway_method.health.http_method
+  type              = "MOCK"
+  request_templates = { "application/json" = "{\"statusCode\": 200}" }
+}
+
+resource "aws_api_gateway_deployment" "this" {
+  rest_api_id = aws_api_gateway_rest_api.this.id
+
+  depends_on = [aws_api_gateway_integration.health]
+}
+
+resource "aws_api_gateway_stage" "this" {
+  rest_api_id   = aws_api_gateway_rest_api.this.id
+  deployment_id = aws_api_gateway_deployment.this.id
+  stage_name    = "prod"
+}
+
+module "waf" {
+  source = "../../"
+
+  provider_name     = "aws"
+  project_name      = "composition"
+  environment       = "dev"
+  waf_name          = "composition-api"
+  managed_rule_sets = ["common", "sqli", "bot"]
+  rate_limit        = 2000
+  attach_to         = [{ provider = "aws", type = "api_gateway_stage", id = aws_api_gateway_stage.this.arn }]
+}
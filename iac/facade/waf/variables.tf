@@ -0,0 +1,96 @@
+# General Configuration
+variable "provider_name" {
+  description = "Cloud provider (aws, azure, gcp)"
+  type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
+}
+
+variable "project_name" {
+  description = "Project name"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment (local, dev, staging, prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+# Firewall Configuration
+variable "waf_name" {
+  description = "Web ACL / WAF policy / security policy name"
+  type        = string
+  validation {
+    condition     = can(regex("^[a-z][a-z0-9-]{1,61}[a-z0-9]$", var.waf_name))
+    error_message = "WAF name must be 3-63 lower case letters, digits and hyphens, starting with a letter and not ending with a hyphen"
+  }
+}
+
+variable "managed_rule_sets" {
+  description = "Provider-managed rule sets to enforce: common (OWASP-style protections), sqli, bot"
+  type        = list(string)
+  default     = ["common", "sqli"]
+  validation {
+    condition     = alltrue([for s in var.managed_rule_sets : contains(["common", "sqli", "bot"], s)])
+    error_message = "managed_rule_sets must be one of: common, sqli, bot"
+  }
+  validation {
+    condition     = length(distinct(var.managed_rule_sets)) == length(var.managed_rule_sets)
+    error_message = "managed_rule_sets must not repeat a rule set, e.g. [\"common\", \"sqli\"]"
+  }
+}
+
+variable "rate_limit" {
+  description = "Requests a client IP may send in 5 minutes before it is blocked (null for no limit)"
+  type        = number
+  default     = null
+  validation {
+    condition     = var.rate_limit == null || try(var.rate_limit >= 100 && floor(var.rate_limit) == var.rate_limit, false)
+    error_message = "rate_limit must be a whole number of at least 100 requests per 5 minutes"
+  }
+}
+
+variable "attach_to" {
+  description = <<-EOT
+    Resources the firewall protects ({provider, type, id}): an API Gateway
+    stage or Application Load Balancer ARN (api_gateway_stage, alb) on aws,
+    Front Door endpoint or custom domain IDs (frontdoor) on azure, and global
+    backend services (backend_service) on gcp.
+  EOT
+  type = list(object({
+    provider = string
+    type     = string
+    id       = string
+  }))
+  default = []
+  validation {
+    condition = alltrue([
+      for r in var.attach_to : contains(lookup({
+        aws   = ["api_gateway_stage", "alb"]
+        azure = ["frontdoor"]
+        gcp   = ["backend_service"]
+      }, r.provider, []), r.type)
+    ])
+    error_message = "attach_to types are api_gateway_stage or alb on aws, frontdoor on azure, and backend_service on gcp, e.g. type = \"alb\" with provider = \"aws\""
+  }
+}
+
+# Provider-specific Configuration
+variable "provider_config" {
+  description = "Provider-specific settings (resource_group_name, location, frontdoor_sku for azure; project_id for gcp)"
+  type        = any
+  default     = {}
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
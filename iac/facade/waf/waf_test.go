@@ -0,0 +1,262 @@
+package waf_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/planerr"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	apiStage     = "arn:aws:apigateway:us-east-1::/restapis/a1b2c3d4e5/stages/prod"
+	loadBalancer = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/shop-alb/50dc6c495c0c9188"
+	frontDoor    = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/test-rg/providers/Microsoft.Cdn/profiles/shop-cdn/afdEndpoints/shop-cdn"
+)
+
+// blocks returns a nested block list from planned attribute values
+func blocks(values map[string]interface{}, name string) []map[string]interface{} {
+	var result []map[string]interface{}
+	list, _ := values[name].([]interface{})
+	for _, item := range list {
+		result = append(result, item.(map[string]interface{}))
+	}
+	return result
+}
+
+func TestWafFacadeAws(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":     "aws",
+			"project_name":      "testproject",
+			"environment":       "dev",
+			"waf_name":          "shop-api",
+			"managed_rule_sets": []string{"common", "sqli", "bot"},
+			"rate_limit":        1000,
+			"attach_to": []map[string]interface{}{
+				{"provider": "aws", "type": "api_gateway_stage", "id": apiStage},
+				{"provider": "aws", "type": "alb", "id": loadBalancer},
+			},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	acl, ok := plan.ResourcePlannedValuesMap["module.aws_waf[0].aws_wafv2_web_acl.this"]
+	require.True(t, ok, "Plan should create a web ACL")
+	assert.Equal(t, "REGIONAL", acl.AttributeValues["scope"])
+
+	groups := map[string]bool{}
+	var rateLimits []interface{}
+	for _, rule := range blocks(acl.AttributeValues, "rule") {
+		statement := blocks(rule, "statement")[0]
+		for _, group := range blocks(statement, "managed_rule_group_statement") {
+			groups[group["name"].(string)] = true
+		}
+		for _, rate := range blocks(statement, "rate_based_statement") {
+			rateLimits = append(rateLimits, rate["limit"])
+		}
+	}
+	assert.Equal(t, map[string]bool{
+		"AWSManagedRulesCommonRuleSet":     true,
+		"AWSManagedRulesSQLiRuleSet":       true,
+		"AWSManagedRulesBotControlRuleSet": true,
+	}, groups, "There should be one managed rule group per rule set")
+	assert.Equal(t, []interface{}{float64(1000)}, rateLimits)
+
+	var associated []interface{}
+	for i := 0; i < 2; i++ {
+		association, ok := plan.ResourcePlannedValuesMap[fmt.Sprintf("module.aws_waf[0].aws_wafv2_web_acl_association.this[%d]", i)]
+		require.True(t, ok, "Plan should associate every attach_to resource")
+		associated = append(associated, association.AttributeValues["resource_arn"])
+	}
+	assert.ElementsMatch(t, []interface{}{apiStage, loadBalancer}, associated)
+}
+
+func TestWafFacadeAzureFrontDoor(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":     "azure",
+			"project_name":      "testproject",
+			"environment":       "dev",
+			"waf_name":          "shop-cdn",
+			"managed_rule_sets": []string{"common", "sqli", "bot"},
+			"rate_limit":        500,
+			"attach_to":         []map[string]interface{}{{"provider": "azure", "type": "frontdoor", "id": frontDoor}},
+			"provider_config":   map[string]interface{}{"resource_group_name": "test-rg"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	policy, ok := plan.ResourcePlannedValuesMap["module.azure_waf[0].azurerm_cdn_frontdoor_firewall_policy.this[0]"]
+	require.True(t, ok, "Plan should create a Front Door firewall policy")
+	assert.Equal(t, "shopcdn", policy.AttributeValues["name"])
+	assert.Equal(t, "Premium_AzureFrontDoor", policy.AttributeValues["sku_name"])
+	var ruleSets []interface{}
+	for _, rule := range blocks(policy.AttributeValues, "managed_rule") {
+		ruleSets = append(ruleSets, rule["type"])
+	}
+	assert.ElementsMatch(t, []interface{}{"Microsoft_DefaultRuleSet", "Microsoft_BotManagerRuleSet"}, ruleSets,
+		"common and sqli share the default rule set")
+	customRules := blocks(policy.AttributeValues, "custom_rule")
+	require.Len(t, customRules, 1)
+	assert.EqualValues(t, 500, customRules[0]["rate_limit_threshold"])
+
+	securityPolicy, ok := plan.ResourcePlannedValuesMap["module.azure_waf[0].azurerm_cdn_frontdoor_security_policy.this[0]"]
+	require.True(t, ok, "Plan should link the policy to Front Door")
+	assert.Equal(t, "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/test-rg/providers/Microsoft.Cdn/profiles/shop-cdn",
+		securityPolicy.AttributeValues["cdn_frontdoor_profile_id"])
+	firewall := blocks(blocks(securityPolicy.AttributeValues, "security_policies")[0], "firewall")[0]
+	domain := blocks(blocks(firewall, "association")[0], "domain")
+	require.Len(t, domain, 1)
+	assert.Equal(t, frontDoor, domain[0]["cdn_frontdoor_domain_id"])
+
+	assert.NotContains(t, plan.ResourcePlannedValuesMap, "module.azure_waf[0].azurerm_web_application_firewall_policy.this[0]")
+}
+
+func TestWafFacadeAzureApplicationGateway(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":     "azure",
+			"project_name":      "testproject",
+			"environment":       "dev",
+			"waf_name":          "shop-gateway",
+			"managed_rule_sets": []string{"common", "bot"},
+			"provider_config":   map[string]interface{}{"resource_group_name": "test-rg", "location": "westeurope"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	policy, ok := plan.ResourcePlannedValuesMap["module.azure_waf[0].azurerm_web_application_firewall_policy.this[0]"]
+	require.True(t, ok, "Without Front Door targets the plan should create an Application Gateway policy")
+	assert.Equal(t, "westeurope", policy.AttributeValues["location"])
+	var ruleSets []interface{}
+	for _, set := range blocks(blocks(policy.AttributeValues, "managed_rules")[0], "managed_rule_set") {
+		ruleSets = append(ruleSets, set["type"])
+	}
+	assert.ElementsMatch(t, []interface{}{"OWASP", "Microsoft_BotManagerRuleSet"}, ruleSets)
+	assert.Empty(t, blocks(policy.AttributeValues, "custom_rules"), "No rate_limit means no rate limit rule")
+}
+
+func TestWafFacadeGcp(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"provider_name":     "gcp",
+			"project_name":      "testproject",
+			"environment":       "dev",
+			"waf_name":          "shop-backend",
+			"managed_rule_sets": []string{"common", "sqli"},
+			"rate_limit":        300,
+			"attach_to":         []map[string]interface{}{{"provider": "gcp", "type": "backend_service", "id": "projects/test-project/global/backendServices/shop-backend"}},
+			"provider_config":   map[string]interface{}{"project_id": "test-project"},
+		},
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	policy, ok := plan.ResourcePlannedValuesMap["module.gcp_waf[0].google_compute_security_policy.this"]
+	require.True(t, ok, "Plan should create a Cloud Armor security policy")
+	actions := map[string]int{}
+	for _, rule := range blocks(policy.AttributeValues, "rule") {
+		actions[rule["action"].(string)]++
+	}
+	assert.Equal(t, map[string]int{"deny(403)": 2, "throttle": 1, "allow": 1}, actions,
+		"There should be one WAF rule per rule set, the rate limit and the default rule")
+
+	attach, ok := plan.ResourcePlannedValuesMap["module.gcp_waf[0].null_resource.attach[0]"]
+	require.True(t, ok, "Plan should attach the policy to the backend service")
+	triggers := attach.AttributeValues["triggers"].(map[string]interface{})
+	assert.Equal(t, "shop-backend", triggers["backend_service"])
+	assert.Equal(t, "shop-backend", triggers["policy"])
+}
+
+func TestWafFacadeComposition(t *testing.T) {
+	t.Parallel()
+
+	plan := terraform.InitAndPlanAndShowWithStruct(t, tfopts.New(t, &terraform.Options{
+		TerraformDir: "testdata/composition",
+		PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+		NoColor:      true,
+	}))
+
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "aws_api_gateway_stage.this", "Plan should create the stage")
+	assert.Contains(t, plan.ResourcePlannedValuesMap, "module.waf.module.aws_waf[0].aws_wafv2_web_acl_association.this[0]",
+		"The stage reference should produce an association")
+
+	// The stage ARN is unknown until apply, so check the wiring in the configuration
+	call := plan.RawPlan.Config.RootModule.ModuleCalls["waf"]
+	require.NotNil(t, call)
+	assert.Contains(t, call.Expressions["attach_to"].References, "aws_api_gateway_stage.this.arn")
+}
+
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "testproject",
+		"environment":   "dev",
+		"waf_name":      "shop-api",
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name: "InvalidName",
+			Vars: map[string]interface{}{"waf_name": "Shop_API"},
+			Want: "WAF name must be 3-63 lower case letters",
+		},
+		{
+			Name: "RateLimitBelowMinimum",
+			Vars: map[string]interface{}{"rate_limit": 99},
+			Want: "rate_limit must be a whole number of at least 100",
+		},
+		{
+			Name: "UnknownRuleSet",
+			Vars: map[string]interface{}{"managed_rule_sets": []string{"common", "xss"}},
+			Want: "managed_rule_sets must be one of: common, sqli, bot",
+		},
+		{
+			Name: "RepeatedRuleSet",
+			Vars: map[string]interface{}{"managed_rule_sets": []string{"sqli", "sqli"}},
+			Want: "managed_rule_sets must not repeat a rule set",
+		},
+		{
+			Name: "UnsupportedAttachType",
+			Vars: map[string]interface{}{"attach_to": []map[string]interface{}{{"provider": "aws", "type": "frontdoor", "id": frontDoor}}},
+			Want: "attach_to types are api_gateway_stage or alb on aws",
+		},
+		{
+			Name: "AttachToOtherProvider",
+			Vars: map[string]interface{}{"attach_to": []map[string]interface{}{{"provider": "azure", "type": "frontdoor", "id": frontDoor}}},
+			Want: "attach_to includes a resource from azure but this module deploys to aws",
+		},
+		{
+			Name: "StandardFrontDoorWithManagedRules",
+			Vars: map[string]interface{}{
+				"provider_name":   "azure",
+				"attach_to":       []map[string]interface{}{{"provider": "azure", "type": "frontdoor", "id": frontDoor}},
+				"provider_config": map[string]interface{}{"resource_group_name": "test-rg", "frontdoor_sku": "Standard_AzureFrontDoor"},
+			},
+			Want: "Managed rule sets on Front Door need a Premium_AzureFrontDoor profile",
+		},
+	})
+}
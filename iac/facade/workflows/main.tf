@@ -1,17 +1,33 @@
 # Workflows Facade
 
-locals {
-  common_tags = merge(
-    var.tags,
+module "default_tags" {
+  source = "../../common/tags"
+
+  project_name = var.project_name
+  environment  = var.environment
+  tags = merge(
     {
-      ManagedBy   = "Terraform"
-      Environment = var.environment
-      Project     = var.project_name
-      Module      = "Workflows-Facade"
-    }
+      Module = "Workflows-Facade"
+    },
+    var.tags
   )
 }
 
+module "resource_group_name" {
+  source = "../../common/naming"
+
+  resource_type = "azure_resource_group"
+  project_name  = var.project_name
+  environment   = var.environment
+  name          = "rg"
+}
+
+locals {
+  # Mandatory project/environment/managed_by tags merged with caller tags
+  default_tags   = module.default_tags.tags
+  default_labels = module.default_tags.labels
+}
+
 # AWS: Step Functions
 module "aws_workflows" {
   count  = var.provider_name == "aws" ? 1 : 0
@@ -21,7 +37,7 @@ module "aws_workflows" {
   definition = var.definition
   role_arn   = var.role_arn
   
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # Azure: Logic App
@@ -30,11 +46,11 @@ module "azure_workflows" {
   source = "../../azure/core/workflows"
 
   name                = var.name
-  resource_group_name = "${var.project_name}-${var.environment}-rg"
+  resource_group_name = module.resource_group_name.name
   location            = "East US"
   workflow_definition = var.definition # CAUTION: Azure expects JSON, not ASL
   
-  tags = local.common_tags
+  tags = local.default_tags
 }
 
 # GCP: Workflows
@@ -47,7 +63,7 @@ module "gcp_workflows" {
   region          = "us-central1"
   source_contents = var.definition # CAUTION: GCP expects YAML
   
-  labels = local.common_tags
+  labels = local.default_labels
 }
 
 output "workflow_id" {
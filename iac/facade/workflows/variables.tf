@@ -1,6 +1,10 @@
 variable "provider_name" {
-  description = "Cloud provider"
+  description = "Cloud provider (aws, azure, gcp)"
   type        = string
+  validation {
+    condition     = contains(["aws", "azure", "gcp"], var.provider_name)
+    error_message = "provider_name must be one of: aws, azure, gcp"
+  }
 }
 
 variable "name" {
@@ -21,6 +25,10 @@ variable "role_arn" {
 variable "environment" {
   description = "Environment"
   type        = string
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
 }
 
 variable "project_name" {
@@ -0,0 +1,31 @@
+package test
+
+import (
+	"testing"
+
+	"iac/testutil/facadedocs"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestFacadeDocs regenerates each facade's README.generated.md in memory
+// and fails with a diff where the committed file is stale. `go run
+// ./tools/facadedocs` rewrites them.
+func TestFacadeDocs(t *testing.T) {
+	t.Parallel()
+
+	dirs, err := facadedocs.Dirs(".")
+	require.NoError(t, err)
+	require.NotEmpty(t, dirs)
+
+	for _, dir := range dirs {
+		generated, err := facadedocs.Generate(dir)
+		require.NoError(t, err)
+
+		diff, err := facadedocs.Stale(dir, generated)
+		require.NoError(t, err)
+		if diff != "" {
+			t.Errorf("%s/%s is stale; run go run ./tools/facadedocs:\n%s", dir, facadedocs.FileName, diff)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+# GCP Backup Core Module (Storage Transfer Service copies of GCS buckets)
+
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+data "google_storage_transfer_project_service_account" "this" {
+  project = var.project_id
+}
+
+locals {
+  transfer_member = "serviceAccount:${data.google_storage_transfer_project_service_account.this.email}"
+}
+
+# Each bucket is copied into a versioned <bucket>-backup bucket; an object
+# overwritten or deleted by a later run stays as a noncurrent version until
+# the retention has passed
+resource "google_storage_bucket" "backup" {
+  count = length(var.bucket_names)
+
+  name                        = "${var.bucket_names[count.index]}-backup"
+  project                     = var.project_id
+  location                    = var.location
+  uniform_bucket_level_access = true
+
+  versioning {
+    enabled = true
+  }
+
+  lifecycle_rule {
+    action {
+      type = "Delete"
+    }
+    condition {
+      days_since_noncurrent_time = var.retention_days
+    }
+  }
+
+  labels = var.labels
+}
+
+resource "google_storage_bucket_iam_member" "source_reader" {
+  count = length(var.bucket_names)
+
+  bucket = var.bucket_names[count.index]
+  role   = "roles/storage.legacyBucketReader"
+  member = local.transfer_member
+}
+
+resource "google_storage_bucket_iam_member" "source_viewer" {
+  count = length(var.bucket_names)
+
+  bucket = var.bucket_names[count.index]
+  role   = "roles/storage.objectViewer"
+  member = local.transfer_member
+}
+
+resource "google_storage_bucket_iam_member" "sink_writer" {
+  count = length(var.bucket_names)
+
+  bucket = google_storage_bucket.backup[count.index].name
+  role   = "roles/storage.legacyBucketWriter"
+  member = local.transfer_member
+}
+
+resource "google_storage_transfer_job" "this" {
+  count = length(var.bucket_names)
+
+  description = "${var.job_name}: ${var.bucket_names[count.index]}"
+  project     = var.project_id
+
+  transfer_spec {
+    gcs_data_source {
+      bucket_name = var.bucket_names[count.index]
+    }
+    gcs_data_sink {
+      bucket_name = google_storage_bucket.backup[count.index].name
+    }
+    transfer_options {
+      overwrite_when = "DIFFERENT"
+    }
+  }
+
+  schedule {
+    schedule_start_date {
+      year  = var.start_date.year
+      month = var.start_date.month
+      day   = var.start_date.day
+    }
+    start_time_of_day {
+      hours   = var.start_time.hours
+      minutes = var.start_time.minutes
+      seconds = 0
+      nanos   = 0
+    }
+    repeat_interval = var.repeat_interval
+  }
+
+  depends_on = [
+    google_storage_bucket_iam_member.source_reader,
+    google_storage_bucket_iam_member.source_viewer,
+    google_storage_bucket_iam_member.sink_writer,
+  ]
+}
+
+output "backup_bucket_names" {
+  description = "Backup bucket of each source bucket, in input order"
+  value       = google_storage_bucket.backup[*].name
+}
+
+output "transfer_job_names" {
+  description = "Transfer job of each source bucket, in input order"
+  value       = google_storage_transfer_job.this[*].name
+}
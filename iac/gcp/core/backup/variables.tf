@@ -0,0 +1,55 @@
+variable "project_id" {
+  description = "GCP project ID"
+  type        = string
+}
+
+variable "job_name" {
+  description = "Name prefixed to each transfer job's description"
+  type        = string
+}
+
+variable "location" {
+  description = "Location of the backup buckets"
+  type        = string
+  default     = "US"
+}
+
+variable "bucket_names" {
+  description = "Names of the GCS buckets to back up"
+  type        = list(string)
+  default     = []
+}
+
+variable "retention_days" {
+  description = "Days a replaced or deleted object is kept in the backup bucket"
+  type        = number
+}
+
+variable "start_date" {
+  description = "UTC date of the first transfer run; weekly schedules repeat on its weekday"
+  type = object({
+    year  = number
+    month = number
+    day   = number
+  })
+}
+
+variable "start_time" {
+  description = "UTC time of day of each transfer run"
+  type = object({
+    hours   = number
+    minutes = number
+  })
+}
+
+variable "repeat_interval" {
+  description = "Interval between transfer runs, e.g. 86400s"
+  type        = string
+  default     = "86400s"
+}
+
+variable "labels" {
+  description = "Labels"
+  type        = map(string)
+  default     = {}
+}
@@ -0,0 +1,66 @@
+# GCP Billing Budget Core Module
+
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+# Budget filters name projects by number
+data "google_project" "this" {
+  project_id = var.project_id
+}
+
+# Budgets email through Cloud Monitoring channels
+resource "google_monitoring_notification_channel" "email" {
+  count = length(var.email_addresses)
+
+  project      = var.project_id
+  display_name = "Budget ${var.name} ${var.email_addresses[count.index]}"
+  type         = "email"
+
+  labels = {
+    email_address = var.email_addresses[count.index]
+  }
+
+  user_labels = var.labels
+}
+
+resource "google_billing_budget" "this" {
+  billing_account = var.billing_account_id
+  display_name    = var.name
+
+  budget_filter {
+    projects = ["projects/${data.google_project.this.number}"]
+  }
+
+  # The API splits the amount into whole units (a string) and nanos
+  amount {
+    specified_amount {
+      currency_code = "USD"
+      units         = tostring(floor(var.amount))
+      nanos         = floor((var.amount - floor(var.amount)) * 1000000000 + 0.5)
+    }
+  }
+
+  # Threshold rules are fractions of the amount
+  dynamic "threshold_rules" {
+    for_each = var.threshold_percentages
+    content {
+      threshold_percent = threshold_rules.value / 100
+      spend_basis       = "CURRENT_SPEND"
+    }
+  }
+
+  all_updates_rule {
+    pubsub_topic                     = var.pubsub_topic
+    monitoring_notification_channels = concat(google_monitoring_notification_channel.email[*].id, var.notification_channels)
+  }
+}
+
+output "budget_id" {
+  value = google_billing_budget.this.id
+}
@@ -0,0 +1,48 @@
+variable "project_id" {
+  description = "GCP project the budget tracks"
+  type        = string
+}
+
+variable "billing_account_id" {
+  description = "Billing account owning the budget, e.g. 012345-6789AB-CDEF01"
+  type        = string
+}
+
+variable "name" {
+  description = "Budget display name"
+  type        = string
+}
+
+variable "amount" {
+  description = "Monthly limit in USD; the billing account must bill in USD"
+  type        = number
+}
+
+variable "threshold_percentages" {
+  description = "Percentages of the limit that send a notification"
+  type        = list(number)
+}
+
+variable "email_addresses" {
+  description = "Email addresses notified through new Cloud Monitoring channels"
+  type        = list(string)
+  default     = []
+}
+
+variable "notification_channels" {
+  description = "Existing Cloud Monitoring notification channel IDs"
+  type        = list(string)
+  default     = []
+}
+
+variable "pubsub_topic" {
+  description = "Pub/Sub topic receiving budget updates (projects/<project>/topics/<topic>)"
+  type        = string
+  default     = null
+}
+
+variable "labels" {
+  description = "Labels for the notification channels"
+  type        = map(string)
+  default     = {}
+}
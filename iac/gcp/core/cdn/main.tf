@@ -0,0 +1,83 @@
+# GCP Cloud CDN Core Module (backend bucket behind a global load balancer)
+
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+locals {
+  certificate_manager = var.ssl_certificate_id != null && can(regex("/locations/[^/]+/certificates/", var.ssl_certificate_id))
+}
+
+resource "google_compute_backend_bucket" "this" {
+  name        = "${var.name}-backend"
+  project     = var.project_id
+  bucket_name = var.bucket_name
+  enable_cdn  = true
+
+  cdn_policy {
+    cache_mode        = "CACHE_ALL_STATIC"
+    default_ttl       = var.default_ttl
+    client_ttl        = var.default_ttl
+    max_ttl           = max(var.default_ttl, 86400)
+    serve_while_stale = 86400
+  }
+}
+
+resource "google_compute_url_map" "this" {
+  name            = var.name
+  project         = var.project_id
+  default_service = google_compute_backend_bucket.this.id
+}
+
+resource "google_compute_global_address" "this" {
+  name    = "${var.name}-ip"
+  project = var.project_id
+
+  labels = var.labels
+}
+
+# HTTPS when a certificate is given, plain HTTP otherwise
+resource "google_compute_target_https_proxy" "this" {
+  count = var.ssl_certificate_id != null ? 1 : 0
+
+  name    = "${var.name}-https"
+  project = var.project_id
+  url_map = google_compute_url_map.this.id
+
+  # Compute SSL certificates and Certificate Manager certificates attach
+  # through different fields
+  ssl_certificates                 = local.certificate_manager ? null : [var.ssl_certificate_id]
+  certificate_manager_certificates = local.certificate_manager ? [var.ssl_certificate_id] : null
+}
+
+resource "google_compute_target_http_proxy" "this" {
+  count = var.ssl_certificate_id == null ? 1 : 0
+
+  name    = "${var.name}-http"
+  project = var.project_id
+  url_map = google_compute_url_map.this.id
+}
+
+resource "google_compute_global_forwarding_rule" "this" {
+  name                  = var.name
+  project               = var.project_id
+  ip_address            = google_compute_global_address.this.address
+  port_range            = var.ssl_certificate_id != null ? "443" : "80"
+  target                = var.ssl_certificate_id != null ? google_compute_target_https_proxy.this[0].id : google_compute_target_http_proxy.this[0].id
+  load_balancing_scheme = "EXTERNAL_MANAGED"
+
+  labels = var.labels
+}
+
+output "backend_bucket_id" {
+  value = google_compute_backend_bucket.this.id
+}
+
+output "ip_address" {
+  value = google_compute_global_address.this.address
+}
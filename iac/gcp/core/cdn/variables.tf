@@ -0,0 +1,32 @@
+variable "project_id" {
+  description = "GCP project ID"
+  type        = string
+}
+
+variable "name" {
+  description = "Name of the URL map and forwarding rule; prefixes the other resources"
+  type        = string
+}
+
+variable "bucket_name" {
+  description = "Origin GCS bucket name; its objects must be publicly readable"
+  type        = string
+}
+
+variable "ssl_certificate_id" {
+  description = "Compute SSL certificate or Certificate Manager certificate for HTTPS; null serves plain HTTP"
+  type        = string
+  default     = null
+}
+
+variable "default_ttl" {
+  description = "Seconds objects stay cached when the origin sends no Cache-Control"
+  type        = number
+  default     = 3600
+}
+
+variable "labels" {
+  description = "Labels"
+  type        = map(string)
+  default     = {}
+}
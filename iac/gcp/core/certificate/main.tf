@@ -0,0 +1,65 @@
+# GCP Certificate Manager Core Module (DNS authorization in Cloud DNS)
+
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+locals {
+  domains = distinct(concat([var.domain_name], var.subject_alternative_names))
+
+  # One authorization covers a domain and its wildcard
+  authorized_domains = distinct([for d in local.domains : trimprefix(d, "*.")])
+}
+
+resource "google_certificate_manager_dns_authorization" "this" {
+  for_each = toset(local.authorized_domains)
+
+  name    = "${var.name}-${replace(each.key, ".", "-")}"
+  project = var.project_id
+  domain  = each.key
+
+  labels = var.labels
+}
+
+resource "google_dns_record_set" "validation" {
+  for_each = google_certificate_manager_dns_authorization.this
+
+  project      = var.project_id
+  managed_zone = var.managed_zone
+  name         = each.value.dns_resource_record[0].name
+  type         = each.value.dns_resource_record[0].type
+  ttl          = 300
+  rrdatas      = [each.value.dns_resource_record[0].data]
+}
+
+resource "google_certificate_manager_certificate" "this" {
+  name    = var.name
+  project = var.project_id
+
+  managed {
+    domains            = local.domains
+    dns_authorizations = [for auth in google_certificate_manager_dns_authorization.this : auth.id]
+  }
+
+  labels = var.labels
+
+  depends_on = [google_dns_record_set.validation]
+}
+
+output "certificate_id" {
+  value = google_certificate_manager_certificate.this.id
+}
+
+output "status" {
+  value = google_certificate_manager_certificate.this.managed[0].state
+}
+
+output "validation_record_names" {
+  description = "Validation record name by domain (without its wildcard label)"
+  value       = { for d, record in google_dns_record_set.validation : d => record.name }
+}
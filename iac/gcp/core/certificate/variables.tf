@@ -0,0 +1,31 @@
+variable "project_id" {
+  description = "GCP project ID"
+  type        = string
+}
+
+variable "name" {
+  description = "Certificate name; prefixes the DNS authorizations"
+  type        = string
+}
+
+variable "domain_name" {
+  description = "Primary domain name of the certificate"
+  type        = string
+}
+
+variable "subject_alternative_names" {
+  description = "Additional domain names covered by the certificate"
+  type        = list(string)
+  default     = []
+}
+
+variable "managed_zone" {
+  description = "Cloud DNS managed zone that receives the authorization records"
+  type        = string
+}
+
+variable "labels" {
+  description = "Labels"
+  type        = map(string)
+  default     = {}
+}
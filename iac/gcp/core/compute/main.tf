@@ -39,6 +39,7 @@ resource "google_compute_instance" "this" {
     {
       ssh-keys = var.ssh_keys
     },
+    var.block_project_ssh_keys ? { block-project-ssh-keys = "TRUE" } : {},
     var.metadata
   )
   
@@ -49,10 +50,102 @@ resource "google_compute_instance" "this" {
     scopes = var.service_account_scopes
   }
   
+  dynamic "shielded_instance_config" {
+    for_each = var.shielded_vm ? [1] : []
+    content {
+      enable_secure_boot          = true
+      enable_vtpm                 = true
+      enable_integrity_monitoring = true
+    }
+  }
+  
   tags   = var.network_tags
   labels = var.labels
   
   allow_stopping_for_update = true
+
+  # Data disks are attached by google_compute_attached_disk
+  lifecycle {
+    ignore_changes = [attached_disk]
+  }
+}
+
+# ============================================================================
+# DATA DISKS
+# ============================================================================
+
+locals {
+  data_volumes = { for v in var.data_volumes : v.mount_name => v }
+  snapshotted  = { for k, v in local.data_volumes : k => v if v.snapshot_start_time != null }
+
+  # Resource policies are regional: the zone less its letter
+  region = join("-", slice(split("-", var.zone), 0, length(split("-", var.zone)) - 1))
+}
+
+resource "google_compute_disk" "data" {
+  for_each = local.data_volumes
+
+  name = each.value.name
+  type = each.value.type
+  size = each.value.size_gb
+  zone = var.zone
+
+  labels = var.labels
+}
+
+# The disk appears in the guest as /dev/disk/by-id/google-<mount name>
+resource "google_compute_attached_disk" "data" {
+  for_each = local.data_volumes
+
+  disk        = google_compute_disk.data[each.key].id
+  instance    = google_compute_instance.this.id
+  device_name = each.key
+}
+
+resource "google_compute_resource_policy" "snapshot" {
+  for_each = local.snapshotted
+
+  name   = each.value.name
+  region = local.region
+
+  snapshot_schedule_policy {
+    schedule {
+      dynamic "daily_schedule" {
+        for_each = each.value.snapshot_day == null ? [1] : []
+        content {
+          days_in_cycle = 1
+          start_time    = each.value.snapshot_start_time
+        }
+      }
+
+      dynamic "weekly_schedule" {
+        for_each = each.value.snapshot_day != null ? [1] : []
+        content {
+          day_of_weeks {
+            day        = each.value.snapshot_day
+            start_time = each.value.snapshot_start_time
+          }
+        }
+      }
+    }
+
+    retention_policy {
+      max_retention_days    = each.value.retention_days
+      on_source_disk_delete = "KEEP_AUTO_SNAPSHOTS"
+    }
+
+    snapshot_properties {
+      labels = var.labels
+    }
+  }
+}
+
+resource "google_compute_disk_resource_policy_attachment" "snapshot" {
+  for_each = local.snapshotted
+
+  name = google_compute_resource_policy.snapshot[each.key].name
+  disk = google_compute_disk.data[each.key].name
+  zone = var.zone
 }
 
 # Outputs
@@ -85,3 +178,8 @@ output "zone" {
   description = "Instance zone"
   value       = google_compute_instance.this.zone
 }
+
+output "data_volume_ids" {
+  description = "Persistent disk ID of each data disk, by mount name"
+  value       = { for name, disk in google_compute_disk.data : name => disk.id }
+}
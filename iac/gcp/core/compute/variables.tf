@@ -85,8 +85,34 @@ variable "network_tags" {
   default     = []
 }
 
+variable "shielded_vm" {
+  description = "Boot as a Shielded VM, with secure boot, the vTPM and integrity monitoring; boot_disk_image must support it"
+  type        = bool
+  default     = true
+}
+
+variable "block_project_ssh_keys" {
+  description = "Accept only the instance's own ssh_keys, not the project-wide SSH keys"
+  type        = bool
+  default     = true
+}
+
 variable "labels" {
   description = "Resource labels"
   type        = map(string)
   default     = {}
 }
+
+variable "data_volumes" {
+  description = "Persistent data disks to attach, keyed by mount_name, which is also the device name. A disk with a snapshot_start_time (HH:00 UTC) is snapshotted daily from then, or weekly on snapshot_day (e.g. SUNDAY), and each snapshot kept retention_days."
+  type = list(object({
+    mount_name          = string
+    name                = string
+    size_gb             = number
+    type                = string
+    snapshot_start_time = optional(string)
+    snapshot_day        = optional(string)
+    retention_days      = optional(number)
+  }))
+  default = []
+}
@@ -15,12 +15,16 @@ resource "google_sql_database_instance" "this" {
   database_version = var.database_version
   region           = var.region
   
+  # Customer-managed encryption key
+  encryption_key_name = var.encryption_key_name
+  
   settings {
     tier              = var.tier
     availability_type = var.high_availability ? "REGIONAL" : "ZONAL"
     disk_size         = var.disk_size_gb
     disk_type         = var.disk_type
     disk_autoresize   = var.disk_autoresize
+    user_labels       = var.labels
     
     backup_configuration {
       enabled                        = var.backup_enabled
@@ -116,3 +116,15 @@ variable "user_password" {
   type        = string
   sensitive   = true
 }
+
+variable "encryption_key_name" {
+  description = "Cloud KMS crypto key for CMEK (optional)"
+  type        = string
+  default     = null
+}
+
+variable "labels" {
+  description = "Labels applied to the instance"
+  type        = map(string)
+  default     = {}
+}
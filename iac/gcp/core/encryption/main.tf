@@ -1,5 +1,14 @@
 # GCP KMS Module
 
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
 resource "google_kms_key_ring" "this" {
   project  = var.project_id
   name     = var.key_ring_name
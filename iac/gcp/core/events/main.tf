@@ -1,11 +1,53 @@
 # GCP PubSub Topic
 
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
 resource "google_pubsub_topic" "this" {
   project = var.project_id
   name    = var.topic_name
   labels  = var.labels
 }
 
+# Eventarc Triggers
+# Deliver every message published to the topic to a Cloud Run service (or a
+# 2nd gen Cloud Function, which runs as one). Pub/Sub triggers cannot filter
+# on message content.
+resource "google_eventarc_trigger" "this" {
+  for_each = { for t in var.triggers : t.name => t }
+
+  project  = var.project_id
+  name     = each.key
+  location = each.value.region
+
+  matching_criteria {
+    attribute = "type"
+    value     = "google.cloud.pubsub.topic.v1.messagePublished"
+  }
+
+  destination {
+    cloud_run_service {
+      service = each.value.service
+      region  = each.value.region
+    }
+  }
+
+  transport {
+    pubsub {
+      topic = google_pubsub_topic.this.id
+    }
+  }
+
+  service_account = var.trigger_service_account
+  labels          = var.labels
+}
+
 output "topic_id" {
   value = google_pubsub_topic.this.id
 }
@@ -13,3 +55,8 @@ output "topic_id" {
 output "topic_name" {
   value = google_pubsub_topic.this.name
 }
+
+output "trigger_ids" {
+  description = "ID of each Eventarc trigger, by name"
+  value       = { for name, t in google_eventarc_trigger.this : name => t.id }
+}
@@ -8,6 +8,22 @@ variable "topic_name" {
   type        = string
 }
 
+variable "triggers" {
+  description = "Eventarc triggers delivering topic messages to Cloud Run services"
+  type = list(object({
+    name    = string
+    service = string
+    region  = string
+  }))
+  default = []
+}
+
+variable "trigger_service_account" {
+  description = "Service account the triggers invoke their services as; null uses the Compute Engine default"
+  type        = string
+  default     = null
+}
+
 variable "labels" {
   description = "Resource labels"
   type        = map(string)
@@ -28,11 +28,22 @@ resource "google_project_iam_member" "project" {
   member  = var.create_service_account ? "serviceAccount:${google_service_account.this[0].email}" : var.member
 }
 
+# Cross-Project Impersonation
+# Members from other projects are allowed to mint tokens for this account
+resource "google_service_account_iam_member" "trusted" {
+  for_each = var.create_service_account ? toset(var.trusted_members) : toset([])
+  
+  service_account_id = google_service_account.this[0].name
+  role               = var.trusted_member_role
+  member             = each.value
+}
+
 # Service Account Key
 resource "google_service_account_key" "this" {
   count = var.create_key && var.create_service_account ? 1 : 0
   
   service_account_id = google_service_account.this[0].name
+  keepers            = var.key_keepers
 }
 
 # Custom Role
@@ -58,7 +69,7 @@ output "service_account_name" {
 
 output "private_key" {
   description = "The private key in JSON format"
-  value       = var.create_key ? google_service_account_key.this[0].private_key : null
+  value       = var.create_key && var.create_service_account ? google_service_account_key.this[0].private_key : null
   sensitive   = true
 }
 
@@ -42,6 +42,19 @@ variable "member" {
   default     = null
 }
 
+# Trust Variables
+variable "trusted_members" {
+  description = "IAM members (from any project) allowed to impersonate the service account"
+  type        = list(string)
+  default     = []
+}
+
+variable "trusted_member_role" {
+  description = "Role granted to trusted members on the service account"
+  type        = string
+  default     = "roles/iam.serviceAccountTokenCreator"
+}
+
 # Key Variables
 variable "create_key" {
   description = "Create a service account key"
@@ -49,6 +62,12 @@ variable "create_key" {
   default     = false
 }
 
+variable "key_keepers" {
+  description = "Arbitrary values that replace the key when changed; GCP keys carry no other metadata"
+  type        = map(string)
+  default     = null
+}
+
 # Custom Role Variables
 variable "create_custom_role" {
   description = "Create a custom IAM role"
@@ -1,40 +1,118 @@
+# GCP Cloud Functions (2nd gen)
+# Counterpart of the AWS Lambda core module
+
 terraform {
   required_providers {
     google = {
-      source = "hashicorp/google"
+      source  = "hashicorp/google"
+      version = "~> 5.0"
     }
   }
 }
 
-variable "function_name" { type = string }
-variable "handler" { type = string }
-variable "runtime" { type = string }
-variable "filename" { type = string }
-variable "environment_variables" { type = map(string) }
-variable "tags" { type = map(string) }
+locals {
+  # Keep memory on the familiar Cloud Functions tiers; round up to the nearest one
+  memory_tiers        = [128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768]
+  available_memory_mb = [for m in local.memory_tiers : m if m >= var.memory_size][0]
+
+  # "main.handler" -> "handler": GCP entry points name the function, not the file
+  entry_point = element(split(".", var.handler), length(split(".", var.handler)) - 1)
+
+  # Content-addressed object name so a new package triggers a new build
+  archive_name = var.source_md5 != null ? "${var.function_name}-${var.source_md5}.zip" : "${var.function_name}.zip"
+}
 
-resource "google_storage_bucket" "bucket" {
+# Source Bucket
+resource "google_storage_bucket" "source" {
   name     = "${var.function_name}-src"
+  project  = var.project_id
   location = "US"
+
+  uniform_bucket_level_access = true
+  labels                      = var.tags
+
+  dynamic "encryption" {
+    for_each = var.kms_key_name != null ? [1] : []
+    content {
+      default_kms_key_name = var.kms_key_name
+    }
+  }
 }
 
 resource "google_storage_bucket_object" "archive" {
-  name   = "source.zip"
-  bucket = google_storage_bucket.bucket.name
+  name   = local.archive_name
+  bucket = google_storage_bucket.source.name
   source = var.filename
 }
 
-resource "google_cloudfunctions_function" "function" {
+# Cloud Function
+resource "google_cloudfunctions2_function" "this" {
   name        = var.function_name
+  project     = var.project_id
+  location    = var.region
   description = "Managed by Terraform SEA"
-  runtime     = var.runtime == "python3.11" ? "python311" : "nodejs18"
-
-  available_memory_mb   = 128
-  source_archive_bucket = google_storage_bucket.bucket.name
-  source_archive_object = google_storage_bucket_object.archive.name
-  trigger_http          = true
-  entry_point           = replace(var.handler, ".handler", "")
-  
-  environment_variables = var.environment_variables
-  labels                = var.tags
+
+  # Customer-managed key for the function and its build artifacts
+  kms_key_name = var.kms_key_name
+
+  build_config {
+    runtime     = var.runtime
+    entry_point = local.entry_point
+
+    source {
+      storage_source {
+        bucket = google_storage_bucket.source.name
+        object = google_storage_bucket_object.archive.name
+      }
+    }
+  }
+
+  service_config {
+    available_memory   = "${local.available_memory_mb}M"
+    timeout_seconds    = min(var.timeout, 3600)
+    min_instance_count = var.min_instance_count
+    max_instance_count = var.max_instance_count
+
+    # Serverless VPC Access
+    vpc_connector = var.vpc_connector
+
+    # Without an HTTP endpoint the function is only reachable from inside the project
+    ingress_settings = var.http_endpoint ? "ALLOW_ALL" : "ALLOW_INTERNAL_ONLY"
+
+    environment_variables = var.environment_variables
+  }
+
+  labels = var.tags
+}
+
+# Invoker binding on the underlying Cloud Run service
+resource "google_cloud_run_service_iam_member" "invoker" {
+  for_each = toset(var.invoker_members)
+
+  project  = var.project_id
+  location = var.region
+  service  = google_cloudfunctions2_function.this.service_config[0].service
+  role     = "roles/run.invoker"
+  member   = each.value
+}
+
+# Outputs
+output "function_id" {
+  description = "ID of the Cloud Function"
+  value       = google_cloudfunctions2_function.this.id
+}
+
+output "function_name" {
+  description = "Name of the Cloud Function"
+  value       = google_cloudfunctions2_function.this.name
+}
+
+output "function_uri" {
+  description = "HTTPS URI of the underlying Cloud Run service"
+  value       = google_cloudfunctions2_function.this.service_config[0].uri
+}
+
+output "source_bucket" {
+  description = "Bucket holding the deployment packages"
+  value       = google_storage_bucket.source.name
 }
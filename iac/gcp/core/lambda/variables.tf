@@ -1,59 +1,38 @@
 variable "function_name" {
-  description = "Name of the Lambda function"
+  description = "Name of the Cloud Function"
   type        = string
 }
 
-variable "description" {
-  description = "Description of what the function does"
-  type        = string
-  default     = null
-}
-
 variable "handler" {
-  description = "Function entrypoint (e.g. index.handler)"
+  description = "Function entrypoint (e.g. main.handler)"
   type        = string
 }
 
 variable "runtime" {
-  description = "Lambda runtime (e.g. nodejs18.x, python3.9)"
+  description = "Cloud Functions runtime identifier (e.g. python311, nodejs20)"
   type        = string
-  default     = "python3.9"
-}
-
-variable "memory_size" {
-  description = "Memory size in MB"
-  type        = number
-  default     = 128
 }
 
-variable "timeout" {
-  description = "Function timeout in seconds"
-  type        = number
-  default     = 3
-}
-
-# Source Code (Option A: Local file)
-variable "filename" {
-  description = "Path to the deployment package (zip)"
+variable "project_id" {
+  description = "GCP project ID"
   type        = string
   default     = null
 }
 
-variable "source_code_hash" {
-  description = "Base64-encoded SHA256 hash of the package"
+variable "region" {
+  description = "Region to deploy the function in"
   type        = string
-  default     = null
+  default     = "us-central1"
 }
 
-# Source Code (Option B: S3)
-variable "s3_bucket" {
-  description = "S3 bucket location containing the package"
+variable "filename" {
+  description = "Path to the deployment package (zip)"
   type        = string
   default     = null
 }
 
-variable "s3_key" {
-  description = "S3 key for the package"
+variable "source_md5" {
+  description = "MD5 of the deployment package, used to version the uploaded object"
   type        = string
   default     = null
 }
@@ -64,46 +43,56 @@ variable "environment_variables" {
   default     = {}
 }
 
-# VPC Configuration
-variable "vpc_subnet_ids" {
-  description = "List of subnet IDs for VPC access"
-  type        = list(string)
-  default     = null
+variable "memory_size" {
+  description = "Memory size in MB (rounded up to the nearest Cloud Functions tier)"
+  type        = number
+  default     = 128
 }
 
-variable "vpc_security_group_ids" {
-  description = "List of security group IDs for VPC access"
-  type        = list(string)
-  default     = null
+variable "timeout" {
+  description = "Function timeout in seconds (capped at 3600)"
+  type        = number
+  default     = 60
 }
 
-variable "tracing_mode" {
-  description = "X-Ray tracing mode (PassThrough or Active)"
-  type        = string
-  default     = "PassThrough"
+variable "min_instance_count" {
+  description = "Instances kept running while the function is idle"
+  type        = number
+  default     = 0
 }
 
-variable "log_retention_days" {
-  description = "CloudWatch log retention in days"
+variable "max_instance_count" {
+  description = "Maximum number of concurrently running instances"
   type        = number
-  default     = 14
+  default     = 100
 }
 
-# Triggers
-variable "create_apigw_permission" {
-  description = "Create permission for API Gateway invoke"
+variable "vpc_connector" {
+  description = "Serverless VPC Access connector name or self link"
+  type        = string
+  default     = null
+}
+
+variable "http_endpoint" {
+  description = "Accept HTTPS requests from outside the project"
   type        = bool
   default     = false
 }
 
-variable "apigw_execution_arn" {
-  description = "Execution ARN of the API Gateway"
+variable "invoker_members" {
+  description = "Members granted roles/run.invoker on the function (use allUsers for public access)"
+  type        = list(string)
+  default     = []
+}
+
+variable "kms_key_name" {
+  description = "Cloud KMS crypto key for the function and source bucket (optional)"
   type        = string
   default     = null
 }
 
 variable "tags" {
-  description = "Resource tags"
+  description = "Resource labels"
   type        = map(string)
   default     = {}
 }
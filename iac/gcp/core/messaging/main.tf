@@ -1,28 +1,162 @@
+# GCP Messaging (Pub/Sub)
+# Counterpart of the AWS SQS/SNS core module
+
 terraform {
   required_providers {
     google = {
-      source = "hashicorp/google"
+      source  = "hashicorp/google"
+      version = "~> 5.0"
     }
   }
 }
 
-variable "queue_name" { type = string }
-variable "topic_name" { type = string }
-variable "create_queue" { type = bool }
-variable "create_topic" { type = bool }
-variable "tags" { type = map(string) }
+locals {
+  # Pub/Sub has no standalone queue: a queue is a topic plus a single pull
+  # subscription that consumers read from.
+  topic_name = var.create_queue ? var.queue_name : var.topic_name
+
+  # Label keys and values must be lowercase
+  labels = { for k, v in var.tags : lower(k) => lower(v) }
+}
+
+# ============================================================================
+# TOPICS
+# ============================================================================
 
-# Pub/Sub Topic
 resource "google_pubsub_topic" "this" {
-  count = var.create_topic ? 1 : 0
-  name  = var.topic_name
-  labels = var.tags
+  count = var.create_queue || var.create_topic ? 1 : 0
+
+  name         = local.topic_name
+  project      = var.project_id
+  kms_key_name = var.kms_key_name
+  labels       = local.labels
 }
 
-# Pub/Sub Subscription (acts as a queue)
-resource "google_pubsub_subscription" "this" {
+# ============================================================================
+# QUEUE SUBSCRIPTION
+# ============================================================================
+
+resource "google_pubsub_subscription" "queue" {
   count = var.create_queue ? 1 : 0
-  name  = var.queue_name
-  topic = var.topic_name # In GCP, queues (subscriptions) need a topic. Simplified for parity.
-  labels = var.tags
+
+  name    = var.queue_name
+  project = var.project_id
+  topic   = google_pubsub_topic.this[0].id
+  labels  = local.labels
+
+  ack_deadline_seconds       = var.ack_deadline_seconds
+  message_retention_duration = "${var.message_retention_seconds}s"
+
+  dynamic "dead_letter_policy" {
+    for_each = var.dead_letter ? [1] : []
+    content {
+      dead_letter_topic     = google_pubsub_topic.dead_letter[0].id
+      max_delivery_attempts = var.max_delivery_attempts
+    }
+  }
+}
+
+# ============================================================================
+# DEAD LETTERS
+# ============================================================================
+
+# Messages that exhaust their delivery attempts are published to <name>-dlq
+# and kept by a subscription of the same name until someone reads them
+resource "google_pubsub_topic" "dead_letter" {
+  count = var.create_queue && var.dead_letter ? 1 : 0
+
+  name         = "${var.queue_name}-dlq"
+  project      = var.project_id
+  kms_key_name = var.kms_key_name
+  labels       = local.labels
+}
+
+resource "google_pubsub_subscription" "dead_letter" {
+  count = var.create_queue && var.dead_letter ? 1 : 0
+
+  name    = "${var.queue_name}-dlq"
+  project = var.project_id
+  topic   = google_pubsub_topic.dead_letter[0].id
+  labels  = local.labels
+
+  message_retention_duration = "604800s" # 7 days, the maximum
+}
+
+# The Pub/Sub service agent forwards dead letters, so it must be able to
+# publish to the dead-letter topic and acknowledge on the queue subscription
+data "google_project" "this" {
+  count = var.create_queue && var.dead_letter ? 1 : 0
+
+  project_id = var.project_id
+}
+
+resource "google_pubsub_topic_iam_member" "dead_letter_publisher" {
+  count = var.create_queue && var.dead_letter ? 1 : 0
+
+  project = var.project_id
+  topic   = google_pubsub_topic.dead_letter[0].name
+  role    = "roles/pubsub.publisher"
+  member  = "serviceAccount:service-${data.google_project.this[0].number}@gcp-sa-pubsub.iam.gserviceaccount.com"
+}
+
+resource "google_pubsub_subscription_iam_member" "dead_letter_subscriber" {
+  count = var.create_queue && var.dead_letter ? 1 : 0
+
+  project      = var.project_id
+  subscription = google_pubsub_subscription.queue[0].name
+  role         = "roles/pubsub.subscriber"
+  member       = "serviceAccount:service-${data.google_project.this[0].number}@gcp-sa-pubsub.iam.gserviceaccount.com"
+}
+
+# ============================================================================
+# ACCESS CONTROL
+# ============================================================================
+
+# Members are added to the resource's policy, not set as its only members,
+# so grants made elsewhere survive. Keyed by member and role, so removing one
+# grant leaves the others in place; members must be known at plan time
+resource "google_pubsub_topic_iam_member" "this" {
+  for_each = length(google_pubsub_topic.this) > 0 ? {
+    for m in var.topic_iam_members : "${m.member}-${m.role}" => m
+  } : {}
+
+  project = var.project_id
+  topic   = google_pubsub_topic.this[0].name
+  role    = each.value.role
+  member  = each.value.member
+}
+
+resource "google_pubsub_subscription_iam_member" "this" {
+  for_each = var.create_queue ? {
+    for m in var.subscription_iam_members : "${m.member}-${m.role}" => m
+  } : {}
+
+  project      = var.project_id
+  subscription = google_pubsub_subscription.queue[0].name
+  role         = each.value.role
+  member       = each.value.member
+}
+
+# ============================================================================
+# OUTPUTS
+# ============================================================================
+
+output "topic_id" {
+  description = "Full resource ID of the topic (projects/<project>/topics/<name>)"
+  value       = length(google_pubsub_topic.this) > 0 ? google_pubsub_topic.this[0].id : null
+}
+
+output "subscription_id" {
+  description = "Full resource ID of the queue subscription"
+  value       = var.create_queue ? google_pubsub_subscription.queue[0].id : null
+}
+
+output "dead_letter_subscription_id" {
+  description = "Full resource ID of the dead-letter subscription"
+  value       = var.create_queue && var.dead_letter ? google_pubsub_subscription.dead_letter[0].id : null
+}
+
+output "subscription_pull_url" {
+  description = "REST endpoint consumers pull queue messages from"
+  value       = var.create_queue ? "https://pubsub.googleapis.com/v1/${google_pubsub_subscription.queue[0].id}:pull" : null
 }
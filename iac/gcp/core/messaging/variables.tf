@@ -1,126 +1,86 @@
-# SQS Configuration
+# Queue Configuration
 variable "create_queue" {
-  description = "Create SQS queue"
+  description = "Create a topic with a pull subscription acting as a queue"
   type        = bool
   default     = false
 }
 
 variable "queue_name" {
-  description = "Name of the queue"
+  description = "Name of the queue (used for both the topic and its subscription)"
   type        = string
   default     = null
 }
 
-variable "fifo_queue" {
-  description = "Boolean designating a FIFO queue"
-  type        = bool
-  default     = false
-}
-
-variable "content_based_deduplication" {
-  description = "Enable content-based deduplication for FIFO queues"
-  type        = bool
-  default     = false
-}
-
-variable "visibility_timeout_seconds" {
-  description = "Visibility timeout for the queue"
+variable "ack_deadline_seconds" {
+  description = "Time a subscriber has to acknowledge a message (10-600)"
   type        = number
-  default     = 30
+  default     = 10
 }
 
 variable "message_retention_seconds" {
-  description = "Message retention in seconds"
+  description = "How long unacknowledged messages are retained (600-604800)"
   type        = number
-  default     = 345600 # 4 days
+  default     = 604800 # 7 days
 }
 
-variable "max_message_size" {
-  description = "Max message size in bytes"
-  type        = number
-  default     = 262144 # 256 KB
-}
-
-variable "delay_seconds" {
-  description = "Delay seconds"
-  type        = number
-  default     = 0
-}
-
-variable "receive_wait_time_seconds" {
-  description = "Receive wait time seconds"
-  type        = number
-  default     = 0
-}
-
-variable "sqs_managed_sse_enabled" {
-  description = "Enable server-side encryption with SQS-owned key"
-  type        = bool
-  default     = true
-}
-
-# Dead Letter Queue
-variable "create_dlq" {
-  description = "Create a managed Dead Letter Queue"
+variable "dead_letter" {
+  description = "Forward messages that exhaust max_delivery_attempts to a <queue_name>-dlq topic and subscription"
   type        = bool
   default     = false
 }
 
-variable "dead_letter_queue_arn" {
-  description = "ARN of an existing DLQ (if create_dlq is false)"
-  type        = string
-  default     = null
-}
-
-variable "max_receive_count" {
-  description = "Max receive count before moving to DLQ"
+variable "max_delivery_attempts" {
+  description = "Delivery attempts before a message is dead-lettered (5-100)"
   type        = number
-  default     = 3
+  default     = 5
 }
 
-variable "dlq_message_retention_seconds" {
-  description = "Message retention for DLQ"
-  type        = number
-  default     = 1209600 # 14 days
-}
-
-# SNS Configuration
+# Topic Configuration
 variable "create_topic" {
-  description = "Create SNS topic"
+  description = "Create Pub/Sub topic"
   type        = bool
   default     = false
 }
 
 variable "topic_name" {
-  description = "Name of the SNS topic"
+  description = "Name of the topic"
   type        = string
   default     = null
 }
 
-variable "fifo_topic" {
-  description = "Boolean designating a FIFO topic"
-  type        = bool
-  default     = false
+variable "project_id" {
+  description = "GCP project ID (defaults to the provider project)"
+  type        = string
+  default     = null
 }
 
-variable "kms_master_key_id" {
-  description = "ID of an AWS-managed customer master key (CMK) for SNS"
+variable "kms_key_name" {
+  description = "Cloud KMS crypto key for topic encryption (optional)"
   type        = string
-  default     = "alias/aws/sns"
+  default     = null
+}
+
+# Access Control
+variable "topic_iam_members" {
+  description = "Roles granted on the topic (e.g. roles/pubsub.publisher to serviceAccount:<email>)"
+  type = list(object({
+    role   = string
+    member = string
+  }))
+  default = []
 }
 
-variable "subscriptions" {
-  description = "List of subscriptions for the topic"
+variable "subscription_iam_members" {
+  description = "Roles granted on the queue subscription (e.g. roles/pubsub.subscriber to serviceAccount:<email>)"
   type = list(object({
-    protocol             = string
-    endpoint             = string
-    raw_message_delivery = optional(bool)
+    role   = string
+    member = string
   }))
   default = []
 }
 
 variable "tags" {
-  description = "Resource tags"
+  description = "Resource labels"
   type        = map(string)
   default     = {}
 }
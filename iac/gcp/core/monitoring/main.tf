@@ -34,6 +34,7 @@ resource "google_monitoring_alert_policy" "this" {
   }
   
   notification_channels = var.notification_channels
+  user_labels           = var.labels
 }
 
 # Notification Channel (Email)
@@ -46,6 +47,8 @@ resource "google_monitoring_notification_channel" "email" {
   labels = {
     email_address = var.email_address
   }
+
+  user_labels = var.labels
 }
 
 output "alert_policy_id" {
@@ -1,16 +1,83 @@
-variable "create_alert_policy" { type = bool; default = false }
-variable "display_name" { type = string; default = null }
-variable "combiner" { type = string; default = "OR" }
-
-variable "condition_display_name" { type = string; default = "Condition" }
-variable "filter" { type = string; default = null } # Required: e.g. "metric.type=\"compute.googleapis.com/instance/cpu/utilization\" AND resource.type=\"gce_instance\""
-variable "duration" { type = string; default = "60s" }
-variable "comparison" { type = string; default = "COMPARISON_GT" }
-variable "alignment_period" { type = string; default = "60s" }
-variable "per_series_aligner" { type = string; default = "ALIGN_MEAN" }
-variable "threshold_value" { type = number; default = 0.8 }
-
-variable "notification_channels" { type = list(string); default = [] }
-
-variable "create_email_channel" { type = bool; default = false }
-variable "email_address" { type = string; default = null }
+variable "create_alert_policy" {
+  description = "Create an alert policy"
+  type        = bool
+  default     = false
+}
+
+variable "display_name" {
+  description = "Alert policy display name"
+  type        = string
+  default     = null
+}
+
+variable "combiner" {
+  description = "How conditions are combined (AND, OR, AND_WITH_MATCHING_RESOURCE)"
+  type        = string
+  default     = "OR"
+}
+
+variable "condition_display_name" {
+  description = "Display name of the threshold condition"
+  type        = string
+  default     = "Condition"
+}
+
+variable "filter" {
+  description = "Monitoring filter selecting the time series, e.g. metric.type=\"compute.googleapis.com/instance/cpu/utilization\" AND resource.type=\"gce_instance\""
+  type        = string
+  default     = null
+}
+
+variable "duration" {
+  description = "How long the threshold must be breached before alerting"
+  type        = string
+  default     = "60s"
+}
+
+variable "comparison" {
+  description = "Threshold comparison (COMPARISON_GT, COMPARISON_LT, ...)"
+  type        = string
+  default     = "COMPARISON_GT"
+}
+
+variable "alignment_period" {
+  description = "Period over which series are aligned"
+  type        = string
+  default     = "60s"
+}
+
+variable "per_series_aligner" {
+  description = "Per-series aligner (ALIGN_MEAN, ALIGN_MAX, ...)"
+  type        = string
+  default     = "ALIGN_MEAN"
+}
+
+variable "threshold_value" {
+  description = "Alert threshold"
+  type        = number
+  default     = 0.8
+}
+
+variable "notification_channels" {
+  description = "Existing notification channel IDs"
+  type        = list(string)
+  default     = []
+}
+
+variable "create_email_channel" {
+  description = "Create an email notification channel"
+  type        = bool
+  default     = false
+}
+
+variable "email_address" {
+  description = "Address of the email notification channel"
+  type        = string
+  default     = null
+}
+
+variable "labels" {
+  description = "User labels applied to the alert policy and notification channel"
+  type        = map(string)
+  default     = {}
+}
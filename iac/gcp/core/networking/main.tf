@@ -15,6 +15,9 @@ resource "google_compute_network" "this" {
   auto_create_subnetworks = var.auto_create_subnetworks
   routing_mode            = var.routing_mode
   description             = var.description
+  
+  # A unique local /48 for subnets with internal IPv6
+  enable_ula_internal_ipv6 = var.enable_ipv6
 }
 
 resource "google_compute_subnetwork" "subnets" {
@@ -27,6 +30,20 @@ resource "google_compute_subnetwork" "subnets" {
   
   private_ip_google_access = lookup(var.subnets[count.index], "private_ip_google_access", true)
   
+  # GCP picks each subnet's /64; EXTERNAL ones are reachable from the internet
+  stack_type       = var.enable_ipv6 ? "IPV4_IPV6" : "IPV4_ONLY"
+  ipv6_access_type = var.enable_ipv6 ? coalesce(var.subnets[count.index].ipv6_access_type, "INTERNAL") : null
+  
+  # Subnet flow logs go to Cloud Logging, so there is no sink to configure
+  dynamic "log_config" {
+    for_each = var.enable_flow_logs ? [1] : []
+    content {
+      aggregation_interval = "INTERVAL_5_SEC"
+      flow_sampling        = 0.5
+      metadata             = "INCLUDE_ALL_METADATA"
+    }
+  }
+  
   dynamic "secondary_ip_range" {
     for_each = lookup(var.subnets[count.index], "secondary_ip_ranges", [])
     content {
@@ -73,6 +90,42 @@ resource "google_compute_firewall" "allow_ssh" {
   source_ranges = var.ssh_source_ranges
 }
 
+resource "google_compute_firewall" "rules" {
+  for_each = { for r in var.firewall_rules : r.name => r }
+  
+  name        = "${var.network_name}-${each.key}"
+  network     = google_compute_network.this.name
+  description = each.value.description != "" ? each.value.description : null
+  direction   = upper(each.value.direction)
+  
+  allow {
+    protocol = each.value.protocol
+    ports    = length(each.value.ports) > 0 ? each.value.ports : null
+  }
+  
+  source_ranges      = each.value.direction == "ingress" ? each.value.cidr_blocks : null
+  destination_ranges = each.value.direction == "egress" ? each.value.cidr_blocks : null
+}
+
+# Private service access
+resource "google_compute_global_address" "private_service_access" {
+  count = var.private_service_access ? 1 : 0
+  
+  name          = "${var.network_name}-private-services"
+  purpose       = "VPC_PEERING"
+  address_type  = "INTERNAL"
+  prefix_length = var.private_service_prefix_length
+  network       = google_compute_network.this.id
+}
+
+resource "google_service_networking_connection" "private_service_access" {
+  count = var.private_service_access ? 1 : 0
+  
+  network                 = google_compute_network.this.id
+  service                 = "servicenetworking.googleapis.com"
+  reserved_peering_ranges = [google_compute_global_address.private_service_access[0].name]
+}
+
 # Outputs
 output "network_name" {
   description = "Network name"
@@ -103,3 +156,25 @@ output "subnet_regions" {
   description = "Subnet regions"
   value       = google_compute_subnetwork.subnets[*].region
 }
+
+output "ipv6_cidr" {
+  description = "The network's internal IPv6 range, when enable_ipv6 is set"
+  value       = var.enable_ipv6 ? google_compute_network.this.internal_ipv6_range : null
+}
+
+output "subnet_ipv6_cidrs" {
+  description = "IPv6 /64 of each subnet: external or internal by its ipv6_access_type"
+  value = var.enable_ipv6 ? [
+    for s in google_compute_subnetwork.subnets : s.ipv6_access_type == "EXTERNAL" ? s.external_ipv6_prefix : s.internal_ipv6_prefix
+  ] : []
+}
+
+output "firewall_rule_ids" {
+  description = "IDs of the firewall_rules firewalls, by rule name"
+  value       = { for name, fw in google_compute_firewall.rules : name => fw.id }
+}
+
+output "private_service_connection_id" {
+  description = "Service networking connection ID, when private service access is on"
+  value       = length(google_service_networking_connection.private_service_access) > 0 ? google_service_networking_connection.private_service_access[0].id : null
+}
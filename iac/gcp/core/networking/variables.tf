@@ -28,6 +28,7 @@ variable "subnets" {
     cidr                     = string
     region                   = string
     private_ip_google_access = optional(bool)
+    ipv6_access_type         = optional(string)
     secondary_ip_ranges      = optional(list(object({
       range_name    = string
       ip_cidr_range = string
@@ -36,6 +37,12 @@ variable "subnets" {
   default = []
 }
 
+variable "enable_ipv6" {
+  description = "Dual stack subnets, with EXTERNAL or INTERNAL IPv6 as each subnet's ipv6_access_type says (INTERNAL when unset)"
+  type        = bool
+  default     = false
+}
+
 variable "create_internal_firewall" {
   description = "Create firewall rule allowing internal traffic"
   type        = bool
@@ -53,3 +60,34 @@ variable "ssh_source_ranges" {
   type        = list(string)
   default     = ["0.0.0.0/0"]
 }
+
+variable "firewall_rules" {
+  description = "Firewall rules from the networking facade: direction ingress or egress, protocol tcp, udp, icmp or all, ports as \"443\" or \"8000-8080\" (empty for every port)"
+  type = list(object({
+    name        = string
+    direction   = string
+    protocol    = string
+    ports       = list(string)
+    cidr_blocks = list(string)
+    description = string
+  }))
+  default = []
+}
+
+variable "enable_flow_logs" {
+  description = "Turn on VPC flow logs for every subnet"
+  type        = bool
+  default     = false
+}
+
+variable "private_service_access" {
+  description = "Reserve a range and peer it with servicenetworking.googleapis.com, so Cloud SQL and other producer services get private IPs in the network"
+  type        = bool
+  default     = false
+}
+
+variable "private_service_prefix_length" {
+  description = "Prefix length of the range reserved for private service access"
+  type        = number
+  default     = 16
+}
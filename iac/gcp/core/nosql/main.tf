@@ -1,5 +1,14 @@
 # GCP Firestore Core Module
 
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
 resource "google_firestore_database" "this" {
   project     = var.project_id
   name        = var.database_id
@@ -10,6 +19,24 @@ resource "google_firestore_database" "this" {
   # delete_protection_state = "DELETE_PROTECTION_DISABLED"
 }
 
+# Composite indexes, ascending on each field in order, for queries that
+# filter on one field and order by another
+resource "google_firestore_index" "this" {
+  for_each = { for index in var.composite_indexes : index.name => index }
+
+  project    = var.project_id
+  database   = google_firestore_database.this.name
+  collection = each.value.collection
+
+  dynamic "fields" {
+    for_each = each.value.fields
+    content {
+      field_path = fields.value
+      order      = "ASCENDING"
+    }
+  }
+}
+
 output "database_id" {
   value = google_firestore_database.this.name
 }
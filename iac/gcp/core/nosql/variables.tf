@@ -20,3 +20,13 @@ variable "type" {
   type        = string
   default     = "FIRESTORE_NATIVE"
 }
+
+variable "composite_indexes" {
+  description = "Composite indexes keyed by name, each over two or more fields of a collection"
+  type = list(object({
+    name       = string
+    collection = string
+    fields     = list(string)
+  }))
+  default = []
+}
@@ -1,5 +1,14 @@
 # GCP Secret Manager
 
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
 resource "google_secret_manager_secret" "this" {
   project   = var.project_id
   secret_id = var.secret_id
@@ -0,0 +1,57 @@
+# GCP State Backend Core Module
+# Cloud Storage bucket holding Terraform state. The gcs backend locks state
+# with a lock object next to it, so no lock store is needed.
+
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+resource "google_storage_bucket" "this" {
+  name          = var.bucket_name
+  project       = var.project_id
+  location      = var.location
+  storage_class = "STANDARD"
+  force_destroy = var.force_destroy
+
+  # State holds secrets in plain text
+  uniform_bucket_level_access = true
+  public_access_prevention    = "enforced"
+
+  # Every write of the state keeps the previous one, so a bad apply can be
+  # rolled back
+  versioning {
+    enabled = true
+  }
+
+  dynamic "encryption" {
+    for_each = var.encryption_key_name != null ? [1] : []
+    content {
+      default_kms_key_name = var.encryption_key_name
+    }
+  }
+
+  lifecycle_rule {
+    action {
+      type = "Delete"
+    }
+    condition {
+      days_since_noncurrent_time = var.noncurrent_version_days
+      with_state                 = "ARCHIVED"
+    }
+  }
+
+  labels = var.labels
+}
+
+output "bucket_name" {
+  value = google_storage_bucket.this.name
+}
+
+output "bucket_url" {
+  value = google_storage_bucket.this.url
+}
@@ -0,0 +1,40 @@
+variable "bucket_name" {
+  description = "Name of the state bucket"
+  type        = string
+}
+
+variable "project_id" {
+  description = "GCP project ID"
+  type        = string
+  default     = null
+}
+
+variable "location" {
+  description = "Bucket location"
+  type        = string
+  default     = "US"
+}
+
+variable "encryption_key_name" {
+  description = "Cloud KMS key encrypting the bucket; Google-managed keys when null"
+  type        = string
+  default     = null
+}
+
+variable "noncurrent_version_days" {
+  description = "Days a replaced state version is kept"
+  type        = number
+  default     = 90
+}
+
+variable "force_destroy" {
+  description = "Delete the bucket on destroy even when it holds state"
+  type        = bool
+  default     = false
+}
+
+variable "labels" {
+  description = "Resource labels"
+  type        = map(string)
+  default     = {}
+}
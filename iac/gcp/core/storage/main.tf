@@ -12,18 +12,26 @@ terraform {
 
 resource "google_storage_bucket" "this" {
   name          = var.bucket_name
+  project       = var.project_id
   location      = var.location
   storage_class = var.storage_class
   
   uniform_bucket_level_access = var.uniform_bucket_level_access
   force_destroy               = var.force_destroy
   
+  # Enforced refuses allUsers and allAuthenticatedUsers grants on the bucket
+  # and its objects; inherited leaves it to the organization policy
+  public_access_prevention = var.block_public_access ? "enforced" : "inherited"
+  
   versioning {
     enabled = var.versioning_enabled
   }
   
-  encryption {
-    default_kms_key_name = var.encryption_key_name
+  dynamic "encryption" {
+    for_each = var.encryption_key_name != null ? [1] : []
+    content {
+      default_kms_key_name = var.encryption_key_name
+    }
   }
   
   dynamic "lifecycle_rule" {
@@ -43,20 +51,59 @@ resource "google_storage_bucket" "this" {
     }
   }
   
+  dynamic "website" {
+    for_each = var.website != null ? [var.website] : []
+    content {
+      main_page_suffix = website.value.main_page_suffix
+      not_found_page   = website.value.not_found_page
+    }
+  }
+  
   labels = var.labels
 }
 
-# Public access prevention
-resource "google_storage_bucket_iam_binding" "public_access_prevention" {
-  count = var.block_public_access ? 1 : 0
+resource "google_storage_bucket_iam_member" "public_read" {
+  count = var.public_read ? 1 : 0
   
   bucket = google_storage_bucket.this.name
   role   = "roles/storage.objectViewer"
-  
-  members = []  # No members = block public access
+  member = "allUsers"
+}
+
+# Cloud Storage publishes notifications as the project's storage service
+# agent, which must be able to publish to each topic
+data "google_storage_project_service_account" "this" {
+  count = length(var.notifications) > 0 ? 1 : 0
+
+  project = var.project_id
+}
+
+resource "google_pubsub_topic_iam_member" "notifications" {
+  count = length(var.notifications)
+
+  topic  = var.notifications[count.index].topic
+  role   = "roles/pubsub.publisher"
+  member = "serviceAccount:${data.google_storage_project_service_account.this[0].email_address}"
+}
+
+resource "google_storage_notification" "this" {
+  count = length(var.notifications)
+
+  bucket             = google_storage_bucket.this.name
+  payload_format     = "JSON_API_V1"
+  topic              = var.notifications[count.index].topic
+  event_types        = var.notifications[count.index].events
+  object_name_prefix = var.notifications[count.index].filter_prefix
+
+  depends_on = [google_pubsub_topic_iam_member.notifications]
 }
 
 # Outputs
+output "bucket_id" {
+  description = "Bucket ID"
+  value       = google_storage_bucket.this.id
+}
+
 output "bucket_name" {
   description = "Bucket name"
   value       = google_storage_bucket.this.name
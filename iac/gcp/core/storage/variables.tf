@@ -3,6 +3,12 @@ variable "bucket_name" {
   type        = string
 }
 
+variable "project_id" {
+  description = "GCP project ID (defaults to the provider project)"
+  type        = string
+  default     = null
+}
+
 variable "location" {
   description = "Bucket location (region or multi-region)"
   type        = string
@@ -13,6 +19,10 @@ variable "storage_class" {
   description = "Storage class (STANDARD, NEARLINE, COLDLINE, ARCHIVE)"
   type        = string
   default     = "STANDARD"
+  validation {
+    condition     = contains(["STANDARD", "NEARLINE", "COLDLINE", "ARCHIVE"], var.storage_class)
+    error_message = "Storage class must be one of: STANDARD, NEARLINE, COLDLINE, ARCHIVE"
+  }
 }
 
 variable "uniform_bucket_level_access" {
@@ -63,6 +73,35 @@ variable "lifecycle_rules" {
   default = []
 }
 
+variable "website" {
+  description = "Website index and 404 pages (optional)"
+  type = object({
+    main_page_suffix = string
+    not_found_page   = string
+  })
+  default = null
+}
+
+variable "public_read" {
+  description = "Let anyone read objects, as Cloud CDN backend buckets need"
+  type        = bool
+  default     = false
+}
+
+variable "notifications" {
+  description = "Object events to publish to Pub/Sub topics, as JSON_API_V1 payloads. Cloud Storage filters on a name prefix only."
+  type = list(object({
+    events        = list(string)
+    filter_prefix = optional(string)
+    topic         = string
+  }))
+  default = []
+  validation {
+    condition     = length(distinct([for n in var.notifications : n.topic])) == length(var.notifications)
+    error_message = "notifications must each publish to a different topic, as each grants the storage service agent on its topic: list every event for a topic in one notification, e.g. events = [\"created\", \"deleted\"]"
+  }
+}
+
 variable "labels" {
   description = "Resource labels"
   type        = map(string)
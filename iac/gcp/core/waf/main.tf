@@ -0,0 +1,101 @@
+# GCP Cloud Armor Core Module (backend security policy)
+
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+    null = {
+      source  = "hashicorp/null"
+      version = "~> 3.0"
+    }
+  }
+}
+
+resource "google_compute_security_policy" "this" {
+  name    = var.name
+  project = var.project_id
+  type    = "CLOUD_ARMOR"
+
+  # One rule per preconfigured WAF expression, in the order given
+  dynamic "rule" {
+    for_each = var.waf_expressions
+    content {
+      action   = "deny(403)"
+      priority = 1000 + rule.key
+
+      match {
+        expr {
+          expression = rule.value
+        }
+      }
+    }
+  }
+
+  # Throttles a client IP over rate_limit requests in a 5 minute window
+  dynamic "rule" {
+    for_each = var.rate_limit != null ? [var.rate_limit] : []
+    content {
+      action   = "throttle"
+      priority = 2000
+
+      match {
+        versioned_expr = "SRC_IPS_V1"
+        config {
+          src_ip_ranges = ["*"]
+        }
+      }
+
+      rate_limit_options {
+        conform_action = "allow"
+        exceed_action  = "deny(429)"
+        enforce_on_key = "IP"
+
+        rate_limit_threshold {
+          count        = rule.value
+          interval_sec = 300
+        }
+      }
+    }
+  }
+
+  rule {
+    action      = "allow"
+    priority    = 2147483647
+    description = "Default rule"
+
+    match {
+      versioned_expr = "SRC_IPS_V1"
+      config {
+        src_ip_ranges = ["*"]
+      }
+    }
+  }
+}
+
+# A backend service holds its policy in its own security_policy field, and
+# the provider has no separate attachment resource, so attach with gcloud.
+# Counted rather than keyed: the service IDs are often unknown until apply.
+resource "null_resource" "attach" {
+  count = length(var.backend_services)
+
+  triggers = {
+    project         = var.project_id
+    policy          = google_compute_security_policy.this.name
+    backend_service = element(split("/", var.backend_services[count.index]), length(split("/", var.backend_services[count.index])) - 1)
+  }
+
+  provisioner "local-exec" {
+    command = "gcloud compute backend-services update ${self.triggers.backend_service} --global --project ${self.triggers.project} --security-policy ${self.triggers.policy}"
+  }
+
+  provisioner "local-exec" {
+    when    = destroy
+    command = "gcloud compute backend-services update ${self.triggers.backend_service} --global --project ${self.triggers.project} --security-policy ''"
+  }
+}
+
+output "security_policy_id" {
+  value = google_compute_security_policy.this.id
+}
@@ -0,0 +1,27 @@
+variable "project_id" {
+  description = "GCP project ID"
+  type        = string
+}
+
+variable "name" {
+  description = "Name of the security policy"
+  type        = string
+}
+
+variable "waf_expressions" {
+  description = "Cloud Armor rule expressions, e.g. evaluatePreconfiguredWaf('sqli-v33-stable')"
+  type        = list(string)
+  default     = []
+}
+
+variable "rate_limit" {
+  description = "Requests per client IP in 5 minutes before it is throttled (null to skip)"
+  type        = number
+  default     = null
+}
+
+variable "backend_services" {
+  description = "Global backend service names or IDs to attach the policy to"
+  type        = list(string)
+  default     = []
+}
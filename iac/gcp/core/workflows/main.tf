@@ -1,5 +1,14 @@
 # GCP Workflows
 
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
 resource "google_workflows_workflow" "this" {
   name            = var.name
   project         = var.project_id
@@ -0,0 +1,53 @@
+package gcphelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+)
+
+// WriteDocument stores fields as the document id of collection in the
+// Firestore database databaseID, replacing any document already there
+func (h *Helpers) WriteDocument(ctx context.Context, databaseID, collection, id string, fields map[string]interface{}) error {
+	client, err := h.firestoreClient(ctx, databaseID)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if _, err := client.Collection(collection).Doc(id).Set(ctx, fields); err != nil {
+		return fmt.Errorf("gcphelpers: writing %s/%s/%s: %w", databaseID, collection, id, err)
+	}
+	return nil
+}
+
+// ReadDocument returns the fields of the document id of collection in the
+// Firestore database databaseID
+func (h *Helpers) ReadDocument(ctx context.Context, databaseID, collection, id string) (map[string]interface{}, error) {
+	client, err := h.firestoreClient(ctx, databaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	snapshot, err := client.Collection(collection).Doc(id).Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcphelpers: reading %s/%s/%s: %w", databaseID, collection, id, err)
+	}
+	return snapshot.Data(), nil
+}
+
+// firestoreClient opens a client for one database of the project; the
+// caller closes it
+func (h *Helpers) firestoreClient(ctx context.Context, databaseID string) (*firestore.Client, error) {
+	if h.firestoreOptions == nil {
+		return nil, errors.New("gcphelpers: no Firestore endpoint; use NewEmulatorHelpers")
+	}
+	client, err := firestore.NewClientWithDatabase(ctx, h.ProjectID, databaseID, h.firestoreOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("gcphelpers: firestore client for database %q: %w", databaseID, err)
+	}
+	return client, nil
+}
@@ -0,0 +1,190 @@
+package gcphelpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"iac/testutil/compat"
+)
+
+// FunctionPollInterval is how often WaitForFunctionReady reads the
+// function's state
+const FunctionPollInterval = 2 * time.Second
+
+// httpClient sends the REST calls and function invocations; a function's
+// first request may wait for a cold start
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// statusError is a REST call the emulator answered with an error status.
+// compat.IsUnsupported reads its StatusCode.
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.code, e.body)
+}
+
+func (e *statusError) StatusCode() int { return e.code }
+
+// Function is the part of a Cloud Functions (2nd gen) resource the tests
+// read
+type Function struct {
+	Name string `json:"name"`
+
+	// State is ACTIVE once the function serves requests, FAILED when its
+	// build or deployment failed, and DEPLOYING before either
+	State string `json:"state"`
+
+	ServiceConfig struct {
+		URI string `json:"uri"`
+	} `json:"serviceConfig"`
+}
+
+// GetFunction reads a function through the Cloud Functions v2 REST API
+func (h *Helpers) GetFunction(ctx context.Context, location, name string) (*Function, error) {
+	if h.endpoint == "" {
+		return nil, errors.New("gcphelpers: no Cloud Functions endpoint; use NewEmulatorHelpers")
+	}
+
+	url := fmt.Sprintf("%s/v2/projects/%s/locations/%s/functions/%s", h.endpoint, h.ProjectID, location, ResourceID(name))
+	var fn Function
+	if err := h.do(ctx, http.MethodGet, url, nil, &fn); err != nil {
+		return nil, fmt.Errorf("gcphelpers: reading function %q: %w", name, err)
+	}
+	return &fn, nil
+}
+
+// WaitForFunctionReady polls the function until it is ACTIVE and returns
+// it. It gives up when the function is FAILED or timeout passes, with the
+// last state or error it saw.
+func (h *Helpers) WaitForFunctionReady(ctx context.Context, location, name string, timeout time.Duration) (*Function, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(FunctionPollInterval)
+	defer ticker.Stop()
+
+	var last error
+	for {
+		fn, err := h.GetFunction(ctx, location, name)
+		switch {
+		case err != nil:
+			last = err
+		case fn.State == "ACTIVE":
+			return fn, nil
+		case fn.State == "FAILED":
+			return nil, fmt.Errorf("gcphelpers: function %q failed to deploy", name)
+		default:
+			last = fmt.Errorf("gcphelpers: function %q is %s", name, fn.State)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gcphelpers: function %q not ready after %s: %w", name, timeout, last)
+		case <-ticker.C:
+		}
+	}
+}
+
+// InvokeFunction POSTs payload as JSON to a function's URL and returns the
+// status code and body of the response
+func (h *Helpers) InvokeFunction(ctx context.Context, url string, payload []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, fmt.Errorf("gcphelpers: invoking %s: %w", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("gcphelpers: invoking %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("gcphelpers: reading the response of %s: %w", url, err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// FunctionLogs returns the text of the project's log entries for a
+// function, newest first, through the Cloud Logging v2 REST API. Cloud
+// Functions (2nd gen) run on Cloud Run, so a function's output is logged
+// against the Cloud Run service of the same name. An emulator without the
+// API answers with an error wrapping compat.ErrUnsupported.
+func (h *Helpers) FunctionLogs(ctx context.Context, name string) ([]string, error) {
+	if h.endpoint == "" {
+		return nil, errors.New("gcphelpers: no Cloud Logging endpoint; use NewEmulatorHelpers")
+	}
+
+	request, err := json.Marshal(map[string]interface{}{
+		"resourceNames": []string{"projects/" + h.ProjectID},
+		"filter":        fmt.Sprintf(`resource.type="cloud_run_revision" AND resource.labels.service_name=%q`, ResourceID(name)),
+		"orderBy":       "timestamp desc",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Entries []struct {
+			TextPayload string          `json:"textPayload"`
+			JSONPayload json.RawMessage `json:"jsonPayload"`
+		} `json:"entries"`
+	}
+	err = h.do(ctx, http.MethodPost, h.endpoint+"/v2/entries:list", request, &response)
+	var status *statusError
+	if errors.As(err, &status) && status.code == http.StatusNotFound {
+		err = fmt.Errorf("%w: %w", compat.ErrUnsupported, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gcphelpers: listing logs of function %q: %w", name, err)
+	}
+
+	lines := make([]string, 0, len(response.Entries))
+	for _, e := range response.Entries {
+		if e.TextPayload != "" {
+			lines = append(lines, e.TextPayload)
+		} else if len(e.JSONPayload) > 0 {
+			lines = append(lines, string(e.JSONPayload))
+		}
+	}
+	return lines, nil
+}
+
+// do sends a JSON request to the emulator and decodes the JSON response
+// into out
+func (h *Helpers) do(ctx context.Context, method, url string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(data))}
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,294 @@
+package gcphelpers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iac/gcp/gcphelpers"
+	"iac/testutil/compat"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const testProject = "local-test"
+
+// newPubsubHelpers dials an in-process pstest server, so the Pub/Sub helpers
+// are exercised without CloudEmu
+func newPubsubHelpers(t *testing.T) (*gcphelpers.Helpers, *pubsub.Client) {
+	ctx := context.Background()
+
+	srv := pstest.NewServer()
+	t.Cleanup(func() { srv.Close() })
+
+	conn, err := grpc.Dial(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	client, err := pubsub.NewClient(ctx, testProject, option.WithGRPCConn(conn))
+	require.NoError(t, err)
+
+	helpers := gcphelpers.NewWithClients(testProject, nil, client)
+	t.Cleanup(func() { helpers.Close() })
+
+	return helpers, client
+}
+
+func TestVerifyTopicExists(t *testing.T) {
+	t.Parallel()
+
+	helpers, client := newPubsubHelpers(t)
+	ctx := context.Background()
+
+	_, err := client.CreateTopic(ctx, "orders")
+	require.NoError(t, err)
+
+	assert.NoError(t, helpers.VerifyTopicExists(ctx, "orders"))
+	assert.NoError(t, helpers.VerifyTopicExists(ctx, "projects/local-test/topics/orders"), "Full resource names should resolve to the topic ID")
+
+	err = helpers.VerifyTopicExists(ctx, "missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `topic "missing" does not exist`)
+}
+
+func TestPublishAndReceive(t *testing.T) {
+	t.Parallel()
+
+	helpers, client := newPubsubHelpers(t)
+	ctx := context.Background()
+
+	_, err := client.CreateTopic(ctx, "orders")
+	require.NoError(t, err)
+
+	received, err := helpers.PublishAndReceive(ctx, "projects/local-test/topics/orders", "orders-test", "order-42")
+	require.NoError(t, err)
+	assert.Equal(t, "order-42", received)
+
+	exists, err := client.Subscription("orders-test").Exists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists, "PublishAndReceive should create the missing subscription")
+
+	// A second round-trip reuses the subscription
+	received, err = helpers.PublishAndReceive(ctx, "orders", "orders-test", "order-43")
+	require.NoError(t, err)
+	assert.Equal(t, "order-43", received)
+}
+
+func TestPublishAndReceiveUnknownTopic(t *testing.T) {
+	t.Parallel()
+
+	helpers, _ := newPubsubHelpers(t)
+
+	_, err := helpers.PublishAndReceive(context.Background(), "missing", "missing-test", "hello")
+	assert.Error(t, err)
+}
+
+func TestPublishThenReceive(t *testing.T) {
+	t.Parallel()
+
+	helpers, client := newPubsubHelpers(t)
+	ctx := context.Background()
+
+	topic, err := client.CreateTopic(ctx, "orders")
+	require.NoError(t, err)
+	_, err = client.CreateSubscription(ctx, "orders-load", pubsub.SubscriptionConfig{Topic: topic})
+	require.NoError(t, err)
+
+	require.NoError(t, helpers.Publish(ctx, "projects/local-test/topics/orders", "order-44"))
+
+	receiveCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	received := make(chan string, 1)
+	err = helpers.Receive(receiveCtx, "orders-load", func(data []byte) {
+		select {
+		case received <- string(data):
+		default:
+		}
+		cancel()
+	})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "order-44", msg)
+	default:
+		t.Fatal("The published message never arrived on the subscription")
+	}
+}
+
+func TestVerifyBucketExists(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/storage/v1/b/assets" {
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"kind": "storage#bucket", "name": "assets"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"error": {"code": 404, "message": "Not Found"}}`)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx, option.WithEndpoint(server.URL+"/storage/v1/"), option.WithoutAuthentication())
+	require.NoError(t, err)
+
+	helpers := gcphelpers.NewWithClients(testProject, client, nil)
+	defer helpers.Close()
+
+	assert.NoError(t, helpers.VerifyBucketExists(ctx, "assets"))
+
+	err = helpers.VerifyBucketExists(ctx, "missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `bucket "missing" does not exist`)
+}
+
+func TestFirestoreNeedsEndpoint(t *testing.T) {
+	t.Parallel()
+
+	helpers := gcphelpers.NewWithClients(testProject, nil, nil)
+
+	err := helpers.WriteDocument(context.Background(), "orders", "orders", "order-1", map[string]interface{}{"id": "order-1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Firestore endpoint")
+
+	_, err = helpers.ReadDocument(context.Background(), "orders", "orders", "order-1")
+	assert.Error(t, err)
+}
+
+// newFunctionsHelpers connects to handler as if it were the emulator, and
+// returns the handler's URL too. The Pub/Sub client dials the same address
+// over gRPC; its HTTP/2 preface is turned away before it reaches handler.
+func newFunctionsHelpers(t *testing.T, handler http.HandlerFunc) (*gcphelpers.Helpers, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PRI" {
+			http.Error(w, "not a gRPC server", http.StatusHTTPVersionNotSupported)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	helpers, err := gcphelpers.NewEmulatorHelpers(context.Background(), server.URL, testProject)
+	require.NoError(t, err)
+	t.Cleanup(func() { helpers.Close() })
+	return helpers, server.URL
+}
+
+func TestWaitForFunctionReady(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	helpers, _ := newFunctionsHelpers(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/projects/local-test/locations/us-east1/functions/echo" {
+			http.NotFound(w, r)
+			return
+		}
+		state := "DEPLOYING"
+		if calls.Add(1) > 1 {
+			state = "ACTIVE"
+		}
+		fmt.Fprintf(w, `{"name": "projects/local-test/locations/us-east1/functions/echo", "state": %q, "serviceConfig": {"uri": "http://localhost/echo"}}`, state)
+	})
+
+	fn, err := helpers.WaitForFunctionReady(context.Background(), "us-east1", "echo", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/echo", fn.ServiceConfig.URI)
+	assert.Equal(t, int32(2), calls.Load(), "A deploying function should be polled again")
+
+	_, err = helpers.WaitForFunctionReady(context.Background(), "us-east1", "missing", 100*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `function "missing" not ready`)
+}
+
+func TestWaitForFunctionReadyFailed(t *testing.T) {
+	t.Parallel()
+
+	helpers, _ := newFunctionsHelpers(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"name": "echo", "state": "FAILED"}`)
+	})
+
+	_, err := helpers.WaitForFunctionReady(context.Background(), "us-east1", "echo", time.Minute)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `function "echo" failed to deploy`, "A failed deployment should not be waited out")
+}
+
+func TestInvokeFunction(t *testing.T) {
+	t.Parallel()
+
+	helpers, url := newFunctionsHelpers(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		io.Copy(w, r.Body)
+	})
+
+	status, body, err := helpers.InvokeFunction(context.Background(), url+"/echo", []byte(`{"message": "hi"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"message": "hi"}`, string(body))
+}
+
+func TestFunctionLogs(t *testing.T) {
+	t.Parallel()
+
+	helpers, _ := newFunctionsHelpers(t, func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ResourceNames []string `json:"resourceNames"`
+			Filter        string   `json:"filter"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "/v2/entries:list", r.URL.Path)
+		assert.Equal(t, []string{"projects/local-test"}, request.ResourceNames)
+		assert.Contains(t, request.Filter, `resource.labels.service_name="echo"`)
+
+		io.WriteString(w, `{"entries": [{"textPayload": "echo hi"}, {"jsonPayload": {"echo": "hi"}}, {"protoPayload": {}}]}`)
+	})
+
+	lines, err := helpers.FunctionLogs(context.Background(), "projects/local-test/locations/us-east1/functions/echo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo hi", `{"echo": "hi"}`}, lines)
+}
+
+func TestFunctionLogsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	helpers, _ := newFunctionsHelpers(t, http.NotFound)
+
+	_, err := helpers.FunctionLogs(context.Background(), "echo")
+	require.Error(t, err)
+	assert.True(t, compat.IsUnsupported(err), "An emulator without Cloud Logging should read as a compatibility gap: %v", err)
+}
+
+func TestFunctionsNeedEndpoint(t *testing.T) {
+	t.Parallel()
+
+	helpers := gcphelpers.NewWithClients(testProject, nil, nil)
+
+	_, err := helpers.GetFunction(context.Background(), "us-east1", "echo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Cloud Functions endpoint")
+
+	_, err = helpers.FunctionLogs(context.Background(), "echo")
+	assert.Error(t, err)
+}
+
+func TestResourceID(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "orders", gcphelpers.ResourceID("projects/p/topics/orders"))
+	assert.Equal(t, "orders", gcphelpers.ResourceID("orders"))
+}
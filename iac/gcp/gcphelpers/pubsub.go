@@ -0,0 +1,116 @@
+package gcphelpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// VerifyTopicExists returns an error unless the topic exists. topic may be an
+// ID or a full projects/<p>/topics/<id> name.
+func (h *Helpers) VerifyTopicExists(ctx context.Context, topic string) error {
+	id := ResourceID(topic)
+	ok, err := h.pubsub.Topic(id).Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("gcphelpers: reading topic %q: %w", id, err)
+	}
+	if !ok {
+		return fmt.Errorf("gcphelpers: topic %q does not exist in project %s", id, h.ProjectID)
+	}
+	return nil
+}
+
+// PublishAndReceive publishes msg to topic and waits for it on subscription,
+// creating the subscription first if it does not exist. The subscription must
+// be created before publishing, so an existing one may hold older messages;
+// those are acked and skipped. Returns the received payload.
+func (h *Helpers) PublishAndReceive(ctx context.Context, topic, subscription, msg string) (string, error) {
+	t := h.pubsub.Topic(ResourceID(topic))
+	defer t.Stop()
+
+	sub, err := h.ensureSubscription(ctx, t, ResourceID(subscription))
+	if err != nil {
+		return "", err
+	}
+
+	result := t.Publish(ctx, &pubsub.Message{Data: []byte(msg)})
+	if _, err := result.Get(ctx); err != nil {
+		return "", fmt.Errorf("gcphelpers: publishing to %s: %w", t.ID(), err)
+	}
+
+	receiveCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		received string
+		found    bool
+	)
+	err = sub.Receive(receiveCtx, func(_ context.Context, m *pubsub.Message) {
+		m.Ack()
+		mu.Lock()
+		defer mu.Unlock()
+		if !found && string(m.Data) == msg {
+			received, found = string(m.Data), true
+			cancel()
+		}
+	})
+	if err != nil {
+		return "", fmt.Errorf("gcphelpers: receiving from %s: %w", sub.ID(), err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !found {
+		return "", fmt.Errorf("gcphelpers: message published to %s never arrived on %s", t.ID(), sub.ID())
+	}
+	return received, nil
+}
+
+// Publish publishes msg to topic and waits for the server to accept it
+func (h *Helpers) Publish(ctx context.Context, topic, msg string) error {
+	t := h.pubsub.Topic(ResourceID(topic))
+	defer t.Stop()
+
+	if _, err := t.Publish(ctx, &pubsub.Message{Data: []byte(msg)}).Get(ctx); err != nil {
+		return fmt.Errorf("gcphelpers: publishing to %s: %w", t.ID(), err)
+	}
+	return nil
+}
+
+// Receive acks each message that arrives on subscription and passes its
+// payload to fn, until ctx is done. fn may be called concurrently.
+func (h *Helpers) Receive(ctx context.Context, subscription string, fn func(data []byte)) error {
+	sub := h.pubsub.Subscription(ResourceID(subscription))
+	err := sub.Receive(ctx, func(_ context.Context, m *pubsub.Message) {
+		m.Ack()
+		fn(m.Data)
+	})
+	if err != nil {
+		return fmt.Errorf("gcphelpers: receiving from %s: %w", sub.ID(), err)
+	}
+	return nil
+}
+
+func (h *Helpers) ensureSubscription(ctx context.Context, topic *pubsub.Topic, id string) (*pubsub.Subscription, error) {
+	sub := h.pubsub.Subscription(id)
+	ok, err := sub.Exists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcphelpers: reading subscription %q: %w", id, err)
+	}
+	if ok {
+		return sub, nil
+	}
+
+	sub, err = h.pubsub.CreateSubscription(ctx, id, pubsub.SubscriptionConfig{
+		Topic:       topic,
+		AckDeadline: 10 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcphelpers: creating subscription %q on %s: %w", id, topic.ID(), err)
+	}
+	return sub, nil
+}
@@ -0,0 +1,67 @@
+package gcphelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// VerifyBucketExists returns an error unless the bucket exists
+func (h *Helpers) VerifyBucketExists(ctx context.Context, bucket string) error {
+	_, err := h.storage.Bucket(bucket).Attrs(ctx)
+	if errors.Is(err, storage.ErrBucketNotExist) {
+		return fmt.Errorf("gcphelpers: bucket %q does not exist", bucket)
+	}
+	if err != nil {
+		return fmt.Errorf("gcphelpers: reading bucket %q: %w", bucket, err)
+	}
+	return nil
+}
+
+// ListBuckets returns the names of the project's buckets
+func (h *Helpers) ListBuckets(ctx context.Context) ([]string, error) {
+	var names []string
+	it := h.storage.Buckets(ctx, h.ProjectID)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gcphelpers: listing buckets: %w", err)
+		}
+		names = append(names, attrs.Name)
+	}
+}
+
+// WriteObject stores data as bucket/name
+func (h *Helpers) WriteObject(ctx context.Context, bucket, name string, data []byte) error {
+	w := h.storage.Bucket(bucket).Object(name).NewWriter(ctx)
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("gcphelpers: writing gs://%s/%s: %w", bucket, name, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("gcphelpers: writing gs://%s/%s: %w", bucket, name, err)
+	}
+	return nil
+}
+
+// ReadObject returns the contents of bucket/name
+func (h *Helpers) ReadObject(ctx context.Context, bucket, name string) ([]byte, error) {
+	r, err := h.storage.Bucket(bucket).Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcphelpers: reading gs://%s/%s: %w", bucket, name, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("gcphelpers: reading gs://%s/%s: %w", bucket, name, err)
+	}
+	return data, nil
+}
@@ -1,6 +1,19 @@
-variable "project_id" { type = string }
-variable "region" { type = string; default = "us-central1" }
-variable "zone" { type = string; default = "us-central1-a" }
+variable "project_id" {
+  description = "GCP project ID"
+  type        = string
+}
+
+variable "region" {
+  description = "Default GCP region"
+  type        = string
+  default     = "us-central1"
+}
+
+variable "zone" {
+  description = "Default GCP zone"
+  type        = string
+  default     = "us-central1-a"
+}
 
 variable "stack_name" {
   description = "Name of the stack"
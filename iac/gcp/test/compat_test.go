@@ -0,0 +1,42 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"iac/gcp/gcphelpers"
+	"iac/testutil/compat"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestGCPCompatibilityMatrix probes the GCP operations in
+// testutil/compat/matrix.json against CloudEmu, as
+// TestCloudEmuCompatibilityMatrix does for AWS, so the function log check
+// in TestGCPIntegration stops skipping once CloudEmu serves Cloud Logging
+func TestGCPCompatibilityMatrix(t *testing.T) {
+	t.Parallel()
+
+	cfg := ensureGCPRunning(t)
+
+	matrix, err := compat.Default()
+	require.NoError(t, err)
+
+	helpers, err := gcphelpers.NewFromTestConfig(context.Background(), cfg, gcpProject)
+	require.NoError(t, err)
+	defer helpers.Close()
+
+	probes := map[string]compat.Probe{
+		// Any answer to the list call, even one without entries, means the
+		// API is served
+		"logging.ListLogEntries": func(ctx context.Context) error {
+			_, err := helpers.FunctionLogs(ctx, "compat-probe")
+			return err
+		},
+	}
+
+	results := compat.Verify(context.Background(), matrix, compat.CloudEmu, probes, compat.DefaultProbeTimeout)
+	compat.Check(t, results)
+}
@@ -1,49 +1,107 @@
+//go:build integration
+
 package test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
+	"iac/gcp/gcphelpers"
+	"iac/testutil/artifacts"
+	"iac/testutil/compat"
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+	"iac/testutil/eventually"
+	"iac/testutil/integration"
+	"iac/testutil/smoke"
+	"iac/testutil/tfopts"
+
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
-	gcpEndpoint = "http://localhost:4567"
+	// Project configured on the google provider in examples/gcp-integration
+	gcpProject = "local-test"
+
+	// Region examples/gcp-integration deploys its Cloud Function to
+	gcpRegion = "us-east1"
+
+	// The emulator may not serve a resource the moment Terraform reports it.
+	// Longer than eventually.DefaultTimeout because a single Pub/Sub
+	// round-trip can wait up to 30s for delivery.
+	emulatorTimeout = 2 * time.Minute
 )
 
 // TestGCPIntegration tests the GCP provider integration with CloudEmu
 func TestGCPIntegration(t *testing.T) {
 	t.Parallel()
 
-	ensureGCPRunning(t)
+	cfg := ensureGCPRunning(t)
 
 	timestamp := time.Now().Unix()
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
+	terraformOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
 		TerraformDir: "../../examples/gcp-integration",
 		Vars: map[string]interface{}{
-			"bucket_name": fmt.Sprintf("test-gcp-bucket-%d", timestamp),
-			"table_name":  fmt.Sprintf("test-gcp-collection-%d", timestamp),
-			"environment": "test",
+			"bucket_name":   fmt.Sprintf("test-gcp-bucket-%d", timestamp),
+			"table_name":    fmt.Sprintf("test-gcp-collection-%d", timestamp),
+			"function_name": fmt.Sprintf("test-gcp-func-%d", timestamp),
+			"environment":   "local",
+			"gcp_endpoint":  cfg.GCPEndpoint,
 		},
 		NoColor: true,
+	}, tfopts.CloudEmuGCP)
+
+	defer concurrency.Destroy(t, terraformOptions)
+	// Deploys every facade at once, so it counts double against
+	// SWE_TEST_MAX_PARALLEL
+	concurrency.RunWeighted(t, 2, func() {
+		concurrency.InitAndApply(t, terraformOptions)
 	})
 
-	defer terraform.Destroy(t, terraformOptions)
-	terraform.InitAndApply(t, terraformOptions)
+	helpers, err := gcphelpers.NewFromTestConfig(context.Background(), cfg, gcpProject)
+	require.NoError(t, err)
+	defer helpers.Close()
 
-	// 1. Verify Storage (GCS)
+	// 1. Verify Storage (GCS): bucket exists and an object round-trips
 	bucketName := terraform.Output(t, terraformOptions, "bucket_name")
-	assert.NotEmpty(t, bucketName)
-
-	bucketURL := terraform.Output(t, terraformOptions, "bucket_url")
-	assert.NotEmpty(t, bucketURL)
+	assert.Contains(t, terraform.Output(t, terraformOptions, "bucket_url"), bucketName)
+
+	objectContent := fmt.Sprintf("hello from gcs %d", timestamp)
+	data := eventually.EventuallyValue(t, emulatorTimeout, eventually.DefaultInterval, func() ([]byte, error) {
+		ctx := context.Background()
+		if err := helpers.VerifyBucketExists(ctx, bucketName); err != nil {
+			return nil, err
+		}
+		if err := helpers.WriteObject(ctx, bucketName, "roundtrip.txt", []byte(objectContent)); err != nil {
+			return nil, err
+		}
+		return helpers.ReadObject(ctx, bucketName, "roundtrip.txt")
+	})
+	artifacts.Compare(t, "roundtrip.txt", data, []byte(objectContent))
+
+	// 2. Verify NoSQL (Firestore): a document round-trips through the
+	// database and collection the database facade names
+	database := terraform.Output(t, terraformOptions, "firestore_database")
+	collection := terraform.Output(t, terraformOptions, "table_name")
+	document := map[string]interface{}{
+		"id":      "order-1",
+		"message": fmt.Sprintf("hello from firestore %d", timestamp),
+	}
 
-	// 2. Verify NoSQL (Firestore)
-	tableName := terraform.Output(t, terraformOptions, "table_name")
-	assert.NotEmpty(t, tableName)
+	stored := eventually.EventuallyValue(t, emulatorTimeout, eventually.DefaultInterval, func() (map[string]interface{}, error) {
+		ctx := context.Background()
+		if err := helpers.WriteDocument(ctx, database, collection, "order-1", document); err != nil {
+			return nil, err
+		}
+		return helpers.ReadDocument(ctx, database, collection, "order-1")
+	})
+	assert.Equal(t, document["message"], stored["message"])
 
 	// 3. Verify Networking (VPC)
 	vpcID := terraform.Output(t, terraformOptions, "vpc_id")
@@ -51,27 +109,76 @@ func TestGCPIntegration(t *testing.T) {
 
 	// 4. Verify Identity (Service Account)
 	saEmail := terraform.Output(t, terraformOptions, "sa_email")
-	assert.NotEmpty(t, saEmail)
+	assert.Contains(t, saEmail, "@")
 
-	// 5. Verify Compute (Cloud Function)
+	// 5. Verify Compute (Cloud Function): the echo function packaged from
+	// functions/echo answers a POST through the emulator
 	functionName := terraform.Output(t, terraformOptions, "function_name")
-	assert.NotEmpty(t, functionName)
+	function, err := helpers.WaitForFunctionReady(context.Background(), gcpRegion, functionName, emulatorTimeout)
+	require.NoError(t, err)
+
+	functionURL := terraform.Output(t, terraformOptions, "function_url")
+	if functionURL == "" {
+		functionURL = function.ServiceConfig.URI
+	}
+	require.NotEmpty(t, functionURL, "The function should have an HTTP endpoint")
+
+	payload := fmt.Sprintf(`{"message": "hello from cloud functions %d"}`, timestamp)
+	echoed := eventually.EventuallyValue(t, emulatorTimeout, eventually.DefaultInterval, func() (string, error) {
+		status, body, err := helpers.InvokeFunction(context.Background(), functionURL, []byte(payload))
+		if err != nil {
+			return "", err
+		}
+		if status != http.StatusOK {
+			return "", fmt.Errorf("function answered %d: %s", status, body)
+		}
+		return string(body), nil
+	})
+	assert.JSONEq(t, fmt.Sprintf(`{"echo": %s}`, payload), echoed)
+
+	// The function prints what it echoes, so the invocation should be
+	// logged; CloudEmu may not serve Cloud Logging, which skips only this
+	t.Run("function logs", func(t *testing.T) {
+		compat.RequireEmulatorSupport(t, "logging.ListLogEntries")
+
+		eventually.Eventually(t, emulatorTimeout, eventually.DefaultInterval, func() error {
+			lines, err := helpers.FunctionLogs(context.Background(), functionName)
+			if err != nil {
+				return err
+			}
+			for _, line := range lines {
+				if strings.Contains(line, fmt.Sprintf("hello from cloud functions %d", timestamp)) {
+					return nil
+				}
+			}
+			return fmt.Errorf("no log entry of %s mentions the payload among %d entries", functionName, len(lines))
+		})
+	})
 
-	// 6. Verify Messaging (Pub/Sub)
+	// 6. Verify Messaging (Pub/Sub): publish and receive through a test subscription
 	topicARN := terraform.Output(t, terraformOptions, "topic_arn")
-	assert.NotEmpty(t, topicARN)
+	subscription := fmt.Sprintf("%s-test-%d", gcphelpers.ResourceID(topicARN), timestamp)
+	message := fmt.Sprintf("hello from pubsub %d", timestamp)
+
+	received := eventually.EventuallyValue(t, emulatorTimeout, eventually.DefaultInterval, func() (string, error) {
+		ctx := context.Background()
+		if err := helpers.VerifyTopicExists(ctx, topicARN); err != nil {
+			return "", err
+		}
+		return helpers.PublishAndReceive(ctx, topicARN, subscription, message)
+	})
+	assert.Equal(t, message, received)
 
 	t.Log("✓ GCP integration test successful")
 }
 
-func ensureGCPRunning(t *testing.T) {
-	client := &http.Client{Timeout: 2 * time.Second}
-	// Check GCS endpoint
-	resp, err := client.Get(gcpEndpoint)
-	
-	if err != nil {
-		t.Skip("CloudEmu (GCP) not running. Start with: cd cloudemu && cargo run --release -p cloudemu-server")
-	}
-	
-	t.Log("✓ CloudEmu (GCP) is running")
+// ensureGCPRunning skips the test, or fails it under
+// SWE_REQUIRE_INTEGRATION, unless the GCP services CloudEmu serves answer, and
+// returns the config it checked
+func ensureGCPRunning(t *testing.T) *config.TestConfig {
+	t.Helper()
+
+	cfg := config.Load(t)
+	integration.Require(t, "CloudEmu (GCP)", integration.StartCloudEmu, smoke.Probe(cfg, smoke.ForProvider("gcp")...))
+	return cfg
 }
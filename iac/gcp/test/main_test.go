@@ -0,0 +1,20 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"iac/testutil/config"
+	"iac/testutil/emureset"
+)
+
+// TestMain clears the GCP emulator of resources earlier runs left behind
+// when SWE_TEST_RESET_EMULATOR is set
+func TestMain(m *testing.M) {
+	os.Exit(emureset.Main(m, func(ctx context.Context, cfg *config.TestConfig) (*emureset.Emulator, error) {
+		return emureset.GCP(ctx, cfg, gcpProject)
+	}))
+}
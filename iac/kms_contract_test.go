@@ -0,0 +1,122 @@
+package test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const kmsContractFixture = "testdata/kms-key-ref"
+
+// cmkCapableResourceTypes are the resource types the facades create that can
+// be encrypted with a customer-managed key. Any of them planned by a facade
+// that received kms_key_ref must reference the key.
+var cmkCapableResourceTypes = map[string]bool{
+	"aws_s3_bucket_server_side_encryption_configuration": true,
+	"aws_sqs_queue":                   true,
+	"aws_sns_topic":                   true,
+	"aws_db_instance":                 true,
+	"aws_lambda_function":             true,
+	"aws_cloudwatch_log_group":        true,
+	"azurerm_storage_account":         true,
+	"azurerm_servicebus_namespace":    true,
+	"azurerm_mssql_server":            true,
+	"google_storage_bucket":           true,
+	"google_pubsub_topic":             true,
+	"google_sql_database_instance":    true,
+	"google_cloudfunctions2_function": true,
+}
+
+// TestKmsKeyRefHonoredByAllFacades feeds one key reference into the storage,
+// messaging, database and lambda facades and checks, through the JSON plan,
+// that no CMK-capable resource silently ignores it.
+func TestKmsKeyRefHonoredByAllFacades(t *testing.T) {
+	cases := []struct {
+		provider string
+		keyID    string
+	}{
+		{"aws", "arn:aws:kms:us-east-1:111122223333:key/0f3c1c2e-8f7a-4b7e-9d3c-2a1b5c6d7e8f"},
+		{"azure", "https://cmk-contract-kv.vault.azure.net/keys/contract-key"},
+		{"gcp", "projects/cmk-contract/locations/us/keyRings/contract/cryptoKeys/contract-key"},
+	}
+
+	// Subtests share the fixture directory, so they run sequentially
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.provider, func(t *testing.T) {
+			terraformOptions := tfopts.New(t, &terraform.Options{
+				TerraformDir: kmsContractFixture,
+				Vars: map[string]interface{}{
+					"provider_name": tc.provider,
+					"kms_key_ref": map[string]interface{}{
+						"provider": tc.provider,
+						"id":       tc.keyID,
+					},
+				},
+			})
+
+			plan := terraform.InitAndPlanAndShowWithStruct(t, terraformOptions)
+
+			checked := 0
+			var ignored []string
+			for address, resource := range plan.ResourcePlannedValuesMap {
+				if !cmkCapableResourceTypes[resource.Type] {
+					continue
+				}
+				checked++
+				if !containsString(resource.AttributeValues, tc.keyID) {
+					ignored = append(ignored, address)
+				}
+			}
+			sort.Strings(ignored)
+
+			require.NotZero(t, checked, "Plan should contain CMK-capable resources for %s", tc.provider)
+			assert.Empty(t, ignored, "Resources that support customer-managed keys but ignored kms_key_ref:\n  %s", strings.Join(ignored, "\n  "))
+		})
+	}
+}
+
+func TestKmsKeyRefProviderMismatch(t *testing.T) {
+	terraformOptions := tfopts.New(t, &terraform.Options{
+		TerraformDir: kmsContractFixture,
+		Vars: map[string]interface{}{
+			"provider_name": "gcp",
+			"kms_key_ref": map[string]interface{}{
+				"provider": "aws",
+				"id":       "arn:aws:kms:us-east-1:111122223333:key/0f3c1c2e-8f7a-4b7e-9d3c-2a1b5c6d7e8f",
+			},
+		},
+	})
+
+	_, err := terraform.InitAndPlanE(t, terraformOptions)
+	assert.Error(t, err, "Plan should fail when the key belongs to a different provider")
+}
+
+// containsString reports whether want appears as a string anywhere in a
+// decoded JSON value.
+func containsString(value interface{}, want string) bool {
+	switch v := value.(type) {
+	case string:
+		return v == want
+	case map[string]interface{}:
+		for _, item := range v {
+			if containsString(item, want) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if containsString(item, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package test
+
+import (
+	"testing"
+
+	"iac/testutil/modgraph"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestModuleGraph checks the local module calls of every module for cycles
+// and layering violations: provider modules must not reach a facade,
+// platform modules only call facades, and examples only call facades and
+// platform modules. `go run ./tools/modgraph --dot` draws the graph.
+func TestModuleGraph(t *testing.T) {
+	t.Parallel()
+
+	g, err := modgraph.Build(".")
+	require.NoError(t, err)
+	require.NotEmpty(t, g.Modules)
+
+	for _, v := range g.Check() {
+		t.Errorf("%s", v)
+	}
+}
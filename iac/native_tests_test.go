@@ -0,0 +1,92 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/config"
+	"iac/testutil/tfopts"
+	"iac/testutil/tftest"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNativeModuleTests runs the native test suite of every module with a
+// tests directory, e.g. facade/storage/tests/storage.tftest.hcl, with
+// `terraform test -json`, and reports each run block as a subtest named
+// after its file and run: a failed or errored run fails with the
+// diagnostics terraform gave, and a skipped run is skipped. The suites'
+// aws providers point at CloudEmu through tftest.EmulatorEnv, so runs that
+// apply create their resources there; plan-only runs need no emulator.
+func TestNativeModuleTests(t *testing.T) {
+	t.Parallel()
+
+	modules, err := tftest.Discover(".")
+	require.NoError(t, err)
+	require.NotEmpty(t, modules, "No module has native tests in a %s directory", tftest.TestsDir)
+
+	cfg := config.Load(t)
+	env := tftest.EmulatorEnv(cfg.CloudEmuEndpoint, cfg.Region)
+
+	for _, module := range modules {
+		module := module
+
+		t.Run(module, func(t *testing.T) {
+			t.Parallel()
+
+			// The copy keeps the module's .terraform directory, and the
+			// state of runs that apply, out of the tree
+			options := tfopts.New(t, &terraform.Options{
+				TerraformDir: test_structure.CopyTerraformFolderToTemp(t, ".", module),
+				EnvVars:      env,
+				NoColor:      true,
+			})
+			_, err := terraform.InitE(t, options)
+			require.NoError(t, err, "Initializing %s", module)
+
+			// terraform test exits non-zero when a run fails, which the
+			// runs below report; the error only matters when they do not
+			output, testErr := terraform.RunTerraformCommandAndGetStdoutE(t, options, "test", "-json")
+			results, err := tftest.Parse(strings.NewReader(output))
+			require.NoError(t, err)
+
+			for _, run := range results.Runs {
+				run := run
+
+				name := strings.TrimSuffix(filepath.Base(run.File), tftest.Extension) + "/" + run.Name
+				t.Run(name, func(t *testing.T) {
+					switch run.Status {
+					case tftest.Pass:
+					case tftest.Skip, tftest.Pending:
+						t.Skipf("terraform test reported run %q in %s as %s", run.Name, run.File, run.Status)
+					default:
+						t.Errorf("Run %q in %s: %s%s", run.Name, run.File, run.Status, diagnostics(run.Diagnostics))
+					}
+				})
+			}
+
+			if len(results.Diagnostics) > 0 {
+				t.Logf("terraform test diagnostics outside any run:%s", diagnostics(results.Diagnostics))
+			}
+			if results.Failed() {
+				t.Errorf("terraform test failed in %s", module)
+			} else if testErr != nil {
+				t.Errorf("terraform test in %s exited with an error though no run failed: %v", module, testErr)
+			}
+		})
+	}
+}
+
+// diagnostics formats terraform test diagnostics one per line, for a
+// failure message
+func diagnostics(diags []tftest.Diagnostic) string {
+	var b strings.Builder
+	for _, d := range diags {
+		b.WriteString("\n  ")
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,234 @@
+# Service Platform Module
+# Composes the facades into a standard service skeleton: a function running
+# as its own identity, with the network, table, queue, bucket and alarms
+# selected in features wired to it
+
+terraform {
+  # Optional object attributes with defaults
+  required_version = ">= 1.3"
+}
+
+locals {
+  name = "${var.service_name}-${var.environment}"
+
+  network  = lookup(var.features, "network", false)
+  database = lookup(var.features, "database", false)
+  queue    = lookup(var.features, "queue", false)
+  bucket   = lookup(var.features, "bucket", false)
+  alarms   = lookup(var.features, "alarms", false)
+
+  # Capacity per size. Cosmos DB stays serverless while small and autoscales
+  # up to max_throughput RU/s above it; DynamoDB tables are on-demand.
+  size_profiles = {
+    small  = { memory_mb = 256, throughput_mode = "serverless", max_throughput = null }
+    medium = { memory_mb = 512, throughput_mode = "autoscale", max_throughput = 1000 }
+    large  = { memory_mb = 1024, throughput_mode = "autoscale", max_throughput = 4000 }
+  }
+  profile = local.size_profiles[var.size]
+
+  # Function code deployed when features bring no source_dir
+  stub_source = <<-EOT
+    import json
+
+
+    def handler(event, context):
+        print(json.dumps(event))
+        return {"statusCode": 200}
+  EOT
+
+  # Azure Function Apps join a VNet through a subnet delegated to
+  # Microsoft.Web/serverFarms, which the networking facade does not create,
+  # so only AWS places the function in the private subnets
+  function_in_network = local.network && var.provider == "aws"
+
+  table_ref = local.database ? module.database[0].backup_ref.id : null
+
+  # Resources the identity reaches, and nothing else. Azure grants data
+  # roles on the storage account behind the bucket.
+  grants = concat(
+    local.bucket ? [
+      { capability = "storage_read", resource = var.provider == "aws" ? module.storage[0].bucket_arn : module.storage[0].bucket_id },
+      { capability = "storage_write", resource = var.provider == "aws" ? module.storage[0].bucket_arn : module.storage[0].bucket_id },
+    ] : [],
+    local.database ? [
+      { capability = "nosql_read", resource = local.table_ref },
+      { capability = "nosql_write", resource = local.table_ref },
+    ] : [],
+    local.queue ? [
+      { capability = "queue_consume", resource = module.queue[0].queue_id },
+    ] : [],
+  )
+
+  # Where the function finds the resources it owns
+  function_environment = merge(
+    var.function.environment_variables,
+    local.bucket ? { BUCKET_NAME = module.storage[0].bucket.name } : {},
+    local.database ? { TABLE_NAME = module.database[0].nosql_table.name } : {},
+    local.queue ? { QUEUE_URL = module.queue[0].queue_url } : {},
+  )
+}
+
+# ============================================================================
+# RESOURCES THE FUNCTION OWNS
+# ============================================================================
+
+module "network" {
+  count  = local.network ? 1 : 0
+  source = "../../facade/networking"
+
+  provider_name = var.provider
+  project_name  = var.service_name
+  environment   = var.environment
+  network_name  = local.name
+
+  network_config = {
+    cidr            = "10.0.0.0/16"
+    azs             = try(var.provider_config.availability_zones, ["us-east-1a", "us-east-1b"])
+    public_subnets  = ["10.0.0.0/24", "10.0.1.0/24"]
+    private_subnets = ["10.0.10.0/24", "10.0.11.0/24"]
+  }
+
+  # The function's security group: HTTPS out, to the S3 and DynamoDB
+  # gateway endpoints' public ranges as well as the interface endpoints
+  firewall_rules = local.function_in_network ? [
+    { name = "function-https", direction = "egress", ports = ["443"], cidr_blocks = ["0.0.0.0/0"] },
+  ] : []
+
+  # The function reaches its own resources without a NAT gateway
+  enable_private_endpoints = local.function_in_network ? compact([
+    local.bucket ? "storage" : "",
+    local.database ? "nosql" : "",
+    local.queue ? "queue" : "",
+  ]) : []
+
+  provider_config = var.provider_config
+  tags            = var.tags
+}
+
+module "database" {
+  count  = local.database ? 1 : 0
+  source = "../../facade/database"
+
+  provider_name = var.provider
+  project_name  = var.service_name
+  environment   = var.environment
+  identifier    = "${local.name}-table"
+  engine_type   = "nosql"
+  hash_key      = "id"
+
+  throughput_mode = var.provider == "azure" ? local.profile.throughput_mode : "serverless"
+  max_throughput  = var.provider == "azure" ? local.profile.max_throughput : null
+
+  provider_config = var.provider_config
+  tags            = var.tags
+}
+
+module "queue" {
+  count  = local.queue ? 1 : 0
+  source = "../../facade/messaging"
+
+  provider_name     = var.provider
+  project_name      = var.service_name
+  environment       = var.environment
+  name              = "${local.name}-queue"
+  type              = "queue"
+  dead_letter_queue = true
+
+  enable_default_alarms = local.alarms
+  notification_ref      = var.notification_ref
+
+  provider_config = var.provider_config
+  tags            = var.tags
+}
+
+module "storage" {
+  count  = local.bucket ? 1 : 0
+  source = "../../facade/storage"
+
+  provider_name = var.provider
+  project_name  = var.service_name
+  environment   = var.environment
+  bucket_name   = "${local.name}-data"
+
+  versioning_enabled = true
+
+  provider_config = var.provider_config
+  tags            = var.tags
+}
+
+# ============================================================================
+# FUNCTION AND IDENTITY
+# ============================================================================
+
+# AWS functions assume a role; Azure Function Apps run as a user-assigned
+# managed identity
+module "identity" {
+  source = "../../facade/iam"
+
+  provider_name = var.provider
+  project_name  = var.service_name
+  environment   = var.environment
+  identity_name = "${local.name}-function"
+  identity_type = var.provider == "aws" ? "role" : "service_agent"
+  principals    = var.provider == "aws" ? ["lambda.amazonaws.com"] : []
+
+  resource_grants = local.grants
+
+  provider_config = var.provider_config
+  tags            = var.tags
+}
+
+module "function" {
+  source = "../../facade/lambda"
+
+  provider_name = var.provider
+  project_name  = var.service_name
+  environment   = var.environment
+  function_name = "${local.name}-function"
+  handler       = var.function.handler
+  runtime       = var.function.runtime
+  source_dir    = var.function.source_dir
+  source_code   = var.function.source_dir == null ? local.stub_source : null
+  memory_mb     = local.profile.memory_mb
+
+  environment_variables = local.function_environment
+  identity_ref          = module.identity.identity_ref
+
+  vpc_config = local.function_in_network ? {
+    subnet_ids         = module.network[0].private_subnet_ids
+    security_group_ids = [module.network[0].security_group_id]
+  } : null
+
+  # Azure functions subscribe through a serviceBusTrigger binding in their
+  # own function.json, reading the queue from QUEUE_URL
+  queue_trigger = local.queue && var.provider == "aws" ? { queue_id = module.queue[0].queue_id } : null
+
+  enable_default_alarms = local.alarms
+  notification_ref      = var.notification_ref
+
+  provider_config = var.provider_config
+  tags            = var.tags
+}
+
+# ============================================================================
+# ALARMS
+# ============================================================================
+
+# The queue and the function bring their own default alarms; the table is
+# alarmed on as soon as it throttles a request
+module "table_alarm" {
+  count  = local.database && local.alarms ? 1 : 0
+  source = "../../facade/monitoring"
+
+  provider_name      = var.provider
+  project_name       = var.service_name
+  environment        = var.environment
+  alarm_name         = "${local.name}-table-throttles"
+  monitored_resource = module.database[0].monitored_resource
+  preset             = "nosql_throttles"
+  threshold          = 0
+  notification_ref   = var.notification_ref
+
+  provider_config = var.provider_config
+  tags            = var.tags
+}
@@ -0,0 +1,48 @@
+output "function_name" {
+  description = "Name of the service's function"
+  value       = module.function.function_name
+}
+
+output "function_arn" {
+  description = "Function identifier (Lambda ARN / Function App ID)"
+  value       = module.function.function_arn
+}
+
+output "identity_ref" {
+  description = "Identity the function runs as ({provider, id}): the IAM role ARN on aws, the managed identity ID on azure"
+  value       = module.identity.identity_ref
+}
+
+output "network_id" {
+  description = "VPC or VNet ID; null without the network feature"
+  value       = local.network ? module.network[0].network_id : null
+}
+
+output "table_name" {
+  description = "Name of the table items are keyed by id in (DynamoDB table / Cosmos DB container); null without the database feature"
+  value       = local.database ? module.database[0].nosql_table.name : null
+}
+
+output "queue_url" {
+  description = "Queue endpoint (SQS URL / Service Bus queue URL); null without the queue feature"
+  value       = local.queue ? module.queue[0].queue_url : null
+}
+
+output "queue_id" {
+  description = "Queue identifier (SQS ARN / Service Bus queue ID); null without the queue feature"
+  value       = local.queue ? module.queue[0].queue_id : null
+}
+
+output "bucket_name" {
+  description = "Name of the bucket; null without the bucket feature"
+  value       = local.bucket ? module.storage[0].bucket.name : null
+}
+
+output "alarm_ids" {
+  description = "Identifiers of the function, queue and table alarms; empty without the alarms feature"
+  value = concat(
+    module.function.alarm_arns,
+    local.queue ? module.queue[0].alarm_arns : [],
+    module.table_alarm[*].alarm_id,
+  )
+}
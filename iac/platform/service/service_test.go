@@ -0,0 +1,222 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/inventory"
+	"iac/testutil/planerr"
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smallWithQueueAndBucket is the configuration most services start from
+func smallWithQueueAndBucket(provider string) map[string]interface{} {
+	return map[string]interface{}{
+		"service_name": "orders",
+		"environment":  "dev",
+		"provider":     provider,
+		"size":         "small",
+		"features": map[string]interface{}{
+			"queue":  true,
+			"bucket": true,
+		},
+	}
+}
+
+func TestServiceAwsSmallWithQueueAndBucket(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars:         smallWithQueueAndBucket("aws"),
+		NoColor:      true,
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	for _, address := range []string{
+		"module.storage[0].module.aws_storage[0].aws_s3_bucket.this",
+		"module.queue[0].module.aws_messaging[0].aws_sqs_queue.this[0]",
+		"module.queue[0].module.aws_messaging[0].aws_sqs_queue.dlq[0]",
+		"module.identity.module.aws_iam[0].aws_iam_role.this[0]",
+		"module.identity.module.aws_iam[0].aws_iam_policy.this[0]",
+		"module.function.module.aws_lambda[0].aws_lambda_function.this",
+		"module.function.module.aws_lambda[0].aws_lambda_event_source_mapping.this[0]",
+	} {
+		assert.Contains(t, planString, address)
+	}
+
+	assert.NotContains(t, planString, "module.function.module.aws_lambda[0].aws_iam_role.this", "The function should run as the scoped role, not one of its own")
+	assert.Regexp(t, `name\s+= "orders-dev-function-grants"`, planString, "The role should carry one policy for the bucket and queue")
+	assert.Regexp(t, `memory_size\s+= 256`, planString)
+	assert.Regexp(t, `"QUEUE_URL"\s+= \(known after apply\)`, planString)
+
+	for _, module := range []string{"module.network", "module.database", "module.table_alarm", "default_alarms"} {
+		assert.NotContains(t, planString, module, "Features left off should plan nothing")
+	}
+}
+
+func TestServiceAzureSmallWithQueueAndBucket(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars:         smallWithQueueAndBucket("azure"),
+		NoColor:      true,
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	for _, address := range []string{
+		"module.storage[0].module.azure_storage[0].azurerm_storage_account.this",
+		"module.queue[0].module.azure_messaging[0].azurerm_servicebus_queue.this[0]",
+		"module.identity.module.azure_iam[0].azurerm_user_assigned_identity.this[0]",
+		"module.function.module.azure_lambda[0].azurerm_linux_function_app.this",
+	} {
+		assert.Contains(t, planString, address)
+	}
+
+	// storage_read, storage_write and queue_consume
+	assert.Contains(t, planString, "module.identity.module.azure_iam[0].azurerm_role_assignment.resource[2]")
+	assert.NotContains(t, planString, "module.identity.module.azure_iam[0].azurerm_role_assignment.resource[3]")
+	assert.Regexp(t, `type\s+= "UserAssigned"`, planString, "The Function App should run as the scoped identity")
+
+	assert.NotContains(t, planString, "module.network")
+	assert.NotContains(t, planString, "module.database")
+}
+
+func TestServiceAwsAllFeatures(t *testing.T) {
+	t.Parallel()
+
+	terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+		TerraformDir: ".",
+		Vars: map[string]interface{}{
+			"service_name": "orders",
+			"provider":     "aws",
+			"size":         "large",
+			"features": map[string]interface{}{
+				"network":  true,
+				"database": true,
+				"queue":    true,
+				"bucket":   true,
+				"alarms":   true,
+			},
+		},
+		NoColor: true,
+	})
+
+	planString := terraform.InitAndPlan(t, terraformOptions)
+
+	assert.Contains(t, planString, "module.network[0].module.aws_networking[0]")
+	assert.Contains(t, planString, "module.database[0].module.aws_nosql[0]")
+	assert.Contains(t, planString, "module.function.module.aws_lambda[0].aws_iam_role_policy_attachment.vpc_access[0]", "The function should run in the private subnets")
+	assert.Regexp(t, `memory_size\s+= 1024`, planString)
+
+	for _, alarm := range []string{"orders-dev-function-errors", "orders-dev-queue-dead-letters", "orders-dev-table-throttles"} {
+		assert.Regexp(t, `alarm_name\s+= "`+alarm+`"`, planString)
+	}
+}
+
+// inventoryDifferences are the categories only one provider's plan of the
+// module has
+var inventoryDifferences = []inventory.Expected{
+	{
+		Category: inventory.ResourceGroup,
+		Provider: "azure",
+		Reason:   "the lambda facade creates a resource group for each Function App",
+	},
+}
+
+// TestServiceInventoryAwsAzure plans every feature on AWS and Azure and
+// fails when one plan lacks a category of resource, such as a queue or an
+// alarm, that the other has. The comparison goes to the test log, and to
+// inventory.md in the test's artifact directory when SWE_TEST_ARTIFACT_DIR
+// is set.
+func TestServiceInventoryAwsAzure(t *testing.T) {
+	t.Parallel()
+
+	inventories := map[string]inventory.Inventory{}
+	for _, provider := range []string{"aws", "azure"} {
+		terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+			TerraformDir: ".",
+			Vars: map[string]interface{}{
+				"service_name": "orders",
+				"provider":     provider,
+				"features": map[string]interface{}{
+					"network":  true,
+					"database": true,
+					"queue":    true,
+					"bucket":   true,
+					"alarms":   true,
+				},
+			},
+			PlanFilePath: filepath.Join(t.TempDir(), provider+".plan"),
+			NoColor:      true,
+		})
+
+		inv, err := inventory.FromPlan([]byte(terraform.InitAndPlanAndShow(t, terraformOptions)))
+		require.NoError(t, err, "Every resource type in the %s plan should have a category", provider)
+		inventories[provider] = inv
+	}
+
+	comparison := inventory.Compare("aws", inventories["aws"], "azure", inventories["azure"], inventoryDifferences)
+
+	var report strings.Builder
+	require.NoError(t, inventory.WriteReport(&report, comparison))
+	t.Logf("Resource inventory:\n%s", report.String())
+	if dir := tflog.LoadOptions().ArtifactDir; dir != "" {
+		path := filepath.Join(dir, tflog.ArtifactName(t.Name()), inventory.ReportFile)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(report.String()), 0o644))
+	}
+
+	for _, row := range comparison.Unexpected() {
+		t.Errorf("The %s plan has no %s resource, which the other plan has", row.Missing, row.Category)
+	}
+	for _, e := range comparison.Stale {
+		t.Errorf("Expected difference not seen, remove it from inventoryDifferences: %s", e)
+	}
+}
+
+func TestServiceValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]interface{}{
+		"service_name": "orders",
+		"provider":     "aws",
+	}
+
+	planerr.RunMatrix(t, ".", base, []planerr.Case{
+		{
+			Name:     "UnknownFeature",
+			Vars:     map[string]interface{}{"features": map[string]interface{}{"cache": true}},
+			Want:     "features keys must be among: network, database, queue, bucket, alarms",
+			Variable: "features",
+		},
+		{
+			Name:     "GcpProvider",
+			Vars:     map[string]interface{}{"provider": "gcp"},
+			Want:     "provider must be one of: aws, azure",
+			Variable: "provider",
+		},
+		{
+			Name:     "HugeSize",
+			Vars:     map[string]interface{}{"size": "xlarge"},
+			Want:     "size must be one of: small, medium, large",
+			Variable: "size",
+		},
+		{
+			Name:     "UpperCaseName",
+			Vars:     map[string]interface{}{"service_name": "Orders"},
+			Want:     "service_name must be 3-32 lower case letters",
+			Variable: "service_name",
+		},
+	})
+}
@@ -0,0 +1,86 @@
+variable "service_name" {
+  description = "Name of the service; prefixes every resource the module creates"
+  type        = string
+  validation {
+    condition     = can(regex("^[a-z][a-z0-9-]{1,30}[a-z0-9]$", var.service_name))
+    error_message = "service_name must be 3-32 lower case letters, digits and hyphens, starting with a letter, e.g. orders"
+  }
+}
+
+variable "environment" {
+  description = "Environment name"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+variable "provider" {
+  description = "Cloud provider the service is deployed to (aws, azure)"
+  type        = string
+  validation {
+    condition     = contains(["aws", "azure"], var.provider)
+    error_message = "provider must be one of: aws, azure; the facades behind the service wire their identities together only there"
+  }
+}
+
+variable "size" {
+  description = "Capacity of the service: function memory, and on Azure Cosmos DB throughput (see size_profiles in main.tf)"
+  type        = string
+  default     = "small"
+  validation {
+    condition     = contains(["small", "medium", "large"], var.size)
+    error_message = "size must be one of: small, medium, large"
+  }
+}
+
+variable "features" {
+  description = <<-EOT
+    Parts of the service skeleton to create besides the function and its identity, all off by default:
+      - network: VPC or VNet with private subnets; on AWS the function runs in them
+      - database: NoSQL table keyed by id
+      - queue: queue with a dead-letter queue; on AWS it triggers the function
+      - bucket: storage bucket
+      - alarms: default alarms on the function, the queue and the table
+  EOT
+  type        = map(bool)
+  default     = {}
+  validation {
+    condition     = alltrue([for f in keys(var.features) : contains(["network", "database", "queue", "bucket", "alarms"], f)])
+    error_message = "features keys must be among: network, database, queue, bucket, alarms, e.g. { queue = true, bucket = true }"
+  }
+}
+
+variable "function" {
+  description = "Code of the service's function: source_dir is zipped and deployed, without it a stub that logs each event is; environment_variables are set besides BUCKET_NAME, TABLE_NAME and QUEUE_URL"
+  type = object({
+    source_dir            = optional(string)
+    handler               = optional(string, "index.handler")
+    runtime               = optional(string, "python3.11")
+    environment_variables = optional(map(string), {})
+  })
+  default = {}
+}
+
+variable "notification_ref" {
+  description = "Channel the alarms notify ({provider, channel_id}): an SNS topic ARN on aws, a Monitor action group ID on azure"
+  type = object({
+    provider   = string
+    channel_id = string
+  })
+  default = null
+}
+
+variable "provider_config" {
+  description = "Provider-specific configuration passed to every facade, e.g. { location = \"westeurope\" } on azure; availability_zones overrides the two zones the network spans"
+  type        = any
+  default     = {}
+}
+
+variable "tags" {
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
+}
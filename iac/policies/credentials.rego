@@ -0,0 +1,44 @@
+# Long-lived access credentials must carry an expiry, so a key minted for CI
+# bootstrap is rotated instead of staying valid forever. Where the provider
+# cannot expire the credential itself, facade/iam records credentials_expiry_days
+# as metadata: a tag on the AWS user, a keeper on the GCP key.
+
+package iac.policies.credentials
+
+import data.iac.lib
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_iam_access_key"
+	not user_has_expiry(rc)
+	msg := "IAM access key is planned for a user without a CredentialsExpiryDays tag"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "azuread_application_password"
+	object.get(rc.change.after, "end_date", null) == null
+	object.get(rc.change.after, "end_date_relative", null) == null
+	msg := "application password has no end_date or end_date_relative, so it is valid for the two-year default"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "google_service_account_key"
+	not lib.unknown(rc, "keepers")
+	not has_expiry_keeper(rc)
+	msg := "service account key has no credentials_expiry_days keeper, and GCP keys never expire on their own"
+}
+
+# The key's user lives in the same module; it may be unchanged in the plan,
+# so every resource change is searched, not just planned ones
+user_has_expiry(key) {
+	user := input.resource_changes[_]
+	user.type == "aws_iam_user"
+	lib.module_of(user) == lib.module_of(key)
+	user.change.after.tags.CredentialsExpiryDays
+}
+
+has_expiry_keeper(rc) {
+	rc.change.after.keepers.credentials_expiry_days
+}
@@ -0,0 +1,50 @@
+# Data must be encrypted at rest, and in transit where the service lets it be
+# turned off
+
+package iac.policies.encryption
+
+import data.iac.lib
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_s3_bucket"
+	not has_encryption_configuration(lib.module_of(rc))
+	msg := "S3 bucket is planned without an aws_s3_bucket_server_side_encryption_configuration"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_db_instance"
+	not lib.unknown(rc, "storage_encrypted")
+	object.get(rc.change.after, "storage_encrypted", null) != true
+	msg := "RDS instance storage is not encrypted (storage_encrypted)"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_ebs_volume"
+	not lib.unknown(rc, "encrypted")
+	object.get(rc.change.after, "encrypted", null) != true
+	msg := "EBS volume is not encrypted"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_sqs_queue"
+	rc.change.after.sqs_managed_sse_enabled == false
+	object.get(rc.change.after, "kms_master_key_id", null) == null
+	msg := "SQS queue has neither SQS-managed SSE nor a KMS key"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "azurerm_storage_account"
+	rc.change.after.enable_https_traffic_only == false
+	msg := "storage account accepts unencrypted HTTP traffic (enable_https_traffic_only)"
+}
+
+has_encryption_configuration(module) {
+	config := lib.planned[_]
+	config.type == "aws_s3_bucket_server_side_encryption_configuration"
+	lib.module_of(config) == module
+}
@@ -0,0 +1,57 @@
+# Instances must not hand out more through their metadata service and boot
+# chain than they need to: IMDSv2 only on EC2, Trusted Launch on Azure VMs,
+# and Shielded VM without project-wide SSH keys on GCE. The compute facade
+# hardens its instances this way unless insecure_metadata is set.
+
+package iac.policies.instance_metadata
+
+import data.iac.lib
+
+azure_vm_types := {"azurerm_linux_virtual_machine", "azurerm_windows_virtual_machine"}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_instance"
+	not lib.unknown(rc, "metadata_options")
+	not imdsv2_only(rc)
+	msg := "EC2 instance allows IMDSv1 (metadata_options http_tokens is not required)"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	azure_vm_types[rc.type]
+	not trusted_launch(rc)
+	msg := "VM is planned without Trusted Launch (secure_boot_enabled and vtpm_enabled)"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "google_compute_instance"
+	not shielded(rc)
+	msg := "instance is not a Shielded VM with secure boot (shielded_instance_config)"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "google_compute_instance"
+	not lib.unknown(rc, "metadata")
+	not blocks_project_ssh_keys(rc)
+	msg := "instance accepts project-wide SSH keys (metadata block-project-ssh-keys)"
+}
+
+imdsv2_only(rc) {
+	lib.as_list(rc.change.after.metadata_options)[_].http_tokens == "required"
+}
+
+trusted_launch(rc) {
+	rc.change.after.secure_boot_enabled == true
+	rc.change.after.vtpm_enabled == true
+}
+
+blocks_project_ssh_keys(rc) {
+	lower(rc.change.after.metadata["block-project-ssh-keys"]) == "true"
+}
+
+shielded(rc) {
+	lib.as_list(rc.change.after.shielded_instance_config)[_].enable_secure_boot == true
+}
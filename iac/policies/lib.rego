@@ -0,0 +1,33 @@
+# Helpers shared by the policies in this bundle
+
+package iac.lib
+
+changing_actions := {"create", "update"}
+
+# Resource changes the plan creates or updates; deletes and no-ops are not
+# checked
+planned[rc] {
+	rc := input.resource_changes[_]
+	changing_actions[rc.change.actions[_]]
+}
+
+# Module address a resource belongs to, "" for the root module
+module_of(rc) = m {
+	m := rc.module_address
+} else = "" {
+	true
+}
+
+# True when the attribute is only known after apply, so its value cannot be
+# judged from the plan
+unknown(rc, attr) {
+	rc.change.after_unknown[attr] == true
+}
+
+# Normalizes optional list attributes, which the plan encodes as null when
+# unset
+as_list(x) = x {
+	is_array(x)
+} else = [] {
+	true
+}
@@ -0,0 +1,57 @@
+# Access to queues and topics must name its actions. A wildcard also grants
+# whatever SQS and SNS add later, including deleting the resource and
+# rewriting its policy; the messaging facade's grants never use one.
+
+package iac.policies.messaging_access
+
+import data.iac.lib
+
+resource_policy_types := {"aws_sqs_queue_policy", "aws_sns_topic_policy"}
+
+identity_policy_types := {"aws_iam_policy", "aws_iam_role_policy", "aws_iam_user_policy"}
+
+messaging_services := {"sqs", "sns"}
+
+# Any wildcard in a queue or topic policy, "*" included, applies to the
+# messaging resource
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	resource_policy_types[rc.type]
+	not lib.unknown(rc, "policy")
+	action := allowed_actions(rc.change.after.policy)[_]
+	contains(action, "*")
+	msg := sprintf("%s allows the wildcard action %q", [rc.type, action])
+}
+
+# Identity policies are only judged on their SQS and SNS actions; "*" on its
+# own is not a messaging grant
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	identity_policy_types[rc.type]
+	not lib.unknown(rc, "policy")
+	action := allowed_actions(rc.change.after.policy)[_]
+	messaging_wildcard(action)
+	msg := sprintf("IAM policy allows the wildcard action %q on queues or topics", [action])
+}
+
+messaging_wildcard(action) {
+	parts := split(lower(action), ":")
+	count(parts) == 2
+	messaging_services[parts[0]]
+	contains(parts[1], "*")
+}
+
+# Actions of a policy document's Allow statements. Statement and Action may
+# each be a single value or a list.
+allowed_actions(policy) = [action |
+	document := json.unmarshal(policy)
+	statement := as_array(document.Statement)[_]
+	statement.Effect == "Allow"
+	action := as_array(statement.Action)[_]
+]
+
+as_array(x) = x {
+	is_array(x)
+} else = [x] {
+	true
+}
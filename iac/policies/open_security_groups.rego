@@ -0,0 +1,75 @@
+# No ingress rule may admit traffic from anywhere on the internet
+
+package iac.policies.open_security_groups
+
+import data.iac.lib
+
+open_cidrs := {"0.0.0.0/0", "::/0"}
+
+# Azure also accepts service tags and wildcards as source prefixes
+open_prefixes := {"*", "0.0.0.0/0", "::/0", "Internet", "Any"}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_security_group"
+	rule := lib.as_list(rc.change.after.ingress)[_]
+	cidr := array.concat(lib.as_list(rule.cidr_blocks), lib.as_list(rule.ipv6_cidr_blocks))[_]
+	open_cidrs[cidr]
+	msg := sprintf("ingress on ports %v-%v is open to %s", [rule.from_port, rule.to_port, cidr])
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_security_group_rule"
+	rc.change.after.type == "ingress"
+	cidr := array.concat(lib.as_list(rc.change.after.cidr_blocks), lib.as_list(rc.change.after.ipv6_cidr_blocks))[_]
+	open_cidrs[cidr]
+	msg := sprintf("ingress on ports %v-%v is open to %s", [rc.change.after.from_port, rc.change.after.to_port, cidr])
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_vpc_security_group_ingress_rule"
+	cidr := [object.get(rc.change.after, "cidr_ipv4", null), object.get(rc.change.after, "cidr_ipv6", null)][_]
+	open_cidrs[cidr]
+	msg := sprintf("ingress rule is open to %s", [cidr])
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "azurerm_network_security_group"
+	rule := lib.as_list(rc.change.after.security_rule)[_]
+	inbound_allow(rule)
+	prefix := azure_sources(rule)[_]
+	open_prefixes[prefix]
+	msg := sprintf("inbound rule %q allows traffic from %s", [rule.name, prefix])
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "azurerm_network_security_rule"
+	inbound_allow(rc.change.after)
+	prefix := azure_sources(rc.change.after)[_]
+	open_prefixes[prefix]
+	msg := sprintf("inbound rule %q allows traffic from %s", [rc.change.after.name, prefix])
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "google_compute_firewall"
+	upper(object.get(rc.change.after, "direction", "INGRESS")) == "INGRESS"
+	count(lib.as_list(rc.change.after.allow)) > 0
+	cidr := lib.as_list(rc.change.after.source_ranges)[_]
+	open_cidrs[cidr]
+	msg := sprintf("firewall %q allows ingress from %s", [rc.change.after.name, cidr])
+}
+
+inbound_allow(rule) {
+	lower(rule.direction) == "inbound"
+	lower(rule.access) == "allow"
+}
+
+azure_sources(rule) = sources {
+	single := object.get(rule, "source_address_prefix", null)
+	sources := array.concat([single], lib.as_list(object.get(rule, "source_address_prefixes", [])))
+}
@@ -0,0 +1,12 @@
+// Package policies embeds the Rego policy bundle every facade plan must
+// satisfy. Each policy lives in package iac.policies.<name> and defines a
+// violation set of {"address", "msg"} objects over a `terraform show -json`
+// plan; shared helpers live in package iac.lib.
+package policies
+
+import "embed"
+
+// Bundle holds the .rego sources of every policy and helper
+//
+//go:embed *.rego
+var Bundle embed.FS
@@ -0,0 +1,73 @@
+# No storage may be readable by the public internet
+
+package iac.policies.public_access
+
+import data.iac.lib
+
+block_flags := ["block_public_acls", "block_public_policy", "ignore_public_acls", "restrict_public_buckets"]
+
+public_acls := {"public-read", "public-read-write", "authenticated-read"}
+
+public_members := {"allUsers", "allAuthenticatedUsers"}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_s3_bucket"
+	not has_public_access_block(lib.module_of(rc))
+	msg := "S3 bucket is planned without an aws_s3_bucket_public_access_block"
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_s3_bucket_public_access_block"
+	flag := block_flags[_]
+	rc.change.after[flag] == false
+	msg := sprintf("public access block leaves %s disabled", [flag])
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "aws_s3_bucket_acl"
+	public_acls[rc.change.after.acl]
+	msg := sprintf("bucket ACL %q grants public access", [rc.change.after.acl])
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "azurerm_storage_account"
+	rc.change.after.allow_nested_items_to_be_public == true
+	msg := "storage account allows public blob access (allow_nested_items_to_be_public)"
+}
+
+# A bucket in website mode is public by design: Cloud CDN backend buckets
+# only serve publicly readable objects
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "google_storage_bucket_iam_member"
+	public_members[rc.change.after.member]
+	not website_bucket(lib.module_of(rc))
+	msg := sprintf("bucket IAM grants %s to %s", [rc.change.after.role, rc.change.after.member])
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	rc.type == "google_storage_bucket_iam_binding"
+	member := lib.as_list(rc.change.after.members)[_]
+	public_members[member]
+	msg := sprintf("bucket IAM grants %s to %s", [rc.change.after.role, member])
+}
+
+# The facades create the bucket and its public access block in the same
+# module, so the block is matched by module rather than by bucket reference
+has_public_access_block(module) {
+	block := lib.planned[_]
+	block.type == "aws_s3_bucket_public_access_block"
+	lib.module_of(block) == module
+}
+
+website_bucket(module) {
+	bucket := lib.planned[_]
+	bucket.type == "google_storage_bucket"
+	lib.module_of(bucket) == module
+	count(lib.as_list(bucket.change.after.website)) > 0
+}
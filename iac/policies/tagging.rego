@@ -0,0 +1,38 @@
+# Every taggable resource must carry the tags cost allocation relies on.
+# The facades inject them through common/tags.
+
+package iac.policies.tagging
+
+import data.iac.lib
+
+required_tags := ["project", "environment", "managed_by"]
+
+# AWS and Azure carry them as Project, Environment and ManagedBy tags; GCP
+# calls them labels and spells them project, environment and managed_by.
+# Keys are matched ignoring case and underscores so either spelling counts.
+tag_attribute(rc) = "labels" {
+	startswith(rc.type, "google_")
+} else = "tags" {
+	true
+}
+
+violation[{"address": rc.address, "msg": msg}] {
+	rc := lib.planned[_]
+	attr := tag_attribute(rc)
+
+	# Resource types without the attribute are not taggable
+	object.get(rc.change.after, attr, "absent") != "absent"
+	not lib.unknown(rc, attr)
+
+	tag := required_tags[_]
+	not has_tag(rc.change.after[attr], tag)
+	msg := sprintf("missing required %s %q", [trim_suffix(attr, "s"), tag])
+}
+
+has_tag(tags, tag) {
+	some key
+	_ = tags[key]
+	normalize(key) == normalize(tag)
+}
+
+normalize(key) = replace(lower(key), "_", "")
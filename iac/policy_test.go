@@ -0,0 +1,144 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/policycheck"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// facadePlanVars are the minimal variables each facade needs to plan,
+// besides provider_name and project_name
+var facadePlanVars = map[string]map[string]interface{}{
+	"backup": {
+		"plan_name":   "policy-backup",
+		"environment": "dev",
+	},
+	"budget": {
+		"budget_name":       "policy-budget",
+		"monthly_limit_usd": 100,
+		"notification_ref":  map[string]interface{}{"emails": []string{"finops@example.com"}},
+		"environment":       "dev",
+	},
+	"cdn": {
+		"name": "policy-cdn",
+		"origin_ref": map[string]interface{}{
+			"provider":       "aws",
+			"bucket_name":    "policy-site",
+			"id":             "arn:aws:s3:::policy-site",
+			"domain_name":    "policy-site.s3.us-east-1.amazonaws.com",
+			"index_document": "index.html",
+		},
+		"environment": "dev",
+	},
+	"certificate": {
+		"domain_name": "policy.example.com",
+		"zone_ref":    map[string]interface{}{"provider": "aws", "id": "Z0123456789ABCDEFGHIJ", "name": "example.com"},
+		"environment": "dev",
+	},
+	"compute": {
+		"instance_name": "policy-instance",
+	},
+	"database": {
+		"identifier":      "policy-db",
+		"master_password": "PolicyCheck123!",
+	},
+	"encryption": {
+		"name":        "policy-key",
+		"environment": "dev",
+	},
+	"eventbus": {
+		"bus_name":    "policy-eventbus",
+		"environment": "dev",
+	},
+	"events": {
+		"name":        "policy-bus",
+		"environment": "dev",
+	},
+	"iam": {
+		"identity_name": "policy-identity",
+		"environment":   "dev",
+	},
+	"kubernetes": {
+		"cluster_name": "policy-cluster",
+		"vpc_id":       "vpc-12345678",
+		"subnet_ids":   []string{"subnet-12345678", "subnet-87654321"},
+		"environment":  "dev",
+	},
+	"lambda": {
+		"function_name": "policy-function",
+	},
+	"messaging": {
+		"name": "policy-queue",
+	},
+	"networking": {
+		"network_name": "policy-network",
+		"environment":  "dev",
+		"network_config": map[string]interface{}{
+			"cidr":            "10.0.0.0/16",
+			"azs":             []string{"us-east-1a", "us-east-1b"},
+			"public_subnets":  []string{"10.0.1.0/24", "10.0.2.0/24"},
+			"private_subnets": []string{"10.0.10.0/24", "10.0.11.0/24"},
+		},
+	},
+	"monitoring": {
+		"alarm_name":  "policy-alarm",
+		"metric_name": "CPUUtilization",
+		"threshold":   80,
+	},
+	"nosql": {
+		"table_name": "policy-table",
+		"hash_key":   "id",
+	},
+	"secrets": {
+		"name":        "policy-secret",
+		"environment": "dev",
+	},
+	"storage": {
+		"bucket_name":         "policy-bucket",
+		"environment":         "dev",
+		"allow_public_access": false,
+	},
+	"waf": {
+		"waf_name":    "policy-waf",
+		"environment": "dev",
+	},
+	"workflows": {
+		"name":        "policy-workflow",
+		"definition":  `{"StartAt":"Done","States":{"Done":{"Type":"Succeed"}}}`,
+		"role_arn":    "arn:aws:iam::123456789012:role/policy-workflow",
+		"environment": "dev",
+	},
+}
+
+// TestFacadePolicies plans every facade on AWS and checks the plan against
+// the policy bundle in iac/policies
+func TestFacadePolicies(t *testing.T) {
+	t.Parallel()
+
+	for facade, vars := range facadePlanVars {
+		facade, vars := facade, vars
+
+		t.Run(facade, func(t *testing.T) {
+			t.Parallel()
+
+			planVars := map[string]interface{}{
+				"provider_name": "aws",
+				"project_name":  "policycheck",
+			}
+			for k, v := range vars {
+				planVars[k] = v
+			}
+
+			terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+				TerraformDir: filepath.Join("facade", facade),
+				Vars:         planVars,
+			})
+
+			policycheck.AssertPlan(t, terraformOptions)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/providercheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFacadeModulesDeclareNoProviders checks that no facade, and no module a
+// facade calls, configures a provider. Callers pass providers in, e.g. one
+// aliased aws provider per region; a provider block inside the module tree
+// would make that impossible. Provider configuration belongs to the root
+// module (or the spi layer it includes).
+func TestFacadeModulesDeclareNoProviders(t *testing.T) {
+	t.Parallel()
+
+	facades, err := filepath.Glob("facade/*/main.tf")
+	require.NoError(t, err)
+	require.NotEmpty(t, facades)
+	for i, file := range facades {
+		facades[i] = filepath.Dir(file)
+	}
+
+	mods, err := providercheck.Reachable(facades)
+	require.NoError(t, err)
+
+	blocks := providercheck.Report(mods)
+	assert.Empty(t, blocks, "Provider blocks in modules called by facades; move them to the root module and pass providers with the providers argument:\n  %s",
+		strings.Join(blocks, "\n  "))
+}
@@ -0,0 +1,42 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"iac/testutil/modules"
+	"iac/testutil/versioncheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// providerVersionExemptions are modules that intentionally pin a provider
+// outside the shared constraints. Each needs a reason.
+var providerVersionExemptions = []versioncheck.Exemption{}
+
+// TestProviderVersionConstraints checks that every module declares the
+// providers it uses with a pessimistic (~> major.minor) constraint and that
+// modules agree on each provider's major version, so a provider release
+// cannot break one module while its siblings keep planning.
+func TestProviderVersionConstraints(t *testing.T) {
+	t.Parallel()
+
+	for _, e := range providerVersionExemptions {
+		require.NotEmpty(t, e.Reason, "Exemption for %s/%s needs a reason", e.Module, e.Provider)
+	}
+
+	dirs, err := modules.Discover(".")
+	require.NoError(t, err)
+
+	mods, err := versioncheck.InspectAll(dirs)
+	require.NoError(t, err)
+
+	var problems []string
+	for _, p := range versioncheck.Audit(mods, providerVersionExemptions) {
+		problems = append(problems, p.String())
+	}
+
+	assert.Empty(t, problems, "Provider constraint problems:\n  %s\n\n%s",
+		strings.Join(problems, "\n  "), versioncheck.Table(mods))
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"testing"
+
+	"iac/testutil/modules"
+	"iac/testutil/seccheck"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestAllModulesSecurityScan runs trivy (or tfsec) on every Terraform module
+// and fails once per finding at or above SECCHECK_MIN_SEVERITY that
+// .tfsec-ignore.yaml does not suppress.
+func TestAllModulesSecurityScan(t *testing.T) {
+	t.Parallel()
+
+	scanner, err := seccheck.FindScanner()
+	if err != nil {
+		t.Skipf("%v; install trivy or tfsec to run the security scan", err)
+	}
+
+	minSeverity, err := seccheck.MinSeverity()
+	require.NoError(t, err)
+
+	ignores, err := seccheck.LoadIgnores(".tfsec-ignore.yaml")
+	require.NoError(t, err)
+
+	dirs, err := modules.Discover(".")
+	require.NoError(t, err)
+
+	for _, module := range dirs {
+		modulePath := module
+
+		t.Run(modulePath, func(t *testing.T) {
+			t.Parallel()
+
+			seccheck.AssertModule(t, scanner, modulePath, ignores, minSeverity)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/password"
+	"iac/testutil/sensitivecheck"
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// sensitiveAuditVars gives facades with sensitive inputs a value for each,
+// on top of facadePlanVars, so the audit has something to search for
+func sensitiveAuditVars(t *testing.T) map[string]map[string]interface{} {
+	return map[string]map[string]interface{}{
+		"compute": {
+			"ssh_public_key": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAuditKeyForSensitivityCheck audit@example.com",
+		},
+		"database": {
+			"master_password": password.New(t, "aws"),
+		},
+		"secrets": {
+			"secret_string": password.New(t, "aws"),
+		},
+	}
+}
+
+// TestFacadeOutputSensitivity plans every facade on AWS and fails for any
+// output named like a secret that is not marked sensitive, and for any
+// sensitive input that reaches a non-sensitive output or attribute
+func TestFacadeOutputSensitivity(t *testing.T) {
+	t.Parallel()
+
+	extra := sensitiveAuditVars(t)
+
+	for facade, vars := range facadePlanVars {
+		facade, vars := facade, vars
+
+		t.Run(facade, func(t *testing.T) {
+			t.Parallel()
+
+			planVars := map[string]interface{}{
+				"provider_name": "aws",
+				"project_name":  "sensitivity",
+			}
+			for k, v := range vars {
+				planVars[k] = v
+			}
+			var secrets []string
+			for k, v := range extra[facade] {
+				planVars[k] = v
+				secrets = append(secrets, v.(string))
+			}
+
+			terraformOptions := tflog.WithSensitive(t, tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+				TerraformDir: filepath.Join("facade", facade),
+				Vars:         planVars,
+				PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+			}), secrets...)
+
+			planJSON := terraform.InitAndPlanAndShow(t, terraformOptions)
+			sensitivecheck.Check(t, []byte(planJSON), sensitivecheck.DefaultPatterns)
+		})
+	}
+}
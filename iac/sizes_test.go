@@ -0,0 +1,158 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sizesFile = "common/sizes/sizes.json"
+
+// sizedResource is where a facade's provider branch applies the resolved
+// size: the planned resource and the path to the attribute within it
+type sizedResource struct {
+	address   string
+	attribute []string
+}
+
+// sizedFacades lists, per facade, the provider branches that consume
+// sizes.json. The facade name is also the resource kind in sizes.json, so a
+// provider added there without a branch here fails the test by name.
+var sizedFacades = map[string]struct {
+	sizeVar   string
+	providers map[string]sizedResource
+}{
+	"compute": {
+		sizeVar: "instance_size",
+		providers: map[string]sizedResource{
+			"aws":   {"module.aws_compute[0].aws_instance.this", []string{"instance_type"}},
+			"azure": {"module.azure_compute[0].azurerm_linux_virtual_machine.this", []string{"size"}},
+			"gcp":   {"module.gcp_compute[0].google_compute_instance.this", []string{"machine_type"}},
+			"zero":  {"module.zero_compute[0].aws_instance.this", []string{"instance_type"}},
+		},
+	},
+	"database": {
+		sizeVar: "instance_class",
+		providers: map[string]sizedResource{
+			"aws":   {"module.aws_database[0].aws_db_instance.this", []string{"instance_class"}},
+			"azure": {"module.azure_database[0].azurerm_mssql_database.this", []string{"sku_name"}},
+			"gcp":   {"module.gcp_database[0].google_sql_database_instance.this", []string{"settings", "tier"}},
+		},
+	},
+}
+
+// loadSizes reads sizes.json as kind -> provider -> size -> instance type
+func loadSizes(t *testing.T) map[string]map[string]map[string]string {
+	data, err := os.ReadFile(sizesFile)
+	require.NoError(t, err)
+
+	var sizes map[string]map[string]map[string]string
+	require.NoError(t, json.Unmarshal(data, &sizes), "%s should map kind -> provider -> size -> instance type", sizesFile)
+	return sizes
+}
+
+// TestSizeMappingsOnFacades plans every (facade, provider, size) combination
+// in sizes.json and checks the planned resource carries the mapped instance
+// type, so a size or provider added to the mappings cannot go unhandled by
+// the facade that is meant to consume it.
+func TestSizeMappingsOnFacades(t *testing.T) {
+	t.Parallel()
+
+	sizes := loadSizes(t)
+
+	for kind := range sizes {
+		if _, ok := sizedFacades[kind]; !ok {
+			t.Errorf("%s maps %q sizes, but no facade in sizedFacades consumes them", sizesFile, kind)
+		}
+	}
+
+	for facade, sized := range sizedFacades {
+		bySize, ok := sizes[facade]
+		if !ok {
+			t.Errorf("%s has no %q sizes for facade/%s", sizesFile, facade, facade)
+			continue
+		}
+
+		for provider := range sized.providers {
+			if _, ok := bySize[provider]; !ok {
+				t.Errorf("facade/%s sizes its %s branch, but %s has no %s.%s mappings", facade, provider, sizesFile, facade, provider)
+			}
+		}
+
+		for provider, types := range bySize {
+			resource, ok := sized.providers[provider]
+			if !ok {
+				t.Errorf("%s maps %s sizes for %s, but facade/%s has no %s branch in sizedFacades", sizesFile, facade, provider, facade, provider)
+				continue
+			}
+
+			for size, expected := range types {
+				facade, sized, provider, resource, size, expected := facade, sized, provider, resource, size, expected
+
+				t.Run(fmt.Sprintf("%s/%s/%s", facade, provider, size), func(t *testing.T) {
+					t.Parallel()
+
+					planVars := map[string]interface{}{
+						"provider_name": provider,
+						"project_name":  "sizes",
+						sized.sizeVar:   size,
+					}
+					for k, v := range facadePlanVars[facade] {
+						planVars[k] = v
+					}
+					if config, ok := tagProviderConfig[provider]; ok {
+						planVars["provider_config"] = config
+					}
+
+					terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+						TerraformDir: filepath.Join("facade", facade),
+						Vars:         planVars,
+						PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+					})
+
+					plan, err := terraform.InitAndPlanAndShowWithStructE(t, terraformOptions)
+					require.NoError(t, err, "facade/%s cannot plan %s=%q from %s on %s; check its %s validation and %s branch",
+						facade, sized.sizeVar, size, sizesFile, provider, sized.sizeVar, provider)
+
+					planned, ok := plan.ResourcePlannedValuesMap[resource.address]
+					if !ok {
+						addresses := make([]string, 0, len(plan.ResourcePlannedValuesMap))
+						for address := range plan.ResourcePlannedValuesMap {
+							addresses = append(addresses, address)
+						}
+						sort.Strings(addresses)
+						t.Fatalf("facade/%s on %s did not plan %s; planned:\n  %v", facade, provider, resource.address, addresses)
+					}
+
+					assert.Equal(t, expected, attributeAt(planned.AttributeValues, resource.attribute),
+						"%s %s should be the %s.%s.%s mapping", resource.address, resource.attribute, facade, provider, size)
+				})
+			}
+		}
+	}
+}
+
+// attributeAt follows path through planned attribute values, taking the
+// first element of any nested block list on the way
+func attributeAt(values map[string]interface{}, path []string) interface{} {
+	var v interface{} = values
+	for _, key := range path {
+		if list, ok := v.([]interface{}); ok {
+			if len(list) == 0 {
+				return nil
+			}
+			v = list[0]
+		}
+		v = asObject(v)[key]
+	}
+	return v
+}
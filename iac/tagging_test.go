@@ -0,0 +1,281 @@
+package test
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mandatoryTagKeys are injected into every facade resource by common/tags,
+// as tags on AWS and Azure and as labels on GCP
+var mandatoryTagKeys = map[string][]string{
+	"aws":   {"Project", "Environment", "ManagedBy"},
+	"azure": {"Project", "Environment", "ManagedBy"},
+	"gcp":   {"project", "environment", "managed_by"},
+}
+
+// untaggableResourceTypes are resource types the facades plan that support
+// neither tags nor labels
+var untaggableResourceTypes = map[string]bool{
+	"aws_acm_certificate_validation":                       true,
+	"aws_backup_selection":                                 true,
+	"aws_cloudfront_origin_access_control":                 true,
+	"aws_cloudwatch_dashboard":                             true,
+	"aws_iam_access_key":                                   true,
+	"aws_iam_role_policy_attachment":                       true,
+	"aws_iam_user_policy_attachment":                       true,
+	"aws_kms_alias":                                        true,
+	"aws_lambda_alias":                                     true,
+	"aws_lambda_function_url":                              true,
+	"aws_lambda_permission":                                true,
+	"aws_route":                                            true,
+	"aws_route53_record":                                   true,
+	"aws_route_table_association":                          true,
+	"aws_s3_bucket_policy":                                 true,
+	"aws_s3_bucket_public_access_block":                    true,
+	"aws_s3_bucket_server_side_encryption_configuration":   true,
+	"aws_s3_bucket_versioning":                             true,
+	"aws_secretsmanager_secret_version":                    true,
+	"aws_sns_topic_subscription":                           true,
+	"aws_volume_attachment":                                true,
+	"aws_wafv2_web_acl_association":                        true,
+	"azurerm_cdn_frontdoor_custom_domain":                  true,
+	"azurerm_cdn_frontdoor_custom_domain_association":      true,
+	"azurerm_cdn_frontdoor_origin":                         true,
+	"azurerm_cdn_frontdoor_origin_group":                   true,
+	"azurerm_cdn_frontdoor_route":                          true,
+	"azurerm_cdn_frontdoor_rule":                           true,
+	"azurerm_cdn_frontdoor_rule_set":                       true,
+	"azurerm_cdn_frontdoor_secret":                         true,
+	"azurerm_cdn_frontdoor_security_policy":                true,
+	"azurerm_consumption_budget_resource_group":            true,
+	"azurerm_cosmosdb_sql_container":                       true,
+	"azurerm_cosmosdb_sql_database":                        true,
+	"azurerm_data_protection_backup_instance_blob_storage": true,
+	"azurerm_data_protection_backup_instance_disk":         true,
+	"azurerm_data_protection_backup_policy_blob_storage":   true,
+	"azurerm_data_protection_backup_policy_disk":           true,
+	"azurerm_mssql_firewall_rule":                          true,
+	"azurerm_role_assignment":                              true,
+	"azurerm_role_definition":                              true,
+	"azurerm_servicebus_queue":                             true,
+	"azurerm_servicebus_topic":                             true,
+	"azurerm_storage_container":                            true,
+	"azurerm_subnet":                                       true,
+	"azurerm_virtual_machine_data_disk_attachment":         true,
+	"google_billing_budget":                                true,
+	"google_cloud_run_service_iam_member":                  true,
+	"google_compute_attached_disk":                         true,
+	"google_compute_backend_bucket":                        true,
+	"google_compute_disk_resource_policy_attachment":       true,
+	"google_compute_firewall":                              true,
+	"google_compute_network":                               true,
+	"google_compute_resource_policy":                       true,
+	"google_compute_security_policy":                       true,
+	"google_compute_subnetwork":                            true,
+	"google_compute_target_http_proxy":                     true,
+	"google_compute_target_https_proxy":                    true,
+	"google_compute_url_map":                               true,
+	"google_dns_record_set":                                true,
+	"google_firestore_database":                            true,
+	"google_firestore_index":                               true,
+	"google_kms_key_ring":                                  true,
+	"google_project_iam_custom_role":                       true,
+	"google_project_iam_member":                            true,
+	"google_secret_manager_secret_version":                 true,
+	"google_service_account":                               true,
+	"google_service_account_iam_member":                    true,
+	"google_service_account_key":                           true,
+	"google_sql_database":                                  true,
+	"google_sql_user":                                      true,
+	"google_storage_bucket_iam_binding":                    true,
+	"google_storage_bucket_iam_member":                     true,
+	"google_storage_bucket_object":                         true,
+	"google_storage_transfer_job":                          true,
+	"null_resource":                                        true,
+}
+
+// tagProviderConfig is the provider_config each provider's route needs to
+// plan, passed only to facades that declare the variable
+var tagProviderConfig = map[string]map[string]interface{}{
+	"azure": {
+		"resource_group_name": "tagging-rg",
+		"location":            "eastus",
+		// The monitoring facade's metric alert needs a resource to watch
+		"scopes": []string{"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/tagging-rg"},
+	},
+	"gcp": {"project_id": "tagging-project", "region": "us-central1"},
+}
+
+// tagProviderVars are facade variables that differ by provider: references
+// to other facades' resources, which must belong to the planned provider
+var tagProviderVars = map[string]map[string]map[string]interface{}{
+	"backup": {
+		"gcp": {"resources": []map[string]interface{}{{"provider": "gcp", "type": "gcs", "id": "tagging-bucket"}}},
+	},
+	// The shared gcp provider_config has no billing account, so the budget
+	// facade is not in facadesWithProviderConfig
+	"budget": {
+		"gcp": {"provider_config": map[string]interface{}{"project_id": "tagging-project", "billing_account_id": "012345-6789AB-CDEF01"}},
+	},
+	"cdn": {
+		"azure": {"origin_ref": map[string]interface{}{
+			"provider": "azure", "bucket_name": "taggingsite", "id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/tagging-rg/providers/Microsoft.Storage/storageAccounts/taggingsite",
+			"domain_name": "taggingsite.z13.web.core.windows.net", "index_document": "index.html",
+		}},
+		"gcp": {"origin_ref": map[string]interface{}{
+			"provider": "gcp", "bucket_name": "tagging-site", "id": "tagging-site", "index_document": "index.html",
+		}},
+	},
+	// The certificate facade needs a Key Vault rather than the shared
+	// provider_config, so it is not in facadesWithProviderConfig
+	"certificate": {
+		"azure": {
+			"zone_ref":        nil,
+			"provider_config": map[string]interface{}{"key_vault_id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/tagging-rg/providers/Microsoft.KeyVault/vaults/tagging-kv"},
+		},
+		"gcp": {
+			"zone_ref":        map[string]interface{}{"provider": "gcp", "id": "tagging-zone", "name": "example.com"},
+			"provider_config": map[string]interface{}{"project_id": "tagging-project"},
+		},
+	},
+	// A snapshotted data volume, so its disk and snapshot policy are checked
+	"compute": {
+		"aws":   {"data_volumes": taggingDataVolumes},
+		"azure": {"data_volumes": taggingDataVolumes},
+		"gcp":   {"data_volumes": taggingDataVolumes},
+	},
+}
+
+var taggingDataVolumes = []map[string]interface{}{
+	{"mount_name": "data", "size_gb": 10, "snapshot_schedule": map[string]interface{}{"cron": "0 2 * * *"}},
+}
+
+// untaggedRoutes are facade routes that plan no taggable resource at all
+var untaggedRoutes = map[string]bool{
+	// Consumption budgets take no tags
+	"budget/azure": true,
+	// Cloud Armor security policies take neither tags nor labels
+	"waf/gcp": true,
+}
+
+var facadesWithProviderConfig = map[string]bool{
+	"backup":     true,
+	"cdn":        true,
+	"compute":    true,
+	"database":   true,
+	"eventbus":   true,
+	"iam":        true,
+	"lambda":     true,
+	"messaging":  true,
+	"monitoring": true,
+	"networking": true,
+	"storage":    true,
+	"waf":        true,
+}
+
+// TestMandatoryTagsOnAllFacades plans every facade on each provider and
+// checks that every taggable resource carries the mandatory tags (labels on
+// GCP), so cost allocation never meets an untagged resource.
+func TestMandatoryTagsOnAllFacades(t *testing.T) {
+	t.Parallel()
+
+	for facade, vars := range facadePlanVars {
+		providers := []string{"aws", "azure", "gcp"}
+		if facade == "kubernetes" {
+			// Only the EKS route is implemented
+			providers = []string{"aws"}
+		}
+
+		for _, provider := range providers {
+			facade, vars, provider := facade, vars, provider
+
+			t.Run(facade+"/"+provider, func(t *testing.T) {
+				t.Parallel()
+
+				planVars := map[string]interface{}{
+					"provider_name": provider,
+					"project_name":  "tagging",
+				}
+				for k, v := range vars {
+					planVars[k] = v
+				}
+				for k, v := range tagProviderVars[facade][provider] {
+					planVars[k] = v
+				}
+				if config, ok := tagProviderConfig[provider]; ok && facadesWithProviderConfig[facade] {
+					planVars["provider_config"] = config
+				}
+
+				terraformOptions := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+					TerraformDir: filepath.Join("facade", facade),
+					Vars:         planVars,
+					PlanFilePath: filepath.Join(t.TempDir(), "plan.out"),
+				})
+
+				plan := terraform.InitAndPlanAndShowWithStruct(t, terraformOptions)
+
+				checked := 0
+				var problems []string
+				for address, resource := range plan.ResourcePlannedValuesMap {
+					if resource.Mode != "managed" || untaggableResourceTypes[resource.Type] {
+						continue
+					}
+					checked++
+
+					tags, ok := resourceTags(resource.Type, resource.AttributeValues)
+					if !ok {
+						problems = append(problems, fmt.Sprintf("%s: no tags (allowlist the type if it cannot be tagged)", address))
+						continue
+					}
+					for _, key := range mandatoryTagKeys[provider] {
+						if _, present := tags[key]; !present {
+							problems = append(problems, fmt.Sprintf("%s: missing %q", address, key))
+						}
+					}
+				}
+				sort.Strings(problems)
+
+				if !untaggedRoutes[facade+"/"+provider] {
+					require.NotZero(t, checked, "Plan should contain taggable resources")
+				}
+				assert.Empty(t, problems, "Resources without the mandatory tags:\n  %s", strings.Join(problems, "\n  "))
+			})
+		}
+	}
+}
+
+// resourceTags returns the tag map of a planned resource: labels on GCP,
+// user_labels where GCP reserves labels for something else, tags elsewhere
+func resourceTags(resourceType string, attributes map[string]interface{}) (map[string]interface{}, bool) {
+	var value interface{}
+	switch {
+	case resourceType == "google_sql_database_instance":
+		settings, _ := attributes["settings"].([]interface{})
+		if len(settings) > 0 {
+			value = asObject(settings[0])["user_labels"]
+		}
+	case strings.HasPrefix(resourceType, "google_monitoring_"):
+		value = attributes["user_labels"]
+	case strings.HasPrefix(resourceType, "google_"):
+		value = attributes["labels"]
+	default:
+		value = attributes["tags"]
+	}
+
+	tags, ok := value.(map[string]interface{})
+	return tags, ok
+}
+
+func asObject(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
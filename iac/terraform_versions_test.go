@@ -0,0 +1,116 @@
+//go:build tfversions
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
+	"iac/testutil/tfversions"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFacadeTerraformVersions plans every facade in facadePlanVars with each
+// Terraform version in SWE_TF_VERSIONS (default: the oldest supported and
+// the latest), and reports a facade × version table to the test log, and to
+// versions.md in the test's artifact directory when SWE_TEST_ARTIFACT_DIR is
+// set. A version whose init fails on the first facade is given up on
+// without planning the rest, so an unsupported CLI costs one init rather
+// than a full run. Run it with the tfversions tag:
+//
+//	SWE_TF_VERSIONS=1.9.0,latest go test -tags tfversions -run TestFacadeTerraformVersions -timeout 2h .
+func TestFacadeTerraformVersions(t *testing.T) {
+	versions, err := tfversions.VersionsFromEnv()
+	require.NoError(t, err)
+	cacheDir, err := tfversions.CacheDirFromEnv()
+	require.NoError(t, err)
+	installer := &tfversions.Installer{CacheDir: cacheDir}
+
+	facades := make([]string, 0, len(facadePlanVars))
+	for facade := range facadePlanVars {
+		facades = append(facades, facade)
+	}
+	sort.Strings(facades)
+
+	var results tfversions.Results
+	labels := map[string]string{}
+
+	for _, version := range versions {
+		version := version
+
+		t.Run(version, func(t *testing.T) {
+			binary, err := installer.Install(context.Background(), version)
+			if err != nil {
+				results.AddAll(version, facades, tfversions.NotInstalled)
+				t.Fatal(err)
+			}
+			if binary.Version != version {
+				labels[version] = fmt.Sprintf("%s (%s)", version, binary.Version)
+			}
+
+			// Each version plans its own copy, so no CLI reads another's
+			// .terraform directory or lock file
+			root := test_structure.CopyTerraformFolderToTemp(t, ".", "facade")
+
+			first := facades[0]
+			if _, err := terraform.InitE(t, versionOptions(t, filepath.Join(root, first), binary.Path, first)); err != nil {
+				results.AddAll(version, facades, tfversions.InitFailed)
+				t.Fatalf("Terraform %s cannot init facade/%s, so the rest were not planned: %v", binary.Version, first, err)
+			}
+
+			t.Run("plan", func(t *testing.T) {
+				for _, facade := range facades {
+					facade := facade
+
+					t.Run(facade, func(t *testing.T) {
+						t.Parallel()
+
+						_, err := terraform.InitAndPlanE(t, versionOptions(t, filepath.Join(root, facade), binary.Path, facade))
+						if !assert.NoError(t, err, "facade/%s should plan with Terraform %s", facade, binary.Version) {
+							results.Add(version, facade, tfversions.Fail)
+							return
+						}
+						results.Add(version, facade, tfversions.Pass)
+					})
+				}
+			})
+		})
+	}
+
+	var report strings.Builder
+	require.NoError(t, tfversions.WriteTable(&report, results.All(), labels))
+	t.Logf("Terraform versions:\n%s", report.String())
+	if dir := tflog.LoadOptions().ArtifactDir; dir != "" {
+		path := filepath.Join(dir, tflog.ArtifactName(t.Name()), "versions.md")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(report.String()), 0o644))
+	}
+}
+
+// versionOptions plans facade in dir on AWS with the Terraform at binary
+func versionOptions(t *testing.T, dir, binary, facade string) *terraform.Options {
+	vars := map[string]interface{}{
+		"provider_name": "aws",
+		"project_name":  "tfversions",
+	}
+	for k, v := range facadePlanVars[facade] {
+		vars[k] = v
+	}
+	return tfopts.New(t, &terraform.Options{
+		TerraformDir:    dir,
+		TerraformBinary: binary,
+		Vars:            vars,
+		NoColor:         true,
+	})
+}
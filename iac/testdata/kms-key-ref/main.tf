@@ -0,0 +1,92 @@
+# kms_key_ref contract fixture
+#
+# Feeds one customer-managed key reference into every facade that accepts
+# kms_key_ref so the contract test can check each encryptable resource uses it.
+
+terraform {
+  required_version = ">= 1.2"
+}
+
+variable "provider_name" {
+  description = "Provider under test"
+  type        = string
+}
+
+variable "kms_key_ref" {
+  description = "Key reference shared by all facades"
+  type = object({
+    provider = string
+    id       = string
+  })
+}
+
+locals {
+  provider_config = {
+    resource_group_name = "cmk-contract-rg"
+    location            = "eastus"
+    region              = "us-central1"
+    project_id          = "cmk-contract"
+    sku                 = "Premium"
+  }
+}
+
+module "storage" {
+  source = "../../facade/storage"
+
+  provider_name   = var.provider_name
+  project_name    = "cmkcontract"
+  environment     = "test"
+  bucket_name     = "cmk-contract-bucket"
+  kms_key_ref     = var.kms_key_ref
+  provider_config = local.provider_config
+}
+
+module "queue" {
+  source = "../../facade/messaging"
+
+  provider_name   = var.provider_name
+  project_name    = "cmkcontract"
+  name            = "cmk-contract-queue"
+  type            = "queue"
+  kms_key_ref     = var.kms_key_ref
+  provider_config = local.provider_config
+}
+
+module "topic" {
+  source = "../../facade/messaging"
+
+  provider_name   = var.provider_name
+  project_name    = "cmkcontract"
+  name            = "cmk-contract-topic"
+  type            = "topic"
+  kms_key_ref     = var.kms_key_ref
+  provider_config = local.provider_config
+}
+
+module "database" {
+  source = "../../facade/database"
+
+  provider_name   = var.provider_name
+  project_name    = "cmkcontract"
+  identifier      = "cmk-contract-db"
+  master_password = "Contract-Test-Passw0rd"
+  kms_key_ref     = var.kms_key_ref
+  provider_config = local.provider_config
+}
+
+module "lambda" {
+  source = "../../facade/lambda"
+
+  provider_name   = var.provider_name
+  project_name    = "cmkcontract"
+  function_name   = "cmk-contract-fn"
+  runtime         = "python3.11"
+  handler         = "index.handler"
+  kms_key_ref     = var.kms_key_ref
+  provider_config = local.provider_config
+
+  source_code = <<-EOT
+    def handler(event, context):
+        return {"statusCode": 200}
+  EOT
+}
@@ -0,0 +1,204 @@
+// Package artifacts gives each test a scratch directory of its own for the
+// files it uploads, downloads or generates, in place of fixed paths in /tmp
+// that collide when tests run in parallel and linger after a crash:
+//
+//	upload := artifacts.WriteTempFile(t, "upload.txt", []byte("Hello"))
+//	download := filepath.Join(artifacts.TempDir(t), "download.txt")
+//	artifacts.ReadAndCompare(t, download, []byte("Hello"))
+//
+// The directory is created under the test's artifact directory when
+// SWE_TEST_ARTIFACT_DIR is set, beside its Terraform log (see tflog), and
+// under the system temporary directory otherwise. It is removed when the
+// test ends, unless the test failed or SWE_KEEP_ARTIFACTS is set, in which
+// case its path is logged so the files can be inspected.
+package artifacts
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"iac/testutil/config"
+	"iac/testutil/tflog"
+)
+
+// EnvKeepArtifacts keeps every test's directory, passed or failed
+const EnvKeepArtifacts = "SWE_KEEP_ARTIFACTS"
+
+// TempRoot is the directory under the system temporary directory that
+// holds the test directories when SWE_TEST_ARTIFACT_DIR is not set
+const TempRoot = "swe-test-artifacts"
+
+// Options configures where test directories go and whether they are kept
+type Options struct {
+	// Root holds one directory per test, named by tflog.ArtifactName
+	Root string
+
+	// Keep retains the directories of tests that passed
+	Keep bool
+}
+
+// LoadOptions reads Options from SWE_TEST_ARTIFACT_DIR and
+// SWE_KEEP_ARTIFACTS
+func LoadOptions() (Options, error) {
+	keep, err := config.BoolFromEnv(EnvKeepArtifacts)
+	if err != nil {
+		return Options{}, err
+	}
+	root := tflog.LoadOptions().ArtifactDir
+	if root == "" {
+		root = filepath.Join(os.TempDir(), TempRoot)
+	}
+	return Options{Root: root, Keep: keep}, nil
+}
+
+// TestingT is the subset of testing.TB the package needs; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Name() string
+	Failed() bool
+	Cleanup(func())
+	Logf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+var (
+	dirsMu sync.Mutex
+	dirs   = map[TestingT]string{}
+)
+
+// TempDir returns t's directory, creating it on the first call, with the
+// options from the environment. An invalid SWE_KEEP_ARTIFACTS fails t.
+func TempDir(t TestingT) string {
+	t.Helper()
+
+	opts, err := LoadOptions()
+	if err != nil {
+		t.Fatalf("artifacts: %v", err)
+	}
+	return TempDirWith(t, opts)
+}
+
+// TempDirWith is TempDir with opts in place of the environment. The
+// directory is named after t under opts.Root, with a random suffix, so
+// parallel tests, and repeated runs of one test, never share one.
+func TempDirWith(t TestingT, opts Options) string {
+	t.Helper()
+
+	dirsMu.Lock()
+	defer dirsMu.Unlock()
+
+	if dir, ok := dirs[t]; ok {
+		return dir
+	}
+
+	parent := filepath.Join(opts.Root, tflog.ArtifactName(t.Name()))
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		t.Fatalf("artifacts: creating %s: %v", parent, err)
+	}
+	dir, err := os.MkdirTemp(parent, "tmp-")
+	if err != nil {
+		t.Fatalf("artifacts: creating a directory in %s: %v", parent, err)
+	}
+	dirs[t] = dir
+
+	t.Cleanup(func() {
+		dirsMu.Lock()
+		delete(dirs, t)
+		dirsMu.Unlock()
+
+		switch {
+		case t.Failed():
+			t.Logf("Test failed; its files are kept in %s", dir)
+		case opts.Keep:
+			t.Logf("%s is set; the test's files are kept in %s", EnvKeepArtifacts, dir)
+		default:
+			if err := os.RemoveAll(dir); err != nil {
+				t.Errorf("artifacts: removing %s: %v", dir, err)
+				return
+			}
+			removeEmptyParents(parent, opts.Root)
+		}
+	})
+	return dir
+}
+
+// removeEmptyParents removes dir and its parents up to root, stopping at
+// the first that is not empty, such as one holding a Terraform log
+func removeEmptyParents(dir, root string) {
+	for dir != root && dir != "." && dir != string(filepath.Separator) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// WriteTempFile writes data to name in t's directory and returns its path
+func WriteTempFile(t TestingT, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(TempDir(t), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("artifacts: creating the directory of %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("artifacts: writing %s: %v", path, err)
+	}
+	return path
+}
+
+// ReadAndCompare reads the file at path, such as a download, and fails t
+// unless it holds want. It reports whether it did.
+func ReadAndCompare(t TestingT, path string, want []byte) bool {
+	t.Helper()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("artifacts: reading %s: %v", path, err)
+		return false
+	}
+	return Compare(t, filepath.Base(path), got, want)
+}
+
+// Compare fails t unless got is want, describing where they first differ
+// rather than printing them, which may be large. On a mismatch both are
+// written to t's directory, as name.got and name.want, which is kept since
+// t failed. It reports whether they matched.
+func Compare(t TestingT, name string, got, want []byte) bool {
+	t.Helper()
+
+	if bytes.Equal(got, want) {
+		return true
+	}
+
+	dir := TempDir(t)
+	for suffix, data := range map[string][]byte{".got": got, ".want": want} {
+		if err := os.WriteFile(filepath.Join(dir, name+suffix), data, 0o644); err != nil {
+			t.Logf("artifacts: keeping %s%s: %v", name, suffix, err)
+		}
+	}
+	t.Errorf("%s: %s; both are kept in %s", name, difference(got, want), dir)
+	return false
+}
+
+// difference describes where got first differs from want
+func difference(got, want []byte) string {
+	n := min(len(got), len(want))
+	for i := 0; i < n; i++ {
+		if got[i] != want[i] {
+			return fmt.Sprintf("got %d bytes, want %d; first difference at byte %d: got %q, want %q",
+				len(got), len(want), i, excerpt(got, i), excerpt(want, i))
+		}
+	}
+	return fmt.Sprintf("got %d bytes, want %d; one is a prefix of the other", len(got), len(want))
+}
+
+// excerpt returns up to 16 bytes of data from i
+func excerpt(data []byte, i int) []byte {
+	return data[i:min(i+16, len(data))]
+}
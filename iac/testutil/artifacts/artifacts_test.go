@@ -0,0 +1,200 @@
+package artifacts_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"iac/testutil/artifacts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeT is a test that can fail without failing the test running it
+type fakeT struct {
+	name string
+
+	mu       sync.Mutex
+	failed   bool
+	logs     []string
+	cleanups []func()
+}
+
+// errFatal is what Fatalf panics with, for run to recover
+type errFatal struct{}
+
+func (f *fakeT) Helper()      {}
+func (f *fakeT) Name() string { return f.name }
+
+func (f *fakeT) Failed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failed
+}
+
+func (f *fakeT) Cleanup(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeT) Logf(format string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs = append(f.logs, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.Logf(format, args...)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failed = true
+}
+
+func (f *fakeT) Fatalf(format string, args ...interface{}) {
+	f.Errorf(format, args...)
+	panic(errFatal{})
+}
+
+// run runs fn as the body of f, recovering from Fatalf as the testing
+// package does, then runs f's cleanups, last registered first
+func (f *fakeT) run(fn func(t *fakeT)) {
+	defer func() {
+		for i := len(f.cleanups) - 1; i >= 0; i-- {
+			f.cleanups[i]()
+		}
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(errFatal); !ok {
+				panic(r)
+			}
+		}
+	}()
+	fn(f)
+}
+
+func TestTempDirRemovedAfterPass(t *testing.T) {
+	t.Parallel()
+
+	opts := artifacts.Options{Root: t.TempDir()}
+	var dir string
+	fake := &fakeT{name: "TestUpload/small"}
+	fake.run(func(ft *fakeT) {
+		dir = artifacts.TempDirWith(ft, opts)
+		assert.Equal(t, dir, artifacts.TempDirWith(ft, opts), "A test should get one directory however often it asks")
+		assert.True(t, strings.HasPrefix(dir, filepath.Join(opts.Root, "TestUpload", "small")+string(filepath.Separator)))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "upload.txt"), []byte("data"), 0o644))
+	})
+
+	assert.False(t, fake.Failed())
+	assert.NoDirExists(t, dir)
+	assert.NoDirExists(t, filepath.Join(opts.Root, "TestUpload"), "Empty parents should go too")
+	assert.DirExists(t, opts.Root)
+}
+
+func TestTempDirKeptAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	opts := artifacts.Options{Root: t.TempDir()}
+	var dir string
+	fake := &fakeT{name: "TestDownload"}
+	fake.run(func(ft *fakeT) {
+		dir = artifacts.TempDirWith(ft, opts)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "download.txt"), []byte("partial"), 0o644))
+		ft.Fatalf("download was cut short")
+		t.Error("Fatalf should stop the fake test")
+	})
+
+	assert.True(t, fake.Failed())
+	assert.FileExists(t, filepath.Join(dir, "download.txt"), "A failed test's files should be kept")
+	require.NotEmpty(t, fake.logs)
+	assert.Contains(t, fake.logs[len(fake.logs)-1], dir, "The kept directory should be logged")
+}
+
+func TestTempDirKeptWhenAsked(t *testing.T) {
+	t.Parallel()
+
+	opts := artifacts.Options{Root: t.TempDir(), Keep: true}
+	var dir string
+	fake := &fakeT{name: "TestKeep"}
+	fake.run(func(ft *fakeT) {
+		dir = artifacts.TempDirWith(ft, opts)
+	})
+
+	assert.False(t, fake.Failed())
+	assert.DirExists(t, dir)
+	require.Len(t, fake.logs, 1)
+	assert.Contains(t, fake.logs[0], artifacts.EnvKeepArtifacts)
+}
+
+func TestTempDirUniqueUnderConcurrency(t *testing.T) {
+	t.Parallel()
+
+	// Same-named tests, as with -count or two packages' TestIntegration,
+	// must still get directories of their own
+	opts := artifacts.Options{Root: t.TempDir(), Keep: true}
+	const tests = 64
+	paths := make([]string, tests)
+	var wg sync.WaitGroup
+	for i := range tests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			(&fakeT{name: "TestIntegration"}).run(func(ft *fakeT) {
+				paths[i] = artifacts.TempDirWith(ft, opts)
+			})
+		}()
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for _, path := range paths {
+		require.NotEmpty(t, path)
+		assert.False(t, seen[path], "%s was handed out twice", path)
+		seen[path] = true
+		assert.DirExists(t, path)
+	}
+}
+
+func TestWriteTempFileAndReadAndCompare(t *testing.T) {
+	t.Setenv(artifacts.EnvKeepArtifacts, "")
+	t.Setenv("SWE_TEST_ARTIFACT_DIR", t.TempDir())
+
+	var path, dir string
+	fake := &fakeT{name: "TestRoundTrip"}
+	fake.run(func(ft *fakeT) {
+		path = artifacts.WriteTempFile(ft, "nested/upload.txt", []byte("Hello from Terratest!"))
+		dir = artifacts.TempDir(ft)
+		assert.Equal(t, filepath.Join(dir, "nested", "upload.txt"), path)
+		assert.True(t, artifacts.ReadAndCompare(ft, path, []byte("Hello from Terratest!")))
+	})
+	assert.False(t, fake.Failed())
+	assert.NoFileExists(t, path)
+
+	fake = &fakeT{name: "TestRoundTrip"}
+	fake.run(func(ft *fakeT) {
+		path = artifacts.WriteTempFile(ft, "download.txt", []byte("Hello from Terratest?"))
+		dir = artifacts.TempDir(ft)
+		assert.False(t, artifacts.ReadAndCompare(ft, path, []byte("Hello from Terratest!")))
+	})
+	require.True(t, fake.Failed())
+	assert.Contains(t, strings.Join(fake.logs, "\n"), `first difference at byte 20: got "?", want "!"`)
+	assert.FileExists(t, filepath.Join(dir, "download.txt.got"))
+	assert.FileExists(t, filepath.Join(dir, "download.txt.want"))
+}
+
+func TestTempDirRejectsInvalidKeep(t *testing.T) {
+	t.Setenv(artifacts.EnvKeepArtifacts, "sometimes")
+
+	fake := &fakeT{name: "TestInvalid"}
+	fake.run(func(ft *fakeT) {
+		artifacts.TempDir(ft)
+	})
+	require.True(t, fake.Failed())
+	assert.Contains(t, fake.logs[0], artifacts.EnvKeepArtifacts)
+}
@@ -0,0 +1,271 @@
+// Package cidralloc hands out network ranges that do not overlap, so
+// networking tests running in parallel against one emulator or account do
+// not fail peering and overlapping-subnet validations on each other's
+// hard-coded 10.x.0.0/16 blocks:
+//
+//	cidr := cidralloc.Block(t)
+//	subnets, err := cidralloc.SubnetsFrom(cidr, 4, 8)
+//	require.NoError(t, err)
+//	public, private := subnets[:2], subnets[2:]
+//
+// Block carves /16s out of the SWE_TEST_CIDR_SUPERNET range, starting at a
+// random one so that two test binaries sharing the range rarely collide.
+// Block6 does the same for dual-stack fixtures, with /56s out of the
+// SWE_TEST_CIDR6_SUPERNET unique local range; SubnetsFrom splits those into
+// /64s.
+package cidralloc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/netip"
+	"os"
+	"sync"
+	"testing"
+)
+
+// BlockBits is the prefix length of the blocks Block hands out
+const BlockBits = 16
+
+// Block6Bits is the prefix length of the blocks Block6 hands out, the
+// size of an AWS VPC's IPv6 range
+const Block6Bits = 56
+
+// EnvCIDRSupernet names the range Block carves blocks out of
+const EnvCIDRSupernet = "SWE_TEST_CIDR_SUPERNET"
+
+// EnvCIDR6Supernet names the range Block6 carves blocks out of
+const EnvCIDR6Supernet = "SWE_TEST_CIDR6_SUPERNET"
+
+// DefaultSupernet is the range when SWE_TEST_CIDR_SUPERNET is unset
+const DefaultSupernet = "10.0.0.0/8"
+
+// DefaultSupernet6 is the range when SWE_TEST_CIDR6_SUPERNET is unset
+const DefaultSupernet6 = "fd00::/40"
+
+// Options configures Block and Block6
+type Options struct {
+	// Supernet is an IPv4 range of /16 or larger
+	Supernet string
+	// Supernet6 is an IPv6 range of /56 or larger
+	Supernet6 string
+}
+
+// LoadOptions reads Options from SWE_TEST_CIDR_SUPERNET and
+// SWE_TEST_CIDR6_SUPERNET
+func LoadOptions() (Options, error) {
+	supernet, err := loadSupernet(EnvCIDRSupernet, DefaultSupernet, true, BlockBits)
+	if err != nil {
+		return Options{}, err
+	}
+	supernet6, err := loadSupernet(EnvCIDR6Supernet, DefaultSupernet6, false, Block6Bits)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{Supernet: supernet, Supernet6: supernet6}, nil
+}
+
+func loadSupernet(name, fallback string, is4 bool, bits int) (string, error) {
+	supernet := os.Getenv(name)
+	if supernet == "" {
+		return fallback, nil
+	}
+
+	family := map[bool]string{true: "IPv4", false: "IPv6"}[is4]
+	prefix, err := netip.ParsePrefix(supernet)
+	switch {
+	case err != nil:
+		return "", fmt.Errorf("cidralloc: %s: %q is not a CIDR block", name, supernet)
+	case prefix.Addr().Is4() != is4:
+		return "", fmt.Errorf("cidralloc: %s: %q must be %s", name, supernet, family)
+	case prefix.Bits() > bits:
+		return "", fmt.Errorf("cidralloc: %s: %q must be /%d or larger", name, supernet, bits)
+	}
+	return supernet, nil
+}
+
+// ErrExhausted is returned once every block of the supernet is in use
+var ErrExhausted = errors.New("cidralloc: supernet exhausted")
+
+// Allocator hands out blocks of one prefix length from a supernet, never
+// the same block twice while it is in use. It is safe for concurrent use.
+type Allocator struct {
+	supernet netip.Prefix
+	bits     int
+	blocks   int
+	start    int
+
+	mu   sync.Mutex
+	used map[int]bool
+}
+
+// New returns an allocator of /bits blocks from the supernet, starting at a
+// random block. IPv6 blocks can be at most /64.
+func New(supernet string, bits int) (*Allocator, error) {
+	prefix, err := netip.ParsePrefix(supernet)
+	if err != nil {
+		return nil, fmt.Errorf("cidralloc: supernet: %w", err)
+	}
+	if bits < prefix.Bits() || bits > maxBits(prefix.Addr()) {
+		return nil, fmt.Errorf("cidralloc: /%d blocks do not fit in %s", bits, supernet)
+	}
+	// Cap the count so a /0 supernet of /32s still fits in an int
+	blocks := 1 << min(bits-prefix.Bits(), 30)
+
+	return &Allocator{
+		supernet: prefix.Masked(),
+		bits:     bits,
+		blocks:   blocks,
+		start:    rand.IntN(blocks),
+		used:     make(map[int]bool),
+	}, nil
+}
+
+// Allocate returns a block no other caller holds, or ErrExhausted
+func (a *Allocator) Allocate() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i := 0; i < a.blocks; i++ {
+		n := (a.start + i) % a.blocks
+		if !a.used[n] {
+			a.used[n] = true
+			return a.block(n).String(), nil
+		}
+	}
+	return "", fmt.Errorf("%w: all %d /%d blocks of %s are in use", ErrExhausted, a.blocks, a.bits, a.supernet)
+}
+
+// Release returns a block to the allocator. Releasing a block it did not
+// hand out does nothing.
+func (a *Allocator) Release(cidr string) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil || prefix.Bits() != a.bits || !a.supernet.Contains(prefix.Addr()) {
+		return
+	}
+	n := int((high(prefix.Addr()) - high(a.supernet.Addr())) >> (64 - a.bits))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.used, n)
+}
+
+func (a *Allocator) block(n int) netip.Prefix {
+	return nth(a.supernet, a.bits, n)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Allocator)
+)
+
+// Block returns a /16 from the configured supernet that no other test in
+// this process holds, released when t finishes. It fails t when the
+// supernet is exhausted.
+func Block(t testing.TB) string {
+	t.Helper()
+
+	opts, err := LoadOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return allocate(t, opts.Supernet, BlockBits)
+}
+
+// Block6 is Block for IPv6: a /56 from the configured unique local range
+func Block6(t testing.TB) string {
+	t.Helper()
+
+	opts, err := LoadOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return allocate(t, opts.Supernet6, Block6Bits)
+}
+
+func allocate(t testing.TB, supernet string, bits int) string {
+	t.Helper()
+
+	registryMu.Lock()
+	a, ok := registry[supernet]
+	if !ok {
+		var err error
+		a, err = New(supernet, bits)
+		if err != nil {
+			registryMu.Unlock()
+			t.Fatalf("%v", err)
+		}
+		registry[supernet] = a
+	}
+	registryMu.Unlock()
+
+	cidr, err := a.Allocate()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	t.Cleanup(func() { a.Release(cidr) })
+	return cidr
+}
+
+// SubnetsFrom splits the first count subnets off cidr, each newBits longer
+// than it, as Terraform's cidrsubnet(cidr, newBits, i) does for i from 0 to
+// count-1: SubnetsFrom("10.7.0.0/16", 2, 8) is 10.7.0.0/24 and 10.7.1.0/24.
+// IPv6 ranges split down to /64: SubnetsFrom("fd00:0:7::/56", 2, 8) is
+// fd00:0:7::/64 and fd00:0:7:1::/64.
+func SubnetsFrom(cidr string, count, newBits int) ([]string, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("cidralloc: %w", err)
+	}
+	if prefix.Masked() != prefix {
+		return nil, fmt.Errorf("cidralloc: %s has host bits set, want %s", cidr, prefix.Masked())
+	}
+
+	bits := prefix.Bits() + newBits
+	if newBits < 1 || bits > maxBits(prefix.Addr()) {
+		return nil, fmt.Errorf("cidralloc: cannot split %s into /%d subnets", cidr, bits)
+	}
+	if count < 0 || (newBits < 31 && count > 1<<newBits) {
+		return nil, fmt.Errorf("cidralloc: %s has %d /%d subnets, %d requested", cidr, 1<<newBits, bits, count)
+	}
+
+	subnets := make([]string, count)
+	for i := range subnets {
+		subnets[i] = nth(prefix, bits, i).String()
+	}
+	return subnets, nil
+}
+
+// maxBits is the longest prefix this package hands out for addr's family:
+// /32 for IPv4 and /64, the smallest subnet clouds allow, for IPv6
+func maxBits(addr netip.Addr) int {
+	if addr.Is4() {
+		return 32
+	}
+	return 64
+}
+
+// nth is the nth /bits block of prefix. Blocks are counted in the top 64
+// bits of the address, where an IPv4 address takes the top 32.
+func nth(prefix netip.Prefix, bits, n int) netip.Prefix {
+	v := high(prefix.Addr()) + uint64(n)<<(64-bits)
+	if prefix.Addr().Is4() {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(v>>32))
+		return netip.PrefixFrom(netip.AddrFrom4(b), bits)
+	}
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], v)
+	return netip.PrefixFrom(netip.AddrFrom16(b), bits)
+}
+
+func high(addr netip.Addr) uint64 {
+	if addr.Is4() {
+		b := addr.As4()
+		return uint64(binary.BigEndian.Uint32(b[:])) << 32
+	}
+	b := addr.As16()
+	return binary.BigEndian.Uint64(b[:8])
+}
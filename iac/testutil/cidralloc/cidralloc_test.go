@@ -0,0 +1,256 @@
+package cidralloc_test
+
+import (
+	"errors"
+	"net/netip"
+	"sync"
+	"testing"
+
+	"iac/testutil/cidralloc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocateExhaustsSupernet(t *testing.T) {
+	t.Parallel()
+
+	a, err := cidralloc.New("10.0.0.0/14", 16)
+	require.NoError(t, err)
+
+	var blocks []string
+	for i := 0; i < 4; i++ {
+		cidr, err := a.Allocate()
+		require.NoError(t, err)
+		blocks = append(blocks, cidr)
+	}
+	assert.ElementsMatch(t, []string{"10.0.0.0/16", "10.1.0.0/16", "10.2.0.0/16", "10.3.0.0/16"}, blocks)
+
+	_, err = a.Allocate()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, cidralloc.ErrExhausted))
+	assert.Contains(t, err.Error(), "all 4 /16 blocks of 10.0.0.0/14")
+
+	a.Release("10.2.0.0/16")
+	cidr, err := a.Allocate()
+	require.NoError(t, err)
+	assert.Equal(t, "10.2.0.0/16", cidr, "A released block should be handed out again")
+}
+
+func TestReleaseIgnoresForeignBlocks(t *testing.T) {
+	t.Parallel()
+
+	a, err := cidralloc.New("10.0.0.0/15", 16)
+	require.NoError(t, err)
+	first, err := a.Allocate()
+	require.NoError(t, err)
+
+	for _, cidr := range []string{"192.168.0.0/16", "10.0.0.0/24", "not a cidr"} {
+		a.Release(cidr)
+	}
+
+	second, err := a.Allocate()
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	_, err = a.Allocate()
+	assert.ErrorIs(t, err, cidralloc.ErrExhausted, "Foreign releases should not free a block")
+}
+
+func TestAllocateConcurrently(t *testing.T) {
+	t.Parallel()
+
+	a, err := cidralloc.New("10.0.0.0/8", 16)
+	require.NoError(t, err)
+
+	const workers = 64
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		blocks []netip.Prefix
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 4; j++ {
+				cidr, err := a.Allocate()
+				if !assert.NoError(t, err) {
+					return
+				}
+				mu.Lock()
+				blocks = append(blocks, netip.MustParsePrefix(cidr))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, blocks, 256, "Every /16 of a /8 should be handed out")
+	supernet := netip.MustParsePrefix("10.0.0.0/8")
+	for i, p := range blocks {
+		assert.True(t, supernet.Contains(p.Addr()), "%s is outside %s", p, supernet)
+		for _, q := range blocks[i+1:] {
+			assert.False(t, p.Overlaps(q), "%s overlaps %s", p, q)
+		}
+	}
+}
+
+func TestNewRejectsBadSupernets(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		supernet string
+		bits     int
+		contains string
+	}{
+		"not a cidr":      {"10.0.0.0", 16, "supernet"},
+		"block too large": {"10.0.0.0/16", 8, "do not fit"},
+		"block too small": {"10.0.0.0/8", 33, "do not fit"},
+		"ipv6 past /64":   {"fd00::/56", 72, "do not fit"},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := cidralloc.New(tc.supernet, tc.bits)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.contains)
+		})
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	t.Setenv(cidralloc.EnvCIDRSupernet, "")
+	t.Setenv(cidralloc.EnvCIDR6Supernet, "")
+	opts, err := cidralloc.LoadOptions()
+	require.NoError(t, err)
+	assert.Equal(t, cidralloc.DefaultSupernet, opts.Supernet)
+	assert.Equal(t, cidralloc.DefaultSupernet6, opts.Supernet6)
+
+	tests := map[string]struct {
+		env      string
+		supernet string
+		contains string
+	}{
+		"not a cidr":      {cidralloc.EnvCIDRSupernet, "10.0.0.0", "is not a CIDR block"},
+		"ipv6":            {cidralloc.EnvCIDRSupernet, "fd00::/8", "must be IPv4"},
+		"too small":       {cidralloc.EnvCIDRSupernet, "10.0.0.0/24", "/16 or larger"},
+		"ipv4 for ipv6":   {cidralloc.EnvCIDR6Supernet, "10.0.0.0/8", "must be IPv6"},
+		"ipv6 too small":  {cidralloc.EnvCIDR6Supernet, "fd00::/64", "/56 or larger"},
+		"ipv6 not a cidr": {cidralloc.EnvCIDR6Supernet, "fd00::", "is not a CIDR block"},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(tc.env, tc.supernet)
+
+			_, err := cidralloc.LoadOptions()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.env)
+			assert.Contains(t, err.Error(), tc.contains)
+		})
+	}
+}
+
+func TestAllocateIPv6(t *testing.T) {
+	t.Parallel()
+
+	a, err := cidralloc.New("fd00:0:100::/54", 56)
+	require.NoError(t, err)
+
+	var blocks []string
+	for i := 0; i < 4; i++ {
+		cidr, err := a.Allocate()
+		require.NoError(t, err)
+		blocks = append(blocks, cidr)
+	}
+	assert.ElementsMatch(t, []string{"fd00:0:100::/56", "fd00:0:100:100::/56", "fd00:0:100:200::/56", "fd00:0:100:300::/56"}, blocks)
+
+	_, err = a.Allocate()
+	assert.ErrorIs(t, err, cidralloc.ErrExhausted)
+
+	a.Release("fd00:0:100:200::/56")
+	a.Release("10.0.0.0/16")
+	cidr, err := a.Allocate()
+	require.NoError(t, err)
+	assert.Equal(t, "fd00:0:100:200::/56", cidr, "A released block should be handed out again")
+}
+
+func TestBlockReleasesWhenTestFinishes(t *testing.T) {
+	t.Setenv(cidralloc.EnvCIDRSupernet, "172.20.0.0/16")
+
+	var held string
+	t.Run("holder", func(t *testing.T) {
+		held = cidralloc.Block(t)
+		assert.Equal(t, "172.20.0.0/16", held)
+	})
+
+	// The only block is free again once the subtest has finished
+	assert.Equal(t, held, cidralloc.Block(t))
+}
+
+func TestBlock6(t *testing.T) {
+	t.Setenv(cidralloc.EnvCIDR6Supernet, "fd12:3456:7800::/56")
+
+	assert.Equal(t, "fd12:3456:7800::/56", cidralloc.Block6(t))
+}
+
+func TestSubnetsFrom(t *testing.T) {
+	t.Parallel()
+
+	subnets, err := cidralloc.SubnetsFrom("10.7.0.0/16", 4, 8)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.7.0.0/24", "10.7.1.0/24", "10.7.2.0/24", "10.7.3.0/24"}, subnets)
+
+	// Subnets stay inside the parent and apart from each other
+	parent := netip.MustParsePrefix("172.16.0.0/12")
+	subnets, err = cidralloc.SubnetsFrom(parent.String(), 16, 4)
+	require.NoError(t, err)
+	require.Len(t, subnets, 16)
+	for i, s := range subnets {
+		p := netip.MustParsePrefix(s)
+		assert.Equal(t, 16, p.Bits())
+		assert.True(t, parent.Contains(p.Addr()), "%s is outside %s", p, parent)
+		for _, other := range subnets[i+1:] {
+			assert.False(t, p.Overlaps(netip.MustParsePrefix(other)), "%s overlaps %s", s, other)
+		}
+	}
+	assert.Equal(t, "172.31.0.0/16", subnets[15], "The last subnet should end where the parent does")
+
+	subnets, err = cidralloc.SubnetsFrom("fd00:0:7::/56", 3, 8)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fd00:0:7::/64", "fd00:0:7:1::/64", "fd00:0:7:2::/64"}, subnets)
+}
+
+func TestSubnetsFromRejectsBadInput(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cidr     string
+		count    int
+		newBits  int
+		contains string
+	}{
+		"not a cidr":  {"10.0.0.0", 2, 8, "cidralloc"},
+		"past /64":    {"fd00::/64", 2, 8, "cannot split"},
+		"host bits":   {"10.0.1.0/16", 2, 8, "has host bits set, want 10.0.0.0/16"},
+		"past /32":    {"10.0.0.0/28", 2, 8, "cannot split"},
+		"no new bits": {"10.0.0.0/16", 1, 0, "cannot split"},
+		"too many":    {"10.0.0.0/16", 5, 2, "has 4 /18 subnets, 5 requested"},
+		"negative":    {"10.0.0.0/16", -1, 8, "-1 requested"},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := cidralloc.SubnetsFrom(tc.cidr, tc.count, tc.newBits)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.contains)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package compat records which cloud API operations each emulator
+// implements, so a test that needs a missing one skips with a message that
+// names the gap instead of failing somewhere inside terraform apply:
+//
+//	ensureCloudEmuRunning(t)
+//	compat.RequireEmulatorSupport(t, "s3.PutBucketReplication")
+//
+// The matrix lives in matrix.json next to this file, one entry per
+// service, operation and emulator. An operation a test depends on must be
+// listed, and an unsupported one must link the issue or note tracking it.
+// Verify probes listed operations against a running emulator, so the
+// report test in aws/test fails once an unsupported operation starts
+// working and its tests can stop skipping.
+package compat
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Emulators, as named in the matrix
+const (
+	CloudEmu  = "cloudemu"
+	ZeroCloud = "zerocloud"
+)
+
+// displayNames are how skip messages name each emulator, matching
+// integration.Require
+var displayNames = map[string]string{
+	CloudEmu:  "CloudEmu",
+	ZeroCloud: "ZeroCloud",
+}
+
+// Entry is one operation on one emulator
+type Entry struct {
+	// Service and Operation name the API call as in the SDK, e.g. "s3" and
+	// "PutBucketReplication". Behaviour that is not a call of its own,
+	// such as SNS applying a subscription's filter policy, gets a name of
+	// its own, e.g. "FilterPolicy".
+	Service   string `json:"service"`
+	Operation string `json:"operation"`
+
+	Emulator  string `json:"emulator"`
+	Supported bool   `json:"supported"`
+
+	// Issue links where the gap is tracked; required when Supported is
+	// false
+	Issue string `json:"issue,omitempty"`
+}
+
+// Name is the entry's "service.Operation"
+func (e Entry) Name() string {
+	return e.Service + "." + e.Operation
+}
+
+// Matrix is every entry, in file order
+type Matrix []Entry
+
+//go:embed matrix.json
+var matrixJSON []byte
+
+// Parse decodes and validates a matrix: every field of an entry is set,
+// the emulator is a known one, no operation is listed twice for the same
+// emulator, and every unsupported entry links an issue
+func Parse(data []byte) (Matrix, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var m Matrix
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("compat: decoding matrix: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for i, e := range m {
+		switch {
+		case e.Service == "" || e.Operation == "":
+			return nil, fmt.Errorf("compat: entry %d: service and operation are required", i)
+		case displayNames[e.Emulator] == "":
+			return nil, fmt.Errorf("compat: %s: unknown emulator %q, want %q or %q", e.Name(), e.Emulator, CloudEmu, ZeroCloud)
+		case !e.Supported && e.Issue == "":
+			return nil, fmt.Errorf("compat: %s on %s is unsupported but links no issue", e.Name(), e.Emulator)
+		}
+
+		key := e.Emulator + " " + e.Name()
+		if seen[key] {
+			return nil, fmt.Errorf("compat: %s on %s is listed twice", e.Name(), e.Emulator)
+		}
+		seen[key] = true
+	}
+	return m, nil
+}
+
+var loadDefault = sync.OnceValues(func() (Matrix, error) {
+	return Parse(matrixJSON)
+})
+
+// Default returns the matrix in matrix.json
+func Default() (Matrix, error) {
+	return loadDefault()
+}
+
+// Lookup returns the entry for name ("s3.PutBucketReplication") on emulator
+func (m Matrix) Lookup(emulator, name string) (Entry, bool) {
+	for _, e := range m {
+		if e.Emulator == emulator && e.Name() == name {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// TestingT is the subset of testing.TB the package needs; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Skipf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// Require skips t when the matrix says emulator does not support name, and
+// fails it when name is not listed for emulator at all, so every gap a test
+// works around is recorded
+func (m Matrix) Require(t TestingT, emulator, name string) {
+	t.Helper()
+
+	e, ok := m.Lookup(emulator, name)
+	if !ok {
+		t.Fatalf("compat: %s on %s is not in the compatibility matrix; add it to testutil/compat/matrix.json", name, emulator)
+		return
+	}
+	if !e.Supported {
+		t.Skipf("%s does not support %s (%s)", displayNames[emulator], name, e.Issue)
+	}
+}
+
+// RequireEmulatorSupport is Require on the Default matrix for CloudEmu
+func RequireEmulatorSupport(t TestingT, name string) {
+	t.Helper()
+
+	m, err := Default()
+	if err != nil {
+		t.Fatalf("%v", err)
+		return
+	}
+	m.Require(t, CloudEmu, name)
+}
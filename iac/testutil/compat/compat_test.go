@@ -0,0 +1,156 @@
+package compat_test
+
+import (
+	"fmt"
+	"testing"
+
+	"iac/testutil/compat"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a compat.TestingT and compat.Reporter that keeps what it was
+// told
+type recorder struct {
+	skips  []string
+	fatals []string
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Skipf(format string, args ...interface{}) {
+	r.skips = append(r.skips, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.fatals = append(r.fatals, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+const testMatrix = `[
+  {"service": "s3", "operation": "PutBucketVersioning", "emulator": "cloudemu", "supported": true},
+  {"service": "s3", "operation": "PutBucketReplication", "emulator": "cloudemu", "supported": false, "issue": "https://example.com/issues/1"},
+  {"service": "s3", "operation": "PutBucketReplication", "emulator": "zerocloud", "supported": true}
+]`
+
+func TestDefault(t *testing.T) {
+	t.Parallel()
+
+	m, err := compat.Default()
+	require.NoError(t, err, "matrix.json should be valid")
+	assert.NotEmpty(t, m)
+
+	for _, name := range []string{"s3.PutBucketReplication", "s3.PutBucketNotificationConfiguration", "dynamodb.GlobalSecondaryIndex", "sns.FilterPolicy", "lambda.ReservedConcurrency"} {
+		_, ok := m.Lookup(compat.CloudEmu, name)
+		assert.True(t, ok, "%s gates integration tests and must be listed", name)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	m, err := compat.Parse([]byte(testMatrix))
+	require.NoError(t, err)
+	require.Len(t, m, 3)
+
+	e, ok := m.Lookup(compat.CloudEmu, "s3.PutBucketReplication")
+	require.True(t, ok)
+	assert.Equal(t, compat.Entry{
+		Service:   "s3",
+		Operation: "PutBucketReplication",
+		Emulator:  compat.CloudEmu,
+		Issue:     "https://example.com/issues/1",
+	}, e)
+
+	e, ok = m.Lookup(compat.ZeroCloud, "s3.PutBucketReplication")
+	require.True(t, ok, "The same operation may be listed once per emulator")
+	assert.True(t, e.Supported)
+
+	_, ok = m.Lookup(compat.CloudEmu, "s3.GetBucketReplication")
+	assert.False(t, ok)
+}
+
+func TestParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		matrix string
+		want   string
+	}{
+		"not json": {
+			matrix: `{`,
+			want:   "compat: decoding matrix",
+		},
+		"unknown field": {
+			matrix: `[{"service": "s3", "operation": "ListBuckets", "emulator": "cloudemu", "supported": true, "notes": "x"}]`,
+			want:   `unknown field "notes"`,
+		},
+		"missing operation": {
+			matrix: `[{"service": "s3", "emulator": "cloudemu", "supported": true}]`,
+			want:   "compat: entry 0: service and operation are required",
+		},
+		"unknown emulator": {
+			matrix: `[{"service": "s3", "operation": "ListBuckets", "emulator": "localstack", "supported": true}]`,
+			want:   `compat: s3.ListBuckets: unknown emulator "localstack"`,
+		},
+		"unsupported without issue": {
+			matrix: `[{"service": "s3", "operation": "PutBucketReplication", "emulator": "cloudemu", "supported": false}]`,
+			want:   "compat: s3.PutBucketReplication on cloudemu is unsupported but links no issue",
+		},
+		"duplicate": {
+			matrix: `[
+				{"service": "s3", "operation": "ListBuckets", "emulator": "cloudemu", "supported": true},
+				{"service": "s3", "operation": "ListBuckets", "emulator": "cloudemu", "supported": true}
+			]`,
+			want: "compat: s3.ListBuckets on cloudemu is listed twice",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := compat.Parse([]byte(tt.matrix))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	t.Parallel()
+
+	m, err := compat.Parse([]byte(testMatrix))
+	require.NoError(t, err)
+
+	supported := &recorder{}
+	m.Require(supported, compat.CloudEmu, "s3.PutBucketVersioning")
+	assert.Empty(t, supported.skips)
+	assert.Empty(t, supported.fatals)
+
+	unsupported := &recorder{}
+	m.Require(unsupported, compat.CloudEmu, "s3.PutBucketReplication")
+	assert.Equal(t, []string{"CloudEmu does not support s3.PutBucketReplication (https://example.com/issues/1)"}, unsupported.skips)
+	assert.Empty(t, unsupported.fatals)
+
+	unlisted := &recorder{}
+	m.Require(unlisted, compat.CloudEmu, "s3.GetBucketReplication")
+	assert.Empty(t, unlisted.skips)
+	assert.Equal(t, []string{
+		"compat: s3.GetBucketReplication on cloudemu is not in the compatibility matrix; add it to testutil/compat/matrix.json",
+	}, unlisted.fatals, "A gap a test works around must be recorded")
+}
+
+func TestRequireEmulatorSupport(t *testing.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	compat.RequireEmulatorSupport(r, "s3.PutBucketReplication")
+	require.Len(t, r.skips, 1)
+	assert.Contains(t, r.skips[0], "CloudEmu does not support s3.PutBucketReplication")
+}
@@ -0,0 +1,183 @@
+package compat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+)
+
+// DefaultProbeTimeout bounds each probe
+const DefaultProbeTimeout = 30 * time.Second
+
+// Probe exercises one operation against a running emulator. It returns nil
+// when the operation works, an error for which IsUnsupported is true when
+// the emulator rejects or ignores it, and any other error when the probe
+// could not tell.
+type Probe func(ctx context.Context) error
+
+// ErrUnsupported is wrapped by probes that see an operation accepted but
+// not honoured, such as a filter policy that lets every message through
+var ErrUnsupported = errors.New("operation not supported")
+
+// unsupportedCodes are the error codes emulators answer unimplemented API
+// calls with
+var unsupportedCodes = []string{
+	"InvalidAction",
+	"NotImplemented",
+	"NotImplementedException",
+	"UnknownOperationException",
+	"UnsupportedOperation",
+}
+
+// IsUnsupported reports whether err says the operation is not implemented:
+// ErrUnsupported, an SDK error with one of the codes emulators use for
+// unimplemented calls, or an HTTP 501
+func IsUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrUnsupported) {
+		return true
+	}
+
+	// The SDK's awserr.Error and awserr.RequestFailure, without the import
+	var coded interface{ Code() string }
+	if errors.As(err, &coded) && slices.Contains(unsupportedCodes, coded.Code()) {
+		return true
+	}
+	var status interface{ StatusCode() int }
+	return errors.As(err, &status) && status.StatusCode() == http.StatusNotImplemented
+}
+
+// Status compares a probe with the matrix
+type Status string
+
+// Statuses of a Result
+const (
+	// Confirmed means the probe agrees with the matrix
+	Confirmed Status = "confirmed"
+	// Stale means the matrix says unsupported but the probe succeeded
+	Stale Status = "stale"
+	// Regressed means the matrix says supported but the probe was rejected
+	Regressed Status = "regressed"
+	// Failed means the probe could not tell, or the operation is not listed
+	Failed Status = "failed"
+)
+
+// Result is one probed operation
+type Result struct {
+	Name     string
+	Emulator string
+
+	// Entry is the matrix entry, zero when the operation is not listed
+	Entry  Entry
+	Status Status
+	Err    error
+}
+
+// Verify runs each probe against emulator, within timeout each, and returns
+// the results sorted by operation name
+func Verify(ctx context.Context, m Matrix, emulator string, probes map[string]Probe, timeout time.Duration) []Result {
+	names := make([]string, 0, len(probes))
+	for name := range probes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]Result, 0, len(names))
+	for _, name := range names {
+		e, ok := m.Lookup(emulator, name)
+		if !ok {
+			results = append(results, Result{
+				Name:     name,
+				Emulator: emulator,
+				Status:   Failed,
+				Err:      errors.New("not in the compatibility matrix"),
+			})
+			continue
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := probes[name](probeCtx)
+		cancel()
+		results = append(results, classify(e, err))
+	}
+	return results
+}
+
+// classify compares the outcome of e's probe with e
+func classify(e Entry, err error) Result {
+	r := Result{Name: e.Name(), Emulator: e.Emulator, Entry: e, Err: err}
+	switch {
+	case err == nil && e.Supported, IsUnsupported(err) && !e.Supported:
+		r.Status = Confirmed
+	case err == nil:
+		r.Status = Stale
+	case IsUnsupported(err):
+		r.Status = Regressed
+	default:
+		r.Status = Failed
+	}
+	return r
+}
+
+// Reporter is the subset of testing.TB Check needs
+type Reporter interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Check fails t once for every result that is not Confirmed and reports
+// whether all were
+func Check(t Reporter, results []Result) bool {
+	t.Helper()
+
+	ok := true
+	for _, r := range results {
+		switch r.Status {
+		case Stale:
+			t.Errorf("%s on %s now works: set supported to true in testutil/compat/matrix.json so its tests stop skipping", r.Name, r.Emulator)
+		case Regressed:
+			t.Errorf("%s on %s is listed as supported but was rejected: %v", r.Name, r.Emulator, r.Err)
+		case Failed:
+			t.Errorf("%s on %s: probe failed: %v", r.Name, r.Emulator, r.Err)
+		default:
+			continue
+		}
+		ok = false
+	}
+	return ok
+}
+
+// WriteReport writes results as a Markdown table: operation, emulator, what
+// the matrix says, what the probe saw, the status and the issue
+func WriteReport(w io.Writer, results []Result) error {
+	if _, err := fmt.Fprint(w, "| Operation | Emulator | Matrix | Probe | Status | Issue |\n| :--- | :--- | :--- | :--- | :--- | :--- |\n"); err != nil {
+		return err
+	}
+	for _, r := range results {
+		matrix := "unsupported"
+		switch {
+		case r.Entry.Service == "":
+			matrix = "unlisted"
+		case r.Entry.Supported:
+			matrix = "supported"
+		}
+		probe := "supported"
+		switch {
+		case r.Status == Failed:
+			probe = "error"
+		case r.Err != nil:
+			probe = "unsupported"
+		}
+		if _, err := fmt.Fprintf(w, "| `%s` | %s | %s | %s | %s | %s |\n", r.Name, r.Emulator, matrix, probe, r.Status, r.Entry.Issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
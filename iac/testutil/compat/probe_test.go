@@ -0,0 +1,154 @@
+package compat_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/compat"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiError stands in for the SDK's awserr.RequestFailure
+type apiError struct {
+	code   string
+	status int
+}
+
+func (e apiError) Error() string   { return fmt.Sprintf("%s: status %d", e.code, e.status) }
+func (e apiError) Code() string    { return e.code }
+func (e apiError) StatusCode() int { return e.status }
+
+func TestIsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"nil":                      {nil, false},
+		"sentinel":                 {fmt.Errorf("filtered message delivered: %w", compat.ErrUnsupported), true},
+		"not implemented code":     {apiError{"NotImplemented", 400}, true},
+		"unknown operation code":   {apiError{"UnknownOperationException", 400}, true},
+		"wrapped code":             {fmt.Errorf("put replication: %w", apiError{"InvalidAction", 400}), true},
+		"501 without a known code": {apiError{"InternalError", 501}, true},
+		"access denied":            {apiError{"AccessDenied", 403}, false},
+		"connection refused":       {errors.New("dial tcp 127.0.0.1:4566: connection refused"), false},
+	}
+
+	for name, tt := range tests {
+		assert.Equal(t, tt.want, compat.IsUnsupported(tt.err), name)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	m, err := compat.Parse([]byte(`[
+		{"service": "s3", "operation": "A", "emulator": "cloudemu", "supported": true},
+		{"service": "s3", "operation": "B", "emulator": "cloudemu", "supported": false, "issue": "#2"},
+		{"service": "s3", "operation": "C", "emulator": "cloudemu", "supported": false, "issue": "#3"},
+		{"service": "s3", "operation": "D", "emulator": "cloudemu", "supported": true},
+		{"service": "s3", "operation": "E", "emulator": "cloudemu", "supported": true}
+	]`))
+	require.NoError(t, err)
+
+	notImplemented := apiError{"NotImplemented", 501}
+	broken := errors.New("connection reset")
+	probes := map[string]compat.Probe{
+		"s3.E": func(context.Context) error { return broken },
+		"s3.D": func(context.Context) error { return notImplemented },
+		"s3.C": func(context.Context) error { return nil },
+		"s3.B": func(context.Context) error { return notImplemented },
+		"s3.A": func(context.Context) error { return nil },
+		"s3.Z": func(context.Context) error { return nil },
+	}
+
+	results := compat.Verify(context.Background(), m, compat.CloudEmu, probes, time.Second)
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Name+" "+string(r.Status))
+	}
+	assert.Equal(t, []string{
+		"s3.A confirmed",
+		"s3.B confirmed",
+		"s3.C stale",
+		"s3.D regressed",
+		"s3.E failed",
+		"s3.Z failed",
+	}, got, "Results should be sorted by name")
+	assert.Equal(t, broken, results[4].Err)
+	assert.EqualError(t, results[5].Err, "not in the compatibility matrix")
+}
+
+func TestVerifyTimeout(t *testing.T) {
+	t.Parallel()
+
+	m, err := compat.Parse([]byte(`[{"service": "s3", "operation": "A", "emulator": "cloudemu", "supported": true}]`))
+	require.NoError(t, err)
+
+	probes := map[string]compat.Probe{
+		"s3.A": func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	results := compat.Verify(context.Background(), m, compat.CloudEmu, probes, 10*time.Millisecond)
+	require.Len(t, results, 1)
+	assert.Equal(t, compat.Failed, results[0].Status, "A probe that times out cannot tell either way")
+	assert.ErrorIs(t, results[0].Err, context.DeadlineExceeded)
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	results := []compat.Result{
+		{Name: "s3.A", Emulator: compat.CloudEmu, Status: compat.Confirmed},
+		{Name: "s3.C", Emulator: compat.CloudEmu, Status: compat.Stale},
+		{Name: "s3.D", Emulator: compat.CloudEmu, Status: compat.Regressed, Err: errors.New("NotImplemented")},
+		{Name: "s3.E", Emulator: compat.CloudEmu, Status: compat.Failed, Err: errors.New("connection reset")},
+	}
+
+	r := &recorder{}
+	assert.False(t, compat.Check(r, results))
+	assert.Equal(t, []string{
+		"s3.C on cloudemu now works: set supported to true in testutil/compat/matrix.json so its tests stop skipping",
+		"s3.D on cloudemu is listed as supported but was rejected: NotImplemented",
+		"s3.E on cloudemu: probe failed: connection reset",
+	}, r.errors)
+
+	clean := &recorder{}
+	assert.True(t, compat.Check(clean, results[:1]))
+	assert.Empty(t, clean.errors)
+}
+
+func TestWriteReport(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	require.NoError(t, compat.WriteReport(&b, []compat.Result{
+		{
+			Name:     "s3.PutBucketReplication",
+			Emulator: compat.CloudEmu,
+			Entry:    compat.Entry{Service: "s3", Operation: "PutBucketReplication", Emulator: compat.CloudEmu, Issue: "#1"},
+			Status:   compat.Confirmed,
+			Err:      compat.ErrUnsupported,
+		},
+		{Name: "s3.Z", Emulator: compat.CloudEmu, Status: compat.Failed, Err: errors.New("not in the compatibility matrix")},
+	}))
+
+	assert.Equal(t, strings.Join([]string{
+		"| Operation | Emulator | Matrix | Probe | Status | Issue |",
+		"| :--- | :--- | :--- | :--- | :--- | :--- |",
+		"| `s3.PutBucketReplication` | cloudemu | unsupported | unsupported | confirmed | #1 |",
+		"| `s3.Z` | cloudemu | unlisted | error | failed |  |",
+		"",
+	}, "\n"), b.String())
+}
@@ -0,0 +1,198 @@
+// Package concurrency throttles integration tests so running the whole
+// suite with t.Parallel does not overload the emulators or corrupt the
+// shared Terraform plugin cache.
+//
+// Tests deploy inside RunThrottled, which holds a slot of a semaphore sized
+// by SWE_TEST_MAX_PARALLEL (default 4) for as long as its function runs.
+// Init serializes terraform init on a lock file in TF_PLUGIN_CACHE_DIR, when
+// that is set, because concurrent inits writing the same cache entry
+// intermittently fail or leave a truncated provider binary behind.
+//
+// The semaphore is per test binary: go test ./... runs packages in separate
+// processes, so the suite-wide cap is SWE_TEST_MAX_PARALLEL times go test's
+// -p. The plugin cache lock is shared by every process.
+package concurrency
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"iac/testutil/config"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// EnvMaxParallel sizes the semaphore RunThrottled and RunWeighted share
+const EnvMaxParallel = "SWE_TEST_MAX_PARALLEL"
+
+// DefaultMaxParallel is the number of slots when SWE_TEST_MAX_PARALLEL is
+// unset
+const DefaultMaxParallel = 4
+
+// PluginCacheEnv names the plugin cache directory Terraform shares between
+// inits
+const PluginCacheEnv = "TF_PLUGIN_CACHE_DIR"
+
+// pluginCacheLockName is the lock file Init creates in the plugin cache
+const pluginCacheLockName = ".swe-test-init.lock"
+
+var (
+	sharedOnce sync.Once
+	shared     *Semaphore
+
+	// held records the slots each test holds, so a throttled helper called
+	// from inside RunThrottled does not wait for a second slot (and, with
+	// every slot taken by such tests, deadlock)
+	heldMu sync.Mutex
+	held   = make(map[*testing.T]int64)
+)
+
+// Options configures the throttle
+type Options struct {
+	// MaxParallel is the number of slots in the shared semaphore
+	MaxParallel int
+}
+
+// LoadOptions reads Options from SWE_TEST_MAX_PARALLEL
+func LoadOptions() (Options, error) {
+	n, err := config.IntFromEnv(EnvMaxParallel, DefaultMaxParallel, 1)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{MaxParallel: n}, nil
+}
+
+// sharedSemaphore returns the process-wide semaphore, sized from Options on
+// first use
+func sharedSemaphore(t *testing.T) *Semaphore {
+	sharedOnce.Do(func() {
+		opts, err := LoadOptions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		shared = NewSemaphore(int64(opts.MaxParallel))
+	})
+	return shared
+}
+
+// RunThrottled runs fn while holding one slot of the shared semaphore
+func RunThrottled(t *testing.T, fn func()) {
+	t.Helper()
+	RunWeighted(t, 1, fn)
+}
+
+// RunWeighted runs fn while holding weight slots, for tests that deploy
+// several stacks at once. Weights above SWE_TEST_MAX_PARALLEL are capped to
+// it, so such a test still runs, alone, when the limit is low. Calls nested
+// inside another RunWeighted of the same test run without taking more
+// slots.
+func RunWeighted(t *testing.T, weight int64, fn func()) {
+	t.Helper()
+
+	heldMu.Lock()
+	nested := held[t] > 0
+	heldMu.Unlock()
+	if nested {
+		fn()
+		return
+	}
+
+	sem := sharedSemaphore(t)
+	weight = min(max(weight, 1), sem.Size())
+
+	ctx, cancel := testContext(t)
+	defer cancel()
+
+	start := time.Now()
+	if err := sem.Acquire(ctx, weight); err != nil {
+		t.Fatalf("%v (raise %s or run fewer tests at once)", err, EnvMaxParallel)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Logf("Waited %s for %d of %d test slots", waited.Round(time.Second), weight, sem.Size())
+	}
+
+	heldMu.Lock()
+	held[t] = weight
+	heldMu.Unlock()
+
+	// Deferred so the slots come back when fn fails the test with
+	// t.Fatal, which exits fn through runtime.Goexit
+	defer func() {
+		heldMu.Lock()
+		delete(held, t)
+		heldMu.Unlock()
+		sem.Release(weight)
+	}()
+
+	fn()
+}
+
+// Init runs terraform init, holding the plugin cache lock while it does
+// when a cache is configured in the environment or in options.EnvVars
+func Init(t *testing.T, options *terraform.Options) {
+	t.Helper()
+
+	cacheDir := options.EnvVars[PluginCacheEnv]
+	if cacheDir == "" {
+		cacheDir = os.Getenv(PluginCacheEnv)
+	}
+	if cacheDir == "" {
+		terraform.Init(t, options)
+		return
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		t.Fatalf("concurrency: creating plugin cache %s: %v", cacheDir, err)
+	}
+
+	ctx, cancel := testContext(t)
+	defer cancel()
+
+	lock := NewFileLock(filepath.Join(cacheDir, pluginCacheLockName))
+	if err := lock.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	terraform.Init(t, options)
+}
+
+// InitAndApply is terraform.InitAndApply under RunThrottled, with init
+// going through Init
+func InitAndApply(t *testing.T, options *terraform.Options) {
+	t.Helper()
+
+	RunThrottled(t, func() {
+		Init(t, options)
+		tfopts.Apply(t, options)
+	})
+}
+
+// Destroy is terraform.Destroy under RunThrottled, for deferring next to
+// InitAndApply
+func Destroy(t *testing.T, options *terraform.Options) {
+	t.Helper()
+
+	RunThrottled(t, func() {
+		tfopts.Destroy(t, options)
+	})
+}
+
+// testContext is canceled shortly before the test binary's -timeout, so a
+// test stuck waiting fails with a message instead of a panic dump
+func testContext(t *testing.T) (context.Context, context.CancelFunc) {
+	deadline, ok := t.Deadline()
+	if !ok {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), deadline.Add(-30*time.Second))
+}
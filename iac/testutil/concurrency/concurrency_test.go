@@ -0,0 +1,46 @@
+package concurrency_test
+
+import (
+	"testing"
+
+	"iac/testutil/concurrency"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Not parallel: these share the process-wide semaphore, sized by the
+// default SWE_TEST_MAX_PARALLEL of 4
+
+func TestRunThrottledNested(t *testing.T) {
+	ran := false
+	concurrency.RunThrottled(t, func() {
+		// Would wait forever for a slot if every slot were held by tests
+		// doing the same
+		concurrency.RunWeighted(t, 4, func() { ran = true })
+	})
+	assert.True(t, ran)
+}
+
+func TestRunWeightedCapsWeight(t *testing.T) {
+	ran := false
+	concurrency.RunWeighted(t, 100, func() { ran = true })
+	assert.True(t, ran, "A weight above the limit should run alone rather than fail")
+}
+
+func TestLoadOptions(t *testing.T) {
+	t.Setenv(concurrency.EnvMaxParallel, "")
+	opts, err := concurrency.LoadOptions()
+	require.NoError(t, err)
+	assert.Equal(t, concurrency.DefaultMaxParallel, opts.MaxParallel)
+
+	t.Setenv(concurrency.EnvMaxParallel, "2")
+	opts, err = concurrency.LoadOptions()
+	require.NoError(t, err)
+	assert.Equal(t, 2, opts.MaxParallel)
+
+	t.Setenv(concurrency.EnvMaxParallel, "0")
+	_, err = concurrency.LoadOptions()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SWE_TEST_MAX_PARALLEL")
+}
@@ -0,0 +1,74 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultStaleAfter is how old a lock file must be before FileLock treats
+// it as left behind by a test process that died holding it
+const DefaultStaleAfter = 10 * time.Minute
+
+// lockPollInterval is how often a waiting FileLock retries
+const lockPollInterval = 100 * time.Millisecond
+
+// FileLock is an exclusive lock shared between processes, held by whoever
+// created its file. Creating the file with O_EXCL works the same on every
+// platform and filesystem the suite runs on, unlike flock.
+type FileLock struct {
+	path string
+
+	// StaleAfter overrides DefaultStaleAfter
+	StaleAfter time.Duration
+}
+
+// NewFileLock returns a lock on path; the file need not exist
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path, StaleAfter: DefaultStaleAfter}
+}
+
+// Lock blocks until the lock is taken or ctx is done. A lock file older
+// than StaleAfter is removed and taken over.
+func (l *FileLock) Lock(ctx context.Context) error {
+	for {
+		f, err := os.OpenFile(l.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			// The PID only helps whoever finds a stale lock
+			_, werr := f.WriteString(strconv.Itoa(os.Getpid()))
+			cerr := f.Close()
+			if werr != nil || cerr != nil {
+				os.Remove(l.path)
+				return fmt.Errorf("concurrency: writing lock %s: %w", l.path, errors.Join(werr, cerr))
+			}
+			return nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("concurrency: creating lock %s: %w", l.path, err)
+		}
+
+		if info, err := os.Stat(l.path); err == nil && time.Since(info.ModTime()) > l.StaleAfter {
+			// Another waiter may remove it first; either way, retry
+			os.Remove(l.path)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("concurrency: waiting for lock %s: %w", l.path, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Unlock releases the lock
+func (l *FileLock) Unlock() error {
+	if err := os.Remove(l.path); err != nil {
+		return fmt.Errorf("concurrency: releasing lock %s: %w", l.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package concurrency_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLockContention(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "init.lock")
+
+	// Separate FileLock values on one path, as separate processes would have
+	var holders, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock := concurrency.NewFileLock(path)
+			if !assert.NoError(t, lock.Lock(context.Background())) {
+				return
+			}
+
+			n := holders.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			holders.Add(-1)
+
+			assert.NoError(t, lock.Unlock())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), peak.Load(), "Only one holder at a time")
+	assert.NoFileExists(t, path, "The lock file should be gone once released")
+}
+
+func TestFileLockTimeout(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "init.lock")
+	holder := concurrency.NewFileLock(path)
+	require.NoError(t, holder.Lock(context.Background()))
+	defer holder.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	err := concurrency.NewFileLock(path).Lock(ctx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "waiting for lock")
+}
+
+func TestFileLockTakesOverStaleLock(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "init.lock")
+	require.NoError(t, os.WriteFile(path, []byte("12345"), 0o644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	lock := concurrency.NewFileLock(path)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, lock.Lock(ctx), "A lock file older than StaleAfter should be taken over")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, "12345", string(data), "The lock should now record this process")
+	assert.NoError(t, lock.Unlock())
+}
@@ -0,0 +1,116 @@
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Semaphore is a weighted semaphore. Waiters are served in arrival order,
+// so a heavy test waiting for several slots is not starved by light ones
+// that keep taking single slots as they free up.
+type Semaphore struct {
+	size int64
+
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List // of *waiter
+}
+
+type waiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// NewSemaphore returns a semaphore with size slots
+func NewSemaphore(size int64) *Semaphore {
+	return &Semaphore{size: size}
+}
+
+// Size returns the number of slots
+func (s *Semaphore) Size() int64 {
+	return s.size
+}
+
+// Acquire takes n slots, blocking until they are free or ctx is done. On
+// error no slots are held.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	if n > s.size {
+		return fmt.Errorf("concurrency: acquiring %d slots of a semaphore of %d", n, s.size)
+	}
+
+	s.mu.Lock()
+	if s.waiters.Len() == 0 && s.size-s.cur >= n {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+
+	w := &waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// Granted while ctx was being canceled; give the slots back
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			s.waiters.Remove(elem)
+			// The front waiter leaving may unblock the ones behind it
+			s.notifyWaiters()
+		}
+		s.mu.Unlock()
+		return fmt.Errorf("concurrency: waiting for %d of %d slots: %w", n, s.size, ctx.Err())
+	}
+}
+
+// TryAcquire takes n slots if they are free right now and nobody is
+// waiting, and reports whether it did
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.waiters.Len() == 0 && s.size-s.cur >= n {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release returns n slots. Releasing more than are held panics, as it
+// means a caller released twice.
+func (s *Semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cur -= n
+	if s.cur < 0 {
+		panic("concurrency: semaphore released more slots than were held")
+	}
+	s.notifyWaiters()
+}
+
+// notifyWaiters grants slots to waiters in order until the next one does
+// not fit. s.mu must be held.
+func (s *Semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,120 @@
+package concurrency_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemaphoreLimitsConcurrency(t *testing.T) {
+	t.Parallel()
+
+	sem := concurrency.NewSemaphore(3)
+
+	var running, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !assert.NoError(t, sem.Acquire(context.Background(), 1)) {
+				return
+			}
+			defer sem.Release(1)
+
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(3), peak.Load(), "At most three goroutines should hold a slot at once")
+}
+
+func TestSemaphoreWeighted(t *testing.T) {
+	t.Parallel()
+
+	sem := concurrency.NewSemaphore(4)
+
+	require.NoError(t, sem.Acquire(context.Background(), 3))
+	assert.False(t, sem.TryAcquire(2), "Only one slot is free")
+	assert.True(t, sem.TryAcquire(1))
+
+	sem.Release(4)
+	assert.True(t, sem.TryAcquire(4), "Released slots should be free again")
+}
+
+func TestSemaphoreAcquireMoreThanSize(t *testing.T) {
+	t.Parallel()
+
+	err := concurrency.NewSemaphore(2).Acquire(context.Background(), 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "acquiring 3 slots of a semaphore of 2")
+}
+
+func TestSemaphoreServesWaitersInOrder(t *testing.T) {
+	t.Parallel()
+
+	sem := concurrency.NewSemaphore(2)
+	require.NoError(t, sem.Acquire(context.Background(), 1))
+
+	// A heavy waiter queued first must not be overtaken by a light one,
+	// even though the light one would fit now
+	heavy := make(chan struct{})
+	go func() {
+		sem.Acquire(context.Background(), 2)
+		close(heavy)
+	}()
+	queued := func() bool {
+		if sem.TryAcquire(1) {
+			sem.Release(1)
+			return false
+		}
+		return true
+	}
+	assert.Eventually(t, queued, time.Second, time.Millisecond, "TryAcquire should fail once the heavy waiter is queued")
+
+	sem.Release(1)
+	select {
+	case <-heavy:
+	case <-time.After(time.Second):
+		t.Fatal("The heavy waiter should get both slots once they are free")
+	}
+	sem.Release(2)
+}
+
+func TestSemaphoreAcquireCanceled(t *testing.T) {
+	t.Parallel()
+
+	sem := concurrency.NewSemaphore(1)
+	require.NoError(t, sem.Acquire(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := sem.Acquire(ctx, 1)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	sem.Release(1)
+	assert.True(t, sem.TryAcquire(1), "A canceled waiter should not keep a slot or block the queue")
+}
+
+func TestSemaphoreReleaseTooMany(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() { concurrency.NewSemaphore(1).Release(1) })
+}
@@ -0,0 +1,266 @@
+// Package config resolves the emulator endpoints, region and credentials the
+// integration tests run against.
+//
+// Every value defaults to a locally started emulator. A JSON or YAML file
+// named by SWE_TEST_CONFIG overrides the defaults, and the individual SWE_*
+// environment variables override the file, so CI can point the suite at a
+// shared emulator without editing any test package.
+//
+// Settings that belong to one helper, such as the throttle of
+// testutil/concurrency, live in that helper's own Options, read from the
+// environment with IntFromEnv and BoolFromEnv.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by LoadTestConfig
+const (
+	EnvConfigFile         = "SWE_TEST_CONFIG"
+	EnvCloudEmuEndpoint   = "SWE_CLOUDEMU_ENDPOINT"
+	EnvAzureEndpoint      = "SWE_AZURE_ENDPOINT"
+	EnvGCPEndpoint        = "SWE_GCP_ENDPOINT"
+	EnvZeroEndpoint       = "SWE_ZERO_ENDPOINT"
+	EnvRegion             = "SWE_TEST_REGION"
+	EnvCredentialsProfile = "SWE_TEST_CREDENTIALS_PROFILE"
+)
+
+// Defaults for an emulator started locally
+const (
+	DefaultCloudEmuEndpoint = "http://localhost:4566"
+	DefaultAzureEndpoint    = "http://localhost:10000"
+	DefaultGCPEndpoint      = "http://localhost:4567"
+	DefaultZeroEndpoint     = "http://localhost:8080"
+	DefaultRegion           = "us-east-1"
+)
+
+// TestConfig holds everything an integration test needs to reach its emulator
+type TestConfig struct {
+	CloudEmuEndpoint string `json:"cloudemu_endpoint" yaml:"cloudemu_endpoint"`
+	AzureEndpoint    string `json:"azure_endpoint" yaml:"azure_endpoint"`
+	GCPEndpoint      string `json:"gcp_endpoint" yaml:"gcp_endpoint"`
+	ZeroEndpoint     string `json:"zero_endpoint" yaml:"zero_endpoint"`
+	Region           string `json:"region" yaml:"region"`
+
+	// CredentialsProfile names a shared-credentials profile for the AWS SDK.
+	// Empty means the static test/test keys CloudEmu accepts. It fills in
+	// Credentials.AWS.Profile when that is empty.
+	CredentialsProfile string `json:"credentials_profile" yaml:"credentials_profile"`
+
+	// Credentials select how the SDK clients authenticate (see AWSConfig,
+	// AzureCredential and GCPClientOptions)
+	Credentials Credentials `json:"credentials" yaml:"credentials"`
+}
+
+// Default returns the configuration for emulators running on localhost
+func Default() *TestConfig {
+	return &TestConfig{
+		CloudEmuEndpoint: DefaultCloudEmuEndpoint,
+		AzureEndpoint:    DefaultAzureEndpoint,
+		GCPEndpoint:      DefaultGCPEndpoint,
+		ZeroEndpoint:     DefaultZeroEndpoint,
+		Region:           DefaultRegion,
+	}
+}
+
+// LoadTestConfig resolves the configuration from defaults, the
+// SWE_TEST_CONFIG file and the SWE_* environment variables, in increasing
+// order of precedence, and validates the result
+func LoadTestConfig() (*TestConfig, error) {
+	cfg := Default()
+
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Credentials.AWS.Profile == "" {
+		cfg.Credentials.AWS.Profile = cfg.CredentialsProfile
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Load is LoadTestConfig for tests: a bad configuration fails the test
+// immediately rather than surfacing as a connection error later
+func Load(t testing.TB) *TestConfig {
+	t.Helper()
+
+	cfg, err := LoadTestConfig()
+	if err != nil {
+		t.Fatalf("loading test config: %v", err)
+	}
+	return cfg
+}
+
+// Validate returns an error unless every endpoint is an absolute http(s) URL
+// and a region is set
+func (c *TestConfig) Validate() error {
+	endpoints := []struct {
+		name  string
+		value string
+	}{
+		{"cloudemu_endpoint", c.CloudEmuEndpoint},
+		{"azure_endpoint", c.AzureEndpoint},
+		{"gcp_endpoint", c.GCPEndpoint},
+		{"zero_endpoint", c.ZeroEndpoint},
+	}
+
+	var problems []string
+	for _, e := range endpoints {
+		if err := validateEndpoint(e.value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", e.name, err))
+		}
+	}
+	if strings.TrimSpace(c.Region) == "" {
+		problems = append(problems, "region: must not be empty")
+	}
+	problems = append(problems, c.Credentials.validate()...)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("config: invalid test config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func validateEndpoint(value string) error {
+	if value == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid URL: %w", value, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q must use http or https", value)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", value)
+	}
+	return nil
+}
+
+// loadFile overlays the non-empty fields of a JSON or YAML file, chosen by
+// extension
+func (c *TestConfig) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: reading %s: %w", path, err)
+	}
+
+	var file TestConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		return fmt.Errorf("config: %s: unsupported extension, want .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+
+	c.merge(&file)
+	return nil
+}
+
+func (c *TestConfig) applyEnv() error {
+	env := &TestConfig{
+		CloudEmuEndpoint:   os.Getenv(EnvCloudEmuEndpoint),
+		AzureEndpoint:      os.Getenv(EnvAzureEndpoint),
+		GCPEndpoint:        os.Getenv(EnvGCPEndpoint),
+		ZeroEndpoint:       os.Getenv(EnvZeroEndpoint),
+		Region:             os.Getenv(EnvRegion),
+		CredentialsProfile: os.Getenv(EnvCredentialsProfile),
+		Credentials: Credentials{
+			AWS: AWSCredentials{
+				Profile:    os.Getenv(EnvCredentialsProfile),
+				RoleARN:    os.Getenv(EnvAWSRoleARN),
+				ExternalID: os.Getenv(EnvAWSExternalID),
+			},
+			Azure: AzureCredentials{
+				TenantID:           os.Getenv(EnvAzureTenantID),
+				ClientID:           os.Getenv(EnvAzureClientID),
+				FederatedTokenFile: os.Getenv(EnvAzureFederatedTokenFile),
+			},
+			GCP: GCPCredentials{
+				CredentialsFile:           os.Getenv(EnvGCPCredentialsFile),
+				ImpersonateServiceAccount: os.Getenv(EnvGCPImpersonateServiceAccount),
+			},
+		},
+	}
+
+	c.merge(env)
+	return nil
+}
+
+// merge copies every non-empty (non-zero) field of other over c
+func (c *TestConfig) merge(other *TestConfig) {
+	set := func(dst *string, src string) {
+		if src != "" {
+			*dst = strings.TrimRight(src, "/")
+		}
+	}
+	set(&c.CloudEmuEndpoint, other.CloudEmuEndpoint)
+	set(&c.AzureEndpoint, other.AzureEndpoint)
+	set(&c.GCPEndpoint, other.GCPEndpoint)
+	set(&c.ZeroEndpoint, other.ZeroEndpoint)
+	set(&c.Region, other.Region)
+	set(&c.CredentialsProfile, other.CredentialsProfile)
+
+	set(&c.Credentials.AWS.Profile, other.Credentials.AWS.Profile)
+	set(&c.Credentials.AWS.RoleARN, other.Credentials.AWS.RoleARN)
+	set(&c.Credentials.AWS.ExternalID, other.Credentials.AWS.ExternalID)
+	set(&c.Credentials.Azure.TenantID, other.Credentials.Azure.TenantID)
+	set(&c.Credentials.Azure.ClientID, other.Credentials.Azure.ClientID)
+	set(&c.Credentials.Azure.FederatedTokenFile, other.Credentials.Azure.FederatedTokenFile)
+	set(&c.Credentials.GCP.CredentialsFile, other.Credentials.GCP.CredentialsFile)
+	set(&c.Credentials.GCP.ImpersonateServiceAccount, other.Credentials.GCP.ImpersonateServiceAccount)
+}
+
+// IntFromEnv returns the whole number in the environment variable name, or
+// fallback when it is unset. Values below min are an error.
+func IntFromEnv(name string, fallback, min int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min {
+		return 0, fmt.Errorf("config: %s: %q must be a whole number of at least %d", name, v, min)
+	}
+	return n, nil
+}
+
+// BoolFromEnv returns the boolean in the environment variable name, or false
+// when it is unset
+func BoolFromEnv(name string) (bool, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("config: %s: %q must be true or false", name, v)
+	}
+	return b, nil
+}
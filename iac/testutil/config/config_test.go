@@ -0,0 +1,206 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allEnv = []string{
+	config.EnvConfigFile,
+	config.EnvCloudEmuEndpoint,
+	config.EnvAzureEndpoint,
+	config.EnvGCPEndpoint,
+	config.EnvZeroEndpoint,
+	config.EnvRegion,
+	config.EnvCredentialsProfile,
+	config.EnvAWSRoleARN,
+	config.EnvAWSExternalID,
+	config.EnvAzureTenantID,
+	config.EnvAzureClientID,
+	config.EnvAzureFederatedTokenFile,
+	config.EnvGCPCredentialsFile,
+	config.EnvGCPImpersonateServiceAccount,
+}
+
+// clearEnv blanks every SWE_* variable so the host environment cannot leak
+// into a test. t.Setenv restores them afterwards.
+func clearEnv(t *testing.T) {
+	for _, name := range allEnv {
+		t.Setenv(name, "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadTestConfigDefaults(t *testing.T) {
+	clearEnv(t)
+
+	cfg, err := config.LoadTestConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Default(), cfg)
+	assert.Equal(t, "http://localhost:4566", cfg.CloudEmuEndpoint)
+	assert.Equal(t, "us-east-1", cfg.Region)
+	assert.Empty(t, cfg.CredentialsProfile)
+}
+
+func TestLoadTestConfigFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(config.EnvCloudEmuEndpoint, "http://emulator.ci:4566/")
+	t.Setenv(config.EnvZeroEndpoint, "https://zero.ci")
+	t.Setenv(config.EnvRegion, "eu-west-1")
+	t.Setenv(config.EnvCredentialsProfile, "ci")
+
+	cfg, err := config.LoadTestConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://emulator.ci:4566", cfg.CloudEmuEndpoint, "Trailing slashes should be trimmed")
+	assert.Equal(t, "https://zero.ci", cfg.ZeroEndpoint)
+	assert.Equal(t, "eu-west-1", cfg.Region)
+	assert.Equal(t, "ci", cfg.CredentialsProfile)
+	assert.Equal(t, config.DefaultAzureEndpoint, cfg.AzureEndpoint, "Unset variables should keep their defaults")
+}
+
+func TestLoadTestConfigFilePrecedence(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{"cloudemu_endpoint": "http://file:4566", "gcp_endpoint": "http://file:4567", "region": "ap-south-1"}`,
+		"config.yaml": "cloudemu_endpoint: http://file:4566\ngcp_endpoint: http://file:4567\nregion: ap-south-1\n",
+	}
+
+	for name, content := range files {
+		name, content := name, content
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv(config.EnvConfigFile, writeFile(t, name, content))
+			t.Setenv(config.EnvCloudEmuEndpoint, "http://env:4566")
+
+			cfg, err := config.LoadTestConfig()
+			require.NoError(t, err)
+
+			assert.Equal(t, "http://env:4566", cfg.CloudEmuEndpoint, "Env vars should override the file")
+			assert.Equal(t, "http://file:4567", cfg.GCPEndpoint, "The file should override defaults")
+			assert.Equal(t, "ap-south-1", cfg.Region)
+			assert.Equal(t, config.DefaultZeroEndpoint, cfg.ZeroEndpoint, "Fields missing from the file should keep their defaults")
+		})
+	}
+}
+
+func TestLoadTestConfigFileErrors(t *testing.T) {
+	tests := map[string]struct {
+		path     func(t *testing.T) string
+		contains string
+	}{
+		"missing file": {
+			path:     func(t *testing.T) string { return filepath.Join(t.TempDir(), "absent.json") },
+			contains: "reading",
+		},
+		"unsupported extension": {
+			path:     func(t *testing.T) string { return writeFile(t, "config.toml", "region = 'x'") },
+			contains: "unsupported extension",
+		},
+		"malformed json": {
+			path:     func(t *testing.T) string { return writeFile(t, "config.json", "{") },
+			contains: "parsing",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv(config.EnvConfigFile, tc.path(t))
+
+			_, err := config.LoadTestConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.contains)
+		})
+	}
+}
+
+func TestLoadTestConfigValidation(t *testing.T) {
+	tests := map[string]struct {
+		env      string
+		value    string
+		contains string
+	}{
+		"no scheme":          {config.EnvCloudEmuEndpoint, "localhost:4566", "cloudemu_endpoint"},
+		"unsupported scheme": {config.EnvAzureEndpoint, "ftp://localhost:10000", "must use http or https"},
+		"no host":            {config.EnvGCPEndpoint, "http://", "has no host"},
+		"unparseable":        {config.EnvZeroEndpoint, "http://[::1", "zero_endpoint"},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv(tc.env, tc.value)
+
+			_, err := config.LoadTestConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.contains)
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := config.Default()
+	cfg.CloudEmuEndpoint = ""
+	cfg.Region = " "
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cloudemu_endpoint: must not be empty")
+	assert.Contains(t, err.Error(), "region: must not be empty")
+}
+
+func TestIntFromEnv(t *testing.T) {
+	const name = "SWE_TEST_CONFIG_INT"
+
+	t.Setenv(name, "")
+	n, err := config.IntFromEnv(name, 4, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, n, "An unset variable should fall back")
+
+	t.Setenv(name, "2")
+	n, err = config.IntFromEnv(name, 4, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	for _, bad := range []string{"four", "0", "64MB"} {
+		t.Setenv(name, bad)
+		_, err = config.IntFromEnv(name, 4, 1)
+		require.Error(t, err, bad)
+		assert.Contains(t, err.Error(), name)
+		assert.Contains(t, err.Error(), "at least 1")
+	}
+}
+
+func TestBoolFromEnv(t *testing.T) {
+	const name = "SWE_TEST_CONFIG_BOOL"
+
+	t.Setenv(name, "")
+	b, err := config.BoolFromEnv(name)
+	require.NoError(t, err)
+	assert.False(t, b, "An unset variable should be false")
+
+	t.Setenv(name, "1")
+	b, err = config.BoolFromEnv(name)
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	t.Setenv(name, "yes")
+	_, err = config.BoolFromEnv(name)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), name)
+	assert.Contains(t, err.Error(), "must be true or false")
+}
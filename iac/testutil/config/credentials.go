@@ -0,0 +1,248 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+)
+
+// Environment variables filling in the credentials section
+const (
+	EnvAWSRoleARN                   = "SWE_AWS_ROLE_ARN"
+	EnvAWSExternalID                = "SWE_AWS_EXTERNAL_ID"
+	EnvAzureTenantID                = "SWE_AZURE_TENANT_ID"
+	EnvAzureClientID                = "SWE_AZURE_CLIENT_ID"
+	EnvAzureFederatedTokenFile      = "SWE_AZURE_FEDERATED_TOKEN_FILE"
+	EnvGCPCredentialsFile           = "SWE_GCP_CREDENTIALS_FILE"
+	EnvGCPImpersonateServiceAccount = "SWE_GCP_IMPERSONATE_SERVICE_ACCOUNT"
+)
+
+// EmulatorAccessKey is the static AWS key pair every emulator accepts, used
+// when no AWS credentials are configured
+const EmulatorAccessKey = "test"
+
+// AssumeRoleSessionName names the sessions the helpers assume a role in,
+// so CloudTrail shows which calls came from the test suite
+const AssumeRoleSessionName = "swe-iac-test"
+
+// gcpScope is requested for an impersonated service account
+const gcpScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// Credentials selects how the SDK clients authenticate. Left empty, every
+// provider uses its emulator's placeholder credentials; filled in, the same
+// helpers reach a real account.
+type Credentials struct {
+	AWS   AWSCredentials   `json:"aws" yaml:"aws"`
+	Azure AzureCredentials `json:"azure" yaml:"azure"`
+	GCP   GCPCredentials   `json:"gcp" yaml:"gcp"`
+}
+
+// AWSCredentials are a shared-credentials profile, a role to assume, or both
+type AWSCredentials struct {
+	// Profile names a shared-credentials profile; credentials_profile at
+	// the top level of the file, and SWE_TEST_CREDENTIALS_PROFILE, set it too
+	Profile string `json:"profile" yaml:"profile"`
+
+	// RoleARN is assumed with the profile's credentials, or with those in
+	// the environment when no profile is set
+	RoleARN    string `json:"role_arn" yaml:"role_arn"`
+	ExternalID string `json:"external_id" yaml:"external_id"`
+}
+
+// AzureCredentials are a service principal federated with the identity
+// provider that writes FederatedTokenFile, such as AKS workload identity or
+// a CI job's OIDC token
+type AzureCredentials struct {
+	TenantID           string `json:"tenant_id" yaml:"tenant_id"`
+	ClientID           string `json:"client_id" yaml:"client_id"`
+	FederatedTokenFile string `json:"federated_token_file" yaml:"federated_token_file"`
+}
+
+// GCPCredentials are a credentials file (a service account key or an
+// external account configuration for workload identity federation), a
+// service account to impersonate, or both
+type GCPCredentials struct {
+	CredentialsFile string `json:"credentials_file" yaml:"credentials_file"`
+
+	// ImpersonateServiceAccount is impersonated with the credentials file,
+	// or with the application default credentials when there is none
+	ImpersonateServiceAccount string `json:"impersonate_service_account" yaml:"impersonate_service_account"`
+}
+
+// validate returns the problems with the credentials section
+func (c Credentials) validate() []string {
+	var problems []string
+	if c.AWS.ExternalID != "" && c.AWS.RoleARN == "" {
+		problems = append(problems, "credentials.aws.external_id: needs role_arn")
+	}
+	if c.AWS.RoleARN != "" && !strings.HasPrefix(c.AWS.RoleARN, "arn:") {
+		problems = append(problems, fmt.Sprintf("credentials.aws.role_arn: %q is not an ARN", c.AWS.RoleARN))
+	}
+
+	azure := []string{c.Azure.TenantID, c.Azure.ClientID, c.Azure.FederatedTokenFile}
+	set := 0
+	for _, v := range azure {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 0 && set != len(azure) {
+		problems = append(problems, "credentials.azure: tenant_id, client_id and federated_token_file must be set together")
+	}
+
+	if sa := c.GCP.ImpersonateServiceAccount; sa != "" && !strings.Contains(sa, "@") {
+		problems = append(problems, fmt.Sprintf("credentials.gcp.impersonate_service_account: %q is not a service account email", sa))
+	}
+	return problems
+}
+
+// AWSConfig returns the SDK configuration of an AWS client in the
+// configured region with the configured credentials (see
+// AWSCredentialsProvider). An endpoint, such as CloudEmu's, replaces the
+// AWS endpoints of every service and turns on path-style S3 addressing;
+// empty reaches AWS itself. A transport, such as httprecord.Transport's,
+// carries the client's requests; nil keeps the SDK's default. Building it
+// makes no network calls.
+func (c *TestConfig) AWSConfig(endpoint string, transport http.RoundTripper) (*aws.Config, error) {
+	provider, err := c.AWSCredentialsProvider(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := aws.NewConfig().
+		WithRegion(c.Region).
+		WithCredentials(credentials.NewCredentials(provider))
+	if endpoint != "" {
+		cfg = cfg.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+	if transport != nil {
+		cfg = cfg.WithHTTPClient(&http.Client{Transport: transport})
+	}
+	return cfg, nil
+}
+
+// AWSSession is a session over AWSConfig
+func (c *TestConfig) AWSSession(endpoint string, transport http.RoundTripper) (*session.Session, error) {
+	cfg, err := c.AWSConfig(endpoint, transport)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("config: aws session for %q: %w", endpoint, err)
+	}
+	return sess, nil
+}
+
+// AWSCredentialsProvider returns where the AWS clients get credentials:
+//
+//   - nothing configured: the static keys emulators accept
+//   - a profile: the shared-credentials profile
+//   - a role: an STS AssumeRole provider with the external ID, signing with
+//     the profile or, without one, the environment's keys or default profile
+//
+// The role is assumed through STS at endpoint, so an emulator can stand in
+// for it. The provider only calls STS when a client first needs
+// credentials.
+func (c *TestConfig) AWSCredentialsProvider(endpoint string) (credentials.Provider, error) {
+	creds := c.Credentials.AWS
+
+	var base credentials.Provider
+	switch {
+	case creds.Profile != "":
+		base = &credentials.SharedCredentialsProvider{Profile: creds.Profile}
+	case creds.RoleARN != "":
+		base = &credentials.ChainProvider{Providers: []credentials.Provider{
+			&credentials.EnvProvider{},
+			&credentials.SharedCredentialsProvider{},
+		}}
+	default:
+		return &credentials.StaticProvider{Value: credentials.Value{
+			AccessKeyID:     EmulatorAccessKey,
+			SecretAccessKey: EmulatorAccessKey,
+		}}, nil
+	}
+	if creds.RoleARN == "" {
+		return base, nil
+	}
+
+	stsConfig := aws.NewConfig().WithRegion(c.Region).WithCredentials(credentials.NewCredentials(base))
+	if endpoint != "" {
+		stsConfig = stsConfig.WithEndpoint(endpoint)
+	}
+	sess, err := session.NewSession(stsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("config: sts session for assuming %s: %w", creds.RoleARN, err)
+	}
+
+	provider := &stscreds.AssumeRoleProvider{
+		Client:          sts.New(sess),
+		RoleARN:         creds.RoleARN,
+		RoleSessionName: AssumeRoleSessionName,
+		Duration:        stscreds.DefaultDuration,
+	}
+	if creds.ExternalID != "" {
+		provider.ExternalID = aws.String(creds.ExternalID)
+	}
+	return provider, nil
+}
+
+// AzureCredential returns the token credential of the configured service
+// principal, or nil when none is configured and clients should use the
+// emulator's shared keys. The federated token file is read again whenever
+// a token is requested, as the platforms writing it rotate it.
+func (c *TestConfig) AzureCredential() (azcore.TokenCredential, error) {
+	creds := c.Credentials.Azure
+	if creds.TenantID == "" {
+		return nil, nil
+	}
+	cred, err := azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+		TenantID:      creds.TenantID,
+		ClientID:      creds.ClientID,
+		TokenFilePath: creds.FederatedTokenFile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("config: azure credential: %w", err)
+	}
+	return cred, nil
+}
+
+// GCPClientOptions returns the options authenticating a GCP client: none
+// with nothing configured, as against an emulator; the credentials file;
+// or a token source impersonating the service account. Impersonation reads
+// the credentials file, or looks up the application default credentials
+// without one, but calls IAM only when a client first needs a token.
+func (c *TestConfig) GCPClientOptions(ctx context.Context) ([]option.ClientOption, error) {
+	creds := c.Credentials.GCP
+
+	var base []option.ClientOption
+	if creds.CredentialsFile != "" {
+		base = append(base, option.WithCredentialsFile(creds.CredentialsFile))
+	}
+	if creds.ImpersonateServiceAccount == "" {
+		if len(base) == 0 {
+			return []option.ClientOption{option.WithoutAuthentication()}, nil
+		}
+		return base, nil
+	}
+
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: creds.ImpersonateServiceAccount,
+		Scopes:          []string{gcpScope},
+	}, base...)
+	if err != nil {
+		return nil, fmt.Errorf("config: impersonating %s: %w", creds.ImpersonateServiceAccount, err)
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
@@ -0,0 +1,188 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"iac/testutil/config"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func TestAWSCredentialsProviderEmulatorKeys(t *testing.T) {
+	cfg := config.Default()
+
+	provider, err := cfg.AWSCredentialsProvider(cfg.CloudEmuEndpoint)
+	require.NoError(t, err)
+
+	static, ok := provider.(*credentials.StaticProvider)
+	require.True(t, ok, "Without credentials the emulator keys should be used, got %T", provider)
+	assert.Equal(t, config.EmulatorAccessKey, static.AccessKeyID)
+	assert.Equal(t, config.EmulatorAccessKey, static.SecretAccessKey)
+}
+
+func TestAWSCredentialsProviderProfile(t *testing.T) {
+	cfg := config.Default()
+	cfg.Credentials.AWS.Profile = "ci"
+
+	provider, err := cfg.AWSCredentialsProvider("")
+	require.NoError(t, err)
+
+	shared, ok := provider.(*credentials.SharedCredentialsProvider)
+	require.True(t, ok, "A profile should be read from the shared credentials, got %T", provider)
+	assert.Equal(t, "ci", shared.Profile)
+}
+
+func TestAWSCredentialsProviderAssumeRole(t *testing.T) {
+	cfg := config.Default()
+	cfg.Credentials.AWS = config.AWSCredentials{
+		Profile:    "ci",
+		RoleARN:    "arn:aws:iam::123456789012:role/integration",
+		ExternalID: "swe-ci",
+	}
+
+	provider, err := cfg.AWSCredentialsProvider("")
+	require.NoError(t, err)
+
+	role, ok := provider.(*stscreds.AssumeRoleProvider)
+	require.True(t, ok, "A role should be assumed through STS, got %T", provider)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/integration", role.RoleARN)
+	assert.Equal(t, config.AssumeRoleSessionName, role.RoleSessionName)
+	require.NotNil(t, role.ExternalID)
+	assert.Equal(t, "swe-ci", *role.ExternalID)
+}
+
+func TestAWSConfigRegionAndEndpoint(t *testing.T) {
+	cfg := config.Default()
+	cfg.Region = "eu-west-1"
+
+	emulator, err := cfg.AWSConfig("http://localhost:4566", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", *emulator.Region)
+	assert.Equal(t, "http://localhost:4566", *emulator.Endpoint)
+	assert.True(t, *emulator.S3ForcePathStyle, "An emulator needs path-style S3 addressing")
+
+	assert.Nil(t, emulator.HTTPClient, "Without a transport the SDK's default client should be used")
+
+	real, err := cfg.AWSConfig("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", *real.Region)
+	assert.Nil(t, real.Endpoint, "Without an endpoint the clients should reach AWS")
+}
+
+func TestLoadTestConfigCredentialsFromFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(config.EnvConfigFile, writeFile(t, "config.yaml", ""+
+		"credentials_profile: legacy\n"+
+		"credentials:\n"+
+		"  aws:\n"+
+		"    role_arn: arn:aws:iam::123456789012:role/integration\n"+
+		"  azure:\n"+
+		"    tenant_id: tenant\n"+
+		"    client_id: client\n"+
+		"    federated_token_file: /var/run/secrets/azure/token\n"+
+		"  gcp:\n"+
+		"    impersonate_service_account: ci@acme.iam.gserviceaccount.com\n"))
+	t.Setenv(config.EnvAWSExternalID, "swe-ci")
+
+	cfg, err := config.LoadTestConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, config.AWSCredentials{
+		Profile:    "legacy",
+		RoleARN:    "arn:aws:iam::123456789012:role/integration",
+		ExternalID: "swe-ci",
+	}, cfg.Credentials.AWS, "credentials_profile should fill in the profile")
+	assert.Equal(t, "tenant", cfg.Credentials.Azure.TenantID)
+	assert.Equal(t, "ci@acme.iam.gserviceaccount.com", cfg.Credentials.GCP.ImpersonateServiceAccount)
+}
+
+func TestValidateCredentials(t *testing.T) {
+	cfg := config.Default()
+	cfg.Credentials = config.Credentials{
+		AWS:   config.AWSCredentials{RoleARN: "integration", ExternalID: "swe-ci"},
+		Azure: config.AzureCredentials{TenantID: "tenant"},
+		GCP:   config.GCPCredentials{ImpersonateServiceAccount: "ci"},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `credentials.aws.role_arn: "integration" is not an ARN`)
+	assert.Contains(t, err.Error(), "credentials.azure: tenant_id, client_id and federated_token_file must be set together")
+	assert.Contains(t, err.Error(), "credentials.gcp.impersonate_service_account")
+
+	cfg.Credentials.AWS.RoleARN = ""
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "credentials.aws.external_id: needs role_arn")
+}
+
+func TestAzureCredential(t *testing.T) {
+	cfg := config.Default()
+	cred, err := cfg.AzureCredential()
+	require.NoError(t, err)
+	assert.Nil(t, cred, "Without a tenant the emulator keys should be used")
+
+	cfg.Credentials.Azure = config.AzureCredentials{
+		TenantID:           "tenant",
+		ClientID:           "client",
+		FederatedTokenFile: writeFile(t, "token", "federated-jwt\n"),
+	}
+	cred, err = cfg.AzureCredential()
+	require.NoError(t, err)
+	assert.IsType(t, &azidentity.WorkloadIdentityCredential{}, cred)
+}
+
+func TestAzureCredentialInvalidTenant(t *testing.T) {
+	cfg := config.Default()
+	cfg.Credentials.Azure = config.AzureCredentials{
+		TenantID:           "not a tenant",
+		ClientID:           "client",
+		FederatedTokenFile: writeFile(t, "token", "federated-jwt"),
+	}
+
+	_, err := cfg.AzureCredential()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config: azure credential")
+}
+
+func TestGCPClientOptions(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default()
+
+	opts, err := cfg.GCPClientOptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []option.ClientOption{option.WithoutAuthentication()}, opts, "Without credentials an emulator should be reached unauthenticated")
+
+	cfg.Credentials.GCP.CredentialsFile = "/etc/gcp/key.json"
+	opts, err = cfg.GCPClientOptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []option.ClientOption{option.WithCredentialsFile("/etc/gcp/key.json")}, opts)
+}
+
+func TestGCPClientOptionsImpersonation(t *testing.T) {
+	key := writeFile(t, "key.json", `{
+  "type": "service_account",
+  "project_id": "acme",
+  "private_key_id": "1",
+  "private_key": "",
+  "client_email": "base@acme.iam.gserviceaccount.com",
+  "token_uri": "https://oauth2.googleapis.com/token"
+}`)
+
+	cfg := config.Default()
+	cfg.Credentials.GCP = config.GCPCredentials{
+		CredentialsFile:           key,
+		ImpersonateServiceAccount: "ci@acme.iam.gserviceaccount.com",
+	}
+
+	opts, err := cfg.GCPClientOptions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, opts, 1, "Impersonation should authenticate with a single token source")
+	assert.NotEqual(t, option.WithCredentialsFile(key), opts[0])
+}
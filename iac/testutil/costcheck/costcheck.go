@@ -0,0 +1,256 @@
+// Package costcheck prices Terraform plans with Infracost so facade tests
+// fail when a change silently raises the monthly cost of a configuration,
+// such as a size mapping moving "small" to a larger instance class.
+//
+// Plans are priced by running `infracost breakdown --format json` on the
+// JSON plan; a pre-generated breakdown can be loaded with Load instead. Tests
+// are skipped when the infracost binary or INFRACOST_API_KEY is missing, so
+// the checks only run where pricing is available.
+package costcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// Binary is the Infracost executable looked up on PATH
+const Binary = "infracost"
+
+// EnvAPIKey holds the Infracost API key the pricing lookups need
+const EnvAPIKey = "INFRACOST_API_KEY"
+
+// TestingT is the subset of testing.TB the assertions need; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Cost is a decimal amount Infracost reports as a string. Costs that depend
+// on usage Infracost has no estimate for are null and decode as zero.
+type Cost float64
+
+// UnmarshalJSON decodes "12.41" and null
+func (c *Cost) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*c = 0
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("cost %s: %w", data, err)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("cost %q: %w", s, err)
+	}
+	*c = Cost(v)
+	return nil
+}
+
+// Report is the `infracost breakdown --format json` output
+type Report struct {
+	Version          string    `json:"version"`
+	Currency         string    `json:"currency"`
+	Projects         []Project `json:"projects"`
+	TotalHourlyCost  Cost      `json:"totalHourlyCost"`
+	TotalMonthlyCost Cost      `json:"totalMonthlyCost"`
+	Summary          Summary   `json:"summary"`
+}
+
+// Project is one priced plan or directory
+type Project struct {
+	Name      string    `json:"name"`
+	Breakdown Breakdown `json:"breakdown"`
+}
+
+// Breakdown lists the priced resources of a project
+type Breakdown struct {
+	Resources        []Resource `json:"resources"`
+	TotalHourlyCost  Cost       `json:"totalHourlyCost"`
+	TotalMonthlyCost Cost       `json:"totalMonthlyCost"`
+}
+
+// Resource is a priced resource, named by its Terraform address. Its costs
+// include those of its subresources.
+type Resource struct {
+	Name           string          `json:"name"`
+	ResourceType   string          `json:"resourceType"`
+	HourlyCost     Cost            `json:"hourlyCost"`
+	MonthlyCost    Cost            `json:"monthlyCost"`
+	CostComponents []CostComponent `json:"costComponents"`
+	Subresources   []Resource      `json:"subresources"`
+}
+
+// CostComponent is one billed line of a resource, e.g. instance hours
+type CostComponent struct {
+	Name            string `json:"name"`
+	Unit            string `json:"unit"`
+	MonthlyQuantity Cost   `json:"monthlyQuantity"`
+	Price           Cost   `json:"price"`
+	MonthlyCost     Cost   `json:"monthlyCost"`
+}
+
+// Summary counts the resources Infracost detected and could price
+type Summary struct {
+	TotalDetectedResources    int `json:"totalDetectedResources"`
+	TotalSupportedResources   int `json:"totalSupportedResources"`
+	TotalUnsupportedResources int `json:"totalUnsupportedResources"`
+	TotalNoPriceResources     int `json:"totalNoPriceResources"`
+}
+
+// Parse decodes Infracost JSON output
+func Parse(data []byte) (*Report, error) {
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("costcheck: decoding infracost JSON: %w", err)
+	}
+	if report.Version == "" {
+		return nil, fmt.Errorf("costcheck: not an infracost breakdown (no version field)")
+	}
+	return &report, nil
+}
+
+// Load parses a pre-generated `infracost breakdown --format json` file
+func Load(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("costcheck: reading %s: %w", path, err)
+	}
+	return Parse(data)
+}
+
+// Run prices path, a Terraform directory or JSON plan file, with Infracost
+func Run(ctx context.Context, path string) (*Report, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, Binary, "breakdown", "--path", path, "--format", "json", "--no-color")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("costcheck: %s breakdown --path %s: %w: %s", Binary, path, err, strings.TrimSpace(stderr.String()))
+	}
+	return Parse(stdout.Bytes())
+}
+
+// SkipIfUnavailable skips t when Infracost cannot price plans here
+func SkipIfUnavailable(t testing.TB) {
+	t.Helper()
+
+	if _, err := exec.LookPath(Binary); err != nil {
+		t.Skipf("%s not found on PATH; install it to run cost checks", Binary)
+	}
+	if os.Getenv(EnvAPIKey) == "" {
+		t.Skipf("%s not set; cost checks need an Infracost API key", EnvAPIKey)
+	}
+}
+
+// Estimate plans options and prices the JSON plan, skipping t when Infracost
+// is unavailable. options.PlanFilePath is set to a temporary file if empty.
+func Estimate(t testing.TB, options *terraform.Options) *Report {
+	t.Helper()
+
+	SkipIfUnavailable(t)
+
+	if options.PlanFilePath == "" {
+		options.PlanFilePath = filepath.Join(t.TempDir(), "plan.out")
+	}
+
+	planJSON := filepath.Join(t.TempDir(), "plan.json")
+	if err := os.WriteFile(planJSON, []byte(terraform.InitAndPlanAndShow(t, options)), 0o644); err != nil {
+		t.Fatalf("costcheck: writing plan JSON: %v", err)
+	}
+
+	report, err := Run(context.Background(), planJSON)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return report
+}
+
+// Resources returns every priced resource across projects, keyed by address
+func (r *Report) Resources() map[string]Resource {
+	resources := make(map[string]Resource)
+	for _, p := range r.Projects {
+		for _, res := range p.Breakdown.Resources {
+			resources[res.Name] = res
+		}
+	}
+	return resources
+}
+
+// AssertMonthlyCostBelow fails t when the total monthly cost is usd or more
+func (r *Report) AssertMonthlyCostBelow(t TestingT, usd float64) bool {
+	t.Helper()
+
+	if float64(r.TotalMonthlyCost) < usd {
+		return true
+	}
+	t.Errorf("monthly cost %.2f %s is not below %.2f; most expensive resources:\n  %s",
+		float64(r.TotalMonthlyCost), r.Currency, usd, strings.Join(r.topResources(5), "\n  "))
+	return false
+}
+
+// AssertResourceCostBelow fails t when the resource at address costs usd or
+// more per month, or was not priced at all
+func (r *Report) AssertResourceCostBelow(t TestingT, address string, usd float64) bool {
+	t.Helper()
+
+	resources := r.Resources()
+	res, ok := resources[address]
+	if !ok {
+		names := make([]string, 0, len(resources))
+		for name := range resources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		t.Errorf("resource %s not in the cost breakdown; priced resources:\n  %s", address, strings.Join(names, "\n  "))
+		return false
+	}
+
+	if float64(res.MonthlyCost) < usd {
+		return true
+	}
+
+	lines := make([]string, len(res.CostComponents))
+	for i, c := range res.CostComponents {
+		lines[i] = fmt.Sprintf("%s: %.2f", c.Name, float64(c.MonthlyCost))
+	}
+	t.Errorf("%s costs %.2f %s per month, not below %.2f:\n  %s",
+		address, float64(res.MonthlyCost), r.Currency, usd, strings.Join(lines, "\n  "))
+	return false
+}
+
+// topResources describes the n most expensive resources, for failure output
+func (r *Report) topResources(n int) []string {
+	var all []Resource
+	for _, res := range r.Resources() {
+		all = append(all, res)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].MonthlyCost != all[j].MonthlyCost {
+			return all[i].MonthlyCost > all[j].MonthlyCost
+		}
+		return all[i].Name < all[j].Name
+	})
+	if len(all) > n {
+		all = all[:n]
+	}
+
+	lines := make([]string, len(all))
+	for i, res := range all {
+		lines[i] = fmt.Sprintf("%s: %.2f", res.Name, float64(res.MonthlyCost))
+	}
+	return lines
+}
@@ -0,0 +1,143 @@
+package costcheck_test
+
+import (
+	"fmt"
+	"testing"
+
+	"iac/testutil/costcheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dbAddress = "module.aws_database[0].aws_db_instance.this"
+
+// recorder captures Errorf instead of failing the enclosing test
+type recorder struct {
+	failed  bool
+	message string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failed = true
+	r.message = fmt.Sprintf(format, args...)
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	report, err := costcheck.Load("testdata/aws-database.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, "USD", report.Currency)
+	assert.InDelta(t, 14.71, float64(report.TotalMonthlyCost), 0.001)
+	require.Len(t, report.Projects, 1)
+	assert.Equal(t, 1, report.Summary.TotalSupportedResources)
+
+	resources := report.Projects[0].Breakdown.Resources
+	require.Len(t, resources, 1)
+	assert.Equal(t, dbAddress, resources[0].Name)
+	assert.Equal(t, "aws_db_instance", resources[0].ResourceType)
+	require.Len(t, resources[0].CostComponents, 3)
+
+	instance := resources[0].CostComponents[0]
+	assert.Equal(t, "Database instance (on-demand, Single-AZ, db.t3.micro)", instance.Name)
+	assert.InDelta(t, 730, float64(instance.MonthlyQuantity), 0.001)
+	assert.InDelta(t, 0.017, float64(instance.Price), 0.0001)
+
+	// Usage-based components without a usage estimate are null
+	assert.Zero(t, resources[0].CostComponents[2].MonthlyCost)
+}
+
+func TestLoadMultipleProjects(t *testing.T) {
+	t.Parallel()
+
+	report, err := costcheck.Load("testdata/multi-project.json")
+	require.NoError(t, err)
+
+	resources := report.Resources()
+	require.Len(t, resources, 2)
+
+	instance := resources["module.aws_compute[0].aws_instance.this"]
+	assert.InDelta(t, 8.232, float64(instance.MonthlyCost), 0.001)
+	require.Len(t, instance.Subresources, 1)
+	assert.Equal(t, "root_block_device", instance.Subresources[0].Name)
+
+	queue := resources["module.aws_messaging[0].aws_sqs_queue.this[0]"]
+	assert.Zero(t, queue.MonthlyCost, "A null monthly cost should decode as zero")
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input   string
+		message string
+	}{
+		"not json":        {"Error: No INFRACOST_API_KEY environment variable is set.", "decoding infracost JSON"},
+		"not a breakdown": {`{"resource_changes": []}`, "not an infracost breakdown"},
+		"bad cost":        {`{"version": "0.2", "totalMonthlyCost": "twelve"}`, `cost "twelve"`},
+		"numeric cost":    {`{"version": "0.2", "totalMonthlyCost": 12}`, "cost 12"},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := costcheck.Parse([]byte(tc.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.message)
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	t.Parallel()
+
+	_, err := costcheck.Load("testdata/absent.json")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "testdata/absent.json")
+}
+
+func TestAssertMonthlyCostBelow(t *testing.T) {
+	t.Parallel()
+
+	report, err := costcheck.Load("testdata/multi-project.json")
+	require.NoError(t, err)
+
+	rec := &recorder{}
+	assert.True(t, report.AssertMonthlyCostBelow(rec, 10))
+	assert.False(t, rec.failed, rec.message)
+
+	rec = &recorder{}
+	assert.False(t, report.AssertMonthlyCostBelow(rec, 5))
+	require.True(t, rec.failed)
+	assert.Contains(t, rec.message, "monthly cost 8.23 USD is not below 5.00")
+	assert.Contains(t, rec.message, "module.aws_compute[0].aws_instance.this: 8.23")
+}
+
+func TestAssertResourceCostBelow(t *testing.T) {
+	t.Parallel()
+
+	report, err := costcheck.Load("testdata/aws-database.json")
+	require.NoError(t, err)
+
+	rec := &recorder{}
+	assert.True(t, report.AssertResourceCostBelow(rec, dbAddress, 20))
+	assert.False(t, rec.failed, rec.message)
+
+	rec = &recorder{}
+	assert.False(t, report.AssertResourceCostBelow(rec, dbAddress, 10))
+	require.True(t, rec.failed)
+	assert.Contains(t, rec.message, "costs 14.71 USD per month, not below 10.00")
+	assert.Contains(t, rec.message, "Database instance (on-demand, Single-AZ, db.t3.micro): 12.41")
+
+	rec = &recorder{}
+	assert.False(t, report.AssertResourceCostBelow(rec, "module.aws_database[0].aws_db_instance.replica", 20))
+	require.True(t, rec.failed, "An address missing from the breakdown should fail")
+	assert.Contains(t, rec.message, "not in the cost breakdown")
+	assert.Contains(t, rec.message, dbAddress)
+}
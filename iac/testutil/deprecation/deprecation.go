@@ -0,0 +1,107 @@
+// Package deprecation reads deprecations.json, the manifest of module
+// variables that were renamed. The old name stays declared, defaulting to
+// null, until the version it is removed in, so a caller that still sets it
+// plans with a warning rather than an error; setting both names is a
+// validation error.
+//
+// Check holds each module to its entries with the HCL parser; the plans
+// that prove the old name still works are left to the caller.
+package deprecation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"iac/testutil/varcheck"
+)
+
+// ManifestFile is the manifest's name at the repository root
+const ManifestFile = "deprecations.json"
+
+// Deprecation is one renamed variable
+type Deprecation struct {
+	// Module is the module's directory relative to the repository root,
+	// e.g. facade/networking
+	Module string `json:"module"`
+
+	// Variable is the deprecated name and Replacement the one it was
+	// renamed to
+	Variable    string `json:"variable"`
+	Replacement string `json:"replacement"`
+
+	// RemovedIn is the release the deprecated name is removed in, e.g.
+	// 2.0.0
+	RemovedIn string `json:"removed_in"`
+}
+
+// manifest is the layout of deprecations.json
+type manifest struct {
+	Deprecations []Deprecation `json:"deprecations"`
+}
+
+var version = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`)
+
+// Load reads the manifest at path. Every field of an entry is required,
+// and a variable may only be deprecated once per module.
+func Load(path string) ([]Deprecation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("deprecation: %w", err)
+	}
+
+	var m manifest
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("deprecation: %s: %w", path, err)
+	}
+
+	seen := make(map[[2]string]bool)
+	for i, d := range m.Deprecations {
+		switch {
+		case d.Module == "" || d.Variable == "" || d.Replacement == "":
+			return nil, fmt.Errorf("deprecation: %s: entry %d needs module, variable and replacement", path, i)
+		case d.Variable == d.Replacement:
+			return nil, fmt.Errorf("deprecation: %s: %s %s is replaced by itself", path, d.Module, d.Variable)
+		case !version.MatchString(d.RemovedIn):
+			return nil, fmt.Errorf("deprecation: %s: %s %s: removed_in must be a version such as 2.0.0, not %q", path, d.Module, d.Variable, d.RemovedIn)
+		case seen[[2]string{d.Module, d.Variable}]:
+			return nil, fmt.Errorf("deprecation: %s: %s %s is listed twice", path, d.Module, d.Variable)
+		}
+		seen[[2]string{d.Module, d.Variable}] = true
+	}
+	return m.Deprecations, nil
+}
+
+// Check inspects d's module under root and returns what keeps the rename
+// from working: the deprecated variable or its replacement is not
+// declared, or the deprecated variable does not default to null, so a
+// caller setting only the replacement would still have to set it
+func Check(root string, d Deprecation) ([]string, error) {
+	vars, err := varcheck.Inspect(filepath.Join(root, d.Module))
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]varcheck.Variable, len(vars))
+	for _, v := range vars {
+		byName[v.Name] = v
+	}
+
+	var problems []string
+	old, ok := byName[d.Variable]
+	switch {
+	case !ok:
+		problems = append(problems, fmt.Sprintf("%s: deprecated variable %s is not declared; it is kept until %s", d.Module, d.Variable, d.RemovedIn))
+	case !old.NullDefault:
+		problems = append(problems, fmt.Sprintf("%s: deprecated variable %s (%s:%d) must default to null", d.Module, d.Variable, old.File, old.Line))
+	}
+	if _, ok := byName[d.Replacement]; !ok {
+		problems = append(problems, fmt.Sprintf("%s: %s, which replaces %s, is not declared", d.Module, d.Replacement, d.Variable))
+	}
+	return problems, nil
+}
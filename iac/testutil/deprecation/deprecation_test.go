@@ -0,0 +1,103 @@
+package deprecation_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/deprecation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	deprecations, err := deprecation.Load("testdata/deprecations.json")
+	require.NoError(t, err)
+	require.Len(t, deprecations, 3)
+
+	assert.Equal(t, deprecation.Deprecation{
+		Module:      "module",
+		Variable:    "metrics",
+		Replacement: "network_config",
+		RemovedIn:   "2.0.0",
+	}, deprecations[0])
+}
+
+func TestLoadRejectsBadEntries(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		manifest string
+		want     string
+	}{
+		"MissingReplacement": {
+			manifest: `{"deprecations": [{"module": "facade/networking", "variable": "metrics", "removed_in": "2.0.0"}]}`,
+			want:     "entry 0 needs module, variable and replacement",
+		},
+		"ReplacedByItself": {
+			manifest: `{"deprecations": [{"module": "facade/networking", "variable": "metrics", "replacement": "metrics", "removed_in": "2.0.0"}]}`,
+			want:     "facade/networking metrics is replaced by itself",
+		},
+		"NoVersion": {
+			manifest: `{"deprecations": [{"module": "facade/networking", "variable": "metrics", "replacement": "network_config", "removed_in": "next"}]}`,
+			want:     `removed_in must be a version such as 2.0.0, not "next"`,
+		},
+		"ListedTwice": {
+			manifest: `{"deprecations": [` +
+				`{"module": "facade/networking", "variable": "metrics", "replacement": "network_config", "removed_in": "2.0.0"},` +
+				`{"module": "facade/networking", "variable": "metrics", "replacement": "network", "removed_in": "3.0.0"}]}`,
+			want: "facade/networking metrics is listed twice",
+		},
+		"UnknownField": {
+			manifest: `{"deprecations": [{"module": "facade/networking", "variable": "metrics", "replacement": "network_config", "removed": "2.0.0"}]}`,
+			want:     `unknown field "removed"`,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), deprecation.ManifestFile)
+			require.NoError(t, os.WriteFile(path, []byte(tc.manifest), 0o644))
+
+			_, err := deprecation.Load(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	deprecations, err := deprecation.Load("testdata/deprecations.json")
+	require.NoError(t, err)
+
+	var problems []string
+	for _, d := range deprecations {
+		p, err := deprecation.Check("testdata", d)
+		require.NoError(t, err)
+		problems = append(problems, p...)
+	}
+
+	assert.Equal(t, []string{
+		"module: deprecated variable size (variables.tf:13) must default to null",
+		"module: instance_size, which replaces size, is not declared",
+		"module: deprecated variable name is not declared; it is kept until 2.0.0",
+		"module: network_name, which replaces name, is not declared",
+	}, problems)
+}
+
+func TestCheckMissingModule(t *testing.T) {
+	t.Parallel()
+
+	problems, err := deprecation.Check("testdata", deprecation.Deprecation{Module: "missing", Variable: "metrics", Replacement: "network_config", RemovedIn: "2.0.0"})
+	require.NoError(t, err)
+	assert.Len(t, problems, 2, "A module with no .tf files declares neither name")
+}
@@ -0,0 +1,17 @@
+variable "network_config" {
+  description = "Network CIDR"
+  type        = object({ cidr = string })
+  default     = null
+}
+
+variable "metrics" {
+  description = "Deprecated: renamed network_config"
+  type        = object({ cidr = string })
+  default     = null
+}
+
+variable "size" {
+  description = "Deprecated: renamed instance_size"
+  type        = string
+  default     = "small"
+}
@@ -0,0 +1,221 @@
+// Package driftaudit reports the drift of root modules planned against
+// their real state in audit mode (see tfopts.Audit): the resources the
+// refresh found changed or deleted outside Terraform, as planrisk
+// classifies them. TestAuditDrift plans each module in SWE_AUDIT_MODULES
+// and writes the report:
+//
+//	SWE_AUDIT_STATE_BACKEND=$PWD/backend.hcl SWE_AUDIT_MODULES=live/network,live/service \
+//	  go test -tags audit -run TestAuditDrift .
+//
+// The report names resources and actions, never attribute values, so it
+// can be kept as a CI artifact without copying secrets out of the state.
+package driftaudit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"iac/testutil/planrisk"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// EnvModules lists the root modules to audit, relative to the iac module,
+// separated by commas or spaces
+const EnvModules = "SWE_AUDIT_MODULES"
+
+// ReportFile is written to the test's artifact directory
+const ReportFile = "drift.md"
+
+// ModulesFromEnv returns the modules in SWE_AUDIT_MODULES, dropping
+// repeats, and rejects a path that leaves the iac module
+func ModulesFromEnv() ([]string, error) {
+	fields := strings.FieldsFunc(os.Getenv(EnvModules), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+
+	var modules []string
+	seen := map[string]bool{}
+	for _, m := range fields {
+		m = filepath.Clean(m)
+		if !filepath.IsLocal(m) {
+			return nil, fmt.Errorf("driftaudit: %s: %q is not a directory under the iac module", EnvModules, m)
+		}
+		if !seen[m] {
+			seen[m] = true
+			modules = append(modules, m)
+		}
+	}
+	return modules, nil
+}
+
+// DeclaredBackend returns the type of the backend block in the .tf files
+// of dir, e.g. s3, or "" when there is none. Without one, terraform
+// ignores -backend-config and plans against an empty local state, which
+// would report no drift.
+func DeclaredBackend(dir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("driftaudit: no .tf files in %s", dir)
+	}
+	sort.Strings(files)
+
+	parser := hclparse.NewParser()
+	for _, file := range files {
+		f, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return "", fmt.Errorf("driftaudit: %s", diags.Error())
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			return "", fmt.Errorf("driftaudit: %s is not native HCL syntax", file)
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "terraform" {
+				continue
+			}
+			for _, inner := range block.Body.Blocks {
+				if inner.Type == "backend" && len(inner.Labels) > 0 {
+					return inner.Labels[0], nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// Status is the outcome of auditing one module
+type Status string
+
+// Statuses of a Result
+const (
+	InSync  Status = "in sync"
+	Drifted Status = "drifted"
+
+	// NoBackend means the module declares no backend, so it has no state
+	// to audit
+	NoBackend Status = "no backend"
+
+	// Failed means the module could not be planned
+	Failed Status = "failed"
+)
+
+// Result is one audited module
+type Result struct {
+	Module string
+	Status Status
+
+	// Drift lists the resources changed or deleted outside Terraform
+	Drift []planrisk.Change
+
+	// Err is why the module Failed
+	Err error
+}
+
+// NewResult classifies the drift in planJSON, the `terraform show -json`
+// output of module's refresh-only plan
+func NewResult(module string, planJSON []byte) Result {
+	summary, err := planrisk.ClassifyDrift(planJSON)
+	if err != nil {
+		return Result{Module: module, Status: Failed, Err: err}
+	}
+
+	result := Result{Module: module, Status: InSync}
+	for _, c := range summary.Changes {
+		if c.Action != planrisk.NoOp {
+			result.Drift = append(result.Drift, c)
+		}
+	}
+	if len(result.Drift) > 0 {
+		result.Status = Drifted
+	}
+	return result
+}
+
+// Results collects the results of parallel subtests
+type Results struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+// Add records a module's result
+func (r *Results) Add(result Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+}
+
+// All returns the results sorted by module
+func (r *Results) All() []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := append([]Result(nil), r.results...)
+	sort.Slice(results, func(i, j int) bool { return results[i].Module < results[j].Module })
+	return results
+}
+
+// WriteReport writes results as Markdown: a table with a row per module
+// counting what changed and what was deleted outside Terraform, then a
+// section per module that drifted or failed listing the resources, or the
+// error
+func WriteReport(w io.Writer, results []Result) error {
+	var b strings.Builder
+	b.WriteString("# Drift audit\n\n")
+	b.WriteString("| Module | Status | Changed | Deleted |\n| :--- | :--- | ---: | ---: |\n")
+	for _, r := range results {
+		changed, deleted := "-", "-"
+		if r.Status == InSync || r.Status == Drifted {
+			changed, deleted = count(r.Drift, planrisk.Update), count(r.Drift, planrisk.Destroy)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", r.Module, r.Status, changed, deleted)
+	}
+
+	for _, r := range results {
+		switch r.Status {
+		case Drifted:
+			fmt.Fprintf(&b, "\n## %s\n\n", r.Module)
+			for _, c := range r.Drift {
+				fmt.Fprintf(&b, "- %s `%s`\n", action(c.Action), c.Address)
+			}
+		case Failed:
+			fmt.Fprintf(&b, "\n## %s\n\n```\n%v\n```\n", r.Module, r.Err)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// count returns how many of changes have action, as a table cell
+func count(changes []planrisk.Change, action planrisk.Action) string {
+	n := 0
+	for _, c := range changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return fmt.Sprint(n)
+}
+
+// action describes a drift action: a refresh finds an object either
+// modified or gone
+func action(a planrisk.Action) string {
+	switch a {
+	case planrisk.Update:
+		return "changed"
+	case planrisk.Destroy:
+		return "deleted"
+	default:
+		return string(a)
+	}
+}
@@ -0,0 +1,98 @@
+package driftaudit_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"iac/testutil/driftaudit"
+	"iac/testutil/planrisk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModulesFromEnv(t *testing.T) {
+	t.Setenv(driftaudit.EnvModules, "live/network, live/service live/network/")
+	modules, err := driftaudit.ModulesFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"live/network", "live/service"}, modules)
+
+	t.Setenv(driftaudit.EnvModules, "")
+	modules, err = driftaudit.ModulesFromEnv()
+	require.NoError(t, err)
+	assert.Empty(t, modules)
+
+	t.Setenv(driftaudit.EnvModules, "../prod")
+	_, err = driftaudit.ModulesFromEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a directory under the iac module")
+}
+
+func TestDeclaredBackend(t *testing.T) {
+	t.Parallel()
+
+	backend, err := driftaudit.DeclaredBackend("testdata/backend")
+	require.NoError(t, err)
+	assert.Equal(t, "s3", backend)
+
+	backend, err = driftaudit.DeclaredBackend("testdata/local")
+	require.NoError(t, err)
+	assert.Empty(t, backend)
+
+	_, err = driftaudit.DeclaredBackend(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestNewResult(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/drift.json")
+	require.NoError(t, err)
+
+	result := driftaudit.NewResult("live/storage", data)
+	require.NoError(t, result.Err)
+	assert.Equal(t, driftaudit.Drifted, result.Status)
+	require.Len(t, result.Drift, 2)
+	assert.Equal(t, planrisk.Destroy, result.Drift[1].Action)
+
+	result = driftaudit.NewResult("live/network", []byte(`{"format_version": "1.2", "resource_drift": []}`))
+	assert.Equal(t, driftaudit.InSync, result.Status)
+	assert.Empty(t, result.Drift)
+
+	result = driftaudit.NewResult("live/service", []byte(`Plan: 1 to add`))
+	assert.Equal(t, driftaudit.Failed, result.Status)
+	assert.Error(t, result.Err)
+}
+
+func TestWriteReport(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/drift.json")
+	require.NoError(t, err)
+
+	var results driftaudit.Results
+	results.Add(driftaudit.NewResult("live/storage", data))
+	results.Add(driftaudit.Result{Module: "live/service", Status: driftaudit.Failed, Err: errors.New("Error: Failed to get existing workspaces")})
+	results.Add(driftaudit.Result{Module: "examples/web-app", Status: driftaudit.NoBackend})
+	results.Add(driftaudit.NewResult("live/network", []byte(`{"format_version": "1.2"}`)))
+
+	var out strings.Builder
+	require.NoError(t, driftaudit.WriteReport(&out, results.All()))
+
+	assert.Equal(t, ""+
+		"# Drift audit\n\n"+
+		"| Module | Status | Changed | Deleted |\n"+
+		"| :--- | :--- | ---: | ---: |\n"+
+		"| examples/web-app | no backend | - | - |\n"+
+		"| live/network | in sync | 0 | 0 |\n"+
+		"| live/service | failed | - | - |\n"+
+		"| live/storage | drifted | 1 | 1 |\n"+
+		"\n## live/service\n\n"+
+		"```\nError: Failed to get existing workspaces\n```\n"+
+		"\n## live/storage\n\n"+
+		"- changed `module.aws_storage[0].aws_s3_bucket.this`\n"+
+		"- deleted `module.aws_storage[0].aws_s3_bucket_policy.this[0]`\n", out.String())
+	assert.NotContains(t, out.String(), "audit-bucket", "The report should not copy attribute values out of the state")
+}
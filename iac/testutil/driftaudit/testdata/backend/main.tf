@@ -0,0 +1,7 @@
+terraform {
+  required_version = ">= 1.9.0"
+
+  backend "s3" {
+    key = "network/terraform.tfstate"
+  }
+}
@@ -0,0 +1,170 @@
+// Package driftcheck verifies that a module repairs configuration changed
+// outside Terraform, which is what running the facades on a schedule for
+// drift remediation relies on.
+//
+// Detecting drift alone proves little: a refresh reports any attribute the
+// provider reads back, including ones the module leaves unmanaged or lists in
+// ignore_changes. The check therefore also requires the normal plan to change
+// every drifted resource, and a plan after the repair to be empty.
+package driftcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// ResourceChange is a resource entry of a JSON plan, from either its
+// resource_drift or resource_changes list
+type ResourceChange struct {
+	Address string `json:"address"`
+	Change  struct {
+		Actions []string               `json:"actions"`
+		Before  map[string]interface{} `json:"before"`
+		After   map[string]interface{} `json:"after"`
+	} `json:"change"`
+}
+
+// Changed reports whether the entry does anything beyond a no-op or read
+func (rc ResourceChange) Changed() bool {
+	for _, a := range rc.Change.Actions {
+		if a != "no-op" && a != "read" {
+			return true
+		}
+	}
+	return false
+}
+
+// Plan is the `terraform show -json` output for a plan
+type Plan struct {
+	FormatVersion string `json:"format_version"`
+
+	// ResourceDrift lists the differences the refresh found between the
+	// state and the real objects
+	ResourceDrift []ResourceChange `json:"resource_drift"`
+
+	// ResourceChanges lists what applying the plan does to each resource
+	ResourceChanges []ResourceChange `json:"resource_changes"`
+}
+
+// ParsePlan decodes `terraform show -json` output for a plan
+func ParsePlan(data []byte) (*Plan, error) {
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("driftcheck: decoding plan JSON: %w", err)
+	}
+	if p.FormatVersion == "" {
+		return nil, fmt.Errorf("driftcheck: not terraform show -json output (no format_version field)")
+	}
+	return &p, nil
+}
+
+// Drifted returns the addresses of the resources the refresh found changed,
+// sorted
+func (p *Plan) Drifted() []string {
+	return changedAddresses(p.ResourceDrift)
+}
+
+// Changed returns the addresses of the resources the plan changes, sorted
+func (p *Plan) Changed() []string {
+	return changedAddresses(p.ResourceChanges)
+}
+
+// Change returns the planned change for address, or nil if the plan does not
+// list it
+func (p *Plan) Change(address string) *ResourceChange {
+	for i := range p.ResourceChanges {
+		if p.ResourceChanges[i].Address == address {
+			return &p.ResourceChanges[i]
+		}
+	}
+	return nil
+}
+
+func changedAddresses(changes []ResourceChange) []string {
+	var addresses []string
+	for _, rc := range changes {
+		if rc.Changed() {
+			addresses = append(addresses, rc.Address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// Unrepaired returns the resources that drifted but that repair, the normal
+// plan made after the drift, leaves alone: the module ignores whatever was
+// changed on them
+func Unrepaired(drifted []string, repair *Plan) []string {
+	var unrepaired []string
+	for _, address := range drifted {
+		if rc := repair.Change(address); rc == nil || !rc.Changed() {
+			unrepaired = append(unrepaired, address)
+		}
+	}
+	return unrepaired
+}
+
+// DetectAndRepairDrift applies the workspace at options.TerraformDir, calls
+// mutate to change its resources out of band, and then checks that
+//
+//   - a refresh-only plan reports drift,
+//   - the normal plan changes every drifted resource, and
+//   - after applying that plan, a further plan is empty.
+//
+// assertRepair receives the normal plan to check what it changes. The caller
+// destroys the workspace, as after terraform.InitAndApply.
+func DetectAndRepairDrift(t *testing.T, options *terraform.Options, mutate func(), assertRepair func(plan *Plan)) {
+	t.Helper()
+
+	tfopts.InitAndApply(t, options)
+
+	mutate()
+
+	refresh := showPlan(t, options, "refresh", "-refresh-only")
+	drifted := refresh.Drifted()
+	if len(drifted) == 0 {
+		t.Fatalf("refresh-only plan found no drift after the out-of-band change")
+	}
+	t.Logf("Drift detected on:\n  %s", strings.Join(drifted, "\n  "))
+
+	repair := showPlan(t, options, "repair")
+	if unrepaired := Unrepaired(drifted, repair); len(unrepaired) > 0 {
+		t.Errorf("plan does not repair drifted resources; the module ignores the changed attributes:\n  %s", strings.Join(unrepaired, "\n  "))
+	}
+	assertRepair(repair)
+
+	tfopts.Apply(t, options)
+
+	if remaining := showPlan(t, options, "converged").Changed(); len(remaining) > 0 {
+		t.Errorf("plan after repairing drift is not empty:\n  %s", strings.Join(remaining, "\n  "))
+	}
+}
+
+// showPlan saves a plan with the extra flags and returns it decoded
+func showPlan(t *testing.T, options *terraform.Options, name string, flags ...string) *Plan {
+	t.Helper()
+
+	planOptions := *options
+	planOptions.PlanFilePath = filepath.Join(t.TempDir(), name+".plan")
+
+	args := append([]string{"plan", "-input=false", "-lock=false", "-out=" + planOptions.PlanFilePath}, flags...)
+	if options.NoColor {
+		args = append(args, "-no-color")
+	}
+	args = append(args, terraform.FormatTerraformVarsAsArgs(options.Vars)...)
+	terraform.RunTerraformCommand(t, &planOptions, args...)
+
+	plan, err := ParsePlan([]byte(terraform.Show(t, &planOptions)))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return plan
+}
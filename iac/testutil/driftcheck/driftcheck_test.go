@@ -0,0 +1,80 @@
+package driftcheck_test
+
+import (
+	"os"
+	"testing"
+
+	"iac/testutil/driftcheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bucketAddress     = "module.aws_storage[0].aws_s3_bucket.this"
+	versioningAddress = "module.aws_storage[0].aws_s3_bucket_versioning.this[0]"
+)
+
+func loadPlan(t *testing.T, path string) *driftcheck.Plan {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	plan, err := driftcheck.ParsePlan(data)
+	require.NoError(t, err)
+	return plan
+}
+
+func TestRefreshOnlyPlanReportsDrift(t *testing.T) {
+	t.Parallel()
+
+	refresh := loadPlan(t, "testdata/refresh.json")
+
+	assert.Equal(t, []string{bucketAddress, versioningAddress}, refresh.Drifted())
+	assert.Empty(t, refresh.Changed(), "A refresh-only plan changes no resources")
+}
+
+func TestRepairPlanChangesDriftedResources(t *testing.T) {
+	t.Parallel()
+
+	drifted := loadPlan(t, "testdata/refresh.json").Drifted()
+	repair := loadPlan(t, "testdata/repair.json")
+
+	assert.Empty(t, driftcheck.Unrepaired(drifted, repair))
+	assert.Equal(t, []string{bucketAddress, versioningAddress}, repair.Changed())
+
+	versioning := repair.Change(versioningAddress)
+	require.NotNil(t, versioning)
+	assert.Equal(t, []string{"update"}, versioning.Change.Actions)
+	assert.Equal(t, []interface{}{map[string]interface{}{"status": "Enabled"}}, versioning.Change.After["versioning_configuration"])
+}
+
+func TestIgnoredAttributeIsUnrepaired(t *testing.T) {
+	t.Parallel()
+
+	drifted := loadPlan(t, "testdata/refresh.json").Drifted()
+
+	// Versioning drifted but the plan leaves it alone, as it would with
+	// ignore_changes on the status
+	assert.Equal(t, []string{versioningAddress}, driftcheck.Unrepaired(drifted, loadPlan(t, "testdata/ignored.json")))
+}
+
+func TestUnrepairedMissingResource(t *testing.T) {
+	t.Parallel()
+
+	repair := loadPlan(t, "testdata/repair.json")
+
+	assert.Equal(t, []string{"module.aws_storage[0].aws_s3_bucket_policy.this"},
+		driftcheck.Unrepaired([]string{"module.aws_storage[0].aws_s3_bucket_policy.this"}, repair))
+	assert.Nil(t, repair.Change("module.aws_storage[0].aws_s3_bucket_policy.this"))
+}
+
+func TestParsePlanRejectsOtherJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := driftcheck.ParsePlan([]byte(`{"resource_drift": []}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no format_version")
+
+	_, err = driftcheck.ParsePlan([]byte(`No changes.`))
+	assert.Error(t, err)
+}
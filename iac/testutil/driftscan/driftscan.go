@@ -0,0 +1,175 @@
+// Package driftscan plans every deployed root module in a manifest and
+// reports their drift, for a scheduled job rather than a test run. Each
+// module gets a refresh-only plan, whose resource_drift is what changed
+// outside Terraform, and a regular plan, which is what applying would do
+// about it; planrisk classifies both:
+//
+//	manifest, err := driftscan.LoadManifest("modules.json")
+//	tf := driftscan.NewTerraform(binary, logDir)
+//	report := driftscan.NewReport(driftscan.Scan(ctx, manifest.Modules, manifest.Concurrency, tf), tf.LogPath)
+//	report.WriteMarkdown(os.Stdout)
+//	os.Exit(report.ExitCode)
+//
+// tools/driftscan is that command. A module's outcome is destructive drift
+// when an object was deleted outside Terraform or the plan would replace
+// or destroy one, in-place drift when anything else drifted or would
+// change, and no drift otherwise; the scan's outcome is its worst module's.
+package driftscan
+
+import (
+	"context"
+	"sync"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/planrisk"
+)
+
+// Outcome is what a scan found in one module, or in all of them
+type Outcome string
+
+// Outcomes, from best to worst
+const (
+	NoDrift          Outcome = "no drift"
+	InPlaceDrift     Outcome = "in-place drift"
+	DestructiveDrift Outcome = "destructive drift"
+
+	// Failed means a module could not be planned, so its drift is unknown
+	Failed Outcome = "failed"
+)
+
+// Exit codes of tools/driftscan, one per Outcome
+const (
+	ExitNoDrift          = 0
+	ExitInPlaceDrift     = 1
+	ExitDestructiveDrift = 2
+	ExitFailed           = 3
+)
+
+var severity = map[Outcome]int{
+	NoDrift:          ExitNoDrift,
+	InPlaceDrift:     ExitInPlaceDrift,
+	DestructiveDrift: ExitDestructiveDrift,
+	Failed:           ExitFailed,
+}
+
+// ExitCode returns the exit code of a scan whose outcome is o
+func (o Outcome) ExitCode() int {
+	return severity[o]
+}
+
+// Plans is the `terraform show -json` output of a module's two plans
+type Plans struct {
+	Refresh []byte
+	Plan    []byte
+}
+
+// Planner plans one module
+type Planner interface {
+	Plan(ctx context.Context, module Module) (*Plans, error)
+}
+
+// PlannerFunc adapts a function to Planner
+type PlannerFunc func(ctx context.Context, module Module) (*Plans, error)
+
+// Plan implements Planner
+func (f PlannerFunc) Plan(ctx context.Context, module Module) (*Plans, error) {
+	return f(ctx, module)
+}
+
+// Result is one scanned module
+type Result struct {
+	Module  string
+	Outcome Outcome
+
+	// Drift lists the resources changed or deleted outside Terraform, and
+	// Changes what the regular plan would do; no-ops are left out of both
+	Drift   []planrisk.Change
+	Changes []planrisk.Change
+
+	// Err is why the module Failed
+	Err error
+}
+
+// NewResult classifies the drift and changes in plans, the plans of the
+// module named module
+func NewResult(module string, plans *Plans) Result {
+	drift, err := planrisk.ClassifyDrift(plans.Refresh)
+	if err != nil {
+		return Result{Module: module, Outcome: Failed, Err: err}
+	}
+	changes, err := planrisk.Classify(plans.Plan)
+	if err != nil {
+		return Result{Module: module, Outcome: Failed, Err: err}
+	}
+
+	result := Result{Module: module, Outcome: NoDrift}
+	for _, c := range drift.Changes {
+		if c.Action != planrisk.NoOp {
+			result.Drift = append(result.Drift, c)
+			result.Outcome = worse(result.Outcome, InPlaceDrift)
+		}
+		if c.Action == planrisk.Destroy {
+			result.Outcome = DestructiveDrift
+		}
+	}
+	for _, c := range changes.Changes {
+		if c.Action != planrisk.NoOp {
+			result.Changes = append(result.Changes, c)
+			result.Outcome = worse(result.Outcome, InPlaceDrift)
+		}
+		if c.Destructive() {
+			result.Outcome = DestructiveDrift
+		}
+	}
+	return result
+}
+
+// Scan plans modules with planner, at most limit at once, and returns
+// their results in the order of modules. A module whose turn has not come
+// when ctx is done Fails with ctx's error.
+func Scan(ctx context.Context, modules []Module, limit int, planner Planner) []Result {
+	if limit < 1 {
+		limit = DefaultConcurrency
+	}
+	sem := concurrency.NewSemaphore(int64(limit))
+
+	results := make([]Result, len(modules))
+	var wg sync.WaitGroup
+	for i, module := range modules {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := sem.Acquire(ctx, 1); err != nil {
+				results[i] = Result{Module: module.Name, Outcome: Failed, Err: err}
+				return
+			}
+			defer sem.Release(1)
+
+			plans, err := planner.Plan(ctx, module)
+			if err != nil {
+				results[i] = Result{Module: module.Name, Outcome: Failed, Err: err}
+				return
+			}
+			results[i] = NewResult(module.Name, plans)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Worst returns the worst outcome of results, NoDrift for none
+func Worst(results []Result) Outcome {
+	outcome := NoDrift
+	for _, r := range results {
+		outcome = worse(outcome, r.Outcome)
+	}
+	return outcome
+}
+
+func worse(a, b Outcome) Outcome {
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
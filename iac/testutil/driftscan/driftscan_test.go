@@ -0,0 +1,162 @@
+package driftscan_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iac/testutil/driftscan"
+	"iac/testutil/planrisk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plans returns the plans of a module whose refresh-only and regular plans
+// are both the plan at path; planrisk reads only the drift of one and the
+// changes of the other
+func plans(t *testing.T, path string) *driftscan.Plans {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return &driftscan.Plans{Refresh: data, Plan: data}
+}
+
+func TestNewResult(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		outcome driftscan.Outcome
+		drift   planrisk.Action
+		change  planrisk.Action
+	}{
+		"testdata/in-sync.json":  {driftscan.NoDrift, "", ""},
+		"testdata/changed.json":  {driftscan.InPlaceDrift, planrisk.Update, planrisk.Update},
+		"testdata/deleted.json":  {driftscan.DestructiveDrift, planrisk.Destroy, planrisk.Create},
+		"testdata/replaced.json": {driftscan.DestructiveDrift, planrisk.Update, planrisk.Replace},
+	}
+	for path, tt := range tests {
+		t.Run(path, func(t *testing.T) {
+			t.Parallel()
+
+			result := driftscan.NewResult("storage", plans(t, path))
+			require.NoError(t, result.Err)
+			assert.Equal(t, "storage", result.Module)
+			assert.Equal(t, tt.outcome, result.Outcome)
+
+			if tt.outcome == driftscan.NoDrift {
+				assert.Empty(t, result.Drift)
+				assert.Empty(t, result.Changes, "No-ops should be left out")
+				return
+			}
+			require.Len(t, result.Drift, 1)
+			assert.Equal(t, tt.drift, result.Drift[0].Action)
+			require.Len(t, result.Changes, 1)
+			assert.Equal(t, tt.change, result.Changes[0].Action)
+		})
+	}
+}
+
+func TestNewResultInvalidPlan(t *testing.T) {
+	t.Parallel()
+
+	valid := plans(t, "testdata/changed.json").Plan
+	for _, p := range []*driftscan.Plans{
+		{Refresh: []byte("not json"), Plan: valid},
+		{Refresh: valid, Plan: []byte("not json")},
+	} {
+		result := driftscan.NewResult("storage", p)
+		assert.Equal(t, driftscan.Failed, result.Outcome)
+		assert.Error(t, result.Err)
+	}
+}
+
+func TestScanLimitsConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var modules []driftscan.Module
+	for i := 0; i < 10; i++ {
+		modules = append(modules, driftscan.Module{Name: fmt.Sprintf("module-%d", i)})
+	}
+
+	var running, most atomic.Int32
+	planner := driftscan.PlannerFunc(func(ctx context.Context, module driftscan.Module) (*driftscan.Plans, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		switch module.Name {
+		case "module-3":
+			return plans(t, "testdata/changed.json"), nil
+		case "module-7":
+			return nil, errors.New("terraform init in module-7: exit status 1")
+		default:
+			return plans(t, "testdata/in-sync.json"), nil
+		}
+	})
+
+	results := driftscan.Scan(context.Background(), modules, 3, planner)
+
+	assert.Equal(t, int32(3), most.Load(), "Scan should plan at most 3 modules at once, and use all 3")
+	require.Len(t, results, len(modules))
+	for i, r := range results {
+		assert.Equal(t, modules[i].Name, r.Module, "Results should be in the order of the modules")
+	}
+	assert.Equal(t, driftscan.InPlaceDrift, results[3].Outcome)
+	assert.Equal(t, driftscan.Failed, results[7].Outcome)
+	assert.EqualError(t, results[7].Err, "terraform init in module-7: exit status 1")
+	assert.Equal(t, driftscan.NoDrift, results[0].Outcome)
+	assert.Equal(t, driftscan.Failed, driftscan.Worst(results))
+}
+
+func TestScanCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	planner := driftscan.PlannerFunc(func(ctx context.Context, module driftscan.Module) (*driftscan.Plans, error) {
+		cancel()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	modules := []driftscan.Module{{Name: "network"}, {Name: "storage"}, {Name: "compute"}}
+	results := driftscan.Scan(ctx, modules, 1, planner)
+
+	require.Len(t, results, 3)
+	for _, r := range results {
+		assert.Equal(t, driftscan.Failed, r.Outcome, r.Module)
+		assert.ErrorIs(t, r.Err, context.Canceled, r.Module)
+	}
+}
+
+func TestWorst(t *testing.T) {
+	t.Parallel()
+
+	results := func(outcomes ...driftscan.Outcome) []driftscan.Result {
+		var rs []driftscan.Result
+		for _, o := range outcomes {
+			rs = append(rs, driftscan.Result{Outcome: o})
+		}
+		return rs
+	}
+
+	assert.Equal(t, driftscan.NoDrift, driftscan.Worst(nil))
+	assert.Equal(t, driftscan.InPlaceDrift, driftscan.Worst(results(driftscan.NoDrift, driftscan.InPlaceDrift, driftscan.NoDrift)))
+	assert.Equal(t, driftscan.DestructiveDrift, driftscan.Worst(results(driftscan.DestructiveDrift, driftscan.InPlaceDrift)))
+	assert.Equal(t, driftscan.Failed, driftscan.Worst(results(driftscan.Failed, driftscan.DestructiveDrift)))
+
+	assert.Equal(t, 0, driftscan.NoDrift.ExitCode())
+	assert.Equal(t, 1, driftscan.InPlaceDrift.ExitCode())
+	assert.Equal(t, 2, driftscan.DestructiveDrift.ExitCode())
+	assert.Equal(t, 3, driftscan.Failed.ExitCode())
+}
@@ -0,0 +1,109 @@
+package driftscan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultConcurrency is how many modules are planned at once when the
+// manifest does not say
+const DefaultConcurrency = 4
+
+// Manifest lists the deployed root modules a scan plans
+type Manifest struct {
+	// Concurrency is how many modules are planned at once; 0 means
+	// DefaultConcurrency
+	Concurrency int `json:"concurrency"`
+
+	Modules []Module `json:"modules"`
+}
+
+// Module is one deployed root module
+type Module struct {
+	// Name labels the module in reports; it defaults to Path as written
+	Name string `json:"name"`
+
+	// Path is the module's directory
+	Path string `json:"path"`
+
+	// BackendConfig is the -backend-config file init reads, such as the
+	// backend.hcl the State Backend facade writes. Empty keeps the backend
+	// settings the module declares.
+	BackendConfig string `json:"backend_config"`
+
+	// VarFiles and Vars are passed to both plans, as -var-file and -var
+	VarFiles []string               `json:"var_files"`
+	Vars     map[string]interface{} `json:"vars"`
+}
+
+// LoadManifest reads the manifest at path; see ParseManifest
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("driftscan: %w", err)
+	}
+	m, err := ParseManifest(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%w (in %s)", err, path)
+	}
+	return m, nil
+}
+
+// ParseManifest decodes a JSON manifest. Relative paths in it, to modules,
+// backend configuration and var files, are taken relative to dir, the
+// manifest's directory. Every module needs a path and a name of its own,
+// and unknown fields are rejected, so a misspelt backend_config is not
+// silently dropped.
+func ParseManifest(data []byte, dir string) (*Manifest, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var m Manifest
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("driftscan: decoding manifest: %w", err)
+	}
+	if m.Concurrency < 0 {
+		return nil, fmt.Errorf("driftscan: concurrency is %d, want 1 or more, or 0 for the default of %d", m.Concurrency, DefaultConcurrency)
+	}
+	if m.Concurrency == 0 {
+		m.Concurrency = DefaultConcurrency
+	}
+	if len(m.Modules) == 0 {
+		return nil, fmt.Errorf("driftscan: the manifest lists no modules")
+	}
+
+	seen := map[string]bool{}
+	for i := range m.Modules {
+		module := &m.Modules[i]
+		if module.Path == "" {
+			return nil, fmt.Errorf("driftscan: module %d has no path", i+1)
+		}
+		if module.Name == "" {
+			module.Name = filepath.ToSlash(filepath.Clean(module.Path))
+		}
+		if seen[module.Name] {
+			return nil, fmt.Errorf("driftscan: module %q is listed twice; give one a name of its own", module.Name)
+		}
+		seen[module.Name] = true
+
+		module.Path = resolve(dir, module.Path)
+		if module.BackendConfig != "" {
+			module.BackendConfig = resolve(dir, module.BackendConfig)
+		}
+		for j, file := range module.VarFiles {
+			module.VarFiles[j] = resolve(dir, file)
+		}
+	}
+	return &m, nil
+}
+
+// resolve returns path relative to dir, unless it is absolute
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,92 @@
+package driftscan_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/driftscan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("live", "prod")
+	abs, err := filepath.Abs("shared")
+	require.NoError(t, err)
+
+	manifest, err := driftscan.ParseManifest([]byte(`{
+		"modules": [
+			{"path": "./network/", "backend_config": "backend.hcl", "var_files": ["prod.tfvars", "`+filepath.ToSlash(abs)+`/common.tfvars"]},
+			{"name": "storage-eu", "path": "storage", "vars": {"region": "eu-west-1"}}
+		]
+	}`), dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, driftscan.DefaultConcurrency, manifest.Concurrency)
+	require.Len(t, manifest.Modules, 2)
+	assert.Equal(t, driftscan.Module{
+		Name:          "network",
+		Path:          filepath.Join(dir, "network"),
+		BackendConfig: filepath.Join(dir, "backend.hcl"),
+		VarFiles:      []string{filepath.Join(dir, "prod.tfvars"), filepath.Join(abs, "common.tfvars")},
+	}, manifest.Modules[0])
+	assert.Equal(t, driftscan.Module{
+		Name: "storage-eu",
+		Path: filepath.Join(dir, "storage"),
+		Vars: map[string]interface{}{"region": "eu-west-1"},
+	}, manifest.Modules[1])
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		manifest string
+		err      string
+	}{
+		"not JSON":          {`modules: [network]`, "decoding manifest"},
+		"unknown field":     {`{"modules": [{"path": "network", "backend": "backend.hcl"}]}`, `unknown field "backend"`},
+		"negative limit":    {`{"concurrency": -1, "modules": [{"path": "network"}]}`, "concurrency is -1"},
+		"no modules":        {`{"concurrency": 2}`, "lists no modules"},
+		"no path":           {`{"modules": [{"path": "network"}, {"name": "storage"}]}`, "module 2 has no path"},
+		"same path":         {`{"modules": [{"path": "network"}, {"path": "./network"}]}`, `module "network" is listed twice`},
+		"same name":         {`{"modules": [{"name": "eu", "path": "network"}, {"name": "eu", "path": "storage"}]}`, `module "eu" is listed twice`},
+		"name as path":      {`{"modules": [{"path": "network"}, {"name": "network", "path": "storage"}]}`, `module "network" is listed twice`},
+		"wrong vars shape":  {`{"modules": [{"path": "network", "vars": ["region"]}]}`, "decoding manifest"},
+		"wrong files shape": {`{"modules": [{"path": "network", "var_files": "prod.tfvars"}]}`, "decoding manifest"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := driftscan.ParseManifest([]byte(tt.manifest), ".")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "modules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"concurrency": 2, "modules": [{"path": "network"}]}`), 0o644))
+
+	manifest, err := driftscan.LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, manifest.Concurrency)
+	assert.Equal(t, filepath.Join(dir, "network"), manifest.Modules[0].Path, "Paths should be relative to the manifest")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"modules": []}`), 0o644))
+	_, err = driftscan.LoadManifest(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path, "The error should name the manifest")
+
+	_, err = driftscan.LoadManifest(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
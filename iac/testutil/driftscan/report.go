@@ -0,0 +1,191 @@
+package driftscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"iac/testutil/planrisk"
+)
+
+// Files tools/driftscan writes to its output directory
+const (
+	JSONReportFile     = "drift.json"
+	MarkdownReportFile = "drift.md"
+)
+
+// Kinds of drift, as the refresh finds an object either modified or gone
+const (
+	Changed = "changed"
+	Deleted = "deleted"
+)
+
+// Report is the machine-readable report of a scan. It names resources,
+// actions and the attributes forcing a replacement, never attribute
+// values, so it can be kept and filed without copying secrets out of the
+// state.
+type Report struct {
+	Outcome  Outcome        `json:"outcome"`
+	ExitCode int            `json:"exit_code"`
+	Modules  []ModuleReport `json:"modules"`
+}
+
+// ModuleReport is one module of a Report
+type ModuleReport struct {
+	Module  string  `json:"module"`
+	Outcome Outcome `json:"outcome"`
+
+	// Drift has kind changed or deleted; Changes has the planrisk action
+	Drift   []Change `json:"drift"`
+	Changes []Change `json:"changes"`
+
+	// Error is why the module failed
+	Error string `json:"error,omitempty"`
+
+	// Log is where the module's Terraform output was written
+	Log string `json:"log,omitempty"`
+}
+
+// Change is one resource's drift or planned change
+type Change struct {
+	Address      string   `json:"address"`
+	Type         string   `json:"type"`
+	Kind         string   `json:"kind"`
+	Reason       string   `json:"reason,omitempty"`
+	ReplacePaths []string `json:"replace_paths,omitempty"`
+}
+
+// NewReport builds the report of results. logPath, when not nil, gives the
+// log of each module, e.g. Terraform.LogPath.
+func NewReport(results []Result, logPath func(module string) string) *Report {
+	outcome := Worst(results)
+	report := &Report{Outcome: outcome, ExitCode: outcome.ExitCode(), Modules: []ModuleReport{}}
+	for _, r := range results {
+		m := ModuleReport{Module: r.Module, Outcome: r.Outcome, Drift: []Change{}, Changes: []Change{}}
+		for _, c := range r.Drift {
+			m.Drift = append(m.Drift, Change{Address: c.Address, Type: c.Type, Kind: driftKind(c.Action)})
+		}
+		for _, c := range r.Changes {
+			m.Changes = append(m.Changes, Change{Address: c.Address, Type: c.Type, Kind: string(c.Action), Reason: c.Reason, ReplacePaths: c.Paths()})
+		}
+		if r.Err != nil {
+			m.Error = r.Err.Error()
+		}
+		if logPath != nil {
+			m.Log = logPath(r.Module)
+		}
+		report.Modules = append(report.Modules, m)
+	}
+	return report
+}
+
+// driftKind names a drift action
+func driftKind(a planrisk.Action) string {
+	switch a {
+	case planrisk.Update:
+		return Changed
+	case planrisk.Destroy:
+		return Deleted
+	default:
+		return string(a)
+	}
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// kinds are the sections of a module in the Markdown report, in order,
+// with their headings
+var kinds = []struct {
+	drift   bool
+	kind    string
+	heading string
+}{
+	{true, Deleted, "Deleted outside Terraform"},
+	{true, Changed, "Changed outside Terraform"},
+	{false, string(planrisk.Destroy), "Plan would destroy"},
+	{false, string(planrisk.Replace), "Plan would replace"},
+	{false, string(planrisk.Update), "Plan would update in place"},
+	{false, string(planrisk.Create), "Plan would create"},
+}
+
+// WriteMarkdown writes the report as Markdown: the outcome, a table with a
+// row per module counting each kind of drift and change, then a section
+// per module that drifted or failed, grouping its resources by kind with
+// the destructive ones first, or giving the error
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Drift scan\n\n")
+	fmt.Fprintf(&b, "**Outcome**: %s (exit %d)\n\n", r.Outcome, r.ExitCode)
+	b.WriteString("| Module | Outcome | Changed | Deleted | Create | Update | Replace | Destroy |\n")
+	b.WriteString("| :--- | :--- | ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	for _, m := range r.Modules {
+		if m.Outcome == Failed {
+			fmt.Fprintf(&b, "| %s | %s | - | - | - | - | - | - |\n", m.Module, m.Outcome)
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %d | %d | %d | %d |\n", m.Module, m.Outcome,
+			count(m.Drift, Changed), count(m.Drift, Deleted),
+			count(m.Changes, string(planrisk.Create)), count(m.Changes, string(planrisk.Update)),
+			count(m.Changes, string(planrisk.Replace)), count(m.Changes, string(planrisk.Destroy)))
+	}
+
+	for _, m := range r.Modules {
+		switch m.Outcome {
+		case Failed:
+			fmt.Fprintf(&b, "\n## %s\n\n```\n%s\n```\n", m.Module, m.Error)
+		case InPlaceDrift, DestructiveDrift:
+			fmt.Fprintf(&b, "\n## %s\n", m.Module)
+			for _, k := range kinds {
+				changes := m.Changes
+				if k.drift {
+					changes = m.Drift
+				}
+				writeKind(&b, k.heading, k.kind, changes)
+			}
+		default:
+			continue
+		}
+		if m.Log != "" {
+			fmt.Fprintf(&b, "\nLog: `%s`\n", m.Log)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeKind writes a section listing the changes of kind, if there are any
+func writeKind(b *strings.Builder, heading, kind string, changes []Change) {
+	n := count(changes, kind)
+	if n == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s (%d)\n\n", heading, n)
+	for _, c := range changes {
+		if c.Kind != kind {
+			continue
+		}
+		fmt.Fprintf(b, "- `%s`", c.Address)
+		if len(c.ReplacePaths) > 0 {
+			fmt.Fprintf(b, " forced by %s", strings.Join(c.ReplacePaths, ", "))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// count returns how many of changes are of kind
+func count(changes []Change, kind string) int {
+	n := 0
+	for _, c := range changes {
+		if c.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
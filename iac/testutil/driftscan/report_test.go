@@ -0,0 +1,130 @@
+package driftscan_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"iac/testutil/driftscan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// results are a scan of four modules, one of which failed
+func results(t *testing.T) []driftscan.Result {
+	t.Helper()
+	return []driftscan.Result{
+		driftscan.NewResult("network", plans(t, "testdata/in-sync.json")),
+		driftscan.NewResult("storage", plans(t, "testdata/changed.json")),
+		driftscan.NewResult("storage-eu", plans(t, "testdata/replaced.json")),
+		{Module: "compute", Outcome: driftscan.Failed, Err: errors.New("terraform init in compute: exit status 1\nError: Backend initialization required")},
+	}
+}
+
+func logPath(module string) string {
+	return "logs/" + module + "/terraform.log"
+}
+
+func TestReportMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	require.NoError(t, driftscan.NewReport(results(t), logPath).WriteMarkdown(&b))
+
+	assert.Equal(t, "# Drift scan\n"+
+		"\n"+
+		"**Outcome**: failed (exit 3)\n"+
+		"\n"+
+		"| Module | Outcome | Changed | Deleted | Create | Update | Replace | Destroy |\n"+
+		"| :--- | :--- | ---: | ---: | ---: | ---: | ---: | ---: |\n"+
+		"| network | no drift | 0 | 0 | 0 | 0 | 0 | 0 |\n"+
+		"| storage | in-place drift | 1 | 0 | 0 | 1 | 0 | 0 |\n"+
+		"| storage-eu | destructive drift | 1 | 0 | 0 | 0 | 1 | 0 |\n"+
+		"| compute | failed | - | - | - | - | - | - |\n"+
+		"\n"+
+		"## storage\n"+
+		"\n"+
+		"### Changed outside Terraform (1)\n"+
+		"\n"+
+		"- `module.aws_storage[0].aws_s3_bucket.this`\n"+
+		"\n"+
+		"### Plan would update in place (1)\n"+
+		"\n"+
+		"- `module.aws_storage[0].aws_s3_bucket.this`\n"+
+		"\n"+
+		"Log: `logs/storage/terraform.log`\n"+
+		"\n"+
+		"## storage-eu\n"+
+		"\n"+
+		"### Changed outside Terraform (1)\n"+
+		"\n"+
+		"- `module.aws_storage[0].aws_s3_bucket_versioning.this[0]`\n"+
+		"\n"+
+		"### Plan would replace (1)\n"+
+		"\n"+
+		"- `module.aws_storage[0].aws_s3_bucket_versioning.this[0]` forced by expected_bucket_owner\n"+
+		"\n"+
+		"Log: `logs/storage-eu/terraform.log`\n"+
+		"\n"+
+		"## compute\n"+
+		"\n"+
+		"```\n"+
+		"terraform init in compute: exit status 1\n"+
+		"Error: Backend initialization required\n"+
+		"```\n"+
+		"\n"+
+		"Log: `logs/compute/terraform.log`\n", b.String())
+}
+
+func TestReportMarkdownDeletedFirst(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	report := driftscan.NewReport([]driftscan.Result{driftscan.NewResult("storage", plans(t, "testdata/deleted.json"))}, nil)
+	require.NoError(t, report.WriteMarkdown(&b))
+
+	md := b.String()
+	assert.Contains(t, md, "**Outcome**: destructive drift (exit 2)")
+	assert.Contains(t, md, "### Deleted outside Terraform (1)\n\n- `module.aws_storage[0].aws_s3_bucket_policy.this[0]`\n")
+	assert.Less(t, bytes.Index(b.Bytes(), []byte("### Deleted")), bytes.Index(b.Bytes(), []byte("### Plan would create")))
+	assert.NotContains(t, md, "Log:", "Without logPath there is no log to point at")
+}
+
+func TestReportJSON(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	require.NoError(t, driftscan.NewReport(results(t), logPath).WriteJSON(&b))
+
+	var report driftscan.Report
+	require.NoError(t, json.Unmarshal(b.Bytes(), &report))
+	assert.Equal(t, driftscan.Failed, report.Outcome)
+	assert.Equal(t, driftscan.ExitFailed, report.ExitCode)
+	require.Len(t, report.Modules, 4)
+
+	network := report.Modules[0]
+	assert.Equal(t, driftscan.NoDrift, network.Outcome)
+	assert.NotNil(t, network.Drift, "An empty list should be [], not null")
+	assert.Empty(t, network.Drift)
+
+	assert.Equal(t, []driftscan.Change{{
+		Address: "module.aws_storage[0].aws_s3_bucket_versioning.this[0]",
+		Type:    "aws_s3_bucket_versioning",
+		Kind:    driftscan.Changed,
+	}}, report.Modules[2].Drift)
+	assert.Equal(t, []driftscan.Change{{
+		Address:      "module.aws_storage[0].aws_s3_bucket_versioning.this[0]",
+		Type:         "aws_s3_bucket_versioning",
+		Kind:         "replace",
+		Reason:       "replace_because_cannot_update",
+		ReplacePaths: []string{"expected_bucket_owner"},
+	}}, report.Modules[2].Changes)
+
+	compute := report.Modules[3]
+	assert.Contains(t, compute.Error, "Backend initialization required")
+	assert.Equal(t, "logs/compute/terraform.log", compute.Log)
+
+	assert.NotContains(t, b.String(), "scan-bucket", "The report should never copy attribute values")
+}
@@ -0,0 +1,234 @@
+package driftscan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"iac/testutil/eventually"
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// errorLines is how many of a failed command's last lines its error quotes
+// when none is a Terraform error
+const errorLines = 10
+
+// Terraform plans modules with a Terraform CLI. init and plan never take
+// the state lock, so a scan never blocks a deploy, and each module's
+// .terraform directory goes to a temporary TF_DATA_DIR rather than into
+// the module.
+type Terraform struct {
+	// Binary is the CLI, e.g. terraform or tofu
+	Binary string
+
+	// LogDir receives each module's output, redacted, in
+	// <module name>/terraform.log; empty discards it
+	LogDir string
+
+	// Retries says which failures are transient and how often a command
+	// failing with one is run again; the attempts back off from
+	// TimeBetweenRetries as eventually.Retry spaces them
+	Retries tfopts.RetryPolicy
+}
+
+// NewTerraform returns a Terraform that retries as tfopts.DefaultRetries
+// does for binary
+func NewTerraform(binary, logDir string) *Terraform {
+	return &Terraform{
+		Binary:  binary,
+		LogDir:  logDir,
+		Retries: tfopts.DefaultRetries(binary),
+	}
+}
+
+// LogPath returns where the output of the module named module is logged,
+// or "" when tf discards it
+func (tf *Terraform) LogPath(module string) string {
+	if tf.LogDir == "" {
+		return ""
+	}
+	return filepath.Join(tf.LogDir, tflog.ArtifactName(module), tflog.LogFile)
+}
+
+// Plan implements Planner: it inits module, then saves and shows a
+// refresh-only plan and a regular one. Sensitive values, of the variables
+// the module declares sensitive and of whatever the plans mark sensitive,
+// are redacted from the log and from the error.
+func (tf *Terraform) Plan(ctx context.Context, module Module) (*Plans, error) {
+	dataDir, err := os.MkdirTemp("", "driftscan-")
+	if err != nil {
+		return nil, fmt.Errorf("driftscan: %w", err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	r := &run{tf: tf, module: module, dataDir: dataDir}
+	secrets, err := tflog.SensitiveVars(module.Path, module.Vars)
+	if err != nil {
+		return nil, fmt.Errorf("driftscan: %s: %w", module.Name, err)
+	}
+	r.redactor = tflog.NewRedactor(secrets...)
+
+	plans, err := r.plans(ctx)
+	logErr := r.writeLog()
+	if err != nil {
+		return nil, errors.New(r.redactor.Redact(err.Error()))
+	}
+	if logErr != nil {
+		return nil, fmt.Errorf("driftscan: %s: %w", module.Name, logErr)
+	}
+	return plans, nil
+}
+
+// run is the planning of one module
+type run struct {
+	tf       *Terraform
+	module   Module
+	dataDir  string
+	redactor *tflog.Redactor
+
+	mu  sync.Mutex
+	log bytes.Buffer
+}
+
+func (r *run) plans(ctx context.Context) (*Plans, error) {
+	init := []string{"init", "-input=false", "-no-color", "-reconfigure", "-lock=false"}
+	if r.module.BackendConfig != "" {
+		backendConfig, err := filepath.Abs(r.module.BackendConfig)
+		if err != nil {
+			return nil, err
+		}
+		init = append(init, "-backend-config="+backendConfig)
+	}
+	if _, err := r.terraform(ctx, init...); err != nil {
+		return nil, err
+	}
+
+	refresh, err := r.plan(ctx, "refresh", "-refresh-only")
+	if err != nil {
+		return nil, err
+	}
+	plan, err := r.plan(ctx, "plan")
+	if err != nil {
+		return nil, err
+	}
+	return &Plans{Refresh: refresh, Plan: plan}, nil
+}
+
+// plan saves a plan with the extra flags and returns it as JSON
+func (r *run) plan(ctx context.Context, name string, flags ...string) ([]byte, error) {
+	planFile := filepath.Join(r.dataDir, name+".plan")
+	args := append([]string{"plan", "-input=false", "-no-color", "-lock=false", "-out=" + planFile}, flags...)
+	for _, file := range r.module.VarFiles {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-var-file="+abs)
+	}
+	args = append(args, terraform.FormatTerraformVarsAsArgs(r.module.Vars)...)
+	if _, err := r.terraform(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	planJSON, err := r.terraform(ctx, "show", "-json", planFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.redactor.AddPlan(planJSON); err != nil {
+		return nil, err
+	}
+	return planJSON, nil
+}
+
+// terraform runs the CLI in the module, retrying transient errors, and
+// returns its standard output. Everything it prints but the JSON of show
+// goes to the log.
+func (r *run) terraform(ctx context.Context, args ...string) ([]byte, error) {
+	retries := r.tf.Retries
+	retried := 0
+
+	stdout, err := eventually.Retry(ctx, retries.MaxRetries, retries.TimeBetweenRetries, func() ([]byte, error) {
+		r.logf("Running %s %s in %s", r.tf.Binary, strings.Join(args, " "), r.module.Path)
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, r.tf.Binary, append([]string{"-chdir=" + r.module.Path}, args...)...)
+		cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1", "TF_INPUT=0", "TF_DATA_DIR="+r.dataDir)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+
+		if args[0] != "show" {
+			r.logf("%s", stdout.String())
+		}
+		r.logf("%s", stderr.String())
+		if err == nil {
+			return stdout.Bytes(), nil
+		}
+
+		output := stdout.String() + stderr.String()
+		failure := fmt.Errorf("terraform %s in %s: %w\n%s", args[0], r.module.Name, err, summarize(output))
+		description := retries.Retryable(output)
+		if description == "" || retried >= retries.MaxRetries || ctx.Err() != nil {
+			return nil, eventually.Permanent(failure)
+		}
+		retried++
+		r.logf("Retrying terraform %s (%d of %d): %s", args[0], retried, retries.MaxRetries, description)
+		return nil, failure
+	})
+	if err != nil && err == ctx.Err() {
+		// Canceled while waiting to retry
+		return nil, fmt.Errorf("terraform %s in %s: %w", args[0], r.module.Name, err)
+	}
+	return stdout, err
+}
+
+// summarize returns the error lines of a failed command's output, or its
+// last lines when it printed no Terraform error
+func summarize(output string) string {
+	var diagnostics []string
+	tail := tflog.NewTail(errorLines)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if tflog.Classify(line) == tflog.Error {
+			diagnostics = append(diagnostics, tflog.Clean(line))
+		}
+		tail.Add(line)
+	}
+	if len(diagnostics) > 0 {
+		return strings.Join(diagnostics, "\n")
+	}
+	return strings.Join(tail.Lines(), "\n")
+}
+
+func (r *run) logf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	if msg != "" {
+		r.log.WriteString(msg + "\n")
+	}
+}
+
+// writeLog writes the log once the module is planned, so values the last
+// plan marks sensitive are redacted from the output of the first
+func (r *run) writeLog() error {
+	path := r.tf.LogPath(r.module.Name)
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return os.WriteFile(path, []byte(r.redactor.Redact(r.log.String())), 0o644)
+}
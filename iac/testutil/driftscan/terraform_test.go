@@ -0,0 +1,99 @@
+package driftscan_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"iac/testutil/driftscan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTerraform writes a terraform stand-in that fails its first init with
+// a retryable error, echoes the arguments of plan, which carry the -var
+// values, and shows testdata/changed.json for every plan. Its last
+// argument fails the command it names instead.
+func fakeTerraform(t *testing.T, fail string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake terraform is a shell script")
+	}
+	plan, err := filepath.Abs("testdata/changed.json")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "terraform")
+	script := `#!/bin/sh
+if [ "$2" = "` + fail + `" ]; then
+  echo "Planning with $*"
+  echo "Error: Invalid provider configuration" >&2
+  exit 1
+fi
+case "$2" in
+init)
+  if [ ! -f "` + dir + `/initialized" ]; then
+    touch "` + dir + `/initialized"
+    echo "Error: Failed to query available provider packages" >&2
+    exit 1
+  fi
+  echo "Terraform has been successfully initialized!" ;;
+plan) echo "Planning with $*" ;;
+show) cat "` + plan + `" ;;
+esac
+`
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o755))
+	return binary
+}
+
+// sensitiveModule returns a module with a sensitive variable set to secret
+func sensitiveModule(t *testing.T, secret string) driftscan.Module {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte(`variable "password" {
+  type      = string
+  sensitive = true
+}
+`), 0o644))
+	return driftscan.Module{Name: "storage/eu", Path: dir, Vars: map[string]interface{}{"password": secret}}
+}
+
+func TestTerraformPlan(t *testing.T) {
+	t.Parallel()
+
+	const secret = "hunter2-secret"
+	tf := driftscan.NewTerraform(fakeTerraform(t, ""), t.TempDir())
+	tf.Retries.TimeBetweenRetries = 0
+	module := sensitiveModule(t, secret)
+
+	plans, err := tf.Plan(context.Background(), module)
+	require.NoError(t, err)
+	assert.Equal(t, driftscan.InPlaceDrift, driftscan.NewResult(module.Name, plans).Outcome)
+
+	log, err := os.ReadFile(tf.LogPath(module.Name))
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "Retrying terraform init (1 of 3)", "The transient init failure should be retried")
+	assert.Contains(t, string(log), "-refresh-only")
+	assert.Contains(t, string(log), "-lock=false")
+	assert.Contains(t, string(log), "-var password=(redacted:")
+	assert.NotContains(t, string(log), secret)
+	assert.NotContains(t, string(log), `"resource_drift"`, "The plan JSON should not be logged")
+}
+
+func TestTerraformPlanFails(t *testing.T) {
+	t.Parallel()
+
+	const secret = "hunter2-secret"
+	tf := driftscan.NewTerraform(fakeTerraform(t, "plan"), "")
+	tf.Retries.TimeBetweenRetries = 0
+	module := sensitiveModule(t, secret)
+
+	_, err := tf.Plan(context.Background(), module)
+	require.Error(t, err)
+	assert.Equal(t, "terraform plan in storage/eu: exit status 1\nError: Invalid provider configuration", err.Error(),
+		"The error should quote the Terraform error, not the whole output")
+	assert.Empty(t, tf.LogPath(module.Name), "Without a LogDir nothing is logged")
+}
@@ -0,0 +1,112 @@
+// Package egress catches requests that would leave the machine while a
+// test runs against a local emulator. A Canary is an HTTP proxy that
+// forwards nothing: Terraform and its providers are pointed at it through
+// HTTP_PROXY and HTTPS_PROXY, so any request that is not for the emulator
+// (an endpoint override left out, an instance metadata lookup, a checkpoint
+// call) reaches the canary instead of the internet and is recorded:
+//
+//	canary := egress.Start(t)
+//	options.EnvVars = canary.EnvVars(cfg.CloudEmuEndpoint)
+//	tfopts.Apply(t, options)
+//	assert.Empty(t, canary.Hosts())
+//
+// Go never proxies localhost or loopback addresses, so requests to an
+// emulator on 127.0.0.1 go straight to it; an emulator on another host is
+// added to NO_PROXY. terraform init must run without the canary, as it
+// downloads providers from the registry.
+package egress
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Canary records the host of every request proxied through it and
+// refuses it
+type Canary struct {
+	// URL is the proxy's base URL
+	URL string
+
+	mu    sync.Mutex
+	hosts map[string]int
+}
+
+// Start runs a Canary on a local port until t finishes, and logs the hosts
+// it caught then
+func Start(t testing.TB) *Canary {
+	t.Helper()
+
+	c := &Canary{hosts: map[string]int{}}
+	server := httptest.NewServer(c)
+	c.URL = server.URL
+	t.Cleanup(func() {
+		server.Close()
+		if hosts := c.Hosts(); len(hosts) > 0 {
+			t.Logf("egress canary caught requests to %s", strings.Join(hosts, ", "))
+		}
+	})
+	return c
+}
+
+// ServeHTTP records the host r is for and answers 403. A CONNECT, which
+// an HTTPS request through a proxy starts with, names it in r.Host; a
+// plain HTTP request in its absolute URL.
+func (c *Canary) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if r.Method != http.MethodConnect && r.URL.Host != "" {
+		host = r.URL.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	c.mu.Lock()
+	c.hosts[host]++
+	c.mu.Unlock()
+
+	http.Error(w, "egress canary: requests must not leave for "+host, http.StatusForbidden)
+}
+
+// Hosts returns the hosts of the requests caught so far, sorted, each
+// once
+func (c *Canary) Hosts() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hosts := make([]string, 0, len(c.hosts))
+	for host := range c.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// EnvVars returns the environment that sends Terraform's requests through
+// the canary, except those to the hosts of allowed, which are base URLs
+// such as the emulator endpoint. Terraform's checkpoint call is turned off,
+// so a run that leaks nothing leaves the canary empty.
+func (c *Canary) EnvVars(allowed ...string) map[string]string {
+	noProxy := []string{"localhost", "127.0.0.1", "::1"}
+	for _, endpoint := range allowed {
+		if u, err := url.Parse(endpoint); err == nil && u.Hostname() != "" {
+			noProxy = append(noProxy, u.Hostname())
+		}
+	}
+
+	// Lower case names are read first by some tools and are the only ones
+	// curl honours for http
+	env := map[string]string{"CHECKPOINT_DISABLE": "1"}
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		env[name] = c.URL
+	}
+	for _, name := range []string{"NO_PROXY", "no_proxy"} {
+		env[name] = strings.Join(noProxy, ",")
+	}
+	return env
+}
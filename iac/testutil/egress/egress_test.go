@@ -0,0 +1,60 @@
+package egress_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"iac/testutil/egress"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// client sends every request through the canary, as HTTP_PROXY would
+func client(t *testing.T, canary *egress.Canary) *http.Client {
+	proxy, err := url.Parse(canary.URL)
+	require.NoError(t, err)
+	return &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxy)}}
+}
+
+func TestCanaryCatchesRequests(t *testing.T) {
+	t.Parallel()
+
+	canary := egress.Start(t)
+	c := client(t, canary)
+
+	resp, err := c.Get("http://169.254.169.254/latest/meta-data/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "Plain HTTP should be refused")
+
+	for i := 0; i < 2; i++ {
+		_, err = c.Get("https://sts.amazonaws.com/")
+		assert.Error(t, err, "The CONNECT an HTTPS request starts with should be refused")
+	}
+
+	assert.Equal(t, []string{"169.254.169.254", "sts.amazonaws.com"}, canary.Hosts(),
+		"Each host should be listed once, without its port")
+}
+
+func TestCanaryEmpty(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, egress.Start(t).Hosts())
+}
+
+func TestEnvVars(t *testing.T) {
+	t.Parallel()
+
+	canary := egress.Start(t)
+	env := canary.EnvVars("http://cloudemu.ci.internal:4566", "not a url")
+
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		assert.Equal(t, canary.URL, env[name], name)
+	}
+	assert.Equal(t, "localhost,127.0.0.1,::1,cloudemu.ci.internal", env["NO_PROXY"],
+		"An emulator on another host should not be proxied")
+	assert.Equal(t, env["NO_PROXY"], env["no_proxy"])
+	assert.Equal(t, "1", env["CHECKPOINT_DISABLE"])
+}
@@ -0,0 +1,155 @@
+// Package emulatorprovider copies the shared AWS provider configuration in
+// common/emulator-provider into each configuration that runs against a
+// local emulator, as emulator_provider.tf.
+//
+// A module cannot configure its caller's provider and an override file
+// cannot add a block, so the one provider block is kept in one place and
+// generated into its consumers instead. A consumer is any directory with an
+// emulator_provider.tf; it declares the variables the block reads
+// (emulator_endpoint and aws_region) with defaults that suit its emulator.
+//
+// tools/emulatorprovider writes the copies; TestEmulatorProvider fails when
+// one no longer matches the source or its directory misses a variable.
+package emulatorprovider
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"iac/testutil/modules"
+	"iac/testutil/varcheck"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Source is the shared provider block, relative to the repository root
+var Source = filepath.Join("common", "emulator-provider", "provider.tf")
+
+// FileName is the generated copy in each consumer
+const FileName = "emulator_provider.tf"
+
+// Header opens every copy
+const Header = "# Generated by go run ./tools/emulatorprovider from common/emulator-provider/provider.tf. DO NOT EDIT."
+
+// Render returns the copy of the source file at path, with CRLF line
+// endings written as LF
+func Render(path string) ([]byte, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src = bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+	return append([]byte(Header+"\n\n"), src...), nil
+}
+
+// Consumers returns the directories under root with an emulator_provider.tf,
+// sorted
+func Consumers(root string) ([]string, error) {
+	dirs, err := modules.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+	var consumers []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, FileName)); err == nil {
+			consumers = append(consumers, dir)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return consumers, nil
+}
+
+// Stale compares the emulator_provider.tf in dir with generated, ignoring
+// CRLF line endings, and returns a unified diff from one to the other, or
+// "" when they match. A missing file is stale.
+func Stale(dir string, generated []byte) (string, error) {
+	path := filepath.Join(dir, FileName)
+	committed, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	committed = bytes.ReplaceAll(committed, []byte("\r\n"), []byte("\n"))
+	if bytes.Equal(committed, generated) {
+		return "", nil
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(committed)),
+		B:        difflib.SplitLines(string(generated)),
+		FromFile: filepath.ToSlash(path),
+		ToFile:   "generated",
+		Context:  3,
+	})
+}
+
+// Missing returns the variables the source file at path reads that dir
+// does not declare, sorted
+func Missing(path, dir string) ([]string, error) {
+	reads, err := variables(path)
+	if err != nil {
+		return nil, err
+	}
+	declared, err := varcheck.Inspect(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	have := map[string]bool{}
+	for _, v := range declared {
+		have[v.Name] = true
+	}
+	var missing []string
+	for _, name := range reads {
+		if !have[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// variables returns the names of the variables the file at path reads,
+// sorted
+func variables(path string) ([]string, error) {
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("emulatorprovider: %s", diags.Error())
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("emulatorprovider: %s is not native HCL syntax", path)
+	}
+
+	seen := map[string]bool{}
+	diags = hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		expr, ok := node.(hclsyntax.Expression)
+		if !ok {
+			return nil
+		}
+		for _, traversal := range expr.Variables() {
+			if traversal.RootName() != "var" || len(traversal) < 2 {
+				continue
+			}
+			if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+				seen[attr.Name] = true
+			}
+		}
+		return nil
+	})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("emulatorprovider: %s", diags.Error())
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,80 @@
+package emulatorprovider_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/emulatorprovider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var source = filepath.Join("testdata", "source", "provider.tf")
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	got, err := emulatorprovider.Render(source)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(string(got), emulatorprovider.Header+"\n\nprovider \"aws\" {\n"))
+	assert.NotContains(t, string(got), "\r", "A copy should not depend on the source's line endings")
+
+	_, err = emulatorprovider.Render(filepath.Join("testdata", "missing.tf"))
+	assert.Error(t, err)
+}
+
+func TestConsumers(t *testing.T) {
+	t.Parallel()
+
+	dirs, err := emulatorprovider.Consumers(filepath.Join("testdata", "root"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("testdata", "root", "app"),
+		filepath.Join("testdata", "root", "partial"),
+	}, dirs, "Only directories with a copy are consumers")
+}
+
+func TestStale(t *testing.T) {
+	t.Parallel()
+
+	generated, err := emulatorprovider.Render(source)
+	require.NoError(t, err)
+
+	diff, err := emulatorprovider.Stale(filepath.Join("testdata", "root", "app"), generated)
+	require.NoError(t, err)
+	assert.Empty(t, diff, "A current copy with CRLF line endings is not stale")
+
+	diff, err = emulatorprovider.Stale(filepath.Join("testdata", "root", "partial"), generated)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+"+emulatorprovider.Header, "A copy edited by hand is stale")
+
+	dir := t.TempDir()
+	diff, err = emulatorprovider.Stale(dir, generated)
+	require.NoError(t, err)
+	assert.Contains(t, diff, `+provider "aws" {`, "A missing file is stale")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, emulatorprovider.FileName), generated, 0o644))
+	diff, err = emulatorprovider.Stale(dir, generated)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+func TestMissing(t *testing.T) {
+	t.Parallel()
+
+	missing, err := emulatorprovider.Missing(source, filepath.Join("testdata", "root", "app"))
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	missing, err = emulatorprovider.Missing(source, filepath.Join("testdata", "root", "partial"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aws_region"}, missing)
+
+	missing, err = emulatorprovider.Missing(source, filepath.Join("testdata", "root", "other"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aws_region", "emulator_endpoint"}, missing, "Variables should be listed sorted")
+}
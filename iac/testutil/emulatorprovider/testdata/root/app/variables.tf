@@ -0,0 +1,11 @@
+variable "emulator_endpoint" {
+  description = "Emulator endpoint URL"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region"
+  type        = string
+  default     = "us-east-1"
+}
@@ -0,0 +1 @@
+resource "null_resource" "this" {}
@@ -0,0 +1,5 @@
+variable "emulator_endpoint" {
+  description = "Emulator endpoint URL"
+  type        = string
+  default     = "http://localhost:8080"
+}
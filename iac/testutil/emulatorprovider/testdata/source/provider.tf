@@ -0,0 +1,11 @@
+provider "aws" {
+  region = var.aws_region
+
+  endpoints {
+    s3  = var.emulator_endpoint
+    sts = var.emulator_endpoint
+  }
+
+  access_key = "test"
+  secret_key = "test"
+}
@@ -0,0 +1,198 @@
+package emureset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"iac/testutil/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// AWS returns the configured CloudEmu endpoint with its S3 buckets,
+// DynamoDB tables, SQS queues, SNS topics and Lambda functions, using the
+// credentials the AWS integration tests use. It can be passed to Main as
+// is.
+func AWS(_ context.Context, cfg *config.TestConfig) (*Emulator, error) {
+	sess, err := cfg.AWSSession(cfg.CloudEmuEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("emureset: %w", err)
+	}
+	return &Emulator{Endpoint: cfg.CloudEmuEndpoint, Services: AWSServices(sess)}, nil
+}
+
+// AWSServices are the AWS services purged through sess
+func AWSServices(sess *session.Session) []Service {
+	return []Service{
+		&s3Buckets{client: s3.New(sess)},
+		&dynamoTables{client: dynamodb.New(sess)},
+		&sqsQueues{client: sqs.New(sess)},
+		&snsTopics{client: sns.New(sess)},
+		&lambdaFunctions{client: lambda.New(sess)},
+	}
+}
+
+type s3Buckets struct{ client *s3.S3 }
+
+func (s *s3Buckets) Kind() string { return "s3 bucket" }
+
+func (s *s3Buckets) List(ctx context.Context) ([]Resource, error) {
+	out, err := s.client.ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+	var resources []Resource
+	for _, b := range out.Buckets {
+		resources = append(resources, named(aws.StringValue(b.Name)))
+	}
+	return resources, nil
+}
+
+// Delete empties the bucket first; S3 refuses to delete one with objects
+func (s *s3Buckets) Delete(ctx context.Context, r Resource) error {
+	var keys []*string
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{Bucket: aws.String(r.ID)},
+		func(page *s3.ListObjectsV2Output, _ bool) bool {
+			for _, o := range page.Contents {
+				keys = append(keys, o.Key)
+			}
+			return true
+		})
+	if err != nil {
+		return ignoreNotFound(err)
+	}
+	for _, key := range keys {
+		_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{Bucket: aws.String(r.ID), Key: key})
+		if err != nil {
+			return fmt.Errorf("object %s: %w", aws.StringValue(key), err)
+		}
+	}
+	_, err = s.client.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{Bucket: aws.String(r.ID)})
+	return ignoreNotFound(err)
+}
+
+type dynamoTables struct{ client *dynamodb.DynamoDB }
+
+func (d *dynamoTables) Kind() string { return "dynamodb table" }
+
+func (d *dynamoTables) List(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	err := d.client.ListTablesPagesWithContext(ctx, &dynamodb.ListTablesInput{},
+		func(page *dynamodb.ListTablesOutput, _ bool) bool {
+			for _, name := range page.TableNames {
+				resources = append(resources, named(aws.StringValue(name)))
+			}
+			return true
+		})
+	return resources, err
+}
+
+func (d *dynamoTables) Delete(ctx context.Context, r Resource) error {
+	_, err := d.client.DeleteTableWithContext(ctx, &dynamodb.DeleteTableInput{TableName: aws.String(r.ID)})
+	return ignoreNotFound(err)
+}
+
+type sqsQueues struct{ client *sqs.SQS }
+
+func (q *sqsQueues) Kind() string { return "sqs queue" }
+
+// List names queues by the last segment of their URL and deletes by URL
+func (q *sqsQueues) List(ctx context.Context) ([]Resource, error) {
+	out, err := q.client.ListQueuesWithContext(ctx, &sqs.ListQueuesInput{})
+	if err != nil {
+		return nil, err
+	}
+	var resources []Resource
+	for _, url := range out.QueueUrls {
+		u := aws.StringValue(url)
+		resources = append(resources, Resource{Name: path.Base(u), ID: u})
+	}
+	return resources, nil
+}
+
+func (q *sqsQueues) Delete(ctx context.Context, r Resource) error {
+	_, err := q.client.DeleteQueueWithContext(ctx, &sqs.DeleteQueueInput{QueueUrl: aws.String(r.ID)})
+	return ignoreNotFound(err)
+}
+
+type snsTopics struct{ client *sns.SNS }
+
+func (t *snsTopics) Kind() string { return "sns topic" }
+
+// List names topics by the last field of their ARN and deletes by ARN
+func (t *snsTopics) List(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	err := t.client.ListTopicsPagesWithContext(ctx, &sns.ListTopicsInput{},
+		func(page *sns.ListTopicsOutput, _ bool) bool {
+			for _, topic := range page.Topics {
+				arn := aws.StringValue(topic.TopicArn)
+				resources = append(resources, Resource{Name: arn[strings.LastIndex(arn, ":")+1:], ID: arn})
+			}
+			return true
+		})
+	return resources, err
+}
+
+func (t *snsTopics) Delete(ctx context.Context, r Resource) error {
+	_, err := t.client.DeleteTopicWithContext(ctx, &sns.DeleteTopicInput{TopicArn: aws.String(r.ID)})
+	return ignoreNotFound(err)
+}
+
+type lambdaFunctions struct{ client *lambda.Lambda }
+
+func (l *lambdaFunctions) Kind() string { return "lambda function" }
+
+func (l *lambdaFunctions) List(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	err := l.client.ListFunctionsPagesWithContext(ctx, &lambda.ListFunctionsInput{},
+		func(page *lambda.ListFunctionsOutput, _ bool) bool {
+			for _, fn := range page.Functions {
+				resources = append(resources, named(aws.StringValue(fn.FunctionName)))
+			}
+			return true
+		})
+	return resources, err
+}
+
+func (l *lambdaFunctions) Delete(ctx context.Context, r Resource) error {
+	_, err := l.client.DeleteFunctionWithContext(ctx, &lambda.DeleteFunctionInput{FunctionName: aws.String(r.ID)})
+	return ignoreNotFound(err)
+}
+
+func named(name string) Resource {
+	return Resource{Name: name, ID: name}
+}
+
+// notFoundCodes are the error codes services answer a missing resource
+// with, often alongside a 400 rather than a 404. Lambda shares DynamoDB's.
+var notFoundCodes = map[string]bool{
+	s3.ErrCodeNoSuchBucket:                    true,
+	dynamodb.ErrCodeResourceNotFoundException: true,
+	sqs.ErrCodeQueueDoesNotExist:              true,
+	sns.ErrCodeNotFoundException:              true,
+}
+
+// ignoreNotFound treats a resource deleted since it was listed, such as by
+// a test's own destroy, as deleted
+func ignoreNotFound(err error) error {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.StatusCode() == http.StatusNotFound {
+		return nil
+	}
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && notFoundCodes[awsErr.Code()] {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package emureset_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/config"
+	"iac/testutil/emureset"
+	"iac/testutil/objectstore"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPurgeCloudEmu seeds CloudEmu with test-named and foreign-named
+// buckets and queues and checks only the test-named ones are purged. The
+// purge is limited to this run's names, so other packages testing against
+// the same emulator are not disturbed.
+func TestPurgeCloudEmu(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Load(t)
+	objectstore.SkipUnlessRunning(t, cfg, "aws")
+	ctx := context.Background()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:           aws.String(cfg.Region),
+		Endpoint:         aws.String(cfg.CloudEmuEndpoint),
+		Credentials:      credentials.NewStaticCredentials("test", "test", ""),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	require.NoError(t, err)
+	s3Client, sqsClient := s3.New(sess), sqs.New(sess)
+
+	run := fmt.Sprintf("%d", time.Now().UnixNano())
+	testBucket, foreignBucket := "test-bucket-"+run, "keep-bucket-"+run
+	testQueue, foreignQueue := "test-queue-"+run, "keep-queue-"+run
+
+	for _, bucket := range []string{testBucket, foreignBucket} {
+		_, err := s3Client.CreateBucketWithContext(ctx, &s3.CreateBucketInput{Bucket: aws.String(bucket)})
+		require.NoError(t, err)
+	}
+	_, err = s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(testBucket),
+		Key:    aws.String("leftover.txt"),
+		Body:   strings.NewReader("left by a crashed run"),
+	})
+	require.NoError(t, err)
+
+	var foreignQueueURL string
+	for _, queue := range []string{testQueue, foreignQueue} {
+		out, err := sqsClient.CreateQueueWithContext(ctx, &sqs.CreateQueueInput{QueueName: aws.String(queue)})
+		require.NoError(t, err)
+		if queue == foreignQueue {
+			foreignQueueURL = aws.StringValue(out.QueueUrl)
+		}
+	}
+	defer func() {
+		s3Client.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{Bucket: aws.String(foreignBucket)})
+		sqsClient.DeleteQueueWithContext(ctx, &sqs.DeleteQueueInput{QueueUrl: aws.String(foreignQueueURL)})
+	}()
+
+	emu, err := emureset.AWS(ctx, cfg)
+	require.NoError(t, err)
+
+	thisRun := func(name string) bool {
+		return strings.HasSuffix(name, run) && emureset.IsTestResource(name)
+	}
+	deleted, err := emureset.Purge(ctx, emu.Services, thisRun, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s3 bucket " + testBucket, "sqs queue " + testQueue}, deleted)
+
+	buckets, err := s3Client.ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
+	require.NoError(t, err)
+	var bucketNames []string
+	for _, b := range buckets.Buckets {
+		bucketNames = append(bucketNames, aws.StringValue(b.Name))
+	}
+	assert.Contains(t, bucketNames, foreignBucket)
+	assert.NotContains(t, bucketNames, testBucket)
+
+	queues, err := sqsClient.ListQueuesWithContext(ctx, &sqs.ListQueuesInput{})
+	require.NoError(t, err)
+	queueURLs := strings.Join(aws.StringValueSlice(queues.QueueUrls), " ")
+	assert.Contains(t, queueURLs, foreignQueue)
+	assert.NotContains(t, queueURLs, testQueue)
+}
@@ -0,0 +1,115 @@
+package emureset
+
+import (
+	"context"
+	"fmt"
+
+	"iac/azure/azurehelpers"
+	"iac/testutil/config"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+// Azure returns the configured Azure endpoint with its blob containers and
+// Cosmos DB databases, using the emulator's well-known keys. It can be
+// passed to Main as is.
+//
+// Service Bus queues are not purged: CloudEmu only serves them over the
+// HTTP protocol azurehelpers drives, which cannot list queues.
+func Azure(_ context.Context, cfg *config.TestConfig) (*Emulator, error) {
+	emu := azurehelpers.EmulatorConfig(cfg.AzureEndpoint)
+
+	blobCred, err := azblob.NewSharedKeyCredential(emu.AccountName, emu.AccountKey)
+	if err != nil {
+		return nil, fmt.Errorf("emureset: blob credential: %w", err)
+	}
+	blobClient, err := azblob.NewClientWithSharedKeyCredential(emu.BlobEndpoint+"/", blobCred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("emureset: blob client for %s: %w", emu.BlobEndpoint, err)
+	}
+
+	cosmosCred, err := azcosmos.NewKeyCredential(emu.CosmosKey)
+	if err != nil {
+		return nil, fmt.Errorf("emureset: cosmos credential: %w", err)
+	}
+	cosmosClient, err := azcosmos.NewClientWithKey(emu.CosmosEndpoint, cosmosCred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("emureset: cosmos client for %s: %w", emu.CosmosEndpoint, err)
+	}
+
+	return &Emulator{Endpoint: cfg.AzureEndpoint, Services: AzureServices(blobClient, cosmosClient)}, nil
+}
+
+// AzureServices are the Azure services purged through the clients. Either
+// may be nil to leave its service out.
+func AzureServices(blob *azblob.Client, cosmos *azcosmos.Client) []Service {
+	var services []Service
+	if blob != nil {
+		services = append(services, &blobContainers{client: blob})
+	}
+	if cosmos != nil {
+		services = append(services, &cosmosDatabases{client: cosmos})
+	}
+	return services
+}
+
+type blobContainers struct{ client *azblob.Client }
+
+func (b *blobContainers) Kind() string { return "blob container" }
+
+func (b *blobContainers) List(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	pager := b.client.NewListContainersPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range page.ContainerItems {
+			if c.Name != nil {
+				resources = append(resources, named(*c.Name))
+			}
+		}
+	}
+	return resources, nil
+}
+
+// Delete removes the container with its blobs
+func (b *blobContainers) Delete(ctx context.Context, r Resource) error {
+	_, err := b.client.DeleteContainer(ctx, r.ID, nil)
+	if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+		return nil
+	}
+	return err
+}
+
+type cosmosDatabases struct{ client *azcosmos.Client }
+
+func (c *cosmosDatabases) Kind() string { return "cosmos database" }
+
+func (c *cosmosDatabases) List(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	pager := c.client.NewQueryDatabasesPager("SELECT * FROM dbs", nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, db := range page.Databases {
+			resources = append(resources, named(db.ID))
+		}
+	}
+	return resources, nil
+}
+
+// Delete removes the database with its containers and items
+func (c *cosmosDatabases) Delete(ctx context.Context, r Resource) error {
+	db, err := c.client.NewDatabase(r.ID)
+	if err != nil {
+		return err
+	}
+	_, err = db.Delete(ctx, nil)
+	return err
+}
@@ -0,0 +1,281 @@
+// Package emureset clears an emulator of the resources earlier test runs
+// left behind, so a bucket or queue from a crashed run cannot make a
+// list-based assertion pass or fail.
+//
+// CloudEmu keeps its state across restarts and has no route to reset it, so
+// Reset falls back to purging through the cloud SDKs: every bucket, table,
+// queue, topic and function whose name an integration test would have
+// given it is deleted, and nothing else.
+package emureset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"iac/testutil/concurrency"
+	"iac/testutil/config"
+)
+
+// Prefixes start the names integration tests give the resources they
+// create ("test-bucket-1700000000", "fullstack-queue-1700000000")
+var Prefixes = []string{
+	"test-",
+	"adopted-",
+	"canary-fn-",
+	"compat-",
+	"drift-",
+	"equiv-",
+	"fullstack-",
+	"function-url-fn-",
+	"multi-region-",
+	"objectstore-",
+	"source-dir-fn-",
+	"stress-",
+	"upgrade-",
+	"zero-test-",
+}
+
+// runStamp matches the Unix time tests append to their names
+var runStamp = regexp.MustCompile(`[0-9]{9,}`)
+
+// IsTestResource reports whether name is one an integration test would
+// have created: it starts with one of Prefixes and carries a Unix time
+// stamp. A "test-data" bucket someone made by hand does not match.
+func IsTestResource(name string) bool {
+	for _, prefix := range Prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return runStamp.MatchString(name[len(prefix):])
+		}
+	}
+	return false
+}
+
+// Resource is one resource a Service lists
+type Resource struct {
+	// Name is what IsTestResource matches against
+	Name string
+
+	// ID is what the service deletes by: a queue URL, a topic ARN, or the
+	// name again
+	ID string
+}
+
+// Service lists and deletes one kind of emulator resource
+type Service interface {
+	// Kind names the resources, as in "s3 bucket"
+	Kind() string
+	List(ctx context.Context) ([]Resource, error)
+
+	// Delete removes the resource and anything it holds, such as a
+	// bucket's objects
+	Delete(ctx context.Context, r Resource) error
+}
+
+// DefaultParallel is how many deletions Main runs at once
+const DefaultParallel = 8
+
+// Purge deletes the resources of services whose names match, running at
+// most parallel deletions at once. It returns the deleted resources as
+// "<kind> <name>", sorted. A service that cannot be listed or a resource
+// that cannot be deleted does not stop the others; their errors are
+// joined.
+func Purge(ctx context.Context, services []Service, match func(name string) bool, parallel int) ([]string, error) {
+	if parallel < 1 {
+		return nil, fmt.Errorf("emureset: parallel must be at least 1, got %d", parallel)
+	}
+	sem := concurrency.NewSemaphore(int64(parallel))
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		deleted []string
+		errs    []error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+
+	for _, svc := range services {
+		resources, err := svc.List(ctx)
+		if err != nil {
+			fail(fmt.Errorf("emureset: listing %ss: %w", svc.Kind(), err))
+			continue
+		}
+		for _, r := range resources {
+			if !match(r.Name) {
+				continue
+			}
+			if err := sem.Acquire(ctx, 1); err != nil {
+				fail(fmt.Errorf("emureset: deleting %s %s: %w", svc.Kind(), r.Name, err))
+				continue
+			}
+
+			wg.Add(1)
+			go func(svc Service, r Resource) {
+				defer wg.Done()
+				defer sem.Release(1)
+
+				if err := svc.Delete(ctx, r); err != nil {
+					fail(fmt.Errorf("emureset: deleting %s %s: %w", svc.Kind(), r.Name, err))
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				deleted = append(deleted, svc.Kind()+" "+r.Name)
+			}(svc, r)
+		}
+	}
+	wg.Wait()
+
+	sort.Strings(deleted)
+	return deleted, errors.Join(errs...)
+}
+
+// ResetPath is the LocalStack state reset route. CloudEmu answers
+// LocalStack's health route but not this one; Reset still asks first so an
+// emulator that can reset itself is not purged resource by resource.
+const ResetPath = "/_localstack/state/reset"
+
+// Reset clears the emulator at endpoint. It asks the emulator to drop all
+// of its state and, when the emulator answers with anything but success,
+// purges the test resources of services instead. It returns what it
+// purged, which is nothing when the emulator reset itself.
+func Reset(ctx context.Context, endpoint string, services []Service, parallel int) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+ResetPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("emureset: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("emureset: resetting %s: %w", endpoint, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil, nil
+	}
+	return Purge(ctx, services, IsTestResource, parallel)
+}
+
+// Emulator is what an integration package resets
+type Emulator struct {
+	// Endpoint is the emulator's base URL
+	Endpoint string
+	Services []Service
+
+	// Close, if set, releases the services' clients
+	Close func() error
+}
+
+// Timeout bounds the reset Main runs
+const Timeout = 5 * time.Minute
+
+// EnvResetEmulator asks Main to reset the emulator before the tests run
+const EnvResetEmulator = "SWE_TEST_RESET_EMULATOR"
+
+// Options configures Main
+type Options struct {
+	// Reset empties the emulator before the package's tests run
+	Reset bool
+}
+
+// LoadOptions reads Options from SWE_TEST_RESET_EMULATOR
+func LoadOptions() (Options, error) {
+	reset, err := config.BoolFromEnv(EnvResetEmulator)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{Reset: reset}, nil
+}
+
+// Main runs the tests of an integration package, first resetting the
+// emulator connect returns when SWE_TEST_RESET_EMULATOR is set. Use it
+// from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(emureset.Main(m, connectEmulator))
+//	}
+//
+// An emulator that does not answer at all is left alone, since the
+// package's tests skip without it. A failed reset fails the package
+// without running its tests.
+func Main(m *testing.M, connect func(ctx context.Context, cfg *config.TestConfig) (*Emulator, error)) int {
+	opts, err := LoadOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !opts.Reset {
+		return m.Run()
+	}
+
+	cfg, err := config.LoadTestConfig()
+	if err != nil {
+		// A bad config fails each test through config.Load instead
+		return m.Run()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	if err := run(ctx, cfg, connect); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return m.Run()
+}
+
+func run(ctx context.Context, cfg *config.TestConfig, connect func(ctx context.Context, cfg *config.TestConfig) (*Emulator, error)) error {
+	emu, err := connect(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("emureset: connecting: %w", err)
+	}
+	if emu.Close != nil {
+		defer emu.Close()
+	}
+
+	if !answers(ctx, emu.Endpoint) {
+		fmt.Fprintf(os.Stderr, "emureset: %s is not running, not resetting it\n", emu.Endpoint)
+		return nil
+	}
+
+	purged, err := Reset(ctx, emu.Endpoint, emu.Services, DefaultParallel)
+	for _, name := range purged {
+		fmt.Fprintf(os.Stderr, "emureset: deleted %s\n", name)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "emureset: reset %s, %d leftover resources deleted\n", emu.Endpoint, len(purged))
+	return nil
+}
+
+// answers reports whether anything serves HTTP at endpoint. Each emulator
+// has its own health check in its integration package; any response is
+// enough to know a reset can reach it.
+func answers(ctx context.Context, endpoint string) bool {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
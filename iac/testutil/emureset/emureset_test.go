@@ -0,0 +1,236 @@
+package emureset_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iac/testutil/emureset"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeService is an in-memory Service that records how many deletions run
+// at once
+type fakeService struct {
+	kind  string
+	delay time.Duration
+
+	listErr   error
+	deleteErr map[string]error
+
+	mu    sync.Mutex
+	names map[string]bool
+
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func newFakeService(kind string, names ...string) *fakeService {
+	f := &fakeService{kind: kind, names: map[string]bool{}}
+	for _, name := range names {
+		f.names[name] = true
+	}
+	return f
+}
+
+func (f *fakeService) Kind() string { return f.kind }
+
+func (f *fakeService) List(context.Context) ([]emureset.Resource, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var resources []emureset.Resource
+	for name := range f.names {
+		resources = append(resources, emureset.Resource{Name: name, ID: "id-" + name})
+	}
+	return resources, nil
+}
+
+func (f *fakeService) Delete(_ context.Context, r emureset.Resource) error {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		max := f.maxInFlight.Load()
+		if n <= max || f.maxInFlight.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(f.delay)
+
+	name := r.ID[len("id-"):]
+	if err := f.deleteErr[name]; err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.names, name)
+	return nil
+}
+
+func (f *fakeService) remaining() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var names []string
+	for name := range f.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestIsTestResource(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"test-bucket-1700000000":               true,
+		"fullstack-queue-1700000000":           true,
+		"multi-region-1700000000-us-west-2":    true,
+		"equiv-aws-1700000000":                 true,
+		"test-gcp-bucket-1700000000-test-1700": true,
+		"test-bucket-1700000000123456789":      true,
+		"test-data":                            false,
+		"test-bucket-manual":                   false,
+		"test-bucket-42":                       false,
+		"prod-assets-1700000000":               false,
+		"my-test-bucket-1700000000":            false,
+		"TEST-BUCKET-1700000000":               false,
+		"":                                     false,
+	}
+
+	for name, want := range tests {
+		assert.Equal(t, want, emureset.IsTestResource(name), "%q", name)
+	}
+}
+
+func TestPurgeOnlyDeletesTestResources(t *testing.T) {
+	t.Parallel()
+
+	buckets := newFakeService("bucket", "test-bucket-1700000000", "drift-bucket-1700000001", "prod-assets", "test-data")
+	queues := newFakeService("queue", "fullstack-queue-1700000000", "orders-1700000000")
+
+	deleted, err := emureset.Purge(context.Background(), []emureset.Service{buckets, queues}, emureset.IsTestResource, 4)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"bucket drift-bucket-1700000001", "bucket test-bucket-1700000000", "queue fullstack-queue-1700000000"}, deleted)
+	assert.Equal(t, []string{"prod-assets", "test-data"}, buckets.remaining())
+	assert.Equal(t, []string{"orders-1700000000"}, queues.remaining())
+}
+
+func TestPurgeBoundsParallelism(t *testing.T) {
+	t.Parallel()
+
+	svc := newFakeService("bucket")
+	for i := 0; i < 12; i++ {
+		svc.names[fmt.Sprintf("test-bucket-%d", 1700000000+i)] = true
+	}
+	svc.delay = 20 * time.Millisecond
+
+	deleted, err := emureset.Purge(context.Background(), []emureset.Service{svc}, emureset.IsTestResource, 3)
+	require.NoError(t, err)
+
+	assert.Len(t, deleted, 12)
+	assert.Empty(t, svc.remaining())
+	assert.Equal(t, int32(3), svc.maxInFlight.Load(), "Deletions should run concurrently, at most 3 at once")
+}
+
+func TestPurgeContinuesPastErrors(t *testing.T) {
+	t.Parallel()
+
+	broken := newFakeService("table")
+	broken.listErr = errors.New("connection refused")
+
+	buckets := newFakeService("bucket", "test-bucket-1700000000", "test-bucket-1700000001")
+	buckets.deleteErr = map[string]error{"test-bucket-1700000000": errors.New("BucketNotEmpty")}
+
+	deleted, err := emureset.Purge(context.Background(), []emureset.Service{broken, buckets}, emureset.IsTestResource, 2)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "listing tables: connection refused")
+	assert.Contains(t, err.Error(), "deleting bucket test-bucket-1700000000: BucketNotEmpty")
+	assert.Equal(t, []string{"bucket test-bucket-1700000001"}, deleted, "Other resources should still be deleted")
+}
+
+func TestPurgeRejectsZeroParallel(t *testing.T) {
+	t.Parallel()
+
+	_, err := emureset.Purge(context.Background(), nil, emureset.IsTestResource, 0)
+	assert.Error(t, err)
+}
+
+func TestResetUsesResetRoute(t *testing.T) {
+	t.Parallel()
+
+	var resets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == emureset.ResetPath {
+			resets.Add(1)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	svc := newFakeService("bucket", "test-bucket-1700000000")
+	purged, err := emureset.Reset(context.Background(), server.URL+"/", []emureset.Service{svc}, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), resets.Load())
+	assert.Empty(t, purged)
+	assert.Equal(t, []string{"test-bucket-1700000000"}, svc.remaining(), "An emulator that reset itself should not be purged")
+}
+
+func TestResetFallsBackToPurge(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	svc := newFakeService("bucket", "test-bucket-1700000000", "prod-assets")
+	purged, err := emureset.Reset(context.Background(), server.URL, []emureset.Service{svc}, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"bucket test-bucket-1700000000"}, purged)
+	assert.Equal(t, []string{"prod-assets"}, svc.remaining())
+}
+
+func TestResetUnreachable(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	svc := newFakeService("bucket", "test-bucket-1700000000")
+	_, err := emureset.Reset(context.Background(), server.URL, []emureset.Service{svc}, 2)
+	require.Error(t, err)
+	assert.Equal(t, []string{"test-bucket-1700000000"}, svc.remaining())
+}
+
+func TestLoadOptions(t *testing.T) {
+	t.Setenv(emureset.EnvResetEmulator, "")
+	opts, err := emureset.LoadOptions()
+	require.NoError(t, err)
+	assert.False(t, opts.Reset, "Emulators should only be reset when asked for")
+
+	t.Setenv(emureset.EnvResetEmulator, "1")
+	opts, err = emureset.LoadOptions()
+	require.NoError(t, err)
+	assert.True(t, opts.Reset)
+
+	t.Setenv(emureset.EnvResetEmulator, "yes")
+	_, err = emureset.LoadOptions()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SWE_TEST_RESET_EMULATOR")
+}
@@ -0,0 +1,159 @@
+package emureset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"iac/testutil/config"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// GCP returns the configured GCP endpoint with the Cloud Storage buckets
+// and Pub/Sub topics and subscriptions of project, connecting as
+// gcphelpers does. Close releases both clients.
+func GCP(ctx context.Context, cfg *config.TestConfig, project string) (*Emulator, error) {
+	endpoint := strings.TrimRight(cfg.GCPEndpoint, "/")
+
+	storageClient, err := storage.NewClient(ctx,
+		option.WithEndpoint(endpoint+"/storage/v1/"),
+		option.WithoutAuthentication(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("emureset: storage client for %s: %w", endpoint, err)
+	}
+
+	pubsubClient, err := pubsub.NewClient(ctx, project,
+		option.WithEndpoint(strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://")),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		storageClient.Close()
+		return nil, fmt.Errorf("emureset: pubsub client for %s: %w", endpoint, err)
+	}
+
+	return &Emulator{
+		Endpoint: cfg.GCPEndpoint,
+		Services: GCPServices(project, storageClient, pubsubClient),
+		Close: func() error {
+			return errors.Join(storageClient.Close(), pubsubClient.Close())
+		},
+	}, nil
+}
+
+// GCPServices are the GCP services of project purged through the clients.
+// Either may be nil to leave its services out.
+func GCPServices(project string, storageClient *storage.Client, pubsubClient *pubsub.Client) []Service {
+	var services []Service
+	if storageClient != nil {
+		services = append(services, &gcsBuckets{client: storageClient, project: project})
+	}
+	if pubsubClient != nil {
+		services = append(services, &pubsubSubscriptions{client: pubsubClient}, &pubsubTopics{client: pubsubClient})
+	}
+	return services
+}
+
+type gcsBuckets struct {
+	client  *storage.Client
+	project string
+}
+
+func (g *gcsBuckets) Kind() string { return "gcs bucket" }
+
+func (g *gcsBuckets) List(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	it := g.client.Buckets(ctx, g.project)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return resources, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, named(attrs.Name))
+	}
+}
+
+// Delete empties the bucket first; Cloud Storage refuses to delete one
+// with objects
+func (g *gcsBuckets) Delete(ctx context.Context, r Resource) error {
+	bucket := g.client.Bucket(r.ID)
+	it := bucket.Objects(ctx, nil)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if errors.Is(err, storage.ErrBucketNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := bucket.Object(attrs.Name).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			return fmt.Errorf("object %s: %w", attrs.Name, err)
+		}
+	}
+
+	err := bucket.Delete(ctx)
+	if errors.Is(err, storage.ErrBucketNotExist) {
+		return nil
+	}
+	return err
+}
+
+type pubsubTopics struct{ client *pubsub.Client }
+
+func (p *pubsubTopics) Kind() string { return "pubsub topic" }
+
+func (p *pubsubTopics) List(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	it := p.client.Topics(ctx)
+	for {
+		topic, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return resources, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, named(topic.ID()))
+	}
+}
+
+func (p *pubsubTopics) Delete(ctx context.Context, r Resource) error {
+	return p.client.Topic(r.ID).Delete(ctx)
+}
+
+type pubsubSubscriptions struct{ client *pubsub.Client }
+
+func (p *pubsubSubscriptions) Kind() string { return "pubsub subscription" }
+
+func (p *pubsubSubscriptions) List(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	it := p.client.Subscriptions(ctx)
+	for {
+		sub, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return resources, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, named(sub.ID()))
+	}
+}
+
+func (p *pubsubSubscriptions) Delete(ctx context.Context, r Resource) error {
+	return p.client.Subscription(r.ID).Delete(ctx)
+}
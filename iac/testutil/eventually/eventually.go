@@ -0,0 +1,189 @@
+// Package eventually retries integration test assertions against emulators
+// that are only eventually consistent: a bucket may 404 right after apply, a
+// message may not be visible on the first receive, a function may not be
+// Active yet.
+//
+// Assertions are plain func() error values rather than testify calls, so a
+// failed attempt can be retried instead of failing the test. Attempts are
+// spaced with capped exponential backoff plus jitter, and when the timeout
+// expires the test fails with the last few errors, not just the final one.
+//
+// Retry is the same backoff for a bounded number of attempts, for commands
+// run outside a test, such as driftscan's terraform runs.
+package eventually
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Defaults suited to a locally running emulator
+const (
+	DefaultTimeout  = 30 * time.Second
+	DefaultInterval = 500 * time.Millisecond
+)
+
+const (
+	// maxBackoffFactor caps the delay between attempts at this multiple of
+	// the initial interval
+	maxBackoffFactor = 8
+
+	// jitterFraction spreads each delay by up to +/- this share, so parallel
+	// tests do not poll the emulator in lockstep
+	jitterFraction = 0.2
+
+	// keptErrors is how many of the most recent errors a failure reports
+	keptErrors = 5
+)
+
+// TestingT is the subset of testing.TB the package needs; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+// Eventually calls fn until it returns nil, failing t if it has not done so
+// within timeout. interval is the delay before the second attempt.
+func Eventually(t TestingT, timeout, interval time.Duration, fn func() error) {
+	t.Helper()
+
+	EventuallyValue(t, timeout, interval, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+}
+
+// EventuallyValue is Eventually for assertions that produce a value, such as
+// a received message. It returns the value from the first successful attempt;
+// if t's Fatalf returns (as a fake's might), the zero value is returned.
+func EventuallyValue[T any](t TestingT, timeout, interval time.Duration, fn func() (T, error)) T {
+	t.Helper()
+
+	value, err := Poll(timeout, interval, fn)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return value
+}
+
+// Poll retries fn like EventuallyValue but returns the aggregated error
+// instead of failing a test, for callers that want to decide themselves
+func Poll[T any](timeout, interval time.Duration, fn func() (T, error)) (T, error) {
+	start := time.Now()
+	deadline := start.Add(timeout)
+	b := newBackoff(interval)
+
+	var recent []attemptError
+	for attempt := 1; ; attempt++ {
+		value, err := fn()
+		if err == nil {
+			return value, nil
+		}
+
+		recent = append(recent, attemptError{attempt: attempt, err: err})
+		if len(recent) > keptErrors {
+			recent = recent[1:]
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			var zero T
+			return zero, newTimeoutError(time.Since(start), attempt, recent)
+		}
+
+		sleep := b.next()
+		if sleep > remaining {
+			sleep = remaining
+		}
+		time.Sleep(sleep)
+	}
+}
+
+// Permanent marks an error retrying will not fix, so Retry returns it
+// without another attempt
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Retry calls fn until it returns nil, retrying it up to retries times,
+// spaced like Poll's attempts. It returns fn's last error, the error fn
+// marked Permanent, unwrapped, at once, or ctx's error once ctx is done.
+func Retry[T any](ctx context.Context, retries int, interval time.Duration, fn func() (T, error)) (T, error) {
+	b := newBackoff(interval)
+
+	for attempt := 0; ; attempt++ {
+		value, err := fn()
+		if err == nil {
+			return value, nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return value, permanent.err
+		}
+		if attempt >= retries {
+			return value, err
+		}
+
+		timer := time.NewTimer(b.next())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			var zero T
+			return zero, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff doubles the delay between attempts, up to maxBackoffFactor times
+// the first
+type backoff struct {
+	delay    time.Duration
+	maxDelay time.Duration
+}
+
+func newBackoff(interval time.Duration) *backoff {
+	return &backoff{delay: interval, maxDelay: interval * maxBackoffFactor}
+}
+
+// next returns the next delay, jittered
+func (b *backoff) next() time.Duration {
+	sleep := withJitter(b.delay)
+	if b.delay *= 2; b.delay > b.maxDelay {
+		b.delay = b.maxDelay
+	}
+	return sleep
+}
+
+type attemptError struct {
+	attempt int
+	err     error
+}
+
+func newTimeoutError(elapsed time.Duration, attempts int, recent []attemptError) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "condition not met after %s (%d attempts); last %d errors:",
+		elapsed.Round(time.Millisecond), attempts, len(recent))
+	for _, e := range recent {
+		fmt.Fprintf(&b, "\n  attempt %d: %v", e.attempt, e.err)
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
+func withJitter(d time.Duration) time.Duration {
+	spread := float64(d) * jitterFraction
+	return d + time.Duration((rand.Float64()*2-1)*spread)
+}
@@ -0,0 +1,166 @@
+package eventually_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/eventually"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder captures Fatalf instead of failing the enclosing test, so the
+// timeout path can be asserted on
+type recorder struct {
+	failed  bool
+	message string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed = true
+	r.message = fmt.Sprintf(format, args...)
+}
+
+func TestEventuallySucceedsAfterRetries(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	rec := &recorder{}
+	eventually.Eventually(rec, time.Second, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+
+	assert.False(t, rec.failed, "Eventually should not fail once the condition holds: %s", rec.message)
+	assert.Equal(t, 3, calls)
+}
+
+func TestEventuallyValueReturnsFirstSuccess(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	value := eventually.EventuallyValue(t, time.Second, time.Millisecond, func() (string, error) {
+		calls++
+		if calls < 2 {
+			return "", errors.New("message not visible")
+		}
+		return fmt.Sprintf("message-%d", calls), nil
+	})
+
+	assert.Equal(t, "message-2", value)
+}
+
+func TestEventuallyTimeoutAggregatesRecentErrors(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	rec := &recorder{}
+	start := time.Now()
+	eventually.Eventually(rec, 50*time.Millisecond, time.Millisecond, func() error {
+		calls++
+		return fmt.Errorf("bucket missing (call %d)", calls)
+	})
+
+	require.True(t, rec.failed, "Eventually should fail when the condition never holds")
+	assert.Less(t, time.Since(start), time.Second, "Eventually should give up at the timeout")
+	assert.Greater(t, calls, 5, "Eventually should retry until the timeout")
+
+	assert.Contains(t, rec.message, "condition not met after")
+	assert.Contains(t, rec.message, fmt.Sprintf("(%d attempts)", calls))
+	assert.Contains(t, rec.message, "last 5 errors")
+	assert.Contains(t, rec.message, fmt.Sprintf("attempt %d: bucket missing (call %d)", calls, calls), "The final error should be reported")
+	assert.Contains(t, rec.message, fmt.Sprintf("attempt %d: bucket missing (call %d)", calls-4, calls-4), "Earlier errors should be reported too")
+	assert.NotContains(t, rec.message, fmt.Sprintf("attempt %d:", calls-5), "Only the most recent errors should be kept")
+	assert.Equal(t, 6, len(strings.Split(rec.message, "\n")))
+}
+
+func TestEventuallyValueTimeoutReturnsZero(t *testing.T) {
+	t.Parallel()
+
+	rec := &recorder{}
+	value := eventually.EventuallyValue(rec, 10*time.Millisecond, time.Millisecond, func() (int, error) {
+		return 42, errors.New("function not Active")
+	})
+
+	require.True(t, rec.failed)
+	assert.Zero(t, value)
+	assert.Contains(t, rec.message, "function not Active")
+}
+
+func TestPollReportsFewerErrorsThanKept(t *testing.T) {
+	t.Parallel()
+
+	_, err := eventually.Poll(0, time.Millisecond, func() (bool, error) {
+		return false, errors.New("queue empty")
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "(1 attempts); last 1 errors:\n  attempt 1: queue empty")
+}
+
+func TestRetryGivesUpAfterRetries(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	_, err := eventually.Retry(context.Background(), 2, time.Millisecond, func() (string, error) {
+		calls++
+		return "", fmt.Errorf("attempt %d refused", calls)
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, 3, calls, "The first attempt and two retries should run")
+	assert.Equal(t, "attempt 3 refused", err.Error(), "The last error should be returned as it is")
+}
+
+func TestRetrySucceedsAfterRetries(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	value, err := eventually.Retry(context.Background(), 3, time.Millisecond, func() (string, error) {
+		calls++
+		if calls < 2 {
+			return "", errors.New("connection reset")
+		}
+		return "initialized", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "initialized", value)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRetryStopsAtPermanentError(t *testing.T) {
+	t.Parallel()
+
+	invalid := errors.New("invalid provider configuration")
+	calls := 0
+	_, err := eventually.Retry(context.Background(), 3, time.Millisecond, func() (string, error) {
+		calls++
+		return "", eventually.Permanent(invalid)
+	})
+
+	assert.Same(t, invalid, err, "A permanent error should be returned unwrapped")
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryStopsWhenCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := eventually.Retry(ctx, 3, time.Hour, func() (string, error) {
+		cancel()
+		return "", errors.New("connection refused")
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
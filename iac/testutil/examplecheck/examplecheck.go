@@ -0,0 +1,203 @@
+// Package examplecheck checks that the examples under examples/ keep up
+// with the facades: every facade is called by at least one example, no
+// example calls a facade that does not exist, and every example declares
+// the standard variables and at least one output, so it can be planned and
+// read the same way as the others.
+//
+// Module calls come from modgraph and variables from varcheck, so the
+// rules can be tested against small fixture trees independently of the
+// real one.
+package examplecheck
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"iac/testutil/modgraph"
+	"iac/testutil/varcheck"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// StandardVariables are declared by every example
+var StandardVariables = []string{"environment", "project_name"}
+
+// Example is one examples/<name> directory and what the rules look at
+type Example struct {
+	// Dir is the slash-separated path relative to the root
+	Dir string
+
+	// Facades are the facade directories the example calls, sorted, whether
+	// or not they exist
+	Facades []string
+
+	Variables []string
+	Outputs   []string
+}
+
+// Inventory is every facade and example under a root
+type Inventory struct {
+	// Facades are the facade/<name> directories with .tf files, sorted
+	Facades  []string
+	Examples []Example
+}
+
+// Inspect reads the facades and examples under root
+func Inspect(root string) (*Inventory, error) {
+	g, err := modgraph.Build(root)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &Inventory{}
+	// Edges has an entry for every directory with .tf files; Modules also
+	// holds the targets of calls to directories that do not exist
+	for _, module := range g.Modules {
+		if _, ok := g.Edges[module]; !ok {
+			continue
+		}
+		switch {
+		case isTopLevel(module, "facade"):
+			inv.Facades = append(inv.Facades, module)
+		case isTopLevel(module, "examples"):
+			ex, err := inspectExample(root, module, g.Edges[module])
+			if err != nil {
+				return nil, err
+			}
+			inv.Examples = append(inv.Examples, ex)
+		}
+	}
+	return inv, nil
+}
+
+// isTopLevel reports whether module is <dir>/<name>
+func isTopLevel(module, dir string) bool {
+	parent, name := path.Split(module)
+	return parent == dir+"/" && name != ""
+}
+
+func inspectExample(root, dir string, calls []modgraph.Edge) (Example, error) {
+	ex := Example{Dir: dir}
+
+	seen := map[string]bool{}
+	for _, e := range calls {
+		if modgraph.LayerOf(e.To) == modgraph.LayerFacade && !seen[e.To] {
+			seen[e.To] = true
+			ex.Facades = append(ex.Facades, e.To)
+		}
+	}
+	sort.Strings(ex.Facades)
+
+	full := filepath.Join(root, filepath.FromSlash(dir))
+	vars, err := varcheck.Inspect(full)
+	if err != nil {
+		return Example{}, err
+	}
+	for _, v := range vars {
+		ex.Variables = append(ex.Variables, v.Name)
+	}
+
+	ex.Outputs, err = outputs(full)
+	if err != nil {
+		return Example{}, err
+	}
+	return ex, nil
+}
+
+// outputs returns the names of the output blocks in the .tf files of dir
+func outputs(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	parser := hclparse.NewParser()
+	var names []string
+	for _, file := range files {
+		f, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("examplecheck: %s", diags.Error())
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, fmt.Errorf("examplecheck: %s is not native HCL syntax", file)
+		}
+		for _, block := range body.Blocks {
+			if block.Type == "output" && len(block.Labels) > 0 {
+				names = append(names, block.Labels[0])
+			}
+		}
+	}
+	return names, nil
+}
+
+// Problem is one rule a facade or example breaks
+type Problem struct {
+	// Module is the facade or example directory
+	Module  string
+	Message string
+}
+
+// Check applies the rules to inv: every facade is called by an example, and
+// every example calls only existing facades, declares each of standard and
+// has an output. Facades without an example come first, then the problems
+// of each example in order.
+func Check(inv *Inventory, standard []string) []Problem {
+	exists := make(map[string]bool, len(inv.Facades))
+	for _, f := range inv.Facades {
+		exists[f] = true
+	}
+	called := map[string]bool{}
+	for _, ex := range inv.Examples {
+		for _, f := range ex.Facades {
+			called[f] = true
+		}
+	}
+
+	var problems []Problem
+	for _, f := range inv.Facades {
+		if !called[f] {
+			problems = append(problems, Problem{f, "no example calls this facade"})
+		}
+	}
+
+	for _, ex := range inv.Examples {
+		for _, f := range ex.Facades {
+			if !exists[f] {
+				problems = append(problems, Problem{ex.Dir, fmt.Sprintf("calls %s, which does not exist", f)})
+			}
+		}
+
+		declared := make(map[string]bool, len(ex.Variables))
+		for _, v := range ex.Variables {
+			declared[v] = true
+		}
+		for _, v := range standard {
+			if !declared[v] {
+				problems = append(problems, Problem{ex.Dir, fmt.Sprintf("does not declare variable %q", v)})
+			}
+		}
+
+		if len(ex.Outputs) == 0 {
+			problems = append(problems, Problem{ex.Dir, "has no outputs"})
+		}
+	}
+	return problems
+}
+
+// Report lists problems one per line, facades without an example first:
+//
+//	facade/budget: no example calls this facade
+//	examples/demo: calls facade/queue, which does not exist
+func Report(problems []Problem) string {
+	var b strings.Builder
+	for _, p := range problems {
+		fmt.Fprintf(&b, "%s: %s\n", p.Module, p.Message)
+	}
+	return b.String()
+}
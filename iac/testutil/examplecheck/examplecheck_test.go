@@ -0,0 +1,83 @@
+package examplecheck_test
+
+import (
+	"testing"
+
+	"iac/testutil/examplecheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func inspect(t *testing.T) *examplecheck.Inventory {
+	t.Helper()
+	inv, err := examplecheck.Inspect("testdata/tree")
+	require.NoError(t, err)
+	return inv
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	inv := inspect(t)
+
+	assert.Equal(t, []string{"facade/queue", "facade/storage"}, inv.Facades,
+		"A facade called but never written is not a facade")
+	assert.Equal(t, []examplecheck.Example{
+		{
+			Dir:       "examples/broken",
+			Facades:   []string{"facade/cache", "facade/storage"},
+			Variables: []string{"environment"},
+		},
+		{
+			Dir:       "examples/good",
+			Facades:   []string{"facade/storage"},
+			Variables: []string{"project_name", "environment"},
+			Outputs:   []string{"bucket"},
+		},
+		{
+			Dir:       "examples/registry",
+			Variables: []string{"project_name", "environment"},
+			Outputs:   []string{"vpc_id"},
+		},
+	}, inv.Examples, "Each facade should be listed once however many times it is called")
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	problems := examplecheck.Check(inspect(t), examplecheck.StandardVariables)
+
+	assert.Equal(t, []examplecheck.Problem{
+		{Module: "facade/queue", Message: "no example calls this facade"},
+		{Module: "examples/broken", Message: "calls facade/cache, which does not exist"},
+		{Module: "examples/broken", Message: `does not declare variable "project_name"`},
+		{Module: "examples/broken", Message: "has no outputs"},
+	}, problems, "An example calling no facade is fine as long as it follows the other rules")
+}
+
+func TestCheckClean(t *testing.T) {
+	t.Parallel()
+
+	inv := &examplecheck.Inventory{
+		Facades: []string{"facade/storage"},
+		Examples: []examplecheck.Example{{
+			Dir:       "examples/site",
+			Facades:   []string{"facade/storage"},
+			Variables: []string{"environment", "project_name", "provider_name"},
+			Outputs:   []string{"bucket"},
+		}},
+	}
+	assert.Empty(t, examplecheck.Check(inv, examplecheck.StandardVariables))
+}
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	report := examplecheck.Report([]examplecheck.Problem{
+		{Module: "facade/queue", Message: "no example calls this facade"},
+		{Module: "examples/broken", Message: "has no outputs"},
+	})
+
+	assert.Equal(t, "facade/queue: no example calls this facade\nexamples/broken: has no outputs\n", report)
+}
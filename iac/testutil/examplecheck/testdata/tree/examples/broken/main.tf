@@ -0,0 +1,13 @@
+module "bucket" {
+  source      = "../../facade/storage"
+  bucket_name = "broken"
+}
+
+module "cache" {
+  source = "../../facade/cache"
+}
+
+variable "environment" {
+  description = "Environment"
+  type        = string
+}
@@ -0,0 +1,9 @@
+module "bucket" {
+  source      = "../../facade/storage"
+  bucket_name = "${var.project_name}-${var.environment}"
+}
+
+module "logs" {
+  source      = "../../facade/storage"
+  bucket_name = "${var.project_name}-${var.environment}-logs"
+}
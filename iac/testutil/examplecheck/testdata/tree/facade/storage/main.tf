@@ -0,0 +1,4 @@
+variable "bucket_name" {
+  description = "Bucket name"
+  type        = string
+}
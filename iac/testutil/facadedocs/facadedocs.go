@@ -0,0 +1,388 @@
+// Package facadedocs renders the README.generated.md of each facade: which
+// providers have a submodule, and a table of the facade's inputs and
+// outputs read from its .tf files with the HCL parser.
+//
+// Types are written on one line, with the attributes of object types, and
+// of objects nested in them, listed under the inputs table. Defaults that
+// are constants, heredocs included, are written as HCL literals; anything
+// else is the expression's source. Each validation block becomes a
+// constraint, its error_message.
+//
+// tools/facadedocs writes the files; TestFacadeDocs fails when a committed
+// one no longer matches what the facade declares.
+package facadedocs
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FileName is the generated file in each facade directory
+const FileName = "README.generated.md"
+
+// Providers are the provider directories a facade can call a submodule
+// in, in the order the support matrix lists them
+var Providers = []string{"aws", "azure", "gcp", "zero"}
+
+// Facade is what a facade declares
+type Facade struct {
+	// Name is the facade directory's base name
+	Name string
+
+	// Submodules maps each provider with a submodule to the
+	// slash-separated paths it calls, relative to the repository root
+	Submodules map[string][]string
+
+	Variables []Variable
+	Outputs   []Output
+}
+
+// Variable is one variable block
+type Variable struct {
+	Name        string
+	Description string
+
+	// Type is the type constraint on one line; empty without one
+	Type string
+
+	// Default is the default as an HCL literal, or its source when not a
+	// constant; empty when Required
+	Default  string
+	Required bool
+
+	Sensitive bool
+
+	// Attributes of an object type, nested ones included, in declaration
+	// order
+	Attributes []Attribute
+
+	// Constraints are the error messages of the validation blocks
+	Constraints []string
+}
+
+// Attribute is one attribute of an object type constraint
+type Attribute struct {
+	// Path is dotted from the variable; elements of a list, set or map
+	// are written [*], as in rules[*].port
+	Path string
+	Type string
+
+	// Optional is true for optional(...) attributes, whose Default is the
+	// literal given to optional or "null"
+	Optional bool
+	Default  string
+}
+
+// Output is one output block
+type Output struct {
+	Name        string
+	Description string
+	Sensitive   bool
+}
+
+// Inspect reads the variable, output and module blocks in the .tf files of
+// dir, in file and line order
+func Inspect(dir string) (*Facade, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	f := &Facade{Name: filepath.Base(dir), Submodules: map[string][]string{}}
+	parser := hclparse.NewParser()
+	for _, file := range files {
+		parsed, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("facadedocs: %s", diags.Error())
+		}
+		body, ok := parsed.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, fmt.Errorf("facadedocs: %s is not native HCL syntax", file)
+		}
+
+		src := parsed.Bytes
+		for _, block := range body.Blocks {
+			if len(block.Labels) == 0 {
+				continue
+			}
+			switch block.Type {
+			case "variable":
+				f.Variables = append(f.Variables, variable(src, block))
+			case "output":
+				f.Outputs = append(f.Outputs, Output{
+					Name:        block.Labels[0],
+					Description: text(src, block.Body.Attributes["description"]),
+					Sensitive:   isTrue(block.Body.Attributes["sensitive"]),
+				})
+			case "module":
+				module, providers := submodule(src, block)
+				for _, p := range providers {
+					if !contains(f.Submodules[p], module) {
+						f.Submodules[p] = append(f.Submodules[p], module)
+					}
+				}
+			}
+		}
+	}
+	return f, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func variable(src []byte, block *hclsyntax.Block) Variable {
+	attrs := block.Body.Attributes
+	v := Variable{
+		Name:        block.Labels[0],
+		Description: text(src, attrs["description"]),
+		Sensitive:   isTrue(attrs["sensitive"]),
+	}
+
+	if attr, ok := attrs["type"]; ok {
+		v.Type = typeString(src, attr.Expr)
+		v.Attributes = attributes(src, attr.Expr, v.Name)
+	}
+
+	if attr, ok := attrs["default"]; ok {
+		v.Default = literal(src, attr.Expr)
+	} else {
+		v.Required = true
+	}
+
+	for _, nested := range block.Body.Blocks {
+		if nested.Type == "validation" {
+			v.Constraints = append(v.Constraints, text(src, nested.Body.Attributes["error_message"]))
+		}
+	}
+	return v
+}
+
+// providerCondition matches the provider a module's count selects it for
+var providerCondition = regexp.MustCompile(`var\.provider_name\s*==\s*"([a-z]+)"`)
+
+// submodule returns the path of a module block whose source is a local
+// path into a provider directory, and the providers it deploys: those its
+// count compares var.provider_name with, or else the directory's own, so
+// ZeroCloud served through an AWS module counts as zero
+func submodule(src []byte, block *hclsyntax.Block) (module string, providers []string) {
+	attr, found := block.Body.Attributes["source"]
+	if !found {
+		return "", nil
+	}
+	v, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+		return "", nil
+	}
+
+	local := v.AsString()
+	if !strings.HasPrefix(local, "./") && !strings.HasPrefix(local, "../") {
+		return "", nil
+	}
+	parts := strings.Split(local, "/")
+	for len(parts) > 0 && (parts[0] == ".." || parts[0] == ".") {
+		parts = parts[1:]
+	}
+	if len(parts) < 2 || !contains(Providers, parts[0]) {
+		return "", nil
+	}
+	module = strings.Join(parts, "/")
+
+	if count, ok := block.Body.Attributes["count"]; ok {
+		for _, m := range providerCondition.FindAllStringSubmatch(source(src, count.Expr), -1) {
+			if contains(Providers, m[1]) && !contains(providers, m[1]) {
+				providers = append(providers, m[1])
+			}
+		}
+	}
+	if len(providers) == 0 {
+		providers = []string{parts[0]}
+	}
+	return module, providers
+}
+
+// typeString writes a type constraint on one line, with object attributes
+// separated by commas
+func typeString(src []byte, expr hclsyntax.Expression) string {
+	switch e := expr.(type) {
+	case *hclsyntax.ScopeTraversalExpr:
+		return e.Traversal.RootName()
+	case *hclsyntax.FunctionCallExpr:
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			if e.Name == "optional" && i > 0 {
+				args[i] = literal(src, arg)
+			} else {
+				args[i] = typeString(src, arg)
+			}
+		}
+		return e.Name + "(" + strings.Join(args, ", ") + ")"
+	case *hclsyntax.ObjectConsExpr:
+		items := make([]string, len(e.Items))
+		for i, item := range e.Items {
+			items[i] = key(src, item.KeyExpr) + " = " + typeString(src, item.ValueExpr)
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case *hclsyntax.TupleConsExpr:
+		items := make([]string, len(e.Exprs))
+		for i, item := range e.Exprs {
+			items[i] = typeString(src, item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return source(src, expr)
+}
+
+// attributes lists the attributes of the object types in a type
+// constraint, depth first, with paths starting at prefix
+func attributes(src []byte, expr hclsyntax.Expression, prefix string) []Attribute {
+	call, ok := expr.(*hclsyntax.FunctionCallExpr)
+	if !ok || len(call.Args) == 0 {
+		return nil
+	}
+
+	switch call.Name {
+	case "list", "set", "map":
+		return attributes(src, call.Args[0], prefix+"[*]")
+	case "object":
+		object, ok := call.Args[0].(*hclsyntax.ObjectConsExpr)
+		if !ok {
+			return nil
+		}
+		var attrs []Attribute
+		for _, item := range object.Items {
+			a := Attribute{Path: prefix + "." + key(src, item.KeyExpr)}
+			typeExpr := item.ValueExpr
+			if opt, ok := typeExpr.(*hclsyntax.FunctionCallExpr); ok && opt.Name == "optional" && len(opt.Args) > 0 {
+				a.Optional = true
+				a.Default = "null"
+				if len(opt.Args) > 1 {
+					a.Default = literal(src, opt.Args[1])
+				}
+				typeExpr = opt.Args[0]
+			}
+			a.Type = typeString(src, typeExpr)
+			attrs = append(attrs, a)
+			attrs = append(attrs, attributes(src, typeExpr, a.Path)...)
+		}
+		return attrs
+	}
+	return nil
+}
+
+// key returns an object key as written, without quotes
+func key(src []byte, expr hclsyntax.Expression) string {
+	if name := hcl.ExprAsKeyword(expr); name != "" {
+		return name
+	}
+	if v, diags := expr.Value(nil); !diags.HasErrors() && v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+		return v.AsString()
+	}
+	return source(src, expr)
+}
+
+// text returns a string attribute's value with whitespace collapsed, for
+// one table cell. A template that is not a constant, such as an
+// error_message naming the rejected value, is written as between its
+// quotes; any other expression as its source.
+func text(src []byte, attr *hclsyntax.Attribute) string {
+	if attr == nil {
+		return ""
+	}
+	v, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+		s := source(src, attr.Expr)
+		if _, ok := attr.Expr.(*hclsyntax.TemplateExpr); ok && len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+			return s[1 : len(s)-1]
+		}
+		return s
+	}
+	return strings.Join(strings.Fields(v.AsString()), " ")
+}
+
+func isTrue(attr *hclsyntax.Attribute) bool {
+	if attr == nil {
+		return false
+	}
+	v, diags := attr.Expr.Value(nil)
+	return !diags.HasErrors() && v.IsKnown() && !v.IsNull() && v.Type() == cty.Bool && v.True()
+}
+
+// literal writes a constant expression as an HCL literal on one line, and
+// anything else as its source
+func literal(src []byte, expr hclsyntax.Expression) string {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsWhollyKnown() {
+		return source(src, expr)
+	}
+	return value(v)
+}
+
+func value(v cty.Value) string {
+	if v.IsNull() {
+		return "null"
+	}
+
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		return quote(v.AsString())
+	case t == cty.Number:
+		return v.AsBigFloat().Text('f', -1)
+	case t == cty.Bool:
+		if v.True() {
+			return "true"
+		}
+		return "false"
+	case t.IsListType() || t.IsSetType() || t.IsTupleType():
+		var items []string
+		for it := v.ElementIterator(); it.Next(); {
+			_, item := it.Element()
+			items = append(items, value(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case t.IsMapType() || t.IsObjectType():
+		var items []string
+		for it := v.ElementIterator(); it.Next(); {
+			k, item := it.Element()
+			name := k.AsString()
+			if !hclsyntax.ValidIdentifier(name) {
+				name = quote(name)
+			}
+			items = append(items, name+" = "+value(item))
+		}
+		if len(items) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(items, ", ") + " }"
+	}
+	return v.GoString()
+}
+
+// quote writes s as a quoted HCL string. Heredocs read from a file
+// checked out with CRLF line endings are written with \n, as on any other
+// checkout.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+	return `"` + r.Replace(s) + `"`
+}
+
+// source returns the text of expr with whitespace collapsed
+func source(src []byte, expr hclsyntax.Expression) string {
+	return strings.Join(strings.Fields(string(expr.Range().SliceBytes(src))), " ")
+}
@@ -0,0 +1,131 @@
+package facadedocs_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/facadedocs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const demo = "testdata/facade/demo"
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	f, err := facadedocs.Inspect(demo)
+	require.NoError(t, err)
+
+	assert.Equal(t, "demo", f.Name)
+	assert.Equal(t, map[string][]string{
+		"aws":  {"aws/core/demo", "aws/core/dns"},
+		"zero": {"zero/core/demo", "aws/core/dns"},
+	}, f.Submodules, "A module counts for the provider its count selects; registry and common modules are not provider submodules")
+
+	vars := map[string]facadedocs.Variable{}
+	for _, v := range f.Variables {
+		vars[v.Name] = v
+	}
+	require.Len(t, vars, 6)
+
+	assert.Equal(t, "Instance size. Small suits development.", vars["size"].Description, "Heredoc descriptions should fit one cell")
+	assert.Equal(t, []string{
+		"Size must be small or large, not ${var.size}",
+		"Only aws offers | large",
+	}, vars["size"].Constraints, "Each validation block should become a constraint")
+
+	assert.True(t, vars["settings"].Required)
+	assert.Equal(t, []facadedocs.Attribute{
+		{Path: "settings.name", Type: "string"},
+		{Path: "settings.endpoint", Type: "object({host = string, port = optional(number, 443)})", Optional: true, Default: "null"},
+		{Path: "settings.endpoint.host", Type: "string"},
+		{Path: "settings.endpoint.port", Type: "number", Optional: true, Default: "443"},
+		{Path: "settings.rules", Type: "list(object({cidr = string, ports = list(number)}))", Optional: true, Default: "[]"},
+		{Path: "settings.rules[*].cidr", Type: "string"},
+		{Path: "settings.rules[*].ports", Type: "list(number)"},
+		{Path: "settings.labels", Type: "map(string)", Optional: true, Default: `{ cost-center = "42", team = "platform" }`},
+	}, vars["settings"].Attributes, "Nested objects should be listed depth first")
+
+	assert.Equal(t, `"#!/bin/sh\necho \"$${HOME}\" > /tmp/home\n"`, vars["user_data"].Default,
+		"A heredoc default should be one escaped HCL string, whatever the checkout's line endings")
+
+	assert.Equal(t, "null", vars["admin_password"].Default)
+	assert.False(t, vars["admin_password"].Required, "A null default still makes a variable optional")
+	assert.True(t, vars["admin_password"].Sensitive)
+
+	assert.Empty(t, vars["extra"].Type)
+	assert.Equal(t, "{}", vars["extra"].Default)
+
+	assert.Equal(t, []facadedocs.Output{
+		{Name: "id", Description: "Identifier of the demo, whichever provider made it"},
+		{Name: "admin_password", Sensitive: true},
+	}, f.Outputs)
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	got, err := facadedocs.Generate(demo)
+	require.NoError(t, err)
+
+	want, err := os.ReadFile("testdata/demo.golden.md")
+	require.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(string(want), "\r\n", "\n"), string(got))
+}
+
+func TestRenderEmpty(t *testing.T) {
+	t.Parallel()
+
+	got := string(facadedocs.Render(&facadedocs.Facade{Name: "empty"}))
+
+	assert.Contains(t, got, "| aws | no |  |\n")
+	assert.Contains(t, got, "## Inputs\n\nNone.\n")
+	assert.Contains(t, got, "## Outputs\n\nNone.\n")
+}
+
+func TestRenderCodeSpans(t *testing.T) {
+	t.Parallel()
+
+	got := string(facadedocs.Render(&facadedocs.Facade{
+		Name:      "spans",
+		Variables: []facadedocs.Variable{{Name: "command", Default: `"echo ` + "`date`" + ` | tee"`}},
+	}))
+
+	assert.Contains(t, got, "| ``"+` "echo `+"`date`"+` \| tee" `+"`` |",
+		"A default with backticks needs a longer fence, and pipes must not end the cell")
+}
+
+func TestDirs(t *testing.T) {
+	t.Parallel()
+
+	dirs, err := facadedocs.Dirs("testdata")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("testdata", "facade", "demo")}, dirs)
+}
+
+func TestStale(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	generated := []byte("# demo facade\n\nNone.\n")
+
+	diff, err := facadedocs.Stale(dir, generated)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+# demo facade", "A missing file is stale")
+
+	path := filepath.Join(dir, facadedocs.FileName)
+	require.NoError(t, os.WriteFile(path, []byte("# demo facade\r\n\r\nNone.\r\n"), 0o644))
+	diff, err = facadedocs.Stale(dir, generated)
+	require.NoError(t, err)
+	assert.Empty(t, diff, "CRLF line endings should not make a file stale")
+
+	require.NoError(t, os.WriteFile(path, []byte("# demo facade\n\nSomething.\n"), 0o644))
+	diff, err = facadedocs.Stale(dir, generated)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-Something.")
+	assert.Contains(t, diff, "+None.")
+}
@@ -0,0 +1,156 @@
+package facadedocs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Header opens every generated file
+const Header = "<!-- Generated by go run ./tools/facadedocs from the facade's .tf files. DO NOT EDIT. -->"
+
+// Render writes f as Markdown: the provider support matrix, the inputs
+// with the attributes of object types under them, and the outputs
+func Render(f *Facade) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "%s\n\n# %s facade\n\n", Header, f.Name)
+
+	b.WriteString("## Providers\n\n")
+	b.WriteString("| Provider | Supported | Submodules |\n| :--- | :--- | :--- |\n")
+	for _, p := range Providers {
+		modules := f.Submodules[p]
+		supported := "no"
+		if len(modules) > 0 {
+			supported = "yes"
+		}
+		spans := make([]string, len(modules))
+		for i, m := range modules {
+			spans[i] = code(m)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", p, supported, strings.Join(spans, ", "))
+	}
+
+	b.WriteString("\n## Inputs\n\n")
+	if len(f.Variables) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("| Name | Description | Type | Default | Required | Sensitive | Constraints |\n")
+		b.WriteString("| :--- | :--- | :--- | :--- | :--- | :--- | :--- |\n")
+		for _, v := range f.Variables {
+			constraints := make([]string, len(v.Constraints))
+			for i, c := range v.Constraints {
+				constraints[i] = cell(c)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+				code(v.Name), cell(v.Description), code(v.Type), code(v.Default),
+				yesNo(v.Required), yesNo(v.Sensitive), strings.Join(constraints, "<br>"))
+		}
+	}
+
+	for _, v := range f.Variables {
+		if len(v.Attributes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s attributes\n\n", code(v.Name))
+		b.WriteString("| Attribute | Type | Default | Required |\n| :--- | :--- | :--- | :--- |\n")
+		for _, a := range v.Attributes {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", code(a.Path), code(a.Type), code(a.Default), yesNo(!a.Optional))
+		}
+	}
+
+	b.WriteString("\n## Outputs\n\n")
+	if len(f.Outputs) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("| Name | Description | Sensitive |\n| :--- | :--- | :--- |\n")
+		for _, o := range f.Outputs {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", code(o.Name), cell(o.Description), yesNo(o.Sensitive))
+		}
+	}
+	return b.Bytes()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// cell escapes the pipes that would end a table cell
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// code writes s as a code span in a table cell, fenced with more
+// backticks than it contains; empty stays empty
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if len(fence) > 1 || strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + cell(s) + " " + fence
+	}
+	return fence + cell(s) + fence
+}
+
+// Generate renders the facade in dir
+func Generate(dir string) ([]byte, error) {
+	f, err := Inspect(dir)
+	if err != nil {
+		return nil, err
+	}
+	return Render(f), nil
+}
+
+// Dirs returns the facade/<name> directories under root that have .tf
+// files, sorted
+func Dirs(root string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(root, "facade", "*", "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var dirs []string
+	for _, file := range files {
+		if dir := filepath.Dir(file); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// Stale compares the README.generated.md committed in dir with generated,
+// ignoring CRLF line endings, and returns a unified diff from one to the
+// other, or "" when they match. A missing file is stale.
+func Stale(dir string, generated []byte) (string, error) {
+	path := filepath.Join(dir, FileName)
+	committed, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	committed = bytes.ReplaceAll(committed, []byte("\r\n"), []byte("\n"))
+	if bytes.Equal(committed, generated) {
+		return "", nil
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(committed)),
+		B:        difflib.SplitLines(string(generated)),
+		FromFile: filepath.ToSlash(path),
+		ToFile:   "generated",
+		Context:  3,
+	})
+}
@@ -0,0 +1,42 @@
+module "tags" {
+  source = "../../common/tags"
+}
+
+module "aws_demo" {
+  count  = var.provider_name == "aws" ? 1 : 0
+  source = "../../aws/core/demo"
+}
+
+module "aws_demo_dns" {
+  count  = var.provider_name == "aws" ? 1 : 0
+  source = "../../aws/core/dns"
+}
+
+module "zero_demo" {
+  count  = var.provider_name == "zero" ? 1 : 0
+  source = "../../zero/core/demo"
+}
+
+# ZeroCloud's DNS through the AWS shim
+module "zero_demo_dns" {
+  count  = var.provider_name == "zero" ? 1 : 0
+  source = "../../aws/core/dns"
+}
+
+module "registry" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+
+output "id" {
+  description = <<-EOT
+    Identifier of the demo,
+    whichever provider made it
+  EOT
+  value       = "demo"
+}
+
+output "admin_password" {
+  value     = var.admin_password
+  sensitive = true
+}
@@ -0,0 +1,61 @@
+variable "provider_name" {
+  description = "Cloud provider (aws, zero)"
+  type        = string
+  validation {
+    condition     = contains(["aws", "zero"], var.provider_name)
+    error_message = "Provider must be one of: aws, zero"
+  }
+}
+
+variable "size" {
+  description = <<-EOT
+    Instance size.
+    Small suits development.
+  EOT
+  type        = string
+  default     = "small"
+  validation {
+    condition     = contains(["small", "large"], var.size)
+    error_message = "Size must be small or large, not ${var.size}"
+  }
+  validation {
+    condition     = var.size != "large" || var.provider_name == "aws"
+    error_message = "Only aws offers | large"
+  }
+}
+
+variable "settings" {
+  description = "Per-environment settings"
+  type = object({
+    name = string
+    endpoint = optional(object({
+      host = string
+      port = optional(number, 443)
+    }))
+    rules = optional(list(object({
+      cidr  = string
+      ports = list(number)
+    })), [])
+    labels = optional(map(string), { team = "platform", "cost-center" = "42" })
+  })
+}
+
+variable "user_data" {
+  description = "Cloud-init script"
+  type        = string
+  default     = <<-EOT
+    #!/bin/sh
+    echo "${"$"}{HOME}" > /tmp/home
+  EOT
+}
+
+variable "admin_password" {
+  description = "Administrator password"
+  type        = string
+  default     = null
+  sensitive   = true
+}
+
+variable "extra" {
+  default = {}
+}
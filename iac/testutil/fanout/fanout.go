@@ -0,0 +1,181 @@
+// Package fanout runs one Terraform configuration many times at once, for
+// stress tests that look for races in the emulator and the modules under
+// concurrent creates:
+//
+//	dirs := fanout.Workspaces(t, "../..", "aws/test/fixtures/storage", 20)
+//	results := fanout.Run(len(dirs), func(i int) error {
+//		_, err := tfopts.ApplyE(t, options[i])
+//		return err
+//	})
+//	t.Logf("apply latency: %s", fanout.Latencies(results))
+//	assert.NoError(t, fanout.Errors(results))
+//
+// Each run gets its own copy of the configuration, so the runs do not share
+// a .terraform directory or state file.
+package fanout
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Workspaces copies root into n temporary directories, removed when t
+// finishes, and returns the path of module, relative to root, in each.
+// Copying all of root keeps relative module sources such as
+// "../../facade/storage" working. Hidden files, .terraform directories and
+// state files are not copied.
+func Workspaces(t testing.TB, root, module string, n int) []string {
+	t.Helper()
+
+	dirs := make([]string, n)
+	for i := range dirs {
+		dest := t.TempDir()
+		if err := copyTree(root, dest); err != nil {
+			t.Fatalf("fanout: copying %s: %v", root, err)
+		}
+		dirs[i] = filepath.Join(dest, module)
+	}
+	return dirs
+}
+
+// skipped are the names copyTree leaves out: hidden files such as
+// .terraform, and state, which belong to the workspace that wrote them
+func skipped(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "terraform.tfstate")
+}
+
+// copyTree copies the regular files below src into dest
+func copyTree(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != src && skipped(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
+
+// Result is the outcome of one run
+type Result struct {
+	Index    int
+	Duration time.Duration
+	Err      error
+}
+
+// Run calls fn for every index from 0 to n-1, all at once, and returns
+// each call's result and duration in index order
+func Run(n int, fn func(i int) error) []Result {
+	results := make([]Result, n)
+
+	// Start every call together, so they overlap as much as possible
+	var ready, done sync.WaitGroup
+	start := make(chan struct{})
+	for i := range results {
+		ready.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			ready.Done()
+			<-start
+
+			began := time.Now()
+			err := fn(i)
+			results[i] = Result{Index: i, Duration: time.Since(began), Err: err}
+		}()
+	}
+	ready.Wait()
+	close(start)
+	done.Wait()
+
+	return results
+}
+
+// Errors joins the error of every failed run, each prefixed with its
+// index, or returns nil when all succeeded
+func Errors(results []Result) error {
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("run %d: %w", r.Index, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Summary is the latency distribution of a set of runs
+type Summary struct {
+	Count int
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// String formats the summary for a test log
+func (s Summary) String() string {
+	return fmt.Sprintf("%d runs, p50 %s, p90 %s, p99 %s, max %s",
+		s.Count, s.P50.Round(time.Millisecond), s.P90.Round(time.Millisecond),
+		s.P99.Round(time.Millisecond), s.Max.Round(time.Millisecond))
+}
+
+// Latencies summarizes the durations of results, failed runs included
+func Latencies(results []Result) Summary {
+	durations := make([]time.Duration, len(results))
+	for i, r := range results {
+		durations[i] = r.Duration
+	}
+	slices.Sort(durations)
+
+	return Summary{
+		Count: len(durations),
+		P50:   Percentile(durations, 50),
+		P90:   Percentile(durations, 90),
+		P99:   Percentile(durations, 99),
+		Max:   Percentile(durations, 100),
+	}
+}
+
+// Percentile returns the nearest-rank pth percentile of sorted: the
+// smallest duration at least p percent of them do not exceed. It returns 0
+// for no durations.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -0,0 +1,149 @@
+package fanout_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"iac/testutil/fanout"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile creates path under root with its parent directories
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+
+	full := filepath.Join(root, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+	require.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+}
+
+func TestWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "facade/storage/main.tf", "# facade\n")
+	writeFile(t, root, "fixtures/stress/main.tf", "module \"storage\" { source = \"../../facade/storage\" }\n")
+	writeFile(t, root, "fixtures/stress/.terraform/modules/modules.json", "{}\n")
+	writeFile(t, root, "fixtures/stress/terraform.tfstate", "{}\n")
+
+	dirs := fanout.Workspaces(t, root, "fixtures/stress", 3)
+	require.Len(t, dirs, 3)
+
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		assert.False(t, seen[dir], "Workspaces should not share a directory")
+		seen[dir] = true
+		assert.NotEqual(t, filepath.Join(root, "fixtures/stress"), dir, "The module should be copied")
+
+		assert.FileExists(t, filepath.Join(dir, "main.tf"))
+		assert.FileExists(t, filepath.Join(dir, "../../facade/storage/main.tf"), "Relative module sources should resolve inside the copy")
+		assert.NoDirExists(t, filepath.Join(dir, ".terraform"), "Working directories should not be copied")
+		assert.NoFileExists(t, filepath.Join(dir, "terraform.tfstate"), "State should not be copied")
+	}
+
+	// Terraform writing into one workspace leaves the others alone
+	writeFile(t, dirs[0], "terraform.tfstate", "{\"serial\": 1}\n")
+	assert.NoFileExists(t, filepath.Join(dirs[1], "terraform.tfstate"))
+}
+
+func TestRunOverlapsCalls(t *testing.T) {
+	t.Parallel()
+
+	const n = 20
+	var started sync.WaitGroup
+	started.Add(n)
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+
+	results := fanout.Run(n, func(i int) error {
+		started.Done()
+		// Only returns once every call is running at the same time
+		select {
+		case <-allStarted:
+		case <-time.After(5 * time.Second):
+			return errors.New("calls did not overlap")
+		}
+		if i%7 == 3 {
+			return errors.New("bucket already exists")
+		}
+		return nil
+	})
+
+	require.Len(t, results, n)
+	for i, r := range results {
+		assert.Equal(t, i, r.Index, "Results should be in index order")
+		assert.Positive(t, int64(r.Duration))
+	}
+
+	err := fanout.Errors(results)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "did not overlap")
+	assert.Contains(t, err.Error(), "run 3: bucket already exists")
+	assert.Contains(t, err.Error(), "run 10: bucket already exists")
+	assert.Contains(t, err.Error(), "run 17: bucket already exists")
+	assert.NotContains(t, err.Error(), "run 0:")
+}
+
+func TestErrorsNone(t *testing.T) {
+	t.Parallel()
+
+	results := fanout.Run(3, func(int) error { return nil })
+	assert.NoError(t, fanout.Errors(results))
+	assert.NoError(t, fanout.Errors(nil))
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	sorted := make([]time.Duration, 20)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Second
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 1 * time.Second},
+		{5, 1 * time.Second},
+		{50, 10 * time.Second},
+		{51, 11 * time.Second},
+		{90, 18 * time.Second},
+		{99, 20 * time.Second},
+		{100, 20 * time.Second},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, fanout.Percentile(sorted, tc.p), "p%v", tc.p)
+	}
+
+	assert.Zero(t, fanout.Percentile(nil, 50))
+	assert.Equal(t, 3*time.Second, fanout.Percentile([]time.Duration{3 * time.Second}, 99))
+}
+
+func TestLatencies(t *testing.T) {
+	t.Parallel()
+
+	var results []fanout.Result
+	for _, d := range []time.Duration{4, 1, 3, 2} {
+		results = append(results, fanout.Result{Duration: d * time.Second})
+	}
+	results[1].Err = errors.New("failed runs count too")
+
+	summary := fanout.Latencies(results)
+	assert.Equal(t, fanout.Summary{
+		Count: 4,
+		P50:   2 * time.Second,
+		P90:   4 * time.Second,
+		P99:   4 * time.Second,
+		Max:   4 * time.Second,
+	}, summary)
+	assert.Equal(t, "4 runs, p50 2s, p90 4s, p99 4s, max 4s", summary.String())
+	assert.Equal(t, 4*time.Second, results[0].Duration, "Latencies should not reorder results")
+}
@@ -0,0 +1,295 @@
+// Package faultproxy is an HTTP proxy that sits between Terraform and an
+// emulator and fails some of the requests on the way through, so tests can
+// check that the retries configured with tfopts.WithEmulatorRetries and
+// the providers' own retryers cover the errors a stressed or starting
+// endpoint returns:
+//
+//	proxy := faultproxy.Start(t, cfg.CloudEmuEndpoint, faultproxy.Scenario{
+//		Name:            "transient 503s",
+//		SlowDownPercent: 20,
+//	})
+//	vars["emulator_endpoint"] = proxy.URL
+//
+// Faults are spread evenly rather than drawn at random: of any 100
+// consecutive requests, exactly the configured percentage get each fault,
+// and a run gets the same faults in the same places every time.
+package faultproxy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Fault is what the proxy does to a request instead of forwarding it
+type Fault string
+
+// Faults the proxy injects
+const (
+	// None forwards the request
+	None Fault = "none"
+	// InternalError answers 500 with an S3-style InternalError body
+	InternalError Fault = "500 InternalError"
+	// SlowDown answers 503 with an S3-style SlowDown body, as S3 does when
+	// throttling
+	SlowDown Fault = "503 SlowDown"
+	// Reset closes the connection without a response, with an RST
+	Reset Fault = "connection reset"
+)
+
+// slots is the length of the cycle faults are spread over, so percentages
+// are exact over any slots consecutive requests
+const slots = 100
+
+// Strides through the slots of a cycle. Both are coprime to slots, so each
+// visits every slot once per cycle, and they differ so latency does not
+// always land on the faulted requests.
+const (
+	faultStride   = 37
+	latencyStride = 71
+)
+
+// Scenario says which faults to inject and how often. Percentages are of
+// all requests through the proxy; a request gets at most one fault, so the
+// fault percentages must add up to 100 or less.
+type Scenario struct {
+	// Name identifies the scenario in logs
+	Name string
+	// InternalErrorPercent of requests get InternalError
+	InternalErrorPercent int
+	// SlowDownPercent of requests get SlowDown
+	SlowDownPercent int
+	// ResetPercent of requests get Reset
+	ResetPercent int
+	// LatencyPercent of requests, faulted or not, wait Latency first
+	LatencyPercent int
+	Latency        time.Duration
+	// Warmup is how long after the proxy is created every request gets
+	// Reset, as from an emulator whose port is published before its
+	// server listens
+	Warmup time.Duration
+}
+
+// Validate checks the percentages and that a latency is set when requests
+// are to be delayed
+func (s Scenario) Validate() error {
+	percents := map[string]int{
+		"InternalErrorPercent": s.InternalErrorPercent,
+		"SlowDownPercent":      s.SlowDownPercent,
+		"ResetPercent":         s.ResetPercent,
+		"LatencyPercent":       s.LatencyPercent,
+	}
+	for name, p := range percents {
+		if p < 0 || p > 100 {
+			return fmt.Errorf("faultproxy: %s is %d, want 0-100", name, p)
+		}
+	}
+	if sum := s.InternalErrorPercent + s.SlowDownPercent + s.ResetPercent; sum > 100 {
+		return fmt.Errorf("faultproxy: fault percentages add up to %d, want at most 100", sum)
+	}
+	if s.LatencyPercent > 0 && s.Latency <= 0 {
+		return errors.New("faultproxy: LatencyPercent is set without a Latency")
+	}
+	if s.Warmup < 0 {
+		return fmt.Errorf("faultproxy: Warmup is %s, want 0 or more", s.Warmup)
+	}
+	return nil
+}
+
+// Fault returns the fault of the nth request through the proxy, counting
+// from 0, after the Warmup
+func (s Scenario) Fault(n int) Fault {
+	slot := n * faultStride % slots
+	for _, band := range []struct {
+		fault   Fault
+		percent int
+	}{
+		{InternalError, s.InternalErrorPercent},
+		{SlowDown, s.SlowDownPercent},
+		{Reset, s.ResetPercent},
+	} {
+		if slot < band.percent {
+			return band.fault
+		}
+		slot -= band.percent
+	}
+	return None
+}
+
+// Delayed reports whether the nth request waits Latency
+func (s Scenario) Delayed(n int) bool {
+	return n*latencyStride%slots < s.LatencyPercent
+}
+
+// Stats counts the requests through a Proxy
+type Stats struct {
+	// Requests is every request received
+	Requests int
+	// Delayed is the requests held for the scenario's Latency
+	Delayed int
+	// Faults counts each fault injected, and None the requests forwarded
+	Faults map[Fault]int
+}
+
+// Proxy is an http.Handler that forwards to a target, injecting the
+// faults of a Scenario
+type Proxy struct {
+	scenario Scenario
+	forward  *httputil.ReverseProxy
+	created  time.Time
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New returns a Proxy to target, a base URL such as http://localhost:4566
+func New(target string, scenario Scenario) (*Proxy, error) {
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("faultproxy: target: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("faultproxy: target %q is not an absolute URL", target)
+	}
+
+	return &Proxy{
+		scenario: scenario,
+		forward: &httputil.ReverseProxy{
+			Rewrite: func(r *httputil.ProxyRequest) {
+				r.SetURL(u)
+				// Keep the Host the client signed, so SigV4 signatures
+				// still match
+				r.Out.Host = r.In.Host
+			},
+		},
+		created: time.Now(),
+		stats:   Stats{Faults: map[Fault]int{}},
+	}, nil
+}
+
+// WithTransport makes the proxy forward requests with transport instead of
+// http.DefaultTransport, e.g. to record them. Call it before the proxy
+// serves any request.
+func (p *Proxy) WithTransport(transport http.RoundTripper) *Proxy {
+	p.forward.Transport = transport
+	return p
+}
+
+// Stats returns the counts so far
+func (p *Proxy) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := p.stats
+	stats.Faults = make(map[Fault]int, len(p.stats.Faults))
+	for fault, n := range p.stats.Faults {
+		stats.Faults[fault] = n
+	}
+	return stats
+}
+
+// next numbers a request in arrival order and records what happens to it
+func (p *Proxy) next() (Fault, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := p.stats.Requests
+	fault, delayed := p.scenario.Fault(n), p.scenario.Delayed(n)
+	if time.Since(p.created) < p.scenario.Warmup {
+		fault = Reset
+	}
+
+	p.stats.Requests++
+	p.stats.Faults[fault]++
+	if delayed {
+		p.stats.Delayed++
+	}
+	return fault, delayed
+}
+
+// ServeHTTP forwards r, or answers it with the scenario's fault
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fault, delayed := p.next()
+
+	if delayed {
+		select {
+		case <-time.After(p.scenario.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch fault {
+	case InternalError:
+		writeError(w, http.StatusInternalServerError, "InternalError", "We encountered an internal error. Please try again.")
+	case SlowDown:
+		writeError(w, http.StatusServiceUnavailable, "SlowDown", "Please reduce your request rate.")
+	case Reset:
+		reset(w)
+	default:
+		p.forward.ServeHTTP(w, r)
+	}
+}
+
+// writeError answers with an S3-style XML error, which the AWS SDKs parse
+// into an error code their retryers recognize
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>%s</Code><Message>%s</Message><RequestId>faultproxy</RequestId></Error>`, code, message)
+}
+
+// reset drops the client's connection without writing anything. Setting
+// linger to 0 makes the close send an RST, so the client sees "connection
+// reset by peer" rather than a clean EOF.
+func reset(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// Server is a Proxy listening on a local port
+type Server struct {
+	*Proxy
+	// URL is the proxy's base URL, to use as the endpoint in place of
+	// the target
+	URL string
+}
+
+// Start runs a Proxy to target on a local port until t finishes, and logs
+// its Stats then
+func Start(t testing.TB, target string, scenario Scenario) *Server {
+	t.Helper()
+
+	proxy, err := New(target, scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(proxy)
+	t.Cleanup(func() {
+		server.Close()
+		stats := proxy.Stats()
+		t.Logf("faultproxy %q: %d requests, %d delayed, faults: %v", scenario.Name, stats.Requests, stats.Delayed, stats.Faults)
+	})
+	return &Server{Proxy: proxy, URL: server.URL}
+}
@@ -0,0 +1,287 @@
+package faultproxy_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"iac/testutil/faultproxy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleIsExactOverAnyHundredRequests(t *testing.T) {
+	t.Parallel()
+
+	scenario := faultproxy.Scenario{
+		InternalErrorPercent: 10,
+		SlowDownPercent:      20,
+		ResetPercent:         5,
+		LatencyPercent:       30,
+		Latency:              time.Millisecond,
+	}
+
+	for _, start := range []int{0, 1, 37, 250} {
+		faults := map[faultproxy.Fault]int{}
+		delayed := 0
+		for n := start; n < start+100; n++ {
+			faults[scenario.Fault(n)]++
+			if scenario.Delayed(n) {
+				delayed++
+			}
+		}
+
+		assert.Equal(t, map[faultproxy.Fault]int{
+			faultproxy.InternalError: 10,
+			faultproxy.SlowDown:      20,
+			faultproxy.Reset:         5,
+			faultproxy.None:          65,
+		}, faults, "Requests %d-%d", start, start+99)
+		assert.Equal(t, 30, delayed, "Requests %d-%d", start, start+99)
+	}
+}
+
+func TestScheduleSpreadsTransientFaults(t *testing.T) {
+	t.Parallel()
+
+	// A request retried straight after a transient fault should get through
+	scenario := faultproxy.Scenario{SlowDownPercent: 20}
+	for n := range 1000 {
+		if scenario.Fault(n) != faultproxy.None {
+			assert.Equal(t, faultproxy.None, scenario.Fault(n+1), "Request %d follows a fault", n+1)
+		}
+	}
+}
+
+func TestScheduleExtremes(t *testing.T) {
+	t.Parallel()
+
+	for n := range 200 {
+		assert.Equal(t, faultproxy.None, faultproxy.Scenario{}.Fault(n))
+		assert.False(t, faultproxy.Scenario{}.Delayed(n))
+		assert.Equal(t, faultproxy.InternalError, faultproxy.Scenario{InternalErrorPercent: 100}.Fault(n))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		scenario faultproxy.Scenario
+		want     string
+	}{
+		{"None", faultproxy.Scenario{}, ""},
+		{"AllFaults", faultproxy.Scenario{InternalErrorPercent: 50, SlowDownPercent: 30, ResetPercent: 20}, ""},
+		{"Negative", faultproxy.Scenario{SlowDownPercent: -1}, "SlowDownPercent is -1"},
+		{"OverHundred", faultproxy.Scenario{ResetPercent: 101}, "ResetPercent is 101"},
+		{"SumOverHundred", faultproxy.Scenario{InternalErrorPercent: 60, SlowDownPercent: 50}, "add up to 110"},
+		{"LatencyWithoutDuration", faultproxy.Scenario{LatencyPercent: 10}, "without a Latency"},
+		{"NegativeWarmup", faultproxy.Scenario{Warmup: -time.Second}, "Warmup is -1s"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.scenario.Validate()
+			if tc.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestNewRejectsRelativeTarget(t *testing.T) {
+	t.Parallel()
+
+	_, err := faultproxy.New("localhost:4566", faultproxy.Scenario{})
+	assert.Error(t, err)
+}
+
+// echo answers every request with what it received, so tests can check
+// what the proxy forwarded
+func echo(t *testing.T) (*httptest.Server, *int) {
+	hits := new(int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hits++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Echo-Method", r.Method)
+		w.Header().Set("X-Echo-Host", r.Host)
+		w.Header().Set("X-Echo-Authorization", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, r.URL.RequestURI()+"\n"+string(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, hits
+}
+
+func TestPassThrough(t *testing.T) {
+	t.Parallel()
+
+	backend, hits := echo(t)
+	proxy := faultproxy.Start(t, backend.URL, faultproxy.Scenario{Name: "none"})
+
+	req, err := http.NewRequest(http.MethodPut, proxy.URL+"/bucket/key?versionId=1&x-id=PutObject", strings.NewReader("payload"))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=test")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/bucket/key?versionId=1&x-id=PutObject\npayload", string(body))
+	assert.Equal(t, http.MethodPut, resp.Header.Get("X-Echo-Method"))
+	assert.Equal(t, req.URL.Host, resp.Header.Get("X-Echo-Host"), "The Host the client signed should be kept")
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=test", resp.Header.Get("X-Echo-Authorization"))
+	assert.Equal(t, 1, *hits)
+
+	stats := proxy.Stats()
+	assert.Equal(t, 1, stats.Requests)
+	assert.Equal(t, map[faultproxy.Fault]int{faultproxy.None: 1}, stats.Faults)
+}
+
+// roundTripFunc is an http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestWithTransport(t *testing.T) {
+	t.Parallel()
+
+	backend, hits := echo(t)
+	proxy, err := faultproxy.New(backend.URL, faultproxy.Scenario{Name: "none"})
+	require.NoError(t, err)
+
+	var forwarded []string
+	proxy.WithTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		forwarded = append(forwarded, r.Method+" "+r.URL.Path)
+		return http.DefaultTransport.RoundTrip(r)
+	}))
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/bucket")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []string{"GET /bucket"}, forwarded)
+	assert.Equal(t, 1, *hits)
+}
+
+func TestErrorFaults(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		scenario faultproxy.Scenario
+		status   int
+		code     string
+	}{
+		{"InternalError", faultproxy.Scenario{InternalErrorPercent: 100}, http.StatusInternalServerError, "<Code>InternalError</Code>"},
+		{"SlowDown", faultproxy.Scenario{SlowDownPercent: 100}, http.StatusServiceUnavailable, "<Code>SlowDown</Code>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			backend, hits := echo(t)
+			proxy := faultproxy.Start(t, backend.URL, tc.scenario)
+
+			resp, err := http.Get(proxy.URL + "/bucket")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+			assert.Contains(t, string(body), tc.code)
+			assert.Zero(t, *hits, "A faulted request should not reach the target")
+		})
+	}
+}
+
+func TestResetFault(t *testing.T) {
+	t.Parallel()
+
+	backend, hits := echo(t)
+	proxy := faultproxy.Start(t, backend.URL, faultproxy.Scenario{ResetPercent: 100})
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	_, err := client.Get(proxy.URL + "/bucket")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+	assert.Zero(t, *hits)
+}
+
+func TestWarmup(t *testing.T) {
+	t.Parallel()
+
+	backend, hits := echo(t)
+	proxy := faultproxy.Start(t, backend.URL, faultproxy.Scenario{Warmup: 200 * time.Millisecond})
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	_, err := client.Get(proxy.URL + "/bucket")
+	assert.ErrorIs(t, err, syscall.ECONNRESET, "Requests during the warmup should be reset")
+
+	time.Sleep(250 * time.Millisecond)
+	resp, err := client.Get(proxy.URL + "/bucket")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "Requests after the warmup should be forwarded")
+
+	assert.Equal(t, 1, *hits)
+	assert.Equal(t, map[faultproxy.Fault]int{faultproxy.Reset: 1, faultproxy.None: 1}, proxy.Stats().Faults)
+}
+
+func TestLatency(t *testing.T) {
+	t.Parallel()
+
+	backend, hits := echo(t)
+	proxy := faultproxy.Start(t, backend.URL, faultproxy.Scenario{LatencyPercent: 100, Latency: 100 * time.Millisecond})
+
+	start := time.Now()
+	resp, err := http.Get(proxy.URL + "/bucket")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "A delayed request should still be forwarded")
+	assert.Equal(t, 1, *hits)
+	assert.Equal(t, 1, proxy.Stats().Delayed)
+}
+
+func TestProxyFollowsSchedule(t *testing.T) {
+	t.Parallel()
+
+	backend, hits := echo(t)
+	scenario := faultproxy.Scenario{SlowDownPercent: 20}
+	proxy := faultproxy.Start(t, backend.URL, scenario)
+
+	for n := range 20 {
+		resp, err := http.Get(proxy.URL + "/bucket")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		want := http.StatusCreated
+		if scenario.Fault(n) == faultproxy.SlowDown {
+			want = http.StatusServiceUnavailable
+		}
+		assert.Equal(t, want, resp.StatusCode, "Request %d", n)
+	}
+
+	stats := proxy.Stats()
+	assert.Equal(t, 20, stats.Requests)
+	assert.Equal(t, stats.Faults[faultproxy.None], *hits)
+	assert.Equal(t, 20, stats.Faults[faultproxy.None]+stats.Faults[faultproxy.SlowDown])
+}
@@ -0,0 +1,278 @@
+// Package httpassert fetches the URLs modules output, such as function URLs
+// and website endpoints, and asserts on the response. Emulators serve their
+// https URLs with self-signed certificates, which a default client rejects;
+// this client trusts them when told to, and never falls back to http:
+//
+//	opts, err := httpassert.LoadOptions()
+//	resp := httpassert.GetWithRetries(t, invokeURL, opts)
+//	resp.AssertStatus(t, http.StatusOK)
+//	resp.AssertBodyContains(t, "hello from function url")
+//
+// SWE_TLS_CA_BUNDLE names a PEM file of certificates to trust besides the
+// system's, for emulators that publish their CA; SWE_TLS_INSECURE_SKIP_VERIFY
+// turns verification off for those that do not. A certificate that fails
+// verification fails the request at once, with a hint naming both, rather
+// than being retried.
+package httpassert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"iac/testutil/config"
+)
+
+// Environment variables read by LoadOptions
+const (
+	EnvInsecureSkipVerify = "SWE_TLS_INSECURE_SKIP_VERIFY"
+	EnvCABundle           = "SWE_TLS_CA_BUNDLE"
+)
+
+// Defaults suited to a locally running emulator
+const (
+	DefaultTimeout        = 30 * time.Second
+	DefaultInterval       = 500 * time.Millisecond
+	DefaultRequestTimeout = 10 * time.Second
+	DefaultMaxRedirects   = 10
+)
+
+// ErrRedirect is wrapped by the error of a redirect the client refused to
+// follow
+var ErrRedirect = errors.New("httpassert: redirect refused")
+
+// Options configures how GetWithRetries connects and retries
+type Options struct {
+	// InsecureSkipVerify accepts any server certificate
+	InsecureSkipVerify bool
+
+	// CABundle is a PEM file of certificates trusted besides the system's
+	CABundle string
+
+	// FollowRedirects follows up to MaxRedirects redirects; otherwise the
+	// redirect itself is the response. A redirect from https to http is
+	// never followed.
+	FollowRedirects bool
+	MaxRedirects    int
+
+	// Timeout bounds all attempts together, RequestTimeout each one, and
+	// Interval is the delay between attempts
+	Timeout        time.Duration
+	RequestTimeout time.Duration
+	Interval       time.Duration
+
+	// Header is sent with every request
+	Header http.Header
+}
+
+// LoadOptions reads Options from SWE_TLS_INSECURE_SKIP_VERIFY and
+// SWE_TLS_CA_BUNDLE, with redirects followed and the default timeouts
+func LoadOptions() (Options, error) {
+	insecure, err := config.BoolFromEnv(EnvInsecureSkipVerify)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{
+		InsecureSkipVerify: insecure,
+		CABundle:           os.Getenv(EnvCABundle),
+		FollowRedirects:    true,
+		MaxRedirects:       DefaultMaxRedirects,
+		Timeout:            DefaultTimeout,
+		RequestTimeout:     DefaultRequestTimeout,
+		Interval:           DefaultInterval,
+	}, nil
+}
+
+// NewClient returns a client that verifies certificates against the system
+// roots and opts.CABundle, unless opts.InsecureSkipVerify, and follows
+// redirects as opts says
+func NewClient(opts Options) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CABundle != "" {
+		pem, err := os.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("httpassert: reading %s: %w", EnvCABundle, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("httpassert: %s: no PEM certificates in %s", EnvCABundle, opts.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   opts.RequestTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !opts.FollowRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= opts.MaxRedirects {
+				return fmt.Errorf("%w: stopped after %d redirects", ErrRedirect, len(via))
+			}
+			if prev := via[len(via)-1].URL; prev.Scheme == "https" && req.URL.Scheme == "http" {
+				return fmt.Errorf("%w: %s to %s downgrades to http", ErrRedirect, prev, req.URL)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// Response is what the server answered, with its body read in full
+type Response struct {
+	// URL is the one that answered, after any redirects
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// TestingT is the subset of testing.TB the package needs; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// GetWithRetries is GetWithRetriesE that fails t when no response came
+func GetWithRetries(t TestingT, url string, opts Options) *Response {
+	t.Helper()
+
+	resp, err := GetWithRetriesE(context.Background(), url, opts)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return resp
+}
+
+// GetWithRetriesE sends GET url until it gets a response other than 502,
+// 503 or 504, retrying failed connections, such as one refused by an
+// emulator that is still starting, until opts.Timeout. A certificate that
+// fails verification, or a refused redirect, is not retried.
+func GetWithRetriesE(ctx context.Context, url string, opts Options) (*Response, error) {
+	client, err := NewClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		resp, err := get(ctx, client, url, opts.Header)
+		switch {
+		case err == nil && !retryableStatus(resp.StatusCode):
+			return resp, nil
+		case err == nil:
+			lastErr = fmt.Errorf("%s answered %d", url, resp.StatusCode)
+		case isCertificateError(err):
+			return nil, fmt.Errorf("httpassert: GET %s: %w; set %s to the emulator's CA certificate, or %s=true", url, err, EnvCABundle, EnvInsecureSkipVerify)
+		case errors.Is(err, ErrRedirect):
+			return nil, fmt.Errorf("httpassert: GET %s: %w", url, err)
+		default:
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("httpassert: GET %s: no response after %d attempts in %s: %w", url, attempt, opts.Timeout, lastErr)
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+func get(ctx context.Context, client *http.Client, url string, header http.Header) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{URL: resp.Request.URL.String(), StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+// retryableStatus reports whether code is a gateway or availability error,
+// which an emulator answers while the resource behind a URL is starting
+func retryableStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var verification *tls.CertificateVerificationError
+	return errors.As(err, &unknownAuthority) || errors.As(err, &hostname) || errors.As(err, &invalid) || errors.As(err, &verification)
+}
+
+// AssertStatus fails t unless the status is want, reporting whether it was
+func (r *Response) AssertStatus(t TestingT, want int) bool {
+	t.Helper()
+
+	if r.StatusCode != want {
+		t.Errorf("GET %s: status %d, want %d; body: %s", r.URL, r.StatusCode, want, excerpt(r.Body))
+		return false
+	}
+	return true
+}
+
+// AssertBodyContains fails t unless the body contains substr, reporting
+// whether it did
+func (r *Response) AssertBodyContains(t TestingT, substr string) bool {
+	t.Helper()
+
+	if !strings.Contains(string(r.Body), substr) {
+		t.Errorf("GET %s: body does not contain %q: %s", r.URL, substr, excerpt(r.Body))
+		return false
+	}
+	return true
+}
+
+// AssertHeader fails t unless the header name is want, reporting whether
+// it was
+func (r *Response) AssertHeader(t TestingT, name, want string) bool {
+	t.Helper()
+
+	if got := r.Header.Get(name); got != want {
+		t.Errorf("GET %s: header %s is %q, want %q", r.URL, name, got, want)
+		return false
+	}
+	return true
+}
+
+// maxExcerpt is how much of a body a failure message quotes
+const maxExcerpt = 512
+
+func excerpt(body []byte) string {
+	if len(body) > maxExcerpt {
+		return fmt.Sprintf("%q (%d more bytes)", body[:maxExcerpt], len(body)-maxExcerpt)
+	}
+	return fmt.Sprintf("%q", body)
+}
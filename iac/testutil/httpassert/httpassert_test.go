@@ -0,0 +1,242 @@
+package httpassert_test
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iac/testutil/httpassert"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeT records failures instead of failing the test running it
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Fatalf(format string, args ...interface{}) {
+	f.Errorf(format, args...)
+}
+
+// options are quick to give up, for servers that answer at once
+func options() httpassert.Options {
+	return httpassert.Options{
+		FollowRedirects: true,
+		MaxRedirects:    httpassert.DefaultMaxRedirects,
+		Timeout:         5 * time.Second,
+		RequestTimeout:  time.Second,
+		Interval:        50 * time.Millisecond,
+	}
+}
+
+func hello(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Served-By", "emulator")
+	fmt.Fprint(w, "hello from function url")
+}
+
+// caBundle writes server's self-signed certificate to a PEM file, as an
+// emulator that publishes its CA would
+func caBundle(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
+
+func TestGetWithRetriesSelfSignedCertificate(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(hello))
+	defer server.Close()
+
+	t.Run("rejected by default", func(t *testing.T) {
+		start := time.Now()
+		_, err := httpassert.GetWithRetriesE(context.Background(), server.URL, options())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), httpassert.EnvCABundle, "The error should say how to trust the certificate")
+		assert.Contains(t, err.Error(), httpassert.EnvInsecureSkipVerify)
+		assert.Less(t, time.Since(start), 2*time.Second, "A certificate error should not be retried")
+	})
+
+	t.Run("custom CA", func(t *testing.T) {
+		opts := options()
+		opts.CABundle = caBundle(t, server)
+
+		fake := &fakeT{}
+		resp := httpassert.GetWithRetries(fake, server.URL, opts)
+		require.Empty(t, fake.errors)
+		assert.True(t, resp.AssertStatus(fake, http.StatusOK))
+		assert.True(t, resp.AssertBodyContains(fake, "hello from function url"))
+		assert.True(t, resp.AssertHeader(fake, "X-Served-By", "emulator"))
+		assert.Empty(t, fake.errors)
+	})
+
+	t.Run("skip verify", func(t *testing.T) {
+		opts := options()
+		opts.InsecureSkipVerify = true
+
+		resp, err := httpassert.GetWithRetriesE(context.Background(), server.URL, opts)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, strings.HasPrefix(resp.URL, "https://"), "The request should stay on https")
+	})
+
+	t.Run("bundle without certificates", func(t *testing.T) {
+		opts := options()
+		opts.CABundle = filepath.Join(t.TempDir(), "empty.pem")
+		require.NoError(t, os.WriteFile(opts.CABundle, []byte("not a certificate"), 0o644))
+
+		_, err := httpassert.GetWithRetriesE(context.Background(), server.URL, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no PEM certificates")
+	})
+}
+
+func TestGetWithRetriesConnectionRefused(t *testing.T) {
+	t.Parallel()
+
+	// Reserve a port, then free it so the first attempts are refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(hello))
+	defer server.Close()
+	started := make(chan struct{})
+	go func() {
+		defer close(started)
+		time.Sleep(300 * time.Millisecond)
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		server.Listener = listener
+		server.StartTLS()
+	}()
+
+	opts := options()
+	opts.InsecureSkipVerify = true
+	resp, err := httpassert.GetWithRetriesE(context.Background(), "https://"+addr, opts)
+	<-started
+	require.NoError(t, err, "Refused connections should be retried until the server starts")
+	assert.Equal(t, "hello from function url", string(resp.Body))
+}
+
+func TestGetWithRetriesGivesUp(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	opts := options()
+	opts.Timeout = 500 * time.Millisecond
+	_, err := httpassert.GetWithRetriesE(context.Background(), server.URL, opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "answered 503")
+	assert.Greater(t, calls.Load(), int32(1), "A 503 should be retried")
+
+	fake := &fakeT{}
+	httpassert.GetWithRetries(fake, server.URL, opts)
+	assert.Len(t, fake.errors, 1)
+}
+
+func TestGetWithRetriesRedirects(t *testing.T) {
+	t.Parallel()
+
+	plain := httptest.NewServer(http.HandlerFunc(hello))
+	defer plain.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/hello", http.HandlerFunc(hello))
+	mux.Handle("/moved", http.RedirectHandler("/hello", http.StatusFound))
+	mux.Handle("/downgrade", http.RedirectHandler(plain.URL, http.StatusFound))
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	opts := options()
+	opts.CABundle = caBundle(t, server)
+
+	t.Run("followed", func(t *testing.T) {
+		resp, err := httpassert.GetWithRetriesE(context.Background(), server.URL+"/moved", opts)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, server.URL+"/hello", resp.URL)
+	})
+
+	t.Run("not followed", func(t *testing.T) {
+		opts := opts
+		opts.FollowRedirects = false
+
+		resp, err := httpassert.GetWithRetriesE(context.Background(), server.URL+"/moved", opts)
+		require.NoError(t, err)
+		fake := &fakeT{}
+		assert.True(t, resp.AssertStatus(fake, http.StatusFound))
+		assert.True(t, resp.AssertHeader(fake, "Location", "/hello"))
+	})
+
+	t.Run("downgrade refused", func(t *testing.T) {
+		_, err := httpassert.GetWithRetriesE(context.Background(), server.URL+"/downgrade", opts)
+		require.ErrorIs(t, err, httpassert.ErrRedirect)
+		assert.Contains(t, err.Error(), "downgrades to http")
+	})
+}
+
+func TestResponseAssertionsReportMismatches(t *testing.T) {
+	t.Parallel()
+
+	resp := &httpassert.Response{
+		URL:        "https://example.test/",
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       []byte(strings.Repeat("x", 600)),
+	}
+
+	fake := &fakeT{}
+	assert.False(t, resp.AssertStatus(fake, http.StatusOK))
+	assert.False(t, resp.AssertBodyContains(fake, "hello"))
+	assert.False(t, resp.AssertHeader(fake, "Content-Type", "application/json"))
+	require.Len(t, fake.errors, 3)
+	assert.Contains(t, fake.errors[0], "status 404, want 200")
+	assert.Contains(t, fake.errors[0], "(88 more bytes)", "Long bodies should be cut short")
+	assert.Contains(t, fake.errors[2], `header Content-Type is "text/plain", want "application/json"`)
+}
+
+func TestLoadOptions(t *testing.T) {
+	t.Setenv(httpassert.EnvInsecureSkipVerify, "true")
+	t.Setenv(httpassert.EnvCABundle, "/etc/emulator/ca.pem")
+
+	opts, err := httpassert.LoadOptions()
+	require.NoError(t, err)
+	assert.True(t, opts.InsecureSkipVerify)
+	assert.Equal(t, "/etc/emulator/ca.pem", opts.CABundle)
+	assert.True(t, opts.FollowRedirects)
+	assert.Equal(t, httpassert.DefaultTimeout, opts.Timeout)
+
+	t.Setenv(httpassert.EnvInsecureSkipVerify, "maybe")
+	_, err = httpassert.LoadOptions()
+	assert.ErrorContains(t, err, httpassert.EnvInsecureSkipVerify)
+}
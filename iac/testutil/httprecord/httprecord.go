@@ -0,0 +1,350 @@
+// Package httprecord records the HTTP requests tests and Terraform send to
+// an emulator, so that when CloudEmu behaves differently from AWS the exact
+// calls can be read back. With SWE_RECORD_HTTP=1, each test writes one JSON
+// Exchange a line to $SWE_TEST_ARTIFACT_DIR/<test name>/http.jsonl:
+//
+//	awsConfig, err := cfg.AWSConfig(cfg.CloudEmuEndpoint, httprecord.Transport(t, nil))
+//	vars["emulator_endpoint"] = httprecord.Endpoint(t, cfg.CloudEmuEndpoint)
+//
+// Transport wraps an SDK client's transport. Endpoint puts a pass-through
+// faultproxy in front of the emulator for Terraform's providers to send
+// their requests through. Both return what they were given when recording
+// is off.
+//
+// Exchanges are sanitized before they are written: only SelectedHeaders
+// are kept, Authorization and the other signing headers and query
+// parameters are redacted, as are the values of JSON, XML and form fields
+// whose names Patterns calls secret, and bodies are cut at MaxBody bytes.
+// go run ./tools/httptrace summarizes a trace.
+package httprecord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"iac/testutil/faultproxy"
+	"iac/testutil/tflog"
+)
+
+// EnvRecordHTTP turns recording on when it is "1"
+const EnvRecordHTTP = "SWE_RECORD_HTTP"
+
+// TraceFile is the trace's name in each test's artifact directory
+const TraceFile = "http.jsonl"
+
+// MaxBody is how many bytes of a request or response body are kept
+const MaxBody = 4096
+
+// Exchange is one request and its response
+type Exchange struct {
+	Time time.Time `json:"time"`
+
+	// Service is the AWS service the request was signed for, e.g. s3,
+	// and Action the operation named by X-Amz-Target or an Action
+	// parameter, e.g. PutItem; REST APIs such as S3 have none
+	Service string `json:"service,omitempty"`
+	Action  string `json:"action,omitempty"`
+
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+
+	// Status is 0 when no response arrived, and Error then says why
+	Status    int     `json:"status,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+
+	Request  Message  `json:"request"`
+	Response *Message `json:"response,omitempty"`
+}
+
+// Message is the recorded part of a request or response
+type Message struct {
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+
+	// Size is the body's length in bytes, -1 when it was not declared
+	Size int64 `json:"size"`
+
+	// Truncated is true when Body holds only the first MaxBody bytes
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Failed reports whether the request got no response or an error status
+func (e Exchange) Failed() bool {
+	return e.Error != "" || e.Status >= 400
+}
+
+// Recorder writes sanitized exchanges as JSON lines. It is safe for
+// concurrent use.
+type Recorder struct {
+	redactor *tflog.Redactor
+
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewRecorder returns a Recorder writing to w that also redacts secrets
+// wherever they appear
+func NewRecorder(w io.Writer, secrets ...string) *Recorder {
+	return &Recorder{w: w, redactor: tflog.NewRedactor(secrets...)}
+}
+
+// Add makes r redact secrets as well
+func (r *Recorder) Add(secrets ...string) {
+	r.redactor.Add(secrets...)
+}
+
+// Record sanitizes e and writes it as one line. After a failed write
+// every call returns the first error.
+func (r *Recorder) Record(e Exchange) error {
+	line, err := json.Marshal(sanitize(e, r.redactor))
+	if err != nil {
+		return fmt.Errorf("httprecord: encoding exchange: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == nil {
+		if _, err := r.w.Write(append(line, '\n')); err != nil {
+			r.err = fmt.Errorf("httprecord: writing exchange: %w", err)
+		}
+	}
+	return r.err
+}
+
+// Err returns the first error writing an exchange, if any
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Wrap returns a RoundTripper that sends requests with next and records
+// each exchange. The response is recorded when its headers arrive; the
+// body the caller reads is unchanged.
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	return &transport{next: next, recorder: r}
+}
+
+// transport is a recording http.RoundTripper
+type transport struct {
+	next     http.RoundTripper
+	recorder *Recorder
+}
+
+// RoundTrip implements http.RoundTripper. The request is not modified: a
+// body it reads is replayed to next on a copy.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	e := Exchange{
+		Time:    start.UTC(),
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Query:   req.URL.RawQuery,
+		Request: Message{Headers: flatten(req.Header), Size: req.ContentLength},
+	}
+	e.Request.Headers["Host"] = req.Host
+	if req.Host == "" {
+		e.Request.Headers["Host"] = req.URL.Host
+	}
+
+	out := req
+	if req.Body != nil && req.Body != http.NoBody {
+		prefix, body, err := peek(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("httprecord: reading request body: %w", err)
+		}
+		out = req.Clone(req.Context())
+		out.Body = body
+		e.Request.Body, e.Request.Truncated = text(prefix)
+	}
+
+	resp, err := t.next.RoundTrip(out)
+	e.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		e.Error = err.Error()
+	} else {
+		e.Status = resp.StatusCode
+		e.Response = &Message{Headers: flatten(resp.Header), Size: resp.ContentLength}
+		if resp.Body != nil && resp.Body != http.NoBody {
+			prefix, body, peekErr := peek(resp.Body)
+			resp.Body = body
+			e.Response.Body, e.Response.Truncated = text(prefix)
+			if peekErr != nil {
+				e.Error = peekErr.Error()
+			}
+		}
+	}
+
+	// A failed write is reported when the test finishes, rather than
+	// failing the request
+	_ = t.recorder.Record(e)
+	return resp, err
+}
+
+// flatten joins each header's values with commas
+func flatten(header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		flat[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+	}
+	return flat
+}
+
+// peek reads up to MaxBody+1 bytes of body and returns them with a body
+// that replays them before the rest. An error is returned with what was
+// read, and the replacement body reports it in turn.
+func peek(body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	prefix, err := io.ReadAll(io.LimitReader(body, MaxBody+1))
+	replay := &replayBody{Reader: io.MultiReader(bytes.NewReader(prefix), body), closer: body}
+	if err != nil {
+		replay.Reader = io.MultiReader(bytes.NewReader(prefix), errReader{err})
+	}
+	return prefix, replay, err
+}
+
+// replayBody reads a peeked body back and closes the original
+type replayBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (b *replayBody) Close() error { return b.closer.Close() }
+
+// errReader fails every read with err
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// text returns the recorded form of a peeked body and whether it was cut
+func text(prefix []byte) (string, bool) {
+	truncated := len(prefix) > MaxBody
+	if truncated {
+		prefix = trimPartialRune(prefix[:MaxBody])
+	}
+	if !utf8.Valid(prefix) {
+		return fmt.Sprintf("(%d bytes of binary)", len(prefix)), truncated
+	}
+	return string(prefix), truncated
+}
+
+// trimPartialRune drops a multi-byte character cut off at the end of b,
+// leaving b alone if that does not make it valid UTF-8
+func trimPartialRune(b []byte) []byte {
+	for n := 0; n < utf8.UTFMax && n <= len(b); n++ {
+		if utf8.Valid(b[:len(b)-n]) {
+			return b[:len(b)-n]
+		}
+	}
+	return b
+}
+
+// Enabled reports whether SWE_RECORD_HTTP is 1
+func Enabled() bool {
+	return os.Getenv(EnvRecordHTTP) == "1"
+}
+
+var (
+	// recorders holds the recorder of each test, so its SDK clients and
+	// proxies share one trace
+	recordersMu sync.Mutex
+	recorders   = make(map[*testing.T]*Recorder)
+)
+
+// For returns the recorder of t's trace, creating the file the first
+// time, or nil when recording is off. Recording needs
+// SWE_TEST_ARTIFACT_DIR, so t fails without it.
+func For(t *testing.T) *Recorder {
+	t.Helper()
+
+	if !Enabled() {
+		return nil
+	}
+
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+
+	if r, ok := recorders[t]; ok {
+		return r
+	}
+
+	root := tflog.LoadOptions().ArtifactDir
+	if root == "" {
+		t.Fatalf("httprecord: %s=1 needs %s to write traces to", EnvRecordHTTP, tflog.EnvArtifactDir)
+	}
+	dir := filepath.Join(root, tflog.ArtifactName(t.Name()))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("httprecord: creating %s: %v", dir, err)
+	}
+	file, err := os.Create(filepath.Join(dir, TraceFile))
+	if err != nil {
+		t.Fatalf("httprecord: creating trace: %v", err)
+	}
+
+	r := NewRecorder(file)
+	recorders[t] = r
+	t.Cleanup(func() {
+		recordersMu.Lock()
+		delete(recorders, t)
+		recordersMu.Unlock()
+
+		if err := r.Err(); err != nil {
+			t.Error(err)
+		}
+		if err := file.Close(); err != nil {
+			t.Errorf("httprecord: closing %s: %v", file.Name(), err)
+		}
+	})
+	return r
+}
+
+// Transport returns next, or http.DefaultTransport when next is nil,
+// recording into t's trace; with recording off it returns next as given,
+// so a nil next keeps the client's default
+func Transport(t *testing.T, next http.RoundTripper) http.RoundTripper {
+	t.Helper()
+
+	r := For(t)
+	if r == nil {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return r.Wrap(next)
+}
+
+// Endpoint returns target, a base URL such as http://localhost:4566, or,
+// with recording on, the URL of a faultproxy to target that injects no
+// faults and records every request into t's trace. The proxy runs until t
+// finishes.
+func Endpoint(t *testing.T, target string) string {
+	t.Helper()
+
+	r := For(t)
+	if r == nil {
+		return target
+	}
+
+	proxy, err := faultproxy.New(target, faultproxy.Scenario{Name: "http recording"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(proxy.WithTransport(r.Wrap(http.DefaultTransport)))
+	t.Cleanup(server.Close)
+	return server.URL
+}
@@ -0,0 +1,199 @@
+package httprecord_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"iac/testutil/httprecord"
+	"iac/testutil/tflog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backend answers every request with response, echoing the request body
+// quoted in a header
+func backend(t *testing.T, response string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Header().Set("X-Amzn-Requestid", "req-1")
+		w.Header().Set("Content-Length", strconv.Itoa(len(response)))
+		w.Header().Set("X-Echo-Body", strconv.Quote(string(body)))
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTransportRecordsExchange(t *testing.T) {
+	t.Parallel()
+
+	server := backend(t, `{"TableName":"orders"}`)
+	var trace bytes.Buffer
+	client := &http.Client{Transport: httprecord.NewRecorder(&trace).Wrap(http.DefaultTransport)}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/", strings.NewReader(`{"TableName":"orders"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260101/us-east-1/dynamodb/aws4_request, SignedHeaders=host, Signature=abc123")
+	req.Header.Set("X-Amz-Target", "DynamoDB_20120810.DescribeTable")
+	req.Header.Set("X-Amz-Content-Sha256", "e3b0c442")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, `{"TableName":"orders"}`, string(body), "The caller should read the whole response")
+	assert.Equal(t, strconv.Quote(`{"TableName":"orders"}`), resp.Header.Get("X-Echo-Body"), "The server should get the whole request")
+
+	exchanges, err := httprecord.Read(&trace)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+	e := exchanges[0]
+
+	assert.Equal(t, "dynamodb", e.Service, "The service comes from the credential scope")
+	assert.Equal(t, "DescribeTable", e.Action)
+	assert.Equal(t, http.MethodPost, e.Method)
+	assert.Equal(t, "/", e.Path)
+	assert.Equal(t, http.StatusOK, e.Status)
+	assert.False(t, e.Failed())
+	assert.Positive(t, e.LatencyMS)
+
+	assert.Equal(t, `{"TableName":"orders"}`, e.Request.Body)
+	assert.Equal(t, int64(len(`{"TableName":"orders"}`)), e.Request.Size)
+	assert.Equal(t, tflog.Redacted(req.Header.Get("Authorization")), e.Request.Headers["Authorization"])
+	assert.NotContains(t, e.Request.Headers, "X-Amz-Content-Sha256", "Only selected headers are kept")
+	assert.Equal(t, "DynamoDB_20120810.DescribeTable", e.Request.Headers["X-Amz-Target"])
+
+	require.NotNil(t, e.Response)
+	assert.Equal(t, `{"TableName":"orders"}`, e.Response.Body)
+	assert.Equal(t, "req-1", e.Response.Headers["X-Amzn-Requestid"])
+	assert.NotContains(t, e.Response.Headers, "X-Echo-Body")
+}
+
+func TestTransportTruncatesBodies(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("é", httprecord.MaxBody)
+	server := backend(t, large)
+	var trace bytes.Buffer
+	client := &http.Client{Transport: httprecord.NewRecorder(&trace).Wrap(http.DefaultTransport)}
+
+	resp, err := client.Post(server.URL+"/bucket/object", "application/octet-stream", bytes.NewReader([]byte{0xff, 0xfe, 0x00, 0x01}))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, large, string(body), "The caller should read past what was recorded")
+
+	exchanges, err := httprecord.Read(&trace)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+
+	assert.Equal(t, "(4 bytes of binary)", exchanges[0].Request.Body)
+	assert.False(t, exchanges[0].Request.Truncated)
+
+	response := exchanges[0].Response
+	assert.True(t, response.Truncated)
+	assert.Equal(t, strings.Repeat("é", httprecord.MaxBody/2), response.Body, "A body is cut at MaxBody bytes, on a character boundary")
+	assert.Equal(t, int64(len(large)), response.Size)
+}
+
+func TestTransportRecordsErrors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	var trace bytes.Buffer
+	client := &http.Client{Transport: httprecord.NewRecorder(&trace).Wrap(http.DefaultTransport)}
+	_, err := client.Get(url + "/queue")
+	require.Error(t, err)
+
+	exchanges, err := httprecord.Read(&trace)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+	assert.Zero(t, exchanges[0].Status)
+	assert.Contains(t, exchanges[0].Error, "connection refused")
+	assert.Nil(t, exchanges[0].Response)
+	assert.True(t, exchanges[0].Failed())
+}
+
+func TestRecorderRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	var trace bytes.Buffer
+	recorder := httprecord.NewRecorder(&trace, "hunter2-database")
+
+	require.NoError(t, recorder.Record(httprecord.Exchange{
+		Method: http.MethodPost,
+		Path:   "/",
+		Request: httprecord.Message{
+			Headers: map[string]string{"Content-Type": "application/x-amz-json-1.1"},
+			Body:    `{"Name":"db","SecretString":"hunter2-database"}`,
+		},
+	}))
+
+	exchanges, err := httprecord.Read(&trace)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+	assert.Equal(t, `{"Name":"db","SecretString":"`+tflog.Redacted("hunter2-database")+`"}`, exchanges[0].Request.Body)
+	assert.NotContains(t, trace.String(), "hunter2")
+}
+
+func TestDisabledReturnsInputs(t *testing.T) {
+	t.Setenv(httprecord.EnvRecordHTTP, "")
+
+	assert.False(t, httprecord.Enabled())
+	assert.Nil(t, httprecord.For(t))
+	assert.Nil(t, httprecord.Transport(t, nil), "A nil transport keeps the client's default")
+	assert.Same(t, http.DefaultTransport, httprecord.Transport(t, http.DefaultTransport))
+	assert.Equal(t, "http://localhost:4566", httprecord.Endpoint(t, "http://localhost:4566"))
+}
+
+func TestEndpointRecordsIntoArtifactDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(httprecord.EnvRecordHTTP, "1")
+	t.Setenv(tflog.EnvArtifactDir, dir)
+
+	server := backend(t, `{}`)
+
+	t.Run("terraform/apply", func(t *testing.T) {
+		endpoint := httprecord.Endpoint(t, server.URL)
+		require.NotEqual(t, server.URL, endpoint, "Requests should go through a recording proxy")
+
+		resp, err := http.Get(endpoint + "/bucket")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		client := &http.Client{Transport: httprecord.Transport(t, nil)}
+		resp, err = client.Get(server.URL + "/queue")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Same(t, httprecord.For(t), httprecord.For(t), "A test's clients and proxies share one trace")
+	})
+
+	f, err := os.Open(filepath.Join(dir, "TestEndpointRecordsIntoArtifactDir", "terraform", "apply", httprecord.TraceFile))
+	require.NoError(t, err)
+	defer f.Close()
+
+	exchanges, err := httprecord.Read(f)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 2)
+	assert.Equal(t, "/bucket", exchanges[0].Path)
+	assert.Equal(t, "/queue", exchanges[1].Path)
+}
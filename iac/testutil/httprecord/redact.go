@@ -0,0 +1,185 @@
+package httprecord
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"iac/testutil/sensitivecheck"
+	"iac/testutil/tflog"
+)
+
+// SelectedHeaders are the headers kept in a trace: what says which API and
+// operation was called, by what, and how the emulator answered. The rest,
+// such as X-Amz-Content-Sha256, are noise when reading one.
+var SelectedHeaders = []string{
+	"Authorization",
+	"Content-Type",
+	"Host",
+	"Location",
+	"User-Agent",
+	"X-Amz-Security-Token",
+	"X-Amz-Target",
+	"X-Amz-Request-Id",
+	"X-Amzn-Requestid",
+	"X-Amzn-Errortype",
+}
+
+// signing names the headers and query parameters that carry credentials
+// or signatures; they are redacted whatever their names say
+var signing = map[string]bool{
+	"authorization":        true,
+	"x-amz-security-token": true,
+	"x-amz-credential":     true,
+	"x-amz-signature":      true,
+}
+
+// Patterns decide which field names hold secrets: sensitivecheck's, less
+// the AWS names that only say key or token, such as an S3 object Key or a
+// NextToken, which a trace needs to be read
+var Patterns = func() sensitivecheck.Patterns {
+	p := sensitivecheck.DefaultPatterns
+	p.Allow = append(append([]string(nil), p.Allow...),
+		"Key", "KeyMarker", "NextKeyMarker", "KeyConditionExpression",
+		"NextToken", "ContinuationToken", "NextContinuationToken",
+		"ClientToken", "ClientRequestToken", "StartAfter",
+	)
+	return p
+}()
+
+var (
+	// credentialScope is the date/region/service/aws4_request part of a
+	// SigV4 Authorization header
+	credentialScope = regexp.MustCompile(`Credential=[^/,\s]+/[0-9]{8}/[^/]+/([^/]+)/aws4_request`)
+
+	jsonString  = regexp.MustCompile(`"([^"\\]+)"(\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+	xmlElement  = regexp.MustCompile(`<([A-Za-z_][A-Za-z0-9_.:-]*)>([^<]*)</([A-Za-z_][A-Za-z0-9_.:-]*)>`)
+	formContent = "application/x-www-form-urlencoded"
+)
+
+// sanitize returns e as it is written: the service and action read off
+// its request, only SelectedHeaders, and secrets redacted
+func sanitize(e Exchange, redactor *tflog.Redactor) Exchange {
+	if m := credentialScope.FindStringSubmatch(e.Request.Headers["Authorization"]); m != nil {
+		e.Service = m[1]
+	}
+	e.Action = action(e)
+
+	e.Query = redactForm(e.Query)
+	e.Request = sanitizeMessage(e.Request)
+	if e.Response != nil {
+		response := sanitizeMessage(*e.Response)
+		e.Response = &response
+	}
+
+	e.Path = redactor.Redact(e.Path)
+	e.Query = redactor.Redact(e.Query)
+	e.Error = redactor.Redact(e.Error)
+	for _, m := range []*Message{&e.Request, e.Response} {
+		if m == nil {
+			continue
+		}
+		m.Body = redactor.Redact(m.Body)
+		for name, value := range m.Headers {
+			m.Headers[name] = redactor.Redact(value)
+		}
+	}
+	return e
+}
+
+// action is the operation an X-Amz-Target header, e.g.
+// DynamoDB_20120810.PutItem, or an Action parameter in the query or a
+// form body names
+func action(e Exchange) string {
+	if target := e.Request.Headers["X-Amz-Target"]; target != "" {
+		return target[strings.LastIndex(target, ".")+1:]
+	}
+	if values, err := url.ParseQuery(e.Query); err == nil && values.Get("Action") != "" {
+		return values.Get("Action")
+	}
+	if strings.HasPrefix(e.Request.Headers["Content-Type"], formContent) {
+		if values, err := url.ParseQuery(e.Request.Body); err == nil {
+			return values.Get("Action")
+		}
+	}
+	return ""
+}
+
+// sanitizeMessage keeps m's SelectedHeaders, redacting the signing ones,
+// and redacts secret fields in its body
+func sanitizeMessage(m Message) Message {
+	headers := make(map[string]string, len(SelectedHeaders))
+	for _, name := range SelectedHeaders {
+		value, ok := m.Headers[name]
+		if !ok {
+			continue
+		}
+		if signing[strings.ToLower(name)] {
+			value = tflog.Redacted(value)
+		}
+		headers[name] = value
+	}
+	m.Headers = headers
+
+	if strings.HasPrefix(headers["Content-Type"], formContent) {
+		m.Body = redactForm(m.Body)
+	}
+	m.Body = redactJSON(m.Body)
+	m.Body = redactXML(m.Body)
+	return m
+}
+
+// secret reports whether a field called name holds a credential or a
+// secret
+func secret(name string) bool {
+	return signing[strings.ToLower(name)] || Patterns.SensitiveName(name)
+}
+
+// redactForm redacts the values of secret parameters in a query string or
+// form body, leaving the rest as written
+func redactForm(form string) string {
+	if form == "" {
+		return form
+	}
+	pairs := strings.Split(form, "&")
+	for i, pair := range pairs {
+		rawName, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		name := rawName
+		if unescaped, err := url.QueryUnescape(rawName); err == nil {
+			name = unescaped
+		}
+		if secret(name) {
+			if unescaped, err := url.QueryUnescape(value); err == nil {
+				value = unescaped
+			}
+			pairs[i] = rawName + "=" + tflog.Redacted(value)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// redactJSON redacts the string values of secret members. It works on
+// text, so a body cut at MaxBody is redacted too.
+func redactJSON(body string) string {
+	return jsonString.ReplaceAllStringFunc(body, func(member string) string {
+		m := jsonString.FindStringSubmatch(member)
+		if !secret(m[1]) {
+			return member
+		}
+		return `"` + m[1] + `"` + m[2] + `"` + tflog.Redacted(m[3]) + `"`
+	})
+}
+
+// redactXML redacts the text of secret elements
+func redactXML(body string) string {
+	return xmlElement.ReplaceAllStringFunc(body, func(element string) string {
+		m := xmlElement.FindStringSubmatch(element)
+		if m[1] != m[3] || !secret(m[1]) {
+			return element
+		}
+		return "<" + m[1] + ">" + tflog.Redacted(m[2]) + "</" + m[3] + ">"
+	})
+}
@@ -0,0 +1,122 @@
+package httprecord_test
+
+import (
+	"bytes"
+	"testing"
+
+	"iac/testutil/httprecord"
+	"iac/testutil/tflog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// record writes e through a Recorder and reads it back
+func record(t *testing.T, e httprecord.Exchange) httprecord.Exchange {
+	t.Helper()
+
+	var trace bytes.Buffer
+	require.NoError(t, httprecord.NewRecorder(&trace).Record(e))
+	exchanges, err := httprecord.Read(&trace)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+	return exchanges[0]
+}
+
+func TestRedactSigningHeaders(t *testing.T) {
+	t.Parallel()
+
+	e := record(t, httprecord.Exchange{
+		Method: "GET",
+		Path:   "/bucket",
+		Request: httprecord.Message{Headers: map[string]string{
+			"Authorization":        "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260101/eu-west-1/s3/aws4_request, Signature=abc",
+			"X-Amz-Security-Token": "session-token-value",
+			"Cookie":               "session=1",
+		}},
+	})
+
+	assert.Equal(t, "s3", e.Service)
+	assert.Equal(t, map[string]string{
+		"Authorization":        tflog.Redacted("AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260101/eu-west-1/s3/aws4_request, Signature=abc"),
+		"X-Amz-Security-Token": tflog.Redacted("session-token-value"),
+	}, e.Request.Headers)
+}
+
+func TestRedactPresignedQuery(t *testing.T) {
+	t.Parallel()
+
+	e := record(t, httprecord.Exchange{
+		Method: "GET",
+		Path:   "/bucket/report.csv",
+		Query:  "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=AKIDEXAMPLE%2F20260101%2Fus-east-1%2Fs3%2Faws4_request&X-Amz-Signature=abc123&versionId=3",
+	})
+
+	assert.Equal(t, "X-Amz-Algorithm=AWS4-HMAC-SHA256"+
+		"&X-Amz-Credential="+tflog.Redacted("AKIDEXAMPLE/20260101/us-east-1/s3/aws4_request")+
+		"&X-Amz-Signature="+tflog.Redacted("abc123")+
+		"&versionId=3", e.Query)
+}
+
+func TestRedactBodies(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		contentType string
+		body        string
+		want        string
+		action      string
+	}{
+		"JSON": {
+			contentType: "application/x-amz-json-1.1",
+			body:        `{"SecretId":"db","SecretString" : "p4ssw0rd!","NextToken":"page-2"}`,
+			want:        `{"SecretId":"db","SecretString" : "` + tflog.Redacted("p4ssw0rd!") + `","NextToken":"page-2"}`,
+		},
+		"JSONCutAtMaxBody": {
+			contentType: "application/json",
+			body:        `{"Environment":{"Variables":{"API_KEY":"abcdef123456"}},"Code":{"ZipFile":"UEsDBBQ`,
+			want:        `{"Environment":{"Variables":{"API_KEY":"` + tflog.Redacted("abcdef123456") + `"}},"Code":{"ZipFile":"UEsDBBQ`,
+		},
+		"XML": {
+			contentType: "text/xml",
+			body:        `<ListBucketResult><Contents><Key>logs/app.log</Key></Contents><Password>p4ssw0rd!</Password></ListBucketResult>`,
+			want:        `<ListBucketResult><Contents><Key>logs/app.log</Key></Contents><Password>` + tflog.Redacted("p4ssw0rd!") + `</Password></ListBucketResult>`,
+		},
+		"Form": {
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			body:        "Action=CreateUser&UserName=ci&Password=p4ssw0rd%21&Version=2010-05-08",
+			want:        "Action=CreateUser&UserName=ci&Password=" + tflog.Redacted("p4ssw0rd!") + "&Version=2010-05-08",
+			action:      "CreateUser",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			e := record(t, httprecord.Exchange{
+				Method: "POST",
+				Path:   "/",
+				Request: httprecord.Message{
+					Headers: map[string]string{"Content-Type": tc.contentType},
+					Body:    tc.body,
+				},
+			})
+			assert.Equal(t, tc.want, e.Request.Body)
+			assert.Equal(t, tc.action, e.Action)
+		})
+	}
+}
+
+func TestPatternsKeepAWSNames(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"Key", "NextToken", "ContinuationToken", "KeyConditionExpression"} {
+		assert.False(t, httprecord.Patterns.SensitiveName(name), name)
+	}
+	for _, name := range []string{"SecretString", "Password", "SecretAccessKey", "api_key"} {
+		assert.True(t, httprecord.Patterns.SensitiveName(name), name)
+	}
+}
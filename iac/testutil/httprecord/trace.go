@@ -0,0 +1,179 @@
+package httprecord
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Read decodes a trace, one Exchange a line; blank lines are skipped
+func Read(r io.Reader) ([]Exchange, error) {
+	var exchanges []Exchange
+	scanner := bufio.NewScanner(r)
+	// A line holds two bodies of up to MaxBody bytes, escaped
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e Exchange
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("httprecord: line %d: %w", n, err)
+		}
+		exchanges = append(exchanges, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("httprecord: reading trace: %w", err)
+	}
+	return exchanges, nil
+}
+
+// Filter keeps the exchanges matching every one of its fields: service,
+// action, method, status (e.g. 404 or 4xx), path (a substring) and
+// failed (true or false)
+type Filter map[string]string
+
+// FilterKeys are the fields a Filter may hold
+var FilterKeys = []string{"service", "action", "method", "status", "path", "failed"}
+
+// Parse adds key=value, as given to httptrace --filter, to f
+func (f Filter) Parse(expr string) error {
+	key, value, ok := strings.Cut(expr, "=")
+	if !ok || value == "" {
+		return fmt.Errorf("httprecord: filter %q is not key=value", expr)
+	}
+	for _, k := range FilterKeys {
+		if k == key {
+			f[key] = value
+			return nil
+		}
+	}
+	return fmt.Errorf("httprecord: filter key %q is not one of: %s", key, strings.Join(FilterKeys, ", "))
+}
+
+// Match reports whether e matches every field of f
+func (f Filter) Match(e Exchange) bool {
+	for key, want := range f {
+		var ok bool
+		switch key {
+		case "service":
+			ok = strings.EqualFold(e.Service, want)
+		case "action":
+			ok = strings.EqualFold(e.Action, want)
+		case "method":
+			ok = strings.EqualFold(e.Method, want)
+		case "status":
+			status := strconv.Itoa(e.Status)
+			ok = status == want || len(want) == 3 && strings.HasSuffix(strings.ToLower(want), "xx") && status[:1] == want[:1]
+		case "path":
+			ok = strings.Contains(e.Path, want)
+		case "failed":
+			ok = strconv.FormatBool(e.Failed()) == strings.ToLower(want)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the exchanges f matches, in order
+func (f Filter) Apply(exchanges []Exchange) []Exchange {
+	var matched []Exchange
+	for _, e := range exchanges {
+		if f.Match(e) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// operation names what an exchange called: its action, or for a REST API
+// its method and path
+func operation(e Exchange) string {
+	if e.Action != "" {
+		return e.Action
+	}
+	return e.Method + " " + e.Path
+}
+
+// WriteSummary writes one row per exchange, timed from the first, and
+// then the count, failures and latency of each service's operations,
+// slowest total first:
+//
+//	+0.000s  s3        PUT /bucket  200  12.3ms
+//	...
+//	SERVICE  OPERATION    CALLS  FAILED  TOTAL   MAX
+//	s3       PUT /bucket  3      0       40.1ms  15.0ms
+func WriteSummary(w io.Writer, exchanges []Exchange) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	type key struct{ service, operation string }
+	type totals struct {
+		calls, failed int
+		total, max    float64
+	}
+	byOperation := make(map[key]*totals)
+
+	var start time.Time
+	for i, e := range exchanges {
+		if i == 0 {
+			start = e.Time
+		}
+		status := strconv.Itoa(e.Status)
+		if e.Error != "" {
+			status = "error: " + e.Error
+		}
+		fmt.Fprintf(tw, "+%.3fs\t%s\t%s\t%s\t%.1fms\n", e.Time.Sub(start).Seconds(), e.Service, operation(e), status, e.LatencyMS)
+
+		k := key{e.Service, operation(e)}
+		t, ok := byOperation[k]
+		if !ok {
+			t = &totals{}
+			byOperation[k] = t
+		}
+		t.calls++
+		if e.Failed() {
+			t.failed++
+		}
+		t.total += e.LatencyMS
+		if e.LatencyMS > t.max {
+			t.max = e.LatencyMS
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	keys := make([]key, 0, len(byOperation))
+	for k := range byOperation {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := byOperation[keys[i]], byOperation[keys[j]]
+		if a.total != b.total {
+			return a.total > b.total
+		}
+		if keys[i].service != keys[j].service {
+			return keys[i].service < keys[j].service
+		}
+		return keys[i].operation < keys[j].operation
+	})
+
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	fmt.Fprintln(tw, "SERVICE\tOPERATION\tCALLS\tFAILED\tTOTAL\tMAX")
+	for _, k := range keys {
+		t := byOperation[k]
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.1fms\t%.1fms\n", k.service, k.operation, t.calls, t.failed, t.total, t.max)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,96 @@
+package httprecord_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/httprecord"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+var exchanges = []httprecord.Exchange{
+	{Time: start, Service: "s3", Method: "PUT", Path: "/bucket", Status: 200, LatencyMS: 12.5},
+	{Time: start.Add(250 * time.Millisecond), Service: "sqs", Action: "CreateQueue", Method: "POST", Path: "/", Status: 400, LatencyMS: 3},
+	{Time: start.Add(500 * time.Millisecond), Service: "s3", Method: "PUT", Path: "/bucket", Status: 503, LatencyMS: 20},
+	{Time: start.Add(time.Second), Service: "s3", Method: "GET", Path: "/bucket/key", Error: "connection reset by peer", LatencyMS: 1},
+}
+
+func TestReadSkipsBlankLines(t *testing.T) {
+	t.Parallel()
+
+	got, err := httprecord.Read(strings.NewReader("\n" + `{"method":"GET","path":"/","status":200,"latency_ms":1,"request":{"size":0}}` + "\n\n"))
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "GET", got[0].Method)
+
+	_, err = httprecord.Read(strings.NewReader(`{"method":"GET"}` + "\nnot json\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		filters []string
+		want    []int
+	}{
+		"Service":     {[]string{"service=S3"}, []int{0, 2, 3}},
+		"Action":      {[]string{"action=createqueue"}, []int{1}},
+		"StatusClass": {[]string{"status=5xx"}, []int{2}},
+		"Status":      {[]string{"status=200"}, []int{0}},
+		"Path":        {[]string{"path=/bucket/"}, []int{3}},
+		"Failed":      {[]string{"failed=true"}, []int{1, 2, 3}},
+		"All":         {[]string{"service=s3", "method=put", "failed=false"}, []int{0}},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			filter := httprecord.Filter{}
+			for _, f := range tc.filters {
+				require.NoError(t, filter.Parse(f))
+			}
+
+			var want []httprecord.Exchange
+			for _, i := range tc.want {
+				want = append(want, exchanges[i])
+			}
+			assert.Equal(t, want, filter.Apply(exchanges))
+		})
+	}
+}
+
+func TestFilterParseRejects(t *testing.T) {
+	t.Parallel()
+
+	filter := httprecord.Filter{}
+	assert.ErrorContains(t, filter.Parse("service"), "is not key=value")
+	assert.ErrorContains(t, filter.Parse("region=us-east-1"), `filter key "region" is not one of: service, action`)
+}
+
+func TestWriteSummary(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	require.NoError(t, httprecord.WriteSummary(&out, exchanges))
+
+	assert.Equal(t, ""+
+		"+0.000s  s3   PUT /bucket      200                              12.5ms\n"+
+		"+0.250s  sqs  CreateQueue      400                              3.0ms\n"+
+		"+0.500s  s3   PUT /bucket      503                              20.0ms\n"+
+		"+1.000s  s3   GET /bucket/key  error: connection reset by peer  1.0ms\n"+
+		"\n"+
+		"SERVICE  OPERATION        CALLS  FAILED  TOTAL   MAX\n"+
+		"s3       PUT /bucket      2      1       32.5ms  20.0ms\n"+
+		"sqs      CreateQueue      1      1       3.0ms   3.0ms\n"+
+		"s3       GET /bucket/key  1      1       1.0ms   1.0ms\n", out.String())
+}
@@ -0,0 +1,258 @@
+// Package importcheck verifies that existing resources can be adopted by a
+// facade with `terraform import`. Adoption only works if the facade, given
+// variables describing the resource, plans no change to it afterwards;
+// anything else means the next apply rewrites or recreates a resource the
+// team already depends on.
+//
+// The one difference tolerated is added tags: resources created outside
+// Terraform rarely carry the project/environment/managed_by tags the facades
+// apply, and adding them is an in-place update.
+package importcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// tagAttributes are the attributes a tag-only update may change
+var tagAttributes = map[string]bool{"tags": true, "tags_all": true}
+
+// AttributeDiff is one top-level attribute a planned update changes
+type AttributeDiff struct {
+	Name   string
+	Before interface{}
+	After  interface{}
+
+	// Unknown is true when the new value is only known after apply
+	Unknown bool
+
+	// Sensitive hides both values in reports
+	Sensitive bool
+}
+
+func (d AttributeDiff) String() string {
+	if d.Sensitive {
+		return d.Name + ": (sensitive)"
+	}
+	after := render(d.After)
+	if d.Unknown {
+		after = "(known after apply)"
+	}
+	return fmt.Sprintf("%s: %s -> %s", d.Name, render(d.Before), after)
+}
+
+// Change is a planned change to a resource that an import should have left
+// untouched
+type Change struct {
+	Address string
+	Actions []string
+
+	// Attributes lists what an update changes; it is empty for a create,
+	// delete or replace
+	Attributes []AttributeDiff
+}
+
+type plan struct {
+	FormatVersion   string `json:"format_version"`
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			Actions         []string               `json:"actions"`
+			Before          map[string]interface{} `json:"before"`
+			After           map[string]interface{} `json:"after"`
+			AfterUnknown    map[string]interface{} `json:"after_unknown"`
+			BeforeSensitive interface{}            `json:"before_sensitive"`
+			AfterSensitive  interface{}            `json:"after_sensitive"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// Residual returns the changes in planJSON, the `terraform show -json`
+// output for a plan, other than no-ops, reads and updates that only add tags
+func Residual(planJSON []byte) ([]Change, error) {
+	var p plan
+	if err := json.Unmarshal(planJSON, &p); err != nil {
+		return nil, fmt.Errorf("importcheck: decoding plan JSON: %w", err)
+	}
+	if p.FormatVersion == "" {
+		return nil, fmt.Errorf("importcheck: not terraform show -json output (no format_version field)")
+	}
+
+	var changes []Change
+	for _, rc := range p.ResourceChanges {
+		actions := rc.Change.Actions
+		if len(actions) == 1 && (actions[0] == "no-op" || actions[0] == "read") {
+			continue
+		}
+
+		change := Change{Address: rc.Address, Actions: actions}
+		if len(actions) == 1 && actions[0] == "update" {
+			before, after := rc.Change.Before, rc.Change.After
+			names := make(map[string]bool)
+			for name := range before {
+				names[name] = true
+			}
+			for name := range after {
+				names[name] = true
+			}
+			for name := range rc.Change.AfterUnknown {
+				names[name] = true
+			}
+
+			for name := range names {
+				unknown := rc.Change.AfterUnknown[name] == true
+				if !unknown && reflect.DeepEqual(before[name], after[name]) {
+					continue
+				}
+				change.Attributes = append(change.Attributes, AttributeDiff{
+					Name:      name,
+					Before:    before[name],
+					After:     after[name],
+					Unknown:   unknown,
+					Sensitive: isSensitive(rc.Change.BeforeSensitive, name) || isSensitive(rc.Change.AfterSensitive, name),
+				})
+			}
+			sort.Slice(change.Attributes, func(i, j int) bool {
+				return change.Attributes[i].Name < change.Attributes[j].Name
+			})
+
+			if onlyAddsTags(change.Attributes) {
+				continue
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// onlyAddsTags reports whether diffs are all tag maps that keep their
+// existing tags
+func onlyAddsTags(diffs []AttributeDiff) bool {
+	if len(diffs) == 0 {
+		return false
+	}
+	for _, d := range diffs {
+		if !tagAttributes[d.Name] {
+			return false
+		}
+		if d.Unknown {
+			// tags_all is recomputed whenever tags change
+			continue
+		}
+		before, _ := d.Before.(map[string]interface{})
+		after, _ := d.After.(map[string]interface{})
+		for k, v := range before {
+			if after[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isSensitive reports whether the top-level attribute name is marked, in
+// whole or in part, in a before_sensitive or after_sensitive value
+func isSensitive(marks interface{}, name string) bool {
+	switch m := marks.(type) {
+	case bool:
+		return m
+	case map[string]interface{}:
+		return anyMarked(m[name])
+	default:
+		return false
+	}
+}
+
+// anyMarked reports whether a sensitivity mark structure holds any true
+func anyMarked(marks interface{}) bool {
+	switch m := marks.(type) {
+	case bool:
+		return m
+	case map[string]interface{}:
+		for _, v := range m {
+			if anyMarked(v) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range m {
+			if anyMarked(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Report formats changes for a test failure, one resource per line followed
+// by its changed attributes:
+//
+//	module.aws_storage[0].aws_s3_bucket.this (update)
+//	  force_destroy: false -> true
+func Report(changes []Change) string {
+	var b strings.Builder
+	for _, c := range changes {
+		fmt.Fprintf(&b, "%s (%s)\n", c.Address, strings.Join(c.Actions, ", "))
+		for _, d := range c.Attributes {
+			fmt.Fprintf(&b, "  %s\n", d)
+		}
+	}
+	return b.String()
+}
+
+// ImportAndAssertClean imports the existing object id to address in the
+// workspace at options.TerraformDir, then plans the workspace and fails t
+// unless the plan is empty apart from added tags, reporting the residual
+// diff otherwise. options.Vars are passed to the import as well as the plan,
+// since facades need them to evaluate the resource's configuration.
+func ImportAndAssertClean(t *testing.T, options *terraform.Options, address, id string) bool {
+	t.Helper()
+
+	terraform.Init(t, options)
+
+	// Options come before the address and ID, which FormatArgs would put
+	// first
+	args := []string{"import", "-input=false"}
+	if options.NoColor {
+		args = append(args, "-no-color")
+	}
+	args = append(args, terraform.FormatTerraformVarsAsArgs(options.Vars)...)
+	args = append(args, address, id)
+	tfopts.RequireWritable(t, options, args...)
+	terraform.RunTerraformCommand(t, options, args...)
+
+	planOptions := *options
+	planOptions.PlanFilePath = filepath.Join(t.TempDir(), "import.plan")
+	planJSON := terraform.InitAndPlanAndShow(t, &planOptions)
+
+	changes, err := Residual([]byte(planJSON))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(changes) > 0 {
+		t.Errorf("plan after importing %s as %s is not clean:\n%s", id, address, Report(changes))
+		return false
+	}
+	return true
+}
+
+// render formats an attribute value the way terraform's JSON plan holds it
+func render(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,80 @@
+package importcheck_test
+
+import (
+	"os"
+	"testing"
+
+	"iac/testutil/importcheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func residual(t *testing.T, path string) []importcheck.Change {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	changes, err := importcheck.Residual(data)
+	require.NoError(t, err)
+	return changes
+}
+
+func TestResidualIgnoresNoOpsAndReads(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, residual(t, "testdata/clean.json"))
+}
+
+func TestResidualToleratesAddedTags(t *testing.T) {
+	t.Parallel()
+
+	// Tags are added while the existing owner tag is kept, and tags_all is
+	// only known after apply
+	assert.Empty(t, residual(t, "testdata/tags-added.json"))
+}
+
+func TestResidualReportsDrift(t *testing.T) {
+	t.Parallel()
+
+	changes := residual(t, "testdata/drift.json")
+	require.Len(t, changes, 2)
+
+	bucket := changes[0]
+	assert.Equal(t, "module.aws_storage[0].aws_s3_bucket.this", bucket.Address)
+	assert.Equal(t, []string{"update"}, bucket.Actions)
+
+	var names []string
+	for _, d := range bucket.Attributes {
+		names = append(names, d.Name)
+	}
+	assert.Equal(t, []string{"force_destroy", "object_lock_token", "tags", "tags_all"}, names,
+		"Unchanged attributes should be left out, in name order")
+
+	versioning := changes[1]
+	assert.Equal(t, []string{"create"}, versioning.Actions)
+	assert.Empty(t, versioning.Attributes)
+}
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	want := `module.aws_storage[0].aws_s3_bucket.this (update)
+  force_destroy: false -> true
+  object_lock_token: (sensitive)
+  tags: {"owner":"data-team"} -> {"project":"adopt"}
+  tags_all: {"owner":"data-team"} -> (known after apply)
+module.aws_storage[0].aws_s3_bucket_versioning.this[0] (create)
+`
+	assert.Equal(t, want, importcheck.Report(residual(t, "testdata/drift.json")))
+}
+
+func TestResidualRejectsOtherJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := importcheck.Residual([]byte(`{"resource_changes": []}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no format_version")
+
+	_, err = importcheck.Residual([]byte(`No changes.`))
+	assert.Error(t, err)
+}
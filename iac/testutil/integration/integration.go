@@ -0,0 +1,119 @@
+// Package integration decides what an integration test does when its
+// emulator is not running. By default it skips, so `go test -tags
+// integration ./...` on a laptop without CloudEmu stays green; with
+// SWE_REQUIRE_INTEGRATION set it fails instead, so a CI job whose emulator
+// never came up cannot pass having run nothing:
+//
+//	cfg := config.Load(t)
+//	integration.Require(t, "CloudEmu", integration.StartCloudEmu,
+//		integration.HTTP(cfg.CloudEmuEndpoint+"/health", http.StatusOK))
+//
+// The tests that deploy to an emulator carry the integration build tag, so
+// plain `go test ./...` only runs the plan-only layer. tools/testrunner
+// picks the tags, packages and timeout of each layer.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"iac/testutil/config"
+)
+
+// How to start each emulator, for the skip and failure messages
+const (
+	StartCloudEmu = "cd cloudemu && cargo run --release -p cloudemu-server"
+	StartZero     = "cd cloudemu/zero && cargo run"
+)
+
+// ProbeTimeout bounds each health check
+const ProbeTimeout = 2 * time.Second
+
+// EnvRequireIntegration turns the skip for a missing emulator into a failure
+const EnvRequireIntegration = "SWE_REQUIRE_INTEGRATION"
+
+// Options configures Require
+type Options struct {
+	// Required fails tests whose emulator is not running instead of
+	// skipping them
+	Required bool
+}
+
+// LoadOptions reads Options from SWE_REQUIRE_INTEGRATION
+func LoadOptions() (Options, error) {
+	required, err := config.BoolFromEnv(EnvRequireIntegration)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{Required: required}, nil
+}
+
+// Probe returns nil when the emulator answers
+type Probe func(ctx context.Context) error
+
+// TestingT is the subset of testing.TB the package needs; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Logf(format string, args ...interface{})
+	Skipf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// HTTP probes url with a GET, which must answer with one of statuses. With
+// no statuses any response will do.
+func HTTP(url string, statuses ...int) Probe {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if len(statuses) > 0 && !slices.Contains(statuses, resp.StatusCode) {
+			return fmt.Errorf("GET %s: %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// Require is Options.Require with the Options from LoadOptions
+func Require(t TestingT, emulator, start string, probe Probe) {
+	t.Helper()
+
+	opts, err := LoadOptions()
+	if err != nil {
+		t.Fatalf("%v", err)
+		return
+	}
+	opts.Require(t, emulator, start, probe)
+}
+
+// Require runs probe and, unless it succeeds within ProbeTimeout, skips t,
+// or fails it when o.Required is set. emulator names the emulator and start
+// says how to run it.
+func (o Options) Require(t TestingT, emulator, start string, probe Probe) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), ProbeTimeout)
+	defer cancel()
+
+	err := probe(ctx)
+	if err == nil {
+		t.Logf("✓ %s is running", emulator)
+		return
+	}
+
+	if o.Required {
+		t.Fatalf("%s not running (%v), and %s is set. Start with: %s", emulator, err, EnvRequireIntegration, start)
+		return
+	}
+	t.Skipf("%s not running (%v). Start with: %s", emulator, err, start)
+}
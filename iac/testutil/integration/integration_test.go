@@ -0,0 +1,126 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"iac/testutil/integration"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder captures Skipf and Fatalf instead of ending the enclosing test
+type recorder struct {
+	skipped bool
+	failed  bool
+	message string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Logf(format string, args ...interface{}) {}
+
+func (r *recorder) Skipf(format string, args ...interface{}) {
+	r.skipped = true
+	r.message = fmt.Sprintf(format, args...)
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed = true
+	r.message = fmt.Sprintf(format, args...)
+}
+
+func TestRequire(t *testing.T) {
+	t.Parallel()
+
+	down := func(context.Context) error { return errors.New("connection refused") }
+	up := func(context.Context) error { return nil }
+
+	tests := map[string]struct {
+		probe   integration.Probe
+		require bool
+		skipped bool
+		failed  bool
+	}{
+		"running":                  {up, false, false, false},
+		"running and required":     {up, true, false, false},
+		"not running":              {down, false, true, false},
+		"not running and required": {down, true, false, true},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := &recorder{}
+			integration.Options{Required: tc.require}.Require(rec, "CloudEmu", integration.StartCloudEmu, tc.probe)
+
+			assert.Equal(t, tc.skipped, rec.skipped, "skipped")
+			assert.Equal(t, tc.failed, rec.failed, "failed")
+			if tc.skipped || tc.failed {
+				assert.Contains(t, rec.message, "CloudEmu not running (connection refused)")
+				assert.Contains(t, rec.message, "Start with: "+integration.StartCloudEmu)
+			}
+			if tc.failed {
+				assert.Contains(t, rec.message, integration.EnvRequireIntegration, "The failure should say why it did not skip")
+			}
+		})
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	t.Setenv(integration.EnvRequireIntegration, "")
+	opts, err := integration.LoadOptions()
+	require.NoError(t, err)
+	assert.False(t, opts.Required, "A missing emulator should skip by default")
+
+	t.Setenv(integration.EnvRequireIntegration, "true")
+	opts, err = integration.LoadOptions()
+	require.NoError(t, err)
+	assert.True(t, opts.Required)
+
+	t.Setenv(integration.EnvRequireIntegration, "always")
+	_, err = integration.LoadOptions()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SWE_REQUIRE_INTEGRATION")
+
+	rec := &recorder{}
+	integration.Require(rec, "CloudEmu", integration.StartCloudEmu, func(context.Context) error { return nil })
+	assert.True(t, rec.failed, "A bad value should fail rather than guess")
+}
+
+func TestHTTP(t *testing.T) {
+	t.Parallel()
+
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	ctx := context.Background()
+
+	assert.NoError(t, integration.HTTP(server.URL, http.StatusOK)(ctx))
+	assert.NoError(t, integration.HTTP(server.URL)(ctx))
+
+	status = http.StatusServiceUnavailable
+	err := integration.HTTP(server.URL, http.StatusOK, http.StatusNotFound)(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503 Service Unavailable")
+	assert.NoError(t, integration.HTTP(server.URL)(ctx), "Without statuses any answer should do")
+}
+
+func TestHTTPNotListening(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	assert.Error(t, integration.HTTP(url)(context.Background()))
+}
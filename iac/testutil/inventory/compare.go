@@ -0,0 +1,144 @@
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReportFile is the name the comparison is written to in a test's artifact
+// directory
+const ReportFile = "inventory.md"
+
+// Expected is a category that only one provider's plan is expected to
+// have, such as the resource group every Azure resource lives in
+type Expected struct {
+	Category Category
+
+	// Provider is the one whose plan has the category
+	Provider string
+
+	// Reason says why the other provider's plan lacks it
+	Reason string
+}
+
+func (e Expected) String() string {
+	return fmt.Sprintf("%s only on %s (%s)", e.Category, e.Provider, e.Reason)
+}
+
+// Row compares one category's resources in two plans
+type Row struct {
+	Category Category
+
+	// Counts are the resources of the category in each plan, in the
+	// order of the Comparison's Providers
+	Counts [2]int
+
+	// Missing is the provider whose plan lacks the category the other
+	// has, empty when both have it
+	Missing string
+
+	// Reason is the Expected reason for the category to be missing, empty
+	// when it is not expected
+	Reason string
+}
+
+// Unexpected reports whether the category is missing from one plan without
+// an Expected reason
+func (r Row) Unexpected() bool {
+	return r.Missing != "" && r.Reason == ""
+}
+
+// Comparison is the category inventories of two providers' plans
+type Comparison struct {
+	Providers [2]string
+
+	// Rows has each category either plan has, in Categories order
+	Rows []Row
+
+	// Stale are the Expected differences the plans do not show, because
+	// both plans or neither have the category, or the other provider's
+	// does
+	Stale []Expected
+}
+
+// Unexpected returns the rows whose category is missing from one plan
+// without an Expected reason
+func (c Comparison) Unexpected() []Row {
+	var rows []Row
+	for _, r := range c.Rows {
+		if r.Unexpected() {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// Compare compares the inventories of providerA's and providerB's plans.
+// Counts may differ, as an SQS dead-letter queue is a second queue where
+// a Service Bus queue has its own; a category present in one plan and not
+// the other is a difference unless expected lists it.
+func Compare(providerA string, a Inventory, providerB string, b Inventory, expected []Expected) Comparison {
+	c := Comparison{Providers: [2]string{providerA, providerB}}
+	used := make([]bool, len(expected))
+
+	for _, category := range Categories {
+		row := Row{Category: category, Counts: [2]int{a.Count(category), b.Count(category)}}
+		if row.Counts[0] == 0 && row.Counts[1] == 0 {
+			continue
+		}
+
+		has := ""
+		switch {
+		case row.Counts[0] == 0:
+			row.Missing, has = providerA, providerB
+		case row.Counts[1] == 0:
+			row.Missing, has = providerB, providerA
+		}
+		if has != "" {
+			for i, e := range expected {
+				if e.Category == category && e.Provider == has {
+					row.Reason = e.Reason
+					used[i] = true
+				}
+			}
+		}
+		c.Rows = append(c.Rows, row)
+	}
+
+	for i, e := range expected {
+		if !used[i] {
+			c.Stale = append(c.Stale, e)
+		}
+	}
+	return c
+}
+
+// WriteReport writes c as Markdown: a table with a row per category
+// counting its resources in each plan and noting a missing category, then
+// the Expected differences the plans no longer show
+func WriteReport(w io.Writer, c Comparison) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Resource inventory: %s and %s\n\n", c.Providers[0], c.Providers[1])
+	fmt.Fprintf(&b, "| Category | %s | %s | Difference |\n| :--- | ---: | ---: | :--- |\n", c.Providers[0], c.Providers[1])
+	for _, r := range c.Rows {
+		difference := ""
+		switch {
+		case r.Unexpected():
+			difference = "**missing on " + r.Missing + "**"
+		case r.Missing != "":
+			difference = "expected: " + r.Reason
+		}
+		fmt.Fprintf(&b, "| %s | %d | %d | %s |\n", r.Category, r.Counts[0], r.Counts[1], difference)
+	}
+
+	if len(c.Stale) > 0 {
+		b.WriteString("\nExpected differences not seen:\n\n")
+		for _, e := range c.Stale {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,86 @@
+package inventory_test
+
+import (
+	"strings"
+	"testing"
+
+	"iac/testutil/inventory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counted returns an inventory with n placeholder addresses per category
+func counted(counts map[inventory.Category]int) inventory.Inventory {
+	inv := inventory.Inventory{}
+	for c, n := range counts {
+		for i := 0; i < n; i++ {
+			inv[c] = append(inv[c], string(c))
+		}
+	}
+	return inv
+}
+
+var resourceGroup = inventory.Expected{
+	Category: inventory.ResourceGroup,
+	Provider: "azure",
+	Reason:   "Azure resources live in a resource group",
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	aws := counted(map[inventory.Category]int{inventory.Queue: 2, inventory.Function: 1, inventory.Alarm: 3, inventory.Topic: 1})
+	azure := counted(map[inventory.Category]int{inventory.Queue: 1, inventory.Function: 1, inventory.Alarm: 3, inventory.ResourceGroup: 1})
+
+	c := inventory.Compare("aws", aws, "azure", azure, []inventory.Expected{resourceGroup})
+
+	assert.Equal(t, [2]string{"aws", "azure"}, c.Providers)
+	assert.Equal(t, []inventory.Row{
+		{Category: inventory.Queue, Counts: [2]int{2, 1}},
+		{Category: inventory.Topic, Counts: [2]int{1, 0}, Missing: "azure"},
+		{Category: inventory.Function, Counts: [2]int{1, 1}},
+		{Category: inventory.Alarm, Counts: [2]int{3, 3}},
+		{Category: inventory.ResourceGroup, Counts: [2]int{0, 1}, Missing: "aws", Reason: resourceGroup.Reason},
+	}, c.Rows, "Counts may differ; only a missing category is a difference")
+	assert.Equal(t, []inventory.Row{c.Rows[1]}, c.Unexpected())
+	assert.Empty(t, c.Stale)
+}
+
+func TestCompareStaleExpected(t *testing.T) {
+	t.Parallel()
+
+	both := counted(map[inventory.Category]int{inventory.ResourceGroup: 1})
+	c := inventory.Compare("aws", both, "azure", both, []inventory.Expected{resourceGroup})
+	assert.Empty(t, c.Unexpected())
+	assert.Equal(t, []inventory.Expected{resourceGroup}, c.Stale, "Both plans having the category should make its exception stale")
+
+	c = inventory.Compare("aws", both, "azure", inventory.Inventory{}, []inventory.Expected{resourceGroup})
+	require.Len(t, c.Unexpected(), 1, "An exception for one provider should not cover the other")
+	assert.Equal(t, "azure", c.Unexpected()[0].Missing)
+	assert.Equal(t, []inventory.Expected{resourceGroup}, c.Stale)
+}
+
+func TestWriteReport(t *testing.T) {
+	t.Parallel()
+
+	aws := counted(map[inventory.Category]int{inventory.Bucket: 1, inventory.Topic: 1})
+	azure := counted(map[inventory.Category]int{inventory.Bucket: 2, inventory.ResourceGroup: 1})
+	stale := inventory.Expected{Category: inventory.Network, Provider: "aws", Reason: "no VNet without a delegated subnet"}
+
+	var out strings.Builder
+	require.NoError(t, inventory.WriteReport(&out, inventory.Compare("aws", aws, "azure", azure, []inventory.Expected{resourceGroup, stale})))
+
+	assert.Equal(t, ""+
+		"# Resource inventory: aws and azure\n"+
+		"\n"+
+		"| Category | aws | azure | Difference |\n"+
+		"| :--- | ---: | ---: | :--- |\n"+
+		"| bucket | 1 | 2 |  |\n"+
+		"| topic | 1 | 0 | **missing on azure** |\n"+
+		"| resource group | 0 | 1 | expected: Azure resources live in a resource group |\n"+
+		"\n"+
+		"Expected differences not seen:\n"+
+		"\n"+
+		"- network only on aws (no VNet without a delegated subnet)\n", out.String())
+}
@@ -0,0 +1,192 @@
+// Package inventory sorts the resources a plan creates into abstract
+// categories, such as bucket or queue, so that plans of one module for two
+// providers can be compared. The platform module promises the same service
+// skeleton on AWS and Azure: where one provider's plan has a queue and the
+// other's has none, the other is missing a resource.
+//
+//	awsInventory, err := inventory.FromPlan([]byte(awsPlanJSON))
+//	azureInventory, err := inventory.FromPlan([]byte(azurePlanJSON))
+//	comparison := inventory.Compare("aws", awsInventory, "azure", azureInventory, expected)
+//
+// Types maps each resource type to its category. A resource that only
+// configures or connects another, such as a bucket's versioning or a role's
+// policy, is a Part and is not counted. A type missing from Types is an
+// error, so a resource added to a facade is categorized before plans with
+// it are compared.
+package inventory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"iac/testutil/planrisk"
+)
+
+// Category is what a resource is, whichever provider it is on
+type Category string
+
+// Categories of resources
+const (
+	Network       Category = "network"
+	Bucket        Category = "bucket"
+	Table         Category = "table"
+	Queue         Category = "queue"
+	Topic         Category = "topic"
+	Function      Category = "function"
+	Role          Category = "role"
+	Alarm         Category = "alarm"
+	ResourceGroup Category = "resource group"
+
+	// Part is a resource that configures or connects another, and is not
+	// counted
+	Part Category = "part"
+)
+
+// Categories lists every counted Category, in report order
+var Categories = []Category{Network, Bucket, Table, Queue, Topic, Function, Role, Alarm, ResourceGroup}
+
+// Types maps the resource types of the AWS and Azure modules behind the
+// facades to their Category. Identities of any kind are roles, and an
+// Azure Cosmos DB container, not its account, is the table.
+var Types = map[string]Category{
+	// AWS
+	"aws_vpc":                             Network,
+	"aws_subnet":                          Part,
+	"aws_route":                           Part,
+	"aws_route_table":                     Part,
+	"aws_route_table_association":         Part,
+	"aws_internet_gateway":                Part,
+	"aws_egress_only_internet_gateway":    Part,
+	"aws_security_group":                  Part,
+	"aws_vpc_security_group_egress_rule":  Part,
+	"aws_vpc_security_group_ingress_rule": Part,
+	"aws_vpc_endpoint":                    Part,
+	"aws_flow_log":                        Part,
+
+	"aws_s3_bucket":                                      Bucket,
+	"aws_s3_bucket_notification":                         Part,
+	"aws_s3_bucket_public_access_block":                  Part,
+	"aws_s3_bucket_server_side_encryption_configuration": Part,
+	"aws_s3_bucket_versioning":                           Part,
+
+	"aws_dynamodb_table": Table,
+
+	"aws_sqs_queue":              Queue,
+	"aws_sns_topic":              Topic,
+	"aws_sns_topic_subscription": Part,
+
+	"aws_lambda_function":                       Function,
+	"aws_lambda_alias":                          Part,
+	"aws_lambda_event_source_mapping":           Part,
+	"aws_lambda_function_url":                   Part,
+	"aws_lambda_permission":                     Part,
+	"aws_lambda_provisioned_concurrency_config": Part,
+	"aws_cloudwatch_log_group":                  Part,
+
+	"aws_iam_role":                   Role,
+	"aws_iam_user":                   Role,
+	"aws_iam_access_key":             Part,
+	"aws_iam_instance_profile":       Part,
+	"aws_iam_policy":                 Part,
+	"aws_iam_role_policy":            Part,
+	"aws_iam_role_policy_attachment": Part,
+	"aws_iam_user_policy_attachment": Part,
+
+	"aws_cloudwatch_metric_alarm": Alarm,
+	"aws_cloudwatch_dashboard":    Part,
+
+	// Azure
+	"azurerm_virtual_network":                           Network,
+	"azurerm_subnet":                                    Part,
+	"azurerm_network_security_group":                    Part,
+	"azurerm_network_security_rule":                     Part,
+	"azurerm_subnet_network_security_group_association": Part,
+	"azurerm_network_watcher_flow_log":                  Part,
+	"azurerm_private_endpoint":                          Part,
+	"azurerm_private_dns_zone":                          Part,
+	"azurerm_private_dns_zone_virtual_network_link":     Part,
+
+	"azurerm_storage_account":                           Bucket,
+	"azurerm_storage_container":                         Part,
+	"azurerm_eventgrid_system_topic":                    Part,
+	"azurerm_eventgrid_system_topic_event_subscription": Part,
+
+	"azurerm_cosmosdb_sql_container": Table,
+	"azurerm_cosmosdb_account":       Part,
+	"azurerm_cosmosdb_sql_database":  Part,
+
+	"azurerm_servicebus_queue":     Queue,
+	"azurerm_servicebus_topic":     Topic,
+	"azurerm_servicebus_namespace": Part,
+
+	"azurerm_linux_function_app":      Function,
+	"azurerm_linux_function_app_slot": Part,
+	"azurerm_service_plan":            Part,
+
+	"azurerm_user_assigned_identity": Role,
+	"azuread_service_principal":      Role,
+	"azuread_application":            Part,
+	"azuread_application_password":   Part,
+	"azurerm_role_assignment":        Part,
+	"azurerm_role_definition":        Part,
+
+	"azurerm_monitor_metric_alert":    Alarm,
+	"azurerm_monitor_action_group":    Part,
+	"azurerm_log_analytics_workspace": Part,
+
+	"azurerm_resource_group": ResourceGroup,
+
+	// Either provider
+	"null_resource": Part,
+}
+
+// Inventory holds the addresses of a plan's resources in each counted
+// Category
+type Inventory map[Category][]string
+
+// Count returns how many resources of category the inventory holds
+func (inv Inventory) Count(category Category) int {
+	return len(inv[category])
+}
+
+// FromPlan takes the inventory of planJSON, the `terraform show -json`
+// output for a plan: every managed resource that exists once it is
+// applied, created or not
+func FromPlan(planJSON []byte) (Inventory, error) {
+	summary, err := planrisk.Classify(planJSON)
+	if err != nil {
+		return nil, fmt.Errorf("inventory: %w", err)
+	}
+	return Take(summary.Changes)
+}
+
+// Take sorts changes into an Inventory, leaving out destroyed resources
+// and Parts. It fails, naming each one, if any type is missing from Types.
+func Take(changes []planrisk.Change) (Inventory, error) {
+	inv := Inventory{}
+	unmapped := map[string]bool{}
+	for _, c := range changes {
+		if c.Action == planrisk.Destroy {
+			continue
+		}
+		category, ok := Types[c.Type]
+		if !ok {
+			unmapped[c.Type] = true
+			continue
+		}
+		if category != Part {
+			inv[category] = append(inv[category], c.Address)
+		}
+	}
+
+	if len(unmapped) > 0 {
+		types := make([]string, 0, len(unmapped))
+		for t := range unmapped {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		return nil, fmt.Errorf("inventory: resource types with no category in inventory.Types: %s", strings.Join(types, ", "))
+	}
+	return inv, nil
+}
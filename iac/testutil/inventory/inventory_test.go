@@ -0,0 +1,68 @@
+package inventory_test
+
+import (
+	"os"
+	"testing"
+
+	"iac/testutil/inventory"
+	"iac/testutil/planrisk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromPlan(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/plan.json")
+	require.NoError(t, err)
+
+	inv, err := inventory.FromPlan(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, inventory.Inventory{
+		inventory.Bucket: {"module.storage[0].module.aws_storage[0].aws_s3_bucket.this"},
+		inventory.Queue: {
+			"module.queue[0].module.aws_messaging[0].aws_sqs_queue.this[0]",
+			"module.queue[0].module.aws_messaging[0].aws_sqs_queue.dlq[0]",
+		},
+		inventory.Role:     {"module.identity.module.aws_iam[0].aws_iam_role.this[0]"},
+		inventory.Function: {"module.function.module.aws_lambda[0].aws_lambda_function.this"},
+	}, inv, "Kept and replaced resources should count; parts, data sources and destroyed resources should not")
+	assert.Equal(t, 2, inv.Count(inventory.Queue))
+	assert.Zero(t, inv.Count(inventory.Alarm))
+}
+
+func TestFromPlanRejectsOtherJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := inventory.FromPlan([]byte(`{"resource_changes": []}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not terraform show -json output")
+}
+
+func TestTakeNamesUnmappedTypes(t *testing.T) {
+	t.Parallel()
+
+	_, err := inventory.Take([]planrisk.Change{
+		{Address: "aws_s3_bucket.this", Type: "aws_s3_bucket", Action: planrisk.Create},
+		{Address: "aws_kinesis_stream.b", Type: "aws_kinesis_stream", Action: planrisk.Create},
+		{Address: "aws_kinesis_stream.a", Type: "aws_kinesis_stream", Action: planrisk.Create},
+		{Address: "aws_apigatewayv2_api.this", Type: "aws_apigatewayv2_api", Action: planrisk.Create},
+		{Address: "aws_glue_job.old", Type: "aws_glue_job", Action: planrisk.Destroy},
+	})
+	require.Error(t, err)
+	assert.Equal(t, "inventory: resource types with no category in inventory.Types: aws_apigatewayv2_api, aws_kinesis_stream", err.Error())
+}
+
+func TestTypesUseKnownCategories(t *testing.T) {
+	t.Parallel()
+
+	known := map[inventory.Category]bool{inventory.Part: true}
+	for _, c := range inventory.Categories {
+		known[c] = true
+	}
+	for resourceType, c := range inventory.Types {
+		assert.True(t, known[c], "%s maps to %q, which is not in inventory.Categories", resourceType, c)
+	}
+}
@@ -0,0 +1,399 @@
+// Package modgraph builds the graph of local module calls across the
+// repository and checks it against the layering the facades rely on:
+//
+//   - no module reaches itself through its calls
+//   - provider modules (aws/, azure/, gcp/, zero/ outside their test
+//     fixtures) never reach a facade, directly or through other modules
+//   - platform modules only call facades
+//   - examples only call facades and platform modules
+//
+// Modules are read with the HCL parser, so the check needs no terraform
+// init. tools/modgraph runs the same checks and prints the graph as DOT.
+package modgraph
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"iac/testutil/modules"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Layer is the part of the repository a module belongs to
+type Layer string
+
+// Layers, by top-level directory
+const (
+	LayerFacade   Layer = "facade"
+	LayerPlatform Layer = "platform"
+	LayerProvider Layer = "provider"
+	LayerCommon   Layer = "common"
+	LayerExample  Layer = "example"
+	LayerTest     Layer = "test"
+	LayerOther    Layer = "other"
+)
+
+// providerDirs hold one provider's core modules, SPI and test fixtures
+var providerDirs = map[string]bool{"aws": true, "azure": true, "gcp": true, "zero": true}
+
+// LayerOf classifies a module by its slash-separated path relative to the
+// repository root
+func LayerOf(dir string) Layer {
+	parts := strings.Split(path.Clean(dir), "/")
+	for _, part := range parts {
+		if part == "testdata" {
+			return LayerTest
+		}
+	}
+
+	switch {
+	case parts[0] == "facade":
+		return LayerFacade
+	case parts[0] == "platform":
+		return LayerPlatform
+	case parts[0] == "examples":
+		return LayerExample
+	case parts[0] == "common":
+		return LayerCommon
+	case providerDirs[parts[0]] && len(parts) > 1 && parts[1] == "test":
+		return LayerTest
+	case providerDirs[parts[0]]:
+		return LayerProvider
+	}
+	return LayerOther
+}
+
+// Edge is one module block with a local source
+type Edge struct {
+	// From and To are slash-separated module paths relative to the root
+	From string
+	To   string
+
+	// File and Line locate the module block, File relative to From
+	File string
+	Line int
+}
+
+func (e Edge) String() string {
+	return fmt.Sprintf("%s (%s:%d) -> %s", e.From, e.File, e.Line, e.To)
+}
+
+// Graph is every module under a root and the local calls between them
+type Graph struct {
+	// Modules are sorted; a call to a directory without .tf files still
+	// adds its target
+	Modules []string
+
+	// Edges maps a module to its calls, sorted by target then position
+	Edges map[string][]Edge
+}
+
+// Build discovers the modules under root (see modules.Discover, which
+// skips testdata fixtures) and reads their module blocks
+func Build(root string) (*Graph, error) {
+	dirs, err := modules.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Graph{Edges: make(map[string][]Edge)}
+	seen := make(map[string]bool)
+	add := func(module string) {
+		if !seen[module] {
+			seen[module] = true
+			g.Modules = append(g.Modules, module)
+		}
+	}
+
+	parser := hclparse.NewParser()
+	for _, dir := range dirs {
+		from, err := relative(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		add(from)
+
+		edges, err := calls(parser, root, dir, from)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range edges {
+			add(e.To)
+		}
+		g.Edges[from] = edges
+	}
+
+	sort.Strings(g.Modules)
+	return g, nil
+}
+
+// calls reads the module blocks of dir with ./ or ../ sources
+func calls(parser *hclparse.Parser, root, dir, from string) ([]Edge, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var edges []Edge
+	for _, file := range files {
+		f, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("modgraph: %s", diags.Error())
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, fmt.Errorf("modgraph: %s is not native HCL syntax", file)
+		}
+
+		for _, block := range body.Blocks {
+			if block.Type != "module" {
+				continue
+			}
+			source := sourceOf(block.Body)
+			if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+				continue
+			}
+			to, err := relative(root, filepath.Join(dir, filepath.FromSlash(source)))
+			if err != nil {
+				return nil, err
+			}
+			edges = append(edges, Edge{From: from, To: to, File: filepath.Base(file), Line: block.TypeRange.Start.Line})
+		}
+	}
+
+	sort.SliceStable(edges, func(i, j int) bool { return edges[i].To < edges[j].To })
+	return edges, nil
+}
+
+// sourceOf returns a literal source attribute, or "" for any other value
+func sourceOf(body *hclsyntax.Body) string {
+	attr, ok := body.Attributes["source"]
+	if !ok {
+		return ""
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.String {
+		return ""
+	}
+	return value.AsString()
+}
+
+func relative(root, dir string) (string, error) {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", fmt.Errorf("modgraph: %w", err)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Violation is a broken rule and the chain of calls that breaks it
+type Violation struct {
+	Rule  string
+	Chain []Edge
+}
+
+func (v Violation) String() string {
+	steps := make([]string, len(v.Chain))
+	for i, e := range v.Chain {
+		steps[i] = fmt.Sprintf("%s (%s:%d)", e.From, e.File, e.Line)
+	}
+	return fmt.Sprintf("%s: %s -> %s", v.Rule, strings.Join(steps, " -> "), v.Chain[len(v.Chain)-1].To)
+}
+
+// Rules broken by a Violation
+const (
+	RuleCycle            = "module cycle"
+	RuleProviderToFacade = "provider modules may not source facades"
+	RulePlatformSource   = "platform modules may only source facades"
+	RuleExampleSource    = "examples may only source facades or platform modules"
+)
+
+// Check returns every violation in g: each cycle once, the shortest chain
+// from each provider module to each facade it reaches, every platform call
+// to a module that is not a facade, and every example call to a module that
+// is neither
+func (g *Graph) Check() []Violation {
+	var violations []Violation
+	violations = append(violations, g.cycles()...)
+
+	for _, module := range g.Modules {
+		switch LayerOf(module) {
+		case LayerProvider:
+			for _, chain := range g.reach(module, LayerFacade) {
+				violations = append(violations, Violation{Rule: RuleProviderToFacade, Chain: chain})
+			}
+		case LayerPlatform:
+			for _, e := range g.Edges[module] {
+				if LayerOf(e.To) != LayerFacade {
+					violations = append(violations, Violation{Rule: RulePlatformSource, Chain: []Edge{e}})
+				}
+			}
+		case LayerExample:
+			for _, e := range g.Edges[module] {
+				if to := LayerOf(e.To); to != LayerFacade && to != LayerPlatform {
+					violations = append(violations, Violation{Rule: RuleExampleSource, Chain: []Edge{e}})
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// reach returns the shortest call chain from module to each module of
+// layer it reaches, without following calls past the first such module
+func (g *Graph) reach(module string, layer Layer) [][]Edge {
+	via := map[string]Edge{}
+	visited := map[string]bool{module: true}
+	queue := []string{module}
+	var found []string
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, e := range g.Edges[current] {
+			if visited[e.To] {
+				continue
+			}
+			visited[e.To] = true
+			via[e.To] = e
+			if LayerOf(e.To) == layer {
+				found = append(found, e.To)
+				continue
+			}
+			queue = append(queue, e.To)
+		}
+	}
+
+	sort.Strings(found)
+	chains := make([][]Edge, 0, len(found))
+	for _, target := range found {
+		var chain []Edge
+		for at := target; at != module; at = via[at].From {
+			chain = append([]Edge{via[at]}, chain...)
+		}
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+// cycles finds the cycles closed by each back edge of a depth-first
+// search, each reported once starting from its smallest module
+func (g *Graph) cycles() []Violation {
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	state := map[string]int{}
+	var stack []Edge
+	seen := map[string]bool{}
+	var violations []Violation
+
+	var visit func(module string)
+	visit = func(module string) {
+		state[module] = active
+		for _, e := range g.Edges[module] {
+			switch state[e.To] {
+			case unvisited:
+				stack = append(stack, e)
+				visit(e.To)
+				stack = stack[:len(stack)-1]
+			case active:
+				// The cycle is the stack from the call into e.To, plus e
+				start := len(stack)
+				for start > 0 && stack[start-1].To != e.To {
+					start--
+				}
+				chain := rotate(append(append([]Edge(nil), stack[start:]...), e))
+				key := chainKey(chain)
+				if !seen[key] {
+					seen[key] = true
+					violations = append(violations, Violation{Rule: RuleCycle, Chain: chain})
+				}
+			}
+		}
+		state[module] = done
+	}
+
+	for _, module := range g.Modules {
+		if state[module] == unvisited {
+			visit(module)
+		}
+	}
+	return violations
+}
+
+// rotate starts a cycle at its smallest module, so the same cycle found
+// from different modules reads the same
+func rotate(chain []Edge) []Edge {
+	smallest := 0
+	for i, e := range chain {
+		if e.From < chain[smallest].From {
+			smallest = i
+		}
+	}
+	return append(append([]Edge(nil), chain[smallest:]...), chain[:smallest]...)
+}
+
+func chainKey(chain []Edge) string {
+	var b strings.Builder
+	for _, e := range chain {
+		fmt.Fprintf(&b, "%s:%s:%d>", e.From, e.File, e.Line)
+	}
+	return b.String()
+}
+
+// layerColors fill DOT nodes by layer
+var layerColors = map[Layer]string{
+	LayerFacade:   "lightblue",
+	LayerPlatform: "lightsalmon",
+	LayerProvider: "lightyellow",
+	LayerCommon:   "lightgrey",
+	LayerExample:  "palegreen",
+	LayerTest:     "white",
+	LayerOther:    "white",
+}
+
+// DOT renders g for Graphviz, filling modules by layer and drawing the
+// calls in violations red
+func (g *Graph) DOT(violations []Violation) string {
+	bad := map[Edge]bool{}
+	for _, v := range violations {
+		for _, e := range v.Chain {
+			bad[e] = true
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph modules {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=filled];\n")
+	for _, module := range g.Modules {
+		fmt.Fprintf(&b, "  %q [fillcolor=%s];\n", module, layerColors[LayerOf(module)])
+	}
+	for _, module := range g.Modules {
+		drawn := map[string]bool{}
+		for _, e := range g.Edges[module] {
+			if bad[e] {
+				fmt.Fprintf(&b, "  %q -> %q [color=red];\n", e.From, e.To)
+				drawn[e.To] = true
+			}
+		}
+		for _, e := range g.Edges[module] {
+			// One arrow per pair of modules, however many calls
+			if !drawn[e.To] {
+				fmt.Fprintf(&b, "  %q -> %q;\n", e.From, e.To)
+				drawn[e.To] = true
+			}
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package modgraph_test
+
+import (
+	"testing"
+
+	"iac/testutil/modgraph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func build(t *testing.T, root string) *modgraph.Graph {
+	t.Helper()
+	g, err := modgraph.Build(root)
+	require.NoError(t, err)
+	return g
+}
+
+// strings renders violations the way the tests report them
+func strings(violations []modgraph.Violation) []string {
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = v.String()
+	}
+	return lines
+}
+
+func TestLayerOf(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]modgraph.Layer{
+		"facade/storage":                    modgraph.LayerFacade,
+		"aws/core/storage":                  modgraph.LayerProvider,
+		"zero/spi":                          modgraph.LayerProvider,
+		"azure/test/fixtures/storage":       modgraph.LayerTest,
+		"facade/waf/testdata/composition":   modgraph.LayerTest,
+		"common/tags":                       modgraph.LayerCommon,
+		"examples/static-site":              modgraph.LayerExample,
+		"platform/service":                  modgraph.LayerPlatform,
+		"tools/fixtures/stack":              modgraph.LayerOther,
+		"aws/../facade/storage":             modgraph.LayerFacade,
+		"gcp/core/messaging/../../../aws/x": modgraph.LayerProvider,
+	}
+	for dir, want := range tests {
+		assert.Equal(t, want, modgraph.LayerOf(dir), "LayerOf(%q)", dir)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	g := build(t, "testdata/clean")
+
+	assert.Equal(t, []string{
+		"aws/core/app",
+		"aws/test/fixtures/app",
+		"common/tags",
+		"examples/service",
+		"examples/site",
+		"facade/app",
+		"platform/svc",
+	}, g.Modules)
+	assert.Equal(t, []modgraph.Edge{
+		{From: "facade/app", To: "aws/core/app", File: "main.tf", Line: 1},
+		{From: "facade/app", To: "common/tags", File: "main.tf", Line: 5},
+	}, g.Edges["facade/app"])
+	assert.Len(t, g.Edges["examples/site"], 1, "Registry sources are not local calls")
+}
+
+func TestCheckClean(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, build(t, "testdata/clean").Check(),
+		"Facades calling provider and common modules, platform modules calling facades, and examples and fixtures calling either, are allowed")
+}
+
+func TestCheckCycle(t *testing.T) {
+	t.Parallel()
+
+	violations := build(t, "testdata/cycle").Check()
+
+	require.Len(t, violations, 1, "A cycle is reported once, however many modules lead into it")
+	assert.Equal(t, modgraph.RuleCycle, violations[0].Rule)
+	assert.Equal(t, []string{
+		"module cycle: facade/a (main.tf:1) -> facade/b (main.tf:1) -> facade/c (main.tf:1) -> facade/a",
+	}, strings(violations))
+}
+
+func TestCheckLayering(t *testing.T) {
+	t.Parallel()
+
+	violations := build(t, "testdata/layering").Check()
+
+	assert.Equal(t, []string{
+		"provider modules may not source facades: azure/core/queue (main.tf:1) -> facade/monitoring",
+		"examples may only source facades or platform modules: examples/raw (main.tf:1) -> gcp/core/bucket",
+		"provider modules may not source facades: gcp/core/bucket (main.tf:1) -> common/helper (main.tf:1) -> facade/storage",
+		"platform modules may only source facades: platform/raw (main.tf:2) -> gcp/core/bucket",
+	}, strings(violations), "Indirect calls should print the whole chain")
+}
+
+func TestDOT(t *testing.T) {
+	t.Parallel()
+
+	g := build(t, "testdata/layering")
+	dot := g.DOT(g.Check())
+
+	assert.Contains(t, dot, "digraph modules {")
+	assert.Contains(t, dot, `"facade/storage" [fillcolor=lightblue];`)
+	assert.Contains(t, dot, `"gcp/core/bucket" [fillcolor=lightyellow];`)
+	assert.Contains(t, dot, `"azure/core/queue" -> "facade/monitoring" [color=red];`)
+	assert.Contains(t, dot, `"examples/raw" -> "facade/storage";`, "Allowed calls are drawn plainly")
+}
@@ -0,0 +1,3 @@
+module "default_tags" {
+  source = "../../../common/tags"
+}
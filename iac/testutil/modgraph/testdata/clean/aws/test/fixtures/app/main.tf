@@ -0,0 +1,4 @@
+# Test fixtures may call facades
+module "app" {
+  source = "../../../../facade/app"
+}
@@ -0,0 +1,8 @@
+module "app" {
+  source = "../../facade/app"
+}
+
+# Registry modules are not part of the graph
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
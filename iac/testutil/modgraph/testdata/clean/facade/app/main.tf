@@ -0,0 +1,7 @@
+module "aws_app" {
+  source = "../../aws/core/app"
+}
+
+module "default_tags" {
+  source = "../../common/tags"
+}
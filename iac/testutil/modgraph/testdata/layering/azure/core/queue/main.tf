@@ -0,0 +1,3 @@
+module "alarms" {
+  source = "../../../facade/monitoring"
+}
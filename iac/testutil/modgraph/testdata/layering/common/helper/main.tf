@@ -0,0 +1,3 @@
+module "storage" {
+  source = "../../facade/storage"
+}
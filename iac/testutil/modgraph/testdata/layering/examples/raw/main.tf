@@ -0,0 +1,7 @@
+module "bucket" {
+  source = "../../gcp/core/bucket"
+}
+
+module "storage" {
+  source = "../../facade/storage"
+}
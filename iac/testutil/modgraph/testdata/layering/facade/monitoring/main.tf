@@ -0,0 +1,3 @@
+variable "alarm_name" {
+  type = string
+}
@@ -0,0 +1,3 @@
+module "helper" {
+  source = "../../../common/helper"
+}
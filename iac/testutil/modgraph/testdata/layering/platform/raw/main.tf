@@ -0,0 +1,4 @@
+# Platform modules compose facades, not provider modules
+module "bucket" {
+  source = "../../gcp/core/bucket"
+}
@@ -0,0 +1,47 @@
+// Package modules discovers the Terraform modules in a directory tree, so
+// repository-wide checks (validate, security scans) cover every module
+// without keeping a list by hand.
+package modules
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Discover returns every directory under root that contains a .tf file,
+// sorted. Hidden directories such as .terraform and .git are skipped, as are
+// testdata directories, which hold fixtures rather than modules.
+func Discover(root string) ([]string, error) {
+	seen := make(map[string]bool)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(path) == ".tf" {
+			seen[filepath.Dir(path)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	modules := make([]string, 0, len(seen))
+	for dir := range seen {
+		modules = append(modules, dir)
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
@@ -0,0 +1,63 @@
+package modules_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/modules"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, file := range []string{
+		"facade/storage/main.tf",
+		"facade/storage/variables.tf",
+		"aws/core/storage/main.tf",
+		"aws/core/storage/README.md",
+		"docs/notes.md",
+		// Never modules
+		"facade/storage/.terraform/modules/aws_storage/main.tf",
+		".git/hooks/main.tf",
+		"testdata/fixture/main.tf",
+		// Only the extension counts, not a name containing it
+		"scripts/main.tfvars",
+		// Directory names merely containing ".terraform" are not hidden
+		"examples/my.terraform-demo/main.tf",
+	} {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+
+	found, err := modules.Discover(root)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join(root, "aws/core/storage"),
+		filepath.Join(root, "examples/my.terraform-demo"),
+		filepath.Join(root, "facade/storage"),
+	}, found)
+}
+
+func TestDiscoverRelativeRoot(t *testing.T) {
+	t.Parallel()
+
+	found, err := modules.Discover("../../common")
+	require.NoError(t, err)
+
+	assert.Contains(t, found, filepath.Join("../../common", "duration"))
+	assert.Contains(t, found, "../../common")
+}
+
+func TestDiscoverMissingRoot(t *testing.T) {
+	t.Parallel()
+
+	_, err := modules.Discover(filepath.Join(t.TempDir(), "absent"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,152 @@
+// Package naming mirrors common/naming, the module the facades build
+// resource names with, so its rules can be tested on many more names than
+// plans could cover:
+//
+//	rules, err := naming.Load(filepath.Join(root, naming.RulesFile))
+//	name := naming.Generate(rules["azure_storage_account"], "acme", "dev", "assets")
+//	err = rules["azure_storage_account"].Check(name)
+//
+// Generate follows the module's steps exactly; TestModuleMatchesGenerate
+// in common/naming applies the module to names from the property tests and
+// checks the two agree, so a change to one without the other fails there.
+// Terraform normalizes strings to Unicode NFC, so names given here should
+// be NFC too.
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RulesFile is the rules map, relative to the iac module
+const RulesFile = "common/naming/naming.json"
+
+// HashLength is how many hex digits of the full name's SHA-256 a cut or
+// padded name ends in
+const HashLength = 8
+
+// Rule is what one resource type allows of a name
+type Rule struct {
+	MinLength int `json:"min_length"`
+	MaxLength int `json:"max_length"`
+
+	// Lowercase lowers the name before Chars are applied
+	Lowercase bool `json:"lowercase"`
+
+	// Chars is the body of a regular expression character class of the
+	// characters allowed, e.g. a-z0-9-
+	Chars string `json:"chars"`
+
+	// Separator replaces each run of characters outside Chars, and comes
+	// before the hash; it is empty for types that allow no hyphen
+	Separator string `json:"separator"`
+
+	// LetterFirst prefixes an n to names that do not start with a letter
+	LetterFirst bool `json:"letter_first"`
+
+	// Pattern is the documented regular expression every name of the type
+	// matches, lengths aside
+	Pattern string `json:"pattern"`
+}
+
+// Load reads the rules map at path, keyed by resource type, and checks
+// each rule's expressions compile
+func Load(path string) (map[string]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("naming: %w", err)
+	}
+	var rules map[string]Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("naming: %s: %w", path, err)
+	}
+	for _, resourceType := range Types(rules) {
+		rule := rules[resourceType]
+		if _, err := regexp.Compile("[^" + rule.Chars + "]+"); err != nil {
+			return nil, fmt.Errorf("naming: %s: chars of %s: %w", path, resourceType, err)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return nil, fmt.Errorf("naming: %s: pattern of %s: %w", path, resourceType, err)
+		}
+		if rule.MinLength < 1 || rule.MaxLength < rule.MinLength || rule.MaxLength < HashLength+len(rule.Separator)+1 {
+			return nil, fmt.Errorf("naming: %s: %s must have 1 <= min_length <= max_length, and room for a hash", path, resourceType)
+		}
+	}
+	return rules, nil
+}
+
+// Types returns the resource types in rules, sorted
+func Types(rules map[string]Rule) []string {
+	types := make([]string, 0, len(rules))
+	for resourceType := range rules {
+		types = append(types, resourceType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+var hyphens = regexp.MustCompile(`-+`)
+
+// Generate joins the non-empty parts with hyphens and makes the result
+// legal under rule, as the module does
+func Generate(rule Rule, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	full := strings.Join(kept, "-")
+
+	cased := full
+	if rule.Lowercase {
+		cased = strings.ToLower(full)
+	}
+	illegal := regexp.MustCompile("[^" + rule.Chars + "]+")
+	cleaned := strings.Trim(hyphens.ReplaceAllString(illegal.ReplaceAllLiteralString(cased, rule.Separator), "-"), "-")
+
+	lettered := cleaned
+	if rule.LetterFirst && !startsWithLetter(cleaned) {
+		lettered = "n" + cleaned
+	}
+
+	if len(lettered) >= rule.MinLength && len(lettered) <= rule.MaxLength {
+		return lettered
+	}
+
+	sum := sha256.Sum256([]byte(full))
+	hash := hex.EncodeToString(sum[:])[:HashLength]
+	prefix := lettered
+	if keep := rule.MaxLength - len(rule.Separator) - HashLength; len(prefix) > keep {
+		prefix = prefix[:keep]
+	}
+	prefix = strings.Trim(prefix, "-")
+	if prefix == "" {
+		return hash
+	}
+	return prefix + rule.Separator + hash
+}
+
+func startsWithLetter(s string) bool {
+	return s != "" && (s[0] >= 'a' && s[0] <= 'z' || s[0] >= 'A' && s[0] <= 'Z')
+}
+
+// Check returns an error describing how name breaks rule, or nil
+func (r Rule) Check(name string) error {
+	if n := len(name); n < r.MinLength || n > r.MaxLength {
+		return fmt.Errorf("%q is %d characters, not %d-%d", name, n, r.MinLength, r.MaxLength)
+	}
+	if !regexp.MustCompile(r.Pattern).MatchString(name) {
+		return fmt.Errorf("%q does not match %s", name, r.Pattern)
+	}
+	if !regexp.MustCompile("^[" + r.Chars + "]*$").MatchString(name) {
+		return fmt.Errorf("%q has characters outside [%s]", name, r.Chars)
+	}
+	return nil
+}
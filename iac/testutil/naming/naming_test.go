@@ -0,0 +1,129 @@
+package naming_test
+
+import (
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/naming"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadRules(t *testing.T) map[string]naming.Rule {
+	t.Helper()
+
+	rules, err := naming.Load(filepath.Join("..", "..", naming.RulesFile))
+	require.NoError(t, err, "naming.json should be valid")
+	return rules
+}
+
+func TestRulesCoverFacadeTypes(t *testing.T) {
+	t.Parallel()
+
+	rules := loadRules(t)
+	for _, resourceType := range []string{"azure_resource_group", "azure_storage_account", "aws_s3_bucket", "gcp_compute_instance", "gcp_service_account", "aws_lb", "aws_ebs_volume", "azure_managed_disk", "azure_data_protection_backup_vault", "gcp_compute_disk"} {
+		assert.Contains(t, rules, resourceType, "The facades name %s through common/naming", resourceType)
+	}
+}
+
+// samples are names the property test always includes: ones that fit, and
+// the awkward ones that once failed at apply time
+var samples = [][]string{
+	{"acme", "dev", "rg"},
+	{"acme-platform-payments-reconciliation-svc", "staging", "assets"},
+	{"-acme-", "dev", "-bucket-"},
+	{"Acme_Platform", "PROD", "web.01"},
+	{"projet-été", "dev", "données"},
+	{"日本語", "dev", ""},
+	{"", "", "9lives"},
+	{"a", "", ""},
+	{"---", "", "___"},
+	{strings.Repeat("x", 200), "dev", "vm"},
+}
+
+// alphabet mixes legal characters with the separators, punctuation and
+// unicode that real project names contain
+var alphabet = []rune("abcxyzABCXYZ0189-_. éüßÅ日本🙂")
+
+func randomPart(r *rand.Rand) string {
+	n := r.Intn(60)
+	runes := make([]rune, n)
+	for i := range runes {
+		runes[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	// Hyphens at the boundaries, which cutting must not leave behind
+	if n > 0 && r.Intn(4) == 0 {
+		runes[0], runes[n-1] = '-', '-'
+	}
+	return string(runes)
+}
+
+// TestGenerateProperties checks, for every resource type, that names built
+// from the samples and a few hundred random parts satisfy the type's rule,
+// are the same each time, and are left alone when already legal
+func TestGenerateProperties(t *testing.T) {
+	t.Parallel()
+
+	rules := loadRules(t)
+	r := rand.New(rand.NewSource(1398))
+	inputs := append([][]string{}, samples...)
+	for i := 0; i < 500; i++ {
+		inputs = append(inputs, []string{randomPart(r), []string{"dev", "staging", "prod", ""}[r.Intn(4)], randomPart(r)})
+	}
+
+	for _, resourceType := range naming.Types(rules) {
+		rule := rules[resourceType]
+
+		t.Run(resourceType, func(t *testing.T) {
+			t.Parallel()
+
+			for _, parts := range inputs {
+				name := naming.Generate(rule, parts...)
+				require.NoError(t, rule.Check(name), "Generate(%q)", parts)
+				assert.Equal(t, name, naming.Generate(rule, parts...), "Generate(%q) should be deterministic", parts)
+				assert.Equal(t, name, naming.Generate(rule, name), "A legal name %q should be left alone", name)
+			}
+		})
+	}
+}
+
+func TestGenerateTruncatesWithStableHash(t *testing.T) {
+	t.Parallel()
+
+	rule := loadRules(t)["azure_storage_account"]
+	project := "acme-platform-payments-reconciliation"
+
+	dev := naming.Generate(rule, project, "dev", "assets")
+	prod := naming.Generate(rule, project, "prod", "assets")
+	assert.Len(t, dev, rule.MaxLength)
+	assert.True(t, strings.HasPrefix(dev, "acmeplatformpayme"), "The name should keep as much of the convention as fits: %s", dev)
+	assert.NotEqual(t, dev, prod, "Names cut to the same prefix should differ by their hash")
+	assert.Equal(t, dev, naming.Generate(rule, project, "dev", "assets"))
+
+	assert.Equal(t, "acmedevassets", naming.Generate(rule, "acme", "dev", "assets"), "Short names should only lose their illegal characters")
+}
+
+func TestGenerateLetterFirstAndPadding(t *testing.T) {
+	t.Parallel()
+
+	rules := loadRules(t)
+
+	instance := naming.Generate(rules["gcp_compute_instance"], "", "", "9lives")
+	assert.Equal(t, "n9lives", instance, "A name must start with a letter on GCP")
+
+	account := naming.Generate(rules["gcp_service_account"], "", "", "ab")
+	assert.True(t, strings.HasPrefix(account, "ab-"), "A name under the minimum should be padded with the hash: %s", account)
+	assert.Len(t, account, len("ab-")+naming.HashLength)
+}
+
+func TestCheckReportsViolations(t *testing.T) {
+	t.Parallel()
+
+	rule := loadRules(t)["gcp_service_account"]
+	assert.ErrorContains(t, rule.Check("short"), "is 5 characters, not 6-30")
+	assert.ErrorContains(t, rule.Check("1-account"), "does not match")
+	assert.NoError(t, rule.Check("build-agent"))
+}
@@ -0,0 +1,104 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"iac/azure/azurehelpers"
+	"iac/testutil/config"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+// AzureBlob is an ObjectStore over a blob container
+type AzureBlob struct {
+	client    *azblob.Client
+	container string
+}
+
+// NewAzureBlob connects to container on the configured Azure endpoint with
+// the configured Azure credentials, or else the emulator's well-known
+// account
+func NewAzureBlob(cfg *config.TestConfig, container string) (*AzureBlob, error) {
+	cred, err := cfg.AzureCredential()
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: %w", err)
+	}
+	emu := azurehelpers.EmulatorConfig(cfg.AzureEndpoint)
+	emu.Credential = cred
+
+	client, err := azurehelpers.NewBlobClient(emu)
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: %w", err)
+	}
+	return NewAzureBlobWithClient(client, container), nil
+}
+
+// NewAzureBlobWithClient wraps an existing client
+func NewAzureBlobWithClient(client *azblob.Client, container string) *AzureBlob {
+	return &AzureBlob{client: client, container: container}
+}
+
+// Provider returns "azure"
+func (a *AzureBlob) Provider() string { return "azure" }
+
+// Put writes data and metadata to key, overwriting any existing blob
+func (a *AzureBlob) Put(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	_, err := a.client.UploadBuffer(ctx, a.container, key, data, &azblob.UploadBufferOptions{
+		Metadata: pointers(metadata),
+	})
+	if err != nil {
+		return fmt.Errorf("objectstore: uploading %s/%s: %w", a.container, key, err)
+	}
+	return nil
+}
+
+// Get reads key and its metadata
+func (a *AzureBlob) Get(ctx context.Context, key string) (*Object, error) {
+	resp, err := a.client.DownloadStream(ctx, a.container, key, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, fmt.Errorf("%w: %s/%s", ErrNotFound, a.container, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: downloading %s/%s: %w", a.container, key, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: reading %s/%s: %w", a.container, key, err)
+	}
+	return &Object{Key: key, Data: data, Metadata: lowerKeys(resp.Metadata)}, nil
+}
+
+// List returns the names of the blobs starting with prefix
+func (a *AzureBlob) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	pager := a.client.NewListBlobsFlatPager(a.container, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("objectstore: listing %s/%s: %w", a.container, prefix, err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name != nil {
+				keys = append(keys, *item.Name)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// Delete removes key; deleting a missing blob is not an error, matching S3
+func (a *AzureBlob) Delete(ctx context.Context, key string) error {
+	_, err := a.client.DeleteBlob(ctx, a.container, key, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("objectstore: deleting %s/%s: %w", a.container, key, err)
+	}
+	return nil
+}
+
+// Close does nothing; the blob client holds no connections of its own
+func (a *AzureBlob) Close() error { return nil }
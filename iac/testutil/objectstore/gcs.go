@@ -0,0 +1,119 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"iac/testutil/config"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// GCS is an ObjectStore over a Cloud Storage bucket
+type GCS struct {
+	client *storage.Client
+	bucket string
+}
+
+// NewGCS connects to bucket on the configured GCP endpoint over the JSON API
+// as gcphelpers does, with the configured GCP credentials or, without any,
+// no authentication
+func NewGCS(ctx context.Context, cfg *config.TestConfig, bucket string) (*GCS, error) {
+	endpoint := strings.TrimRight(cfg.GCPEndpoint, "/")
+	auth, err := cfg.GCPClientOptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: %w", err)
+	}
+	client, err := storage.NewClient(ctx,
+		append([]option.ClientOption{option.WithEndpoint(endpoint + "/storage/v1/")}, auth...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: storage client for %s: %w", endpoint, err)
+	}
+	return NewGCSWithClient(client, bucket), nil
+}
+
+// NewGCSWithClient wraps an existing client; Close closes it
+func NewGCSWithClient(client *storage.Client, bucket string) *GCS {
+	return &GCS{client: client, bucket: bucket}
+}
+
+// Provider returns "gcp"
+func (g *GCS) Provider() string { return "gcp" }
+
+// Put writes data and metadata to key
+func (g *GCS) Put(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	w := g.client.Bucket(g.bucket).Object(key).NewWriter(ctx)
+	w.Metadata = metadata
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("objectstore: writing gs://%s/%s: %w", g.bucket, key, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("objectstore: writing gs://%s/%s: %w", g.bucket, key, err)
+	}
+	return nil
+}
+
+// Get reads key and its metadata. The reader does not carry user metadata,
+// so it is fetched with a separate attributes call.
+func (g *GCS) Get(ctx context.Context, key string) (*Object, error) {
+	obj := g.client.Bucket(g.bucket).Object(key)
+
+	r, err := obj.NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, fmt.Errorf("%w: gs://%s/%s", ErrNotFound, g.bucket, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: reading gs://%s/%s: %w", g.bucket, key, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: reading gs://%s/%s: %w", g.bucket, key, err)
+	}
+
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: reading attributes of gs://%s/%s: %w", g.bucket, key, err)
+	}
+	metadata := make(map[string]string, len(attrs.Metadata))
+	for k, v := range attrs.Metadata {
+		metadata[strings.ToLower(k)] = v
+	}
+	return &Object{Key: key, Data: data, Metadata: metadata}, nil
+}
+
+// List returns the names of the objects starting with prefix
+func (g *GCS) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	it := g.client.Bucket(g.bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return keys, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("objectstore: listing gs://%s/%s: %w", g.bucket, prefix, err)
+		}
+		keys = append(keys, attrs.Name)
+	}
+}
+
+// Delete removes key; deleting a missing object is not an error, matching S3
+func (g *GCS) Delete(ctx context.Context, key string) error {
+	err := g.client.Bucket(g.bucket).Object(key).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("objectstore: deleting gs://%s/%s: %w", g.bucket, key, err)
+	}
+	return nil
+}
+
+// Close closes the storage client
+func (g *GCS) Close() error { return g.client.Close() }
@@ -0,0 +1,360 @@
+// Package objectstore drives the buckets the storage facade creates through
+// one provider-agnostic interface, so the same scenario can be run against
+// S3, Azure Blob Storage and Cloud Storage and the results compared.
+//
+// The facade promises the same interface on any cloud; Check verifies the
+// deployed buckets also behave the same for the operations callers rely on:
+// put, get, list by prefix, delete, and user metadata surviving a round trip.
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"iac/testutil/config"
+	"iac/testutil/integration"
+	"iac/testutil/smoke"
+)
+
+// Providers are the provider_name values with an ObjectStore implementation
+var Providers = []string{"aws", "azure", "gcp"}
+
+// ErrNotFound is returned by Get for a key that does not exist
+var ErrNotFound = errors.New("objectstore: object not found")
+
+// Object is an object read back from a store. Metadata keys are lower-cased:
+// S3 and Azure return them in HTTP header case ("Owner"), Cloud Storage as
+// written.
+type Object struct {
+	Key      string
+	Data     []byte
+	Metadata map[string]string
+}
+
+// ObjectStore is one bucket (an S3 bucket, a blob container or a Cloud
+// Storage bucket)
+type ObjectStore interface {
+	// Provider returns the provider_name the store belongs to
+	Provider() string
+
+	Put(ctx context.Context, key string, data []byte, metadata map[string]string) error
+	Get(ctx context.Context, key string) (*Object, error)
+
+	// List returns the keys starting with prefix, in the order the
+	// provider returns them
+	List(ctx context.Context, prefix string) ([]string, error)
+
+	Delete(ctx context.Context, key string) error
+	Close() error
+}
+
+// Open returns the store for bucket on the emulator cfg configures for
+// provider
+func Open(ctx context.Context, cfg *config.TestConfig, provider, bucket string) (ObjectStore, error) {
+	var (
+		store ObjectStore
+		err   error
+	)
+	// Assigned per case so a failed constructor yields a nil interface,
+	// not one holding a nil pointer
+	switch provider {
+	case "aws":
+		var s *S3
+		if s, err = NewS3(cfg, bucket); err == nil {
+			store = s
+		}
+	case "azure":
+		var a *AzureBlob
+		if a, err = NewAzureBlob(cfg, bucket); err == nil {
+			store = a
+		}
+	case "gcp":
+		var g *GCS
+		if g, err = NewGCS(ctx, cfg, bucket); err == nil {
+			store = g
+		}
+	default:
+		err = fmt.Errorf("objectstore: no implementation for provider %q", provider)
+	}
+	return store, err
+}
+
+// Endpoint returns the emulator URL cfg configures for provider, answering
+// once the emulator serves that provider's storage API
+func Endpoint(cfg *config.TestConfig, provider string) string {
+	switch provider {
+	case "aws":
+		return cfg.CloudEmuEndpoint
+	case "azure":
+		return strings.TrimRight(cfg.AzureEndpoint, "/") + "/devstoreaccount1"
+	case "gcp":
+		return cfg.GCPEndpoint
+	}
+	return ""
+}
+
+// SkipUnlessRunning skips the test, or fails it under
+// SWE_REQUIRE_INTEGRATION, unless the object store of provider's emulator answers
+func SkipUnlessRunning(t *testing.T, cfg *config.TestConfig, provider string) {
+	t.Helper()
+
+	integration.Require(t, fmt.Sprintf("CloudEmu (%s)", provider), integration.StartCloudEmu,
+		smoke.Probe(cfg, storageServices(provider)...))
+}
+
+// storageServices returns the smoke check for provider's object store
+func storageServices(provider string) []smoke.Service {
+	name := map[string]string{"aws": "s3", "azure": "blob", "gcp": "gcs"}[provider]
+	services, err := smoke.Lookup(name)
+	if err != nil {
+		return nil
+	}
+	return services
+}
+
+// Capabilities checked by Check, in the order they run
+const (
+	CapabilityPut        = "put"
+	CapabilityGet        = "get"
+	CapabilityMetadata   = "metadata round-trip"
+	CapabilityListPrefix = "list by prefix"
+	CapabilityDelete     = "delete"
+)
+
+// Result is the outcome of one capability on one store. Err is nil when the
+// store behaved as expected.
+type Result struct {
+	Capability string
+	Err        error
+}
+
+// scenario is what Check writes, relative to its prefix. Only the docs/
+// keys are listed, so logs/ catches a provider ignoring the prefix.
+var (
+	scenarioKeys   = []string{"docs/a.txt", "docs/b.txt", "logs/c.txt"}
+	scenarioList   = []string{"docs/a.txt", "docs/b.txt"}
+	scenarioDelete = "docs/a.txt"
+
+	scenarioMetadata = map[string]string{
+		"owner":   "swe-cloud",
+		"purpose": "equivalence",
+	}
+)
+
+// Check runs the shared scenario against store under prefix and returns one
+// Result per capability. A capability whose setup failed is reported failed
+// too rather than left out, so every store yields the same rows. Check
+// deletes what it wrote.
+func Check(ctx context.Context, store ObjectStore, prefix string) []Result {
+	var results []Result
+	record := func(capability string, err error) bool {
+		results = append(results, Result{Capability: capability, Err: err})
+		return err == nil
+	}
+	defer func() {
+		for _, key := range scenarioKeys {
+			store.Delete(ctx, prefix+key)
+		}
+	}()
+
+	var putErr error
+	for _, key := range scenarioKeys {
+		if err := store.Put(ctx, prefix+key, content(key), scenarioMetadata); err != nil {
+			putErr = err
+			break
+		}
+	}
+	if !record(CapabilityPut, putErr) {
+		for _, capability := range []string{CapabilityGet, CapabilityMetadata, CapabilityListPrefix, CapabilityDelete} {
+			record(capability, fmt.Errorf("not run: %s failed", CapabilityPut))
+		}
+		return results
+	}
+
+	key := prefix + scenarioKeys[0]
+	obj, err := store.Get(ctx, key)
+	if err == nil && !bytes.Equal(obj.Data, content(scenarioKeys[0])) {
+		err = fmt.Errorf("read back %q, wrote %q", obj.Data, content(scenarioKeys[0]))
+	}
+	record(CapabilityGet, err)
+
+	if err == nil {
+		err = compareMetadata(obj.Metadata)
+	} else {
+		err = fmt.Errorf("not run: %s failed", CapabilityGet)
+	}
+	record(CapabilityMetadata, err)
+
+	record(CapabilityListPrefix, checkList(ctx, store, prefix))
+	record(CapabilityDelete, checkDelete(ctx, store, prefix))
+	return results
+}
+
+// content is the body written for key
+func content(key string) []byte {
+	return []byte("objectstore scenario: " + key)
+}
+
+// compareMetadata returns an error unless every scenario metadata entry came
+// back unchanged. Extra entries a provider adds are ignored.
+func compareMetadata(got map[string]string) error {
+	var problems []string
+	for k, want := range scenarioMetadata {
+		v, ok := got[k]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s missing", k))
+		case v != want:
+			problems = append(problems, fmt.Sprintf("%s: got %q, want %q", k, v, want))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// checkList lists prefix+"docs/" and compares the keys as a set: providers
+// may order them differently
+func checkList(ctx context.Context, store ObjectStore, prefix string) error {
+	keys, err := store.List(ctx, prefix+"docs/")
+	if err != nil {
+		return err
+	}
+
+	want := make([]string, len(scenarioList))
+	for i, key := range scenarioList {
+		want[i] = prefix + key
+	}
+	got := append([]string(nil), keys...)
+	sort.Strings(got)
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		return fmt.Errorf("listed %v, want %v", got, want)
+	}
+	return nil
+}
+
+// checkDelete deletes one object and expects Get to report ErrNotFound and
+// List to leave it out
+func checkDelete(ctx context.Context, store ObjectStore, prefix string) error {
+	key := prefix + scenarioDelete
+	if err := store.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("get after delete: got %v, want ErrNotFound", err)
+	}
+
+	keys, err := store.List(ctx, prefix+"docs/")
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k == key {
+			return fmt.Errorf("%s still listed after delete", key)
+		}
+	}
+	return nil
+}
+
+// Failed returns the results with an error
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Report renders a capability × provider table, followed by the error of
+// every failed cell. A provider without results (say, its emulator was not
+// running) shows "-":
+//
+//	capability           aws   azure  gcp
+//	put                  ok    ok     ok
+//	metadata round-trip  ok    FAIL   ok
+//
+//	azure: metadata round-trip: owner missing
+func Report(results map[string][]Result) string {
+	providers := make([]string, 0, len(results))
+	for provider := range results {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	capabilities := []string{CapabilityPut, CapabilityGet, CapabilityMetadata, CapabilityListPrefix, CapabilityDelete}
+
+	width := len("capability")
+	for _, c := range capabilities {
+		width = max(width, len(c))
+	}
+	colWidths := make([]int, len(providers))
+	for i, p := range providers {
+		colWidths[i] = max(len(p), len("FAIL"))
+	}
+
+	var b strings.Builder
+	row := func(first string, cells []string) {
+		line := fmt.Sprintf("%-*s", width, first)
+		for i, cell := range cells {
+			line += fmt.Sprintf("  %-*s", colWidths[i], cell)
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	row("capability", providers)
+	var details []string
+	for _, c := range capabilities {
+		cells := make([]string, len(providers))
+		for i, p := range providers {
+			cells[i] = "-"
+			for _, r := range results[p] {
+				if r.Capability != c {
+					continue
+				}
+				cells[i] = "ok"
+				if r.Err != nil {
+					cells[i] = "FAIL"
+					details = append(details, fmt.Sprintf("%s: %s: %v", p, c, r.Err))
+				}
+			}
+		}
+		row(c, cells)
+	}
+
+	if len(details) > 0 {
+		b.WriteString("\n" + strings.Join(details, "\n") + "\n")
+	}
+	return b.String()
+}
+
+// lowerKeys returns metadata with lower-cased keys and nil values dropped
+func lowerKeys(metadata map[string]*string) map[string]string {
+	out := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if v != nil {
+			out[strings.ToLower(k)] = *v
+		}
+	}
+	return out
+}
+
+// pointers converts metadata to the map[string]*string the AWS and Azure
+// SDKs take
+func pointers(metadata map[string]string) map[string]*string {
+	out := make(map[string]*string, len(metadata))
+	for k, v := range metadata {
+		out[k] = &v
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"iac/testutil/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3 is an ObjectStore over an S3 bucket
+type S3 struct {
+	client *s3.S3
+	bucket string
+}
+
+// NewS3 connects to bucket on the configured CloudEmu endpoint, with the
+// credentials the AWS integration tests use
+func NewS3(cfg *config.TestConfig, bucket string) (*S3, error) {
+	sess, err := cfg.AWSSession(cfg.CloudEmuEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: %w", err)
+	}
+	return NewS3WithClient(s3.New(sess), bucket), nil
+}
+
+// NewS3WithClient wraps an existing client
+func NewS3WithClient(client *s3.S3, bucket string) *S3 {
+	return &S3{client: client, bucket: bucket}
+}
+
+// Provider returns "aws"
+func (s *S3) Provider() string { return "aws" }
+
+// Put writes data and metadata to key
+func (s *S3) Put(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		Body:     bytes.NewReader(data),
+		Metadata: pointers(metadata),
+	})
+	if err != nil {
+		return fmt.Errorf("objectstore: putting s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+// Get reads key and its metadata
+func (s *S3) Get(ctx context.Context, key string) (*Object, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, fmt.Errorf("%w: s3://%s/%s", ErrNotFound, s.bucket, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: getting s3://%s/%s: %w", s.bucket, key, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: reading s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return &Object{Key: key, Data: data, Metadata: lowerKeys(out.Metadata)}, nil
+}
+
+// List returns the keys starting with prefix
+func (s *S3) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.StringValue(obj.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: listing s3://%s/%s: %w", s.bucket, prefix, err)
+	}
+	return keys, nil
+}
+
+// Delete removes key; deleting a missing key is not an error
+func (s *S3) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("objectstore: deleting s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+// PresignGetURL returns a URL that downloads key with a plain HTTP GET,
+// without credentials, until expiry has passed
+func (s *S3) PresignGetURL(key string, expiry time.Duration) (string, error) {
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	url, err := req.Presign(expiry)
+	if err != nil {
+		return "", fmt.Errorf("objectstore: presigning GET s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return url, nil
+}
+
+// PresignPutURL returns a URL that uploads key with a plain HTTP PUT of
+// the object as the body, without credentials, until expiry has passed
+func (s *S3) PresignPutURL(key string, expiry time.Duration) (string, error) {
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	url, err := req.Presign(expiry)
+	if err != nil {
+		return "", fmt.Errorf("objectstore: presigning PUT s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return url, nil
+}
+
+// Close does nothing; the S3 client holds no connections of its own
+func (s *S3) Close() error { return nil }
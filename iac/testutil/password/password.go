@@ -0,0 +1,173 @@
+// Package password generates database master passwords that each
+// provider accepts, so facade tests stop hard-coding "password123", which
+// Azure SQL rejects and which reads badly in plan logs.
+//
+// Rules mirrors the master_password validation in
+// facade/database/variables.tf. TestDatabaseFacadePasswordRules plans the
+// facade with passwords on either side of every rule and checks Terraform
+// and ValidatePassword agree, so a change to one without the other fails there.
+package password
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// DefaultUsername is the facade's default master_username, which Azure SQL
+// passwords may not contain
+const DefaultUsername = "admin"
+
+// Length is how long generated passwords are: long, and short enough for
+// RDS
+const Length = 24
+
+// Rule is what one provider requires of a master password
+type Rule struct {
+	// MinLength and MaxLength bound the length in characters; a zero
+	// MaxLength is unbounded
+	MinLength int
+	MaxLength int
+
+	// PrintableASCII allows only the characters '!' to '~'
+	PrintableASCII bool
+
+	// Forbidden lists characters the password may not contain
+	Forbidden string
+
+	// MinClasses is how many of upper case, lower case, digits and other
+	// characters the password must mix
+	MinClasses int
+
+	// NoUsername rejects passwords containing the login name, ignoring case
+	NoUsername bool
+}
+
+// Rules are the password rules of each provider the database facade
+// deploys to
+var Rules = map[string]Rule{
+	// RDS, for MySQL's 41-character limit as well as PostgreSQL
+	"aws": {MinLength: 8, MaxLength: 41, PrintableASCII: true, Forbidden: `/@"`},
+	// Azure SQL's complexity policy
+	"azure": {MinLength: 8, MaxLength: 128, MinClasses: 3, NoUsername: true},
+	// Cloud SQL built-in users without a password policy
+	"gcp": {MinLength: 8, MaxLength: 128},
+}
+
+// ValidatePassword returns an error naming every rule of provider that password
+// breaks, for a login named username. The error never includes the
+// password.
+func ValidatePassword(provider, username, password string) error {
+	rule, ok := Rules[provider]
+	if !ok {
+		return fmt.Errorf("password: unknown provider %q", provider)
+	}
+
+	var problems []string
+	length := utf8.RuneCountInString(password)
+	if length < rule.MinLength {
+		problems = append(problems, fmt.Sprintf("%d characters, want at least %d", length, rule.MinLength))
+	}
+	if rule.MaxLength > 0 && length > rule.MaxLength {
+		problems = append(problems, fmt.Sprintf("%d characters, want at most %d", length, rule.MaxLength))
+	}
+	if rule.PrintableASCII && strings.IndexFunc(password, func(r rune) bool { return r < '!' || r > '~' }) >= 0 {
+		problems = append(problems, "contains a space or a character outside printable ASCII")
+	}
+	if rule.Forbidden != "" && strings.ContainsAny(password, rule.Forbidden) {
+		problems = append(problems, fmt.Sprintf("contains one of %s", rule.Forbidden))
+	}
+	if n := classes(password); n < rule.MinClasses {
+		problems = append(problems, fmt.Sprintf("mixes %d of upper case, lower case, digits and symbols, want %d", n, rule.MinClasses))
+	}
+	if rule.NoUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		problems = append(problems, fmt.Sprintf("contains the login name %q", username))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("password: breaks the %s rules: %s", provider, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// classes counts the character classes in password. Only ASCII letters
+// and digits count as such, as in the facade's regular expressions.
+func classes(password string) int {
+	var upper, lower, digit, other int
+	for _, r := range password {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = 1
+		case r >= 'a' && r <= 'z':
+			lower = 1
+		case r >= '0' && r <= '9':
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return upper + lower + digit + other
+}
+
+// Character sets of generated passwords. Symbols leave out quotes,
+// backslashes, "/" and "@", which some provider or shell rejects.
+const (
+	upperChars  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	lowerChars  = "abcdefghijkmnopqrstuvwxyz"
+	digitChars  = "23456789"
+	symbolChars = "!#$%^&*()-_=+[]{}<>?.,:;~"
+	allChars    = upperChars + lowerChars + digitChars + symbolChars
+)
+
+// GeneratePassword returns a random Length-character password, from crypto/rand,
+// with every character class, that provider accepts for DefaultUsername
+func GeneratePassword(provider string) (string, error) {
+	if _, ok := Rules[provider]; !ok {
+		return "", fmt.Errorf("password: unknown provider %q", provider)
+	}
+
+	for {
+		chars := []byte{pick(upperChars), pick(lowerChars), pick(digitChars), pick(symbolChars)}
+		for len(chars) < Length {
+			chars = append(chars, pick(allChars))
+		}
+		// Move the guaranteed characters off the front
+		for i := len(chars) - 1; i > 0; i-- {
+			j := randInt(i + 1)
+			chars[i], chars[j] = chars[j], chars[i]
+		}
+
+		// Only fails on the rare password that spells the username
+		password := string(chars)
+		if ValidatePassword(provider, DefaultUsername, password) == nil {
+			return password, nil
+		}
+	}
+}
+
+// New is GeneratePassword for tests: an unknown provider fails t
+func New(t testing.TB, provider string) string {
+	t.Helper()
+
+	password, err := GeneratePassword(provider)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return password
+}
+
+func pick(chars string) byte {
+	return chars[randInt(len(chars))]
+}
+
+func randInt(n int) int {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(fmt.Sprintf("password: reading random bytes: %v", err))
+	}
+	return int(i.Int64())
+}
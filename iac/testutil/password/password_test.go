@@ -0,0 +1,106 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"iac/testutil/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePassword(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		provider string
+		password string
+		contains string // empty when the password is valid
+	}{
+		{"aws", "password123", ""},
+		{"aws", strings.Repeat("a", 41), ""},
+		{"aws", "short", "5 characters, want at least 8"},
+		{"aws", strings.Repeat("a", 42), "42 characters, want at most 41"},
+		{"aws", "pass word123", "contains a space"},
+		{"aws", "pässword123", "outside printable ASCII"},
+		{"aws", "pass/word123", `contains one of /@"`},
+		{"aws", "pass@word123", `contains one of /@"`},
+		{"aws", `pass"word123`, `contains one of /@"`},
+
+		{"azure", "Password123", ""},
+		{"azure", "pass word-123", ""},
+		{"azure", "password123", "mixes 2 of upper case, lower case, digits and symbols, want 3"},
+		{"azure", "PASSWORD!!", "mixes 2"},
+		{"azure", "Pa1!", "4 characters, want at least 8"},
+		{"azure", "MyAdmin123", `contains the login name "admin"`},
+		{"azure", "MyADMIN123", `contains the login name "admin"`},
+
+		{"gcp", "password", ""},
+		{"gcp", "hunter2", "7 characters, want at least 8"},
+		{"gcp", strings.Repeat("p", 129), "129 characters, want at most 128"},
+
+		{"oracle", "Password123", `unknown provider "oracle"`},
+	}
+
+	for _, tc := range tests {
+		err := password.ValidatePassword(tc.provider, password.DefaultUsername, tc.password)
+		if tc.contains == "" {
+			assert.NoError(t, err, "%s %q", tc.provider, tc.password)
+			continue
+		}
+		if assert.Error(t, err, "%s %q", tc.provider, tc.password) {
+			assert.Contains(t, err.Error(), tc.contains)
+			if len(tc.password) > 0 {
+				assert.NotContains(t, err.Error(), tc.password, "Errors should not quote the password")
+			}
+		}
+	}
+}
+
+func TestValidatePasswordReportsEveryRule(t *testing.T) {
+	t.Parallel()
+
+	err := password.ValidatePassword("aws", password.DefaultUsername, "a b/")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "breaks the aws rules")
+	assert.Contains(t, err.Error(), "4 characters")
+	assert.Contains(t, err.Error(), "contains a space")
+	assert.Contains(t, err.Error(), "contains one of")
+}
+
+func TestValidatePasswordUsername(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, password.ValidatePassword("azure", "dbowner", "MyAdmin123"))
+	assert.Error(t, password.ValidatePassword("azure", "dbowner", "Dbowner123!"))
+	assert.NoError(t, password.ValidatePassword("aws", "dbowner", "Dbowner123!"), "Only Azure forbids the login name")
+}
+
+func TestGeneratePassword(t *testing.T) {
+	t.Parallel()
+
+	for provider := range password.Rules {
+		seen := make(map[string]bool)
+		for i := 0; i < 200; i++ {
+			pw, err := password.GeneratePassword(provider)
+			require.NoError(t, err)
+
+			assert.Len(t, pw, password.Length)
+			assert.NoError(t, password.ValidatePassword(provider, password.DefaultUsername, pw))
+			assert.True(t, strings.ContainsAny(pw, "ABCDEFGHJKLMNPQRSTUVWXYZ"), "%s has no upper case letter", provider)
+			assert.True(t, strings.ContainsAny(pw, "abcdefghijkmnopqrstuvwxyz"), "%s has no lower case letter", provider)
+			assert.True(t, strings.ContainsAny(pw, "23456789"), "%s has no digit", provider)
+			assert.False(t, seen[pw], "Passwords should not repeat")
+			seen[pw] = true
+		}
+	}
+}
+
+func TestGeneratePasswordUnknownProvider(t *testing.T) {
+	t.Parallel()
+
+	_, err := password.GeneratePassword("oracle")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown provider "oracle"`)
+}
@@ -0,0 +1,156 @@
+package plandiff
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"iac/testutil/examplecheck"
+	"iac/testutil/modgraph"
+)
+
+// Selection is what a change reaches and the examples planned to show it
+type Selection struct {
+	// Facades are the facades whose configuration the change reaches,
+	// directly or through the modules they call, sorted
+	Facades []string
+
+	// Examples are the examples to plan, sorted by Dir
+	Examples []Target
+
+	// Uncovered are the Facades no example calls, so none is planned
+	Uncovered []string
+}
+
+// Target is an example to plan
+type Target struct {
+	// Dir is the slash-separated path relative to the root, e.g.
+	// examples/static-site
+	Dir string
+
+	// Facades are the changed facades the example is planned for; empty
+	// when only the example itself changed
+	Facades []string
+
+	// Variables are the variables the example declares
+	Variables []string
+}
+
+// Affected returns what a change to paths reaches in the tree at root.
+// paths are slash-separated and relative to root, as git diff --relative
+// prints them; a path belongs to the innermost module directory holding
+// it, and Go files, Markdown and .tftest.hcl files, which change no plan,
+// are ignored.
+//
+// Each facade a changed module reaches is planned through its default
+// example: of the examples that reach the facade, the one reaching the
+// fewest modules, then the first by name. An example that changed is
+// planned as well.
+func Affected(root string, paths []string) (*Selection, error) {
+	g, err := modgraph.Build(root)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := examplecheck.Inspect(root)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := map[string]bool{}
+	for _, p := range paths {
+		if ignored(p) {
+			continue
+		}
+		if module := owner(g, p); module != "" {
+			changed[module] = true
+		}
+	}
+
+	sel := &Selection{}
+	for _, facade := range inv.Facades {
+		for module := range reach(g, facade) {
+			if changed[module] {
+				sel.Facades = append(sel.Facades, facade)
+				break
+			}
+		}
+	}
+
+	reached := make(map[string]map[string]bool, len(inv.Examples))
+	for _, ex := range inv.Examples {
+		reached[ex.Dir] = reach(g, ex.Dir)
+	}
+
+	targets := map[string]*Target{}
+	target := func(ex examplecheck.Example) *Target {
+		if targets[ex.Dir] == nil {
+			targets[ex.Dir] = &Target{Dir: ex.Dir, Variables: ex.Variables}
+		}
+		return targets[ex.Dir]
+	}
+	for _, facade := range sel.Facades {
+		var best *examplecheck.Example
+		for i, ex := range inv.Examples {
+			if !reached[ex.Dir][facade] {
+				continue
+			}
+			// Examples are sorted by Dir, so a tie keeps the first
+			if best == nil || len(reached[ex.Dir]) < len(reached[best.Dir]) {
+				best = &inv.Examples[i]
+			}
+		}
+		if best == nil {
+			sel.Uncovered = append(sel.Uncovered, facade)
+			continue
+		}
+		t := target(*best)
+		t.Facades = append(t.Facades, facade)
+	}
+	for _, ex := range inv.Examples {
+		if changed[ex.Dir] {
+			target(ex)
+		}
+	}
+
+	for _, t := range targets {
+		sel.Examples = append(sel.Examples, *t)
+	}
+	sort.Slice(sel.Examples, func(i, j int) bool { return sel.Examples[i].Dir < sel.Examples[j].Dir })
+	return sel, nil
+}
+
+// ignored reports whether a change to p cannot change a plan
+func ignored(p string) bool {
+	switch {
+	case strings.HasSuffix(p, ".go"), strings.HasSuffix(p, ".md"), strings.HasSuffix(p, ".tftest.hcl"):
+		return true
+	}
+	return false
+}
+
+// owner returns the innermost module of g whose directory holds p, or ""
+func owner(g *modgraph.Graph, p string) string {
+	for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, ok := g.Edges[dir]; ok {
+			return dir
+		}
+	}
+	return ""
+}
+
+// reach returns module and every module it calls, directly or not
+func reach(g *modgraph.Graph, module string) map[string]bool {
+	seen := map[string]bool{module: true}
+	stack := []string{module}
+	for len(stack) > 0 {
+		from := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, e := range g.Edges[from] {
+			if !seen[e.To] {
+				seen[e.To] = true
+				stack = append(stack, e.To)
+			}
+		}
+	}
+	return seen
+}
@@ -0,0 +1,94 @@
+package plandiff_test
+
+import (
+	"testing"
+
+	"iac/testutil/plandiff"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exampleVariables are what both fixture examples declare
+var exampleVariables = []string{"project_name", "environment"}
+
+func TestAffected(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  plandiff.Selection
+	}{
+		{
+			name:  "facade planned through the smallest example",
+			paths: []string{"facade/storage/main.tf"},
+			want: plandiff.Selection{
+				Facades:  []string{"facade/storage"},
+				Examples: []plandiff.Target{{Dir: "examples/bucket", Facades: []string{"facade/storage"}, Variables: exampleVariables}},
+			},
+		},
+		{
+			name:  "core module reaches the facade calling it",
+			paths: []string{"aws/core/storage/main.tf"},
+			want: plandiff.Selection{
+				Facades:  []string{"facade/storage"},
+				Examples: []plandiff.Target{{Dir: "examples/bucket", Facades: []string{"facade/storage"}, Variables: exampleVariables}},
+			},
+		},
+		{
+			name:  "facades sharing an example",
+			paths: []string{"facade/cdn/main.tf", "facade/storage/main.tf"},
+			want: plandiff.Selection{
+				Facades: []string{"facade/cdn", "facade/storage"},
+				Examples: []plandiff.Target{
+					{Dir: "examples/bucket", Facades: []string{"facade/storage"}, Variables: exampleVariables},
+					{Dir: "examples/site", Facades: []string{"facade/cdn"}, Variables: exampleVariables},
+				},
+			},
+		},
+		{
+			name:  "file a module reads from a subdirectory",
+			paths: []string{"facade/cdn/templates/policy.json"},
+			want: plandiff.Selection{
+				Facades:  []string{"facade/cdn"},
+				Examples: []plandiff.Target{{Dir: "examples/site", Facades: []string{"facade/cdn"}, Variables: exampleVariables}},
+			},
+		},
+		{
+			name:  "changed example",
+			paths: []string{"examples/site/main.tf"},
+			want: plandiff.Selection{
+				Examples: []plandiff.Target{{Dir: "examples/site", Variables: exampleVariables}},
+			},
+		},
+		{
+			name:  "facade no example calls",
+			paths: []string{"facade/queue/main.tf"},
+			want: plandiff.Selection{
+				Facades:   []string{"facade/queue"},
+				Uncovered: []string{"facade/queue"},
+			},
+		},
+		{
+			name: "docs, tests and files outside any module",
+			paths: []string{
+				"facade/storage/README.generated.md",
+				"facade/storage/storage_test.go",
+				"facade/storage/tests/storage.tftest.hcl",
+				"doc/overview.txt",
+				"go.mod",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sel, err := plandiff.Affected("testdata/tree", tt.paths)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *sel)
+		})
+	}
+}
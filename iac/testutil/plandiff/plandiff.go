@@ -0,0 +1,297 @@
+// Package plandiff compares two Terraform plans structurally. Plans of the
+// same configuration for different environments should create the same
+// resources and differ only in a known set of attributes (names, sizes, HA
+// flags); a resource that exists in one environment only, or an attribute
+// that differs unexpectedly, is usually a conditional gone wrong.
+//
+// Plans are compared on their planned_values: resources by address, and
+// attributes by flattened path such as tags.environment or settings.0.tier.
+// Attributes only known after apply are absent from both sides.
+//
+// The same comparison shows a reviewer what a pull request changes: Review
+// plans the examples of the facades a change reaches at the base commit and
+// at the head, in temporary worktrees, and reports the differences by
+// resource address with planrisk's actions. tools/plandiff is that command.
+package plandiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Resource is a planned resource instance
+type Resource struct {
+	Address string
+	Type    string
+	Values  map[string]interface{}
+}
+
+// Plan holds the planned resources of a plan by address
+type Plan struct {
+	Resources map[string]Resource
+}
+
+type module struct {
+	Resources []struct {
+		Address string                 `json:"address"`
+		Mode    string                 `json:"mode"`
+		Type    string                 `json:"type"`
+		Values  map[string]interface{} `json:"values"`
+	} `json:"resources"`
+	ChildModules []module `json:"child_modules"`
+}
+
+// Parse decodes the managed resources of `terraform show -json` output for a
+// plan
+func Parse(planJSON []byte) (*Plan, error) {
+	var raw struct {
+		FormatVersion string `json:"format_version"`
+		PlannedValues struct {
+			RootModule module `json:"root_module"`
+		} `json:"planned_values"`
+	}
+	if err := json.Unmarshal(planJSON, &raw); err != nil {
+		return nil, fmt.Errorf("plandiff: decoding plan JSON: %w", err)
+	}
+	if raw.FormatVersion == "" {
+		return nil, fmt.Errorf("plandiff: not terraform show -json output (no format_version field)")
+	}
+	return newPlan(raw.PlannedValues.RootModule), nil
+}
+
+// ParseSnapshot decodes the managed resources of a plan normalized by
+// snapshot.Normalize, which keeps planned_values but not format_version
+func ParseSnapshot(normalized []byte) (*Plan, error) {
+	var raw struct {
+		PlannedValues *struct {
+			RootModule module `json:"root_module"`
+		} `json:"planned_values"`
+	}
+	if err := json.Unmarshal(normalized, &raw); err != nil {
+		return nil, fmt.Errorf("plandiff: decoding plan JSON: %w", err)
+	}
+	if raw.PlannedValues == nil {
+		return nil, fmt.Errorf("plandiff: not a normalized plan (no planned_values field)")
+	}
+	return newPlan(raw.PlannedValues.RootModule), nil
+}
+
+// newPlan collects the managed resources of root and its child modules
+func newPlan(root module) *Plan {
+	plan := &Plan{Resources: make(map[string]Resource)}
+	var walk func(m module)
+	walk = func(m module) {
+		for _, r := range m.Resources {
+			if r.Mode == "data" {
+				continue
+			}
+			plan.Resources[r.Address] = Resource{Address: r.Address, Type: r.Type, Values: r.Values}
+		}
+		for _, child := range m.ChildModules {
+			walk(child)
+		}
+	}
+	walk(root)
+	return plan
+}
+
+// TypeCounts returns the number of planned resources of each type
+func (p *Plan) TypeCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, r := range p.Resources {
+		counts[r.Type]++
+	}
+	return counts
+}
+
+// Difference is an attribute whose planned value differs between the plans
+type Difference struct {
+	Address string
+	Type    string
+
+	// Path is the flattened attribute path, e.g. tags.environment
+	Path string
+
+	A interface{}
+	B interface{}
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s %s: %s -> %s", d.Address, d.Path, render(d.A), render(d.B))
+}
+
+// Result is the comparison of plan A with plan B
+type Result struct {
+	// OnlyInA and OnlyInB list the addresses planned on one side only,
+	// sorted
+	OnlyInA []string
+	OnlyInB []string
+
+	// Allowed are the differences matching the allowlist, Unexpected the
+	// rest; both sorted by address and path
+	Allowed    []Difference
+	Unexpected []Difference
+}
+
+// Clean reports whether the plans have the same resources and differ only in
+// allowed attributes
+func (r *Result) Clean() bool {
+	return len(r.OnlyInA) == 0 && len(r.OnlyInB) == 0 && len(r.Unexpected) == 0
+}
+
+// Find returns the allowed or unexpected difference at address and path, or
+// nil if the attribute is the same in both plans
+func (r *Result) Find(address, path string) *Difference {
+	for _, diffs := range [][]Difference{r.Allowed, r.Unexpected} {
+		for i := range diffs {
+			if diffs[i].Address == address && diffs[i].Path == path {
+				return &diffs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Report describes what makes the result unclean:
+//
+//	only in A: module.database.module.aws_database[0].aws_db_instance.replica[0]
+//	unexpected: module.storage.module.aws_storage[0].aws_s3_bucket.this force_destroy: false -> true
+func (r *Result) Report() string {
+	var b strings.Builder
+	for _, address := range r.OnlyInA {
+		fmt.Fprintf(&b, "only in A: %s\n", address)
+	}
+	for _, address := range r.OnlyInB {
+		fmt.Fprintf(&b, "only in B: %s\n", address)
+	}
+	for _, d := range r.Unexpected {
+		fmt.Fprintf(&b, "unexpected: %s\n", d)
+	}
+	return b.String()
+}
+
+// Compare diffs plan a against plan b. allow lists the attributes expected to
+// differ, as <resource type>.<path> patterns in which * stands for any run of
+// characters: "aws_db_instance.multi_az", "*.tags.environment".
+func Compare(a, b *Plan, allow []string) *Result {
+	allowed := make([]*regexp.Regexp, len(allow))
+	for i, pattern := range allow {
+		quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+		allowed[i] = regexp.MustCompile("^" + quoted + "$")
+	}
+
+	result := &Result{}
+	for address := range a.Resources {
+		if _, ok := b.Resources[address]; !ok {
+			result.OnlyInA = append(result.OnlyInA, address)
+		}
+	}
+	for address, rb := range b.Resources {
+		ra, ok := a.Resources[address]
+		if !ok {
+			result.OnlyInB = append(result.OnlyInB, address)
+			continue
+		}
+
+		fa, fb := flatten(ra.Values), flatten(rb.Values)
+		paths := make(map[string]bool, len(fa))
+		for path := range fa {
+			paths[path] = true
+		}
+		for path := range fb {
+			paths[path] = true
+		}
+
+		for path := range paths {
+			if reflect.DeepEqual(fa[path], fb[path]) {
+				continue
+			}
+			d := Difference{Address: address, Type: rb.Type, Path: path, A: fa[path], B: fb[path]}
+			if matchesAny(allowed, rb.Type+"."+path) {
+				result.Allowed = append(result.Allowed, d)
+			} else {
+				result.Unexpected = append(result.Unexpected, d)
+			}
+		}
+	}
+
+	sort.Strings(result.OnlyInA)
+	sort.Strings(result.OnlyInB)
+	sortDifferences(result.Allowed)
+	sortDifferences(result.Unexpected)
+	return result
+}
+
+// flatten maps each leaf of values to its dotted path. Empty objects and
+// lists are leaves, so a block present on one side only still differs.
+func flatten(values map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			if len(value) == 0 {
+				flat[prefix] = value
+			}
+			for k, child := range value {
+				walk(join(prefix, k), child)
+			}
+		case []interface{}:
+			if len(value) == 0 {
+				flat[prefix] = value
+			}
+			for i, child := range value {
+				walk(join(prefix, strconv.Itoa(i)), child)
+			}
+		default:
+			flat[prefix] = value
+		}
+	}
+	for k, v := range values {
+		walk(k, v)
+	}
+	return flat
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, p := range patterns {
+		if p.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortDifferences(diffs []Difference) {
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Address != diffs[j].Address {
+			return diffs[i].Address < diffs[j].Address
+		}
+		return diffs[i].Path < diffs[j].Path
+	})
+}
+
+// render formats a planned value as JSON; an attribute missing from one plan
+// renders as null
+func render(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
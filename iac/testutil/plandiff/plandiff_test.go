@@ -0,0 +1,125 @@
+package plandiff_test
+
+import (
+	"os"
+	"testing"
+
+	"iac/testutil/plandiff"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bucketAddress   = "module.storage.module.aws_storage[0].aws_s3_bucket.this"
+	databaseAddress = "module.database.module.aws_database[0].aws_db_instance.this"
+)
+
+// environmentAttributes are what dev and prod plans of one configuration may
+// differ in
+var environmentAttributes = []string{
+	"aws_s3_bucket.bucket",
+	"aws_db_instance.identifier",
+	"aws_db_instance.instance_class",
+	"aws_db_instance.multi_az",
+	"*.tags.environment",
+	"*.tags_all.environment",
+}
+
+func loadPlan(t *testing.T, path string) *plandiff.Plan {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	plan, err := plandiff.Parse(data)
+	require.NoError(t, err)
+	return plan
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	plan := loadPlan(t, "testdata/dev.json")
+
+	assert.Len(t, plan.Resources, 3, "Data sources should be left out")
+	assert.Equal(t, "aws_db_instance", plan.Resources[databaseAddress].Type)
+	assert.Equal(t, map[string]int{
+		"aws_s3_bucket":            1,
+		"aws_s3_bucket_versioning": 1,
+		"aws_db_instance":          1,
+	}, plan.TypeCounts())
+}
+
+func TestCompareAllowedDifferences(t *testing.T) {
+	t.Parallel()
+
+	result := plandiff.Compare(loadPlan(t, "testdata/dev.json"), loadPlan(t, "testdata/prod.json"), environmentAttributes)
+
+	assert.True(t, result.Clean(), result.Report())
+	assert.Empty(t, result.Report())
+
+	class := result.Find(databaseAddress, "instance_class")
+	require.NotNil(t, class)
+	assert.Equal(t, "db.t3.micro", class.A)
+	assert.Equal(t, "db.t3.medium", class.B)
+
+	multiAZ := result.Find(databaseAddress, "multi_az")
+	require.NotNil(t, multiAZ)
+	assert.Equal(t, false, multiAZ.A)
+	assert.Equal(t, true, multiAZ.B)
+
+	assert.NotNil(t, result.Find(bucketAddress, "tags.environment"))
+	assert.Nil(t, result.Find(bucketAddress, "tags.project"), "Equal attributes are not differences")
+	assert.Nil(t, result.Find(databaseAddress, "vpc_security_group_ids"), "Equal empty lists are not differences")
+}
+
+func TestCompareUnexpectedDifferences(t *testing.T) {
+	t.Parallel()
+
+	result := plandiff.Compare(loadPlan(t, "testdata/dev.json"), loadPlan(t, "testdata/prod-drifted.json"), environmentAttributes)
+
+	assert.False(t, result.Clean())
+	assert.Empty(t, result.OnlyInA)
+	assert.Equal(t, []string{"module.database.module.aws_database[0].aws_db_instance.replica[0]"}, result.OnlyInB)
+	require.Len(t, result.Unexpected, 1)
+	assert.Equal(t, "force_destroy", result.Unexpected[0].Path)
+
+	assert.Equal(t, `only in B: module.database.module.aws_database[0].aws_db_instance.replica[0]
+unexpected: module.storage.module.aws_storage[0].aws_s3_bucket.this force_destroy: false -> true
+`, result.Report())
+
+	// Swapping the plans swaps the sides
+	swapped := plandiff.Compare(loadPlan(t, "testdata/prod-drifted.json"), loadPlan(t, "testdata/dev.json"), environmentAttributes)
+	assert.Equal(t, result.OnlyInB, swapped.OnlyInA)
+}
+
+func TestCompareWithoutAllowlist(t *testing.T) {
+	t.Parallel()
+
+	result := plandiff.Compare(loadPlan(t, "testdata/dev.json"), loadPlan(t, "testdata/prod.json"), nil)
+
+	var paths []string
+	for _, d := range result.Unexpected {
+		paths = append(paths, d.Address+" "+d.Path)
+	}
+	assert.Equal(t, []string{
+		databaseAddress + " identifier",
+		databaseAddress + " instance_class",
+		databaseAddress + " multi_az",
+		databaseAddress + " tags.environment",
+		databaseAddress + " tags_all.environment",
+		bucketAddress + " bucket",
+		bucketAddress + " tags.environment",
+		bucketAddress + " tags_all.environment",
+	}, paths)
+}
+
+func TestParseRejectsOtherJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := plandiff.Parse([]byte(`{"planned_values": {}}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no format_version")
+
+	_, err = plandiff.Parse([]byte(`Plan: 3 to add`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,315 @@
+package plandiff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"iac/testutil/planrisk"
+	"iac/testutil/snapshot"
+)
+
+// Markers prefix each resource in the Markdown report, as terraform plan
+// prefixes its changes
+var Markers = map[planrisk.Action]string{
+	planrisk.Create:  "+",
+	planrisk.Update:  "~",
+	planrisk.Replace: "-/+",
+	planrisk.Destroy: "-",
+}
+
+// ResourceDiff is one resource the head plans differently from the base
+type ResourceDiff struct {
+	Address string
+	Type    string
+
+	// Action is Create for a resource only the head plans, Destroy for one
+	// only the base plans, and Update for one whose attributes differ, or
+	// Replace when the head's plan itself replaces it
+	Action planrisk.Action
+
+	// Attributes are the differing attributes of an Update or Replace, A
+	// being the base's value and B the head's, sorted by path
+	Attributes []Difference
+}
+
+// Diff compares the plan of an example at the base with its plan at the
+// head, both `terraform show -json` output. base is nil when the example
+// does not exist at the base, so the head creates everything it plans.
+//
+// Both plans are normalized with snapshot.Normalize and its DefaultMasks
+// first, so timestamps and random suffixes do not show as differences.
+// Plans from no state only create, so a Replace shows only when the head
+// is planned against a deployment, as in a CloudEmu workspace the base was
+// applied to.
+func Diff(base, head []byte) ([]ResourceDiff, error) {
+	headPlan, err := normalizedPlan(head)
+	if err != nil {
+		return nil, err
+	}
+	basePlan := &Plan{Resources: map[string]Resource{}}
+	if base != nil {
+		if basePlan, err = normalizedPlan(base); err != nil {
+			return nil, err
+		}
+	}
+	summary, err := planrisk.Classify(head)
+	if err != nil {
+		return nil, err
+	}
+	headActions := make(map[string]planrisk.Action, len(summary.Changes))
+	for _, c := range summary.Changes {
+		headActions[c.Address] = c.Action
+	}
+
+	result := Compare(basePlan, headPlan, nil)
+	var diffs []ResourceDiff
+	for _, address := range result.OnlyInA {
+		diffs = append(diffs, ResourceDiff{Address: address, Type: basePlan.Resources[address].Type, Action: planrisk.Destroy})
+	}
+	for _, address := range result.OnlyInB {
+		diffs = append(diffs, ResourceDiff{Address: address, Type: headPlan.Resources[address].Type, Action: planrisk.Create})
+	}
+	changed := map[string]int{}
+	for _, d := range result.Unexpected {
+		i, ok := changed[d.Address]
+		if !ok {
+			action := planrisk.Update
+			if headActions[d.Address] == planrisk.Replace {
+				action = planrisk.Replace
+			}
+			i = len(diffs)
+			changed[d.Address] = i
+			diffs = append(diffs, ResourceDiff{Address: d.Address, Type: d.Type, Action: action})
+		}
+		diffs[i].Attributes = append(diffs[i].Attributes, d)
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].Address < diffs[j].Address })
+	return diffs, nil
+}
+
+// normalizedPlan parses planJSON after snapshot.Normalize
+func normalizedPlan(planJSON []byte) (*Plan, error) {
+	normalized, err := snapshot.Normalize(planJSON, snapshot.DefaultMasks...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSnapshot(normalized)
+}
+
+// ExampleDiff is one planned example of a Report
+type ExampleDiff struct {
+	Example string
+
+	// Facades are the changed facades it was planned for
+	Facades []string
+
+	// Resources are sorted by address
+	Resources []ResourceDiff
+
+	// Err is why the example could not be planned or compared
+	Err error
+}
+
+// Count returns how many of the resources have action
+func (e ExampleDiff) Count(action planrisk.Action) int {
+	n := 0
+	for _, r := range e.Resources {
+		if r.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Report is what a change does to the plans of the examples it reaches
+type Report struct {
+	// Base and Head are the commits compared
+	Base string
+	Head string
+
+	// Facades and Uncovered are those of the Selection
+	Facades   []string
+	Uncovered []string
+
+	Examples []ExampleDiff
+}
+
+// Failed reports whether an example could not be planned
+func (r *Report) Failed() bool {
+	for _, e := range r.Examples {
+		if e.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Review compares what the commits base and head of the repository at root
+// plan. root is the directory of the Terraform tree, the iac module here,
+// and may be below the repository's top level. Both commits are checked out
+// into temporary worktrees, the files changed between them select the
+// examples to plan (see Affected), and each example is planned at both
+// commits by planner with the vars it declares. An example that fails is
+// reported with its error; the error returned is git's or the tree's.
+func Review(ctx context.Context, root, base, head string, planner Planner, vars map[string]interface{}) (*Report, error) {
+	baseCommit, err := ResolveCommit(root, base)
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := ResolveCommit(root, head)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := ChangedFiles(root, baseCommit, headCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	headTree, err := AddWorktree(root, headCommit)
+	if err != nil {
+		return nil, err
+	}
+	defer headTree.Remove()
+	sel, err := Affected(headTree.Root, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Base: baseCommit, Head: headCommit, Facades: sel.Facades, Uncovered: sel.Uncovered}
+	if len(sel.Examples) == 0 {
+		return report, nil
+	}
+	baseTree, err := AddWorktree(root, baseCommit)
+	if err != nil {
+		return nil, err
+	}
+	defer baseTree.Remove()
+
+	for _, target := range sel.Examples {
+		e := ExampleDiff{Example: target.Dir, Facades: target.Facades}
+		e.Resources, e.Err = diffExample(ctx, planner, baseTree.Root, headTree.Root, target, declared(vars, target.Variables))
+		report.Examples = append(report.Examples, e)
+	}
+	return report, nil
+}
+
+// diffExample plans target in both checkouts and compares the plans
+func diffExample(ctx context.Context, planner Planner, baseRoot, headRoot string, target Target, vars map[string]interface{}) ([]ResourceDiff, error) {
+	dir := filepath.FromSlash(target.Dir)
+	head, err := planner.Plan(ctx, filepath.Join(headRoot, dir), vars)
+	if err != nil {
+		return nil, fmt.Errorf("planning the head: %w", err)
+	}
+
+	var base []byte
+	if _, err := os.Stat(filepath.Join(baseRoot, dir)); err == nil {
+		if base, err = planner.Plan(ctx, filepath.Join(baseRoot, dir), vars); err != nil {
+			return nil, fmt.Errorf("planning the base: %w", err)
+		}
+	}
+	return Diff(base, head)
+}
+
+// declared returns the vars among names
+func declared(vars map[string]interface{}, names []string) map[string]interface{} {
+	kept := map[string]interface{}{}
+	for _, name := range names {
+		if value, ok := vars[name]; ok {
+			kept[name] = value
+		}
+	}
+	return kept
+}
+
+// WriteMarkdown writes the report as Markdown for a pull request comment:
+// the commits and facades, a table counting each example's changes, then
+// a section per example listing its resources by address, each with its
+// marker and action, and the attributes that differ as base and head
+// values
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Plan diff\n\n")
+	fmt.Fprintf(&b, "**Base**: `%s` **Head**: `%s`\n\n", short(r.Base), short(r.Head))
+	if len(r.Facades) == 0 {
+		b.WriteString("No facade changed.\n")
+	} else {
+		fmt.Fprintf(&b, "**Facades changed**: %s\n", codeList(r.Facades))
+	}
+	if len(r.Uncovered) > 0 {
+		fmt.Fprintf(&b, "\n**Not planned**, as no example calls them: %s\n", codeList(r.Uncovered))
+	}
+	if len(r.Examples) == 0 {
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("\n| Example | Planned for | Create | Update | Replace | Destroy |\n")
+	b.WriteString("| :--- | :--- | ---: | ---: | ---: | ---: |\n")
+	for _, e := range r.Examples {
+		facades := strings.Join(e.Facades, ", ")
+		if facades == "" {
+			facades = "the example itself"
+		}
+		if e.Err != nil {
+			fmt.Fprintf(&b, "| %s | %s | - | - | - | - |\n", e.Example, facades)
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %d | %d |\n", e.Example, facades,
+			e.Count(planrisk.Create), e.Count(planrisk.Update), e.Count(planrisk.Replace), e.Count(planrisk.Destroy))
+	}
+
+	for _, e := range r.Examples {
+		fmt.Fprintf(&b, "\n## %s\n", e.Example)
+		switch {
+		case e.Err != nil:
+			fmt.Fprintf(&b, "\n```\n%s\n```\n", e.Err)
+		case len(e.Resources) == 0:
+			b.WriteString("\nNo resource differences.\n")
+		}
+		for _, res := range e.Resources {
+			fmt.Fprintf(&b, "\n### %s `%s` (%s)\n", Markers[res.Action], res.Address, res.Action)
+			if len(res.Attributes) == 0 {
+				continue
+			}
+			b.WriteString("\n| Attribute | Base | Head |\n")
+			b.WriteString("| :--- | :--- | :--- |\n")
+			for _, d := range res.Attributes {
+				fmt.Fprintf(&b, "| `%s` | %s | %s |\n", d.Path, cell(d.A), cell(d.B))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// short abbreviates a commit hash
+func short(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+func codeList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = "`" + item + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// cell renders a planned value for a table cell, or a dash for a value
+// missing on that side
+func cell(v interface{}) string {
+	if v == nil {
+		return "-"
+	}
+	return "`" + strings.ReplaceAll(render(v), "|", `\|`) + "`"
+}
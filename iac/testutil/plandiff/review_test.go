@@ -0,0 +1,337 @@
+package plandiff_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"iac/testutil/plandiff"
+	"iac/testutil/planrisk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const replicaAddress = "module.database.module.aws_database[0].aws_db_instance.replica[0]"
+
+// planJSON builds `terraform show -json` output planning resources, keyed
+// by address in the root module, each created unless it is in replaced
+func planJSON(t *testing.T, resources map[string]map[string]interface{}, replaced ...string) []byte {
+	t.Helper()
+	addresses := make([]string, 0, len(resources))
+	for address := range resources {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	var planned, changes []interface{}
+	for _, address := range addresses {
+		resourceType, name, _ := strings.Cut(address, ".")
+		planned = append(planned, map[string]interface{}{
+			"address": address, "mode": "managed", "type": resourceType, "name": name, "values": resources[address],
+		})
+		actions := []string{"create"}
+		for _, r := range replaced {
+			if r == address {
+				actions = []string{"delete", "create"}
+			}
+		}
+		changes = append(changes, map[string]interface{}{
+			"address": address, "mode": "managed", "type": resourceType, "name": name,
+			"change": map[string]interface{}{"actions": actions, "after": resources[address], "after_unknown": map[string]interface{}{}},
+		})
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"format_version":   "1.2",
+		"planned_values":   map[string]interface{}{"root_module": map[string]interface{}{"resources": planned}},
+		"resource_changes": changes,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	diffs, err := plandiff.Diff(readFile(t, "testdata/dev.json"), readFile(t, "testdata/prod-drifted.json"))
+	require.NoError(t, err)
+
+	require.Len(t, diffs, 3, "Resources planned alike should be left out")
+	assert.Equal(t, replicaAddress, diffs[0].Address)
+	assert.Equal(t, planrisk.Create, diffs[0].Action)
+	assert.Empty(t, diffs[0].Attributes)
+
+	assert.Equal(t, databaseAddress, diffs[1].Address)
+	assert.Equal(t, planrisk.Update, diffs[1].Action)
+	assert.Equal(t, bucketAddress, diffs[2].Address)
+	assert.Equal(t, planrisk.Update, diffs[2].Action)
+
+	var paths []string
+	for _, d := range diffs[2].Attributes {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"bucket", "force_destroy", "tags.environment", "tags_all.environment"}, paths)
+	assert.Equal(t, false, diffs[2].Attributes[1].A)
+	assert.Equal(t, true, diffs[2].Attributes[1].B)
+
+	// Swapping the plans turns the creation into a destruction
+	swapped, err := plandiff.Diff(readFile(t, "testdata/prod-drifted.json"), readFile(t, "testdata/dev.json"))
+	require.NoError(t, err)
+	assert.Equal(t, planrisk.Destroy, swapped[0].Action)
+}
+
+func TestDiffNewExample(t *testing.T) {
+	t.Parallel()
+
+	diffs, err := plandiff.Diff(nil, readFile(t, "testdata/dev.json"))
+	require.NoError(t, err)
+	require.Len(t, diffs, 3)
+	for _, d := range diffs {
+		assert.Equal(t, planrisk.Create, d.Action, d.Address)
+	}
+}
+
+func TestDiffReplace(t *testing.T) {
+	t.Parallel()
+
+	base := planJSON(t, map[string]map[string]interface{}{
+		"aws_s3_bucket.this": {"bucket": "site-dev", "force_destroy": false},
+		"aws_sqs_queue.jobs": {"name": "jobs-dev-0f1e2d3c4b"},
+	})
+	head := planJSON(t, map[string]map[string]interface{}{
+		"aws_s3_bucket.this": {"bucket": "site-dev-assets", "force_destroy": false},
+		"aws_sqs_queue.jobs": {"name": "jobs-dev-a1b2c3d4e5"},
+	}, "aws_s3_bucket.this")
+
+	diffs, err := plandiff.Diff(base, head)
+	require.NoError(t, err)
+
+	require.Len(t, diffs, 1, "A random suffix should be masked rather than differ")
+	assert.Equal(t, "aws_s3_bucket.this", diffs[0].Address)
+	assert.Equal(t, planrisk.Replace, diffs[0].Action, "An attribute the head's plan replaces the resource for should be a replacement")
+	require.Len(t, diffs[0].Attributes, 1)
+	assert.Equal(t, "bucket", diffs[0].Attributes[0].Path)
+}
+
+func TestDiffRejectsOtherJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := plandiff.Diff(nil, []byte(`Plan: 3 to add`))
+	assert.Error(t, err)
+}
+
+func TestParseSnapshot(t *testing.T) {
+	t.Parallel()
+
+	_, err := plandiff.ParseSnapshot([]byte(`{"variables": {}}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no planned_values")
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	t.Parallel()
+
+	report := &plandiff.Report{
+		Base:      "0123456789abcdef0123",
+		Head:      "fedcba9876543210fedc",
+		Facades:   []string{"facade/queue", "facade/storage"},
+		Uncovered: []string{"facade/queue"},
+		Examples: []plandiff.ExampleDiff{
+			{
+				Example: "examples/bucket",
+				Facades: []string{"facade/storage"},
+				Resources: []plandiff.ResourceDiff{
+					{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Action: planrisk.Create},
+					{Address: "aws_s3_bucket.this", Type: "aws_s3_bucket", Action: planrisk.Replace, Attributes: []plandiff.Difference{
+						{Address: "aws_s3_bucket.this", Type: "aws_s3_bucket", Path: "bucket", A: "site", B: "site|assets"},
+						{Address: "aws_s3_bucket.this", Type: "aws_s3_bucket", Path: "tags.owner", B: "web"},
+					}},
+				},
+			},
+			{Example: "examples/site"},
+			{Example: "examples/broken", Facades: []string{"facade/storage"}, Err: assert.AnError},
+		},
+	}
+	assert.True(t, report.Failed())
+
+	var b strings.Builder
+	require.NoError(t, report.WriteMarkdown(&b))
+	assert.Equal(t, "# Plan diff\n"+
+		"\n"+
+		"**Base**: `0123456789ab` **Head**: `fedcba987654`\n"+
+		"\n"+
+		"**Facades changed**: `facade/queue`, `facade/storage`\n"+
+		"\n"+
+		"**Not planned**, as no example calls them: `facade/queue`\n"+
+		"\n"+
+		"| Example | Planned for | Create | Update | Replace | Destroy |\n"+
+		"| :--- | :--- | ---: | ---: | ---: | ---: |\n"+
+		"| examples/bucket | facade/storage | 1 | 0 | 1 | 0 |\n"+
+		"| examples/site | the example itself | 0 | 0 | 0 | 0 |\n"+
+		"| examples/broken | facade/storage | - | - | - | - |\n"+
+		"\n"+
+		"## examples/bucket\n"+
+		"\n"+
+		"### + `aws_s3_bucket.logs` (create)\n"+
+		"\n"+
+		"### -/+ `aws_s3_bucket.this` (replace)\n"+
+		"\n"+
+		"| Attribute | Base | Head |\n"+
+		"| :--- | :--- | :--- |\n"+
+		"| `bucket` | `\"site\"` | `\"site\\|assets\"` |\n"+
+		"| `tags.owner` | - | `\"web\"` |\n"+
+		"\n"+
+		"## examples/site\n"+
+		"\n"+
+		"No resource differences.\n"+
+		"\n"+
+		"## examples/broken\n"+
+		"\n"+
+		"```\n"+assert.AnError.Error()+"\n```\n", b.String())
+}
+
+func TestWriteMarkdownNothingChanged(t *testing.T) {
+	t.Parallel()
+
+	report := &plandiff.Report{Base: "0123456", Head: "7654321"}
+	assert.False(t, report.Failed())
+
+	var b strings.Builder
+	require.NoError(t, report.WriteMarkdown(&b))
+	assert.Equal(t, "# Plan diff\n\n**Base**: `0123456` **Head**: `7654321`\n\nNo facade changed.\n", b.String())
+}
+
+// fakeTerraform writes a terraform stand-in whose plan logs its arguments
+// to plans.log beside it and whose show prints the plan.json of the
+// directory it runs in, as a checkout of the fixture repository has one per
+// example
+func fakeTerraform(t *testing.T) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake terraform is a shell script")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "terraform")
+	log := filepath.Join(dir, "plans.log")
+	script := `#!/bin/sh
+dir="${1#-chdir=}"
+case "$2" in
+init) echo "Terraform has been successfully initialized!" ;;
+plan) echo "$*" >> "` + log + `" ;;
+show)
+  if [ ! -f "$dir/plan.json" ]; then
+    echo "Error: Failed to read the plan" >&2
+    exit 1
+  fi
+  cat "$dir/plan.json" ;;
+esac
+`
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o755))
+	return binary, log
+}
+
+// copyTree copies the regular files below src into dest
+func copyTree(t *testing.T, src, dest string) {
+	t.Helper()
+	err := filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	require.NoError(t, err)
+}
+
+func TestReview(t *testing.T) {
+	t.Parallel()
+
+	binary, log := fakeTerraform(t)
+	repo, root := newRepo(t)
+	copyTree(t, "testdata/tree", root)
+	writeFiles(t, root, map[string]string{
+		"examples/bucket/plan.json": string(readFile(t, "testdata/dev.json")),
+		"examples/site/plan.json":   string(readFile(t, "testdata/dev.json")),
+	})
+	base := commit(t, repo, "base")
+
+	// The pull request changes the core module under the storage facade,
+	// which the bucket example plans, and a README, which changes nothing
+	writeFiles(t, root, map[string]string{
+		"aws/core/storage/main.tf":  "# force_destroy\n" + string(readFile(t, "testdata/tree/aws/core/storage/main.tf")),
+		"examples/bucket/plan.json": string(readFile(t, "testdata/prod-drifted.json")),
+		"facade/cdn/README.md":      "# CDN\n",
+	})
+	head := commit(t, repo, "head")
+
+	tf := &plandiff.Terraform{Binary: binary}
+	vars := map[string]interface{}{"environment": "dev", "provider_name": "aws"}
+	report, err := plandiff.Review(context.Background(), root, base, head, tf, vars)
+	require.NoError(t, err)
+
+	assert.Equal(t, base, report.Base)
+	assert.Equal(t, head, report.Head)
+	assert.Equal(t, []string{"facade/storage"}, report.Facades)
+	require.Len(t, report.Examples, 1, "Only the storage facade's default example should be planned")
+	e := report.Examples[0]
+	require.NoError(t, e.Err)
+	assert.Equal(t, "examples/bucket", e.Example)
+	assert.Equal(t, 1, e.Count(planrisk.Create))
+	assert.Equal(t, 2, e.Count(planrisk.Update))
+
+	plans := string(readFile(t, log))
+	assert.Equal(t, 2, strings.Count(plans, "-var environment=dev"), "Both commits should be planned with the example's variables")
+	assert.NotContains(t, plans, "provider_name", "A variable the example does not declare should not be passed")
+
+	var b strings.Builder
+	require.NoError(t, report.WriteMarkdown(&b))
+	assert.Contains(t, b.String(), "| examples/bucket | facade/storage | 1 | 2 | 0 | 0 |")
+	assert.Contains(t, b.String(), "### + `"+replicaAddress+"` (create)")
+	assert.Contains(t, b.String(), "| `force_destroy` | `false` | `true` |")
+
+	worktrees := runGit(t, repo, "worktree", "list")
+	assert.Equal(t, 1, strings.Count(worktrees, "\n")+1, "The worktrees should be removed:\n%s", worktrees)
+}
+
+func TestReviewExampleFails(t *testing.T) {
+	t.Parallel()
+
+	binary, _ := fakeTerraform(t)
+	repo, root := newRepo(t)
+	copyTree(t, "testdata/tree", root)
+	base := commit(t, repo, "base")
+	writeFiles(t, root, map[string]string{"facade/cdn/main.tf": "# changed\n" + string(readFile(t, "testdata/tree/facade/cdn/main.tf"))})
+	head := commit(t, repo, "head")
+
+	report, err := plandiff.Review(context.Background(), root, base, head, &plandiff.Terraform{Binary: binary}, nil)
+	require.NoError(t, err, "An example that cannot be planned should be reported, not fail the review")
+	require.Len(t, report.Examples, 1)
+	assert.Equal(t, "examples/site", report.Examples[0].Example)
+	require.Error(t, report.Examples[0].Err)
+	assert.Contains(t, report.Examples[0].Err.Error(), "planning the head: terraform show: exit status 1\nError: Failed to read the plan")
+	assert.True(t, report.Failed())
+}
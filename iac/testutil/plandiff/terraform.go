@@ -0,0 +1,106 @@
+package plandiff
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"iac/testutil/tflog"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// errorLines is how many of a failed command's last lines its error quotes
+// when none is a Terraform error
+const errorLines = 10
+
+// Planner plans the example in dir with vars and returns the plan as
+// `terraform show -json` prints it
+type Planner interface {
+	Plan(ctx context.Context, dir string, vars map[string]interface{}) ([]byte, error)
+}
+
+// PlannerFunc adapts a function to Planner
+type PlannerFunc func(ctx context.Context, dir string, vars map[string]interface{}) ([]byte, error)
+
+// Plan implements Planner
+func (f PlannerFunc) Plan(ctx context.Context, dir string, vars map[string]interface{}) ([]byte, error) {
+	return f(ctx, dir, vars)
+}
+
+// Terraform plans examples with a Terraform CLI, from no state: init
+// skips the backend, so nothing deployed is read or locked, and the
+// .terraform directory goes to a temporary TF_DATA_DIR rather than into the
+// checkout
+type Terraform struct {
+	// Binary is the CLI, e.g. terraform or tofu
+	Binary string
+
+	// Env is added to the environment of every command, e.g. EmulatorEnv
+	Env []string
+}
+
+// EmulatorEnv points the AWS provider at a CloudEmu endpoint with the
+// emulator's placeholder credentials, for examples that configure no
+// provider of their own
+func EmulatorEnv(endpoint, region string) []string {
+	return []string{
+		"AWS_ENDPOINT_URL=" + endpoint,
+		"AWS_ACCESS_KEY_ID=test",
+		"AWS_SECRET_ACCESS_KEY=test",
+		"AWS_REGION=" + region,
+	}
+}
+
+// Plan implements Planner
+func (tf *Terraform) Plan(ctx context.Context, dir string, vars map[string]interface{}) ([]byte, error) {
+	dataDir, err := os.MkdirTemp("", "plandiff-data-")
+	if err != nil {
+		return nil, fmt.Errorf("plandiff: %w", err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	planFile := filepath.Join(dataDir, "plan.out")
+	if _, err := tf.run(ctx, dir, dataDir, "init", "-input=false", "-no-color", "-backend=false"); err != nil {
+		return nil, err
+	}
+	args := append([]string{"plan", "-input=false", "-no-color", "-lock=false", "-out=" + planFile}, terraform.FormatTerraformVarsAsArgs(vars)...)
+	if _, err := tf.run(ctx, dir, dataDir, args...); err != nil {
+		return nil, err
+	}
+	return tf.run(ctx, dir, dataDir, "show", "-json", planFile)
+}
+
+// run runs the CLI in dir and returns its standard output
+func (tf *Terraform) run(ctx context.Context, dir, dataDir string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, tf.Binary, append([]string{"-chdir=" + dir}, args...)...)
+	cmd.Env = append(append(os.Environ(), tf.Env...), "TF_IN_AUTOMATION=1", "TF_INPUT=0", "TF_DATA_DIR="+dataDir)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("terraform %s: %w\n%s", args[0], err, summarize(stdout.String()+stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// summarize returns the error lines of a failed command's output, or its
+// last lines when it printed no Terraform error
+func summarize(output string) string {
+	var diagnostics []string
+	tail := tflog.NewTail(errorLines)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if tflog.Classify(line) == tflog.Error {
+			diagnostics = append(diagnostics, tflog.Clean(line))
+		}
+		tail.Add(line)
+	}
+	if len(diagnostics) > 0 {
+		return strings.Join(diagnostics, "\n")
+	}
+	return strings.Join(tail.Lines(), "\n")
+}
@@ -0,0 +1,8 @@
+variable "bucket_name" {
+  description = "Bucket name"
+  type        = string
+}
+
+resource "aws_s3_bucket" "this" {
+  bucket = var.bucket_name
+}
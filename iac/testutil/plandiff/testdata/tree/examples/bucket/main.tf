@@ -0,0 +1,4 @@
+module "bucket" {
+  source      = "../../facade/storage"
+  bucket_name = "${var.project_name}-${var.environment}"
+}
@@ -0,0 +1,9 @@
+module "site" {
+  source      = "../../facade/storage"
+  bucket_name = "${var.project_name}-${var.environment}-site"
+}
+
+module "cdn" {
+  source = "../../facade/cdn"
+  origin = "${var.project_name}-${var.environment}-site"
+}
@@ -0,0 +1,4 @@
+variable "origin" {
+  description = "Origin bucket"
+  type        = string
+}
@@ -0,0 +1,9 @@
+variable "bucket_name" {
+  description = "Bucket name"
+  type        = string
+}
+
+module "aws_storage" {
+  source      = "../../aws/core/storage"
+  bucket_name = var.bucket_name
+}
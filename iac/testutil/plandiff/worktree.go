@@ -0,0 +1,109 @@
+package plandiff
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Worktree is a commit checked out, detached, into a temporary directory
+// with git worktree, so planning it leaves the working tree alone
+type Worktree struct {
+	// Root is the directory within the checkout that corresponds to the
+	// directory the worktree was added from, e.g. <temp>/checkout/iac
+	Root string
+
+	// Commit is the commit checked out
+	Commit string
+
+	repo string
+	dir  string
+	temp string
+}
+
+// AddWorktree checks ref out into a new temporary directory. root is a
+// directory of the repository, which may be below its top level, as the
+// iac module is; the Worktree's Root is the same directory in the checkout.
+// Remove deletes it again.
+func AddWorktree(root, ref string) (*Worktree, error) {
+	commit, err := ResolveCommit(root, ref)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := git(root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+
+	temp, err := os.MkdirTemp("", "plandiff-")
+	if err != nil {
+		return nil, fmt.Errorf("plandiff: %w", err)
+	}
+	dir := filepath.Join(temp, "checkout")
+	if _, err := git(root, "worktree", "add", "--detach", "--quiet", dir, commit); err != nil {
+		os.RemoveAll(temp)
+		return nil, err
+	}
+	return &Worktree{
+		Root:   filepath.Join(dir, filepath.FromSlash(prefix)),
+		Commit: commit,
+		repo:   root,
+		dir:    dir,
+		temp:   temp,
+	}, nil
+}
+
+// Remove unregisters the worktree and deletes its directory, along with
+// whatever planning wrote into it
+func (w *Worktree) Remove() error {
+	_, err := git(w.repo, "worktree", "remove", "--force", w.dir)
+	if removeErr := os.RemoveAll(w.temp); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// ResolveCommit returns the commit ref names in the repository at root
+func ResolveCommit(root, ref string) (string, error) {
+	commit, err := git(root, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("plandiff: %q is not a commit", ref)
+	}
+	return commit, nil
+}
+
+// MergeBase returns the commit head branched from base at: what a pull
+// request from head into base is compared against
+func MergeBase(root, base, head string) (string, error) {
+	return git(root, "merge-base", base, head)
+}
+
+// ChangedFiles returns the files that differ between commits base and
+// head below root, relative to root and slash-separated, sorted
+func ChangedFiles(root, base, head string) ([]string, error) {
+	out, err := git(root, "diff", "--name-only", "--relative", "--no-renames", base, head, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("plandiff: git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("plandiff: git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,146 @@
+package plandiff_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/plandiff"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runGit runs git in dir with a fixed identity and returns its trimmed output
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+// newRepo creates a git repository on main whose Terraform tree is its iac
+// directory, as in this repository, and returns both directories
+func newRepo(t *testing.T) (string, string) {
+	t.Helper()
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q", "-b", "main")
+	root := filepath.Join(repo, "iac")
+	require.NoError(t, os.MkdirAll(root, 0o755))
+	return repo, root
+}
+
+// writeFiles writes files, keyed by slash-separated path below dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+// commit commits everything in repo and returns the new commit's hash
+func commit(t *testing.T, repo, message string) string {
+	t.Helper()
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-q", "-m", message)
+	return runGit(t, repo, "rev-parse", "HEAD")
+}
+
+func TestWorktree(t *testing.T) {
+	t.Parallel()
+
+	repo, root := newRepo(t)
+	writeFiles(t, repo, map[string]string{"README.md": "first\n", "iac/main.tf": "# first\n"})
+	first := commit(t, repo, "first")
+	writeFiles(t, repo, map[string]string{"iac/main.tf": "# second\n"})
+	commit(t, repo, "second")
+
+	w, err := plandiff.AddWorktree(root, first)
+	require.NoError(t, err)
+	assert.Equal(t, first, w.Commit)
+	assert.Equal(t, "iac", filepath.Base(w.Root), "Root should be the same directory of the repository in the checkout")
+
+	content, err := os.ReadFile(filepath.Join(w.Root, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# first\n", string(content), "The worktree should hold the commit, not the working tree")
+	assert.Contains(t, runGit(t, repo, "worktree", "list"), first[:7])
+
+	// Planning writes into the worktree; Remove deletes that too
+	writeFiles(t, w.Root, map[string]string{".terraform.lock.hcl": "# lock\n"})
+	require.NoError(t, w.Remove())
+	assert.NoDirExists(t, w.Root)
+	assert.NotContains(t, runGit(t, repo, "worktree", "list"), first[:7])
+
+	current, err := os.ReadFile(filepath.Join(root, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# second\n", string(current), "The working tree should be left alone")
+}
+
+func TestAddWorktreeRejectsUnknownRef(t *testing.T) {
+	t.Parallel()
+
+	repo, root := newRepo(t)
+	writeFiles(t, repo, map[string]string{"iac/main.tf": "# first\n"})
+	commit(t, repo, "first")
+
+	_, err := plandiff.AddWorktree(root, "no-such-ref")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"no-such-ref" is not a commit`)
+}
+
+func TestChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	repo, root := newRepo(t)
+	writeFiles(t, repo, map[string]string{
+		"README.md":                 "first\n",
+		"iac/facade/storage/a.tf":   "# a\n",
+		"iac/facade/storage/old.tf": "# old\n",
+	})
+	base := commit(t, repo, "first")
+	writeFiles(t, repo, map[string]string{
+		"README.md":               "second\n",
+		"iac/facade/storage/a.tf": "# a, changed\n",
+		"iac/facade/cdn/main.tf":  "# new\n",
+	})
+	require.NoError(t, os.Rename(filepath.Join(root, "facade/storage/old.tf"), filepath.Join(root, "facade/storage/new.tf")))
+	head := commit(t, repo, "second")
+
+	files, err := plandiff.ChangedFiles(root, base, head)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"facade/cdn/main.tf",
+		"facade/storage/a.tf",
+		"facade/storage/new.tf",
+		"facade/storage/old.tf",
+	}, files, "Paths should be relative to root, leave out files outside it and list both sides of a rename")
+
+	files, err = plandiff.ChangedFiles(root, head, head)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestMergeBase(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := newRepo(t)
+	writeFiles(t, repo, map[string]string{"iac/main.tf": "# first\n"})
+	base := commit(t, repo, "first")
+	runGit(t, repo, "checkout", "-q", "-b", "feature")
+	writeFiles(t, repo, map[string]string{"iac/main.tf": "# feature\n"})
+	commit(t, repo, "feature")
+	runGit(t, repo, "checkout", "-q", "main")
+	writeFiles(t, repo, map[string]string{"iac/main.tf": "# main\n"})
+	commit(t, repo, "main")
+
+	got, err := plandiff.MergeBase(repo, "main", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, base, got, "The base should be where the branch left main")
+}
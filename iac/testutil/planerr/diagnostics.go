@@ -0,0 +1,206 @@
+package planerr
+
+import (
+	"bufio"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// validationSummary is the summary of the diagnostic a failed variable
+// validation rule reports
+const validationSummary = "Invalid value for variable"
+
+var (
+	// declaration and argument find the variable a diagnostic's source line
+	// is about: a root module's declaration, or the argument of a module
+	// call whose variable rejected the value
+	declaration = regexp.MustCompile(`^\s*variable\s+"([^"]+)"`)
+	argument    = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_-]*)\s*=(?:[^=]|$)`)
+
+	// checkedBy is the sentence terraform appends to a validation rule's
+	// error_message
+	checkedBy = regexp.MustCompile(`\s*This was checked by the validation rule at \S+?\.?\s*$`)
+
+	subject    = regexp.MustCompile(`^on (.+?) line (\d+)`)
+	sourceLine = regexp.MustCompile(`^\d+: (.*)$`)
+)
+
+// Diagnostic is one error or warning terraform reported
+type Diagnostic struct {
+	// Severity is "error" or "warning"
+	Severity string
+	Summary  string
+
+	// Detail is the diagnostic's text; for a failed validation rule, its
+	// error_message without terraform's note of where the rule is
+	Detail string
+
+	// Variable is the variable the diagnostic's source line declares or,
+	// for a module call, the argument it sets; empty when terraform showed
+	// no source
+	Variable string
+
+	Filename string
+	Line     int
+}
+
+// IsValidation reports whether d is a variable's validation rule failing
+func (d Diagnostic) IsValidation() bool {
+	return d.Summary == validationSummary
+}
+
+// jsonDiagnostic is a diagnostic as terraform writes it with -json
+type jsonDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	Range    *struct {
+		Filename string `json:"filename"`
+		Start    struct {
+			Line int `json:"line"`
+		} `json:"start"`
+	} `json:"range"`
+	Snippet *struct {
+		Code string `json:"code"`
+	} `json:"snippet"`
+}
+
+// jsonLine is a line of plan or apply -json output, which carries one
+// diagnostic, or validate -json output, which carries them all
+type jsonLine struct {
+	Type        string           `json:"type"`
+	Diagnostic  *jsonDiagnostic  `json:"diagnostic"`
+	Diagnostics []jsonDiagnostic `json:"diagnostics"`
+}
+
+// Parse returns the diagnostics in output, which is either the JSON that
+// plan -json and validate -json write or terraform's human-readable
+// boxes. Lines that are neither, such as terratest's prefixes, are skipped.
+func Parse(output string) []Diagnostic {
+	if diags, ok := parseJSON(output); ok {
+		return diags
+	}
+	return parseText(output)
+}
+
+func parseJSON(output string) ([]Diagnostic, bool) {
+	var diags []Diagnostic
+
+	// validate -json writes one indented document, which may be followed
+	// by the command's error
+	var doc jsonLine
+	if start := strings.Index(output, "{"); start >= 0 && json.NewDecoder(strings.NewReader(output[start:])).Decode(&doc) == nil && doc.Diagnostics != nil {
+		for _, d := range doc.Diagnostics {
+			diags = append(diags, d.diagnostic())
+		}
+		return diags, true
+	}
+
+	found := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// terratest logs each line behind the test name and a timestamp
+		start := strings.Index(line, "{")
+		if start < 0 {
+			continue
+		}
+
+		var l jsonLine
+		if err := json.Unmarshal([]byte(line[start:]), &l); err != nil {
+			continue
+		}
+		if l.Diagnostic != nil {
+			found = true
+			diags = append(diags, l.Diagnostic.diagnostic())
+		}
+		if l.Diagnostics != nil {
+			found = true
+			for _, d := range l.Diagnostics {
+				diags = append(diags, d.diagnostic())
+			}
+		}
+	}
+	return diags, found
+}
+
+func (d jsonDiagnostic) diagnostic() Diagnostic {
+	diag := Diagnostic{
+		Severity: d.Severity,
+		Summary:  d.Summary,
+		Detail:   strings.TrimSpace(checkedBy.ReplaceAllString(d.Detail, "")),
+	}
+	if d.Range != nil {
+		diag.Filename = d.Range.Filename
+		diag.Line = d.Range.Start.Line
+	}
+	if d.Snippet != nil {
+		diag.Variable = variable(d.Snippet.Code)
+	}
+	return diag
+}
+
+func variable(code string) string {
+	if m := declaration.FindStringSubmatch(code); m != nil {
+		return m[1]
+	}
+	if m := argument.FindStringSubmatch(code); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// parseText reads the boxes terraform draws without -json:
+//
+//	│ Error: Invalid value for variable
+//	│
+//	│   on variables.tf line 26:
+//	│   26: variable "instance_size" {
+//	│     ├────────────────
+//	│     │ var.instance_size is "huge"
+//	│
+//	│ Instance size must be one of: small, medium, large, xlarge
+//	│
+//	│ This was checked by the validation rule at variables.tf:30,3-13.
+func parseText(output string) []Diagnostic {
+	var diags []Diagnostic
+	var current *Diagnostic
+	var detail []string
+	flush := func() {
+		if current != nil {
+			current.Detail = strings.TrimSpace(checkedBy.ReplaceAllString(Normalize(strings.Join(detail, "\n")), ""))
+			diags = append(diags, *current)
+			current, detail = nil, nil
+		}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "│"))
+		switch {
+		case strings.HasPrefix(trimmed, "Error:"), strings.HasPrefix(trimmed, "Warning:"):
+			flush()
+			severity, summary, _ := strings.Cut(trimmed, ":")
+			current = &Diagnostic{Severity: strings.ToLower(severity), Summary: strings.TrimSpace(summary)}
+		case strings.HasPrefix(trimmed, "╵"):
+			flush()
+		case current == nil, trimmed == "":
+		case subject.MatchString(trimmed) && current.Filename == "":
+			m := subject.FindStringSubmatch(trimmed)
+			current.Filename = m[1]
+			current.Line, _ = strconv.Atoi(m[2])
+		case sourceLine.MatchString(trimmed) && current.Variable == "" && len(detail) == 0:
+			current.Variable = variable(sourceLine.FindStringSubmatch(trimmed)[1])
+		case strings.HasPrefix(trimmed, "├"), strings.HasPrefix(trimmed, "│"), strings.HasPrefix(trimmed, "with "):
+			// the values the expression read, and the resource a
+			// warning is about
+		default:
+			detail = append(detail, trimmed)
+		}
+	}
+	flush()
+	return diags
+}
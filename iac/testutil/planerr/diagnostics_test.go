@@ -0,0 +1,131 @@
+package planerr_test
+
+import (
+	"errors"
+	"testing"
+
+	"iac/testutil/planerr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlanJSON(t *testing.T) {
+	t.Parallel()
+
+	diags := planerr.Parse(readFixture(t, "plan.json"))
+	require.Len(t, diags, 4)
+
+	assert.Equal(t, planerr.Diagnostic{
+		Severity: "warning",
+		Summary:  "Argument is deprecated",
+		Detail:   "Use the aws_s3_bucket_versioning resource instead.",
+		Filename: "../../aws/core/storage/main.tf",
+		Line:     14,
+	}, diags[0])
+	assert.False(t, diags[0].IsValidation())
+
+	assert.Equal(t, planerr.Diagnostic{
+		Severity: "error",
+		Summary:  "Invalid value for variable",
+		Detail:   "bucket_name must be 3-63 lower case letters, digits, dots and hyphens, starting and ending with a letter or digit, e.g. my-bucket.",
+		Variable: "bucket_name",
+		Filename: "variables.tf",
+		Line:     27,
+	}, diags[1], "A root variable is named by its declaration")
+	assert.True(t, diags[1].IsValidation())
+
+	assert.Equal(t, "name", diags[2].Variable, "A module's variable is named by the argument the call sets")
+	assert.Equal(t, "Bucket names must not contain underscores.", diags[2].Detail)
+
+	assert.Equal(t, "master_password", diags[3].Variable)
+	assert.Equal(t, `master_password does not meet the aws password rules: 8-41 printable ASCII characters without /, @, " or spaces`, diags[3].Detail)
+}
+
+func TestParseValidateJSON(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []planerr.Diagnostic{{
+		Severity: "error",
+		Summary:  "Unsupported argument",
+		Detail:   `An argument named "storage_clas" is not expected here. Did you mean "storage_class"?`,
+		Variable: "storage_clas",
+		Filename: "main.tf",
+		Line:     44,
+	}}, planerr.Parse(readFixture(t, "validate.json")))
+}
+
+func TestParseText(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []planerr.Diagnostic{
+		{
+			Severity: "error",
+			Summary:  "Invalid value for variable",
+			Detail:   "Instance size must be one of: small, medium, large, xlarge",
+			Variable: "instance_size",
+			Filename: "variables.tf",
+			Line:     26,
+		},
+		{
+			Severity: "warning",
+			Summary:  "Argument is deprecated",
+			Detail:   "Use vpc_security_group_ids instead.",
+			Filename: "../../aws/core/compute/main.tf",
+			Line:     20,
+		},
+	}, planerr.Parse(readFixture(t, "validation.txt")))
+
+	diags := planerr.Parse(readFixture(t, "provider.txt"))
+	require.Len(t, diags, 2)
+	assert.Empty(t, diags[1].Variable, "An expression is not an argument")
+	assert.Equal(t, `This object does not have an attribute named "metric_alert_id".`, diags[1].Detail)
+}
+
+func TestMatchValidation(t *testing.T) {
+	t.Parallel()
+
+	output := readFixture(t, "plan.json")
+
+	assert.NoError(t, planerr.MatchValidation(output, errExit, "bucket_name", "bucket_name must be 3-63"))
+	assert.NoError(t, planerr.MatchValidation(output, errExit, "master_password", "does not meet the aws password rules"))
+	assert.NoError(t, planerr.MatchValidation(readFixture(t, "validation.txt"), errExit, "instance_size", "Instance size must be one of"))
+
+	err := planerr.MatchValidation(output, errExit, "storage_class", "bucket_name must be 3-63")
+	require.Error(t, err, "The message matching is not enough when another variable's rule reported it")
+	assert.Equal(t, ""+
+		"plan failed validation, but not var.storage_class with \"bucket_name must be 3-63\":\n"+
+		"  var.bucket_name: bucket_name must be 3-63 lower case letters, digits, dots and hyphens, starting and ending with a letter or digit, e.g. my-bucket.\n"+
+		"  var.name: Bucket names must not contain underscores.\n"+
+		"  var.master_password: master_password does not meet the aws password rules: 8-41 printable ASCII characters without /, @, \" or spaces",
+		err.Error())
+}
+
+func TestMatchValidationOtherFailures(t *testing.T) {
+	t.Parallel()
+
+	err := planerr.MatchValidation("", nil, "bucket_name", "bucket_name must be 3-63")
+	require.Error(t, err)
+	assert.Equal(t, `plan succeeded, want var.bucket_name to fail validation with "bucket_name must be 3-63"`, err.Error())
+
+	err = planerr.MatchValidation(readFixture(t, "validate.json"), errExit, "storage_class", "storage_class must be one of")
+	require.Error(t, err)
+	assert.Equal(t, ""+
+		"plan failed, but not validating var.storage_class:\n"+
+		"  Error: Unsupported argument An argument named \"storage_clas\" is not expected here. Did you mean \"storage_class\"?",
+		err.Error())
+
+	err = planerr.MatchValidation("", errors.New("exec: \"terraform\": executable file not found in $PATH"), "bucket_name", "bucket_name must be 3-63")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "executable file not found")
+}
+
+func TestAssertValidationErrorReadsTheOutput(t *testing.T) {
+	t.Parallel()
+
+	// with -json the diagnostics go to stdout, which terratest leaves out
+	// of its error
+	err := &planerr.Error{Output: readFixture(t, "plan.json"), Err: errExit}
+	assert.True(t, planerr.AssertValidationError(t, err, "bucket_name", "bucket_name must be 3-63"))
+	assert.ErrorIs(t, err, errExit)
+}
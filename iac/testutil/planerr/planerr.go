@@ -0,0 +1,223 @@
+// Package planerr checks that a Terraform plan fails for the expected
+// reason. Asserting only that a plan errors lets a facade's negative tests
+// pass on an unrelated provider or syntax error while the validation rule
+// they target is broken; matching the validation message proves the right
+// rule fired.
+//
+// Terraform draws diagnostics in boxes and may wrap long messages, so output
+// is normalized (box characters dropped, whitespace collapsed) before
+// matching.
+//
+// A message alone does not say which variable rejected the value, and two
+// variables' rules can share one. PlanE plans with -json, and
+// AssertValidationError reads the structured diagnostics to check the rule
+// that failed belongs to the expected variable:
+//
+//	err := planerr.PlanE(t, options)
+//	planerr.AssertValidationError(t, err, "bucket_name", "bucket_name must be 3-63")
+package planerr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"iac/testutil/tflog"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+var (
+	boxChars   = strings.NewReplacer("╷", " ", "╵", " ", "│", " ", "├", " ", "─", " ")
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// Normalize drops diagnostic box drawing and collapses whitespace, so a
+// message matches however terraform wrapped it
+func Normalize(output string) string {
+	return strings.TrimSpace(whitespace.ReplaceAllString(boxChars.Replace(output), " "))
+}
+
+// Diagnostics returns the normalized error diagnostics in output, one per
+// "Error:" block
+func Diagnostics(output string) []string {
+	var diags []string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			diags = append(diags, Normalize(strings.Join(current, "\n")))
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "│"))
+		switch {
+		case strings.HasPrefix(trimmed, "Error:"):
+			flush()
+			current = []string{trimmed}
+		case strings.HasPrefix(trimmed, "╵"), strings.HasPrefix(trimmed, "Warning:"):
+			flush()
+		case current != nil:
+			current = append(current, trimmed)
+		}
+	}
+	flush()
+	return diags
+}
+
+// Match returns nil when the plan failed (err is non-nil) and its output or
+// error mentions want; otherwise it describes what happened instead
+func Match(output string, err error, want string) error {
+	if err == nil {
+		return fmt.Errorf("plan succeeded, want it to fail with %q", want)
+	}
+
+	text := output + "\n" + err.Error()
+	if strings.Contains(Normalize(text), Normalize(want)) {
+		return nil
+	}
+
+	diags := Diagnostics(text)
+	if len(diags) == 0 {
+		return fmt.Errorf("plan failed, but not with %q:\n%s", want, strings.TrimSpace(text))
+	}
+	return fmt.Errorf("plan failed, but not with %q:\n  %s", want, strings.Join(diags, "\n  "))
+}
+
+// Error is a failed terraform command with the output it wrote, which
+// terratest's own error leaves out when terraform wrote it to stdout, as
+// it does with -json
+type Error struct {
+	Output string
+	Err    error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// PlanE runs terraform init and plan -json with options. It returns nil
+// when the plan succeeds and otherwise an *Error holding the output.
+func PlanE(t testing.TB, options *terraform.Options) error {
+	t.Helper()
+
+	if output, err := terraform.InitE(t, options); err != nil {
+		return &Error{Output: output, Err: err}
+	}
+	output, err := terraform.RunTerraformCommandE(t, options, terraform.FormatArgs(options, "plan", "-input=false", "-json")...)
+	if err != nil {
+		return &Error{Output: output, Err: err}
+	}
+	return nil
+}
+
+// MatchValidation returns nil when the plan failed (err is non-nil) on a
+// validation rule of variable whose message contains want; otherwise it
+// describes what happened instead. output is read as JSON when it holds
+// any and as terraform's boxes otherwise.
+func MatchValidation(output string, err error, variable, want string) error {
+	if err == nil {
+		return fmt.Errorf("plan succeeded, want var.%s to fail validation with %q", variable, want)
+	}
+
+	text := output + "\n" + err.Error()
+	var failed, others []string
+	for _, d := range Parse(text) {
+		switch {
+		case d.Severity != "error":
+		case !d.IsValidation():
+			others = append(others, Normalize("Error: "+d.Summary+" "+d.Detail))
+		case d.Variable == variable && strings.Contains(Normalize(d.Detail), Normalize(want)):
+			return nil
+		default:
+			failed = append(failed, fmt.Sprintf("var.%s: %s", d.Variable, Normalize(d.Detail)))
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		return fmt.Errorf("plan failed validation, but not var.%s with %q:\n  %s", variable, want, strings.Join(failed, "\n  "))
+	case len(others) > 0:
+		return fmt.Errorf("plan failed, but not validating var.%s:\n  %s", variable, strings.Join(others, "\n  "))
+	}
+	return fmt.Errorf("plan failed, but not validating var.%s:\n%s", variable, strings.TrimSpace(text))
+}
+
+// AssertValidationError fails t unless err, as PlanE returns it, is a
+// plan that failed on a validation rule of variable whose message
+// contains want, and reports whether it passed
+func AssertValidationError(t testing.TB, err error, variable, want string) bool {
+	t.Helper()
+
+	var output string
+	var planErr *Error
+	if errors.As(err, &planErr) {
+		output = planErr.Output
+	}
+	if mismatch := MatchValidation(output, err, variable, want); mismatch != nil {
+		t.Error(mismatch)
+		return false
+	}
+	return true
+}
+
+// Case is one invalid input and the message its validation rule reports
+type Case struct {
+	Name string
+
+	// Vars override the base variables of the matrix
+	Vars map[string]interface{}
+
+	// Want is a substring of the expected error, usually the rule's
+	// error_message
+	Want string
+
+	// Variable, when set, is the variable whose validation rule must
+	// report Want; the plan then runs with -json and the rule's variable
+	// is checked as well as its message
+	Variable string
+}
+
+// RunMatrix plans dir once per case, in parallel subtests, with the case's
+// variables merged over base, and fails each subtest whose plan succeeds or
+// fails for a different reason, including, for a case with a Variable, the
+// rule of another variable. Variables dir declares sensitive are redacted
+// from the log, as with tflog.WithSensitive.
+func RunMatrix(t *testing.T, dir string, base map[string]interface{}, cases []Case) {
+	t.Helper()
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			vars := make(map[string]interface{}, len(base)+len(tc.Vars))
+			for k, v := range base {
+				vars[k] = v
+			}
+			for k, v := range tc.Vars {
+				vars[k] = v
+			}
+
+			options := tflog.WithSensitive(t, tfopts.New(t, &terraform.Options{
+				TerraformDir: dir,
+				Vars:         vars,
+				NoColor:      true,
+			}))
+			if tc.Variable != "" {
+				AssertValidationError(t, PlanE(t, options), tc.Variable, tc.Want)
+				return
+			}
+
+			output, err := terraform.InitAndPlanE(t, options)
+			if mismatch := Match(output, err, tc.Want); mismatch != nil {
+				t.Error(mismatch)
+			}
+		})
+	}
+}
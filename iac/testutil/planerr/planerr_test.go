@@ -0,0 +1,81 @@
+package planerr_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"iac/testutil/planerr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return string(data)
+}
+
+var errExit = errors.New("exit status 1")
+
+func TestMatchWrappedValidationMessage(t *testing.T) {
+	t.Parallel()
+
+	output := readFixture(t, "validation.txt")
+
+	assert.NoError(t, planerr.Match(output, errExit, "Instance size must be one of: small, medium, large, xlarge"))
+	assert.NoError(t, planerr.Match(output, errExit, `var.instance_size is "huge"`))
+}
+
+func TestMatchReadsTheError(t *testing.T) {
+	t.Parallel()
+
+	// terratest carries stderr in the error rather than the output
+	err := errors.New("error while running command: exit status 1; \n│ Error: Invalid value for variable\n│ \n│ Runtime must be one of: python3.9")
+	assert.NoError(t, planerr.Match("", err, "Runtime must be one of"))
+}
+
+func TestMatchSuccessfulPlan(t *testing.T) {
+	t.Parallel()
+
+	err := planerr.Match("Plan: 1 to add, 0 to change, 0 to destroy.", nil, "Threshold must not be negative")
+	require.Error(t, err)
+	assert.Equal(t, `plan succeeded, want it to fail with "Threshold must not be negative"`, err.Error())
+}
+
+func TestMatchUnrelatedError(t *testing.T) {
+	t.Parallel()
+
+	err := planerr.Match(readFixture(t, "provider.txt"), errExit, "Threshold must not be negative")
+	require.Error(t, err)
+	assert.Equal(t, ""+
+		"plan failed, but not with \"Threshold must not be negative\":\n"+
+		"  Error: No valid credential sources found with provider[\"registry.terraform.io/hashicorp/aws\"], on <empty> line 0: (source code not available) Please see https://registry.terraform.io/providers/hashicorp/aws for more information about providing credentials.\n"+
+		"  Error: Unsupported attribute on main.tf line 83, in output \"alarm_id\": 83: var.provider_name == \"azure\" ? module.azure_monitoring[0].metric_alert_id : null This object does not have an attribute named \"metric_alert_id\".",
+		err.Error())
+}
+
+func TestMatchWithoutDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	err := planerr.Match("", errors.New("exec: \"terraform\": executable file not found in $PATH"), "Provider must be one of")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "executable file not found")
+}
+
+func TestDiagnosticsSkipsWarnings(t *testing.T) {
+	t.Parallel()
+
+	diags := planerr.Diagnostics(readFixture(t, "validation.txt"))
+	assert.Equal(t, []string{
+		"Error: Invalid value for variable on variables.tf line 26: 26: variable \"instance_size\" { var.instance_size is \"huge\" Instance size must be one of: small, medium, large, xlarge This was checked by the validation rule at variables.tf:30,3-13.",
+	}, diags)
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Error: Invalid value for variable a b", planerr.Normalize("╷\n│ Error: Invalid value for variable\n│ \n│   a\n│ b\n╵\n"))
+}
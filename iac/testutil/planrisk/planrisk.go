@@ -0,0 +1,253 @@
+// Package planrisk classifies the resource changes of a Terraform plan by
+// what they do to existing infrastructure. Creates and in-place updates are
+// safe to roll out; a replacement or destroy loses the existing object and
+// whatever data it held, which a reviewer of a facade change needs to know
+// before it reaches a deployment:
+//
+//	planJSON := terraform.InitAndPlanAndShow(t, options)
+//	planrisk.AssertNoReplacements(t, []byte(planJSON),
+//		"module.aws_storage[0].aws_s3_bucket_versioning.*",
+//	)
+//
+// tools/planrisk prints the same classification for a plan file.
+package planrisk
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Action is what a plan does to one resource
+type Action string
+
+// Actions, from least to most destructive
+const (
+	NoOp    Action = "no-op"
+	Create  Action = "create"
+	Update  Action = "update"
+	Replace Action = "replace"
+	Destroy Action = "destroy"
+)
+
+// Actions lists every Action in order, for reports
+var Actions = []Action{NoOp, Create, Update, Replace, Destroy}
+
+// Change is the planned change to one resource instance
+type Change struct {
+	Address string
+	Type    string
+	Action  Action
+
+	// CreateBeforeDestroy is set on a replacement that creates the new
+	// object before destroying the old one
+	CreateBeforeDestroy bool
+
+	// Reason is terraform's action_reason, e.g. replace_because_tainted
+	Reason string
+
+	// ReplacePaths are the attributes that force a replacement
+	ReplacePaths [][]interface{}
+}
+
+// Destructive reports whether the change loses the existing object
+func (c Change) Destructive() bool {
+	return c.Action == Replace || c.Action == Destroy
+}
+
+func (c Change) String() string {
+	s := string(c.Action) + " " + c.Address
+	if c.Reason != "" {
+		s += " (" + c.Reason + ")"
+	}
+	if len(c.ReplacePaths) > 0 {
+		s += " forced by " + strings.Join(c.Paths(), ", ")
+	}
+	return s
+}
+
+// Paths returns ReplacePaths as dotted attribute paths, e.g.
+// versioning_configuration.0.mfa_delete
+func (c Change) Paths() []string {
+	paths := make([]string, len(c.ReplacePaths))
+	for i, path := range c.ReplacePaths {
+		parts := make([]string, len(path))
+		for j, part := range path {
+			parts[j] = fmt.Sprint(part)
+		}
+		paths[i] = strings.Join(parts, ".")
+	}
+	return paths
+}
+
+// Summary is the classified resource changes of a plan
+type Summary struct {
+	// Changes are the managed resources' changes in plan order, no-ops
+	// included
+	Changes []Change
+
+	// Counts has the number of changes of each Action
+	Counts map[Action]int
+}
+
+type plan struct {
+	FormatVersion   string           `json:"format_version"`
+	ResourceChanges []resourceChange `json:"resource_changes"`
+	ResourceDrift   []resourceChange `json:"resource_drift"`
+}
+
+type resourceChange struct {
+	Address      string `json:"address"`
+	Mode         string `json:"mode"`
+	Type         string `json:"type"`
+	ActionReason string `json:"action_reason"`
+	Change       struct {
+		Actions      []string        `json:"actions"`
+		ReplacePaths [][]interface{} `json:"replace_paths"`
+	} `json:"change"`
+}
+
+// Classify decodes planJSON, the `terraform show -json` output for a plan,
+// and classifies the change to each managed resource. Data sources are left
+// out: reading one changes nothing.
+func Classify(planJSON []byte) (*Summary, error) {
+	p, err := decode(planJSON)
+	if err != nil {
+		return nil, err
+	}
+	return summarize(p.ResourceChanges), nil
+}
+
+// ClassifyDrift classifies the drift planJSON records instead: the changes
+// the refresh found made outside Terraform, an Update for an object
+// modified and a Destroy for one deleted. A refresh-only plan records
+// nothing else.
+func ClassifyDrift(planJSON []byte) (*Summary, error) {
+	p, err := decode(planJSON)
+	if err != nil {
+		return nil, err
+	}
+	return summarize(p.ResourceDrift), nil
+}
+
+func decode(planJSON []byte) (*plan, error) {
+	var p plan
+	if err := json.Unmarshal(planJSON, &p); err != nil {
+		return nil, fmt.Errorf("planrisk: decoding plan JSON: %w", err)
+	}
+	if p.FormatVersion == "" {
+		return nil, fmt.Errorf("planrisk: not terraform show -json output (no format_version field)")
+	}
+	return &p, nil
+}
+
+func summarize(changes []resourceChange) *Summary {
+	summary := &Summary{Counts: make(map[Action]int)}
+	for _, rc := range changes {
+		if rc.Mode == "data" {
+			continue
+		}
+		action, createFirst := classify(rc.Change.Actions)
+		summary.Changes = append(summary.Changes, Change{
+			Address:             rc.Address,
+			Type:                rc.Type,
+			Action:              action,
+			CreateBeforeDestroy: action == Replace && createFirst,
+			Reason:              rc.ActionReason,
+			ReplacePaths:        rc.Change.ReplacePaths,
+		})
+		summary.Counts[action]++
+	}
+	return summary
+}
+
+// classify names a resource's planned actions, and reports whether a
+// create comes first. Terraform plans a replacement as ["delete", "create"],
+// or ["create", "delete"] under create_before_destroy.
+func classify(actions []string) (Action, bool) {
+	hasCreate, hasDelete, hasUpdate := false, false, false
+	for _, a := range actions {
+		switch a {
+		case "create":
+			hasCreate = true
+		case "delete":
+			hasDelete = true
+		case "update":
+			hasUpdate = true
+		}
+	}
+	createFirst := len(actions) > 0 && actions[0] == "create"
+	switch {
+	case hasDelete && hasCreate:
+		return Replace, createFirst
+	case hasDelete:
+		return Destroy, false
+	case hasCreate:
+		return Create, false
+	case hasUpdate:
+		return Update, false
+	default:
+		return NoOp, false
+	}
+}
+
+// Destructive returns the replacements and destroys, except those whose
+// address matches one of allow. Allow patterns match whole addresses, with
+// * standing for any run of characters.
+func (s *Summary) Destructive(allow ...string) []Change {
+	allowed := make([]*regexp.Regexp, len(allow))
+	for i, pattern := range allow {
+		quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+		allowed[i] = regexp.MustCompile("^" + quoted + "$")
+	}
+
+	var changes []Change
+	for _, c := range s.Changes {
+		if c.Destructive() && !matchesAny(allowed, c.Address) {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+func matchesAny(patterns []*regexp.Regexp, address string) bool {
+	for _, p := range patterns {
+		if p.MatchString(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// String summarizes the counts the way terraform's plan line does, e.g.
+// "1 to create, 0 to update in place, 1 to replace, 0 to destroy"
+func (s *Summary) String() string {
+	return fmt.Sprintf("%d to create, %d to update in place, %d to replace, %d to destroy",
+		s.Counts[Create], s.Counts[Update], s.Counts[Replace], s.Counts[Destroy])
+}
+
+// TestingT is the subset of testing.T AssertNoReplacements uses
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertNoReplacements fails t for every resource planJSON would replace
+// or destroy, except addresses matching allow (see Summary.Destructive),
+// and reports whether there were none. A destroy counts as well: like a
+// replacement, it loses the existing object.
+func AssertNoReplacements(t TestingT, planJSON []byte, allow ...string) bool {
+	t.Helper()
+
+	summary, err := Classify(planJSON)
+	if err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	changes := summary.Destructive(allow...)
+	for _, c := range changes {
+		t.Errorf("plan would %s; allowlist the address if that is intended", c)
+	}
+	return len(changes) == 0
+}
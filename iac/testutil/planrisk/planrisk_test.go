@@ -0,0 +1,209 @@
+package planrisk_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"iac/testutil/planrisk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func classify(t *testing.T, path string) *planrisk.Summary {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	summary, err := planrisk.Classify(data)
+	require.NoError(t, err)
+	return summary
+}
+
+func readPlan(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
+
+// recorder is a planrisk.TestingT that keeps its failures
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	summary := classify(t, "testdata/renamed.json")
+
+	require.Len(t, summary.Changes, 4)
+	assert.Equal(t, planrisk.Change{
+		Address: "module.aws_storage[0].aws_s3_bucket.this",
+		Type:    "aws_s3_bucket",
+		Action:  planrisk.Destroy,
+		Reason:  "delete_because_no_resource_config",
+	}, summary.Changes[0])
+	assert.Equal(t, planrisk.Create, summary.Changes[1].Action)
+	assert.Equal(t, planrisk.Replace, summary.Changes[2].Action)
+	assert.False(t, summary.Changes[2].CreateBeforeDestroy)
+	assert.Equal(t, planrisk.Update, summary.Changes[3].Action)
+
+	assert.Equal(t, "1 to create, 1 to update in place, 1 to replace, 1 to destroy", summary.String())
+}
+
+func TestClassifyTainted(t *testing.T) {
+	t.Parallel()
+
+	summary := classify(t, "testdata/tainted.json")
+
+	changes := summary.Destructive()
+	require.Len(t, changes, 1)
+	assert.Equal(t, planrisk.Replace, changes[0].Action)
+	assert.Empty(t, changes[0].ReplacePaths, "A tainted resource is replaced without a forcing attribute")
+	assert.Equal(t, "replace module.aws_storage[0].aws_s3_bucket.this (replace_because_tainted)", changes[0].String())
+	assert.Equal(t, 1, summary.Counts[planrisk.Update])
+}
+
+func TestClassifyCreateBeforeDestroy(t *testing.T) {
+	t.Parallel()
+
+	summary := classify(t, "testdata/create-before-destroy.json")
+
+	assert.Equal(t, map[planrisk.Action]int{
+		planrisk.Update:  1,
+		planrisk.Replace: 1,
+		planrisk.Create:  1,
+	}, summary.Counts)
+
+	changes := summary.Destructive()
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].CreateBeforeDestroy)
+	assert.Equal(t, []string{"name"}, changes[0].Paths())
+	assert.Equal(t,
+		"replace module.aws_lambda[0].aws_lambda_alias.live (replace_because_cannot_update) forced by name",
+		changes[0].String())
+}
+
+func TestClassifyMoved(t *testing.T) {
+	t.Parallel()
+
+	// A moved block leaves the bucket a no-op; create_before_destroy orders
+	// the replacement create first
+	changes := classify(t, "testdata/moved.json").Destructive()
+
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].CreateBeforeDestroy)
+	assert.Equal(t,
+		"replace module.aws_storage[0].aws_s3_bucket_versioning.this[0] (replace_because_cannot_update) forced by versioning_configuration.0.mfa_delete",
+		changes[0].String())
+}
+
+func TestClassifyNoOp(t *testing.T) {
+	t.Parallel()
+
+	summary := classify(t, "testdata/noop.json")
+
+	// The data source read is left out
+	require.Len(t, summary.Changes, 2)
+	assert.Equal(t, map[planrisk.Action]int{planrisk.NoOp: 2}, summary.Counts)
+	assert.Empty(t, summary.Destructive())
+	assert.Equal(t, "0 to create, 0 to update in place, 0 to replace, 0 to destroy", summary.String())
+}
+
+func TestClassifyDrift(t *testing.T) {
+	t.Parallel()
+
+	data := readPlan(t, "testdata/drift.json")
+
+	summary, err := planrisk.Classify(data)
+	require.NoError(t, err)
+	assert.Empty(t, summary.Changes, "A refresh-only plan changes nothing")
+
+	drift, err := planrisk.ClassifyDrift(data)
+	require.NoError(t, err)
+	require.Len(t, drift.Changes, 2)
+	assert.Equal(t, planrisk.Update, drift.Changes[0].Action)
+	assert.Equal(t, "module.aws_storage[0].aws_s3_bucket_policy.this[0]", drift.Changes[1].Address)
+	assert.Equal(t, planrisk.Destroy, drift.Changes[1].Action)
+	assert.Equal(t, 1, drift.Counts[planrisk.Destroy])
+}
+
+func TestDestructiveAllowlist(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		allow []string
+		want  []string
+	}{
+		"exact address": {
+			allow: []string{"module.aws_storage[0].aws_s3_bucket.this"},
+			want:  []string{"module.aws_storage[0].aws_s3_bucket_versioning.this[0]"},
+		},
+		"brackets are literal": {
+			// Would match as a glob character class
+			allow: []string{"module.aws_storage[0].aws_s3_bucket.thi[s]"},
+			want: []string{
+				"module.aws_storage[0].aws_s3_bucket.this",
+				"module.aws_storage[0].aws_s3_bucket_versioning.this[0]",
+			},
+		},
+		"wildcard": {
+			allow: []string{"module.aws_storage[*].aws_s3_bucket_versioning.*"},
+			want:  []string{"module.aws_storage[0].aws_s3_bucket.this"},
+		},
+		"prefix needs a wildcard": {
+			allow: []string{"module.aws_storage[0]"},
+			want: []string{
+				"module.aws_storage[0].aws_s3_bucket.this",
+				"module.aws_storage[0].aws_s3_bucket_versioning.this[0]",
+			},
+		},
+		"everything": {
+			allow: []string{"*"},
+		},
+	}
+
+	summary := classify(t, "testdata/renamed.json")
+	for name, tc := range tests {
+		var addresses []string
+		for _, c := range summary.Destructive(tc.allow...) {
+			addresses = append(addresses, c.Address)
+		}
+		assert.Equal(t, tc.want, addresses, name)
+	}
+}
+
+func TestAssertNoReplacements(t *testing.T) {
+	t.Parallel()
+
+	rec := &recorder{}
+	assert.False(t, planrisk.AssertNoReplacements(rec, readPlan(t, "testdata/renamed.json"), "module.aws_storage[0].aws_s3_bucket.this"))
+	require.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], "plan would replace module.aws_storage[0].aws_s3_bucket_versioning.this[0]")
+
+	rec = &recorder{}
+	assert.True(t, planrisk.AssertNoReplacements(rec, readPlan(t, "testdata/noop.json")))
+	assert.Empty(t, rec.errors)
+}
+
+func TestClassifyRejectsOtherJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := planrisk.Classify([]byte(`{"values": {}}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no format_version")
+
+	_, err = planrisk.Classify([]byte(`Plan: 1 to add`))
+	assert.Error(t, err)
+
+	rec := &recorder{}
+	assert.False(t, planrisk.AssertNoReplacements(rec, []byte(`Plan: 1 to add`)))
+	assert.Len(t, rec.errors, 1)
+}
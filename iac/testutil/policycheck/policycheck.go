@@ -0,0 +1,139 @@
+// Package policycheck evaluates the Rego bundle in iac/policies against
+// Terraform JSON plans, so security guarantees such as "no public buckets"
+// are enforced on every facade instead of through ad-hoc string assertions.
+package policycheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"iac/policies"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// query binds every violation of every policy package under iac.policies
+const query = "data.iac.policies[policy].violation[v]"
+
+// Violation is one policy failure for one planned resource
+type Violation struct {
+	Policy  string
+	Address string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s [%s]", v.Address, v.Message, v.Policy)
+}
+
+var (
+	prepareOnce sync.Once
+	prepared    rego.PreparedEvalQuery
+	prepareErr  error
+)
+
+// Evaluate runs every policy in the bundle against a `terraform show -json`
+// plan and returns the violations sorted by address
+func Evaluate(ctx context.Context, planJSON []byte) ([]Violation, error) {
+	pq, err := preparedQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var input interface{}
+	if err := json.Unmarshal(planJSON, &input); err != nil {
+		return nil, fmt.Errorf("policycheck: decoding plan JSON: %w", err)
+	}
+
+	results, err := pq.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, fmt.Errorf("policycheck: evaluating policies: %w", err)
+	}
+
+	violations := make([]Violation, 0, len(results))
+	for _, result := range results {
+		policy, _ := result.Bindings["policy"].(string)
+		v, _ := result.Bindings["v"].(map[string]interface{})
+		address, _ := v["address"].(string)
+		msg, _ := v["msg"].(string)
+		violations = append(violations, Violation{Policy: policy, Address: address, Message: msg})
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		if a.Policy != b.Policy {
+			return a.Policy < b.Policy
+		}
+		return a.Message < b.Message
+	})
+	return violations, nil
+}
+
+// Check fails t with one line per violation of the plan
+func Check(t testing.TB, planJSON []byte) {
+	t.Helper()
+
+	violations, err := Evaluate(context.Background(), planJSON)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(violations) == 0 {
+		return
+	}
+
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = v.String()
+	}
+	t.Fatalf("plan violates %d policies:\n  %s", len(violations), strings.Join(lines, "\n  "))
+}
+
+// AssertPlan plans options and checks the JSON plan against the bundle.
+// options.PlanFilePath is set to a temporary file if empty.
+func AssertPlan(t testing.TB, options *terraform.Options) {
+	t.Helper()
+
+	if options.PlanFilePath == "" {
+		options.PlanFilePath = filepath.Join(t.TempDir(), "plan.out")
+	}
+
+	Check(t, []byte(terraform.InitAndPlanAndShow(t, options)))
+}
+
+// preparedQuery compiles the bundle once per test binary
+func preparedQuery(ctx context.Context) (rego.PreparedEvalQuery, error) {
+	prepareOnce.Do(func() {
+		options := []func(*rego.Rego){rego.Query(query)}
+
+		files, err := fs.Glob(policies.Bundle, "*.rego")
+		if err != nil {
+			prepareErr = fmt.Errorf("policycheck: listing policies: %w", err)
+			return
+		}
+		for _, name := range files {
+			src, err := fs.ReadFile(policies.Bundle, name)
+			if err != nil {
+				prepareErr = fmt.Errorf("policycheck: reading %s: %w", name, err)
+				return
+			}
+			options = append(options, rego.Module(name, string(src)))
+		}
+
+		prepared, err = rego.New(options...).PrepareForEval(ctx)
+		if err != nil {
+			prepareErr = fmt.Errorf("policycheck: compiling policies: %w", err)
+		}
+	})
+	return prepared, prepareErr
+}
@@ -0,0 +1,428 @@
+package policycheck_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"iac/testutil/policycheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resource is one entry of a synthetic plan's resource_changes
+type resource struct {
+	address string
+	after   map[string]interface{}
+	unknown map[string]interface{}
+	actions []string
+}
+
+// plan renders resources as a minimal `terraform show -json` document. The
+// type and module address are derived from the resource address.
+func plan(t *testing.T, resources ...resource) []byte {
+	changes := make([]map[string]interface{}, 0, len(resources))
+	for _, r := range resources {
+		actions := r.actions
+		if actions == nil {
+			actions = []string{"create"}
+		}
+		unknown := r.unknown
+		if unknown == nil {
+			unknown = map[string]interface{}{}
+		}
+
+		parts := strings.Split(r.address, ".")
+		rc := map[string]interface{}{
+			"address": r.address,
+			"type":    parts[len(parts)-2],
+			"change": map[string]interface{}{
+				"actions":       actions,
+				"before":        nil,
+				"after":         r.after,
+				"after_unknown": unknown,
+			},
+		}
+		if len(parts) > 2 {
+			rc["module_address"] = strings.Join(parts[:len(parts)-2], ".")
+		}
+		changes = append(changes, rc)
+	}
+
+	out, err := json.Marshal(map[string]interface{}{"resource_changes": changes})
+	require.NoError(t, err)
+	return out
+}
+
+// tags are spelled the way common/tags writes them on AWS; labels the way
+// it writes them on GCP
+var (
+	tags   = map[string]interface{}{"Project": "policycheck", "Environment": "test", "ManagedBy": "swe-cloud"}
+	labels = map[string]interface{}{"project": "policycheck", "environment": "test", "managed_by": "swe-cloud"}
+
+	// expiringTags are tags plus the rotation metadata facade/iam puts on a
+	// user with an access key
+	expiringTags = map[string]interface{}{"Project": "policycheck", "Environment": "test", "ManagedBy": "swe-cloud", "CredentialsExpiryDays": "90"}
+)
+
+// compliantBucket is an S3 bucket with every companion resource the policies
+// expect in its module
+func compliantBucket(module string) []resource {
+	return []resource{
+		{address: module + ".aws_s3_bucket.this", after: map[string]interface{}{"bucket": "b", "tags": tags}},
+		{address: module + ".aws_s3_bucket_server_side_encryption_configuration.this[0]", after: map[string]interface{}{"bucket": "b"}},
+		{address: module + ".aws_s3_bucket_public_access_block.this[0]", after: map[string]interface{}{
+			"block_public_acls": true, "block_public_policy": true, "ignore_public_acls": true, "restrict_public_buckets": true,
+		}},
+	}
+}
+
+func evaluate(t *testing.T, resources ...resource) []policycheck.Violation {
+	violations, err := policycheck.Evaluate(context.Background(), plan(t, resources...))
+	require.NoError(t, err)
+	return violations
+}
+
+func TestCompliantPlanHasNoViolations(t *testing.T) {
+	t.Parallel()
+
+	resources := append(compliantBucket("module.storage.module.aws_storage[0]"),
+		resource{address: "module.net.aws_security_group.default[0]", after: map[string]interface{}{
+			"tags":    tags,
+			"ingress": []interface{}{map[string]interface{}{"from_port": 0, "to_port": 0, "cidr_blocks": []interface{}{"10.0.0.0/16"}, "ipv6_cidr_blocks": nil}},
+		}},
+		resource{address: "google_storage_bucket.this", after: map[string]interface{}{"labels": labels}},
+		resource{address: "azurerm_storage_account.this", after: map[string]interface{}{
+			"tags": map[string]interface{}{"Project": "policycheck", "Environment": "test", "Managed_By": "swe-cloud"}, "allow_nested_items_to_be_public": false, "enable_https_traffic_only": true,
+		}},
+		resource{address: "module.ci.aws_iam_user.this[0]", after: map[string]interface{}{"tags": expiringTags}},
+		resource{address: "module.ci.aws_iam_access_key.this[0]", after: map[string]interface{}{"user": "ci"}},
+		resource{address: "azuread_application_password.this", after: map[string]interface{}{"end_date": nil, "end_date_relative": "2160h"}},
+		resource{address: "google_service_account_key.this", after: map[string]interface{}{"keepers": map[string]interface{}{"credentials_expiry_days": "90"}}},
+		resource{address: "module.vm.aws_instance.this", after: map[string]interface{}{
+			"tags": tags, "metadata_options": []interface{}{map[string]interface{}{"http_tokens": "required", "http_put_response_hop_limit": 1}},
+		}},
+		resource{address: "azurerm_linux_virtual_machine.this", after: map[string]interface{}{"tags": tags, "secure_boot_enabled": true, "vtpm_enabled": true}},
+		resource{address: "google_compute_instance.this", after: map[string]interface{}{
+			"labels":                   labels,
+			"metadata":                 map[string]interface{}{"block-project-ssh-keys": "TRUE"},
+			"shielded_instance_config": []interface{}{map[string]interface{}{"enable_secure_boot": true, "enable_vtpm": true}},
+		}},
+		resource{address: "module.queue.aws_sqs_queue_policy.access[0]", after: map[string]interface{}{
+			"policy": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:role/worker"},"Action":["sqs:GetQueueUrl","sqs:SendMessage"],"Resource":"arn:aws:sqs:us-east-1:123456789012:jobs"}]}`,
+		}},
+		// "*" alone is not a messaging grant, and denials narrow access
+		resource{address: "module.ops.aws_iam_policy.this[0]", after: map[string]interface{}{
+			"tags":   tags,
+			"policy": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"},{"Effect":"Deny","Action":"sqs:*","Resource":"*"}]}`,
+		}},
+		// Deletions are not checked
+		resource{address: "aws_security_group.old", actions: []string{"delete"}, after: nil},
+	)
+
+	assert.Empty(t, evaluate(t, resources...))
+}
+
+func TestPolicyViolations(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		resources []resource
+		policy    string
+		address   string
+		message   string
+	}{
+		"bucket without public access block": {
+			resources: []resource{
+				{address: "module.s.aws_s3_bucket.this", after: map[string]interface{}{"tags": tags}},
+				{address: "module.s.aws_s3_bucket_server_side_encryption_configuration.this", after: map[string]interface{}{}},
+			},
+			policy:  "public_access",
+			address: "module.s.aws_s3_bucket.this",
+			message: "without an aws_s3_bucket_public_access_block",
+		},
+		"public access block with a flag disabled": {
+			resources: []resource{{address: "aws_s3_bucket_public_access_block.this", after: map[string]interface{}{
+				"block_public_acls": true, "block_public_policy": false, "ignore_public_acls": true, "restrict_public_buckets": true,
+			}}},
+			policy:  "public_access",
+			address: "aws_s3_bucket_public_access_block.this",
+			message: "leaves block_public_policy disabled",
+		},
+		"public bucket acl": {
+			resources: []resource{{address: "aws_s3_bucket_acl.this", after: map[string]interface{}{"acl": "public-read"}}},
+			policy:    "public_access",
+			address:   "aws_s3_bucket_acl.this",
+			message:   `bucket ACL "public-read" grants public access`,
+		},
+		"azure public blobs": {
+			resources: []resource{{address: "azurerm_storage_account.this", after: map[string]interface{}{"tags": tags, "allow_nested_items_to_be_public": true}}},
+			policy:    "public_access",
+			address:   "azurerm_storage_account.this",
+			message:   "allows public blob access",
+		},
+		"gcs bucket granted to allUsers": {
+			resources: []resource{{address: "google_storage_bucket_iam_binding.read", after: map[string]interface{}{
+				"role": "roles/storage.objectViewer", "members": []interface{}{"user:a@example.com", "allUsers"},
+			}}},
+			policy:  "public_access",
+			address: "google_storage_bucket_iam_binding.read",
+			message: "grants roles/storage.objectViewer to allUsers",
+		},
+		"missing environment tag": {
+			resources: []resource{{address: "aws_sqs_queue.this", after: map[string]interface{}{"tags": map[string]interface{}{"project": "p", "managed_by": "swe-cloud"}}}},
+			policy:    "tagging",
+			address:   "aws_sqs_queue.this",
+			message:   `missing required tag "environment"`,
+		},
+		"missing managed_by tag": {
+			resources: []resource{{address: "aws_sns_topic.this", after: map[string]interface{}{"tags": map[string]interface{}{"project": "p", "environment": "test"}}}},
+			policy:    "tagging",
+			address:   "aws_sns_topic.this",
+			message:   `missing required tag "managed_by"`,
+		},
+		"missing gcp label": {
+			resources: []resource{{address: "google_pubsub_topic.this", after: map[string]interface{}{"labels": map[string]interface{}{"environment": "test", "managed_by": "swe-cloud"}}}},
+			policy:    "tagging",
+			address:   "google_pubsub_topic.this",
+			message:   `missing required label "project"`,
+		},
+		"bucket without encryption": {
+			resources: []resource{
+				{address: "aws_s3_bucket.this", after: map[string]interface{}{"tags": tags}},
+				{address: "aws_s3_bucket_public_access_block.this", after: map[string]interface{}{}},
+			},
+			policy:  "encryption",
+			address: "aws_s3_bucket.this",
+			message: "without an aws_s3_bucket_server_side_encryption_configuration",
+		},
+		"unencrypted rds": {
+			resources: []resource{{address: "module.db.aws_db_instance.this", after: map[string]interface{}{"tags": tags, "storage_encrypted": false}}},
+			policy:    "encryption",
+			address:   "module.db.aws_db_instance.this",
+			message:   "storage_encrypted",
+		},
+		"sqs without sse": {
+			resources: []resource{{address: "aws_sqs_queue.this", after: map[string]interface{}{"tags": tags, "sqs_managed_sse_enabled": false, "kms_master_key_id": nil}}},
+			policy:    "encryption",
+			address:   "aws_sqs_queue.this",
+			message:   "neither SQS-managed SSE nor a KMS key",
+		},
+		"azure http traffic": {
+			resources: []resource{{address: "azurerm_storage_account.this", after: map[string]interface{}{"tags": tags, "enable_https_traffic_only": false}}},
+			policy:    "encryption",
+			address:   "azurerm_storage_account.this",
+			message:   "unencrypted HTTP traffic",
+		},
+		"security group open to the internet": {
+			resources: []resource{{address: "aws_security_group.web", after: map[string]interface{}{
+				"tags":    tags,
+				"ingress": []interface{}{map[string]interface{}{"from_port": 22, "to_port": 22, "cidr_blocks": []interface{}{"0.0.0.0/0"}, "ipv6_cidr_blocks": nil}},
+			}}},
+			policy:  "open_security_groups",
+			address: "aws_security_group.web",
+			message: "ingress on ports 22-22 is open to 0.0.0.0/0",
+		},
+		"security group rule open over ipv6": {
+			resources: []resource{{address: "aws_security_group_rule.ssh", after: map[string]interface{}{
+				"type": "ingress", "from_port": 22, "to_port": 22, "cidr_blocks": nil, "ipv6_cidr_blocks": []interface{}{"::/0"},
+			}}},
+			policy:  "open_security_groups",
+			address: "aws_security_group_rule.ssh",
+			message: "open to ::/0",
+		},
+		"azure nsg rule from the internet": {
+			resources: []resource{{address: "azurerm_network_security_group.this", after: map[string]interface{}{
+				"tags": tags,
+				"security_rule": []interface{}{map[string]interface{}{
+					"name": "AllowSSH", "direction": "Inbound", "access": "Allow", "source_address_prefix": "Internet", "source_address_prefixes": nil,
+				}},
+			}}},
+			policy:  "open_security_groups",
+			address: "azurerm_network_security_group.this",
+			message: `inbound rule "AllowSSH" allows traffic from Internet`,
+		},
+		"gcp firewall from anywhere": {
+			resources: []resource{{address: "module.net.google_compute_firewall.allow_ssh[0]", after: map[string]interface{}{
+				"name": "allow-ssh", "direction": "INGRESS", "allow": []interface{}{map[string]interface{}{"protocol": "tcp"}}, "source_ranges": []interface{}{"0.0.0.0/0"},
+			}}},
+			policy:  "open_security_groups",
+			address: "module.net.google_compute_firewall.allow_ssh[0]",
+			message: `firewall "allow-ssh" allows ingress from 0.0.0.0/0`,
+		},
+		"access key for a user without an expiry tag": {
+			resources: []resource{
+				{address: "module.ci.aws_iam_user.this[0]", after: map[string]interface{}{"tags": tags}},
+				{address: "module.ci.aws_iam_access_key.this[0]", after: map[string]interface{}{"user": "ci"}},
+			},
+			policy:  "credentials",
+			address: "module.ci.aws_iam_access_key.this[0]",
+			message: "without a CredentialsExpiryDays tag",
+		},
+		"access key next to an expiring user in another module": {
+			resources: []resource{
+				{address: "module.a.aws_iam_user.this[0]", after: map[string]interface{}{"tags": expiringTags}},
+				{address: "module.b.aws_iam_access_key.this[0]", after: map[string]interface{}{"user": "b"}},
+			},
+			policy:  "credentials",
+			address: "module.b.aws_iam_access_key.this[0]",
+			message: "without a CredentialsExpiryDays tag",
+		},
+		"azure application password without an end date": {
+			resources: []resource{{address: "azuread_application_password.this", after: map[string]interface{}{"end_date_relative": nil}}},
+			policy:    "credentials",
+			address:   "azuread_application_password.this",
+			message:   "no end_date or end_date_relative",
+		},
+		"gcp key without an expiry keeper": {
+			resources: []resource{{address: "google_service_account_key.this", after: map[string]interface{}{"keepers": nil}}},
+			policy:    "credentials",
+			address:   "google_service_account_key.this",
+			message:   "no credentials_expiry_days keeper",
+		},
+		"ec2 instance allowing imdsv1": {
+			resources: []resource{{address: "module.vm.aws_instance.this", after: map[string]interface{}{
+				"tags": tags, "metadata_options": []interface{}{map[string]interface{}{"http_tokens": "optional"}},
+			}}},
+			policy:  "instance_metadata",
+			address: "module.vm.aws_instance.this",
+			message: "allows IMDSv1",
+		},
+		"azure vm without vtpm": {
+			resources: []resource{{address: "azurerm_linux_virtual_machine.this", after: map[string]interface{}{"tags": tags, "secure_boot_enabled": true, "vtpm_enabled": false}}},
+			policy:    "instance_metadata",
+			address:   "azurerm_linux_virtual_machine.this",
+			message:   "without Trusted Launch",
+		},
+		"gce instance without shielded vm": {
+			resources: []resource{{address: "google_compute_instance.this", after: map[string]interface{}{
+				"labels": labels, "metadata": map[string]interface{}{"block-project-ssh-keys": "TRUE"}, "shielded_instance_config": nil,
+			}}},
+			policy:  "instance_metadata",
+			address: "google_compute_instance.this",
+			message: "not a Shielded VM",
+		},
+		"gce instance accepting project ssh keys": {
+			resources: []resource{{address: "google_compute_instance.this", after: map[string]interface{}{
+				"labels":                   labels,
+				"metadata":                 map[string]interface{}{"ssh-keys": "admin:ssh-ed25519 AAAA"},
+				"shielded_instance_config": []interface{}{map[string]interface{}{"enable_secure_boot": true}},
+			}}},
+			policy:  "instance_metadata",
+			address: "google_compute_instance.this",
+			message: "accepts project-wide SSH keys",
+		},
+		"queue policy allowing every sqs action": {
+			resources: []resource{{address: "module.queue.aws_sqs_queue_policy.access[0]", after: map[string]interface{}{
+				"policy": `{"Statement":{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:role/worker"},"Action":"sqs:*","Resource":"arn:aws:sqs:us-east-1:123456789012:jobs"}}`,
+			}}},
+			policy:  "messaging_access",
+			address: "module.queue.aws_sqs_queue_policy.access[0]",
+			message: `wildcard action "sqs:*"`,
+		},
+		"topic policy allowing every action": {
+			resources: []resource{{address: "aws_sns_topic_policy.this", after: map[string]interface{}{
+				"policy": `{"Statement":[{"Effect":"Allow","Principal":"*","Action":["sns:Publish","*"],"Resource":"arn:aws:sns:us-east-1:123456789012:events"}]}`,
+			}}},
+			policy:  "messaging_access",
+			address: "aws_sns_topic_policy.this",
+			message: `wildcard action "*"`,
+		},
+		"iam policy with a wildcard sqs action": {
+			resources: []resource{{address: "module.worker.aws_iam_policy.this[0]", after: map[string]interface{}{
+				"tags":   tags,
+				"policy": `{"Statement":[{"Effect":"Allow","Action":["sqs:Receive*"],"Resource":"arn:aws:sqs:us-east-1:123456789012:jobs"}]}`,
+			}}},
+			policy:  "messaging_access",
+			address: "module.worker.aws_iam_policy.this[0]",
+			message: `"sqs:Receive*" on queues or topics`,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			violations := evaluate(t, tc.resources...)
+			require.Len(t, violations, 1, "Expected exactly one violation, got %v", violations)
+			assert.Equal(t, tc.policy, violations[0].Policy)
+			assert.Equal(t, tc.address, violations[0].Address)
+			assert.Contains(t, violations[0].Message, tc.message)
+		})
+	}
+}
+
+func TestUnknownTagsAreNotJudged(t *testing.T) {
+	t.Parallel()
+
+	violations := evaluate(t, resource{
+		address: "aws_sqs_queue.this",
+		after:   map[string]interface{}{},
+		unknown: map[string]interface{}{"tags": true},
+	})
+	assert.Empty(t, violations, "Tags only known after apply cannot be checked from the plan")
+}
+
+func TestUnknownQueuePolicyIsNotJudged(t *testing.T) {
+	t.Parallel()
+
+	violations := evaluate(t, resource{
+		address: "aws_sqs_queue_policy.this",
+		after:   map[string]interface{}{},
+		unknown: map[string]interface{}{"policy": true},
+	})
+	assert.Empty(t, violations, "A policy only known after apply cannot be checked from the plan")
+}
+
+func TestWebsiteBucketMayBePublic(t *testing.T) {
+	t.Parallel()
+
+	website := []interface{}{map[string]interface{}{"main_page_suffix": "index.html"}}
+	violations := evaluate(t,
+		resource{address: "module.site.google_storage_bucket.this", after: map[string]interface{}{"labels": labels, "website": website}},
+		resource{address: "module.site.google_storage_bucket_iam_member.public_read[0]", after: map[string]interface{}{
+			"role": "roles/storage.objectViewer", "member": "allUsers",
+		}},
+		resource{address: "module.data.google_storage_bucket.this", after: map[string]interface{}{"labels": labels, "website": []interface{}{}}},
+		resource{address: "module.data.google_storage_bucket_iam_member.public_read[0]", after: map[string]interface{}{
+			"role": "roles/storage.objectViewer", "member": "allUsers",
+		}},
+	)
+	require.Len(t, violations, 1, "Only the bucket without website mode should be flagged")
+	assert.Equal(t, "module.data.google_storage_bucket_iam_member.public_read[0]", violations[0].Address)
+}
+
+func TestBucketChecksMatchByModule(t *testing.T) {
+	t.Parallel()
+
+	// The public access block and encryption belong to a different module,
+	// so the bucket in module.b is still flagged by both policies
+	resources := append(compliantBucket("module.a"),
+		resource{address: "module.b.aws_s3_bucket.this", after: map[string]interface{}{"tags": tags}})
+
+	violations := evaluate(t, resources...)
+	require.Len(t, violations, 2)
+	for _, v := range violations {
+		assert.Equal(t, "module.b.aws_s3_bucket.this", v.Address)
+	}
+	assert.Equal(t, "encryption", violations[0].Policy, "Violations should be sorted by address, then policy")
+	assert.Equal(t, "public_access", violations[1].Policy)
+}
+
+func TestEvaluateInvalidPlan(t *testing.T) {
+	t.Parallel()
+
+	_, err := policycheck.Evaluate(context.Background(), []byte("Plan: 1 to add"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "decoding plan JSON")
+}
+
+func TestViolationString(t *testing.T) {
+	t.Parallel()
+
+	v := policycheck.Violation{Policy: "tagging", Address: "aws_sqs_queue.this", Message: `missing required tag "project"`}
+	assert.Equal(t, `aws_sqs_queue.this: missing required tag "project" [tagging]`, v.String())
+}
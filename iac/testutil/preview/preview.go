@@ -0,0 +1,388 @@
+// Package preview manages ephemeral preview environments: the platform
+// service stack applied under a name derived from a branch, so several
+// developers share one emulator or account, and torn down when the branch
+// is done.
+//
+// Every preview is applied from the same root module (DefaultDir) in a
+// Terraform workspace of its own, with a generated .tfvars.json file
+// carrying its slug. The variable files and a registry of the live
+// previews are kept in StateDir under the root module, so Status lists
+// them and Reap destroys those left running.
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DefaultDir is the root module previews are applied from, relative to the
+// iac module
+const DefaultDir = "examples/preview"
+
+// StateDir holds the variable files and the registry under the root module;
+// hidden, so module discovery and validation skip it
+const StateDir = ".preview"
+
+// TargetAccount is the Target of a preview applied to the account the
+// credentials in the environment select rather than to an emulator
+const TargetAccount = "aws"
+
+// Vars are the variables of the root module a preview is applied with
+type Vars struct {
+	ProjectName string `json:"project_name,omitempty"`
+	Environment string `json:"environment"`
+	Preview     string `json:"preview"`
+
+	// EmulatorEndpoint is left out for a real account
+	EmulatorEndpoint string `json:"emulator_endpoint,omitempty"`
+	Region           string `json:"aws_region,omitempty"`
+
+	// Features overrides the root module's, which turn everything on
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// WriteVars writes vars to path as a .tfvars.json file
+func WriteVars(path string, vars Vars) error {
+	data, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return fmt.Errorf("preview: encoding variables: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("preview: creating %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("preview: writing variables: %w", err)
+	}
+	return nil
+}
+
+// Options select where previews are applied
+type Options struct {
+	// Dir is the root module, DefaultDir when empty
+	Dir string
+
+	// EmulatorEndpoint applies to an emulator such as CloudEmu; empty
+	// applies to the account the credentials select
+	EmulatorEndpoint string
+	Region           string
+
+	// ProjectName is the service name the slug is appended to; the root
+	// module's default when empty
+	ProjectName string
+	Features    map[string]bool
+
+	// Binary is the terraform executable, "terraform" when empty
+	Binary string
+
+	// Stdout and Stderr receive Terraform's output; discarded when nil
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// RegistryPath is the registry file under the root module
+func (o Options) RegistryPath() (string, error) {
+	dir, err := o.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, StateDir, "registry.json"), nil
+}
+
+// dir returns the root module as an absolute path, so entries stay usable
+// from any working directory
+func (o Options) dir() (string, error) {
+	dir := o.Dir
+	if dir == "" {
+		dir = DefaultDir
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("preview: resolving %s: %w", dir, err)
+	}
+	return abs, nil
+}
+
+// Up applies the preview named after name (see Slug), creating its
+// workspace the first time, and records it in the registry. Bringing an
+// existing preview up again applies the current code to it and keeps its
+// creation time.
+func Up(ctx context.Context, opts Options, name string) (Entry, error) {
+	slug, err := Slug(name)
+	if err != nil {
+		return Entry{}, err
+	}
+	dir, err := opts.dir()
+	if err != nil {
+		return Entry{}, err
+	}
+	registryPath, err := opts.RegistryPath()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	vars := Vars{
+		ProjectName:      opts.ProjectName,
+		Environment:      "dev",
+		Preview:          slug,
+		EmulatorEndpoint: opts.EmulatorEndpoint,
+		Region:           opts.Region,
+		Features:         opts.Features,
+	}
+	target := TargetAccount
+	if opts.EmulatorEndpoint != "" {
+		vars.Environment = "local"
+		target = opts.EmulatorEndpoint
+	}
+
+	entry := Entry{
+		Slug:      slug,
+		Name:      name,
+		Workspace: slug,
+		Dir:       dir,
+		VarFile:   filepath.Join(dir, StateDir, slug+".tfvars.json"),
+		Target:    target,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := WriteVars(entry.VarFile, vars); err != nil {
+		return Entry{}, err
+	}
+
+	// Recorded before the apply, so a preview that fails halfway can still
+	// be brought down
+	err = UpdateRegistry(ctx, registryPath, func(r *Registry) error {
+		if existing, ok := r.Get(slug); ok {
+			entry.CreatedAt = existing.CreatedAt
+		}
+		r.Put(entry)
+		return nil
+	})
+	if err != nil {
+		return Entry{}, err
+	}
+
+	// select is refused while TF_WORKSPACE is set, so it runs without; the
+	// other commands pin the workspace they act on
+	tf := opts.terraform(dir)
+	if err := tf.run(ctx, "default", "init", "-input=false"); err != nil {
+		return entry, err
+	}
+	if err := tf.run(ctx, "", "workspace", "select", "-or-create=true", entry.Workspace); err != nil {
+		return entry, err
+	}
+	if err := tf.run(ctx, entry.Workspace, "apply", "-auto-approve", "-input=false", "-var-file="+entry.VarFile); err != nil {
+		return entry, err
+	}
+
+	outputs, err := tf.outputs(ctx, entry.Workspace)
+	if err != nil {
+		return entry, err
+	}
+	entry.Outputs = outputs
+
+	err = UpdateRegistry(ctx, registryPath, func(r *Registry) error {
+		r.Put(entry)
+		return nil
+	})
+	return entry, err
+}
+
+// Down destroys the preview named after name, deletes its workspace and
+// variable file, and removes it from the registry
+func Down(ctx context.Context, opts Options, name string) error {
+	slug, err := Slug(name)
+	if err != nil {
+		return err
+	}
+	registryPath, err := opts.RegistryPath()
+	if err != nil {
+		return err
+	}
+
+	r, err := LoadRegistry(registryPath)
+	if err != nil {
+		return err
+	}
+	entry, ok := r.Get(slug)
+	if !ok {
+		return fmt.Errorf("preview: no preview %s in %s", slug, registryPath)
+	}
+	return down(ctx, opts, registryPath, entry)
+}
+
+// Reap brings down every preview created more than age before now and
+// returns the slugs it removed. It carries on past a preview that fails
+// to come down and returns every failure.
+func Reap(ctx context.Context, opts Options, age time.Duration, now time.Time) ([]string, error) {
+	registryPath, err := opts.RegistryPath()
+	if err != nil {
+		return nil, err
+	}
+	r, err := LoadRegistry(registryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	var errs []error
+	for _, entry := range r.OlderThan(age, now) {
+		if err := down(ctx, opts, registryPath, entry); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed = append(removed, entry.Slug)
+	}
+	return removed, errors.Join(errs...)
+}
+
+// down destroys entry with the root module and variables it was applied
+// with, whatever opts say, so a reaper need not know each preview's target
+func down(ctx context.Context, opts Options, registryPath string, entry Entry) error {
+	tf := opts.terraform(entry.Dir)
+	if err := tf.run(ctx, "default", "init", "-input=false"); err != nil {
+		return err
+	}
+	if err := tf.run(ctx, entry.Workspace, "destroy", "-auto-approve", "-input=false", "-var-file="+entry.VarFile); err != nil {
+		return err
+	}
+	// A workspace cannot be deleted while it is selected
+	if err := tf.run(ctx, "default", "workspace", "delete", entry.Workspace); err != nil {
+		return err
+	}
+
+	if err := os.Remove(entry.VarFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("preview: removing %s: %w", entry.VarFile, err)
+	}
+	return UpdateRegistry(ctx, registryPath, func(r *Registry) error {
+		r.Remove(entry.Slug)
+		return nil
+	})
+}
+
+// Status returns the live previews, oldest first
+func Status(opts Options) ([]Entry, error) {
+	registryPath, err := opts.RegistryPath()
+	if err != nil {
+		return nil, err
+	}
+	r, err := LoadRegistry(registryPath)
+	if err != nil {
+		return nil, err
+	}
+	return r.Entries(), nil
+}
+
+// WriteStatus writes entries as a table of slug, branch, target and age
+func WriteStatus(w io.Writer, entries []Entry, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SLUG\tBRANCH\tTARGET\tAGE")
+	for _, e := range entries {
+		age := now.Sub(e.CreatedAt).Round(time.Minute)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Slug, e.Name, e.Target, age)
+	}
+	return tw.Flush()
+}
+
+// terraform runs the terraform CLI in one root module
+type terraform struct {
+	dir    string
+	binary string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (o Options) terraform(dir string) terraform {
+	tf := terraform{dir: dir, binary: o.Binary, stdout: o.Stdout, stderr: o.Stderr}
+	if tf.binary == "" {
+		tf.binary = "terraform"
+	}
+	if tf.stdout == nil {
+		tf.stdout = io.Discard
+	}
+	if tf.stderr == nil {
+		tf.stderr = io.Discard
+	}
+	return tf
+}
+
+// command returns terraform args in the root module. The workspace is
+// given through TF_WORKSPACE rather than terraform workspace select, whose
+// selection every preview in the root module would share; empty leaves
+// TF_WORKSPACE unset.
+func (tf terraform) command(ctx context.Context, workspace string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, tf.binary, append([]string{"-chdir=" + tf.dir}, args...)...)
+
+	env := []string{"TF_IN_AUTOMATION=1"}
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "TF_WORKSPACE=") {
+			env = append(env, kv)
+		}
+	}
+	if workspace != "" {
+		env = append(env, "TF_WORKSPACE="+workspace)
+	}
+	cmd.Env = env
+	return cmd
+}
+
+// run runs terraform args, folding the tail of its error output into the
+// error
+func (tf terraform) run(ctx context.Context, workspace string, args ...string) error {
+	var stderr strings.Builder
+	cmd := tf.command(ctx, workspace, args...)
+	cmd.Stdout = tf.stdout
+	cmd.Stderr = io.MultiWriter(tf.stderr, &stderr)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("preview: terraform %s in %s: %w: %s", args[0], tf.dir, err, lastLines(stderr.String(), 5))
+	}
+	return nil
+}
+
+// outputs returns the root module's outputs in workspace, those that are
+// not strings as JSON; null outputs are left out
+func (tf terraform) outputs(ctx context.Context, workspace string) (map[string]string, error) {
+	cmd := tf.command(ctx, workspace, "output", "-json")
+	cmd.Stderr = tf.stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("preview: terraform output in %s: %w", tf.dir, err)
+	}
+
+	var raw map[string]struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("preview: parsing terraform output: %w", err)
+	}
+
+	outputs := make(map[string]string, len(raw))
+	for name, o := range raw {
+		var s string
+		switch {
+		case string(o.Value) == "null":
+		case json.Unmarshal(o.Value, &s) == nil:
+			outputs[name] = s
+		default:
+			outputs[name] = string(o.Value)
+		}
+	}
+	return outputs, nil
+}
+
+// lastLines returns the last n non-empty lines of s
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,76 @@
+package preview_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/preview"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteVarsEmulator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".preview", "login.tfvars.json")
+
+	require.NoError(t, preview.WriteVars(path, preview.Vars{
+		Environment:      "local",
+		Preview:          "login",
+		EmulatorEndpoint: "http://localhost:4566",
+		Region:           "us-east-1",
+		Features:         map[string]bool{"queue": true, "network": false},
+	}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"environment": "local",
+		"preview": "login",
+		"emulator_endpoint": "http://localhost:4566",
+		"aws_region": "us-east-1",
+		"features": {"network": false, "queue": true}
+	}`, string(data))
+}
+
+func TestWriteVarsAccountLeavesEmulatorUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "login.tfvars.json")
+
+	require.NoError(t, preview.WriteVars(path, preview.Vars{ProjectName: "orders", Environment: "dev", Preview: "login"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var vars map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &vars))
+	assert.Equal(t, map[string]interface{}{"project_name": "orders", "environment": "dev", "preview": "login"}, vars,
+		"Unset fields should be left to the root module's defaults, so emulator_endpoint stays null")
+}
+
+func TestRegistryPathIsUnderRootModule(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := preview.Options{Dir: dir}.RegistryPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, preview.StateDir, "registry.json"), path)
+
+	path, err = preview.Options{}.RegistryPath()
+	require.NoError(t, err)
+	assert.True(t, filepath.IsAbs(path))
+	assert.Contains(t, filepath.ToSlash(path), preview.DefaultDir+"/"+preview.StateDir+"/")
+}
+
+func TestWriteStatus(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, preview.WriteStatus(&out, []preview.Entry{
+		entry("old", 26*time.Hour),
+		{Slug: "login", Name: "feature/login", Target: preview.TargetAccount, CreatedAt: created.Add(-90 * time.Minute)},
+	}, created))
+
+	assert.Equal(t, ""+
+		"SLUG   BRANCH         TARGET                 AGE\n"+
+		"old    feature/old    http://localhost:4566  26h0m0s\n"+
+		"login  feature/login  aws                    1h30m0s\n", out.String())
+}
@@ -0,0 +1,159 @@
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"iac/testutil/concurrency"
+)
+
+// Entry records one live preview
+type Entry struct {
+	Slug string `json:"slug"`
+
+	// Name is the branch the slug was derived from
+	Name string `json:"name"`
+
+	// Workspace is the Terraform workspace holding the preview's state
+	Workspace string `json:"workspace"`
+
+	// Dir is the absolute path of the root module the preview was applied
+	// from, and VarFile that of its generated variables
+	Dir     string `json:"dir"`
+	VarFile string `json:"var_file"`
+
+	// Target is the emulator endpoint, or TargetAccount for the account the
+	// credentials select
+	Target string `json:"target"`
+
+	CreatedAt time.Time         `json:"created_at"`
+	Outputs   map[string]string `json:"outputs,omitempty"`
+}
+
+// Registry is the file recording the live previews
+type Registry struct {
+	path    string
+	entries map[string]Entry
+}
+
+// registryFile is the registry's JSON layout
+type registryFile struct {
+	Previews []Entry `json:"previews"`
+}
+
+// LoadRegistry reads the registry at path; a missing file is an empty
+// registry
+func LoadRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path, entries: make(map[string]Entry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("preview: reading registry: %w", err)
+	}
+
+	var file registryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("preview: parsing registry %s: %w", path, err)
+	}
+	for _, e := range file.Previews {
+		r.entries[e.Slug] = e
+	}
+	return r, nil
+}
+
+// Get returns the preview named slug
+func (r *Registry) Get(slug string) (Entry, bool) {
+	e, ok := r.entries[slug]
+	return e, ok
+}
+
+// Put records e, replacing any preview with the same slug
+func (r *Registry) Put(e Entry) {
+	r.entries[e.Slug] = e
+}
+
+// Remove forgets the preview named slug
+func (r *Registry) Remove(slug string) {
+	delete(r.entries, slug)
+}
+
+// Entries returns every preview, oldest first
+func (r *Registry) Entries() []Entry {
+	entries := make([]Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+		}
+		return entries[i].Slug < entries[j].Slug
+	})
+	return entries
+}
+
+// OlderThan returns the previews created more than age before now, oldest
+// first
+func (r *Registry) OlderThan(age time.Duration, now time.Time) []Entry {
+	var old []Entry
+	for _, e := range r.Entries() {
+		if now.Sub(e.CreatedAt) > age {
+			old = append(old, e)
+		}
+	}
+	return old
+}
+
+// Save writes the registry, replacing the file in one rename so a reader
+// never sees half of it
+func (r *Registry) Save() error {
+	data, err := json.MarshalIndent(registryFile{Previews: r.Entries()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("preview: encoding registry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("preview: creating registry directory: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("preview: writing registry: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("preview: writing registry: %w", err)
+	}
+	return nil
+}
+
+// UpdateRegistry loads the registry at path, passes it to fn and saves it
+// when fn succeeds, holding a lock beside the file throughout so previews
+// brought up or down at the same time do not drop each other's entries
+func UpdateRegistry(ctx context.Context, path string, fn func(*Registry) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("preview: creating registry directory: %w", err)
+	}
+
+	lock := concurrency.NewFileLock(path + ".lock")
+	if err := lock.Lock(ctx); err != nil {
+		return err
+	}
+
+	r, err := LoadRegistry(path)
+	if err == nil {
+		err = fn(r)
+	}
+	if err == nil {
+		err = r.Save()
+	}
+	return errors.Join(err, lock.Unlock())
+}
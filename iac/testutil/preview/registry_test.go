@@ -0,0 +1,136 @@
+package preview_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"iac/testutil/preview"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var created = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func entry(slug string, age time.Duration) preview.Entry {
+	return preview.Entry{
+		Slug:      slug,
+		Name:      "feature/" + slug,
+		Workspace: slug,
+		Dir:       "/src/iac/examples/preview",
+		VarFile:   "/src/iac/examples/preview/.preview/" + slug + ".tfvars.json",
+		Target:    "http://localhost:4566",
+		CreatedAt: created.Add(-age),
+		Outputs:   map[string]string{"function_name": "preview-" + slug + "-local-fn"},
+	}
+}
+
+func TestRegistryMissingFileIsEmpty(t *testing.T) {
+	r, err := preview.LoadRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	require.NoError(t, err)
+	assert.Empty(t, r.Entries())
+}
+
+func TestRegistryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "registry.json")
+
+	r, err := preview.LoadRegistry(path)
+	require.NoError(t, err)
+	r.Put(entry("new", time.Hour))
+	r.Put(entry("old", 48*time.Hour))
+	require.NoError(t, r.Save(), "Save should create the registry's directory")
+
+	loaded, err := preview.LoadRegistry(path)
+	require.NoError(t, err)
+	assert.Equal(t, []preview.Entry{entry("old", 48*time.Hour), entry("new", time.Hour)}, loaded.Entries(), "Entries should survive a save, oldest first")
+
+	loaded.Remove("old")
+	require.NoError(t, loaded.Save())
+
+	again, err := preview.LoadRegistry(path)
+	require.NoError(t, err)
+	_, ok := again.Get("old")
+	assert.False(t, ok, "A removed preview should stay removed")
+	got, ok := again.Get("new")
+	require.True(t, ok)
+	assert.Equal(t, entry("new", time.Hour), got)
+
+	_, err = os.Stat(path + ".tmp")
+	assert.ErrorIs(t, err, os.ErrNotExist, "Save should not leave its temporary file behind")
+}
+
+func TestRegistryPutReplacesSameSlug(t *testing.T) {
+	r, err := preview.LoadRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	require.NoError(t, err)
+
+	r.Put(entry("login", time.Hour))
+	replacement := entry("login", time.Hour)
+	replacement.Target = preview.TargetAccount
+	r.Put(replacement)
+
+	require.Len(t, r.Entries(), 1)
+	assert.Equal(t, preview.TargetAccount, r.Entries()[0].Target)
+}
+
+func TestRegistryOlderThan(t *testing.T) {
+	r, err := preview.LoadRegistry(filepath.Join(t.TempDir(), "registry.json"))
+	require.NoError(t, err)
+	r.Put(entry("fresh", time.Hour))
+	r.Put(entry("stale", 25*time.Hour))
+	r.Put(entry("ancient", 72*time.Hour))
+
+	var slugs []string
+	for _, e := range r.OlderThan(24*time.Hour, created) {
+		slugs = append(slugs, e.Slug)
+	}
+	assert.Equal(t, []string{"ancient", "stale"}, slugs)
+}
+
+func TestRegistryRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+
+	_, err := preview.LoadRegistry(path)
+	assert.ErrorContains(t, err, path)
+}
+
+func TestUpdateRegistryKeepsConcurrentEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+
+	var wg sync.WaitGroup
+	for _, slug := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, preview.UpdateRegistry(context.Background(), path, func(r *preview.Registry) error {
+				r.Put(entry(slug, time.Hour))
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+
+	r, err := preview.LoadRegistry(path)
+	require.NoError(t, err)
+	assert.Len(t, r.Entries(), 8, "No update should overwrite another's entry")
+
+	_, err = os.Stat(path + ".lock")
+	assert.ErrorIs(t, err, os.ErrNotExist, "The lock should be released")
+}
+
+func TestUpdateRegistrySkipsSaveOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+
+	err := preview.UpdateRegistry(context.Background(), path, func(r *preview.Registry) error {
+		r.Put(entry("a", time.Hour))
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "A failed update should not be written")
+}
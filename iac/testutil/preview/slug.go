@@ -0,0 +1,52 @@
+package preview
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxSlugLength keeps <project_name>-<slug> within the 32 characters the
+// platform service module allows its service_name
+const MaxSlugLength = 16
+
+// EnvHeadRef is set by GitHub Actions to the source branch of a pull
+// request, and names the preview when no name is given
+const EnvHeadRef = "GITHUB_HEAD_REF"
+
+// nonSlug matches the runs of characters a slug replaces with one hyphen
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slug derives the name of a preview from a branch: refs/heads/ is
+// dropped, the rest lower-cased and every run of other characters than
+// letters and digits turned into a hyphen, so fix/Login_Page becomes
+// fix-login-page. A slug longer than MaxSlugLength is cut short and
+// ends in four hex digits of its hash, so branches sharing a long prefix
+// get different previews.
+func Slug(name string) (string, error) {
+	branch := strings.TrimPrefix(strings.TrimSpace(name), "refs/heads/")
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(branch), "-"), "-")
+	if slug == "" {
+		return "", fmt.Errorf("preview: %q has no letters or digits to name a preview after", name)
+	}
+	if len(slug) <= MaxSlugLength {
+		return slug, nil
+	}
+
+	sum := sha256.Sum256([]byte(slug))
+	prefix := strings.TrimRight(slug[:MaxSlugLength-5], "-")
+	return fmt.Sprintf("%s-%x", prefix, sum[:2]), nil
+}
+
+// Name returns what a preview is named after: name when it is set,
+// otherwise the pull request branch in EnvHeadRef
+func Name(name string, getenv func(string) string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	if ref := getenv(EnvHeadRef); ref != "" {
+		return ref, nil
+	}
+	return "", fmt.Errorf("preview: no name given and %s is not set", EnvHeadRef)
+}
@@ -0,0 +1,72 @@
+package preview_test
+
+import (
+	"testing"
+
+	"iac/testutil/preview"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlug(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"main", "main"},
+		{"fix/Login_Page", "fix-login-page"},
+		{"refs/heads/fix/123", "fix-123"},
+		{"  --Hotfix--  ", "hotfix"},
+		{"feature/Add.CSV", "feature-add-csv"},
+	}
+	for _, c := range cases {
+		got, err := preview.Slug(c.name)
+		require.NoError(t, err, c.name)
+		assert.Equal(t, c.want, got, "Slug(%q)", c.name)
+	}
+}
+
+func TestSlugShortensLongNames(t *testing.T) {
+	a, err := preview.Slug("feature/checkout-redesign-step-one")
+	require.NoError(t, err)
+	b, err := preview.Slug("feature/checkout-redesign-step-two")
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, len(a), preview.MaxSlugLength)
+	assert.Regexp(t, `^feature-che-[0-9a-f]{4}$`, a, "A long slug should keep its prefix and end in a hash")
+	assert.NotEqual(t, a, b, "Branches sharing a long prefix should get different slugs")
+
+	again, err := preview.Slug("feature/checkout-redesign-step-one")
+	require.NoError(t, err)
+	assert.Equal(t, a, again, "The same branch should always get the same slug")
+}
+
+func TestSlugDropsTrailingHyphenBeforeHash(t *testing.T) {
+	got, err := preview.Slug("abcdefghij-klmnopqrstuvwxyz")
+	require.NoError(t, err)
+	assert.Regexp(t, `^abcdefghij-[0-9a-f]{4}$`, got)
+}
+
+func TestSlugRejectsNamesWithoutLettersOrDigits(t *testing.T) {
+	for _, name := range []string{"", "///", "refs/heads/"} {
+		_, err := preview.Slug(name)
+		assert.Error(t, err, "Slug(%q)", name)
+	}
+}
+
+func TestName(t *testing.T) {
+	env := map[string]string{preview.EnvHeadRef: "feature/from-ci"}
+	getenv := func(key string) string { return env[key] }
+
+	name, err := preview.Name("mine", getenv)
+	require.NoError(t, err)
+	assert.Equal(t, "mine", name, "An explicit name should win over the environment")
+
+	name, err = preview.Name("", getenv)
+	require.NoError(t, err)
+	assert.Equal(t, "feature/from-ci", name)
+
+	_, err = preview.Name("", func(string) string { return "" })
+	assert.ErrorContains(t, err, preview.EnvHeadRef)
+}
@@ -0,0 +1,154 @@
+// Package providercheck finds provider configuration blocks in modules that
+// are meant to be called. A module with its own provider block cannot be
+// given a provider by its caller, so it cannot be instantiated once per
+// region or account through provider aliases, and it cannot be used with
+// count or for_each at all.
+//
+// Facades and everything they call are found by following module blocks
+// with local sources, and read with the HCL parser, so the check needs no
+// terraform init.
+package providercheck
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Block is a provider configuration block
+type Block struct {
+	Name string
+
+	// Alias is empty for a default configuration
+	Alias string
+
+	File string
+	Line int
+}
+
+// Module is the provider blocks and local module calls of one directory
+type Module struct {
+	Dir       string
+	Providers []Block
+
+	// Calls lists the directories of the modules called with a local
+	// (./ or ../) source, cleaned and sorted
+	Calls []string
+}
+
+// Inspect parses the .tf files of dir
+func Inspect(dir string) (*Module, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	mod := &Module{Dir: filepath.Clean(dir)}
+	calls := make(map[string]bool)
+	parser := hclparse.NewParser()
+
+	for _, file := range files {
+		f, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("providercheck: %s", diags.Error())
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, fmt.Errorf("providercheck: %s is not native HCL syntax", file)
+		}
+
+		for _, block := range body.Blocks {
+			if len(block.Labels) == 0 {
+				continue
+			}
+			switch block.Type {
+			case "provider":
+				mod.Providers = append(mod.Providers, Block{
+					Name:  block.Labels[0],
+					Alias: stringAttribute(block.Body, "alias"),
+					File:  filepath.Base(file),
+					Line:  block.TypeRange.Start.Line,
+				})
+			case "module":
+				source := stringAttribute(block.Body, "source")
+				if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+					calls[filepath.Join(dir, filepath.FromSlash(source))] = true
+				}
+			}
+		}
+	}
+
+	for call := range calls {
+		mod.Calls = append(mod.Calls, call)
+	}
+	sort.Strings(mod.Calls)
+	return mod, nil
+}
+
+// Reachable inspects roots and every module they call through local
+// sources, directly or indirectly, returning each module once sorted by
+// directory
+func Reachable(roots []string) ([]*Module, error) {
+	seen := make(map[string]*Module)
+	queue := append([]string(nil), roots...)
+
+	for len(queue) > 0 {
+		dir := filepath.Clean(queue[0])
+		queue = queue[1:]
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+
+		mod, err := Inspect(dir)
+		if err != nil {
+			return nil, err
+		}
+		seen[dir] = mod
+		queue = append(queue, mod.Calls...)
+	}
+
+	mods := make([]*Module, 0, len(seen))
+	for _, mod := range seen {
+		mods = append(mods, mod)
+	}
+	sort.Slice(mods, func(i, j int) bool { return mods[i].Dir < mods[j].Dir })
+	return mods, nil
+}
+
+// Report lists the provider blocks of mods, one per line:
+//
+//	aws/core/storage/main.tf:14: provider "aws"
+//	aws/core/storage/main.tf:19: provider "aws" (alias "replica")
+func Report(mods []*Module) []string {
+	var lines []string
+	for _, mod := range mods {
+		for _, b := range mod.Providers {
+			line := fmt.Sprintf("%s:%d: provider %q", filepath.ToSlash(filepath.Join(mod.Dir, b.File)), b.Line, b.Name)
+			if b.Alias != "" {
+				line += fmt.Sprintf(" (alias %q)", b.Alias)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// stringAttribute returns the constant string value of an attribute, or ""
+// when it is missing or not a constant string
+func stringAttribute(body *hclsyntax.Body, name string) string {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+	v, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+		return ""
+	}
+	return v.AsString()
+}
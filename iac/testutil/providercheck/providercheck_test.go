@@ -0,0 +1,70 @@
+package providercheck_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/providercheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	mod, err := providercheck.Inspect("testdata/core/bucket")
+	require.NoError(t, err)
+
+	assert.Equal(t, []providercheck.Block{
+		{Name: "aws", File: "providers.tf", Line: 1},
+		{Name: "aws", Alias: "replica", File: "providers.tf", Line: 5},
+	}, mod.Providers)
+	assert.Empty(t, mod.Calls)
+}
+
+func TestInspectFollowsLocalSourcesOnly(t *testing.T) {
+	t.Parallel()
+
+	mod, err := providercheck.Inspect("testdata/facade/app")
+	require.NoError(t, err)
+
+	assert.Empty(t, mod.Providers)
+	assert.Equal(t, []string{
+		filepath.Join("testdata", "core", "bucket"),
+		filepath.Join("testdata", "core", "queue"),
+	}, mod.Calls, "Registry sources should not be followed")
+}
+
+func TestReachable(t *testing.T) {
+	t.Parallel()
+
+	mods, err := providercheck.Reachable([]string{"testdata/facade/app"})
+	require.NoError(t, err)
+
+	var dirs []string
+	for _, mod := range mods {
+		dirs = append(dirs, filepath.ToSlash(mod.Dir))
+	}
+	assert.Equal(t, []string{
+		"testdata/core/bucket",
+		"testdata/core/dlq",
+		"testdata/core/queue",
+		"testdata/facade/app",
+	}, dirs, "Every called module should be listed once, without the uncalled one")
+
+	assert.Equal(t, []string{
+		`testdata/core/bucket/providers.tf:1: provider "aws"`,
+		`testdata/core/bucket/providers.tf:5: provider "aws" (alias "replica")`,
+	}, providercheck.Report(mods))
+}
+
+func TestReachableWithoutProviders(t *testing.T) {
+	t.Parallel()
+
+	mods, err := providercheck.Reachable([]string{"testdata/core/queue"})
+	require.NoError(t, err)
+
+	assert.Len(t, mods, 2)
+	assert.Empty(t, providercheck.Report(mods))
+}
@@ -0,0 +1,8 @@
+resource "aws_sqs_queue" "dlq" {
+  name = "fixture-dlq"
+}
+
+# Calls back into the queue module; Reachable must not loop
+module "queue" {
+  source = "../queue"
+}
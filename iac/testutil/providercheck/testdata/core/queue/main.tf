@@ -0,0 +1,7 @@
+resource "aws_sqs_queue" "this" {
+  name = "fixture"
+}
+
+module "dlq" {
+  source = "./../dlq"
+}
@@ -0,0 +1,13 @@
+module "bucket" {
+  source = "../../core/bucket"
+}
+
+module "queue" {
+  count  = 2
+  source = "../../core/queue"
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 5.0"
+}
@@ -0,0 +1,4 @@
+# Not called by the facade, so its provider block is not reported
+provider "google" {
+  project = "fixture"
+}
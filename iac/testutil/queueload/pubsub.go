@@ -0,0 +1,89 @@
+package queueload
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"iac/gcp/gcphelpers"
+	"iac/testutil/config"
+)
+
+// PubSub publishes to a Pub/Sub topic and receives from a subscription to
+// it. Pub/Sub streams messages rather than answering polls, so one stream
+// is opened on the first Receive and every consumer takes from it.
+type PubSub struct {
+	helpers      *gcphelpers.Helpers
+	topic        string
+	subscription string
+
+	start    sync.Once
+	stop     context.CancelFunc
+	messages chan string
+	errs     chan error
+}
+
+// NewPubSub returns the topic and subscription, IDs or full names, in
+// project on the GCP endpoint of cfg
+func NewPubSub(ctx context.Context, cfg *config.TestConfig, project, topic, subscription string) (*PubSub, error) {
+	helpers, err := gcphelpers.NewFromTestConfig(ctx, cfg, project)
+	if err != nil {
+		return nil, fmt.Errorf("queueload: %w", err)
+	}
+	return &PubSub{
+		helpers:      helpers,
+		topic:        topic,
+		subscription: subscription,
+		messages:     make(chan string, 1024),
+		errs:         make(chan error, 1),
+	}, nil
+}
+
+// Name implements Backend
+func (q *PubSub) Name() string { return "pubsub" }
+
+// Send implements Producer
+func (q *PubSub) Send(ctx context.Context, body string) error {
+	return q.helpers.Publish(ctx, q.topic, body)
+}
+
+// Receive implements Consumer, waiting up to a second for the stream to
+// deliver a message
+func (q *PubSub) Receive(ctx context.Context) ([]string, error) {
+	q.start.Do(func() {
+		// The stream outlives the first caller's ctx, until Close
+		streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		q.stop = cancel
+		go func() {
+			err := q.helpers.Receive(streamCtx, q.subscription, func(data []byte) {
+				select {
+				case q.messages <- string(data):
+				case <-streamCtx.Done():
+				}
+			})
+			if err != nil {
+				q.errs <- err
+			}
+		}()
+	})
+
+	select {
+	case body := <-q.messages:
+		return []string{body}, nil
+	case err := <-q.errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Second):
+		return nil, nil
+	}
+}
+
+// Close stops the stream and closes the clients
+func (q *PubSub) Close() error {
+	if q.stop != nil {
+		q.stop()
+	}
+	return q.helpers.Close()
+}
@@ -0,0 +1,215 @@
+// Package queueload puts sustained load on an emulator queue, to measure how
+// much traffic it takes before sends fail or messages lag, and to compare
+// emulator releases:
+//
+//	backend, err := queueload.NewSQS(cfg, queueURL)
+//	report := queueload.Run(ctx, backend, queueload.Options{Producers: 4, Consumers: 4, Duration: 30 * time.Second})
+//	queueload.WriteTable(os.Stdout, report)
+//
+// Producers send for the Duration and consumers receive until every sent
+// message has arrived or the Drain after it runs out. Each message carries
+// the time it was sent, so its end-to-end latency is measured on arrival;
+// a message this run did not send, left on the queue by an earlier one, is
+// counted as Foreign and left out of the latencies.
+//
+// SQS, Service Bus and Pub/Sub queues are Backends. tools/queueloader runs
+// the load from the command line, on a queue it creates with the messaging
+// facade or one given by URL.
+package queueload
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for the zero Options
+const (
+	DefaultProducers   = 1
+	DefaultConsumers   = 1
+	DefaultMessageSize = 256
+	DefaultDuration    = 10 * time.Second
+	DefaultDrain       = 10 * time.Second
+	DefaultInterval    = time.Second
+)
+
+// Producer sends messages to a queue
+type Producer interface {
+	Send(ctx context.Context, body string) error
+}
+
+// Consumer receives messages from a queue, removing them
+type Consumer interface {
+	// Receive returns the bodies of the messages waiting, none when the
+	// queue stays empty for the backend's poll
+	Receive(ctx context.Context) ([]string, error)
+}
+
+// Backend is a queue on one provider. Its methods are called from every
+// producer and consumer goroutine at once.
+type Backend interface {
+	Producer
+	Consumer
+
+	// Name is the backend in reports, e.g. "sqs"
+	Name() string
+
+	Close() error
+}
+
+// Options configures a Run
+type Options struct {
+	// Producers and Consumers are the goroutines sending and receiving
+	Producers int
+	Consumers int
+
+	// MessageSize is the size of each message body in bytes; bodies are
+	// never shorter than their send time
+	MessageSize int
+
+	// Duration is how long producers send
+	Duration time.Duration
+
+	// Drain is how long consumers keep receiving after the producers
+	// stop, for the messages still on the queue
+	Drain time.Duration
+
+	// Interval is the width of the report's throughput buckets
+	Interval time.Duration
+}
+
+// withDefaults fills in the zero fields of o
+func (o Options) withDefaults() Options {
+	if o.Producers <= 0 {
+		o.Producers = DefaultProducers
+	}
+	if o.Consumers <= 0 {
+		o.Consumers = DefaultConsumers
+	}
+	if o.MessageSize <= 0 {
+		o.MessageSize = DefaultMessageSize
+	}
+	if o.Duration <= 0 {
+		o.Duration = DefaultDuration
+	}
+	if o.Drain <= 0 {
+		o.Drain = DefaultDrain
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultInterval
+	}
+	return o
+}
+
+// Run loads backend as opts says and reports what it measured. It returns
+// early, with what was measured so far, when ctx is done.
+func Run(ctx context.Context, backend Backend, opts Options) Report {
+	opts = opts.withDefaults()
+
+	start := time.Now()
+	recorder := NewRecorder(start, opts.Interval)
+
+	produceCtx, stopProducing := context.WithDeadline(ctx, start.Add(opts.Duration))
+	defer stopProducing()
+	consumeCtx, stopConsuming := context.WithDeadline(ctx, start.Add(opts.Duration+opts.Drain))
+	defer stopConsuming()
+
+	var producers, consumers sync.WaitGroup
+	for range opts.Producers {
+		producers.Add(1)
+		go func() {
+			defer producers.Done()
+			produce(produceCtx, backend, recorder, opts.MessageSize)
+		}()
+	}
+
+	produced := make(chan struct{})
+	for range opts.Consumers {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			consume(consumeCtx, backend, recorder, start, produced, stopConsuming)
+		}()
+	}
+
+	producers.Wait()
+	producing := time.Since(start)
+	close(produced)
+	consumers.Wait()
+
+	report := recorder.Report(time.Now())
+	report.Backend = backend.Name()
+	report.Producers = opts.Producers
+	report.Consumers = opts.Consumers
+	report.MessageSize = opts.MessageSize
+	report.SendRate = rate(report.Sent, producing)
+	return report
+}
+
+// produce sends messages until ctx is done
+func produce(ctx context.Context, p Producer, recorder *Recorder, size int) {
+	for ctx.Err() == nil {
+		sent := time.Now()
+		err := p.Send(ctx, Body(sent, size))
+		switch {
+		case err == nil:
+			recorder.Sent(sent)
+		case ctx.Err() == nil:
+			recorder.SendFailed(time.Now())
+		}
+	}
+}
+
+// consume receives messages until ctx is done, or until the producers are
+// done and every message they sent has arrived, which stops the others too
+func consume(ctx context.Context, c Consumer, recorder *Recorder, start time.Time, produced <-chan struct{}, stop context.CancelFunc) {
+	for ctx.Err() == nil {
+		select {
+		case <-produced:
+			if recorder.Pending() <= 0 {
+				stop()
+				return
+			}
+		default:
+		}
+
+		// A receive can fail after taking messages, as when SQS cannot
+		// delete them, so its bodies count either way
+		bodies, err := c.Receive(ctx)
+		now := time.Now()
+		if err != nil && ctx.Err() == nil {
+			recorder.ReceiveFailed(now)
+		}
+		for _, body := range bodies {
+			sent, ok := SentAt(body)
+			if !ok || sent.Before(start) {
+				recorder.Foreign()
+				continue
+			}
+			recorder.Received(now, now.Sub(sent))
+		}
+	}
+}
+
+// Body returns a message body of size bytes that starts with sent, padded
+// with "x"
+func Body(sent time.Time, size int) string {
+	stamp := strconv.FormatInt(sent.UnixNano(), 10)
+	if size <= len(stamp)+1 {
+		return stamp
+	}
+	return stamp + " " + strings.Repeat("x", size-len(stamp)-1)
+}
+
+// SentAt reads the send time from a Body, reporting false for a body Body
+// did not write
+func SentAt(body string) (time.Time, bool) {
+	stamp, _, _ := strings.Cut(body, " ")
+	nanos, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil || nanos <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
@@ -0,0 +1,116 @@
+package queueload_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iac/testutil/queueload"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryQueue is a Backend over a channel, which fails every failEvery-th
+// send when failEvery is set
+type memoryQueue struct {
+	messages  chan string
+	sends     atomic.Int64
+	failEvery int64
+}
+
+func newMemoryQueue() *memoryQueue {
+	return &memoryQueue{messages: make(chan string, 100000)}
+}
+
+func (q *memoryQueue) Name() string { return "memory" }
+
+func (q *memoryQueue) Send(ctx context.Context, body string) error {
+	if n := q.sends.Add(1); q.failEvery > 0 && n%q.failEvery == 0 {
+		return errors.New("throttled")
+	}
+	// Slow enough that a run sends a few hundred messages, not millions
+	time.Sleep(time.Millisecond)
+	q.messages <- body
+	return nil
+}
+
+func (q *memoryQueue) Receive(ctx context.Context) ([]string, error) {
+	select {
+	case body := <-q.messages:
+		return []string{body}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(10 * time.Millisecond):
+		return nil, nil
+	}
+}
+
+func (q *memoryQueue) Close() error { return nil }
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	q := newMemoryQueue()
+	q.failEvery = 10
+	q.messages <- "left over by an earlier run"
+
+	report := queueload.Run(context.Background(), q, queueload.Options{
+		Producers:   3,
+		Consumers:   2,
+		MessageSize: 64,
+		Duration:    200 * time.Millisecond,
+		Drain:       5 * time.Second,
+		Interval:    50 * time.Millisecond,
+	})
+
+	assert.Equal(t, "memory", report.Backend)
+	assert.Equal(t, 3, report.Producers)
+	assert.Equal(t, 2, report.Consumers)
+	assert.Positive(t, report.Sent)
+	assert.Equal(t, report.Sent, report.Received, "Every message sent should be received before the drain runs out")
+	assert.Zero(t, report.Lost)
+	assert.Equal(t, 1, report.Foreign)
+	assert.Positive(t, report.SendErrors)
+	assert.Less(t, report.Seconds, 5.0, "The run should end once every message has arrived, not at the end of the drain")
+	assert.Positive(t, report.SendRate)
+	assert.Positive(t, report.Latency.MaxMS)
+	assert.GreaterOrEqual(t, len(report.Intervals), 4, "A 200ms run should span four 50ms intervals")
+}
+
+func TestRunCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report := queueload.Run(ctx, newMemoryQueue(), queueload.Options{Duration: time.Minute})
+	assert.Zero(t, report.Sent)
+	assert.Zero(t, report.SendErrors, "Sends stopped by the context should not count as errors")
+	assert.Less(t, report.Seconds, 1.0)
+}
+
+func TestBody(t *testing.T) {
+	t.Parallel()
+
+	sent := time.Unix(1760000000, 123456789)
+
+	body := queueload.Body(sent, 100)
+	assert.Len(t, body, 100)
+	assert.True(t, strings.HasPrefix(body, "1760000000123456789 x"))
+
+	got, ok := queueload.SentAt(body)
+	assert.True(t, ok)
+	assert.True(t, sent.Equal(got))
+
+	short := queueload.Body(sent, 8)
+	assert.Equal(t, "1760000000123456789", short, "A body is never shorter than its send time")
+	got, ok = queueload.SentAt(short)
+	assert.True(t, ok)
+	assert.True(t, sent.Equal(got))
+
+	_, ok = queueload.SentAt("hello world")
+	assert.False(t, ok)
+}
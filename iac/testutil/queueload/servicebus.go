@@ -0,0 +1,57 @@
+package queueload
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"iac/azure/azurehelpers"
+	"iac/testutil/config"
+)
+
+// emptyQueueBackoff is how long a Service Bus consumer waits after finding
+// the queue empty, which it learns at once, before asking again
+const emptyQueueBackoff = 100 * time.Millisecond
+
+// ServiceBus is a Service Bus queue, driven over HTTP through azurehelpers.
+// Each receive takes the head of the queue, receive-and-delete.
+type ServiceBus struct {
+	helpers *azurehelpers.Helpers
+	queue   string
+}
+
+// NewServiceBus returns the queue named queue on the Azure endpoint of cfg
+func NewServiceBus(cfg *config.TestConfig, queue string) (*ServiceBus, error) {
+	helpers, err := azurehelpers.NewFromTestConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("queueload: %w", err)
+	}
+	return &ServiceBus{helpers: helpers, queue: queue}, nil
+}
+
+// Name implements Backend
+func (q *ServiceBus) Name() string { return "servicebus" }
+
+// Send implements Producer
+func (q *ServiceBus) Send(ctx context.Context, body string) error {
+	return q.helpers.SendQueueMessage(ctx, q.queue, body)
+}
+
+// Receive implements Consumer
+func (q *ServiceBus) Receive(ctx context.Context) ([]string, error) {
+	msg, err := q.helpers.ReceiveQueueMessage(ctx, q.queue)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		select {
+		case <-ctx.Done():
+		case <-time.After(emptyQueueBackoff):
+		}
+		return nil, nil
+	}
+	return []string{msg.Body}, nil
+}
+
+// Close implements Backend
+func (q *ServiceBus) Close() error { return nil }
@@ -0,0 +1,91 @@
+package queueload
+
+import (
+	"context"
+	"fmt"
+
+	"iac/testutil/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// SQS is an SQS queue. Receives long-poll for a second and delete what they
+// return in one batch.
+type SQS struct {
+	client   *sqs.SQS
+	queueURL string
+}
+
+// NewSQS returns the queue at queueURL on the CloudEmu endpoint of cfg. The
+// SDK does not retry, so every failed call is counted.
+func NewSQS(cfg *config.TestConfig, queueURL string) (*SQS, error) {
+	awsConfig, err := cfg.AWSConfig(cfg.CloudEmuEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("queueload: %w", err)
+	}
+	sess, err := session.NewSession(awsConfig.WithMaxRetries(0))
+	if err != nil {
+		return nil, fmt.Errorf("queueload: %w", err)
+	}
+	return &SQS{client: sqs.New(sess), queueURL: queueURL}, nil
+}
+
+// Name implements Backend
+func (q *SQS) Name() string { return "sqs" }
+
+// Send implements Producer
+func (q *SQS) Send(ctx context.Context, body string) error {
+	_, err := q.client.SendMessageWithContext(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.queueURL),
+		MessageBody: aws.String(body),
+	})
+	if err != nil {
+		return fmt.Errorf("queueload: sending to %s: %w", q.queueURL, err)
+	}
+	return nil
+}
+
+// Receive implements Consumer
+func (q *SQS) Receive(ctx context.Context) ([]string, error) {
+	out, err := q.client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(q.queueURL),
+		MaxNumberOfMessages: aws.Int64(10),
+		WaitTimeSeconds:     aws.Int64(1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("queueload: receiving from %s: %w", q.queueURL, err)
+	}
+	if len(out.Messages) == 0 {
+		return nil, nil
+	}
+
+	bodies := make([]string, len(out.Messages))
+	entries := make([]*sqs.DeleteMessageBatchRequestEntry, len(out.Messages))
+	for i, m := range out.Messages {
+		bodies[i] = aws.StringValue(m.Body)
+		entries[i] = &sqs.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(fmt.Sprint(i)),
+			ReceiptHandle: m.ReceiptHandle,
+		}
+	}
+
+	// A message that is not deleted comes back after the visibility
+	// timeout and is counted twice, so a failed delete fails the receive
+	deleted, err := q.client.DeleteMessageBatchWithContext(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(q.queueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		return bodies, fmt.Errorf("queueload: deleting from %s: %w", q.queueURL, err)
+	}
+	if len(deleted.Failed) > 0 {
+		return bodies, fmt.Errorf("queueload: deleting from %s: %d of %d messages failed: %s",
+			q.queueURL, len(deleted.Failed), len(entries), aws.StringValue(deleted.Failed[0].Message))
+	}
+	return bodies, nil
+}
+
+// Close implements Backend
+func (q *SQS) Close() error { return nil }
@@ -0,0 +1,228 @@
+package queueload
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"iac/testutil/fanout"
+)
+
+// Recorder counts the sends, receives and errors of a run in fixed-width
+// intervals from its start, and keeps every latency. It is safe for
+// concurrent use.
+type Recorder struct {
+	start    time.Time
+	interval time.Duration
+
+	mu        sync.Mutex
+	intervals []Interval
+	latencies []time.Duration
+	foreign   int
+	last      time.Time
+}
+
+// NewRecorder returns a Recorder whose first interval begins at start
+func NewRecorder(start time.Time, interval time.Duration) *Recorder {
+	return &Recorder{start: start, interval: interval}
+}
+
+// Sent records a message sent at at
+func (r *Recorder) Sent(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bucket(at).Sent++
+}
+
+// SendFailed records a send that failed at at
+func (r *Recorder) SendFailed(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bucket(at).SendErrors++
+}
+
+// Received records a message received at at, latency after it was sent
+func (r *Recorder) Received(at time.Time, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bucket(at).Received++
+	r.latencies = append(r.latencies, latency)
+	if at.After(r.last) {
+		r.last = at
+	}
+}
+
+// ReceiveFailed records a receive that failed at at
+func (r *Recorder) ReceiveFailed(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bucket(at).ReceiveErrors++
+}
+
+// Foreign records a received message the run did not send
+func (r *Recorder) Foreign() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.foreign++
+}
+
+// Pending returns how many sent messages have not been received
+func (r *Recorder) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sent() - len(r.latencies)
+}
+
+func (r *Recorder) sent() int {
+	n := 0
+	for _, i := range r.intervals {
+		n += i.Sent
+	}
+	return n
+}
+
+// bucket returns the interval at falls in, adding intervals up to it; a
+// time before the start falls in the first. r.mu must be held.
+func (r *Recorder) bucket(at time.Time) *Interval {
+	i := 0
+	if elapsed := at.Sub(r.start); elapsed > 0 {
+		i = int(elapsed / r.interval)
+	}
+	for len(r.intervals) <= i {
+		n := len(r.intervals)
+		r.intervals = append(r.intervals, Interval{Start: r.start.Add(time.Duration(n) * r.interval)})
+	}
+	return &r.intervals[i]
+}
+
+// Interval counts what happened in one interval of a run
+type Interval struct {
+	Start         time.Time `json:"start"`
+	Sent          int       `json:"sent"`
+	Received      int       `json:"received"`
+	SendErrors    int       `json:"send_errors"`
+	ReceiveErrors int       `json:"receive_errors"`
+}
+
+// Latency is the distribution of end-to-end latencies, in milliseconds
+type Latency struct {
+	P50MS float64 `json:"p50_ms"`
+	P90MS float64 `json:"p90_ms"`
+	P99MS float64 `json:"p99_ms"`
+	MaxMS float64 `json:"max_ms"`
+}
+
+// LatencyOf summarizes latencies with nearest-rank percentiles, as
+// fanout.Percentile computes them
+func LatencyOf(latencies []time.Duration) Latency {
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	return Latency{
+		P50MS: milliseconds(fanout.Percentile(sorted, 50)),
+		P90MS: milliseconds(fanout.Percentile(sorted, 90)),
+		P99MS: milliseconds(fanout.Percentile(sorted, 99)),
+		MaxMS: milliseconds(fanout.Percentile(sorted, 100)),
+	}
+}
+
+// Report is what a run measured
+type Report struct {
+	Backend     string `json:"backend"`
+	Producers   int    `json:"producers"`
+	Consumers   int    `json:"consumers"`
+	MessageSize int    `json:"message_size"`
+
+	// Seconds is how long the run took, draining included
+	Seconds float64 `json:"seconds"`
+
+	Sent          int `json:"sent"`
+	Received      int `json:"received"`
+	SendErrors    int `json:"send_errors"`
+	ReceiveErrors int `json:"receive_errors"`
+
+	// Lost are the sent messages that never arrived
+	Lost int `json:"lost"`
+
+	// Foreign are the messages received that the run did not send
+	Foreign int `json:"foreign"`
+
+	// SendRate is messages sent per second while the producers ran, and
+	// ReceiveRate messages received per second until the last arrived
+	SendRate    float64 `json:"send_rate"`
+	ReceiveRate float64 `json:"receive_rate"`
+
+	Latency   Latency    `json:"latency"`
+	Intervals []Interval `json:"intervals"`
+}
+
+// Report totals what r recorded, for a run that ended at end. Intervals
+// stop at the last one anything was recorded in.
+func (r *Recorder) Report(end time.Time) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := Report{
+		Seconds:   end.Sub(r.start).Seconds(),
+		Received:  len(r.latencies),
+		Foreign:   r.foreign,
+		Latency:   LatencyOf(r.latencies),
+		Intervals: slices.Clone(r.intervals),
+	}
+	for _, i := range r.intervals {
+		report.Sent += i.Sent
+		report.SendErrors += i.SendErrors
+		report.ReceiveErrors += i.ReceiveErrors
+	}
+	report.Lost = max(report.Sent-report.Received, 0)
+	if !r.last.IsZero() {
+		report.ReceiveRate = rate(report.Received, r.last.Sub(r.start))
+	}
+	return report
+}
+
+// rate returns n per second over d, or 0 when d is not positive
+func rate(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// WriteJSON writes report as indented JSON
+func WriteJSON(w io.Writer, report Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("queueload: encoding report: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteTable writes report for a terminal: the totals, the latency
+// percentiles, then one row per interval
+func WriteTable(w io.Writer, report Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s: %d producers, %d consumers, %d byte messages, %.1fs\n\n",
+		report.Backend, report.Producers, report.Consumers, report.MessageSize, report.Seconds)
+	fmt.Fprintln(tw, "SENT\tRECEIVED\tLOST\tFOREIGN\tSEND ERRORS\tRECEIVE ERRORS\tSEND/S\tRECEIVE/S")
+	fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%d\t%.1f\t%.1f\n\n",
+		report.Sent, report.Received, report.Lost, report.Foreign,
+		report.SendErrors, report.ReceiveErrors, report.SendRate, report.ReceiveRate)
+	fmt.Fprintln(tw, "LATENCY\tP50\tP90\tP99\tMAX")
+	fmt.Fprintf(tw, "ms\t%.1f\t%.1f\t%.1f\t%.1f\n\n",
+		report.Latency.P50MS, report.Latency.P90MS, report.Latency.P99MS, report.Latency.MaxMS)
+	fmt.Fprintln(tw, "INTERVAL\tSENT\tRECEIVED\tSEND ERRORS\tRECEIVE ERRORS")
+	for _, i := range report.Intervals {
+		offset := i.Start.Sub(report.Intervals[0].Start)
+		fmt.Fprintf(tw, "+%s\t%d\t%d\t%d\t%d\n", offset, i.Sent, i.Received, i.SendErrors, i.ReceiveErrors)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,136 @@
+package queueload_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"iac/testutil/queueload"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// at is start plus seconds
+func at(seconds float64) time.Time {
+	return start.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+func TestLatencyOf(t *testing.T) {
+	t.Parallel()
+
+	// 1ms to 100ms, shuffled: nearest rank makes the pth percentile p ms
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration((i*37)%100+1) * time.Millisecond
+	}
+
+	assert.Equal(t, queueload.Latency{P50MS: 50, P90MS: 90, P99MS: 99, MaxMS: 100}, queueload.LatencyOf(latencies))
+	assert.Equal(t, 38*time.Millisecond, latencies[1], "LatencyOf should sort a copy, leaving its argument alone")
+}
+
+func TestLatencyOfFew(t *testing.T) {
+	t.Parallel()
+
+	latencies := []time.Duration{30 * time.Millisecond, 10 * time.Millisecond, 1500 * time.Microsecond}
+	assert.Equal(t, queueload.Latency{P50MS: 10, P90MS: 30, P99MS: 30, MaxMS: 30}, queueload.LatencyOf(latencies))
+	assert.Equal(t, queueload.Latency{}, queueload.LatencyOf(nil))
+}
+
+func TestRecorderIntervals(t *testing.T) {
+	t.Parallel()
+
+	r := queueload.NewRecorder(start, time.Second)
+	r.Sent(at(0))
+	r.Sent(at(0.999))
+	r.Sent(at(1))
+	r.SendFailed(at(1.5))
+	r.Received(at(1.2), 200*time.Millisecond)
+	r.ReceiveFailed(at(3.1))
+	r.Received(at(3.4), 2400*time.Millisecond)
+
+	report := r.Report(at(4))
+	assert.Equal(t, []queueload.Interval{
+		{Start: at(0), Sent: 2},
+		{Start: at(1), Sent: 1, Received: 1, SendErrors: 1},
+		{Start: at(2)},
+		{Start: at(3), Received: 1, ReceiveErrors: 1},
+	}, report.Intervals, "An interval with nothing in it should still be reported")
+
+	assert.Equal(t, 3, report.Sent)
+	assert.Equal(t, 2, report.Received)
+	assert.Equal(t, 1, report.Lost)
+	assert.Equal(t, 1, report.SendErrors)
+	assert.Equal(t, 1, report.ReceiveErrors)
+	assert.Equal(t, 4.0, report.Seconds)
+	assert.InDelta(t, 2/3.4, report.ReceiveRate, 1e-9, "The receive rate should run to the last arrival")
+	assert.Equal(t, queueload.Latency{P50MS: 200, P90MS: 2400, P99MS: 2400, MaxMS: 2400}, report.Latency)
+}
+
+func TestRecorderBeforeStart(t *testing.T) {
+	t.Parallel()
+
+	r := queueload.NewRecorder(start, time.Second)
+	r.Sent(start.Add(-time.Millisecond))
+
+	report := r.Report(at(1))
+	assert.Equal(t, []queueload.Interval{{Start: at(0), Sent: 1}}, report.Intervals)
+}
+
+func TestRecorderPending(t *testing.T) {
+	t.Parallel()
+
+	r := queueload.NewRecorder(start, time.Second)
+	r.Sent(at(0))
+	r.Sent(at(0.5))
+	r.Foreign()
+	r.Received(at(0.6), 600*time.Millisecond)
+	assert.Equal(t, 1, r.Pending(), "A foreign message should not count as one of the run's")
+
+	report := r.Report(at(1))
+	assert.Equal(t, 1, report.Foreign)
+	assert.Equal(t, 1, report.Received)
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	r := queueload.NewRecorder(start, time.Second)
+	r.Sent(at(0))
+	r.Received(at(0.5), 25*time.Millisecond)
+	report := r.Report(at(1))
+	report.Backend = "sqs"
+
+	var buf bytes.Buffer
+	require.NoError(t, queueload.WriteJSON(&buf, report))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "sqs", decoded["backend"])
+	assert.Equal(t, 1.0, decoded["sent"])
+	assert.Equal(t, 25.0, decoded["latency"].(map[string]any)["p99_ms"])
+	assert.Len(t, decoded["intervals"], 1)
+}
+
+func TestWriteTable(t *testing.T) {
+	t.Parallel()
+
+	r := queueload.NewRecorder(start, 500*time.Millisecond)
+	r.Sent(at(0))
+	r.Received(at(0.7), 700*time.Millisecond)
+	report := r.Report(at(1))
+	report.Backend, report.Producers, report.Consumers, report.MessageSize = "servicebus", 2, 3, 512
+
+	var buf bytes.Buffer
+	require.NoError(t, queueload.WriteTable(&buf, report))
+	out := buf.String()
+
+	assert.Contains(t, out, "servicebus: 2 producers, 3 consumers, 512 byte messages, 1.0s")
+	assert.Contains(t, out, "P50")
+	assert.Regexp(t, `ms\s+700\.0\s+700\.0\s+700\.0\s+700\.0`, out)
+	assert.Regexp(t, `\+0s\s+1\s+0\s+0\s+0`, out)
+	assert.Regexp(t, `\+500ms\s+0\s+1\s+0\s+0`, out)
+}
@@ -0,0 +1,114 @@
+package seccheck
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Ignore is one reviewed exception to the security scan
+type Ignore struct {
+	// Rule is the finding's rule ID, e.g. AVD-AWS-0086
+	Rule string `yaml:"rule"`
+
+	// Module and Resource narrow the exception; both accept path.Match
+	// patterns and match everything when empty
+	Module   string `yaml:"module"`
+	Resource string `yaml:"resource"`
+
+	// Reason records why the finding is accepted and is required
+	Reason string `yaml:"reason"`
+
+	// Expires, as YYYY-MM-DD, makes the exception lapse after that day
+	Expires string `yaml:"expires"`
+}
+
+// Ignores is the parsed suppression file
+type Ignores struct {
+	Entries []Ignore `yaml:"ignores"`
+}
+
+// LoadIgnores reads a suppression file. A missing file yields no exceptions.
+func LoadIgnores(file string) (*Ignores, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Ignores{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("seccheck: reading %s: %w", file, err)
+	}
+
+	ignores, err := ParseIgnores(data)
+	if err != nil {
+		return nil, fmt.Errorf("seccheck: %s: %w", file, err)
+	}
+	return ignores, nil
+}
+
+// ParseIgnores decodes and validates suppression YAML
+func ParseIgnores(data []byte) (*Ignores, error) {
+	var ignores Ignores
+	if err := yaml.Unmarshal(data, &ignores); err != nil {
+		return nil, fmt.Errorf("decoding YAML: %w", err)
+	}
+
+	var problems []string
+	for i, e := range ignores.Entries {
+		if e.Rule == "" {
+			problems = append(problems, fmt.Sprintf("entry %d: rule is required", i+1))
+		}
+		if strings.TrimSpace(e.Reason) == "" {
+			problems = append(problems, fmt.Sprintf("entry %d (%s): reason is required", i+1, e.Rule))
+		}
+		for _, pattern := range []string{e.Module, e.Resource} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				problems = append(problems, fmt.Sprintf("entry %d (%s): bad pattern %q", i+1, e.Rule, pattern))
+			}
+		}
+		if e.Expires != "" {
+			if _, err := time.Parse("2006-01-02", e.Expires); err != nil {
+				problems = append(problems, fmt.Sprintf("entry %d (%s): expires %q is not YYYY-MM-DD", i+1, e.Rule, e.Expires))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid suppressions:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return &ignores, nil
+}
+
+// Suppresses reports whether an unexpired entry covers the finding in module
+func (ig *Ignores) Suppresses(module string, f Finding) bool {
+	if ig == nil {
+		return false
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+
+	for _, e := range ig.Entries {
+		if !strings.EqualFold(e.Rule, f.RuleID) {
+			continue
+		}
+		if e.Expires != "" && today > e.Expires {
+			continue
+		}
+		if !matches(e.Module, module) || !matches(e.Resource, f.Resource) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func matches(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
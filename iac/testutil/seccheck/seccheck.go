@@ -0,0 +1,319 @@
+// Package seccheck runs a static security scanner (trivy config, or tfsec
+// when trivy is not installed) on Terraform modules and reports each
+// misconfiguration as its own test failure, with the rule, resource and
+// resolution, instead of one block of CLI output.
+//
+// Findings below the minimum severity (SECCHECK_MIN_SEVERITY, default HIGH)
+// are ignored. Reviewed exceptions live in one suppression file,
+// iac/.tfsec-ignore.yaml, read by this package rather than by the scanner so
+// both scanners honor the same list.
+package seccheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// EnvMinSeverity sets the lowest severity reported, e.g. MEDIUM
+const EnvMinSeverity = "SECCHECK_MIN_SEVERITY"
+
+// DefaultMinSeverity is used when EnvMinSeverity is unset
+const DefaultMinSeverity = SeverityHigh
+
+// Severity of a finding, ordered from least to most severe
+type Severity int
+
+// Severities as named by trivy and tfsec
+const (
+	SeverityUnknown Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return severityNames[0]
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity converts a severity name, case-insensitively
+func ParseSeverity(name string) (Severity, error) {
+	for i, n := range severityNames {
+		if strings.EqualFold(strings.TrimSpace(name), n) {
+			return Severity(i), nil
+		}
+	}
+	return SeverityUnknown, fmt.Errorf("seccheck: unknown severity %q (want one of %s)", name, strings.Join(severityNames, ", "))
+}
+
+// MinSeverity reads EnvMinSeverity, falling back to DefaultMinSeverity
+func MinSeverity() (Severity, error) {
+	name := os.Getenv(EnvMinSeverity)
+	if name == "" {
+		return DefaultMinSeverity, nil
+	}
+	return ParseSeverity(name)
+}
+
+// Finding is one misconfiguration reported by the scanner
+type Finding struct {
+	RuleID     string
+	Severity   Severity
+	Title      string
+	Resource   string
+	Resolution string
+	URL        string
+
+	// File is relative to the scanned module
+	File      string
+	StartLine int
+}
+
+func (f Finding) String() string {
+	location := f.File
+	if f.StartLine > 0 {
+		location = fmt.Sprintf("%s:%d", f.File, f.StartLine)
+	}
+	s := fmt.Sprintf("%s %s %s (%s): %s", f.Severity, f.RuleID, f.Resource, location, f.Title)
+	if f.Resolution != "" {
+		s += "\n  resolution: " + f.Resolution
+	}
+	if f.URL != "" {
+		s += "\n  see " + f.URL
+	}
+	return s
+}
+
+// trivyReport is the subset of `trivy config --format json` output used here
+type trivyReport struct {
+	Results []struct {
+		Target            string `json:"Target"`
+		Misconfigurations []struct {
+			ID            string `json:"ID"`
+			AVDID         string `json:"AVDID"`
+			Title         string `json:"Title"`
+			Message       string `json:"Message"`
+			Resolution    string `json:"Resolution"`
+			Severity      string `json:"Severity"`
+			PrimaryURL    string `json:"PrimaryURL"`
+			Status        string `json:"Status"`
+			CauseMetadata struct {
+				Resource  string `json:"Resource"`
+				StartLine int    `json:"StartLine"`
+			} `json:"CauseMetadata"`
+		} `json:"Misconfigurations"`
+	} `json:"Results"`
+}
+
+// ParseTrivy decodes `trivy config --format json` output. Only failed checks
+// are returned.
+func ParseTrivy(data []byte) ([]Finding, error) {
+	var report trivyReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("seccheck: decoding trivy JSON: %w", err)
+	}
+
+	var findings []Finding
+	for _, result := range report.Results {
+		for _, m := range result.Misconfigurations {
+			if m.Status != "" && m.Status != "FAIL" {
+				continue
+			}
+			severity, _ := ParseSeverity(m.Severity)
+			id := m.AVDID
+			if id == "" {
+				id = m.ID
+			}
+			title := m.Message
+			if title == "" {
+				title = m.Title
+			}
+			findings = append(findings, Finding{
+				RuleID:     id,
+				Severity:   severity,
+				Title:      title,
+				Resource:   m.CauseMetadata.Resource,
+				Resolution: m.Resolution,
+				URL:        m.PrimaryURL,
+				File:       filepath.ToSlash(result.Target),
+				StartLine:  m.CauseMetadata.StartLine,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// tfsecReport is the subset of `tfsec --format json` output used here
+type tfsecReport struct {
+	Results []struct {
+		RuleID          string   `json:"rule_id"`
+		LongID          string   `json:"long_id"`
+		RuleDescription string   `json:"rule_description"`
+		Description     string   `json:"description"`
+		Resolution      string   `json:"resolution"`
+		Links           []string `json:"links"`
+		Severity        string   `json:"severity"`
+		Resource        string   `json:"resource"`
+		Location        struct {
+			Filename  string `json:"filename"`
+			StartLine int    `json:"start_line"`
+		} `json:"location"`
+	} `json:"results"`
+}
+
+// ParseTfsec decodes `tfsec --format json` output. Filenames are made
+// relative to dir, the scanned module, when they lie inside it.
+func ParseTfsec(data []byte, dir string) ([]Finding, error) {
+	var report tfsecReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("seccheck: decoding tfsec JSON: %w", err)
+	}
+
+	absDir, _ := filepath.Abs(dir)
+
+	findings := make([]Finding, 0, len(report.Results))
+	for _, r := range report.Results {
+		severity, _ := ParseSeverity(r.Severity)
+
+		file := r.Location.Filename
+		if rel, err := filepath.Rel(absDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+
+		var url string
+		if len(r.Links) > 0 {
+			url = r.Links[0]
+		}
+		title := r.Description
+		if title == "" {
+			title = r.RuleDescription
+		}
+
+		findings = append(findings, Finding{
+			RuleID:     r.RuleID,
+			Severity:   severity,
+			Title:      title,
+			Resource:   r.Resource,
+			Resolution: r.Resolution,
+			URL:        url,
+			File:       filepath.ToSlash(file),
+			StartLine:  r.Location.StartLine,
+		})
+	}
+	return findings, nil
+}
+
+// Scanner runs a security scanner on one module directory
+type Scanner struct {
+	Name string
+	run  func(ctx context.Context, dir string) ([]Finding, error)
+}
+
+// Scan runs the scanner on dir
+func (s Scanner) Scan(ctx context.Context, dir string) ([]Finding, error) {
+	return s.run(ctx, dir)
+}
+
+// FindScanner returns trivy if it is on PATH, otherwise tfsec
+func FindScanner() (Scanner, error) {
+	if _, err := exec.LookPath("trivy"); err == nil {
+		return Scanner{Name: "trivy", run: func(ctx context.Context, dir string) ([]Finding, error) {
+			out, err := runScanner(ctx, "trivy", "config", "--format", "json", "--quiet", "--exit-code", "0", dir)
+			if err != nil {
+				return nil, err
+			}
+			return ParseTrivy(out)
+		}}, nil
+	}
+	if _, err := exec.LookPath("tfsec"); err == nil {
+		return Scanner{Name: "tfsec", run: func(ctx context.Context, dir string) ([]Finding, error) {
+			// --soft-fail keeps the exit code zero when there are findings
+			out, err := runScanner(ctx, "tfsec", dir, "--format", "json", "--soft-fail", "--no-color")
+			if err != nil {
+				return nil, err
+			}
+			return ParseTfsec(out, dir)
+		}}, nil
+	}
+	return Scanner{}, fmt.Errorf("seccheck: neither trivy nor tfsec found on PATH")
+}
+
+func runScanner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("seccheck: %s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// TestingT is the subset of testing.TB the reporting needs; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Report fails t once per finding in module at or above minSeverity that
+// ignores does not suppress. Findings in files outside the module directory
+// (child modules scanned along with it) are left to that module's own scan.
+// It returns the findings that were reported.
+func Report(t TestingT, module string, findings []Finding, ignores *Ignores, minSeverity Severity) []Finding {
+	t.Helper()
+
+	var reported []Finding
+	for _, f := range findings {
+		if f.Severity < minSeverity || strings.Contains(f.File, "/") {
+			continue
+		}
+		if ignores.Suppresses(module, f) {
+			continue
+		}
+		reported = append(reported, f)
+	}
+
+	sort.Slice(reported, func(i, j int) bool {
+		a, b := reported[i], reported[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return a.RuleID < b.RuleID
+	})
+
+	for _, f := range reported {
+		t.Errorf("%s: %s", module, f)
+	}
+	return reported
+}
+
+// AssertModule scans module with scanner and reports its findings on t
+func AssertModule(t testing.TB, scanner Scanner, module string, ignores *Ignores, minSeverity Severity) {
+	t.Helper()
+
+	findings, err := scanner.Scan(context.Background(), module)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	Report(t, filepath.ToSlash(module), findings, ignores, minSeverity)
+}
@@ -0,0 +1,222 @@
+package seccheck_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/seccheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder captures Errorf instead of failing the enclosing test
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return data
+}
+
+func TestParseTrivy(t *testing.T) {
+	t.Parallel()
+
+	findings, err := seccheck.ParseTrivy(readFixture(t, "trivy.json"))
+	require.NoError(t, err)
+
+	// The PASS result is dropped
+	require.Len(t, findings, 3)
+
+	assert.Equal(t, seccheck.Finding{
+		RuleID:     "AVD-AWS-0132",
+		Severity:   seccheck.SeverityHigh,
+		Title:      "Bucket does not encrypt data with a customer managed key.",
+		Resource:   "aws_s3_bucket.this",
+		Resolution: "Enable encryption using customer managed keys",
+		URL:        "https://avd.aquasec.com/misconfig/avd-aws-0132",
+		File:       "main.tf",
+		StartLine:  13,
+	}, findings[1])
+	assert.Equal(t, seccheck.SeverityMedium, findings[0].Severity)
+	assert.Equal(t, "modules/logging/main.tf", findings[2].File)
+}
+
+func TestParseTfsec(t *testing.T) {
+	t.Parallel()
+
+	findings, err := seccheck.ParseTfsec(readFixture(t, "tfsec.json"), "/work/iac/gcp/core/networking")
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+
+	assert.Equal(t, seccheck.Finding{
+		RuleID:     "AVD-GCP-0027",
+		Severity:   seccheck.SeverityCritical,
+		Title:      "Firewall rule allows ingress traffic from multiple addresses on the public internet.",
+		Resource:   "google_compute_firewall.allow_ssh[0]",
+		Resolution: "Set a more restrictive cidr range",
+		URL:        "https://aquasecurity.github.io/tfsec/v1.28.1/checks/google/compute/no-public-ingress/",
+		File:       "main.tf",
+		StartLine:  75,
+	}, findings[0])
+	assert.Equal(t, "", findings[1].URL, "A rule without links has no URL")
+}
+
+func TestParseInvalidJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := seccheck.ParseTrivy([]byte("2024-03-01T12:00:00Z INFO Misconfiguration scanning is enabled"))
+	assert.Error(t, err)
+
+	_, err = seccheck.ParseTfsec([]byte("No problems detected!"), ".")
+	assert.Error(t, err)
+}
+
+func TestParseSeverity(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]seccheck.Severity{
+		"LOW":      seccheck.SeverityLow,
+		"medium":   seccheck.SeverityMedium,
+		" High ":   seccheck.SeverityHigh,
+		"CRITICAL": seccheck.SeverityCritical,
+		"unknown":  seccheck.SeverityUnknown,
+	} {
+		got, err := seccheck.ParseSeverity(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := seccheck.ParseSeverity("SEVERE")
+	assert.Error(t, err)
+}
+
+func TestMinSeverity(t *testing.T) {
+	t.Setenv(seccheck.EnvMinSeverity, "")
+	got, err := seccheck.MinSeverity()
+	require.NoError(t, err)
+	assert.Equal(t, seccheck.SeverityHigh, got, "HIGH and above should be reported by default")
+
+	t.Setenv(seccheck.EnvMinSeverity, "medium")
+	got, err = seccheck.MinSeverity()
+	require.NoError(t, err)
+	assert.Equal(t, seccheck.SeverityMedium, got)
+
+	t.Setenv(seccheck.EnvMinSeverity, "loud")
+	_, err = seccheck.MinSeverity()
+	assert.Error(t, err)
+}
+
+func TestParseIgnores(t *testing.T) {
+	t.Parallel()
+
+	ignores, err := seccheck.ParseIgnores([]byte(`
+ignores:
+  - rule: AVD-AWS-0132
+    module: aws/core/*
+    resource: aws_s3_bucket.this
+    reason: Buckets default to SSE-S3; callers opt into a CMK through kms_key_ref
+    expires: 2999-12-31
+`))
+	require.NoError(t, err)
+	require.Len(t, ignores.Entries, 1)
+	assert.Equal(t, "aws/core/*", ignores.Entries[0].Module)
+
+	_, err = seccheck.ParseIgnores([]byte(`
+ignores:
+  - rule: AVD-AWS-0132
+  - reason: no rule
+  - rule: AVD-AWS-0089
+    reason: bad pattern
+    module: "aws/[core"
+  - rule: AVD-AWS-0086
+    reason: bad date
+    expires: next week
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "entry 1 (AVD-AWS-0132): reason is required")
+	assert.Contains(t, err.Error(), "entry 2: rule is required")
+	assert.Contains(t, err.Error(), `entry 3 (AVD-AWS-0089): bad pattern "aws/[core"`)
+	assert.Contains(t, err.Error(), `entry 4 (AVD-AWS-0086): expires "next week" is not YYYY-MM-DD`)
+}
+
+func TestLoadIgnoresMissingFile(t *testing.T) {
+	t.Parallel()
+
+	ignores, err := seccheck.LoadIgnores(filepath.Join(t.TempDir(), ".tfsec-ignore.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, ignores.Entries)
+}
+
+func TestSuppresses(t *testing.T) {
+	t.Parallel()
+
+	ignores := &seccheck.Ignores{Entries: []seccheck.Ignore{
+		{Rule: "AVD-AWS-0132", Module: "aws/core/*", Resource: "aws_s3_bucket.this", Reason: "r"},
+		{Rule: "AVD-GCP-0027", Module: "gcp/core/networking", Reason: "r"},
+		{Rule: "AVD-AWS-0089", Reason: "r", Expires: "2000-01-01"},
+	}}
+
+	finding := func(rule, resource string) seccheck.Finding {
+		return seccheck.Finding{RuleID: rule, Resource: resource}
+	}
+
+	tests := []struct {
+		name    string
+		module  string
+		finding seccheck.Finding
+		want    bool
+	}{
+		{"rule, module and resource match", "aws/core/storage", finding("AVD-AWS-0132", "aws_s3_bucket.this"), true},
+		{"rule ID is case-insensitive", "aws/core/storage", finding("avd-aws-0132", "aws_s3_bucket.this"), true},
+		{"other module", "zero/core/storage", finding("AVD-AWS-0132", "aws_s3_bucket.this"), false},
+		{"module glob does not cross directories", "aws/core/storage/nested", finding("AVD-AWS-0132", "aws_s3_bucket.this"), false},
+		{"other resource", "aws/core/storage", finding("AVD-AWS-0132", "aws_s3_bucket.logs"), false},
+		{"empty resource matches any", "gcp/core/networking", finding("AVD-GCP-0027", "google_compute_firewall.allow_ssh[0]"), true},
+		{"other rule", "aws/core/storage", finding("AVD-AWS-0086", "aws_s3_bucket.this"), false},
+		{"expired entry", "aws/core/storage", finding("AVD-AWS-0089", "aws_s3_bucket.this"), false},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, ignores.Suppresses(tc.module, tc.finding), tc.name)
+	}
+
+	var none *seccheck.Ignores
+	assert.False(t, none.Suppresses("aws/core/storage", finding("AVD-AWS-0132", "aws_s3_bucket.this")))
+}
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	findings, err := seccheck.ParseTrivy(readFixture(t, "trivy.json"))
+	require.NoError(t, err)
+
+	rec := &recorder{}
+	reported := seccheck.Report(rec, "aws/core/storage", findings, nil, seccheck.SeverityMedium)
+
+	// The LOW finding is below the threshold and in a child module's file
+	require.Len(t, reported, 2)
+	require.Len(t, rec.errors, 2)
+	assert.Equal(t, "AVD-AWS-0132", reported[0].RuleID, "Findings should be reported most severe first")
+	assert.Contains(t, rec.errors[0], "aws/core/storage: HIGH AVD-AWS-0132 aws_s3_bucket.this (main.tf:13)")
+	assert.Contains(t, rec.errors[0], "resolution: Enable encryption using customer managed keys")
+	assert.Contains(t, rec.errors[0], "see https://avd.aquasec.com/misconfig/avd-aws-0132")
+
+	ignores := &seccheck.Ignores{Entries: []seccheck.Ignore{{Rule: "AVD-AWS-0132", Reason: "r"}}}
+	rec = &recorder{}
+	reported = seccheck.Report(rec, "aws/core/storage", findings, ignores, seccheck.SeverityHigh)
+	assert.Empty(t, reported)
+	assert.Empty(t, rec.errors, "Suppressed findings should not fail the test")
+}
@@ -0,0 +1,301 @@
+// Package sensitivecheck audits a Terraform plan for secrets that reach
+// places Terraform prints. It reports two kinds of finding:
+//
+//   - an output whose name says it holds a secret (password, secret, key,
+//     token, credential) but which is not marked sensitive
+//   - the value of a sensitive input variable appearing verbatim in a
+//     non-sensitive output or resource attribute, e.g. a connection string
+//     built from master_password after the mark was dropped
+//
+// The name rules live in one place, DefaultPatterns:
+//
+//	planJSON := terraform.InitAndPlanAndShow(t, options)
+//	sensitivecheck.Check(t, []byte(planJSON), sensitivecheck.DefaultPatterns)
+package sensitivecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Patterns decide which names hold secrets and which values are worth
+// searching for
+type Patterns struct {
+	// Words mark a name as sensitive when one of its words, or the word
+	// with an s, matches. Words are split at underscores, dashes, dots and
+	// lower-to-upper case changes, so keyspace and monkey do not match key.
+	Words []string
+
+	// IdentifierSuffixes mark a name as a reference to a secret rather
+	// than the secret itself when its last word is one of them, e.g.
+	// secret_arn or kms_key_ref
+	IdentifierSuffixes []string
+
+	// Allow lists output names reviewed as not secret despite their words
+	Allow []string
+
+	// MinSecretLength is the shortest sensitive value searched for; shorter
+	// values match too much by chance
+	MinSecretLength int
+}
+
+// DefaultPatterns is used by the facade audit and the secrets facade tests
+var DefaultPatterns = Patterns{
+	Words:              []string{"password", "secret", "key", "token", "credential"},
+	IdentifierSuffixes: []string{"arn", "id", "name", "path", "ref", "type", "uri", "url", "version"},
+	Allow: []string{
+		// TXT record values published in DNS to prove domain ownership
+		"custom_domain_validation_tokens",
+	},
+	MinSecretLength: 6,
+}
+
+// SensitiveName reports whether an output called name should be marked
+// sensitive
+func (p Patterns) SensitiveName(name string) bool {
+	for _, allowed := range p.Allow {
+		if name == allowed {
+			return false
+		}
+	}
+
+	words := splitWords(name)
+	if len(words) == 0 {
+		return false
+	}
+	last := words[len(words)-1]
+	for _, suffix := range p.IdentifierSuffixes {
+		if last == suffix || last == suffix+"s" {
+			return false
+		}
+	}
+
+	for _, word := range words {
+		for _, pattern := range p.Words {
+			if word == pattern || word == pattern+"s" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitWords lower-cases name and splits it into words
+func splitWords(name string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// Kind of finding
+type Kind string
+
+// Kinds of finding
+const (
+	// UnmarkedOutput is an output named like a secret but not sensitive
+	UnmarkedOutput Kind = "unmarked output"
+	// LeakedValue is a sensitive input found in a non-sensitive value
+	LeakedValue Kind = "leaked value"
+)
+
+// Finding is one place a secret may be printed
+type Finding struct {
+	Kind Kind
+
+	// Address is output.<name> or the resource instance address
+	Address string
+
+	// Path is the dotted attribute path of a leaked value, empty for the
+	// whole output
+	Path string
+
+	// Variable is the sensitive input variable whose value leaked
+	Variable string
+}
+
+func (f Finding) String() string {
+	location := f.Address
+	if f.Path != "" {
+		location += "." + f.Path
+	}
+	if f.Kind == UnmarkedOutput {
+		return fmt.Sprintf("%s: name suggests a secret but the output is not marked sensitive", location)
+	}
+	return fmt.Sprintf("%s: contains the value of sensitive variable %s but is not marked sensitive", location, f.Variable)
+}
+
+// plan is the part of `terraform show -json` the audit reads
+type plan struct {
+	Variables map[string]struct {
+		Value interface{} `json:"value"`
+	} `json:"variables"`
+	OutputChanges   map[string]change `json:"output_changes"`
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  change `json:"change"`
+	} `json:"resource_changes"`
+	Configuration struct {
+		RootModule struct {
+			Variables map[string]struct {
+				Sensitive bool `json:"sensitive"`
+			} `json:"variables"`
+		} `json:"root_module"`
+	} `json:"configuration"`
+}
+
+// change holds the planned value and its sensitivity mask: true for a
+// sensitive value, or an object or array of masks mirroring the value
+type change struct {
+	After          interface{} `json:"after"`
+	AfterSensitive interface{} `json:"after_sensitive"`
+}
+
+// Scan decodes planJSON and returns its findings sorted by address and path
+func Scan(planJSON []byte, patterns Patterns) ([]Finding, error) {
+	var p plan
+	if err := json.Unmarshal(planJSON, &p); err != nil {
+		return nil, fmt.Errorf("sensitivecheck: decoding plan JSON: %w", err)
+	}
+
+	var findings []Finding
+	for name, output := range p.OutputChanges {
+		if patterns.SensitiveName(name) && output.AfterSensitive != true {
+			findings = append(findings, Finding{Kind: UnmarkedOutput, Address: "output." + name})
+		}
+	}
+
+	secrets := sensitiveValues(p, patterns.MinSecretLength)
+	for name, output := range p.OutputChanges {
+		findings = append(findings, leaks(secrets, "output."+name, output)...)
+	}
+	for _, resource := range p.ResourceChanges {
+		findings = append(findings, leaks(secrets, resource.Address, resource.Change)...)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Variable < b.Variable
+	})
+	return findings, nil
+}
+
+// sensitiveValues maps every string in a sensitive root variable that is
+// at least minLength long to the variable's name
+func sensitiveValues(p plan, minLength int) map[string]string {
+	secrets := map[string]string{}
+	for name, variable := range p.Configuration.RootModule.Variables {
+		if !variable.Sensitive {
+			continue
+		}
+		walk(p.Variables[name].Value, nil, "", func(_ string, value string) {
+			if len(value) >= minLength {
+				secrets[value] = name
+			}
+		})
+	}
+	return secrets
+}
+
+// leaks returns a finding for every non-sensitive string in c that
+// contains one of secrets
+func leaks(secrets map[string]string, address string, c change) []Finding {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	var findings []Finding
+	walk(c.After, c.AfterSensitive, "", func(path, value string) {
+		for secret, variable := range secrets {
+			if strings.Contains(value, secret) {
+				findings = append(findings, Finding{Kind: LeakedValue, Address: address, Path: path, Variable: variable})
+			}
+		}
+	})
+	return findings
+}
+
+// walk calls visit with the path of every string in value that mask does
+// not mark sensitive
+func walk(value, mask interface{}, path string, visit func(path, value string)) {
+	if mask == true {
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		visit(path, v)
+	case map[string]interface{}:
+		masks, _ := mask.(map[string]interface{})
+		for key, item := range v {
+			walk(item, masks[key], join(path, key), visit)
+		}
+	case []interface{}:
+		masks, _ := mask.([]interface{})
+		for i, item := range v {
+			var itemMask interface{}
+			if i < len(masks) {
+				itemMask = masks[i]
+			}
+			walk(item, itemMask, join(path, strconv.Itoa(i)), visit)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// TestingT is the subset of testing.T Check uses
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Check fails t once per finding in planJSON and reports whether there
+// were none
+func Check(t TestingT, planJSON []byte, patterns Patterns) bool {
+	t.Helper()
+
+	findings, err := Scan(planJSON, patterns)
+	if err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	for _, f := range findings {
+		t.Errorf("%s", f)
+	}
+	return len(findings) == 0
+}
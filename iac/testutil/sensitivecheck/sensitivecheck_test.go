@@ -0,0 +1,129 @@
+package sensitivecheck_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"iac/testutil/sensitivecheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a sensitivecheck.TestingT that keeps its failures
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func readPlan(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/plan.json")
+	require.NoError(t, err)
+	return data
+}
+
+func TestSensitiveName(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"password":          true,
+		"master_password":   true,
+		"access_key_secret": true,
+		"api_token":         true,
+		"credentials_json":  true,
+		"primaryKey":        true,
+		"ssh_keys":          true,
+		// Lookalikes that only contain a word
+		"keyspace":     false,
+		"monkey":       false,
+		"tokenizer":    false,
+		"passwordless": false,
+		// References to a secret rather than the secret
+		"secret_arn":    false,
+		"secret_id":     false,
+		"key_id":        false,
+		"kms_key_ref":   false,
+		"access_key_id": false,
+		"keyVaultID":    false,
+		"token_url":     false,
+		// Reviewed in DefaultPatterns.Allow
+		"custom_domain_validation_tokens": false,
+		"instance_id":                     false,
+		"":                                false,
+	}
+
+	for name, want := range tests {
+		assert.Equal(t, want, sensitivecheck.DefaultPatterns.SensitiveName(name), "SensitiveName(%q)", name)
+	}
+}
+
+func TestSensitiveNameCustomPatterns(t *testing.T) {
+	t.Parallel()
+
+	patterns := sensitivecheck.Patterns{Words: []string{"connection"}}
+
+	assert.True(t, patterns.SensitiveName("primary_connection_string"))
+	assert.False(t, patterns.SensitiveName("db_password"), "Only the configured words match")
+}
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+
+	findings, err := sensitivecheck.Scan(readPlan(t), sensitivecheck.DefaultPatterns)
+	require.NoError(t, err)
+
+	assert.Equal(t, []sensitivecheck.Finding{
+		{Kind: sensitivecheck.LeakedValue, Address: "module.aws_database[0].aws_db_instance.this", Path: "tags.Note", Variable: "api_settings"},
+		{Kind: sensitivecheck.LeakedValue, Address: "output.connection_string", Variable: "master_password"},
+		{Kind: sensitivecheck.UnmarkedOutput, Address: "output.db_password"},
+		{Kind: sensitivecheck.LeakedValue, Address: "output.db_password", Variable: "master_password"},
+	}, findings, "Sensitive attributes, short values and non-sensitive variables should be left alone")
+}
+
+func TestScanMinSecretLength(t *testing.T) {
+	t.Parallel()
+
+	patterns := sensitivecheck.DefaultPatterns
+	patterns.MinSecretLength = 4
+
+	findings, err := sensitivecheck.Scan(readPlan(t), patterns)
+	require.NoError(t, err)
+
+	assert.Contains(t, findings, sensitivecheck.Finding{
+		Kind:     sensitivecheck.LeakedValue,
+		Address:  "module.aws_database[0].aws_db_instance.this",
+		Path:     "pin_code",
+		Variable: "pin",
+	})
+}
+
+func TestScanInvalidJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := sensitivecheck.Scan([]byte("not json"), sensitivecheck.DefaultPatterns)
+	assert.ErrorContains(t, err, "sensitivecheck: decoding plan JSON")
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	assert.False(t, sensitivecheck.Check(r, readPlan(t), sensitivecheck.DefaultPatterns))
+	assert.Equal(t, []string{
+		"module.aws_database[0].aws_db_instance.this.tags.Note: contains the value of sensitive variable api_settings but is not marked sensitive",
+		"output.connection_string: contains the value of sensitive variable master_password but is not marked sensitive",
+		"output.db_password: name suggests a secret but the output is not marked sensitive",
+		"output.db_password: contains the value of sensitive variable master_password but is not marked sensitive",
+	}, r.errors)
+
+	clean := &recorder{}
+	assert.True(t, sensitivecheck.Check(clean, []byte(`{"output_changes": {"keyspace": {"after": "orders", "after_sensitive": false}}}`), sensitivecheck.DefaultPatterns))
+	assert.Empty(t, clean.errors)
+}
@@ -0,0 +1,269 @@
+// Package smoke checks that the emulator services the integration tests
+// depend on answer, with one cheap list call per service, so a broken
+// service shows up in seconds rather than after a failed terraform apply:
+//
+//	results := smoke.Run(ctx, cfg, smoke.Services, smoke.DefaultTimeout)
+//	smoke.WriteTable(os.Stdout, results)
+//
+// tools/smoketest runs the checks from the command line. The integration
+// test helpers pass Probe to integration.Require, so a skip names the
+// services that are down rather than just the emulator.
+package smoke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"iac/azure/azurehelpers"
+	"iac/gcp/gcphelpers"
+	"iac/testutil/config"
+	"iac/zero/zeroclient"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// DefaultTimeout bounds each service's check
+const DefaultTimeout = 5 * time.Second
+
+// GCPProject is the project the Cloud Storage check lists buckets in
+const GCPProject = "local-test"
+
+// Service is one emulator service and how to check it
+type Service struct {
+	// Name identifies the service, e.g. "s3"
+	Name string
+	// Provider is the provider_name whose emulator serves it
+	Provider string
+	// Check returns nil when the service answers
+	Check func(ctx context.Context, cfg *config.TestConfig) error
+}
+
+// Services are the services the integration tests depend on
+var Services = []Service{
+	{Name: "s3", Provider: "aws", Check: checkS3},
+	{Name: "dynamodb", Provider: "aws", Check: checkDynamoDB},
+	{Name: "sqs", Provider: "aws", Check: checkSQS},
+	{Name: "sns", Provider: "aws", Check: checkSNS},
+	{Name: "lambda", Provider: "aws", Check: checkLambda},
+	{Name: "blob", Provider: "azure", Check: checkBlob},
+	{Name: "gcs", Provider: "gcp", Check: checkGCS},
+	{Name: "zero-store", Provider: "zero", Check: checkZeroStore},
+}
+
+// Lookup returns the named Services, in the order given
+func Lookup(names ...string) ([]Service, error) {
+	services := make([]Service, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(Services, func(s Service) bool { return s.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("smoke: unknown service %q, want one of: %s", name, strings.Join(Names(), ", "))
+		}
+		services = append(services, Services[i])
+	}
+	return services, nil
+}
+
+// Names returns the names of Services
+func Names() []string {
+	names := make([]string, len(Services))
+	for i, s := range Services {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// ForProvider returns the Services of one provider's emulator
+func ForProvider(provider string) []Service {
+	var services []Service
+	for _, s := range Services {
+		if s.Provider == provider {
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
+// Result is the outcome of one service's check
+type Result struct {
+	Service Service
+	Latency time.Duration
+	Err     error
+}
+
+// OK reports whether the service answered
+func (r Result) OK() bool { return r.Err == nil }
+
+// Run checks services concurrently, each within timeout, and returns their
+// results in the order of services
+func Run(ctx context.Context, cfg *config.TestConfig, services []Service, timeout time.Duration) []Result {
+	results := make([]Result, len(services))
+
+	var wg sync.WaitGroup
+	for i, s := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check(ctx, cfg, s, timeout)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func check(ctx context.Context, cfg *config.TestConfig, s Service, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := s.Check(ctx, cfg)
+	if err == nil && ctx.Err() != nil {
+		// A check that ignores its context must not pass late
+		err = ctx.Err()
+	}
+	return Result{Service: s, Latency: time.Since(start), Err: err}
+}
+
+// Failed returns the results whose service did not answer
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if !r.OK() {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Probe returns an integration.Probe that checks services within the
+// probe's context and names every one that is down
+func Probe(cfg *config.TestConfig, services ...Service) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		// The context's deadline is the budget; Run needs a positive timeout
+		timeout := DefaultTimeout
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+
+		var errs []string
+		for _, r := range Failed(Run(ctx, cfg, services, timeout)) {
+			errs = append(errs, fmt.Sprintf("%s: %v", r.Service.Name, r.Err))
+		}
+		if len(errs) > 0 {
+			return errors.New(strings.Join(errs, "; "))
+		}
+		return nil
+	}
+}
+
+// WriteTable writes one row per result: service, provider, OK or FAIL,
+// latency and the error
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tPROVIDER\tSTATUS\tLATENCY\tERROR")
+	for _, r := range results {
+		status, detail := "OK", ""
+		if !r.OK() {
+			status, detail = "FAIL", r.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Service.Name, r.Service.Provider, status, r.Latency.Round(time.Millisecond), detail)
+	}
+	return tw.Flush()
+}
+
+// awsSession connects to the configured CloudEmu endpoint with the
+// credentials the AWS integration tests use
+func awsSession(cfg *config.TestConfig) (*session.Session, error) {
+	awsConfig, err := cfg.AWSConfig(cfg.CloudEmuEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	return session.NewSession(awsConfig.WithMaxRetries(0))
+}
+
+func checkS3(ctx context.Context, cfg *config.TestConfig) error {
+	sess, err := awsSession(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = s3.New(sess).ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
+	return err
+}
+
+func checkDynamoDB(ctx context.Context, cfg *config.TestConfig) error {
+	sess, err := awsSession(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = dynamodb.New(sess).ListTablesWithContext(ctx, &dynamodb.ListTablesInput{Limit: aws.Int64(1)})
+	return err
+}
+
+func checkSQS(ctx context.Context, cfg *config.TestConfig) error {
+	sess, err := awsSession(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = sqs.New(sess).ListQueuesWithContext(ctx, &sqs.ListQueuesInput{})
+	return err
+}
+
+func checkSNS(ctx context.Context, cfg *config.TestConfig) error {
+	sess, err := awsSession(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = sns.New(sess).ListTopicsWithContext(ctx, &sns.ListTopicsInput{})
+	return err
+}
+
+func checkLambda(ctx context.Context, cfg *config.TestConfig) error {
+	sess, err := awsSession(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = lambda.New(sess).ListFunctionsWithContext(ctx, &lambda.ListFunctionsInput{MaxItems: aws.Int64(1)})
+	return err
+}
+
+func checkBlob(ctx context.Context, cfg *config.TestConfig) error {
+	helpers, err := azurehelpers.NewFromTestConfig(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = helpers.ListContainers(ctx)
+	return err
+}
+
+func checkGCS(ctx context.Context, cfg *config.TestConfig) error {
+	helpers, err := gcphelpers.NewFromTestConfig(ctx, cfg, GCPProject)
+	if err != nil {
+		return err
+	}
+	defer helpers.Close()
+
+	_, err = helpers.ListBuckets(ctx)
+	return err
+}
+
+// checkZeroStore lists ZeroStore buckets under /v1/store; a store with no
+// bucket route yet still proves ZeroCloud is up
+func checkZeroStore(ctx context.Context, cfg *config.TestConfig) error {
+	_, err := zeroclient.New(cfg.ZeroEndpoint).ListBuckets(ctx)
+	if err != nil && !zeroclient.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package smoke_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"iac/testutil/config"
+	"iac/testutil/smoke"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fake(name string, check func(ctx context.Context) error) smoke.Service {
+	return smoke.Service{
+		Name:     name,
+		Provider: "aws",
+		Check:    func(ctx context.Context, _ *config.TestConfig) error { return check(ctx) },
+	}
+}
+
+var (
+	up      = func(context.Context) error { return nil }
+	refused = func(context.Context) error { return errors.New("connection refused") }
+	// hangs until its check times out
+	hangs = func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	services := []smoke.Service{fake("s3", up), fake("sqs", refused), fake("sns", hangs)}
+
+	start := time.Now()
+	results := smoke.Run(context.Background(), config.Default(), services, 50*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second, "A hung service should only cost its own timeout")
+
+	require.Len(t, results, 3)
+	assert.Equal(t, "s3", results[0].Service.Name, "Results should keep the order of services")
+	assert.True(t, results[0].OK())
+	assert.EqualError(t, results[1].Err, "connection refused")
+	assert.ErrorIs(t, results[2].Err, context.DeadlineExceeded)
+
+	failed := smoke.Failed(results)
+	require.Len(t, failed, 2)
+	assert.Equal(t, "sqs", failed[0].Service.Name)
+	assert.Equal(t, "sns", failed[1].Service.Name)
+}
+
+func TestProbeNamesFailedServices(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	probe := smoke.Probe(config.Default(), fake("s3", up), fake("dynamodb", refused), fake("lambda", refused))
+	err := probe(ctx)
+	require.Error(t, err)
+	assert.Equal(t, "dynamodb: connection refused; lambda: connection refused", err.Error())
+
+	assert.NoError(t, smoke.Probe(config.Default(), fake("s3", up))(ctx))
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	services, err := smoke.Lookup("sqs", "s3")
+	require.NoError(t, err)
+	require.Len(t, services, 2)
+	assert.Equal(t, "sqs", services[0].Name)
+	assert.Equal(t, "aws", services[1].Provider)
+
+	_, err = smoke.Lookup("s3", "kinesis")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown service "kinesis"`)
+	assert.Contains(t, err.Error(), "zero-store")
+
+	for _, provider := range []string{"aws", "azure", "gcp", "zero"} {
+		assert.NotEmpty(t, smoke.ForProvider(provider), "Every provider should have a service to check")
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	t.Parallel()
+
+	results := smoke.Run(context.Background(), config.Default(),
+		[]smoke.Service{fake("s3", up), fake("sqs", refused)}, time.Second)
+
+	var buf bytes.Buffer
+	require.NoError(t, smoke.WriteTable(&buf, results))
+
+	assert.Regexp(t, `SERVICE\s+PROVIDER\s+STATUS\s+LATENCY\s+ERROR`, buf.String())
+	assert.Regexp(t, `s3\s+aws\s+OK\s+\d+s?`, buf.String())
+	assert.Regexp(t, `sqs\s+aws\s+FAIL\s+\S+\s+connection refused`, buf.String())
+}
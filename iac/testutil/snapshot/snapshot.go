@@ -0,0 +1,278 @@
+// Package snapshot compares normalized Terraform plans against golden files,
+// catching regressions in parts of a plan no attribute-level assertion
+// covers.
+//
+// Plans are reduced to the sections that describe what would change
+// (variables, planned_values, resource_changes, output_changes), unknown and
+// sensitive values are replaced with fixed placeholders, volatile strings
+// are masked and keys are sorted, so the same configuration produces the
+// same snapshot on every machine. Set UPDATE_SNAPSHOTS=1 to rewrite the
+// golden files instead of comparing against them.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// EnvUpdate regenerates golden files when set to "1"
+const EnvUpdate = "UPDATE_SNAPSHOTS"
+
+// Placeholders written in place of values that differ between runs
+const (
+	Unknown   = "(known after apply)"
+	Sensitive = "(sensitive)"
+)
+
+// planSections are the top-level plan keys kept in a snapshot. The rest
+// (timestamp, terraform_version, prior_state, configuration, checks ...)
+// varies with the Terraform version or the machine rather than the module.
+var planSections = []string{"variables", "planned_values", "resource_changes", "output_changes"}
+
+// Mask replaces every match of Pattern in a string value with Replacement
+type Mask struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// DefaultMasks cover RFC 3339 timestamps and random hex suffixes such as
+// the ones random_id appends to resource names
+var DefaultMasks = []Mask{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`), "<timestamp>"},
+	{regexp.MustCompile(`-[0-9a-f]{8,}\b`), "-<random>"},
+}
+
+// TestingT is the subset of testing.TB the package needs; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+	Logf(format string, args ...interface{})
+}
+
+// AssertPlan plans options, normalizes the JSON plan with DefaultMasks and
+// compares it against testdata/<name>.golden.json in options.TerraformDir.
+// options.PlanFilePath is set to a temporary file if empty. A snapshot
+// whose golden file has not been recorded yet is skipped rather than
+// failed, so adding a provider to a snapshot test does not break the build
+// before someone with Terraform runs it with UPDATE_SNAPSHOTS=1.
+func AssertPlan(t testing.TB, options *terraform.Options, name string) {
+	t.Helper()
+
+	golden := filepath.Join(options.TerraformDir, "testdata", name+".golden.json")
+	if _, err := os.Stat(golden); os.IsNotExist(err) && os.Getenv(EnvUpdate) != "1" {
+		t.Skipf("snapshot: golden file %s has not been recorded; run with %s=1 to create it", golden, EnvUpdate)
+	}
+
+	if options.PlanFilePath == "" {
+		options.PlanFilePath = filepath.Join(t.TempDir(), "plan.out")
+	}
+
+	planJSON := terraform.InitAndPlanAndShow(t, options)
+
+	normalized, err := Normalize([]byte(planJSON), DefaultMasks...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	Match(t, golden, normalized)
+}
+
+// Match compares got against the golden file at path, failing t with a
+// unified diff on mismatch. With UPDATE_SNAPSHOTS=1 the file is rewritten.
+func Match(t TestingT, path string, got []byte) {
+	t.Helper()
+
+	if os.Getenv(EnvUpdate) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("snapshot: creating %s: %v", filepath.Dir(path), err)
+			return
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("snapshot: writing %s: %v", path, err)
+			return
+		}
+		t.Logf("snapshot: updated %s", path)
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("snapshot: golden file %s does not exist; run with %s=1 to create it", path, EnvUpdate)
+		return
+	}
+	if err != nil {
+		t.Fatalf("snapshot: reading %s: %v", path, err)
+		return
+	}
+
+	// Golden files may be checked out with CRLF line endings
+	want = bytes.ReplaceAll(want, []byte("\r\n"), []byte("\n"))
+	if bytes.Equal(want, got) {
+		return
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(want)),
+		B:        difflib.SplitLines(string(got)),
+		FromFile: path,
+		ToFile:   "plan",
+		Context:  3,
+	})
+	if err != nil {
+		t.Fatalf("snapshot: diffing against %s: %v", path, err)
+		return
+	}
+	t.Fatalf("snapshot: plan does not match %s (run with %s=1 to accept):\n%s", path, EnvUpdate, diff)
+}
+
+// Normalize reduces a `terraform show -json` plan to a stable, indented
+// form. OpenTofu's provider addresses are rewritten to Terraform's, so both
+// CLIs match the same golden file.
+func Normalize(planJSON []byte, masks ...Mask) ([]byte, error) {
+	var plan map[string]interface{}
+	if err := json.Unmarshal(tfopts.NormalizeProviderAddresses(planJSON), &plan); err != nil {
+		return nil, fmt.Errorf("snapshot: decoding plan JSON: %w", err)
+	}
+
+	kept := make(map[string]interface{}, len(planSections))
+	for _, key := range planSections {
+		if value, ok := plan[key]; ok {
+			kept[key] = value
+		}
+	}
+
+	maskSensitiveVariables(kept["variables"], plan["configuration"])
+
+	if changes, ok := kept["resource_changes"].([]interface{}); ok {
+		for _, rc := range changes {
+			if change, ok := asMap(rc)["change"].(map[string]interface{}); ok {
+				normalizeChange(change)
+			}
+		}
+	}
+	if outputs, ok := kept["output_changes"].(map[string]interface{}); ok {
+		for _, oc := range outputs {
+			if change := asMap(oc); change != nil {
+				normalizeChange(change)
+			}
+		}
+	}
+
+	masked := applyMasks(kept, masks)
+
+	// encoding/json writes map keys sorted, which fixes the key order.
+	// HTML escaping is off so placeholders like <random> stay readable.
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(masked); err != nil {
+		return nil, fmt.Errorf("snapshot: encoding normalized plan: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// normalizeChange folds after_unknown and the *_sensitive markers into the
+// before/after values as placeholders, then drops the markers
+func normalizeChange(change map[string]interface{}) {
+	change["after"] = overlay(change["after"], change["after_unknown"], Unknown)
+	change["after"] = overlay(change["after"], change["after_sensitive"], Sensitive)
+	change["before"] = overlay(change["before"], change["before_sensitive"], Sensitive)
+
+	for _, key := range []string{"after_unknown", "after_sensitive", "before_sensitive"} {
+		delete(change, key)
+	}
+}
+
+// overlay replaces every value in v marked true in marks with placeholder.
+// marks mirrors the shape of v: true marks the whole value, nested maps and
+// lists mark individual elements.
+func overlay(v, marks interface{}, placeholder string) interface{} {
+	switch m := marks.(type) {
+	case bool:
+		if m {
+			return placeholder
+		}
+	case map[string]interface{}:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			if v != nil {
+				return v
+			}
+			// The whole object is unknown, so only the marked keys exist
+			obj = map[string]interface{}{}
+		}
+		for key, mark := range m {
+			current, present := obj[key]
+			if replaced := overlay(current, mark, placeholder); present || replaced != nil {
+				obj[key] = replaced
+			}
+		}
+		if !ok && len(obj) == 0 {
+			return v
+		}
+		return obj
+	case []interface{}:
+		list, _ := v.([]interface{})
+		for i, mark := range m {
+			if i < len(list) {
+				list[i] = overlay(list[i], mark, placeholder)
+			} else if mark == true {
+				list = append(list, placeholder)
+			}
+		}
+		if list == nil {
+			return v
+		}
+		return list
+	}
+	return v
+}
+
+// maskSensitiveVariables hides the values of input variables the
+// configuration declares sensitive; the plan records them in clear text
+func maskSensitiveVariables(variables, configuration interface{}) {
+	declared := asMap(asMap(asMap(configuration)["root_module"])["variables"])
+	for name, v := range asMap(variables) {
+		if sensitive, _ := asMap(declared[name])["sensitive"].(bool); sensitive {
+			if value := asMap(v); value != nil {
+				value["value"] = Sensitive
+			}
+		}
+	}
+}
+
+func applyMasks(v interface{}, masks []Mask) interface{} {
+	switch value := v.(type) {
+	case string:
+		for _, m := range masks {
+			value = m.Pattern.ReplaceAllString(value, m.Replacement)
+		}
+		return value
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = applyMasks(item, masks)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = applyMasks(item, masks)
+		}
+	}
+	return v
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
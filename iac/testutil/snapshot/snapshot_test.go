@@ -0,0 +1,218 @@
+package snapshot_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/snapshot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// planJSON is a trimmed `terraform show -json` plan with every kind of value
+// the normalizer rewrites
+const planJSON = `{
+  "format_version": "1.2",
+  "terraform_version": "1.6.2",
+  "timestamp": "2024-03-01T12:00:00Z",
+  "variables": {"bucket_name": {"value": "unit-test-bucket"}, "db_password": {"value": "hunter2"}},
+  "planned_values": {"root_module": {"child_modules": [{"address": "module.aws_storage[0]"}]}},
+  "resource_changes": [
+    {
+      "address": "module.aws_storage[0].aws_s3_bucket.this",
+      "type": "aws_s3_bucket",
+      "change": {
+        "actions": ["create"],
+        "before": null,
+        "after": {
+          "bucket": "unit-test-bucket-1a2b3c4d5e",
+          "tags": {"CreatedAt": "2024-03-01T12:00:00.123+01:00", "Name": "unit-test-bucket"},
+          "password": "hunter2",
+          "grants": [{"id": "g1"}]
+        },
+        "after_unknown": {"arn": true, "id": true, "tags": {}, "grants": [{"uri": true}], "versioning": [true]},
+        "after_sensitive": {"password": true},
+        "before_sensitive": false
+      }
+    }
+  ],
+  "output_changes": {
+    "bucket_arn": {"actions": ["create"], "before": null, "after_unknown": true, "after_sensitive": false},
+    "secret": {"actions": ["create"], "before": null, "after": "s3cr3t", "after_unknown": false, "after_sensitive": true}
+  },
+  "prior_state": {"format_version": "1.0"},
+  "configuration": {"root_module": {"variables": {"bucket_name": {}, "db_password": {"sensitive": true}}}}
+}`
+
+const wantNormalized = `{
+  "output_changes": {
+    "bucket_arn": {
+      "actions": [
+        "create"
+      ],
+      "after": "(known after apply)",
+      "before": null
+    },
+    "secret": {
+      "actions": [
+        "create"
+      ],
+      "after": "(sensitive)",
+      "before": null
+    }
+  },
+  "planned_values": {
+    "root_module": {
+      "child_modules": [
+        {
+          "address": "module.aws_storage[0]"
+        }
+      ]
+    }
+  },
+  "resource_changes": [
+    {
+      "address": "module.aws_storage[0].aws_s3_bucket.this",
+      "change": {
+        "actions": [
+          "create"
+        ],
+        "after": {
+          "arn": "(known after apply)",
+          "bucket": "unit-test-bucket-<random>",
+          "grants": [
+            {
+              "id": "g1",
+              "uri": "(known after apply)"
+            }
+          ],
+          "id": "(known after apply)",
+          "password": "(sensitive)",
+          "tags": {
+            "CreatedAt": "<timestamp>",
+            "Name": "unit-test-bucket"
+          },
+          "versioning": [
+            "(known after apply)"
+          ]
+        },
+        "before": null
+      },
+      "type": "aws_s3_bucket"
+    }
+  ],
+  "variables": {
+    "bucket_name": {
+      "value": "unit-test-bucket"
+    },
+    "db_password": {
+      "value": "(sensitive)"
+    }
+  }
+}
+`
+
+// recorder captures Fatalf instead of failing the enclosing test
+type recorder struct {
+	failed  bool
+	message string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed = true
+	r.message = fmt.Sprintf(format, args...)
+}
+
+func (r *recorder) Logf(string, ...interface{}) {}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	got, err := snapshot.Normalize([]byte(planJSON), snapshot.DefaultMasks...)
+	require.NoError(t, err)
+	assert.Equal(t, wantNormalized, string(got))
+}
+
+func TestNormalizeIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	first, err := snapshot.Normalize([]byte(planJSON), snapshot.DefaultMasks...)
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		again, err := snapshot.Normalize([]byte(planJSON), snapshot.DefaultMasks...)
+		require.NoError(t, err)
+		require.Equal(t, string(first), string(again), "Map iteration order must not leak into the snapshot")
+	}
+}
+
+func TestNormalizeOpenTofuProviderAddresses(t *testing.T) {
+	t.Parallel()
+
+	plan := func(registry string) string {
+		return `{"resource_changes": [{"address": "aws_s3_bucket.this", "provider_name": "` + registry + `/hashicorp/aws", "change": {"actions": ["create"]}}]}`
+	}
+
+	terraform, err := snapshot.Normalize([]byte(plan("registry.terraform.io")))
+	require.NoError(t, err)
+	tofu, err := snapshot.Normalize([]byte(plan("registry.opentofu.org")))
+	require.NoError(t, err)
+	assert.Equal(t, string(terraform), string(tofu), "Terraform and OpenTofu plans should share a snapshot")
+}
+
+func TestNormalizeInvalidJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := snapshot.Normalize([]byte("Plan: 1 to add"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "decoding plan JSON")
+}
+
+func TestMatch(t *testing.T) {
+	t.Setenv(snapshot.EnvUpdate, "")
+
+	golden := filepath.Join(t.TempDir(), "plan-aws.golden.json")
+	require.NoError(t, os.WriteFile(golden, []byte("{\r\n  \"a\": 1,\r\n  \"b\": 2\r\n}\r\n"), 0o644))
+
+	rec := &recorder{}
+	snapshot.Match(rec, golden, []byte("{\n  \"a\": 1,\n  \"b\": 2\n}\n"))
+	assert.False(t, rec.failed, "CRLF golden files should match LF output: %s", rec.message)
+
+	rec = &recorder{}
+	snapshot.Match(rec, golden, []byte("{\n  \"a\": 1,\n  \"b\": 3\n}\n"))
+	require.True(t, rec.failed, "A changed plan should fail")
+	assert.Contains(t, rec.message, "--- "+golden)
+	assert.Contains(t, rec.message, "+++ plan")
+	assert.Contains(t, rec.message, "-  \"b\": 2\n")
+	assert.Contains(t, rec.message, "+  \"b\": 3\n")
+	assert.Contains(t, rec.message, "UPDATE_SNAPSHOTS=1")
+}
+
+func TestMatchMissingGolden(t *testing.T) {
+	t.Setenv(snapshot.EnvUpdate, "")
+
+	rec := &recorder{}
+	snapshot.Match(rec, filepath.Join(t.TempDir(), "absent.golden.json"), []byte("{}\n"))
+
+	require.True(t, rec.failed)
+	assert.Contains(t, rec.message, "does not exist")
+}
+
+func TestMatchUpdate(t *testing.T) {
+	t.Setenv(snapshot.EnvUpdate, "1")
+
+	golden := filepath.Join(t.TempDir(), "testdata", "plan-gcp.golden.json")
+
+	rec := &recorder{}
+	snapshot.Match(rec, golden, []byte("{}\n"))
+	require.False(t, rec.failed, rec.message)
+
+	written, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, "{}\n", string(written))
+}
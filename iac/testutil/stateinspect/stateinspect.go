@@ -0,0 +1,280 @@
+// Package stateinspect reads the state of an applied Terraform workspace so
+// integration tests can assert on attributes a module does not export as
+// outputs, such as the tags or billing mode of a resource nested two
+// modules deep.
+//
+// State is read with `terraform show -json`; a saved copy can be loaded with
+// Load instead, which is how the package's own tests run without terraform.
+package stateinspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// TestingT is the subset of testing.TB the assertions need; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// State is the `terraform show -json` representation of a state
+type State struct {
+	FormatVersion    string  `json:"format_version"`
+	TerraformVersion string  `json:"terraform_version"`
+	Values           *Values `json:"values"`
+}
+
+// Values holds the outputs and the module tree; it is absent from the state
+// of a workspace that was never applied
+type Values struct {
+	Outputs    map[string]Output `json:"outputs"`
+	RootModule Module            `json:"root_module"`
+}
+
+// Output is a root module output
+type Output struct {
+	Value     interface{} `json:"value"`
+	Sensitive bool        `json:"sensitive"`
+}
+
+// Module is the root module or a module call; child modules of a call with
+// count or for_each appear once per instance
+type Module struct {
+	Address      string     `json:"address"`
+	Resources    []Resource `json:"resources"`
+	ChildModules []Module   `json:"child_modules"`
+}
+
+// Resource is one resource instance
+type Resource struct {
+	Address      string `json:"address"`
+	Mode         string `json:"mode"`
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	ProviderName string `json:"provider_name"`
+
+	// Index is the count (float64) or for_each (string) key, nil for a
+	// single instance
+	Index interface{} `json:"index"`
+
+	Values    map[string]interface{} `json:"values"`
+	DependsOn []string               `json:"depends_on"`
+	Tainted   bool                   `json:"tainted"`
+}
+
+// Parse decodes `terraform show -json` output for a state
+func Parse(data []byte) (*State, error) {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("stateinspect: decoding state JSON: %w", err)
+	}
+	if state.FormatVersion == "" {
+		return nil, fmt.Errorf("stateinspect: not terraform show -json output (no format_version field)")
+	}
+	return &state, nil
+}
+
+// Load parses a saved `terraform show -json` file
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("stateinspect: reading %s: %w", path, err)
+	}
+	return Parse(data)
+}
+
+// Read shows the state of the workspace at options.TerraformDir, failing t
+// if terraform or the decoding fails
+func Read(t testing.TB, options *terraform.Options) *State {
+	t.Helper()
+
+	// A plan file would make terraform show the plan instead of the state
+	stateOptions := *options
+	stateOptions.PlanFilePath = ""
+
+	out, err := terraform.ShowE(t, &stateOptions)
+	if err != nil {
+		t.Fatalf("stateinspect: terraform show: %v", err)
+	}
+	state, err := Parse([]byte(out))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return state
+}
+
+// Resources returns every resource instance in the state, depth first, in
+// the order terraform lists them
+func (s *State) Resources() []Resource {
+	if s.Values == nil {
+		return nil
+	}
+	var resources []Resource
+	var walk func(m Module)
+	walk = func(m Module) {
+		resources = append(resources, m.Resources...)
+		for _, child := range m.ChildModules {
+			walk(child)
+		}
+	}
+	walk(s.Values.RootModule)
+	return resources
+}
+
+// ResourceByAddress finds the resource instance at its full address, e.g.
+// module.storage.module.aws_storage[0].aws_s3_bucket.this
+func (s *State) ResourceByAddress(address string) (*Resource, error) {
+	resources := s.Resources()
+	for i := range resources {
+		if resources[i].Address == address {
+			return &resources[i], nil
+		}
+	}
+
+	addresses := make([]string, len(resources))
+	for i, r := range resources {
+		addresses[i] = r.Address
+	}
+	sort.Strings(addresses)
+	return nil, fmt.Errorf("stateinspect: no resource %s in state; resources:\n  %s", address, strings.Join(addresses, "\n  "))
+}
+
+// AssertNoTainted fails t for every tainted resource instance, which a
+// failed create or provisioner leaves behind while apply still reports its
+// outputs
+func (s *State) AssertNoTainted(t TestingT) bool {
+	t.Helper()
+
+	var tainted []string
+	for _, r := range s.Resources() {
+		if r.Tainted {
+			tainted = append(tainted, r.Address)
+		}
+	}
+	if len(tainted) == 0 {
+		return true
+	}
+	sort.Strings(tainted)
+	t.Errorf("tainted resources in state:\n  %s", strings.Join(tainted, "\n  "))
+	return false
+}
+
+// Attribute returns the value at path in the resource's attributes. Path
+// segments are object keys, or list indexes for lists:
+//
+//	r.Attribute("versioning_configuration", "0", "status")
+func (r *Resource) Attribute(path ...string) (interface{}, error) {
+	var v interface{} = r.Values
+	for i, segment := range path {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			next, ok := value[segment]
+			if !ok {
+				return nil, fmt.Errorf("stateinspect: %s has no attribute %s", r.Address, strings.Join(path[:i+1], "."))
+			}
+			v = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, fmt.Errorf("stateinspect: %s: %s has %d elements, no index %q", r.Address, strings.Join(path[:i], "."), len(value), segment)
+			}
+			v = value[index]
+		default:
+			return nil, fmt.Errorf("stateinspect: %s: %s is %s, not an object or list", r.Address, strings.Join(path[:i], "."), describe(v))
+		}
+	}
+	return v, nil
+}
+
+// AttributeString returns the string at path
+func (r *Resource) AttributeString(path ...string) (string, error) {
+	v, err := r.Attribute(path...)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", r.typeError(path, v, "a string")
+	}
+	return s, nil
+}
+
+// AttributeBool returns the bool at path
+func (r *Resource) AttributeBool(path ...string) (bool, error) {
+	v, err := r.Attribute(path...)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, r.typeError(path, v, "a bool")
+	}
+	return b, nil
+}
+
+// AttributeList returns the list or set at path
+func (r *Resource) AttributeList(path ...string) ([]interface{}, error) {
+	v, err := r.Attribute(path...)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, r.typeError(path, v, "a list")
+	}
+	return list, nil
+}
+
+// AttributeMap returns the map of strings at path, such as tags
+func (r *Resource) AttributeMap(path ...string) (map[string]string, error) {
+	v, err := r.Attribute(path...)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, r.typeError(path, v, "a map")
+	}
+	m := make(map[string]string, len(object))
+	for k, value := range object {
+		s, ok := value.(string)
+		if !ok {
+			return nil, r.typeError(append(path, k), value, "a string")
+		}
+		m[k] = s
+	}
+	return m, nil
+}
+
+func (r *Resource) typeError(path []string, v interface{}, want string) error {
+	return fmt.Errorf("stateinspect: %s: %s is %s, not %s", r.Address, strings.Join(path, "."), describe(v), want)
+}
+
+// describe names the JSON type of v for error messages
+func describe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a bool"
+	case float64:
+		return "a number"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,203 @@
+package stateinspect_test
+
+import (
+	"fmt"
+	"testing"
+
+	"iac/testutil/stateinspect"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bucketAddress     = "module.storage.module.aws_storage[0].aws_s3_bucket.this"
+	versioningAddress = "module.storage.module.aws_storage[0].aws_s3_bucket_versioning.this[0]"
+	tableAddress      = "module.nosql_table.module.aws_nosql[0].aws_dynamodb_table.this"
+)
+
+// recorder captures Errorf instead of failing the enclosing test
+type recorder struct {
+	failed  bool
+	message string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failed = true
+	r.message = fmt.Sprintf(format, args...)
+}
+
+func loadState(t *testing.T, path string) *stateinspect.State {
+	t.Helper()
+	state, err := stateinspect.Load(path)
+	require.NoError(t, err)
+	return state
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	state := loadState(t, "testdata/state.json")
+
+	assert.Equal(t, "1.6.6", state.TerraformVersion)
+	require.NotNil(t, state.Values)
+	assert.Equal(t, "fullstack-bucket-1700000000", state.Values.Outputs["bucket_name"].Value)
+}
+
+func TestParseRejectsOtherJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := stateinspect.Parse([]byte(`{"version": "0.2", "projects": []}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no format_version")
+
+	_, err = stateinspect.Parse([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestResourcesWalksNestedModules(t *testing.T) {
+	t.Parallel()
+
+	var addresses []string
+	for _, r := range loadState(t, "testdata/state.json").Resources() {
+		addresses = append(addresses, r.Address)
+	}
+
+	assert.Equal(t, []string{
+		"data.aws_caller_identity.current",
+		tableAddress,
+		`module.queue.module.aws_messaging[0].aws_sns_topic_subscription.this["orders"]`,
+		bucketAddress,
+		versioningAddress,
+	}, addresses)
+}
+
+func TestResourcesOfEmptyState(t *testing.T) {
+	t.Parallel()
+
+	state := loadState(t, "testdata/empty.json")
+
+	assert.Empty(t, state.Resources())
+	_, err := state.ResourceByAddress(bucketAddress)
+	assert.Error(t, err)
+}
+
+func TestResourceByAddress(t *testing.T) {
+	t.Parallel()
+
+	state := loadState(t, "testdata/state.json")
+
+	versioning, err := state.ResourceByAddress(versioningAddress)
+	require.NoError(t, err)
+	assert.Equal(t, "aws_s3_bucket_versioning", versioning.Type)
+	assert.Equal(t, float64(0), versioning.Index, "A count index should decode as a number")
+
+	subscription, err := state.ResourceByAddress(`module.queue.module.aws_messaging[0].aws_sns_topic_subscription.this["orders"]`)
+	require.NoError(t, err)
+	assert.Equal(t, "orders", subscription.Index, "A for_each key should decode as a string")
+
+	data, err := state.ResourceByAddress("data.aws_caller_identity.current")
+	require.NoError(t, err)
+	assert.Equal(t, "data", data.Mode)
+}
+
+func TestResourceByAddressListsCandidates(t *testing.T) {
+	t.Parallel()
+
+	// Without the instance key the address does not match
+	_, err := loadState(t, "testdata/state.json").ResourceByAddress("module.storage.module.aws_storage.aws_s3_bucket.this")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no resource module.storage.module.aws_storage.aws_s3_bucket.this in state")
+	assert.Contains(t, err.Error(), "\n  "+bucketAddress)
+}
+
+func TestAttributeAccessors(t *testing.T) {
+	t.Parallel()
+
+	state := loadState(t, "testdata/state.json")
+
+	table, err := state.ResourceByAddress(tableAddress)
+	require.NoError(t, err)
+
+	billingMode, err := table.AttributeString("billing_mode")
+	require.NoError(t, err)
+	assert.Equal(t, "PAY_PER_REQUEST", billingMode)
+
+	pitr, err := table.AttributeBool("point_in_time_recovery", "0", "enabled")
+	require.NoError(t, err)
+	assert.True(t, pitr)
+
+	attributes, err := table.AttributeList("attribute")
+	require.NoError(t, err)
+	assert.Len(t, attributes, 1)
+
+	bucket, err := state.ResourceByAddress(bucketAddress)
+	require.NoError(t, err)
+
+	tags, err := bucket.AttributeMap("tags")
+	require.NoError(t, err)
+	assert.Equal(t, "local-test", tags["Project"])
+	assert.Equal(t, "swe-cloud", tags["ManagedBy"])
+
+	versioning, err := state.ResourceByAddress(versioningAddress)
+	require.NoError(t, err)
+
+	status, err := versioning.AttributeString("versioning_configuration", "0", "status")
+	require.NoError(t, err)
+	assert.Equal(t, "Enabled", status)
+}
+
+func TestAttributeErrors(t *testing.T) {
+	t.Parallel()
+
+	table, err := loadState(t, "testdata/state.json").ResourceByAddress(tableAddress)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		get  func() error
+		want string
+	}{
+		"missing attribute": {
+			get:  func() error { _, err := table.AttributeString("stream_view_type"); return err },
+			want: tableAddress + " has no attribute stream_view_type",
+		},
+		"wrong type": {
+			get:  func() error { _, err := table.AttributeBool("billing_mode"); return err },
+			want: tableAddress + ": billing_mode is a string, not a bool",
+		},
+		"number is not a string": {
+			get:  func() error { _, err := table.AttributeString("read_capacity"); return err },
+			want: tableAddress + ": read_capacity is a number, not a string",
+		},
+		"index out of range": {
+			get:  func() error { _, err := table.AttributeString("attribute", "1", "name"); return err },
+			want: tableAddress + `: attribute has 1 elements, no index "1"`,
+		},
+		"key into a string": {
+			get:  func() error { _, err := table.AttributeString("billing_mode", "mode"); return err },
+			want: tableAddress + ": billing_mode is a string, not an object or list",
+		},
+	}
+
+	for name, tc := range tests {
+		err := tc.get()
+		if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), tc.want, name)
+		}
+	}
+}
+
+func TestAssertNoTainted(t *testing.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	assert.True(t, loadState(t, "testdata/state.json").AssertNoTainted(r))
+	assert.False(t, r.failed)
+
+	r = &recorder{}
+	assert.False(t, loadState(t, "testdata/tainted.json").AssertNoTainted(r))
+	assert.True(t, r.failed)
+	assert.Equal(t, "tainted resources in state:\n  module.lambda.module.aws_lambda[0].aws_lambda_function.this", r.message)
+}
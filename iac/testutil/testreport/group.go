@@ -0,0 +1,81 @@
+package testreport
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Providers are the matrix columns, in order
+var Providers = []string{"aws", "azure", "gcp", "zero"}
+
+// Facades are the directories under facade/, which name the matrix rows
+var Facades = []string{
+	"backup", "budget", "cdn", "certificate", "compute", "database", "encryption", "eventbus", "events", "iam", "kubernetes",
+	"lambda", "messaging", "monitoring", "networking", "nosql", "secrets", "statebackend", "storage", "waf", "workflows",
+}
+
+// facadeWord matches a capitalized facade name as a word of a CamelCase
+// test name: TestStorageFacadeAws, TestCloudEmuImportNoSQL, TestIAMFacadeGcp
+var facadeWord = func() *regexp.Regexp {
+	var words []string
+	for _, f := range Facades {
+		words = append(words, strings.ToUpper(f[:1])+"(?i:"+f[1:]+")")
+	}
+	return regexp.MustCompile(`(` + strings.Join(words, "|") + `)(?:[A-Z0-9_]|$)`)
+}()
+
+// providerWord matches the provider that follows "Facade" in names like
+// TestMessagingFacadeGcpQueue
+var providerWord = regexp.MustCompile(`Facade(Aws|AWS|Azure|Gcp|GCP|Zero)(?:[A-Z0-9_]|$)`)
+
+// Classify returns the facade and provider a test exercises, following the
+// naming convention of the suite. The facade is the facade/<name> package
+// the test lives in, or else the first facade named in the top-level test
+// name. The provider is the provider integration package the test lives in
+// (aws/test), or else a subtest named after a provider
+// (TestStorageFacadePlanSnapshot/aws), or else the provider following
+// "Facade" in the test name (TestStorageFacadeAws). Either is empty when
+// the test is not specific to one.
+func Classify(pkg, test string) (facade, provider string) {
+	segments := strings.Split(pkg, "/")
+	for i, s := range segments {
+		if s == "facade" && i+1 < len(segments) {
+			facade = segments[i+1]
+			break
+		}
+	}
+
+	top, _, _ := strings.Cut(test, "/")
+	if facade == "" {
+		if m := facadeWord.FindStringSubmatch(top); m != nil {
+			facade = strings.ToLower(m[1])
+		}
+	}
+
+	for _, s := range segments {
+		if isProvider(s) {
+			return facade, s
+		}
+	}
+
+	names := strings.Split(test, "/")
+	for i := len(names) - 1; i > 0; i-- {
+		if name := strings.ToLower(names[i]); isProvider(name) {
+			return facade, name
+		}
+	}
+
+	if m := providerWord.FindStringSubmatch(top); m != nil {
+		return facade, strings.ToLower(m[1])
+	}
+	return facade, ""
+}
+
+func isProvider(name string) bool {
+	for _, p := range Providers {
+		if name == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package testreport_test
+
+import (
+	"testing"
+
+	"iac/testutil/testreport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pkg, test        string
+		facade, provider string
+	}{
+		{"iac/facade/storage", "TestStorageFacadeAws", "storage", "aws"},
+		{"iac/facade/messaging", "TestMessagingFacadeGcpQueue", "messaging", "gcp"},
+		{"iac/facade/lambda", "TestLambdaFacadeAwsCanary", "lambda", "aws"},
+		{"iac/facade/iam", "TestIAMFacadeAzure", "iam", "azure"},
+		{"iac/facade/compute", "TestComputeFacadeZero", "compute", "zero"},
+		{"iac/facade/storage", "TestStorageFacadePlanSnapshot/gcp", "storage", "gcp"},
+		{"iac/facade/storage", "TestStorageDataPlaneEquivalence/providers/azure", "storage", "azure"},
+		{"iac/facade/storage", "TestStorageFacadePlanSnapshot", "storage", ""},
+		{"iac/facade/messaging", "TestMessagingFacadeQueueTuningBounds", "messaging", ""},
+		{"iac/facade/database", "TestDatabaseFacadeUpgrade", "database", ""},
+		{"iac/aws/test", "TestCloudEmuStorageFacade", "storage", "aws"},
+		{"iac/aws/test", "TestCloudEmuImportNoSQL", "nosql", "aws"},
+		{"iac/aws/test", "TestCloudEmuLambdaCanary", "lambda", "aws"},
+		{"iac/aws/test", "TestCloudEmuEventBusLambdaTarget", "eventbus", "aws"},
+		{"iac/aws/test", "TestCloudEmuFullStack", "", "aws"},
+		{"iac/azure/test", "TestAzureIntegration", "", "azure"},
+		{"iac/zero/test", "TestZeroIntegration", "", "zero"},
+		{"iac", "TestFacadeValidationMatrix/SpacesAndUppercase", "", ""},
+		{"iac", "TestAllModulesValidate", "", ""},
+		{"iac", "TestKmsKeyRefHonoredByAllFacades", "", ""},
+		{"iac", "TestEventsPreventsDuplicates", "events", ""},
+		{"iac/facade/storage", "", "storage", ""},
+		{"iac/facade/backup", "TestBackupFacadeComposition", "backup", ""},
+		{"iac/facade/cdn", "TestCdnFacadeAzure", "cdn", "azure"},
+		{"iac/facade/certificate", "TestCertificateFacadeGcp", "certificate", "gcp"},
+		{"iac/facade/waf", "TestWafFacadeAzureFrontDoor", "waf", "azure"},
+		{"iac/facade/budget", "TestBudgetFacadeGcp", "budget", "gcp"},
+		{"iac/gcp/test", "", "", "gcp"},
+	}
+
+	for _, tt := range tests {
+		facade, provider := testreport.Classify(tt.pkg, tt.test)
+		assert.Equal(t, tt.facade, facade, "facade of %s %s", tt.pkg, tt.test)
+		assert.Equal(t, tt.provider, provider, "provider of %s %s", tt.pkg, tt.test)
+	}
+}
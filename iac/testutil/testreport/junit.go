@@ -0,0 +1,101 @@
+package testreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes a report as JUnit XML, one testsuite per package and
+// one testcase per test and subtest. Each testcase carries the test's
+// output, and a classname that appends its facade and provider to the
+// package so CI can group on them.
+func WriteJUnit(w io.Writer, r *Report) error {
+	suites := junitSuites{}
+	var total time.Duration
+	for _, p := range r.Packages {
+		suite := junitSuite{Name: p.Name, Time: junitTime(p.Elapsed)}
+		for _, t := range p.Tests {
+			c := junitCase{ClassName: className(t), Name: t.Name, Time: junitTime(t.Elapsed)}
+			switch t.Status {
+			case Fail:
+				c.Failure = &junitMessage{Message: "Failed", Body: t.Output}
+				suite.Failures++
+			case Skip:
+				c.Skipped = &junitMessage{Message: "Skipped", Body: t.Output}
+				suite.Skipped++
+			default:
+				c.SystemOut = t.Output
+			}
+			suite.Cases = append(suite.Cases, c)
+			suite.Tests++
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		total += p.Elapsed
+		suites.Suites = append(suites.Suites, suite)
+	}
+	suites.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return fmt.Errorf("testreport: encoding JUnit XML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// className is the package, followed by the facade and provider of the
+// test when it has them: iac/facade/storage.storage.aws
+func className(t *Test) string {
+	name := t.Package
+	if t.Facade != "" {
+		name += "." + t.Facade
+	}
+	if t.Provider != "" {
+		name += "." + t.Provider
+	}
+	return name
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
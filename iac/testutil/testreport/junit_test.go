@@ -0,0 +1,86 @@
+package testreport_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"iac/testutil/testreport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type junitSuites struct {
+	Tests    int `xml:"tests,attr"`
+	Failures int `xml:"failures,attr"`
+	Skipped  int `xml:"skipped,attr"`
+	Suites   []struct {
+		Name     string `xml:"name,attr"`
+		Tests    int    `xml:"tests,attr"`
+		Failures int    `xml:"failures,attr"`
+		Skipped  int    `xml:"skipped,attr"`
+		Time     string `xml:"time,attr"`
+		Cases    []struct {
+			ClassName string `xml:"classname,attr"`
+			Name      string `xml:"name,attr"`
+			Time      string `xml:"time,attr"`
+			Failure   *struct {
+				Body string `xml:",chardata"`
+			} `xml:"failure"`
+			Skipped *struct{} `xml:"skipped"`
+		} `xml:"testcase"`
+	} `xml:"testsuite"`
+}
+
+func TestWriteJUnit(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	require.NoError(t, testreport.WriteJUnit(&b, parseFile(t, "testdata/suite.jsonl")))
+	assert.True(t, strings.HasPrefix(b.String(), xml.Header))
+
+	var got junitSuites
+	require.NoError(t, xml.Unmarshal([]byte(b.String()), &got))
+
+	assert.Equal(t, 14, got.Tests, "Every test and subtest is a testcase")
+	assert.Equal(t, 1, got.Failures)
+	assert.Equal(t, 3, got.Skipped)
+	require.Len(t, got.Suites, 4)
+
+	storage := got.Suites[0]
+	assert.Equal(t, "iac/facade/storage", storage.Name)
+	assert.Equal(t, "7.300", storage.Time)
+	assert.Equal(t, 9, storage.Tests)
+	assert.Equal(t, 1, storage.Failures)
+	assert.Equal(t, 1, storage.Skipped)
+
+	for _, c := range storage.Cases {
+		switch c.Name {
+		case "TestStorageFacadeZero":
+			assert.Equal(t, "iac/facade/storage.storage.zero", c.ClassName)
+			assert.Equal(t, "0.750", c.Time)
+			require.NotNil(t, c.Failure)
+			assert.Contains(t, c.Failure.Body, `expected "zero", actual ""`)
+		case "TestStorageFacadeGcp":
+			assert.NotNil(t, c.Skipped)
+		case "TestStorageFacadePlanSnapshot":
+			assert.Equal(t, "iac/facade/storage.storage", c.ClassName)
+			assert.Nil(t, c.Failure)
+		}
+	}
+
+	assert.Equal(t, "iac/aws/test.aws", got.Suites[2].Cases[1].ClassName, "TestCloudEmuFullStack has no facade")
+}
+
+func TestWriteJUnitBuildFailure(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	require.NoError(t, testreport.WriteJUnit(&b, parseFile(t, "testdata/incomplete.jsonl")))
+
+	var got junitSuites
+	require.NoError(t, xml.Unmarshal([]byte(b.String()), &got))
+	assert.Equal(t, 2, got.Failures)
+	assert.Equal(t, testreport.PackageTest, got.Suites[0].Cases[0].Name)
+}
@@ -0,0 +1,162 @@
+package testreport
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Shared is the matrix column for facade tests that are not specific to
+// one provider, such as TestFacadeValidationMatrix
+const Shared = "shared"
+
+// Other is the matrix row for tests that exercise no single facade, such
+// as TestAllModulesValidate
+const Other = "other"
+
+// Cell aggregates the counted tests of one facade and provider
+type Cell struct {
+	Passed  int
+	Failed  int
+	Skipped int
+	Elapsed time.Duration
+}
+
+// Status is Fail if any test failed, Pass if any passed, and Skip if every
+// test skipped
+func (c *Cell) Status() Status {
+	switch {
+	case c.Failed > 0:
+		return Fail
+	case c.Passed > 0:
+		return Pass
+	}
+	return Skip
+}
+
+func (c *Cell) String() string {
+	switch c.Status() {
+	case Fail:
+		return fmt.Sprintf("✗ %d/%d %s", c.Failed, c.Passed+c.Failed+c.Skipped, duration(c.Elapsed))
+	case Pass:
+		return "✓ " + duration(c.Elapsed)
+	}
+	return "skip"
+}
+
+// Matrix is the counted tests of a report grouped by facade and provider
+type Matrix struct {
+	// Rows are the facades with results, sorted, with Other last
+	Rows []string
+
+	// Columns are Providers, followed by Shared when a row has results
+	// for no single provider
+	Columns []string
+
+	Cells map[string]map[string]*Cell
+
+	// Failures are the counted tests that failed, in stream order
+	Failures []*Test
+}
+
+// NewMatrix groups the counted tests of a report by facade and provider
+func NewMatrix(r *Report) *Matrix {
+	m := &Matrix{Columns: slices.Clone(Providers), Cells: map[string]map[string]*Cell{}}
+	shared := false
+	for _, t := range r.Counted() {
+		row, column := t.Facade, t.Provider
+		if row == "" {
+			row = Other
+		}
+		if column == "" {
+			column = Shared
+			shared = true
+		}
+		if m.Cells[row] == nil {
+			m.Cells[row] = map[string]*Cell{}
+			m.Rows = append(m.Rows, row)
+		}
+		c := m.Cells[row][column]
+		if c == nil {
+			c = &Cell{}
+			m.Cells[row][column] = c
+		}
+		switch t.Status {
+		case Pass:
+			c.Passed++
+		case Fail:
+			c.Failed++
+			m.Failures = append(m.Failures, t)
+		case Skip:
+			c.Skipped++
+		}
+		c.Elapsed += t.Elapsed
+	}
+
+	slices.SortFunc(m.Rows, func(a, b string) int {
+		if (a == Other) != (b == Other) {
+			if a == Other {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a, b)
+	})
+	if shared {
+		m.Columns = append(m.Columns, Shared)
+	}
+	return m
+}
+
+// Cell returns the results of one facade and provider, or nil when the
+// stream has none
+func (m *Matrix) Cell(facade, provider string) *Cell {
+	return m.Cells[facade][provider]
+}
+
+// WriteMarkdown writes the facade × provider matrix of a report as a
+// Markdown table, followed by the list of failed tests. Durations are the
+// sum of each cell's tests, so parallel tests add up to more than the wall
+// clock time of the run.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	m := NewMatrix(r)
+	var b strings.Builder
+
+	b.WriteString("| Facade |")
+	for _, c := range m.Columns {
+		fmt.Fprintf(&b, " %s |", c)
+	}
+	b.WriteString("\n| :--- |")
+	for range m.Columns {
+		b.WriteString(" :---: |")
+	}
+	b.WriteString("\n")
+
+	for _, row := range m.Rows {
+		fmt.Fprintf(&b, "| **%s** |", row)
+		for _, column := range m.Columns {
+			text := "–"
+			if c := m.Cell(row, column); c != nil {
+				text = c.String()
+			}
+			fmt.Fprintf(&b, " %s |", text)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(m.Failures) > 0 {
+		b.WriteString("\n**Failed tests**\n\n")
+		for _, t := range m.Failures {
+			fmt.Fprintf(&b, "- `%s` (`%s`)\n", t.Name, t.Package)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func duration(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
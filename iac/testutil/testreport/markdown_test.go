@@ -0,0 +1,75 @@
+package testreport_test
+
+import (
+	"strings"
+	"testing"
+
+	"iac/testutil/testreport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMatrix(t *testing.T) {
+	t.Parallel()
+
+	m := testreport.NewMatrix(parseFile(t, "testdata/suite.jsonl"))
+
+	assert.Equal(t, []string{"messaging", "storage", testreport.Other}, m.Rows)
+	assert.Equal(t, []string{"aws", "azure", "gcp", "zero", testreport.Shared}, m.Columns)
+
+	zero := m.Cell("storage", "zero")
+	require.NotNil(t, zero)
+	assert.Equal(t, testreport.Fail, zero.Status(), "One failure fails the cell")
+	assert.Equal(t, 1, zero.Passed)
+
+	gcp := m.Cell("storage", "gcp")
+	require.NotNil(t, gcp)
+	assert.Equal(t, testreport.Pass, gcp.Status(), "A skip next to a pass still passes")
+
+	assert.Equal(t, testreport.Skip, m.Cell(testreport.Other, "aws").Status())
+	assert.Nil(t, m.Cell("messaging", "azure"))
+
+	require.Len(t, m.Failures, 1)
+	assert.Equal(t, "TestStorageFacadeZero", m.Failures[0].Name)
+}
+
+func TestNewMatrixWithoutSharedTests(t *testing.T) {
+	t.Parallel()
+
+	stream := `{"Action":"pass","Package":"iac/facade/storage","Test":"TestStorageFacadeAws","Elapsed":1}
+{"Action":"pass","Package":"iac/facade/storage","Elapsed":1.1}`
+	report, err := testreport.Parse(strings.NewReader(stream))
+	require.NoError(t, err)
+
+	assert.Equal(t, testreport.Providers, testreport.NewMatrix(report).Columns)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	require.NoError(t, testreport.WriteMarkdown(&b, parseFile(t, "testdata/suite.jsonl")))
+
+	assert.Equal(t, `| Facade | aws | azure | gcp | zero | shared |
+| :--- | :---: | :---: | :---: | :---: | :---: |
+| **messaging** | – | – | ✓ 1.2s | – | ✓ 0.4s |
+| **storage** | ✓ 4.3s | ✓ 3.5s | ✓ 1.0s | ✗ 1/2 1.8s | – |
+| **other** | skip | – | – | – | ✓ 12.0s |
+
+**Failed tests**
+
+- `+"`TestStorageFacadeZero` (`iac/facade/storage`)"+`
+`, b.String())
+}
+
+func TestWriteMarkdownBuildFailure(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	require.NoError(t, testreport.WriteMarkdown(&b, parseFile(t, "testdata/incomplete.jsonl")))
+
+	assert.Contains(t, b.String(), "| **database** | ✗ 1/1 0.0s |", "A test that never finished has no elapsed time")
+	assert.Contains(t, b.String(), "| **nosql** | – | – | – | – | ✗ 1/1 0.0s |")
+	assert.Contains(t, b.String(), "- `(package)` (`iac/facade/nosql`)")
+}
@@ -0,0 +1,198 @@
+// Package testreport turns the output of `go test -json` into a JUnit XML
+// report for CI and a Markdown matrix of facades against providers.
+package testreport
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a test or package, named after the test2json
+// actions that end one
+type Status string
+
+const (
+	Pass Status = "pass"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// PackageTest names the result recorded for a package that failed without
+// a failing test, such as a build failure or a panic in TestMain
+const PackageTest = "(package)"
+
+// event is one line of test2json output
+type event struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// Test is the result of one test or subtest
+type Test struct {
+	Package  string
+	Name     string
+	Status   Status
+	Elapsed  time.Duration
+	Output   string
+	Facade   string
+	Provider string
+
+	// Parent is true when the stream ran subtests of this test
+	Parent bool
+}
+
+// Package is the result of one test binary
+type Package struct {
+	Name    string
+	Status  Status
+	Elapsed time.Duration
+	Tests   []*Test
+}
+
+// Report is every package in a go test -json stream, in the order they
+// started
+type Report struct {
+	Packages []*Package
+}
+
+// Parse reads a go test -json stream. Lines that are not JSON, such as
+// stderr interleaved by `2>&1`, are ignored. Tests that never finished,
+// because the binary panicked or timed out, are reported as failed.
+func Parse(r io.Reader) (*Report, error) {
+	report := &Report{}
+	packages := map[string]*Package{}
+	tests := map[string]map[string]*Test{}
+	output := map[*Test]*strings.Builder{}
+
+	pkg := func(name string) *Package {
+		p, ok := packages[name]
+		if !ok {
+			p = &Package{Name: name}
+			packages[name] = p
+			tests[name] = map[string]*Test{}
+			report.Packages = append(report.Packages, p)
+		}
+		return p
+	}
+
+	test := func(p *Package, name string) *Test {
+		t, ok := tests[p.Name][name]
+		if !ok {
+			t = &Test{Package: p.Name, Name: name}
+			tests[p.Name][name] = t
+			output[t] = &strings.Builder{}
+			p.Tests = append(p.Tests, t)
+			if i := strings.LastIndex(name, "/"); i >= 0 {
+				if parent, ok := tests[p.Name][name[:i]]; ok {
+					parent.Parent = true
+				}
+			}
+		}
+		return t
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var e event
+		if err := json.Unmarshal(line, &e); err != nil || e.Package == "" {
+			continue
+		}
+
+		p := pkg(e.Package)
+		if e.Test == "" {
+			switch Status(e.Action) {
+			case Pass, Fail, Skip:
+				p.Status = Status(e.Action)
+				p.Elapsed = seconds(e.Elapsed)
+			}
+			continue
+		}
+
+		t := test(p, e.Test)
+		switch e.Action {
+		case "output":
+			output[t].WriteString(e.Output)
+		case string(Pass), string(Fail), string(Skip):
+			t.Status = Status(e.Action)
+			t.Elapsed = seconds(e.Elapsed)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("testreport: reading go test output: %w", err)
+	}
+
+	for _, p := range report.Packages {
+		failed := false
+		for _, t := range p.Tests {
+			t.Output = output[t].String()
+			if t.Status == "" {
+				t.Status = Fail
+				t.Output += "--- test did not finish\n"
+			}
+			if t.Status == Fail {
+				failed = true
+			}
+			t.Facade, t.Provider = Classify(t.Package, t.Name)
+		}
+		if p.Status == "" {
+			p.Status = Fail
+		}
+		if p.Status == Fail && !failed {
+			t := &Test{Package: p.Name, Name: PackageTest, Status: Fail, Elapsed: p.Elapsed}
+			t.Facade, t.Provider = Classify(p.Name, "")
+			p.Tests = append(p.Tests, t)
+		}
+	}
+	return report, nil
+}
+
+// Failed reports whether any package or test in the report failed
+func (r *Report) Failed() bool {
+	for _, p := range r.Packages {
+		if p.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// Counted returns the tests that make up the matrix: tests without
+// subtests, plus parents that failed without a failing subtest, so a
+// parent's time and its subtests' are not counted twice
+func (r *Report) Counted() []*Test {
+	var counted []*Test
+	for _, p := range r.Packages {
+		for _, t := range p.Tests {
+			if !t.Parent || (t.Status == Fail && !subtestFailed(p, t)) {
+				counted = append(counted, t)
+			}
+		}
+	}
+	return counted
+}
+
+func subtestFailed(p *Package, parent *Test) bool {
+	prefix := parent.Name + "/"
+	for _, t := range p.Tests {
+		if t.Status == Fail && strings.HasPrefix(t.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
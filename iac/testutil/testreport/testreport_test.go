@@ -0,0 +1,137 @@
+package testreport_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"iac/testutil/testreport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFile(t *testing.T, path string) *testreport.Report {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	report, err := testreport.Parse(f)
+	require.NoError(t, err)
+	return report
+}
+
+func findTest(t *testing.T, report *testreport.Report, pkg, name string) *testreport.Test {
+	t.Helper()
+
+	for _, p := range report.Packages {
+		for _, test := range p.Tests {
+			if test.Package == pkg && test.Name == name {
+				return test
+			}
+		}
+	}
+	require.Failf(t, "test not found", "%s %s", pkg, name)
+	return nil
+}
+
+func TestParseInterleavedParallelTests(t *testing.T) {
+	t.Parallel()
+
+	report := parseFile(t, "testdata/suite.jsonl")
+
+	var names []string
+	for _, p := range report.Packages {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"iac/facade/storage", "iac/facade/messaging", "iac/aws/test", "iac"}, names)
+	assert.Equal(t, testreport.Fail, report.Packages[0].Status)
+	assert.Equal(t, 7300*time.Millisecond, report.Packages[0].Elapsed)
+	assert.True(t, report.Failed())
+
+	aws := findTest(t, report, "iac/facade/storage", "TestStorageFacadeAws")
+	assert.Equal(t, testreport.Pass, aws.Status)
+	assert.Equal(t, 3250*time.Millisecond, aws.Elapsed)
+	assert.Equal(t, "=== RUN   TestStorageFacadeAws\n=== PAUSE TestStorageFacadeAws\n=== CONT  TestStorageFacadeAws\n"+
+		"    storage_test.go:30: planning\n--- PASS: TestStorageFacadeAws (3.25s)\n", aws.Output,
+		"Output of parallel tests is separated by test")
+
+	zero := findTest(t, report, "iac/facade/storage", "TestStorageFacadeZero")
+	assert.Equal(t, testreport.Fail, zero.Status)
+	assert.Contains(t, zero.Output, `expected "zero", actual ""`)
+	assert.NotContains(t, zero.Output, "GCP credentials")
+
+	gcp := findTest(t, report, "iac/facade/storage", "TestStorageFacadeGcp")
+	assert.Equal(t, testreport.Skip, gcp.Status)
+	assert.Equal(t, "storage", gcp.Facade)
+	assert.Equal(t, "gcp", gcp.Provider)
+
+	snapshot := findTest(t, report, "iac/facade/storage", "TestStorageFacadePlanSnapshot")
+	assert.True(t, snapshot.Parent)
+	assert.False(t, findTest(t, report, "iac/facade/storage", "TestStorageFacadePlanSnapshot/aws").Parent)
+}
+
+func TestCountedSkipsPassingParents(t *testing.T) {
+	t.Parallel()
+
+	report := parseFile(t, "testdata/suite.jsonl")
+
+	for _, test := range report.Counted() {
+		assert.NotEqual(t, "TestStorageFacadePlanSnapshot", test.Name, "The parent's subtests are counted instead")
+	}
+	assert.Len(t, report.Counted(), 13)
+}
+
+func TestCountedKeepsParentsFailingOnTheirOwn(t *testing.T) {
+	t.Parallel()
+
+	stream := strings.Join([]string{
+		`{"Action":"run","Package":"iac/facade/storage","Test":"TestStorageFacadePlanSnapshot"}`,
+		`{"Action":"run","Package":"iac/facade/storage","Test":"TestStorageFacadePlanSnapshot/aws"}`,
+		`{"Action":"pass","Package":"iac/facade/storage","Test":"TestStorageFacadePlanSnapshot/aws","Elapsed":1}`,
+		`{"Action":"output","Package":"iac/facade/storage","Test":"TestStorageFacadePlanSnapshot","Output":"    snapshot_test.go:40: cleanup failed\n"}`,
+		`{"Action":"fail","Package":"iac/facade/storage","Test":"TestStorageFacadePlanSnapshot","Elapsed":1.5}`,
+		`{"Action":"fail","Package":"iac/facade/storage","Elapsed":1.6}`,
+	}, "\n")
+
+	report, err := testreport.Parse(strings.NewReader(stream))
+	require.NoError(t, err)
+
+	var counted []string
+	for _, test := range report.Counted() {
+		counted = append(counted, test.Name)
+	}
+	assert.Equal(t, []string{"TestStorageFacadePlanSnapshot", "TestStorageFacadePlanSnapshot/aws"}, counted)
+}
+
+func TestParseUnfinishedTestsAndBuildFailures(t *testing.T) {
+	t.Parallel()
+
+	report := parseFile(t, "testdata/incomplete.jsonl")
+	require.Len(t, report.Packages, 2)
+
+	build := report.Packages[0]
+	assert.Equal(t, "iac/facade/nosql", build.Name)
+	assert.Equal(t, testreport.Fail, build.Status)
+	require.Len(t, build.Tests, 1, "A package failing without a failed test gets a result of its own")
+	assert.Equal(t, testreport.PackageTest, build.Tests[0].Name)
+	assert.Equal(t, testreport.Fail, build.Tests[0].Status)
+	assert.Equal(t, "nosql", build.Tests[0].Facade)
+
+	timedOut := findTest(t, report, "iac/facade/database", "TestDatabaseFacadeAws")
+	assert.Equal(t, testreport.Fail, timedOut.Status, "A test that never finished failed")
+	assert.Contains(t, timedOut.Output, "panic: test timed out")
+	assert.Len(t, report.Packages[1].Tests, 1, "The package has a failed test, so no package result is added")
+}
+
+func TestParseEmptyStream(t *testing.T) {
+	t.Parallel()
+
+	report, err := testreport.Parse(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, report.Packages)
+	assert.False(t, report.Failed())
+}
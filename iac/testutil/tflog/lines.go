@@ -0,0 +1,132 @@
+package tflog
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Level is how a Terraform output line is treated
+type Level int
+
+const (
+	// Detail lines only go to the log file
+	Detail Level = iota
+
+	// Summary lines report the outcome of a command ("Apply complete!
+	// Resources: 3 added, ...") and are surfaced on the test output
+	Summary
+
+	// Warning and Error lines are surfaced on the test output
+	Warning
+	Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Summary:
+		return "SUMMARY"
+	case Warning:
+		return "WARN"
+	case Error:
+		return "ERROR"
+	}
+	return "DETAIL"
+}
+
+// ansiEscape matches the color codes Terraform emits without -no-color
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// summaryPrefixes start the lines Terraform ends a command with
+var summaryPrefixes = []string{
+	"Apply complete!",
+	"Destroy complete!",
+	"Plan:",
+	"No changes.",
+	"Terraform has been successfully initialized!",
+	"Import successful!",
+}
+
+// Clean strips color codes and the box-drawing gutter Terraform draws
+// around diagnostics ("│ Error: ...", "╷", "╵")
+func Clean(line string) string {
+	line = ansiEscape.ReplaceAllString(line, "")
+	line = strings.TrimSpace(line)
+	for _, gutter := range []string{"│", "╷", "╵"} {
+		line = strings.TrimSpace(strings.TrimPrefix(line, gutter))
+	}
+	return line
+}
+
+// Classify returns the level of one line of Terraform output
+func Classify(line string) Level {
+	line = Clean(line)
+	// Diagnostics start with "Error:"; TF_LOG lines carry a timestamp
+	// before their level
+	switch {
+	case strings.HasPrefix(line, "Error:"), strings.Contains(line, "[ERROR]"):
+		return Error
+	case strings.HasPrefix(line, "Warning:"), strings.Contains(line, "[WARN]"):
+		return Warning
+	}
+	for _, prefix := range summaryPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return Summary
+		}
+	}
+	return Detail
+}
+
+// IsPlanJSON reports whether line is the output of terraform show -json for
+// a saved plan (state JSON has no planned_values)
+func IsPlanJSON(line string) bool {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") || !strings.Contains(line, `"format_version"`) {
+		return false
+	}
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(line), &top); err != nil {
+		return false
+	}
+	_, ok := top["planned_values"]
+	return ok
+}
+
+// Tail keeps the last lines written to it
+type Tail struct {
+	lines []string
+	next  int
+	full  bool
+}
+
+// NewTail returns a Tail keeping n lines
+func NewTail(n int) *Tail {
+	return &Tail{lines: make([]string, n)}
+}
+
+// Add records one line, dropping the oldest once n are kept
+func (t *Tail) Add(line string) {
+	if len(t.lines) == 0 {
+		return
+	}
+	t.lines[t.next] = line
+	t.next = (t.next + 1) % len(t.lines)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// Lines returns the kept lines, oldest first
+func (t *Tail) Lines() []string {
+	if !t.full {
+		return append([]string(nil), t.lines[:t.next]...)
+	}
+	return append(append([]string(nil), t.lines[t.next:]...), t.lines[:t.next]...)
+}
+
+// splitLines splits a logged message into lines. Terratest logs command
+// output one line at a time, but its own messages and a test's may span
+// several.
+func splitLines(msg string) []string {
+	return strings.Split(strings.TrimRight(msg, "\n"), "\n")
+}
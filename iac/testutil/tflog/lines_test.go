@@ -0,0 +1,91 @@
+package tflog_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/tflog"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]tflog.Level{
+		"module.storage.aws_s3_bucket.this: Creating...":                            tflog.Detail,
+		"│ Error: creating S3 Bucket (x): BucketAlreadyExists":                      tflog.Error,
+		"\x1b[31m│\x1b[0m \x1b[1m\x1b[31mError: \x1b[0m\x1b[0m\x1b[1mInvalid value": tflog.Error,
+		"2024-01-01T00:00:00Z [ERROR] provider: plugin exited":                      tflog.Error,
+		"2024-01-01T00:00:00Z [WARN]  Provider produced an invalid plan":            tflog.Warning,
+		"╷":                                 tflog.Detail,
+		"│ Warning: Argument is deprecated": tflog.Warning,
+		"Apply complete! Resources: 3 added, 0 changed, 0 destroyed.": tflog.Summary,
+		"Destroy complete! Resources: 3 destroyed.":                   tflog.Summary,
+		"Plan: 2 to add, 0 to change, 0 to destroy.":                  tflog.Summary,
+		"No changes. Your infrastructure matches the configuration.":  tflog.Summary,
+		"  # module.storage.aws_s3_bucket.this will be created":       tflog.Detail,
+		"      + error_document = (known after apply)":                tflog.Detail,
+	}
+
+	for line, want := range tests {
+		assert.Equal(t, want, tflog.Classify(line), "%q", line)
+	}
+}
+
+func TestClean(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Error: Invalid value", tflog.Clean("\x1b[31m│\x1b[0m \x1b[1m\x1b[31mError: \x1b[0m\x1b[0m\x1b[1mInvalid value\x1b[0m"))
+	assert.Equal(t, "", tflog.Clean("╵"))
+	assert.Equal(t, "on main.tf line 3:", tflog.Clean("│   on main.tf line 3:"))
+}
+
+func TestIsPlanJSON(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, tflog.IsPlanJSON(`{"format_version":"1.2","planned_values":{"root_module":{}}}`))
+	assert.False(t, tflog.IsPlanJSON(`{"format_version":"1.0","values":{"root_module":{}}}`), "State JSON is not a plan")
+	assert.False(t, tflog.IsPlanJSON(`{"format_version": truncated`))
+	assert.False(t, tflog.IsPlanJSON(`Plan: 1 to add, 0 to change, 0 to destroy.`))
+}
+
+func TestTail(t *testing.T) {
+	t.Parallel()
+
+	tail := tflog.NewTail(3)
+	assert.Empty(t, tail.Lines())
+
+	tail.Add("1")
+	tail.Add("2")
+	assert.Equal(t, []string{"1", "2"}, tail.Lines(), "Fewer lines than the capacity are kept as they are")
+
+	for i := 3; i <= 7; i++ {
+		tail.Add(fmt.Sprint(i))
+	}
+	assert.Equal(t, []string{"5", "6", "7"}, tail.Lines(), "Only the last three lines should be kept, oldest first")
+
+	tail.Add("8")
+	assert.Equal(t, []string{"6", "7", "8"}, tail.Lines())
+}
+
+func TestTailExactlyFull(t *testing.T) {
+	t.Parallel()
+
+	tail := tflog.NewTail(2)
+	tail.Add("a")
+	tail.Add("b")
+	assert.Equal(t, []string{"a", "b"}, tail.Lines())
+
+	assert.Empty(t, tflog.NewTail(0).Lines())
+}
+
+func TestArtifactName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "TestCloudEmuFullStack", tflog.ArtifactName("TestCloudEmuFullStack"))
+	assert.Equal(t, "TestEquivalence/providers/aws", filepath.ToSlash(tflog.ArtifactName("TestEquivalence/providers/aws")))
+	assert.Equal(t, "TestMatrix/bucket_name_with_spaces_", filepath.ToSlash(tflog.ArtifactName("TestMatrix/bucket_name_with_spaces:")))
+	assert.Equal(t, "TestX/_/_", filepath.ToSlash(tflog.ArtifactName("TestX/../")))
+}
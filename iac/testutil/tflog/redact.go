@@ -0,0 +1,360 @@
+package tflog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"iac/testutil/varcheck"
+)
+
+// MinLearnedLength is the shortest string a Redactor learns from a plan's
+// sensitive markers, or from one line of a multi-line value. Shorter ones,
+// such as a sensitive port or "admin", would blank out unrelated text
+// while hiding little; sensitivecheck draws the line in the same place.
+const MinLearnedLength = 6
+
+// Redacted returns what value is logged as: "(redacted:<sha8>)", with the
+// first eight hex digits of its SHA-256, so two lines holding the same
+// secret can still be matched up
+func Redacted(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "(redacted:" + hex.EncodeToString(sum[:])[:8] + ")"
+}
+
+// Redactor replaces sensitive values in Terraform output. Each value is
+// looked for as written, as terraform show -json escapes it (with and
+// without <, > and & escaped), and as plan text renders it in a quoted HCL
+// string; a multi-line value also has each of its lines looked for, since
+// plan text prints it as a heredoc.
+//
+// A value that starts or ends with a letter, digit or underscore is only
+// replaced where the neighbouring character is not one too, so a secret
+// "db" leaves aws_db_instance alone. An escape such as \n before the value
+// counts as a boundary. It is safe for concurrent use.
+type Redactor struct {
+	mu     sync.Mutex
+	values map[string]bool
+
+	// forms is replaced rather than appended to, so Redact can read it
+	// without holding mu
+	forms []form
+	first [256]bool
+}
+
+// form is one way a value can appear in output
+type form struct {
+	text        string
+	replacement string
+}
+
+// NewRedactor returns a Redactor for values; empty ones are ignored
+func NewRedactor(values ...string) *Redactor {
+	r := &Redactor{values: map[string]bool{}}
+	r.Add(values...)
+	return r
+}
+
+// Add makes r replace each of values as well
+func (r *Redactor) Add(values ...string) {
+	r.add(0, values)
+}
+
+func (r *Redactor) add(minLength int, values []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	forms := append([]form(nil), r.forms...)
+	seen := map[string]bool{}
+	for _, f := range forms {
+		seen[f.text] = true
+	}
+
+	added := false
+	for _, v := range values {
+		if v == "" || len(v) < minLength || r.values[v] {
+			continue
+		}
+		r.values[v] = true
+
+		replacement := Redacted(v)
+		texts := escapings(v)
+		if strings.Contains(v, "\n") {
+			for _, line := range strings.Split(v, "\n") {
+				if line = strings.TrimSpace(line); len(line) >= MinLearnedLength {
+					texts = append(texts, escapings(line)...)
+				}
+			}
+		}
+		for _, text := range texts {
+			if seen[text] {
+				continue
+			}
+			seen[text] = true
+			forms = append(forms, form{text: text, replacement: replacement})
+			r.first[text[0]] = true
+			added = true
+		}
+	}
+	if !added {
+		return
+	}
+
+	// Longest first, so a whole value wins over one of its lines
+	sort.SliceStable(forms, func(i, j int) bool { return len(forms[i].text) > len(forms[j].text) })
+	r.forms = forms
+}
+
+// escapings returns v and the ways JSON and HCL strings escape it
+func escapings(v string) []string {
+	texts := []string{v}
+	for _, escaped := range []string{jsonString(v, true), jsonString(v, false), hclString(v)} {
+		if escaped != v {
+			texts = append(texts, escaped)
+		}
+	}
+	return texts
+}
+
+// jsonString returns v as it appears between the quotes of a JSON string
+func jsonString(v string, escapeHTML bool) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(v); err != nil {
+		return v
+	}
+	s := strings.TrimSuffix(b.String(), "\n")
+	return s[1 : len(s)-1]
+}
+
+// hclString returns v as plan text renders it between the quotes of an
+// HCL string
+func hclString(v string) string {
+	quoted := strconv.Quote(v)
+	quoted = strings.ReplaceAll(quoted[1:len(quoted)-1], "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
+
+// Redact returns s with every value r knows replaced
+func (r *Redactor) Redact(s string) string {
+	r.mu.Lock()
+	forms, first := r.forms, r.first
+	r.mu.Unlock()
+
+	if len(forms) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(s); {
+		if !first[s[i]] {
+			i++
+			continue
+		}
+		f, ok := match(forms, s, i)
+		if !ok {
+			i++
+			continue
+		}
+		b.WriteString(s[last:i])
+		b.WriteString(f.replacement)
+		i += len(f.text)
+		last = i
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// match returns the longest form found at s[i:] that is not part of a
+// longer word
+func match(forms []form, s string, i int) (form, bool) {
+	for _, f := range forms {
+		if strings.HasPrefix(s[i:], f.text) && bounded(s, i, i+len(f.text)) {
+			return f, true
+		}
+	}
+	return form{}, false
+}
+
+// bounded reports whether s[i:j] does not run on into a word on either side
+func bounded(s string, i, j int) bool {
+	if i > 0 && isWord(s[i]) && isWord(s[i-1]) && !afterEscape(s, i) {
+		return false
+	}
+	if j < len(s) && isWord(s[j-1]) && isWord(s[j]) {
+		return false
+	}
+	return true
+}
+
+func isWord(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// afterEscape reports whether s[:i] ends with an escape such as \n or
+// \u003c, whose last character is a letter or digit but not part of a word
+func afterEscape(s string, i int) bool {
+	if i >= 2 && strings.IndexByte("bfnrt", s[i-1]) >= 0 && oddBackslashes(s, i-1) {
+		return true
+	}
+	if i >= 6 && s[i-5] == 'u' && isHex(s[i-4:i]) && oddBackslashes(s, i-5) {
+		return true
+	}
+	return false
+}
+
+// oddBackslashes reports whether s[:end] ends with an odd number of
+// backslashes, so the character at end is escaped
+func oddBackslashes(s string, end int) bool {
+	n := 0
+	for end-n-1 >= 0 && s[end-n-1] == '\\' {
+		n++
+	}
+	return n%2 == 1
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// markedPlan is the part of `terraform show -json` that says which values
+// are sensitive
+type markedPlan struct {
+	Variables map[string]struct {
+		Value interface{} `json:"value"`
+	} `json:"variables"`
+	OutputChanges   map[string]markedChange `json:"output_changes"`
+	ResourceChanges []struct {
+		Change markedChange `json:"change"`
+	} `json:"resource_changes"`
+	Configuration struct {
+		RootModule struct {
+			Variables map[string]struct {
+				Sensitive bool `json:"sensitive"`
+			} `json:"variables"`
+		} `json:"root_module"`
+	} `json:"configuration"`
+}
+
+// markedChange holds a change's values and their sensitivity masks: true
+// for a sensitive value, or an object or array of masks mirroring it
+type markedChange struct {
+	Before          interface{} `json:"before"`
+	After           interface{} `json:"after"`
+	BeforeSensitive interface{} `json:"before_sensitive"`
+	AfterSensitive  interface{} `json:"after_sensitive"`
+}
+
+// AddPlan makes r replace the strings planJSON marks sensitive: the values
+// of sensitive root variables and the sensitive parts of every resource
+// and output change. Strings shorter than MinLearnedLength are left out.
+func (r *Redactor) AddPlan(planJSON []byte) error {
+	var p markedPlan
+	if err := json.Unmarshal(planJSON, &p); err != nil {
+		return fmt.Errorf("tflog: decoding plan JSON: %w", err)
+	}
+
+	var values []string
+	collect := func(s string) { values = append(values, s) }
+
+	for name, variable := range p.Configuration.RootModule.Variables {
+		if variable.Sensitive {
+			sensitiveStrings(p.Variables[name].Value, true, collect)
+		}
+	}
+	changes := make([]markedChange, 0, len(p.OutputChanges)+len(p.ResourceChanges))
+	for _, c := range p.OutputChanges {
+		changes = append(changes, c)
+	}
+	for _, rc := range p.ResourceChanges {
+		changes = append(changes, rc.Change)
+	}
+	for _, c := range changes {
+		sensitiveStrings(c.Before, c.BeforeSensitive, collect)
+		sensitiveStrings(c.After, c.AfterSensitive, collect)
+	}
+
+	r.add(MinLearnedLength, values)
+	return nil
+}
+
+// sensitiveStrings calls visit with every string in value that mask marks
+// sensitive
+func sensitiveStrings(value, mask interface{}, visit func(string)) {
+	switch v := value.(type) {
+	case string:
+		if mask == true {
+			visit(v)
+		}
+	case map[string]interface{}:
+		masks, _ := mask.(map[string]interface{})
+		for key, item := range v {
+			itemMask := masks[key]
+			if mask == true {
+				itemMask = true
+			}
+			sensitiveStrings(item, itemMask, visit)
+		}
+	case []interface{}:
+		masks, _ := mask.([]interface{})
+		for i, item := range v {
+			var itemMask interface{}
+			if mask == true {
+				itemMask = true
+			} else if i < len(masks) {
+				itemMask = masks[i]
+			}
+			sensitiveStrings(item, itemMask, visit)
+		}
+	}
+}
+
+// SensitiveVars returns the strings in vars, the -var values of a
+// terraform.Options, that belong to the variables dir declares sensitive,
+// including those nested in maps and lists. Numbers and booleans are left
+// out.
+func SensitiveVars(dir string, vars map[string]interface{}) ([]string, error) {
+	if len(vars) == 0 {
+		return nil, nil
+	}
+
+	declared, err := varcheck.Inspect(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, v := range declared {
+		value, ok := vars[v.Name]
+		if !v.Sensitive || !ok {
+			continue
+		}
+		// Round-trip through JSON, so any map or slice type can be walked
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("tflog: encoding variable %s: %w", v.Name, err)
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return nil, fmt.Errorf("tflog: decoding variable %s: %w", v.Name, err)
+		}
+		sensitiveStrings(decoded, true, func(s string) { values = append(values, s) })
+	}
+	return values, nil
+}
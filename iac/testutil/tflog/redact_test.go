@@ -0,0 +1,151 @@
+package tflog_test
+
+import (
+	"testing"
+
+	"iac/testutil/tflog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedacted(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "(redacted:93848fb7)", tflog.Redacted("hunter2-secret"))
+	assert.Equal(t, tflog.Redacted("hunter2-secret"), tflog.Redacted("hunter2-secret"), "Equal values should redact alike")
+	assert.NotEqual(t, tflog.Redacted("hunter2-secret"), tflog.Redacted("hunter3-secret"))
+}
+
+func TestRedactorRedact(t *testing.T) {
+	t.Parallel()
+
+	const (
+		html      = "Xy7<k&9>Qz"
+		quoted    = `pa"ss\word`
+		bucket    = "bucket"
+		multiLine = "-----BEGIN KEY-----\nMIIBOgIBAAJBAK\n-----END KEY-----"
+		template  = "p${w}%{d}"
+	)
+	r := tflog.NewRedactor(html, quoted, bucket, multiLine, template, "")
+
+	tests := map[string]struct {
+		in, want string
+	}{
+		"command line": {
+			in:   "Running command terraform with args [plan -var master_password=" + html + "]",
+			want: "Running command terraform with args [plan -var master_password=" + tflog.Redacted(html) + "]",
+		},
+		"JSON with HTML escaped": {
+			in:   `{"master_password":{"value":"Xy7\u003ck\u00269\u003eQz"}}`,
+			want: `{"master_password":{"value":"` + tflog.Redacted(html) + `"}}`,
+		},
+		"JSON with HTML kept": {
+			in:   `{"value":"Xy7<k&9>Qz"}`,
+			want: `{"value":"` + tflog.Redacted(html) + `"}`,
+		},
+		"JSON with escaped quotes": {
+			in:   `{"value":"pa\"ss\\word"}`,
+			want: `{"value":"` + tflog.Redacted(quoted) + `"}`,
+		},
+		"HCL with escaped quotes": {
+			in:   `      + password = "pa\"ss\\word"`,
+			want: `      + password = "` + tflog.Redacted(quoted) + `"`,
+		},
+		"HCL template escapes": {
+			in:   `      + password = "p$${w}%%{d}"`,
+			want: `      + password = "` + tflog.Redacted(template) + `"`,
+		},
+		"substring of a resource name": {
+			in:   "module.aws_storage[0].aws_s3_bucket.this will be created",
+			want: "module.aws_storage[0].aws_s3_bucket.this will be created",
+		},
+		"whole word next to a resource name": {
+			in:   `aws_s3_bucket.this: name = "bucket"`,
+			want: `aws_s3_bucket.this: name = "` + tflog.Redacted(bucket) + `"`,
+		},
+		"multi-line value in JSON": {
+			in:   `{"value":"-----BEGIN KEY-----\nMIIBOgIBAAJBAK\n-----END KEY-----"}`,
+			want: `{"value":"` + tflog.Redacted(multiLine) + `"}`,
+		},
+		"multi-line value in a heredoc": {
+			in:   "            MIIBOgIBAAJBAK",
+			want: "            " + tflog.Redacted(multiLine),
+		},
+		"line of a multi-line value after a JSON escape": {
+			in:   `{"user_data":"#!/bin/sh\nMIIBOgIBAAJBAK\nexit 0"}`,
+			want: `{"user_data":"#!/bin/sh\n` + tflog.Redacted(multiLine) + `\nexit 0"}`,
+		},
+		"escaped backslash is not an escape": {
+			in:   `{"path":"C:\\nMIIBOgIBAAJBAK"}`,
+			want: `{"path":"C:\\nMIIBOgIBAAJBAK"}`,
+		},
+		"nothing to redact": {
+			in:   "Plan: 1 to add, 0 to change, 0 to destroy.",
+			want: "Plan: 1 to add, 0 to change, 0 to destroy.",
+		},
+	}
+
+	for name, tt := range tests {
+		assert.Equal(t, tt.want, r.Redact(tt.in), name)
+	}
+}
+
+func TestRedactorAddPlan(t *testing.T) {
+	t.Parallel()
+
+	r := tflog.NewRedactor()
+	require.NoError(t, r.AddPlan([]byte(`{
+		"variables": {
+			"master_password": {"value": "from-the-variable"},
+			"identifier": {"value": "orders-db"}
+		},
+		"configuration": {"root_module": {"variables": {
+			"master_password": {"sensitive": true},
+			"identifier": {}
+		}}},
+		"resource_changes": [{
+			"address": "aws_db_instance.this",
+			"change": {
+				"after": {"username": "admin", "endpoint": {"token": "from-the-provider", "port": "5432"}, "tags": ["visible-tag"]},
+				"after_sensitive": {"username": true, "endpoint": {"token": true, "port": true}, "tags": [false]},
+				"before": null,
+				"before_sensitive": false
+			}
+		}],
+		"output_changes": {
+			"connection": {"after": ["from-an-output"], "after_sensitive": true}
+		}
+	}`)))
+
+	for _, secret := range []string{"from-the-variable", "from-the-provider", "from-an-output"} {
+		assert.Equal(t, "x "+tflog.Redacted(secret), r.Redact("x "+secret), "%q is marked sensitive", secret)
+	}
+	for _, visible := range []string{"orders-db", "visible-tag", "admin", "5432"} {
+		assert.Equal(t, "x "+visible, r.Redact("x "+visible), "%q is not marked sensitive or is too short to hide", visible)
+	}
+
+	assert.Error(t, r.AddPlan([]byte(`{"variables":`)))
+}
+
+func TestSensitiveVars(t *testing.T) {
+	t.Parallel()
+
+	values, err := tflog.SensitiveVars("testdata/module", map[string]interface{}{
+		"identifier":      "orders-db",
+		"master_password": "Xy7<k&9>Qz",
+		"connection": map[string]interface{}{
+			"user":  "svc",
+			"token": "tok-123",
+			"port":  5432,
+		},
+		"undeclared": "ignored",
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Xy7<k&9>Qz", "svc", "tok-123"}, values,
+		"Strings in sensitive variables should be found however deeply nested, numbers left out")
+
+	none, err := tflog.SensitiveVars("testdata/missing", map[string]interface{}{"master_password": "x"})
+	require.NoError(t, err)
+	assert.Empty(t, none, "A directory without .tf files declares nothing sensitive")
+}
@@ -0,0 +1,21 @@
+variable "identifier" {
+  description = "Database identifier"
+  type        = string
+}
+
+variable "master_password" {
+  description = "Master password"
+  type        = string
+  sensitive   = true
+}
+
+variable "connection" {
+  description = "Credentials of the upstream service"
+  type = object({
+    user  = string
+    token = string
+    port  = number
+  })
+  default   = null
+  sensitive = true
+}
@@ -0,0 +1,269 @@
+// Package tflog captures each test's Terraform output in its own file, so a
+// failed apply can be read without untangling the interleaved output of
+// every parallel test.
+//
+// WithCapturedLogs gives the options a terratest Logger that writes every
+// line of init, plan, apply and destroy to
+// $SWE_TEST_ARTIFACT_DIR/<test name>/terraform.log, and the JSON of the last
+// shown plan to plan.json next to it. Only warnings, errors and each
+// command's closing summary reach the test output. When the test fails, the
+// log's path and its last 50 lines are printed.
+//
+// Without SWE_TEST_ARTIFACT_DIR the options are returned with the default
+// logger, so local runs print everything as before.
+//
+// Secrets never reach either: the variables the module declares sensitive
+// are read from the options' Vars, and each plan JSON adds the values it
+// marks sensitive, so a generated database password is logged as
+// "(redacted:<sha8>)" however it is quoted. WithSensitive applies the same
+// redaction to the default logger and to values the module does not
+// declare sensitive.
+package tflog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	tttesting "github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// Files written to each test's artifact directory
+const (
+	LogFile  = "terraform.log"
+	PlanFile = "plan.json"
+)
+
+// TailLines is how many lines of the log are printed when a test fails
+const TailLines = 50
+
+// EnvArtifactDir names the directory test logs are captured under
+const EnvArtifactDir = "SWE_TEST_ARTIFACT_DIR"
+
+// Options configures log capture
+type Options struct {
+	// ArtifactDir is the root of the per-test log directories. Empty means
+	// no capture.
+	ArtifactDir string
+}
+
+// LoadOptions reads Options from SWE_TEST_ARTIFACT_DIR
+func LoadOptions() Options {
+	return Options{ArtifactDir: strings.TrimRight(os.Getenv(EnvArtifactDir), "/")}
+}
+
+// WithCapturedLogs returns a copy of options whose Terraform output goes to
+// the test's artifact directory, if SWE_TEST_ARTIFACT_DIR is set. Options
+// for one test share a log, so a test with several configurations can call
+// it once for each; each starts its part with a line naming the module and
+// the CLI that runs it.
+func WithCapturedLogs(t *testing.T, options *terraform.Options) *terraform.Options {
+	t.Helper()
+
+	captured, err := options.Clone()
+	if err != nil {
+		t.Fatalf("tflog: copying terraform options: %v", err)
+	}
+
+	root := LoadOptions().ArtifactDir
+	if root == "" {
+		return captured
+	}
+
+	c, err := open(t, filepath.Join(root, ArtifactName(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := SensitiveVars(options.TerraformDir, options.Vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.redactor.Add(secrets...)
+	c.logf("=== %s %s with %s", time.Now().Format(time.RFC3339), options.TerraformDir, binary(options))
+
+	captured.Logger = logger.New(c)
+	return captured
+}
+
+// WithSensitive returns a copy of options whose logger replaces secrets
+// with Redacted before passing a line on, whether to the default logger or
+// to a capture from WithCapturedLogs. The secrets are values, the
+// variables in options.Vars that the module declares sensitive, and
+// whatever each plan JSON logged marks sensitive. Terratest logs every -var
+// on the command line and terraform show -json prints variables in full,
+// so a password passed to a plan otherwise reaches the test output. Apply
+// it after WithCapturedLogs, which replaces the logger.
+func WithSensitive(t *testing.T, options *terraform.Options, values ...string) *terraform.Options {
+	t.Helper()
+
+	redacted, err := options.Clone()
+	if err != nil {
+		t.Fatalf("tflog: copying terraform options: %v", err)
+	}
+
+	secrets, err := SensitiveVars(options.TerraformDir, options.Vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := options.Logger
+	if next == nil {
+		next = logger.Default
+	}
+	redacted.Logger = logger.New(&redactor{next: next, redactor: NewRedactor(append(secrets, values...)...)})
+	return redacted
+}
+
+// redactor is a terratest logger that hides secrets before logging
+type redactor struct {
+	next     *logger.Logger
+	redactor *Redactor
+}
+
+// Logf implements logger.TestLogger
+func (r *redactor) Logf(t tttesting.TestingT, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	for _, line := range splitLines(msg) {
+		if !IsPlanJSON(line) {
+			continue
+		}
+		if err := r.redactor.AddPlan([]byte(line)); err != nil {
+			// The plan is still redacted with the secrets already known
+			r.next.Logf(t, "%v", err)
+		}
+	}
+	r.next.Logf(t, "%s", r.redactor.Redact(msg))
+}
+
+// binary is the CLI options run, terratest's default when unset
+func binary(options *terraform.Options) string {
+	if options.TerraformBinary == "" {
+		return "terraform"
+	}
+	return options.TerraformBinary
+}
+
+// ArtifactName turns a test name into a relative directory: subtests nest
+// under their parent, and characters that are awkward in paths become "_"
+func ArtifactName(testName string) string {
+	parts := strings.Split(testName, "/")
+	for i, part := range parts {
+		part = unsafePathChars.ReplaceAllString(part, "_")
+		if part == "" || part == "." || part == ".." {
+			part = "_"
+		}
+		parts[i] = part
+	}
+	return filepath.Join(parts...)
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// capture is the terratest logger for one test
+type capture struct {
+	t   *testing.T
+	dir string
+
+	mu       sync.Mutex
+	file     *os.File
+	tail     *Tail
+	redactor *Redactor
+}
+
+var (
+	// captures holds the open capture of each test, so several options
+	// for one test share a file instead of truncating each other's
+	capturesMu sync.Mutex
+	captures   = make(map[*testing.T]*capture)
+)
+
+// open returns the capture for t, creating dir and truncating its log the
+// first time
+func open(t *testing.T, dir string) (*capture, error) {
+	capturesMu.Lock()
+	defer capturesMu.Unlock()
+
+	if c, ok := captures[t]; ok {
+		return c, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("tflog: creating %s: %w", dir, err)
+	}
+	file, err := os.Create(filepath.Join(dir, LogFile))
+	if err != nil {
+		return nil, fmt.Errorf("tflog: creating log: %w", err)
+	}
+
+	c := &capture{t: t, dir: dir, file: file, tail: NewTail(TailLines), redactor: NewRedactor()}
+	captures[t] = c
+	t.Cleanup(c.close)
+	return c, nil
+}
+
+// Logf implements logger.TestLogger. Terratest's TestingT is ignored: the
+// capture belongs to the test that created it.
+func (c *capture) Logf(_ tttesting.TestingT, format string, args ...interface{}) {
+	c.logf(format, args...)
+}
+
+func (c *capture) logf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, line := range splitLines(msg) {
+		if IsPlanJSON(line) {
+			if err := c.redactor.AddPlan([]byte(line)); err != nil {
+				fmt.Fprintln(c.file, err)
+			}
+			c.writePlan(c.redactor.Redact(line))
+			line = fmt.Sprintf("(plan JSON written to %s)", PlanFile)
+		}
+
+		line = c.redactor.Redact(line)
+		fmt.Fprintln(c.file, line)
+		c.tail.Add(line)
+
+		if level := Classify(line); level != Detail {
+			c.t.Logf("[%s] %s", level, Clean(line))
+		}
+	}
+}
+
+// writePlan replaces plan.json. c.mu must be held.
+func (c *capture) writePlan(planJSON string) {
+	path := filepath.Join(c.dir, PlanFile)
+	if err := os.WriteFile(path, []byte(planJSON+"\n"), 0o644); err != nil {
+		fmt.Fprintf(c.file, "tflog: writing %s: %v\n", path, err)
+	}
+}
+
+// close runs when the test finishes, printing where the log is, and its
+// tail, if the test failed
+func (c *capture) close() {
+	capturesMu.Lock()
+	delete(captures, c.t)
+	capturesMu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.file.Name()
+	if err := c.file.Close(); err != nil {
+		c.t.Errorf("tflog: closing %s: %v", path, err)
+	}
+
+	if c.t.Failed() {
+		lines := c.tail.Lines()
+		c.t.Logf("Terraform log: %s\nLast %d lines:\n%s", path, len(lines), strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,154 @@
+package tflog_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/tflog"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	tttesting "github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCapturedLogs(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(tflog.EnvArtifactDir, root)
+
+	original := &terraform.Options{TerraformDir: "fixtures/example"}
+	options := tflog.WithCapturedLogs(t, original)
+	require.NotNil(t, options.Logger)
+	assert.Nil(t, original.Logger, "The caller's options should be left alone")
+
+	// As terratest logs command output: one line per call
+	for _, line := range []string{
+		"Running command terraform with args [apply -auto-approve -input=false]",
+		"module.storage.aws_s3_bucket.this: Creating...",
+		"│ Error: creating S3 Bucket: BucketAlreadyExists",
+		`{"format_version":"1.2","planned_values":{"root_module":{}}}`,
+	} {
+		options.Logger.Logf(t, "%s", line)
+	}
+
+	// A second configuration of the same test appends to the same log
+	second := tflog.WithCapturedLogs(t, &terraform.Options{TerraformDir: "fixtures/other", TerraformBinary: "tofu"})
+	second.Logger.Logf(t, "%s", "Apply complete! Resources: 1 added, 0 changed, 0 destroyed.")
+
+	dir := filepath.Join(root, "TestWithCapturedLogs")
+	log, err := os.ReadFile(filepath.Join(dir, tflog.LogFile))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(log)), "\n")
+	require.Len(t, lines, 7)
+	assert.Contains(t, lines[0], "fixtures/example with terraform")
+	assert.Equal(t, "│ Error: creating S3 Bucket: BucketAlreadyExists", lines[3])
+	assert.Equal(t, "(plan JSON written to plan.json)", lines[4], "Plan JSON should not bloat the log")
+	assert.Contains(t, lines[5], "fixtures/other with tofu", "The log should record which CLI wrote each part")
+
+	plan, err := os.ReadFile(filepath.Join(dir, tflog.PlanFile))
+	require.NoError(t, err)
+	assert.Equal(t, `{"format_version":"1.2","planned_values":{"root_module":{}}}`+"\n", string(plan))
+}
+
+func TestWithCapturedLogsDisabled(t *testing.T) {
+	t.Setenv(tflog.EnvArtifactDir, "")
+
+	options := tflog.WithCapturedLogs(t, &terraform.Options{TerraformDir: "."})
+	assert.Nil(t, options.Logger, "Without an artifact directory the default logger should be kept")
+	assert.Equal(t, ".", options.TerraformDir)
+}
+
+// lineRecorder is a terratest logger keeping what it is given
+type lineRecorder struct{ lines []string }
+
+func (r *lineRecorder) Logf(_ tttesting.TestingT, format string, args ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func TestWithSensitive(t *testing.T) {
+	const secret = "Xy7<k&9>Qz"
+
+	recorder := &lineRecorder{}
+	original := &terraform.Options{TerraformDir: ".", Logger: logger.New(recorder)}
+	options := tflog.WithSensitive(t, original, secret, "")
+
+	options.Logger.Logf(t, "Running command terraform with args [plan -var master_password=%s]", secret)
+	options.Logger.Logf(t, "%s", `{"variables":{"master_password":{"value":"Xy7\u003ck\u00269\u003eQz"}}}`)
+
+	require.Len(t, recorder.lines, 2)
+	assert.Equal(t, "Running command terraform with args [plan -var master_password="+tflog.Redacted(secret)+"]", recorder.lines[0])
+	assert.Equal(t, `{"variables":{"master_password":{"value":"`+tflog.Redacted(secret)+`"}}}`, recorder.lines[1], "JSON-escaped values should be hidden too")
+
+	original.Logger.Logf(t, "%s", secret)
+	assert.Equal(t, secret, recorder.lines[2], "The caller's logger should be left alone")
+}
+
+func TestWithSensitiveCapturedLogs(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(tflog.EnvArtifactDir, root)
+
+	options := tflog.WithSensitive(t, tflog.WithCapturedLogs(t, &terraform.Options{TerraformDir: "."}), "hunter2-secret")
+	options.Logger.Logf(t, "%s", "│ Error: password hunter2-secret rejected")
+
+	log, err := os.ReadFile(filepath.Join(root, "TestWithSensitiveCapturedLogs", tflog.LogFile))
+	require.NoError(t, err)
+	assert.NotContains(t, string(log), "hunter2")
+	assert.Contains(t, string(log), "password (redacted:93848fb7) rejected")
+}
+
+func TestWithSensitiveVars(t *testing.T) {
+	const secret = "from-the-options"
+
+	recorder := &lineRecorder{}
+	options := tflog.WithSensitive(t, &terraform.Options{
+		TerraformDir: "testdata/module",
+		Vars:         map[string]interface{}{"identifier": "orders-db", "master_password": secret},
+		Logger:       logger.New(recorder),
+	})
+
+	options.Logger.Logf(t, "Running command terraform with args [plan -var identifier=orders-db -var master_password=%s]", secret)
+	assert.Equal(t, "Running command terraform with args [plan -var identifier=orders-db -var master_password="+tflog.Redacted(secret)+"]", recorder.lines[0],
+		"Variables the module declares sensitive should be hidden without being listed")
+}
+
+func TestWithSensitivePlanMarkers(t *testing.T) {
+	recorder := &lineRecorder{}
+	options := tflog.WithSensitive(t, &terraform.Options{TerraformDir: ".", Logger: logger.New(recorder)})
+
+	plan := `{"format_version":"1.2","planned_values":{},"resource_changes":[{"change":{"after":{"token":"from-the-provider"},"after_sensitive":{"token":true}}}]}`
+	options.Logger.Logf(t, "%s", plan)
+	options.Logger.Logf(t, "%s", "token from-the-provider expired")
+
+	require.Len(t, recorder.lines, 2)
+	assert.NotContains(t, recorder.lines[0], "from-the-provider", "The plan that marks a value should be redacted itself")
+	assert.Equal(t, "token "+tflog.Redacted("from-the-provider")+" expired", recorder.lines[1], "Later lines should be redacted too")
+}
+
+func TestWithCapturedLogsRedactsPlan(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(tflog.EnvArtifactDir, root)
+
+	options := tflog.WithCapturedLogs(t, &terraform.Options{
+		TerraformDir: "testdata/module",
+		Vars:         map[string]interface{}{"master_password": "captured-secret"},
+	})
+	options.Logger.Logf(t, "%s", "Running command terraform with args [plan -var master_password=captured-secret]")
+	options.Logger.Logf(t, "%s", `{"format_version":"1.2","planned_values":{},"variables":{"master_password":{"value":"captured-secret"}},"output_changes":{"dsn":{"after":"postgres://app:from-the-plan@db","after_sensitive":true}}}`)
+
+	dir := filepath.Join(root, "TestWithCapturedLogsRedactsPlan")
+	log, err := os.ReadFile(filepath.Join(dir, tflog.LogFile))
+	require.NoError(t, err)
+	assert.NotContains(t, string(log), "captured-secret")
+	assert.Contains(t, string(log), tflog.Redacted("captured-secret"))
+
+	plan, err := os.ReadFile(filepath.Join(dir, tflog.PlanFile))
+	require.NoError(t, err)
+	assert.NotContains(t, string(plan), "captured-secret")
+	assert.NotContains(t, string(plan), "from-the-plan", "Values the plan marks sensitive should not reach plan.json")
+	assert.Contains(t, string(plan), tflog.Redacted("postgres://app:from-the-plan@db"))
+}
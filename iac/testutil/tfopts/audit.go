@@ -0,0 +1,188 @@
+package tfopts
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// EnvAuditStateBackend turns on audit mode: the -backend-config file of
+// the real state backend the modules are planned against, such as the
+// backend.hcl the State Backend facade writes
+const EnvAuditStateBackend = "SWE_AUDIT_STATE_BACKEND"
+
+// AuditPlanArgs are added to every plan in audit mode, through
+// TF_CLI_ARGS_plan, so a plan only compares the state with the real
+// objects and proposes no changes
+const AuditPlanArgs = "-refresh-only"
+
+// mutatingCommands are the CLI commands that write state or change
+// infrastructure, which RequireWritable refuses for audit options. state
+// is refused for the subcommands listed; the others are refused outright.
+var mutatingCommands = map[string][]string{
+	"apply":        nil,
+	"destroy":      nil,
+	"import":       nil,
+	"refresh":      nil,
+	"taint":        nil,
+	"untaint":      nil,
+	"force-unlock": nil,
+	"state":        {"mv", "push", "replace-provider", "rm"},
+}
+
+// AuditStateBackendFromEnv returns the backend configuration file in
+// SWE_AUDIT_STATE_BACKEND, which must exist, or "" when audit mode is off
+func AuditStateBackendFromEnv() (string, error) {
+	path := strings.TrimSpace(os.Getenv(EnvAuditStateBackend))
+	if path == "" {
+		return "", nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("tfopts: %s: %w", EnvAuditStateBackend, err)
+	}
+	return path, nil
+}
+
+// Audit turns options, which it changes in place, into read-only options
+// against the state backend configured in backendConfig:
+//
+//   - init reads backendConfig, through TF_CLI_ARGS_init as BackendConfig
+//     only passes key=value pairs, in place of any backend configuration
+//     the test gave, reconfiguring rather than migrating an existing state
+//   - no command takes the state lock, so an audit never blocks a deploy
+//   - plans are refresh-only (AuditPlanArgs)
+//   - apply, destroy and the other commands that write state fail the test
+//     when run through RequireWritable or the Apply and Destroy functions
+//     here, before the CLI starts
+//
+// The options carry SWE_AUDIT_STATE_BACKEND in their environment, which
+// marks them as audit options through copies (see IsAudit).
+func Audit(options *terraform.Options, backendConfig string) {
+	options.BackendConfig = nil
+	options.Reconfigure = true
+	options.MigrateState = false
+	options.Lock = false
+	options.LockTimeout = ""
+
+	env := maps.Clone(options.EnvVars)
+	if env == nil {
+		env = map[string]string{}
+	}
+	env["TF_CLI_ARGS_init"] = "-backend-config=" + backendConfig
+	env["TF_CLI_ARGS_plan"] = strings.TrimSpace(env["TF_CLI_ARGS_plan"] + " " + AuditPlanArgs)
+	env[EnvAuditStateBackend] = backendConfig
+	options.EnvVars = env
+}
+
+// IsAudit reports whether Audit made options read-only
+func IsAudit(options *terraform.Options) bool {
+	return options.EnvVars[EnvAuditStateBackend] != ""
+}
+
+// TestingT is the subset of testing.TB RequireWritable needs; *testing.T
+// satisfies it
+type TestingT interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+// RequireWritable fails t, and returns false, when options are audit
+// options and args, a command line as terratest would run it, writes state
+// or changes infrastructure. Call it before running such a command other
+// than through Apply or Destroy, e.g. an import.
+func RequireWritable(t TestingT, options *terraform.Options, args ...string) bool {
+	t.Helper()
+
+	if !IsAudit(options) {
+		return true
+	}
+	if command := mutatingCommand(args); command != "" {
+		t.Fatalf("tfopts: terraform %s refused: %s is set, so modules are only planned", command, EnvAuditStateBackend)
+		return false
+	}
+	return true
+}
+
+// Apply is terraform.Apply, refused for audit options. testing.TB stops
+// the test at Fatalf, so the Apply and Destroy functions never go on to
+// run a refused command.
+func Apply(t testing.TB, options *terraform.Options) string {
+	t.Helper()
+
+	RequireWritable(t, options, "apply")
+	return terraform.Apply(t, options)
+}
+
+// ApplyE is terraform.ApplyE, refused for audit options
+func ApplyE(t testing.TB, options *terraform.Options) (string, error) {
+	t.Helper()
+
+	RequireWritable(t, options, "apply")
+	return terraform.ApplyE(t, options)
+}
+
+// InitAndApply is terraform.InitAndApply, refused for audit options
+// before init runs
+func InitAndApply(t testing.TB, options *terraform.Options) string {
+	t.Helper()
+
+	RequireWritable(t, options, "apply")
+	return terraform.InitAndApply(t, options)
+}
+
+// InitAndApplyE is terraform.InitAndApplyE, refused for audit options
+// before init runs
+func InitAndApplyE(t testing.TB, options *terraform.Options) (string, error) {
+	t.Helper()
+
+	RequireWritable(t, options, "apply")
+	return terraform.InitAndApplyE(t, options)
+}
+
+// Destroy is terraform.Destroy, refused for audit options
+func Destroy(t testing.TB, options *terraform.Options) string {
+	t.Helper()
+
+	RequireWritable(t, options, "destroy")
+	return terraform.Destroy(t, options)
+}
+
+// DestroyE is terraform.DestroyE, refused for audit options
+func DestroyE(t testing.TB, options *terraform.Options) (string, error) {
+	t.Helper()
+
+	RequireWritable(t, options, "destroy")
+	return terraform.DestroyE(t, options)
+}
+
+// mutatingCommand returns the command args run, e.g. "state rm", if it is
+// one audit mode refuses, and "" otherwise
+func mutatingCommand(args []string) string {
+	var words []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			words = append(words, arg)
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+
+	subcommands, ok := mutatingCommands[words[0]]
+	if !ok {
+		return ""
+	}
+	if subcommands == nil {
+		return words[0]
+	}
+	for _, sub := range subcommands {
+		if len(words) > 1 && words[1] == sub {
+			return words[0] + " " + sub
+		}
+	}
+	return ""
+}
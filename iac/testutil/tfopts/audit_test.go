@@ -0,0 +1,131 @@
+package tfopts_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditOptions returns options New built with SWE_AUDIT_STATE_BACKEND
+// naming a backend.hcl, and its path
+func auditOptions(t *testing.T, options *terraform.Options) (*terraform.Options, string) {
+	t.Helper()
+
+	backendConfig := filepath.Join(t.TempDir(), "backend.hcl")
+	require.NoError(t, os.WriteFile(backendConfig, []byte("bucket = \"acme-tfstate\"\n"), 0o644))
+	t.Setenv(tfopts.EnvAuditStateBackend, backendConfig)
+
+	return tfopts.New(t, options), backendConfig
+}
+
+func TestAuditStateBackendFromEnv(t *testing.T) {
+	t.Setenv(tfopts.EnvAuditStateBackend, "")
+	path, err := tfopts.AuditStateBackendFromEnv()
+	require.NoError(t, err)
+	assert.Empty(t, path, "Audit mode should be off by default")
+
+	t.Setenv(tfopts.EnvAuditStateBackend, filepath.Join(t.TempDir(), "backend.hcl"))
+	_, err = tfopts.AuditStateBackendFromEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), tfopts.EnvAuditStateBackend)
+}
+
+func TestAuditDisablesLock(t *testing.T) {
+	options, backendConfig := auditOptions(t, &terraform.Options{
+		TerraformDir:  "fixtures/storage",
+		BackendConfig: map[string]interface{}{"bucket": "emulator-tfstate"},
+		EnvVars: map[string]string{
+			"AWS_REGION":       "us-east-1",
+			"TF_CLI_ARGS_init": "-backend-config=emulator.hcl",
+		},
+		Lock:        true,
+		LockTimeout: "60s",
+	})
+
+	assert.False(t, options.Lock)
+	assert.Empty(t, options.LockTimeout)
+	assert.Contains(t, terraform.FormatArgs(options, "plan", "-input=false"), "-lock=false")
+	assert.NotContains(t, terraform.FormatArgs(options, "init"), "-lock=true")
+
+	assert.Empty(t, options.BackendConfig, "The test's own backend settings should be dropped")
+	assert.Equal(t, "-backend-config="+backendConfig, options.EnvVars["TF_CLI_ARGS_init"])
+	assert.True(t, options.Reconfigure)
+	assert.False(t, options.MigrateState)
+	assert.Equal(t, "-refresh-only", options.EnvVars["TF_CLI_ARGS_plan"])
+	assert.Equal(t, "us-east-1", options.EnvVars["AWS_REGION"])
+}
+
+// recorder captures Fatalf instead of ending the enclosing test
+type recorder struct {
+	failed  bool
+	message string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed = true
+	r.message = fmt.Sprintf(format, args...)
+}
+
+func TestAuditRefusesWrites(t *testing.T) {
+	options, _ := auditOptions(t, &terraform.Options{TerraformDir: t.TempDir()})
+	require.True(t, tfopts.IsAudit(options))
+
+	for _, args := range [][]string{
+		{"apply", "-input=false", "-auto-approve"},
+		{"destroy", "-auto-approve"},
+		{"state", "rm", "aws_s3_bucket.this"},
+		{"-chdir=.", "import", "aws_s3_bucket.this", "acme"},
+	} {
+		r := &recorder{}
+		assert.False(t, tfopts.RequireWritable(r, options, args...), "%v should be refused", args)
+		assert.True(t, r.failed, "%v should fail the test", args)
+	}
+
+	r := &recorder{}
+	tfopts.RequireWritable(r, options, "apply")
+	assert.Equal(t, "tfopts: terraform apply refused: SWE_AUDIT_STATE_BACKEND is set, so modules are only planned", r.message)
+}
+
+func TestAuditAllowsReads(t *testing.T) {
+	options, _ := auditOptions(t, &terraform.Options{TerraformDir: t.TempDir()})
+
+	for _, args := range [][]string{
+		{"init", "-input=false"},
+		{"plan", "-input=false", "-out=plan.out"},
+		{"show", "-json", "plan.out"},
+		{"state", "list"},
+		{"output", "-json"},
+	} {
+		r := &recorder{}
+		assert.True(t, tfopts.RequireWritable(r, options, args...), "%v should be allowed", args)
+		assert.False(t, r.failed)
+	}
+}
+
+func TestRequireWritableWithoutAudit(t *testing.T) {
+	t.Setenv(tfopts.EnvAuditStateBackend, "")
+
+	options := tfopts.New(t, &terraform.Options{})
+	assert.False(t, tfopts.IsAudit(options))
+
+	r := &recorder{}
+	assert.True(t, tfopts.RequireWritable(r, options, "apply", "-auto-approve"))
+	assert.False(t, r.failed, "Options outside audit mode should apply")
+}
+
+func TestNewWithoutAuditKeepsLock(t *testing.T) {
+	t.Setenv(tfopts.EnvAuditStateBackend, "")
+
+	options := tfopts.New(t, &terraform.Options{Lock: true})
+	assert.True(t, options.Lock)
+	assert.NotContains(t, options.EnvVars, "TF_CLI_ARGS_plan")
+}
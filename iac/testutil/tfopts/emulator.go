@@ -0,0 +1,114 @@
+package tfopts
+
+import (
+	"fmt"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// Emulator is an emulator the integration tests deploy to, named as in
+// their skip messages
+type Emulator string
+
+// Emulators with a RetryPolicy
+const (
+	CloudEmu      Emulator = "CloudEmu"
+	CloudEmuAzure Emulator = "CloudEmu (Azure)"
+	CloudEmuGCP   Emulator = "CloudEmu (GCP)"
+	ZeroCloud     Emulator = "ZeroCloud"
+)
+
+// RetryPolicy is how Terraform commands against an emulator are retried
+type RetryPolicy struct {
+	MaxRetries         int
+	TimeBetweenRetries time.Duration
+
+	// Errors maps a regexp matching a transient error in Terraform's
+	// output to what it means, as RetryableTerraformErrors does
+	Errors map[string]string
+}
+
+// connectionRefused is an emulator whose port is not open yet, as while
+// it starts
+const connectionRefused = `connect: connection refused`
+
+// EmulatorRetries holds the transient errors of each emulator, which
+// terratest's defaults, written for AWS itself, miss. An emulator that is
+// still starting refuses connections, or resets them when a container
+// port is published ahead of the server, and CloudEmu (Azure) answers 409
+// while a namespace or account it is creating is still provisioning. The
+// retries are spaced to outlast a start or a provisioning.
+var EmulatorRetries = map[Emulator]RetryPolicy{
+	CloudEmu: {
+		MaxRetries:         6,
+		TimeBetweenRetries: 5 * time.Second,
+		Errors: map[string]string{
+			connectionRefused:                        "CloudEmu is not listening yet.",
+			`(read|write): connection reset by peer`: "CloudEmu dropped the connection while starting.",
+			`request send failed, .*": EOF`:          "CloudEmu closed the connection without answering.",
+			`StatusCode: 503, `:                      "CloudEmu answered 503 while a service starts, past the provider's own retries.",
+		},
+	},
+	CloudEmuAzure: {
+		MaxRetries:         6,
+		TimeBetweenRetries: 10 * time.Second,
+		Errors: map[string]string{
+			connectionRefused: "CloudEmu (Azure) is not listening yet.",
+			`unexpected status 409 \(409 Conflict\).*(Activating|[Pp]rovisioning)`: "CloudEmu (Azure) is still provisioning a namespace or account.",
+			`ContainerBeingDeleted`:  "CloudEmu (Azure) is still deleting a container of the same name.",
+			`unexpected status 503 `: "CloudEmu (Azure) answered 503 while a service starts.",
+		},
+	},
+	CloudEmuGCP: {
+		MaxRetries:         6,
+		TimeBetweenRetries: 5 * time.Second,
+		Errors: map[string]string{
+			connectionRefused:                           "CloudEmu (GCP) is not listening yet.",
+			`googleapi: Error 503: `:                    "CloudEmu (GCP) answered 503 while a service starts.",
+			`googleapi: Error 429: .*rateLimitExceeded`: "CloudEmu (GCP) throttled the request.",
+		},
+	},
+	ZeroCloud: {
+		MaxRetries:         4,
+		TimeBetweenRetries: 5 * time.Second,
+		Errors: map[string]string{
+			connectionRefused:                        "ZeroCloud is not listening yet.",
+			`(read|write): connection reset by peer`: "ZeroCloud dropped the connection while starting.",
+			`StatusCode: 503, `:                      "ZeroCloud answered 503 while a service starts.",
+		},
+	},
+}
+
+// WithEmulatorRetries is WithDefaultRetryableErrors for options deployed to
+// emulator: terratest's retries, those of the CLI, and the emulator's
+// transient errors from EmulatorRetries, retried as its policy says. Each
+// error's description names the emulator and the pattern, so terratest's
+// log of a retry says which entry matched. An emulator without a policy
+// fails t.
+func WithEmulatorRetries(t testing.TB, options *terraform.Options, emulator Emulator) *terraform.Options {
+	t.Helper()
+
+	policy, ok := EmulatorRetries[emulator]
+	if !ok {
+		t.Fatalf("tfopts: no retry policy for emulator %q", emulator)
+	}
+
+	retried := terraform.WithDefaultRetryableErrors(t, options)
+	maps.Copy(retried.RetryableTerraformErrors, describe(emulator, policy))
+	retried.MaxRetries = policy.MaxRetries
+	retried.TimeBetweenRetries = policy.TimeBetweenRetries
+	return New(t, retried)
+}
+
+// describe returns policy's errors with each description followed by
+// the emulator and the pattern, as terratest logs it on a retry
+func describe(emulator Emulator, policy RetryPolicy) map[string]string {
+	errors := make(map[string]string, len(policy.Errors))
+	for pattern, description := range policy.Errors {
+		errors[pattern] = fmt.Sprintf("%s (%s retry on %q)", description, emulator, pattern)
+	}
+	return errors
+}
@@ -0,0 +1,136 @@
+package tfopts_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emulatorErrors are errors as Terraform prints them against each
+// emulator: transient ones, which a pattern in the emulator's policy must
+// match, and lasting ones, which none may
+var emulatorErrors = map[tfopts.Emulator]struct {
+	transient []string
+	lasting   []string
+}{
+	tfopts.CloudEmu: {
+		transient: []string{
+			`Error: creating S3 Bucket (orders-dev-data): operation error S3: CreateBucket, exceeded maximum number of attempts, 3, https response error StatusCode: 0, RequestID: , HostID: , request send failed, Put "http://localhost:4566/orders-dev-data": dial tcp 127.0.0.1:4566: connect: connection refused`,
+			`Error: reading SQS Queue (http://localhost:4566/000000000000/orders): operation error SQS: GetQueueAttributes, exceeded maximum number of attempts, 3, https response error StatusCode: 0, RequestID: , request send failed, Post "http://localhost:4566/": read tcp 127.0.0.1:51234->127.0.0.1:4566: read: connection reset by peer`,
+			`Error: creating DynamoDB Table (orders): operation error DynamoDB: CreateTable, exceeded maximum number of attempts, 3, https response error StatusCode: 0, RequestID: , request send failed, Post "http://localhost:4566/": EOF`,
+			`Error: creating Lambda Function (orders): operation error Lambda: CreateFunction, exceeded maximum number of attempts, 3, https response error StatusCode: 503, RequestID: 7a1c, api error ServiceUnavailable: Service is starting`,
+		},
+		lasting: []string{
+			`Error: creating S3 Bucket (orders-dev-data): operation error S3: CreateBucket, https response error StatusCode: 409, RequestID: 9f2e, HostID: , BucketAlreadyOwnedByYou: Your previous request to create the named bucket succeeded and you already own it.`,
+			`Error: creating IAM Role (orders): operation error IAM: CreateRole, https response error StatusCode: 400, RequestID: 1b3d, api error MalformedPolicyDocument: Has prohibited field Resource`,
+			`Error: creating Lambda Function (orders): operation error Lambda: CreateFunction, https response error StatusCode: 500, RequestID: 5e6f, api error ServiceException: unsupported runtime nodejs4.3`,
+		},
+	},
+	tfopts.CloudEmuAzure: {
+		transient: []string{
+			`Error: creating Namespace (Subscription: "00000000-0000-0000-0000-000000000000" Resource Group Name: "orders-rg" Namespace Name: "orders"): performing CreateOrUpdate: unexpected status 409 (409 Conflict) with error: Conflict: Namespace orders is Activating and cannot be updated`,
+			`Error: creating Account (Subscription: "00000000-0000-0000-0000-000000000000" Resource Group Name: "orders-rg" Database Account Name: "orders"): performing DatabaseAccountsCreateOrUpdate: unexpected status 409 (409 Conflict) with error: Conflict: The account is still provisioning`,
+			`Error: creating Container "orders" (Account "ordersdata"): executing request: unexpected status 409 (409 The specified container is being deleted. Try operation later.) with ContainerBeingDeleted: The specified container is being deleted. Try operation later.`,
+			`Error: checking for existing Storage Account: Get "http://127.0.0.1:10000/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/orders-rg": dial tcp 127.0.0.1:10000: connect: connection refused`,
+			`Error: retrieving Queue: unexpected status 503 (503 Service Unavailable) with response: {"error":"starting"}`,
+		},
+		lasting: []string{
+			`Error: creating Namespace: performing CreateOrUpdate: unexpected status 409 (409 Conflict) with error: Conflict: a resource with the name "orders" already exists`,
+			`Error: creating Storage Account: unexpected status 400 (400 Bad Request) with error: AccountNameInvalid: orders_data is not a valid storage account name.`,
+			`Error: retrieving Queue: unexpected status 404 (404 Not Found) with error: MessagingEntityNotFound`,
+		},
+	},
+	tfopts.CloudEmuGCP: {
+		transient: []string{
+			`Error: googleapi: Error 503: The service is currently unavailable., backendError`,
+			`Error: googleapi: Error 429: The rate of change requests to the bucket is too high, rateLimitExceeded`,
+			`Error: Post "http://localhost:4567/storage/v1/b?alt=json&project=orders": dial tcp [::1]:4567: connect: connection refused`,
+		},
+		lasting: []string{
+			`Error: googleapi: Error 409: Your previous request to create the named bucket succeeded and you already own it., conflict`,
+			`Error: googleapi: Error 403: The caller does not have permission, forbidden`,
+		},
+	},
+	tfopts.ZeroCloud: {
+		transient: []string{
+			`Error: creating EC2 VPC: operation error EC2: CreateVpc, exceeded maximum number of attempts, 25, https response error StatusCode: 0, RequestID: , request send failed, Post "http://localhost:8080/": dial tcp 127.0.0.1:8080: connect: connection refused`,
+			`Error: creating S3 Bucket (zero-test): operation error S3: CreateBucket, https response error StatusCode: 0, RequestID: , HostID: , request send failed, Put "http://localhost:8080/zero-test": write tcp 127.0.0.1:51234->127.0.0.1:8080: write: connection reset by peer`,
+			`Error: creating EC2 Subnet: operation error EC2: CreateSubnet, exceeded maximum number of attempts, 25, https response error StatusCode: 503, RequestID: , api error Unavailable: network service starting`,
+		},
+		lasting: []string{
+			`Error: creating EC2 Subnet: operation error EC2: CreateSubnet, https response error StatusCode: 400, RequestID: , api error InvalidSubnet.Range: The CIDR '10.1.0.0/24' is invalid.`,
+		},
+	},
+}
+
+func TestEmulatorRetriesMatchTransientErrors(t *testing.T) {
+	t.Parallel()
+
+	require.Len(t, emulatorErrors, len(tfopts.EmulatorRetries), "Every emulator with a retry policy should have sample errors")
+
+	for emulator, samples := range emulatorErrors {
+		emulator, samples := emulator, samples
+
+		t.Run(string(emulator), func(t *testing.T) {
+			t.Parallel()
+
+			policy, ok := tfopts.EmulatorRetries[emulator]
+			require.True(t, ok, "No retry policy for %s", emulator)
+
+			matched := map[string]bool{}
+			for _, sample := range samples.transient {
+				patterns := matching(t, policy.Errors, sample)
+				assert.NotEmpty(t, patterns, "A %s retry should match:\n%s", emulator, sample)
+				for _, p := range patterns {
+					matched[p] = true
+				}
+			}
+			for _, sample := range samples.lasting {
+				assert.Empty(t, matching(t, policy.Errors, sample), "No %s retry should match:\n%s", emulator, sample)
+			}
+			for pattern := range policy.Errors {
+				assert.True(t, matched[pattern], "%s retries on %q, which no sample error matches", emulator, pattern)
+			}
+		})
+	}
+}
+
+// matching returns the patterns in errors that match output
+func matching(t *testing.T, errors map[string]string, output string) []string {
+	t.Helper()
+
+	var patterns []string
+	for pattern := range errors {
+		re, err := regexp.Compile(pattern)
+		require.NoError(t, err)
+		if re.MatchString(output) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func TestWithEmulatorRetries(t *testing.T) {
+	t.Setenv(tfopts.EnvBinary, "")
+
+	original := &terraform.Options{TerraformDir: "fixtures/storage"}
+	built := tfopts.WithEmulatorRetries(t, original, tfopts.CloudEmuAzure)
+
+	assert.Equal(t, "terraform", built.TerraformBinary)
+	assert.Equal(t, 6, built.MaxRetries)
+	assert.Equal(t, 10*time.Second, built.TimeBetweenRetries)
+	for pattern := range terraform.DefaultRetryableTerraformErrors {
+		assert.Contains(t, built.RetryableTerraformErrors, pattern, "terratest's retries should be kept")
+	}
+	assert.Equal(t,
+		`CloudEmu (Azure) is still deleting a container of the same name. (CloudEmu (Azure) retry on "ContainerBeingDeleted")`,
+		built.RetryableTerraformErrors["ContainerBeingDeleted"], "The description should name the emulator and the pattern")
+	assert.Empty(t, original.RetryableTerraformErrors, "The options passed in should not change")
+	assert.NotContains(t, tfopts.EmulatorRetries[tfopts.CloudEmuAzure].Errors["ContainerBeingDeleted"], "retry on", "The table should not change")
+}
@@ -0,0 +1,217 @@
+// Package tfopts builds the terraform.Options of every test, so the CLI the
+// suite runs, and what differs between CLIs, is decided in one place.
+//
+// Tests describe their module with a terraform.Options literal and pass it
+// through New, or WithDefaultRetryableErrors in place of terratest's:
+//
+//	options := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{
+//		TerraformDir: "fixtures/storage",
+//	})
+//
+// SWE_TF_BINARY selects the CLI: terraform (the default), tofu for
+// OpenTofu, or a path to either. OpenTofu installs providers from its own
+// registry, so its provider installation failures are retried like
+// Terraform's, and NormalizeProviderAddresses maps its provider addresses
+// in plan JSON onto Terraform's. Matrix runs a test under both CLIs when
+// both are installed.
+//
+// SWE_AUDIT_STATE_BACKEND turns on audit mode, planning against a real
+// state backend without writing to it (see Audit).
+package tfopts
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// EnvBinary selects the CLI the tests run
+const EnvBinary = "SWE_TF_BINARY"
+
+// CLIs SWE_TF_BINARY names, found on PATH
+const (
+	Terraform = "terraform"
+	OpenTofu  = "tofu"
+)
+
+// Registry hosts in provider addresses, e.g.
+// registry.terraform.io/hashicorp/aws
+const (
+	TerraformRegistry = "registry.terraform.io"
+	OpenTofuRegistry  = "registry.opentofu.org"
+)
+
+// OpenTofuRetryableErrors are the provider installation failures OpenTofu
+// reports in its own words, retried like the ones in terratest's
+// DefaultRetryableTerraformErrors
+var OpenTofuRetryableErrors = map[string]string{
+	".*Failed to install provider.*":                 "Failed to retrieve provider from the OpenTofu registry due to transient network error.",
+	".*Failed to retrieve provider package.*":        "Failed to retrieve provider from the OpenTofu registry due to transient network error.",
+	".*registry.opentofu.org.*(timeout|reset|EOF).*": "Failed to reach the OpenTofu registry due to transient network error.",
+}
+
+// BinaryFromEnv returns the CLI in SWE_TF_BINARY: Terraform when it is
+// unset, OpenTofu, or a path, which must exist
+func BinaryFromEnv() (string, error) {
+	binary := strings.TrimSpace(os.Getenv(EnvBinary))
+	switch binary {
+	case "":
+		return Terraform, nil
+	case Terraform, OpenTofu:
+		return binary, nil
+	}
+	if !strings.ContainsRune(binary, filepath.Separator) && !strings.ContainsRune(binary, '/') {
+		return "", fmt.Errorf("tfopts: %s=%q: want %s, %s or a path to either", EnvBinary, binary, Terraform, OpenTofu)
+	}
+	if _, err := os.Stat(binary); err != nil {
+		return "", fmt.Errorf("tfopts: %s: %w", EnvBinary, err)
+	}
+	return binary, nil
+}
+
+// IsOpenTofu reports whether binary is OpenTofu, going by its file name,
+// e.g. tofu or /opt/tofu/1.8.0/tofu
+func IsOpenTofu(binary string) bool {
+	return strings.HasPrefix(filepath.Base(binary), OpenTofu)
+}
+
+// New returns a copy of options for the CLI in SWE_TF_BINARY, unless
+// options.TerraformBinary already names one, as in Matrix and the version
+// matrix. Options with retryable errors get the CLI's provider
+// installation failures added to them, and with SWE_AUDIT_STATE_BACKEND
+// set the options are made read-only by Audit. An invalid SWE_TF_BINARY or
+// SWE_AUDIT_STATE_BACKEND fails t.
+func New(t testing.TB, options *terraform.Options) *terraform.Options {
+	t.Helper()
+
+	built, err := options.Clone()
+	if err != nil {
+		t.Fatalf("tfopts: copying terraform options: %v", err)
+	}
+
+	if built.TerraformBinary == "" {
+		binary, err := BinaryFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		built.TerraformBinary = binary
+	}
+
+	// Clone never leaves RetryableTerraformErrors nil, so the original
+	// says whether the options retry
+	if len(options.RetryableTerraformErrors) > 0 && IsOpenTofu(built.TerraformBinary) {
+		maps.Copy(built.RetryableTerraformErrors, OpenTofuRetryableErrors)
+	}
+
+	backendConfig, err := AuditStateBackendFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backendConfig != "" {
+		Audit(built, backendConfig)
+	}
+	return built
+}
+
+// WithDefaultRetryableErrors is terraform.WithDefaultRetryableErrors for
+// the CLI New selects: terratest's retries, plus OpenTofu's provider
+// installation failures when the CLI is OpenTofu
+func WithDefaultRetryableErrors(t testing.TB, options *terraform.Options) *terraform.Options {
+	t.Helper()
+
+	return New(t, terraform.WithDefaultRetryableErrors(t, options))
+}
+
+// Retry count and spacing terraform.WithDefaultRetryableErrors sets
+const (
+	DefaultMaxRetries         = 3
+	DefaultTimeBetweenRetries = 5 * time.Second
+)
+
+// DefaultRetries is the policy WithDefaultRetryableErrors gives options
+// for binary, for running the CLI outside terratest: terratest's
+// retryable errors, count and spacing, and OpenTofu's provider
+// installation failures when binary is OpenTofu
+func DefaultRetries(binary string) RetryPolicy {
+	errors := maps.Clone(terraform.DefaultRetryableTerraformErrors)
+	if IsOpenTofu(binary) {
+		maps.Copy(errors, OpenTofuRetryableErrors)
+	}
+	return RetryPolicy{
+		MaxRetries:         DefaultMaxRetries,
+		TimeBetweenRetries: DefaultTimeBetweenRetries,
+		Errors:             errors,
+	}
+}
+
+// Retries returns the policy terratest retries options' commands by, e.g.
+// to run the CLI outside terratest as a test's options would
+func Retries(options *terraform.Options) RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:         options.MaxRetries,
+		TimeBetweenRetries: options.TimeBetweenRetries,
+		Errors:             maps.Clone(options.RetryableTerraformErrors),
+	}
+}
+
+// Retryable returns the description of an error in p that output
+// matches, as terratest matches RetryableTerraformErrors, or ""
+func (p RetryPolicy) Retryable(output string) string {
+	for pattern, description := range p.Errors {
+		if matched, _ := regexp.MatchString(pattern, output); matched {
+			return description
+		}
+	}
+	return ""
+}
+
+// Installed returns Terraform and OpenTofu, those of them on PATH
+func Installed() []string {
+	var binaries []string
+	for _, binary := range []string{Terraform, OpenTofu} {
+		if _, err := exec.LookPath(binary); err == nil {
+			binaries = append(binaries, binary)
+		}
+	}
+	return binaries
+}
+
+// Matrix runs fn in a subtest named after each CLI when both Terraform and
+// OpenTofu are installed, and otherwise once, directly in t, with the CLI
+// in SWE_TF_BINARY. fn sets TerraformBinary to the binary it is given.
+func Matrix(t *testing.T, fn func(t *testing.T, binary string)) {
+	t.Helper()
+
+	installed := Installed()
+	if len(installed) < 2 {
+		binary, err := BinaryFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, binary)
+		return
+	}
+
+	for _, binary := range installed {
+		binary := binary
+		t.Run(binary, func(t *testing.T) {
+			fn(t, binary)
+		})
+	}
+}
+
+// NormalizeProviderAddresses rewrites the OpenTofu registry host in plan
+// or state JSON to Terraform's, so both CLIs produce the same provider
+// addresses, e.g. registry.terraform.io/hashicorp/aws
+func NormalizeProviderAddresses(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte(`"`+OpenTofuRegistry+`/`), []byte(`"`+TerraformRegistry+`/`))
+}
@@ -0,0 +1,143 @@
+package tfopts_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tofu")
+	require.NoError(t, os.WriteFile(path, nil, 0o755))
+
+	tests := map[string]struct {
+		value string
+		want  string
+		err   string
+	}{
+		"unset":   {value: "", want: "terraform"},
+		"tofu":    {value: "tofu", want: "tofu"},
+		"path":    {value: path, want: path},
+		"unknown": {value: "terragrunt", err: "want terraform, tofu or a path to either"},
+		"missing": {value: filepath.Join(t.TempDir(), "terraform"), err: "no such file"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(tfopts.EnvBinary, tc.value)
+
+			binary, err := tfopts.BinaryFromEnv()
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, binary)
+		})
+	}
+}
+
+func TestIsOpenTofu(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, tfopts.IsOpenTofu("tofu"))
+	assert.True(t, tfopts.IsOpenTofu("/opt/tofu/1.8.0/tofu"))
+	assert.False(t, tfopts.IsOpenTofu("terraform"))
+	assert.False(t, tfopts.IsOpenTofu("/home/ci/.cache/swe-iac/terraform/1.9.0/terraform"))
+}
+
+func TestNewSetsBinaryFromEnv(t *testing.T) {
+	t.Setenv(tfopts.EnvBinary, "tofu")
+
+	original := &terraform.Options{TerraformDir: "fixtures/storage"}
+	built := tfopts.New(t, original)
+
+	assert.Equal(t, "tofu", built.TerraformBinary)
+	assert.Equal(t, "fixtures/storage", built.TerraformDir)
+	assert.Empty(t, original.TerraformBinary, "The options passed in should not change")
+	assert.Empty(t, built.RetryableTerraformErrors, "Options without retries should not gain any")
+}
+
+func TestNewKeepsExplicitBinary(t *testing.T) {
+	t.Setenv(tfopts.EnvBinary, "tofu")
+
+	built := tfopts.New(t, &terraform.Options{TerraformBinary: "/opt/terraform/1.9.0/terraform"})
+	assert.Equal(t, "/opt/terraform/1.9.0/terraform", built.TerraformBinary)
+}
+
+func TestWithDefaultRetryableErrors(t *testing.T) {
+	t.Setenv(tfopts.EnvBinary, "")
+
+	built := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{})
+	assert.Equal(t, "terraform", built.TerraformBinary)
+	assert.Equal(t, terraform.DefaultRetryableTerraformErrors, built.RetryableTerraformErrors, "Terraform should keep terratest's retries")
+
+	t.Setenv(tfopts.EnvBinary, "tofu")
+
+	built = tfopts.WithDefaultRetryableErrors(t, &terraform.Options{})
+	for pattern := range terraform.DefaultRetryableTerraformErrors {
+		assert.Contains(t, built.RetryableTerraformErrors, pattern)
+	}
+	for pattern := range tfopts.OpenTofuRetryableErrors {
+		assert.Contains(t, built.RetryableTerraformErrors, pattern)
+	}
+	assert.NotContains(t, terraform.DefaultRetryableTerraformErrors, ".*Failed to install provider.*", "terratest's defaults should not be modified")
+}
+
+func TestDefaultRetriesMatchTerratest(t *testing.T) {
+	t.Setenv(tfopts.EnvBinary, "tofu")
+
+	built := tfopts.WithDefaultRetryableErrors(t, &terraform.Options{})
+	assert.Equal(t, tfopts.Retries(built), tfopts.DefaultRetries("tofu"), "The policy should be the one terratest retries the options by")
+	assert.Equal(t, terraform.DefaultRetryableTerraformErrors, tfopts.DefaultRetries("terraform").Errors)
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	policy := tfopts.DefaultRetries("tofu")
+
+	assert.NotEmpty(t, policy.Retryable("Error: Failed to install provider hashicorp/aws"))
+	assert.Empty(t, policy.Retryable("Error: Invalid provider configuration"))
+}
+
+func TestNormalizeProviderAddresses(t *testing.T) {
+	t.Parallel()
+
+	plan := `{"resource_changes":[{"address":"aws_s3_bucket.this","provider_name":"registry.opentofu.org/hashicorp/aws"}],` +
+		`"variables":{"note":{"value":"mirrors registry.opentofu.org/hashicorp/aws"}}}`
+
+	assert.Equal(t, `{"resource_changes":[{"address":"aws_s3_bucket.this","provider_name":"registry.terraform.io/hashicorp/aws"}],`+
+		`"variables":{"note":{"value":"mirrors registry.opentofu.org/hashicorp/aws"}}}`,
+		string(tfopts.NormalizeProviderAddresses([]byte(plan))), "Only whole provider addresses should be rewritten")
+}
+
+func TestMatrixSingleBinary(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv(tfopts.EnvBinary, "tofu")
+
+	var binaries []string
+	tfopts.Matrix(t, func(t *testing.T, binary string) {
+		binaries = append(binaries, binary)
+	})
+	assert.Equal(t, []string{"tofu"}, binaries, "Without both CLIs installed the test should run once with SWE_TF_BINARY")
+}
+
+func TestMatrixBothBinaries(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"terraform", "tofu"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0o755))
+	}
+	t.Setenv("PATH", dir)
+
+	var binaries []string
+	tfopts.Matrix(t, func(t *testing.T, binary string) {
+		binaries = append(binaries, binary)
+		assert.Contains(t, t.Name(), "/"+binary, "Each CLI should run in a subtest of its own")
+	})
+	assert.Equal(t, []string{"terraform", "tofu"}, binaries)
+}
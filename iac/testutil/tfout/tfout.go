@@ -0,0 +1,375 @@
+// Package tfout decodes every output of a Terraform workspace into a struct
+// in one `terraform output -json`, instead of a terraform.Output call and a
+// string key per value:
+//
+//	type FullStackOutputs struct {
+//		BucketName string            `tfout:"bucket_name"`
+//		Commands   map[string]string `tfout:"verification_commands"`
+//		Password   tfout.Sensitive[string] `tfout:"admin_password"`
+//	}
+//
+//	outputs := tfout.OutputsAs[FullStackOutputs](t, terraformOptions)
+//
+// Fields are matched by their tfout tag; untagged fields are left alone.
+// Nested structs match object attributes the same way, and slices, maps,
+// numbers and bools take lists, maps, numbers and bools. A tag of
+// "name,optional" allows the output to be missing. Every missing or
+// mismatched field is reported at once.
+package tfout
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// Sensitive holds the value of an output declared sensitive = true. It
+// formats as "(sensitive)" so the value does not reach test logs through
+// %v or an assertion message, and decoding errors never quote it.
+type Sensitive[T any] struct {
+	value T
+}
+
+// NewSensitive wraps a value, for building expected structs in tests
+func NewSensitive[T any](value T) Sensitive[T] {
+	return Sensitive[T]{value: value}
+}
+
+// Value returns the wrapped value
+func (s Sensitive[T]) Value() T {
+	return s.value
+}
+
+// String redacts the value
+func (s Sensitive[T]) String() string {
+	return "(sensitive)"
+}
+
+// GoString redacts the value from %#v
+func (s Sensitive[T]) GoString() string {
+	return "(sensitive)"
+}
+
+// target lets the decoder fill the wrapped value of any Sensitive[T]
+func (s *Sensitive[T]) target() reflect.Value {
+	return reflect.ValueOf(&s.value).Elem()
+}
+
+type sensitiveTarget interface {
+	target() reflect.Value
+}
+
+// output is one entry of `terraform output -json`
+type output struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// OutputsAs runs `terraform output -json` in options.TerraformDir and
+// decodes it into a T, failing t with every missing or mismatched field
+func OutputsAs[T any](t testing.TB, options *terraform.Options) T {
+	t.Helper()
+
+	out, err := terraform.OutputJsonE(t, options, "")
+	if err != nil {
+		t.Fatalf("tfout: terraform output: %v", err)
+	}
+	v, err := Decode[T]([]byte(out))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return v
+}
+
+// Decode decodes `terraform output -json` into a T, which must be a struct.
+// The error lists every field that is missing or does not match its output.
+func Decode[T any](data []byte) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	if rv.Kind() != reflect.Struct {
+		return v, fmt.Errorf("tfout: decoding into %s: want a struct", rv.Type())
+	}
+
+	var outputs map[string]output
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return v, fmt.Errorf("tfout: decoding terraform output JSON: %w", err)
+	}
+
+	d := &decoder{}
+	for _, f := range fields(rv) {
+		out, ok := outputs[f.name]
+		if !ok {
+			if !f.optional {
+				d.fail(f.name, "missing output")
+			}
+			continue
+		}
+
+		value, err := parse(out.Value)
+		if err != nil {
+			d.fail(f.name, "invalid value: %v", err)
+			continue
+		}
+
+		dst := f.value
+		if st, ok := dst.Addr().Interface().(sensitiveTarget); ok {
+			dst = st.target()
+		} else if out.Sensitive {
+			d.fail(f.name, "output is sensitive, want a tfout.Sensitive[%s] field", dst.Type())
+			continue
+		}
+		d.decode(f.name, value, dst, out.Sensitive)
+	}
+
+	if len(d.problems) > 0 {
+		return v, fmt.Errorf("tfout: decoding outputs into %s:\n  %s", rv.Type(), strings.Join(d.problems, "\n  "))
+	}
+	return v, nil
+}
+
+// field is a tagged struct field
+type field struct {
+	name     string
+	optional bool
+	value    reflect.Value
+}
+
+// fields returns the tagged, exported fields of a struct value
+func fields(rv reflect.Value) []field {
+	var tagged []field
+	for i := 0; i < rv.NumField(); i++ {
+		sf := rv.Type().Field(i)
+		tag, ok := sf.Tag.Lookup("tfout")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		tagged = append(tagged, field{name: name, optional: opts == "optional", value: rv.Field(i)})
+	}
+	return tagged
+}
+
+// parse decodes JSON keeping numbers as json.Number, so integers are
+// checked exactly rather than through float64
+func parse(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var value interface{}
+	err := dec.Decode(&value)
+	return value, err
+}
+
+type decoder struct {
+	problems []string
+}
+
+func (d *decoder) fail(path, format string, args ...interface{}) {
+	d.problems = append(d.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// mismatch reports value as the wrong kind for dst, quoting it unless it
+// is sensitive
+func (d *decoder) mismatch(path string, value interface{}, dst reflect.Value, sensitive bool) {
+	got := kind(value)
+	if sensitive {
+		got += " (sensitive)"
+	} else if s := scalar(value); s != "" {
+		got += " " + s
+	}
+	d.fail(path, "want %s, got %s", dst.Type(), got)
+}
+
+// decode stores value, parsed from JSON, in dst
+func (d *decoder) decode(path string, value interface{}, dst reflect.Value, sensitive bool) {
+	// Terraform null leaves the zero value
+	if value == nil {
+		return
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			d.fail(path, "unsupported field type %s", dst.Type())
+			return
+		}
+		dst.Set(reflect.ValueOf(plain(value)))
+
+	case reflect.Pointer:
+		elem := reflect.New(dst.Type().Elem())
+		d.decode(path, value, elem.Elem(), sensitive)
+		dst.Set(elem)
+
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		dst.SetString(s)
+
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		dst.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(json.Number)
+		if !ok {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		i, err := n.Int64()
+		if err != nil || dst.OverflowInt(i) {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		dst.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := value.(json.Number)
+		if !ok {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		i, err := n.Int64()
+		if err != nil || i < 0 || dst.OverflowUint(uint64(i)) {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		dst.SetUint(uint64(i))
+
+	case reflect.Float32, reflect.Float64:
+		n, ok := value.(json.Number)
+		if !ok {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		f, err := n.Float64()
+		if err != nil || dst.OverflowFloat(f) {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		dst.SetFloat(f)
+
+	case reflect.Slice:
+		list, ok := value.([]interface{})
+		if !ok {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		s := reflect.MakeSlice(dst.Type(), len(list), len(list))
+		for i, item := range list {
+			d.decode(fmt.Sprintf("%s[%d]", path, i), item, s.Index(i), sensitive)
+		}
+		dst.Set(s)
+
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		m := reflect.MakeMapWithSize(dst.Type(), len(object))
+		for _, key := range sortedKeys(object) {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			d.decode(path+"."+key, object[key], elem, sensitive)
+			m.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+		}
+		dst.Set(m)
+
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			d.mismatch(path, value, dst, sensitive)
+			return
+		}
+		for _, f := range fields(dst) {
+			attr, ok := object[f.name]
+			if !ok {
+				if !f.optional {
+					d.fail(path+"."+f.name, "missing attribute")
+				}
+				continue
+			}
+			d.decode(path+"."+f.name, attr, f.value, sensitive)
+		}
+
+	default:
+		d.fail(path, "unsupported field type %s", dst.Type())
+	}
+}
+
+// kind names a parsed JSON value in Terraform's terms
+func kind(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case json.Number:
+		return "number"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "null"
+}
+
+// scalar quotes a short string, number or bool for an error message, and
+// returns "" for anything else
+func scalar(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if len(v) > 40 {
+			v = v[:40] + "..."
+		}
+		return fmt.Sprintf("%q", v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// plain converts json.Number back to float64 for interface{} fields, to
+// match what encoding/json gives them
+func plain(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = plain(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = plain(v[k])
+		}
+	}
+	return value
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,208 @@
+package tfout_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"iac/testutil/tfout"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type table struct {
+	ARN           string `tfout:"arn"`
+	Name          string `tfout:"name"`
+	StreamEnabled bool   `tfout:"stream_enabled"`
+}
+
+type connection struct {
+	Host string `tfout:"host"`
+	Port int    `tfout:"port"`
+}
+
+type fixtureOutputs struct {
+	BucketName    string                      `tfout:"bucket_name"`
+	BucketTags    map[string]string           `tfout:"bucket_tags"`
+	InstanceCount int                         `tfout:"instance_count"`
+	RetentionDays float64                     `tfout:"retention_days"`
+	SubnetIDs     []string                    `tfout:"subnet_ids"`
+	Table         table                       `tfout:"table"`
+	VPCID         *string                     `tfout:"vpc_id"`
+	AdminPassword tfout.Sensitive[string]     `tfout:"admin_password"`
+	Connection    tfout.Sensitive[connection] `tfout:"connection"`
+	Untagged      string
+}
+
+func readFixture(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/outputs.json")
+	require.NoError(t, err)
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	outputs, err := tfout.Decode[fixtureOutputs](readFixture(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, "fullstack-bucket-1700000000", outputs.BucketName)
+	assert.Equal(t, map[string]string{"Environment": "test", "ManagedBy": "terraform"}, outputs.BucketTags)
+	assert.Equal(t, 3, outputs.InstanceCount)
+	assert.Equal(t, 7.5, outputs.RetentionDays)
+	assert.Equal(t, []string{"subnet-a", "subnet-b"}, outputs.SubnetIDs)
+	assert.Equal(t, table{
+		ARN:  "arn:aws:dynamodb:us-east-1:000000000000:table/fullstack-table-1700000000",
+		Name: "fullstack-table-1700000000",
+	}, outputs.Table)
+	assert.Nil(t, outputs.VPCID, "a null output leaves the zero value")
+	assert.Equal(t, "hunter2-do-not-log", outputs.AdminPassword.Value())
+	assert.Equal(t, connection{Host: "db.internal", Port: 5432}, outputs.Connection.Value())
+	assert.Empty(t, outputs.Untagged)
+}
+
+func TestDecodeListsEveryProblem(t *testing.T) {
+	t.Parallel()
+
+	type wrong struct {
+		BucketName    int            `tfout:"bucket_name"`
+		BucketTags    map[string]int `tfout:"bucket_tags"`
+		InstanceCount string         `tfout:"instance_count"`
+		RetentionDays int            `tfout:"retention_days"`
+		Table         struct {
+			Name  string `tfout:"name"`
+			Owner string `tfout:"owner"`
+		} `tfout:"table"`
+		QueueURL string `tfout:"queue_url"`
+		TopicARN string `tfout:"topic_arn"`
+	}
+
+	_, err := tfout.Decode[wrong](readFixture(t))
+	require.Error(t, err)
+
+	msg := err.Error()
+	for _, want := range []string{
+		`bucket_name: want int, got string "fullstack-bucket-1700000000"`,
+		`bucket_tags.Environment: want int, got string "test"`,
+		`bucket_tags.ManagedBy: want int, got string "terraform"`,
+		`instance_count: want string, got number 3`,
+		`retention_days: want int, got number 7.5`,
+		`table.owner: missing attribute`,
+		`queue_url: missing output`,
+		`topic_arn: missing output`,
+	} {
+		assert.Contains(t, msg, want)
+	}
+}
+
+func TestDecodeOptional(t *testing.T) {
+	t.Parallel()
+
+	type optional struct {
+		BucketName string `tfout:"bucket_name"`
+		QueueURL   string `tfout:"queue_url,optional"`
+	}
+
+	outputs, err := tfout.Decode[optional](readFixture(t))
+	require.NoError(t, err)
+	assert.Equal(t, "fullstack-bucket-1700000000", outputs.BucketName)
+	assert.Empty(t, outputs.QueueURL)
+}
+
+func TestDecodeNumbers(t *testing.T) {
+	t.Parallel()
+
+	type numbers struct {
+		Small int8    `tfout:"small"`
+		Count uint    `tfout:"count"`
+		Ratio float32 `tfout:"ratio"`
+		Any   any     `tfout:"any"`
+	}
+
+	outputs, err := tfout.Decode[numbers]([]byte(`{
+		"small": {"type": "number", "value": 12},
+		"count": {"type": "number", "value": 9007199254740993},
+		"ratio": {"type": "number", "value": 0.25},
+		"any": {"type": ["list", "number"], "value": [1, 2]}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, int8(12), outputs.Small)
+	assert.Equal(t, uint(9007199254740993), outputs.Count, "integers are not rounded through float64")
+	assert.Equal(t, float32(0.25), outputs.Ratio)
+	assert.Equal(t, []interface{}{1.0, 2.0}, outputs.Any)
+
+	_, err = tfout.Decode[numbers]([]byte(`{
+		"small": {"type": "number", "value": 300},
+		"count": {"type": "number", "value": -1},
+		"ratio": {"type": "number", "value": 1},
+		"any": {"type": "string", "value": "x"}
+	}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "small: want int8, got number 300")
+	assert.Contains(t, err.Error(), "count: want uint, got number -1")
+}
+
+func TestDecodeSensitiveIntoPlainField(t *testing.T) {
+	t.Parallel()
+
+	type plain struct {
+		AdminPassword string `tfout:"admin_password"`
+	}
+
+	_, err := tfout.Decode[plain](readFixture(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "admin_password: output is sensitive, want a tfout.Sensitive[string] field")
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestDecodeSensitiveMismatchIsRedacted(t *testing.T) {
+	t.Parallel()
+
+	type wrong struct {
+		AdminPassword tfout.Sensitive[int]   `tfout:"admin_password"`
+		Connection    tfout.Sensitive[[]int] `tfout:"connection"`
+	}
+
+	_, err := tfout.Decode[wrong](readFixture(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "admin_password: want int, got string (sensitive)")
+	assert.Contains(t, err.Error(), "connection: want []int, got object (sensitive)")
+	assert.NotContains(t, err.Error(), "hunter2")
+	assert.NotContains(t, err.Error(), "db.internal")
+}
+
+func TestSensitiveFormatting(t *testing.T) {
+	t.Parallel()
+
+	outputs, err := tfout.Decode[fixtureOutputs](readFixture(t))
+	require.NoError(t, err)
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		printed := fmt.Sprintf(format, outputs)
+		assert.NotContains(t, printed, "hunter2", format)
+		assert.NotContains(t, printed, "db.internal", format)
+		assert.Contains(t, printed, "(sensitive)", format)
+	}
+	assert.Equal(t, tfout.NewSensitive("hunter2-do-not-log"), outputs.AdminPassword)
+}
+
+func TestDecodeRejectsBadInput(t *testing.T) {
+	t.Parallel()
+
+	_, err := tfout.Decode[fixtureOutputs]([]byte(`not json`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "decoding terraform output JSON")
+
+	_, err = tfout.Decode[map[string]string]([]byte(`{}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "want a struct")
+
+	type unsupported struct {
+		Ch chan int `tfout:"bucket_name"`
+	}
+	_, err = tfout.Decode[unsupported](readFixture(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bucket_name: unsupported field type chan int")
+}
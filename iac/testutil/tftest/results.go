@@ -0,0 +1,237 @@
+package tftest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Status is the outcome terraform test reports for a run, a file or the
+// whole suite
+type Status string
+
+// Statuses of a Run
+const (
+	// Pending is a run that never reported an outcome, as when terraform
+	// stopped before reaching it
+	Pending Status = "pending"
+	// Skip is a run terraform did not execute, usually because an earlier
+	// run in its file errored
+	Skip Status = "skip"
+	// Pass is a run whose assertions and expected failures all held
+	Pass Status = "pass"
+	// Fail is a run with a failed assertion or an expected failure that
+	// did not happen
+	Fail Status = "fail"
+	// Error is a run that could not be executed, such as a plan that
+	// failed unexpectedly
+	Error Status = "error"
+)
+
+// Failed reports whether s is Fail or Error
+func (s Status) Failed() bool {
+	return s == Fail || s == Error
+}
+
+// Diagnostic is an error or warning terraform reported
+type Diagnostic struct {
+	Severity string
+	Summary  string
+	Detail   string
+
+	// Filename and Line locate the source of the diagnostic, such as the
+	// assert block that failed; they are empty when it has none
+	Filename string
+	Line     int
+}
+
+// String formats d as terraform's human-readable output does, e.g.
+// "tests/storage.tftest.hcl:14: error: Test assertion failed: ..."
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	if d.Filename != "" {
+		fmt.Fprintf(&b, "%s:%d: ", d.Filename, d.Line)
+	}
+	fmt.Fprintf(&b, "%s: %s", d.Severity, d.Summary)
+	if d.Detail != "" {
+		fmt.Fprintf(&b, ": %s", d.Detail)
+	}
+	return b.String()
+}
+
+// Run is one run block of a test file
+type Run struct {
+	// File is the test file, relative to the module, e.g.
+	// tests/storage.tftest.hcl
+	File string
+	Name string
+
+	Status      Status
+	Diagnostics []Diagnostic
+}
+
+// Summary is the count terraform test reports once every file has run
+type Summary struct {
+	Status  Status `json:"status"`
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+	Errored int    `json:"errored"`
+	Skipped int    `json:"skipped"`
+}
+
+// Results is what one `terraform test -json` reported
+type Results struct {
+	// Runs are in file order, and in order within each file
+	Runs []Run
+
+	// Files holds the outcome of each test file
+	Files map[string]Status
+
+	// Diagnostics are the ones outside any run, such as a test file that
+	// could not be loaded, and lines of output that were not JSON
+	Diagnostics []Diagnostic
+
+	// Summary is nil when the output stopped before the summary
+	Summary *Summary
+}
+
+// Failed reports whether a run or a file failed or errored, or a
+// diagnostic outside the runs is an error
+func (r *Results) Failed() bool {
+	for _, run := range r.Runs {
+		if run.Status.Failed() {
+			return true
+		}
+	}
+	for _, status := range r.Files {
+		if status.Failed() {
+			return true
+		}
+	}
+	for _, d := range r.Diagnostics {
+		if d.Severity == "error" {
+			return true
+		}
+	}
+	return r.Summary != nil && r.Summary.Status.Failed()
+}
+
+// message is one line of `terraform test -json` output; type says which
+// of the payload fields is set
+type message struct {
+	Type    string `json:"type"`
+	File    string `json:"@testfile"`
+	RunName string `json:"@testrun"`
+
+	Abstract map[string][]string `json:"test_abstract"`
+	TestFile *struct {
+		Path   string `json:"path"`
+		Status Status `json:"status"`
+	} `json:"test_file"`
+	TestRun *struct {
+		Path   string `json:"path"`
+		Run    string `json:"run"`
+		Status Status `json:"status"`
+	} `json:"test_run"`
+	Summary    *Summary    `json:"test_summary"`
+	Diagnostic *diagnostic `json:"diagnostic"`
+}
+
+type diagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	Range    *struct {
+		Filename string `json:"filename"`
+		Start    struct {
+			Line int `json:"line"`
+		} `json:"start"`
+	} `json:"range"`
+}
+
+// Parse reads the output of `terraform test -json`, one JSON message per
+// line. Runs start out Pending when the output lists them, and take the
+// status of their last test_run message. Terraform 1.7 and later report a
+// run's progress before its outcome; 1.6 reports only the outcome. A line
+// that is not a JSON message, such as a crash, is kept as an error
+// diagnostic, so Parse only fails when r does.
+func Parse(r io.Reader) (*Results, error) {
+	results := &Results{Files: map[string]Status{}}
+	runs := map[[2]string]int{}
+	run := func(file, name string) *Run {
+		key := [2]string{file, name}
+		i, ok := runs[key]
+		if !ok {
+			i = len(results.Runs)
+			runs[key] = i
+			results.Runs = append(results.Runs, Run{File: file, Name: name, Status: Pending})
+		}
+		return &results.Runs[i]
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var m message
+		if err := json.Unmarshal([]byte(line), &m); err != nil || m.Type == "" {
+			results.Diagnostics = append(results.Diagnostics, Diagnostic{Severity: "error", Summary: "Unexpected output", Detail: line})
+			continue
+		}
+
+		switch m.Type {
+		case "test_abstract":
+			files := make([]string, 0, len(m.Abstract))
+			for file := range m.Abstract {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			for _, file := range files {
+				for _, name := range m.Abstract[file] {
+					run(file, name)
+				}
+			}
+		case "test_file":
+			if m.TestFile != nil && m.TestFile.Status != "" {
+				results.Files[m.TestFile.Path] = m.TestFile.Status
+			}
+		case "test_run":
+			if m.TestRun != nil && m.TestRun.Status != "" {
+				run(m.TestRun.Path, m.TestRun.Run).Status = m.TestRun.Status
+			}
+		case "test_summary":
+			results.Summary = m.Summary
+		case "diagnostic":
+			if m.Diagnostic == nil {
+				continue
+			}
+			d := m.Diagnostic.toDiagnostic()
+			if m.File != "" && m.RunName != "" {
+				target := run(m.File, m.RunName)
+				target.Diagnostics = append(target.Diagnostics, d)
+			} else {
+				results.Diagnostics = append(results.Diagnostics, d)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("tftest: reading terraform test output: %w", err)
+	}
+	return results, nil
+}
+
+func (d *diagnostic) toDiagnostic() Diagnostic {
+	out := Diagnostic{Severity: d.Severity, Summary: d.Summary, Detail: d.Detail}
+	if d.Range != nil {
+		out.Filename = d.Range.Filename
+		out.Line = d.Range.Start.Line
+	}
+	return out
+}
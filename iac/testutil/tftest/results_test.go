@@ -0,0 +1,118 @@
+package tftest_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"iac/testutil/tftest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFile(t *testing.T, path string) *tftest.Results {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	results, err := tftest.Parse(f)
+	require.NoError(t, err)
+	return results
+}
+
+func TestParsePassingSuite(t *testing.T) {
+	t.Parallel()
+
+	results := parseFile(t, "testdata/pass.jsonl")
+	assert.False(t, results.Failed())
+
+	require.Len(t, results.Runs, 2)
+	for _, run := range results.Runs {
+		assert.Equal(t, "tests/iam.tftest.hcl", run.File)
+		assert.Equal(t, tftest.Pass, run.Status, "%s should pass", run.Name)
+		assert.Empty(t, run.Diagnostics)
+	}
+	assert.Equal(t, "plans_role", results.Runs[0].Name)
+	assert.Equal(t, map[string]tftest.Status{"tests/iam.tftest.hcl": tftest.Pass}, results.Files)
+	assert.Equal(t, &tftest.Summary{Status: tftest.Pass, Passed: 2}, results.Summary)
+}
+
+func TestParseFailingSuite(t *testing.T) {
+	t.Parallel()
+
+	results := parseFile(t, "testdata/fail.jsonl")
+	assert.True(t, results.Failed())
+
+	statuses := map[string]tftest.Status{}
+	for _, run := range results.Runs {
+		statuses[run.Name] = run.Status
+	}
+	assert.Equal(t, map[string]tftest.Status{
+		"plans_bucket":                  tftest.Pass,
+		"blocks_public_access":          tftest.Fail,
+		"rejects_uppercase_bucket_name": tftest.Fail,
+		"plans_website":                 tftest.Error,
+		"serves_index":                  tftest.Skip,
+	}, statuses)
+
+	// Files in name order, runs in the order the file declares them
+	require.Len(t, results.Runs, 5)
+	assert.Equal(t, "tests/storage.tftest.hcl", results.Runs[0].File)
+	assert.Equal(t, "plans_bucket", results.Runs[0].Name)
+	assert.Equal(t, "serves_index", results.Runs[4].Name)
+
+	assertion := results.Runs[1]
+	require.Len(t, assertion.Diagnostics, 1)
+	assert.Equal(t, tftest.Diagnostic{
+		Severity: "error",
+		Summary:  "Test assertion failed",
+		Detail:   "A private bucket should get a public access block",
+		Filename: "tests/storage.tftest.hcl",
+		Line:     52,
+	}, assertion.Diagnostics[0])
+	assert.Equal(t, "tests/storage.tftest.hcl:52: error: Test assertion failed: A private bucket should get a public access block",
+		assertion.Diagnostics[0].String())
+
+	missing := results.Runs[2]
+	require.Len(t, missing.Diagnostics, 1)
+	assert.Equal(t, "Missing expected failure", missing.Diagnostics[0].Summary)
+
+	assert.Empty(t, results.Runs[4].Diagnostics, "A skipped run should carry no diagnostics")
+	assert.Empty(t, results.Diagnostics, "Every diagnostic belongs to a run")
+	assert.Equal(t, tftest.Error, results.Files["tests/website.tftest.hcl"])
+	assert.Equal(t, &tftest.Summary{Status: tftest.Error, Passed: 1, Failed: 2, Errored: 1, Skipped: 1}, results.Summary)
+}
+
+func TestParseProgressWithoutStatus(t *testing.T) {
+	t.Parallel()
+
+	// Terraform stopped after starting the run, before its outcome
+	output := `{"type":"test_abstract","test_abstract":{"tests/a.tftest.hcl":["first","second"]}}
+{"type":"test_run","@testfile":"tests/a.tftest.hcl","@testrun":"first","test_run":{"path":"tests/a.tftest.hcl","run":"first","progress":"starting","elapsed":0}}
+`
+	results, err := tftest.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, results.Runs, 2)
+	assert.Equal(t, tftest.Pending, results.Runs[0].Status)
+	assert.Equal(t, tftest.Pending, results.Runs[1].Status)
+	assert.Nil(t, results.Summary)
+	assert.False(t, results.Failed(), "Pending runs are not failures by themselves")
+}
+
+func TestParseKeepsUnexpectedOutput(t *testing.T) {
+	t.Parallel()
+
+	output := `{"type":"diagnostic","diagnostic":{"severity":"error","summary":"Failed to load plugin schemas","detail":"Could not load the schema for provider registry.terraform.io/hashicorp/aws"}}
+panic: runtime error: invalid memory address or nil pointer dereference
+`
+	results, err := tftest.Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	assert.Empty(t, results.Runs)
+	require.Len(t, results.Diagnostics, 2)
+	assert.Equal(t, "error: Failed to load plugin schemas: Could not load the schema for provider registry.terraform.io/hashicorp/aws", results.Diagnostics[0].String())
+	assert.Contains(t, results.Diagnostics[1].Detail, "panic: runtime error")
+	assert.True(t, results.Failed())
+}
@@ -0,0 +1,81 @@
+// Package tftest runs the native test suites of the modules, the
+// .tftest.hcl files in a module's tests directory, from Go:
+//
+//	modules, err := tftest.Discover(".")
+//	output, err := terraform.RunTerraformCommandAndGetStdoutE(t, options, "test", "-json")
+//	results, err := tftest.Parse(strings.NewReader(output))
+//
+// Native tests keep simple assertions, such as a validation rule rejecting
+// a value, next to the module they cover; Go still starts the emulators
+// and collects the results. Parse reads the machine-readable output of
+// `terraform test -json` into one Run per run block, with the diagnostics
+// that explain a failure, so each run can be reported as a subtest.
+//
+// Suites that configure a provider read the emulator from the
+// emulator_endpoint and aws_region variables, which EmulatorEnv sets.
+package tftest
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TestsDir is the directory of a module that holds its native tests
+const TestsDir = "tests"
+
+// Extension is the extension of native test files
+const Extension = ".tftest.hcl"
+
+// Discover returns every directory under root with a .tftest.hcl file in
+// its tests directory, sorted. Hidden directories such as .terraform and
+// testdata directories are skipped, as in modules.Discover.
+func Discover(root string) ([]string, error) {
+	seen := make(map[string]bool)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		dir := filepath.Dir(path)
+		if strings.HasSuffix(path, Extension) && filepath.Base(dir) == TestsDir {
+			seen[filepath.Dir(dir)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	modules := make([]string, 0, len(seen))
+	for dir := range seen {
+		modules = append(modules, dir)
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
+
+// EmulatorEnv returns the environment `terraform test` needs to reach
+// CloudEmu at endpoint: the emulator_endpoint and aws_region variables the
+// suites' provider blocks read, and the placeholder credentials, region and
+// endpoint an aws provider without one falls back to
+func EmulatorEnv(endpoint, region string) map[string]string {
+	return map[string]string{
+		"TF_VAR_emulator_endpoint": endpoint,
+		"TF_VAR_aws_region":        region,
+		"AWS_ENDPOINT_URL":         endpoint,
+		"AWS_REGION":               region,
+		"AWS_ACCESS_KEY_ID":        "test",
+		"AWS_SECRET_ACCESS_KEY":    "test",
+	}
+}
@@ -0,0 +1,50 @@
+package tftest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/tftest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, file := range []string{
+		"facade/storage/main.tf",
+		"facade/storage/tests/storage.tftest.hcl",
+		"facade/storage/tests/website.tftest.hcl",
+		"facade/iam/tests/iam.tftest.hcl",
+		"facade/network/main.tf",
+		"facade/network/network.tftest.hcl",
+		"facade/queue/tests/README.md",
+		"facade/cdn/testdata/tests/cdn.tftest.hcl",
+		"facade/cdn/.terraform/modules/tests/cdn.tftest.hcl",
+	} {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+
+	modules, err := tftest.Discover(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, "facade/iam"),
+		filepath.Join(root, "facade/storage"),
+	}, modules)
+}
+
+func TestEmulatorEnv(t *testing.T) {
+	t.Parallel()
+
+	env := tftest.EmulatorEnv("http://cloudemu:4566", "eu-west-1")
+	assert.Equal(t, "http://cloudemu:4566", env["TF_VAR_emulator_endpoint"])
+	assert.Equal(t, "eu-west-1", env["TF_VAR_aws_region"])
+	assert.Equal(t, "http://cloudemu:4566", env["AWS_ENDPOINT_URL"])
+	assert.Equal(t, "test", env["AWS_ACCESS_KEY_ID"])
+}
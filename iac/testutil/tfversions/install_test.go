@@ -0,0 +1,166 @@
+package tfversions_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"iac/testutil/tfversions"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReleases serves one Terraform release, also as the latest, in the
+// shape of the releases API. Its counters record what was fetched.
+type fakeReleases struct {
+	version  string
+	archive  []byte
+	checksum string
+
+	lookups   atomic.Int32
+	downloads atomic.Int32
+}
+
+func newFakeReleases(t *testing.T, version, content string) (*fakeReleases, *httptest.Server) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("terraform")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	sum := sha256.Sum256(buf.Bytes())
+	f := &fakeReleases{version: version, archive: buf.Bytes(), checksum: hex.EncodeToString(sum[:])}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zipName := fmt.Sprintf("terraform_%s_linux_amd64.zip", f.version)
+		switch r.URL.Path {
+		case "/v1/releases/terraform/" + f.version, "/v1/releases/terraform/latest":
+			f.lookups.Add(1)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"version": f.version,
+				"builds": []map[string]string{
+					{"os": "darwin", "arch": "arm64", "url": server.URL + "/terraform_darwin_arm64.zip"},
+					{"os": "linux", "arch": "amd64", "url": server.URL + "/" + zipName},
+				},
+				"url_shasums": server.URL + "/SHA256SUMS",
+			})
+		case "/SHA256SUMS":
+			fmt.Fprintf(w, "%s  terraform_darwin_arm64.zip\n%s  %s\n", f.checksum, f.checksum, zipName)
+		case "/" + zipName:
+			f.downloads.Add(1)
+			w.Write(f.archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func newInstaller(t *testing.T, server *httptest.Server) *tfversions.Installer {
+	return &tfversions.Installer{
+		CacheDir: t.TempDir(),
+		APIURL:   server.URL,
+		OS:       "linux",
+		Arch:     "amd64",
+	}
+}
+
+func TestInstall(t *testing.T) {
+	t.Parallel()
+
+	f, server := newFakeReleases(t, "1.9.0", "#!/bin/sh\necho 1.9.0\n")
+	installer := newInstaller(t, server)
+
+	binary, err := installer.Install(context.Background(), "1.9.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.9.0", binary.Version)
+
+	content, err := os.ReadFile(binary.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho 1.9.0\n", string(content))
+	info, err := os.Stat(binary.Path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm(), "The binary should be executable")
+
+	again, err := installer.Install(context.Background(), "1.9.0")
+	require.NoError(t, err)
+	assert.Equal(t, binary, again)
+	assert.EqualValues(t, 1, f.lookups.Load(), "An installed version should come from the cache")
+	assert.EqualValues(t, 1, f.downloads.Load())
+}
+
+func TestInstallLatest(t *testing.T) {
+	t.Parallel()
+
+	f, server := newFakeReleases(t, "1.10.5", "terraform")
+	installer := newInstaller(t, server)
+
+	binary, err := installer.Install(context.Background(), tfversions.Latest)
+	require.NoError(t, err)
+	assert.Equal(t, "1.10.5", binary.Version, "latest should resolve to the release")
+
+	again, err := installer.Install(context.Background(), tfversions.Latest)
+	require.NoError(t, err)
+	assert.Equal(t, binary, again)
+	assert.EqualValues(t, 2, f.lookups.Load(), "latest should be looked up every time")
+	assert.EqualValues(t, 1, f.downloads.Load(), "The resolved release should come from the cache")
+}
+
+func TestInstallChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	f, server := newFakeReleases(t, "1.9.0", "terraform")
+	f.checksum = hex.EncodeToString(make([]byte, sha256.Size))
+	installer := newInstaller(t, server)
+
+	_, err := installer.Install(context.Background(), "1.9.0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "want 0000")
+
+	entries, err := os.ReadDir(installer.CacheDir + "/1.9.0")
+	require.NoError(t, err)
+	assert.Empty(t, entries, "Nothing should be left in the cache")
+}
+
+func TestInstallErrors(t *testing.T) {
+	t.Parallel()
+
+	_, server := newFakeReleases(t, "1.9.0", "terraform")
+
+	tests := map[string]struct {
+		version string
+		os      string
+		want    string
+	}{
+		"no build":        {version: "1.9.0", os: "plan9", want: "Terraform 1.9.0 has no plan9/amd64 build"},
+		"unknown release": {version: "1.2.3", os: "linux", want: "404 Not Found"},
+		"bad version":     {version: "1.9", os: "linux", want: `"1.9" is not a Terraform version`},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			installer := newInstaller(t, server)
+			installer.OS = tc.os
+
+			_, err := installer.Install(context.Background(), tc.version)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package tfversions
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Status is the outcome of one facade on one version
+type Status string
+
+// Statuses of a Result
+const (
+	Pass Status = "pass"
+	Fail Status = "fail"
+
+	// InitFailed means terraform init failed on the first facade, so the
+	// version was given up on without planning the rest
+	InitFailed Status = "init failed"
+
+	// NotInstalled means the version could not be downloaded
+	NotInstalled Status = "not installed"
+)
+
+// Result is one facade planned with one Terraform version
+type Result struct {
+	// Version is as requested, e.g. Latest, so every result of one run of
+	// the harness lands in the same column whether or not it installed
+	Version string
+	Facade  string
+	Status  Status
+}
+
+// Results collects the results of parallel subtests
+type Results struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+// Add records the status of facade on version
+func (r *Results) Add(version, facade string, status Status) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, Result{Version: version, Facade: facade, Status: status})
+}
+
+// AddAll records status for every facade on version, as when the version
+// is given up on
+func (r *Results) AddAll(version string, facades []string, status Status) {
+	for _, facade := range facades {
+		r.Add(version, facade, status)
+	}
+}
+
+// All returns the results in the order they were added
+func (r *Results) All() []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Result(nil), r.results...)
+}
+
+// WriteTable writes results as a Markdown table with a row per facade, in
+// name order, and a column per version, in the order versions first
+// appear. A facade with no result on a version is marked "-". labels may
+// rename version columns, e.g. "latest (1.10.5)"; it may be nil.
+func WriteTable(w io.Writer, results []Result, labels map[string]string) error {
+	var versions, facades []string
+	status := map[[2]string]Status{}
+	seenVersion, seenFacade := map[string]bool{}, map[string]bool{}
+	for _, r := range results {
+		if !seenVersion[r.Version] {
+			seenVersion[r.Version] = true
+			versions = append(versions, r.Version)
+		}
+		if !seenFacade[r.Facade] {
+			seenFacade[r.Facade] = true
+			facades = append(facades, r.Facade)
+		}
+		status[[2]string{r.Facade, r.Version}] = r.Status
+	}
+	sort.Strings(facades)
+
+	header := []string{"Facade"}
+	for _, v := range versions {
+		if label, ok := labels[v]; ok {
+			v = label
+		}
+		header = append(header, v)
+	}
+	if _, err := fmt.Fprintf(w, "| %s |\n|%s\n", strings.Join(header, " | "), strings.Repeat(" :--- |", len(header))); err != nil {
+		return err
+	}
+
+	for _, facade := range facades {
+		row := []string{facade}
+		for _, v := range versions {
+			s, ok := status[[2]string{facade, v}]
+			if !ok {
+				s = "-"
+			}
+			row = append(row, string(s))
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
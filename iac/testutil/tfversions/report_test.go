@@ -0,0 +1,46 @@
+package tfversions_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"iac/testutil/tfversions"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTable(t *testing.T) {
+	t.Parallel()
+
+	var results tfversions.Results
+	var wg sync.WaitGroup
+	for _, facade := range []string{"storage", "networking"} {
+		wg.Add(1)
+		go func(facade string) {
+			defer wg.Done()
+			results.Add("latest", facade, tfversions.Pass)
+		}(facade)
+	}
+	wg.Wait()
+	results.AddAll("1.3.0", []string{"networking", "storage"}, tfversions.InitFailed)
+	results.Add("1.9.0", "storage", tfversions.Fail)
+
+	var out strings.Builder
+	require.NoError(t, tfversions.WriteTable(&out, results.All(), map[string]string{"latest": "latest (1.10.5)"}))
+
+	assert.Equal(t, ""+
+		"| Facade | latest (1.10.5) | 1.3.0 | 1.9.0 |\n"+
+		"| :--- | :--- | :--- | :--- |\n"+
+		"| networking | pass | init failed | - |\n"+
+		"| storage | pass | init failed | fail |\n", out.String())
+}
+
+func TestWriteTableEmpty(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	require.NoError(t, tfversions.WriteTable(&out, nil, nil))
+	assert.Equal(t, "| Facade |\n| :--- |\n", out.String())
+}
@@ -0,0 +1,97 @@
+// Package tfversions runs the plan-only facade tests against several
+// Terraform CLI versions, so the versions the modules claim to support are
+// the versions they are tested on:
+//
+//	SWE_TF_VERSIONS=1.9.0,1.10.5,latest go test -tags tfversions -run TestFacadeTerraformVersions -timeout 2h .
+//
+// Install downloads each release from HashiCorp into a cache directory,
+// checking it against the release's SHA256SUMS, and the test sets
+// terraform.Options.TerraformBinary to it. WriteTable reports a facade ×
+// version table of the outcomes.
+package tfversions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Environment variables the harness reads
+const (
+	// EnvVersions lists the versions to test, separated by commas or
+	// spaces; "latest" is the newest release
+	EnvVersions = "SWE_TF_VERSIONS"
+
+	// EnvCacheDir is where releases are installed, so each is downloaded
+	// once per machine
+	EnvCacheDir = "SWE_TF_CACHE_DIR"
+)
+
+// Latest names the newest Terraform release
+const Latest = "latest"
+
+// OldestSupported is the highest required_version floor among the facades,
+// and so the oldest CLI every facade supports
+const OldestSupported = "1.9.0"
+
+// DefaultVersions are tested when EnvVersions is unset: the oldest
+// supported release and the newest
+var DefaultVersions = []string{OldestSupported, Latest}
+
+// versionPattern matches a release version such as 1.9.0 or 1.10.0-beta1
+var versionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.]+)?$`)
+
+// ParseVersions splits a list of versions separated by commas or spaces,
+// dropping repeats, and rejects anything that is neither a release
+// version nor Latest
+func ParseVersions(s string) ([]string, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+
+	var versions []string
+	seen := map[string]bool{}
+	for _, v := range fields {
+		v = strings.TrimPrefix(v, "v")
+		if v != Latest && !versionPattern.MatchString(v) {
+			return nil, fmt.Errorf("tfversions: %q is not a Terraform version such as 1.9.0 or %s", v, Latest)
+		}
+		if !seen[v] {
+			seen[v] = true
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("tfversions: no versions in %q", s)
+	}
+	return versions, nil
+}
+
+// VersionsFromEnv returns the versions in EnvVersions, or DefaultVersions
+// when it is unset or blank
+func VersionsFromEnv() ([]string, error) {
+	value := strings.TrimSpace(os.Getenv(EnvVersions))
+	if value == "" {
+		return DefaultVersions, nil
+	}
+	versions, err := ParseVersions(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvVersions, err)
+	}
+	return versions, nil
+}
+
+// CacheDirFromEnv returns EnvCacheDir, or swe-iac/terraform in the user's
+// cache directory when it is unset
+func CacheDirFromEnv() (string, error) {
+	if dir := os.Getenv(EnvCacheDir); dir != "" {
+		return dir, nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("tfversions: no cache directory; set %s: %w", EnvCacheDir, err)
+	}
+	return filepath.Join(base, "swe-iac", "terraform"), nil
+}
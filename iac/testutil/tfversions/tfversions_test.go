@@ -0,0 +1,55 @@
+package tfversions_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"iac/testutil/tfversions"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersions(t *testing.T) {
+	t.Parallel()
+
+	versions, err := tfversions.ParseVersions("1.9.0, 1.10.0-beta1 v1.9.0\tlatest")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.9.0", "1.10.0-beta1", "latest"}, versions, "Repeats should be dropped and a leading v trimmed")
+
+	for _, bad := range []string{"1.9", "~> 1.9", "newest", " , "} {
+		_, err := tfversions.ParseVersions(bad)
+		assert.Error(t, err, "%q should be rejected", bad)
+	}
+}
+
+func TestVersionsFromEnv(t *testing.T) {
+	t.Setenv(tfversions.EnvVersions, " ")
+	versions, err := tfversions.VersionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{tfversions.OldestSupported, tfversions.Latest}, versions)
+
+	t.Setenv(tfversions.EnvVersions, "1.9.8,latest")
+	versions, err = tfversions.VersionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.9.8", "latest"}, versions)
+
+	t.Setenv(tfversions.EnvVersions, "1.x")
+	_, err = tfversions.VersionsFromEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), tfversions.EnvVersions)
+}
+
+func TestCacheDirFromEnv(t *testing.T) {
+	t.Setenv(tfversions.EnvCacheDir, "/var/cache/terraform")
+	dir, err := tfversions.CacheDirFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "/var/cache/terraform", dir)
+
+	t.Setenv(tfversions.EnvCacheDir, "")
+	t.Setenv("XDG_CACHE_HOME", "/home/ci/.cache")
+	t.Setenv("HOME", "/home/ci")
+	dir, err = tfversions.CacheDirFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("swe-iac", "terraform"), filepath.Join(filepath.Base(filepath.Dir(dir)), filepath.Base(dir)))
+}
@@ -0,0 +1,330 @@
+// Package upgrade checks that a module change does not destroy what the last
+// release created. Renaming a resource without a moved block, or changing an
+// argument that forces a new resource, plans cleanly on its own and only
+// shows up as a delete or replace when an existing deployment upgrades.
+//
+// RunUpgradeTest reproduces that upgrade: it applies a facade as it was at a
+// base ref (see BaseRef) against CloudEmu, then plans the working-tree
+// version of the same facade against the resulting state.
+package upgrade
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/config"
+	"iac/testutil/integration"
+	"iac/testutil/planrisk"
+	"iac/testutil/smoke"
+	"iac/testutil/tfopts"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// EnvBaseRef names the git ref RunUpgradeTest upgrades from: a tag, branch
+// or commit. It overrides the release tag and merge base BaseRef falls back
+// to, e.g. to check an upgrade from a release older than the latest.
+const EnvBaseRef = "SWE_UPGRADE_BASE_REF"
+
+// mainBranches are tried in order for the merge base when the checkout has
+// no release tag
+var mainBranches = []string{"main", "origin/main"}
+
+// providerFile is written into both workspaces to point the AWS provider at
+// CloudEmu; facades declare no provider blocks of their own
+const providerFile = "upgrade_test_provider.tf"
+
+const providerConfig = `provider "aws" {
+  region = %[2]q
+
+  endpoints {
+    s3       = %[1]q
+    dynamodb = %[1]q
+    sqs      = %[1]q
+    sns      = %[1]q
+    lambda   = %[1]q
+    kms      = %[1]q
+    iam      = %[1]q
+    sts      = %[1]q
+  }
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+  s3_use_path_style           = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+`
+
+// BaseRef returns the ref to upgrade from in the git checkout at dir:
+// explicit when it is not empty, otherwise the latest release tag reachable
+// from HEAD, otherwise the merge base of HEAD with main (or origin/main). It
+// fails when explicit names no commit or when none of the fallbacks exists.
+func BaseRef(dir, explicit string) (string, error) {
+	if explicit != "" {
+		if _, err := git(dir, "rev-parse", "--verify", "--quiet", explicit+"^{commit}"); err != nil {
+			return "", fmt.Errorf("upgrade: base ref %q is not a commit", explicit)
+		}
+		return explicit, nil
+	}
+
+	if tag, err := git(dir, "describe", "--tags", "--abbrev=0"); err == nil {
+		return tag, nil
+	}
+	for _, branch := range mainBranches {
+		if base, err := git(dir, "merge-base", "HEAD", branch); err == nil {
+			return base, nil
+		}
+	}
+	return "", fmt.Errorf("upgrade: no release tag and no merge base with %s; set %s", strings.Join(mainBranches, " or "), EnvBaseRef)
+}
+
+// RunUpgradeTest applies the facade at facadeDir as of the base ref (see
+// BaseRef, with SWE_UPGRADE_BASE_REF as the explicit ref) with vars, plans
+// the working-tree version against the same state and fails t for every
+// resource the plan would destroy or replace, except addresses matching
+// allow (see planrisk.Summary.Destructive).
+//
+// The test fails when SWE_UPGRADE_BASE_REF names no commit, and is skipped
+// when CloudEmu is not running, when no base ref resolves, or when the
+// facade did not exist at the base ref. Both versions
+// run in temporary copies of the module tree, so the working tree gets no
+// .terraform directory or state, and the working-tree copy is initialized
+// with -upgrade so providers are re-selected for its constraints.
+func RunUpgradeTest(t *testing.T, facadeDir string, vars map[string]interface{}, allow ...string) {
+	t.Helper()
+
+	cfg := config.Load(t)
+	integration.Require(t, "CloudEmu", integration.StartCloudEmu, smoke.Probe(cfg, smoke.ForProvider("aws")...))
+
+	root, facade, err := moduleRoot(facadeDir)
+	if err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+
+	explicit := os.Getenv(EnvBaseRef)
+	base, err := BaseRef(root, explicit)
+	if err != nil {
+		if explicit != "" {
+			t.Fatalf("%v", err)
+		}
+		t.Skipf("nothing to upgrade from: %v", err)
+	}
+	prefix, err := git(root, "rev-parse", "--show-prefix")
+	if err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+	if _, err := git(root, "cat-file", "-e", base+":"+prefix+filepath.ToSlash(facade)+"/main.tf"); err != nil {
+		t.Skipf("%s did not exist at %s", facade, base)
+	}
+
+	releaseRoot := filepath.Join(t.TempDir(), "release")
+	currentRoot := filepath.Join(t.TempDir(), "current")
+	if err := exportRef(root, base, prefix, releaseRoot); err != nil {
+		t.Fatalf("upgrade: exporting %s: %v", base, err)
+	}
+	if err := copyTree(root, currentRoot); err != nil {
+		t.Fatalf("upgrade: copying working tree: %v", err)
+	}
+
+	provider := fmt.Sprintf(providerConfig, cfg.CloudEmuEndpoint, cfg.Region)
+	for _, dir := range []string{releaseRoot, currentRoot} {
+		if err := os.WriteFile(filepath.Join(dir, facade, providerFile), []byte(provider), 0o644); err != nil {
+			t.Fatalf("upgrade: %v", err)
+		}
+	}
+
+	releaseOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: filepath.Join(releaseRoot, facade),
+		Vars:         vars,
+		NoColor:      true,
+	}, tfopts.CloudEmu)
+	defer tfopts.Destroy(t, releaseOptions)
+
+	t.Logf("Applying %s at %s", facade, base)
+	tfopts.InitAndApply(t, releaseOptions)
+
+	// Plan the working tree against a copy of the release state, leaving the
+	// original in place for the deferred destroy
+	state, err := os.ReadFile(filepath.Join(releaseOptions.TerraformDir, "terraform.tfstate"))
+	if err != nil {
+		t.Fatalf("upgrade: reading release state: %v", err)
+	}
+	currentOptions := tfopts.WithEmulatorRetries(t, &terraform.Options{
+		TerraformDir: filepath.Join(currentRoot, facade),
+		Vars:         vars,
+		NoColor:      true,
+		Upgrade:      true,
+		PlanFilePath: filepath.Join(t.TempDir(), "upgrade.plan"),
+	}, tfopts.CloudEmu)
+	if err := os.WriteFile(filepath.Join(currentOptions.TerraformDir, "terraform.tfstate"), state, 0o644); err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+
+	t.Logf("Planning working-tree %s against the %s state", facade, base)
+	planJSON := terraform.InitAndPlanAndShow(t, currentOptions)
+
+	if !planrisk.AssertNoReplacements(t, []byte(planJSON), allow...) {
+		t.Errorf("upgrading %s from %s would lose existing resources; add moved blocks or allowlist the addresses", facade, base)
+	}
+}
+
+// moduleRoot returns the directory holding go.mod above facadeDir, which is
+// also the root of the Terraform tree, and facadeDir relative to it
+func moduleRoot(facadeDir string) (string, string, error) {
+	abs, err := filepath.Abs(facadeDir)
+	if err != nil {
+		return "", "", err
+	}
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			rel, err := filepath.Rel(dir, abs)
+			return dir, rel, err
+		}
+		if filepath.Dir(dir) == dir {
+			return "", "", fmt.Errorf("no go.mod above %s", abs)
+		}
+	}
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// exportRef writes the module tree (prefix within the repository) as of ref
+// into dest
+func exportRef(root, ref, prefix, dest string) error {
+	// git archive paths are relative to the repository root
+	top, err := git(root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+
+	args := []string{"archive", "--format=tar", ref}
+	if prefix != "" {
+		args = append(args, prefix)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = top
+
+	archive, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	extractErr := extract(tar.NewReader(archive), prefix, dest)
+	if extractErr != nil {
+		io.Copy(io.Discard, archive)
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git archive: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return extractErr
+}
+
+// extract writes the directories and regular files of r below dest,
+// stripping prefix from their names
+func extract(r *tar.Reader, prefix, dest string) error {
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(header.Name, prefix)
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if !strings.HasPrefix(target, filepath.Clean(dest)) {
+			return fmt.Errorf("archive entry %s escapes %s", header.Name, dest)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0o777)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, r)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// skipped are the paths copyTree leaves out: terraform working data and
+// state, which belong to whichever workspace created them
+func skipped(name string) bool {
+	return name == ".git" || name == ".terraform" || strings.HasPrefix(name, "terraform.tfstate")
+}
+
+// copyTree copies the regular files below src into dest
+func copyTree(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if skipped(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,93 @@
+package upgrade_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac/testutil/upgrade"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runGit runs git in dir with a fixed identity and returns its trimmed output
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+// newRepo creates a git repository on branch and returns its directory
+func newRepo(t *testing.T, branch string) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", branch)
+	return dir
+}
+
+// commit writes a file named after message and commits it, returning the
+// new commit's hash
+func commit(t *testing.T, dir, message string) string {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, message+".tf"), []byte("# "+message+"\n"), 0o644))
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", message)
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+func TestBaseRefExplicit(t *testing.T) {
+	dir := newRepo(t, "main")
+	commit(t, dir, "first")
+	commit(t, dir, "second")
+	runGit(t, dir, "tag", "v1.0.0")
+
+	ref, err := upgrade.BaseRef(dir, "HEAD~1")
+	require.NoError(t, err)
+	assert.Equal(t, "HEAD~1", ref, "An explicit ref should win over the release tag")
+
+	_, err = upgrade.BaseRef(dir, "no-such-ref")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"no-such-ref" is not a commit`)
+}
+
+func TestBaseRefReleaseTag(t *testing.T) {
+	dir := newRepo(t, "main")
+	commit(t, dir, "first")
+	runGit(t, dir, "tag", "v1.0.0")
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	commit(t, dir, "second")
+
+	ref, err := upgrade.BaseRef(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", ref)
+}
+
+func TestBaseRefMergeBase(t *testing.T) {
+	dir := newRepo(t, "main")
+	base := commit(t, dir, "first")
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	commit(t, dir, "second")
+	runGit(t, dir, "checkout", "-q", "main")
+	commit(t, dir, "third")
+	runGit(t, dir, "checkout", "-q", "feature")
+
+	ref, err := upgrade.BaseRef(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, base, ref, "Without a tag the branch point from main should be used")
+}
+
+func TestBaseRefNothingToUpgradeFrom(t *testing.T) {
+	dir := newRepo(t, "trunk")
+	commit(t, dir, "first")
+
+	_, err := upgrade.BaseRef(dir, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), upgrade.EnvBaseRef, "The error should say how to name a base")
+}
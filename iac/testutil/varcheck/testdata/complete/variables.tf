@@ -0,0 +1,29 @@
+variable "environment" {
+  description = "Environment (dev, staging, or prod)"
+  type        = string
+  default     = "dev"
+  validation {
+    condition     = contains(["local", "dev", "staging", "prod"], var.environment)
+    error_message = "Environment must be one of: local, dev, staging, prod"
+  }
+}
+
+variable "tags" {
+  description = <<-EOT
+    Tags applied to every resource
+  EOT
+  type        = map(string)
+  default     = {}
+}
+
+variable "api_token" {
+  description = "Token for the deployment API"
+  type        = string
+  sensitive   = true
+}
+
+variable "region" {
+  description = "Region, or null for the provider's default"
+  type        = string
+  default     = null
+}
@@ -0,0 +1,5 @@
+variable "environment" { default = "prod" }
+
+output "summary" {
+  value = "${var.name}-${var.instance_size}-${var.environment}"
+}
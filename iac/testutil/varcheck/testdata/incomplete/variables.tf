@@ -0,0 +1,18 @@
+variable "name" {
+  type = string
+}
+
+variable "instance_size" {
+  description = "Instance size (small, medium, large)"
+  type        = string
+}
+
+variable "blank" {
+  description = "  "
+  type        = string
+}
+
+variable "null_description" {
+  description = null
+  type        = string
+}
@@ -0,0 +1,40 @@
+variable "instance_size" {
+  description = "Instance size"
+  type        = string
+  validation {
+    condition     = contains(["small", "medium", "large"], var.instance_size)
+    error_message = "Instance size must be one of: small, medium, large"
+  }
+}
+
+variable "bucket_name" {
+  description = "Bucket name"
+  type        = string
+  validation {
+    condition     = can(regex("^[a-z0-9-]{3,63}$", var.bucket_name))
+    error_message = "Names must be lower case letters, digits and hyphens"
+  }
+  validation {
+    condition     = !startswith(var.bucket_name, "xn--")
+    error_message = "bucket_name ${var.bucket_name} must not start with xn--, e.g. my-bucket"
+  }
+}
+
+variable "retention_days" {
+  description = "Days to keep backups"
+  type        = number
+  validation {
+    condition     = var.retention_days > 0
+    error_message = "Must be positive"
+  }
+}
+
+variable "kms_key_ref" {
+  description = "Key from the encryption facade"
+  type        = object({ provider = string, id = string })
+  default     = null
+  validation {
+    condition     = var.kms_key_ref == null || var.kms_key_ref.provider == var.provider_name
+    error_message = "The key belongs to another provider"
+  }
+}
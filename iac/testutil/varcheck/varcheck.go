@@ -0,0 +1,294 @@
+// Package varcheck checks that Terraform input variables are documented and
+// typed, and that the variables most prone to silent misconfiguration
+// (environment, provider_name, instance_size and the like) reject values the
+// modules do not handle with a validation block.
+//
+// CheckMessages holds validation error messages to a standard: a rule on
+// the variable's own value must name the variable and show a valid value,
+// so the person who set it knows which input to change and to what.
+//
+// Modules are read with the HCL parser, so the rules can be tested against
+// small fixture modules independently of the real tree.
+package varcheck
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DefaultMustValidate names the variables that need a validation block
+// wherever they are declared
+var DefaultMustValidate = []string{
+	"provider",
+	"provider_name",
+	"environment",
+	"instance_size",
+	"identity_type",
+	"storage_class",
+}
+
+// Variable is a variable block and the parts of it the rules look at
+type Variable struct {
+	Module string
+	Name   string
+	File   string
+	Line   int
+
+	// HasDescription is false when description is missing or empty
+	HasDescription bool
+	HasType        bool
+	HasValidation  bool
+
+	// Sensitive is true when sensitive is the constant true
+	Sensitive bool
+
+	// NullDefault is true when default is the constant null, so the
+	// variable may be left unset
+	NullDefault bool
+
+	Validations []Validation
+}
+
+// Validation is one validation block of a variable
+type Validation struct {
+	Line int
+
+	// Message is the error_message; a template that is not a constant is
+	// kept as written between its quotes
+	Message string
+
+	// CrossVariable is true when the condition reads anything besides the
+	// variable itself, such as another variable or a local: the fix is
+	// then often to another input, and the message need not show a valid
+	// value of this one
+	CrossVariable bool
+}
+
+// Inspect returns the variables declared in the .tf files of dir, in file
+// and line order
+func Inspect(dir string) ([]Variable, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	module := filepath.ToSlash(dir)
+	parser := hclparse.NewParser()
+
+	var vars []Variable
+	for _, file := range files {
+		f, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("varcheck: %s", diags.Error())
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, fmt.Errorf("varcheck: %s is not native HCL syntax", file)
+		}
+
+		for _, block := range body.Blocks {
+			if block.Type != "variable" || len(block.Labels) == 0 {
+				continue
+			}
+
+			v := Variable{
+				Module: module,
+				Name:   block.Labels[0],
+				File:   filepath.Base(file),
+				Line:   block.TypeRange.Start.Line,
+			}
+			if attr, ok := block.Body.Attributes["description"]; ok {
+				v.HasDescription = !isEmptyString(attr.Expr)
+			}
+			_, v.HasType = block.Body.Attributes["type"]
+			if attr, ok := block.Body.Attributes["sensitive"]; ok {
+				v.Sensitive = isTrue(attr.Expr)
+			}
+			if attr, ok := block.Body.Attributes["default"]; ok {
+				v.NullDefault = isNull(attr.Expr)
+			}
+			for _, nested := range block.Body.Blocks {
+				if nested.Type == "validation" {
+					v.HasValidation = true
+					v.Validations = append(v.Validations, validation(f.Bytes, v.Name, nested))
+				}
+			}
+			vars = append(vars, v)
+		}
+	}
+	return vars, nil
+}
+
+func validation(src []byte, name string, block *hclsyntax.Block) Validation {
+	val := Validation{Line: block.TypeRange.Start.Line}
+
+	if attr, ok := block.Body.Attributes["error_message"]; ok {
+		v, diags := attr.Expr.Value(nil)
+		if !diags.HasErrors() && v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+			val.Message = v.AsString()
+		} else {
+			text := string(attr.Expr.Range().SliceBytes(src))
+			val.Message = strings.TrimSuffix(strings.TrimPrefix(text, `"`), `"`)
+		}
+	}
+
+	if attr, ok := block.Body.Attributes["condition"]; ok {
+		for _, traversal := range attr.Expr.Variables() {
+			if traversal.RootName() != "var" || len(traversal) < 2 {
+				val.CrossVariable = true
+				continue
+			}
+			if step, ok := traversal[1].(hcl.TraverseAttr); !ok || step.Name != name {
+				val.CrossVariable = true
+			}
+		}
+	}
+	return val
+}
+
+// InspectAll inspects each of dirs
+func InspectAll(dirs []string) ([]Variable, error) {
+	var all []Variable
+	for _, dir := range dirs {
+		vars, err := Inspect(dir)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, vars...)
+	}
+	return all, nil
+}
+
+// isTrue reports whether expr is the constant true
+func isTrue(expr hclsyntax.Expression) bool {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.Bool {
+		return false
+	}
+	return v.True()
+}
+
+// isNull reports whether expr is the constant null
+func isNull(expr hclsyntax.Expression) bool {
+	v, diags := expr.Value(nil)
+	return !diags.HasErrors() && v.IsNull()
+}
+
+// isEmptyString reports whether expr is a constant, blank string
+func isEmptyString(expr hclsyntax.Expression) bool {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() {
+		return false
+	}
+	if v.IsNull() {
+		return true
+	}
+	return v.Type() == cty.String && strings.TrimSpace(v.AsString()) == ""
+}
+
+// Problem is one rule a variable breaks
+type Problem struct {
+	Variable Variable
+	Message  string
+}
+
+// Check applies the rules to vars: every variable needs a description and a
+// type, and those named in mustValidate also need a validation block
+func Check(vars []Variable, mustValidate []string) []Problem {
+	validated := make(map[string]bool, len(mustValidate))
+	for _, name := range mustValidate {
+		validated[name] = true
+	}
+
+	var problems []Problem
+	for _, v := range vars {
+		if !v.HasDescription {
+			problems = append(problems, Problem{v, "no description"})
+		}
+		if !v.HasType {
+			problems = append(problems, Problem{v, "no type"})
+		}
+		if validated[v.Name] && !v.HasValidation {
+			problems = append(problems, Problem{v, "no validation block"})
+		}
+	}
+	return problems
+}
+
+// example matches the ways a message shows a valid value: a list of them,
+// a worked example, a quoted or parenthesised value, or a number such as a
+// length or range
+var example = regexp.MustCompile(`(?i)one of|e\.g\.|such as|for example|[0-9"'` + "`" + `(]`)
+
+// CheckMessages applies the message rules to the validation blocks of vars
+// that check the variable's own value: the error_message names the
+// variable, as written or with spaces for underscores in any case
+// ("Instance size" for instance_size), and shows a valid value
+func CheckMessages(vars []Variable) []Problem {
+	var problems []Problem
+	for _, v := range vars {
+		spoken := strings.ReplaceAll(v.Name, "_", " ")
+		for _, val := range v.Validations {
+			if val.CrossVariable {
+				continue
+			}
+			where := fmt.Sprintf("validation at %s:%d", v.File, val.Line)
+			lower := strings.ToLower(val.Message)
+			if !strings.Contains(val.Message, v.Name) && !strings.Contains(lower, spoken) {
+				problems = append(problems, Problem{v, where + " does not name the variable"})
+			}
+			if !example.MatchString(val.Message) {
+				problems = append(problems, Problem{v, where + " shows no valid value"})
+			}
+		}
+	}
+	return problems
+}
+
+// Report groups problems by module and variable, both sorted by name:
+//
+//	facade/iam
+//	  identity_type (variables.tf:24): no validation block
+//	  name (variables.tf:3): no description, no type
+func Report(problems []Problem) string {
+	type key struct{ module, name string }
+
+	byVariable := make(map[key][]string)
+	location := make(map[key]string)
+	for _, p := range problems {
+		k := key{p.Variable.Module, p.Variable.Name}
+		byVariable[k] = append(byVariable[k], p.Message)
+		location[k] = fmt.Sprintf("%s:%d", p.Variable.File, p.Variable.Line)
+	}
+
+	keys := make([]key, 0, len(byVariable))
+	for k := range byVariable {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].module != keys[j].module {
+			return keys[i].module < keys[j].module
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	var b strings.Builder
+	module := ""
+	for _, k := range keys {
+		if k.module != module {
+			module = k.module
+			fmt.Fprintln(&b, module)
+		}
+		fmt.Fprintf(&b, "  %s (%s): %s\n", k.name, location[k], strings.Join(byVariable[k], ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,168 @@
+package varcheck_test
+
+import (
+	"testing"
+
+	"iac/testutil/varcheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	vars, err := varcheck.Inspect("testdata/complete")
+	require.NoError(t, err)
+
+	assert.Equal(t, []varcheck.Variable{
+		{
+			Module:         "testdata/complete",
+			Name:           "environment",
+			File:           "variables.tf",
+			Line:           1,
+			HasDescription: true,
+			HasType:        true,
+			HasValidation:  true,
+			Validations: []varcheck.Validation{
+				{Line: 5, Message: "Environment must be one of: local, dev, staging, prod"},
+			},
+		},
+		{
+			Module:         "testdata/complete",
+			Name:           "tags",
+			File:           "variables.tf",
+			Line:           11,
+			HasDescription: true,
+			HasType:        true,
+		},
+		{
+			Module:         "testdata/complete",
+			Name:           "api_token",
+			File:           "variables.tf",
+			Line:           19,
+			HasDescription: true,
+			HasType:        true,
+			Sensitive:      true,
+		},
+		{
+			Module:         "testdata/complete",
+			Name:           "region",
+			File:           "variables.tf",
+			Line:           25,
+			HasDescription: true,
+			HasType:        true,
+			NullDefault:    true,
+		},
+	}, vars)
+}
+
+func TestInspectIncomplete(t *testing.T) {
+	t.Parallel()
+
+	vars, err := varcheck.Inspect("testdata/incomplete")
+	require.NoError(t, err)
+	require.Len(t, vars, 5)
+
+	byName := make(map[string]varcheck.Variable)
+	for _, v := range vars {
+		byName[v.Name] = v
+	}
+
+	// Single-line blocks in main.tf count as well as variables.tf
+	assert.Equal(t, "main.tf", byName["environment"].File)
+	assert.False(t, byName["environment"].HasType)
+
+	assert.False(t, byName["name"].HasDescription)
+	assert.False(t, byName["blank"].HasDescription, "A blank description should not count")
+	assert.False(t, byName["null_description"].HasDescription, "A null description should not count")
+	assert.True(t, byName["instance_size"].HasDescription)
+	assert.False(t, byName["instance_size"].HasValidation)
+}
+
+func TestInspectParseError(t *testing.T) {
+	t.Parallel()
+
+	_, err := varcheck.Inspect("testdata/invalid")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "testdata/invalid/variables.tf")
+}
+
+func TestCheckFixtures(t *testing.T) {
+	t.Parallel()
+
+	vars, err := varcheck.InspectAll([]string{"testdata/complete", "testdata/incomplete"})
+	require.NoError(t, err)
+
+	assert.Equal(t, ""+
+		"testdata/incomplete\n"+
+		"  blank (variables.tf:10): no description\n"+
+		"  environment (main.tf:1): no description, no type, no validation block\n"+
+		"  instance_size (variables.tf:5): no validation block\n"+
+		"  name (variables.tf:1): no description\n"+
+		"  null_description (variables.tf:15): no description\n",
+		varcheck.Report(varcheck.Check(vars, varcheck.DefaultMustValidate)))
+}
+
+func TestCheckMustValidateIsConfigurable(t *testing.T) {
+	t.Parallel()
+
+	vars := []varcheck.Variable{
+		{Module: "facade/iam", Name: "identity_type", HasDescription: true, HasType: true},
+		{Module: "facade/iam", Name: "role_name", HasDescription: true, HasType: true},
+	}
+
+	assert.Len(t, varcheck.Check(vars, []string{"identity_type"}), 1)
+	assert.Len(t, varcheck.Check(vars, []string{"identity_type", "role_name"}), 2)
+	assert.Empty(t, varcheck.Check(vars, nil), "Only listed variables need validation")
+}
+
+func TestInspectValidations(t *testing.T) {
+	t.Parallel()
+
+	vars, err := varcheck.Inspect("testdata/messages")
+	require.NoError(t, err)
+	require.Len(t, vars, 4)
+
+	assert.Equal(t, []varcheck.Validation{
+		{Line: 13, Message: "Names must be lower case letters, digits and hyphens"},
+		{Line: 17, Message: "bucket_name ${var.bucket_name} must not start with xn--, e.g. my-bucket"},
+	}, vars[1].Validations, "A template should be kept as written")
+	assert.True(t, vars[3].Validations[0].CrossVariable, "A condition reading var.provider_name checks more than kms_key_ref")
+}
+
+func TestCheckMessages(t *testing.T) {
+	t.Parallel()
+
+	vars, err := varcheck.Inspect("testdata/messages")
+	require.NoError(t, err)
+
+	assert.Equal(t, ""+
+		"testdata/messages\n"+
+		"  bucket_name (variables.tf:10): validation at variables.tf:13 does not name the variable, validation at variables.tf:13 shows no valid value\n"+
+		"  retention_days (variables.tf:23): validation at variables.tf:26 does not name the variable, validation at variables.tf:26 shows no valid value\n",
+		varcheck.Report(varcheck.CheckMessages(vars)))
+}
+
+func TestReportGroupsByModule(t *testing.T) {
+	t.Parallel()
+
+	v := func(module, name string, line int) varcheck.Variable {
+		return varcheck.Variable{Module: module, Name: name, File: "variables.tf", Line: line}
+	}
+	problems := []varcheck.Problem{
+		{Variable: v("facade/storage", "storage_class", 40), Message: "no validation block"},
+		{Variable: v("facade/iam", "name", 3), Message: "no description"},
+		{Variable: v("facade/iam", "name", 3), Message: "no type"},
+		{Variable: v("facade/iam", "identity_type", 24), Message: "no validation block"},
+	}
+
+	assert.Equal(t, ""+
+		"facade/iam\n"+
+		"  identity_type (variables.tf:24): no validation block\n"+
+		"  name (variables.tf:3): no description, no type\n"+
+		"facade/storage\n"+
+		"  storage_class (variables.tf:40): no validation block\n",
+		varcheck.Report(problems))
+	assert.Empty(t, varcheck.Report(nil))
+}
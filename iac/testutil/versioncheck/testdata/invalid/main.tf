@@ -0,0 +1,4 @@
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
@@ -0,0 +1,18 @@
+terraform {
+  required_providers {
+    google = "~> 5.1"
+  }
+}
+
+provider "google" {
+  project = "legacy"
+}
+
+resource "google_compute_network" "this" {
+  name = "legacy"
+}
+
+resource "google_compute_security_policy" "this" {
+  provider = google-beta.edge
+  name     = "legacy"
+}
@@ -0,0 +1,5 @@
+data "aws_caller_identity" "current" {}
+
+resource "aws_s3_bucket" "this" {
+  bucket = "pinned-${data.aws_caller_identity.current.account_id}"
+}
@@ -0,0 +1,18 @@
+terraform {
+  required_providers {
+    azurerm = {
+      source = "registry.terraform.io/HashiCorp/azurerm"
+    }
+    null = {
+      source  = "hashicorp/null"
+      version = ">= 3.0"
+    }
+  }
+}
+
+resource "azurerm_resource_group" "this" {
+  name     = "unpinned"
+  location = "eastus"
+}
+
+resource "null_resource" "this" {}
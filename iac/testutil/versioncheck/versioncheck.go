@@ -0,0 +1,328 @@
+// Package versioncheck audits the provider version constraints of Terraform
+// modules: every provider a module uses must be declared in
+// required_providers with a pessimistic constraint (~> major.minor), and the
+// modules pinning a provider must agree on its major version, so an upgrade
+// such as azurerm 4.x cannot break one module while its siblings stay on 3.x.
+//
+// Modules are read with the HCL parser rather than terraform, so the audit
+// needs no providers, credentials or network access.
+package versioncheck
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Requirement is one required_providers entry
+type Requirement struct {
+	// Name is the provider's local name, e.g. aws
+	Name string
+
+	// Source is the registry address with the default registry host
+	// removed, e.g. hashicorp/aws
+	Source string
+
+	// Version is the raw constraint, empty when none is declared
+	Version string
+
+	File string
+	Line int
+}
+
+// Module is the provider usage of one module directory
+type Module struct {
+	Dir string
+
+	// Required is keyed by provider local name
+	Required map[string]Requirement
+
+	// Used lists the local names of the providers referenced by resource,
+	// data and provider blocks, sorted
+	Used []string
+}
+
+// Inspect parses the .tf files of dir
+func Inspect(dir string) (*Module, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	mod := &Module{Dir: filepath.ToSlash(dir), Required: make(map[string]Requirement)}
+	used := make(map[string]bool)
+
+	parser := hclparse.NewParser()
+	for _, file := range files {
+		f, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("versioncheck: %s", diags.Error())
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, fmt.Errorf("versioncheck: %s is not native HCL syntax", file)
+		}
+
+		for _, block := range body.Blocks {
+			switch block.Type {
+			case "terraform":
+				if err := readRequiredProviders(mod, block.Body, file); err != nil {
+					return nil, err
+				}
+			case "resource", "data":
+				if len(block.Labels) > 0 {
+					used[providerOf(block)] = true
+				}
+			case "provider":
+				if len(block.Labels) > 0 {
+					used[block.Labels[0]] = true
+				}
+			}
+		}
+	}
+
+	for name := range used {
+		mod.Used = append(mod.Used, name)
+	}
+	sort.Strings(mod.Used)
+	return mod, nil
+}
+
+// InspectAll inspects each of dirs
+func InspectAll(dirs []string) ([]*Module, error) {
+	mods := make([]*Module, 0, len(dirs))
+	for _, dir := range dirs {
+		mod, err := Inspect(dir)
+		if err != nil {
+			return nil, err
+		}
+		mods = append(mods, mod)
+	}
+	return mods, nil
+}
+
+func readRequiredProviders(mod *Module, body *hclsyntax.Body, file string) error {
+	for _, block := range body.Blocks {
+		if block.Type != "required_providers" {
+			continue
+		}
+		for name, attr := range block.Body.Attributes {
+			req := Requirement{
+				Name:   name,
+				Source: "hashicorp/" + name,
+				File:   filepath.ToSlash(file),
+				Line:   attr.SrcRange.Start.Line,
+			}
+
+			// Legacy form: aws = "~> 5.0"
+			if version, ok := stringValue(attr.Expr); ok {
+				req.Version = version
+				mod.Required[name] = req
+				continue
+			}
+
+			pairs, diags := hcl.ExprMap(attr.Expr)
+			if diags.HasErrors() {
+				return fmt.Errorf("versioncheck: %s: required_providers.%s: %s", file, name, diags.Error())
+			}
+			for _, pair := range pairs {
+				key, _ := stringValue(pair.Key)
+				switch key {
+				case "source":
+					if source, ok := stringValue(pair.Value); ok {
+						req.Source = normalizeSource(source)
+					}
+				case "version":
+					if version, ok := stringValue(pair.Value); ok {
+						req.Version = version
+					}
+				}
+			}
+			mod.Required[name] = req
+		}
+	}
+	return nil
+}
+
+// providerOf returns the provider local name of a resource or data block:
+// its provider meta-argument if set, else the resource type's prefix
+func providerOf(block *hclsyntax.Block) string {
+	if attr, ok := block.Body.Attributes["provider"]; ok {
+		if traversal, diags := hcl.AbsTraversalForExpr(attr.Expr); !diags.HasErrors() {
+			return traversal.RootName()
+		}
+	}
+	name, _, _ := strings.Cut(block.Labels[0], "_")
+	return name
+}
+
+// stringValue evaluates a constant string expression
+func stringValue(expr hcl.Expression) (string, bool) {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+		return "", false
+	}
+	return v.AsString(), true
+}
+
+func normalizeSource(source string) string {
+	source = strings.ToLower(source)
+	source = strings.TrimPrefix(source, "registry.terraform.io/")
+	return source
+}
+
+// Exemption lets a module declare a provider outside the rules, e.g. to pin
+// an exact version while a regression is open upstream
+type Exemption struct {
+	Module   string
+	Provider string
+	Reason   string
+}
+
+// Problem is one rule a module breaks
+type Problem struct {
+	Module   string
+	Provider string
+	Message  string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Module, p.Provider, p.Message)
+}
+
+var pessimistic = regexp.MustCompile(`^~>\s*(\d+)\.\d+(\.\d+)?$`)
+
+// Audit checks mods against the rules, skipping exempted module/provider
+// pairs, and returns the problems sorted by module and provider
+func Audit(mods []*Module, exemptions []Exemption) []Problem {
+	exempt := make(map[[2]string]bool, len(exemptions))
+	for _, e := range exemptions {
+		exempt[[2]string{e.Module, e.Provider}] = true
+	}
+
+	var problems []Problem
+
+	// majors[source][major] lists the modules pinning that major
+	majors := make(map[string]map[int][]pin)
+
+	for _, mod := range mods {
+		for _, name := range mod.Used {
+			if _, ok := mod.Required[name]; !ok && !exempt[[2]string{mod.Dir, name}] {
+				problems = append(problems, Problem{mod.Dir, name, "used but not declared in required_providers"})
+			}
+		}
+
+		for name, req := range mod.Required {
+			if exempt[[2]string{mod.Dir, name}] {
+				continue
+			}
+			if req.Version == "" {
+				problems = append(problems, Problem{mod.Dir, name, "no version constraint"})
+				continue
+			}
+			m := pessimistic.FindStringSubmatch(strings.TrimSpace(req.Version))
+			if m == nil {
+				problems = append(problems, Problem{mod.Dir, name, fmt.Sprintf("constraint %q is not pessimistic (~> major.minor)", req.Version)})
+				continue
+			}
+			major, _ := strconv.Atoi(m[1])
+			if majors[req.Source] == nil {
+				majors[req.Source] = make(map[int][]pin)
+			}
+			majors[req.Source][major] = append(majors[req.Source][major], pin{mod.Dir, name})
+		}
+	}
+
+	for source, byMajor := range majors {
+		if len(byMajor) < 2 {
+			continue
+		}
+		want := mostCommonMajor(byMajor)
+		for major, pins := range byMajor {
+			if major == want {
+				continue
+			}
+			for _, p := range pins {
+				problems = append(problems, Problem{p.module, p.provider, fmt.Sprintf(
+					"%s pinned to major %d, but %d other modules use %d", source, major, len(byMajor[want]), want)})
+			}
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Module != problems[j].Module {
+			return problems[i].Module < problems[j].Module
+		}
+		if problems[i].Provider != problems[j].Provider {
+			return problems[i].Provider < problems[j].Provider
+		}
+		return problems[i].Message < problems[j].Message
+	})
+	return problems
+}
+
+// pin is a module pinning a provider, by local name
+type pin struct {
+	module   string
+	provider string
+}
+
+// mostCommonMajor picks the major most modules pin, the newer one on a tie
+func mostCommonMajor(byMajor map[int][]pin) int {
+	best := -1
+	for major, dirs := range byMajor {
+		if best < 0 || len(dirs) > len(byMajor[best]) || len(dirs) == len(byMajor[best]) && major > best {
+			best = major
+		}
+	}
+	return best
+}
+
+// Table renders module, provider, source and constraint for every module
+// that declares or uses a provider, for failure output
+func Table(mods []*Module) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODULE\tPROVIDER\tSOURCE\tCONSTRAINT")
+
+	sorted := append([]*Module(nil), mods...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Dir < sorted[j].Dir })
+
+	for _, mod := range sorted {
+		names := make(map[string]bool)
+		for name := range mod.Required {
+			names[name] = true
+		}
+		for _, name := range mod.Used {
+			names[name] = true
+		}
+		sortedNames := make([]string, 0, len(names))
+		for name := range names {
+			sortedNames = append(sortedNames, name)
+		}
+		sort.Strings(sortedNames)
+
+		for _, name := range sortedNames {
+			source, constraint := "-", "(undeclared)"
+			if req, ok := mod.Required[name]; ok {
+				source, constraint = req.Source, req.Version
+				if constraint == "" {
+					constraint = "(none)"
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mod.Dir, name, source, constraint)
+		}
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package versioncheck_test
+
+import (
+	"testing"
+
+	"iac/testutil/versioncheck"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	mod, err := versioncheck.Inspect("testdata/pinned")
+	require.NoError(t, err)
+
+	assert.Equal(t, "testdata/pinned", mod.Dir)
+	assert.Equal(t, []string{"aws"}, mod.Used, "Resources and data sources should both count")
+	assert.Equal(t, map[string]versioncheck.Requirement{
+		"aws": {
+			Name:    "aws",
+			Source:  "hashicorp/aws",
+			Version: "~> 5.0",
+			File:    "testdata/pinned/versions.tf",
+			Line:    5,
+		},
+	}, mod.Required)
+}
+
+func TestInspectLegacyRequirementAndProviderMetaArgument(t *testing.T) {
+	t.Parallel()
+
+	mod, err := versioncheck.Inspect("testdata/legacy")
+	require.NoError(t, err)
+
+	require.Contains(t, mod.Required, "google")
+	assert.Equal(t, "~> 5.1", mod.Required["google"].Version)
+	assert.Equal(t, "hashicorp/google", mod.Required["google"].Source, "An omitted source defaults to hashicorp/<name>")
+
+	// google-beta comes from the provider meta-argument, not the type prefix
+	assert.Equal(t, []string{"google", "google-beta"}, mod.Used)
+}
+
+func TestInspectNormalizesSource(t *testing.T) {
+	t.Parallel()
+
+	mod, err := versioncheck.Inspect("testdata/unpinned")
+	require.NoError(t, err)
+
+	assert.Equal(t, "hashicorp/azurerm", mod.Required["azurerm"].Source)
+	assert.Equal(t, "", mod.Required["azurerm"].Version)
+	assert.Equal(t, []string{"azurerm", "null"}, mod.Used)
+}
+
+func TestInspectWithoutProviders(t *testing.T) {
+	t.Parallel()
+
+	mod, err := versioncheck.Inspect("testdata/no-providers")
+	require.NoError(t, err)
+
+	assert.Empty(t, mod.Required)
+	assert.Empty(t, mod.Used)
+}
+
+func TestInspectParseError(t *testing.T) {
+	t.Parallel()
+
+	_, err := versioncheck.Inspect("testdata/invalid")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "testdata/invalid/main.tf")
+}
+
+func TestAuditFixtures(t *testing.T) {
+	t.Parallel()
+
+	mods, err := versioncheck.InspectAll([]string{
+		"testdata/legacy",
+		"testdata/no-providers",
+		"testdata/pinned",
+		"testdata/unpinned",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []versioncheck.Problem{
+		{Module: "testdata/legacy", Provider: "google-beta", Message: "used but not declared in required_providers"},
+		{Module: "testdata/unpinned", Provider: "azurerm", Message: "no version constraint"},
+		{Module: "testdata/unpinned", Provider: "null", Message: `constraint ">= 3.0" is not pessimistic (~> major.minor)`},
+	}, versioncheck.Audit(mods, nil))
+}
+
+func requirement(name, source, version string) versioncheck.Requirement {
+	return versioncheck.Requirement{Name: name, Source: source, Version: version}
+}
+
+func TestAuditConstraintForms(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"~> 5.0":          true,
+		"~>5.31":          true,
+		"~> 3.2.1":        true,
+		"~> 5":            false,
+		">= 5.0":          false,
+		"5.31.0":          false,
+		">= 5.0, < 6.0":   false,
+		"~> 5.0, != 5.12": false,
+	}
+
+	for constraint, ok := range tests {
+		mods := []*versioncheck.Module{{
+			Dir:      "aws/core/storage",
+			Required: map[string]versioncheck.Requirement{"aws": requirement("aws", "hashicorp/aws", constraint)},
+			Used:     []string{"aws"},
+		}}
+		assert.Equal(t, ok, len(versioncheck.Audit(mods, nil)) == 0, constraint)
+	}
+}
+
+func TestAuditMajorConsistency(t *testing.T) {
+	t.Parallel()
+
+	mod := func(dir, version string) *versioncheck.Module {
+		return &versioncheck.Module{
+			Dir:      dir,
+			Required: map[string]versioncheck.Requirement{"azurerm": requirement("azurerm", "hashicorp/azurerm", version)},
+			Used:     []string{"azurerm"},
+		}
+	}
+	mods := []*versioncheck.Module{
+		mod("azure/core/compute", "~> 3.0"),
+		mod("azure/core/storage", "~> 3.100"),
+		mod("azure/core/messaging", "~> 4.0"),
+	}
+
+	assert.Equal(t, []versioncheck.Problem{{
+		Module:   "azure/core/messaging",
+		Provider: "azurerm",
+		Message:  "hashicorp/azurerm pinned to major 4, but 2 other modules use 3",
+	}}, versioncheck.Audit(mods, nil))
+
+	exemptions := []versioncheck.Exemption{{
+		Module:   "azure/core/messaging",
+		Provider: "azurerm",
+		Reason:   "Trials the 4.x Service Bus resources",
+	}}
+	assert.Empty(t, versioncheck.Audit(mods, exemptions), "An exempt module should not count towards or against the majority")
+}
+
+func TestAuditMatchesBySource(t *testing.T) {
+	t.Parallel()
+
+	// The same provider under a different local name is still compared
+	mods := []*versioncheck.Module{
+		{Dir: "a", Required: map[string]versioncheck.Requirement{"aws": requirement("aws", "hashicorp/aws", "~> 5.0")}},
+		{Dir: "b", Required: map[string]versioncheck.Requirement{"amazon": requirement("amazon", "hashicorp/aws", "~> 4.67")}},
+	}
+
+	problems := versioncheck.Audit(mods, nil)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "b", problems[0].Module, "A tie should favor the newer major")
+	assert.Equal(t, "amazon", problems[0].Provider)
+}
+
+func TestTable(t *testing.T) {
+	t.Parallel()
+
+	mods := []*versioncheck.Module{
+		{
+			Dir:      "facade/lambda",
+			Required: map[string]versioncheck.Requirement{"null": requirement("null", "hashicorp/null", "")},
+			Used:     []string{"archive", "null"},
+		},
+		{
+			Dir:      "aws/core/storage",
+			Required: map[string]versioncheck.Requirement{"aws": requirement("aws", "hashicorp/aws", "~> 5.0")},
+			Used:     []string{"aws"},
+		},
+		{Dir: "api/storage"},
+	}
+
+	assert.Equal(t, ""+
+		"MODULE            PROVIDER  SOURCE          CONSTRAINT\n"+
+		"aws/core/storage  aws       hashicorp/aws   ~> 5.0\n"+
+		"facade/lambda     archive   -               (undeclared)\n"+
+		"facade/lambda     null      hashicorp/null  (none)\n",
+		versioncheck.Table(mods))
+}
@@ -0,0 +1,107 @@
+// Command driftscan plans every deployed root module in a manifest, as a
+// nightly job would, and reports what drifted from its state. Run it from
+// the iac module, with credentials that can read the state and the
+// resources:
+//
+//	go run ./tools/driftscan -manifest live/modules.json
+//	go run ./tools/driftscan -manifest live/modules.json -out drift -concurrency 2
+//
+// The manifest is JSON; paths in it are relative to it:
+//
+//	{
+//	  "concurrency": 4,
+//	  "modules": [
+//	    {"path": "network", "backend_config": "backend.hcl", "var_files": ["prod.tfvars"]},
+//	    {"name": "storage-eu", "path": "storage", "vars": {"region": "eu-west-1"}}
+//	  ]
+//	}
+//
+// Each module gets a refresh-only plan and a regular one, neither taking
+// the state lock (see testutil/driftscan). The JSON report and a Markdown
+// summary go to the -out directory, with each module's redacted Terraform
+// log under logs/, and the summary to stdout as well. SWE_TF_BINARY
+// selects the CLI. driftscan exits 0 for no drift, 1 for in-place drift,
+// 2 for destructive drift (an object deleted outside Terraform, or a plan
+// that would replace or destroy one) and 3 when a module could not be
+// planned or the scan could not run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"iac/testutil/driftscan"
+	"iac/testutil/tfopts"
+)
+
+func main() {
+	manifestPath := flag.String("manifest", "", "JSON manifest of the root modules to scan")
+	out := flag.String("out", "driftscan", "directory the reports and logs are written to")
+	limit := flag.Int("concurrency", 0, "modules planned at once; 0 keeps the manifest's")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: driftscan -manifest modules.json [-out dir] [-concurrency n]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *manifestPath == "" || flag.NArg() > 0 || *limit < 0 {
+		flag.Usage()
+		os.Exit(driftscan.ExitFailed)
+	}
+
+	manifest, err := driftscan.LoadManifest(*manifestPath)
+	if err != nil {
+		fail(err)
+	}
+	if *limit > 0 {
+		manifest.Concurrency = *limit
+	}
+	binary, err := tfopts.BinaryFromEnv()
+	if err != nil {
+		fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	tf := driftscan.NewTerraform(binary, filepath.Join(*out, "logs"))
+	report := driftscan.NewReport(driftscan.Scan(ctx, manifest.Modules, manifest.Concurrency, tf), tf.LogPath)
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fail(fmt.Errorf("driftscan: %w", err))
+	}
+	if err := writeFile(filepath.Join(*out, driftscan.JSONReportFile), report.WriteJSON); err != nil {
+		fail(fmt.Errorf("driftscan: %w", err))
+	}
+	if err := writeFile(filepath.Join(*out, driftscan.MarkdownReportFile), report.WriteMarkdown); err != nil {
+		fail(fmt.Errorf("driftscan: %w", err))
+	}
+	if err := report.WriteMarkdown(os.Stdout); err != nil {
+		fail(fmt.Errorf("driftscan: %w", err))
+	}
+	os.Exit(report.ExitCode)
+}
+
+// writeFile creates path and writes it with write
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fail prints err and exits as a scan that could not run
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(driftscan.ExitFailed)
+}
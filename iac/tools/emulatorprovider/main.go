@@ -0,0 +1,92 @@
+// Command emulatorprovider copies common/emulator-provider/provider.tf, the
+// AWS provider block pointed at a local emulator, into every directory with
+// an emulator_provider.tf (see testutil/emulatorprovider):
+//
+//	go run ./tools/emulatorprovider
+//	go run ./tools/emulatorprovider --check
+//	go run ./tools/emulatorprovider examples/new-example
+//
+// Directories given as arguments become consumers, whether or not they
+// have a copy yet. With --check nothing is written; each stale copy's diff
+// is printed and the command exits 1, as it does when a consumer does not
+// declare a variable the block reads. It exits 2 when a file cannot be
+// read.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"iac/testutil/emulatorprovider"
+)
+
+func main() {
+	root := flag.String("root", ".", "repository `directory` to scan")
+	check := flag.Bool("check", false, "report stale copies instead of writing them")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: emulatorprovider [--root dir] [--check] [dir ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	source := filepath.Join(*root, emulatorprovider.Source)
+	generated, err := emulatorprovider.Render(source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	dirs, err := emulatorprovider.Consumers(*root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	dirs = append(dirs, flag.Args()...)
+
+	stale, incomplete := 0, 0
+	for _, dir := range dirs {
+		missing, err := emulatorprovider.Missing(source, dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if len(missing) > 0 {
+			incomplete++
+			fmt.Fprintf(os.Stderr, "%s does not declare %s\n", dir, strings.Join(missing, ", "))
+		}
+
+		diff, err := emulatorprovider.Stale(dir, generated)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if diff == "" {
+			continue
+		}
+		stale++
+
+		path := filepath.Join(dir, emulatorprovider.FileName)
+		if *check {
+			fmt.Fprint(os.Stderr, diff)
+			continue
+		}
+		if err := os.WriteFile(path, generated, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		fmt.Printf("wrote %s\n", path)
+	}
+
+	if *check && stale > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d emulator provider copies are stale; run go run ./tools/emulatorprovider\n", stale, len(dirs))
+	}
+	if incomplete > 0 || (*check && stale > 0) {
+		os.Exit(1)
+	}
+	if stale == 0 {
+		fmt.Printf("%d emulator provider copies up to date\n", len(dirs))
+	}
+}
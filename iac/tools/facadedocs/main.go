@@ -0,0 +1,77 @@
+// Command facadedocs writes the README.generated.md of every facade (see
+// testutil/facadedocs): the providers with a submodule, and tables of the
+// facade's inputs and outputs read from its .tf files:
+//
+//	go run ./tools/facadedocs
+//	go run ./tools/facadedocs --check
+//
+// With --check nothing is written; each stale file's diff is printed and
+// the command exits 1. It exits 2 when a facade cannot be read.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"iac/testutil/facadedocs"
+)
+
+func main() {
+	root := flag.String("root", ".", "repository `directory` to scan")
+	check := flag.Bool("check", false, "report stale files instead of writing them")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: facadedocs [--root dir] [--check]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dirs, err := facadedocs.Dirs(*root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	stale := 0
+	for _, dir := range dirs {
+		generated, err := facadedocs.Generate(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		diff, err := facadedocs.Stale(dir, generated)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if diff == "" {
+			continue
+		}
+		stale++
+
+		path := filepath.Join(dir, facadedocs.FileName)
+		if *check {
+			fmt.Fprint(os.Stderr, diff)
+			continue
+		}
+		if err := os.WriteFile(path, generated, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		fmt.Printf("wrote %s\n", path)
+	}
+
+	if *check && stale > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d facade docs are stale; run go run ./tools/facadedocs\n", stale, len(dirs))
+		os.Exit(1)
+	}
+	if stale == 0 {
+		fmt.Printf("%d facade docs up to date\n", len(dirs))
+	}
+}
@@ -0,0 +1,118 @@
+// Command httptrace summarizes the HTTP traces tests write with
+// SWE_RECORD_HTTP=1 (see testutil/httprecord): a row per request, then the
+// calls, failures and latency of each operation. Arguments are trace files
+// or directories searched for http.jsonl, such as a test's artifact
+// directory or the whole of $SWE_TEST_ARTIFACT_DIR:
+//
+//	go run ./tools/httptrace "$SWE_TEST_ARTIFACT_DIR/TestCloudEmuStorage"
+//	go run ./tools/httptrace --filter service=s3 --filter status=4xx "$SWE_TEST_ARTIFACT_DIR"
+//
+// --raw prints the matching exchanges as JSON lines instead, bodies and
+// all. It exits 2 when a trace cannot be read.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"iac/testutil/httprecord"
+)
+
+func main() {
+	filter := httprecord.Filter{}
+	raw := flag.Bool("raw", false, "print the matching exchanges as JSON lines")
+	flag.Func("filter", "key=value to match, with key one of: "+strings.Join(httprecord.FilterKeys, ", ")+". Repeatable", filter.Parse)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: httptrace [--filter key=value]... [--raw] trace-or-dir...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	files, err := traces(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "httptrace: %v\n", err)
+		os.Exit(2)
+	}
+
+	for i, file := range files {
+		exchanges, err := read(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "httptrace: %s: %v\n", file, err)
+			os.Exit(2)
+		}
+		exchanges = filter.Apply(exchanges)
+
+		if *raw {
+			enc := json.NewEncoder(os.Stdout)
+			for _, e := range exchanges {
+				if err := enc.Encode(e); err != nil {
+					fmt.Fprintf(os.Stderr, "httptrace: %v\n", err)
+					os.Exit(2)
+				}
+			}
+			continue
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("== %s (%d exchanges)\n", file, len(exchanges))
+		if err := httprecord.WriteSummary(os.Stdout, exchanges); err != nil {
+			fmt.Fprintf(os.Stderr, "httptrace: %v\n", err)
+			os.Exit(2)
+		}
+	}
+}
+
+// traces returns the files among args and the traces under the
+// directories among them, sorted
+func traces(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && d.Name() == httprecord.TraceFile {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no %s under %s", httprecord.TraceFile, strings.Join(args, ", "))
+	}
+	return files, nil
+}
+
+func read(path string) ([]httprecord.Exchange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return httprecord.Read(f)
+}
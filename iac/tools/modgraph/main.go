@@ -0,0 +1,55 @@
+// Command modgraph checks the local module calls of the repository for
+// cycles and layering violations (see testutil/modgraph), and prints the
+// graph as DOT for Graphviz:
+//
+//	go run ./tools/modgraph
+//	go run ./tools/modgraph --dot | dot -Tsvg > modules.svg
+//
+// Calls that break a rule are drawn red. It exits 1 when a rule is broken
+// and 2 when the modules cannot be read.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"iac/testutil/modgraph"
+)
+
+func main() {
+	root := flag.String("root", ".", "repository `directory` to scan")
+	dot := flag.Bool("dot", false, "print the graph as DOT on stdout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: modgraph [--root dir] [--dot]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	g, err := modgraph.Build(*root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	violations := g.Check()
+
+	if *dot {
+		fmt.Print(g.DOT(violations))
+	}
+
+	if len(violations) > 0 {
+		fmt.Fprintf(os.Stderr, "%d module graph violation(s):\n", len(violations))
+		for _, v := range violations {
+			fmt.Fprintf(os.Stderr, "  %s\n", v)
+		}
+		os.Exit(1)
+	}
+	if !*dot {
+		fmt.Printf("%d modules, no cycles or layering violations\n", len(g.Modules))
+	}
+}
@@ -0,0 +1,120 @@
+// Command plandiff shows what a pull request does to the plans of the
+// facades it changes, as a Markdown comment for its reviewers. Run it from
+// the iac module with the pull request's base and head refs:
+//
+//	go run ./tools/plandiff -base origin/main -head HEAD
+//	go run ./tools/plandiff -base origin/main -head feature/login -cloudemu -out plandiff.md
+//	go run ./tools/plandiff -base v1.4.0 -head HEAD -var provider_name=azure
+//
+// The files changed between the merge base of the two refs and the head
+// select the facades, and each facade's default example is planned at both
+// commits, each checked out into a temporary git worktree (see
+// testutil/plandiff). With -cloudemu the AWS provider is pointed at CloudEmu
+// with placeholder credentials, the endpoint and region coming from the
+// shared test config (SWE_TEST_CONFIG and the SWE_* variables); otherwise
+// the credentials in the environment are used. -var values go to the
+// examples that declare the variable. SWE_TF_BINARY selects the CLI.
+//
+// The report goes to stdout, or to the -out file. plandiff exits 1 when an
+// example could not be planned and 2 when it cannot run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"iac/testutil/config"
+	"iac/testutil/plandiff"
+	"iac/testutil/tfopts"
+)
+
+func main() {
+	vars := map[string]interface{}{}
+	base := flag.String("base", "", "git ref the pull request merges into, e.g. origin/main")
+	head := flag.String("head", "HEAD", "git ref of the pull request")
+	root := flag.String("root", ".", "`directory` of the Terraform tree within the repository")
+	out := flag.String("out", "", "file the report is written to instead of stdout")
+	cloudEmu := flag.Bool("cloudemu", false, "plan against CloudEmu with placeholder credentials")
+	flag.Func("var", "`name=value` passed to the examples declaring name. Repeatable", func(s string) error {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("want name=value, got %q", s)
+		}
+		vars[name] = value
+		return nil
+	})
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: plandiff -base ref [-head ref] [-root dir] [-out file] [-cloudemu] [-var name=value]...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *base == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	binary, err := tfopts.BinaryFromEnv()
+	if err != nil {
+		fail(err)
+	}
+	tf := &plandiff.Terraform{Binary: binary}
+	if *cloudEmu {
+		cfg, err := config.LoadTestConfig()
+		if err != nil {
+			fail(err)
+		}
+		tf.Env = plandiff.EmulatorEnv(cfg.CloudEmuEndpoint, cfg.Region)
+		if _, ok := vars["emulator_endpoint"]; !ok {
+			vars["emulator_endpoint"] = cfg.CloudEmuEndpoint
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Compare against where the head branched off, so commits merged into
+	// the base since do not show up as the pull request's
+	mergeBase, err := plandiff.MergeBase(*root, *base, *head)
+	if err != nil {
+		fail(err)
+	}
+	report, err := plandiff.Review(ctx, *root, mergeBase, *head, tf, vars)
+	if err != nil {
+		fail(err)
+	}
+
+	if err := write(*out, report.WriteMarkdown); err != nil {
+		fail(fmt.Errorf("plandiff: %w", err))
+	}
+	if report.Failed() {
+		os.Exit(1)
+	}
+}
+
+// write writes to path with fn, or to stdout when path is empty
+func write(path string, fn func(io.Writer) error) error {
+	if path == "" {
+		return fn(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fail prints err and exits as a comparison that could not run
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
@@ -0,0 +1,125 @@
+// Command planrisk prints what a Terraform plan does to each resource and
+// fails when it would replace or destroy any, so a consumer can check a
+// facade upgrade against their own state before applying it:
+//
+//	terraform plan -out plan.tfplan && terraform show -json plan.tfplan > plan.json
+//	go run ./tools/planrisk --plan plan.json
+//	go run ./tools/planrisk --plan plan.json --allow 'module.storage.module.aws_storage[0].aws_s3_bucket_versioning.*'
+//
+// Rows are colored by action unless -no-color or NO_COLOR is set. It exits
+// 1 when a replacement or destroy is not allowlisted and 2 when it cannot
+// read the plan.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"iac/testutil/planrisk"
+)
+
+// ANSI colors for each action and the header; every one is the same
+// length, so colored cells stay aligned in the table
+var colors = map[planrisk.Action]string{
+	planrisk.NoOp:    "\x1b[90m",
+	planrisk.Create:  "\x1b[32m",
+	planrisk.Update:  "\x1b[33m",
+	planrisk.Replace: "\x1b[31m",
+	planrisk.Destroy: "\x1b[31m",
+}
+
+const (
+	bold  = "\x1b[01m"
+	reset = "\x1b[0m"
+)
+
+func main() {
+	var allow []string
+	path := flag.String("plan", "", "`terraform show -json` output for the plan")
+	all := flag.Bool("all", false, "list unchanged resources too")
+	noColor := flag.Bool("no-color", os.Getenv("NO_COLOR") != "", "print without ANSI colors")
+	flag.Func("allow", "address pattern, with * wildcards, whose replacement or destroy is intended. Repeatable", func(pattern string) error {
+		allow = append(allow, pattern)
+		return nil
+	})
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: planrisk --plan plan.json [--allow pattern]... [--all] [--no-color]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *path == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "planrisk: %v\n", err)
+		os.Exit(2)
+	}
+	summary, err := planrisk.Classify(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if err := writeTable(os.Stdout, summary, *all, !*noColor); err != nil {
+		fmt.Fprintf(os.Stderr, "planrisk: %v\n", err)
+		os.Exit(2)
+	}
+
+	if destructive := summary.Destructive(allow...); len(destructive) > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d resource(s) would be replaced or destroyed:\n", len(destructive))
+		for _, c := range destructive {
+			fmt.Fprintf(os.Stderr, "  %s\n", c)
+		}
+		os.Exit(1)
+	}
+}
+
+// writeTable writes one row per change, skipping no-ops unless all is set,
+// then the plan's counts
+func writeTable(w io.Writer, summary *planrisk.Summary, all, color bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "ACTION"
+	if color {
+		header = bold + header + reset
+	}
+	fmt.Fprintf(tw, "%s\tADDRESS\tDETAIL\n", header)
+	for _, c := range summary.Changes {
+		if c.Action == planrisk.NoOp && !all {
+			continue
+		}
+		action := string(c.Action)
+		if color {
+			action = colors[c.Action] + action + reset
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", action, c.Address, detail(c))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nPlan: %s\n", summary)
+	return err
+}
+
+// detail explains a change: why terraform planned it, what forced a
+// replacement, and the order of a create_before_destroy one
+func detail(c planrisk.Change) string {
+	var parts []string
+	if c.Reason != "" {
+		parts = append(parts, c.Reason)
+	}
+	if len(c.ReplacePaths) > 0 {
+		parts = append(parts, "forced by "+strings.Join(c.Paths(), ", "))
+	}
+	if c.CreateBeforeDestroy {
+		parts = append(parts, "create before destroy")
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,162 @@
+// Command preview brings up the platform service stack as an ephemeral
+// preview environment named after a branch, and tears it down again. Run
+// it from the iac module:
+//
+//	go run ./tools/preview up -name feature/login         # on CloudEmu
+//	go run ./tools/preview up -target aws                 # named after GITHUB_HEAD_REF
+//	go run ./tools/preview status
+//	go run ./tools/preview down -name feature/login
+//	go run ./tools/preview down -all-older-than 24h
+//
+// Each preview is applied from examples/preview in a Terraform workspace
+// named by its slug (see testutil/preview). The CloudEmu endpoint and
+// region come from the shared test config (SWE_TEST_CONFIG and the SWE_*
+// variables); -target aws applies to the account the AWS credentials in
+// the environment select. preview exits 1 when a preview fails to come up
+// or down and 2 when it cannot run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"iac/testutil/config"
+	"iac/testutil/preview"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "up":
+		err = up(ctx, args)
+	case "down":
+		err = down(ctx, args)
+	case "status":
+		err = status(args)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "preview: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: preview up|down|status [flags]; preview <command> -h lists each command's flags\n")
+	os.Exit(2)
+}
+
+// commonFlags are the flags every command takes
+func commonFlags(name string, opts *preview.Options) *flag.FlagSet {
+	flags := flag.NewFlagSet("preview "+name, flag.ExitOnError)
+	flags.StringVar(&opts.Dir, "dir", preview.DefaultDir, "root module previews are applied from")
+	flags.StringVar(&opts.Binary, "terraform", "terraform", "terraform executable")
+	return flags
+}
+
+func up(ctx context.Context, args []string) error {
+	opts := preview.Options{Stdout: os.Stdout, Stderr: os.Stderr}
+	flags := commonFlags("up", &opts)
+	name := flags.String("name", "", "branch to name the preview after; "+preview.EnvHeadRef+" when empty")
+	target := flags.String("target", "cloudemu", "where to apply: cloudemu, or aws for the account the credentials select")
+	flags.StringVar(&opts.ProjectName, "project", "", "service name the slug is appended to (default the root module's)")
+	features := flags.String("features", "", "comma-separated platform service features to create, e.g. queue,bucket (default all)")
+	flags.Parse(args)
+
+	branch, err := preview.Name(*name, os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadTestConfig()
+	if err != nil {
+		fatal(err)
+	}
+	opts.Region = cfg.Region
+	switch *target {
+	case "cloudemu":
+		opts.EmulatorEndpoint = cfg.CloudEmuEndpoint
+	case preview.TargetAccount:
+	default:
+		fatal(fmt.Errorf("unknown target %q, want cloudemu or aws", *target))
+	}
+	if *features != "" {
+		opts.Features = map[string]bool{}
+		for _, f := range strings.Split(*features, ",") {
+			opts.Features[strings.TrimSpace(f)] = true
+		}
+	}
+
+	entry, err := preview.Up(ctx, opts, branch)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\npreview %s is up on %s\n", entry.Slug, entry.Target)
+	names := make([]string, 0, len(entry.Outputs))
+	for name := range entry.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %s = %s\n", name, entry.Outputs[name])
+	}
+	return nil
+}
+
+func down(ctx context.Context, args []string) error {
+	opts := preview.Options{Stdout: os.Stdout, Stderr: os.Stderr}
+	flags := commonFlags("down", &opts)
+	name := flags.String("name", "", "branch the preview is named after; "+preview.EnvHeadRef+" when empty")
+	olderThan := flags.Duration("all-older-than", 0, "bring down every preview created longer ago than this instead, e.g. 24h")
+	flags.Parse(args)
+
+	if *olderThan > 0 {
+		removed, err := preview.Reap(ctx, opts, *olderThan, time.Now())
+		for _, slug := range removed {
+			fmt.Printf("preview %s is down\n", slug)
+		}
+		return err
+	}
+
+	branch, err := preview.Name(*name, os.Getenv)
+	if err != nil {
+		return err
+	}
+	if err := preview.Down(ctx, opts, branch); err != nil {
+		return err
+	}
+	slug, _ := preview.Slug(branch)
+	fmt.Printf("preview %s is down\n", slug)
+	return nil
+}
+
+func status(args []string) error {
+	var opts preview.Options
+	commonFlags("status", &opts).Parse(args)
+
+	entries, err := preview.Status(opts)
+	if err != nil {
+		return err
+	}
+	return preview.WriteStatus(os.Stdout, entries, time.Now())
+}
+
+// fatal reports a problem with the command line or configuration
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "preview: %v\n", err)
+	os.Exit(2)
+}
@@ -0,0 +1,194 @@
+// Command queueloader puts sustained load on an emulator queue and reports
+// send and receive throughput, end-to-end latency percentiles and errors,
+// as a table or as JSON. Run it from the iac module:
+//
+//	go run ./tools/queueloader -create bench -producers 8 -consumers 8 -duration 30s
+//	go run ./tools/queueloader -queue http://localhost:4566/000000000000/orders -json
+//	go run ./tools/queueloader -backend servicebus -queue orders -size 1024
+//	go run ./tools/queueloader -backend pubsub -topic orders -subscription orders-load
+//
+// -create applies the messaging facade for an SQS queue of that name on
+// CloudEmu from tools/queueloader/queue, and destroys it when the run ends;
+// Service Bus queues and Pub/Sub subscriptions must already exist.
+// Endpoints come from the shared test config (SWE_TEST_CONFIG and the
+// SWE_*_ENDPOINT variables). queueloader exits 1 when a send or receive
+// failed or a message was lost, and 2 when it cannot run.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"iac/testutil/config"
+	"iac/testutil/queueload"
+	"iac/testutil/smoke"
+)
+
+// queueModule is the root module -create applies, relative to the iac
+// module
+const queueModule = "tools/queueloader/queue"
+
+func main() {
+	os.Exit(run())
+}
+
+// run is main, returning the exit code once the deferred destroy of a
+// -create queue has run
+func run() int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := config.LoadTestConfig()
+	if err != nil {
+		return fail(err)
+	}
+
+	var opts queueload.Options
+	flags := flag.NewFlagSet("queueloader", flag.ExitOnError)
+	backendName := flags.String("backend", "sqs", "queue to load: sqs, servicebus or pubsub")
+	queue := flags.String("queue", "", "SQS queue URL, or Service Bus queue name")
+	create := flags.String("create", "", "create an SQS queue of this name with the messaging facade, and destroy it afterwards")
+	topic := flags.String("topic", "", "Pub/Sub topic to publish to")
+	subscription := flags.String("subscription", "", "Pub/Sub subscription to the topic to receive from")
+	project := flags.String("project", smoke.GCPProject, "GCP project of the Pub/Sub topic")
+	binary := flags.String("terraform", "terraform", "terraform executable for -create")
+	asJSON := flags.Bool("json", false, "print the report as JSON instead of a table")
+	flags.IntVar(&opts.Producers, "producers", queueload.DefaultProducers, "goroutines sending")
+	flags.IntVar(&opts.Consumers, "consumers", queueload.DefaultConsumers, "goroutines receiving")
+	flags.IntVar(&opts.MessageSize, "size", queueload.DefaultMessageSize, "message size in bytes")
+	flags.DurationVar(&opts.Duration, "duration", queueload.DefaultDuration, "how long to send")
+	flags.DurationVar(&opts.Drain, "drain", queueload.DefaultDrain, "how long to keep receiving once sending stops")
+	flags.DurationVar(&opts.Interval, "interval", queueload.DefaultInterval, "width of each throughput interval in the report")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: queueloader [-backend sqs|servicebus|pubsub] [-create name | -queue url|name | -topic t -subscription s] [flags]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if err := cfg.Validate(); err != nil {
+		return fail(err)
+	}
+
+	if *create != "" {
+		if *backendName != "sqs" || *queue != "" {
+			return fail(errors.New("-create makes an SQS queue, and takes neither -queue nor another -backend"))
+		}
+		tf := terraform{binary: *binary, dir: queueModule}
+		url, err := tf.apply(ctx, cfg, *create)
+		defer func() {
+			if err := tf.destroy(cfg, *create); err != nil {
+				fmt.Fprintf(os.Stderr, "queueloader: %v\n", err)
+			}
+		}()
+		if err != nil {
+			return fail(err)
+		}
+		*queue = url
+	}
+
+	var backend queueload.Backend
+	switch *backendName {
+	case "sqs":
+		backend, err = queueload.NewSQS(cfg, *queue)
+	case "servicebus":
+		backend, err = queueload.NewServiceBus(cfg, *queue)
+	case "pubsub":
+		if *topic == "" || *subscription == "" {
+			return fail(errors.New("-backend pubsub needs -topic and -subscription"))
+		}
+		backend, err = queueload.NewPubSub(ctx, cfg, *project, *topic, *subscription)
+	default:
+		err = fmt.Errorf("unknown backend %q, want sqs, servicebus or pubsub", *backendName)
+	}
+	if err == nil && *backendName != "pubsub" && *queue == "" {
+		err = fmt.Errorf("-backend %s needs -queue or -create", *backendName)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	defer backend.Close()
+
+	report := queueload.Run(ctx, backend, opts)
+	if *asJSON {
+		err = queueload.WriteJSON(os.Stdout, report)
+	} else {
+		err = queueload.WriteTable(os.Stdout, report)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	if report.SendErrors+report.ReceiveErrors+report.Lost > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d send errors, %d receive errors, %d messages lost\n", report.SendErrors, report.ReceiveErrors, report.Lost)
+		return 1
+	}
+	return 0
+}
+
+// fail prints err and returns the exit code for a run that could not go
+// ahead
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "queueloader: %v\n", err)
+	return 2
+}
+
+// terraform applies and destroys the -create queue module
+type terraform struct {
+	binary string
+	dir    string
+}
+
+func (tf terraform) vars(cfg *config.TestConfig, name string) []string {
+	return []string{
+		"-var=queue_name=" + name,
+		"-var=emulator_endpoint=" + cfg.CloudEmuEndpoint,
+		"-var=aws_region=" + cfg.Region,
+	}
+}
+
+// apply creates the queue and returns its URL
+func (tf terraform) apply(ctx context.Context, cfg *config.TestConfig, name string) (string, error) {
+	if err := tf.run(ctx, "init", "-input=false"); err != nil {
+		return "", err
+	}
+	args := append([]string{"apply", "-auto-approve", "-input=false"}, tf.vars(cfg, name)...)
+	if err := tf.run(ctx, args...); err != nil {
+		return "", err
+	}
+
+	out, err := exec.CommandContext(ctx, tf.binary, "-chdir="+tf.dir, "output", "-json", "queue_url").Output()
+	if err != nil {
+		return "", fmt.Errorf("terraform output in %s: %w", tf.dir, err)
+	}
+	var url string
+	if err := json.Unmarshal(out, &url); err != nil {
+		return "", fmt.Errorf("parsing queue_url from %s: %w", tf.dir, err)
+	}
+	return url, nil
+}
+
+// destroy removes the queue. It runs on its own context, so an interrupted
+// run still cleans up.
+func (tf terraform) destroy(cfg *config.TestConfig, name string) error {
+	args := append([]string{"destroy", "-auto-approve", "-input=false"}, tf.vars(cfg, name)...)
+	return tf.run(context.Background(), args...)
+}
+
+// run runs terraform args in the module, its output going to stderr so
+// that -json output stays parseable
+func (tf terraform) run(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, tf.binary, append([]string{"-chdir=" + tf.dir}, args...)...)
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1")
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("terraform %s in %s: %w", args[0], tf.dir, err)
+	}
+	return nil
+}
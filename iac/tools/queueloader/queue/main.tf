@@ -0,0 +1,46 @@
+# queueloader queue
+#
+# The SQS queue queueloader -create loads: one queue from the messaging
+# facade on CloudEmu, destroyed again when the run ends.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "emulator_endpoint" {
+  description = "CloudEmu AWS endpoint URL, which every AWS service is sent to"
+  type        = string
+  default     = "http://localhost:4566"
+}
+
+variable "aws_region" {
+  description = "AWS region configured on the provider"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "queue_name" {
+  description = "Name of the queue to load"
+  type        = string
+}
+
+module "queue" {
+  source = "../../../facade/messaging"
+
+  provider_name = "aws"
+  name          = var.queue_name
+  type          = "queue"
+  project_name  = "queueloader"
+  environment   = "local"
+}
+
+output "queue_url" {
+  value = module.queue.queue_url
+}
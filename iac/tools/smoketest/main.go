@@ -0,0 +1,86 @@
+// Command smoketest checks that every emulator service the integration
+// tests depend on answers, and prints a table of OK or FAIL with each
+// check's latency. Run it from the iac module before a long apply:
+//
+//	go run ./tools/smoketest
+//	go run ./tools/smoketest -services s3,dynamodb -endpoint http://localhost:4566
+//	go run ./tools/smoketest -endpoint azure=http://localhost:10000 -timeout 10s
+//
+// Endpoints come from the shared test config (SWE_TEST_CONFIG and the
+// SWE_*_ENDPOINT variables) unless -endpoint overrides them. smoketest
+// exits 1 when a selected service fails and 2 when it cannot run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"iac/testutil/config"
+	"iac/testutil/smoke"
+)
+
+func main() {
+	cfg, err := config.LoadTestConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "smoketest: %v\n", err)
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("smoketest", flag.ExitOnError)
+	services := flags.String("services", strings.Join(smoke.Names(), ","), "comma-separated services to check")
+	timeout := flags.Duration("timeout", smoke.DefaultTimeout, "time each service has to answer")
+	flags.Func("endpoint", "emulator endpoint as provider=url (aws, azure, gcp or zero); a bare url is the CloudEmu (aws) endpoint. Repeatable", func(value string) error {
+		return setEndpoint(cfg, value)
+	})
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: smoketest [-services s3,sqs,...] [-endpoint [provider=]url] [-timeout d]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "smoketest: %v\n", err)
+		os.Exit(2)
+	}
+	selected, err := smoke.Lookup(strings.Split(*services, ",")...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	results := smoke.Run(context.Background(), cfg, selected, *timeout)
+	if err := smoke.WriteTable(os.Stdout, results); err != nil {
+		fmt.Fprintf(os.Stderr, "smoketest: %v\n", err)
+		os.Exit(2)
+	}
+
+	if failed := smoke.Failed(results); len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d of %d services failed\n", len(failed), len(results))
+		os.Exit(1)
+	}
+}
+
+// setEndpoint applies one -endpoint flag to cfg
+func setEndpoint(cfg *config.TestConfig, value string) error {
+	provider, url, found := strings.Cut(value, "=")
+	if !found {
+		provider, url = "aws", value
+	}
+
+	switch provider {
+	case "aws":
+		cfg.CloudEmuEndpoint = url
+	case "azure":
+		cfg.AzureEndpoint = url
+	case "gcp":
+		cfg.GCPEndpoint = url
+	case "zero":
+		cfg.ZeroEndpoint = url
+	default:
+		return fmt.Errorf("unknown provider %q, want aws, azure, gcp or zero", provider)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Command testreport reads `go test -json` output and writes a JUnit XML
+// report and a Markdown facade × provider matrix:
+//
+//	go test -json ./... | tee test.json | go run ./tools/testreport -junit report.xml -markdown summary.md
+//
+// It exits with status 1 when any test or package failed, so a pipeline
+// without pipefail still fails, and 2 when it cannot read or write.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"iac/testutil/testreport"
+)
+
+func main() {
+	junit := flag.String("junit", "", "write JUnit XML to this file")
+	markdown := flag.String("markdown", "", "write the Markdown matrix to this file, or - for stdout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: testreport [-junit file] [-markdown file] [go-test-json-file]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	failed, err := run(*junit, *markdown, flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// run writes the reports of the stream in path, or stdin when path is
+// empty, and reports whether any test failed
+func run(junit, markdown, path string) (bool, error) {
+	in := io.Reader(os.Stdin)
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return false, fmt.Errorf("testreport: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	report, err := testreport.Parse(in)
+	if err != nil {
+		return false, err
+	}
+
+	if junit != "" {
+		if err := writeFile(junit, report, testreport.WriteJUnit); err != nil {
+			return false, err
+		}
+	}
+	if markdown == "" && junit == "" {
+		markdown = "-"
+	}
+	if markdown != "" {
+		if err := writeFile(markdown, report, testreport.WriteMarkdown); err != nil {
+			return false, err
+		}
+	}
+	return report.Failed(), nil
+}
+
+func writeFile(path string, report *testreport.Report, write func(io.Writer, *testreport.Report) error) error {
+	if path == "-" {
+		return write(os.Stdout, report)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("testreport: %w", err)
+	}
+	if err := write(f, report); err != nil {
+		f.Close()
+		return fmt.Errorf("testreport: writing %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("testreport: writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"iac/testutil/integration"
+)
+
+// Layer is a set of tests run together: the build tags that select them,
+// the packages they live in and how long they may take
+type Layer struct {
+	Name     string
+	Tags     []string
+	Packages []string
+	Timeout  time.Duration
+
+	// RequireIntegration fails tests whose emulator is down instead of
+	// skipping them (see testutil/integration)
+	RequireIntegration bool
+}
+
+// Layers are the layers testrunner knows, from fastest to slowest
+var Layers = []Layer{
+	// Plans and static checks: Terraform, but no emulator. Untagged, so
+	// the integration tests are not even compiled.
+	{
+		Name:     "unit",
+		Packages: []string{"./..."},
+		Timeout:  30 * time.Minute,
+	},
+	// Deploys to the emulators; each must be running. The facade
+	// packages hold the upgrade and cross-provider equivalence tests.
+	{
+		Name:               "integration",
+		Tags:               []string{"integration"},
+		Packages:           []string{"./aws/test/...", "./azure/test/...", "./gcp/test/...", "./zero/test/...", "./facade/..."},
+		Timeout:            60 * time.Minute,
+		RequireIntegration: true,
+	},
+	// Both, as one go test run
+	{
+		Name:               "all",
+		Tags:               []string{"integration"},
+		Packages:           []string{"./..."},
+		Timeout:            90 * time.Minute,
+		RequireIntegration: true,
+	},
+}
+
+// LayerByName returns the layer called name
+func LayerByName(name string) (Layer, error) {
+	var names []string
+	for _, layer := range Layers {
+		if layer.Name == name {
+			return layer, nil
+		}
+		names = append(names, layer.Name)
+	}
+	return Layer{}, fmt.Errorf("testrunner: unknown layer %q, want one of %s", name, strings.Join(names, ", "))
+}
+
+// GoTestArgs returns the go command arguments that run layer. A non-zero
+// timeout overrides the layer's, packages override its packages, and extra
+// go test flags follow the layer's, so a repeated flag overrides it.
+func GoTestArgs(layer Layer, timeout time.Duration, packages, extra []string) []string {
+	if timeout == 0 {
+		timeout = layer.Timeout
+	}
+	if len(packages) == 0 {
+		packages = layer.Packages
+	}
+
+	args := []string{"test"}
+	if len(layer.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(layer.Tags, ","))
+	}
+	args = append(args, "-timeout", timeout.String())
+	args = append(args, extra...)
+	return append(args, packages...)
+}
+
+// Environ returns environ with SWE_REQUIRE_INTEGRATION set for a layer that
+// requires its emulators. A value already in environ wins, so a developer
+// can still run the integration layer with SWE_REQUIRE_INTEGRATION=false.
+func Environ(layer Layer, environ []string) []string {
+	if !layer.RequireIntegration {
+		return environ
+	}
+	for _, kv := range environ {
+		if strings.HasPrefix(kv, integration.EnvRequireIntegration+"=") {
+			return environ
+		}
+	}
+	return append(environ[:len(environ):len(environ)], integration.EnvRequireIntegration+"=true")
+}
+
+// splitArgs splits the command line at the first "--" into testrunner's
+// arguments and go test flags. The flag package would drop the "--" and
+// leave the go test flags looking like packages.
+func splitArgs(args []string) (own, extra []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoTestArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		layer    string
+		timeout  time.Duration
+		packages []string
+		extra    []string
+		want     []string
+	}{
+		"unit": {
+			layer: "unit",
+			want:  []string{"test", "-timeout", "30m0s", "./..."},
+		},
+		"integration": {
+			layer: "integration",
+			want: []string{"test", "-tags", "integration", "-timeout", "1h0m0s",
+				"./aws/test/...", "./azure/test/...", "./gcp/test/...", "./zero/test/...", "./facade/..."},
+		},
+		"all": {
+			layer: "all",
+			want:  []string{"test", "-tags", "integration", "-timeout", "1h30m0s", "./..."},
+		},
+		"timeout override": {
+			layer:   "integration",
+			timeout: 15 * time.Minute,
+			want: []string{"test", "-tags", "integration", "-timeout", "15m0s",
+				"./aws/test/...", "./azure/test/...", "./gcp/test/...", "./zero/test/...", "./facade/..."},
+		},
+		"packages and go test flags": {
+			layer:    "integration",
+			packages: []string{"./aws/test/..."},
+			extra:    []string{"-run", "Lambda", "-v"},
+			want:     []string{"test", "-tags", "integration", "-timeout", "1h0m0s", "-run", "Lambda", "-v", "./aws/test/..."},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			layer, err := LayerByName(tc.layer)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, GoTestArgs(layer, tc.timeout, tc.packages, tc.extra))
+		})
+	}
+}
+
+func TestLayers(t *testing.T) {
+	t.Parallel()
+
+	unit, err := LayerByName("unit")
+	require.NoError(t, err)
+	assert.Empty(t, unit.Tags, "The unit layer should not compile the integration tests")
+	assert.False(t, unit.RequireIntegration, "The unit layer needs no emulator")
+
+	for _, layer := range Layers {
+		assert.True(t, layer.Timeout > 10*time.Minute, "%s should outlast go test's default timeout", layer.Name)
+		assert.NotEmpty(t, layer.Packages, layer.Name)
+		if layer.Name != "unit" {
+			assert.Contains(t, layer.Tags, "integration", layer.Name)
+			assert.True(t, layer.RequireIntegration, "%s should fail when an emulator is down", layer.Name)
+		}
+	}
+}
+
+func TestLayerByNameUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := LayerByName("e2e")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown layer "e2e", want one of unit, integration, all`)
+}
+
+func TestEnviron(t *testing.T) {
+	t.Parallel()
+
+	unit, err := LayerByName("unit")
+	require.NoError(t, err)
+	integration, err := LayerByName("integration")
+	require.NoError(t, err)
+
+	environ := []string{"HOME=/home/ci"}
+	assert.Equal(t, environ, Environ(unit, environ))
+	assert.Equal(t, []string{"HOME=/home/ci", "SWE_REQUIRE_INTEGRATION=true"}, Environ(integration, environ))
+	assert.Equal(t, []string{"HOME=/home/ci"}, environ, "Environ should not modify its argument")
+
+	optOut := []string{"SWE_REQUIRE_INTEGRATION=false"}
+	assert.Equal(t, optOut, Environ(integration, optOut), "An explicit setting should win")
+}
+
+func TestSplitArgs(t *testing.T) {
+	t.Parallel()
+
+	own, extra := splitArgs([]string{"-layer", "integration", "./aws/test/...", "--", "-run", "Lambda", "--", "x"})
+	assert.Equal(t, []string{"-layer", "integration", "./aws/test/..."}, own)
+	assert.Equal(t, []string{"-run", "Lambda", "--", "x"}, extra)
+
+	own, extra = splitArgs([]string{"-layer", "unit"})
+	assert.Equal(t, []string{"-layer", "unit"}, own)
+	assert.Nil(t, extra)
+}
@@ -0,0 +1,71 @@
+// Command testrunner runs one layer of the test suite with the build tags,
+// packages and timeout it needs, so CI and developers select layers the
+// same way without a Makefile. Run it from the iac module:
+//
+//	go run ./tools/testrunner                                 # plans only, no emulator
+//	go run ./tools/testrunner -layer integration              # deploys to the emulators
+//	go run ./tools/testrunner -layer integration ./aws/test/... -- -run Lambda -v
+//
+// Packages replace the layer's, and flags after "--" go to go test. The
+// integration and all layers set SWE_REQUIRE_INTEGRATION, so a missing
+// emulator fails the run rather than skipping every test. testrunner exits
+// with go test's status, and 2 when it cannot run it.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func main() {
+	own, extra := splitArgs(os.Args[1:])
+
+	flags := flag.NewFlagSet("testrunner", flag.ExitOnError)
+	layerName := flags.String("layer", "unit", "layer to run: "+layerNames())
+	timeout := flags.Duration("timeout", 0, "override the layer's go test timeout")
+	dryRun := flags.Bool("n", false, "print the go test command without running it")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: testrunner [-layer name] [-timeout d] [-n] [packages] [-- go test flags]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(own)
+
+	layer, err := LayerByName(*layerName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	args := GoTestArgs(layer, *timeout, flags.Args(), extra)
+	if *dryRun {
+		fmt.Println("go " + strings.Join(args, " "))
+		return
+	}
+
+	cmd := exec.Command("go", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = Environ(layer, os.Environ())
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Fprintf(os.Stderr, "testrunner: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+func layerNames() string {
+	names := make([]string, len(Layers))
+	for i, layer := range Layers {
+		names[i] = layer.Name
+	}
+	return strings.Join(names, ", ")
+}
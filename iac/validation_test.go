@@ -1,36 +1,38 @@
 package test
 
 import (
-	"os"
 	"path/filepath"
-	"strings"
 	"testing"
 
+	"iac/testutil/modules"
+	"iac/testutil/planerr"
+	"iac/testutil/tfopts"
+
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
 )
 
-// TestAllModulesValidate scans the repository for all Terraform modules 
+// TestAllModulesValidate scans the repository for all Terraform modules
 // and runs 'terraform validate' on each one.
 func TestAllModulesValidate(t *testing.T) {
 	t.Parallel()
 
 	// Find all directories containing .tf files
-	modules, err := findAllTerraformModules(".")
+	dirs, err := modules.Discover(".")
 	assert.NoError(t, err)
 
-	for _, module := range modules {
+	for _, module := range dirs {
 		// Capture module path for the closure
 		modulePath := module
-		
+
 		t.Run(modulePath, func(t *testing.T) {
 			t.Parallel()
 
-			opts := &terraform.Options{
+			opts := tfopts.New(t, &terraform.Options{
 				TerraformDir: modulePath,
 				// Use -backend=false to skip remote state initialization
 				BackendConfig: map[string]interface{}{},
-			}
+			})
 
 			// Run init and validate
 			_, err := terraform.InitAndValidateE(t, opts)
@@ -39,37 +41,55 @@ func TestAllModulesValidate(t *testing.T) {
 	}
 }
 
-// findAllTerraformModules recursively searches for directories containing .tf files
-func findAllTerraformModules(root string) ([]string, error) {
-	var modules []string
-	
-	err := filepath.Walk(root, func(path string) (os.FileInfo, error) {
-		// Skip .terraform directories and hidden files
-		if info, err := os.Stat(path); err == nil && info.IsDir() {
-			if strings.Contains(path, ".terraform") || strings.Contains(path, ".git") {
-				return filepath.SkipDir, nil
+// facadeValidationCases are the rules of facades without a test package of
+// their own; facade packages keep their matrices next to their other tests
+var facadeValidationCases = map[string][]planerr.Case{
+	"kubernetes": {
+		{
+			Name: "InstanceSizeXlarge",
+			Vars: map[string]interface{}{"instance_size": "xlarge"}, // No xlarge node group mapping
+			Want: "Instance size must be one of: small, medium, large.",
+		},
+	},
+}
+
+// TestFacadeValidationMatrix checks the provider_name and environment rules
+// every facade shares, plus facadeValidationCases, and that each plan fails
+// on the rule under test rather than on something unrelated
+func TestFacadeValidationMatrix(t *testing.T) {
+	t.Parallel()
+
+	for facade, vars := range facadePlanVars {
+		facade, vars := facade, vars
+
+		t.Run(facade, func(t *testing.T) {
+			t.Parallel()
+
+			base := map[string]interface{}{
+				"provider_name": "aws",
+				"project_name":  "validation",
+				"environment":   "dev",
 			}
-		}
-
-		// If we find a .tf file, the current directory is a module
-		if filepath.Ext(path) == ".tf" {
-			dir := filepath.Dir(path)
-			// Avoid duplicates
-			if !contains(modules, dir) {
-				modules = append(modules, dir)
+			for k, v := range vars {
+				base[k] = v
 			}
-		}
-		return nil, nil
-	})
 
-	return modules, err
-}
+			cases := append([]planerr.Case{
+				{
+					Name:     "UnknownProvider",
+					Vars:     map[string]interface{}{"provider_name": "digitalocean"},
+					Want:     "provider_name must be one of: aws, azure, gcp",
+					Variable: "provider_name",
+				},
+				{
+					Name:     "UnknownEnvironment",
+					Vars:     map[string]interface{}{"environment": "test"},
+					Want:     "Environment must be one of: local, dev, staging, prod",
+					Variable: "environment",
+				},
+			}, facadeValidationCases[facade]...)
 
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
+			planerr.RunMatrix(t, filepath.Join("facade", facade), base, cases)
+		})
 	}
-	return false
 }
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"testing"
+
+	"iac/testutil/modules"
+	"iac/testutil/varcheck"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mustValidateVariables are the variables that need a validation block
+// wherever they are declared; a typo in any of them plans silently
+var mustValidateVariables = varcheck.DefaultMustValidate
+
+// TestVariableDocumentation checks that every variable of every module has
+// a description and a type, and that the variables in mustValidateVariables
+// reject unsupported values.
+func TestVariableDocumentation(t *testing.T) {
+	t.Parallel()
+
+	dirs, err := modules.Discover(".")
+	require.NoError(t, err)
+
+	vars, err := varcheck.InspectAll(dirs)
+	require.NoError(t, err)
+	require.NotEmpty(t, vars)
+
+	if problems := varcheck.Check(vars, mustValidateVariables); len(problems) > 0 {
+		t.Errorf("%d variable problems (missing description, type or validation):\n%s", len(problems), varcheck.Report(problems))
+	}
+}
+
+// TestValidationMessages checks that every validation error_message names
+// its variable and shows a valid value, so a failed plan says what to set
+// and to what. Rules whose condition also reads other inputs are exempt.
+func TestValidationMessages(t *testing.T) {
+	t.Parallel()
+
+	dirs, err := modules.Discover(".")
+	require.NoError(t, err)
+
+	vars, err := varcheck.InspectAll(dirs)
+	require.NoError(t, err)
+
+	if problems := varcheck.CheckMessages(vars); len(problems) > 0 {
+		t.Errorf("%d validation messages without the variable name or a valid value:\n%s", len(problems), varcheck.Report(problems))
+	}
+}
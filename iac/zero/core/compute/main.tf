@@ -7,11 +7,6 @@ terraform {
   }
 }
 
-variable "instance_name" { type = string }
-variable "instance_type" { type = string }
-variable "ami" { type = string }
-variable "tags" { type = map(string) }
-
 # Reuse AWS Provider for ZeroCompute (redirected via SPI)
 resource "aws_instance" "this" {
   ami           = var.ami
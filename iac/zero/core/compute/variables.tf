@@ -1,3 +1,8 @@
+variable "instance_name" {
+  description = "Instance name (Name tag)"
+  type        = string
+}
+
 variable "ami" {
   description = "AMI ID for the EC2 instance"
   type        = string
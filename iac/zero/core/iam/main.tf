@@ -7,13 +7,37 @@ terraform {
   }
 }
 
-variable "create_role" { type = bool }
-variable "role_name" { type = string }
-variable "create_user" { type = bool }
-variable "user_name" { type = string }
-variable "trusted_services" { type = list(string) }
-variable "managed_policy_arns" { type = list(string) }
-variable "tags" { type = map(string) }
+# Trust Policy Statements (same shape as the AWS core module)
+locals {
+  service_trust_statements = length(var.trusted_services) > 0 ? [
+    {
+      Action = "sts:AssumeRole"
+      Effect = "Allow"
+      Principal = {
+        Service = var.trusted_services
+      }
+    }
+  ] : []
+
+  account_trust_statements = length(var.trusted_principal_arns) > 0 ? [
+    merge(
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          AWS = var.trusted_principal_arns
+        }
+      },
+      var.external_id != null ? {
+        Condition = {
+          StringEquals = {
+            "sts:ExternalId" = var.external_id
+          }
+        }
+      } : {}
+    )
+  ] : []
+}
 
 # Reuse AWS Provider for ZeroID (redirected via SPI)
 resource "aws_iam_role" "this" {
@@ -21,16 +45,8 @@ resource "aws_iam_role" "this" {
   name  = var.role_name
   
   assume_role_policy = jsonencode({
-    Version = "2012-10-17"
-    Statement = [
-      {
-        Action = "sts:AssumeRole"
-        Effect = "Allow"
-        Principal = {
-          Service = var.trusted_services
-        }
-      }
-    ]
+    Version   = "2012-10-17"
+    Statement = concat(local.service_trust_statements, local.account_trust_statements)
   })
 
   tags = var.tags
@@ -7,13 +7,6 @@ terraform {
   }
 }
 
-variable "function_name" { type = string }
-variable "handler" { type = string }
-variable "runtime" { type = string }
-variable "filename" { type = string }
-variable "environment_variables" { type = map(string) }
-variable "tags" { type = map(string) }
-
 # Reuse AWS Provider for ZeroFunc (redirected via SPI)
 resource "aws_lambda_function" "this" {
   filename      = var.filename
@@ -21,7 +14,11 @@ resource "aws_lambda_function" "this" {
   role          = "arn:aws:iam::000000000000:role/lambda-role" # ZeroCloud mock role
   handler       = var.handler
 
-  runtime = var.runtime
+  runtime     = var.runtime
+  memory_size = var.memory_size
+  timeout     = var.timeout
+
+  source_code_hash = var.source_code_hash
 
   environment {
     variables = var.environment_variables
@@ -29,3 +26,15 @@ resource "aws_lambda_function" "this" {
   
   tags = var.tags
 }
+
+output "function_arn" {
+  value = aws_lambda_function.this.arn
+}
+
+output "function_name" {
+  value = aws_lambda_function.this.function_name
+}
+
+output "invoke_arn" {
+  value = aws_lambda_function.this.invoke_arn
+}
@@ -7,17 +7,33 @@ terraform {
   }
 }
 
-variable "queue_name" { type = string }
-variable "topic_name" { type = string }
-variable "create_queue" { type = bool }
-variable "create_topic" { type = bool }
-variable "tags" { type = map(string) }
-
 # Reuse AWS Provider for ZeroQueue (redirected via SPI)
 resource "aws_sqs_queue" "this" {
   count = var.create_queue ? 1 : 0
   name  = var.queue_name
-  tags  = var.tags
+
+  fifo_queue                 = var.fifo_queue
+  visibility_timeout_seconds = var.visibility_timeout_seconds
+  message_retention_seconds  = var.message_retention_seconds
+  max_message_size           = var.max_message_size
+  delay_seconds              = var.delay_seconds
+
+  # Redrive to the managed DLQ, or to an existing one
+  redrive_policy = var.create_dlq || var.dead_letter_queue_arn != null ? jsonencode({
+    deadLetterTargetArn = var.create_dlq ? aws_sqs_queue.dlq[0].arn : var.dead_letter_queue_arn
+    maxReceiveCount     = var.max_receive_count
+  }) : null
+
+  tags = var.tags
+}
+
+resource "aws_sqs_queue" "dlq" {
+  count = var.create_queue && var.create_dlq ? 1 : 0
+  name  = "${var.queue_name}-dlq"
+
+  message_retention_seconds = var.dlq_message_retention_seconds
+
+  tags = var.tags
 }
 
 resource "aws_sns_topic" "this" {
@@ -25,3 +41,15 @@ resource "aws_sns_topic" "this" {
   name  = var.topic_name
   tags  = var.tags
 }
+
+output "queue_id" {
+  value = length(aws_sqs_queue.this) > 0 ? aws_sqs_queue.this[0].id : null
+}
+
+output "queue_arn" {
+  value = length(aws_sqs_queue.this) > 0 ? aws_sqs_queue.this[0].arn : null
+}
+
+output "topic_arn" {
+  value = length(aws_sns_topic.this) > 0 ? aws_sns_topic.this[0].arn : null
+}
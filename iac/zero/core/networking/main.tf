@@ -7,15 +7,6 @@ terraform {
   }
 }
 
-variable "vpc_name" { type = string }
-variable "vpc_cidr" { type = string }
-variable "availability_zones" { type = list(string) }
-variable "public_subnet_cidrs" { type = list(string) }
-variable "private_subnet_cidrs" { type = list(string) }
-variable "create_internet_gateway" { type = bool }
-variable "create_default_security_group" { type = bool }
-variable "tags" { type = map(string) }
-
 # Reuse AWS Provider for ZeroNet (redirected via SPI)
 resource "aws_vpc" "this" {
   cidr_block = var.vpc_cidr
@@ -29,6 +29,14 @@ resource "aws_dynamodb_table" "this" {
     }
   }
 
+  dynamic "ttl" {
+    for_each = var.ttl_attribute != null ? [1] : []
+    content {
+      attribute_name = var.ttl_attribute
+      enabled        = true
+    }
+  }
+
   tags = var.tags
 }
 
@@ -39,3 +47,7 @@ output "table_id" {
 output "table_arn" {
   value = aws_dynamodb_table.this.arn
 }
+
+output "table_url" {
+  value = "http://localhost:8080/v1/db/tables/${var.table_name}"
+}
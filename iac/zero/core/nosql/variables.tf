@@ -1,29 +1,41 @@
 # Zero NoSQL Variables
 
 variable "table_name" {
-  type = string
+  description = "Table name"
+  type        = string
 }
 
 variable "hash_key" {
-  type = string
+  description = "Partition key attribute name"
+  type        = string
 }
 
 variable "hash_key_type" {
-  type    = string
-  default = "S"
+  description = "Partition key type (S, N, or B)"
+  type        = string
+  default     = "S"
 }
 
 variable "range_key" {
-  type    = string
-  default = null
+  description = "Sort key attribute name"
+  type        = string
+  default     = null
 }
 
 variable "range_key_type" {
-  type    = string
-  default = "S"
+  description = "Sort key type (S, N, or B)"
+  type        = string
+  default     = "S"
+}
+
+variable "ttl_attribute" {
+  description = "Attribute holding each item's expiry as epoch seconds; null leaves TTL off"
+  type        = string
+  default     = null
 }
 
 variable "tags" {
-  type    = map(string)
-  default = {}
+  description = "Resource tags"
+  type        = map(string)
+  default     = {}
 }
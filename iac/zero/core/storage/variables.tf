@@ -1,20 +1,24 @@
 # Zero Storage Variables
 
 variable "bucket_name" {
+  description = "Bucket name"
   type        = string
 }
 
 variable "versioning_enabled" {
+  description = "Enable object versioning"
   type        = bool
   default     = false
 }
 
 variable "force_destroy" {
+  description = "Delete all objects when destroying the bucket"
   type        = bool
   default     = true
 }
 
 variable "tags" {
+  description = "Resource tags"
   type        = map(string)
   default     = {}
 }
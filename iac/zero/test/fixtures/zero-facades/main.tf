@@ -0,0 +1,180 @@
+# ZeroCloud facade fixture
+#
+# Provisions one resource per facade with provider_name = "zero". The AWS
+# provider is pointed at ZeroCloud, which speaks the AWS wire protocols.
+
+terraform {
+  required_version = ">= 1.2"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+
+  endpoints {
+    ec2      = var.zero_endpoint
+    s3       = var.zero_endpoint
+    dynamodb = var.zero_endpoint
+    lambda   = var.zero_endpoint
+    sqs      = var.zero_endpoint
+    iam      = var.zero_endpoint
+    sts      = var.zero_endpoint
+  }
+
+  skip_credentials_validation = true
+  skip_metadata_api_check     = true
+  skip_requesting_account_id  = true
+  s3_use_path_style           = true
+
+  access_key = "test"
+  secret_key = "test"
+}
+
+variable "zero_endpoint" {
+  description = "ZeroCloud endpoint URL"
+  type        = string
+  default     = "http://localhost:8080"
+}
+
+variable "name_prefix" {
+  description = "Unique prefix for every resource under test"
+  type        = string
+}
+
+variable "vpc_cidr" {
+  description = "CIDR block of the VPC, allocated per test run"
+  type        = string
+  default     = "10.0.0.0/16"
+}
+
+variable "public_subnets" {
+  description = "Public subnet CIDRs within vpc_cidr"
+  type        = list(string)
+  default     = ["10.0.1.0/24", "10.0.2.0/24"]
+}
+
+variable "private_subnets" {
+  description = "Private subnet CIDRs within vpc_cidr"
+  type        = list(string)
+  default     = ["10.0.3.0/24", "10.0.4.0/24"]
+}
+
+locals {
+  project_name = "zero-test-project"
+  environment  = "dev"
+}
+
+module "storage" {
+  source = "../../../../facade/storage"
+
+  provider_name = "zero"
+  project_name  = local.project_name
+  environment   = local.environment
+  bucket_name   = "${var.name_prefix}-bucket"
+}
+
+module "nosql" {
+  source = "../../../../facade/nosql"
+
+  provider_name = "zero"
+  project_name  = local.project_name
+  environment   = local.environment
+  table_name    = "${var.name_prefix}-table"
+  hash_key      = "id"
+}
+
+module "networking" {
+  source = "../../../../facade/networking"
+
+  provider_name = "zero"
+  project_name  = local.project_name
+  environment   = local.environment
+  network_name  = "${var.name_prefix}-vpc"
+
+  network_config = {
+    cidr            = var.vpc_cidr
+    azs             = ["us-east-1a", "us-east-1b"]
+    public_subnets  = var.public_subnets
+    private_subnets = var.private_subnets
+  }
+}
+
+module "iam" {
+  source = "../../../../facade/iam"
+
+  provider_name = "zero"
+  project_name  = local.project_name
+  environment   = local.environment
+  identity_type = "role"
+  identity_name = "${var.name_prefix}-role"
+  principals    = ["lambda.amazonaws.com"]
+  roles         = ["storage_read", "nosql_write"]
+}
+
+module "lambda" {
+  source = "../../../../facade/lambda"
+
+  provider_name = "zero"
+  project_name  = local.project_name
+  environment   = local.environment
+  function_name = "${var.name_prefix}-func"
+  runtime       = "python3.11"
+  handler       = "index.handler"
+
+  source_code = <<-EOT
+    def handler(event, context):
+        return {"statusCode": 200, "body": "hello from zerofunc"}
+  EOT
+}
+
+module "queue" {
+  source = "../../../../facade/messaging"
+
+  provider_name = "zero"
+  project_name  = local.project_name
+  environment   = local.environment
+  name          = "${var.name_prefix}-queue"
+  type          = "queue"
+}
+
+output "bucket_id" {
+  value = module.storage.bucket_id
+}
+
+output "bucket_url" {
+  value = module.storage.bucket_url
+}
+
+output "table_name" {
+  value = module.nosql.table_id
+}
+
+output "vpc_id" {
+  value = module.networking.network_id
+}
+
+output "role_arn" {
+  value = module.iam.principal_id
+}
+
+output "function_arn" {
+  value = module.lambda.function_arn
+}
+
+output "function_name" {
+  value = module.lambda.function_name
+}
+
+output "queue_url" {
+  value = module.queue.queue_url
+}
+
+output "queue_id" {
+  value = module.queue.queue_id
+}
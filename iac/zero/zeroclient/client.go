@@ -0,0 +1,102 @@
+// Package zeroclient is a typed client for the ZeroCloud REST API.
+//
+// It covers the /v1/store, /v1/db, /v1/queue and /v1/func surfaces that the
+// Zero facades provision against, so integration tests can verify resources
+// through the API instead of trusting Terraform outputs.
+package zeroclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the address ZeroCloud listens on when started locally
+const DefaultEndpoint = "http://localhost:8080"
+
+// Client talks to a single ZeroCloud instance
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New returns a client for the ZeroCloud instance at baseURL
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewFromEnv returns a client for $ZERO_URL, falling back to DefaultEndpoint
+func NewFromEnv() *Client {
+	if url := os.Getenv("ZERO_URL"); url != "" {
+		return New(url)
+	}
+	return New(DefaultEndpoint)
+}
+
+// WithHTTPClient replaces the underlying HTTP client (timeouts, transports)
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c.httpClient = httpClient
+	return c
+}
+
+// doJSON sends an optional JSON body and decodes a JSON response into out
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("zeroclient: encoding %s %s request: %w", method, path, err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	data, err := c.do(ctx, method, path, "application/json", body)
+	if err != nil {
+		return err
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("zeroclient: decoding %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// do sends a request and returns the raw response body, mapping non-2xx
+// statuses to NotFoundError, ServerError or APIError
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("zeroclient: building %s %s: %w", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("zeroclient: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("zeroclient: reading %s %s response: %w", method, path, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newStatusError(method, path, resp.StatusCode, data)
+	}
+	return data, nil
+}
@@ -0,0 +1,203 @@
+package zeroclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"iac/zero/zeroclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeZero serves canned ZeroCloud responses keyed by "METHOD path"
+func newFakeZero(t *testing.T, routes map[string]http.HandlerFunc) *zeroclient.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := routes[r.Method+" "+r.URL.EscapedPath()]
+		if !ok {
+			http.Error(w, "route not found", http.StatusNotFound)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return zeroclient.New(server.URL + "/")
+}
+
+func writeJSON(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}
+}
+
+func TestListAndGetBucket(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeZero(t, map[string]http.HandlerFunc{
+		"GET /v1/store/buckets": writeJSON(`{"buckets": ["assets", "logs"]}`),
+	})
+	ctx := context.Background()
+
+	buckets, err := client.ListBuckets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"assets", "logs"}, buckets)
+
+	bucket, err := client.GetBucket(ctx, "logs")
+	require.NoError(t, err)
+	assert.Equal(t, "logs", bucket.Name)
+
+	_, err = client.GetBucket(ctx, "missing")
+	assert.True(t, zeroclient.IsNotFound(err), "Unknown bucket should be a NotFoundError, got %v", err)
+	assert.Contains(t, err.Error(), `bucket "missing"`)
+}
+
+func TestObjectRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var stored []byte
+	client := newFakeZero(t, map[string]http.HandlerFunc{
+		"PUT /v1/store/buckets/assets/objects/dir%2Fhello.txt": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+			stored, _ = io.ReadAll(r.Body)
+		},
+		"GET /v1/store/buckets/assets/objects/dir%2Fhello.txt": func(w http.ResponseWriter, r *http.Request) {
+			w.Write(stored)
+		},
+	})
+	ctx := context.Background()
+
+	require.NoError(t, client.PutObject(ctx, "assets", "dir/hello.txt", []byte("hello zero")))
+
+	data, err := client.GetObject(ctx, "assets", "dir/hello.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello zero", string(data))
+
+	_, err = client.GetObject(ctx, "assets", "absent.txt")
+	assert.True(t, zeroclient.IsNotFound(err))
+	assert.Contains(t, err.Error(), `object "assets/absent.txt"`)
+}
+
+func TestGetTable(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeZero(t, map[string]http.HandlerFunc{
+		"GET /v1/db/tables": writeJSON(`{"tables": ["users"]}`),
+	})
+	ctx := context.Background()
+
+	table, err := client.GetTable(ctx, "users")
+	require.NoError(t, err)
+	assert.Equal(t, "users", table.Name)
+
+	_, err = client.GetTable(ctx, "orders")
+	assert.True(t, zeroclient.IsNotFound(err))
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var queued string
+	client := newFakeZero(t, map[string]http.HandlerFunc{
+		"GET /v1/queue/queues": writeJSON(`{"QueueUrls": ["http://localhost:8080/v1/queue/queues/jobs"]}`),
+		"POST /v1/queue/queues/jobs/messages": func(w http.ResponseWriter, r *http.Request) {
+			var in map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+			queued = in["body"]
+			writeJSON(`{"MessageId": "m-1"}`)(w, r)
+		},
+		"GET /v1/queue/queues/jobs/messages": func(w http.ResponseWriter, r *http.Request) {
+			if queued == "" {
+				writeJSON(`{"Messages": null}`)(w, r)
+				return
+			}
+			body, _ := json.Marshal(queued)
+			writeJSON(`{"Messages": {"MessageId": "m-1", "Body": `+string(body)+`, "ReceiptHandle": "rh-1"}}`)(w, r)
+		},
+		"DELETE /v1/queue/queues/jobs/messages/rh-1": func(w http.ResponseWriter, r *http.Request) {
+			queued = ""
+			writeJSON(`{"status": "Deleted"}`)(w, r)
+		},
+	})
+	ctx := context.Background()
+
+	queues, err := client.ListQueues(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://localhost:8080/v1/queue/queues/jobs"}, queues)
+
+	id, err := client.SendMessage(ctx, "jobs", "payload")
+	require.NoError(t, err)
+	assert.Equal(t, "m-1", id)
+
+	msg, err := client.ReceiveMessage(ctx, "jobs")
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, "payload", msg.Body)
+	assert.Equal(t, "rh-1", msg.ReceiptHandle)
+
+	require.NoError(t, client.DeleteMessage(ctx, "jobs", msg.ReceiptHandle))
+
+	msg, err = client.ReceiveMessage(ctx, "jobs")
+	require.NoError(t, err)
+	assert.Nil(t, msg, "Queue should be empty after the message is deleted")
+}
+
+func TestFunctions(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeZero(t, map[string]http.HandlerFunc{
+		"GET /v1/func/functions": writeJSON(`{"functions": ["hello"]}`),
+		"POST /v1/func/functions/hello/invocations": func(w http.ResponseWriter, r *http.Request) {
+			var in map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+			writeJSON(`{"statusCode": 200, "body": "hello `+in["name"]+`"}`)(w, r)
+		},
+	})
+	ctx := context.Background()
+
+	function, err := client.GetFunction(ctx, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", function.Name)
+
+	result, err := client.InvokeFunction(ctx, "hello", map[string]string{"name": "zero"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"statusCode": 200, "body": "hello zero"}`, string(result))
+
+	_, err = client.InvokeFunction(ctx, "absent", nil)
+	assert.True(t, zeroclient.IsNotFound(err))
+	assert.Contains(t, err.Error(), `function "absent"`)
+}
+
+func TestErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeZero(t, map[string]http.HandlerFunc{
+		"GET /v1/store/buckets": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Driver error: disk full", http.StatusInternalServerError)
+		},
+		"POST /v1/queue/queues/jobs/messages": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Missing body", http.StatusBadRequest)
+		},
+	})
+	ctx := context.Background()
+
+	_, err := client.ListBuckets(ctx)
+	var serverErr *zeroclient.ServerError
+	require.ErrorAs(t, err, &serverErr)
+	assert.Equal(t, http.StatusInternalServerError, serverErr.StatusCode)
+	assert.Contains(t, serverErr.Body, "disk full")
+	assert.True(t, zeroclient.IsServerError(err))
+	assert.False(t, zeroclient.IsNotFound(err))
+
+	_, err = client.SendMessage(ctx, "jobs", "")
+	var apiErr *zeroclient.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.False(t, zeroclient.IsServerError(err))
+	assert.False(t, zeroclient.IsNotFound(err))
+}
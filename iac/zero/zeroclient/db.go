@@ -0,0 +1,37 @@
+package zeroclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// Table is a ZeroDB table
+type Table struct {
+	Name string
+}
+
+// ListTables returns the names of all ZeroDB tables
+func (c *Client) ListTables(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Tables []string `json:"tables"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/v1/db/tables", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Tables, nil
+}
+
+// GetTable returns the named table, or a NotFoundError if it does not exist.
+// ZeroDB has no per-table route, so this searches the table listing.
+func (c *Client) GetTable(ctx context.Context, name string) (*Table, error) {
+	tables, err := c.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if table == name {
+			return &Table{Name: table}, nil
+		}
+	}
+	return nil, &NotFoundError{Kind: "table", Name: name}
+}
@@ -0,0 +1,78 @@
+package zeroclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// NotFoundError reports a resource (or route) that does not exist
+type NotFoundError struct {
+	Kind string // bucket, object, table, queue, function; empty for a 404 route
+	Name string
+	Body string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Kind == "" {
+		return fmt.Sprintf("zeroclient: not found: %s", e.Body)
+	}
+	return fmt.Sprintf("zeroclient: %s %q not found", e.Kind, e.Name)
+}
+
+// ServerError reports a 5xx response; ZeroCloud returns these for failures
+// inside its services, so they are usually worth retrying or logging
+type ServerError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("zeroclient: %s %s: server error %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// APIError reports any other non-2xx response (validation, conflicts)
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("zeroclient: %s %s: status %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether err is, or wraps, a NotFoundError
+func IsNotFound(err error) bool {
+	var target *NotFoundError
+	return errors.As(err, &target)
+}
+
+// IsServerError reports whether err is, or wraps, a ServerError
+func IsServerError(err error) bool {
+	var target *ServerError
+	return errors.As(err, &target)
+}
+
+func newStatusError(method, path string, status int, body []byte) error {
+	switch {
+	case status == http.StatusNotFound:
+		return &NotFoundError{Body: string(body)}
+	case status >= 500:
+		return &ServerError{Method: method, Path: path, StatusCode: status, Body: string(body)}
+	default:
+		return &APIError{Method: method, Path: path, StatusCode: status, Body: string(body)}
+	}
+}
+
+// notFound tags a 404 with the resource it was looking for
+func notFound(err error, kind, name string) error {
+	var target *NotFoundError
+	if errors.As(err, &target) {
+		return &NotFoundError{Kind: kind, Name: name, Body: target.Body}
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package zeroclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Function is a ZeroFunc function
+type Function struct {
+	Name string
+}
+
+// ListFunctions returns the names of all ZeroFunc functions
+func (c *Client) ListFunctions(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Functions []string `json:"functions"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/v1/func/functions", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Functions, nil
+}
+
+// GetFunction returns the named function, or a NotFoundError if it does not
+// exist. ZeroFunc has no per-function route, so this searches the listing.
+func (c *Client) GetFunction(ctx context.Context, name string) (*Function, error) {
+	functions, err := c.ListFunctions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, function := range functions {
+		if function == name {
+			return &Function{Name: function}, nil
+		}
+	}
+	return nil, &NotFoundError{Kind: "function", Name: name}
+}
+
+// InvokeFunction synchronously invokes a function and returns its raw JSON result
+func (c *Client) InvokeFunction(ctx context.Context, name string, payload interface{}) (json.RawMessage, error) {
+	if payload == nil {
+		payload = struct{}{}
+	}
+
+	var result json.RawMessage
+	path := "/v1/func/functions/" + url.PathEscape(name) + "/invocations"
+	if err := c.doJSON(ctx, http.MethodPost, path, payload, &result); err != nil {
+		return nil, notFound(err, "function", name)
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package zeroclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Message is a message received from a ZeroQueue queue
+type Message struct {
+	ID            string `json:"MessageId"`
+	Body          string `json:"Body"`
+	ReceiptHandle string `json:"ReceiptHandle"`
+}
+
+// ListQueues returns the URLs of all ZeroQueue queues
+func (c *Client) ListQueues(ctx context.Context) ([]string, error) {
+	var resp struct {
+		QueueURLs []string `json:"QueueUrls"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/v1/queue/queues", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.QueueURLs, nil
+}
+
+// SendMessage enqueues body and returns the message ID
+func (c *Client) SendMessage(ctx context.Context, queue, body string) (string, error) {
+	var resp struct {
+		MessageID string `json:"MessageId"`
+	}
+	in := map[string]string{"body": body}
+	if err := c.doJSON(ctx, http.MethodPost, messagesPath(queue), in, &resp); err != nil {
+		return "", notFound(err, "queue", queue)
+	}
+	return resp.MessageID, nil
+}
+
+// ReceiveMessage returns the next visible message, or nil when the queue is empty
+func (c *Client) ReceiveMessage(ctx context.Context, queue string) (*Message, error) {
+	var resp struct {
+		Messages *Message `json:"Messages"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, messagesPath(queue), nil, &resp); err != nil {
+		return nil, notFound(err, "queue", queue)
+	}
+	return resp.Messages, nil
+}
+
+// DeleteMessage acknowledges a received message
+func (c *Client) DeleteMessage(ctx context.Context, queue, receiptHandle string) error {
+	path := messagesPath(queue) + "/" + url.PathEscape(receiptHandle)
+	return notFound(c.doJSON(ctx, http.MethodDelete, path, nil, nil), "queue", queue)
+}
+
+func messagesPath(queue string) string {
+	return "/v1/queue/queues/" + url.PathEscape(queue) + "/messages"
+}
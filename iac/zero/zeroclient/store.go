@@ -0,0 +1,58 @@
+package zeroclient
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Bucket is a ZeroStore bucket
+type Bucket struct {
+	Name string
+}
+
+// ListBuckets returns the names of all ZeroStore buckets
+func (c *Client) ListBuckets(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Buckets []string `json:"buckets"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/v1/store/buckets", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Buckets, nil
+}
+
+// GetBucket returns the named bucket, or a NotFoundError if it does not exist.
+// ZeroStore has no per-bucket route, so this searches the bucket listing.
+func (c *Client) GetBucket(ctx context.Context, name string) (*Bucket, error) {
+	buckets, err := c.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		if bucket == name {
+			return &Bucket{Name: bucket}, nil
+		}
+	}
+	return nil, &NotFoundError{Kind: "bucket", Name: name}
+}
+
+// PutObject stores data under key in the bucket
+func (c *Client) PutObject(ctx context.Context, bucket, key string, data []byte) error {
+	_, err := c.do(ctx, http.MethodPut, objectPath(bucket, key), "application/octet-stream", bytes.NewReader(data))
+	return notFound(err, "bucket", bucket)
+}
+
+// GetObject returns the contents stored under key in the bucket
+func (c *Client) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	data, err := c.do(ctx, http.MethodGet, objectPath(bucket, key), "", nil)
+	if err != nil {
+		return nil, notFound(err, "object", bucket+"/"+key)
+	}
+	return data, nil
+}
+
+func objectPath(bucket, key string) string {
+	return "/v1/store/buckets/" + url.PathEscape(bucket) + "/objects/" + url.PathEscape(key)
+}